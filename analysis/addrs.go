@@ -0,0 +1,122 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package analysis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/asset-db/types"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+// The longest chain of aliases followed from a name to its addresses.
+const maxAliasChain = 10
+
+// NamesToAddrs returns a NameAddrPair for each name / address combination in the graph,
+// only following the relations that were valid within the time interval. A zero start
+// or end leaves that side of the interval open.
+func NamesToAddrs(ctx context.Context, g *netmap.Graph, start, end time.Time, names ...string) ([]*netmap.NameAddrPair, error) {
+	var pairs []*netmap.NameAddrPair
+
+	filter := make(map[string]struct{})
+	for _, name := range names {
+		select {
+		case <-ctx.Done():
+			return pairs, errors.New("context expired")
+		default:
+		}
+
+		if _, found := filter[name]; found {
+			continue
+		}
+		filter[name] = struct{}{}
+
+		assets, err := g.DB.FindByContent(&domain.FQDN{Name: name}, time.Time{})
+		if err != nil || len(assets) == 0 {
+			continue
+		}
+
+		for _, addr := range addrsInInterval(g, assets[0], start, end) {
+			pairs = append(pairs, &netmap.NameAddrPair{
+				FQDN: &domain.FQDN{Name: name},
+				Addr: addr,
+			})
+		}
+	}
+
+	if len(pairs) == 0 {
+		return nil, errors.New("no addresses were discovered")
+	}
+	return pairs, nil
+}
+
+func addrsInInterval(g *netmap.Graph, a *types.Asset, start, end time.Time) []*network.IPAddress {
+	cur := a
+	// Get to the end of the alias chains for service names and CNAMES
+	for i := 1; i <= maxAliasChain; i++ {
+		reltypes := []string{"cname_record"}
+		if i == 1 {
+			reltypes = append(reltypes, "srv_record")
+		}
+
+		next := nextInInterval(g, cur, start, end, reltypes...)
+		if next == nil {
+			break
+		}
+		cur = next
+	}
+
+	rels, err := g.DB.OutgoingRelations(cur, time.Time{}, addrRelations...)
+	if err != nil {
+		return nil
+	}
+
+	var addrs []*network.IPAddress
+	seen := make(map[string]struct{})
+	for _, rel := range rels {
+		found, err := g.DB.FindById(rel.ToAsset.ID, time.Time{})
+		if err != nil || !ValidInInterval(rel, cur, found, start, end) {
+			continue
+		}
+
+		if ip, ok := found.Asset.(network.IPAddress); ok {
+			if _, dup := seen[ip.Address.String()]; !dup {
+				seen[ip.Address.String()] = struct{}{}
+				addrs = append(addrs, &ip)
+			}
+		}
+	}
+	return addrs
+}
+
+func nextInInterval(g *netmap.Graph, a *types.Asset, start, end time.Time, reltypes ...string) *types.Asset {
+	rels, err := g.DB.OutgoingRelations(a, time.Time{}, reltypes...)
+	if err != nil {
+		return nil
+	}
+
+	for _, rel := range rels {
+		if found, err := g.DB.FindById(rel.ToAsset.ID, time.Time{}); err == nil && ValidInInterval(rel, a, found, start, end) {
+			return found
+		}
+	}
+	return nil
+}
+
+// ValidInInterval returns true if the relation was last seen after the start of the interval, and
+// both assets were discovered before the end, since relations do not record when they were created.
+func ValidInInterval(rel *types.Relation, from, to *types.Asset, start, end time.Time) bool {
+	if !start.IsZero() && rel.LastSeen.Before(start) {
+		return false
+	}
+	if !end.IsZero() && (from.CreatedAt.After(end) || to.CreatedAt.After(end)) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package analysis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+)
+
+func TestNamesToAddrs(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	_ = g.UpsertCNAME(ctx, "api.owasp.org", "owasp.cdn.example.net")
+	_ = g.UpsertA(ctx, "owasp.cdn.example.net", "198.51.100.1")
+	_ = g.UpsertAAAA(ctx, "owasp.cdn.example.net", "2001:db8::100")
+
+	now := time.Now()
+	pairs, err := NamesToAddrs(ctx, g, now.Add(-time.Hour), time.Time{}, "api.owasp.org")
+	if err != nil || len(pairs) != 2 {
+		t.Fatalf("Expected 2 pairs, got %d: %v", len(pairs), err)
+	}
+	for _, p := range pairs {
+		if p.FQDN.Name != "api.owasp.org" {
+			t.Errorf("Unexpected name in the pair: %s", p.FQDN.Name)
+		}
+	}
+
+	if _, err := NamesToAddrs(ctx, g, now.Add(time.Hour), time.Time{}, "api.owasp.org"); err == nil {
+		t.Error("Expected no pairs for resolutions last seen before the interval")
+	}
+	if _, err := NamesToAddrs(ctx, g, time.Time{}, now.Add(-time.Hour), "api.owasp.org"); err == nil {
+		t.Error("Expected no pairs for assets discovered after the interval")
+	}
+}
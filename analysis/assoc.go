@@ -0,0 +1,344 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package analysis
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/asset-db/types"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"golang.org/x/net/publicsuffix"
+)
+
+// The pivots associating the domains outside of the scope with a target domain.
+const (
+	RegistrantPivot = "registrant"
+	NameserverPivot = "nameserver"
+	MailPivot       = "mx"
+	CertOrgPivot    = "cert_org"
+	TrackingIDPivot = "tracking_id"
+)
+
+// AssociationPivots are the pivots in the order they are reported.
+var AssociationPivots = []string{RegistrantPivot, NameserverPivot, MailPivot, CertOrgPivot, TrackingIDPivot}
+
+// CertOrgDetail is the finding detail providing the organization named by the certificate served by a web endpoint.
+const CertOrgDetail = "cert_org"
+
+// The finding types used by the pivots.
+const (
+	// The root domains registered to the organization of a target domain
+	candidateDomainType = "candidate_domain"
+	// The tracking IDs embedded by the web pages of a host
+	trackingIDType = "tracking_id"
+	// The root domains sharing a tracking ID with a target host, according to the data sources indexing them
+	trackingIDDomainType = "tracking_id_domain"
+)
+
+// DomainAssociation is a root domain outside of the scope associated with a target domain by a pivot.
+// The confidence, ranging from 0 to 100, estimates how likely both domains belong to the same organization.
+type DomainAssociation struct {
+	Domain     string   `json:"domain"`
+	Target     string   `json:"target"`
+	Pivot      string   `json:"pivot"`
+	Confidence int      `json:"confidence"`
+	Evidence   []string `json:"evidence"`
+}
+
+// DomainAssociations returns the root domains associated with the target domain by the registrant
+// findings, the nameservers and mail exchanges stored in the graph, the organizations named by the
+// certificates of the web endpoints, and the analytics and advertising tracking IDs embedded by the web
+// pages. The associations are ordered by decreasing confidence.
+func DomainAssociations(ctx context.Context, g *netmap.Graph, all []*findings.Finding, target string) []*DomainAssociation {
+	target = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(target), "."))
+	c := &assocCollector{
+		target: target,
+		assocs: make(map[string]*DomainAssociation),
+	}
+
+	registrantPivots(c, all)
+	certOrgPivots(c, all)
+	trackingIDPivots(c, all)
+	if g != nil {
+		for _, pivot := range []string{NameserverPivot, MailPivot} {
+			select {
+			case <-ctx.Done():
+				return c.results()
+			default:
+			}
+			sharedHostPivots(c, g, pivot)
+		}
+	}
+	return c.results()
+}
+
+type assocCollector struct {
+	target string
+	assocs map[string]*DomainAssociation
+}
+
+// add records the evidence of the association, keeping the highest confidence provided for the pivot.
+func (c *assocCollector) add(name, pivot, evidence string, confidence int) {
+	d, err := publicsuffix.EffectiveTLDPlusOne(strings.ToLower(strings.TrimSuffix(name, ".")))
+	if err != nil || inScope(d, []string{c.target}) || inScope(c.target, []string{d}) {
+		return
+	}
+
+	key := d + "|" + pivot
+	a, found := c.assocs[key]
+	if !found {
+		a = &DomainAssociation{Domain: d, Target: c.target, Pivot: pivot}
+		c.assocs[key] = a
+	}
+	if confidence > a.Confidence {
+		a.Confidence = confidence
+	}
+	for _, e := range a.Evidence {
+		if e == evidence {
+			return
+		}
+	}
+	a.Evidence = append(a.Evidence, evidence)
+}
+
+func (c *assocCollector) results() []*DomainAssociation {
+	pos := make(map[string]int, len(AssociationPivots))
+	for i, p := range AssociationPivots {
+		pos[p] = i
+	}
+
+	var results []*DomainAssociation
+	for _, a := range c.assocs {
+		sort.Strings(a.Evidence)
+		results = append(results, a)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Confidence != results[j].Confidence {
+			return results[i].Confidence > results[j].Confidence
+		}
+		if results[i].Domain != results[j].Domain {
+			return results[i].Domain < results[j].Domain
+		}
+		return pos[results[i].Pivot] < pos[results[j].Pivot]
+	})
+	return results
+}
+
+// registrantPivots adds the candidate domains registered to the organization of the target domain.
+// The candidates rejected by a review are left out.
+func registrantPivots(c *assocCollector, all []*findings.Finding) {
+	for _, f := range all {
+		if f.Type != candidateDomainType || f.Details["review"] == "denied" {
+			continue
+		}
+		if related := f.Details["related"]; related == "" || !inScope(related, []string{c.target}) {
+			continue
+		}
+
+		c.add(f.Asset, RegistrantPivot, "registered to "+f.Details["organization"], 80)
+	}
+}
+
+// certOrgPivots adds the domains with web endpoints serving certificates issued to an organization
+// also named by the certificates of the target domain.
+func certOrgPivots(c *assocCollector, all []*findings.Finding) {
+	orgs := make(map[string]struct{})
+	for _, f := range all {
+		if org := strings.ToLower(strings.TrimSpace(f.Details[CertOrgDetail])); org != "" && inScope(findings.Host(f.Asset), []string{c.target}) {
+			orgs[org] = struct{}{}
+		}
+	}
+
+	for _, f := range all {
+		org := strings.TrimSpace(f.Details[CertOrgDetail])
+		if _, found := orgs[strings.ToLower(org)]; found && org != "" {
+			c.add(findings.Host(f.Asset), CertOrgPivot, "certificate issued to "+org, 70)
+		}
+	}
+}
+
+// trackingIDPivots adds the domains sharing a tracking ID with the hosts of the target domain, found
+// by the data sources indexing the IDs, or by the web pages of the other enumerations in the findings.
+func trackingIDPivots(c *assocCollector, all []*findings.Finding) {
+	// Maps each tracking ID to the domains found to share it
+	users := make(map[string]map[string]struct{})
+	addUser := func(id, name string) {
+		d, err := publicsuffix.EffectiveTLDPlusOne(strings.ToLower(name))
+		if err != nil || inScope(d, []string{c.target}) {
+			return
+		}
+		if _, found := users[id]; !found {
+			users[id] = make(map[string]struct{})
+		}
+		users[id][d] = struct{}{}
+	}
+
+	ids := make(map[string]struct{})
+	for _, f := range all {
+		switch f.Type {
+		case trackingIDType:
+			if inScope(findings.Host(f.Asset), []string{c.target}) {
+				for _, id := range strings.Split(f.Details["tracking_ids"], ",") {
+					if id = strings.TrimSpace(id); id != "" {
+						ids[id] = struct{}{}
+					}
+				}
+			}
+		case trackingIDDomainType:
+			if related := f.Details["related"]; f.Details["review"] != "denied" && related != "" && inScope(related, []string{c.target}) {
+				addUser(f.Details["tracking_id"], f.Asset)
+			}
+		}
+	}
+	for _, f := range all {
+		if f.Type != trackingIDType {
+			continue
+		}
+		for _, id := range strings.Split(f.Details["tracking_ids"], ",") {
+			if _, found := ids[strings.TrimSpace(id)]; found {
+				addUser(strings.TrimSpace(id), findings.Host(f.Asset))
+			}
+		}
+	}
+
+	for id, domains := range users {
+		for d := range domains {
+			c.add(d, TrackingIDPivot, "tracking ID "+id, trackingIDConfidence(id, len(domains)))
+		}
+	}
+}
+
+// trackingIDConfidence estimates the confidence of the association from the kind of the tracking ID and
+// the number of domains sharing it, since the agencies managing many sites can reuse their IDs.
+func trackingIDConfidence(id string, users int) int {
+	conf := 75
+	switch http.TrackingIDKind(id) {
+	case http.GoogleTagManager:
+		conf = 70
+	case http.FacebookPixel:
+		conf = 60
+	}
+
+	switch {
+	case users > 50:
+		return 10
+	case users > 10:
+		return conf / 2
+	}
+	return conf
+}
+
+// sharedHostPivots adds the domains using the nameservers or mail exchanges of the target domain.
+// The hosts operated by the target domain provide the strongest evidence, while the hosts of the
+// providers serving many customers provide very little.
+func sharedHostPivots(c *assocCollector, g *netmap.Graph, pivot string) {
+	rtype, label := "ns_record", "nameserver "
+	if pivot == MailPivot {
+		rtype, label = "mx_record", "mail exchange "
+	}
+
+	since := time.Time{}
+	assets, err := g.DB.FindByContent(&domain.FQDN{Name: c.target}, since)
+	if err != nil {
+		return
+	}
+
+	shared := make(map[string]int)
+	for _, a := range assets {
+		rels, err := g.DB.OutgoingRelations(a, since, rtype)
+		if err != nil {
+			continue
+		}
+
+		for _, rel := range rels {
+			host, err := g.DB.FindById(rel.ToAsset.ID, since)
+			if err != nil {
+				continue
+			}
+			fqdn, ok := host.Asset.(domain.FQDN)
+			if !ok {
+				continue
+			}
+
+			users := hostUsers(g, host, rtype, c.target)
+			for _, d := range users {
+				shared[d]++
+				c.add(d, pivot, label+fqdn.Name, sharedHostConfidence(fqdn.Name, c.target, pivot, len(users)))
+			}
+		}
+	}
+
+	// Sharing several hosts is stronger evidence than sharing one of them
+	for d, count := range shared {
+		if a, found := c.assocs[d+"|"+pivot]; found && count > 1 {
+			a.Confidence += 10
+			if a.Confidence > 95 {
+				a.Confidence = 95
+			}
+		}
+	}
+}
+
+// hostUsers returns the root domains outside of the scope referencing the host with the relation.
+func hostUsers(g *netmap.Graph, host *types.Asset, rtype, target string) []string {
+	rels, err := g.DB.IncomingRelations(host, time.Time{}, rtype)
+	if err != nil {
+		return nil
+	}
+
+	seen := make(map[string]struct{})
+	var results []string
+	for _, rel := range rels {
+		from, err := g.DB.FindById(rel.FromAsset.ID, time.Time{})
+		if err != nil {
+			continue
+		}
+		fqdn, ok := from.Asset.(domain.FQDN)
+		if !ok {
+			continue
+		}
+
+		d, err := publicsuffix.EffectiveTLDPlusOne(strings.ToLower(fqdn.Name))
+		if err != nil || inScope(d, []string{target}) {
+			continue
+		}
+		if _, found := seen[d]; !found {
+			seen[d] = struct{}{}
+			results = append(results, d)
+		}
+	}
+	sort.Strings(results)
+	return results
+}
+
+// sharedHostConfidence estimates the confidence of the association from the operator of the
+// host and the number of domains outside of the scope using it.
+func sharedHostConfidence(host, target, pivot string, users int) int {
+	if inScope(host, []string{target}) {
+		return 90
+	}
+	if pivot == MailPivot {
+		if p := ClassifyMX(host, target); p != ProviderOnPremises && p != ProviderOther {
+			return 5
+		}
+	}
+
+	switch {
+	case users <= 3:
+		return 50
+	case users <= 10:
+		return 30
+	case users <= 50:
+		return 15
+	}
+	return 5
+}
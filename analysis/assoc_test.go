@@ -0,0 +1,75 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package analysis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/amass/v4/findings"
+)
+
+func TestDomainAssociations(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	_ = g.UpsertNS(ctx, "owasp.org", "ns1.owasp.org")
+	_ = g.UpsertNS(ctx, "owasp.org", "ns2.owasp.org")
+	_ = g.UpsertNS(ctx, "owasp.net", "ns1.owasp.org")
+	_ = g.UpsertNS(ctx, "owasp.net", "ns2.owasp.org")
+	_ = g.UpsertMX(ctx, "owasp.org", "aspmx.l.google.com")
+	_ = g.UpsertMX(ctx, "example.com", "aspmx.l.google.com")
+
+	all := []*findings.Finding{
+		{
+			Type:    "candidate_domain",
+			Asset:   "owaspfoundation.org",
+			Details: map[string]string{"organization": "OWASP Foundation", "related": "owasp.org", "review": "pending"},
+		},
+		{
+			Type:    "candidate_domain",
+			Asset:   "unrelated.org",
+			Details: map[string]string{"organization": "OWASP Foundation", "related": "owasp.org", "review": "denied"},
+		},
+		{Type: "http_fingerprint", Asset: "https://www.owasp.org:443", Details: map[string]string{CertOrgDetail: "OWASP Foundation"}},
+		{Type: "http_fingerprint", Asset: "https://shop.owasp-store.com:443", Details: map[string]string{CertOrgDetail: "owasp foundation"}},
+		{Type: "http_fingerprint", Asset: "https://www.example.com:443", Details: map[string]string{CertOrgDetail: "Example Inc"}},
+		{Type: "tracking_id", Asset: "https://www.owasp.org:443", Details: map[string]string{"tracking_ids": "UA-1234567-2,GTM-K9X2ZQ"}},
+		{Type: "tracking_id", Asset: "https://www.owasp-events.org:443", Details: map[string]string{"tracking_ids": "GTM-K9X2ZQ"}},
+		{
+			Type:    "tracking_id_domain",
+			Asset:   "owasp.io",
+			Details: map[string]string{"tracking_id": "UA-1234567-2", "related": "www.owasp.org", "review": "pending"},
+		},
+	}
+
+	expected := []struct {
+		Domain     string
+		Pivot      string
+		Confidence int
+	}{
+		{"owasp.net", NameserverPivot, 95},
+		{"owaspfoundation.org", RegistrantPivot, 80},
+		{"owasp.io", TrackingIDPivot, 75},
+		{"owasp-events.org", TrackingIDPivot, 70},
+		{"owasp-store.com", CertOrgPivot, 70},
+		{"example.com", MailPivot, 5},
+	}
+
+	assocs := DomainAssociations(ctx, g, all, "owasp.org")
+	if len(assocs) != len(expected) {
+		t.Fatalf("Expected %d associations, got %d", len(expected), len(assocs))
+	}
+	for i, e := range expected {
+		if a := assocs[i]; a.Domain != e.Domain || a.Pivot != e.Pivot || a.Confidence != e.Confidence || a.Target != "owasp.org" {
+			t.Errorf("Unexpected association: %+v", a)
+		}
+	}
+	if ev := assocs[0].Evidence; len(ev) != 2 || ev[0] != "nameserver ns1.owasp.org" {
+		t.Errorf("Unexpected evidence: %v", ev)
+	}
+}
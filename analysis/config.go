@@ -0,0 +1,54 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package analysis
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// DefaultPivotWindow is the period of resolution history used to pivot from addresses to co-hosted names.
+const DefaultPivotWindow = 30 * 24 * time.Hour
+
+// PivotWindow returns the time window set by the 'pivot' section of the configuration options.
+// A zero duration is returned when address pivoting has been disabled.
+func PivotWindow(cfg *config.Config) (time.Duration, error) {
+	pivotRaw, ok := cfg.Options["pivot"]
+	if !ok {
+		return DefaultPivotWindow, nil
+	}
+
+	settings, ok := pivotRaw.(map[string]interface{})
+	if !ok {
+		return 0, fmt.Errorf("pivot is not a map[string]interface{}")
+	}
+
+	if raw, ok := settings["enabled"]; ok {
+		enabled, ok := raw.(bool)
+		if !ok {
+			return 0, fmt.Errorf("pivot enabled is not a bool")
+		}
+		if !enabled {
+			return 0, nil
+		}
+	}
+
+	window := DefaultPivotWindow
+	if raw, ok := settings["window"]; ok {
+		str, ok := raw.(string)
+		if !ok {
+			return 0, fmt.Errorf("pivot window is not a string")
+		}
+
+		d, err := time.ParseDuration(str)
+		if err != nil || d <= 0 {
+			return 0, fmt.Errorf("pivot window is not a valid duration: %s", str)
+		}
+		window = d
+	}
+	return window, nil
+}
@@ -0,0 +1,103 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package analysis
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/findings"
+)
+
+// The finding details recorded for the response bodies of the web endpoints.
+const (
+	// BodyHashDetail is the SHA-256 digest of the normalized response body
+	BodyHashDetail = "body_sha256"
+	// ParkedDetail is set to true when the response body is the page of a domain parking service
+	ParkedDetail = "parked"
+)
+
+// The labels of the groups of hosts serving identical content.
+const (
+	// ParkedContent is the page of a domain parking service
+	ParkedContent = "parked"
+	// WildcardContent is the page served for every name below a subdomain, such as by a wildcard virtual host
+	WildcardContent = "wildcard_vhost"
+	// DuplicateContent is any other page served by several hosts
+	DuplicateContent = "duplicate"
+)
+
+// The number of names below the same subdomain serving identical content considered a wildcard virtual host.
+const minWildcardHosts = 3
+
+// ContentGroup is a set of hosts whose web endpoints serve identical content.
+type ContentGroup struct {
+	Hash  string   `json:"hash"`
+	Label string   `json:"label"`
+	Hosts []string `json:"hosts"`
+}
+
+// ContentGroups returns the groups of at least two hosts serving the same normalized response body,
+// according to the body hashes recorded in the details of the findings. The largest groups are first.
+func ContentGroups(all []*findings.Finding) []*ContentGroup {
+	hosts := make(map[string]map[string]struct{})
+	parked := make(map[string]bool)
+	for _, f := range all {
+		hash := f.Details[BodyHashDetail]
+		if hash == "" {
+			continue
+		}
+
+		if _, found := hosts[hash]; !found {
+			hosts[hash] = make(map[string]struct{})
+		}
+		hosts[hash][findings.Host(f.Asset)] = struct{}{}
+		if f.Details[ParkedDetail] == "true" {
+			parked[hash] = true
+		}
+	}
+
+	var groups []*ContentGroup
+	for hash, set := range hosts {
+		if len(set) < 2 {
+			continue
+		}
+
+		g := &ContentGroup{Hash: hash, Label: DuplicateContent}
+		for h := range set {
+			g.Hosts = append(g.Hosts, h)
+		}
+		sort.Strings(g.Hosts)
+
+		if parked[hash] {
+			g.Label = ParkedContent
+		} else if len(g.Hosts) >= minWildcardHosts && sameParent(g.Hosts) {
+			g.Label = WildcardContent
+		}
+		groups = append(groups, g)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i].Hosts) != len(groups[j].Hosts) {
+			return len(groups[i].Hosts) > len(groups[j].Hosts)
+		}
+		return groups[i].Hosts[0] < groups[j].Hosts[0]
+	})
+	return groups
+}
+
+// sameParent returns true when all the names are directly below the same subdomain.
+func sameParent(names []string) bool {
+	var parent string
+
+	for i, name := range names {
+		_, p, found := strings.Cut(name, ".")
+		if !found || (i > 0 && p != parent) {
+			return false
+		}
+		parent = p
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/findings"
+)
+
+func TestContentGroups(t *testing.T) {
+	fingerprint := func(asset, hash, parked string) *findings.Finding {
+		f := &findings.Finding{
+			Type:    "http_fingerprint",
+			Asset:   asset,
+			Details: map[string]string{BodyHashDetail: hash},
+		}
+		if parked != "" {
+			f.Details[ParkedDetail] = parked
+		}
+		return f
+	}
+
+	all := []*findings.Finding{
+		fingerprint("https://a.dev.owasp.org", "wild", ""),
+		fingerprint("https://b.dev.owasp.org", "wild", ""),
+		fingerprint("http://b.dev.owasp.org", "wild", ""),
+		fingerprint("https://c.dev.owasp.org", "wild", ""),
+		fingerprint("https://owasp.net", "parked", "true"),
+		fingerprint("https://owasp.info", "parked", ""),
+		fingerprint("https://www.owasp.org", "dup", ""),
+		fingerprint("https://owasp.org", "dup", ""),
+		fingerprint("https://shop.owasp.org", "unique", ""),
+		{Type: "favicon", Asset: "https://owasp.org"},
+	}
+
+	expected := []*ContentGroup{
+		{Hash: "wild", Label: WildcardContent, Hosts: []string{"a.dev.owasp.org", "b.dev.owasp.org", "c.dev.owasp.org"}},
+		{Hash: "parked", Label: ParkedContent, Hosts: []string{"owasp.info", "owasp.net"}},
+		{Hash: "dup", Label: DuplicateContent, Hosts: []string{"owasp.org", "www.owasp.org"}},
+	}
+	if groups := ContentGroups(all); !reflect.DeepEqual(groups, expected) {
+		for _, g := range groups {
+			t.Logf("%+v", g)
+		}
+		t.Errorf("The content groups were not as expected")
+	}
+}
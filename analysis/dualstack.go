@@ -0,0 +1,166 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package analysis
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/amass/v4/findings"
+)
+
+// The finding type created for the services found by the port scans of the enumeration.
+const openPortFinding = "open_port"
+
+// The dual-stack status of a name, based on the address families it resolves to.
+const (
+	StackDual = "dual"
+	StackIPv4 = "ipv4"
+	StackIPv6 = "ipv6"
+)
+
+// DualStack pairs the IPv4 and IPv6 addresses of a name, along with the services exposed by each side.
+type DualStack struct {
+	Name         string   `json:"name"`
+	IPv4         []string `json:"ipv4"`
+	IPv6         []string `json:"ipv6"`
+	IPv4Services []string `json:"ipv4_services,omitempty"`
+	IPv6Services []string `json:"ipv6_services,omitempty"`
+	// IPv6Only lists the services exposed by the IPv6 addresses and by none of the IPv4 addresses,
+	// which are commonly missed when the firewall rules are only written for IPv4
+	IPv6Only []string `json:"ipv6_only,omitempty"`
+}
+
+// Status returns the address families the name resolves to.
+func (ds *DualStack) Status() string {
+	switch {
+	case len(ds.IPv4) > 0 && len(ds.IPv6) > 0:
+		return StackDual
+	case len(ds.IPv6) > 0:
+		return StackIPv6
+	case len(ds.IPv4) > 0:
+		return StackIPv4
+	}
+	return ""
+}
+
+// ServicesByAddr returns the 'port/protocol' services of each address, as found in the open port findings.
+func ServicesByAddr(all []*findings.Finding) map[string][]string {
+	services := make(map[string][]string)
+
+	for _, f := range all {
+		if f.Type != openPortFinding {
+			continue
+		}
+
+		// The asset is provided in the 'address:port/protocol' form
+		hostport, proto, found := strings.Cut(f.Asset, "/")
+		if !found {
+			continue
+		}
+		host, port, err := net.SplitHostPort(hostport)
+		if err != nil {
+			continue
+		}
+		addr, err := netip.ParseAddr(host)
+		if err != nil {
+			continue
+		}
+
+		key := addr.Unmap().String()
+		services[key] = append(services[key], port+"/"+proto)
+	}
+	return services
+}
+
+// NewDualStack correlates the addresses of the name with the services exposed by each address.
+func NewDualStack(name string, addrs []string, services map[string][]string) *DualStack {
+	ds := &DualStack{Name: name}
+	v4svcs := make(map[string]struct{})
+	v6svcs := make(map[string]struct{})
+
+	for _, a := range addrs {
+		addr, err := netip.ParseAddr(a)
+		if err != nil {
+			continue
+		}
+		addr = addr.Unmap()
+
+		key := addr.String()
+		if addr.Is4() {
+			ds.IPv4 = appendUnique(ds.IPv4, key)
+			for _, svc := range services[key] {
+				v4svcs[svc] = struct{}{}
+			}
+		} else {
+			ds.IPv6 = appendUnique(ds.IPv6, key)
+			for _, svc := range services[key] {
+				v6svcs[svc] = struct{}{}
+			}
+		}
+	}
+
+	ds.IPv4Services = sortedKeys(v4svcs)
+	ds.IPv6Services = sortedKeys(v6svcs)
+	// Services are only compared when the name can be reached over both families
+	if len(ds.IPv4) > 0 {
+		for _, svc := range ds.IPv6Services {
+			if _, found := v4svcs[svc]; !found {
+				ds.IPv6Only = append(ds.IPv6Only, svc)
+			}
+		}
+	}
+	sort.Strings(ds.IPv4)
+	sort.Strings(ds.IPv6)
+	return ds
+}
+
+// DualStacks returns the dual-stack correlation of each name with addresses in the graph,
+// using the resolutions that were valid within the time interval.
+func DualStacks(ctx context.Context, g *netmap.Graph, start, end time.Time, services map[string][]string, names ...string) []*DualStack {
+	pairs, err := NamesToAddrs(ctx, g, start, end, names...)
+	if err != nil {
+		return nil
+	}
+
+	var order []string
+	addrs := make(map[string][]string)
+	for _, p := range pairs {
+		if _, found := addrs[p.FQDN.Name]; !found {
+			order = append(order, p.FQDN.Name)
+		}
+		addrs[p.FQDN.Name] = append(addrs[p.FQDN.Name], p.Addr.Address.String())
+	}
+
+	results := make([]*DualStack, 0, len(order))
+	for _, name := range order {
+		results = append(results, NewDualStack(name, addrs[name], services))
+	}
+	return results
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, item := range list {
+		if item == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	var keys []string
+
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
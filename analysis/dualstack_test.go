@@ -0,0 +1,70 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package analysis
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/amass/v4/findings"
+)
+
+func TestServicesByAddr(t *testing.T) {
+	all := []*findings.Finding{
+		{Type: "open_port", Asset: "192.0.2.10:443/tcp"},
+		{Type: "open_port", Asset: "[2001:db8::10]:22/tcp"},
+		{Type: "open_port", Asset: "[2001:db8::10]:443/tcp"},
+		{Type: "web_endpoint", Asset: "https://192.0.2.10:8443/"},
+		{Type: "open_port", Asset: "not-a-service"},
+	}
+
+	services := ServicesByAddr(all)
+	if len(services) != 2 {
+		t.Fatalf("Expected services for 2 addresses, got %d", len(services))
+	}
+	if got := services["2001:db8::10"]; !reflect.DeepEqual(got, []string{"22/tcp", "443/tcp"}) {
+		t.Errorf("Unexpected IPv6 services: %v", got)
+	}
+}
+
+func TestDualStacks(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	_ = g.UpsertA(ctx, "www.owasp.org", "192.0.2.10")
+	_ = g.UpsertAAAA(ctx, "www.owasp.org", "2001:db8::10")
+	_ = g.UpsertA(ctx, "mail.owasp.org", "192.0.2.11")
+	_ = g.UpsertAAAA(ctx, "v6.owasp.org", "2001:db8::12")
+
+	services := map[string][]string{
+		"192.0.2.10":   {"443/tcp"},
+		"2001:db8::10": {"22/tcp", "443/tcp"},
+		"2001:db8::12": {"22/tcp"},
+	}
+
+	stacks := DualStacks(ctx, g, time.Time{}, time.Time{}, services, "www.owasp.org", "mail.owasp.org", "v6.owasp.org")
+	if len(stacks) != 3 {
+		t.Fatalf("Expected 3 names, got %d", len(stacks))
+	}
+
+	results := make(map[string]*DualStack)
+	for _, ds := range stacks {
+		results[ds.Name] = ds
+	}
+	if ds := results["www.owasp.org"]; ds.Status() != StackDual || !reflect.DeepEqual(ds.IPv6Only, []string{"22/tcp"}) {
+		t.Errorf("Unexpected correlation for www.owasp.org: %+v", ds)
+	}
+	if ds := results["mail.owasp.org"]; ds.Status() != StackIPv4 || len(ds.IPv6Only) != 0 {
+		t.Errorf("Unexpected correlation for mail.owasp.org: %+v", ds)
+	}
+	// Names only reachable over IPv6 do not have an IPv4 side to compare with
+	if ds := results["v6.owasp.org"]; ds.Status() != StackIPv6 || len(ds.IPv6Only) != 0 {
+		t.Errorf("Unexpected correlation for v6.owasp.org: %+v", ds)
+	}
+}
@@ -0,0 +1,288 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package analysis
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/asset-db/types"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+)
+
+// The kinds of infrastructure clustered.
+const (
+	DNSInfrastructure  = "dns"
+	MailInfrastructure = "mail"
+)
+
+// The operators of the hosts serving a cluster.
+const (
+	SelfHosted       = "self-hosted"
+	ThirdPartyHosted = "third-party"
+	MixedHosting     = "mixed"
+)
+
+// The issues flagged for the clusters.
+const (
+	// IssueSingleNameserver is a delegation without a redundant nameserver
+	IssueSingleNameserver = "single_nameserver"
+	// IssueUnresolvedHost is a host within the scope without addresses in the graph, such as a retired server
+	IssueUnresolvedHost = "unresolved_host"
+	// IssueOutlier is a single domain using infrastructure unlike most of the other domains
+	IssueOutlier = "outlier"
+)
+
+// dnsProviders maps the nameserver suffixes operated by well known DNS providers.
+var dnsProviders = []struct {
+	Suffix   string
+	Provider string
+}{
+	{"awsdns", "Amazon Route 53"},
+	{"ns.cloudflare.com", "Cloudflare"},
+	{"azure-dns.com", "Azure DNS"},
+	{"azure-dns.net", "Azure DNS"},
+	{"azure-dns.org", "Azure DNS"},
+	{"azure-dns.info", "Azure DNS"},
+	{"googledomains.com", "Google Cloud DNS"},
+	{"google.com", "Google Cloud DNS"},
+	{"akam.net", "Akamai Edge DNS"},
+	{"nsone.net", "NS1"},
+	{"ultradns.com", "UltraDNS"},
+	{"ultradns.net", "UltraDNS"},
+	{"ultradns.org", "UltraDNS"},
+	{"ultradns.biz", "UltraDNS"},
+	{"dynect.net", "Dyn"},
+	{"domaincontrol.com", "GoDaddy"},
+	{"registrar-servers.com", "Namecheap"},
+	{"dnsmadeeasy.com", "DNS Made Easy"},
+	{"digitalocean.com", "DigitalOcean"},
+	{"linode.com", "Linode"},
+	{"hetzner.com", "Hetzner"},
+	{"ovh.net", "OVHcloud"},
+	{"gandi.net", "Gandi"},
+	{"wixdns.net", "Wix"},
+	{"squarespacedns.com", "Squarespace"},
+}
+
+// InfraCluster groups the domains using the same authoritative nameservers, or the same mail provider.
+type InfraCluster struct {
+	Kind     string   `json:"kind"`
+	Provider string   `json:"provider"`
+	Hosting  string   `json:"hosting"`
+	Hosts    []string `json:"hosts"`
+	Domains  []string `json:"domains"`
+	Issues   []string `json:"issues,omitempty"`
+}
+
+// ClassifyNS returns the DNS provider operating the nameserver of the domain.
+func ClassifyNS(host, domain string) string {
+	host = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(host), "."))
+
+	for _, p := range dnsProviders {
+		// Route 53 assigns the nameservers from several TLDs, such as ns-1.awsdns-01.org
+		if p.Suffix == "awsdns" {
+			if labels := strings.Split(host, "."); len(labels) > 2 && strings.HasPrefix(labels[len(labels)-2], "awsdns-") {
+				return p.Provider
+			}
+			continue
+		}
+		if host == p.Suffix || strings.HasSuffix(host, "."+p.Suffix) {
+			return p.Provider
+		}
+	}
+	// The nameservers of the registered domain are operated by the organization
+	return ClassifyMX(host, domain)
+}
+
+// InfraClusters returns the clusters of the in-scope names with nameservers or mail exchanges in the graph.
+// The domains are clustered by the set of their authoritative nameservers, and by the provider receiving
+// their mail. The hosts within the root domains are considered operated by the organization.
+func InfraClusters(ctx context.Context, g *netmap.Graph, domains []string, since time.Time) []*InfraCluster {
+	var fqdns []oam.Asset
+	for _, d := range domains {
+		fqdns = append(fqdns, domain.FQDN{Name: d})
+	}
+	if len(fqdns) == 0 {
+		return nil
+	}
+
+	assets, err := g.DB.FindByScope(fqdns, since)
+	if err != nil {
+		return nil
+	}
+
+	clusters := make(map[string]*InfraCluster)
+	for _, a := range assets {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		fqdn, ok := a.Asset.(domain.FQDN)
+		if !ok {
+			continue
+		}
+
+		if hosts := recordTargets(g, a, "ns_record", since); len(hosts) > 0 {
+			cluster(clusters, DNSInfrastructure, fqdn.Name, hosts, domains, ClassifyNS)
+		}
+		if hosts := recordTargets(g, a, "mx_record", since); len(hosts) > 0 {
+			cluster(clusters, MailInfrastructure, fqdn.Name, hosts, domains, ClassifyMX)
+		}
+	}
+
+	var results []*InfraCluster
+	for _, c := range clusters {
+		sort.Strings(c.Domains)
+		results = append(results, c)
+	}
+	flagIssues(g, results, domains, since)
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Kind != results[j].Kind {
+			return results[i].Kind < results[j].Kind
+		}
+		if len(results[i].Domains) != len(results[j].Domains) {
+			return len(results[i].Domains) > len(results[j].Domains)
+		}
+		return strings.Join(results[i].Hosts, ",") < strings.Join(results[j].Hosts, ",")
+	})
+	return results
+}
+
+// recordTargets returns the sorted names targeted by the records of the relation type.
+func recordTargets(g *netmap.Graph, a *types.Asset, rtype string, since time.Time) []string {
+	rels, err := g.DB.OutgoingRelations(a, since, rtype)
+	if err != nil {
+		return nil
+	}
+
+	var hosts []string
+	for _, rel := range rels {
+		to, err := g.DB.FindById(rel.ToAsset.ID, since)
+		if err != nil {
+			continue
+		}
+		if target, ok := to.Asset.(domain.FQDN); ok {
+			hosts = append(hosts, strings.ToLower(target.Name))
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// cluster adds the name to the cluster of its hosts. The mail of the names using a well known provider
+// is clustered by the provider, since the providers assign the mail exchanges in various ways.
+func cluster(clusters map[string]*InfraCluster, kind, name string, hosts, domains []string, classify func(string, string) string) {
+	providers := make(map[string]struct{})
+	var self, others int
+	for _, h := range hosts {
+		if inScope(h, domains) || classify(h, name) == ProviderOnPremises {
+			self++
+			providers[ProviderOnPremises] = struct{}{}
+			continue
+		}
+		others++
+		providers[classify(h, name)] = struct{}{}
+	}
+
+	var names []string
+	for p := range providers {
+		names = append(names, p)
+	}
+	sort.Strings(names)
+	provider := strings.Join(names, ", ")
+
+	key := kind + "|" + strings.Join(hosts, ",")
+	if kind == MailInfrastructure && len(names) == 1 && names[0] != ProviderOnPremises && names[0] != ProviderOther {
+		key = kind + "|" + provider
+	}
+
+	c, found := clusters[key]
+	if !found {
+		c = &InfraCluster{Kind: kind, Provider: provider}
+		switch {
+		case others == 0:
+			c.Hosting = SelfHosted
+		case self == 0:
+			c.Hosting = ThirdPartyHosted
+		default:
+			c.Hosting = MixedHosting
+		}
+		clusters[key] = c
+	}
+
+	c.Domains = append(c.Domains, name)
+	for _, h := range hosts {
+		if !containsString(c.Hosts, h) {
+			c.Hosts = append(c.Hosts, h)
+		}
+	}
+	sort.Strings(c.Hosts)
+}
+
+// flagIssues records the delegations without redundancy, the hosts within the scope that do not resolve,
+// and the single domains using infrastructure unlike the majority of the domains.
+func flagIssues(g *netmap.Graph, clusters []*InfraCluster, domains []string, since time.Time) {
+	largest := make(map[string]int)
+	for _, c := range clusters {
+		if len(c.Domains) > largest[c.Kind] {
+			largest[c.Kind] = len(c.Domains)
+		}
+	}
+
+	resolved := make(map[string]bool)
+	for _, c := range clusters {
+		if c.Kind == DNSInfrastructure && len(c.Hosts) == 1 {
+			c.Issues = append(c.Issues, IssueSingleNameserver)
+		}
+
+		for _, h := range c.Hosts {
+			if !inScope(h, domains) {
+				continue
+			}
+			if _, found := resolved[h]; !found {
+				resolved[h] = hasAddress(g, h, since)
+			}
+			if !resolved[h] {
+				c.Issues = append(c.Issues, IssueUnresolvedHost)
+				break
+			}
+		}
+
+		if len(c.Domains) == 1 && largest[c.Kind] >= 3 {
+			c.Issues = append(c.Issues, IssueOutlier)
+		}
+	}
+}
+
+func hasAddress(g *netmap.Graph, name string, since time.Time) bool {
+	assets, err := g.DB.FindByContent(&domain.FQDN{Name: name}, since)
+	if err != nil {
+		return false
+	}
+
+	for _, a := range assets {
+		if rels, err := g.DB.OutgoingRelations(a, since, addrRelations...); err == nil && len(rels) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package analysis
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+)
+
+func TestClassifyNS(t *testing.T) {
+	for host, expected := range map[string]string{
+		"ns-1234.awsdns-01.org.":  "Amazon Route 53",
+		"ada.ns.cloudflare.com":   "Cloudflare",
+		"ns1-01.azure-dns.com":    "Azure DNS",
+		"ns1.owasp.org":           ProviderOnPremises,
+		"ns1.hosting-example.net": ProviderOther,
+	} {
+		if provider := ClassifyNS(host, "www.owasp.org"); provider != expected {
+			t.Errorf("The nameserver %s returned the provider %s, expected %s", host, provider, expected)
+		}
+	}
+}
+
+func TestInfraClusters(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	for _, name := range []string{"owasp.org", "dev.owasp.org", "shop.owasp.org"} {
+		_ = g.UpsertNS(ctx, name, "ada.ns.cloudflare.com")
+		_ = g.UpsertNS(ctx, name, "bob.ns.cloudflare.com")
+	}
+	// A forgotten delegation to a nameserver that no longer resolves
+	_ = g.UpsertNS(ctx, "legacy.owasp.org", "ns-old.owasp.org")
+	_ = g.UpsertMX(ctx, "owasp.org", "aspmx.l.google.com")
+	_ = g.UpsertMX(ctx, "owasp.org", "alt1.aspmx.l.google.com")
+	_ = g.UpsertMX(ctx, "dev.owasp.org", "mail.owasp.org")
+	_ = g.UpsertA(ctx, "mail.owasp.org", "192.0.2.1")
+
+	clusters := InfraClusters(ctx, g, []string{"owasp.org"}, time.Time{})
+	if len(clusters) != 4 {
+		t.Fatalf("Expected 4 clusters, got %d", len(clusters))
+	}
+
+	expected := []*InfraCluster{
+		{
+			Kind:     DNSInfrastructure,
+			Provider: "Cloudflare",
+			Hosting:  ThirdPartyHosted,
+			Hosts:    []string{"ada.ns.cloudflare.com", "bob.ns.cloudflare.com"},
+			Domains:  []string{"dev.owasp.org", "owasp.org", "shop.owasp.org"},
+		},
+		{
+			Kind:     DNSInfrastructure,
+			Provider: ProviderOnPremises,
+			Hosting:  SelfHosted,
+			Hosts:    []string{"ns-old.owasp.org"},
+			Domains:  []string{"legacy.owasp.org"},
+			Issues:   []string{IssueSingleNameserver, IssueUnresolvedHost, IssueOutlier},
+		},
+		{
+			Kind:     MailInfrastructure,
+			Provider: "Google Workspace",
+			Hosting:  ThirdPartyHosted,
+			Hosts:    []string{"alt1.aspmx.l.google.com", "aspmx.l.google.com"},
+			Domains:  []string{"owasp.org"},
+		},
+		{
+			Kind:     MailInfrastructure,
+			Provider: ProviderOnPremises,
+			Hosting:  SelfHosted,
+			Hosts:    []string{"mail.owasp.org"},
+			Domains:  []string{"dev.owasp.org"},
+		},
+	}
+	for i, c := range expected {
+		if !reflect.DeepEqual(clusters[i], c) {
+			t.Errorf("Expected the cluster %+v, got %+v", c, clusters[i])
+		}
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package analysis
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Mail providers that are not identified by the MX target suffixes.
+const (
+	ProviderOnPremises = "On-Premises"
+	ProviderOther      = "Other"
+)
+
+// mailProviders maps the MX target suffixes operated by well known mail providers.
+var mailProviders = []struct {
+	Suffix   string
+	Provider string
+}{
+	{"aspmx.l.google.com", "Google Workspace"},
+	{"googlemail.com", "Google Workspace"},
+	{"google.com", "Google Workspace"},
+	{"mail.protection.outlook.com", "Microsoft 365"},
+	{"outlook.com", "Microsoft 365"},
+	{"pphosted.com", "Proofpoint"},
+	{"ppe-hosted.com", "Proofpoint"},
+	{"mimecast.com", "Mimecast"},
+	{"mimecast.co.za", "Mimecast"},
+	{"barracudanetworks.com", "Barracuda"},
+	{"messagelabs.com", "Broadcom Email Security"},
+	{"iphmx.com", "Cisco Secure Email"},
+	{"fireeyecloud.com", "Trellix Email Security"},
+	{"trendmicro.com", "Trend Micro Email Security"},
+	{"zoho.com", "Zoho Mail"},
+	{"zoho.eu", "Zoho Mail"},
+	{"amazonaws.com", "Amazon SES"},
+	{"secureserver.net", "GoDaddy"},
+	{"mailgun.org", "Mailgun"},
+	{"sendgrid.net", "SendGrid"},
+	{"yandex.net", "Yandex Mail"},
+	{"icloud.com", "iCloud Mail"},
+	{"protonmail.ch", "Proton Mail"},
+}
+
+// MailExchange is a MX target discovered for a domain.
+type MailExchange struct {
+	Host     string `json:"host"`
+	Provider string `json:"provider"`
+}
+
+// MailFlow summarizes the mail exchanges that receive email for a domain.
+type MailFlow struct {
+	Domain    string          `json:"domain"`
+	Exchanges []*MailExchange `json:"exchanges"`
+	Providers []string        `json:"providers"`
+	Summary   string          `json:"summary"`
+}
+
+// ClassifyMX returns the mail provider operating the MX target for the domain.
+func ClassifyMX(target, domain string) string {
+	target = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(target), "."))
+	domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+
+	for _, p := range mailProviders {
+		if target == p.Suffix || strings.HasSuffix(target, "."+p.Suffix) {
+			return p.Provider
+		}
+	}
+
+	base, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil {
+		base = domain
+	}
+	if target == base || strings.HasSuffix(target, "."+base) {
+		return ProviderOnPremises
+	}
+	return ProviderOther
+}
+
+// MailFlows returns the mail flow summary for each of the domains with MX records in the graph.
+func MailFlows(ctx context.Context, g *netmap.Graph, domains []string, since time.Time) []*MailFlow {
+	var flows []*MailFlow
+
+	for _, d := range domains {
+		select {
+		case <-ctx.Done():
+			return flows
+		default:
+		}
+
+		if mf := mailFlow(g, d, since); mf != nil {
+			flows = append(flows, mf)
+		}
+	}
+	return flows
+}
+
+func mailFlow(g *netmap.Graph, name string, since time.Time) *MailFlow {
+	assets, err := g.DB.FindByContent(&domain.FQDN{Name: name}, since)
+	if err != nil || len(assets) == 0 {
+		return nil
+	}
+
+	mf := &MailFlow{Domain: name}
+	providers := make(map[string]struct{})
+
+	for _, a := range assets {
+		if fqdn, ok := a.Asset.(domain.FQDN); !ok || fqdn.Name != name {
+			continue
+		}
+
+		rels, err := g.DB.OutgoingRelations(a, since, "mx_record")
+		if err != nil {
+			continue
+		}
+
+		for _, rel := range rels {
+			to, err := g.DB.FindById(rel.ToAsset.ID, since)
+			if err != nil {
+				continue
+			}
+			if target, ok := to.Asset.(domain.FQDN); ok {
+				provider := ClassifyMX(target.Name, name)
+
+				providers[provider] = struct{}{}
+				mf.Exchanges = append(mf.Exchanges, &MailExchange{
+					Host:     target.Name,
+					Provider: provider,
+				})
+			}
+		}
+	}
+	if len(mf.Exchanges) == 0 {
+		return nil
+	}
+
+	sort.Slice(mf.Exchanges, func(i, j int) bool {
+		return mf.Exchanges[i].Host < mf.Exchanges[j].Host
+	})
+	for p := range providers {
+		mf.Providers = append(mf.Providers, p)
+	}
+	sort.Strings(mf.Providers)
+	mf.Summary = summarizeMailFlow(mf.Providers)
+	return mf
+}
+
+func summarizeMailFlow(providers []string) string {
+	if len(providers) == 1 {
+		switch providers[0] {
+		case ProviderOnPremises:
+			return "Mail is received by servers operated by the organization"
+		case ProviderOther:
+			return "Mail is received by an unidentified third party"
+		}
+		return "Mail is received by " + providers[0]
+	}
+
+	var gateways, others []string
+	for _, p := range providers {
+		if isGateway(p) {
+			gateways = append(gateways, p)
+		} else {
+			others = append(others, p)
+		}
+	}
+
+	if len(gateways) > 0 && len(others) == 0 {
+		return "Mail is filtered by " + strings.Join(gateways, " and ")
+	} else if len(gateways) > 0 {
+		return "Mail is filtered by " + strings.Join(gateways, " and ") + " alongside " + strings.Join(others, " and ")
+	}
+	return "Mail is received by multiple providers: " + strings.Join(providers, ", ")
+}
+
+// Providers that filter mail before delivery to the mailbox platform.
+func isGateway(provider string) bool {
+	switch provider {
+	case "Proofpoint", "Mimecast", "Barracuda", "Broadcom Email Security",
+		"Cisco Secure Email", "Trellix Email Security", "Trend Micro Email Security":
+		return true
+	}
+	return false
+}
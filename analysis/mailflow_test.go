@@ -0,0 +1,57 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package analysis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+)
+
+func TestClassifyMX(t *testing.T) {
+	tests := []struct {
+		target   string
+		domain   string
+		expected string
+	}{
+		{"aspmx.l.google.com.", "owasp.org", "Google Workspace"},
+		{"owasp-org.mail.protection.outlook.com", "owasp.org", "Microsoft 365"},
+		{"mx0a-001234.pphosted.com", "owasp.org", "Proofpoint"},
+		{"mail.owasp.org", "owasp.org", ProviderOnPremises},
+		{"mx.owasp.org", "www.owasp.org", ProviderOnPremises},
+		{"mx.example.net", "owasp.org", ProviderOther},
+	}
+
+	for _, test := range tests {
+		if got := ClassifyMX(test.target, test.domain); got != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.target, test.expected, got)
+		}
+	}
+}
+
+func TestMailFlows(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	_ = g.UpsertMX(ctx, "owasp.org", "mx0a-001234.pphosted.com")
+	_ = g.UpsertMX(ctx, "owasp.org", "owasp-org.mail.protection.outlook.com")
+	_ = g.UpsertMX(ctx, "example.org", "mail.example.org")
+
+	flows := MailFlows(ctx, g, []string{"owasp.org", "example.org", "none.org"}, time.Time{})
+	if len(flows) != 2 {
+		t.Fatalf("Expected 2 mail flows, got %d", len(flows))
+	}
+
+	if mf := flows[0]; len(mf.Exchanges) != 2 || len(mf.Providers) != 2 ||
+		mf.Summary != "Mail is filtered by Proofpoint alongside Microsoft 365" {
+		t.Errorf("Unexpected mail flow for %s: %+v", mf.Domain, mf)
+	}
+	if mf := flows[1]; len(mf.Providers) != 1 || mf.Providers[0] != ProviderOnPremises {
+		t.Errorf("Unexpected mail flow for %s: %+v", mf.Domain, mf)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package analysis
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caffix/netmap"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+var addrRelations = []string{"a_record", "aaaa_record"}
+
+// Association is a name outside of the target scope that was hosted on the
+// address of a target name, along with the evidence bounding when it was observed.
+type Association struct {
+	Name       string    `json:"name"`
+	Address    string    `json:"address"`
+	Target     string    `json:"target"`
+	NameSeen   time.Time `json:"name_last_seen"`
+	TargetSeen time.Time `json:"target_last_seen"`
+}
+
+// AddressPivots returns the names that resolved to the addresses of the in-scope
+// names, when both resolutions were last seen within the time window.
+func AddressPivots(ctx context.Context, g *netmap.Graph, domains []string, start, end time.Time) []*Association {
+	var fqdns []oam.Asset
+	for _, d := range domains {
+		fqdns = append(fqdns, domain.FQDN{Name: d})
+	}
+	if len(fqdns) == 0 {
+		return nil
+	}
+
+	assets, err := g.DB.FindByScope(fqdns, time.Time{})
+	if err != nil {
+		return nil
+	}
+
+	var results []*Association
+	seen := make(map[string]struct{})
+	for _, a := range assets {
+		select {
+		case <-ctx.Done():
+			return results
+		default:
+		}
+
+		target, ok := a.Asset.(domain.FQDN)
+		if !ok {
+			continue
+		}
+
+		rels, err := g.DB.OutgoingRelations(a, time.Time{}, addrRelations...)
+		if err != nil {
+			continue
+		}
+
+		for _, rel := range rels {
+			if !inWindow(rel.LastSeen, start, end) {
+				continue
+			}
+
+			for _, assoc := range coHosted(g, rel.ToAsset.ID, domains, start, end) {
+				key := assoc.Name + "|" + assoc.Address
+				if _, found := seen[key]; found {
+					continue
+				}
+				seen[key] = struct{}{}
+
+				assoc.Target = target.Name
+				assoc.TargetSeen = rel.LastSeen
+				results = append(results, assoc)
+			}
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Address == results[j].Address {
+			return results[i].Name < results[j].Name
+		}
+		return results[i].Address < results[j].Address
+	})
+	return results
+}
+
+// coHosted returns the out of scope names that resolved to the address within the time window.
+func coHosted(g *netmap.Graph, id string, domains []string, start, end time.Time) []*Association {
+	addr, err := g.DB.FindById(id, time.Time{})
+	if err != nil {
+		return nil
+	}
+
+	ip, ok := addr.Asset.(network.IPAddress)
+	if !ok {
+		return nil
+	}
+
+	rels, err := g.DB.IncomingRelations(addr, time.Time{}, addrRelations...)
+	if err != nil {
+		return nil
+	}
+
+	var results []*Association
+	for _, rel := range rels {
+		if !inWindow(rel.LastSeen, start, end) {
+			continue
+		}
+
+		from, err := g.DB.FindById(rel.FromAsset.ID, time.Time{})
+		if err != nil {
+			continue
+		}
+
+		if fqdn, ok := from.Asset.(domain.FQDN); ok && !inScope(fqdn.Name, domains) {
+			results = append(results, &Association{
+				Name:     fqdn.Name,
+				Address:  ip.Address.String(),
+				NameSeen: rel.LastSeen,
+			})
+		}
+	}
+	return results
+}
+
+func inWindow(t, start, end time.Time) bool {
+	if !start.IsZero() && t.Before(start) {
+		return false
+	}
+	if !end.IsZero() && t.After(end) {
+		return false
+	}
+	return true
+}
+
+func inScope(name string, domains []string) bool {
+	name = strings.ToLower(name)
+
+	for _, d := range domains {
+		d = strings.ToLower(d)
+		if name == d || strings.HasSuffix(name, "."+d) {
+			return true
+		}
+	}
+	return false
+}
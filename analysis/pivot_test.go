@@ -0,0 +1,37 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package analysis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+)
+
+func TestAddressPivots(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	_ = g.UpsertA(ctx, "www.owasp.org", "192.0.2.1")
+	_ = g.UpsertA(ctx, "mail.owasp.org", "192.0.2.1")
+	_ = g.UpsertA(ctx, "shop.example.com", "192.0.2.1")
+	_ = g.UpsertA(ctx, "blog.example.com", "192.0.2.2")
+
+	now := time.Now()
+	assocs := AddressPivots(ctx, g, []string{"owasp.org"}, now.Add(-time.Hour), now.Add(time.Hour))
+	if len(assocs) != 1 {
+		t.Fatalf("Expected 1 association, got %d", len(assocs))
+	}
+	if a := assocs[0]; a.Name != "shop.example.com" || a.Address != "192.0.2.1" || a.NameSeen.IsZero() {
+		t.Errorf("Unexpected association: %+v", a)
+	}
+
+	if assocs := AddressPivots(ctx, g, []string{"owasp.org"}, now.Add(-48*time.Hour), now.Add(-24*time.Hour)); len(assocs) != 0 {
+		t.Errorf("Expected no associations outside of the time window, got %d", len(assocs))
+	}
+}
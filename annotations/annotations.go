@@ -0,0 +1,199 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package annotations stores the tags and notes attached by the analysts to the assets of the graph
+// database, in a table of the same database, so the triage state lives alongside the discoveries.
+// The asset model does not provide properties for the assets, so the annotations are keyed by the
+// name, address, netblock or autonomous system of the asset.
+package annotations
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The kinds of annotations.
+const (
+	// TagKind is a key=value pair, and an asset carries a single value for each key
+	TagKind = "tag"
+	// NoteKind is free text, and the notes of an asset are kept in the order they were written
+	NoteKind = "note"
+)
+
+// Annotation is a tag or a note attached to an asset.
+type Annotation struct {
+	ID        uint64    `gorm:"primaryKey" json:"-"`
+	Asset     string    `gorm:"index;not null" json:"asset"`
+	Kind      string    `gorm:"not null" json:"kind"`
+	Key       string    `json:"key,omitempty"`
+	Value     string    `json:"value"`
+	Author    string    `json:"author,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName implements the gorm Tabler interface.
+func (Annotation) TableName() string {
+	return "annotations"
+}
+
+// Store reads and writes the annotations kept in the graph database.
+type Store struct {
+	db *gorm.DB
+}
+
+// Open connects to the graph database using the same system names and DSNs accepted by netmap,
+// and creates the annotations table when it does not exist.
+func Open(system, dsn string) (*Store, error) {
+	var dialector gorm.Dialector
+
+	switch system {
+	case "local":
+		dialector = sqlite.Open(dsn)
+	case "postgres":
+		dialector = postgres.Open(dsn)
+	default:
+		return nil, fmt.Errorf("the %s graph database system cannot store annotations", system)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the %s graph database: %v", system, err)
+	}
+	if err := db.AutoMigrate(&Annotation{}); err != nil {
+		return nil, fmt.Errorf("failed to create the annotations table: %v", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close releases the connections to the graph database.
+func (s *Store) Close() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// Key returns the asset key used by the annotations: the lowercase name, address, netblock
+// or autonomous system of the asset, such as www.example.com, 192.0.2.1 or as64496.
+func Key(asset string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(asset), "."))
+}
+
+// ParseTags returns the tags provided as key=value pairs. The keys are lowercase.
+func ParseTags(pairs []string) (map[string]string, error) {
+	tags := make(map[string]string, len(pairs))
+
+	for _, p := range pairs {
+		k, v, found := strings.Cut(p, "=")
+		k = strings.ToLower(strings.TrimSpace(k))
+		if !found || k == "" {
+			return nil, fmt.Errorf("the tag %s is not a key=value pair", p)
+		}
+		tags[k] = strings.TrimSpace(v)
+	}
+	return tags, nil
+}
+
+// Matches returns true when the tags carry all of the required tags. The values are compared without regard to case.
+func Matches(tags, required map[string]string) bool {
+	for k, v := range required {
+		if value, found := tags[k]; !found || !strings.EqualFold(value, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Tag sets the value of the tag on the asset, replacing the previous value of the key.
+func (s *Store) Tag(asset, key, value, author string) error {
+	asset, key = Key(asset), strings.ToLower(strings.TrimSpace(key))
+	if asset == "" || key == "" {
+		return errors.New("the asset and the tag key must be provided")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("asset = ? AND kind = ? AND key = ?", asset, TagKind, key).Delete(&Annotation{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&Annotation{
+			Asset:     asset,
+			Kind:      TagKind,
+			Key:       key,
+			Value:     strings.TrimSpace(value),
+			Author:    author,
+			CreatedAt: time.Now().UTC(),
+		}).Error
+	})
+}
+
+// Untag removes the tag from the asset, and returns false when the asset did not carry it.
+func (s *Store) Untag(asset, key string) (bool, error) {
+	res := s.db.Where("asset = ? AND kind = ? AND key = ?", Key(asset), TagKind, strings.ToLower(strings.TrimSpace(key))).Delete(&Annotation{})
+	return res.RowsAffected > 0, res.Error
+}
+
+// Note appends the text to the notes of the asset.
+func (s *Store) Note(asset, text, author string) error {
+	asset, text = Key(asset), strings.TrimSpace(text)
+	if asset == "" || text == "" {
+		return errors.New("the asset and the text of the note must be provided")
+	}
+
+	return s.db.Create(&Annotation{
+		Asset:     asset,
+		Kind:      NoteKind,
+		Value:     text,
+		Author:    author,
+		CreatedAt: time.Now().UTC(),
+	}).Error
+}
+
+// Annotations returns the tags of the asset, sorted by key, followed by its notes in the order they were written.
+func (s *Store) Annotations(asset string) ([]*Annotation, error) {
+	var list []*Annotation
+
+	err := s.db.Where("asset = ?", Key(asset)).Order("kind DESC, key, id").Find(&list).Error
+	return list, err
+}
+
+// Tags returns the tags of all the annotated assets, keyed by the asset key.
+func (s *Store) Tags() (map[string]map[string]string, error) {
+	var list []*Annotation
+	if err := s.db.Where("kind = ?", TagKind).Find(&list).Error; err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string]map[string]string)
+	for _, a := range list {
+		if tags[a.Asset] == nil {
+			tags[a.Asset] = make(map[string]string)
+		}
+		tags[a.Asset][a.Key] = a.Value
+	}
+	return tags, nil
+}
+
+// Tagged returns the keys of the assets carrying all of the required tags.
+func (s *Store) Tagged(required map[string]string) ([]string, error) {
+	tags, err := s.Tags()
+	if err != nil {
+		return nil, err
+	}
+
+	var assets []string
+	for asset, t := range tags {
+		if Matches(t, required) {
+			assets = append(assets, asset)
+		}
+	}
+	return assets, nil
+}
@@ -0,0 +1,86 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package annotations
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	s, err := Open("local", filepath.Join(t.TempDir(), "amass.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to open the store: %v", err)
+	}
+	defer s.Close()
+
+	if err := s.Tag("WWW.owasp.org.", "Status", "triaged", "alice"); err != nil {
+		t.Fatalf("Tag returned an error: %v", err)
+	}
+	// The value of the key is replaced
+	_ = s.Tag("www.owasp.org", "status", "confirmed", "bob")
+	_ = s.Tag("www.owasp.org", "owner", "web team", "bob")
+	_ = s.Tag("mail.owasp.org", "status", "Confirmed", "bob")
+	_ = s.Tag("192.0.2.1", "status", "ignored", "bob")
+	_ = s.Note("www.owasp.org", "Login page exposed", "alice")
+	_ = s.Note("www.owasp.org", "Reported to the web team", "bob")
+
+	if err := s.Tag("www.owasp.org", " ", "value", ""); err == nil {
+		t.Error("Tag accepted an empty key")
+	}
+	if err := s.Note("www.owasp.org", "", ""); err == nil {
+		t.Error("Note accepted an empty text")
+	}
+
+	list, err := s.Annotations("www.owasp.org")
+	if err != nil || len(list) != 4 {
+		t.Fatalf("Expected 4 annotations, got %d: %v", len(list), err)
+	}
+	var got []string
+	for _, a := range list {
+		got = append(got, a.Kind+":"+a.Key+"="+a.Value)
+	}
+	expected := []string{"tag:owner=web team", "tag:status=confirmed", "note:=Login page exposed", "note:=Reported to the web team"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected the annotations %v, got %v", expected, got)
+	}
+
+	assets, err := s.Tagged(map[string]string{"status": "confirmed"})
+	sort.Strings(assets)
+	if err != nil || !reflect.DeepEqual(assets, []string{"mail.owasp.org", "www.owasp.org"}) {
+		t.Errorf("Unexpected tagged assets %v: %v", assets, err)
+	}
+
+	if removed, err := s.Untag("www.owasp.org", "owner"); err != nil || !removed {
+		t.Errorf("The tag was not removed: %v", err)
+	}
+	if removed, _ := s.Untag("www.owasp.org", "owner"); removed {
+		t.Error("The missing tag was reported as removed")
+	}
+	if tags, _ := s.Tags(); !reflect.DeepEqual(tags["www.owasp.org"], map[string]string{"status": "confirmed"}) {
+		t.Errorf("Unexpected tags: %v", tags["www.owasp.org"])
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	tags, err := ParseTags([]string{"Status=triaged", "owner = web team", "empty="})
+	if err != nil || !reflect.DeepEqual(tags, map[string]string{"status": "triaged", "owner": "web team", "empty": ""}) {
+		t.Errorf("Unexpected tags %v: %v", tags, err)
+	}
+	for _, bad := range []string{"status", "=value"} {
+		if _, err := ParseTags([]string{bad}); err == nil {
+			t.Errorf("Accepted the tag %s", bad)
+		}
+	}
+
+	if !Matches(map[string]string{"status": "Triaged", "owner": "web"}, map[string]string{"status": "triaged"}) {
+		t.Error("The tags did not match without regard to case")
+	}
+	if Matches(map[string]string{"owner": "web"}, map[string]string{"status": "triaged"}) {
+		t.Error("The tags matched without the required key")
+	}
+}
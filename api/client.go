@@ -0,0 +1,329 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// TokenEnv is the environment variable providing the API token of the remote engine, when it is
+// not provided on the command line.
+const TokenEnv = "AMASS_ENGINE_TOKEN"
+
+// Client submits enumeration sessions to a remote engine serving the API.
+type Client struct {
+	sync.Mutex
+	URL     string
+	Token   string
+	http    *http.Client
+	version *Version
+}
+
+// statusError is the error returned by the engine along with an unsuccessful status.
+type statusError struct {
+	Status  int
+	Message string
+}
+
+func (e *statusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("the engine returned status %d", e.Status)
+	}
+	return fmt.Sprintf("the engine returned status %d: %s", e.Status, e.Message)
+}
+
+// NewClient returns a Client for the engine at the base URL, presenting the token with each request.
+func NewClient(u, token string) *Client {
+	return &Client{
+		URL:   strings.TrimSuffix(u, "/"),
+		Token: token,
+		// The followed logs remain open for the duration of the enumerations
+		http: &http.Client{},
+	}
+}
+
+// Negotiate requests the version of the engine, and returns an error describing the mismatch when the
+// engine does not speak the protocol of the client or does not offer one of the features.
+func (c *Client) Negotiate(ctx context.Context, features ...string) (*Version, error) {
+	v, err := c.engineVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if v.Protocol < ProtocolVersion {
+		return nil, fmt.Errorf("the engine %s is too old: it speaks protocol version %d and the client requires version %d",
+			v.Engine, v.Protocol, ProtocolVersion)
+	}
+	if v.Protocol > ProtocolVersion {
+		return nil, fmt.Errorf("the engine %s speaks protocol version %d, which is newer than version %d of the client",
+			v.Engine, v.Protocol, ProtocolVersion)
+	}
+
+	var missing []string
+	for _, f := range features {
+		if !v.Supports(f) {
+			missing = append(missing, f)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("the engine %s is too old for %s", v.Engine, strings.Join(missing, ", "))
+	}
+	return v, nil
+}
+
+// engineVersion returns the version of the engine, which is only requested once.
+func (c *Client) engineVersion(ctx context.Context) (*Version, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.version != nil {
+		return c.version, nil
+	}
+
+	var v Version
+	if err := c.do(ctx, http.MethodGet, "/version", nil, &v); err != nil {
+		var se *statusError
+		// The engines predating the handshake do not serve the endpoint
+		if errors.As(err, &se) && se.Status == http.StatusNotFound {
+			return nil, fmt.Errorf("the engine is too old: it does not provide its protocol version, and the client requires version %d",
+				ProtocolVersion)
+		}
+		return nil, err
+	}
+	c.version = &v
+	return c.version, nil
+}
+
+// StartSession requests the engine to start the enumeration.
+func (c *Client) StartSession(ctx context.Context, req *SessionRequest) (*Session, error) {
+	features := []string{FeatureSessions}
+	if req.Priority > 0 {
+		features = append(features, FeaturePriority)
+	}
+	if _, err := c.Negotiate(ctx, features...); err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var sess Session
+	if err := c.do(ctx, http.MethodPost, "/sessions", bytes.NewReader(body), &sess); err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+// Session returns the state of the enumeration session.
+func (c *Client) Session(ctx context.Context, id string) (*Session, error) {
+	var sess Session
+	if err := c.do(ctx, http.MethodGet, "/sessions/"+url.PathEscape(id), nil, &sess); err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+// WaitSession waits for the enumeration session to finish, and returns its final state.
+func (c *Client) WaitSession(ctx context.Context, id string) (*Session, error) {
+	for {
+		var sess Session
+		if err := c.do(ctx, http.MethodGet, "/sessions/"+url.PathEscape(id)+"?wait="+maxSessionWait.String(), nil, &sess); err != nil {
+			return nil, err
+		}
+		if sess.State != SessionRunning {
+			return &sess, nil
+		}
+	}
+}
+
+// CancelSession requests the engine to cancel the enumeration, and returns its final state.
+func (c *Client) CancelSession(ctx context.Context, id string) (*Session, error) {
+	var sess Session
+	if err := c.do(ctx, http.MethodDelete, "/sessions/"+url.PathEscape(id), nil, &sess); err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+// PauseSession requests the engine to stop dispatching the new work of the enumeration, and returns its state.
+func (c *Client) PauseSession(ctx context.Context, id string) (*Session, error) {
+	return c.setPaused(ctx, id, "pause")
+}
+
+// ResumeSession requests the engine to continue the paused enumeration, and returns its state.
+func (c *Client) ResumeSession(ctx context.Context, id string) (*Session, error) {
+	return c.setPaused(ctx, id, "resume")
+}
+
+func (c *Client) setPaused(ctx context.Context, id, action string) (*Session, error) {
+	if _, err := c.Negotiate(ctx, FeaturePause); err != nil {
+		return nil, err
+	}
+
+	var sess Session
+	if err := c.do(ctx, http.MethodPost, "/sessions/"+url.PathEscape(id)+"/"+action, nil, &sess); err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+// FollowLog writes the log lines of the session to w until the session finishes.
+func (c *Client) FollowLog(ctx context.Context, id string, w io.Writer) error {
+	return c.Log(ctx, id, nil, true, false, w)
+}
+
+// Log writes the log entries of the session selected by the filter to w, as text lines or JSON lines,
+// and keeps writing the new entries until the session finishes when follow is true.
+func (c *Client) Log(ctx context.Context, id string, filter *LogFilter, follow, asJSON bool, w io.Writer) error {
+	features := []string{FeatureSessions}
+	if filter != nil && (filter.Level != "" || filter.Plugin != "") {
+		features = append(features, FeatureLogFilter)
+	}
+	if _, err := c.Negotiate(ctx, features...); err != nil {
+		return err
+	}
+
+	q := url.Values{}
+	if follow {
+		q.Set("follow", "true")
+	}
+	if asJSON {
+		q.Set("format", "json")
+	}
+	if filter != nil && filter.Level != "" {
+		q.Set("level", filter.Level)
+	}
+	if filter != nil && filter.Plugin != "" {
+		q.Set("plugin", filter.Plugin)
+	}
+
+	path := "/sessions/" + url.PathEscape(id) + "/log"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+
+	resp, err := c.request(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// Names returns the names discovered by the finished session.
+func (c *Client) Names(ctx context.Context, id string) ([]string, error) {
+	var names []string
+
+	for {
+		var page struct {
+			Total   int      `json:"total"`
+			Results []string `json:"results"`
+		}
+
+		path := "/sessions/" + url.PathEscape(id) + "/names?limit=" +
+			strconv.Itoa(MaxLimit) + "&offset=" + strconv.Itoa(len(names))
+		if err := c.do(ctx, http.MethodGet, path, nil, &page); err != nil {
+			return nil, err
+		}
+
+		names = append(names, page.Results...)
+		if len(page.Results) == 0 || len(names) >= page.Total {
+			break
+		}
+	}
+	return names, nil
+}
+
+// LeaseJob leases the next job queued by the engine for the worker, and returns nil when no job is pending.
+func (c *Client) LeaseJob(ctx context.Context, worker string) (*Job, error) {
+	if _, err := c.Negotiate(ctx, FeatureWorkers); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.request(ctx, http.MethodPost, "/jobs/lease?worker="+url.QueryEscape(worker), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	var j Job
+	if err := json.NewDecoder(resp.Body).Decode(&j); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// RenewJob extends the lease of the job. An error is returned once the job is no longer leased by
+// the worker, such as after the session has been canceled.
+func (c *Client) RenewJob(ctx context.Context, id string) error {
+	var v struct{}
+	return c.do(ctx, http.MethodPost, "/jobs/"+url.PathEscape(id)+"/renew", nil, &v)
+}
+
+// CompleteJob reports the result of the job to the engine.
+func (c *Client) CompleteJob(ctx context.Context, id string, result *JobResult) error {
+	body, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	var v struct{}
+	return c.do(ctx, http.MethodPost, "/jobs/"+url.PathEscape(id)+"/complete", bytes.NewReader(body), &v)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader, v interface{}) error {
+	resp, err := c.request(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// request sends the request to the engine, and returns the error provided by the engine
+// when the response does not have a successful status.
+func (c *Client) request(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.URL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	var e errorResponse
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&e)
+	return nil, &statusError{Status: resp.StatusCode, Message: e.Error}
+}
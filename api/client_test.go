@@ -0,0 +1,119 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+)
+
+func TestClient(t *testing.T) {
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	handler := NewServer(g, nil)
+	handler.SetTokens([]*Token{{Name: "ci", Value: "ci-token", Role: Operator}})
+	handler.SetEnumerator(func(ctx context.Context, req *SessionRequest, logger *log.Logger) (*SessionResult, error) {
+		if !strings.Contains(req.Config, "enabled: true") || req.Timeout != "1h0m0s" {
+			t.Errorf("Unexpected session request: %+v", req)
+		}
+		logger.Printf("Enumerating %s", req.Domains[0])
+		return &SessionResult{Names: []string{"www." + req.Domains[0], "mail." + req.Domains[0]}}, nil
+	}, 1)
+	defer handler.Close()
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	ctx := context.Background()
+	if _, err := NewClient(srv.URL, "wrong").StartSession(ctx, &SessionRequest{Domains: []string{"owasp.org"}}); err == nil ||
+		!strings.Contains(err.Error(), "401") {
+		t.Errorf("Expected the engine to reject the token, got %v", err)
+	}
+
+	client := NewClient(srv.URL+"/", "ci-token")
+	sess, err := client.StartSession(ctx, &SessionRequest{
+		Domains: []string{"owasp.org"},
+		Config:  "options:\n  bruteforce:\n    enabled: true\n",
+		Timeout: "1h0m0s",
+	})
+	if err != nil {
+		t.Fatalf("Failed to start the session: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := client.FollowLog(ctx, sess.ID, &buf); err != nil || !strings.Contains(buf.String(), "Enumerating owasp.org") {
+		t.Errorf("Unexpected session log: %q, %v", buf.String(), err)
+	}
+
+	wctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if sess, err = client.WaitSession(wctx, sess.ID); err != nil || sess.State != SessionFinished {
+		t.Fatalf("The session did not finish: %+v, %v", sess, err)
+	}
+
+	names, err := client.Names(ctx, sess.ID)
+	if err != nil || len(names) != 2 || names[0] != "www.owasp.org" {
+		t.Errorf("Unexpected names of the session: %v, %v", names, err)
+	}
+	if _, err := client.Names(ctx, "missing"); err == nil {
+		t.Error("Expected an error for the names of a missing session")
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	handler := NewServer(g, nil)
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	ctx := context.Background()
+	client := NewClient(srv.URL, "")
+	v, err := client.Negotiate(ctx)
+	if err != nil || v.Protocol != ProtocolVersion || v.Engine == "" || len(v.Features) != 0 {
+		t.Errorf("Unexpected version of the engine: %+v, %v", v, err)
+	}
+	if _, err := client.StartSession(ctx, &SessionRequest{Domains: []string{"owasp.org"}}); err == nil ||
+		!strings.Contains(err.Error(), "too old for sessions") {
+		t.Errorf("Expected the client to require the sessions, got %v", err)
+	}
+	if _, err := client.LeaseJob(ctx, "worker"); err == nil || !strings.Contains(err.Error(), "too old for workers") {
+		t.Errorf("Expected the client to require the workers, got %v", err)
+	}
+
+	handler.SetWorkers(1, time.Minute)
+	defer handler.Close()
+	client = NewClient(srv.URL, "")
+	if _, err := client.Negotiate(ctx, FeatureSessions, FeaturePriority, FeatureLogFilter, FeatureWorkers); err != nil {
+		t.Errorf("The engine did not offer the features of the workers: %v", err)
+	}
+	if _, err := client.Negotiate(ctx, FeatureScopeReview); err == nil {
+		t.Error("The engine offered the scope review without a queue")
+	}
+
+	// The engines predating the handshake and those speaking another protocol are rejected
+	for _, h := range []http.HandlerFunc{
+		func(w http.ResponseWriter, r *http.Request) { http.NotFound(w, r) },
+		func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, &Version{Protocol: ProtocolVersion + 1, Engine: "v9.0.0"})
+		},
+	} {
+		other := httptest.NewServer(h)
+		if _, err := NewClient(other.URL, "").Negotiate(ctx); err == nil || !strings.Contains(err.Error(), "protocol version") {
+			t.Errorf("Expected a protocol mismatch, got %v", err)
+		}
+		other.Close()
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// DefaultAddress is the address the API server listens on when none has been configured.
+const DefaultAddress = "127.0.0.1:8080"
+
+// FromConfig returns the listening address and the API keys in the 'api' section of the configuration options.
+func FromConfig(cfg *config.Config) (string, []string, error) {
+	apiRaw, ok := cfg.Options["api"]
+	if !ok {
+		return DefaultAddress, nil, nil
+	}
+
+	settings, ok := apiRaw.(map[string]interface{})
+	if !ok {
+		return "", nil, fmt.Errorf("api is not a map[string]interface{}")
+	}
+
+	addr := DefaultAddress
+	if raw, ok := settings["address"]; ok {
+		str, ok := raw.(string)
+		if !ok {
+			return "", nil, fmt.Errorf("api address is not a string")
+		}
+		addr = str
+	}
+
+	var keys []string
+	if raw, ok := settings["keys"]; ok {
+		list, ok := raw.([]interface{})
+		if !ok {
+			return "", nil, fmt.Errorf("api keys is not a list")
+		}
+
+		for _, k := range list {
+			key, ok := k.(string)
+			if !ok || key == "" {
+				return "", nil, fmt.Errorf("api keys must be non-empty strings")
+			}
+			keys = append(keys, key)
+		}
+	}
+	return addr, keys, nil
+}
+
+// MaxSessions returns the number of enumeration sessions allowed to run at the same time, as set by
+// the 'max_sessions' entry of the 'api' section of the configuration options.
+func MaxSessions(cfg *config.Config) (int, error) {
+	apiRaw, ok := cfg.Options["api"]
+	if !ok {
+		return DefaultMaxSessions, nil
+	}
+
+	settings, ok := apiRaw.(map[string]interface{})
+	if !ok {
+		return 0, fmt.Errorf("api is not a map[string]interface{}")
+	}
+
+	raw, ok := settings["max_sessions"]
+	if !ok {
+		return DefaultMaxSessions, nil
+	}
+
+	max, ok := raw.(int)
+	if !ok || max <= 0 {
+		return 0, fmt.Errorf("api max_sessions must be a positive integer")
+	}
+	return max, nil
+}
+
+// JobShards returns the number of jobs queued for each root domain name of the sessions executed by the
+// workers, as set by the 'job_shards' entry of the 'api' section of the configuration options. Each job
+// of a domain queries its share of the data sources.
+func JobShards(cfg *config.Config) (int, error) {
+	apiRaw, ok := cfg.Options["api"]
+	if !ok {
+		return DefaultJobShards, nil
+	}
+
+	settings, ok := apiRaw.(map[string]interface{})
+	if !ok {
+		return 0, fmt.Errorf("api is not a map[string]interface{}")
+	}
+
+	raw, ok := settings["job_shards"]
+	if !ok {
+		return DefaultJobShards, nil
+	}
+
+	shards, ok := raw.(int)
+	if !ok || shards <= 0 {
+		return 0, fmt.Errorf("api job_shards must be a positive integer")
+	}
+	return shards, nil
+}
+
+// Workers returns whether the sessions are executed by the workers leasing their jobs, as set by the
+// 'workers' entry of the 'api' section of the configuration options, along with the 'lease_time'.
+func Workers(cfg *config.Config) (bool, time.Duration, error) {
+	apiRaw, ok := cfg.Options["api"]
+	if !ok {
+		return false, DefaultLeaseTime, nil
+	}
+
+	settings, ok := apiRaw.(map[string]interface{})
+	if !ok {
+		return false, 0, fmt.Errorf("api is not a map[string]interface{}")
+	}
+
+	var enabled bool
+	if raw, ok := settings["workers"]; ok {
+		if enabled, ok = raw.(bool); !ok {
+			return false, 0, fmt.Errorf("api workers is not a boolean")
+		}
+	}
+
+	lease := DefaultLeaseTime
+	if raw, ok := settings["lease_time"]; ok {
+		str, ok := raw.(string)
+		if !ok {
+			return false, 0, fmt.Errorf("api lease_time is not a string")
+		}
+
+		d, err := time.ParseDuration(str)
+		if err != nil || d < time.Second {
+			return false, 0, fmt.Errorf("api lease_time must be a duration of at least one second")
+		}
+		lease = d
+	}
+	return enabled, lease, nil
+}
+
+// LogSettings are the 'log_dir', 'log_max_size' and 'log_backups' entries of the 'api' section of
+// the configuration options, persisting the logs of the sessions.
+type LogSettings struct {
+	// Dir is the directory holding the session logs, within the output directory by default
+	Dir string
+	// MaxSize is the size in bytes of a session log file before it is rotated
+	MaxSize int64
+	Backups int
+}
+
+// SessionLogs returns the settings persisting the logs of the sessions.
+func SessionLogs(cfg *config.Config) (*LogSettings, error) {
+	ls := &LogSettings{
+		Dir:     filepath.Join(config.OutputDirectory(cfg.Dir), DefaultLogDir),
+		MaxSize: DefaultLogMaxSize,
+		Backups: DefaultLogBackups,
+	}
+
+	apiRaw, ok := cfg.Options["api"]
+	if !ok {
+		return ls, nil
+	}
+
+	settings, ok := apiRaw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("api is not a map[string]interface{}")
+	}
+
+	if raw, ok := settings["log_dir"]; ok {
+		str, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("api log_dir is not a string")
+		}
+		ls.Dir = str
+	}
+	if raw, ok := settings["log_max_size"]; ok {
+		mb, ok := raw.(int)
+		if !ok || mb <= 0 {
+			return nil, fmt.Errorf("api log_max_size must be a positive number of megabytes")
+		}
+		ls.MaxSize = int64(mb) << 20
+	}
+	if raw, ok := settings["log_backups"]; ok {
+		n, ok := raw.(int)
+		if !ok || n < 0 {
+			return nil, fmt.Errorf("api log_backups must be a non-negative integer")
+		}
+		ls.Backups = n
+	}
+	return ls, nil
+}
@@ -0,0 +1,424 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/analysis"
+	"github.com/owasp-amass/amass/v4/annotations"
+	"github.com/owasp-amass/amass/v4/cloud"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/viz"
+	"github.com/owasp-amass/asset-db/types"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+// Subdomain is a name discovered within a domain, along with the addresses it resolved to.
+type Subdomain struct {
+	Name      string            `json:"name"`
+	Addresses []string          `json:"addresses,omitempty"`
+	FirstSeen time.Time         `json:"first_seen"`
+	LastSeen  time.Time         `json:"last_seen"`
+	Tags      map[string]string `json:"tags,omitempty"`
+}
+
+// Address describes the names and the infrastructure associated with an IP address.
+type Address struct {
+	Address   string      `json:"address"`
+	Names     []string    `json:"names"`
+	Netblocks []*Netblock `json:"netblocks"`
+}
+
+// Netblock is an address block, along with the autonomous system announcing it.
+type Netblock struct {
+	CIDR        string `json:"cidr"`
+	ASN         int    `json:"asn,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// GET /domains/{domain}/subdomains?since=&until=&addrs=true&tag=key=value
+func (s *Server) handleDomains(w http.ResponseWriter, r *http.Request) {
+	parts := pathParts(r, "/domains/")
+	if t := tenantFromContext(r.Context()); t != nil && parts[0] != "" && !t.InScope(parts[0]) {
+		writeError(w, http.StatusForbidden, "the domain is not within the scope of the API key")
+		return
+	}
+	if len(parts) == 2 && parts[0] != "" && parts[1] == "export" {
+		s.handleExport(w, r, strings.ToLower(parts[0]))
+		return
+	}
+	if len(parts) == 2 && parts[0] != "" && parts[1] == "cloud" {
+		s.handleCloud(w, r, strings.ToLower(parts[0]))
+		return
+	}
+	if len(parts) != 2 || parts[0] == "" || parts[1] != "subdomains" {
+		writeError(w, http.StatusNotFound, "the resource was not found")
+		return
+	}
+
+	var since, until format.ParseTime
+	q := r.URL.Query()
+	if v := q.Get("since"); v != "" {
+		if err := since.Set(v); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if v := q.Get("until"); v != "" {
+		if err := until.Set(v); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	start, end := time.Time(since), time.Time(until)
+
+	tags, required, err := s.tags(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	d := strings.ToLower(parts[0])
+	assets, err := s.graph.DB.FindByScope([]oam.Asset{domain.FQDN{Name: d}}, start)
+	if err != nil {
+		assets = nil
+	}
+
+	var subs []*Subdomain
+	for _, a := range assets {
+		fqdn, ok := a.Asset.(domain.FQDN)
+		if !ok || (!end.IsZero() && a.CreatedAt.After(end)) {
+			continue
+		}
+
+		t := tags[annotations.Key(fqdn.Name)]
+		if !annotations.Matches(t, required) {
+			continue
+		}
+		subs = append(subs, &Subdomain{
+			Name:      fqdn.Name,
+			FirstSeen: a.CreatedAt,
+			LastSeen:  a.LastSeen,
+			Tags:      t,
+		})
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].Name < subs[j].Name })
+
+	results := make([]interface{}, 0, len(subs))
+	for _, sub := range subs {
+		results = append(results, sub)
+	}
+	if q.Get("addrs") == "true" {
+		s.addAddresses(r, subs, start, end)
+	}
+	writePage(w, r, results)
+}
+
+// GET /domains/{domain}/export?format=&since=&until=&tag=key=value
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request, d string) {
+	q := r.URL.Query()
+	name := q.Get("format")
+	if name == "" {
+		name = "json"
+	}
+
+	enc, err := viz.GetEncoder(name)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var since, until format.ParseTime
+	if v := q.Get("since"); v != "" {
+		if err := since.Set(v); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if v := q.Get("until"); v != "" {
+		if err := until.Set(v); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	tags, required, err := s.tags(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	graph, err := viz.Build(r.Context(), s.graph, []string{d}, time.Time(since), time.Time(until))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	graph.ApplyTags(tags, required)
+
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, graph); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", enc.ContentType())
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// tags returns the tags of the annotated assets and the tags required by the tag query parameters.
+func (s *Server) tags(r *http.Request) (map[string]map[string]string, map[string]string, error) {
+	required, err := annotations.ParseTags(r.URL.Query()["tag"])
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.notes == nil {
+		if len(required) > 0 {
+			return nil, nil, errors.New("the server does not provide the annotations")
+		}
+		return nil, nil, nil
+	}
+
+	tags, err := s.notes.Tags()
+	if err != nil {
+		tags = nil
+	}
+	return tags, required, nil
+}
+
+// GET /domains/{domain}/cloud?provider=&region=&service=&since=
+func (s *Server) handleCloud(w http.ResponseWriter, r *http.Request, d string) {
+	q := r.URL.Query()
+
+	var since format.ParseTime
+	if v := q.Get("since"); v != "" {
+		if err := since.Set(v); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	filter := &cloud.Filter{
+		Provider: q.Get("provider"),
+		Region:   q.Get("region"),
+		Service:  q.Get("service"),
+	}
+
+	var results []interface{}
+	for _, a := range cloud.Assets(r.Context(), s.graph, s.cloud, []string{d}, time.Time(since)) {
+		if filter.Match(&a.Attribution) {
+			results = append(results, a)
+		}
+	}
+	writePage(w, r, results)
+}
+
+// addAddresses fills in the addresses of the subdomains on the requested page.
+func (s *Server) addAddresses(r *http.Request, subs []*Subdomain, start, end time.Time) {
+	offset, limit, ok := pagination(r)
+	if !ok || offset >= len(subs) {
+		return
+	}
+	if offset+limit < len(subs) {
+		subs = subs[offset : offset+limit]
+	} else {
+		subs = subs[offset:]
+	}
+
+	lookup := make(map[string]*Subdomain, len(subs))
+	names := make([]string, 0, len(subs))
+	for _, sub := range subs {
+		lookup[sub.Name] = sub
+		names = append(names, sub.Name)
+	}
+
+	pairs, err := analysis.NamesToAddrs(r.Context(), s.graph, start, end, names...)
+	if err != nil {
+		return
+	}
+	for _, p := range pairs {
+		if sub, found := lookup[p.FQDN.Name]; found {
+			sub.Addresses = append(sub.Addresses, p.Addr.Address.String())
+		}
+	}
+}
+
+// GET /ips/{ip}
+func (s *Server) handleIPs(w http.ResponseWriter, r *http.Request) {
+	parts := pathParts(r, "/ips/")
+	if len(parts) != 1 {
+		writeError(w, http.StatusNotFound, "the resource was not found")
+		return
+	}
+
+	ip, err := netip.ParseAddr(parts[0])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "the IP address is not valid")
+		return
+	}
+
+	t := "IPv4"
+	if ip.Is6() {
+		t = "IPv6"
+	}
+
+	assets, err := s.graph.DB.FindByContent(network.IPAddress{Address: ip, Type: t}, time.Time{})
+	if err != nil || len(assets) == 0 {
+		writeError(w, http.StatusNotFound, "the IP address was not found")
+		return
+	}
+	a := assets[0]
+
+	result := &Address{
+		Address:   ip.String(),
+		Names:     []string{},
+		Netblocks: []*Netblock{},
+	}
+	tenant := tenantFromContext(r.Context())
+	for _, other := range s.related(a, true, "a_record", "aaaa_record") {
+		if fqdn, ok := other.Asset.(domain.FQDN); ok && (tenant == nil || tenant.InScope(fqdn.Name)) {
+			result.Names = append(result.Names, fqdn.Name)
+		}
+	}
+	// The addresses only become visible to a tenant through the names within its domains
+	if tenant != nil && len(result.Names) == 0 {
+		writeError(w, http.StatusNotFound, "the IP address was not found")
+		return
+	}
+	sort.Strings(result.Names)
+
+	for _, other := range s.related(a, true, "contains") {
+		if nb, ok := other.Asset.(network.Netblock); ok {
+			result.Netblocks = append(result.Netblocks, s.netblock(other, nb))
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) netblock(a *types.Asset, nb network.Netblock) *Netblock {
+	result := &Netblock{CIDR: nb.Cidr.String()}
+
+	for _, other := range s.related(a, true, "announces") {
+		if as, ok := other.Asset.(network.AutonomousSystem); ok {
+			result.ASN = as.Number
+
+			for _, org := range s.related(other, false, "managed_by") {
+				if o, ok := org.Asset.(network.RIROrganization); ok {
+					result.Description = o.Name
+					break
+				}
+			}
+			break
+		}
+	}
+	return result
+}
+
+// GET /asns/{asn}/prefixes
+func (s *Server) handleASNs(w http.ResponseWriter, r *http.Request) {
+	if tenantFromContext(r.Context()) != nil {
+		writeError(w, http.StatusForbidden, "the API key does not grant access to the autonomous systems")
+		return
+	}
+
+	parts := pathParts(r, "/asns/")
+	if len(parts) != 2 || parts[1] != "prefixes" {
+		writeError(w, http.StatusNotFound, "the resource was not found")
+		return
+	}
+
+	asn, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(parts[0]), "AS"))
+	if err != nil || asn <= 0 {
+		writeError(w, http.StatusBadRequest, "the ASN is not valid")
+		return
+	}
+
+	assets, err := s.graph.DB.FindByContent(network.AutonomousSystem{Number: asn}, time.Time{})
+	if err != nil || len(assets) == 0 {
+		writeError(w, http.StatusNotFound, "the ASN was not found")
+		return
+	}
+
+	var cidrs []string
+	for _, other := range s.related(assets[0], false, "announces") {
+		if nb, ok := other.Asset.(network.Netblock); ok {
+			cidrs = append(cidrs, nb.Cidr.String())
+		}
+	}
+	sort.Strings(cidrs)
+
+	results := make([]interface{}, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		results = append(results, cidr)
+	}
+	writePage(w, r, results)
+}
+
+// GET /search?q=
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	q := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+	if q == "" {
+		writeError(w, http.StatusBadRequest, "the q parameter must be provided")
+		return
+	}
+
+	assets, err := s.graph.DB.FindByType(oam.FQDN, time.Time{})
+	if err != nil {
+		assets = nil
+	}
+
+	tenant := tenantFromContext(r.Context())
+	var names []string
+	for _, a := range assets {
+		if fqdn, ok := a.Asset.(domain.FQDN); ok && strings.Contains(fqdn.Name, q) &&
+			(tenant == nil || tenant.InScope(fqdn.Name)) {
+			names = append(names, fqdn.Name)
+		}
+	}
+	sort.Strings(names)
+
+	results := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		results = append(results, name)
+	}
+	writePage(w, r, results)
+}
+
+// related returns the assets at the other end of the incoming or outgoing relations.
+func (s *Server) related(a *types.Asset, incoming bool, reltypes ...string) []*types.Asset {
+	var err error
+	var rels []*types.Relation
+
+	if incoming {
+		rels, err = s.graph.DB.IncomingRelations(a, time.Time{}, reltypes...)
+	} else {
+		rels, err = s.graph.DB.OutgoingRelations(a, time.Time{}, reltypes...)
+	}
+	if err != nil {
+		return nil
+	}
+
+	var results []*types.Asset
+	for _, rel := range rels {
+		id := rel.ToAsset.ID
+		if incoming {
+			id = rel.FromAsset.ID
+		}
+
+		if other, err := s.graph.DB.FindById(id, time.Time{}); err == nil {
+			results = append(results, other)
+		}
+	}
+	return results
+}
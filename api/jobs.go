@@ -0,0 +1,376 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+const (
+	// DefaultLeaseTime is the time a worker holds a job without renewing its lease.
+	DefaultLeaseTime = 2 * time.Minute
+	// DefaultJobShards is the number of jobs queued for each root domain name when no split has been configured.
+	DefaultJobShards = 1
+	// The number of times a job is leased before the job fails
+	maxJobAttempts = 3
+	// The largest body accepted by the requests completing jobs
+	maxResultSize = 64 << 20
+)
+
+// Job is the enumeration of a single root domain name, executed by a worker on behalf of a session.
+// The work of a domain is split into several jobs once the engine has been configured with shards,
+// and each job then queries the data sources of its shard.
+type Job struct {
+	ID     string `json:"id"`
+	Domain string `json:"domain"`
+	// Shard selects the data sources queried by the job, when the work of the domain is split into Shards jobs
+	Shard   int    `json:"shard,omitempty"`
+	Shards  int    `json:"shards,omitempty"`
+	Config  string `json:"config,omitempty"`
+	Timeout string `json:"timeout,omitempty"`
+	// Priority is the priority of the session the job was queued for
+	Priority int `json:"priority,omitempty"`
+	// Tenant is the tenant of the session the job was queued for
+	Tenant string `json:"tenant,omitempty"`
+	Worker string `json:"worker,omitempty"`
+	// LeaseSeconds is the time the worker holds the job without renewing its lease
+	LeaseSeconds int `json:"lease_seconds"`
+	Attempts     int `json:"attempts"`
+}
+
+// JobResult is the outcome of a job reported by the worker.
+type JobResult struct {
+	Names  []string                 `json:"names"`
+	Error  string                   `json:"error,omitempty"`
+	Errors []*requests.SourceErrors `json:"errors,omitempty"`
+}
+
+type job struct {
+	Job
+	// owner is the holder of the token used to lease the job
+	owner   string
+	expires time.Time
+	results chan<- *jobOutcome
+	// paused holds the job in the queue while its session is paused
+	paused bool
+}
+
+type jobOutcome struct {
+	job    *job
+	result *JobResult
+}
+
+// jobQueue holds the jobs of the sessions until the workers lease them, and requeues the jobs
+// whose workers stopped renewing the lease.
+type jobQueue struct {
+	sync.Mutex
+	lease   time.Duration
+	shards  int
+	pending []*job
+	leased  map[string]*job
+}
+
+func newJobQueue(lease time.Duration) *jobQueue {
+	if lease <= 0 {
+		lease = DefaultLeaseTime
+	}
+
+	return &jobQueue{
+		lease:  lease,
+		shards: DefaultJobShards,
+		leased: make(map[string]*job),
+	}
+}
+
+// enumerate is the Enumerator of the sessions executed by the workers. The jobs of each root domain
+// name are queued, and the names reported by the workers are deduplicated and kept in scope.
+func (q *jobQueue) enumerate(ctx context.Context, req *SessionRequest, logger *log.Logger) (*SessionResult, error) {
+	q.Lock()
+	shards := q.shards
+	q.Unlock()
+
+	results := make(chan *jobOutcome, len(req.Domains)*shards)
+	var jobs []*job
+	for _, d := range req.Domains {
+		for shard := 0; shard < shards; shard++ {
+			j := &job{
+				Job: Job{
+					ID:       newSessionID(),
+					Domain:   d,
+					Config:   req.Config,
+					Timeout:  req.Timeout,
+					Priority: req.Priority,
+					Tenant:   req.Tenant,
+				},
+				results: results,
+			}
+			if shards > 1 {
+				j.Shard = shard
+				j.Shards = shards
+			}
+			jobs = append(jobs, j)
+		}
+	}
+	q.push(jobs...)
+	defer q.remove(jobs)
+	SetPauser(ctx, &jobPauser{queue: q, jobs: jobs})
+	logger.Printf("Queued %d jobs for the workers", len(jobs))
+
+	scope := &Tenant{Domains: req.Domains}
+	known := make(map[string]struct{})
+	var names []string
+	failed := make(map[string]struct{})
+	var failures [][]*requests.SourceErrors
+	for remaining := len(jobs); remaining > 0; remaining-- {
+		var out *jobOutcome
+
+		select {
+		case <-ctx.Done():
+			return &SessionResult{Names: names, Errors: requests.MergeSourceErrors(failures...)}, nil
+		case out = <-results:
+		}
+
+		if out.result.Error != "" {
+			logger.Printf("The job for %s failed on %s: %s", out.job.String(), out.job.Worker, out.result.Error)
+			failed[out.job.Domain] = struct{}{}
+			continue
+		}
+
+		failures = append(failures, out.result.Errors)
+		var n int
+		for _, name := range out.result.Names {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if _, found := known[name]; found || !scope.InScope(name) {
+				continue
+			}
+			known[name] = struct{}{}
+			names = append(names, name)
+			n++
+		}
+		logger.Printf("The job for %s was completed by %s with %d new names", out.job.String(), out.job.Worker, n)
+	}
+
+	sort.Strings(names)
+	result := &SessionResult{Names: names, Errors: requests.MergeSourceErrors(failures...)}
+	if len(failed) > 0 {
+		var domains []string
+		for d := range failed {
+			domains = append(domains, d)
+		}
+		sort.Strings(domains)
+		return result, fmt.Errorf("the jobs failed for %s", strings.Join(domains, ", "))
+	}
+	return result, nil
+}
+
+// String returns the domain of the job, along with its shard when the work of the domain was split.
+func (j *Job) String() string {
+	if j.Shards <= 1 {
+		return j.Domain
+	}
+	return fmt.Sprintf("%s (shard %d of %d)", j.Domain, j.Shard+1, j.Shards)
+}
+
+func (q *jobQueue) push(jobs ...*job) {
+	q.Lock()
+	defer q.Unlock()
+
+	q.pending = append(q.pending, jobs...)
+}
+
+// remove drops the jobs of a session that is no longer waiting for them.
+func (q *jobQueue) remove(jobs []*job) {
+	q.Lock()
+	defer q.Unlock()
+
+	drop := make(map[*job]struct{}, len(jobs))
+	for _, j := range jobs {
+		drop[j] = struct{}{}
+		delete(q.leased, j.ID)
+	}
+
+	var pending []*job
+	for _, j := range q.pending {
+		if _, found := drop[j]; !found {
+			pending = append(pending, j)
+		}
+	}
+	q.pending = pending
+}
+
+// jobPauser holds the pending jobs of a paused session in the queue, while the workers finish the
+// jobs they already leased.
+type jobPauser struct {
+	queue *jobQueue
+	jobs  []*job
+}
+
+// Pause implements the Pauser interface.
+func (p *jobPauser) Pause() {
+	p.set(true)
+}
+
+// Resume implements the Pauser interface.
+func (p *jobPauser) Resume() {
+	p.set(false)
+}
+
+func (p *jobPauser) set(paused bool) {
+	p.queue.Lock()
+	defer p.queue.Unlock()
+
+	for _, j := range p.jobs {
+		j.paused = paused
+	}
+}
+
+// next leases the oldest pending job that is not paused to the worker, or returns nil when no job is pending.
+func (q *jobQueue) next(owner, worker string) *Job {
+	q.Lock()
+	defer q.Unlock()
+
+	q.expire()
+	idx := -1
+	for i, j := range q.pending {
+		if !j.paused {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return nil
+	}
+
+	j := q.pending[idx]
+	q.pending = append(q.pending[:idx], q.pending[idx+1:]...)
+
+	j.owner = owner
+	j.Worker = worker
+	j.Attempts++
+	j.LeaseSeconds = int(q.lease / time.Second)
+	j.expires = time.Now().Add(q.lease)
+	q.leased[j.ID] = j
+
+	c := j.Job
+	return &c
+}
+
+// expire requeues the jobs whose lease was not renewed, and fails the jobs leased too many times.
+// It must be called while holding the lock.
+func (q *jobQueue) expire() {
+	now := time.Now()
+
+	for id, j := range q.leased {
+		if now.Before(j.expires) {
+			continue
+		}
+
+		delete(q.leased, id)
+		if j.Attempts >= maxJobAttempts {
+			j.results <- &jobOutcome{
+				job:    j,
+				result: &JobResult{Error: fmt.Sprintf("the lease expired %d times", j.Attempts)},
+			}
+			continue
+		}
+		q.pending = append([]*job{j}, q.pending...)
+	}
+}
+
+// renew extends the lease of the job held by the owner.
+func (q *jobQueue) renew(id, owner string) bool {
+	q.Lock()
+	defer q.Unlock()
+
+	q.expire()
+	j, found := q.leased[id]
+	if !found || j.owner != owner {
+		return false
+	}
+
+	j.expires = time.Now().Add(q.lease)
+	return true
+}
+
+// complete reports the result of the job held by the owner to its session.
+func (q *jobQueue) complete(id, owner string, result *JobResult) bool {
+	q.Lock()
+	defer q.Unlock()
+
+	j, found := q.leased[id]
+	if !found || j.owner != owner {
+		return false
+	}
+
+	delete(q.leased, id)
+	j.results <- &jobOutcome{job: j, result: result}
+	return true
+}
+
+// POST /jobs/lease
+// POST /jobs/{id}/renew
+// POST /jobs/{id}/complete
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "only POST requests are supported")
+		return
+	}
+
+	p := principalFromContext(r.Context())
+	if p.role < Operator {
+		writeError(w, http.StatusForbidden, "the operator or admin role is required to execute jobs")
+		return
+	}
+	// The workers execute the jobs of all the tenants
+	if p.tenant != nil {
+		writeError(w, http.StatusForbidden, "the workers must use a key that is not restricted to a tenant")
+		return
+	}
+
+	parts := pathParts(r, "/jobs/")
+	switch {
+	case len(parts) == 1 && parts[0] == "lease":
+		worker := r.URL.Query().Get("worker")
+		if worker == "" {
+			worker = p.name
+		}
+
+		j := s.jobs.next(p.name, worker)
+		if j == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeJSON(w, http.StatusOK, j)
+	case len(parts) == 2 && parts[1] == "renew":
+		if !s.jobs.renew(parts[0], p.name) {
+			writeError(w, http.StatusNotFound, "the job is not leased by the worker")
+			return
+		}
+		writeJSON(w, http.StatusOK, struct{}{})
+	case len(parts) == 2 && parts[1] == "complete":
+		var result JobResult
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxResultSize)).Decode(&result); err != nil {
+			writeError(w, http.StatusBadRequest, "the job result is not valid JSON")
+			return
+		}
+		if !s.jobs.complete(parts[0], p.name, &result) {
+			writeError(w, http.StatusNotFound, "the job is not leased by the worker")
+			return
+		}
+		writeJSON(w, http.StatusOK, struct{}{})
+	default:
+		writeError(w, http.StatusNotFound, "the resource was not found")
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestWorkers(t *testing.T) {
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	handler := NewServer(g, []string{"reader"})
+	handler.SetTokens([]*Token{
+		{Name: "ci", Value: "ci-token", Role: Operator},
+		{Name: "pool-a", Value: "worker-a", Role: Operator},
+		{Name: "pool-b", Value: "worker-b", Role: Operator},
+	})
+	handler.SetWorkers(1, 200*time.Millisecond)
+	defer handler.Close()
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	ctx := context.Background()
+	if _, err := NewClient(srv.URL, "reader").LeaseJob(ctx, "reader"); err == nil {
+		t.Error("Expected the read-only key to be refused the jobs")
+	}
+
+	a := NewClient(srv.URL, "worker-a")
+	b := NewClient(srv.URL, "worker-b")
+	if j, err := a.LeaseJob(ctx, "a"); err != nil || j != nil {
+		t.Errorf("Expected no pending jobs, got %v, %v", j, err)
+	}
+
+	client := NewClient(srv.URL, "ci-token")
+	sess, err := client.StartSession(ctx, &SessionRequest{Domains: []string{"owasp.org", "example.com"}})
+	if err != nil {
+		t.Fatalf("Failed to start the session: %v", err)
+	}
+
+	first, err := a.LeaseJob(ctx, "a")
+	if err != nil || first == nil || first.Domain != "owasp.org" || first.Worker != "a" {
+		t.Fatalf("Unexpected first job: %+v, %v", first, err)
+	}
+	if err := b.CompleteJob(ctx, first.ID, &JobResult{}); err == nil {
+		t.Error("Expected the job to only be completed by the worker holding the lease")
+	}
+	if err := a.CompleteJob(ctx, first.ID, &JobResult{
+		Names:  []string{"www.owasp.org", "WWW.owasp.org", "www.outofscope.net"},
+		Errors: []*requests.SourceErrors{{Source: "Crtsh", Errors: []*requests.ErrorCount{{Category: requests.NetworkError, Count: 1}}}},
+	}); err != nil {
+		t.Errorf("Failed to complete the first job: %v", err)
+	}
+
+	// The job of a worker that stopped renewing the lease is leased to another worker
+	second, err := a.LeaseJob(ctx, "a")
+	if err != nil || second == nil || second.Domain != "example.com" {
+		t.Fatalf("Unexpected second job: %+v, %v", second, err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	if err := a.RenewJob(ctx, second.ID); err == nil {
+		t.Error("Expected the expired lease to not be renewed")
+	}
+
+	again, err := b.LeaseJob(ctx, "b")
+	if err != nil || again == nil || again.ID != second.ID || again.Attempts != 2 {
+		t.Fatalf("Expected the expired job to be leased again, got %+v, %v", again, err)
+	}
+	if err := b.RenewJob(ctx, again.ID); err != nil {
+		t.Errorf("Failed to renew the lease: %v", err)
+	}
+	if err := b.CompleteJob(ctx, again.ID, &JobResult{
+		Names:  []string{"mail.example.com", "www.owasp.org"},
+		Errors: []*requests.SourceErrors{{Source: "Crtsh", Errors: []*requests.ErrorCount{{Category: requests.NetworkError, Count: 2}}}},
+	}); err != nil {
+		t.Errorf("Failed to complete the second job: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for sess.State == SessionRunning && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		if sess, err = client.Session(ctx, sess.ID); err != nil {
+			t.Fatalf("Failed to obtain the session state: %v", err)
+		}
+	}
+
+	names, err := client.Names(ctx, sess.ID)
+	if err != nil || strings.Join(names, ",") != "mail.example.com,www.owasp.org" {
+		t.Errorf("Unexpected names of the session: %v, %v", names, err)
+	}
+	if len(sess.Errors) != 1 || sess.Errors[0].Source != "Crtsh" || sess.Errors[0].Total() != 3 {
+		t.Errorf("Unexpected data source errors of the session: %+v", sess.Errors)
+	}
+}
+
+func TestWorkersConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	if enabled, lease, err := Workers(cfg); err != nil || enabled || lease != DefaultLeaseTime {
+		t.Errorf("Unexpected workers without the api section: %v, %v, %v", enabled, lease, err)
+	}
+
+	cfg.Options["api"] = map[string]interface{}{"workers": true, "lease_time": "5m"}
+	if enabled, lease, err := Workers(cfg); err != nil || !enabled || lease != 5*time.Minute {
+		t.Errorf("Unexpected workers: %v, %v, %v", enabled, lease, err)
+	}
+
+	cfg.Options["api"] = map[string]interface{}{"workers": true, "lease_time": "10ms"}
+	if _, _, err := Workers(cfg); err == nil {
+		t.Error("Expected an error for the lease time under a second")
+	}
+
+	if shards, err := JobShards(cfg); err != nil || shards != DefaultJobShards {
+		t.Errorf("Unexpected job shards without the entry: %d, %v", shards, err)
+	}
+	cfg.Options["api"] = map[string]interface{}{"job_shards": 4}
+	if shards, err := JobShards(cfg); err != nil || shards != 4 {
+		t.Errorf("Unexpected job shards: %d, %v", shards, err)
+	}
+	cfg.Options["api"] = map[string]interface{}{"job_shards": 0}
+	if _, err := JobShards(cfg); err == nil {
+		t.Error("Expected an error for zero job shards")
+	}
+}
+
+func TestJobShards(t *testing.T) {
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	handler := NewServer(g, nil)
+	handler.SetTokens([]*Token{{Name: "pool", Value: "worker", Role: Operator}})
+	if err := handler.SetJobShards(3); err == nil {
+		t.Error("Expected an error for the shards without the workers")
+	}
+	handler.SetWorkers(1, time.Minute)
+	if err := handler.SetJobShards(3); err != nil {
+		t.Fatalf("Failed to set the job shards: %v", err)
+	}
+	defer handler.Close()
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	ctx := context.Background()
+	client := NewClient(srv.URL, "worker")
+	sess, err := client.StartSession(ctx, &SessionRequest{Domains: []string{"owasp.org"}})
+	if err != nil {
+		t.Fatalf("Failed to start the session: %v", err)
+	}
+
+	// The shards of the domain are leased by separate workers and acknowledged one at a time
+	var jobs []*Job
+	for _, worker := range []string{"a", "b", "c"} {
+		j, err := client.LeaseJob(ctx, worker)
+		if err != nil || j == nil || j.Domain != "owasp.org" || j.Shards != 3 || j.Shard != len(jobs) {
+			t.Fatalf("Unexpected job leased by %s: %+v, %v", worker, j, err)
+		}
+		jobs = append(jobs, j)
+	}
+	if j, err := client.LeaseJob(ctx, "d"); err != nil || j != nil {
+		t.Errorf("Expected no pending jobs after the shards were leased, got %+v, %v", j, err)
+	}
+	if jobs[1].String() != "owasp.org (shard 2 of 3)" {
+		t.Errorf("Unexpected description of the job: %s", jobs[1].String())
+	}
+
+	for i, j := range jobs {
+		if err := client.CompleteJob(ctx, j.ID, &JobResult{Names: []string{"www.owasp.org", fmt.Sprintf("shard%d.owasp.org", i)}}); err != nil {
+			t.Fatalf("Failed to complete the shard %d: %v", i, err)
+		}
+	}
+	if sess, err = client.WaitSession(ctx, sess.ID); err != nil || sess.State != SessionFinished {
+		t.Fatalf("The session did not finish once the shards were completed: %+v, %v", sess, err)
+	}
+
+	names, err := client.Names(ctx, sess.ID)
+	if err != nil || strings.Join(names, ",") != "shard0.owasp.org,shard1.owasp.org,shard2.owasp.org,www.owasp.org" {
+		t.Errorf("Unexpected names of the sharded session: %v, %v", names, err)
+	}
+}
+
+func TestPausedJobs(t *testing.T) {
+	q := newJobQueue(time.Minute)
+	paused := []*job{{Job: Job{ID: "a1", Domain: "owasp.org"}}}
+	q.push(paused...)
+	q.push(&job{Job: Job{ID: "b1", Domain: "example.com"}})
+
+	p := &jobPauser{queue: q, jobs: paused}
+	p.Pause()
+	// The jobs of the other sessions continue to be leased
+	if j := q.next("pool", "a"); j == nil || j.ID != "b1" {
+		t.Errorf("Expected the job of the running session, got %+v", j)
+	}
+	if j := q.next("pool", "a"); j != nil {
+		t.Errorf("The job of the paused session was leased: %+v", j)
+	}
+
+	p.Resume()
+	if j := q.next("pool", "a"); j == nil || j.ID != "a1" {
+		t.Errorf("Expected the job of the resumed session, got %+v", j)
+	}
+}
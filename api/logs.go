@@ -0,0 +1,324 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The levels assigned to the log messages of the sessions.
+const (
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+)
+
+var levelNames = []string{LevelDebug, LevelInfo, LevelWarn, LevelError}
+
+const (
+	// DefaultLogMaxSize is the size of a session log file before it is rotated.
+	DefaultLogMaxSize = 10 << 20
+	// DefaultLogBackups is the number of rotated files kept for each session log.
+	DefaultLogBackups = 3
+	// DefaultLogDir is the directory within the output directory holding the session logs.
+	DefaultLogDir = "sessions"
+	// The format of the times shown by the text logs
+	logTimeFormat = "15:04:05.000000"
+)
+
+// LogEntry is a structured message of a session log.
+type LogEntry struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Plugin  string    `json:"plugin,omitempty"`
+	Message string    `json:"message"`
+}
+
+// String returns the entry in the format of the text logs.
+func (e *LogEntry) String() string {
+	return e.Time.Format(logTimeFormat) + " " + e.Message
+}
+
+var (
+	errorMsgRE = regexp.MustCompile(`(?i)\b(failed|failure|error|errors|panic)\b`)
+	warnMsgRE  = regexp.MustCompile(`(?i)\b(warning|exhausted|shed|dropped|rejected|timed out|timeout)\b`)
+)
+
+// newLogEntry returns the entry for the message logged at the time. The enumerations prefix the
+// messages of the data sources and enumeration stages with their name, such as 'Crtsh: ...', which
+// becomes the plugin of the entry, and the level is inferred from the message.
+func newLogEntry(msg string, t time.Time) *LogEntry {
+	e := &LogEntry{
+		Time:    t,
+		Level:   LevelInfo,
+		Message: msg,
+	}
+
+	if prefix, _, found := strings.Cut(msg, ": "); found && len(strings.Fields(prefix)) <= 3 &&
+		!strings.HasPrefix(prefix, "Failed") && !strings.HasPrefix(prefix, "The ") {
+		e.Plugin = prefix
+	}
+
+	switch {
+	case errorMsgRE.MatchString(msg):
+		e.Level = LevelError
+	case warnMsgRE.MatchString(msg):
+		e.Level = LevelWarn
+	}
+	return e
+}
+
+// LogFilter selects the entries of a session log. The empty fields select all the entries.
+type LogFilter struct {
+	// Level is the lowest level of the selected entries
+	Level string
+	// Plugin selects the entries of the data source or enumeration stage, without regard to case
+	Plugin string
+}
+
+// ParseLevel returns the level matching the name, and an error for unknown levels.
+func ParseLevel(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "warning" {
+		name = LevelWarn
+	}
+
+	for _, l := range levelNames {
+		if l == name {
+			return l, nil
+		}
+	}
+	return "", fmt.Errorf("%s is not a valid log level", name)
+}
+
+func levelRank(level string) int {
+	for i, l := range levelNames {
+		if l == level {
+			return i
+		}
+	}
+	return 0
+}
+
+// Matches returns true when the entry is selected by the filter.
+func (f *LogFilter) Matches(e *LogEntry) bool {
+	if f == nil {
+		return true
+	}
+	if f.Level != "" && levelRank(e.Level) < levelRank(f.Level) {
+		return false
+	}
+	return f.Plugin == "" || strings.EqualFold(f.Plugin, e.Plugin)
+}
+
+// logStore persists the session logs as JSON lines in a directory, rotating the file of a
+// session once it grows beyond the size limit.
+type logStore struct {
+	dir     string
+	maxSize int64
+	backups int
+}
+
+func newLogStore(dir string, maxSize int64, backups int) (*logStore, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultLogMaxSize
+	}
+	if backups < 0 {
+		backups = DefaultLogBackups
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create the session log directory: %v", err)
+	}
+
+	return &logStore{
+		dir:     dir,
+		maxSize: maxSize,
+		backups: backups,
+	}, nil
+}
+
+var sessionIDRE = regexp.MustCompile(`^[0-9a-f]{1,64}$`)
+
+func (s *logStore) path(id string) (string, error) {
+	if !sessionIDRE.MatchString(id) {
+		return "", fmt.Errorf("%s is not a valid session ID", id)
+	}
+	return filepath.Join(s.dir, id+".log"), nil
+}
+
+// open returns the file persisting the log of the session, appending to the entries already kept.
+func (s *logStore) open(id string) (*logFile, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &logFile{path: path, maxSize: s.maxSize, backups: s.backups}
+	if err := f.reopen(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// read returns the persisted entries of the session selected by the filter, oldest first.
+func (s *logStore) read(id string, filter *LogFilter) ([]*LogEntry, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var found bool
+	var entries []*LogEntry
+	for i := s.backups; i >= 0; i-- {
+		p := path
+		if i > 0 {
+			p += "." + strconv.Itoa(i)
+		}
+
+		list, err := readLogFile(p, filter)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		found = true
+		entries = append(entries, list...)
+	}
+	if !found {
+		return nil, os.ErrNotExist
+	}
+	return entries, nil
+}
+
+// ReadSessionLog returns the entries of the session log persisted in the directory that are
+// selected by the filter, including the entries of the rotated files, oldest first.
+func ReadSessionLog(dir, id string, backups int, filter *LogFilter) ([]*LogEntry, error) {
+	s := &logStore{dir: dir, backups: backups}
+	return s.read(id, filter)
+}
+
+func readLogFile(path string, filter *LogFilter) ([]*LogEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []*LogEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var e LogEntry
+		// The last line can be partial when the server stopped while writing it
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		if filter.Matches(&e) {
+			entries = append(entries, &e)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// logFile is the persisted log of a single session.
+type logFile struct {
+	sync.Mutex
+	path    string
+	maxSize int64
+	backups int
+	file    *os.File
+	size    int64
+}
+
+func (f *logFile) reopen() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open the session log file: %v", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+func (f *logFile) write(e *LogEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	f.Lock()
+	defer f.Unlock()
+
+	if f.file == nil {
+		return errors.New("the session log file has been closed")
+	}
+	if f.size > 0 && f.size+int64(len(data)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := f.file.Write(data)
+	f.size += int64(n)
+	return err
+}
+
+// rotate shifts the backups of the log, dropping the oldest, and starts a new file. It must
+// be called while holding the lock.
+func (f *logFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+
+	if f.backups == 0 {
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return f.reopen()
+	}
+
+	_ = os.Remove(f.path + "." + strconv.Itoa(f.backups))
+	for i := f.backups - 1; i > 0; i-- {
+		src := f.path + "." + strconv.Itoa(i)
+		if err := os.Rename(src, f.path+"."+strconv.Itoa(i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	if err := os.Rename(f.path, f.path+".1"); err != nil {
+		return err
+	}
+	return f.reopen()
+}
+
+func (f *logFile) close() error {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
@@ -0,0 +1,153 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+)
+
+func TestNewLogEntry(t *testing.T) {
+	tests := []struct {
+		msg    string
+		level  string
+		plugin string
+	}{
+		{"Crtsh: https://crt.sh/?q=owasp.org: failed to obtain the response", LevelError, "Crtsh"},
+		{"DNS cache: 10 queries were answered by the cache", LevelInfo, "DNS cache"},
+		{"Backpressure: 5 requests were shed from the resolver queue", LevelWarn, "Backpressure"},
+		{"Failed to save the DNS wildcard finding: disk full", LevelError, ""},
+		{"The enumeration discovered 3 new names", LevelInfo, ""},
+	}
+
+	for _, test := range tests {
+		e := newLogEntry(test.msg, time.Now())
+		if e.Level != test.level || e.Plugin != test.plugin {
+			t.Errorf("The entry of %q has the level %s and plugin %q, expected %s and %q",
+				test.msg, e.Level, e.Plugin, test.level, test.plugin)
+		}
+	}
+
+	filter := &LogFilter{Level: LevelWarn, Plugin: "crtsh"}
+	if !filter.Matches(newLogEntry(tests[0].msg, time.Now())) || filter.Matches(newLogEntry(tests[2].msg, time.Now())) {
+		t.Error("The filter did not select the entries by level and plugin")
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("ParseLevel did not return an error for an unknown level")
+	}
+}
+
+func TestLogRotation(t *testing.T) {
+	dir := t.TempDir()
+	store, err := newLogStore(dir, 200, 2)
+	if err != nil {
+		t.Fatalf("Failed to create the log store: %v", err)
+	}
+
+	f, err := store.open("abc123")
+	if err != nil {
+		t.Fatalf("Failed to open the session log: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		if err := f.write(newLogEntry(fmt.Sprintf("Message %d", i), time.Now())); err != nil {
+			t.Fatalf("Failed to write the entry: %v", err)
+		}
+	}
+	_ = f.close()
+
+	if _, err := os.Stat(filepath.Join(dir, "abc123.log.3")); err == nil {
+		t.Error("More backups were kept than allowed")
+	}
+
+	entries, err := ReadSessionLog(dir, "abc123", 2, nil)
+	if err != nil {
+		t.Fatalf("Failed to read the session log: %v", err)
+	}
+	if len(entries) == 0 || len(entries) >= 20 || entries[len(entries)-1].Message != "Message 19" {
+		t.Fatalf("Unexpected entries after the rotations: %d", len(entries))
+	}
+	for i := 1; i < len(entries); i++ {
+		if entries[i].Time.Before(entries[i-1].Time) {
+			t.Error("The entries were not read oldest first")
+		}
+	}
+
+	if _, err := ReadSessionLog(dir, "def456", 2, nil); !os.IsNotExist(err) {
+		t.Errorf("Expected a missing log for an unknown session, got %v", err)
+	}
+	if _, err := ReadSessionLog(dir, "../abc123", 2, nil); err == nil {
+		t.Error("A session ID outside of the directory was accepted")
+	}
+}
+
+func TestPersistedSessionLog(t *testing.T) {
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	handler := NewServer(g, nil)
+	handler.SetTokens([]*Token{
+		{Name: "alice", Value: "alice-token", Role: Operator},
+		{Name: "root", Value: "admin-token", Role: Admin},
+	})
+	handler.SetEnumerator(func(ctx context.Context, req *SessionRequest, logger *log.Logger) (*SessionResult, error) {
+		logger.Printf("Enumerating %s", req.Domains[0])
+		logger.Printf("Crtsh: failed to obtain the certificates of %s", req.Domains[0])
+		return nil, nil
+	}, 1)
+	defer handler.Close()
+
+	dir := t.TempDir()
+	if err := handler.SetSessionLogs(dir, 0, 1); err != nil {
+		t.Fatalf("Failed to enable the session logs: %v", err)
+	}
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	ctx := context.Background()
+	client := NewClient(srv.URL, "alice-token")
+	sess, err := client.StartSession(ctx, &SessionRequest{Domains: []string{"owasp.org"}})
+	if err != nil {
+		t.Fatalf("Failed to start the session: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := client.Log(ctx, sess.ID, &LogFilter{Level: LevelError}, true, false, &buf); err != nil {
+		t.Fatalf("Failed to follow the session log: %v", err)
+	}
+	if out := buf.String(); strings.Contains(out, "Enumerating") || !strings.Contains(out, "Crtsh: failed") {
+		t.Errorf("The level filter was not applied to the followed log: %q", out)
+	}
+
+	// The sessions forgotten by the server are read from the persisted log
+	handler.sessions.Lock()
+	delete(handler.sessions.all, sess.ID)
+	handler.sessions.Unlock()
+
+	buf.Reset()
+	if err := client.Log(ctx, sess.ID, nil, false, true, &buf); err == nil {
+		t.Error("The persisted log was provided to the operator")
+	}
+	admin := NewClient(srv.URL, "admin-token")
+	if err := admin.Log(ctx, sess.ID, &LogFilter{Plugin: "crtsh"}, false, true, &buf); err != nil {
+		t.Fatalf("Failed to read the persisted log: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 1 || !strings.Contains(lines[0], `"plugin":"Crtsh"`) {
+		t.Errorf("Unexpected persisted log: %q", buf.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, sess.ID+".log")); err != nil {
+		t.Errorf("The session log was not persisted: %v", err)
+	}
+}
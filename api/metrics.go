@@ -0,0 +1,84 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/owasp-amass/amass/v4/dnscache"
+	"github.com/owasp-amass/amass/v4/throttle"
+)
+
+// GET /metrics
+// The counters are written in the Prometheus text exposition format.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if tenantFromContext(r.Context()) != nil {
+		writeError(w, http.StatusForbidden, "the API key does not grant access to the metrics")
+		return
+	}
+
+	st := dnscache.Totals()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	for _, m := range []struct {
+		name  string
+		help  string
+		value uint64
+	}{
+		{"amass_dns_cache_hits_total", "DNS queries answered by the DNS cache.", st.Hits},
+		{"amass_dns_cache_misses_total", "DNS queries sent to the resolvers after missing the DNS cache.", st.Misses},
+		{"amass_dns_cache_stores_total", "DNS responses saved to the DNS cache.", st.Stores},
+	} {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", m.name, m.help, m.name, m.name, m.value)
+	}
+	writeRateMetrics(w, throttle.Rates())
+}
+
+// writeRateMetrics writes the request rates applied to the data sources, so the operators can identify
+// the sources throttling the enumerations. The rates of the sources that are not limited are +Inf.
+func writeRateMetrics(w io.Writer, rates []throttle.Rate) {
+	if len(rates) == 0 {
+		return
+	}
+
+	for _, m := range []struct {
+		name  string
+		help  string
+		kind  string
+		value func(throttle.Rate) string
+	}{
+		{"amass_source_rate", "Requests per second currently allowed to the data source.", "gauge",
+			func(r throttle.Rate) string { return formatRate(r.Rate) }},
+		{"amass_source_rate_limit", "Requests per second allowed by the rate limit of the data source.", "gauge",
+			func(r throttle.Rate) string { return formatRate(r.Ceiling) }},
+		{"amass_source_throttled", "Whether the data source is throttled below its rate limit.", "gauge",
+			func(r throttle.Rate) string {
+				if r.Throttled {
+					return "1"
+				}
+				return "0"
+			}},
+		{"amass_source_requests_total", "Requests sent to the data source.", "counter",
+			func(r throttle.Rate) string { return strconv.FormatUint(r.Requests, 10) }},
+		{"amass_source_backoffs_total", "Responses with status 429 or a server error lowering the rate of the data source.", "counter",
+			func(r throttle.Rate) string { return strconv.FormatUint(r.Backoffs, 10) }},
+	} {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind)
+		for _, r := range rates {
+			fmt.Fprintf(w, "%s{source=%q} %s\n", m.name, r.Source, m.value(r))
+		}
+	}
+}
+
+func formatRate(rate float64) string {
+	if rate == 0 {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(rate, 'g', -1, 64)
+}
@@ -0,0 +1,155 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// Role is the set of operations granted to an API token.
+type Role int
+
+// The roles that can be assigned to the API tokens, each granting the operations of the previous one.
+const (
+	// ReadOnly grants access to the assets and the status of the sessions
+	ReadOnly Role = iota
+	// Operator also grants the creation of sessions, and the cancellation and logs of its own sessions
+	Operator
+	// Admin grants the cancellation and logs of all the sessions
+	Admin
+)
+
+var roleNames = []string{"read-only", "operator", "admin"}
+
+func (r Role) String() string {
+	if r < ReadOnly || r > Admin {
+		return "unknown"
+	}
+	return roleNames[r]
+}
+
+// ParseRole returns the Role matching the provided name.
+func ParseRole(name string) (Role, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	for i, n := range roleNames {
+		if n == name {
+			return Role(i), nil
+		}
+	}
+	return ReadOnly, fmt.Errorf("%s is not a valid role", name)
+}
+
+// Token is a named API token and the role granted to the requests presenting it.
+type Token struct {
+	Name  string
+	Value string
+	Role  Role
+}
+
+// principal identifies the holder of the API key or token presented by a request.
+type principal struct {
+	name string
+	role Role
+	// tenant restricts the assets available to the request, and is nil for unrestricted access
+	tenant *Tenant
+}
+
+type principalKey struct{}
+
+// canView returns true when the session belongs to the tenant of the principal, or the principal
+// is not restricted to a tenant.
+func (p *principal) canView(s *Session) bool {
+	return p.tenant == nil || p.tenant.Name == s.Tenant
+}
+
+// canControl returns true when the principal can cancel, pause and follow the session. The operators
+// can only control their own sessions, and the admins of a tenant the sessions of the tenant.
+func (p *principal) canControl(s *Session) bool {
+	if !p.canView(s) {
+		return false
+	}
+
+	var tenant string
+	if p.tenant != nil {
+		tenant = p.tenant.Name
+	}
+	// A token sharing the name of a tenant does not own the sessions of the tenant
+	return p.role == Admin || (p.role == Operator && p.name == s.Owner && tenant == s.Tenant)
+}
+
+// principalFromContext returns the holder of the key presented by the request. A read-only
+// principal is returned when the server does not require authentication.
+func principalFromContext(ctx context.Context) *principal {
+	if p, ok := ctx.Value(principalKey{}).(*principal); ok {
+		return p
+	}
+	return &principal{role: ReadOnly}
+}
+
+// tenantFromContext returns the tenant making the request, or nil when the request was made using
+// one of the keys granting access to all the assets.
+func tenantFromContext(ctx context.Context) *Tenant {
+	return principalFromContext(ctx).tenant
+}
+
+// TokensFromConfig returns the tokens in the 'tokens' entry of the 'api' section of the configuration
+// options, keyed by the token name and providing the 'token' value and the 'role' of each of them.
+func TokensFromConfig(cfg *config.Config) ([]*Token, error) {
+	apiRaw, ok := cfg.Options["api"]
+	if !ok {
+		return nil, nil
+	}
+
+	settings, ok := apiRaw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("api is not a map[string]interface{}")
+	}
+
+	tokensRaw, ok := settings["tokens"]
+	if !ok {
+		return nil, nil
+	}
+
+	tokens, ok := tokensRaw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("api tokens is not a map[string]interface{}")
+	}
+
+	var results []*Token
+	for name, raw := range tokens {
+		entry, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("api token %s is not a map[string]interface{}", name)
+		}
+
+		value, ok := entry["token"].(string)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("api token %s must provide a non-empty token", name)
+		}
+
+		role := ReadOnly
+		if raw, ok := entry["role"]; ok {
+			str, ok := raw.(string)
+			if !ok {
+				return nil, fmt.Errorf("api token %s role is not a string", name)
+			}
+
+			var err error
+			if role, err = ParseRole(str); err != nil {
+				return nil, fmt.Errorf("api token %s: %v", name, err)
+			}
+		}
+		results = append(results, &Token{Name: name, Value: value, Role: role})
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results, nil
+}
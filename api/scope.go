@@ -0,0 +1,102 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/scope"
+)
+
+// reviewActions maps the actions of the review endpoint to the states of the candidates.
+var reviewActions = map[string]string{
+	"approve": scope.Approved,
+	"deny":    scope.Denied,
+}
+
+// GET /scope?state=
+func (s *Server) handleScope(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "only GET requests are supported")
+		return
+	}
+
+	state := r.URL.Query().Get("state")
+	if state != "" && state != scope.Pending && state != scope.Approved && state != scope.Denied {
+		writeError(w, http.StatusBadRequest, "the state must be pending, approved or denied")
+		return
+	}
+
+	list, err := s.review.List(state)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	tenant := tenantFromContext(r.Context())
+	results := make([]interface{}, 0, len(list))
+	for _, c := range list {
+		if tenant == nil || tenant.InScope(c.Related) {
+			results = append(results, c)
+		}
+	}
+	writePage(w, r, results)
+}
+
+// POST /scope/{domain}/approve
+// POST /scope/{domain}/deny
+func (s *Server) handleScopeReview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "only POST requests are supported")
+		return
+	}
+
+	p := principalFromContext(r.Context())
+	if p.role < Operator {
+		writeError(w, http.StatusForbidden, "the operator or admin role is required to review the scope")
+		return
+	}
+
+	parts := pathParts(r, "/scope/")
+	if len(parts) != 2 || parts[0] == "" {
+		writeError(w, http.StatusNotFound, "the resource was not found")
+		return
+	}
+	domain := strings.ToLower(strings.TrimSuffix(parts[0], "."))
+	state, found := reviewActions[parts[1]]
+	if !found {
+		writeError(w, http.StatusNotFound, "the resource was not found")
+		return
+	}
+
+	// The tenants only review the candidates related to their domains
+	if p.tenant != nil && !s.relatedToTenant(p.tenant, domain) {
+		writeError(w, http.StatusNotFound, "the candidate was not found")
+		return
+	}
+
+	c, err := s.review.Review(domain, state, p.name)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, c)
+}
+
+// relatedToTenant returns true when the candidate was discovered through a name within the tenant domains.
+func (s *Server) relatedToTenant(tenant *Tenant, domain string) bool {
+	list, err := s.review.List("")
+	if err != nil {
+		return false
+	}
+
+	for _, c := range list {
+		if c.Domain == domain {
+			return tenant.InScope(c.Related)
+		}
+	}
+	return false
+}
@@ -0,0 +1,293 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/amass/v4/annotations"
+	"github.com/owasp-amass/amass/v4/cloud"
+	"github.com/owasp-amass/amass/v4/scope"
+)
+
+const (
+	// DefaultLimit is the number of results in a page when the request does not provide a limit.
+	DefaultLimit = 100
+	// MaxLimit is the largest number of results returned in a single page.
+	MaxLimit = 1000
+)
+
+// Server provides read-only REST endpoints for the assets stored in the graph database, and
+// the endpoints managing the enumeration sessions once an Enumerator has been provided.
+type Server struct {
+	graph    *netmap.Graph
+	cloud    *cloud.Classifier
+	notes    *annotations.Store
+	keys     []string
+	tenants  []*Tenant
+	tokens   []*Token
+	sessions *sessionManager
+	jobs     *jobQueue
+	review   *scope.Queue
+	mux      *http.ServeMux
+}
+
+// Page is the envelope of the paginated results returned by the endpoints.
+type Page struct {
+	Total   int         `json:"total"`
+	Offset  int         `json:"offset"`
+	Limit   int         `json:"limit"`
+	Results interface{} `json:"results"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// NewServer returns a Server for the graph database. When keys are provided, each request must
+// present one of them in the X-API-Key header or as a bearer token. The keys grant the read-only role.
+func NewServer(g *netmap.Graph, keys []string) *Server {
+	s := &Server{
+		graph: g,
+		cloud: cloud.NewClassifier(),
+		keys:  keys,
+		mux:   http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("/domains/", s.handleDomains)
+	s.mux.HandleFunc("/ips/", s.handleIPs)
+	s.mux.HandleFunc("/asns/", s.handleASNs)
+	s.mux.HandleFunc("/search", s.handleSearch)
+	s.mux.HandleFunc("/metrics", s.handleMetrics)
+	s.mux.HandleFunc("/version", s.handleVersion)
+	return s
+}
+
+// SetClassifier replaces the classifier used to attribute the assets to cloud providers, such as
+// with one providing the address ranges published by the providers.
+func (s *Server) SetClassifier(c *cloud.Classifier) {
+	if c != nil {
+		s.cloud = c
+	}
+}
+
+// SetAnnotations provides the tags attached to the assets by the analysts, which are then included
+// in the results and can select them using the tag query parameter.
+func (s *Server) SetAnnotations(store *annotations.Store) {
+	s.notes = store
+}
+
+// SetTenants provides the tenants sharing the server, whose keys only grant access to the assets
+// and the sessions within their domains. The keys provided to NewServer continue to grant access
+// to all the assets.
+func (s *Server) SetTenants(tenants []*Tenant) {
+	s.tenants = tenants
+}
+
+// SetTokens provides the named API tokens, which grant the roles assigned to them.
+func (s *Server) SetTokens(tokens []*Token) {
+	s.tokens = tokens
+}
+
+// SetEnumerator enables the session endpoints, which execute the enumerations requested by the
+// operators using the Enumerator, allowing up to max sessions to run at the same time.
+func (s *Server) SetEnumerator(run Enumerator, max int) {
+	s.sessions = newSessionManager(run, max)
+	s.mux.HandleFunc("/sessions", s.handleSessions)
+	s.mux.HandleFunc("/sessions/", s.handleSession)
+}
+
+// SetSessionLogs persists the logs of the sessions as JSON lines in the directory, so the logs remain
+// available once the sessions are forgotten by the server. The file of a session is rotated once it
+// grows beyond maxSize bytes, keeping the number of backups.
+func (s *Server) SetSessionLogs(dir string, maxSize int64, backups int) error {
+	if s.sessions == nil {
+		return errors.New("the session endpoints have not been enabled")
+	}
+
+	store, err := newLogStore(dir, maxSize, backups)
+	if err != nil {
+		return err
+	}
+
+	s.sessions.Lock()
+	defer s.sessions.Unlock()
+
+	s.sessions.logs = store
+	return nil
+}
+
+// SetWorkers enables the session endpoints, which queue the jobs of each root domain name of the
+// enumerations requested by the operators. The jobs are leased by the workers through the job
+// endpoints, and requeued once a worker has not renewed its lease within the lease time.
+func (s *Server) SetWorkers(max int, lease time.Duration) {
+	s.jobs = newJobQueue(lease)
+	s.SetEnumerator(s.jobs.enumerate, max)
+	s.mux.HandleFunc("/jobs/", s.handleJobs)
+}
+
+// SetJobShards splits the work of each root domain name into the number of jobs, which query their
+// share of the data sources, so the workers execute a single domain together.
+func (s *Server) SetJobShards(shards int) error {
+	if s.jobs == nil {
+		return errors.New("the workers have not been enabled")
+	}
+	if shards <= 0 {
+		shards = DefaultJobShards
+	}
+
+	s.jobs.Lock()
+	defer s.jobs.Unlock()
+
+	s.jobs.shards = shards
+	return nil
+}
+
+// SetScopeReview enables the scope endpoints, which list the root domains discovered by the enumerations
+// and allow the operators to approve or deny them before they are added to the scope.
+func (s *Server) SetScopeReview(q *scope.Queue) {
+	s.review = q
+	s.mux.HandleFunc("/scope", s.handleScope)
+	s.mux.HandleFunc("/scope/", s.handleScopeReview)
+}
+
+// Close cancels the running sessions and waits for their enumerations to finish.
+func (s *Server) Close() {
+	if s.sessions != nil {
+		s.sessions.close()
+	}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	isSession := r.URL.Path == "/sessions" || strings.HasPrefix(r.URL.Path, "/sessions/") ||
+		strings.HasPrefix(r.URL.Path, "/jobs/")
+	isReview := strings.HasPrefix(r.URL.Path, "/scope/")
+	if r.Method != http.MethodGet && !(isSession && (r.Method == http.MethodPost || r.Method == http.MethodDelete)) &&
+		!(isReview && r.Method == http.MethodPost) {
+		writeError(w, http.StatusMethodNotAllowed, "only GET requests are supported")
+		return
+	}
+
+	p, ok := s.authorized(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "a valid API key must be provided")
+		return
+	}
+	if p != nil {
+		r = r.WithContext(context.WithValue(r.Context(), principalKey{}, p))
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// authorized checks the API key of the request, and returns the holder of the key. A nil
+// principal is returned when the server does not require authentication.
+func (s *Server) authorized(r *http.Request) (*principal, bool) {
+	if len(s.keys) == 0 && len(s.tenants) == 0 && len(s.tokens) == 0 {
+		return nil, true
+	}
+
+	key := r.Header.Get("X-API-Key")
+	if auth := r.Header.Get("Authorization"); key == "" && strings.HasPrefix(auth, "Bearer ") {
+		key = strings.TrimPrefix(auth, "Bearer ")
+	}
+	if key == "" {
+		return nil, false
+	}
+
+	match := func(k string) bool {
+		return subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1
+	}
+	for _, t := range s.tokens {
+		if match(t.Value) {
+			return &principal{name: t.Name, role: t.Role}, true
+		}
+	}
+	for _, k := range s.keys {
+		if match(k) {
+			return &principal{name: "key", role: ReadOnly}, true
+		}
+	}
+	for _, t := range s.tenants {
+		for _, k := range t.Keys {
+			if match(k) {
+				return &principal{name: t.Name, role: t.Role, tenant: t}, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// pathParts returns the segments of the request path following the prefix.
+func pathParts(r *http.Request, prefix string) []string {
+	return strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, prefix), "/"), "/")
+}
+
+// pagination returns the offset and limit requested by the query parameters.
+func pagination(r *http.Request) (int, int, bool) {
+	offset, limit := 0, DefaultLimit
+
+	q := r.URL.Query()
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return 0, 0, false
+		}
+		offset = n
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return 0, 0, false
+		}
+		limit = n
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+	return offset, limit, true
+}
+
+// writePage writes the requested page of the sorted results.
+func writePage(w http.ResponseWriter, r *http.Request, results []interface{}) {
+	offset, limit, ok := pagination(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "offset and limit must be non-negative integers")
+		return
+	}
+
+	page := &Page{
+		Total:   len(results),
+		Offset:  offset,
+		Limit:   limit,
+		Results: []interface{}{},
+	}
+	if offset < len(results) {
+		end := offset + limit
+		if end > len(results) {
+			end = len(results)
+		}
+		page.Results = results[offset:end]
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, &errorResponse{Error: msg})
+}
@@ -0,0 +1,435 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/amass/v4/annotations"
+	"github.com/owasp-amass/amass/v4/cloud"
+	"github.com/owasp-amass/amass/v4/scope"
+	"github.com/owasp-amass/amass/v4/throttle"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	_ = g.UpsertA(ctx, "www.owasp.org", "192.0.2.10")
+	_ = g.UpsertA(ctx, "mail.owasp.org", "192.0.2.11")
+	_ = g.UpsertA(ctx, "docs.owasp.org", "192.0.2.10")
+	_ = g.UpsertInfrastructure(ctx, 64496, "EXAMPLE-NET", "192.0.2.10", "192.0.2.0/24")
+
+	srv := httptest.NewServer(NewServer(g, []string{"secret"}))
+	defer srv.Close()
+
+	get := func(path, key string, v interface{}) int {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to request %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+
+		if v != nil {
+			_ = json.NewDecoder(resp.Body).Decode(v)
+		}
+		return resp.StatusCode
+	}
+
+	if code := get("/search?q=owasp", "", nil); code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without an API key, got %d", code)
+	}
+	if code := get("/search?q=owasp", "wrong", nil); code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 with an invalid API key, got %d", code)
+	}
+
+	var page struct {
+		Total   int          `json:"total"`
+		Results []*Subdomain `json:"results"`
+	}
+	if code := get("/domains/owasp.org/subdomains?limit=2&offset=1&addrs=true", "secret", &page); code != http.StatusOK {
+		t.Fatalf("Expected status 200 for the subdomains, got %d", code)
+	}
+	if page.Total != 4 || len(page.Results) != 2 {
+		t.Fatalf("Expected a page of 2 out of 4 names, got %d out of %d", len(page.Results), page.Total)
+	}
+	if r := page.Results[0]; r.Name != "mail.owasp.org" || len(r.Addresses) != 1 || r.Addresses[0] != "192.0.2.11" {
+		t.Errorf("Unexpected subdomain: %+v", r)
+	}
+
+	var addr Address
+	if code := get("/ips/192.0.2.10", "secret", &addr); code != http.StatusOK {
+		t.Fatalf("Expected status 200 for the address, got %d", code)
+	}
+	if len(addr.Names) != 2 || addr.Names[0] != "docs.owasp.org" || addr.Names[1] != "www.owasp.org" {
+		t.Errorf("Unexpected names for the address: %v", addr.Names)
+	}
+	if len(addr.Netblocks) != 1 || addr.Netblocks[0].CIDR != "192.0.2.0/24" || addr.Netblocks[0].ASN != 64496 {
+		t.Errorf("Unexpected netblocks for the address: %+v", addr.Netblocks)
+	}
+
+	var prefixes struct {
+		Total   int      `json:"total"`
+		Results []string `json:"results"`
+	}
+	if code := get("/asns/AS64496/prefixes", "secret", &prefixes); code != http.StatusOK {
+		t.Fatalf("Expected status 200 for the ASN prefixes, got %d", code)
+	}
+	if prefixes.Total != 1 || prefixes.Results[0] != "192.0.2.0/24" {
+		t.Errorf("Unexpected prefixes for the ASN: %v", prefixes.Results)
+	}
+
+	var names struct {
+		Total   int      `json:"total"`
+		Results []string `json:"results"`
+	}
+	if code := get("/search?q=MAIL", "secret", &names); code != http.StatusOK {
+		t.Fatalf("Expected status 200 for the search, got %d", code)
+	}
+	if names.Total != 1 || names.Results[0] != "mail.owasp.org" {
+		t.Errorf("Unexpected search results: %v", names.Results)
+	}
+
+	var export struct {
+		Nodes []map[string]interface{} `json:"nodes"`
+		Edges []map[string]interface{} `json:"edges"`
+	}
+	if code := get("/domains/owasp.org/export?format=json", "secret", &export); code != http.StatusOK {
+		t.Fatalf("Expected status 200 for the export, got %d", code)
+	}
+	if len(export.Nodes) == 0 || len(export.Edges) == 0 {
+		t.Errorf("The export did not provide the graph: %+v", export)
+	}
+	if code := get("/domains/owasp.org/export?format=unknown", "secret", nil); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown export format, got %d", code)
+	}
+
+	if code := get("/ips/not-an-ip", "secret", nil); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid address, got %d", code)
+	}
+	if code := get("/ips/198.51.100.1", "secret", nil); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown address, got %d", code)
+	}
+	if code := get("/search?q=owasp&limit=-1", "secret", nil); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid limit, got %d", code)
+	}
+	if code := get("/metrics", "secret", nil); code != http.StatusOK {
+		t.Errorf("Expected status 200 for the metrics, got %d", code)
+	}
+}
+
+func TestRateMetrics(t *testing.T) {
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	l := throttle.For("RateMetricsSource")
+	l.Configure(time.Second, nil)
+	l.Observe(http.StatusTooManyRequests, nil)
+
+	w := httptest.NewRecorder()
+	NewServer(g, nil).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for the metrics, got %d", w.Code)
+	}
+	for _, line := range []string{
+		`amass_source_rate{source="RateMetricsSource"} 0.5`,
+		`amass_source_rate_limit{source="RateMetricsSource"} 1`,
+		`amass_source_throttled{source="RateMetricsSource"} 1`,
+		`amass_source_backoffs_total{source="RateMetricsSource"} 1`,
+	} {
+		if !strings.Contains(w.Body.String(), line+"\n") {
+			t.Errorf("The metrics did not provide %s", line)
+		}
+	}
+}
+
+func TestTenants(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	_ = g.UpsertA(ctx, "www.owasp.org", "192.0.2.10")
+	_ = g.UpsertA(ctx, "www.example.com", "192.0.2.10")
+	_ = g.UpsertA(ctx, "mail.example.com", "192.0.2.20")
+	_ = g.UpsertInfrastructure(ctx, 64496, "EXAMPLE-NET", "192.0.2.10", "192.0.2.0/24")
+
+	handler := NewServer(g, []string{"admin"})
+	handler.SetTenants([]*Tenant{{Name: "owasp", Keys: []string{"owasp-key"}, Domains: []string{"owasp.org"}}})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	get := func(path, key string, v interface{}) int {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to request %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+
+		if v != nil {
+			_ = json.NewDecoder(resp.Body).Decode(v)
+		}
+		return resp.StatusCode
+	}
+
+	for path, expected := range map[string]int{
+		"/domains/owasp.org/subdomains":     http.StatusOK,
+		"/domains/WWW.OWASP.ORG/subdomains": http.StatusOK,
+		"/domains/example.com/subdomains":   http.StatusForbidden,
+		"/domains/example.com/export":       http.StatusForbidden,
+		"/domains/example.com/cloud":        http.StatusForbidden,
+		"/domains/evilowasp.org/subdomains": http.StatusForbidden,
+		"/asns/AS64496/prefixes":            http.StatusForbidden,
+		"/ips/192.0.2.20":                   http.StatusNotFound,
+	} {
+		if code := get(path, "owasp-key", nil); code != expected {
+			t.Errorf("Expected status %d for the tenant requesting %s, got %d", expected, path, code)
+		}
+	}
+	if code := get("/domains/example.com/subdomains", "admin", nil); code != http.StatusOK {
+		t.Errorf("Expected status 200 for the global key, got %d", code)
+	}
+
+	var addr Address
+	if code := get("/ips/192.0.2.10", "owasp-key", &addr); code != http.StatusOK {
+		t.Fatalf("Expected status 200 for the address, got %d", code)
+	}
+	if len(addr.Names) != 1 || addr.Names[0] != "www.owasp.org" {
+		t.Errorf("The names of the other tenants were returned: %v", addr.Names)
+	}
+
+	var names struct {
+		Total   int      `json:"total"`
+		Results []string `json:"results"`
+	}
+	if code := get("/search?q=www", "owasp-key", &names); code != http.StatusOK {
+		t.Fatalf("Expected status 200 for the search, got %d", code)
+	}
+	if names.Total != 1 || names.Results[0] != "www.owasp.org" {
+		t.Errorf("The search returned the names of the other tenants: %v", names.Results)
+	}
+}
+
+func TestCloudAssets(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	_ = g.UpsertCNAME(ctx, "www.owasp.org", "d111111abcdef8.cloudfront.net")
+	_ = g.UpsertCNAME(ctx, "shop.owasp.org", "shop.owasp.org.edgekey.net")
+	_ = g.UpsertA(ctx, "mail.owasp.org", "192.0.2.11")
+
+	srv := httptest.NewServer(NewServer(g, nil))
+	defer srv.Close()
+
+	var page struct {
+		Total   int            `json:"total"`
+		Results []*cloud.Asset `json:"results"`
+	}
+	resp, err := http.Get(srv.URL + "/domains/owasp.org/cloud?service=cloudfront")
+	if err != nil {
+		t.Fatalf("Failed to request the cloud assets: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for the cloud assets, got %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		t.Fatalf("Failed to decode the cloud assets: %v", err)
+	}
+	if page.Total != 1 || page.Results[0].Name != "www.owasp.org" || page.Results[0].Provider != cloud.AWS {
+		t.Errorf("Unexpected cloud assets: %+v", page.Results)
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	_ = g.UpsertA(ctx, "www.owasp.org", "192.0.2.10")
+	_ = g.UpsertA(ctx, "mail.owasp.org", "192.0.2.11")
+
+	notes, err := annotations.Open("local", filepath.Join(t.TempDir(), "amass.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to open the annotations: %v", err)
+	}
+	defer notes.Close()
+	_ = notes.Tag("www.owasp.org", "status", "confirmed", "alice")
+
+	s := NewServer(g, nil)
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	if w := get("/domains/owasp.org/subdomains?tag=status=confirmed"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without the annotations, got %d", w.Code)
+	}
+
+	s.SetAnnotations(notes)
+	var page struct {
+		Results []*Subdomain `json:"results"`
+	}
+	w := get("/domains/owasp.org/subdomains?tag=status=Confirmed")
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for the tagged subdomains, got %d: %v", w.Code, err)
+	}
+	if len(page.Results) != 1 || page.Results[0].Name != "www.owasp.org" || page.Results[0].Tags["status"] != "confirmed" {
+		t.Errorf("Unexpected tagged subdomains: %+v", page.Results)
+	}
+	if w := get("/domains/owasp.org/subdomains?tag=status"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for the invalid tag, got %d", w.Code)
+	}
+}
+
+func TestScopeReview(t *testing.T) {
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	q, _ := scope.Open(filepath.Join(t.TempDir(), scope.ReviewFile))
+	_, _ = q.Submit("owasp.net", "owasp.org", "WhoisXMLAPI")
+	_, _ = q.Submit("example.net", "example.com", "WhoisXMLAPI")
+
+	s := NewServer(g, []string{"reader"})
+	s.SetTokens([]*Token{{Name: "alice", Value: "alice-token", Role: Operator}})
+	s.SetTenants([]*Tenant{
+		{Name: "owasp", Keys: []string{"owasp-key"}, Domains: []string{"owasp.org"}},
+		{Name: "example", Keys: []string{"example-key"}, Domains: []string{"example.com"}, Role: Operator},
+	})
+	s.SetScopeReview(q)
+	do := func(method, path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		return w
+	}
+
+	var page struct {
+		Total   int                `json:"total"`
+		Results []*scope.Candidate `json:"results"`
+	}
+	w := do(http.MethodGet, "/scope?state=pending", "owasp-key")
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for the pending candidates, got %d: %v", w.Code, err)
+	}
+	if page.Total != 1 || page.Results[0].Domain != "owasp.net" {
+		t.Errorf("The tenant received the candidates of the other tenants: %+v", page.Results)
+	}
+	if w := do(http.MethodGet, "/scope?state=maybe", "reader"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for the unknown state, got %d", w.Code)
+	}
+
+	if w := do(http.MethodPost, "/scope/owasp.net/approve", "reader"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for the read-only key, got %d", w.Code)
+	}
+	// The tenants only review the candidates related to their domains
+	for _, path := range []string{"/scope/owasp.net/approve", "/scope/unknown.net/approve"} {
+		if w := do(http.MethodPost, path, "example-key"); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for the tenant reviewing %s, got %d", path, w.Code)
+		}
+	}
+	if w := do(http.MethodPost, "/scope/example.net/deny", "example-key"); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for the tenant reviewing its candidate, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/scope/owasp.net/ignore", "alice-token"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for the unknown action, got %d", w.Code)
+	}
+
+	var c scope.Candidate
+	w = do(http.MethodPost, "/scope/OWASP.NET/approve", "alice-token")
+	if err := json.NewDecoder(w.Body).Decode(&c); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for the approval, got %d: %v", w.Code, err)
+	}
+	if c.State != scope.Approved || c.Reviewer != "alice" {
+		t.Errorf("Unexpected reviewed candidate: %+v", c)
+	}
+	_ = do(http.MethodPost, "/scope/example.net/deny", "alice-token")
+	if list, _ := q.List(scope.Pending); len(list) != 0 {
+		t.Errorf("The candidates remained pending: %v", list)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	if addr, keys, err := FromConfig(cfg); err != nil || addr != DefaultAddress || len(keys) != 0 {
+		t.Errorf("Unexpected settings without the api section: %s, %v, %v", addr, keys, err)
+	}
+
+	cfg.Options["api"] = map[string]interface{}{
+		"address": ":9090",
+		"keys":    []interface{}{"one", "two"},
+	}
+	if addr, keys, err := FromConfig(cfg); err != nil || addr != ":9090" || len(keys) != 2 {
+		t.Errorf("Unexpected settings from the api section: %s, %v, %v", addr, keys, err)
+	}
+
+	cfg.Options["api"] = map[string]interface{}{"keys": "one"}
+	if _, _, err := FromConfig(cfg); err == nil {
+		t.Error("Expected an error for keys that are not a list")
+	}
+}
+
+func TestTenantsFromConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	if tenants, err := TenantsFromConfig(cfg); err != nil || len(tenants) != 0 {
+		t.Errorf("Unexpected tenants without the api section: %v, %v", tenants, err)
+	}
+
+	cfg.Options["api"] = map[string]interface{}{
+		"tenants": map[string]interface{}{
+			"team-b": map[string]interface{}{
+				"keys":    []interface{}{"b"},
+				"domains": []interface{}{"Example.com."},
+			},
+			"team-a": map[string]interface{}{
+				"keys":    []interface{}{"a1", "a2"},
+				"domains": []interface{}{"owasp.org"},
+				"role":    "operator",
+			},
+		},
+	}
+	tenants, err := TenantsFromConfig(cfg)
+	if err != nil || len(tenants) != 2 {
+		t.Fatalf("Unexpected tenants from the api section: %v, %v", tenants, err)
+	}
+	if tenants[0].Name != "team-a" || len(tenants[0].Keys) != 2 || tenants[1].Domains[0] != "example.com" ||
+		tenants[0].Role != Operator || tenants[1].Role != ReadOnly {
+		t.Errorf("Unexpected tenants: %+v, %+v", tenants[0], tenants[1])
+	}
+	if !tenants[1].InScope("www.EXAMPLE.com") || tenants[1].InScope("notexample.com") {
+		t.Error("The tenant scope was not applied to the subdomains")
+	}
+
+	cfg.Options["api"] = map[string]interface{}{
+		"tenants": map[string]interface{}{
+			"team-a": map[string]interface{}{"keys": []interface{}{"a"}},
+		},
+	}
+	if _, err := TenantsFromConfig(cfg); err == nil {
+		t.Error("Expected an error for a tenant without domains")
+	}
+}
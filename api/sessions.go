@@ -0,0 +1,751 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/settings"
+)
+
+// The states of the enumeration sessions.
+const (
+	SessionRunning  = "running"
+	SessionFinished = "finished"
+	SessionCanceled = "canceled"
+	SessionFailed   = "failed"
+)
+
+const (
+	// DefaultMaxSessions is the number of sessions running at the same time when no limit has been configured.
+	DefaultMaxSessions = 1
+	// The number of finished sessions kept in memory
+	maxSessionHistory = 100
+	// The number of log lines kept for each session
+	maxLogLines = 10000
+	// The largest body accepted by the requests creating sessions
+	maxRequestSize = 1 << 20
+	// The longest time a request waits for the session to finish
+	maxSessionWait = 5 * time.Minute
+)
+
+// SessionRequest is the body of the requests creating an enumeration session.
+type SessionRequest struct {
+	Domains []string `json:"domains"`
+	// Config is the YAML configuration file applied to the enumeration, limited to the scope and the
+	// options accepted by settings.CheckSession
+	Config string `json:"config,omitempty"`
+	// Timeout bounds the enumeration, such as 30m
+	Timeout string `json:"timeout,omitempty"`
+	// Priority weights the share of the data source requests granted to the session while other sessions
+	// are running, overriding the priority set by the configuration
+	Priority int `json:"priority,omitempty"`
+	// Tenant is the tenant requesting the session, which is set by the server
+	Tenant string `json:"-"`
+	// Shard selects the data sources queried by the enumeration of a job, when the work of the domain
+	// was split into Shards jobs, and is set by the workers
+	Shard  int `json:"-"`
+	Shards int `json:"-"`
+}
+
+// SessionResult is the outcome of the enumeration executed for a session.
+type SessionResult struct {
+	// Names are the names discovered by the enumeration
+	Names []string
+	// Errors are the failures of the data sources by category
+	Errors []*requests.SourceErrors
+	// Completion is the reason the enumeration came to an end, such as idle once it drained the work it discovered
+	Completion string
+}
+
+// Enumerator executes the enumeration requested for a session, writing its log messages to
+// the logger, and returns the names discovered by the enumeration.
+type Enumerator func(ctx context.Context, req *SessionRequest, logger *log.Logger) (*SessionResult, error)
+
+// Session describes an enumeration executed by the server.
+type Session struct {
+	ID    string `json:"id"`
+	Owner string `json:"owner"`
+	// Tenant is the tenant that created the session, and is empty for the unrestricted keys and tokens
+	Tenant   string     `json:"tenant,omitempty"`
+	Domains  []string   `json:"domains"`
+	Priority int        `json:"priority,omitempty"`
+	State    string     `json:"state"`
+	Started  time.Time  `json:"started"`
+	Finished *time.Time `json:"finished,omitempty"`
+	// NewNames is the number of names discovered by the enumeration
+	NewNames int    `json:"new_names"`
+	Error    string `json:"error,omitempty"`
+	// Errors are the failures of the data sources by category, so a rejected API key
+	// can be told apart from an unreachable service
+	Errors []*requests.SourceErrors `json:"errors,omitempty"`
+	// Completion is the reason the enumeration came to an end, such as idle once it drained the work it discovered
+	Completion string `json:"completion,omitempty"`
+	// Paused is true while the running session does not dispatch new work
+	Paused bool `json:"paused,omitempty"`
+}
+
+// Pauser is implemented by the enumerations that stop dispatching new work while paused, allowing
+// the work in progress to finish and keeping the remaining work queued until they are resumed.
+type Pauser interface {
+	Pause()
+	Resume()
+}
+
+type pauseKey struct{}
+
+// pauseControl pauses the enumeration of a session, including one that has not been provided yet.
+type pauseControl struct {
+	sync.Mutex
+	target Pauser
+	paused bool
+}
+
+// SetPauser provides the enumeration executing the session of the context, which is then paused
+// and resumed through the session endpoints. Enumerators call it once the enumeration has been created.
+func SetPauser(ctx context.Context, p Pauser) {
+	c, ok := ctx.Value(pauseKey{}).(*pauseControl)
+	if !ok {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.target = p
+	if c.paused {
+		p.Pause()
+	}
+}
+
+func (c *pauseControl) set(paused bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.paused = paused
+	if c.target == nil {
+		return
+	}
+	if paused {
+		c.target.Pause()
+	} else {
+		c.target.Resume()
+	}
+}
+
+type session struct {
+	Session
+	cancel context.CancelFunc
+	pause  *pauseControl
+	log    *sessionLog
+	done   chan struct{}
+	// names holds the names discovered by the enumeration once the session has finished
+	names []string
+}
+
+// sessionManager runs the enumerations requested through the API, holding the sessions in memory.
+type sessionManager struct {
+	sync.Mutex
+	run  Enumerator
+	max  int
+	all  map[string]*session
+	wg   sync.WaitGroup
+	stop bool
+	// logs persists the session logs when it has been provided
+	logs *logStore
+}
+
+func newSessionManager(run Enumerator, max int) *sessionManager {
+	if max <= 0 {
+		max = DefaultMaxSessions
+	}
+
+	return &sessionManager{
+		run: run,
+		max: max,
+		all: make(map[string]*session),
+	}
+}
+
+var errSessionLimit = errors.New("the maximum number of sessions are already running")
+
+func (m *sessionManager) start(owner string, req *SessionRequest, timeout time.Duration) (*Session, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.stop {
+		return nil, errors.New("the server is shutting down")
+	}
+	var running int
+	for _, s := range m.all {
+		if s.State == SessionRunning {
+			running++
+		}
+	}
+	if running >= m.max {
+		return nil, errSessionLimit
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if timeout > 0 {
+		cancel()
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	}
+
+	pause := new(pauseControl)
+	ctx = context.WithValue(ctx, pauseKey{}, pause)
+
+	id := newSessionID()
+	var file *logFile
+	if m.logs != nil {
+		var err error
+		if file, err = m.logs.open(id); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
+	s := &session{
+		Session: Session{
+			ID:       id,
+			Owner:    owner,
+			Tenant:   req.Tenant,
+			Domains:  req.Domains,
+			Priority: req.Priority,
+			State:    SessionRunning,
+			Started:  time.Now().UTC(),
+		},
+		cancel: cancel,
+		pause:  pause,
+		log:    newSessionLog(file),
+		done:   make(chan struct{}),
+	}
+	m.all[s.ID] = s
+	m.prune()
+
+	m.wg.Add(1)
+	go m.execute(ctx, s, req)
+	return m.snapshot(s), nil
+}
+
+func (m *sessionManager) execute(ctx context.Context, s *session, req *SessionRequest) {
+	defer m.wg.Done()
+	defer close(s.done)
+	defer s.cancel()
+
+	// The entries of the session log are timestamped as they are written
+	logger := log.New(s.log, "", 0)
+	result, err := m.run(ctx, req, logger)
+	if result == nil {
+		result = new(SessionResult)
+	}
+	if err != nil {
+		logger.Printf("The enumeration failed: %v", err)
+	}
+	s.log.close()
+
+	m.Lock()
+	defer m.Unlock()
+
+	finished := time.Now().UTC()
+	s.Finished = &finished
+	s.NewNames = len(result.Names)
+	s.names = result.Names
+	s.Errors = result.Errors
+	s.Completion = result.Completion
+	s.Paused = false
+	switch {
+	case err != nil:
+		s.State = SessionFailed
+		s.Error = err.Error()
+	case ctx.Err() == context.Canceled:
+		s.State = SessionCanceled
+	default:
+		s.State = SessionFinished
+	}
+}
+
+// prune removes the oldest finished sessions beyond the history limit.
+func (m *sessionManager) prune() {
+	var finished []*session
+	for _, s := range m.all {
+		if s.State != SessionRunning {
+			finished = append(finished, s)
+		}
+	}
+	if len(finished) <= maxSessionHistory {
+		return
+	}
+
+	sort.Slice(finished, func(i, j int) bool { return finished[i].Started.Before(finished[j].Started) })
+	for _, s := range finished[:len(finished)-maxSessionHistory] {
+		delete(m.all, s.ID)
+	}
+}
+
+func (m *sessionManager) get(id string) (*session, *Session) {
+	m.Lock()
+	defer m.Unlock()
+
+	s, found := m.all[id]
+	if !found {
+		return nil, nil
+	}
+	return s, m.snapshot(s)
+}
+
+// setPaused pauses or resumes the running session, and returns false once the session has finished.
+func (m *sessionManager) setPaused(s *session, paused bool) bool {
+	m.Lock()
+	defer m.Unlock()
+
+	if s.State != SessionRunning {
+		return false
+	}
+
+	s.Paused = paused
+	s.pause.set(paused)
+	return true
+}
+
+// names returns the names discovered by the session, and must be called after the session has finished.
+func (m *sessionManager) names(s *session) []string {
+	m.Lock()
+	defer m.Unlock()
+
+	return append([]string(nil), s.names...)
+}
+
+func (m *sessionManager) list() []*Session {
+	m.Lock()
+	defer m.Unlock()
+
+	results := make([]*Session, 0, len(m.all))
+	for _, s := range m.all {
+		results = append(results, m.snapshot(s))
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Started.Before(results[j].Started) })
+	return results
+}
+
+// snapshot returns a copy of the session description, and must be called while holding the lock.
+func (m *sessionManager) snapshot(s *session) *Session {
+	c := s.Session
+	return &c
+}
+
+// close cancels the running sessions and waits for the enumerations to finish.
+func (m *sessionManager) close() {
+	m.Lock()
+	m.stop = true
+	for _, s := range m.all {
+		s.cancel()
+	}
+	m.Unlock()
+
+	m.wg.Wait()
+}
+
+// waitSession waits up to the duration for the session to finish, and returns its state.
+func (s *Server) waitSession(ctx context.Context, sess *session, id string, d time.Duration) *Session {
+	if d > maxSessionWait {
+		d = maxSessionWait
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-sess.done:
+	case <-t.C:
+	case <-ctx.Done():
+	}
+
+	_, desc := s.sessions.get(id)
+	return desc
+}
+
+func newSessionID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// sessionLog holds the most recent log entries of a session, persists them when a file has
+// been provided, and notifies the clients following them.
+type sessionLog struct {
+	sync.Mutex
+	entries []*LogEntry
+	base    int
+	partial []byte
+	changed chan struct{}
+	closed  bool
+	file    *logFile
+}
+
+func newSessionLog(file *logFile) *sessionLog {
+	return &sessionLog{
+		changed: make(chan struct{}),
+		file:    file,
+	}
+}
+
+// Write implements the io.Writer interface.
+func (l *sessionLog) Write(p []byte) (int, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	l.partial = append(l.partial, p...)
+	for {
+		idx := bytes.IndexByte(l.partial, '\n')
+		if idx == -1 {
+			break
+		}
+		l.add(string(l.partial[:idx]))
+		l.partial = l.partial[idx+1:]
+	}
+	if n := len(l.entries) - maxLogLines; n > 0 {
+		l.entries = l.entries[n:]
+		l.base += n
+	}
+
+	l.notify()
+	return len(p), nil
+}
+
+func (l *sessionLog) close() {
+	l.Lock()
+	defer l.Unlock()
+
+	if len(l.partial) > 0 {
+		l.add(string(l.partial))
+		l.partial = nil
+	}
+	if l.file != nil {
+		_ = l.file.close()
+	}
+	l.closed = true
+	l.notify()
+}
+
+// add appends the entry of the message, and must be called while holding the lock.
+func (l *sessionLog) add(msg string) {
+	e := newLogEntry(msg, time.Now().UTC())
+
+	l.entries = append(l.entries, e)
+	// A failure to persist the entry does not interrupt the enumeration
+	if l.file != nil {
+		_ = l.file.write(e)
+	}
+}
+
+// notify wakes the clients following the log, and must be called while holding the lock.
+func (l *sessionLog) notify() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// next returns the entries following the position, the position after them, the channel closed by
+// the next write, and whether the log has been closed.
+func (l *sessionLog) next(pos int) ([]*LogEntry, int, chan struct{}, bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	if pos < l.base {
+		pos = l.base
+	}
+	entries := append([]*LogEntry(nil), l.entries[pos-l.base:]...)
+	return entries, l.base + len(l.entries), l.changed, l.closed
+}
+
+// GET /sessions
+// POST /sessions
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		// The tenants only see their own sessions
+		p := principalFromContext(r.Context())
+		results := make([]interface{}, 0)
+		for _, sess := range s.sessions.list() {
+			if p.canView(sess) {
+				results = append(results, sess)
+			}
+		}
+		writePage(w, r, results)
+	case http.MethodPost:
+		s.createSession(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "only GET and POST requests are supported")
+	}
+}
+
+func (s *Server) createSession(w http.ResponseWriter, r *http.Request) {
+	p := principalFromContext(r.Context())
+	if p.role < Operator {
+		writeError(w, http.StatusForbidden, "the operator or admin role is required to create sessions")
+		return
+	}
+
+	var req SessionRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "the session request is not valid JSON")
+		return
+	}
+
+	var domains []string
+	for _, d := range req.Domains {
+		if d = strings.ToLower(strings.TrimSpace(d)); d != "" {
+			domains = append(domains, d)
+		}
+	}
+	if len(domains) == 0 {
+		writeError(w, http.StatusBadRequest, "the session request must provide the domains")
+		return
+	}
+	req.Domains = domains
+
+	if p.tenant != nil {
+		for _, d := range domains {
+			if !p.tenant.InScope(d) {
+				writeError(w, http.StatusForbidden, d+" is not within the domains of the tenant")
+				return
+			}
+		}
+		req.Tenant = p.tenant.Name
+	}
+
+	if err := settings.CheckSession(req.Config); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Priority < 0 {
+		writeError(w, http.StatusBadRequest, "the priority must be a positive integer")
+		return
+	}
+
+	var timeout time.Duration
+	if req.Timeout != "" {
+		d, err := time.ParseDuration(req.Timeout)
+		if err != nil || d <= 0 {
+			writeError(w, http.StatusBadRequest, "the timeout is not a valid duration")
+			return
+		}
+		timeout = d
+	}
+
+	sess, err := s.sessions.start(p.name, &req, timeout)
+	if err == errSessionLimit {
+		writeError(w, http.StatusTooManyRequests, err.Error())
+		return
+	} else if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, sess)
+}
+
+// GET /sessions/{id}?wait=1m
+// DELETE /sessions/{id}
+// GET /sessions/{id}/log?follow=true&level=warn&plugin=Crtsh&format=json
+// GET /sessions/{id}/names
+// POST /sessions/{id}/pause
+// POST /sessions/{id}/resume
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request) {
+	parts := pathParts(r, "/sessions/")
+	if len(parts) > 2 || parts[0] == "" || (len(parts) == 2 && parts[1] != "log" && parts[1] != "names" &&
+		parts[1] != "pause" && parts[1] != "resume") {
+		writeError(w, http.StatusNotFound, "the resource was not found")
+		return
+	}
+
+	p := principalFromContext(r.Context())
+	sess, desc := s.sessions.get(parts[0])
+	if sess == nil && len(parts) == 2 && parts[1] == "log" && r.Method == http.MethodGet {
+		// The persisted logs of the sessions forgotten by the server remain available to the admins
+		s.persistedLog(w, r, p, parts[0])
+		return
+	} else if sess == nil || !p.canView(desc) {
+		// The sessions of the other tenants are not disclosed
+		writeError(w, http.StatusNotFound, "the session was not found")
+		return
+	}
+
+	permitted := p.canControl(desc)
+	switch {
+	case len(parts) == 2 && (parts[1] == "pause" || parts[1] == "resume") && r.Method == http.MethodPost:
+		if !permitted {
+			writeError(w, http.StatusForbidden, "the session can only be paused by its owner and the admins")
+			return
+		}
+		if !s.sessions.setPaused(sess, parts[1] == "pause") {
+			writeError(w, http.StatusConflict, "the session has finished")
+			return
+		}
+		_, desc = s.sessions.get(desc.ID)
+		writeJSON(w, http.StatusOK, desc)
+	case len(parts) == 2 && parts[1] == "names" && r.Method == http.MethodGet:
+		if !permitted {
+			writeError(w, http.StatusForbidden, "the names are only available to the owner of the session and the admins")
+			return
+		}
+		if desc.State == SessionRunning {
+			writeError(w, http.StatusConflict, "the session has not finished")
+			return
+		}
+		results := make([]interface{}, 0)
+		for _, name := range s.sessions.names(sess) {
+			results = append(results, name)
+		}
+		writePage(w, r, results)
+	case len(parts) == 2 && parts[1] == "log" && r.Method == http.MethodGet:
+		if !permitted {
+			writeError(w, http.StatusForbidden, "the logs are only available to the owner of the session and the admins")
+			return
+		}
+		filter, ok := logFilter(w, r)
+		if !ok {
+			return
+		}
+		streamLog(w, r, sess.log, filter)
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		if wait := r.URL.Query().Get("wait"); wait != "" {
+			d, err := time.ParseDuration(wait)
+			if err != nil || d <= 0 {
+				writeError(w, http.StatusBadRequest, "the wait is not a valid duration")
+				return
+			}
+			// The request returns as soon as the session finishes
+			desc = s.waitSession(r.Context(), sess, desc.ID, d)
+		}
+		writeJSON(w, http.StatusOK, desc)
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		if !permitted {
+			writeError(w, http.StatusForbidden, "the session can only be canceled by its owner and the admins")
+			return
+		}
+		sess.cancel()
+		<-sess.done
+		_, desc = s.sessions.get(desc.ID)
+		writeJSON(w, http.StatusOK, desc)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "the method is not supported by the resource")
+	}
+}
+
+// persistedLog writes the entries persisted for a session that is no longer held in memory.
+func (s *Server) persistedLog(w http.ResponseWriter, r *http.Request, p *principal, id string) {
+	// The persisted logs do not record the tenant of the session
+	if s.sessions.logs == nil || p.tenant != nil {
+		writeError(w, http.StatusNotFound, "the session was not found")
+		return
+	}
+	if p.role != Admin {
+		writeError(w, http.StatusForbidden, "the logs of the finished sessions are only available to the admins")
+		return
+	}
+
+	filter, ok := logFilter(w, r)
+	if !ok {
+		return
+	}
+	entries, err := s.sessions.logs.read(id, filter)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "the session was not found")
+		return
+	}
+
+	asJSON := r.URL.Query().Get("format") == "json"
+	writeLogHeader(w, asJSON)
+	for _, e := range entries {
+		if !writeLogEntry(w, e, asJSON) {
+			return
+		}
+	}
+}
+
+// logFilter returns the filter provided by the level and plugin query parameters.
+func logFilter(w http.ResponseWriter, r *http.Request) (*LogFilter, bool) {
+	q := r.URL.Query()
+	filter := &LogFilter{Plugin: q.Get("plugin")}
+
+	if v := q.Get("level"); v != "" {
+		level, err := ParseLevel(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "the level must be debug, info, warn or error")
+			return nil, false
+		}
+		filter.Level = level
+	}
+	return filter, true
+}
+
+func writeLogHeader(w http.ResponseWriter, asJSON bool) {
+	if asJSON {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// writeLogEntry writes the entry as a text line or a JSON line, and returns false once the client is gone.
+func writeLogEntry(w http.ResponseWriter, e *LogEntry, asJSON bool) bool {
+	line := []byte(e.String() + "\n")
+	if asJSON {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return true
+		}
+		line = append(data, '\n')
+	}
+
+	_, err := w.Write(line)
+	return err == nil
+}
+
+// streamLog writes the log entries of the session selected by the filter, and keeps writing the
+// new entries until the session finishes when the client requests to follow the log.
+func streamLog(w http.ResponseWriter, r *http.Request, l *sessionLog, filter *LogFilter) {
+	follow := r.URL.Query().Get("follow") == "true"
+	asJSON := r.URL.Query().Get("format") == "json"
+	flusher, _ := w.(http.Flusher)
+
+	writeLogHeader(w, asJSON)
+	var pos int
+	for {
+		entries, next, changed, closed := l.next(pos)
+		for _, e := range entries {
+			if filter.Matches(e) && !writeLogEntry(w, e, asJSON) {
+				return
+			}
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		pos = next
+
+		if !follow || closed {
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-changed:
+		}
+	}
+}
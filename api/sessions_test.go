@@ -0,0 +1,401 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestSessions(t *testing.T) {
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	release := make(chan struct{})
+	handler := NewServer(g, []string{"reader"})
+	handler.SetTokens([]*Token{
+		{Name: "alice", Value: "alice-token", Role: Operator},
+		{Name: "bob", Value: "bob-token", Role: Operator},
+		{Name: "root", Value: "admin-token", Role: Admin},
+	})
+	handler.SetTenants([]*Tenant{{Name: "owasp", Keys: []string{"tenant-key"}, Domains: []string{"owasp.org"}}})
+	handler.SetEnumerator(func(ctx context.Context, req *SessionRequest, logger *log.Logger) (*SessionResult, error) {
+		logger.Printf("Enumerating %s", strings.Join(req.Domains, ","))
+		// The other sessions run until they are canceled
+		if req.Domains[0] != "owasp.org" {
+			<-ctx.Done()
+			return nil, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-release:
+		}
+		logger.Print("Discovered www." + req.Domains[0])
+		return &SessionResult{
+			Names: []string{"www." + req.Domains[0]},
+			Errors: []*requests.SourceErrors{{
+				Source: "SecurityTrails",
+				Errors: []*requests.ErrorCount{{Category: requests.AuthError, Count: 2}},
+			}},
+			Completion: "idle",
+		}, nil
+	}, 1)
+	defer handler.Close()
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	do := func(method, path, key, body string, v interface{}) int {
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		req.Header.Set("X-API-Key", key)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to request %s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+
+		if buf, ok := v.(*bytes.Buffer); ok {
+			_, _ = io.Copy(buf, resp.Body)
+		} else if v != nil {
+			_ = json.NewDecoder(resp.Body).Decode(v)
+		}
+		return resp.StatusCode
+	}
+
+	create := `{"domains": ["OWASP.org"]}`
+	for key, expected := range map[string]int{
+		"reader":     http.StatusForbidden,
+		"tenant-key": http.StatusForbidden,
+		"wrong":      http.StatusUnauthorized,
+	} {
+		if code := do(http.MethodPost, "/sessions", key, create, nil); code != expected {
+			t.Errorf("Expected status %d when creating a session using %s, got %d", expected, key, code)
+		}
+	}
+	if code := do(http.MethodPost, "/sessions", "alice-token", `{"domains": []}`, nil); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a session without domains, got %d", code)
+	}
+	// The settings reading files on the server are refused
+	replay := `{"domains": ["owasp.org"], "config": "options:\n  replay:\n    mode: record\n    file: /tmp/amass.jsonl\n"}`
+	if code := do(http.MethodPost, "/sessions", "alice-token", replay, nil); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a session recording a replay file, got %d", code)
+	}
+	if code := do(http.MethodPost, "/domains/owasp.org/subdomains", "admin-token", "", nil); code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405 for a POST to the assets, got %d", code)
+	}
+
+	var sess Session
+	if code := do(http.MethodPost, "/sessions", "alice-token", create, &sess); code != http.StatusCreated {
+		t.Fatalf("Expected status 201 for the new session, got %d", code)
+	}
+	if sess.ID == "" || sess.Owner != "alice" || sess.State != SessionRunning || sess.Domains[0] != "owasp.org" {
+		t.Errorf("Unexpected session: %+v", sess)
+	}
+	if code := do(http.MethodPost, "/sessions", "bob-token", create, nil); code != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429 over the session limit, got %d", code)
+	}
+
+	path := "/sessions/" + sess.ID
+	var status Session
+	if code := do(http.MethodGet, path, "reader", "", &status); code != http.StatusOK || status.ID != sess.ID {
+		t.Errorf("Expected the read-only key to provide the session status, got %d", code)
+	}
+	for _, key := range []string{"reader", "bob-token"} {
+		if code := do(http.MethodGet, path+"/log", key, "", nil); code != http.StatusForbidden {
+			t.Errorf("Expected status 403 for the log requested using %s, got %d", key, code)
+		}
+		if code := do(http.MethodDelete, path, key, "", nil); code != http.StatusForbidden {
+			t.Errorf("Expected status 403 for the cancellation requested using %s, got %d", key, code)
+		}
+	}
+
+	// The followed log ends once the enumeration finishes
+	logs := make(chan string, 1)
+	go func() {
+		var buf bytes.Buffer
+		_ = do(http.MethodGet, path+"/log?follow=true", "admin-token", "", &buf)
+		logs <- buf.String()
+	}()
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+
+	select {
+	case out := <-logs:
+		if !strings.Contains(out, "Enumerating owasp.org") || !strings.Contains(out, "Discovered www.owasp.org") {
+			t.Errorf("Unexpected session log: %q", out)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("The followed log did not end with the session")
+	}
+
+	if code := do(http.MethodGet, path+"?wait=soon", "alice-token", "", nil); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid wait, got %d", code)
+	}
+	// The request waiting for the session returns once it has finished
+	if code := do(http.MethodGet, path+"?wait=5s", "alice-token", "", &status); code != http.StatusOK {
+		t.Errorf("Expected status 200 while waiting for the session, got %d", code)
+	}
+	if status.State != SessionFinished || status.NewNames != 1 || status.Finished == nil || status.Completion != "idle" {
+		t.Errorf("Unexpected status of the finished session: %+v", status)
+	}
+	if len(status.Errors) != 1 || status.Errors[0].Source != "SecurityTrails" || status.Errors[0].Errors[0].Category != requests.AuthError {
+		t.Errorf("Unexpected data source errors of the session: %+v", status.Errors)
+	}
+
+	// The session of another operator can be canceled by the admins
+	var other Session
+	if code := do(http.MethodPost, "/sessions", "bob-token", `{"domains": ["example.com"], "priority": -1}`, nil); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a negative priority, got %d", code)
+	}
+	if code := do(http.MethodPost, "/sessions", "bob-token", `{"domains": ["example.com"], "timeout": "1h", "priority": 5}`, &other); code != http.StatusCreated {
+		t.Fatalf("Expected status 201 for the second session, got %d", code)
+	}
+	if other.Priority != 5 {
+		t.Errorf("The priority of the session was not recorded: %+v", other)
+	}
+	if code := do(http.MethodDelete, "/sessions/"+other.ID, "admin-token", "", &other); code != http.StatusOK || other.State != SessionCanceled {
+		t.Errorf("Expected the admin to cancel the session, got %d and %+v", code, other)
+	}
+
+	var page struct {
+		Total   int        `json:"total"`
+		Results []*Session `json:"results"`
+	}
+	if code := do(http.MethodGet, "/sessions", "reader", "", &page); code != http.StatusOK || page.Total != 2 {
+		t.Errorf("Expected the two sessions to be listed, got %d and %d", code, page.Total)
+	}
+}
+
+func TestTokensFromConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	if tokens, err := TokensFromConfig(cfg); err != nil || len(tokens) != 0 {
+		t.Errorf("Unexpected tokens without the api section: %v, %v", tokens, err)
+	}
+	if max, err := MaxSessions(cfg); err != nil || max != DefaultMaxSessions {
+		t.Errorf("Unexpected session limit without the api section: %d, %v", max, err)
+	}
+
+	cfg.Options["api"] = map[string]interface{}{
+		"max_sessions": 3,
+		"tokens": map[string]interface{}{
+			"ci":     map[string]interface{}{"token": "one", "role": "Operator"},
+			"viewer": map[string]interface{}{"token": "two"},
+		},
+	}
+	tokens, err := TokensFromConfig(cfg)
+	if err != nil || len(tokens) != 2 || tokens[0].Role != Operator || tokens[1].Role != ReadOnly {
+		t.Errorf("Unexpected tokens: %v, %v", tokens, err)
+	}
+	if max, err := MaxSessions(cfg); err != nil || max != 3 {
+		t.Errorf("Unexpected session limit: %d, %v", max, err)
+	}
+
+	for _, entry := range []map[string]interface{}{
+		{"role": "admin"},
+		{"token": "one", "role": "superuser"},
+	} {
+		cfg.Options["api"] = map[string]interface{}{"tokens": map[string]interface{}{"bad": entry}}
+		if _, err := TokensFromConfig(cfg); err == nil {
+			t.Errorf("Expected an error for the token %v", entry)
+		}
+	}
+}
+
+type testPauser struct {
+	sync.Mutex
+	paused bool
+}
+
+func (p *testPauser) Pause() {
+	p.Lock()
+	defer p.Unlock()
+	p.paused = true
+}
+
+func (p *testPauser) Resume() {
+	p.Lock()
+	defer p.Unlock()
+	p.paused = false
+}
+
+func (p *testPauser) isPaused() bool {
+	p.Lock()
+	defer p.Unlock()
+	return p.paused
+}
+
+func TestPauseSession(t *testing.T) {
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	pauser := new(testPauser)
+	handler := NewServer(g, nil)
+	handler.SetTokens([]*Token{
+		{Name: "alice", Value: "alice-token", Role: Operator},
+		{Name: "bob", Value: "bob-token", Role: Operator},
+	})
+	handler.SetEnumerator(func(ctx context.Context, req *SessionRequest, logger *log.Logger) (*SessionResult, error) {
+		SetPauser(ctx, pauser)
+		<-ctx.Done()
+		return nil, nil
+	}, 1)
+	defer handler.Close()
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	ctx := context.Background()
+	alice := NewClient(srv.URL, "alice-token")
+	sess, err := alice.StartSession(ctx, &SessionRequest{Domains: []string{"owasp.org"}})
+	if err != nil {
+		t.Fatalf("Failed to start the session: %v", err)
+	}
+
+	if _, err := NewClient(srv.URL, "bob-token").PauseSession(ctx, sess.ID); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Expected another operator to be denied the pause, got %v", err)
+	}
+	if sess, err = alice.PauseSession(ctx, sess.ID); err != nil || !sess.Paused {
+		t.Errorf("The session was not paused: %+v, %v", sess, err)
+	}
+	// The enumeration can be provided after the session was paused
+	deadline := time.Now().Add(5 * time.Second)
+	for !pauser.isPaused() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !pauser.isPaused() {
+		t.Error("The enumeration was not paused")
+	}
+	if sess, err = alice.ResumeSession(ctx, sess.ID); err != nil || sess.Paused || pauser.isPaused() {
+		t.Errorf("The session was not resumed: %+v, %v", sess, err)
+	}
+
+	if _, err := alice.CancelSession(ctx, sess.ID); err != nil {
+		t.Fatalf("Failed to cancel the session: %v", err)
+	}
+	if _, err := alice.PauseSession(ctx, sess.ID); err == nil || !strings.Contains(err.Error(), "409") {
+		t.Errorf("Expected the finished session to reject the pause, got %v", err)
+	}
+}
+
+func TestTenantSessions(t *testing.T) {
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	handler := NewServer(g, nil)
+	handler.SetTokens([]*Token{
+		{Name: "root", Value: "admin-token", Role: Admin},
+		// The token sharing the name of a tenant does not own its sessions
+		{Name: "team-a", Value: "team-a-token", Role: Operator},
+	})
+	handler.SetTenants([]*Tenant{
+		{Name: "team-a", Keys: []string{"a-key"}, Domains: []string{"owasp.org"}, Role: Operator},
+		{Name: "team-b", Keys: []string{"b-key"}, Domains: []string{"example.com"}, Role: Admin},
+	})
+	handler.SetWorkers(3, time.Minute)
+	defer handler.Close()
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	ctx := context.Background()
+	a := NewClient(srv.URL, "a-key")
+	b := NewClient(srv.URL, "b-key")
+	if _, err := a.StartSession(ctx, &SessionRequest{Domains: []string{"example.com"}}); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Expected the tenant to be denied a session outside its domains, got %v", err)
+	}
+
+	sessA, err := a.StartSession(ctx, &SessionRequest{Domains: []string{"www.owasp.org"}})
+	if err != nil || sessA.Tenant != "team-a" || sessA.Owner != "team-a" {
+		t.Fatalf("Failed to start the session of the first tenant: %+v, %v", sessA, err)
+	}
+	sessB, err := b.StartSession(ctx, &SessionRequest{Domains: []string{"example.com"}})
+	if err != nil || sessB.Tenant != "team-b" {
+		t.Fatalf("Failed to start the session of the second tenant: %+v, %v", sessB, err)
+	}
+
+	list := func(key string) []string {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/sessions", nil)
+		req.Header.Set("X-API-Key", key)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to list the sessions: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var page struct {
+			Results []*Session `json:"results"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&page)
+
+		var ids []string
+		for _, s := range page.Results {
+			ids = append(ids, s.ID)
+		}
+		return ids
+	}
+	if ids := list("a-key"); len(ids) != 1 || ids[0] != sessA.ID {
+		t.Errorf("The first tenant listed the sessions %v", ids)
+	}
+	if ids := list("b-key"); len(ids) != 1 || ids[0] != sessB.ID {
+		t.Errorf("The second tenant listed the sessions %v", ids)
+	}
+	if ids := list("admin-token"); len(ids) != 2 {
+		t.Errorf("The admin listed the sessions %v", ids)
+	}
+
+	// The sessions of the other tenant are not found, even by the admins of a tenant
+	if _, err := b.Session(ctx, sessA.ID); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected the session of the other tenant to be hidden, got %v", err)
+	}
+	if _, err := b.CancelSession(ctx, sessA.ID); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected the cancellation of the other tenant's session to fail, got %v", err)
+	}
+	if _, err := b.PauseSession(ctx, sessA.ID); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected the pause of the other tenant's session to fail, got %v", err)
+	}
+	if err := b.Log(ctx, sessA.ID, nil, false, false, io.Discard); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected the log of the other tenant's session to be hidden, got %v", err)
+	}
+	if _, err := NewClient(srv.URL, "team-a-token").PauseSession(ctx, sessA.ID); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Expected the token named after the tenant to be denied the pause, got %v", err)
+	}
+	if _, err := a.LeaseJob(ctx, "worker"); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Expected the tenant to be denied the jobs, got %v", err)
+	}
+
+	// The jobs of the tenants are executed by the workers using the unrestricted tokens
+	job, err := NewClient(srv.URL, "admin-token").LeaseJob(ctx, "worker")
+	if err != nil || job == nil || job.Tenant != "team-a" {
+		t.Fatalf("Unexpected job of the first tenant: %+v, %v", job, err)
+	}
+	if err := NewClient(srv.URL, "admin-token").CompleteJob(ctx, job.ID, &JobResult{Names: []string{"www.owasp.org"}}); err != nil {
+		t.Fatalf("Failed to complete the job: %v", err)
+	}
+	if _, err := a.WaitSession(ctx, sessA.ID); err != nil {
+		t.Fatalf("Failed to wait for the session of the first tenant: %v", err)
+	}
+	if names, err := a.Names(ctx, sessA.ID); err != nil || len(names) != 1 {
+		t.Errorf("Unexpected names of the tenant session: %v, %v", names, err)
+	}
+	if _, err := b.Names(ctx, sessA.ID); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected the names of the other tenant's session to be hidden, got %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// Tenant is a user of a shared API server, whose keys only grant access to the assets and the sessions
+// within its domains.
+type Tenant struct {
+	Name    string
+	Keys    []string
+	Domains []string
+	// Role is granted to the keys of the tenant, and only applies to the sessions of the tenant
+	Role Role
+}
+
+// InScope returns true when the name is one of the tenant domains or a subdomain of them.
+func (t *Tenant) InScope(name string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+
+	for _, d := range t.Domains {
+		if name == d || strings.HasSuffix(name, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// TenantsFromConfig returns the tenants in the 'tenants' entry of the 'api' section of the configuration
+// options, keyed by the tenant name and providing the 'keys', 'domains' and optional 'role' of each tenant.
+func TenantsFromConfig(cfg *config.Config) ([]*Tenant, error) {
+	apiRaw, ok := cfg.Options["api"]
+	if !ok {
+		return nil, nil
+	}
+
+	settings, ok := apiRaw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("api is not a map[string]interface{}")
+	}
+
+	tenantsRaw, ok := settings["tenants"]
+	if !ok {
+		return nil, nil
+	}
+
+	tenants, ok := tenantsRaw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("api tenants is not a map[string]interface{}")
+	}
+
+	var results []*Tenant
+	for name, raw := range tenants {
+		entry, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("api tenant %s is not a map[string]interface{}", name)
+		}
+
+		t := &Tenant{Name: name}
+		var err error
+		if t.Keys, err = stringList(entry, "keys"); err != nil || len(t.Keys) == 0 {
+			return nil, fmt.Errorf("api tenant %s must provide a list of keys", name)
+		}
+		if t.Domains, err = stringList(entry, "domains"); err != nil || len(t.Domains) == 0 {
+			return nil, fmt.Errorf("api tenant %s must provide a list of domains", name)
+		}
+		for i, d := range t.Domains {
+			t.Domains[i] = strings.ToLower(strings.TrimSuffix(d, "."))
+		}
+		if raw, ok := entry["role"]; ok {
+			str, ok := raw.(string)
+			if !ok {
+				return nil, fmt.Errorf("api tenant %s role is not a string", name)
+			}
+			if t.Role, err = ParseRole(str); err != nil {
+				return nil, fmt.Errorf("api tenant %s: %v", name, err)
+			}
+		}
+		results = append(results, t)
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results, nil
+}
+
+func stringList(settings map[string]interface{}, key string) ([]string, error) {
+	list, ok := settings[key].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s is not a list", key)
+	}
+
+	var results []string
+	for _, v := range list {
+		str, ok := v.(string)
+		if !ok || str == "" {
+			return nil, fmt.Errorf("%s must be non-empty strings", key)
+		}
+		results = append(results, str)
+	}
+	return results, nil
+}
@@ -0,0 +1,73 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/owasp-amass/amass/v4/format"
+)
+
+// ProtocolVersion is the version of the protocol spoken by the clients and the engine. It is incremented
+// whenever a change to the endpoints breaks the clients of the previous version, while the additions are
+// announced as features.
+const ProtocolVersion = 1
+
+// The features offered by the engine, which the clients require before using them.
+const (
+	// FeatureSessions is offered once the engine executes enumeration sessions
+	FeatureSessions = "sessions"
+	// FeaturePriority is offered once the sessions accept the priority field
+	FeaturePriority = "session_priority"
+	// FeaturePause is offered once the sessions can be paused and resumed
+	FeaturePause = "session_pause"
+	// FeatureLogFilter is offered once the session logs accept the level and plugin filters
+	FeatureLogFilter = "log_filter"
+	// FeatureWorkers is offered once the engine queues the jobs of the sessions for the workers
+	FeatureWorkers = "workers"
+	// FeatureScopeReview is offered once the engine queues the discovered root domains for review
+	FeatureScopeReview = "scope_review"
+)
+
+// Version describes the protocol and the features offered by the engine.
+type Version struct {
+	Protocol int      `json:"protocol"`
+	Engine   string   `json:"engine"`
+	Features []string `json:"features"`
+}
+
+// Supports returns true when the engine offers the feature.
+func (v *Version) Supports(feature string) bool {
+	for _, f := range v.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// GET /version
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.version())
+}
+
+// version returns the features offered by the endpoints enabled on the server.
+func (s *Server) version() *Version {
+	v := &Version{
+		Protocol: ProtocolVersion,
+		Engine:   format.Version,
+		Features: []string{},
+	}
+	if s.sessions != nil {
+		v.Features = append(v.Features, FeatureSessions, FeaturePriority, FeaturePause, FeatureLogFilter)
+	}
+	if s.jobs != nil {
+		v.Features = append(v.Features, FeatureWorkers)
+	}
+	if s.review != nil {
+		v.Features = append(v.Features, FeatureScopeReview)
+	}
+	return v
+}
@@ -0,0 +1,178 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package brute records the position reached within the brute forcing wordlist for each zone,
+// so an interrupted enumeration is resumed where it left off instead of querying the names
+// generated from the start of the wordlist again.
+package brute
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResumeFile is the file of the output directory holding the positions reached within the wordlist.
+const ResumeFile = "brute_resume.json"
+
+// SaveInterval is the shortest time between the writes of the resume file while brute forcing.
+const SaveInterval = 5 * time.Second
+
+// Position is the progress of the brute forcing for a zone.
+type Position struct {
+	// Wordlist identifies the wordlist, so the position is dropped once the wordlist changes
+	Wordlist string    `json:"wordlist"`
+	Offset   int       `json:"offset"`
+	Complete bool      `json:"complete,omitempty"`
+	Updated  time.Time `json:"updated"`
+	// sent is the number of words sent for the zone, which are recorded at the next checkpoint
+	sent int
+}
+
+// Resume holds the brute forcing positions of the zones and writes them to the resume file.
+// All methods are safe to call on a nil Resume, which does not record the positions.
+type Resume struct {
+	sync.Mutex
+	path  string
+	zones map[string]*Position
+	saved time.Time
+	dirty bool
+}
+
+// LoadResume returns the positions recorded in the resume file of the output directory.
+// A missing or unreadable file results in the brute forcing starting from the beginning.
+func LoadResume(dir string) *Resume {
+	r := &Resume{
+		path:  filepath.Join(dir, ResumeFile),
+		zones: make(map[string]*Position),
+	}
+
+	if data, err := os.ReadFile(r.path); err == nil {
+		_ = json.Unmarshal(data, &r.zones)
+	}
+	for zone, pos := range r.zones {
+		if pos == nil {
+			delete(r.zones, zone)
+			continue
+		}
+		pos.sent = pos.Offset
+	}
+	return r
+}
+
+// ClearResume removes the resume file from the output directory, once the enumeration has completed.
+func ClearResume(dir string) error {
+	if err := os.Remove(filepath.Join(dir, ResumeFile)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// WordlistID returns the identifier of the wordlist recorded with the positions.
+func WordlistID(words []string) string {
+	h := sha256.New()
+	for _, w := range words {
+		_, _ = h.Write([]byte(w))
+		_, _ = h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// Start returns the offset within the wordlist where the brute forcing of the zone begins,
+// which is the length of the wordlist when the zone has already been completed.
+func (r *Resume) Start(zone, wordlist string, total int) int {
+	if r == nil {
+		return 0
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	zone = strings.ToLower(zone)
+	pos, found := r.zones[zone]
+	if !found || pos.Wordlist != wordlist || pos.Offset > total {
+		pos = &Position{Wordlist: wordlist, Updated: time.Now().UTC()}
+		r.zones[zone] = pos
+	}
+	if pos.Complete {
+		return total
+	}
+
+	pos.sent = pos.Offset
+	return pos.Offset
+}
+
+// Advance records that n more words of the wordlist were sent for the zone. The recorded offset trails
+// the words sent by one checkpoint, since the names sent last can still be waiting to be resolved when
+// the enumeration is interrupted. The resume file is written when the zone is completed, and otherwise
+// at most once per SaveInterval.
+func (r *Resume) Advance(zone string, n, total int) {
+	if r == nil {
+		return
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	pos, found := r.zones[strings.ToLower(zone)]
+	if !found || pos.Complete {
+		return
+	}
+
+	pos.Offset = pos.sent
+	pos.sent += n
+	if pos.sent >= total {
+		pos.Offset = total
+		pos.Complete = true
+	}
+	pos.Updated = time.Now().UTC()
+	r.dirty = true
+
+	if pos.Complete || time.Since(r.saved) >= SaveInterval {
+		_ = r.save()
+	}
+}
+
+// Save writes the positions to the resume file when they have changed since the last write.
+func (r *Resume) Save() error {
+	if r == nil {
+		return nil
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	return r.save()
+}
+
+func (r *Resume) save() error {
+	if !r.dirty {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(r.zones, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	// The file is replaced atomically, so an interruption does not leave it truncated
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		return err
+	}
+
+	r.saved = time.Now()
+	r.dirty = false
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package brute
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResume(t *testing.T) {
+	dir := t.TempDir()
+	words := []string{"www", "mail", "ftp", "dev", "api", "vpn"}
+	id := WordlistID(words)
+
+	r := LoadResume(dir)
+	if start := r.Start("owasp.org", id, len(words)); start != 0 {
+		t.Fatalf("The new zone started at %d", start)
+	}
+	r.Advance("owasp.org", 2, len(words))
+	r.Advance("OWASP.org", 2, len(words))
+	if err := r.Save(); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+
+	// The recorded offset trails the words sent by one checkpoint
+	r = LoadResume(dir)
+	if start := r.Start("owasp.org", id, len(words)); start != 2 {
+		t.Errorf("The interrupted zone was resumed at %d", start)
+	}
+	if start := r.Start("owasp.org", WordlistID(words[1:]), len(words)-1); start != 0 {
+		t.Errorf("The zone was resumed at %d with another wordlist", start)
+	}
+
+	r.Start("example.com", id, len(words))
+	r.Advance("example.com", len(words), len(words))
+	if start := LoadResume(dir).Start("example.com", id, len(words)); start != len(words) {
+		t.Errorf("The completed zone started at %d", start)
+	}
+
+	if err := ClearResume(dir); err != nil {
+		t.Errorf("ClearResume returned an error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ResumeFile)); !os.IsNotExist(err) {
+		t.Error("The resume file was not removed")
+	}
+	if err := ClearResume(dir); err != nil {
+		t.Errorf("ClearResume returned an error for the missing file: %v", err)
+	}
+
+	var nilResume *Resume
+	if start := nilResume.Start("owasp.org", id, len(words)); start != 0 {
+		t.Errorf("The nil Resume started at %d", start)
+	}
+	nilResume.Advance("owasp.org", 2, len(words))
+	if err := nilResume.Save(); err != nil {
+		t.Errorf("The nil Resume returned an error: %v", err)
+	}
+}
+
+func TestLoadResumeCorrupted(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ResumeFile), []byte("{\"owasp.org\": "), 0644); err != nil {
+		t.Fatalf("Failed to write the resume file: %v", err)
+	}
+
+	if start := LoadResume(dir).Start("owasp.org", WordlistID([]string{"www"}), 1); start != 0 {
+		t.Errorf("The zone started at %d using a corrupted file", start)
+	}
+}
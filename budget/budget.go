@@ -0,0 +1,181 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package budget
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limits caps the resources consumed by an enumeration. Zero values are unlimited.
+type Limits struct {
+	DNSQueries   int64
+	HTTPRequests int64
+	Runtime      time.Duration
+}
+
+// Usage is the amount of resources consumed by a single source.
+type Usage struct {
+	Source       string
+	DNSQueries   int64
+	HTTPRequests int64
+}
+
+// Budget tracks the resources consumed by the sources of an enumeration.
+// All methods are safe to call on a nil Budget, which enforces no limits.
+type Budget struct {
+	sync.Mutex
+	limits  Limits
+	usage   map[string]*Usage
+	dns     int64
+	http    int64
+	reason  string
+	done    chan struct{}
+	doneSet bool
+}
+
+// NewBudget returns a Budget enforcing the provided limits.
+func NewBudget(limits Limits) *Budget {
+	return &Budget{
+		limits: limits,
+		usage:  make(map[string]*Usage),
+		done:   make(chan struct{}),
+	}
+}
+
+// Limits returns the limits enforced by the Budget.
+func (b *Budget) Limits() Limits {
+	if b == nil {
+		return Limits{}
+	}
+	return b.limits
+}
+
+// SpendDNS records a DNS query made by the source and returns false once the query budget has been exhausted.
+func (b *Budget) SpendDNS(source string) bool {
+	if b == nil {
+		return true
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	b.dns++
+	b.get(source).DNSQueries++
+	if b.limits.DNSQueries > 0 && b.dns > b.limits.DNSQueries {
+		b.exhaust(fmt.Sprintf("the limit of %d DNS queries was reached", b.limits.DNSQueries))
+		return false
+	}
+	return true
+}
+
+// SpendHTTP records an HTTP request made by the source and returns false once the request budget has been exhausted.
+func (b *Budget) SpendHTTP(source string) bool {
+	if b == nil {
+		return true
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	b.http++
+	b.get(source).HTTPRequests++
+	if b.limits.HTTPRequests > 0 && b.http > b.limits.HTTPRequests {
+		b.exhaust(fmt.Sprintf("the limit of %d HTTP requests was reached", b.limits.HTTPRequests))
+		return false
+	}
+	return true
+}
+
+// Exhaust marks the Budget as exhausted for the provided reason, such as the runtime expiring.
+func (b *Budget) Exhaust(reason string) {
+	if b == nil {
+		return
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	b.exhaust(reason)
+}
+
+func (b *Budget) exhaust(reason string) {
+	if !b.doneSet {
+		b.doneSet = true
+		b.reason = reason
+		close(b.done)
+	}
+}
+
+func (b *Budget) get(source string) *Usage {
+	u, found := b.usage[source]
+	if !found {
+		u = &Usage{Source: source}
+		b.usage[source] = u
+	}
+	return u
+}
+
+// Done returns a channel that is closed once any limit of the Budget has been exhausted.
+// A nil channel is returned for a nil Budget.
+func (b *Budget) Done() <-chan struct{} {
+	if b == nil {
+		return nil
+	}
+	return b.done
+}
+
+// Reason returns the description of the exhausted limit, or an empty string.
+func (b *Budget) Reason() string {
+	if b == nil {
+		return ""
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	return b.reason
+}
+
+// TopConsumers returns up to max sources ordered by the resources they consumed.
+func (b *Budget) TopConsumers(max int) []Usage {
+	if b == nil {
+		return nil
+	}
+
+	b.Lock()
+	var usage []Usage
+	for _, u := range b.usage {
+		usage = append(usage, *u)
+	}
+	b.Unlock()
+
+	sort.Slice(usage, func(i, j int) bool {
+		ti := usage[i].DNSQueries + usage[i].HTTPRequests
+		tj := usage[j].DNSQueries + usage[j].HTTPRequests
+		if ti == tj {
+			return usage[i].Source < usage[j].Source
+		}
+		return ti > tj
+	})
+
+	if max > 0 && len(usage) > max {
+		usage = usage[:max]
+	}
+	return usage
+}
+
+// Summary returns a single line describing the sources that consumed the most resources.
+func (b *Budget) Summary(max int) string {
+	var parts []string
+
+	for _, u := range b.TopConsumers(max) {
+		parts = append(parts, fmt.Sprintf("%s (%d DNS queries, %d HTTP requests)", u.Source, u.DNSQueries, u.HTTPRequests))
+	}
+	return strings.Join(parts, ", ")
+}
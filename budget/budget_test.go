@@ -0,0 +1,87 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package budget
+
+import (
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func TestNilBudget(t *testing.T) {
+	var b *Budget
+
+	if !b.SpendDNS("test") || !b.SpendHTTP("test") {
+		t.Error("A nil budget denied a request")
+	}
+	if b.Done() != nil || b.Reason() != "" || len(b.TopConsumers(5)) != 0 {
+		t.Error("A nil budget reported consumption")
+	}
+}
+
+func TestBudgetExhaustion(t *testing.T) {
+	b := NewBudget(Limits{DNSQueries: 3, HTTPRequests: 10})
+
+	for i := 0; i < 2; i++ {
+		if !b.SpendDNS("crtsh") {
+			t.Fatal("The budget denied a query within the limit")
+		}
+	}
+	if !b.SpendDNS("brute") {
+		t.Fatal("The budget denied the final query within the limit")
+	}
+	select {
+	case <-b.Done():
+		t.Fatal("The budget was exhausted before the limit was exceeded")
+	default:
+	}
+
+	b.SpendHTTP("brute")
+	b.SpendHTTP("brute")
+	if b.SpendDNS("crtsh") {
+		t.Error("The budget allowed a query beyond the limit")
+	}
+	select {
+	case <-b.Done():
+	default:
+		t.Error("The budget was not exhausted after the limit was exceeded")
+	}
+	if b.Reason() == "" {
+		t.Error("The budget did not provide the reason for the exhaustion")
+	}
+
+	top := b.TopConsumers(1)
+	if len(top) != 1 || top[0].Source != "brute" || top[0].HTTPRequests != 2 {
+		t.Errorf("Unexpected top consumer: %+v", top)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	if b, err := FromConfig(cfg); err != nil || b != nil {
+		t.Errorf("Expected no budget without the section, got %v, %v", b, err)
+	}
+
+	cfg.Options = map[string]interface{}{
+		"budget": map[string]interface{}{
+			"dns_queries":   1000,
+			"http_requests": 50,
+			"runtime":       "30m",
+		},
+	}
+	b, err := FromConfig(cfg)
+	if err != nil || b == nil {
+		t.Fatalf("Failed to create the budget: %v", err)
+	}
+	if l := b.Limits(); l.DNSQueries != 1000 || l.HTTPRequests != 50 || l.Runtime != 30*time.Minute {
+		t.Errorf("Unexpected limits: %+v", l)
+	}
+
+	cfg.Options["budget"] = map[string]interface{}{"runtime": "soon"}
+	if _, err := FromConfig(cfg); err == nil {
+		t.Error("An invalid runtime was accepted")
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package budget
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// FromConfig returns a Budget enforcing the limits in the 'budget' section of the configuration options.
+// A nil Budget is returned when no limits have been configured.
+func FromConfig(cfg *config.Config) (*Budget, error) {
+	budgetRaw, ok := cfg.Options["budget"]
+	if !ok {
+		return nil, nil
+	}
+
+	settings, ok := budgetRaw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("budget is not a map[string]interface{}")
+	}
+
+	var limits Limits
+	if raw, ok := settings["dns_queries"]; ok {
+		n, ok := raw.(int)
+		if !ok || n < 0 {
+			return nil, fmt.Errorf("budget dns_queries is not a positive integer")
+		}
+		limits.DNSQueries = int64(n)
+	}
+	if raw, ok := settings["http_requests"]; ok {
+		n, ok := raw.(int)
+		if !ok || n < 0 {
+			return nil, fmt.Errorf("budget http_requests is not a positive integer")
+		}
+		limits.HTTPRequests = int64(n)
+	}
+	if raw, ok := settings["runtime"]; ok {
+		str, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("budget runtime is not a string")
+		}
+
+		d, err := time.ParseDuration(str)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("budget runtime is not a valid duration: %s", str)
+		}
+		limits.Runtime = d
+	}
+
+	if limits == (Limits{}) {
+		return nil, nil
+	}
+	return NewBudget(limits), nil
+}
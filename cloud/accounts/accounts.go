@@ -0,0 +1,139 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package accounts imports the authoritative inventory of the cloud accounts operated by the
+// organization, using read-only credentials, so it can be merged with the external discovery.
+package accounts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Source is the data source name of the assets imported from the cloud accounts.
+const Source = "cloud-config"
+
+// The cloud providers supported by the accounts.
+const (
+	ProviderAWS   = "aws"
+	ProviderAzure = "azure"
+	ProviderGCP   = "gcp"
+)
+
+// Credentials are sent to the cloud APIs, so the certificates are verified, unlike the client used for web pages.
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// Record is a DNS resource record served by a zone of the account.
+type Record struct {
+	Name string
+	Type uint16
+	Data string
+}
+
+// Inventory holds the assets imported from a cloud account.
+type Inventory struct {
+	// Zones are the apex names of the public DNS zones
+	Zones   []string
+	Records []*Record
+	// Hostnames are the names assigned to the load balancers and public addresses
+	Hostnames []string
+	Addresses []string
+}
+
+func (inv *Inventory) addZone(name string) {
+	inv.Zones = append(inv.Zones, cleanName(name))
+}
+
+func (inv *Inventory) addRecord(name string, rrtype uint16, data string) {
+	if name = cleanName(name); name != "" && data != "" {
+		inv.Records = append(inv.Records, &Record{Name: name, Type: rrtype, Data: cleanName(data)})
+	}
+}
+
+func (inv *Inventory) addHostname(name string) {
+	if name = cleanName(name); name != "" {
+		inv.Hostnames = append(inv.Hostnames, name)
+	}
+}
+
+func (inv *Inventory) addAddress(addr string) {
+	if addr = strings.TrimSpace(addr); addr != "" {
+		inv.Addresses = append(inv.Addresses, addr)
+	}
+}
+
+// Account is a cloud account that the inventory is imported from.
+type Account interface {
+	// Name returns the name of the account in the configuration
+	Name() string
+	// Provider returns the cloud provider operating the account
+	Provider() string
+	// Collect requests the inventory from the APIs of the provider
+	Collect(ctx context.Context) (*Inventory, error)
+}
+
+// RecordsByName groups the records of the inventory by the owner name, in the order of the names.
+func (inv *Inventory) RecordsByName() ([]string, map[string][]*Record) {
+	var names []string
+	byname := make(map[string][]*Record)
+
+	for _, rec := range inv.Records {
+		if _, found := byname[rec.Name]; !found {
+			names = append(names, rec.Name)
+		}
+		byname[rec.Name] = append(byname[rec.Name], rec)
+	}
+	sort.Strings(names)
+	return names, byname
+}
+
+// tokenResponse is the OAuth 2.0 access token response of the identity providers.
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+}
+
+func cleanName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+}
+
+// doJSON sends the request and decodes the JSON response into v.
+func doJSON(req *http.Request, v interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkStatus(req, resp); err != nil {
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func checkStatus(req *http.Request, resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("%s %s returned status %d: %s", req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// getJSON requests the URL with the bearer token and decodes the JSON response into v.
+func getJSON(ctx context.Context, u, token string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	return doJSON(req, v)
+}
@@ -0,0 +1,379 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package accounts
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// AWSAccount imports the Route 53 hosted zones, the load balancers and the public addresses of an AWS account.
+type AWSAccount struct {
+	name         string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	regions      []string
+	privateZones bool
+	// endpoint returns the base URL of the service API in the region, and can be replaced by the tests
+	endpoint func(service, region string) string
+}
+
+func awsEndpoint(service, region string) string {
+	if service == "route53" {
+		return "https://route53.amazonaws.com"
+	}
+	return "https://" + service + "." + region + ".amazonaws.com"
+}
+
+// Name implements the Account interface.
+func (a *AWSAccount) Name() string { return a.name }
+
+// Provider implements the Account interface.
+func (a *AWSAccount) Provider() string { return ProviderAWS }
+
+// Collect implements the Account interface.
+func (a *AWSAccount) Collect(ctx context.Context) (*Inventory, error) {
+	inv := new(Inventory)
+
+	if err := a.hostedZones(ctx, inv); err != nil {
+		return inv, fmt.Errorf("route 53: %v", err)
+	}
+	for _, region := range a.regions {
+		if err := a.loadBalancers(ctx, region, inv); err != nil {
+			return inv, fmt.Errorf("elastic load balancing in %s: %v", region, err)
+		}
+		if err := a.publicAddresses(ctx, region, inv); err != nil {
+			return inv, fmt.Errorf("ec2 in %s: %v", region, err)
+		}
+	}
+	return inv, nil
+}
+
+type route53Zones struct {
+	HostedZones []struct {
+		ID     string `xml:"Id"`
+		Name   string `xml:"Name"`
+		Config struct {
+			PrivateZone bool `xml:"PrivateZone"`
+		} `xml:"Config"`
+	} `xml:"HostedZones>HostedZone"`
+	IsTruncated bool   `xml:"IsTruncated"`
+	NextMarker  string `xml:"NextMarker"`
+}
+
+type route53Records struct {
+	RecordSets []struct {
+		Name    string   `xml:"Name"`
+		Type    string   `xml:"Type"`
+		Values  []string `xml:"ResourceRecords>ResourceRecord>Value"`
+		DNSName string   `xml:"AliasTarget>DNSName"`
+	} `xml:"ResourceRecordSets>ResourceRecordSet"`
+	IsTruncated          bool   `xml:"IsTruncated"`
+	NextRecordName       string `xml:"NextRecordName"`
+	NextRecordType       string `xml:"NextRecordType"`
+	NextRecordIdentifier string `xml:"NextRecordIdentifier"`
+}
+
+func (a *AWSAccount) hostedZones(ctx context.Context, inv *Inventory) error {
+	base := a.endpoint("route53", "us-east-1") + "/2013-04-01/hostedzone"
+
+	var marker string
+	for {
+		q := url.Values{}
+		if marker != "" {
+			q.Set("marker", marker)
+		}
+
+		var zones route53Zones
+		if err := a.get(ctx, "route53", "us-east-1", base, q, &zones); err != nil {
+			return err
+		}
+		for _, z := range zones.HostedZones {
+			if z.Config.PrivateZone && !a.privateZones {
+				continue
+			}
+
+			inv.addZone(z.Name)
+			if err := a.recordSets(ctx, strings.TrimPrefix(z.ID, "/hostedzone/"), inv); err != nil {
+				return err
+			}
+		}
+		if !zones.IsTruncated || zones.NextMarker == "" {
+			return nil
+		}
+		marker = zones.NextMarker
+	}
+}
+
+func (a *AWSAccount) recordSets(ctx context.Context, id string, inv *Inventory) error {
+	base := a.endpoint("route53", "us-east-1") + "/2013-04-01/hostedzone/" + id + "/rrset"
+
+	q := url.Values{}
+	for {
+		var records route53Records
+		if err := a.get(ctx, "route53", "us-east-1", base, q, &records); err != nil {
+			return err
+		}
+		for _, rs := range records.RecordSets {
+			// Alias records point to the names of AWS resources, such as load balancers and distributions
+			if rs.DNSName != "" {
+				inv.addRecord(rs.Name, dns.TypeCNAME, rs.DNSName)
+				inv.addHostname(rs.DNSName)
+				continue
+			}
+
+			rrtype, found := dns.StringToType[rs.Type]
+			if !found {
+				continue
+			}
+			for _, v := range rs.Values {
+				if data := recordData(rrtype, v); data != "" {
+					inv.addRecord(rs.Name, rrtype, data)
+				}
+			}
+		}
+		if !records.IsTruncated {
+			return nil
+		}
+
+		q = url.Values{}
+		q.Set("name", records.NextRecordName)
+		q.Set("type", records.NextRecordType)
+		if records.NextRecordIdentifier != "" {
+			q.Set("identifier", records.NextRecordIdentifier)
+		}
+	}
+}
+
+type elbv2LoadBalancers struct {
+	LoadBalancers []struct {
+		DNSName string `xml:"DNSName"`
+		Scheme  string `xml:"Scheme"`
+	} `xml:"DescribeLoadBalancersResult>LoadBalancers>member"`
+	NextMarker string `xml:"DescribeLoadBalancersResult>NextMarker"`
+}
+
+type elbLoadBalancers struct {
+	LoadBalancers []struct {
+		DNSName string `xml:"DNSName"`
+		Scheme  string `xml:"Scheme"`
+	} `xml:"DescribeLoadBalancersResult>LoadBalancerDescriptions>member"`
+	NextMarker string `xml:"DescribeLoadBalancersResult>NextMarker"`
+}
+
+// loadBalancers adds the internet-facing application, network and classic load balancers in the region.
+func (a *AWSAccount) loadBalancers(ctx context.Context, region string, inv *Inventory) error {
+	base := a.endpoint("elasticloadbalancing", region) + "/"
+
+	for _, version := range []string{"2015-12-01", "2012-06-01"} {
+		var marker string
+		for {
+			q := url.Values{"Action": {"DescribeLoadBalancers"}, "Version": {version}}
+			if marker != "" {
+				q.Set("Marker", marker)
+			}
+
+			var names, schemes []string
+			if version == "2015-12-01" {
+				var lbs elbv2LoadBalancers
+				if err := a.get(ctx, "elasticloadbalancing", region, base, q, &lbs); err != nil {
+					return err
+				}
+				for _, lb := range lbs.LoadBalancers {
+					names, schemes = append(names, lb.DNSName), append(schemes, lb.Scheme)
+				}
+				marker = lbs.NextMarker
+			} else {
+				var lbs elbLoadBalancers
+				if err := a.get(ctx, "elasticloadbalancing", region, base, q, &lbs); err != nil {
+					return err
+				}
+				for _, lb := range lbs.LoadBalancers {
+					names, schemes = append(names, lb.DNSName), append(schemes, lb.Scheme)
+				}
+				marker = lbs.NextMarker
+			}
+
+			for i, name := range names {
+				if schemes[i] != "internal" {
+					inv.addHostname(name)
+				}
+			}
+			if marker == "" {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+type ec2Addresses struct {
+	Addresses []struct {
+		PublicIP string `xml:"publicIp"`
+	} `xml:"addressesSet>item"`
+}
+
+type ec2Instances struct {
+	Instances []struct {
+		IPAddress string `xml:"ipAddress"`
+		DNSName   string `xml:"dnsName"`
+	} `xml:"reservationSet>item>instancesSet>item"`
+	NextToken string `xml:"nextToken"`
+}
+
+// publicAddresses adds the elastic IP addresses and the public addresses of the instances in the region.
+func (a *AWSAccount) publicAddresses(ctx context.Context, region string, inv *Inventory) error {
+	base := a.endpoint("ec2", region) + "/"
+
+	var addrs ec2Addresses
+	q := url.Values{"Action": {"DescribeAddresses"}, "Version": {"2016-11-15"}}
+	if err := a.get(ctx, "ec2", region, base, q, &addrs); err != nil {
+		return err
+	}
+	for _, addr := range addrs.Addresses {
+		inv.addAddress(addr.PublicIP)
+	}
+
+	var token string
+	for {
+		q := url.Values{"Action": {"DescribeInstances"}, "Version": {"2016-11-15"}}
+		if token != "" {
+			q.Set("NextToken", token)
+		}
+
+		var instances ec2Instances
+		if err := a.get(ctx, "ec2", region, base, q, &instances); err != nil {
+			return err
+		}
+		for _, inst := range instances.Instances {
+			inv.addAddress(inst.IPAddress)
+			inv.addHostname(inst.DNSName)
+		}
+		if instances.NextToken == "" {
+			return nil
+		}
+		token = instances.NextToken
+	}
+}
+
+// get sends the signed GET request and decodes the XML response into v.
+func (a *AWSAccount) get(ctx context.Context, service, region, base string, q url.Values, v interface{}) error {
+	u := base
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	signV4(req, nil, a.accessKey, a.secretKey, a.sessionToken, region, service, time.Now())
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkStatus(req, resp); err != nil {
+		return err
+	}
+	return xml.NewDecoder(resp.Body).Decode(v)
+}
+
+// signV4 adds the AWS Signature Version 4 authorization to the request.
+func signV4(req *http.Request, body []byte, accessKey, secretKey, token, region, service string, now time.Time) {
+	amzdate := now.UTC().Format("20060102T150405Z")
+	date := amzdate[:8]
+
+	req.Header.Set("X-Amz-Date", amzdate)
+	if token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+
+	// The host and the x-amz-* headers are signed
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		if lk := strings.ToLower(k); strings.HasPrefix(lk, "x-amz-") || lk == "content-type" {
+			headers[lk] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	var names []string
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonHeaders strings.Builder
+	for _, k := range names {
+		canonHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	payload := sha256.Sum256(body)
+	canonical := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL.Query()),
+		canonHeaders.String(),
+		signed,
+		hex.EncodeToString(payload[:]),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	hashed := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzdate + "\n" + scope + "\n" + hex.EncodeToString(hashed[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	for _, part := range []string{region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKey+"/"+scope+
+		", SignedHeaders="+signed+", Signature="+signature)
+}
+
+// canonicalQuery encodes the query parameters sorted by name, using %20 for the spaces.
+func canonicalQuery(q url.Values) string {
+	return strings.ReplaceAll(q.Encode(), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// recordData returns the target name or address of the record value, or an empty string for
+// the record types that are not imported.
+func recordData(rrtype uint16, value string) string {
+	switch rrtype {
+	case dns.TypeA, dns.TypeAAAA, dns.TypeCNAME, dns.TypeNS:
+		return strings.TrimSpace(value)
+	case dns.TypeMX:
+		// The value holds the preference and the exchange
+		if fields := strings.Fields(value); len(fields) > 0 {
+			return fields[len(fields)-1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,191 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package accounts
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// AzureAccount imports the Azure DNS zones and the public IP addresses of the Azure subscriptions
+// readable by a service principal.
+type AzureAccount struct {
+	name          string
+	tenantID      string
+	clientID      string
+	clientSecret  string
+	subscriptions []string
+	// The base URLs of the APIs, which can be replaced by the tests
+	loginURL      string
+	managementURL string
+}
+
+// Name implements the Account interface.
+func (a *AzureAccount) Name() string { return a.name }
+
+// Provider implements the Account interface.
+func (a *AzureAccount) Provider() string { return ProviderAzure }
+
+// Collect implements the Account interface.
+func (a *AzureAccount) Collect(ctx context.Context) (*Inventory, error) {
+	inv := new(Inventory)
+
+	token, err := a.token(ctx)
+	if err != nil {
+		return inv, fmt.Errorf("failed to obtain the access token: %v", err)
+	}
+
+	for _, sub := range a.subscriptions {
+		base := a.managementURL + "/subscriptions/" + url.PathEscape(sub) + "/providers/Microsoft.Network"
+
+		if err := a.zones(ctx, token, base+"/dnszones?api-version=2018-05-01", inv); err != nil {
+			return inv, fmt.Errorf("dns zones of %s: %v", sub, err)
+		}
+		if err := a.publicIPs(ctx, token, base+"/publicIPAddresses?api-version=2023-05-01", inv); err != nil {
+			return inv, fmt.Errorf("public addresses of %s: %v", sub, err)
+		}
+	}
+	return inv, nil
+}
+
+// token requests an access token for the management API using the client credentials grant.
+func (a *AzureAccount) token(ctx context.Context) (string, error) {
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {a.clientID},
+		"client_secret": {a.clientSecret},
+		"scope":         {"https://management.azure.com/.default"},
+	}
+
+	u := a.loginURL + "/" + url.PathEscape(a.tenantID) + "/oauth2/v2.0/token"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var t tokenResponse
+	if err := doJSON(req, &t); err != nil {
+		return "", err
+	}
+	if t.AccessToken == "" {
+		return "", fmt.Errorf("the response did not provide an access token")
+	}
+	return t.AccessToken, nil
+}
+
+type azureZones struct {
+	Value []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"value"`
+	NextLink string `json:"nextLink"`
+}
+
+type azureRecordSets struct {
+	Value []struct {
+		Properties struct {
+			FQDN     string `json:"fqdn"`
+			ARecords []struct {
+				Address string `json:"ipv4Address"`
+			} `json:"ARecords"`
+			AAAARecords []struct {
+				Address string `json:"ipv6Address"`
+			} `json:"AAAARecords"`
+			CNAMERecord *struct {
+				CNAME string `json:"cname"`
+			} `json:"CNAMERecord"`
+			MXRecords []struct {
+				Exchange string `json:"exchange"`
+			} `json:"MXRecords"`
+			NSRecords []struct {
+				Name string `json:"nsdname"`
+			} `json:"NSRecords"`
+		} `json:"properties"`
+	} `json:"value"`
+	NextLink string `json:"nextLink"`
+}
+
+func (a *AzureAccount) zones(ctx context.Context, token, u string, inv *Inventory) error {
+	for u != "" {
+		var zones azureZones
+		if err := getJSON(ctx, u, token, &zones); err != nil {
+			return err
+		}
+
+		for _, z := range zones.Value {
+			inv.addZone(z.Name)
+			if err := a.recordSets(ctx, token, a.managementURL+z.ID+"/recordsets?api-version=2018-05-01", inv); err != nil {
+				return err
+			}
+		}
+		u = zones.NextLink
+	}
+	return nil
+}
+
+func (a *AzureAccount) recordSets(ctx context.Context, token, u string, inv *Inventory) error {
+	for u != "" {
+		var sets azureRecordSets
+		if err := getJSON(ctx, u, token, &sets); err != nil {
+			return err
+		}
+
+		for _, rs := range sets.Value {
+			p := rs.Properties
+			for _, r := range p.ARecords {
+				inv.addRecord(p.FQDN, dns.TypeA, r.Address)
+			}
+			for _, r := range p.AAAARecords {
+				inv.addRecord(p.FQDN, dns.TypeAAAA, r.Address)
+			}
+			if p.CNAMERecord != nil {
+				inv.addRecord(p.FQDN, dns.TypeCNAME, p.CNAMERecord.CNAME)
+			}
+			for _, r := range p.MXRecords {
+				inv.addRecord(p.FQDN, dns.TypeMX, r.Exchange)
+			}
+			for _, r := range p.NSRecords {
+				inv.addRecord(p.FQDN, dns.TypeNS, r.Name)
+			}
+		}
+		u = sets.NextLink
+	}
+	return nil
+}
+
+type azurePublicIPs struct {
+	Value []struct {
+		Properties struct {
+			IPAddress   string `json:"ipAddress"`
+			DNSSettings struct {
+				FQDN string `json:"fqdn"`
+			} `json:"dnsSettings"`
+		} `json:"properties"`
+	} `json:"value"`
+	NextLink string `json:"nextLink"`
+}
+
+// publicIPs adds the public addresses, which front the load balancers and virtual machines, and their names.
+func (a *AzureAccount) publicIPs(ctx context.Context, token, u string, inv *Inventory) error {
+	for u != "" {
+		var ips azurePublicIPs
+		if err := getJSON(ctx, u, token, &ips); err != nil {
+			return err
+		}
+
+		for _, ip := range ips.Value {
+			inv.addAddress(ip.Properties.IPAddress)
+			inv.addHostname(ip.Properties.DNSSettings.FQDN)
+		}
+		u = ips.NextLink
+	}
+	return nil
+}
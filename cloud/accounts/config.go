@@ -0,0 +1,183 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package accounts
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// FromConfig returns the accounts in the 'cloud_accounts' section of the configuration options.
+// Each entry is keyed by the account name and selects the provider with the 'provider' option.
+func FromConfig(cfg *config.Config) ([]Account, error) {
+	accountsRaw, ok := cfg.Options["cloud_accounts"]
+	if !ok {
+		return nil, nil
+	}
+
+	entries, ok := accountsRaw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cloud_accounts is not a map[string]interface{}")
+	}
+
+	var names []string
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var results []Account
+	for _, name := range names {
+		settings, ok := entries[name].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cloud_accounts %s is not a map[string]interface{}", name)
+		}
+
+		s := &accountSettings{name: name, settings: settings}
+		var acct Account
+		switch provider := s.str("provider", true); provider {
+		case ProviderAWS:
+			acct = s.aws()
+		case ProviderAzure:
+			acct = s.azure()
+		case ProviderGCP:
+			acct = s.gcp()
+		default:
+			if s.err == nil {
+				s.err = fmt.Errorf("cloud_accounts %s provider must be aws, azure or gcp", name)
+			}
+		}
+		if s.err != nil {
+			return nil, s.err
+		}
+		results = append(results, acct)
+	}
+	return results, nil
+}
+
+// accountSettings reads the options of an account, keeping the first error encountered.
+type accountSettings struct {
+	name     string
+	settings map[string]interface{}
+	err      error
+}
+
+func (s *accountSettings) str(key string, required bool) string {
+	raw, ok := s.settings[key]
+	if !ok {
+		if required && s.err == nil {
+			s.err = fmt.Errorf("cloud_accounts %s must provide the %s", s.name, key)
+		}
+		return ""
+	}
+
+	str, ok := raw.(string)
+	if (!ok || (required && str == "")) && s.err == nil {
+		s.err = fmt.Errorf("cloud_accounts %s %s is not a non-empty string", s.name, key)
+	}
+	return str
+}
+
+func (s *accountSettings) list(key string) []string {
+	raw, ok := s.settings[key]
+	if !ok {
+		return nil
+	}
+
+	items, ok := raw.([]interface{})
+	if !ok {
+		if s.err == nil {
+			s.err = fmt.Errorf("cloud_accounts %s %s is not a list", s.name, key)
+		}
+		return nil
+	}
+
+	var results []string
+	for _, item := range items {
+		str, ok := item.(string)
+		if !ok || str == "" {
+			if s.err == nil {
+				s.err = fmt.Errorf("cloud_accounts %s %s must hold non-empty strings", s.name, key)
+			}
+			return nil
+		}
+		results = append(results, str)
+	}
+	return results
+}
+
+func (s *accountSettings) boolean(key string) bool {
+	raw, ok := s.settings[key]
+	if !ok {
+		return false
+	}
+
+	b, ok := raw.(bool)
+	if !ok && s.err == nil {
+		s.err = fmt.Errorf("cloud_accounts %s %s is not a bool", s.name, key)
+	}
+	return b
+}
+
+func (s *accountSettings) aws() *AWSAccount {
+	a := &AWSAccount{
+		name:         s.name,
+		accessKey:    s.str("access_key", true),
+		secretKey:    s.str("secret_key", true),
+		sessionToken: s.str("session_token", false),
+		regions:      s.list("regions"),
+		privateZones: s.boolean("private_zones"),
+		endpoint:     awsEndpoint,
+	}
+	if len(a.regions) == 0 {
+		a.regions = []string{"us-east-1"}
+	}
+	return a
+}
+
+func (s *accountSettings) azure() *AzureAccount {
+	a := &AzureAccount{
+		name:          s.name,
+		tenantID:      s.str("tenant_id", true),
+		clientID:      s.str("client_id", true),
+		clientSecret:  s.str("client_secret", true),
+		subscriptions: s.list("subscriptions"),
+		loginURL:      "https://login.microsoftonline.com",
+		managementURL: "https://management.azure.com",
+	}
+	if len(a.subscriptions) == 0 && s.err == nil {
+		s.err = fmt.Errorf("cloud_accounts %s must provide the subscriptions", s.name)
+	}
+	return a
+}
+
+func (s *accountSettings) gcp() *GCPAccount {
+	a := &GCPAccount{
+		name:         s.name,
+		projects:     s.list("projects"),
+		privateZones: s.boolean("private_zones"),
+		dnsURL:       "https://dns.googleapis.com",
+		computeURL:   "https://compute.googleapis.com",
+	}
+
+	path := s.str("credentials", true)
+	if s.err != nil {
+		return a
+	}
+	key, err := readServiceAccountKey(path)
+	if err != nil {
+		s.err = fmt.Errorf("cloud_accounts %s credentials: %v", s.name, err)
+		return a
+	}
+	a.key = key
+
+	// The project of the service account is used when none are provided
+	if len(a.projects) == 0 && key.ProjectID != "" {
+		a.projects = []string{key.ProjectID}
+	}
+	return a
+}
@@ -0,0 +1,279 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package accounts
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// GCPAccount imports the Cloud DNS zones, the forwarding rules of the load balancers and the
+// external addresses of the Google Cloud projects readable by a service account.
+type GCPAccount struct {
+	name         string
+	key          *serviceAccountKey
+	projects     []string
+	privateZones bool
+	// The base URLs of the APIs, which can be replaced by the tests
+	dnsURL     string
+	computeURL string
+}
+
+// serviceAccountKey is the JSON key file created for a service account.
+type serviceAccountKey struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+func readServiceAccountKey(path string) (*serviceAccountKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var key serviceAccountKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("failed to decode the service account key: %v", err)
+	}
+	if key.ClientEmail == "" || key.PrivateKey == "" {
+		return nil, errors.New("the service account key must provide the client_email and private_key")
+	}
+	if key.TokenURI == "" {
+		key.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	return &key, nil
+}
+
+// Name implements the Account interface.
+func (a *GCPAccount) Name() string { return a.name }
+
+// Provider implements the Account interface.
+func (a *GCPAccount) Provider() string { return ProviderGCP }
+
+// Collect implements the Account interface.
+func (a *GCPAccount) Collect(ctx context.Context) (*Inventory, error) {
+	inv := new(Inventory)
+
+	token, err := a.token(ctx, time.Now())
+	if err != nil {
+		return inv, fmt.Errorf("failed to obtain the access token: %v", err)
+	}
+
+	for _, p := range a.projects {
+		project := url.PathEscape(p)
+
+		if err := a.zones(ctx, token, a.dnsURL+"/dns/v1/projects/"+project+"/managedZones", inv); err != nil {
+			return inv, fmt.Errorf("cloud dns of %s: %v", p, err)
+		}
+		base := a.computeURL + "/compute/v1/projects/" + project + "/aggregated/"
+		if err := a.addresses(ctx, token, base, inv); err != nil {
+			return inv, fmt.Errorf("compute engine of %s: %v", p, err)
+		}
+	}
+	return inv, nil
+}
+
+// token exchanges a JWT signed with the key of the service account for a read-only access token.
+func (a *GCPAccount) token(ctx context.Context, now time.Time) (string, error) {
+	block, _ := pem.Decode([]byte(a.key.PrivateKey))
+	if block == nil {
+		return "", errors.New("the private key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse the private key: %v", err)
+	}
+	priv, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return "", errors.New("the private key is not an RSA key")
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   a.key.ClientEmail,
+		"scope": "https://www.googleapis.com/auth/cloud-platform.read-only",
+		"aud":   a.key.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, priv, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {unsigned + "." + base64.RawURLEncoding.EncodeToString(sig)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.key.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var t tokenResponse
+	if err := doJSON(req, &t); err != nil {
+		return "", err
+	}
+	if t.AccessToken == "" {
+		return "", errors.New("the response did not provide an access token")
+	}
+	return t.AccessToken, nil
+}
+
+type gcpZones struct {
+	ManagedZones []struct {
+		Name       string `json:"name"`
+		DNSName    string `json:"dnsName"`
+		Visibility string `json:"visibility"`
+	} `json:"managedZones"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+type gcpRecordSets struct {
+	RRSets []struct {
+		Name    string   `json:"name"`
+		Type    string   `json:"type"`
+		RRDatas []string `json:"rrdatas"`
+	} `json:"rrsets"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+func (a *GCPAccount) zones(ctx context.Context, token, base string, inv *Inventory) error {
+	var page string
+	for {
+		var zones gcpZones
+		if err := getJSON(ctx, withPageToken(base, page), token, &zones); err != nil {
+			return err
+		}
+
+		for _, z := range zones.ManagedZones {
+			if z.Visibility == "private" && !a.privateZones {
+				continue
+			}
+
+			inv.addZone(z.DNSName)
+			if err := a.recordSets(ctx, token, base+"/"+url.PathEscape(z.Name)+"/rrsets", inv); err != nil {
+				return err
+			}
+		}
+		if page = zones.NextPageToken; page == "" {
+			return nil
+		}
+	}
+}
+
+func (a *GCPAccount) recordSets(ctx context.Context, token, base string, inv *Inventory) error {
+	var page string
+	for {
+		var sets gcpRecordSets
+		if err := getJSON(ctx, withPageToken(base, page), token, &sets); err != nil {
+			return err
+		}
+
+		for _, rs := range sets.RRSets {
+			rrtype, found := dns.StringToType[rs.Type]
+			if !found {
+				continue
+			}
+			for _, v := range rs.RRDatas {
+				if data := recordData(rrtype, v); data != "" {
+					inv.addRecord(rs.Name, rrtype, data)
+				}
+			}
+		}
+		if page = sets.NextPageToken; page == "" {
+			return nil
+		}
+	}
+}
+
+// The aggregated lists of compute resources are keyed by the region or zone.
+type gcpAggregated struct {
+	Items map[string]struct {
+		Addresses []struct {
+			Address     string `json:"address"`
+			AddressType string `json:"addressType"`
+		} `json:"addresses"`
+		ForwardingRules []struct {
+			IPAddress           string `json:"IPAddress"`
+			LoadBalancingScheme string `json:"loadBalancingScheme"`
+		} `json:"forwardingRules"`
+		Instances []struct {
+			NetworkInterfaces []struct {
+				AccessConfigs []struct {
+					NatIP string `json:"natIP"`
+				} `json:"accessConfigs"`
+			} `json:"networkInterfaces"`
+		} `json:"instances"`
+	} `json:"items"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// addresses adds the reserved external addresses, the addresses of the external load balancers, and
+// the external addresses of the instances.
+func (a *GCPAccount) addresses(ctx context.Context, token, base string, inv *Inventory) error {
+	for _, resource := range []string{"addresses", "forwardingRules", "instances"} {
+		var page string
+		for {
+			var agg gcpAggregated
+			if err := getJSON(ctx, withPageToken(base+resource, page), token, &agg); err != nil {
+				return err
+			}
+
+			for _, item := range agg.Items {
+				for _, addr := range item.Addresses {
+					if addr.AddressType != "INTERNAL" {
+						inv.addAddress(addr.Address)
+					}
+				}
+				for _, rule := range item.ForwardingRules {
+					if strings.HasPrefix(rule.LoadBalancingScheme, "EXTERNAL") {
+						inv.addAddress(rule.IPAddress)
+					}
+				}
+				for _, inst := range item.Instances {
+					for _, ni := range inst.NetworkInterfaces {
+						for _, ac := range ni.AccessConfigs {
+							inv.addAddress(ac.NatIP)
+						}
+					}
+				}
+			}
+			if page = agg.NextPageToken; page == "" {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func withPageToken(u, page string) string {
+	if page == "" {
+		return u
+	}
+	return u + "?pageToken=" + url.QueryEscape(page)
+}
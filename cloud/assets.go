@@ -0,0 +1,162 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package cloud
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/asset-db/types"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+// The longest chain of aliases followed from a name to its addresses.
+const maxAliasChain = 10
+
+// Asset is a discovered name attributed to a cloud provider through a CNAME target or an address.
+type Asset struct {
+	Name    string `json:"name"`
+	Target  string `json:"target,omitempty"`
+	Address string `json:"address,omitempty"`
+	Attribution
+}
+
+// Assets returns the attributions of the names within the domains that are stored in the
+// graph, ordered by name. Each name is reported once for every attributed target and address.
+func Assets(ctx context.Context, g *netmap.Graph, c *Classifier, domains []string, since time.Time) []*Asset {
+	var scope []oam.Asset
+	for _, d := range domains {
+		scope = append(scope, domain.FQDN{Name: strings.ToLower(d)})
+	}
+	if len(scope) == 0 {
+		return nil
+	}
+
+	assets, err := g.DB.FindByScope(scope, since)
+	if err != nil {
+		return nil
+	}
+
+	var results []*Asset
+	asns := make(map[string]*Attribution)
+	for _, a := range assets {
+		select {
+		case <-ctx.Done():
+			return results
+		default:
+		}
+
+		if fqdn, ok := a.Asset.(domain.FQDN); ok {
+			results = append(results, classifyFQDN(g, c, a, fqdn.Name, since, asns)...)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Name == results[j].Name {
+			return results[i].Target+results[i].Address < results[j].Target+results[j].Address
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results
+}
+
+func classifyFQDN(g *netmap.Graph, c *Classifier, a *types.Asset, name string, since time.Time, asns map[string]*Attribution) []*Asset {
+	var results []*Asset
+
+	cur := a
+	// Follow the chain of CNAME records to the name holding the addresses
+	for i := 0; i < maxAliasChain; i++ {
+		next := outgoing(g, cur, since, "cname_record")
+		if len(next) == 0 {
+			break
+		}
+
+		cur = next[0]
+		if target, ok := cur.Asset.(domain.FQDN); ok {
+			if attr := c.ClassifyName(target.Name); attr != nil {
+				results = append(results, &Asset{
+					Name:        name,
+					Target:      target.Name,
+					Attribution: *attr,
+				})
+			}
+		}
+	}
+
+	for _, other := range outgoing(g, cur, since, "a_record", "aaaa_record") {
+		ip, ok := other.Asset.(network.IPAddress)
+		if !ok {
+			continue
+		}
+
+		attr := c.ClassifyAddr(ip.Address)
+		if attr == nil {
+			key := ip.Address.String()
+			if cached, found := asns[key]; found {
+				attr = cached
+			} else {
+				attr = classifyByASN(g, c, other)
+				asns[key] = attr
+			}
+		}
+		if attr != nil {
+			results = append(results, &Asset{
+				Name:        name,
+				Address:     ip.Address.String(),
+				Attribution: *attr,
+			})
+		}
+	}
+	return results
+}
+
+// classifyByASN attributes the address using the autonomous system announcing the netblock containing it.
+func classifyByASN(g *netmap.Graph, c *Classifier, addr *types.Asset) *Attribution {
+	for _, nb := range incoming(g, addr, "contains") {
+		for _, as := range incoming(g, nb, "announces") {
+			if asn, ok := as.Asset.(network.AutonomousSystem); ok {
+				if attr := c.ClassifyASN(asn.Number); attr != nil {
+					return attr
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func outgoing(g *netmap.Graph, a *types.Asset, since time.Time, reltypes ...string) []*types.Asset {
+	rels, err := g.DB.OutgoingRelations(a, since, reltypes...)
+	if err != nil {
+		return nil
+	}
+
+	var results []*types.Asset
+	for _, rel := range rels {
+		if other, err := g.DB.FindById(rel.ToAsset.ID, time.Time{}); err == nil {
+			results = append(results, other)
+		}
+	}
+	return results
+}
+
+func incoming(g *netmap.Graph, a *types.Asset, reltypes ...string) []*types.Asset {
+	rels, err := g.DB.IncomingRelations(a, time.Time{}, reltypes...)
+	if err != nil {
+		return nil
+	}
+
+	var results []*types.Asset
+	for _, rel := range rels {
+		if other, err := g.DB.FindById(rel.FromAsset.ID, time.Time{}); err == nil {
+			results = append(results, other)
+		}
+	}
+	return results
+}
@@ -0,0 +1,342 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package cloud attributes the discovered addresses and CNAME targets to the cloud providers
+// operating them, using the address ranges published by the providers and well known names.
+package cloud
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The cloud providers recognized by the classifier.
+const (
+	AWS        = "AWS"
+	Azure      = "Azure"
+	GCP        = "GCP"
+	Cloudflare = "Cloudflare"
+	Akamai     = "Akamai"
+)
+
+// Attribution describes the cloud provider operating an asset and the evidence used to identify it.
+type Attribution struct {
+	Provider string `json:"provider"`
+	Region   string `json:"region,omitempty"`
+	Service  string `json:"service,omitempty"`
+	Evidence string `json:"evidence"`
+}
+
+type addrRange struct {
+	Prefix   netip.Prefix
+	Provider string
+	Region   string
+	Service  string
+}
+
+type nameSuffix struct {
+	Suffix   string
+	Provider string
+	Service  string
+}
+
+// nameSuffixes maps the domains of the names assigned by the cloud services. The more specific
+// suffixes of a provider must appear before the general ones.
+var nameSuffixes = []nameSuffix{
+	{"cloudfront.net", AWS, "CLOUDFRONT"},
+	{"elb.amazonaws.com", AWS, "ELB"},
+	{"execute-api.amazonaws.com", AWS, "API_GATEWAY"},
+	{"elasticbeanstalk.com", AWS, "ELASTICBEANSTALK"},
+	{"awsglobalaccelerator.com", AWS, "GLOBALACCELERATOR"},
+	{"awsapprunner.com", AWS, "APPRUNNER"},
+	{"amazonaws.com", AWS, ""},
+	{"azureedge.net", Azure, "Azure CDN"},
+	{"azurefd.net", Azure, "Azure Front Door"},
+	{"azurewebsites.net", Azure, "App Service"},
+	{"cloudapp.azure.com", Azure, "Virtual Machines"},
+	{"cloudapp.net", Azure, "Cloud Services"},
+	{"trafficmanager.net", Azure, "Traffic Manager"},
+	{"azure-api.net", Azure, "API Management"},
+	{"blob.core.windows.net", Azure, "Storage"},
+	{"web.core.windows.net", Azure, "Storage"},
+	{"azurestaticapps.net", Azure, "Static Web Apps"},
+	{"windows.net", Azure, ""},
+	{"appspot.com", GCP, "App Engine"},
+	{"run.app", GCP, "Cloud Run"},
+	{"cloudfunctions.net", GCP, "Cloud Functions"},
+	{"storage.googleapis.com", GCP, "Cloud Storage"},
+	{"ghs.googlehosted.com", GCP, "App Engine"},
+	{"googleusercontent.com", GCP, "Compute Engine"},
+	{"web.app", GCP, "Firebase Hosting"},
+	{"firebaseapp.com", GCP, "Firebase Hosting"},
+	{"cdn.cloudflare.net", Cloudflare, "CDN"},
+	{"pages.dev", Cloudflare, "Pages"},
+	{"workers.dev", Cloudflare, "Workers"},
+	{"akamaiedge.net", Akamai, "CDN"},
+	{"edgekey.net", Akamai, "CDN"},
+	{"edgesuite.net", Akamai, "CDN"},
+	{"akamaized.net", Akamai, "CDN"},
+	{"akamaihd.net", Akamai, "CDN"},
+	{"akamai.net", Akamai, "CDN"},
+	{"akamaitechnologies.com", Akamai, ""},
+}
+
+// providerASNs maps the autonomous systems announcing the address space of the providers.
+var providerASNs = map[int]string{
+	16509:  AWS,
+	14618:  AWS,
+	8075:   Azure,
+	15169:  GCP,
+	396982: GCP,
+	13335:  Cloudflare,
+	20940:  Akamai,
+	16625:  Akamai,
+	32787:  Akamai,
+}
+
+// Region labels in the names assigned by AWS (us-east-1) and Azure (eastus)
+var (
+	awsRegion   = regexp.MustCompile(`^[a-z]{2}(-gov)?-[a-z]+-[0-9]$`)
+	azureRegion = regexp.MustCompile(`^[a-z]+[0-9]?$`)
+)
+
+// Classifier attributes addresses, names and autonomous systems to the cloud providers.
+type Classifier struct {
+	sync.Mutex
+	ranges []*addrRange
+	sorted bool
+}
+
+// NewClassifier returns a Classifier recognizing the well known names and autonomous systems of
+// the providers. The address ranges published by the providers are added with the Load methods.
+func NewClassifier() *Classifier {
+	return &Classifier{}
+}
+
+// Len returns the number of address ranges known to the classifier.
+func (c *Classifier) Len() int {
+	c.Lock()
+	defer c.Unlock()
+
+	return len(c.ranges)
+}
+
+// AddRange attributes the addresses within the prefix to the provider.
+func (c *Classifier) AddRange(prefix netip.Prefix, provider, region, service string) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.ranges = append(c.ranges, &addrRange{
+		Prefix:   prefix.Masked(),
+		Provider: provider,
+		Region:   region,
+		Service:  service,
+	})
+	c.sorted = false
+}
+
+type awsRanges struct {
+	Prefixes []struct {
+		Prefix  string `json:"ip_prefix"`
+		Region  string `json:"region"`
+		Service string `json:"service"`
+	} `json:"prefixes"`
+	IPv6Prefixes []struct {
+		Prefix  string `json:"ipv6_prefix"`
+		Region  string `json:"region"`
+		Service string `json:"service"`
+	} `json:"ipv6_prefixes"`
+}
+
+// LoadAWS adds the ranges from the ip-ranges.json file published by AWS.
+func (c *Classifier) LoadAWS(r io.Reader) error {
+	var data awsRanges
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return fmt.Errorf("failed to decode the AWS ranges: %v", err)
+	}
+
+	add := func(cidr, region, service string) {
+		// The AMAZON service lists all the address space, including the ranges of the other services
+		if service == "AMAZON" {
+			service = ""
+		}
+		if region == "GLOBAL" {
+			region = ""
+		}
+		if prefix, err := netip.ParsePrefix(cidr); err == nil {
+			c.AddRange(prefix, AWS, region, service)
+		}
+	}
+	for _, p := range data.Prefixes {
+		add(p.Prefix, p.Region, p.Service)
+	}
+	for _, p := range data.IPv6Prefixes {
+		add(p.Prefix, p.Region, p.Service)
+	}
+	return nil
+}
+
+type gcpRanges struct {
+	Prefixes []struct {
+		IPv4Prefix string `json:"ipv4Prefix"`
+		IPv6Prefix string `json:"ipv6Prefix"`
+		Service    string `json:"service"`
+		Scope      string `json:"scope"`
+	} `json:"prefixes"`
+}
+
+// LoadGCP adds the ranges from the cloud.json file published by Google Cloud.
+func (c *Classifier) LoadGCP(r io.Reader) error {
+	var data gcpRanges
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return fmt.Errorf("failed to decode the GCP ranges: %v", err)
+	}
+
+	for _, p := range data.Prefixes {
+		cidr := p.IPv4Prefix
+		if cidr == "" {
+			cidr = p.IPv6Prefix
+		}
+		region := p.Scope
+		if region == "global" {
+			region = ""
+		}
+		if prefix, err := netip.ParsePrefix(cidr); err == nil {
+			c.AddRange(prefix, GCP, region, "")
+		}
+	}
+	return nil
+}
+
+// LoadList adds the ranges from a text file providing a CIDR at the start of each line.
+func (c *Classifier) LoadList(r io.Reader, provider, service string) error {
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if prefix, err := netip.ParsePrefix(strings.Fields(line)[0]); err == nil {
+			c.AddRange(prefix, provider, "", service)
+		}
+	}
+	return scanner.Err()
+}
+
+// sortRanges orders the ranges so the most specific prefix, and then the range naming a service, is found first.
+func (c *Classifier) sortRanges() {
+	if c.sorted {
+		return
+	}
+
+	sort.SliceStable(c.ranges, func(i, j int) bool {
+		if bi, bj := c.ranges[i].Prefix.Bits(), c.ranges[j].Prefix.Bits(); bi != bj {
+			return bi > bj
+		}
+		return c.ranges[i].Service != "" && c.ranges[j].Service == ""
+	})
+	c.sorted = true
+}
+
+// ClassifyAddr returns the attribution of the address, or nil when it is not within the ranges of a provider.
+func (c *Classifier) ClassifyAddr(addr netip.Addr) *Attribution {
+	c.Lock()
+	defer c.Unlock()
+
+	c.sortRanges()
+	addr = addr.Unmap()
+
+	for _, r := range c.ranges {
+		if r.Prefix.Contains(addr) {
+			return &Attribution{
+				Provider: r.Provider,
+				Region:   r.Region,
+				Service:  r.Service,
+				Evidence: "address range " + r.Prefix.String(),
+			}
+		}
+	}
+	return nil
+}
+
+// ClassifyName returns the attribution of the name, such as a CNAME target, or nil when the name
+// is not assigned by a known cloud service.
+func (c *Classifier) ClassifyName(name string) *Attribution {
+	name = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+
+	for _, s := range nameSuffixes {
+		if name != s.Suffix && !strings.HasSuffix(name, "."+s.Suffix) {
+			continue
+		}
+
+		a := &Attribution{
+			Provider: s.Provider,
+			Service:  s.Service,
+			Evidence: "name suffix " + s.Suffix,
+		}
+		labels := strings.Split(strings.TrimSuffix(name, s.Suffix), ".")
+		switch s.Provider {
+		case AWS:
+			// Labels closest to the suffix identify the service, as in bucket.s3.us-west-2.amazonaws.com
+			for i := len(labels) - 1; i >= 0; i-- {
+				l := labels[i]
+
+				if awsRegion.MatchString(l) {
+					a.Region = l
+				} else if a.Service == "" && (l == "s3" || strings.HasPrefix(l, "s3-")) {
+					a.Service = "S3"
+				} else if a.Service == "" && strings.HasPrefix(l, "compute") {
+					a.Service = "EC2"
+				}
+			}
+		case Azure:
+			// Names assigned to virtual machines provide the region, as in name.eastus.cloudapp.azure.com
+			if s.Suffix == "cloudapp.azure.com" && len(labels) >= 3 && azureRegion.MatchString(labels[len(labels)-2]) {
+				a.Region = labels[len(labels)-2]
+			}
+		}
+		return a
+	}
+	return nil
+}
+
+// ClassifyASN returns the attribution of the autonomous system, or nil when it is not operated by a provider.
+func (c *Classifier) ClassifyASN(asn int) *Attribution {
+	if provider, found := providerASNs[asn]; found {
+		return &Attribution{
+			Provider: provider,
+			Evidence: "autonomous system AS" + strconv.Itoa(asn),
+		}
+	}
+	return nil
+}
+
+// Filter selects the attributions matching all of the provided criteria. The zero value matches every attribution.
+type Filter struct {
+	Provider string
+	Region   string
+	Service  string
+}
+
+// Match returns true when the attribution satisfies the criteria of the filter.
+func (fl *Filter) Match(a *Attribution) bool {
+	if a == nil {
+		return false
+	}
+	return matchFold(fl.Provider, a.Provider) && matchFold(fl.Region, a.Region) && matchFold(fl.Service, a.Service)
+}
+
+func matchFold(want, value string) bool {
+	return want == "" || strings.EqualFold(strings.TrimSpace(want), value)
+}
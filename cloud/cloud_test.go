@@ -0,0 +1,155 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package cloud
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+)
+
+const testAWSRanges = `{
+  "syncToken": "1700000000",
+  "prefixes": [
+    {"ip_prefix": "3.0.0.0/9", "region": "us-east-1", "service": "AMAZON", "network_border_group": "us-east-1"},
+    {"ip_prefix": "3.5.0.0/16", "region": "us-east-1", "service": "CLOUDFRONT", "network_border_group": "us-east-1"},
+    {"ip_prefix": "3.5.0.0/16", "region": "us-east-1", "service": "AMAZON", "network_border_group": "us-east-1"},
+    {"ip_prefix": "13.32.0.0/15", "region": "GLOBAL", "service": "CLOUDFRONT", "network_border_group": "GLOBAL"}
+  ],
+  "ipv6_prefixes": [
+    {"ipv6_prefix": "2600:9000::/28", "region": "GLOBAL", "service": "CLOUDFRONT", "network_border_group": "GLOBAL"}
+  ]
+}`
+
+const testGCPRanges = `{
+  "syncToken": "1700000000",
+  "prefixes": [
+    {"ipv4Prefix": "34.80.0.0/15", "service": "Google Cloud", "scope": "asia-east1"},
+    {"ipv6Prefix": "2600:1900:4010::/44", "service": "Google Cloud", "scope": "europe-west1"}
+  ]
+}`
+
+func TestClassifyAddr(t *testing.T) {
+	c := NewClassifier()
+	if err := c.LoadAWS(strings.NewReader(testAWSRanges)); err != nil {
+		t.Fatalf("Failed to load the AWS ranges: %v", err)
+	}
+	if err := c.LoadGCP(strings.NewReader(testGCPRanges)); err != nil {
+		t.Fatalf("Failed to load the GCP ranges: %v", err)
+	}
+	if err := c.LoadList(strings.NewReader("# Cloudflare\n104.16.0.0/13\n\n2606:4700::/32\n"), Cloudflare, ""); err != nil {
+		t.Fatalf("Failed to load the Cloudflare ranges: %v", err)
+	}
+	if c.Len() != 9 {
+		t.Errorf("Expected 9 ranges, got %d", c.Len())
+	}
+
+	cases := []struct {
+		addr     string
+		expected *Attribution
+	}{
+		{"3.5.1.1", &Attribution{Provider: AWS, Region: "us-east-1", Service: "CLOUDFRONT"}},
+		{"3.100.1.1", &Attribution{Provider: AWS, Region: "us-east-1"}},
+		{"13.33.0.1", &Attribution{Provider: AWS, Service: "CLOUDFRONT"}},
+		{"2600:9000::1", &Attribution{Provider: AWS, Service: "CLOUDFRONT"}},
+		{"34.81.2.3", &Attribution{Provider: GCP, Region: "asia-east1"}},
+		{"2600:1900:4010::1", &Attribution{Provider: GCP, Region: "europe-west1"}},
+		{"104.18.0.1", &Attribution{Provider: Cloudflare}},
+		{"::ffff:104.18.0.1", &Attribution{Provider: Cloudflare}},
+		{"192.0.2.1", nil},
+	}
+	for _, tc := range cases {
+		got := c.ClassifyAddr(netip.MustParseAddr(tc.addr))
+		if tc.expected == nil {
+			if got != nil {
+				t.Errorf("%s: expected no attribution, got %+v", tc.addr, got)
+			}
+			continue
+		}
+		if got == nil || got.Provider != tc.expected.Provider || got.Region != tc.expected.Region || got.Service != tc.expected.Service {
+			t.Errorf("%s: expected %+v, got %+v", tc.addr, tc.expected, got)
+		}
+	}
+}
+
+func TestClassifyName(t *testing.T) {
+	c := NewClassifier()
+
+	cases := []struct {
+		name     string
+		expected *Attribution
+	}{
+		{"d111111abcdef8.cloudfront.net.", &Attribution{Provider: AWS, Service: "CLOUDFRONT"}},
+		{"my-lb-123.us-west-2.elb.amazonaws.com", &Attribution{Provider: AWS, Region: "us-west-2", Service: "ELB"}},
+		{"assets.s3.eu-central-1.amazonaws.com", &Attribution{Provider: AWS, Region: "eu-central-1", Service: "S3"}},
+		{"ec2-3-5-1-1.compute-1.amazonaws.com", &Attribution{Provider: AWS, Service: "EC2"}},
+		{"vm1.eastus.cloudapp.azure.com", &Attribution{Provider: Azure, Region: "eastus", Service: "Virtual Machines"}},
+		{"shop.azureedge.net", &Attribution{Provider: Azure, Service: "Azure CDN"}},
+		{"ghs.googlehosted.com", &Attribution{Provider: GCP, Service: "App Engine"}},
+		{"www.example.com.cdn.cloudflare.net", &Attribution{Provider: Cloudflare, Service: "CDN"}},
+		{"www.example.com.edgekey.net", &Attribution{Provider: Akamai, Service: "CDN"}},
+		{"notcloudfront.net", nil},
+		{"www.example.com", nil},
+	}
+	for _, tc := range cases {
+		got := c.ClassifyName(tc.name)
+		if tc.expected == nil {
+			if got != nil {
+				t.Errorf("%s: expected no attribution, got %+v", tc.name, got)
+			}
+			continue
+		}
+		if got == nil || got.Provider != tc.expected.Provider || got.Region != tc.expected.Region || got.Service != tc.expected.Service {
+			t.Errorf("%s: expected %+v, got %+v", tc.name, tc.expected, got)
+		}
+	}
+
+	if a := c.ClassifyASN(13335); a == nil || a.Provider != Cloudflare || a.Evidence != "autonomous system AS13335" {
+		t.Errorf("Unexpected attribution of AS13335: %+v", a)
+	}
+	if a := c.ClassifyASN(64496); a != nil {
+		t.Errorf("Expected no attribution of AS64496, got %+v", a)
+	}
+}
+
+func TestAssets(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	_ = g.UpsertCNAME(ctx, "www.owasp.org", "d111111abcdef8.cloudfront.net")
+	_ = g.UpsertA(ctx, "d111111abcdef8.cloudfront.net", "13.33.0.1")
+	_ = g.UpsertA(ctx, "vpn.owasp.org", "198.51.100.5")
+	_ = g.UpsertInfrastructure(ctx, 20940, "AKAMAI-ASN1", "198.51.100.5", "198.51.100.0/24")
+	_ = g.UpsertA(ctx, "mail.owasp.org", "192.0.2.11")
+
+	c := NewClassifier()
+	if err := c.LoadAWS(strings.NewReader(testAWSRanges)); err != nil {
+		t.Fatalf("Failed to load the AWS ranges: %v", err)
+	}
+
+	assets := Assets(ctx, g, c, []string{"owasp.org"}, time.Time{})
+	if len(assets) != 3 {
+		t.Fatalf("Expected 3 attributions, got %d", len(assets))
+	}
+	if a := assets[0]; a.Name != "vpn.owasp.org" || a.Address != "198.51.100.5" || a.Provider != Akamai {
+		t.Errorf("Unexpected attribution: %+v", a)
+	}
+	if a := assets[1]; a.Name != "www.owasp.org" || a.Address != "13.33.0.1" || a.Service != "CLOUDFRONT" {
+		t.Errorf("Unexpected attribution: %+v", a)
+	}
+	if a := assets[2]; a.Name != "www.owasp.org" || a.Target != "d111111abcdef8.cloudfront.net" || a.Service != "CLOUDFRONT" {
+		t.Errorf("Unexpected attribution: %+v", a)
+	}
+
+	fl := &Filter{Provider: "akamai"}
+	if !fl.Match(&assets[0].Attribution) || fl.Match(&assets[1].Attribution) {
+		t.Error("The filter did not select the Akamai attribution")
+	}
+}
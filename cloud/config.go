@@ -0,0 +1,90 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/owasp-amass/amass/v4/datasets"
+	"github.com/owasp-amass/config/config"
+)
+
+// rangeDatasets maps the datasets providing the published address ranges to the functions loading them.
+var rangeDatasets = []struct {
+	Name string
+	Load func(*Classifier, io.Reader) error
+}{
+	{"aws-ip-ranges", (*Classifier).LoadAWS},
+	{"gcp-ip-ranges", (*Classifier).LoadGCP},
+	{"cloudflare-ipv4", loadCloudflare},
+	{"cloudflare-ipv6", loadCloudflare},
+}
+
+func loadCloudflare(c *Classifier, r io.Reader) error {
+	return c.LoadList(r, Cloudflare, "")
+}
+
+// FromConfig returns the Classifier for the 'cloud' section of the configuration options.
+// A nil Classifier is returned when the attribution has been disabled. The published address
+// ranges are downloaded as datasets, and a range that cannot be obtained is only logged, since
+// the names and autonomous systems of the providers are still recognized without them.
+func FromConfig(ctx context.Context, cfg *config.Config) (*Classifier, error) {
+	enabled, ranges := true, true
+
+	if cloudRaw, ok := cfg.Options["cloud"]; ok {
+		settings, ok := cloudRaw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cloud is not a map[string]interface{}")
+		}
+
+		if raw, ok := settings["enabled"]; ok {
+			if enabled, ok = raw.(bool); !ok {
+				return nil, fmt.Errorf("cloud enabled is not a bool")
+			}
+		}
+		if raw, ok := settings["ranges"]; ok {
+			if ranges, ok = raw.(bool); !ok {
+				return nil, fmt.Errorf("cloud ranges is not a bool")
+			}
+		}
+	}
+	if !enabled {
+		return nil, nil
+	}
+
+	c := NewClassifier()
+	if !ranges {
+		return c, nil
+	}
+
+	mgr, err := datasets.FromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, ds := range rangeDatasets {
+		if err := loadDataset(ctx, mgr, c, ds.Name, ds.Load); err != nil && cfg.Log != nil {
+			cfg.Log.Printf("Cloud attribution: the %s ranges were not loaded: %v", ds.Name, err)
+		}
+	}
+	return c, nil
+}
+
+func loadDataset(ctx context.Context, mgr *datasets.Manager, c *Classifier, name string, load func(*Classifier, io.Reader) error) error {
+	path, err := mgr.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return load(c, f)
+}
@@ -0,0 +1,298 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/api"
+	"github.com/owasp-amass/amass/v4/cloud"
+	"github.com/owasp-amass/amass/v4/dnscache"
+	"github.com/owasp-amass/amass/v4/enum"
+	"github.com/owasp-amass/amass/v4/resources"
+	"github.com/owasp-amass/amass/v4/scheduler"
+	"github.com/owasp-amass/amass/v4/scope"
+	"github.com/owasp-amass/amass/v4/settings"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	apiUsageMsg = "api [options]"
+)
+
+type apiArgs struct {
+	Address string
+	Options struct {
+		NoColor bool
+		Silent  bool
+	}
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+func runAPICommand(clArgs []string) {
+	var args apiArgs
+	var help1, help2 bool
+	apiCommand := flag.NewFlagSet("api", flag.ContinueOnError)
+
+	apiBuf := new(bytes.Buffer)
+	apiCommand.SetOutput(apiBuf)
+
+	apiCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	apiCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	apiCommand.StringVar(&args.Address, "addr", "", "Address the REST API server listens on (default: "+api.DefaultAddress+")")
+	apiCommand.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	apiCommand.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
+	apiCommand.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	apiCommand.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+
+	if err := apiCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(apiUsageMsg, apiCommand, apiBuf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Options.Silent {
+		color.Output = io.Discard
+		color.Error = io.Discard
+	}
+
+	cfg := config.NewConfig()
+	if err := settings.Load("api", cfg, args.Filepaths.Directory, args.Filepaths.ConfigFile); err != nil {
+		r.Fprintf(color.Error, "Failed to load the configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Filepaths.Directory != "" {
+		cfg.Dir = args.Filepaths.Directory
+	}
+
+	if err := serveAPI(cfg, args.Address); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+// serveAPI serves the REST API using the configuration until the user stops the server. The errors are
+// returned, so the graph database is locked again before the command exits.
+func serveAPI(cfg *config.Config, address string) error {
+	addr, keys, err := api.FromConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("configuration error: %v", err)
+	}
+	if address != "" {
+		addr = address
+	}
+
+	tenants, err := api.TenantsFromConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("configuration error: %v", err)
+	}
+	tokens, err := api.TokensFromConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("configuration error: %v", err)
+	}
+	maxSessions, err := api.MaxSessions(cfg)
+	if err != nil {
+		return fmt.Errorf("configuration error: %v", err)
+	}
+
+	workers, lease, err := api.Workers(cfg)
+	if err != nil {
+		return fmt.Errorf("configuration error: %v", err)
+	}
+	shards, err := api.JobShards(cfg)
+	if err != nil {
+		return fmt.Errorf("configuration error: %v", err)
+	}
+
+	logs, err := api.SessionLogs(cfg)
+	if err != nil {
+		return fmt.Errorf("configuration error: %v", err)
+	}
+
+	g, err := openGraphDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer lockGraphDatabase(cfg)
+
+	classifier, err := cloud.FromConfig(context.Background(), cfg)
+	if err != nil {
+		return fmt.Errorf("configuration error: %v", err)
+	}
+	notes, err := openAnnotationStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer lockGraphDatabase(cfg)
+	defer notes.Close()
+	review, err := scope.Open(scope.Path(cfg))
+	if err != nil {
+		return err
+	}
+
+	handler := api.NewServer(g, keys)
+	handler.SetClassifier(classifier)
+	handler.SetAnnotations(notes)
+	handler.SetTenants(tenants)
+	handler.SetTokens(tokens)
+	handler.SetScopeReview(review)
+	// The sessions are only available when a token grants the creation of enumerations
+	for _, t := range tokens {
+		if t.Role < api.Operator {
+			continue
+		}
+		// The workers execute the jobs of the sessions in place of the server
+		if workers {
+			handler.SetWorkers(maxSessions, lease)
+			if err := handler.SetJobShards(shards); err != nil {
+				return err
+			}
+		} else {
+			handler.SetEnumerator(sessionEnumerator(cfg), maxSessions)
+		}
+		if err := handler.SetSessionLogs(logs.Dir, logs.MaxSize, logs.Backups); err != nil {
+			return err
+		}
+		break
+	}
+	defer handler.Close()
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	// Shutdown the server once the user requests it
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(quit)
+
+		<-quit
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+	}()
+
+	if len(keys) == 0 && len(tenants) == 0 && len(tokens) == 0 {
+		fgY.Fprintln(color.Error, "No API keys were configured, so the endpoints do not require authentication")
+	}
+	fmt.Fprintf(color.Output, "The REST API is being served at %s\n", green("http://"+addr))
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// sessionEnumerator returns the Enumerator executing the sessions requested through the API, using the
+// configuration provided by the request along with the output directory and graph database of the server.
+func sessionEnumerator(server *config.Config) api.Enumerator {
+	return func(ctx context.Context, req *api.SessionRequest, logger *log.Logger) (*api.SessionResult, error) {
+		// The settings reading files are refused, since the configuration is provided by the clients of the
+		// server, and by the server to the workers
+		if err := settings.CheckSession(req.Config); err != nil {
+			return nil, err
+		}
+
+		cfg := config.NewConfig()
+		if req.Config != "" {
+			f, err := os.CreateTemp("", "amass-session-*.yaml")
+			if err != nil {
+				return nil, err
+			}
+			defer os.Remove(f.Name())
+
+			_, err = f.WriteString(req.Config)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err == nil {
+				err = cfg.LoadSettings(f.Name())
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		// The server unlocks the encrypted database, and keeps it unlocked while the sessions use it
+		cfg.Dir = server.Dir
+		cfg.GraphDBs = server.GraphDBs
+		// The session configuration cannot provide the data source credentials, which are read by the server
+		cfg.DataSrcConfigs = server.DataSrcConfigs
+		// The sessions use the DNS cache of the server, and the responses are kept apart for each tenant
+		if cache := dnscache.WithNamespace(server, req.Tenant); cache != nil {
+			cfg.Options["dns_cache"] = cache
+		}
+		if req.Priority > 0 {
+			dispatch, _ := cfg.Options["dispatch"].(map[string]interface{})
+			if dispatch == nil {
+				dispatch = make(map[string]interface{})
+			}
+			dispatch["priority"] = req.Priority
+			cfg.Options["dispatch"] = dispatch
+		}
+		cfg.AddDomains(req.Domains...)
+		cfg.Log = logger
+
+		// The names guessed from the wordlists are only resolved by the first shard of a domain
+		if req.Shard > 0 {
+			cfg.BruteForcing = false
+			cfg.Alterations = false
+		}
+		if cfg.BruteForcing && len(cfg.Wordlist) == 0 {
+			if f, err := resources.GetResourceFile("namelist.txt"); err == nil {
+				if list, err := getWordList(f); err == nil {
+					cfg.Wordlist = list
+				}
+			}
+		}
+
+		res, err := scheduler.Execute(ctx, cfg, func(e *enum.Enumeration) {
+			// The session endpoints pause and resume the enumeration
+			api.SetPauser(ctx, e)
+			e.ShardSources(req.Shard, req.Shards)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		known := make(map[string]struct{}, len(res.Before))
+		for _, name := range res.Before {
+			known[name] = struct{}{}
+		}
+		var names []string
+		for _, name := range res.After {
+			if _, found := known[name]; !found {
+				names = append(names, name)
+			}
+		}
+		logger.Printf("The enumeration discovered %d new names", len(names))
+
+		result := &api.SessionResult{Names: names, Errors: res.Errors}
+		if res.Completion != nil {
+			result.Completion = res.Completion.Reason
+		}
+		return result, nil
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/analysis"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/settings"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	assocUsageMsg = "assoc [options] -d DOMAIN"
+)
+
+type assocArgs struct {
+	Domains       *stringset.Set
+	Pivots        format.ParseStrings
+	MinConfidence int
+	Options       struct {
+		JSON    bool
+		NoColor bool
+		Silent  bool
+	}
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+		Domains    format.ParseStrings
+	}
+}
+
+func runAssocCommand(clArgs []string) {
+	args := assocArgs{Domains: stringset.New()}
+	defer args.Domains.Close()
+	var help1, help2 bool
+	assocCommand := flag.NewFlagSet("assoc", flag.ContinueOnError)
+
+	assocBuf := new(bytes.Buffer)
+	assocCommand.SetOutput(assocBuf)
+
+	assocCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	assocCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	assocCommand.Var(args.Domains, "d", "Domain names separated by commas (can be used multiple times)")
+	assocCommand.Var(&args.Pivots, "pivot", "Pivots separated by commas: "+strings.Join(analysis.AssociationPivots, ", "))
+	assocCommand.IntVar(&args.MinConfidence, "min", 0, "Minimum confidence of the associations, from 0 to 100")
+	assocCommand.BoolVar(&args.Options.JSON, "json", false, "Print the associations as JSON lines")
+	assocCommand.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	assocCommand.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
+	assocCommand.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	assocCommand.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database and findings file")
+	assocCommand.Var(&args.Filepaths.Domains, "df", "Path to a file providing root domain names")
+
+	if err := assocCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(assocUsageMsg, assocCommand, assocBuf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Options.Silent {
+		color.Output = io.Discard
+		color.Error = io.Discard
+	}
+
+	pivots := make(map[string]bool)
+	for _, p := range args.Pivots {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if !containsPivot(p) {
+			r.Fprintf(color.Error, "%s is not a pivot\n", p)
+			os.Exit(1)
+		}
+		pivots[p] = true
+	}
+
+	for _, f := range args.Filepaths.Domains {
+		list, err := config.GetListFromFile(f)
+		if err != nil {
+			r.Fprintf(color.Error, "Failed to parse the domain names file: %v\n", err)
+			os.Exit(1)
+		}
+		args.Domains.InsertMany(list...)
+	}
+
+	cfg := config.NewConfig()
+	if err := settings.Load("assoc", cfg, args.Filepaths.Directory, args.Filepaths.ConfigFile); err != nil {
+		r.Fprintf(color.Error, "Failed to load the configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Filepaths.Directory != "" {
+		cfg.Dir = args.Filepaths.Directory
+	}
+	cfg.AddDomains(args.Domains.Slice()...)
+	if len(cfg.Domains()) == 0 {
+		r.Fprintln(color.Error, "No root domain names were provided")
+		os.Exit(1)
+	}
+
+	all, err := findings.ReadFile(filepath.Join(config.OutputDirectory(cfg.Dir), "findings.json"))
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	assocs, err := domainAssociations(cfg, all)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	enc := json.NewEncoder(color.Output)
+	for _, a := range assocs {
+		if a.Confidence < args.MinConfidence || (len(pivots) > 0 && !pivots[a.Pivot]) {
+			continue
+		}
+
+		if args.Options.JSON {
+			if err := enc.Encode(a); err != nil {
+				r.Fprintf(color.Error, "Failed to encode the association: %v\n", err)
+				os.Exit(1)
+			}
+			continue
+		}
+		printAssociation(a)
+	}
+}
+
+// domainAssociations returns the associations of each configured domain. The graph database is
+// locked again before returning, since the command exits on errors once they have been read.
+func domainAssociations(cfg *config.Config, all []*findings.Finding) ([]*analysis.DomainAssociation, error) {
+	g, err := openGraphDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer lockGraphDatabase(cfg)
+
+	var assocs []*analysis.DomainAssociation
+	for _, d := range cfg.Domains() {
+		assocs = append(assocs, analysis.DomainAssociations(context.Background(), g, all, d)...)
+	}
+	return assocs, nil
+}
+
+func containsPivot(pivot string) bool {
+	for _, p := range analysis.AssociationPivots {
+		if p == pivot {
+			return true
+		}
+	}
+	return false
+}
+
+func printAssociation(a *analysis.DomainAssociation) {
+	conf := fmt.Sprintf("%3d%%", a.Confidence)
+	switch {
+	case a.Confidence >= 70:
+		conf = green(conf)
+	case a.Confidence >= 30:
+		conf = yellow(conf)
+	default:
+		conf = blue(conf)
+	}
+
+	fmt.Fprintf(color.Output, "%s %s %s %s (%s)\n", conf, green(a.Domain),
+		magenta(fmt.Sprintf("%-10s", a.Pivot)), a.Target, strings.Join(a.Evidence, ", "))
+}
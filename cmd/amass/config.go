@@ -0,0 +1,118 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/settings"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	configUsageMsg          = "config effective [options]"
+	configEffectiveUsageMsg = "config effective [-command NAME] [enum options]"
+)
+
+func runConfigCommand(clArgs []string) {
+	configBuf := new(bytes.Buffer)
+	configCommand := flag.NewFlagSet("config", flag.ContinueOnError)
+	configCommand.SetOutput(configBuf)
+
+	if len(clArgs) < 1 {
+		commandUsage(configUsageMsg, configCommand, configBuf)
+		return
+	}
+
+	switch clArgs[0] {
+	case "effective":
+		runEffectiveCommand(clArgs[1:])
+	default:
+		commandUsage(configUsageMsg, configCommand, configBuf)
+		os.Exit(1)
+	}
+}
+
+// runEffectiveCommand prints the configuration resolved for the command, along with the layer providing each value.
+// The enum flags are accepted, so the settings used by an enumeration can be checked before it is started.
+func runEffectiveCommand(clArgs []string) {
+	args := enumArgs{
+		AltWordList:       stringset.New(),
+		AltWordListMask:   stringset.New(),
+		BruteWordList:     stringset.New(),
+		BruteWordListMask: stringset.New(),
+		Blacklist:         stringset.New(),
+		Domains:           stringset.New(),
+		Excluded:          stringset.New(),
+		Included:          stringset.New(),
+		Names:             stringset.New(),
+		Resolvers:         stringset.New(),
+		Trusted:           stringset.New(),
+	}
+	var help1, help2 bool
+	var command string
+	effectiveCommand := flag.NewFlagSet("effective", flag.ContinueOnError)
+
+	effectiveBuf := new(bytes.Buffer)
+	effectiveCommand.SetOutput(effectiveBuf)
+
+	effectiveCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	effectiveCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	effectiveCommand.StringVar(&command, "command", "enum", "Name of the command whose configuration file overrides are applied")
+	defineEnumArgumentFlags(effectiveCommand, &args)
+	defineEnumOptionFlags(effectiveCommand, &args)
+	defineEnumFilepathFlags(effectiveCommand, &args)
+
+	if err := effectiveCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(configEffectiveUsageMsg, effectiveCommand, effectiveBuf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Options.Silent {
+		color.Output = io.Discard
+		color.Error = io.Discard
+	}
+	if err := processEnumInputFiles(&args); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	cfg := config.NewConfig()
+	loader := settings.NewLoader(command, cfg)
+	if err := loader.Load(args.Filepaths.Directory, args.Filepaths.ConfigFile); err != nil {
+		r.Fprintf(color.Error, "Failed to load the configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Profile != "" {
+		if err := loader.ApplyProfile(args.Profile); err != nil {
+			r.Fprintf(color.Error, "Configuration error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if len(cfg.Resolvers) > 0 && args.Resolvers.Len() == 0 {
+		args.Resolvers = stringset.New(cfg.Resolvers...)
+	}
+	if err := cfg.UpdateConfig(args); err != nil {
+		r.Fprintf(color.Error, "Configuration error: %v\n", err)
+		os.Exit(1)
+	}
+	loader.Record(settings.FlagLayer)
+
+	for _, v := range loader.Values() {
+		fmt.Fprintf(color.Output, "%s %s %s\n", blue(fmt.Sprintf("%-45s", v.Key)), yellow(fmt.Sprintf("%-9s", v.Layer)), v.Value)
+	}
+}
@@ -0,0 +1,883 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/annotations"
+	"github.com/owasp-amass/amass/v4/confidence"
+	"github.com/owasp-amass/amass/v4/dbcrypt"
+	"github.com/owasp-amass/amass/v4/enum"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/importer"
+	"github.com/owasp-amass/amass/v4/prune"
+	"github.com/owasp-amass/amass/v4/schema"
+	"github.com/owasp-amass/amass/v4/search"
+	"github.com/owasp-amass/amass/v4/settings"
+	"github.com/owasp-amass/config/config"
+	oam "github.com/owasp-amass/open-asset-model"
+)
+
+const (
+	dbUsageMsg      = "db search|upgrade|import|prune|tag|note [options]"
+	searchUsageMsg  = "db search [-regex] [-type fqdn|org] [-limit N] PATTERN"
+	upgradeUsageMsg = "db upgrade [-check] [options]"
+	importUsageMsg  = "db import [-format subfinder|massdns|dnsx|nmap|amass3] [-d domain] FILE..."
+	pruneUsageMsg   = "db prune [-max-age DAYS] [-superseded DAYS] [-vacuum] [-dry-run] [options]"
+	tagUsageMsg     = "db tag [-rm KEY] [options] ASSET [KEY=VALUE ...]"
+	noteUsageMsg    = "db note [options] ASSET TEXT"
+)
+
+type searchArgs struct {
+	Limit   int
+	Types   format.ParseStrings
+	Options struct {
+		JSON    bool
+		NoColor bool
+		Regex   bool
+		Silent  bool
+	}
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+type importArgs struct {
+	Domains *stringset.Set
+	Format  string
+	Options struct {
+		NoColor bool
+		Silent  bool
+	}
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+type pruneArgs struct {
+	MaxAge     int
+	Superseded int
+	Options    struct {
+		DryRun  bool
+		JSON    bool
+		NoColor bool
+		Silent  bool
+		Vacuum  bool
+	}
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+type annotateArgs struct {
+	Author  string
+	Remove  format.ParseStrings
+	Options struct {
+		JSON    bool
+		NoColor bool
+		Silent  bool
+	}
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+type upgradeArgs struct {
+	Options struct {
+		Check   bool
+		NoColor bool
+		Silent  bool
+	}
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+func runDBCommand(clArgs []string) {
+	dbBuf := new(bytes.Buffer)
+	dbCommand := flag.NewFlagSet("db", flag.ContinueOnError)
+	dbCommand.SetOutput(dbBuf)
+
+	if len(clArgs) < 1 {
+		commandUsage(dbUsageMsg, dbCommand, dbBuf)
+		return
+	}
+
+	switch clArgs[0] {
+	case "search":
+		runSearchCommand(clArgs[1:])
+	case "upgrade":
+		runUpgradeCommand(clArgs[1:])
+	case "import":
+		runImportCommand(clArgs[1:])
+	case "prune":
+		runPruneCommand(clArgs[1:])
+	case "tag":
+		runTagCommand(clArgs[1:])
+	case "note":
+		runNoteCommand(clArgs[1:])
+	default:
+		commandUsage(dbUsageMsg, dbCommand, dbBuf)
+		os.Exit(1)
+	}
+}
+
+func runSearchCommand(clArgs []string) {
+	var args searchArgs
+	var help1, help2 bool
+	searchCommand := flag.NewFlagSet("search", flag.ContinueOnError)
+
+	searchBuf := new(bytes.Buffer)
+	searchCommand.SetOutput(searchBuf)
+
+	searchCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	searchCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	searchCommand.IntVar(&args.Limit, "limit", search.DefaultLimit, "Maximum number of matches to print")
+	searchCommand.Var(&args.Types, "type", "Asset types separated by commas to search (fqdn, org)")
+	searchCommand.BoolVar(&args.Options.JSON, "json", false, "Print the matches as JSON")
+	searchCommand.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	searchCommand.BoolVar(&args.Options.Regex, "regex", false, "Treat the pattern as a regular expression instead of a glob")
+	searchCommand.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
+	searchCommand.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	searchCommand.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+
+	if len(clArgs) < 1 {
+		commandUsage(searchUsageMsg, searchCommand, searchBuf)
+		return
+	}
+	if err := searchCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(searchUsageMsg, searchCommand, searchBuf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Options.Silent {
+		color.Output = io.Discard
+		color.Error = io.Discard
+	}
+	if searchCommand.NArg() != 1 {
+		r.Fprintln(color.Error, "Exactly one search pattern must be provided")
+		os.Exit(1)
+	}
+
+	var types []oam.AssetType
+	for _, name := range args.Types {
+		t, err := search.ParseType(name)
+		if err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+			os.Exit(1)
+		}
+		types = append(types, t)
+	}
+
+	cfg := config.NewConfig()
+	if err := settings.Load("db", cfg, args.Filepaths.Directory, args.Filepaths.ConfigFile); err != nil {
+		r.Fprintf(color.Error, "Failed to load the configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Filepaths.Directory != "" {
+		cfg.Dir = args.Filepaths.Directory
+	}
+
+	matches, err := searchGraph(cfg, &search.Query{
+		Pattern: searchCommand.Arg(0),
+		Regex:   args.Options.Regex,
+		Types:   types,
+		Limit:   args.Limit,
+	})
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	if args.Options.JSON {
+		enc := json.NewEncoder(color.Output)
+		enc.SetIndent("", "  ")
+		if matches == nil {
+			matches = []*search.Match{}
+		}
+		_ = enc.Encode(matches)
+		return
+	}
+	for _, m := range matches {
+		fmt.Fprintf(color.Output, "%s %s %s\n", green(m.Name), blue(string(m.Type)), yellow(m.LastSeen.Format(time.RFC3339)))
+	}
+}
+
+// searchGraph returns the assets in the graph database matching the query. The graph database is locked
+// again before returning, since the command exits on errors.
+func searchGraph(cfg *config.Config, q *search.Query) ([]*search.Match, error) {
+	system, dsn, _, err := primaryGraphDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer lockGraphDatabase(cfg)
+
+	if schema.Versioned(system) {
+		if _, _, err := schema.Upgrade(system, dsn); err != nil {
+			return nil, err
+		}
+	}
+
+	s, err := search.Open(system, dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	return s.Search(context.Background(), q)
+}
+
+func runUpgradeCommand(clArgs []string) {
+	var args upgradeArgs
+	var help1, help2 bool
+	upgradeCommand := flag.NewFlagSet("upgrade", flag.ContinueOnError)
+
+	upgradeBuf := new(bytes.Buffer)
+	upgradeCommand.SetOutput(upgradeBuf)
+
+	upgradeCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	upgradeCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	upgradeCommand.BoolVar(&args.Options.Check, "check", false, "Only report the schema version without upgrading the database")
+	upgradeCommand.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	upgradeCommand.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
+	upgradeCommand.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	upgradeCommand.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+
+	if err := upgradeCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(upgradeUsageMsg, upgradeCommand, upgradeBuf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Options.Silent {
+		color.Output = io.Discard
+		color.Error = io.Discard
+	}
+
+	cfg := config.NewConfig()
+	if err := settings.Load("db", cfg, args.Filepaths.Directory, args.Filepaths.ConfigFile); err != nil {
+		r.Fprintf(color.Error, "Failed to load the configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Filepaths.Directory != "" {
+		cfg.Dir = args.Filepaths.Directory
+	}
+
+	if err := upgradeGraphSchema(cfg, args.Options.Check); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+// upgradeGraphSchema reports the schema version of the graph database, and applies the pending migrations
+// unless only the check was requested. The graph database is locked again before returning.
+func upgradeGraphSchema(cfg *config.Config, check bool) error {
+	system, dsn, _, err := primaryGraphDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer lockGraphDatabase(cfg)
+	if !schema.Versioned(system) {
+		return fmt.Errorf("the %s graph database system does not have a versioned schema", system)
+	}
+
+	status, err := schema.Check(system, dsn)
+	if err != nil {
+		return err
+	}
+	printSchemaStatus(status)
+	if check || status.Current() {
+		return nil
+	}
+
+	status, n, err := schema.Upgrade(system, dsn)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(color.Output, "%s %s\n", green("Applied migrations:"), yellow(strconv.Itoa(n)))
+	printSchemaStatus(status)
+	return nil
+}
+
+func printSchemaStatus(status *schema.Status) {
+	version := status.Version()
+	if status.Unversioned {
+		version = "unversioned"
+	} else if version == "" {
+		version = "empty"
+	}
+
+	fmt.Fprintf(color.Output, "%s %s %s\n", green("Graph database schema:"), blue(status.System), yellow(version))
+	for _, id := range status.Pending {
+		fmt.Fprintf(color.Output, "%s %s\n", blue("Pending migration:"), id)
+	}
+	for _, id := range status.Unknown {
+		fmt.Fprintf(color.Output, "%s %s\n", r.Sprint("Unknown migration:"), id)
+	}
+}
+
+func runImportCommand(clArgs []string) {
+	args := importArgs{Domains: stringset.New()}
+	defer args.Domains.Close()
+
+	var help1, help2 bool
+	importCommand := flag.NewFlagSet("import", flag.ContinueOnError)
+
+	importBuf := new(bytes.Buffer)
+	importCommand.SetOutput(importBuf)
+
+	importCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	importCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	importCommand.Var(args.Domains, "d", "Domain names separated by commas limiting the imported names (can be used multiple times)")
+	importCommand.StringVar(&args.Format, "format", "", "Format of the files (subfinder, massdns, dnsx, nmap or amass3); detected when not provided")
+	importCommand.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	importCommand.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
+	importCommand.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	importCommand.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+
+	if len(clArgs) < 1 {
+		commandUsage(importUsageMsg, importCommand, importBuf)
+		return
+	}
+	if err := importCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(importUsageMsg, importCommand, importBuf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Options.Silent {
+		color.Output = io.Discard
+		color.Error = io.Discard
+	}
+	if importCommand.NArg() == 0 {
+		r.Fprintln(color.Error, "At least one file must be provided")
+		os.Exit(1)
+	}
+
+	var f importer.Format
+	if args.Format != "" {
+		var err error
+		if f, err = importer.ParseFormat(args.Format); err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	cfg := config.NewConfig()
+	if err := settings.Load("db", cfg, args.Filepaths.Directory, args.Filepaths.ConfigFile); err != nil {
+		r.Fprintf(color.Error, "Failed to load the configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Filepaths.Directory != "" {
+		cfg.Dir = args.Filepaths.Directory
+	}
+	cfg.AddDomains(args.Domains.Slice()...)
+	// The imported assets can seed the graph database of a new project
+	createOutputDirectory(cfg)
+	if err := createLocalDatabase(cfg); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	if err := importFiles(cfg, importCommand.Args(), f); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+// importFiles stores the assets read from the files in the graph database. The graph database is locked
+// again before returning, since the command exits on errors.
+func importFiles(cfg *config.Config, paths []string, f importer.Format) error {
+	g, err := openGraphDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer lockGraphDatabase(cfg)
+
+	var filter func(string) bool
+	if len(cfg.Domains()) > 0 {
+		filter = cfg.IsDomainInScope
+	}
+
+	dir := config.OutputDirectory(cfg.Dir)
+	store, err := findings.NewStore(filepath.Join(dir, "findings.json"))
+	if err != nil {
+		return fmt.Errorf("failed to open the findings: %v", err)
+	}
+
+	observed := importObservations(cfg)
+	for _, path := range paths {
+		res, err := importer.ReadFile(path, f)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+
+		stats, err := importer.Store(context.Background(), g, res, filter)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+
+		var services int
+		for _, svc := range res.Services {
+			if filter != nil && !cfg.IsAddressInScope(svc.Address) {
+				continue
+			}
+			port := strconv.Itoa(svc.Port) + "/" + svc.Protocol
+			if svc.Name != "" {
+				port += " (" + svc.Name + ")"
+			}
+
+			if added, err := store.Add(&findings.Finding{
+				Type:        enum.OpenPortFinding,
+				Asset:       svc.Key(),
+				Severity:    findings.Info,
+				Description: fmt.Sprintf("Port %s is open on %s", port, svc.Address),
+				Source:      importer.Source,
+			}); err != nil {
+				r.Fprintf(color.Error, "Failed to save the open port finding: %v\n", err)
+			} else if added {
+				services++
+			}
+		}
+
+		if observed != nil {
+			now := time.Now()
+			for _, name := range res.Names() {
+				if filter == nil || filter(name) {
+					observed.Observe(name, importer.Source, now)
+				}
+			}
+		}
+
+		fmt.Fprintf(color.Output, "%s %s: %s names, %s records, %s netblocks and %s open ports imported",
+			green(path), blue(string(res.Format)), yellow(strconv.Itoa(stats.Names)), yellow(strconv.Itoa(stats.Records)),
+			yellow(strconv.Itoa(stats.Netblocks)), yellow(strconv.Itoa(services)))
+		if stats.Skipped > 0 {
+			fmt.Fprintf(color.Output, ", %s records outside of the domains skipped", yellow(strconv.Itoa(stats.Skipped)))
+		}
+		fmt.Fprintln(color.Output)
+	}
+
+	if observed != nil {
+		if err := observed.Save(); err != nil {
+			r.Fprintf(color.Error, "Failed to save the confidence observations: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+func runPruneCommand(clArgs []string) {
+	var args pruneArgs
+	var help1, help2 bool
+	pruneCommand := flag.NewFlagSet("prune", flag.ContinueOnError)
+
+	pruneBuf := new(bytes.Buffer)
+	pruneCommand.SetOutput(pruneBuf)
+
+	pruneCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	pruneCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	pruneCommand.IntVar(&args.MaxAge, "max-age", 0, "Remove the assets and relations not seen again within this number of days")
+	pruneCommand.IntVar(&args.Superseded, "superseded", 0, "Remove the DNS records last seen this number of days before a newer record of the name")
+	pruneCommand.BoolVar(&args.Options.Vacuum, "vacuum", false, "Reclaim the space released by the removals")
+	pruneCommand.BoolVar(&args.Options.DryRun, "dry-run", false, "Report what would be removed without changing the database")
+	pruneCommand.BoolVar(&args.Options.JSON, "json", false, "Print the report as JSON")
+	pruneCommand.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	pruneCommand.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
+	pruneCommand.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	pruneCommand.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+
+	if err := pruneCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(pruneUsageMsg, pruneCommand, pruneBuf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Options.Silent {
+		color.Output = io.Discard
+		color.Error = io.Discard
+	}
+
+	cfg := config.NewConfig()
+	if err := settings.Load("db", cfg, args.Filepaths.Directory, args.Filepaths.ConfigFile); err != nil {
+		r.Fprintf(color.Error, "Failed to load the configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Filepaths.Directory != "" {
+		cfg.Dir = args.Filepaths.Directory
+	}
+
+	policy, err := prune.FromConfig(cfg)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if args.MaxAge > 0 {
+		policy.MaxAge = time.Duration(args.MaxAge) * 24 * time.Hour
+	}
+	if args.Superseded > 0 {
+		policy.Superseded = time.Duration(args.Superseded) * 24 * time.Hour
+	}
+	if args.Options.Vacuum {
+		policy.Vacuum = true
+	}
+	policy.DryRun = args.Options.DryRun
+
+	report, err := pruneGraph(cfg, policy)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	if args.Options.JSON {
+		enc := json.NewEncoder(color.Output)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(report)
+		return
+	}
+	printPruneReport(report)
+}
+
+// pruneGraph removes the assets and relations selected by the policy from the graph database. The graph
+// database is locked again before returning, since the command exits on errors.
+func pruneGraph(cfg *config.Config, policy *prune.Policy) (*prune.Report, error) {
+	system, dsn, _, err := primaryGraphDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer lockGraphDatabase(cfg)
+
+	if schema.Versioned(system) {
+		if _, _, err := schema.Upgrade(system, dsn); err != nil {
+			return nil, err
+		}
+	}
+
+	p, err := prune.Open(system, dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Close()
+
+	return p.Prune(context.Background(), policy)
+}
+
+func printPruneReport(report *prune.Report) {
+	verb := "Removed"
+	if report.DryRun {
+		verb = "Would remove"
+	}
+
+	for _, section := range []struct {
+		label  string
+		counts map[string]int
+	}{
+		{"assets", report.Assets},
+		{"relations", report.Relations},
+		{"superseded DNS records", report.Superseded},
+	} {
+		types := make([]string, 0, len(section.counts))
+		for t := range section.counts {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+
+		for _, t := range types {
+			fmt.Fprintf(color.Output, "%s %s %s %s\n", green(verb), yellow(strconv.Itoa(section.counts[t])), blue(t), section.label)
+		}
+	}
+
+	assets, relations := report.Total()
+	fmt.Fprintf(color.Output, "%s %s assets and %s relations\n", green(verb+":"), yellow(strconv.Itoa(assets)), yellow(strconv.Itoa(relations)))
+	if report.Vacuumed {
+		fmt.Fprintln(color.Output, green("The graph database was vacuumed"))
+	}
+}
+
+// importObservations returns the observations of the data sources reporting each name, when the
+// confidence scoring is enabled, so the imported names are attributed to the import source.
+func importObservations(cfg *config.Config) *confidence.Observations {
+	scorer, err := confidence.FromConfig(cfg)
+	if err != nil || scorer == nil {
+		return nil
+	}
+
+	observed, err := confidence.Load(confidence.Path(cfg))
+	if err != nil {
+		return nil
+	}
+	return observed
+}
+
+// createLocalDatabase creates the local graph database selected by the configuration when it does not exist.
+func createLocalDatabase(cfg *config.Config) error {
+	for _, db := range append(cfg.GraphDBs, cfg.LocalDatabaseSettings(cfg.GraphDBs)) {
+		if !db.Primary {
+			continue
+		}
+		if db.System != "local" {
+			return nil
+		}
+
+		dir := config.OutputDirectory(cfg.Dir)
+		if dbcrypt.Exists(dir) {
+			return nil
+		}
+
+		key, err := dbcrypt.FromConfig(cfg)
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(dir, dbcrypt.DatabaseFile)
+		// The encrypted database is created in memory, so only its encrypted copy is written
+		if key != "" {
+			if path, err = dbcrypt.Unlock(dir, key); err != nil {
+				return err
+			}
+		}
+
+		_, _, err = schema.Upgrade(db.System, path)
+		if key != "" {
+			if lerr := dbcrypt.Lock(dir); err == nil {
+				err = lerr
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+func runTagCommand(clArgs []string) {
+	var args annotateArgs
+	var help1, help2 bool
+	tagCommand := flag.NewFlagSet("tag", flag.ContinueOnError)
+
+	tagBuf := new(bytes.Buffer)
+	tagCommand.SetOutput(tagBuf)
+
+	tagCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	tagCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	tagCommand.StringVar(&args.Author, "author", os.Getenv("USER"), "Name of the analyst recorded with the tags")
+	tagCommand.Var(&args.Remove, "rm", "Tag keys separated by commas to remove from the asset")
+	tagCommand.BoolVar(&args.Options.JSON, "json", false, "Print the annotations of the asset as JSON")
+	tagCommand.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	tagCommand.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
+	tagCommand.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	tagCommand.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+
+	if len(clArgs) < 1 {
+		commandUsage(tagUsageMsg, tagCommand, tagBuf)
+		return
+	}
+	if err := tagCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(tagUsageMsg, tagCommand, tagBuf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Options.Silent {
+		color.Output = io.Discard
+		color.Error = io.Discard
+	}
+	if tagCommand.NArg() < 1 {
+		r.Fprintln(color.Error, "The asset must be provided")
+		os.Exit(1)
+	}
+
+	asset := tagCommand.Arg(0)
+	tags, err := annotations.ParseTags(tagCommand.Args()[1:])
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	err = annotateAsset(annotationConfig(&args), asset, args.Options.JSON, func(store *annotations.Store) error {
+		for _, key := range args.Remove {
+			if removed, err := store.Untag(asset, key); err != nil {
+				return fmt.Errorf("failed to remove the %s tag: %v", key, err)
+			} else if !removed {
+				fgY.Fprintf(color.Error, "The asset did not carry the %s tag\n", key)
+			}
+		}
+
+		keys := make([]string, 0, len(tags))
+		for k := range tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := store.Tag(asset, k, tags[k], args.Author); err != nil {
+				return fmt.Errorf("failed to tag the asset: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runNoteCommand(clArgs []string) {
+	var args annotateArgs
+	var help1, help2 bool
+	noteCommand := flag.NewFlagSet("note", flag.ContinueOnError)
+
+	noteBuf := new(bytes.Buffer)
+	noteCommand.SetOutput(noteBuf)
+
+	noteCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	noteCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	noteCommand.StringVar(&args.Author, "author", os.Getenv("USER"), "Name of the analyst recorded with the note")
+	noteCommand.BoolVar(&args.Options.JSON, "json", false, "Print the annotations of the asset as JSON")
+	noteCommand.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	noteCommand.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
+	noteCommand.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	noteCommand.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+
+	if len(clArgs) < 1 {
+		commandUsage(noteUsageMsg, noteCommand, noteBuf)
+		return
+	}
+	if err := noteCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(noteUsageMsg, noteCommand, noteBuf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Options.Silent {
+		color.Output = io.Discard
+		color.Error = io.Discard
+	}
+	if noteCommand.NArg() < 2 {
+		r.Fprintln(color.Error, "The asset and the text of the note must be provided")
+		os.Exit(1)
+	}
+
+	asset := noteCommand.Arg(0)
+	err := annotateAsset(annotationConfig(&args), asset, args.Options.JSON, func(store *annotations.Store) error {
+		if err := store.Note(asset, strings.Join(noteCommand.Args()[1:], " "), args.Author); err != nil {
+			return fmt.Errorf("failed to add the note: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+// annotateAsset changes the annotations of the asset using the function, and prints the annotations of
+// the asset. The graph database is locked again before returning, since the command exits on errors.
+func annotateAsset(cfg *config.Config, asset string, asJSON bool, annotate func(*annotations.Store) error) error {
+	store, err := openAnnotationStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer lockGraphDatabase(cfg)
+	defer store.Close()
+
+	if err := annotate(store); err != nil {
+		return err
+	}
+	return printAnnotations(store, asset, asJSON)
+}
+
+// annotationConfig returns the configuration selecting the graph database holding the annotations.
+func annotationConfig(args *annotateArgs) *config.Config {
+	cfg := config.NewConfig()
+	if err := settings.Load("db", cfg, args.Filepaths.Directory, args.Filepaths.ConfigFile); err != nil {
+		r.Fprintf(color.Error, "Failed to load the configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Filepaths.Directory != "" {
+		cfg.Dir = args.Filepaths.Directory
+	}
+	return cfg
+}
+
+// printAnnotations prints the tags and the notes of the asset.
+func printAnnotations(store *annotations.Store, asset string, asJSON bool) error {
+	list, err := store.Annotations(asset)
+	if err != nil {
+		return fmt.Errorf("failed to read the annotations: %v", err)
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(color.Output)
+		enc.SetIndent("", "  ")
+		if list == nil {
+			list = []*annotations.Annotation{}
+		}
+		_ = enc.Encode(list)
+		return nil
+	}
+	for _, a := range list {
+		var author string
+		if a.Author != "" {
+			author = " by " + a.Author
+		}
+
+		if a.Kind == annotations.TagKind {
+			fmt.Fprintf(color.Output, "%s %s=%s\n", magenta("[tag]"), green(a.Key), yellow(a.Value))
+			continue
+		}
+		fmt.Fprintf(color.Output, "%s %s %s\n", magenta("[note]"), a.Value,
+			blue("("+a.CreatedAt.Format(time.RFC3339)+author+")"))
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/dbcrypt"
+	"github.com/owasp-amass/amass/v4/health"
+	"github.com/owasp-amass/amass/v4/settings"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	engineUsageMsg       = "engine status [options]"
+	engineStatusUsageMsg = "engine status [options]"
+)
+
+type engineArgs struct {
+	HTTPSTarget string
+	Timeout     time.Duration
+	Options     struct {
+		JSON    bool
+		NoColor bool
+		Silent  bool
+	}
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+func runEngineCommand(clArgs []string) {
+	engineBuf := new(bytes.Buffer)
+	engineCommand := flag.NewFlagSet("engine", flag.ContinueOnError)
+	engineCommand.SetOutput(engineBuf)
+
+	if len(clArgs) < 1 {
+		commandUsage(engineUsageMsg, engineCommand, engineBuf)
+		return
+	}
+
+	switch clArgs[0] {
+	case "status":
+		runEngineStatusCommand(clArgs[1:])
+	default:
+		commandUsage(engineUsageMsg, engineCommand, engineBuf)
+		os.Exit(1)
+	}
+}
+
+func runEngineStatusCommand(clArgs []string) {
+	var args engineArgs
+	var help1, help2 bool
+	statusCommand := flag.NewFlagSet("status", flag.ContinueOnError)
+
+	statusBuf := new(bytes.Buffer)
+	statusCommand.SetOutput(statusBuf)
+
+	statusCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	statusCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	statusCommand.BoolVar(&args.Options.JSON, "json", false, "Print the results of the checks as JSON")
+	statusCommand.DurationVar(&args.Timeout, "timeout", health.DefaultTimeout, "Time allowed for each of the checks")
+	statusCommand.StringVar(&args.HTTPSTarget, "https", health.DefaultHTTPSTarget, "URL requested to check the outbound HTTPS connectivity")
+	statusCommand.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	statusCommand.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
+	statusCommand.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	statusCommand.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+
+	if err := statusCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(engineStatusUsageMsg, statusCommand, statusBuf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Options.Silent {
+		color.Output = io.Discard
+		color.Error = io.Discard
+	}
+
+	cfg := config.NewConfig()
+	if err := settings.Load("engine", cfg, args.Filepaths.Directory, args.Filepaths.ConfigFile); err != nil {
+		r.Fprintf(color.Error, "Failed to load the configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Filepaths.Directory != "" {
+		cfg.Dir = args.Filepaths.Directory
+	}
+	// The failures logged by the components are reported by the checks
+	cfg.Log = log.New(io.Discard, "", 0)
+
+	checks := []*health.Check{graphDatabaseCheck(cfg), health.RedisCheck(cfg)}
+	checks = append(checks, health.ResolverChecks(cfg)...)
+	checks = append(checks, health.HTTPSCheck(args.HTTPSTarget, nil))
+	checks = append(checks, health.PluginChecks(cfg)...)
+
+	results := health.Run(context.Background(), checks, args.Timeout)
+	if args.Options.JSON {
+		enc := json.NewEncoder(color.Output)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			r.Fprintf(color.Error, "Failed to encode the results: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		writeHealthResults(results)
+	}
+
+	if !health.Healthy(results) {
+		os.Exit(1)
+	}
+}
+
+// graphDatabaseCheck opens the primary graph database of the configuration.
+func graphDatabaseCheck(cfg *config.Config) *health.Check {
+	return &health.Check{
+		Component: "graph",
+		Name:      "database",
+		Run: func(ctx context.Context) (string, error) {
+			if localGraphMissing(cfg) {
+				return "", health.Warnf("no enumeration has created the graph database in %s", config.OutputDirectory(cfg.Dir))
+			}
+			// The graph is not removed, since that deletes the local database file
+			if _, err := openGraphDatabase(cfg); err != nil {
+				return "", err
+			}
+			defer lockGraphDatabase(cfg)
+
+			// The DSN of a remote database can include the credentials
+			system, location := "local", config.OutputDirectory(cfg.Dir)
+			for _, db := range cfg.GraphDBs {
+				if db.Primary {
+					system = db.System
+					if system != "local" {
+						location = db.Host
+					}
+					break
+				}
+			}
+
+			return fmt.Sprintf("the %s graph database was opened from %s", strings.ToLower(system), location), nil
+		},
+	}
+}
+
+// localGraphMissing returns true when the primary graph database is the local database, and
+// its file has not been created yet.
+func localGraphMissing(cfg *config.Config) bool {
+	for _, db := range cfg.GraphDBs {
+		if db.Primary && db.System != "local" {
+			return false
+		}
+	}
+
+	return !dbcrypt.Exists(config.OutputDirectory(cfg.Dir))
+}
+
+func writeHealthResults(results []*health.Result) {
+	for _, res := range results {
+		status := fmt.Sprintf("%-7s", res.Status)
+
+		switch res.Status {
+		case health.StatusOK:
+			status = green(status)
+		case health.StatusWarn:
+			status = yellow(status)
+		case health.StatusFail:
+			status = r.Sprint(status)
+		default:
+			status = blue(status)
+		}
+
+		name := fmt.Sprintf("%-8s %-22s", res.Component, res.Name)
+		fmt.Fprintf(color.Output, "%s %s %8s  %s\n", name, status,
+			res.Duration.Round(time.Millisecond), res.Detail)
+	}
+
+	var failed, warned int
+	for _, res := range results {
+		switch res.Status {
+		case health.StatusFail:
+			failed++
+		case health.StatusWarn:
+			warned++
+		}
+	}
+	fmt.Fprintf(color.Output, "\n%s checks, %s failed, %s warnings\n",
+		yellow(fmt.Sprint(len(results))), r.Sprint(failed), yellow(fmt.Sprint(warned)))
+}
@@ -8,6 +8,8 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -17,6 +19,7 @@ import (
 	"os/signal"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -25,10 +28,17 @@ import (
 	"github.com/caffix/netmap"
 	"github.com/caffix/stringset"
 	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/analysis"
+	"github.com/owasp-amass/amass/v4/api"
+	"github.com/owasp-amass/amass/v4/brute"
 	"github.com/owasp-amass/amass/v4/datasrcs"
 	"github.com/owasp-amass/amass/v4/enum"
 	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/neo4j"
+	"github.com/owasp-amass/amass/v4/report"
 	"github.com/owasp-amass/amass/v4/resources"
+	"github.com/owasp-amass/amass/v4/screenshots"
+	"github.com/owasp-amass/amass/v4/settings"
 	"github.com/owasp-amass/amass/v4/systems"
 	"github.com/owasp-amass/config/config"
 )
@@ -45,6 +55,8 @@ type enumArgs struct {
 	BruteWordListMask *stringset.Set
 	Blacklist         *stringset.Set
 	Domains           *stringset.Set
+	Engine            string
+	EngineToken       string
 	Excluded          *stringset.Set
 	Included          *stringset.Set
 	Interface         string
@@ -55,20 +67,25 @@ type enumArgs struct {
 	MinForRecursive   int
 	Names             *stringset.Set
 	Ports             format.ParseInts
+	Profile           string
+	ProgressOut       string
 	Resolvers         *stringset.Set
+	Schedule          string
 	Trusted           *stringset.Set
-	Timeout           int
+	Timeout           format.ParseDuration
 	Options           struct {
 		Active       bool
 		Alterations  bool
 		BruteForcing bool
 		DemoMode     bool
+		Internal     bool
 		ListSources  bool
 		NoAlts       bool
 		NoColor      bool
 		NoRecursive  bool
 		Passive      bool
 		Silent       bool
+		TUI          bool
 		Verbose      bool
 	}
 	Filepaths struct {
@@ -99,6 +116,8 @@ func defineEnumArgumentFlags(enumFlags *flag.FlagSet, args *enumArgs) {
 	enumFlags.Var(args.Blacklist, "bl", "Blacklist of subdomain names that will not be investigated")
 	enumFlags.Var(args.BruteWordListMask, "wm", "\"hashcat-style\" wordlist masks for DNS brute forcing")
 	enumFlags.Var(args.Domains, "d", "Domain names separated by commas (can be used multiple times)")
+	enumFlags.StringVar(&args.Engine, "engine", "", "URL of the remote engine API executing the enumeration")
+	enumFlags.StringVar(&args.EngineToken, "engine-token", "", "API token of the remote engine (default: $"+api.TokenEnv+")")
 	enumFlags.Var(args.Excluded, "exclude", "Data source names separated by commas to be excluded")
 	enumFlags.Var(args.Included, "include", "Data source names separated by commas to be included")
 	enumFlags.StringVar(&args.Interface, "iface", "", "Provide the network interface to send traffic through")
@@ -109,21 +128,26 @@ func defineEnumArgumentFlags(enumFlags *flag.FlagSet, args *enumArgs) {
 	enumFlags.IntVar(&args.MaxDepth, "max-depth", 0, "Maximum number of subdomain labels for brute forcing")
 	enumFlags.IntVar(&args.MinForRecursive, "min-for-recursive", 1, "Subdomain labels seen before recursive brute forcing (Default: 1)")
 	enumFlags.Var(&args.Ports, "p", "Ports separated by commas (default: 80, 443)")
+	enumFlags.StringVar(&args.ProgressOut, "progress-out", "", "Path to a file or named pipe receiving the progress events as NDJSON")
 	enumFlags.Var(args.Resolvers, "r", "IP addresses of untrusted DNS resolvers (can be used multiple times)")
 	enumFlags.Var(args.Resolvers, "tr", "IP addresses of trusted DNS resolvers (can be used multiple times)")
-	enumFlags.IntVar(&args.Timeout, "timeout", 0, "Number of minutes to let enumeration run before quitting")
+	enumFlags.StringVar(&args.Schedule, "schedule", "", "Cron expression or interval (@every 24h) for repeating the enumeration")
+	enumFlags.Var(&args.Timeout, "timeout", "Time budget (minutes or a duration like 1h30m) for the enumeration, including finalization")
 }
 
 func defineEnumOptionFlags(enumFlags *flag.FlagSet, args *enumArgs) {
 	enumFlags.BoolVar(&args.Options.Active, "active", false, "Attempt zone transfers and certificate name grabs")
+	enumFlags.StringVar(&args.Profile, "profile", "", "Preset of the enumeration modes and data sources: "+strings.Join(settings.Profiles(), ", "))
 	enumFlags.BoolVar(&args.Options.BruteForcing, "brute", false, "Execute brute forcing after searches")
 	enumFlags.BoolVar(&args.Options.DemoMode, "demo", false, "Censor output to make it suitable for demonstrations")
+	enumFlags.BoolVar(&args.Options.Internal, "internal", false, "Discover the hosts of the local network with mDNS, NetBIOS and LLMNR")
 	enumFlags.BoolVar(&args.Options.ListSources, "list", false, "Print the names of all available data sources")
 	enumFlags.BoolVar(&args.Options.Alterations, "alts", false, "Enable generation of altered names")
 	enumFlags.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
 	enumFlags.BoolVar(&args.Options.NoRecursive, "norecursive", false, "Turn off recursive brute forcing")
 	enumFlags.BoolVar(&args.Options.Passive, "passive", false, "Deprecated since passive is the default setting")
 	enumFlags.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
+	enumFlags.BoolVar(&args.Options.TUI, "tui", false, "Show a live dashboard of the enumeration activity")
 	enumFlags.BoolVar(&args.Options.Verbose, "v", false, "Output status / debug / troubleshooting info")
 }
 
@@ -151,6 +175,11 @@ func runEnumCommand(clArgs []string) {
 	if cfg == nil {
 		return
 	}
+	// The remote engine executes the enumeration and keeps the results in its graph database
+	if args.Engine != "" {
+		runRemoteEnumeration(cfg, args)
+		return
+	}
 	createOutputDirectory(cfg)
 
 	rLog, wLog := io.Pipe()
@@ -161,34 +190,74 @@ func runEnumCommand(clArgs []string) {
 	if args.Filepaths.LogFile != "" {
 		logfile = args.Filepaths.LogFile
 	}
+	// The dashboard shows the most recent log messages instead of printing them
+	var logTail *lineBuffer
+	if args.Options.TUI {
+		logTail = newLineBuffer(dashboardLogs)
+	}
+	rec, onStart := enumSchedule(cfg, args)
+	if rec != nil && args.ProgressOut != "" {
+		r.Fprintln(color.Error, "The -progress-out flag cannot be used with scheduled enumerations")
+		os.Exit(1)
+	}
+	// The progress events are written for wrappers monitoring the enumeration
+	var progress *progressWriter
+	if args.ProgressOut != "" {
+		var err error
+		if progress, err = newProgressWriter(args.ProgressOut); err != nil {
+			r.Fprintf(color.Error, "Failed to open the progress output: %v\n", err)
+			os.Exit(1)
+		}
+	}
 	// Start handling the log messages
-	go writeLogsAndMessages(rLog, logfile, args.Options.Verbose)
-	// Create the System that will provide architecture to this enumeration
-	sys, err := systems.NewLocalSystem(cfg)
+	go writeLogsAndMessages(rLog, logfile, args.Options.Verbose, logTail)
+	// Scheduled enumerations are launched repeatedly until the user terminates the program
+	if rec != nil {
+		runScheduledEnumerations(cfg, args, rec, onStart)
+		return
+	}
+	err := runEnumeration(cfg, args, logTail, progress)
+	// The progress output is closed before exiting, since the deferred calls are skipped by os.Exit
+	progress.fail(err)
+	progress.close()
 	if err != nil {
 		r.Fprintf(color.Error, "%v\n", err)
 		os.Exit(1)
 	}
+}
+
+// runEnumeration executes the enumeration using the local system. The errors are returned, so the system
+// is shutdown and the graph database locked again before the command exits.
+func runEnumeration(cfg *config.Config, args *enumArgs, logTail *lineBuffer, progress *progressWriter) error {
+	// Create the System that will provide architecture to this enumeration
+	sys, err := systems.NewLocalSystem(cfg)
+	if err != nil {
+		return err
+	}
 	defer func() { _ = sys.Shutdown() }()
 
 	if err := sys.SetDataSources(datasrcs.GetAllSources(sys)); err != nil {
-		r.Fprintf(color.Error, "%v\n", err)
-		os.Exit(1)
+		return err
 	}
 
 	// Setup the new enumeration
 	e := enum.NewEnumeration(cfg, sys, sys.GraphDatabases()[0])
 	if e == nil {
-		r.Fprintf(color.Error, "%s\n", "Failed to setup the enumeration")
-		os.Exit(1)
+		return errors.New("failed to setup the enumeration")
 	}
 
 	var wg sync.WaitGroup
 	var outChans []chan string
 	// This channel sends the signal for goroutines to terminate
 	done := make(chan struct{})
-	// Print output only if JSONOutput is not meant for STDOUT
-	if args.Filepaths.JSONOutput != "-" {
+	if args.Options.TUI {
+		wg.Add(1)
+		// This goroutine will render the dashboard in place of the printed output
+		tuiOutChan := make(chan string, 10)
+		go newDashboard(e, logTail).run(tuiOutChan, &wg)
+		outChans = append(outChans, tuiOutChan)
+	} else if args.Filepaths.JSONOutput != "-" {
+		// Print output only if JSONOutput is not meant for STDOUT
 		wg.Add(1)
 		// This goroutine will handle printing the output
 		printOutChan := make(chan string, 10)
@@ -196,23 +265,38 @@ func runEnumCommand(clArgs []string) {
 		outChans = append(outChans, printOutChan)
 	}
 
+	if progress != nil {
+		e.OnComplete(progress.complete)
+		wg.Add(1)
+		progressOutChan := make(chan string, 10)
+		go progress.run(e, progressOutChan, &wg)
+		outChans = append(outChans, progressOutChan)
+	}
+
 	wg.Add(1)
 	// This goroutine will handle saving the output to the text file
 	txtOutChan := make(chan string, 10)
 	go saveTextOutput(e, args, txtOutChan, &wg)
 	outChans = append(outChans, txtOutChan)
 
-	var ctx context.Context
-	var cancel context.CancelFunc
-	if args.Timeout == 0 {
-		ctx, cancel = context.WithCancel(context.Background())
+	// The output context bounds the entire session, while the enumeration context expires
+	// early enough to flush the data, extract the final output and close the database
+	var outctx, ctx context.Context
+	var outcancel, cancel context.CancelFunc
+	if budget := time.Duration(args.Timeout); budget > 0 {
+		deadline := time.Now().Add(budget)
+
+		outctx, outcancel = context.WithDeadline(context.Background(), deadline)
+		ctx, cancel = context.WithDeadline(outctx, deadline.Add(-finalizationReserve(budget)))
 	} else {
-		ctx, cancel = context.WithTimeout(context.Background(), time.Duration(args.Timeout)*time.Minute)
+		outctx, outcancel = context.WithCancel(context.Background())
+		ctx, cancel = context.WithCancel(outctx)
 	}
+	defer outcancel()
 	defer cancel()
 
 	wg.Add(1)
-	go processOutput(ctx, sys.GraphDatabases()[0], e, outChans, done, &wg)
+	go processOutput(outctx, sys.GraphDatabases()[0], e, outChans, done, &wg)
 	// Monitor for cancellation by the user
 	go func(d chan struct{}, c context.Context, f context.CancelFunc) {
 		quit := make(chan os.Signal, 1)
@@ -226,15 +310,223 @@ func runEnumCommand(clArgs []string) {
 		case <-c.Done():
 		}
 	}(done, ctx, cancel)
+	// Pause and resume the enumeration as the user sends the signals
+	go handlePauseSignals(ctx, func() {
+		e.Pause()
+		cfg.Log.Print("The enumeration was paused by the user")
+	}, func() {
+		e.Resume()
+		cfg.Log.Print("The enumeration was resumed by the user")
+	})
 	// Start the enumeration process
 	if err := e.Start(ctx); err != nil {
-		r.Println(err)
-		os.Exit(1)
+		return err
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		cfg.Log.Printf("The time budget of %s was reached and the enumeration is being finalized", time.Duration(args.Timeout))
+	} else if ctx.Err() == nil {
+		// The brute forcing was completed, so the next enumeration starts from the beginning of the wordlist
+		if err := brute.ClearResume(config.OutputDirectory(cfg.Dir)); err != nil {
+			cfg.Log.Printf("Failed to remove the brute forcing positions: %v", err)
+		}
 	}
 	// Let all the output goroutines know that the enumeration has finished
 	close(done)
 	wg.Wait()
+	writeMailFlowReport(outctx, e, args)
+	writeErrorReport(e, args)
+	writeValidationReport(e)
+	writeAssociationReport(outctx, e)
+	writeScreenshotGallery(e)
+	recordRun(e)
+	syncNeo4j(outctx, e)
 	fmt.Fprintf(color.Error, "\n%s\n", green("The enumeration has finished"))
+	return nil
+
+}
+
+// writeMailFlowReport saves the mail flow summary for the enumerated domains to the output directory.
+func writeMailFlowReport(ctx context.Context, e *enum.Enumeration, args *enumArgs) {
+	flows := analysis.MailFlows(ctx, e.Sys.GraphDatabases()[0], e.Config.Domains(), e.Config.CollectionStartTime.UTC())
+	if len(flows) == 0 {
+		return
+	}
+
+	if data, err := json.MarshalIndent(flows, "", "  "); err == nil {
+		path := filepath.Join(config.OutputDirectory(e.Config.Dir), "mailflow.json")
+
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			e.Config.Log.Printf("Failed to write the mail flow report: %v", err)
+		}
+	}
+
+	if args.Options.Silent {
+		return
+	}
+	fmt.Fprintln(color.Error)
+	for _, mf := range flows {
+		fmt.Fprintf(color.Error, "%s %s: %s\n", blue("Mail flow for"), green(mf.Domain), yellow(mf.Summary))
+	}
+}
+
+// writeErrorReport saves the breakdown of the data source failures by category to the output directory.
+func writeErrorReport(e *enum.Enumeration, args *enumArgs) {
+	failures := e.SourceErrors()
+	if len(failures) == 0 {
+		return
+	}
+
+	if data, err := json.MarshalIndent(failures, "", "  "); err == nil {
+		path := filepath.Join(config.OutputDirectory(e.Config.Dir), "errors.json")
+
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			e.Config.Log.Printf("Failed to write the data source error report: %v", err)
+		}
+	}
+
+	if args.Options.Silent {
+		return
+	}
+	fmt.Fprintln(color.Error)
+	for _, se := range failures {
+		parts := make([]string, 0, len(se.Errors))
+		for _, ec := range se.Errors {
+			parts = append(parts, fmt.Sprintf("%d %s", ec.Count, ec.Category))
+		}
+		fmt.Fprintf(color.Error, "%s %s: %s\n", blue("Errors from"), green(se.Source), yellow(strings.Join(parts, ", ")))
+	}
+}
+
+// writeScreenshotGallery renders the gallery of the screenshots captured by the data sources.
+func writeScreenshotGallery(e *enum.Enumeration) {
+	dir := screenshots.Dir(e.Config)
+	if _, err := os.Stat(filepath.Join(dir, screenshots.IndexName)); err != nil {
+		return
+	}
+
+	path, n, err := screenshots.WriteGalleryFile(dir)
+	if err != nil {
+		e.Config.Log.Printf("Failed to write the screenshot gallery: %v", err)
+		return
+	}
+	fmt.Fprintf(color.Error, "%s %s screenshots were written to %s\n", blue("Screenshots:"), yellow(strconv.Itoa(n)), green(path))
+}
+
+// recordRun adds the enumeration to the history used to report the assets discovered since the previous run.
+func recordRun(e *enum.Enumeration) {
+	if err := report.RecordRun(config.OutputDirectory(e.Config.Dir), &report.Run{
+		Started:  e.Config.CollectionStartTime.UTC(),
+		Finished: time.Now().UTC(),
+		Domains:  e.Config.Domains(),
+	}); err != nil {
+		e.Config.Log.Printf("Failed to record the enumeration history: %v", err)
+	}
+}
+
+// writeValidationReport saves the cross-validation statistics of the untrusted resolvers to the output directory.
+func writeValidationReport(e *enum.Enumeration) {
+	vs := e.ValidationStats()
+	if vs.Confirmed == 0 && vs.Rejected == 0 {
+		return
+	}
+
+	if data, err := json.MarshalIndent(vs, "", "  "); err == nil {
+		path := filepath.Join(config.OutputDirectory(e.Config.Dir), "resolvers.json")
+
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			e.Config.Log.Printf("Failed to write the resolver validation report: %v", err)
+		}
+	}
+}
+
+// writeAssociationReport saves the names that shared addresses with the enumerated names to the output directory.
+func writeAssociationReport(ctx context.Context, e *enum.Enumeration) {
+	window, err := analysis.PivotWindow(e.Config)
+	if err != nil {
+		e.Config.Log.Printf("%v", err)
+		return
+	} else if window == 0 {
+		return
+	}
+
+	now := time.Now().UTC()
+	assocs := analysis.AddressPivots(ctx, e.Sys.GraphDatabases()[0], e.Config.Domains(), now.Add(-window), now)
+	if len(assocs) == 0 {
+		return
+	}
+
+	if data, err := json.MarshalIndent(assocs, "", "  "); err == nil {
+		path := filepath.Join(config.OutputDirectory(e.Config.Dir), "associations.json")
+
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			e.Config.Log.Printf("Failed to write the address associations report: %v", err)
+		}
+	}
+}
+
+// syncNeo4j mirrors the assets and relations updated since the previous synchronization into Neo4j.
+func syncNeo4j(ctx context.Context, e *enum.Enumeration) {
+	client, err := neo4j.FromConfig(e.Config)
+	if err != nil {
+		e.Config.Log.Printf("%v", err)
+		return
+	} else if client == nil {
+		return
+	}
+
+	dir := config.OutputDirectory(e.Config.Dir)
+	start := time.Now()
+	stats, err := client.Sync(ctx, e.Sys.GraphDatabases()[0], neo4j.LastSync(dir))
+	if err != nil {
+		e.Config.Log.Printf("Failed to synchronize the graph with Neo4j: %v", err)
+		return
+	}
+
+	e.Config.Log.Printf("Neo4j: merged %d nodes and %d edges", stats.Nodes, stats.Edges)
+	if err := neo4j.SaveSync(dir, start); err != nil {
+		e.Config.Log.Printf("Failed to record the Neo4j synchronization time: %v", err)
+	}
+}
+
+// handlePauseSignals calls pause and resume as the user sends the signals, until the context expires.
+func handlePauseSignals(ctx context.Context, pause, resume func()) {
+	if len(pauseSignals) == 0 {
+		return
+	}
+
+	ps := make(chan os.Signal, 1)
+	signal.Notify(ps, pauseSignals...)
+	defer signal.Stop(ps)
+
+	rs := make(chan os.Signal, 1)
+	signal.Notify(rs, resumeSignals...)
+	defer signal.Stop(rs)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ps:
+			pause()
+		case <-rs:
+			resume()
+		}
+	}
+}
+
+// finalizationReserve returns the portion of the time budget set aside for finalizing the session.
+func finalizationReserve(budget time.Duration) time.Duration {
+	reserve := budget / 10
+
+	if reserve < 5*time.Second {
+		reserve = 5 * time.Second
+	} else if reserve > 2*time.Minute {
+		reserve = 2 * time.Minute
+	}
+	if reserve > budget/2 {
+		reserve = budget / 2
+	}
+	return reserve
 }
 
 func argsAndConfig(clArgs []string) (*config.Config, *enumArgs) {
@@ -312,21 +604,43 @@ func argsAndConfig(clArgs []string) (*config.Config, *enumArgs) {
 	}
 
 	cfg := config.NewConfig()
-	// Check if a configuration file was provided, and if so, load the settings
-	if err := config.AcquireConfig(args.Filepaths.Directory, args.Filepaths.ConfigFile, cfg); err == nil {
-		// Check if a config file was provided that has DNS resolvers specified
-		if len(cfg.Resolvers) > 0 && args.Resolvers.Len() == 0 {
-			args.Resolvers = stringset.New(cfg.Resolvers...)
-		}
-	} else if args.Filepaths.ConfigFile != "" {
-		r.Fprintf(color.Error, "Failed to load the configuration file: %v\n", err)
+	if err := settings.Load("enum", cfg, args.Filepaths.Directory, args.Filepaths.ConfigFile); err != nil {
+		r.Fprintf(color.Error, "Failed to load the configuration: %v\n", err)
 		os.Exit(1)
 	}
+	// The profile selected by the flag replaces the profile selected by the configuration
+	if args.Profile != "" {
+		if _, err := settings.ApplyProfile(cfg, args.Profile); err != nil {
+			r.Fprintf(color.Error, "Configuration error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	// Check if the configuration provided DNS resolvers
+	if len(cfg.Resolvers) > 0 && args.Resolvers.Len() == 0 {
+		args.Resolvers = stringset.New(cfg.Resolvers...)
+	}
 	// Override configuration file settings with command-line arguments
 	if err := cfg.UpdateConfig(args); err != nil {
 		r.Fprintf(color.Error, "Configuration error: %v\n", err)
 		os.Exit(1)
 	}
+	// The flags cannot enable the data sources disabled by the profile
+	p, err := settings.ProfileFromConfig(cfg)
+	if err == nil {
+		err = p.Check(cfg)
+	}
+	if err != nil {
+		r.Fprintf(color.Error, "Configuration error: %v\n", err)
+		os.Exit(1)
+	}
+	// Brute forcing enabled by the configuration or the profile uses the default wordlist
+	if cfg.BruteForcing && len(cfg.Wordlist) == 0 {
+		if f, err := resources.GetResourceFile("namelist.txt"); err == nil {
+			if list, err := getWordList(f); err == nil {
+				cfg.Wordlist = list
+			}
+		}
+	}
 	// Check if the user has requested the data source names
 	if args.Options.ListSources {
 		for _, line := range GetAllSourceInfo(cfg) {
@@ -378,8 +692,11 @@ func saveTextOutput(e *enum.Enumeration, args *enumArgs, output chan string, wg
 
 	outptr, err := os.OpenFile(txtfile, os.O_WRONLY|os.O_CREATE, 0644)
 	if err != nil {
+		// The enumeration continues, so the output is still received without being saved
 		r.Fprintf(color.Error, "Failed to open the text output file: %v\n", err)
-		os.Exit(1)
+		for range output {
+		}
+		return
 	}
 	defer func() {
 		_ = outptr.Sync()
@@ -436,7 +753,7 @@ func processOutput(ctx context.Context, g *netmap.Graph, e *enum.Enumeration, ou
 	}
 }
 
-func writeLogsAndMessages(logs *io.PipeReader, logfile string, verbose bool) {
+func writeLogsAndMessages(logs *io.PipeReader, logfile string, verbose bool, tail *lineBuffer) {
 	wildcard := regexp.MustCompile("DNS wildcard")
 	queries := regexp.MustCompile("Querying")
 
@@ -471,6 +788,10 @@ func writeLogsAndMessages(logs *io.PipeReader, logfile string, verbose bool) {
 		// Remove the timestamp
 		parts := strings.Split(line, " ")
 		line = strings.Join(parts[1:], " ")
+		if tail != nil {
+			tail.Add(line)
+			continue
+		}
 		// Check for Amass DNS wildcard messages
 		if verbose && wildcard.FindString(line) != "" {
 			fgR.Fprintln(color.Error, line)
@@ -617,6 +938,14 @@ func (e enumArgs) OverrideConfig(conf *config.Config) error {
 		conf.Active = true
 		conf.Passive = false
 	}
+	if e.Options.Internal {
+		internal, _ := conf.Options["internal"].(map[string]interface{})
+		if internal == nil {
+			internal = make(map[string]interface{})
+			conf.Options["internal"] = internal
+		}
+		internal["enabled"] = true
+	}
 	if e.Blacklist.Len() > 0 {
 		conf.Scope.Blacklist = e.Blacklist.Slice()
 	}
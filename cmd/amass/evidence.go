@@ -0,0 +1,182 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/evidence"
+	"github.com/owasp-amass/amass/v4/settings"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	evidenceUsageMsg     = "evidence show|list [options]"
+	evidenceShowUsageMsg = "evidence show [-raw] [options] ID"
+	evidenceListUsageMsg = "evidence list [-asset NAME] [-json] [options]"
+)
+
+type evidenceArgs struct {
+	Asset   string
+	Options struct {
+		JSON    bool
+		NoColor bool
+		Raw     bool
+		Silent  bool
+	}
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+func runEvidenceCommand(clArgs []string) {
+	evidenceBuf := new(bytes.Buffer)
+	evidenceCommand := flag.NewFlagSet("evidence", flag.ContinueOnError)
+	evidenceCommand.SetOutput(evidenceBuf)
+
+	if len(clArgs) < 1 {
+		commandUsage(evidenceUsageMsg, evidenceCommand, evidenceBuf)
+		return
+	}
+
+	switch clArgs[0] {
+	case "show":
+		runEvidenceSubcommand("show", evidenceShowUsageMsg, clArgs[1:])
+	case "list":
+		runEvidenceSubcommand("list", evidenceListUsageMsg, clArgs[1:])
+	default:
+		commandUsage(evidenceUsageMsg, evidenceCommand, evidenceBuf)
+		os.Exit(1)
+	}
+}
+
+func runEvidenceSubcommand(name, usage string, clArgs []string) {
+	var args evidenceArgs
+	var help1, help2 bool
+	cmd := flag.NewFlagSet(name, flag.ContinueOnError)
+
+	buf := new(bytes.Buffer)
+	cmd.SetOutput(buf)
+
+	cmd.BoolVar(&help1, "h", false, "Show the program usage message")
+	cmd.BoolVar(&help2, "help", false, "Show the program usage message")
+	cmd.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	cmd.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
+	cmd.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	cmd.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the output files")
+	if name == "show" {
+		cmd.BoolVar(&args.Options.Raw, "raw", false, "Write the content exactly as stored, without the metadata")
+	} else {
+		cmd.StringVar(&args.Asset, "asset", "", "Only list the evidence recorded for the asset")
+		cmd.BoolVar(&args.Options.JSON, "json", false, "Print the evidence records as JSON")
+	}
+
+	if name == "show" && len(clArgs) < 1 {
+		commandUsage(usage, cmd, buf)
+		return
+	}
+	if err := cmd.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(usage, cmd, buf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Options.Silent {
+		color.Output = io.Discard
+		color.Error = io.Discard
+	}
+
+	cfg := config.NewConfig()
+	if err := settings.Load("evidence", cfg, args.Filepaths.Directory, args.Filepaths.ConfigFile); err != nil {
+		r.Fprintf(color.Error, "Failed to load the configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Filepaths.Directory != "" {
+		cfg.Dir = args.Filepaths.Directory
+	}
+	dir := filepath.Join(config.OutputDirectory(cfg.Dir), evidence.Dir)
+
+	if name == "list" {
+		listEvidence(dir, &args)
+		return
+	}
+	if cmd.NArg() != 1 {
+		r.Fprintln(color.Error, "Exactly one evidence identifier must be provided")
+		os.Exit(1)
+	}
+	showEvidence(dir, cmd.Arg(0), &args)
+}
+
+func showEvidence(dir, id string, args *evidenceArgs) {
+	recs, data, err := evidence.Read(dir, id)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if args.Options.Raw {
+		_, _ = color.Output.Write(data)
+		return
+	}
+
+	fmt.Fprintf(color.Output, "%s %s\n", blue("ID:"), green(recs[0].ID))
+	fmt.Fprintf(color.Output, "%s %s\n", blue("Kind:"), recs[0].Kind)
+	if ct := recs[0].ContentType; ct != "" {
+		fmt.Fprintf(color.Output, "%s %s\n", blue("Content Type:"), ct)
+	}
+	fmt.Fprintf(color.Output, "%s %s bytes\n", blue("Size:"), strconv.Itoa(len(data)))
+	// The same content can back the findings about several assets
+	for _, rec := range recs {
+		fmt.Fprintf(color.Output, "%s %s from %s at %s\n", blue("Observed:"), green(rec.Asset),
+			yellow(rec.Source), rec.Time.Format(time.RFC3339))
+	}
+	fmt.Fprintln(color.Output)
+
+	// The certificates are stored in DER encoding, which is printed using PEM
+	if recs[0].Kind == evidence.Certificate {
+		data = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: data})
+	}
+	_, _ = color.Output.Write(data)
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		fmt.Fprintln(color.Output)
+	}
+}
+
+func listEvidence(dir string, args *evidenceArgs) {
+	recs, err := evidence.Records(dir, args.Asset)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	if args.Options.JSON {
+		enc := json.NewEncoder(color.Output)
+		enc.SetIndent("", "  ")
+		if recs == nil {
+			recs = []*evidence.Record{}
+		}
+		_ = enc.Encode(recs)
+		return
+	}
+	for _, rec := range recs {
+		fmt.Fprintf(color.Output, "%s %s %s %s %s\n", green(rec.ID[:16]), blue(rec.Kind),
+			rec.Asset, yellow(rec.Source), rec.Time.Format(time.RFC3339))
+	}
+}
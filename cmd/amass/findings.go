@@ -0,0 +1,209 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/settings"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	findingsUsageMsg = "findings [options] [-d DOMAIN]"
+)
+
+type findingsArgs struct {
+	Domains  *stringset.Set
+	Types    format.ParseStrings
+	Severity string
+	Since    format.ParseTime
+	Details  format.ParseStrings
+	Options  struct {
+		JSON    bool
+		NoColor bool
+		Silent  bool
+		Summary bool
+	}
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+		Domains    format.ParseStrings
+	}
+}
+
+func runFindingsCommand(clArgs []string) {
+	args := findingsArgs{Domains: stringset.New()}
+	defer args.Domains.Close()
+	var help1, help2 bool
+	findingsCommand := flag.NewFlagSet("findings", flag.ContinueOnError)
+
+	findingsBuf := new(bytes.Buffer)
+	findingsCommand.SetOutput(findingsBuf)
+
+	findingsCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	findingsCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	findingsCommand.Var(args.Domains, "d", "Domain names separated by commas (can be used multiple times)")
+	findingsCommand.Var(&args.Types, "type", "Finding types separated by commas (can be used multiple times)")
+	findingsCommand.StringVar(&args.Severity, "severity", "info", "Minimum severity of the findings: info, low, medium, high or critical")
+	findingsCommand.Var(&args.Details, "detail", "Details the findings must provide, as key=value pairs separated by commas")
+	findingsCommand.Var(&args.Since, "since", "Only list the findings observed after this time (RFC 3339 or YYYY-MM-DD)")
+	findingsCommand.BoolVar(&args.Options.JSON, "json", false, "Print the findings as JSON lines")
+	findingsCommand.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	findingsCommand.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
+	findingsCommand.BoolVar(&args.Options.Summary, "summary", false, "Print the number of findings for each type and severity")
+	findingsCommand.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	findingsCommand.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the findings file")
+	findingsCommand.Var(&args.Filepaths.Domains, "df", "Path to a file providing root domain names")
+
+	if err := findingsCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(findingsUsageMsg, findingsCommand, findingsBuf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Options.Silent {
+		color.Output = io.Discard
+		color.Error = io.Discard
+	}
+
+	sev, err := findings.ParseSeverity(args.Severity)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	details := make(map[string]string)
+	for _, d := range args.Details {
+		k, v, found := strings.Cut(d, "=")
+		if !found || strings.TrimSpace(k) == "" {
+			r.Fprintf(color.Error, "The detail %s is not a key=value pair\n", d)
+			os.Exit(1)
+		}
+		details[strings.TrimSpace(k)] = v
+	}
+
+	for _, f := range args.Filepaths.Domains {
+		list, err := config.GetListFromFile(f)
+		if err != nil {
+			r.Fprintf(color.Error, "Failed to parse the domain names file: %v\n", err)
+			os.Exit(1)
+		}
+		args.Domains.InsertMany(list...)
+	}
+
+	cfg := config.NewConfig()
+	if err := settings.Load("findings", cfg, args.Filepaths.Directory, args.Filepaths.ConfigFile); err != nil {
+		r.Fprintf(color.Error, "Failed to load the configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Filepaths.Directory != "" {
+		cfg.Dir = args.Filepaths.Directory
+	}
+	cfg.AddDomains(args.Domains.Slice()...)
+
+	all, err := findings.ReadFile(filepath.Join(config.OutputDirectory(cfg.Dir), "findings.json"))
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	selected := findings.Select(all, &findings.Filter{
+		Domains:  cfg.Domains(),
+		Types:    args.Types,
+		Severity: sev,
+		Since:    time.Time(args.Since),
+		Details:  details,
+	})
+	switch {
+	case args.Options.Summary:
+		printFindingsSummary(selected)
+	case args.Options.JSON:
+		enc := json.NewEncoder(color.Output)
+		for _, f := range selected {
+			if err := enc.Encode(f); err != nil {
+				r.Fprintf(color.Error, "Failed to encode the finding: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	default:
+		for _, f := range selected {
+			printFinding(f)
+		}
+	}
+}
+
+// severityColor returns the function used to colorize the name of the severity.
+func severityColor(sev findings.Severity) func(a ...interface{}) string {
+	switch sev {
+	case findings.Critical, findings.High:
+		return r.SprintFunc()
+	case findings.Medium:
+		return yellow
+	case findings.Low:
+		return blue
+	}
+	return white
+}
+
+func printFinding(f *findings.Finding) {
+	sev := severityColor(f.Severity)(fmt.Sprintf("%-10s", "["+strings.ToUpper(f.Severity.String())+"]"))
+
+	fmt.Fprintf(color.Output, "%s %s %s %s\n", sev, green(f.Asset), magenta(f.Type), f.Description)
+}
+
+// printFindingsSummary prints the number of findings for each type, ordered by severity and then by type.
+func printFindingsSummary(all []*findings.Finding) {
+	type entry struct {
+		Type     string
+		Severity findings.Severity
+		Count    int
+	}
+
+	counts := make(map[string]*entry)
+	for _, f := range all {
+		k := f.Severity.String() + "|" + f.Type
+		if _, found := counts[k]; !found {
+			counts[k] = &entry{Type: f.Type, Severity: f.Severity}
+		}
+		counts[k].Count++
+	}
+
+	var entries []*entry
+	for _, e := range counts {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Severity == entries[j].Severity {
+			return entries[i].Type < entries[j].Type
+		}
+		return entries[i].Severity > entries[j].Severity
+	})
+
+	for _, e := range entries {
+		sev := severityColor(e.Severity)(fmt.Sprintf("%-10s", "["+strings.ToUpper(e.Severity.String())+"]"))
+
+		fmt.Fprintf(color.Output, "%s %s %s\n", sev, magenta(fmt.Sprintf("%-35s", e.Type)), yellow(e.Count))
+	}
+	fmt.Fprintf(color.Output, "%d findings\n", len(all))
+}
@@ -23,6 +23,42 @@ func runHelpCommand(clArgs []string) {
 		runEnumCommand(help)
 	case "intel":
 		runIntelCommand(help)
+	case "subs":
+		runSubsCommand(help)
+	case "viz":
+		runVizCommand(help)
+	case "report":
+		runReportCommand(help)
+	case "findings":
+		runFindingsCommand(help)
+	case "evidence":
+		runEvidenceCommand(clArgs[1:])
+	case "assoc":
+		runAssocCommand(help)
+	case "scope":
+		runScopeCommand(clArgs[1:])
+	case "infra":
+		runInfraCommand(help)
+	case "db":
+		runDBCommand(clArgs[1:])
+	case "config":
+		runConfigCommand(clArgs[1:])
+	case "api":
+		runAPICommand(help)
+	case "worker":
+		runWorkerCommand(help)
+	case "logs":
+		runLogsCommand(help)
+	case "engine":
+		runEngineCommand(clArgs[1:])
+	case "selftest":
+		runSelftestCommand(help)
+	case "tools":
+		runToolsCommand(clArgs[1:])
+	case "sign":
+		runSignCommand(clArgs[1:])
+	case "project":
+		runProjectCommand(clArgs[1:])
 	default:
 		commandUsage(mainUsageMsg, helpCommand, helpBuf)
 		return
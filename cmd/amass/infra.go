@@ -0,0 +1,153 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/analysis"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/settings"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	infraUsageMsg = "infra [options] -d DOMAIN"
+)
+
+type infraArgs struct {
+	Domains *stringset.Set
+	Since   format.ParseTime
+	Options struct {
+		Issues  bool
+		JSON    bool
+		NoColor bool
+		Silent  bool
+	}
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+		Domains    format.ParseStrings
+	}
+}
+
+func runInfraCommand(clArgs []string) {
+	args := infraArgs{Domains: stringset.New()}
+	defer args.Domains.Close()
+	var help1, help2 bool
+	infraCommand := flag.NewFlagSet("infra", flag.ContinueOnError)
+
+	infraBuf := new(bytes.Buffer)
+	infraCommand.SetOutput(infraBuf)
+
+	infraCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	infraCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	infraCommand.Var(args.Domains, "d", "Domain names separated by commas (can be used multiple times)")
+	infraCommand.Var(&args.Since, "since", "Only use the records observed after this time (RFC 3339 or YYYY-MM-DD)")
+	infraCommand.BoolVar(&args.Options.Issues, "issues", false, "Only print the clusters with issues")
+	infraCommand.BoolVar(&args.Options.JSON, "json", false, "Print the clusters as JSON lines")
+	infraCommand.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	infraCommand.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
+	infraCommand.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	infraCommand.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+	infraCommand.Var(&args.Filepaths.Domains, "df", "Path to a file providing root domain names")
+
+	if err := infraCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(infraUsageMsg, infraCommand, infraBuf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Options.Silent {
+		color.Output = io.Discard
+		color.Error = io.Discard
+	}
+
+	for _, f := range args.Filepaths.Domains {
+		list, err := config.GetListFromFile(f)
+		if err != nil {
+			r.Fprintf(color.Error, "Failed to parse the domain names file: %v\n", err)
+			os.Exit(1)
+		}
+		args.Domains.InsertMany(list...)
+	}
+
+	cfg := config.NewConfig()
+	if err := settings.Load("infra", cfg, args.Filepaths.Directory, args.Filepaths.ConfigFile); err != nil {
+		r.Fprintf(color.Error, "Failed to load the configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Filepaths.Directory != "" {
+		cfg.Dir = args.Filepaths.Directory
+	}
+	cfg.AddDomains(args.Domains.Slice()...)
+	if len(cfg.Domains()) == 0 {
+		r.Fprintln(color.Error, "No root domain names were provided")
+		os.Exit(1)
+	}
+
+	clusters, err := infraClusters(cfg, time.Time(args.Since).UTC())
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	enc := json.NewEncoder(color.Output)
+	for _, c := range clusters {
+		if args.Options.Issues && len(c.Issues) == 0 {
+			continue
+		}
+
+		if args.Options.JSON {
+			if err := enc.Encode(c); err != nil {
+				r.Fprintf(color.Error, "Failed to encode the cluster: %v\n", err)
+				os.Exit(1)
+			}
+			continue
+		}
+		printInfraCluster(c)
+	}
+}
+
+// infraClusters returns the infrastructure clusters of the configured domains. The graph database is
+// locked again before returning, since the command exits on errors once they have been read.
+func infraClusters(cfg *config.Config, since time.Time) ([]*analysis.InfraCluster, error) {
+	g, err := openGraphDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer lockGraphDatabase(cfg)
+
+	return analysis.InfraClusters(context.Background(), g, cfg.Domains(), since), nil
+}
+
+func printInfraCluster(c *analysis.InfraCluster) {
+	hosting := blue(c.Hosting)
+	if c.Hosting == analysis.SelfHosted {
+		hosting = yellow(c.Hosting)
+	}
+
+	fmt.Fprintf(color.Output, "%s %s (%s) %s\n", magenta(fmt.Sprintf("%-6s", "["+strings.ToUpper(c.Kind)+"]")),
+		green(c.Provider), hosting, strings.Join(c.Hosts, ", "))
+	fmt.Fprintf(color.Output, "       %d domains: %s\n", len(c.Domains), strings.Join(c.Domains, ", "))
+	if len(c.Issues) > 0 {
+		fmt.Fprintf(color.Output, "       issues: %s\n", r.Sprint(strings.Join(c.Issues, ", ")))
+	}
+}
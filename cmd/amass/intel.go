@@ -23,6 +23,8 @@ import (
 	"github.com/owasp-amass/amass/v4/datasrcs"
 	"github.com/owasp-amass/amass/v4/format"
 	"github.com/owasp-amass/amass/v4/intel"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/settings"
 	"github.com/owasp-amass/amass/v4/systems"
 	"github.com/owasp-amass/config/config"
 )
@@ -69,7 +71,7 @@ func defineIntelArgumentFlags(intelFlags *flag.FlagSet, args *intelArgs) {
 	intelFlags.Var(&args.Addresses, "addr", "IPs and ranges (192.168.1.1-254) separated by commas")
 	intelFlags.Var(&args.ASNs, "asn", "ASNs separated by commas (can be used multiple times)")
 	intelFlags.Var(&args.CIDRs, "cidr", "CIDRs separated by commas (can be used multiple times)")
-	intelFlags.StringVar(&args.OrganizationName, "org", "", "Search string provided against AS description information")
+	intelFlags.StringVar(&args.OrganizationName, "org", "", "Organization name searched for the autonomous systems attributed to it")
 	intelFlags.Var(args.Domains, "d", "Domain names separated by commas (can be used multiple times)")
 	intelFlags.Var(args.Excluded, "exclude", "Data source names separated by commas to be excluded")
 	intelFlags.Var(args.Included, "include", "Data source names separated by commas to be included")
@@ -143,16 +145,14 @@ func runIntelCommand(clArgs []string) {
 	}
 
 	cfg := config.NewConfig()
-	// Check if a configuration file was provided, and if so, load the settings
-	if err := config.AcquireConfig(args.Filepaths.Directory, args.Filepaths.ConfigFile, cfg); err == nil {
-		// Check if a config file was provided that has DNS resolvers specified
-		if len(cfg.Resolvers) > 0 && args.Resolvers.Len() == 0 {
-			args.Resolvers = stringset.New(cfg.Resolvers...)
-		}
-	} else if args.Filepaths.ConfigFile != "" {
-		r.Fprintf(color.Error, "Failed to load the configuration file: %v\n", err)
+	if err := settings.Load("intel", cfg, args.Filepaths.Directory, args.Filepaths.ConfigFile); err != nil {
+		r.Fprintf(color.Error, "Failed to load the configuration: %v\n", err)
 		os.Exit(1)
 	}
+	// Check if the configuration provided DNS resolvers
+	if len(cfg.Resolvers) > 0 && args.Resolvers.Len() == 0 {
+		args.Resolvers = stringset.New(cfg.Resolvers...)
+	}
 
 	// Override configuration file settings with command-line arguments
 	if err := cfg.UpdateConfig(args); err != nil {
@@ -187,7 +187,7 @@ func runIntelCommand(clArgs []string) {
 	}
 
 	createOutputDirectory(cfg)
-	go writeLogsAndMessages(rLog, logfile, args.Options.Verbose)
+	go writeLogsAndMessages(rLog, logfile, args.Options.Verbose, nil)
 
 	sys, err := systems.NewLocalSystem(cfg)
 	if err != nil {
@@ -199,12 +199,19 @@ func runIntelCommand(clArgs []string) {
 	}
 
 	if args.OrganizationName != "" {
-		var asns []int
-		for _, entry := range sys.Cache().DescriptionSearch(args.OrganizationName) {
-			asns = append(asns, entry.ASN)
+		ctx, cancel := context.WithCancel(context.Background())
+		if args.Timeout > 0 {
+			ctx, cancel = context.WithTimeout(context.Background(), time.Duration(args.Timeout)*time.Minute)
 		}
-		if len(asns) > 0 {
-			printNetblocks(asns, cfg, sys)
+		defer cancel()
+
+		entries, err := intel.NewCollection(cfg, sys).OrganizationASNs(ctx, args.OrganizationName)
+		if err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+			os.Exit(1)
+		}
+		for _, d := range entries {
+			printASN(d)
 		}
 		return
 	}
@@ -263,15 +270,16 @@ func printNetblocks(asns []int, cfg *config.Config, sys systems.System) {
 	for _, asn := range asns {
 		systems.PopulateCache(context.Background(), asn, sys)
 
-		d := sys.Cache().ASNSearch(asn)
-		if d == nil {
-			continue
+		if d := sys.Cache().ASNSearch(asn); d != nil {
+			printASN(d)
 		}
+	}
+}
 
-		fmt.Printf("%s%s %s %s\n", blue("ASN: "), yellow(strconv.Itoa(asn)), green("-"), green(d.Description))
-		for _, cidr := range d.Netblocks {
-			fmt.Printf("%s\n", yellow(fmt.Sprintf("\t%s", cidr)))
-		}
+func printASN(d *requests.ASNRequest) {
+	fmt.Printf("%s%s %s %s\n", blue("ASN: "), yellow(strconv.Itoa(d.ASN)), green("-"), green(d.Description))
+	for _, cidr := range d.Netblocks {
+		fmt.Printf("%s\n", yellow(fmt.Sprintf("\t%s", cidr)))
 	}
 }
 
@@ -8,14 +8,21 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/caffix/netmap"
 	"github.com/caffix/stringset"
+	"github.com/owasp-amass/amass/v4/analysis"
+	"github.com/owasp-amass/amass/v4/confidence"
 	"github.com/owasp-amass/amass/v4/enum"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/geoip"
 	"github.com/owasp-amass/amass/v4/requests"
 	"github.com/owasp-amass/asset-db/types"
+	"github.com/owasp-amass/config/config"
 	oam "github.com/owasp-amass/open-asset-model"
 	"github.com/owasp-amass/open-asset-model/domain"
 	"github.com/owasp-amass/open-asset-model/network"
@@ -93,14 +100,16 @@ func extractAssetName(a *types.Asset) string {
 
 // ExtractOutput is a convenience method for obtaining new discoveries made by the enumeration process.
 func ExtractOutput(ctx context.Context, g *netmap.Graph, e *enum.Enumeration, filter *stringset.Set, asinfo bool) []*requests.Output {
-	return EventOutput(ctx, g, e.Config.Domains(), e.Config.CollectionStartTime, filter, asinfo, e.Sys.Cache())
+	return EventOutput(ctx, g, e.Config.Domains(), e.Config.CollectionStartTime, time.Time{}, filter, nil, asinfo, e.Sys.Cache())
 }
 
 type outLookup map[string]*requests.Output
 
 // EventOutput returns findings within the receiver Graph within the scope identified by the provided domain names.
-// The filter is updated by EventOutput.
-func EventOutput(ctx context.Context, g *netmap.Graph, domains []string, since time.Time, f *stringset.Set, asninfo bool, cache *requests.ASNCache) []*requests.Output {
+// Only names and resolutions seen within the since and until times are included, and a zero time leaves
+// that side of the interval open. The names rejected by the selector are skipped before their resolutions
+// are queried. The filter is updated by EventOutput.
+func EventOutput(ctx context.Context, g *netmap.Graph, domains []string, since, until time.Time, f *stringset.Set, sel *nameSelector, asninfo bool, cache *requests.ASNCache) []*requests.Output {
 	var res []*requests.Output
 
 	if len(domains) == 0 {
@@ -129,7 +138,7 @@ func EventOutput(ctx context.Context, g *netmap.Graph, domains []string, since t
 
 	var names []string
 	for _, a := range assets {
-		if n, ok := a.Asset.(domain.FQDN); ok && !f.Has(n.Name) {
+		if n, ok := a.Asset.(domain.FQDN); ok && !f.Has(n.Name) && seenBefore(a, until) && sel.selects(n.Name) {
 			names = append(names, n.Name)
 		}
 	}
@@ -149,7 +158,7 @@ func EventOutput(ctx context.Context, g *netmap.Graph, domains []string, since t
 		lookup[n] = o
 	}
 	// Build the lookup map used to create the final result set
-	if pairs, err := g.NamesToAddrs(ctx, qtime, names...); err == nil {
+	if pairs, err := analysis.NamesToAddrs(ctx, g, qtime, until.UTC(), names...); err == nil {
 		for _, p := range pairs {
 			addr := p.Addr.Address.String()
 
@@ -168,6 +177,166 @@ func EventOutput(ctx context.Context, g *netmap.Graph, domains []string, since t
 	return addInfrastructureInfo(lookup, f, cache)
 }
 
+// nameSelector selects the names by regular expressions and by the properties recorded for them
+// in the findings. All methods are safe to call on a nil nameSelector, which selects every name.
+type nameSelector struct {
+	match   *regexp.Regexp
+	exclude *regexp.Regexp
+	props   map[string]string
+	// The values of the properties recorded for each name
+	values map[string]map[string]*stringset.Set
+	// The names allowed by the other filters, such as the tags, and nil when all names are allowed
+	allowed map[string]struct{}
+}
+
+// newNameSelector returns a nameSelector for the regular expressions, which are ignored when empty, and
+// the key=value properties. The 'type' and 'source' properties match the type and source of the findings,
+// while the other keys match their details. The values are compared without regard to case.
+func newNameSelector(match, exclude string, props []string, all []*findings.Finding) (*nameSelector, error) {
+	sel := &nameSelector{props: make(map[string]string)}
+
+	var err error
+	if match != "" {
+		if sel.match, err = regexp.Compile(match); err != nil {
+			return nil, fmt.Errorf("the -match expression is invalid: %v", err)
+		}
+	}
+	if exclude != "" {
+		if sel.exclude, err = regexp.Compile(exclude); err != nil {
+			return nil, fmt.Errorf("the -exclude expression is invalid: %v", err)
+		}
+	}
+
+	for _, p := range props {
+		k, v, found := strings.Cut(p, "=")
+		if !found || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("the property %s is not a key=value pair", p)
+		}
+		sel.props[strings.ToLower(strings.TrimSpace(k))] = strings.ToLower(strings.TrimSpace(v))
+	}
+	if len(sel.props) == 0 {
+		return sel, nil
+	}
+
+	sel.values = make(map[string]map[string]*stringset.Set)
+	add := func(name, key, value string) {
+		if value == "" {
+			return
+		}
+		if _, found := sel.props[key]; !found {
+			return
+		}
+		if sel.values[name] == nil {
+			sel.values[name] = make(map[string]*stringset.Set)
+		}
+		if sel.values[name][key] == nil {
+			sel.values[name][key] = stringset.New()
+		}
+		sel.values[name][key].Insert(strings.ToLower(value))
+	}
+	for _, f := range all {
+		name := findings.Host(f.Asset)
+
+		add(name, "type", f.Type)
+		add(name, "source", f.Source)
+		for k, v := range f.Details {
+			add(name, strings.ToLower(k), v)
+		}
+	}
+	return sel, nil
+}
+
+// selects returns true when the name matches the expressions, and carries all of the properties.
+func (sel *nameSelector) selects(name string) bool {
+	if sel == nil {
+		return true
+	}
+	if sel.match != nil && !sel.match.MatchString(name) {
+		return false
+	}
+	if sel.exclude != nil && sel.exclude.MatchString(name) {
+		return false
+	}
+
+	if sel.allowed != nil {
+		if _, found := sel.allowed[strings.ToLower(name)]; !found {
+			return false
+		}
+	}
+
+	for k, v := range sel.props {
+		if set := sel.values[name][k]; set == nil || !set.Has(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// only limits the selected names to those provided.
+func (sel *nameSelector) only(names []string) {
+	sel.allowed = make(map[string]struct{}, len(names))
+	for _, name := range names {
+		sel.allowed[strings.ToLower(name)] = struct{}{}
+	}
+}
+
+// close releases the sets of property values.
+func (sel *nameSelector) close() {
+	if sel == nil {
+		return
+	}
+	for _, props := range sel.values {
+		for _, set := range props {
+			set.Close()
+		}
+	}
+}
+
+// confidenceObservations returns the sources of the names recorded by the enumerations, and the Scorer
+// selected by the configuration. Nil is returned when the scoring has not been enabled, unless forced.
+func confidenceObservations(cfg *config.Config, force bool) (*confidence.Scorer, *confidence.Observations, error) {
+	s, err := confidence.FromConfig(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s == nil {
+		if !force {
+			return nil, nil, nil
+		}
+		s = confidence.NewScorer()
+	}
+
+	obs, err := confidence.Load(confidence.Path(cfg))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load the confidence observations: %v", err)
+	}
+	return s, obs, nil
+}
+
+// addressLocations returns the locations of the addresses, keyed by address, recorded in the findings.
+func addressLocations(all []*findings.Finding) map[string]*geoip.Location {
+	locs := make(map[string]*geoip.Location)
+
+	for _, f := range all {
+		if f.Type != enum.LocationFinding || f.Details["country_code"] == "" {
+			continue
+		}
+
+		locs[f.Asset] = &geoip.Location{
+			Country:     f.Details["country"],
+			CountryCode: f.Details["country_code"],
+			Region:      f.Details["region"],
+			City:        f.Details["city"],
+		}
+	}
+	return locs
+}
+
+// seenBefore returns true if the asset was discovered before the until time, or until is zero.
+func seenBefore(a *types.Asset, until time.Time) bool {
+	return until.IsZero() || a.CreatedAt.IsZero() || !a.CreatedAt.After(until)
+}
+
 func removeDuplicates(lookup outLookup, filter *stringset.Set) []*requests.Output {
 	output := make([]*requests.Output, 0, len(lookup))
 
@@ -212,8 +381,8 @@ func addInfrastructureInfo(lookup outLookup, filter *stringset.Set, cache *reque
 }
 
 // EventNames returns findings within the receiver Graph within the scope identified by the provided domain names.
-// The filter is updated by EventNames.
-func EventNames(ctx context.Context, g *netmap.Graph, domains []string, since time.Time, f *stringset.Set) []*requests.Output {
+// Only names seen within the since and until times are included. The filter is updated by EventNames.
+func EventNames(ctx context.Context, g *netmap.Graph, domains []string, since, until time.Time, f *stringset.Set) []*requests.Output {
 	var res []*requests.Output
 
 	if len(domains) == 0 {
@@ -242,7 +411,7 @@ func EventNames(ctx context.Context, g *netmap.Graph, domains []string, since ti
 
 	var names []string
 	for _, a := range assets {
-		if n, ok := a.Asset.(domain.FQDN); ok && !f.Has(n.Name) {
+		if n, ok := a.Asset.(domain.FQDN); ok && !f.Has(n.Name) && seenBefore(a, until) {
 			names = append(names, n.Name)
 			f.Insert(n.Name)
 		}
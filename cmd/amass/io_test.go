@@ -0,0 +1,124 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/amass/v4/findings"
+)
+
+func TestNameSelector(t *testing.T) {
+	all := []*findings.Finding{
+		{Type: "cloud_asset", Asset: "https://vpn.example.com/login", Source: "Cloud", Details: map[string]string{"Provider": "AWS"}},
+		{Type: "name_validation", Asset: "mail.example.com:443", Source: "Validation", Details: map[string]string{"evidence": "tls"}},
+		{Type: "cloud_asset", Asset: "*.dev.example.com", Source: "Cloud", Details: map[string]string{"provider": "GCP"}},
+	}
+	names := []string{"vpn.example.com", "mail.example.com", "dev.example.com", "www.example.com"}
+
+	tests := []struct {
+		name     string
+		match    string
+		exclude  string
+		props    []string
+		only     []string
+		expected []string
+	}{
+		{name: "empty", expected: names},
+		{name: "match", match: `^(vpn|mail)\.`, expected: []string{"vpn.example.com", "mail.example.com"}},
+		{name: "exclude", exclude: `^dev\.`, expected: []string{"vpn.example.com", "mail.example.com", "www.example.com"}},
+		{name: "match and exclude", match: `example\.com$`, exclude: `^(vpn|www)\.`, expected: []string{"mail.example.com", "dev.example.com"}},
+		{name: "exclude wins", match: `^vpn\.`, exclude: `^vpn\.`},
+		{name: "no match", match: `^ftp\.`},
+		{name: "type", props: []string{"type=cloud_asset"}, expected: []string{"vpn.example.com", "dev.example.com"}},
+		{name: "detail ignoring case", props: []string{" provider = aws "}, expected: []string{"vpn.example.com"}},
+		{name: "all properties", props: []string{"type=cloud_asset", "source=cloud", "provider=gcp"}, expected: []string{"dev.example.com"}},
+		{name: "missing property", props: []string{"type=cloud_asset", "evidence=tls"}},
+		{name: "property and exclude", props: []string{"type=cloud_asset"}, exclude: `^dev\.`, expected: []string{"vpn.example.com"}},
+		{name: "only", only: []string{"WWW.example.com", "mail.example.com"}, expected: []string{"mail.example.com", "www.example.com"}},
+		{name: "only and match", match: `^www\.`, only: []string{"www.example.com", "mail.example.com"}, expected: []string{"www.example.com"}},
+		{name: "only nothing", only: []string{}},
+	}
+
+	for _, test := range tests {
+		sel, err := newNameSelector(test.match, test.exclude, test.props, all)
+		if err != nil {
+			t.Errorf("%s: newNameSelector returned an error: %v", test.name, err)
+			continue
+		}
+		if test.only != nil {
+			sel.only(test.only)
+		}
+
+		var got []string
+		for _, n := range names {
+			if sel.selects(n) {
+				got = append(got, n)
+			}
+		}
+		sel.close()
+
+		if len(got) != len(test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.expected[i] {
+				t.Errorf("%s: expected %v, got %v", test.name, test.expected, got)
+				break
+			}
+		}
+	}
+}
+
+func TestNameSelectorInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		match   string
+		exclude string
+		props   []string
+	}{
+		{name: "match", match: "(vpn"},
+		{name: "exclude", exclude: "[a-"},
+		{name: "no value", props: []string{"provider"}},
+		{name: "no key", props: []string{"=aws"}},
+	}
+
+	for _, test := range tests {
+		if _, err := newNameSelector(test.match, test.exclude, test.props, nil); err == nil {
+			t.Errorf("%s: newNameSelector accepted the invalid selection", test.name)
+		}
+	}
+}
+
+func TestNameSelectorEmptyInput(t *testing.T) {
+	var none *nameSelector
+	// The nil selector selects every name, and is safe to close
+	if !none.selects("www.example.com") || !none.selects("") {
+		t.Error("The nil selector did not select every name")
+	}
+	none.close()
+
+	// The properties cannot be carried by names without findings
+	sel, err := newNameSelector("", "", []string{"type=cloud_asset"}, nil)
+	if err != nil {
+		t.Fatalf("newNameSelector returned an error: %v", err)
+	}
+	defer sel.close()
+	if sel.selects("www.example.com") {
+		t.Error("The name was selected without the findings carrying the property")
+	}
+
+	g := netmap.NewGraph("memory", "", "")
+	if out := EventOutput(context.Background(), g, nil, time.Time{}, time.Time{}, nil, sel, false, nil); len(out) != 0 {
+		t.Errorf("EventOutput returned %d names without domains", len(out))
+	}
+	if out := EventOutput(context.Background(), g, []string{"example.com"}, time.Time{}, time.Time{}, nil, sel, false, nil); len(out) != 0 {
+		t.Errorf("EventOutput returned %d names from the empty graph", len(out))
+	}
+}
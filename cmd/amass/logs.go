@@ -0,0 +1,190 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/api"
+	"github.com/owasp-amass/amass/v4/settings"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	logsUsageMsg = "logs [options] SESSION-ID"
+)
+
+type logsArgs struct {
+	Engine      string
+	EngineToken string
+	Level       string
+	Plugin      string
+	Options     struct {
+		Follow  bool
+		JSON    bool
+		NoColor bool
+		Silent  bool
+	}
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+func runLogsCommand(clArgs []string) {
+	var args logsArgs
+	var help1, help2 bool
+	logsCommand := flag.NewFlagSet("logs", flag.ContinueOnError)
+
+	logsBuf := new(bytes.Buffer)
+	logsCommand.SetOutput(logsBuf)
+
+	logsCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	logsCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	logsCommand.StringVar(&args.Engine, "engine", "", "URL of the engine API executing the session")
+	logsCommand.StringVar(&args.EngineToken, "engine-token", "", "API token of the engine (default: $"+api.TokenEnv+")")
+	logsCommand.BoolVar(&args.Options.Follow, "follow", false, "Keep printing the new messages until the session finishes (requires -engine)")
+	logsCommand.BoolVar(&args.Options.JSON, "json", false, "Print the messages as JSON lines")
+	logsCommand.StringVar(&args.Level, "level", "", "Only print the messages of this level or above: debug, info, warn or error")
+	logsCommand.StringVar(&args.Plugin, "plugin", "", "Only print the messages of this data source or enumeration stage")
+	logsCommand.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	logsCommand.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
+	logsCommand.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	logsCommand.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the session logs of the api subcommand")
+
+	if len(clArgs) < 1 {
+		commandUsage(logsUsageMsg, logsCommand, logsBuf)
+		return
+	}
+	if err := logsCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(logsUsageMsg, logsCommand, logsBuf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Options.Silent {
+		color.Output = io.Discard
+		color.Error = io.Discard
+	}
+	if logsCommand.NArg() != 1 {
+		r.Fprintln(color.Error, "Exactly one session ID must be provided")
+		os.Exit(1)
+	}
+	id := logsCommand.Arg(0)
+
+	filter := &api.LogFilter{Plugin: args.Plugin}
+	if args.Level != "" {
+		level, err := api.ParseLevel(args.Level)
+		if err != nil {
+			r.Fprintln(color.Error, "The -level must be debug, info, warn or error")
+			os.Exit(1)
+		}
+		filter.Level = level
+	}
+
+	if args.Engine != "" {
+		remoteSessionLog(&args, id, filter)
+		return
+	}
+	if args.Options.Follow {
+		r.Fprintln(color.Error, "The -follow flag requires the -engine serving the session")
+		os.Exit(1)
+	}
+
+	cfg := config.NewConfig()
+	if err := settings.Load("logs", cfg, args.Filepaths.Directory, args.Filepaths.ConfigFile); err != nil {
+		r.Fprintf(color.Error, "Failed to load the configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Filepaths.Directory != "" {
+		cfg.Dir = args.Filepaths.Directory
+	}
+
+	ls, err := api.SessionLogs(cfg)
+	if err != nil {
+		r.Fprintf(color.Error, "Configuration error: %v\n", err)
+		os.Exit(1)
+	}
+
+	entries, err := api.ReadSessionLog(ls.Dir, id, ls.Backups, filter)
+	if errors.Is(err, os.ErrNotExist) {
+		r.Fprintf(color.Error, "No log was found for the session %s in %s\n", id, ls.Dir)
+		os.Exit(1)
+	} else if err != nil {
+		r.Fprintf(color.Error, "Failed to read the session log: %v\n", err)
+		os.Exit(1)
+	}
+
+	enc := json.NewEncoder(color.Output)
+	for _, e := range entries {
+		if args.Options.JSON {
+			if err := enc.Encode(e); err != nil {
+				r.Fprintf(color.Error, "Failed to encode the log message: %v\n", err)
+				os.Exit(1)
+			}
+			continue
+		}
+		writeLogEntry(e)
+	}
+}
+
+// remoteSessionLog prints the log of the session requested from the engine.
+func remoteSessionLog(args *logsArgs, id string, filter *api.LogFilter) {
+	token := args.EngineToken
+	if token == "" {
+		token = os.Getenv(api.TokenEnv)
+	}
+	client := api.NewClient(args.Engine, token)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Stop following the log once the user requests it, without canceling the session
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(quit)
+
+		select {
+		case <-quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	err := client.Log(ctx, id, filter, args.Options.Follow, args.Options.JSON, color.Output)
+	if err != nil && ctx.Err() == nil {
+		r.Fprintf(color.Error, "Failed to obtain the session log: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func writeLogEntry(e *api.LogEntry) {
+	level := fmt.Sprintf("%-5s", e.Level)
+
+	switch e.Level {
+	case api.LevelError:
+		level = r.Sprint(level)
+	case api.LevelWarn:
+		level = yellow(level)
+	default:
+		level = blue(level)
+	}
+	fmt.Fprintf(color.Output, "%s %s %s\n", e.Time.Format("2006-01-02 15:04:05"), level, e.Message)
+}
@@ -40,7 +40,7 @@ import (
 )
 
 const (
-	mainUsageMsg         = "intel|enum [options]"
+	mainUsageMsg         = "[-project NAME] intel|enum|subs|viz|report|findings|evidence|assoc|scope|infra|db|config|api|worker|logs|engine|selftest|tools|sign|project [options]"
 	exampleConfigFileURL = "https://github.com/owasp-amass/amass/blob/master/examples/config.yaml"
 	userGuideURL         = "https://github.com/owasp-amass/amass/blob/master/doc/user_guide.md"
 	tutorialURL          = "https://github.com/owasp-amass/amass/blob/master/doc/tutorial.md"
@@ -67,8 +67,26 @@ func commandUsage(msg string, cmdFlagSet *flag.FlagSet, errBuf *bytes.Buffer) {
 
 	if msg == mainUsageMsg {
 		g.Fprintf(color.Error, "\nSubcommands: \n\n")
-		g.Fprintf(color.Error, "\t%-11s - Discover targets for enumerations\n", "amass intel")
-		g.Fprintf(color.Error, "\t%-11s - Perform enumerations and network mapping\n", "amass enum")
+		g.Fprintf(color.Error, "\t%-14s - Discover targets for enumerations\n", "amass intel")
+		g.Fprintf(color.Error, "\t%-14s - Perform enumerations and network mapping\n", "amass enum")
+		g.Fprintf(color.Error, "\t%-14s - Read the subdomains discovered in the graph database\n", "amass subs")
+		g.Fprintf(color.Error, "\t%-14s - Export the graph database for visualization\n", "amass viz")
+		g.Fprintf(color.Error, "\t%-14s - Render an HTML report summarizing the enumerations\n", "amass report")
+		g.Fprintf(color.Error, "\t%-14s - List the findings about the discovered assets\n", "amass findings")
+		g.Fprintf(color.Error, "\t%-14s - Show the raw data backing the findings\n", "amass evidence")
+		g.Fprintf(color.Error, "\t%-14s - Review the domains associated with the target domains\n", "amass assoc")
+		g.Fprintf(color.Error, "\t%-14s - Approve or deny the root domains discovered by the enumerations\n", "amass scope")
+		g.Fprintf(color.Error, "\t%-14s - Cluster the domains by their nameservers and mail providers\n", "amass infra")
+		g.Fprintf(color.Error, "\t%-14s - Search, upgrade, prune and annotate the graph database\n", "amass db")
+		g.Fprintf(color.Error, "\t%-14s - Show the configuration resolved from all the layers\n", "amass config")
+		g.Fprintf(color.Error, "\t%-14s - Serve the graph database through a read-only REST API\n", "amass api")
+		g.Fprintf(color.Error, "\t%-14s - Execute the enumeration jobs queued by a remote engine\n", "amass worker")
+		g.Fprintf(color.Error, "\t%-14s - Print the log of an enumeration session\n", "amass logs")
+		g.Fprintf(color.Error, "\t%-14s - Diagnose the dependencies of the engine\n", "amass engine")
+		g.Fprintf(color.Error, "\t%-14s - Validate the installation against a mock Internet\n", "amass selftest")
+		g.Fprintf(color.Error, "\t%-14s - Manage the resources used by enumerations\n", "amass tools")
+		g.Fprintf(color.Error, "\t%-14s - Sign the exported files and verify their signatures\n", "amass sign")
+		g.Fprintf(color.Error, "\t%-14s - List, switch and archive the project workspaces\n", "amass project")
 	}
 
 	g.Fprintln(color.Error)
@@ -79,6 +97,7 @@ func commandUsage(msg string, cmdFlagSet *flag.FlagSet, errBuf *bytes.Buffer) {
 
 func main() {
 	var version, help1, help2 bool
+	var project string
 	mainFlagSet := flag.NewFlagSet("amass", flag.ContinueOnError)
 
 	defaultBuf := new(bytes.Buffer)
@@ -87,6 +106,7 @@ func main() {
 	mainFlagSet.BoolVar(&help1, "h", false, "Show the program usage message")
 	mainFlagSet.BoolVar(&help2, "help", false, "Show the program usage message")
 	mainFlagSet.BoolVar(&version, "version", false, "Print the version number of this Amass binary")
+	mainFlagSet.StringVar(&project, "project", "", "Create the project workspace and use its output directory")
 
 	if len(os.Args) < 2 {
 		commandUsage(mainUsageMsg, mainFlagSet, defaultBuf)
@@ -105,13 +125,62 @@ func main() {
 		return
 	}
 
-	switch os.Args[1] {
+	args := mainFlagSet.Args()
+	// The project is created and selected before the subcommand loads its configuration
+	if project != "" {
+		selectProject(project, len(args) > 0)
+		if len(args) == 0 {
+			return
+		}
+	}
+	if len(args) == 0 {
+		commandUsage(mainUsageMsg, mainFlagSet, defaultBuf)
+		os.Exit(1)
+	}
+
+	switch args[0] {
 	case "enum":
-		runEnumCommand(os.Args[2:])
+		runEnumCommand(args[1:])
 	case "intel":
-		runIntelCommand(os.Args[2:])
+		runIntelCommand(args[1:])
+	case "subs":
+		runSubsCommand(args[1:])
+	case "viz":
+		runVizCommand(args[1:])
+	case "report":
+		runReportCommand(args[1:])
+	case "findings":
+		runFindingsCommand(args[1:])
+	case "evidence":
+		runEvidenceCommand(args[1:])
+	case "assoc":
+		runAssocCommand(args[1:])
+	case "scope":
+		runScopeCommand(args[1:])
+	case "infra":
+		runInfraCommand(args[1:])
+	case "db":
+		runDBCommand(args[1:])
+	case "config":
+		runConfigCommand(args[1:])
+	case "api":
+		runAPICommand(args[1:])
+	case "worker":
+		runWorkerCommand(args[1:])
+	case "logs":
+		runLogsCommand(args[1:])
+	case "engine":
+		runEngineCommand(args[1:])
+	case "selftest":
+		runSelftestCommand(args[1:])
+	case "tools":
+		runToolsCommand(args[1:])
+	case "sign":
+		runSignCommand(args[1:])
+	case "project":
+		runProjectCommand(args[1:])
 	case "help":
-		runHelpCommand(os.Args[2:])
+		runHelpCommand(args[1:])
 	default:
 		commandUsage(mainUsageMsg, mainFlagSet, defaultBuf)
 		os.Exit(1)
@@ -0,0 +1,241 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/enum"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+const progressInterval = 5 * time.Second
+
+// The kinds of events written to the progress stream.
+const (
+	progressStart       = "start"
+	progressSourceStart = "source_start"
+	progressSourceStop  = "source_stop"
+	progressCounts      = "progress"
+	progressError       = "error"
+	progressComplete    = "complete"
+	progressFinish      = "finish"
+)
+
+// progressBudget is the consumption of the resources limited by the budget of the enumeration.
+type progressBudget struct {
+	DNSQueries   int64  `json:"dns_queries"`
+	HTTPRequests int64  `json:"http_requests"`
+	DNSLimit     int64  `json:"dns_limit,omitempty"`
+	HTTPLimit    int64  `json:"http_limit,omitempty"`
+	Exhausted    string `json:"exhausted,omitempty"`
+}
+
+// progressEvent is a single line of the NDJSON progress stream.
+type progressEvent struct {
+	Event      string          `json:"event"`
+	Time       time.Time       `json:"time"`
+	Domains    []string        `json:"domains,omitempty"`
+	Source     string          `json:"source,omitempty"`
+	Requests   int             `json:"requests,omitempty"`
+	Names      int             `json:"names,omitempty"`
+	Assets     *int            `json:"assets,omitempty"`
+	InputQueue *int            `json:"input_queue,omitempty"`
+	InfraQueue *int            `json:"infra_queue,omitempty"`
+	Paused     bool            `json:"paused,omitempty"`
+	Budget     *progressBudget `json:"budget,omitempty"`
+	Category   string          `json:"category,omitempty"`
+	Count      int             `json:"count,omitempty"`
+	Message    string          `json:"message,omitempty"`
+	Elapsed    float64         `json:"elapsed_seconds,omitempty"`
+}
+
+// progressWriter writes the progress of a running enumeration as NDJSON events to a file or named pipe.
+// Writing stops after the first failure, such as the reader of the pipe going away, so the enumeration
+// is never disrupted. The fail and close methods are safe to call on a nil progressWriter.
+type progressWriter struct {
+	sync.Mutex
+	w      io.WriteCloser
+	enc    *json.Encoder
+	enum   *enum.Enumeration
+	start  time.Time
+	assets int
+	// The number of requests sent to each data source, and the sources currently working
+	requests map[string]int
+	active   map[string]bool
+	// The number of failures already reported for each data source and error category
+	errors map[string]int
+	failed bool
+}
+
+// newProgressWriter opens the file or named pipe receiving the events. Opening a
+// named pipe blocks until a reader has opened the other end of the pipe.
+func newProgressWriter(path string) (*progressWriter, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &progressWriter{
+		w:        f,
+		enc:      json.NewEncoder(f),
+		start:    time.Now(),
+		requests: make(map[string]int),
+		active:   make(map[string]bool),
+		errors:   make(map[string]int),
+	}, nil
+}
+
+func (p *progressWriter) write(e *progressEvent) {
+	if p.failed {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	if err := p.enc.Encode(e); err != nil {
+		p.failed = true
+	}
+}
+
+// fail reports the error that ended the enumeration.
+func (p *progressWriter) fail(err error) {
+	if p == nil || err == nil {
+		return
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	p.write(&progressEvent{Event: progressError, Message: err.Error()})
+}
+
+// sourceErrors reports the failures of the data sources classified since the last update.
+func (p *progressWriter) sourceErrors(failures []*requests.SourceErrors) {
+	for _, se := range failures {
+		for _, ec := range se.Errors {
+			key := se.Source + "/" + string(ec.Category)
+			if n := ec.Count - p.errors[key]; n > 0 {
+				p.write(&progressEvent{
+					Event:    progressError,
+					Source:   se.Source,
+					Category: string(ec.Category),
+					Count:    n,
+					Message:  ec.Last,
+				})
+			}
+			p.errors[key] = ec.Count
+		}
+	}
+}
+
+// run writes the events until the output channel is closed by the enumeration.
+func (p *progressWriter) run(e *enum.Enumeration, output chan string, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	p.Lock()
+	p.enum = e
+	p.write(&progressEvent{Event: progressStart, Domains: e.Config.Domains()})
+	p.Unlock()
+
+	t := time.NewTicker(progressInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case _, ok := <-output:
+			if !ok {
+				p.finish()
+				return
+			}
+			p.Lock()
+			p.assets++
+			p.Unlock()
+		case <-t.C:
+			p.update(false)
+		}
+	}
+}
+
+// update reports the data sources that started or stopped working or failed since the last update, and the counts.
+func (p *progressWriter) update(final bool) {
+	stats := p.enum.Stats()
+	failures := p.enum.SourceErrors()
+
+	p.Lock()
+	defer p.Unlock()
+
+	for _, s := range stats.Sources {
+		busy := s.Queued > 0 || s.Requests > p.requests[s.Name]
+		p.requests[s.Name] = s.Requests
+
+		switch active := p.active[s.Name]; {
+		case busy && !active && !final:
+			p.write(&progressEvent{Event: progressSourceStart, Source: s.Name, Requests: s.Requests, Names: s.Names})
+			p.active[s.Name] = true
+		case active && (!busy || final):
+			p.write(&progressEvent{Event: progressSourceStop, Source: s.Name, Requests: s.Requests, Names: s.Names})
+			delete(p.active, s.Name)
+		}
+	}
+	p.sourceErrors(failures)
+
+	assets, input, infra := p.assets, stats.InputQueue, stats.InfraQueue
+	event := &progressEvent{
+		Event:      progressCounts,
+		Assets:     &assets,
+		InputQueue: &input,
+		InfraQueue: &infra,
+		Paused:     stats.Paused,
+		Elapsed:    time.Since(p.start).Seconds(),
+	}
+	if b := p.enum.Sys.Budget(); b != nil {
+		limits := b.Limits()
+		event.Budget = &progressBudget{
+			DNSLimit:  limits.DNSQueries,
+			HTTPLimit: limits.HTTPRequests,
+			Exhausted: b.Reason(),
+		}
+		for _, u := range b.TopConsumers(0) {
+			event.Budget.DNSQueries += u.DNSQueries
+			event.Budget.HTTPRequests += u.HTTPRequests
+		}
+	}
+	p.write(event)
+}
+
+// complete reports how the enumeration came to an end, before its final output has been extracted.
+func (p *progressWriter) complete(c *enum.Completion) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.write(&progressEvent{Event: progressComplete, Message: c.Reason, Elapsed: c.Finished.Sub(c.Started).Seconds()})
+}
+
+func (p *progressWriter) finish() {
+	p.update(true)
+
+	p.Lock()
+	defer p.Unlock()
+
+	assets := p.assets
+	p.write(&progressEvent{Event: progressFinish, Assets: &assets, Elapsed: time.Since(p.start).Seconds()})
+}
+
+// close releases the file or named pipe.
+func (p *progressWriter) close() {
+	if p == nil {
+		return
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	_ = p.w.Close()
+}
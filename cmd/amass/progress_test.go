@@ -0,0 +1,144 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/caffix/service"
+	"github.com/owasp-amass/amass/v4/enum"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+type failingSource struct {
+	*service.BaseService
+	counts []*requests.ErrorCount
+}
+
+func (f *failingSource) ErrorCounts() []*requests.ErrorCount { return f.counts }
+
+func newTestProgress(t *testing.T) (*progressWriter, string) {
+	path := filepath.Join(t.TempDir(), "progress.ndjson")
+
+	p, err := newProgressWriter(path)
+	if err != nil {
+		t.Fatalf("Failed to open the progress output: %v", err)
+	}
+	return p, path
+}
+
+func readProgress(t *testing.T, path string) []*progressEvent {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open the progress output: %v", err)
+	}
+	defer f.Close()
+
+	var events []*progressEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e progressEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("The progress output has a line that is not JSON: %s", scanner.Text())
+		}
+		events = append(events, &e)
+	}
+	return events
+}
+
+func TestProgressFail(t *testing.T) {
+	var nilWriter *progressWriter
+	// The methods used by the command are safe without a progress output
+	nilWriter.fail(errors.New("failed"))
+	nilWriter.close()
+
+	p, path := newTestProgress(t)
+	p.fail(nil)
+	p.fail(errors.New("failed to setup the enumeration"))
+	p.close()
+
+	events := readProgress(t, path)
+	if len(events) != 1 {
+		t.Fatalf("Expected a single event, got %d", len(events))
+	}
+	if e := events[0]; e.Event != progressError || e.Message != "failed to setup the enumeration" || e.Time.IsZero() {
+		t.Errorf("The error event was not written: %+v", e)
+	}
+}
+
+func TestProgressSourceErrors(t *testing.T) {
+	src := &failingSource{counts: []*requests.ErrorCount{
+		{Category: requests.AuthError, Count: 2, Last: "401 Unauthorized"},
+	}}
+	src.BaseService = service.NewBaseService(src, "Example")
+
+	p, path := newTestProgress(t)
+	sys := &systems.SimpleSystem{Cfg: config.NewConfig(), Service: src}
+	if p.enum = enum.NewEnumeration(sys.Cfg, sys, nil); p.enum == nil {
+		t.Fatal("Failed to setup the enumeration")
+	}
+
+	p.update(false)
+	// Only the failures classified since the last update are reported
+	src.counts = []*requests.ErrorCount{
+		{Category: requests.AuthError, Count: 3, Last: "403 Forbidden"},
+		{Category: requests.NetworkError, Count: 1, Last: "connection refused"},
+	}
+	p.update(false)
+	p.update(true)
+	p.close()
+
+	var failures []*progressEvent
+	var counts int
+	for _, e := range readProgress(t, path) {
+		switch e.Event {
+		case progressError:
+			failures = append(failures, e)
+		case progressCounts:
+			counts++
+		}
+	}
+	if counts != 3 {
+		t.Errorf("Expected three progress events, got %d", counts)
+	}
+
+	expected := []progressEvent{
+		{Source: "Example", Category: "auth", Count: 2, Message: "401 Unauthorized"},
+		{Source: "Example", Category: "auth", Count: 1, Message: "403 Forbidden"},
+		{Source: "Example", Category: "network", Count: 1, Message: "connection refused"},
+	}
+	if len(failures) != len(expected) {
+		t.Fatalf("Expected %d error events, got %d", len(expected), len(failures))
+	}
+	for i, e := range failures {
+		want := expected[i]
+		if e.Source != want.Source || e.Category != want.Category || e.Count != want.Count || e.Message != want.Message {
+			t.Errorf("Error event %d: expected %+v, got %+v", i, want, e)
+		}
+	}
+}
+
+func TestProgressStopsAfterFailure(t *testing.T) {
+	p, path := newTestProgress(t)
+	p.complete(&enum.Completion{Reason: enum.CompletionIdle})
+	// Writing stops once the output fails, such as the reader of a pipe going away
+	p.close()
+	p.fail(errors.New("failed"))
+	p.fail(errors.New("failed again"))
+
+	if !p.failed {
+		t.Error("The failure to write the event was not recorded")
+	}
+	if events := readProgress(t, path); len(events) != 1 || events[0].Event != progressComplete || events[0].Message != enum.CompletionIdle {
+		t.Errorf("Expected only the complete event, got %d events", len(events))
+	}
+}
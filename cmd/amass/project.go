@@ -0,0 +1,198 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/workspace"
+)
+
+const (
+	projectUsageMsg        = "project list|switch|archive [options]"
+	projectListUsageMsg    = "project list [-json]"
+	projectSwitchUsageMsg  = "project switch NAME|-none"
+	projectArchiveUsageMsg = "project archive NAME"
+)
+
+type projectArgs struct {
+	Options struct {
+		JSON    bool
+		None    bool
+		NoColor bool
+		Silent  bool
+	}
+}
+
+func runProjectCommand(clArgs []string) {
+	projectBuf := new(bytes.Buffer)
+	projectCommand := flag.NewFlagSet("project", flag.ContinueOnError)
+	projectCommand.SetOutput(projectBuf)
+
+	if len(clArgs) < 1 {
+		commandUsage(projectUsageMsg, projectCommand, projectBuf)
+		return
+	}
+
+	switch clArgs[0] {
+	case "list":
+		runProjectSubcommand("list", projectListUsageMsg, clArgs[1:])
+	case "switch":
+		runProjectSubcommand("switch", projectSwitchUsageMsg, clArgs[1:])
+	case "archive":
+		runProjectSubcommand("archive", projectArchiveUsageMsg, clArgs[1:])
+	default:
+		commandUsage(projectUsageMsg, projectCommand, projectBuf)
+		os.Exit(1)
+	}
+}
+
+func runProjectSubcommand(name, usage string, clArgs []string) {
+	var args projectArgs
+	var help1, help2 bool
+	cmd := flag.NewFlagSet(name, flag.ContinueOnError)
+
+	buf := new(bytes.Buffer)
+	cmd.SetOutput(buf)
+
+	cmd.BoolVar(&help1, "h", false, "Show the program usage message")
+	cmd.BoolVar(&help2, "help", false, "Show the program usage message")
+	cmd.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	cmd.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
+	switch name {
+	case "list":
+		cmd.BoolVar(&args.Options.JSON, "json", false, "Print the projects as JSON")
+	case "switch":
+		cmd.BoolVar(&args.Options.None, "none", false, "Return to the default output directory")
+	}
+
+	if err := cmd.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(usage, cmd, buf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Options.Silent {
+		color.Output = io.Discard
+		color.Error = io.Discard
+	}
+
+	switch name {
+	case "list":
+		listProjects(&args)
+	case "switch":
+		if (cmd.NArg() == 1) == args.Options.None {
+			r.Fprintln(color.Error, "Either a project name or the -none flag must be provided")
+			os.Exit(1)
+		}
+		switchProject(cmd.Arg(0))
+	case "archive":
+		if cmd.NArg() != 1 {
+			r.Fprintln(color.Error, "Exactly one project name must be provided")
+			os.Exit(1)
+		}
+		archiveProject(cmd.Arg(0))
+	}
+}
+
+func listProjects(args *projectArgs) {
+	projects, err := workspace.List()
+	if err != nil {
+		r.Fprintf(color.Error, "Failed to list the projects: %v\n", err)
+		os.Exit(1)
+	}
+
+	if args.Options.JSON {
+		enc := json.NewEncoder(color.Output)
+		enc.SetIndent("", "  ")
+		if projects == nil {
+			projects = []*workspace.Project{}
+		}
+		if err := enc.Encode(projects); err != nil {
+			r.Fprintf(color.Error, "Failed to encode the projects: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(projects) == 0 {
+		fmt.Fprintf(color.Error, "No projects have been created; use %s to create one\n", yellow("amass -project NAME"))
+		return
+	}
+	for _, p := range projects {
+		var marker, status string
+		if p.Active {
+			marker = green("*")
+		}
+		if p.Archived {
+			status = fgY.Sprint(" (archived)")
+		}
+		fmt.Fprintf(color.Output, "%1s %-30s %s  %s%s\n", marker, green(p.Name),
+			p.Modified.Format("2006-01-02 15:04"), p.Dir, status)
+	}
+}
+
+func switchProject(name string) {
+	if err := workspace.Switch(name); errors.Is(err, workspace.ErrNotFound) {
+		r.Fprintf(color.Error, "%v; use %s to create it\n", err, yellow("amass -project "+name))
+		os.Exit(1)
+	} else if err != nil {
+		r.Fprintf(color.Error, "Failed to switch the project: %v\n", err)
+		os.Exit(1)
+	}
+
+	if name == "" {
+		fmt.Fprintln(color.Error, "The commands now use the default output directory")
+		return
+	}
+	dir, _ := workspace.Dir(name)
+	fmt.Fprintf(color.Error, "The commands now use the %s project in %s\n", green(name), green(dir))
+}
+
+func archiveProject(name string) {
+	dest, err := workspace.Archive(name)
+	if err != nil {
+		r.Fprintf(color.Error, "Failed to archive the project: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(color.Error, "The %s project was archived in %s\n", green(name), green(dest))
+}
+
+// selectProject creates the project provided by the -project flag, and selects it for the command.
+// Without a command, the project becomes the active project of the following commands.
+func selectProject(name string, command bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	dir, err := workspace.Create(name)
+	if err != nil {
+		r.Fprintf(color.Error, "Failed to create the project: %v\n", err)
+		os.Exit(1)
+	}
+
+	if command {
+		if err := os.Setenv(workspace.Env, name); err != nil {
+			r.Fprintf(color.Error, "Failed to select the project: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := workspace.Switch(name); err != nil {
+		r.Fprintf(color.Error, "Failed to switch the project: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(color.Error, "The commands now use the %s project in %s\n", green(name), green(dir))
+}
@@ -0,0 +1,137 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/api"
+	"github.com/owasp-amass/amass/v4/settings"
+	"github.com/owasp-amass/config/config"
+)
+
+// runRemoteEnumeration submits the enumeration to the engine selected by the -engine flag, follows
+// the log of the session, and prints the names discovered by the engine once the session finishes.
+func runRemoteEnumeration(cfg *config.Config, args *enumArgs) {
+	req := &api.SessionRequest{Domains: cfg.Domains()}
+	// The configuration file is packaged with the request, since the engine cannot read the local files
+	if path := settings.ConfigPath(args.Filepaths.Directory, args.Filepaths.ConfigFile); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			r.Fprintf(color.Error, "Failed to read the configuration file: %v\n", err)
+			os.Exit(1)
+		}
+		// The engine refuses the settings reading files, such as the data source credentials and wordlists
+		accepted, dropped, err := settings.StripSession(string(data))
+		if err != nil {
+			r.Fprintf(color.Error, "Failed to read the configuration file: %v\n", err)
+			os.Exit(1)
+		}
+		if len(dropped) > 0 {
+			fgY.Fprintf(color.Error, "The engine does not accept %s from the configuration file\n", strings.Join(dropped, ", "))
+		}
+		req.Config = accepted
+	}
+	if budget := time.Duration(args.Timeout); budget > 0 {
+		req.Timeout = budget.String()
+	}
+
+	token := args.EngineToken
+	if token == "" {
+		token = os.Getenv(api.TokenEnv)
+	}
+	client := api.NewClient(args.Engine, token)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sess, err := client.StartSession(ctx, req)
+	if err != nil {
+		r.Fprintf(color.Error, "Failed to submit the enumeration: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(color.Error, "%s %s was started by %s\n", blue("Session"), green(sess.ID), yellow(args.Engine))
+
+	// Cancel the session on the engine once the user requests it
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(quit)
+
+		select {
+		case <-quit:
+			cctx, ccancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer ccancel()
+
+			if _, err := client.CancelSession(cctx, sess.ID); err != nil {
+				r.Fprintf(color.Error, "Failed to cancel the session: %v\n", err)
+			}
+		case <-done:
+		}
+	}()
+
+	// Pause and resume the session on the engine as the user sends the signals
+	go handlePauseSignals(ctx, func() {
+		if _, err := client.PauseSession(ctx, sess.ID); err != nil {
+			r.Fprintf(color.Error, "Failed to pause the session: %v\n", err)
+		}
+	}, func() {
+		if _, err := client.ResumeSession(ctx, sess.ID); err != nil {
+			r.Fprintf(color.Error, "Failed to resume the session: %v\n", err)
+		}
+	})
+
+	var logs io.Writer = io.Discard
+	if args.Options.Verbose {
+		logs = color.Error
+	}
+	if err := client.FollowLog(ctx, sess.ID, logs); err != nil {
+		r.Fprintf(color.Error, "Failed to follow the session log: %v\n", err)
+	}
+
+	// The followed log ends shortly before the engine records the final state of the session
+	if sess, err = client.WaitSession(ctx, sess.ID); err != nil {
+		r.Fprintf(color.Error, "Failed to obtain the session state: %v\n", err)
+		os.Exit(1)
+	}
+	if sess.State == api.SessionFailed {
+		r.Fprintf(color.Error, "The enumeration failed: %s\n", sess.Error)
+		os.Exit(1)
+	}
+
+	names, err := client.Names(ctx, sess.ID)
+	if err != nil {
+		r.Fprintf(color.Error, "Failed to obtain the discovered names: %v\n", err)
+		os.Exit(1)
+	}
+
+	var out io.Writer = color.Output
+	if args.Filepaths.TermOut != "" {
+		f, err := os.Create(args.Filepaths.TermOut)
+		if err != nil {
+			r.Fprintf(color.Error, "Failed to open the text output file: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		out = io.MultiWriter(color.Output, f)
+	}
+	for _, name := range names {
+		fmt.Fprintln(out, name)
+	}
+	if len(names) == 0 {
+		r.Fprintln(color.Error, "No assets were discovered")
+	}
+	fmt.Fprintf(color.Error, "\n%s\n", green("The enumeration has "+sess.State))
+}
@@ -0,0 +1,373 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/report"
+	"github.com/owasp-amass/amass/v4/settings"
+	"github.com/owasp-amass/amass/v4/viz"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	reportUsageMsg = "report [options] -d DOMAIN | -scoreboard [options] | -crossref [options]"
+)
+
+type reportArgs struct {
+	Domains *stringset.Set
+	Since   format.ParseTime
+	Title   string
+	Org     string
+	FailOn  string
+	Options struct {
+		CrossRef     bool
+		NoColor      bool
+		Scoreboard   bool
+		Silent       bool
+		ShowTemplate bool
+	}
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+		Domains    format.ParseStrings
+		Output     string
+		Template   string
+	}
+}
+
+func runReportCommand(clArgs []string) {
+	args := reportArgs{Domains: stringset.New()}
+	defer args.Domains.Close()
+	var help1, help2 bool
+	reportCommand := flag.NewFlagSet("report", flag.ContinueOnError)
+
+	reportBuf := new(bytes.Buffer)
+	reportCommand.SetOutput(reportBuf)
+
+	reportCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	reportCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	reportCommand.Var(args.Domains, "d", "Domain names separated by commas (can be used multiple times)")
+	reportCommand.Var(&args.Since, "since", "Report assets first seen after this time as new (RFC 3339 or YYYY-MM-DD)")
+	reportCommand.StringVar(&args.Title, "title", "OWASP Amass Report", "Title of the report")
+	reportCommand.StringVar(&args.Org, "org", "", "Organization owning the -d domains on the scoreboard")
+	reportCommand.StringVar(&args.FailOn, "fail-on", "", "Exit with status 2 when findings of this severity or higher exist: info, low, medium, high or critical")
+	reportCommand.BoolVar(&args.Options.CrossRef, "crossref", false, "Render the infrastructure shared between the organizations")
+	reportCommand.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	reportCommand.BoolVar(&args.Options.Scoreboard, "scoreboard", false, "Render the scores of the organizations across their domains")
+	reportCommand.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
+	reportCommand.BoolVar(&args.Options.ShowTemplate, "show-template", false, "Print the default report template and exit")
+	reportCommand.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	reportCommand.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+	reportCommand.Var(&args.Filepaths.Domains, "df", "Path to a file providing root domain names")
+	reportCommand.StringVar(&args.Filepaths.Output, "o", "", "Path to the HTML file that will be created (default: report.html in the output directory)")
+	reportCommand.StringVar(&args.Filepaths.Template, "template", "", "Path to a Go template used in place of the default report template")
+
+	if len(clArgs) < 1 {
+		commandUsage(reportUsageMsg, reportCommand, reportBuf)
+		return
+	}
+	if err := reportCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(reportUsageMsg, reportCommand, reportBuf)
+		return
+	}
+	if args.Options.ShowTemplate {
+		if args.Options.CrossRef {
+			fmt.Fprint(color.Output, report.DefaultCrossReferenceTemplate)
+		} else if args.Options.Scoreboard {
+			fmt.Fprint(color.Output, report.DefaultScoreboardTemplate)
+		} else {
+			fmt.Fprint(color.Output, report.DefaultTemplate)
+		}
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Options.Silent {
+		color.Output = io.Discard
+		color.Error = io.Discard
+	}
+
+	failOn := findings.Severity(-1)
+	if args.FailOn != "" {
+		sev, err := findings.ParseSeverity(args.FailOn)
+		if err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+			os.Exit(1)
+		}
+		failOn = sev
+	}
+
+	parse := report.ParseTemplate
+	if args.Options.CrossRef {
+		parse = report.ParseCrossReferenceTemplate
+	} else if args.Options.Scoreboard {
+		parse = report.ParseScoreboardTemplate
+	}
+	tmpl, err := parse(args.Filepaths.Template)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	for _, f := range args.Filepaths.Domains {
+		list, err := config.GetListFromFile(f)
+		if err != nil {
+			r.Fprintf(color.Error, "Failed to parse the domain names file: %v\n", err)
+			os.Exit(1)
+		}
+		args.Domains.InsertMany(list...)
+	}
+
+	cfg := config.NewConfig()
+	if err := settings.Load("report", cfg, args.Filepaths.Directory, args.Filepaths.ConfigFile); err != nil {
+		r.Fprintf(color.Error, "Failed to load the configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Filepaths.Directory != "" {
+		cfg.Dir = args.Filepaths.Directory
+	}
+	cfg.AddDomains(args.Domains.Slice()...)
+	priv := exportKey(cfg)
+	if args.Options.CrossRef {
+		writeCrossReference(cfg, &args, tmpl, priv)
+		return
+	}
+	if args.Options.Scoreboard {
+		writeScoreboard(cfg, &args, tmpl, failOn, priv)
+		return
+	}
+	if len(cfg.Domains()) == 0 {
+		r.Fprintln(color.Error, "No root domain names were provided")
+		os.Exit(1)
+	}
+
+	dir := config.OutputDirectory(cfg.Dir)
+	since := time.Time(args.Since)
+	// Without the -since flag, the assets discovered by the latest enumeration are reported as new
+	if since.IsZero() {
+		runs, err := report.LoadRuns(dir)
+		if err != nil {
+			r.Fprintf(color.Error, "Failed to read the enumeration history: %v\n", err)
+			os.Exit(1)
+		}
+		since = report.LastRunStart(runs, cfg.Domains())
+	}
+
+	all, err := findings.ReadFile(filepath.Join(dir, "findings.json"))
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	graphs, err := buildGraphs(cfg, cfg.Domains())
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	graph := graphs[0]
+
+	path := args.Filepaths.Output
+	if path == "" {
+		path = filepath.Join(dir, "report.html")
+	}
+	rep := report.New(args.Title, cfg.Domains(), graph, all, since)
+	if err := writeReportFile(path, rep, tmpl); err != nil {
+		r.Fprintf(color.Error, "Failed to write %s: %v\n", path, err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(color.Error, "%s was written for %s with %s new assets and %s findings\n", green(path),
+		green(strings.Join(cfg.Domains(), ", ")), yellow(fmt.Sprint(rep.Summary.New)), yellow(fmt.Sprint(rep.Summary.Findings)))
+	if err := signExport(priv, path); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	checkFailOn(rep.Findings, failOn)
+}
+
+// checkFailOn exits with status 2 when findings at or above the severity exist, so the report
+// can gate a CI/CD pipeline. A negative severity disables the check.
+func checkFailOn(all []*findings.Finding, sev findings.Severity) {
+	if sev < 0 {
+		return
+	}
+
+	selected := findings.Select(all, &findings.Filter{Severity: sev})
+	if len(selected) == 0 {
+		return
+	}
+
+	r.Fprintf(color.Error, "%s findings of %s severity or higher were found\n", fmt.Sprint(len(selected)), sev)
+	os.Exit(2)
+}
+
+// writeScoreboard renders the scores of the organizations in the configuration, or of the -org organization.
+func writeScoreboard(cfg *config.Config, args *reportArgs, tmpl *template.Template, failOn findings.Severity, priv ed25519.PrivateKey) {
+	orgs, err := report.OrganizationsFromConfig(cfg)
+	if err != nil {
+		r.Fprintf(color.Error, "Configuration error: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Org != "" {
+		if len(cfg.Domains()) == 0 {
+			r.Fprintln(color.Error, "No root domain names were provided for the organization")
+			os.Exit(1)
+		}
+		orgs = []*report.Organization{{Name: args.Org, Domains: cfg.Domains()}}
+	}
+	if len(orgs) == 0 {
+		r.Fprintln(color.Error, "No organizations were provided by the -org flag or the configuration")
+		os.Exit(1)
+	}
+
+	since := time.Time(args.Since)
+	if since.IsZero() {
+		since = time.Now().Add(-report.DefaultPeriod).UTC()
+	}
+
+	dir := config.OutputDirectory(cfg.Dir)
+	all, err := findings.ReadFile(filepath.Join(dir, "findings.json"))
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	graphs, err := buildGraphs(cfg, orgDomains(orgs)...)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	var scores []*report.Score
+	for i, org := range orgs {
+		scores = append(scores, report.NewScore(org, graphs[i], all, since))
+	}
+
+	path := args.Filepaths.Output
+	if path == "" {
+		path = filepath.Join(dir, "scoreboard.html")
+	}
+	if err := writeReportFile(path, report.NewScoreboard(args.Title, scores, since), tmpl); err != nil {
+		r.Fprintf(color.Error, "Failed to write %s: %v\n", path, err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(color.Error, "%s was written with the scores of %s organizations\n", green(path), yellow(fmt.Sprint(len(scores))))
+	if err := signExport(priv, path); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	var domains []string
+	for _, org := range orgs {
+		domains = append(domains, org.Domains...)
+	}
+	checkFailOn(findings.Select(all, &findings.Filter{Domains: domains}), failOn)
+}
+
+// writeCrossReference renders the infrastructure shared between the organizations in the configuration.
+func writeCrossReference(cfg *config.Config, args *reportArgs, tmpl *template.Template, priv ed25519.PrivateKey) {
+	orgs, err := report.OrganizationsFromConfig(cfg)
+	if err != nil {
+		r.Fprintf(color.Error, "Configuration error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(orgs) < 2 {
+		r.Fprintln(color.Error, "At least two organizations must be provided by the configuration")
+		os.Exit(1)
+	}
+
+	dir := config.OutputDirectory(cfg.Dir)
+	all, err := findings.ReadFile(filepath.Join(dir, "findings.json"))
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	list, err := buildGraphs(cfg, orgDomains(orgs)...)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	graphs := make(map[string]*viz.Graph, len(orgs))
+	for i, org := range orgs {
+		graphs[org.Name] = list[i]
+	}
+
+	path := args.Filepaths.Output
+	if path == "" {
+		path = filepath.Join(dir, "crossref.html")
+	}
+	cr := report.NewCrossReference(args.Title, orgs, graphs, all)
+	if err := writeReportFile(path, cr, tmpl); err != nil {
+		r.Fprintf(color.Error, "Failed to write %s: %v\n", path, err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(color.Error, "%s was written with %s assets shared between %s organizations\n",
+		green(path), yellow(fmt.Sprint(len(cr.Shared))), yellow(fmt.Sprint(len(orgs))))
+	if err := signExport(priv, path); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+// buildGraphs reads the graph of each set of domains. The graph database is locked again before returning,
+// since the report is written and the command exits on errors once the graphs have been read.
+func buildGraphs(cfg *config.Config, sets ...[]string) ([]*viz.Graph, error) {
+	g, err := openGraphDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer lockGraphDatabase(cfg)
+
+	graphs := make([]*viz.Graph, 0, len(sets))
+	for _, domains := range sets {
+		graph, err := viz.Build(context.Background(), g, domains, time.Time{}, time.Time{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the graph database for %s: %v", strings.Join(domains, ", "), err)
+		}
+		graphs = append(graphs, graph)
+	}
+	return graphs, nil
+}
+
+// orgDomains returns the domains of each organization, in the order of the organizations.
+func orgDomains(orgs []*report.Organization) [][]string {
+	sets := make([][]string, 0, len(orgs))
+	for _, org := range orgs {
+		sets = append(sets, org.Domains)
+	}
+	return sets
+}
+
+func writeReportFile(path string, data interface{}, tmpl *template.Template) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return report.Write(f, data, tmpl)
+}
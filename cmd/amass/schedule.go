@@ -0,0 +1,120 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/notify"
+	"github.com/owasp-amass/amass/v4/scheduler"
+	"github.com/owasp-amass/config/config"
+)
+
+// enumSchedule returns the recurrence of the enumerations provided by the command-line or the configuration,
+// and whether the first enumeration is launched immediately. A nil Recurrence is returned for a single enumeration.
+func enumSchedule(cfg *config.Config, args *enumArgs) (scheduler.Recurrence, bool) {
+	rec, onStart, err := scheduler.FromConfig(cfg)
+	if err != nil {
+		r.Fprintf(color.Error, "Configuration error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if args.Schedule != "" {
+		rec, err = scheduler.ParseRecurrence(args.Schedule)
+		if err != nil {
+			r.Fprintf(color.Error, "Failed to parse the schedule: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	return rec, onStart
+}
+
+// runScheduledEnumerations launches the enumerations at the times of the recurrence, reporting
+// the names discovered by each run, until the user terminates the program.
+func runScheduledEnumerations(cfg *config.Config, args *enumArgs, rec scheduler.Recurrence, onStart bool) {
+	dispatcher, err := notify.FromConfig(cfg)
+	if err != nil {
+		r.Fprintf(color.Error, "Configuration error: %v\n", err)
+		os.Exit(1)
+	}
+
+	s := scheduler.NewScheduler(cfg, rec, dispatcher)
+	s.RunOnStart = onStart
+	// The time budget bounds each of the enumerations
+	if budget := time.Duration(args.Timeout); budget > 0 {
+		s.Enumerate = func(ctx context.Context, cfg *config.Config) ([]string, []string, error) {
+			ctx, cancel := context.WithTimeout(ctx, budget)
+			defer cancel()
+
+			return scheduler.Enumerate(ctx, cfg)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Monitor for cancellation by the user
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(quit)
+
+		select {
+		case <-quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if !onStart {
+		fmt.Fprintf(color.Error, "The first enumeration is scheduled for %s\n", green(rec.Next(time.Now()).Format(time.RFC1123)))
+	}
+	err = s.Run(ctx, func(d *scheduler.Delta, err error) {
+		printDelta(d, err)
+		if next := rec.Next(time.Now()); !next.IsZero() {
+			fmt.Fprintf(color.Error, "The next enumeration is scheduled for %s\n", green(next.Format(time.RFC1123)))
+		}
+	})
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	// Deliver the digests holding the names discovered since they were last sent
+	fctx, fcancel := context.WithTimeout(context.Background(), time.Minute)
+	defer fcancel()
+	if err := dispatcher.Close(fctx); err != nil {
+		r.Fprintf(color.Error, "Failed to deliver the notifications: %v\n", err)
+	}
+	fmt.Fprintf(color.Error, "\n%s\n", green("The scheduled enumerations have been stopped"))
+}
+
+func printDelta(d *scheduler.Delta, err error) {
+	if err != nil {
+		r.Fprintf(color.Error, "Enumeration %d failed: %v\n", d.Run, err)
+		// The names are still reported when only the notification could not be delivered
+		if d.Total == 0 {
+			return
+		}
+	}
+
+	for _, name := range d.NewNames {
+		fmt.Fprintln(color.Output, green(name))
+	}
+
+	elapsed := d.Finished.Sub(d.Started).Truncate(time.Second)
+	if d.Baseline {
+		fmt.Fprintf(color.Error, "Enumeration %d established the baseline of %s names in %s\n",
+			d.Run, yellow(fmt.Sprint(d.Total)), elapsed)
+		return
+	}
+	fmt.Fprintf(color.Error, "Enumeration %d discovered %s new names of %s total in %s\n",
+		d.Run, yellow(fmt.Sprint(len(d.NewNames))), yellow(fmt.Sprint(d.Total)), elapsed)
+}
@@ -0,0 +1,224 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/scope"
+	"github.com/owasp-amass/amass/v4/settings"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	scopeUsageMsg   = "scope list|approve|deny [options]"
+	listUsageMsg    = "scope list [-state pending|approved|denied] [options]"
+	approveUsageMsg = "scope approve [-reviewer NAME] [options] DOMAIN..."
+	denyUsageMsg    = "scope deny [-reviewer NAME] [options] DOMAIN..."
+)
+
+type scopeArgs struct {
+	State    string
+	Reviewer string
+	Options  struct {
+		JSON    bool
+		NoColor bool
+		Silent  bool
+	}
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+func runScopeCommand(clArgs []string) {
+	scopeBuf := new(bytes.Buffer)
+	scopeCommand := flag.NewFlagSet("scope", flag.ContinueOnError)
+	scopeCommand.SetOutput(scopeBuf)
+
+	if len(clArgs) < 1 {
+		commandUsage(scopeUsageMsg, scopeCommand, scopeBuf)
+		return
+	}
+
+	switch clArgs[0] {
+	case "list":
+		runScopeListCommand(clArgs[1:])
+	case "approve":
+		runScopeReviewCommand("approve", scope.Approved, approveUsageMsg, clArgs[1:])
+	case "deny":
+		runScopeReviewCommand("deny", scope.Denied, denyUsageMsg, clArgs[1:])
+	default:
+		commandUsage(scopeUsageMsg, scopeCommand, scopeBuf)
+		os.Exit(1)
+	}
+}
+
+func runScopeListCommand(clArgs []string) {
+	var args scopeArgs
+	var help1, help2 bool
+	listCommand := flag.NewFlagSet("list", flag.ContinueOnError)
+
+	listBuf := new(bytes.Buffer)
+	listCommand.SetOutput(listBuf)
+
+	listCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	listCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	listCommand.StringVar(&args.State, "state", scope.Pending, "Review state of the candidates to list: pending, approved, denied or all")
+	listCommand.BoolVar(&args.Options.JSON, "json", false, "Print the candidates as JSON")
+	listCommand.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	listCommand.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
+	listCommand.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	listCommand.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the scope review queue")
+
+	if err := listCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(listUsageMsg, listCommand, listBuf)
+		return
+	}
+	setScopeOutput(&args)
+
+	state := args.State
+	if state == "all" {
+		state = ""
+	} else if state != scope.Pending && state != scope.Approved && state != scope.Denied {
+		r.Fprintf(color.Error, "%s is not a review state\n", state)
+		os.Exit(1)
+	}
+
+	list, err := openScopeReview(&args).List(state)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	if args.Options.JSON {
+		enc := json.NewEncoder(color.Output)
+		enc.SetIndent("", "  ")
+		if list == nil {
+			list = []*scope.Candidate{}
+		}
+		_ = enc.Encode(list)
+		return
+	}
+	for _, c := range list {
+		printCandidate(c)
+	}
+}
+
+func runScopeReviewCommand(name, state, usage string, clArgs []string) {
+	var args scopeArgs
+	var help1, help2 bool
+	reviewCommand := flag.NewFlagSet(name, flag.ContinueOnError)
+
+	reviewBuf := new(bytes.Buffer)
+	reviewCommand.SetOutput(reviewBuf)
+
+	reviewCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	reviewCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	reviewCommand.StringVar(&args.Reviewer, "reviewer", os.Getenv("USER"), "Name of the analyst recorded with the review")
+	reviewCommand.BoolVar(&args.Options.JSON, "json", false, "Print the reviewed candidates as JSON")
+	reviewCommand.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	reviewCommand.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
+	reviewCommand.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	reviewCommand.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the scope review queue")
+
+	if len(clArgs) < 1 {
+		commandUsage(usage, reviewCommand, reviewBuf)
+		return
+	}
+	if err := reviewCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(usage, reviewCommand, reviewBuf)
+		return
+	}
+	setScopeOutput(&args)
+	if reviewCommand.NArg() < 1 {
+		r.Fprintln(color.Error, "The domains must be provided")
+		os.Exit(1)
+	}
+
+	q := openScopeReview(&args)
+	enc := json.NewEncoder(color.Output)
+	for _, domain := range reviewCommand.Args() {
+		c, err := q.Review(domain, state, args.Reviewer)
+		if err != nil {
+			r.Fprintf(color.Error, "Failed to review %s: %v\n", domain, err)
+			os.Exit(1)
+		}
+
+		if args.Options.JSON {
+			_ = enc.Encode(c)
+			continue
+		}
+		printCandidate(c)
+	}
+}
+
+func setScopeOutput(args *scopeArgs) {
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Options.Silent {
+		color.Output = io.Discard
+		color.Error = io.Discard
+	}
+}
+
+// openScopeReview returns the scope review queue in the output directory of the configuration.
+func openScopeReview(args *scopeArgs) *scope.Queue {
+	cfg := config.NewConfig()
+	if err := settings.Load("scope", cfg, args.Filepaths.Directory, args.Filepaths.ConfigFile); err != nil {
+		r.Fprintf(color.Error, "Failed to load the configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Filepaths.Directory != "" {
+		cfg.Dir = args.Filepaths.Directory
+	}
+
+	q, err := scope.Open(scope.Path(cfg))
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	return q
+}
+
+// printCandidate prints the review state of the candidate, and the target domain it is related to.
+func printCandidate(c *scope.Candidate) {
+	state := fmt.Sprintf("%-8s", c.State)
+	switch c.State {
+	case scope.Approved:
+		state = green(state)
+	case scope.Denied:
+		state = blue(state)
+	default:
+		state = yellow(state)
+	}
+
+	var detail string
+	if c.Related != "" {
+		detail = " registered by the same contact as " + c.Related
+	}
+	if c.Source != "" {
+		detail += " (" + c.Source + ")"
+	}
+	if c.Reviewer != "" {
+		detail += " " + magenta("reviewed by "+c.Reviewer)
+	}
+	fmt.Fprintf(color.Output, "%s %s%s\n", state, green(c.Domain), detail)
+}
@@ -0,0 +1,103 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/selftest"
+)
+
+const (
+	selftestUsageMsg = "selftest [options]"
+	// The default time allowed for the enumeration of the mock Internet
+	defaultSelftestTimeout = 5 * time.Minute
+)
+
+type selftestArgs struct {
+	Timeout time.Duration
+	Options struct {
+		NoColor bool
+		Silent  bool
+		Verbose bool
+	}
+}
+
+func runSelftestCommand(clArgs []string) {
+	var args selftestArgs
+	var help1, help2 bool
+	selftestCommand := flag.NewFlagSet("selftest", flag.ContinueOnError)
+
+	selftestBuf := new(bytes.Buffer)
+	selftestCommand.SetOutput(selftestBuf)
+
+	selftestCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	selftestCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	selftestCommand.DurationVar(&args.Timeout, "timeout", defaultSelftestTimeout, "Time allowed for the enumeration of the mock Internet")
+	selftestCommand.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	selftestCommand.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
+	selftestCommand.BoolVar(&args.Options.Verbose, "v", false, "Output the log messages of the enumeration")
+
+	if err := selftestCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(selftestUsageMsg, selftestCommand, selftestBuf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Options.Silent {
+		color.Output = io.Discard
+		color.Error = io.Discard
+	}
+
+	dir, err := os.MkdirTemp("", "amass-selftest-")
+	if err != nil {
+		r.Fprintf(color.Error, "Failed to create the temporary directory: %v\n", err)
+		os.Exit(1)
+	}
+	defer os.RemoveAll(dir)
+
+	var logger *log.Logger
+	if args.Options.Verbose {
+		logger = log.New(color.Error, "", log.Lmicroseconds)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), args.Timeout)
+	defer cancel()
+
+	fmt.Fprintf(color.Output, "Enumerating the mock Internet started on the loopback interface\n")
+	result, err := selftest.Run(ctx, dir, logger)
+	if err != nil {
+		r.Fprintf(color.Error, "The self-test failed to execute: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(color.Output, "%s names discovered and %s DNS queries answered in %s\n",
+		yellow(fmt.Sprint(len(result.Discovered))), yellow(fmt.Sprint(result.Queries)), yellow(result.Duration.Round(time.Millisecond).String()))
+	if len(result.Missing) > 0 {
+		r.Fprintf(color.Error, "Names that were not discovered: %s\n", strings.Join(result.Missing, ", "))
+	}
+	if len(result.Unexpected) > 0 {
+		r.Fprintf(color.Error, "Names that should not have been discovered: %s\n", strings.Join(result.Unexpected, ", "))
+	}
+	if !result.Passed() {
+		r.Fprintln(color.Error, "The self-test failed")
+		os.Exit(1)
+	}
+	fmt.Fprintf(color.Output, "%s\n", green("The self-test passed"))
+}
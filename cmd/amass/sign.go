@@ -0,0 +1,202 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/signing"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	signUsageMsg   = "sign keygen|file|verify [options]"
+	keygenUsageMsg = "sign keygen -key PATH -pub PATH"
+	fileUsageMsg   = "sign file -key PATH FILE..."
+	verifyUsageMsg = "sign verify -pub PATH [-sig PATH] FILE..."
+)
+
+type signArgs struct {
+	Options struct {
+		NoColor bool
+		Silent  bool
+	}
+	Filepaths struct {
+		PrivateKey string
+		PublicKey  string
+		Signature  string
+	}
+}
+
+func runSignCommand(clArgs []string) {
+	signBuf := new(bytes.Buffer)
+	signCommand := flag.NewFlagSet("sign", flag.ContinueOnError)
+	signCommand.SetOutput(signBuf)
+
+	if len(clArgs) < 1 {
+		commandUsage(signUsageMsg, signCommand, signBuf)
+		return
+	}
+
+	switch clArgs[0] {
+	case "keygen":
+		runSignSubcommand("keygen", keygenUsageMsg, clArgs[1:])
+	case "file":
+		runSignSubcommand("file", fileUsageMsg, clArgs[1:])
+	case "verify":
+		runSignSubcommand("verify", verifyUsageMsg, clArgs[1:])
+	default:
+		commandUsage(signUsageMsg, signCommand, signBuf)
+		os.Exit(1)
+	}
+}
+
+func runSignSubcommand(name, usage string, clArgs []string) {
+	var args signArgs
+	var help1, help2 bool
+	cmd := flag.NewFlagSet(name, flag.ContinueOnError)
+
+	buf := new(bytes.Buffer)
+	cmd.SetOutput(buf)
+
+	cmd.BoolVar(&help1, "h", false, "Show the program usage message")
+	cmd.BoolVar(&help2, "help", false, "Show the program usage message")
+	cmd.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	cmd.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
+	if name != "verify" {
+		cmd.StringVar(&args.Filepaths.PrivateKey, "key", "", "Path to the PEM encoded Ed25519 private key")
+	}
+	if name != "file" {
+		cmd.StringVar(&args.Filepaths.PublicKey, "pub", "", "Path to the PEM encoded Ed25519 public key")
+	}
+	if name == "verify" {
+		cmd.StringVar(&args.Filepaths.Signature, "sig", "", "Path to the signature when it is not next to the file")
+	}
+
+	if len(clArgs) < 1 {
+		commandUsage(usage, cmd, buf)
+		return
+	}
+	if err := cmd.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(usage, cmd, buf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Options.Silent {
+		color.Output = io.Discard
+		color.Error = io.Discard
+	}
+
+	switch name {
+	case "keygen":
+		keygen(&args)
+	case "file":
+		signFiles(&args, cmd.Args())
+	case "verify":
+		verifyFiles(&args, cmd.Args())
+	}
+}
+
+func keygen(args *signArgs) {
+	if args.Filepaths.PrivateKey == "" || args.Filepaths.PublicKey == "" {
+		r.Fprintln(color.Error, "The -key and -pub flags must both be provided")
+		os.Exit(1)
+	}
+
+	pub, err := signing.GenerateKey(args.Filepaths.PrivateKey, args.Filepaths.PublicKey)
+	if err != nil {
+		r.Fprintf(color.Error, "Failed to generate the signing key: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(color.Error, "The signing key %s was written to %s and %s\n", yellow(signing.KeyID(pub)),
+		green(args.Filepaths.PrivateKey), green(args.Filepaths.PublicKey))
+}
+
+func signFiles(args *signArgs, paths []string) {
+	if args.Filepaths.PrivateKey == "" || len(paths) == 0 {
+		r.Fprintln(color.Error, "The -key flag and the files to sign must be provided")
+		os.Exit(1)
+	}
+
+	priv, err := signing.LoadPrivateKey(args.Filepaths.PrivateKey)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	for _, path := range paths {
+		if err := signExport(priv, path); err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+func verifyFiles(args *signArgs, paths []string) {
+	if args.Filepaths.PublicKey == "" || len(paths) == 0 {
+		r.Fprintln(color.Error, "The -pub flag and the files to verify must be provided")
+		os.Exit(1)
+	}
+	if args.Filepaths.Signature != "" && len(paths) > 1 {
+		r.Fprintln(color.Error, "The -sig flag can only be used to verify a single file")
+		os.Exit(1)
+	}
+
+	pub, err := signing.LoadPublicKey(args.Filepaths.PublicKey)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	var failed bool
+	for _, path := range paths {
+		s, err := signing.VerifyFile(path, args.Filepaths.Signature, pub)
+		if err != nil {
+			r.Fprintf(color.Error, "%s: %v\n", path, err)
+			failed = true
+			continue
+		}
+		fmt.Fprintf(color.Error, "%s was signed by %s at %s and has not been modified\n",
+			green(path), yellow(s.KeyID), s.Signed.Format("2006-01-02 15:04:05 MST"))
+	}
+	// The exit status lets the verification gate the delivery of the files
+	if failed {
+		os.Exit(2)
+	}
+}
+
+// exportKey returns the key signing the exported files, provided by the 'signing' section of the configuration.
+func exportKey(cfg *config.Config) ed25519.PrivateKey {
+	priv, err := signing.FromConfig(cfg)
+	if err != nil {
+		r.Fprintf(color.Error, "Configuration error: %v\n", err)
+		os.Exit(1)
+	}
+	return priv
+}
+
+// signExport writes the signature of the exported file, when a signing key has been configured.
+func signExport(priv ed25519.PrivateKey, path string) error {
+	if priv == nil {
+		return nil
+	}
+
+	if _, err := signing.SignFile(path, priv); err != nil {
+		return fmt.Errorf("failed to sign %s: %v", path, err)
+	}
+	fmt.Fprintf(color.Error, "%s was signed in %s\n", green(path), green(path+signing.Ext))
+	return nil
+}
@@ -0,0 +1,18 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// The signals pausing and resuming the enumeration, such as during the change windows of the targets.
+var (
+	pauseSignals  = []os.Signal{syscall.SIGUSR1}
+	resumeSignals = []os.Signal{syscall.SIGUSR2}
+)
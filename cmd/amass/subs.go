@@ -0,0 +1,605 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/analysis"
+	"github.com/owasp-amass/amass/v4/annotations"
+	"github.com/owasp-amass/amass/v4/cloud"
+	"github.com/owasp-amass/amass/v4/confidence"
+	"github.com/owasp-amass/amass/v4/dbcrypt"
+	"github.com/owasp-amass/amass/v4/enum"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/format"
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/owasp-amass/amass/v4/net/validate"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/resources"
+	"github.com/owasp-amass/amass/v4/schema"
+	"github.com/owasp-amass/amass/v4/settings"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	subsUsageMsg = "subs [options] -d DOMAIN [-since TIME] [-until TIME]"
+)
+
+type subsArgs struct {
+	Domains       *stringset.Set
+	Since         format.ParseTime
+	Until         format.ParseTime
+	MinConfidence float64
+	Options       struct {
+		Confidence bool
+		DemoMode   bool
+		DualStack  bool
+		Evidence   string
+		Exclude    string
+		GroupBy    string
+		IPs        bool
+		IPv4       bool
+		IPv6       bool
+		Match      string
+		NoColor    bool
+		Props      format.ParseStrings
+		Silent     bool
+		Summary    bool
+		Tags       format.ParseStrings
+	}
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+		Domains    format.ParseStrings
+		JSONOutput string
+		TermOut    string
+	}
+}
+
+func runSubsCommand(clArgs []string) {
+	args := subsArgs{Domains: stringset.New()}
+	defer args.Domains.Close()
+	var help1, help2 bool
+	subsCommand := flag.NewFlagSet("subs", flag.ContinueOnError)
+
+	subsBuf := new(bytes.Buffer)
+	subsCommand.SetOutput(subsBuf)
+
+	subsCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	subsCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	subsCommand.Var(args.Domains, "d", "Domain names separated by commas (can be used multiple times)")
+	subsCommand.Var(&args.Since, "since", "Exclude names and resolutions last seen before this time (RFC 3339 or YYYY-MM-DD)")
+	subsCommand.Var(&args.Until, "until", "Exclude names and resolutions first seen after this time (RFC 3339 or YYYY-MM-DD)")
+	subsCommand.BoolVar(&args.Options.Confidence, "confidence", false, "Show the confidence of the names and the sources reporting them")
+	subsCommand.BoolVar(&args.Options.DemoMode, "demo", false, "Censor output to make it suitable for demonstrations")
+	subsCommand.BoolVar(&args.Options.DualStack, "dualstack", false, "Show the address families of each name and the services only exposed over IPv6")
+	subsCommand.StringVar(&args.Options.Evidence, "evidence", "", "Only show names confirmed by at least this evidence level: dns, tcp or tls")
+	subsCommand.StringVar(&args.Options.Exclude, "exclude", "", "Do not show names matching this regular expression")
+	subsCommand.BoolVar(&args.Options.IPs, "ip", false, "Show the IP addresses for discovered names")
+	subsCommand.BoolVar(&args.Options.IPv4, "ipv4", false, "Show the IPv4 addresses for discovered names")
+	subsCommand.BoolVar(&args.Options.IPv6, "ipv6", false, "Show the IPv6 addresses for discovered names")
+	subsCommand.StringVar(&args.Options.Match, "match", "", "Only show names matching this regular expression")
+	subsCommand.Float64Var(&args.MinConfidence, "min-confidence", 0, "Do not show names scored below this confidence (0 to 1)")
+	subsCommand.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	subsCommand.Var(&args.Options.Props, "prop", "Only show names carrying the properties, as key=value pairs separated by commas")
+	subsCommand.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
+	subsCommand.BoolVar(&args.Options.Summary, "summary", false, "Print just the table summarizing the netblocks of the names")
+	subsCommand.Var(&args.Options.Tags, "tag", "Only show names tagged by the analysts, as key=value pairs separated by commas")
+	subsCommand.StringVar(&args.Options.GroupBy, "group-by", "", "Group the summary by asn, provider or country (implies -summary)")
+	subsCommand.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	subsCommand.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+	subsCommand.Var(&args.Filepaths.Domains, "df", "Path to a file providing root domain names")
+	subsCommand.StringVar(&args.Filepaths.JSONOutput, "json", "", "Path to the JSON output file, written in the Amass v3 format")
+	subsCommand.StringVar(&args.Filepaths.TermOut, "o", "", "Path to the text file containing terminal stdout/stderr")
+
+	if len(clArgs) < 1 {
+		commandUsage(subsUsageMsg, subsCommand, subsBuf)
+		return
+	}
+	if err := subsCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(subsUsageMsg, subsCommand, subsBuf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Options.Silent {
+		color.Output = io.Discard
+		color.Error = io.Discard
+	}
+
+	if args.Options.Evidence != "" && validate.Rank(args.Options.Evidence) < 0 {
+		r.Fprintln(color.Error, "The -evidence level must be dns, tcp or tls")
+		os.Exit(1)
+	}
+
+	switch args.Options.GroupBy {
+	case "":
+		args.Options.GroupBy = format.GroupByASN
+	case format.GroupByASN, format.GroupByProvider, format.GroupByCountry:
+		args.Options.Summary = true
+	default:
+		r.Fprintln(color.Error, "The -group-by field must be asn, provider or country")
+		os.Exit(1)
+	}
+
+	if args.MinConfidence < 0 || args.MinConfidence > 1 {
+		r.Fprintln(color.Error, "The -min-confidence score must be between 0 and 1")
+		os.Exit(1)
+	}
+
+	since, until := time.Time(args.Since), time.Time(args.Until)
+	if !since.IsZero() && !until.IsZero() && until.Before(since) {
+		r.Fprintln(color.Error, "The -until time must not be before the -since time")
+		os.Exit(1)
+	}
+
+	for _, f := range args.Filepaths.Domains {
+		list, err := config.GetListFromFile(f)
+		if err != nil {
+			r.Fprintf(color.Error, "Failed to parse the domain names file: %v\n", err)
+			os.Exit(1)
+		}
+		args.Domains.InsertMany(list...)
+	}
+
+	cfg := config.NewConfig()
+	if err := settings.Load("subs", cfg, args.Filepaths.Directory, args.Filepaths.ConfigFile); err != nil {
+		r.Fprintf(color.Error, "Failed to load the configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Filepaths.Directory != "" {
+		cfg.Dir = args.Filepaths.Directory
+	}
+	cfg.AddDomains(args.Domains.Slice()...)
+	if len(cfg.Domains()) == 0 {
+		r.Fprintln(color.Error, "No root domain names were provided")
+		os.Exit(1)
+	}
+
+	var err error
+	var cache *requests.ASNCache
+	// The summary and the JSON output attribute the addresses to the netblocks of the IP2ASN data
+	asninfo := args.Options.Summary || args.Filepaths.JSONOutput != ""
+	if asninfo {
+		if cache, err = ip2asnCache(); err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	var all []*findings.Finding
+	if args.Options.DualStack || args.Options.Evidence != "" || args.Options.GroupBy == format.GroupByProvider || args.Options.GroupBy == format.GroupByCountry || len(args.Options.Props) > 0 {
+		all, err = findings.ReadFile(filepath.Join(config.OutputDirectory(cfg.Dir), "findings.json"))
+		if err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// The names are selected before their resolutions are read from the graph database
+	sel, err := newNameSelector(args.Options.Match, args.Options.Exclude, args.Options.Props, all)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	defer sel.close()
+	if len(args.Options.Tags) > 0 {
+		if err := selectTagged(cfg, sel, args.Options.Tags); err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	outputs, err := selectedOutputs(cfg, since, until, sel, asninfo, cache)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	sort.Slice(outputs, func(i, j int) bool {
+		return outputs[i].Name < outputs[j].Name
+	})
+
+	var outfile *os.File
+	if args.Filepaths.TermOut != "" {
+		outfile, err = os.OpenFile(args.Filepaths.TermOut, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			r.Fprintf(color.Error, "Failed to open the text output file: %v\n", err)
+			os.Exit(1)
+		}
+		defer outfile.Close()
+	}
+
+	showAddrs := args.Options.IPs || args.Options.IPv4 || args.Options.IPv6
+	if args.Options.IPs {
+		args.Options.IPv4 = true
+		args.Options.IPv6 = true
+	}
+
+	var jsonfile *os.File
+	if args.Filepaths.JSONOutput != "" {
+		jsonfile, err = os.OpenFile(args.Filepaths.JSONOutput, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			r.Fprintf(color.Error, "Failed to open the JSON output file: %v\n", err)
+			os.Exit(1)
+		}
+		defer jsonfile.Close()
+	}
+
+	var services map[string][]string
+	if args.Options.DualStack {
+		services = analysis.ServicesByAddr(all)
+	}
+	// The names are selected by the strongest evidence recorded by the name validation of the enumerations
+	evidence := make(map[string]string)
+	for _, f := range all {
+		if f.Type != enum.NameValidationFinding {
+			continue
+		}
+		if e := f.Details["evidence"]; validate.Rank(e) > validate.Rank(evidence[f.Asset]) {
+			evidence[f.Asset] = e
+		}
+	}
+
+	// The names scored below the threshold are suppressed, while the names without a score are kept
+	scorer, obs, err := confidenceObservations(cfg, args.Options.Confidence || args.MinConfidence > 0)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	var scores map[string]float64
+	if scorer != nil {
+		if args.MinConfidence > 0 {
+			scorer.Threshold = args.MinConfidence
+		}
+		scores = obs.Scores(scorer, time.Now())
+	}
+
+	var selected []*requests.Output
+	for _, out := range outputs {
+		if args.Options.Evidence != "" && !validate.AtLeast(evidence[out.Name], args.Options.Evidence) {
+			continue
+		}
+		if score, found := scores[out.Name]; found && scorer.Suppressed(score) {
+			continue
+		}
+		if args.Options.Summary && showAddrs {
+			out.Addresses = format.DesiredAddrTypes(out.Addresses, args.Options.IPv4, args.Options.IPv6)
+		}
+		selected = append(selected, out)
+	}
+	if jsonfile != nil {
+		if err := writeSubsJSON(jsonfile, cfg, selected, obs); err != nil {
+			r.Fprintf(color.Error, "Failed to write the JSON output file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if args.Options.Summary {
+		writeSubsSummary(selected, all, cache, args.Options.GroupBy, outfile, args.Options.DemoMode)
+		return
+	}
+
+	for _, out := range selected {
+		var stack *analysis.DualStack
+		if args.Options.DualStack {
+			var addrs []string
+			for _, a := range out.Addresses {
+				addrs = append(addrs, a.Address.String())
+			}
+			stack = analysis.NewDualStack(out.Name, addrs, services)
+		}
+		if showAddrs {
+			out.Addresses = format.DesiredAddrTypes(out.Addresses, args.Options.IPv4, args.Options.IPv6)
+			if len(out.Addresses) == 0 {
+				continue
+			}
+		}
+		var conf string
+		if args.Options.Confidence {
+			conf = confidenceColumn(out.Name, scores, obs)
+		}
+		writeSubsLine(out, stack, conf, outfile, showAddrs, args.Options.DemoMode)
+	}
+}
+
+// selectedOutputs returns the outputs of the selected names in the graph database. The graph database is
+// locked again before returning, since the command exits on errors once the outputs have been read.
+func selectedOutputs(cfg *config.Config, since, until time.Time, sel *nameSelector, asninfo bool, cache *requests.ASNCache) ([]*requests.Output, error) {
+	g, err := openGraphDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer lockGraphDatabase(cfg)
+
+	return EventOutput(context.Background(), g, cfg.Domains(), since, until, nil, sel, asninfo, cache), nil
+}
+
+func writeSubsLine(out *requests.Output, stack *analysis.DualStack, conf string, outfile *os.File, addrs, demo bool) {
+	name, ips := format.OutputLineParts(out, addrs, demo)
+	if ips != "" {
+		ips = " " + ips
+	}
+
+	var col, colored string
+	if stack != nil {
+		col, colored = dualStackColumn(stack)
+	}
+
+	fmt.Fprintf(color.Output, "%s%s%s%s\n", green(name), yellow(ips), colored, blue(conf))
+	if outfile != nil {
+		fmt.Fprintf(outfile, "%s%s%s%s\n", name, ips, col, conf)
+	}
+}
+
+// writeSubsJSON writes the names in the JSON schema of Amass v3, so the tooling built around the
+// legacy output keeps working. The sources are those recorded by the confidence observations.
+func writeSubsJSON(w io.Writer, cfg *config.Config, outputs []*requests.Output, obs *confidence.Observations) error {
+	if obs == nil {
+		var err error
+		if obs, err = confidence.Load(confidence.Path(cfg)); err != nil {
+			return fmt.Errorf("failed to load the confidence observations: %v", err)
+		}
+	}
+
+	scripts, err := resources.GetDefaultScripts()
+	if err != nil {
+		return fmt.Errorf("failed to read the data source scripts: %v", err)
+	}
+	tags := format.SourceTags(scripts)
+
+	var records []*format.V3Output
+	for _, out := range outputs {
+		records = append(records, format.NewV3Output(out, obs.SourceNames(out.Name), tags))
+	}
+	return format.WriteV3JSON(w, records...)
+}
+
+// confidenceColumn returns the column providing the confidence of the name and the sources reporting it.
+func confidenceColumn(name string, scores map[string]float64, obs *confidence.Observations) string {
+	score, found := scores[name]
+	if !found {
+		return " [unscored]"
+	}
+	return fmt.Sprintf(" [%.2f %s]", score, strings.Join(obs.SourceNames(name), ","))
+}
+
+// writeSubsSummary prints the table summarizing the netblocks of the names, grouped by the selected field.
+func writeSubsSummary(outputs []*requests.Output, all []*findings.Finding, cache *requests.ASNCache, groupBy string, outfile *os.File, demo bool) {
+	var w io.Writer = color.Output
+	if outfile != nil {
+		w = io.MultiWriter(color.Output, outfile)
+	}
+
+	if groupBy == format.GroupByASN {
+		asns := make(map[int]*format.ASNSummaryData)
+		for _, out := range outputs {
+			format.UpdateSummaryData(out, asns)
+		}
+		format.FprintEnumerationSummary(w, len(outputs), asns, demo)
+		return
+	}
+
+	key := summaryGroupKey(groupBy, all, cache)
+	groups := make(map[string]*format.GroupSummaryData)
+	for _, out := range outputs {
+		format.UpdateGroupedSummaryData(out, groups, key)
+	}
+	format.FprintGroupedSummary(w, len(outputs), groupBy, groups, demo)
+}
+
+// summaryGroupKey returns the function selecting the provider or country of each address. The
+// providers are identified by the cloud attribution of the names, then by the autonomous systems
+// of the cloud providers, and otherwise named by the description of the autonomous system. The
+// countries are those where the autonomous systems are registered, according to the IP2ASN data.
+func summaryGroupKey(groupBy string, all []*findings.Finding, cache *requests.ASNCache) func(*requests.Output, requests.AddressInfo) string {
+	if groupBy == format.GroupByCountry {
+		locs := addressLocations(all)
+
+		return func(out *requests.Output, addr requests.AddressInfo) string {
+			if loc, found := locs[addr.Address.String()]; found {
+				return loc.CountryCode
+			}
+			if as := cache.ASNSearch(addr.ASN); as != nil && as.CC != "" && as.CC != "None" {
+				return as.CC
+			}
+			return "Unknown"
+		}
+	}
+
+	providers := make(map[string]string)
+	for _, f := range all {
+		if f.Type == enum.CloudAssetFinding && f.Details["provider"] != "" {
+			providers[f.Asset] = f.Details["provider"]
+		}
+	}
+
+	classifier := cloud.NewClassifier()
+	return func(out *requests.Output, addr requests.AddressInfo) string {
+		if p, found := providers[out.Name]; found {
+			return p
+		}
+		if a := classifier.ClassifyASN(addr.ASN); a != nil {
+			return a.Provider
+		}
+		if addr.Description != "" {
+			return addr.Description
+		}
+		return "Unknown"
+	}
+}
+
+// ip2asnCache returns an ASNCache providing the netblocks of the IP2ASN data shipped with Amass.
+func ip2asnCache() (*requests.ASNCache, error) {
+	ranges, err := resources.GetIP2ASNData()
+	if err != nil {
+		return nil, err
+	}
+
+	cache := requests.NewASNCache()
+	for _, r := range ranges {
+		cidr := amassnet.Range2CIDR(r.FirstIP, r.LastIP)
+		if cidr == nil {
+			continue
+		}
+		if ones, _ := cidr.Mask.Size(); ones == 0 {
+			continue
+		}
+
+		cache.Update(&requests.ASNRequest{
+			Address:     r.FirstIP.String(),
+			ASN:         r.ASN,
+			CC:          r.CC,
+			Prefix:      cidr.String(),
+			Description: r.Description,
+		})
+	}
+	return cache, nil
+}
+
+// dualStackColumn returns the plain and colorized column describing the address families of the name.
+func dualStackColumn(stack *analysis.DualStack) (string, string) {
+	status := stack.Status()
+	if status == "" {
+		status = "unresolved"
+	}
+
+	col := " [" + status + "]"
+	if len(stack.IPv6Only) == 0 {
+		return col, blue(col)
+	}
+
+	col = " [" + status + " ipv6-only:" + strings.Join(stack.IPv6Only, ",") + "]"
+	return col, r.Sprint(col)
+}
+
+// openGraphDatabase returns the primary graph database selected by the configuration.
+func openGraphDatabase(cfg *config.Config) (*netmap.Graph, error) {
+	system, dsn, options, err := primaryGraphDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if schema.Versioned(system) {
+		if _, _, err := schema.Upgrade(system, dsn); err != nil {
+			lockGraphDatabase(cfg)
+			return nil, err
+		}
+	}
+
+	g := netmap.NewGraph(system, dsn, options)
+	if g == nil {
+		lockGraphDatabase(cfg)
+		return nil, fmt.Errorf("failed to open the %s graph database", strings.ToLower(system))
+	}
+	return g, nil
+}
+
+// openAnnotationStore returns the annotations kept in the primary graph database selected by the configuration.
+// As with openGraphDatabase, lockGraphDatabase is called once the store is no longer used.
+func openAnnotationStore(cfg *config.Config) (*annotations.Store, error) {
+	system, dsn, _, err := primaryGraphDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := annotations.Open(system, dsn)
+	if err != nil {
+		lockGraphDatabase(cfg)
+		return nil, err
+	}
+	return store, nil
+}
+
+// selectTagged limits the names selected to those carrying the key=value tags.
+func selectTagged(cfg *config.Config, sel *nameSelector, pairs []string) error {
+	required, err := annotations.ParseTags(pairs)
+	if err != nil {
+		return err
+	}
+
+	store, err := openAnnotationStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer lockGraphDatabase(cfg)
+	defer store.Close()
+
+	tagged, err := store.Tagged(required)
+	if err != nil {
+		return fmt.Errorf("failed to read the annotations: %v", err)
+	}
+	sel.only(tagged)
+	return nil
+}
+
+// primaryGraphDatabase returns the system, DSN and options of the primary graph database in the configuration.
+func primaryGraphDatabase(cfg *config.Config) (string, string, string, error) {
+	// Add the local database settings to the configuration
+	cfg.GraphDBs = append(cfg.GraphDBs, cfg.LocalDatabaseSettings(cfg.GraphDBs))
+
+	for _, db := range cfg.GraphDBs {
+		if !db.Primary {
+			continue
+		}
+
+		if db.System == "local" {
+			dir := config.OutputDirectory(cfg.Dir)
+			if !dbcrypt.Exists(dir) {
+				return "", "", "", fmt.Errorf("failed to find the graph database in %s", dir)
+			}
+
+			opts, err := schema.SQLiteOptionsFromConfig(cfg)
+			if err != nil {
+				return "", "", "", err
+			}
+
+			path := filepath.Join(dir, dbcrypt.DatabaseFile)
+			// The encrypted database is decrypted into memory until lockGraphDatabase is called
+			if key, err := dbcrypt.FromConfig(cfg); err != nil {
+				return "", "", "", err
+			} else if key != "" {
+				if path, err = dbcrypt.Unlock(dir, key); err != nil {
+					return "", "", "", err
+				}
+			}
+			return db.System, opts.DSN(path), db.Options, nil
+		}
+
+		connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s", db.Host, db.Port, db.Username, db.Password, db.DBName)
+		return db.System, connStr, db.Options, nil
+	}
+	return "", "", "", errors.New("no primary graph database was found in the configuration")
+}
+
+// lockGraphDatabase encrypts the local graph database decrypted by primaryGraphDatabase again, when the
+// encryption has been enabled by the configuration. Each call of primaryGraphDatabase is paired with one.
+func lockGraphDatabase(cfg *config.Config) {
+	key, err := dbcrypt.FromConfig(cfg)
+	if err != nil || key == "" {
+		return
+	}
+
+	if err := dbcrypt.Lock(config.OutputDirectory(cfg.Dir)); err != nil {
+		r.Fprintf(color.Error, "Failed to encrypt the graph database: %v\n", err)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"net"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/cloud"
+	"github.com/owasp-amass/amass/v4/enum"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+func TestSummaryGroupKeyCountry(t *testing.T) {
+	all := []*findings.Finding{
+		{Type: enum.LocationFinding, Asset: "192.0.2.1", Details: map[string]string{"country": "Germany", "country_code": "DE"}},
+		// Locations without a country code are ignored
+		{Type: enum.LocationFinding, Asset: "198.51.100.1", Details: map[string]string{"country": "France"}},
+	}
+
+	cache := requests.NewASNCache()
+	cache.Update(&requests.ASNRequest{ASN: 64500, CC: "US", Prefix: "192.0.2.0/24", Description: "EXAMPLE-NET"})
+	cache.Update(&requests.ASNRequest{ASN: 64501, CC: "None", Prefix: "198.51.100.0/24", Description: "RESERVED"})
+	cache.Update(&requests.ASNRequest{ASN: 64502, CC: "NL", Prefix: "203.0.113.0/24", Description: "OTHER-NET"})
+
+	key := summaryGroupKey(format.GroupByCountry, all, cache)
+	out := &requests.Output{Name: "www.example.com"}
+
+	tests := []struct {
+		name     string
+		addr     string
+		asn      int
+		expected string
+	}{
+		{"location finding", "192.0.2.1", 64500, "DE"},
+		{"IP2ASN country code", "192.0.2.2", 64500, "US"},
+		{"location without a country code", "198.51.100.1", 64502, "NL"},
+		{"IP2ASN without a country", "198.51.100.2", 64501, "Unknown"},
+		{"unknown autonomous system", "203.0.113.1", 64999, "Unknown"},
+	}
+
+	for _, test := range tests {
+		addr := requests.AddressInfo{Address: net.ParseIP(test.addr), ASN: test.asn}
+
+		if got := key(out, addr); got != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestSummaryGroupKeyProvider(t *testing.T) {
+	all := []*findings.Finding{
+		{Type: enum.CloudAssetFinding, Asset: "cdn.example.com", Details: map[string]string{"provider": "Fastly"}},
+	}
+	key := summaryGroupKey(format.GroupByProvider, all, requests.NewASNCache())
+
+	tests := []struct {
+		name     string
+		out      string
+		addr     requests.AddressInfo
+		expected string
+	}{
+		{"cloud attribution", "cdn.example.com", requests.AddressInfo{ASN: 16509, Description: "AMAZON-02"}, "Fastly"},
+		{"cloud provider network", "www.example.com", requests.AddressInfo{ASN: 16509, Description: "AMAZON-02"}, cloud.AWS},
+		{"autonomous system description", "www.example.com", requests.AddressInfo{ASN: 64500, Description: "EXAMPLE-NET"}, "EXAMPLE-NET"},
+		{"no description", "www.example.com", requests.AddressInfo{ASN: 64500}, "Unknown"},
+	}
+
+	for _, test := range tests {
+		if got := key(&requests.Output{Name: test.out}, test.addr); got != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/datasets"
+	"github.com/owasp-amass/amass/v4/datasrcs"
+	"github.com/owasp-amass/amass/v4/datasrcs/scripting"
+	"github.com/owasp-amass/amass/v4/enum"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/screenshots"
+	"github.com/owasp-amass/amass/v4/settings"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	toolsUsageMsg    = "tools datasets|plugins|gallery [options]"
+	datasetsUsageMsg = "tools datasets [-list] [-update NAME] [-all] [-force]"
+	pluginsUsageMsg  = "tools plugins [-config FILE] [-o FILE]"
+	galleryUsageMsg  = "tools gallery [-config FILE] [-dir PATH]"
+)
+
+type datasetsArgs struct {
+	Update  format.ParseStrings
+	Options struct {
+		All   bool
+		Force bool
+		List  bool
+	}
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+func runToolsCommand(clArgs []string) {
+	toolsBuf := new(bytes.Buffer)
+	toolsCommand := flag.NewFlagSet("tools", flag.ContinueOnError)
+	toolsCommand.SetOutput(toolsBuf)
+
+	if len(clArgs) < 1 {
+		commandUsage(toolsUsageMsg, toolsCommand, toolsBuf)
+		return
+	}
+
+	switch clArgs[0] {
+	case "datasets":
+		runDatasetsCommand(clArgs[1:])
+	case "plugins":
+		runPluginsCommand(clArgs[1:])
+	case "gallery":
+		runGalleryCommand(clArgs[1:])
+	default:
+		commandUsage(toolsUsageMsg, toolsCommand, toolsBuf)
+		os.Exit(1)
+	}
+}
+
+func runDatasetsCommand(clArgs []string) {
+	var args datasetsArgs
+	var help1, help2 bool
+	datasetsCommand := flag.NewFlagSet("datasets", flag.ContinueOnError)
+
+	datasetsBuf := new(bytes.Buffer)
+	datasetsCommand.SetOutput(datasetsBuf)
+
+	datasetsCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	datasetsCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	datasetsCommand.Var(&args.Update, "update", "Dataset names separated by commas to download")
+	datasetsCommand.BoolVar(&args.Options.All, "all", false, "Download all the datasets that are missing or stale")
+	datasetsCommand.BoolVar(&args.Options.Force, "force", false, "Discard partial downloads and fetch the datasets again")
+	datasetsCommand.BoolVar(&args.Options.List, "list", false, "Print the datasets and their cache status")
+	datasetsCommand.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	datasetsCommand.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the output files")
+
+	if err := datasetsCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 || (!args.Options.List && !args.Options.All && len(args.Update) == 0) {
+		commandUsage(datasetsUsageMsg, datasetsCommand, datasetsBuf)
+		return
+	}
+
+	cfg := config.NewConfig()
+	if err := settings.Load("tools", cfg, args.Filepaths.Directory, args.Filepaths.ConfigFile); err != nil {
+		r.Fprintf(color.Error, "Failed to load the configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Filepaths.Directory != "" {
+		cfg.Dir = args.Filepaths.Directory
+	}
+
+	mgr, err := datasets.FromConfig(cfg)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	names := args.Update
+	if args.Options.All {
+		names = mgr.Names()
+	}
+
+	var failed bool
+	ctx := context.Background()
+	for _, name := range names {
+		if s, err := mgr.Status(name); err == nil && !s.Stale && !args.Options.Force {
+			fmt.Fprintf(color.Output, "%s is up to date\n", green(name))
+			continue
+		}
+		if err := mgr.Fetch(ctx, name, args.Options.Force); err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+			failed = true
+			continue
+		}
+		fmt.Fprintf(color.Output, "%s was downloaded\n", green(name))
+	}
+
+	if args.Options.List {
+		printDatasets(mgr)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func printDatasets(mgr *datasets.Manager) {
+	fmt.Fprintf(color.Output, "%-25s%-25s%-25s%s\n", blue("Dataset"), blue("| Updated"), blue("| Status"), blue("| URL"))
+
+	for _, name := range mgr.Names() {
+		s, err := mgr.Status(name)
+		if err != nil {
+			continue
+		}
+
+		updated := "never"
+		status := "missing"
+		if s.Cached {
+			updated = s.Updated.Format(time.RFC3339)
+			status = "current"
+			if s.Stale {
+				status = "stale"
+			}
+		}
+		fmt.Fprintf(color.Output, "%-25s  %-25s  %-25s  %s\n",
+			green(s.Name), yellow(updated), yellow(status), s.URL)
+	}
+}
+
+type pluginsArgs struct {
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+		Output     string
+	}
+}
+
+// pluginsManifest is the machine-readable description of the data source scripts offered by the engine.
+type pluginsManifest struct {
+	APIVersion int           `json:"api_version"`
+	Plugins    []*pluginInfo `json:"plugins"`
+}
+
+type pluginInfo struct {
+	*scripting.Manifest
+	// Indicates that the script passed the check of its configuration, such as API credentials
+	Available bool `json:"available"`
+}
+
+func runPluginsCommand(clArgs []string) {
+	var args pluginsArgs
+	var help1, help2 bool
+	pluginsCommand := flag.NewFlagSet("plugins", flag.ContinueOnError)
+
+	pluginsBuf := new(bytes.Buffer)
+	pluginsCommand.SetOutput(pluginsBuf)
+
+	pluginsCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	pluginsCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	pluginsCommand.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	pluginsCommand.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the output files")
+	pluginsCommand.StringVar(&args.Filepaths.Output, "o", "", "Path to the JSON file that will contain the manifest (default: stdout)")
+
+	if err := pluginsCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(pluginsUsageMsg, pluginsCommand, pluginsBuf)
+		return
+	}
+
+	cfg := config.NewConfig()
+	if err := settings.Load("tools", cfg, args.Filepaths.Directory, args.Filepaths.ConfigFile); err != nil {
+		r.Fprintf(color.Error, "Failed to load the configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Filepaths.Directory != "" {
+		cfg.Dir = args.Filepaths.Directory
+	}
+
+	manifest, err := buildPluginsManifest(cfg)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "    ")
+	if err != nil {
+		r.Fprintf(color.Error, "Failed to encode the plugins manifest: %v\n", err)
+		os.Exit(1)
+	}
+
+	if args.Filepaths.Output == "" {
+		fmt.Fprintln(color.Output, string(data))
+		return
+	}
+	if err := os.WriteFile(args.Filepaths.Output, data, 0640); err != nil {
+		r.Fprintf(color.Error, "Failed to write the plugins manifest: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// buildPluginsManifest starts the data source scripts, so the rate limits and
+// configuration checks are known, and returns the description of each.
+func buildPluginsManifest(cfg *config.Config) (*pluginsManifest, error) {
+	sys, err := systems.NewLocalSystem(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize the system: %v", err)
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	srcs := datasrcs.GetAllSources(sys)
+	if err := sys.SetDataSources(srcs); err != nil {
+		return nil, fmt.Errorf("failed to start the data sources: %v", err)
+	}
+
+	available := make(map[string]struct{})
+	for _, src := range sys.DataSources() {
+		available[src.String()] = struct{}{}
+	}
+
+	manifest := &pluginsManifest{APIVersion: scripting.APIVersion}
+	for _, src := range srcs {
+		s, ok := src.(*scripting.Script)
+		if !ok {
+			continue
+		}
+
+		_, avail := available[s.String()]
+		manifest.Plugins = append(manifest.Plugins, &pluginInfo{
+			Manifest:  s.Manifest(enum.RequestPriority),
+			Available: avail,
+		})
+	}
+
+	sort.Slice(manifest.Plugins, func(i, j int) bool {
+		return manifest.Plugins[i].Name < manifest.Plugins[j].Name
+	})
+	return manifest, nil
+}
+
+type galleryArgs struct {
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+func runGalleryCommand(clArgs []string) {
+	var args galleryArgs
+	var help1, help2 bool
+	galleryCommand := flag.NewFlagSet("gallery", flag.ContinueOnError)
+
+	galleryBuf := new(bytes.Buffer)
+	galleryCommand.SetOutput(galleryBuf)
+
+	galleryCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	galleryCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	galleryCommand.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	galleryCommand.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the output files")
+
+	if err := galleryCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(galleryUsageMsg, galleryCommand, galleryBuf)
+		return
+	}
+
+	cfg := config.NewConfig()
+	if err := settings.Load("tools", cfg, args.Filepaths.Directory, args.Filepaths.ConfigFile); err != nil {
+		r.Fprintf(color.Error, "Failed to load the configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Filepaths.Directory != "" {
+		cfg.Dir = args.Filepaths.Directory
+	}
+
+	path, n, err := screenshots.WriteGalleryFile(screenshots.Dir(cfg))
+	if err != nil {
+		r.Fprintf(color.Error, "Failed to write the screenshot gallery: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(color.Output, "%s was written with %s screenshots\n", green(path), yellow(fmt.Sprint(n)))
+}
@@ -0,0 +1,137 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/enum"
+)
+
+const (
+	dashboardRefresh = time.Second
+	dashboardSources = 15
+	dashboardAssets  = 10
+	dashboardLogs    = 8
+	// Clears the terminal and moves the cursor to the top left corner
+	clearScreen = "\033[H\033[2J"
+)
+
+// lineBuffer retains the most recent lines written to it.
+type lineBuffer struct {
+	sync.Mutex
+	max   int
+	lines []string
+}
+
+func newLineBuffer(max int) *lineBuffer {
+	return &lineBuffer{max: max}
+}
+
+func (lb *lineBuffer) Add(line string) {
+	lb.Lock()
+	defer lb.Unlock()
+
+	lb.lines = append(lb.lines, line)
+	if len(lb.lines) > lb.max {
+		lb.lines = lb.lines[len(lb.lines)-lb.max:]
+	}
+}
+
+func (lb *lineBuffer) Lines() []string {
+	lb.Lock()
+	defer lb.Unlock()
+
+	return append([]string(nil), lb.lines...)
+}
+
+// dashboard renders the live activity of a running enumeration to the terminal.
+type dashboard struct {
+	enum   *enum.Enumeration
+	start  time.Time
+	total  int
+	assets *lineBuffer
+	logs   *lineBuffer
+	last   map[string]int
+}
+
+func newDashboard(e *enum.Enumeration, logs *lineBuffer) *dashboard {
+	return &dashboard{
+		enum:   e,
+		start:  time.Now(),
+		assets: newLineBuffer(dashboardAssets),
+		logs:   logs,
+		last:   make(map[string]int),
+	}
+}
+
+// run redraws the dashboard until the output channel is closed by the enumeration.
+func (d *dashboard) run(output chan string, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	t := time.NewTicker(dashboardRefresh)
+	defer t.Stop()
+
+	for {
+		select {
+		case out, ok := <-output:
+			if !ok {
+				d.draw(color.Output)
+				return
+			}
+			d.total++
+			d.assets.Add(out)
+		case <-t.C:
+			d.draw(color.Output)
+		}
+	}
+}
+
+func (d *dashboard) draw(w io.Writer) {
+	var buf bytes.Buffer
+
+	buf.WriteString(clearScreen)
+	d.render(&buf, d.enum.Stats(), time.Since(d.start))
+	_, _ = w.Write(buf.Bytes())
+}
+
+func (d *dashboard) render(w io.Writer, stats *enum.Stats, elapsed time.Duration) {
+	state := green("running")
+	if stats.Paused {
+		state = yellow("paused")
+	}
+
+	fmt.Fprintf(w, "%s %s  %s %s  %s %s\n", blue("Elapsed:"), yellow(elapsed.Round(time.Second).String()),
+		blue("State:"), state, blue("Discovered:"), yellow(fmt.Sprintf("%d", d.total)))
+	fmt.Fprintf(w, "%s %s  %s %s\n\n", blue("Names Queued:"), yellow(fmt.Sprintf("%d", stats.InputQueue)),
+		blue("Addresses Queued:"), yellow(fmt.Sprintf("%d", stats.InfraQueue)))
+
+	fmt.Fprintf(w, "%s\n", blue(fmt.Sprintf("%-24s %10s %8s %8s %8s %8s", "Data Source", "Requests", "Queued", "Dups", "Names", "Names/s")))
+	for i, s := range stats.Sources {
+		rate := float64(s.Names-d.last[s.Name]) / dashboardRefresh.Seconds()
+		d.last[s.Name] = s.Names
+
+		if i >= dashboardSources {
+			continue
+		}
+		fmt.Fprintf(w, "%-24s %10d %8d %8d %8d %8.1f\n", s.Name, s.Requests, s.Queued, s.Duplicates, s.Names, rate)
+	}
+
+	fmt.Fprintf(w, "\n%s\n", blue("Recently Discovered"))
+	for _, line := range d.assets.Lines() {
+		fmt.Fprintf(w, "%s\n", line)
+	}
+
+	fmt.Fprintf(w, "\n%s\n", blue("Log"))
+	for _, line := range d.logs.Lines() {
+		fmt.Fprintf(w, "%s\n", strings.TrimSpace(line))
+	}
+}
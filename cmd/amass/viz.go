@@ -0,0 +1,261 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/caffix/stringset"
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/annotations"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/settings"
+	"github.com/owasp-amass/amass/v4/viz"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	vizUsageMsg = "viz -graphml|-gexf|-cytoscape FILE | -format NAME [-out FILE] [options] -d DOMAIN"
+)
+
+type vizArgs struct {
+	Domains *stringset.Set
+	Since   format.ParseTime
+	Until   format.ParseTime
+	Format  string
+	Tags    format.ParseStrings
+	Options struct {
+		NoColor bool
+		Silent  bool
+	}
+	Filepaths struct {
+		ConfigFile string
+		Cytoscape  string
+		Directory  string
+		Domains    format.ParseStrings
+		GEXF       string
+		GraphML    string
+		Out        string
+	}
+}
+
+func runVizCommand(clArgs []string) {
+	args := vizArgs{Domains: stringset.New()}
+	defer args.Domains.Close()
+	var help1, help2 bool
+	vizCommand := flag.NewFlagSet("viz", flag.ContinueOnError)
+
+	vizBuf := new(bytes.Buffer)
+	vizCommand.SetOutput(vizBuf)
+
+	vizCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	vizCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	vizCommand.Var(args.Domains, "d", "Domain names separated by commas (can be used multiple times)")
+	vizCommand.Var(&args.Since, "since", "Exclude assets and relations last seen before this time (RFC 3339 or YYYY-MM-DD)")
+	vizCommand.Var(&args.Until, "until", "Exclude assets and relations first seen after this time (RFC 3339 or YYYY-MM-DD)")
+	vizCommand.StringVar(&args.Format, "format", "", "Export format written to the -out file or stdout ("+strings.Join(viz.Encoders(), ", ")+")")
+	vizCommand.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	vizCommand.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
+	vizCommand.Var(&args.Tags, "tag", "Only export the assets tagged by the analysts, as key=value pairs separated by commas")
+	vizCommand.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	vizCommand.StringVar(&args.Filepaths.Cytoscape, "cytoscape", "", "Path to the Cytoscape.js JSON file that will be created")
+	vizCommand.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+	vizCommand.Var(&args.Filepaths.Domains, "df", "Path to a file providing root domain names")
+	vizCommand.StringVar(&args.Filepaths.GEXF, "gexf", "", "Path to the GEXF file that will be created")
+	vizCommand.StringVar(&args.Filepaths.GraphML, "graphml", "", "Path to the GraphML file that will be created")
+	vizCommand.StringVar(&args.Filepaths.Out, "out", "", "Path to the file written in the -format export format")
+
+	if len(clArgs) < 1 {
+		commandUsage(vizUsageMsg, vizCommand, vizBuf)
+		return
+	}
+	if err := vizCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(vizUsageMsg, vizCommand, vizBuf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Options.Silent {
+		color.Output = io.Discard
+		color.Error = io.Discard
+	}
+
+	since, until := time.Time(args.Since), time.Time(args.Until)
+	if !since.IsZero() && !until.IsZero() && until.Before(since) {
+		r.Fprintln(color.Error, "The -until time must not be before the -since time")
+		os.Exit(1)
+	}
+
+	type vizOutput struct {
+		path   string
+		format string
+	}
+	var outputs []vizOutput
+	for _, out := range []vizOutput{
+		{args.Filepaths.GraphML, "graphml"},
+		{args.Filepaths.GEXF, "gexf"},
+		{args.Filepaths.Cytoscape, "cytoscape"},
+	} {
+		if out.path != "" {
+			outputs = append(outputs, out)
+		}
+	}
+	if args.Format != "" {
+		outputs = append(outputs, vizOutput{args.Filepaths.Out, args.Format})
+	} else if args.Filepaths.Out != "" {
+		r.Fprintln(color.Error, "The -format flag must be provided with the -out flag")
+		os.Exit(1)
+	}
+	if len(outputs) == 0 {
+		r.Fprintln(color.Error, "At least one of the -graphml, -gexf, -cytoscape or -format flags must be provided")
+		os.Exit(1)
+	}
+
+	encoders := make([]viz.Encoder, len(outputs))
+	for i, out := range outputs {
+		e, err := viz.GetEncoder(out.format)
+		if err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+			os.Exit(1)
+		}
+		encoders[i] = e
+	}
+
+	for _, f := range args.Filepaths.Domains {
+		list, err := config.GetListFromFile(f)
+		if err != nil {
+			r.Fprintf(color.Error, "Failed to parse the domain names file: %v\n", err)
+			os.Exit(1)
+		}
+		args.Domains.InsertMany(list...)
+	}
+
+	cfg := config.NewConfig()
+	if err := settings.Load("viz", cfg, args.Filepaths.Directory, args.Filepaths.ConfigFile); err != nil {
+		r.Fprintf(color.Error, "Failed to load the configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Filepaths.Directory != "" {
+		cfg.Dir = args.Filepaths.Directory
+	}
+	cfg.AddDomains(args.Domains.Slice()...)
+	if len(cfg.Domains()) == 0 {
+		r.Fprintln(color.Error, "No root domain names were provided")
+		os.Exit(1)
+	}
+	priv := exportKey(cfg)
+
+	graph, err := buildVizGraph(cfg, since, until, args.Tags)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	// The addresses located by the GeoIP enrichment lead to their locations
+	all, err := findings.ReadFile(filepath.Join(config.OutputDirectory(cfg.Dir), "findings.json"))
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if locs := addressLocations(all); len(locs) > 0 {
+		labels := make(map[string]string, len(locs))
+		for addr, loc := range locs {
+			labels[addr] = loc.String()
+		}
+		graph.AddLocations(labels)
+	}
+
+	for i, out := range outputs {
+		// The export is written to stdout when a file was not provided for the format
+		if out.path == "" {
+			if err := encoders[i].Encode(color.Output, graph); err != nil {
+				r.Fprintf(color.Error, "Failed to write the %s export: %v\n", out.format, err)
+				os.Exit(1)
+			}
+			continue
+		}
+		if err := writeVizFile(out.path, graph, encoders[i]); err != nil {
+			r.Fprintf(color.Error, "Failed to write %s: %v\n", out.path, err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(color.Error, "%s was written with %s nodes and %s edges\n",
+			green(out.path), yellow(fmt.Sprint(len(graph.Nodes))), yellow(fmt.Sprint(len(graph.Edges))))
+		if err := signExport(priv, out.path); err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// buildVizGraph reads the graph of the configured domains, with the confidence scores and the tags of the
+// analysts applied. The graph database is locked again before returning, as the command exits on errors.
+func buildVizGraph(cfg *config.Config, since, until time.Time, tags []string) (*viz.Graph, error) {
+	g, err := openGraphDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer lockGraphDatabase(cfg)
+
+	graph, err := viz.Build(context.Background(), g, cfg.Domains(), since, until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the graph database: %v", err)
+	}
+	// The names are scored, and those below the threshold removed, when the scoring has been enabled
+	if scorer, obs, err := confidenceObservations(cfg, false); err != nil {
+		return nil, err
+	} else if scorer != nil {
+		graph.ApplyConfidence(obs.Scores(scorer, time.Now()), scorer.Suppressed)
+	}
+	// The tags attached by the analysts are exported, and select the assets when required
+	if err := applyTags(cfg, graph, tags); err != nil {
+		return nil, err
+	}
+	return graph, nil
+}
+
+func writeVizFile(path string, graph *viz.Graph, enc viz.Encoder) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return enc.Encode(f, graph)
+}
+
+// applyTags sets the tags of the assets in the graph, and keeps the assets carrying the key=value tags when provided.
+func applyTags(cfg *config.Config, graph *viz.Graph, pairs []string) error {
+	required, err := annotations.ParseTags(pairs)
+	if err != nil {
+		return err
+	}
+
+	store, err := openAnnotationStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer lockGraphDatabase(cfg)
+	defer store.Close()
+
+	tags, err := store.Tags()
+	if err != nil {
+		return fmt.Errorf("failed to read the annotations: %v", err)
+	}
+	graph.ApplyTags(tags, required)
+	return nil
+}
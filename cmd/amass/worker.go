@@ -0,0 +1,218 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/api"
+	"github.com/owasp-amass/amass/v4/settings"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	workerUsageMsg = "worker [options] -engine URL"
+	// The time waited before leasing again when the engine has no pending job
+	workerPollInterval = 5 * time.Second
+)
+
+type workerArgs struct {
+	Engine      string
+	EngineToken string
+	Name        string
+	Options     struct {
+		NoColor bool
+		Silent  bool
+		Verbose bool
+	}
+	Filepaths struct {
+		ConfigFile string
+		Directory  string
+	}
+}
+
+func runWorkerCommand(clArgs []string) {
+	var args workerArgs
+	var help1, help2 bool
+	workerCommand := flag.NewFlagSet("worker", flag.ContinueOnError)
+
+	workerBuf := new(bytes.Buffer)
+	workerCommand.SetOutput(workerBuf)
+
+	workerCommand.BoolVar(&help1, "h", false, "Show the program usage message")
+	workerCommand.BoolVar(&help2, "help", false, "Show the program usage message")
+	workerCommand.StringVar(&args.Engine, "engine", "", "URL of the engine API queuing the jobs")
+	workerCommand.StringVar(&args.EngineToken, "engine-token", "", "API token of the engine (default: $"+api.TokenEnv+")")
+	workerCommand.StringVar(&args.Name, "name", "", "Name of the worker shown in the session logs (default: the hostname)")
+	workerCommand.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
+	workerCommand.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
+	workerCommand.BoolVar(&args.Options.Verbose, "v", false, "Output the log messages of the jobs")
+	workerCommand.StringVar(&args.Filepaths.ConfigFile, "config", "", "Path to the YAML configuration file. Additional details below")
+	workerCommand.StringVar(&args.Filepaths.Directory, "dir", "", "Path to the directory containing the graph database")
+
+	if len(clArgs) < 1 {
+		commandUsage(workerUsageMsg, workerCommand, workerBuf)
+		return
+	}
+	if err := workerCommand.Parse(clArgs); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	if help1 || help2 {
+		commandUsage(workerUsageMsg, workerCommand, workerBuf)
+		return
+	}
+	if args.Options.NoColor {
+		color.NoColor = true
+	}
+	if args.Options.Silent {
+		color.Output = io.Discard
+		color.Error = io.Discard
+	}
+	if args.Engine == "" {
+		r.Fprintln(color.Error, "The URL of the engine must be provided")
+		os.Exit(1)
+	}
+	if args.Name == "" {
+		args.Name, _ = os.Hostname()
+	}
+
+	cfg := config.NewConfig()
+	if err := settings.Load("worker", cfg, args.Filepaths.Directory, args.Filepaths.ConfigFile); err != nil {
+		r.Fprintf(color.Error, "Failed to load the configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if args.Filepaths.Directory != "" {
+		cfg.Dir = args.Filepaths.Directory
+	}
+	createOutputDirectory(cfg)
+
+	token := args.EngineToken
+	if token == "" {
+		token = os.Getenv(api.TokenEnv)
+	}
+	client := api.NewClient(args.Engine, token)
+	// Check that the engine queues jobs for the workers before preparing to execute them
+	if _, err := client.Negotiate(context.Background(), api.FeatureWorkers); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+
+	// The graph database stays unlocked while the jobs use it
+	if _, err := openGraphDatabase(cfg); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
+	defer lockGraphDatabase(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Stop leasing jobs once the user requests it, which also cancels the current job
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(quit)
+
+		select {
+		case <-quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var logs io.Writer = io.Discard
+	if args.Options.Verbose {
+		logs = color.Error
+	}
+	logger := log.New(logs, "", log.Lmicroseconds)
+
+	fmt.Fprintf(color.Error, "%s %s is leasing jobs from %s\n", blue("Worker"), green(args.Name), yellow(args.Engine))
+	run := sessionEnumerator(cfg)
+	for ctx.Err() == nil {
+		job, err := client.LeaseJob(ctx, args.Name)
+		if err != nil && ctx.Err() == nil {
+			r.Fprintf(color.Error, "Failed to lease a job: %v\n", err)
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(workerPollInterval):
+			}
+			continue
+		}
+
+		fmt.Fprintf(color.Error, "%s %s was leased for %s\n", blue("Job"), green(job.ID), yellow(job.String()))
+		result := runJob(ctx, client, job, run, logger)
+		if ctx.Err() != nil {
+			// The lease expires, so the job is executed by another worker
+			break
+		}
+		if err := client.CompleteJob(ctx, job.ID, result); err != nil {
+			r.Fprintf(color.Error, "Failed to complete the job %s: %v\n", job.ID, err)
+			continue
+		}
+		fmt.Fprintf(color.Error, "%s %s was completed with %s new names\n", blue("Job"), green(job.ID), yellow(fmt.Sprint(len(result.Names))))
+	}
+	fmt.Fprintf(color.Error, "\n%s\n", green("The worker has been stopped"))
+}
+
+// runJob executes the enumeration of the job while renewing its lease, and cancels the enumeration
+// once the engine no longer holds the job for the worker, such as after the session was canceled.
+func runJob(ctx context.Context, client *api.Client, job *api.Job, run api.Enumerator, logger *log.Logger) *api.JobResult {
+	jctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if job.Timeout != "" {
+		if d, err := time.ParseDuration(job.Timeout); err == nil && d > 0 {
+			jctx, cancel = context.WithTimeout(ctx, d)
+			defer cancel()
+		}
+	}
+
+	lease := time.Duration(job.LeaseSeconds) * time.Second
+	if lease <= 0 {
+		lease = api.DefaultLeaseTime
+	}
+	go func() {
+		t := time.NewTicker(lease / 3)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-jctx.Done():
+				return
+			case <-t.C:
+				if err := client.RenewJob(jctx, job.ID); err != nil && jctx.Err() == nil {
+					logger.Printf("The lease of the job %s was lost: %v", job.ID, err)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	result, err := run(jctx, &api.SessionRequest{
+		Domains:  []string{job.Domain},
+		Config:   job.Config,
+		Priority: job.Priority,
+		Tenant:   job.Tenant,
+		Shard:    job.Shard,
+		Shards:   job.Shards,
+	}, logger)
+	if err != nil {
+		return &api.JobResult{Error: err.Error()}
+	}
+	return &api.JobResult{Names: result.Names, Errors: result.Errors}
+}
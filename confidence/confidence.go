@@ -0,0 +1,252 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package confidence combines the data sources that reported each name into a confidence score. Every
+// source contributes its weight, decayed by the time since it last reported the name, and the name is
+// considered real unless all the sources are wrong, so the score is 1 - Π(1 - weight × decay).
+package confidence
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// File is the name of the file holding the observations in the output directory.
+const File = "confidence.json"
+
+const (
+	// DefaultWeight is the weight of the sources without a configured weight.
+	DefaultWeight = 0.5
+	// DefaultHalfLife is the time after which the weight of an observation is halved.
+	DefaultHalfLife = 30 * 24 * time.Hour
+)
+
+// Scorer computes the confidence of the names from the sources that reported them.
+type Scorer struct {
+	// Weights maps the lowercase names of the sources to their weights, between 0 and 1
+	Weights       map[string]float64
+	DefaultWeight float64
+	// HalfLife is the decay of the observations, which do not decay when zero
+	HalfLife time.Duration
+	// Threshold is the score below which the names are suppressed
+	Threshold float64
+}
+
+// NewScorer returns a Scorer using the default weight and half-life, without a threshold.
+func NewScorer() *Scorer {
+	return &Scorer{
+		Weights:       make(map[string]float64),
+		DefaultWeight: DefaultWeight,
+		HalfLife:      DefaultHalfLife,
+	}
+}
+
+// Weight returns the weight of the source.
+func (s *Scorer) Weight(source string) float64 {
+	if w, found := s.Weights[strings.ToLower(source)]; found {
+		return w
+	}
+	return s.DefaultWeight
+}
+
+// Score returns the confidence, between 0 and 1, of a name reported by the sources at the provided times.
+func (s *Scorer) Score(sources map[string]time.Time, now time.Time) float64 {
+	doubt := 1.0
+
+	for src, seen := range sources {
+		w := s.Weight(src)
+		if age := now.Sub(seen); s.HalfLife > 0 && age > 0 {
+			w *= math.Pow(0.5, float64(age)/float64(s.HalfLife))
+		}
+		doubt *= 1 - math.Max(0, math.Min(1, w))
+	}
+	return 1 - doubt
+}
+
+// Suppressed returns true when the score is below the threshold.
+func (s *Scorer) Suppressed(score float64) bool {
+	return s.Threshold > 0 && score < s.Threshold
+}
+
+// Observations records the last time each source reported each name. Only the names confirmed
+// by the enumeration, or observed by previous enumerations, are saved. All methods are safe to
+// call on nil Observations.
+type Observations struct {
+	sync.Mutex
+	path      string
+	names     map[string]map[string]time.Time
+	confirmed map[string]struct{}
+	changed   bool
+}
+
+// Load returns the Observations saved to the file at the path, when it exists.
+func Load(path string) (*Observations, error) {
+	names, err := readObservations(path)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &Observations{
+		path:      path,
+		names:     names,
+		confirmed: make(map[string]struct{}, len(names)),
+	}
+	for name := range names {
+		o.confirmed[name] = struct{}{}
+	}
+	return o, nil
+}
+
+func readObservations(path string) (map[string]map[string]time.Time, error) {
+	names := make(map[string]map[string]time.Time)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return names, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// Observe records that the source reported the name at the provided time.
+func (o *Observations) Observe(name, source string, t time.Time) {
+	if o == nil || name == "" || source == "" {
+		return
+	}
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+
+	o.Lock()
+	defer o.Unlock()
+
+	sources, found := o.names[name]
+	if !found {
+		sources = make(map[string]time.Time)
+		o.names[name] = sources
+	}
+	if last, found := sources[source]; !found || t.After(last) {
+		sources[source] = t.UTC()
+		o.changed = true
+	}
+}
+
+// Confirm marks the name as real, so its observations are saved.
+func (o *Observations) Confirm(name string) {
+	if o == nil {
+		return
+	}
+
+	o.Lock()
+	defer o.Unlock()
+
+	o.confirmed[strings.ToLower(strings.TrimSuffix(name, "."))] = struct{}{}
+}
+
+// Sources returns the sources that reported the name, and the last time each reported it.
+func (o *Observations) Sources(name string) map[string]time.Time {
+	if o == nil {
+		return nil
+	}
+
+	o.Lock()
+	defer o.Unlock()
+
+	sources := make(map[string]time.Time)
+	for src, t := range o.names[strings.ToLower(name)] {
+		sources[src] = t
+	}
+	return sources
+}
+
+// SourceNames returns the sorted names of the sources that reported the name.
+func (o *Observations) SourceNames(name string) []string {
+	var names []string
+
+	for src := range o.Sources(name) {
+		names = append(names, src)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Scores returns the confidence of each confirmed name.
+func (o *Observations) Scores(s *Scorer, now time.Time) map[string]float64 {
+	if o == nil {
+		return nil
+	}
+
+	o.Lock()
+	defer o.Unlock()
+
+	scores := make(map[string]float64, len(o.confirmed))
+	for name := range o.confirmed {
+		if sources, found := o.names[name]; found {
+			scores[name] = s.Score(sources, now)
+		}
+	}
+	return scores
+}
+
+// Save writes the observations of the confirmed names to the file, merged with those
+// saved by the other enumerations since the file was loaded.
+func (o *Observations) Save() error {
+	if o == nil {
+		return nil
+	}
+
+	o.Lock()
+	defer o.Unlock()
+
+	if !o.changed {
+		return nil
+	}
+
+	names, err := readObservations(o.path)
+	if err != nil {
+		names = make(map[string]map[string]time.Time)
+	}
+	for name := range o.confirmed {
+		sources, found := o.names[name]
+		if !found {
+			continue
+		}
+
+		merged, found := names[name]
+		if !found {
+			merged = make(map[string]time.Time)
+			names[name] = merged
+		}
+		for src, t := range sources {
+			if last, found := merged[src]; !found || t.After(last) {
+				merged[src] = t
+			}
+		}
+	}
+
+	data, err := json.Marshal(names)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(o.path), 0755); err != nil {
+		return err
+	}
+
+	tmp := o.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	o.changed = false
+	return os.Rename(tmp, o.path)
+}
@@ -0,0 +1,116 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package confidence
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func TestScore(t *testing.T) {
+	now := time.Now()
+	s := NewScorer()
+	s.Weights["crtsh"] = 0.9
+
+	if score := s.Score(nil, now); score != 0 {
+		t.Errorf("Expected a name without sources to score 0, got %f", score)
+	}
+	if score := s.Score(map[string]time.Time{"Crtsh": now}, now); math.Abs(score-0.9) > 1e-9 {
+		t.Errorf("Expected the weight of the source to be matched regardless of case, got %f", score)
+	}
+	if score := s.Score(map[string]time.Time{"Crtsh": now, "DNS": now}, now); math.Abs(score-0.95) > 1e-9 {
+		t.Errorf("Expected the sources to be combined into 0.95, got %f", score)
+	}
+	if score := s.Score(map[string]time.Time{"DNS": now.Add(-DefaultHalfLife)}, now); math.Abs(score-0.25) > 1e-9 {
+		t.Errorf("Expected the observation to be halved after the half-life, got %f", score)
+	}
+
+	s.Threshold = 0.5
+	if !s.Suppressed(0.25) || s.Suppressed(0.5) {
+		t.Errorf("Expected only the scores below the threshold to be suppressed")
+	}
+}
+
+func TestObservations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), File)
+	now := time.Now()
+
+	o, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load the missing file: %v", err)
+	}
+	o.Observe("www.owasp.org.", "DNS", now)
+	o.Observe("WWW.OWASP.ORG", "Crtsh", now)
+	o.Observe("fake.owasp.org", "Crtsh", now)
+	o.Confirm("www.owasp.org")
+
+	if srcs := o.SourceNames("www.owasp.org"); len(srcs) != 2 || srcs[0] != "Crtsh" || srcs[1] != "DNS" {
+		t.Errorf("Expected the sources Crtsh and DNS, got %v", srcs)
+	}
+	if scores := o.Scores(NewScorer(), now); len(scores) != 1 {
+		t.Errorf("Expected only the confirmed name to be scored, got %v", scores)
+	}
+	if err := o.Save(); err != nil {
+		t.Fatalf("Failed to save the observations: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load the observations: %v", err)
+	}
+	if len(loaded.Sources("www.owasp.org")) != 2 || len(loaded.Sources("fake.owasp.org")) != 0 {
+		t.Errorf("Expected only the confirmed name to be saved")
+	}
+
+	var nilobs *Observations
+	nilobs.Observe("www.owasp.org", "DNS", now)
+	if err := nilobs.Save(); err != nil || nilobs.Scores(NewScorer(), now) != nil {
+		t.Errorf("Expected the nil Observations to do nothing")
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	if s, err := FromConfig(cfg); s != nil || err != nil {
+		t.Errorf("Expected no Scorer without the confidence section")
+	}
+
+	cfg.Options["confidence"] = map[string]interface{}{
+		"weights":   map[string]interface{}{"Crtsh": 0.9, "DNS": 1},
+		"threshold": 0.3,
+		"half_life": "720h",
+	}
+	s, err := FromConfig(cfg)
+	if err != nil || s == nil {
+		t.Fatalf("Failed to parse the confidence section: %v", err)
+	}
+	if s.Weight("crtsh") != 0.9 || s.Weight("dns") != 1 || s.Weight("other") != DefaultWeight {
+		t.Errorf("The weights were not parsed correctly: %v", s.Weights)
+	}
+	if s.Threshold != 0.3 || s.HalfLife != 720*time.Hour {
+		t.Errorf("The threshold or half-life were not parsed correctly")
+	}
+
+	for _, bad := range []map[string]interface{}{
+		{"enabled": "yes"},
+		{"weights": map[string]interface{}{"Crtsh": 2}},
+		{"threshold": "high"},
+		{"half_life": "soon"},
+	} {
+		cfg.Options["confidence"] = bad
+		if _, err := FromConfig(cfg); err == nil {
+			t.Errorf("Expected an error for %v", bad)
+		}
+	}
+
+	cfg.Options["confidence"] = map[string]interface{}{"enabled": false}
+	if s, err := FromConfig(cfg); s != nil || err != nil {
+		t.Errorf("Expected no Scorer when the scoring is disabled")
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package confidence
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// FromConfig returns the Scorer selected by the 'confidence' section of the configuration options,
+// which provides the 'weights' of the sources, the 'default_weight', the 'half_life' of the
+// observations and the 'threshold' suppressing the names. A nil Scorer is returned when the
+// section is missing or the scoring has not been enabled.
+func FromConfig(cfg *config.Config) (*Scorer, error) {
+	confRaw, ok := cfg.Options["confidence"]
+	if !ok {
+		return nil, nil
+	}
+
+	settings, ok := confRaw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("confidence is not a map[string]interface{}")
+	}
+
+	if raw, ok := settings["enabled"]; ok {
+		enabled, ok := raw.(bool)
+		if !ok {
+			return nil, fmt.Errorf("confidence enabled is not a bool")
+		}
+		if !enabled {
+			return nil, nil
+		}
+	}
+
+	s := NewScorer()
+	if raw, ok := settings["weights"]; ok {
+		weights, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("confidence weights is not a map[string]interface{}")
+		}
+
+		for src, v := range weights {
+			w, err := fraction(v)
+			if err != nil {
+				return nil, fmt.Errorf("confidence weight of %s %v", src, err)
+			}
+			s.Weights[strings.ToLower(src)] = w
+		}
+	}
+	if raw, ok := settings["default_weight"]; ok {
+		w, err := fraction(raw)
+		if err != nil {
+			return nil, fmt.Errorf("confidence default_weight %v", err)
+		}
+		s.DefaultWeight = w
+	}
+	if raw, ok := settings["threshold"]; ok {
+		t, err := fraction(raw)
+		if err != nil {
+			return nil, fmt.Errorf("confidence threshold %v", err)
+		}
+		s.Threshold = t
+	}
+	if raw, ok := settings["half_life"]; ok {
+		str, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("confidence half_life is not a string")
+		}
+
+		d, err := time.ParseDuration(str)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("confidence half_life must be a duration, such as 720h")
+		}
+		s.HalfLife = d
+	}
+	return s, nil
+}
+
+// Path returns the path of the observations file in the output directory of the configuration.
+func Path(cfg *config.Config) string {
+	return filepath.Join(config.OutputDirectory(cfg.Dir), File)
+}
+
+func fraction(raw interface{}) (float64, error) {
+	var v float64
+
+	switch n := raw.(type) {
+	case int:
+		v = float64(n)
+	case float64:
+		v = n
+	default:
+		return 0, fmt.Errorf("is not a number")
+	}
+	if v < 0 || v > 1 {
+		return 0, fmt.Errorf("must be between 0 and 1")
+	}
+	return v, nil
+}
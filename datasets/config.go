@@ -0,0 +1,80 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package datasets
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// FromConfig returns a Manager caching datasets in the output directory, with the
+// datasets from the 'datasets' section of the configuration options registered.
+func FromConfig(cfg *config.Config) (*Manager, error) {
+	dir := config.OutputDirectory(cfg.Dir)
+	if dir == "" {
+		return nil, fmt.Errorf("failed to obtain the output directory")
+	}
+
+	m := NewManager(filepath.Join(dir, "datasets"))
+	if err := loadDatasetSettings(cfg, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func loadDatasetSettings(cfg *config.Config, m *Manager) error {
+	datasetsRaw, ok := cfg.Options["datasets"]
+	if !ok {
+		return nil
+	}
+
+	datasets, ok := datasetsRaw.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("datasets is not a map[string]interface{}")
+	}
+
+	for name, dsRaw := range datasets {
+		settings, ok := dsRaw.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("datasets %s is not a map[string]interface{}", name)
+		}
+
+		// Settings for a default dataset only override the values provided
+		ds, err := m.dataset(name)
+		if err != nil {
+			ds = Dataset{Name: name}
+		}
+		if u, ok := settings["url"]; ok {
+			if ds.URL, ok = u.(string); !ok {
+				return fmt.Errorf("datasets %s url is not a string", name)
+			}
+		}
+		if sum, ok := settings["sha256"]; ok {
+			if ds.SHA256, ok = sum.(string); !ok {
+				return fmt.Errorf("datasets %s sha256 is not a string", name)
+			}
+		}
+		if r, ok := settings["refresh"]; ok {
+			str, ok := r.(string)
+			if !ok {
+				return fmt.Errorf("datasets %s refresh is not a string", name)
+			}
+
+			d, err := time.ParseDuration(str)
+			if err != nil {
+				return fmt.Errorf("datasets %s refresh is not a valid duration: %v", name, err)
+			}
+			ds.Refresh = d
+		}
+
+		if err := m.Register(ds); err != nil {
+			return err
+		}
+	}
+	return nil
+}
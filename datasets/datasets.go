@@ -0,0 +1,363 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package datasets
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+)
+
+// Dataset describes an external bulk file used during enumerations.
+type Dataset struct {
+	Name    string
+	URL     string
+	SHA256  string
+	Refresh time.Duration
+}
+
+// Status reports the state of a dataset in the local cache.
+type Status struct {
+	Dataset
+	Path    string
+	Updated time.Time
+	Size    int64
+	Cached  bool
+	Stale   bool
+}
+
+type metadata struct {
+	URL     string    `json:"url"`
+	SHA256  string    `json:"sha256"`
+	Size    int64     `json:"size"`
+	Updated time.Time `json:"updated"`
+}
+
+// DefaultDatasets are the datasets available without any configuration.
+var DefaultDatasets = []Dataset{
+	{
+		Name:    "psl",
+		URL:     "https://publicsuffix.org/list/public_suffix_list.dat",
+		Refresh: 7 * 24 * time.Hour,
+	},
+	{
+		Name:    "aws-ip-ranges",
+		URL:     "https://ip-ranges.amazonaws.com/ip-ranges.json",
+		Refresh: 24 * time.Hour,
+	},
+	{
+		Name:    "gcp-ip-ranges",
+		URL:     "https://www.gstatic.com/ipranges/cloud.json",
+		Refresh: 24 * time.Hour,
+	},
+	{
+		Name:    "cloudflare-ipv4",
+		URL:     "https://www.cloudflare.com/ips-v4",
+		Refresh: 7 * 24 * time.Hour,
+	},
+	{
+		Name:    "cloudflare-ipv6",
+		URL:     "https://www.cloudflare.com/ips-v6",
+		Refresh: 7 * 24 * time.Hour,
+	},
+	{
+		Name:    "rdap-dns",
+		URL:     "https://data.iana.org/rdap/dns.json",
+		Refresh: 24 * time.Hour,
+	},
+}
+
+// Serializes downloads of the same file across managers.
+var fileLocks sync.Map
+
+// Manager downloads, verifies, and caches datasets under a local directory.
+type Manager struct {
+	sync.Mutex
+	dir      string
+	datasets map[string]Dataset
+	client   *http.Client
+}
+
+// NewManager returns a Manager that caches datasets in the provided directory.
+func NewManager(dir string) *Manager {
+	m := &Manager{
+		dir:      dir,
+		datasets: make(map[string]Dataset),
+		client:   amasshttp.DefaultClient,
+	}
+
+	for _, ds := range DefaultDatasets {
+		_ = m.Register(ds)
+	}
+	return m
+}
+
+// Register adds the dataset to the Manager, replacing any dataset with the same name.
+func (m *Manager) Register(ds Dataset) error {
+	ds.Name = strings.ToLower(strings.TrimSpace(ds.Name))
+	if ds.Name == "" || strings.ContainsAny(ds.Name, `/\`) {
+		return fmt.Errorf("the dataset name '%s' is invalid", ds.Name)
+	}
+	if ds.URL == "" {
+		return fmt.Errorf("the dataset %s has no URL", ds.Name)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	m.datasets[ds.Name] = ds
+	return nil
+}
+
+// Names returns the sorted names of the registered datasets.
+func (m *Manager) Names() []string {
+	m.Lock()
+	defer m.Unlock()
+
+	var names []string
+	for name := range m.datasets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func (m *Manager) dataset(name string) (Dataset, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	ds, found := m.datasets[strings.ToLower(name)]
+	if !found {
+		return ds, fmt.Errorf("the dataset %s is not registered", name)
+	}
+	return ds, nil
+}
+
+// Path returns the location of the dataset in the local cache.
+func (m *Manager) Path(name string) string {
+	return filepath.Join(m.dir, strings.ToLower(name))
+}
+
+func (m *Manager) metaPath(name string) string {
+	return m.Path(name) + ".json"
+}
+
+// Status returns the cache state of the named dataset.
+func (m *Manager) Status(name string) (*Status, error) {
+	ds, err := m.dataset(name)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Status{
+		Dataset: ds,
+		Path:    m.Path(ds.Name),
+		Stale:   true,
+	}
+	if _, err := os.Stat(s.Path); err != nil {
+		return s, nil
+	}
+	if meta, err := m.readMetadata(ds.Name); err == nil && meta.URL == ds.URL {
+		s.Cached = true
+		s.Updated = meta.Updated
+		s.Size = meta.Size
+		s.Stale = ds.Refresh > 0 && time.Since(meta.Updated) > ds.Refresh
+	}
+	return s, nil
+}
+
+// Get returns the path to the named dataset, downloading it when missing or stale.
+// A cached copy is still returned when a refresh fails.
+func (m *Manager) Get(ctx context.Context, name string) (string, error) {
+	s, err := m.Status(name)
+	if err != nil {
+		return "", err
+	}
+	if !s.Stale {
+		return s.Path, nil
+	}
+	if err := m.Fetch(ctx, name, false); err != nil && !s.Cached {
+		return "", err
+	}
+	return s.Path, nil
+}
+
+// Refresh downloads all the registered datasets that are missing or stale.
+func (m *Manager) Refresh(ctx context.Context) error {
+	var errs []string
+
+	for _, name := range m.Names() {
+		if s, err := m.Status(name); err == nil && s.Stale {
+			if err := m.Fetch(ctx, name, false); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Start refreshes the stale datasets on the provided interval until the context expires.
+func (m *Manager) Start(ctx context.Context, interval time.Duration, log func(error)) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		if err := m.Refresh(ctx); err != nil && log != nil {
+			log(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Fetch downloads the named dataset. A partial download left by an earlier attempt
+// is resumed, unless force is true or the server does not support range requests.
+func (m *Manager) Fetch(ctx context.Context, name string, force bool) error {
+	ds, err := m.dataset(name)
+	if err != nil {
+		return err
+	}
+
+	path := m.Path(ds.Name)
+	l, _ := fileLocks.LoadOrStore(path, new(sync.Mutex))
+	lock := l.(*sync.Mutex)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create the datasets directory: %v", err)
+	}
+
+	partial := path + ".part"
+	if force {
+		_ = os.Remove(partial)
+	}
+	if err := m.download(ctx, ds, partial); err != nil {
+		return fmt.Errorf("failed to download the %s dataset: %v", ds.Name, err)
+	}
+
+	sum, size, err := checksum(partial)
+	if err != nil {
+		return fmt.Errorf("failed to read the %s dataset: %v", ds.Name, err)
+	}
+	if ds.SHA256 != "" && !strings.EqualFold(ds.SHA256, sum) {
+		_ = os.Remove(partial)
+		return fmt.Errorf("the %s dataset failed checksum verification: expected %s, got %s", ds.Name, ds.SHA256, sum)
+	}
+	if err := os.Rename(partial, path); err != nil {
+		return fmt.Errorf("failed to move the %s dataset into the cache: %v", ds.Name, err)
+	}
+
+	return m.writeMetadata(ds.Name, &metadata{
+		URL:     ds.URL,
+		SHA256:  sum,
+		Size:    size,
+		Updated: time.Now(),
+	})
+}
+
+func (m *Manager) download(ctx context.Context, ds Dataset, partial string) error {
+	var offset int64
+	if fi, err := os.Stat(partial); err == nil {
+		offset = fi.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ds.URL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", amasshttp.UserAgent)
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		flags |= os.O_APPEND
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The partial file already holds the complete dataset
+		return nil
+	case resp.StatusCode == http.StatusOK:
+		flags |= os.O_TRUNC
+	default:
+		return fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+
+	f, err := os.OpenFile(partial, flags, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+func checksum(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+func (m *Manager) readMetadata(name string) (*metadata, error) {
+	data, err := os.ReadFile(m.metaPath(name))
+	if err != nil {
+		return nil, err
+	}
+
+	var meta metadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+func (m *Manager) writeMetadata(name string, meta *metadata) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.metaPath(name), data, 0644)
+}
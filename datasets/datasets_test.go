@@ -0,0 +1,77 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package datasets
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetchResumeAndVerify(t *testing.T) {
+	content := []byte(strings.Repeat("example.com\n", 1000))
+	sum := sha256.Sum256(content)
+
+	var ranged bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			ranged = true
+		}
+		http.ServeContent(w, r, "list.txt", time.Now(), bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	m := NewManager(t.TempDir())
+	if err := m.Register(Dataset{
+		Name:    "list",
+		URL:     srv.URL,
+		SHA256:  hex.EncodeToString(sum[:]),
+		Refresh: time.Hour,
+	}); err != nil {
+		t.Fatalf("Failed to register the dataset: %v", err)
+	}
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Leave a partial download behind to be resumed
+	if err := os.WriteFile(m.Path("list")+".part", content[:500], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := m.Get(context.Background(), "list")
+	if err != nil {
+		t.Fatalf("Failed to get the dataset: %v", err)
+	}
+	if !ranged {
+		t.Error("The partial download was not resumed")
+	}
+	if data, err := os.ReadFile(path); err != nil || !bytes.Equal(data, content) {
+		t.Error("The cached dataset does not match the content served")
+	}
+	if s, err := m.Status("list"); err != nil || !s.Cached || s.Stale || s.Size != int64(len(content)) {
+		t.Errorf("Unexpected dataset status: %+v", s)
+	}
+
+	if err := m.Register(Dataset{
+		Name:   "list",
+		URL:    srv.URL,
+		SHA256: strings.Repeat("0", 64),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Fetch(context.Background(), "list", true); err == nil {
+		t.Error("The checksum verification did not fail")
+	}
+	if _, err := os.Stat(m.Path("list") + ".part"); err == nil {
+		t.Error("The download that failed verification was not removed")
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// ArchivedURLFinding is the finding type used for the historical URLs of the in-scope hosts found in web archives.
+const ArchivedURLFinding = "archived_url"
+
+// The layout of the capture timestamps used by the Wayback Machine and CommonCrawl indexes.
+const archiveTimeLayout = "20060102150405"
+
+// Archived URLs of static content are only used for the names they provide.
+var staticExtensions = map[string]struct{}{
+	".css": {}, ".eot": {}, ".gif": {}, ".ico": {}, ".jpeg": {}, ".jpg": {},
+	".png": {}, ".svg": {}, ".ttf": {}, ".webp": {}, ".woff": {}, ".woff2": {},
+}
+
+// Wrapper so that scripts can submit a URL found in a web archive, with the timestamps of its first
+// and last captures. The in-scope URL is sent to Amass and recorded as a finding for the timelines.
+func (s *Script) newArchivedURL(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	if err != nil || contextExpired(ctx) {
+		return 0
+	}
+
+	params := L.CheckTable(2)
+	if params == nil {
+		return 0
+	}
+
+	raw, _ := getStringField(L, params, "url")
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return 0
+	}
+	u.Fragment = ""
+
+	host := strings.ToLower(u.Hostname())
+	domain := s.sys.Config().WhichDomain(host)
+	if domain == "" {
+		return 0
+	}
+	s.newNameWithContext(ctx, host)
+	s.newURLWithContext(ctx, u.String())
+
+	if _, found := staticExtensions[strings.ToLower(path.Ext(u.Path))]; found {
+		return 0
+	}
+
+	first, _ := getStringField(L, params, "first_seen")
+	last, _ := getStringField(L, params, "last_seen")
+	captures, _ := getNumberField(L, params, "captures")
+	details := archivedURLDetails(u, first, last, int(captures))
+
+	desc := "The URL was archived"
+	if f, found := details["first_seen"]; found {
+		desc += fmt.Sprintf(" between %s and %s", f, details["last_seen"])
+	}
+
+	if _, err := s.sys.Findings().Add(&findings.Finding{
+		Type:        ArchivedURLFinding,
+		Asset:       u.String(),
+		Severity:    findings.Info,
+		Description: desc,
+		Source:      s.String(),
+		Details:     details,
+	}); err != nil {
+		s.sys.Config().Log.Printf("%s: new_archived_url: %v", s.String(), err)
+	}
+	return 0
+}
+
+func archivedURLDetails(u *url.URL, first, last string, captures int) map[string]string {
+	details := map[string]string{"host": strings.ToLower(u.Hostname())}
+
+	if p := u.EscapedPath(); p != "" {
+		details["path"] = p
+	} else {
+		details["path"] = "/"
+	}
+	if params := queryParams(u); len(params) > 0 {
+		details["params"] = strings.Join(params, ",")
+	}
+
+	firstSeen, ok := archiveTime(first)
+	if ok {
+		details["first_seen"] = firstSeen.Format(time.RFC3339)
+	}
+	lastSeen, found := archiveTime(last)
+	if !found || (ok && lastSeen.Before(firstSeen)) {
+		lastSeen, found = firstSeen, ok
+	}
+	if found {
+		details["last_seen"] = lastSeen.Format(time.RFC3339)
+	}
+	if captures > 0 {
+		details["captures"] = strconv.Itoa(captures)
+	}
+	return details
+}
+
+// archiveTime parses the capture timestamps, which are truncated to the precision known by the archive.
+func archiveTime(ts string) (time.Time, bool) {
+	ts = strings.TrimSpace(ts)
+	if len(ts) < 4 || len(ts) > len(archiveTimeLayout) || len(ts)%2 != 0 {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(archiveTimeLayout[:len(ts)], ts)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t.UTC(), true
+}
+
+// queryParams returns the sorted names of the query parameters of the URL.
+func queryParams(u *url.URL) []string {
+	var names []string
+
+	for name := range u.Query() {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
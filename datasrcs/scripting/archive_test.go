@@ -0,0 +1,110 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestArchiveTime(t *testing.T) {
+	tests := []struct {
+		ts       string
+		expected string
+		ok       bool
+	}{
+		{"20150906071218", "2015-09-06T07:12:18Z", true},
+		{"201509", "2015-09-01T00:00:00Z", true},
+		{"2015090", "", false},
+		{"", "", false},
+		{"2015-09-06", "", false},
+	}
+
+	for _, test := range tests {
+		got, ok := archiveTime(test.ts)
+		if ok != test.ok || (ok && got.Format(time.RFC3339) != test.expected) {
+			t.Errorf("%q: expected (%s, %t), got (%s, %t)", test.ts, test.expected, test.ok, got.Format(time.RFC3339), ok)
+		}
+	}
+}
+
+func TestNewArchivedURL(t *testing.T) {
+	store, err := findings.NewStore(filepath.Join(t.TempDir(), "findings.json"))
+	if err != nil {
+		t.Fatalf("Failed to create the findings store: %v", err)
+	}
+
+	sys := newMockSystem(config.NewConfig())
+	defer func() { _ = sys.Shutdown() }()
+	sys.(*systems.SimpleSystem).Store = store
+
+	script := NewScript(`
+		name="archive"
+		type="testing"
+
+		function vertical(ctx, domain)
+			new_archived_url(ctx, {
+				['url']="https://example.com/",
+				['first_seen']="20150906071218",
+			})
+			new_archived_url(ctx, {
+				['url']="https://www." .. domain .. "/logo.png",
+				['first_seen']="20150906071218",
+			})
+			new_archived_url(ctx, {
+				['url']="https://www." .. domain .. "/search?q=amass&page=2#results",
+				['first_seen']="20150906071218",
+				['last_seen']="20230101",
+				['captures']=12,
+			})
+			new_name(ctx, domain)
+		end
+	`, sys)
+	if script == nil || sys.AddAndStart(script) != nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+
+	domain := "owasp.org"
+	sys.Config().AddDomain(domain)
+	script.Input() <- &requests.DNSRequest{Domain: domain}
+
+	// The name and URL of each in-scope archived URL are sent to Amass, followed by the root domain
+	for i := 0; i < 5; i++ {
+		select {
+		case <-script.Output():
+		case <-time.After(10 * time.Second):
+			t.Fatal("The script did not send the in-scope names and URLs")
+		}
+	}
+
+	all, err := store.All()
+	if err != nil || len(all) != 1 {
+		t.Fatalf("Expected one finding, got %d: %v", len(all), err)
+	}
+
+	f := all[0]
+	if f.Type != ArchivedURLFinding || f.Asset != "https://www.owasp.org/search?q=amass&page=2" {
+		t.Errorf("Unexpected finding: %+v", f)
+	}
+	expected := map[string]string{
+		"host":       "www.owasp.org",
+		"path":       "/search",
+		"params":     "page,q",
+		"first_seen": "2015-09-06T07:12:18Z",
+		"last_seen":  "2023-01-01T00:00:00Z",
+		"captures":   "12",
+	}
+	for k, v := range expected {
+		if f.Details[k] != v {
+			t.Errorf("Expected the detail %s to be %s, got %s", k, v, f.Details[k])
+		}
+	}
+}
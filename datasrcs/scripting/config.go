@@ -5,8 +5,14 @@
 package scripting
 
 import (
-	"github.com/caffix/service"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/brute"
+	"github.com/owasp-amass/amass/v4/datasets"
 	"github.com/owasp-amass/amass/v4/format"
+	"github.com/owasp-amass/amass/v4/net/http"
 	"github.com/owasp-amass/config/config"
 	lua "github.com/yuin/gopher-lua"
 )
@@ -17,6 +23,7 @@ func (s *Script) config(L *lua.LState) int {
 
 	r := L.NewTable()
 	r.RawSetString("version", lua.LString(format.Version))
+	r.RawSetString("api_version", lua.LNumber(APIVersion))
 
 	if cfg.Active {
 		r.RawSetString("mode", lua.LString("active"))
@@ -89,6 +96,11 @@ func (s *Script) config(L *lua.LState) int {
 	tb.RawSetString("recursive", lua.LBool(cfg.Recursive))
 	tb.RawSetString("min_for_recursive", lua.LNumber(cfg.MinForRecursive))
 	tb.RawSetString("max_depth", lua.LNumber(cfg.MaxDepth))
+	adaptive, maxLearned := bruteOptions(cfg)
+	tb.RawSetString("adaptive", lua.LBool(adaptive))
+	tb.RawSetString("max_learned", lua.LNumber(maxLearned))
+	resume, _ := optionsSection(cfg, "bruteforce")["resume"].(bool)
+	tb.RawSetString("resume", lua.LBool(resume))
 	r.RawSetString("brute_forcing", tb)
 
 	tb = L.NewTable()
@@ -98,12 +110,208 @@ func (s *Script) config(L *lua.LState) int {
 	tb.RawSetString("add_words", lua.LBool(cfg.AddWords))
 	tb.RawSetString("add_numbers", lua.LBool(cfg.AddNumbers))
 	tb.RawSetString("edit_distance", lua.LNumber(cfg.EditDistance))
+	guesser, _ := optionsSection(cfg, "alterations")["guesser"].(bool)
+	tb.RawSetString("guesser", lua.LBool(guesser))
 	r.RawSetString("alterations", tb)
 
+	tb = L.NewTable()
+	settings := optionsSection(cfg, "resolver_checks")
+	enabled, _ := settings["enabled"].(bool)
+	tb.RawSetString("enabled", lua.LBool(enabled))
+	snoop := L.NewTable()
+	if names, ok := settings["snoop_names"].([]interface{}); ok {
+		for _, n := range names {
+			if name, ok := n.(string); ok && name != "" {
+				snoop.Append(lua.LString(name))
+			}
+		}
+	}
+	tb.RawSetString("snoop_names", snoop)
+	r.RawSetString("resolver_checks", tb)
+
 	L.Push(r)
 	return 1
 }
 
+// optionsSection returns the settings in the named section of the configuration options.
+func optionsSection(cfg *config.Config, name string) map[string]interface{} {
+	if settings, ok := cfg.Options[name].(map[string]interface{}); ok {
+		return settings
+	}
+	return map[string]interface{}{}
+}
+
+// The default number of words learned from discovered names for adaptive brute forcing.
+const defaultMaxLearned = 500
+
+// bruteOptions returns the adaptive brute forcing settings from the 'bruteforce' section of the configuration options.
+func bruteOptions(cfg *config.Config) (bool, int) {
+	settings := optionsSection(cfg, "bruteforce")
+
+	adaptive, _ := settings["adaptive"].(bool)
+	maxLearned, ok := settings["max_learned"].(int)
+	if !ok || maxLearned <= 0 {
+		maxLearned = defaultMaxLearned
+	}
+	return adaptive, maxLearned
+}
+
+// httpSession returns the headers and cookies in the 'http_sessions' section of the configuration
+// options for the named data source, or nil when none have been configured.
+func httpSession(cfg *config.Config, name string) (*http.Session, error) {
+	var raw interface{}
+	for k, v := range optionsSection(cfg, "http_sessions") {
+		if strings.EqualFold(k, name) {
+			raw = v
+			break
+		}
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	settings, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("http_sessions %s is not a map[string]interface{}", name)
+	}
+
+	domains, err := sessionDomains(name, settings["domains"])
+	if err != nil {
+		return nil, err
+	}
+	hdr, err := sessionValues(cfg, name, "headers", settings["headers"])
+	if err != nil {
+		return nil, err
+	}
+	cookies, err := sessionValues(cfg, name, "cookies", settings["cookies"])
+	if err != nil {
+		return nil, err
+	}
+	return http.NewSession(domains, hdr, cookies), nil
+}
+
+// sessionDomains returns the domains receiving the headers and cookies of the session, which must be provided.
+func sessionDomains(name string, raw interface{}) ([]string, error) {
+	var domains []string
+
+	switch v := raw.(type) {
+	case string:
+		domains = append(domains, v)
+	case []interface{}:
+		for _, d := range v {
+			str, ok := d.(string)
+			if !ok {
+				return nil, fmt.Errorf("http_sessions %s domains contains a value that is not a string", name)
+			}
+			domains = append(domains, str)
+		}
+	case nil:
+	default:
+		return nil, fmt.Errorf("http_sessions %s domains is not a list of strings", name)
+	}
+
+	if len(domains) == 0 {
+		return nil, fmt.Errorf("http_sessions %s must provide the domains receiving the headers and cookies", name)
+	}
+	return domains, nil
+}
+
+func sessionValues(cfg *config.Config, name, key string, raw interface{}) (map[string]string, error) {
+	values := make(map[string]string)
+	if raw == nil {
+		return values, nil
+	}
+
+	settings, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("http_sessions %s %s is not a map[string]interface{}", name, key)
+	}
+
+	for k, v := range settings {
+		str, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("http_sessions %s %s %s is not a string", name, key, k)
+		}
+
+		value, err := secretValue(cfg, name, str)
+		if err != nil {
+			return nil, fmt.Errorf("http_sessions %s %s %s: %v", name, key, k, err)
+		}
+		values[k] = value
+	}
+	return values, nil
+}
+
+// secretValue allows values to be kept out of the configuration file by referencing an environment
+// variable using 'env:NAME', the contents of a file using 'file:PATH', or the credentials of the data
+// source in the data source configuration using 'credential:FIELD' or 'credential:ACCOUNT.FIELD'.
+func secretValue(cfg *config.Config, source, value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, "env:"):
+		name := strings.TrimPrefix(value, "env:")
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("the %s environment variable is not set", name)
+		}
+		return v, nil
+	case strings.HasPrefix(value, "file:"):
+		data, err := os.ReadFile(strings.TrimPrefix(value, "file:"))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(data)), nil
+	case strings.HasPrefix(value, "credential:"):
+		return credentialValue(cfg, source, strings.TrimPrefix(value, "credential:"))
+	}
+	return value, nil
+}
+
+// credentialValue returns the username, password, apikey or secret of the data source credentials.
+// The account must be named when the data source has several of them.
+func credentialValue(cfg *config.Config, source, ref string) (string, error) {
+	account, field := "", ref
+	if idx := strings.LastIndex(ref, "."); idx != -1 {
+		account, field = ref[:idx], ref[idx+1:]
+	}
+
+	dsc := cfg.GetDataSourceConfig(source)
+	if dsc == nil || len(dsc.Creds) == 0 {
+		return "", fmt.Errorf("the data source configuration provides no credentials for %s", source)
+	}
+
+	var creds *config.Credentials
+	if account != "" {
+		creds = dsc.Creds[account]
+	} else if len(dsc.Creds) == 1 {
+		for _, c := range dsc.Creds {
+			creds = c
+		}
+	} else {
+		return "", fmt.Errorf("the credentials of %s must be selected using credential:ACCOUNT.%s", source, field)
+	}
+	if creds == nil {
+		return "", fmt.Errorf("the data source configuration provides no %s account for %s", account, source)
+	}
+
+	var v string
+	switch strings.ToLower(field) {
+	case "username":
+		v = creds.Username
+	case "password":
+		v = creds.Password
+	case "apikey":
+		v = creds.Apikey
+	case "secret":
+		v = creds.Secret
+	default:
+		return "", fmt.Errorf("%s is not a field of the credentials", field)
+	}
+	if v == "" {
+		return "", fmt.Errorf("the credentials of %s do not provide the %s", source, field)
+	}
+	return v, nil
+}
+
 func (s *Script) dataSourceConfig(L *lua.LState) int {
 	dsc := s.sys.Config().DataSrcConfigs
 	if dsc == nil {
@@ -159,12 +367,21 @@ func (s *Script) inScope(L *lua.LState) int {
 	return 1
 }
 
-// Wrapper so that scripts can obtain the brute force wordlist for the current enumeration.
+// Wrapper so that scripts can obtain the brute force wordlist for the current enumeration. When
+// a zone is provided and resuming the brute forcing is enabled, only the words not yet tried for
+// the zone are returned.
 func (s *Script) bruteWordlist(L *lua.LState) int {
 	tb := L.NewTable()
 
 	if _, err := extractContext(L.CheckUserData(1)); err == nil {
-		for _, word := range s.sys.Config().Wordlist {
+		words := s.sys.Config().Wordlist
+		if zone := L.OptString(2, ""); zone != "" {
+			if r := s.bruteResume(); r != nil {
+				words = words[r.Start(zone, s.bruteID, len(words)):]
+			}
+		}
+
+		for _, word := range words {
 			tb.Append(lua.LString(word))
 		}
 	}
@@ -173,6 +390,47 @@ func (s *Script) bruteWordlist(L *lua.LState) int {
 	return 1
 }
 
+// Wrapper so that scripts can record the number of words of the wordlist tried for a zone. False
+// is returned once the context has expired, since the remaining words are left for the next session.
+func (s *Script) bruteProgress(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	if err != nil || contextExpired(ctx) {
+		L.Push(lua.LFalse)
+		return 1
+	}
+
+	zone := L.CheckString(2)
+	if n := L.CheckInt(3); zone != "" && n > 0 {
+		s.bruteResume().Advance(zone, n, len(s.sys.Config().Wordlist))
+	}
+	L.Push(lua.LTrue)
+	return 1
+}
+
+// bruteResume returns the brute forcing positions recorded in the output directory,
+// or nil when resuming the brute forcing has not been enabled.
+func (s *Script) bruteResume() *brute.Resume {
+	s.resumeLock.Lock()
+	defer s.resumeLock.Unlock()
+
+	if s.resume != nil {
+		return s.resume
+	}
+
+	cfg := s.sys.Config()
+	if enabled, _ := optionsSection(cfg, "bruteforce")["resume"].(bool); !enabled {
+		return nil
+	}
+	dir := config.OutputDirectory(cfg.Dir)
+	if dir == "" {
+		return nil
+	}
+
+	s.resume = brute.LoadResume(dir)
+	s.bruteID = brute.WordlistID(cfg.Wordlist)
+	return s.resume
+}
+
 // Wrapper so that scripts can obtain the alteration wordlist for the current enumeration.
 func (s *Script) altWordlist(L *lua.LState) int {
 	tb := L.NewTable()
@@ -187,21 +445,46 @@ func (s *Script) altWordlist(L *lua.LState) int {
 	return 1
 }
 
-// Wrapper so scripts can set the data source rate limit.
-func (s *Script) setRateLimit(L *lua.LState) int {
-	s.seconds = L.CheckInt(1)
+// The number of characters in the n-grams of the name guesser model.
+const guesserOrder = 3
+
+// Wrapper so that scripts can train the name guesser model with a discovered label.
+func (s *Script) trainGuesser(L *lua.LState) int {
+	if _, err := extractContext(L.CheckUserData(1)); err == nil {
+		s.guesser.Train(L.CheckString(2))
+	}
 	return 0
 }
 
-func numRateLimitChecks(srv service.Service, num int) {
-	for i := 0; i < num; i++ {
-		srv.CheckRateLimit()
+// Wrapper so that scripts can obtain the most probable labels from the name guesser model.
+func (s *Script) guessLabels(L *lua.LState) int {
+	tb := L.NewTable()
+
+	if _, err := extractContext(L.CheckUserData(1)); err == nil {
+		for _, label := range s.guesser.Guess(L.CheckInt(2)) {
+			tb.Append(lua.LString(label))
+		}
 	}
+
+	L.Push(tb)
+	return 1
+}
+
+// Wrapper so scripts can set the data source rate limit.
+func (s *Script) setRateLimit(L *lua.LState) int {
+	s.seconds = L.CheckInt(1)
+	return 0
 }
 
 // Wrapper so scripts can block until past the data source rate limit.
 func (s *Script) checkRateLimit(L *lua.LState) int {
-	numRateLimitChecks(s, s.seconds)
+	// The context of the callback carries the session waiting for its turn
+	ctx := L.Context()
+	if ctx == nil {
+		ctx = s.ctx
+	}
+
+	_ = s.limiter.Wait(ctx)
 	return 0
 }
 
@@ -220,3 +503,32 @@ func (s *Script) outputdir(L *lua.LState) int {
 	}
 	return 1
 }
+
+// Wrapper so that scripts can obtain the local path to a downloaded dataset.
+func (s *Script) dataset(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString("No user data parameter or context expired"))
+		return 2
+	}
+
+	name := L.CheckString(2)
+	mgr, err := datasets.FromConfig(s.sys.Config())
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+
+	path, err := mgr.Get(ctx, name)
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+
+	L.Push(lua.LString(path))
+	L.Push(lua.LNil)
+	return 2
+}
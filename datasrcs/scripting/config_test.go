@@ -0,0 +1,145 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/brute"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestHTTPSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookie")
+	if err := os.WriteFile(path, []byte("from-file\n"), 0600); err != nil {
+		t.Fatalf("Failed to write the cookie file: %v", err)
+	}
+	t.Setenv("AMASS_TEST_TOKEN", "from-env")
+
+	cfg := config.NewConfig()
+	cfg.DataSrcConfigs = &config.DataSourceConfig{Datasources: []*config.DataSource{
+		{Name: "Example", Creds: map[string]*config.Credentials{"account": {Apikey: "from-creds"}}},
+		{Name: "Multiple", Creds: map[string]*config.Credentials{
+			"first":  {Username: "alice", Password: "first-pass"},
+			"second": {Username: "bob", Password: "second-pass"},
+		}},
+	}}
+	cfg.Options["http_sessions"] = map[string]interface{}{
+		"Example": map[string]interface{}{
+			"domains": []interface{}{"example.com"},
+			"headers": map[string]interface{}{"X-Token": "env:AMASS_TEST_TOKEN", "X-Key": "credential:apikey"},
+			"cookies": map[string]interface{}{"session": "file:" + path, "lang": "en"},
+		},
+		"Broken": map[string]interface{}{
+			"domains": "example.com",
+			"cookies": map[string]interface{}{"session": "env:AMASS_TEST_MISSING"},
+		},
+		"Unscoped": map[string]interface{}{
+			"cookies": map[string]interface{}{"session": "value"},
+		},
+	}
+
+	if s, err := httpSession(cfg, "example"); err != nil || s == nil {
+		t.Errorf("Failed to obtain the session: %v", err)
+	}
+	if s, err := httpSession(cfg, "Other"); err != nil || s != nil {
+		t.Errorf("Returned a session for a data source without one: %v", err)
+	}
+	if _, err := httpSession(cfg, "Broken"); err == nil {
+		t.Error("Accepted a reference to a missing environment variable")
+	}
+	if _, err := httpSession(cfg, "Unscoped"); err == nil {
+		t.Error("Accepted a session without the domains receiving the cookies")
+	}
+
+	for value, expected := range map[string]string{
+		"plain":                "plain",
+		"env:AMASS_TEST_TOKEN": "from-env",
+		"file:" + path:         "from-file",
+	} {
+		if v, err := secretValue(cfg, "Example", value); err != nil || v != expected {
+			t.Errorf("secretValue(%s) returned %q, expected %q: %v", value, v, expected, err)
+		}
+	}
+
+	for _, c := range []struct {
+		source   string
+		ref      string
+		expected string
+	}{
+		{"Example", "apikey", "from-creds"},
+		{"example", "account.apikey", "from-creds"},
+		{"Multiple", "second.password", "second-pass"},
+		{"Multiple", "password", ""},
+		{"Example", "secret", ""},
+		{"Example", "token", ""},
+		{"Other", "apikey", ""},
+	} {
+		v, err := secretValue(cfg, c.source, "credential:"+c.ref)
+		if c.expected == "" && err == nil {
+			t.Errorf("secretValue(credential:%s) of %s returned %q instead of an error", c.ref, c.source, v)
+		} else if c.expected != "" && (err != nil || v != c.expected) {
+			t.Errorf("secretValue(credential:%s) of %s returned %q, expected %q: %v", c.ref, c.source, v, c.expected, err)
+		}
+	}
+}
+
+func TestBruteResume(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.Wordlist = []string{"a", "b", "c", "d", "e"}
+	cfg.Options["bruteforce"] = map[string]interface{}{"resume": true}
+
+	// The previous session was interrupted after the first two words
+	domain := "owasp.org"
+	data := fmt.Sprintf(`{"%s": {"wordlist": "%s", "offset": 2}}`, domain, brute.WordlistID(cfg.Wordlist))
+	path := filepath.Join(config.OutputDirectory(cfg.Dir), brute.ResumeFile)
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write the resume file: %v", err)
+	}
+
+	sys := newMockSystem(cfg)
+	defer func() { _ = sys.Shutdown() }()
+
+	script := NewScript(`
+		name="resume"
+		type="testing"
+
+		function vertical(ctx, domain)
+			local words = brute_wordlist(ctx, domain)
+			new_name(ctx, words[1] .. "." .. domain)
+			brute_progress(ctx, domain, #words)
+		end
+	`, sys)
+	if script == nil || sys.AddAndStart(script) != nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+
+	sys.Config().AddDomain(domain)
+	script.Input() <- &requests.DNSRequest{Domain: domain}
+
+	select {
+	case req := <-script.Output():
+		if r, ok := req.(*requests.DNSRequest); !ok || r.Name != "c."+domain {
+			t.Errorf("The brute forcing was not resumed from the recorded position: %v", req)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("The script did not finish the callback")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if r := brute.LoadResume(config.OutputDirectory(cfg.Dir)); r.Start(domain, brute.WordlistID(cfg.Wordlist), 5) == 5 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("The zone was not recorded as completed")
+}
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/findings"
 	amassnet "github.com/owasp-amass/amass/v4/net"
 	amassdns "github.com/owasp-amass/amass/v4/net/dns"
 	"github.com/owasp-amass/amass/v4/requests"
@@ -23,6 +24,9 @@ import (
 	"golang.org/x/net/publicsuffix"
 )
 
+// ZoneTransferFinding is the finding type used when a nameserver allows anyone to transfer the zone.
+const ZoneTransferFinding = "dns_zone_transfer"
+
 const (
 	defaultSweepSize = 250
 	activeSweepSize  = 500
@@ -116,6 +120,10 @@ func (s *Script) dnsQuery(ctx context.Context, msg *dns.Msg, r *resolve.Resolver
 		default:
 		}
 
+		if !s.sys.Budget().SpendDNS(s.String()) {
+			return nil, errors.New("the DNS query budget has been exhausted")
+		}
+
 		resp, err := r.QueryBlocking(ctx, msg)
 		if err != nil {
 			continue
@@ -133,6 +141,80 @@ func (s *Script) dnsQuery(ctx context.Context, msg *dns.Msg, r *resolve.Resolver
 	return nil, nil
 }
 
+// Wrapper so that scripts can send a DNS query directly to a specific server.
+func (s *Script) queryServer(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	opt := L.CheckTable(2)
+	if err != nil || opt == nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString("proper parameters were not provided"))
+		return 2
+	}
+
+	server, _ := getStringField(L, opt, "server")
+	name, _ := getStringField(L, opt, "name")
+	t, _ := getStringField(L, opt, "type")
+	qtype := convertType(t)
+	if net.ParseIP(server) == nil || name == "" || qtype == 0 {
+		L.Push(lua.LNil)
+		L.Push(lua.LString("the server, name and type fields must be provided"))
+		return 2
+	}
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), qtype)
+	msg.RecursionDesired = false
+	if lv := L.GetField(opt, "recursion"); lv == lua.LTrue {
+		msg.RecursionDesired = true
+	}
+	if class, ok := getStringField(L, opt, "class"); ok && strings.EqualFold(class, "CH") {
+		msg.Question[0].Qclass = dns.ClassCHAOS
+	}
+	msg.SetEdns0(dns.DefaultMsgSize, false)
+
+	if !s.sys.Budget().SpendDNS(s.String()) {
+		L.Push(lua.LNil)
+		L.Push(lua.LString("the DNS query budget has been exhausted"))
+		return 2
+	}
+
+	resp, err := amassdns.ExchangeMsg(ctx, msg, server)
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+
+	tb := L.NewTable()
+	tb.RawSetString("rcode", lua.LNumber(resp.Rcode))
+	tb.RawSetString("authoritative", lua.LBool(resp.Authoritative))
+	tb.RawSetString("recursion_available", lua.LBool(resp.RecursionAvailable))
+	tb.RawSetString("truncated", lua.LBool(resp.Truncated))
+	tb.RawSetString("size", lua.LNumber(resp.Len()))
+
+	answers := L.NewTable()
+	for _, rr := range resp.Answer {
+		hdr := rr.Header()
+		entry := L.NewTable()
+		entry.RawSetString("rrname", lua.LString(resolve.RemoveLastDot(hdr.Name)))
+		entry.RawSetString("rrtype", lua.LNumber(hdr.Rrtype))
+		entry.RawSetString("rrdata", lua.LString(rrData(rr)))
+		answers.Append(entry)
+	}
+	tb.RawSetString("answers", answers)
+
+	L.Push(tb)
+	L.Push(lua.LNil)
+	return 2
+}
+
+func rrData(rr dns.RR) string {
+	if t, ok := rr.(*dns.TXT); ok {
+		return strings.Join(t.Txt, " ")
+	}
+	return strings.TrimSpace(strings.TrimPrefix(rr.String(), rr.Header().String()))
+}
+
 func convertType(qtype string) uint16 {
 	var t uint16
 
@@ -155,6 +237,8 @@ func convertType(qtype string) uint16 {
 		t = dns.TypeSOA
 	case "srv":
 		t = dns.TypeSRV
+	case "any":
+		t = dns.TypeANY
 	}
 	return t
 }
@@ -327,6 +411,7 @@ func (s *Script) wrapZoneTransfer(L *lua.LState) int {
 
 	tb := L.NewTable()
 	if reqs, err := ZoneTransfer(ctx, name, domain, server); err == nil && len(reqs) > 0 {
+		s.zoneTransferFinding(name, server, len(reqs))
 		for _, req := range reqs {
 			for _, rr := range req.Records {
 				entry := L.NewTable()
@@ -352,6 +437,20 @@ func (s *Script) wrapZoneTransfer(L *lua.LState) int {
 	return 2
 }
 
+// zoneTransferFinding reports the nameserver that allowed the zone of the name to be transferred.
+func (s *Script) zoneTransferFinding(name, server string, num int) {
+	if _, err := s.sys.Findings().Add(&findings.Finding{
+		Type:        ZoneTransferFinding,
+		Asset:       name,
+		Severity:    findings.High,
+		Description: fmt.Sprintf("The nameserver %s allowed the zone to be transferred, disclosing %d names", server, num),
+		Source:      s.String(),
+		Details:     map[string]string{"server": server},
+	}); err != nil {
+		s.sys.Config().Log.Printf("%s: zone_transfer: %v", s.String(), err)
+	}
+}
+
 // ZoneTransfer attempts a DNS zone transfer using the provided server.
 // The returned slice contains all the records discovered from the zone transfer.
 func ZoneTransfer(ctx context.Context, sub, domain, server string) ([]*requests.DNSRequest, error) {
@@ -0,0 +1,103 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/requests"
+	lua "github.com/yuin/gopher-lua"
+)
+
+type errorStats struct {
+	sync.Mutex
+	counts map[requests.ErrorCategory]*requests.ErrorCount
+}
+
+// recordError counts the failure of the data source within the category.
+func (s *Script) recordError(category requests.ErrorCategory, msg string) {
+	s.failures.Lock()
+	defer s.failures.Unlock()
+
+	if s.failures.counts == nil {
+		s.failures.counts = make(map[requests.ErrorCategory]*requests.ErrorCount)
+	}
+
+	ec, found := s.failures.counts[category]
+	if !found {
+		ec = &requests.ErrorCount{Category: category}
+		s.failures.counts[category] = ec
+	}
+	ec.Count++
+	ec.Last = msg
+}
+
+// ErrorCounts returns copies of the failures of the data source, ordered by the error categories.
+func (s *Script) ErrorCounts() []*requests.ErrorCount {
+	s.failures.Lock()
+	defer s.failures.Unlock()
+
+	results := make([]*requests.ErrorCount, 0, len(s.failures.counts))
+	for _, ec := range s.failures.counts {
+		c := *ec
+		results = append(results, &c)
+	}
+
+	requests.SortErrorCounts(results)
+	return results
+}
+
+// classifyHTTP records the failure of the HTTP request sent by the data source, if any. The URL
+// is left out of the messages, since it can carry the API key of the data source.
+func (s *Script) classifyHTTP(u string, resp *http.Response, err error) {
+	if err != nil {
+		// The requests canceled along with the enumeration are not failures of the data source
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		s.recordError(requests.NetworkError, err.Error())
+		return
+	}
+	if resp == nil {
+		return
+	}
+
+	if category := requests.HTTPErrorCategory(resp.StatusCode); category != "" {
+		host := u
+		if parsed, perr := url.Parse(u); perr == nil {
+			host = parsed.Host
+		}
+		s.recordError(category, fmt.Sprintf("%s returned the status %s", host, resp.Status))
+	}
+}
+
+// Wrapper so that scripts can report a failure within one of the error categories, such as a response
+// that could not be decoded. The message is also written to the log.
+func (s *Script) reportError(L *lua.LState) int {
+	if _, err := extractContext(L.CheckUserData(1)); err != nil {
+		return 0
+	}
+
+	msg := L.CheckString(3)
+	category, err := requests.ParseErrorCategory(L.CheckString(2))
+	if err != nil {
+		s.sys.Config().Log.Printf("%s: report_error: %v", s.String(), err)
+		return 0
+	}
+
+	s.recordError(category, msg)
+	s.sys.Config().Log.Printf("%s: %s", s.String(), msg)
+	return 0
+}
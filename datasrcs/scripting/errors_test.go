@@ -0,0 +1,80 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestErrorCounts(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "<html>")
+	}))
+	defer ts.Close()
+
+	sys := newMockSystem(config.NewConfig())
+	defer func() { _ = sys.Shutdown() }()
+
+	script := fmt.Sprintf(`
+		name="errors"
+		type="api"
+
+		local json = require("json")
+
+		function vertical(ctx, domain)
+			request(ctx, {['url']="%s/auth?apikey=secret"})
+
+			local resp, err = request(ctx, {['url']="%s/results"})
+			if (err ~= nil and err ~= "") then
+				return
+			end
+
+			local d = json.decode(resp.body)
+			if (d == nil) then
+				report_error(ctx, "parse", "failed to decode the JSON response")
+			end
+			report_error(ctx, "unknown", "not counted")
+			new_name(ctx, "www.example.com")
+		end
+	`, ts.URL, ts.URL)
+	s := NewScript(script, sys)
+	if s == nil || sys.AddAndStart(s) != nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+
+	sys.Config().AddDomain("owasp.org")
+	s.Input() <- &requests.DNSRequest{Name: "owasp.org", Domain: "owasp.org"}
+
+	var counts []*requests.ErrorCount
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if counts = s.ErrorCounts(); len(counts) == 3 {
+			break
+		}
+	}
+	if len(counts) != 3 {
+		t.Fatalf("Expected three error categories, got %+v", counts)
+	}
+
+	expected := []requests.ErrorCategory{requests.AuthError, requests.ParseError, requests.ScopeRejectedError}
+	for i, ec := range counts {
+		if ec.Category != expected[i] || ec.Count != 1 {
+			t.Errorf("Unexpected error count %+v, expected the %s category", ec, expected[i])
+		}
+	}
+	if last := counts[0].Last; last != ts.Listener.Addr().String()+" returned the status 401 Unauthorized" {
+		t.Errorf("Unexpected message of the auth failure: %s", last)
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/owasp-amass/amass/v4/net/http"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Wrapper so that scripts can compute the hash of a favicon searched by Shodan and ZoomEye.
+func faviconHash(L *lua.LState) int {
+	L.Push(lua.LNumber(http.FaviconHash([]byte(L.CheckString(1)))))
+	return 1
+}
+
+// Wrapper so that scripts can compute the SHA-256 digest of data, returned as a hex string.
+func sha256Hex(L *lua.LState) int {
+	sum := sha256.Sum256([]byte(L.CheckString(1)))
+
+	L.Push(lua.LString(hex.EncodeToString(sum[:])))
+	return 1
+}
+
+// Wrapper so that scripts can compute the hash of a normalized response body served for a host,
+// which is shared by the parked domains and the wildcard virtual hosts serving identical pages.
+func bodyHash(L *lua.LState) int {
+	L.Push(lua.LString(http.BodyHash(L.CheckString(1), L.CheckString(2))))
+	return 1
+}
+
+// Wrapper so that scripts can check whether a response body is a page of a domain parking service.
+func parkedPage(L *lua.LState) int {
+	L.Push(lua.LBool(http.ParkedPage(L.CheckString(1))))
+	return 1
+}
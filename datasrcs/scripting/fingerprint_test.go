@@ -0,0 +1,67 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/config/config"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestFingerprintFunctions(t *testing.T) {
+	sys := newMockSystem(config.NewConfig())
+	defer func() { _ = sys.Shutdown() }()
+
+	script := NewScript(`
+		name="fingerprint"
+		type="testing"
+		requires={"favicon_hash", "sha256"}
+	`, sys)
+	if script == nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+
+	L := script.luaState
+	if err := L.DoString(`icon = favicon_hash("icon") digest = sha256("abc")`); err != nil {
+		t.Fatalf("Failed to execute the functions: %v", err)
+	}
+
+	expected := strconv.Itoa(int(http.FaviconHash([]byte("icon"))))
+	if icon := L.GetGlobal("icon"); icon.Type() != lua.LTNumber || icon.String() != expected {
+		t.Errorf("Expected the favicon hash %s, got %s", expected, icon.String())
+	}
+	if digest := L.GetGlobal("digest").String(); digest != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Errorf("Unexpected SHA-256 digest: %s", digest)
+	}
+}
+
+func TestContentFunctions(t *testing.T) {
+	sys := newMockSystem(config.NewConfig())
+	defer func() { _ = sys.Shutdown() }()
+
+	script := NewScript(`
+		name="content"
+		type="testing"
+		requires={"body_hash", "parked_page"}
+	`, sys)
+	if script == nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+
+	L := script.luaState
+	if err := L.DoString(`digest = body_hash("www.owasp.org", "<h1>www.owasp.org</h1>") parked = parked_page("Buy this domain")`); err != nil {
+		t.Fatalf("Failed to execute the functions: %v", err)
+	}
+
+	if digest := L.GetGlobal("digest").String(); digest != http.BodyHash("dev.owasp.org", "<h1>dev.owasp.org</h1>") {
+		t.Errorf("Unexpected body hash: %s", digest)
+	}
+	if parked := L.GetGlobal("parked"); parked != lua.LTrue {
+		t.Errorf("Expected the page to be parked, got %s", parked.String())
+	}
+}
@@ -6,6 +6,9 @@ package scripting
 
 import (
 	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
 	"net/url"
 	"strings"
 	"time"
@@ -109,6 +112,13 @@ func responseToTable(L *lua.LState, resp *http.Response) *lua.LTable {
 
 				c.RawSetString("version", lua.LNumber(cert.Version))
 				c.RawSetString("common_name", lua.LString(dns.RemoveAsteriskLabel(cert.Subject.CommonName)))
+				c.RawSetString("issuer", lua.LString(cert.Issuer.CommonName))
+				if len(cert.Subject.Organization) > 0 {
+					c.RawSetString("organization", lua.LString(cert.Subject.Organization[0]))
+				}
+				c.RawSetString("not_before", luaTime(cert.NotBefore))
+				c.RawSetString("not_after", luaTime(cert.NotAfter))
+				c.RawSetString("sha256", lua.LString(fmt.Sprintf("%x", sha256.Sum256(cert.Raw))))
 
 				if len(cert.DNSNames) > 0 {
 					san := L.NewTable()
@@ -183,6 +193,16 @@ func (s *Script) scrape(L *lua.LState) int {
 	} else {
 		s.sys.Config().Log.Print(s.String() + ": scrape: " + err.Error())
 	}
+	// Pages providing the results using JavaScript are rendered by the headless browser
+	if fallback, _ := getBoolField(L, opt, "browser"); fallback && sucess == lua.LFalse && body == "" && s.sys.Browser() != nil {
+		if page, err := s.render(ctx, url); err == nil {
+			if num := s.internalSendNames(ctx, page); num > 0 {
+				sucess = lua.LTrue
+			}
+		} else {
+			s.sys.Config().Log.Print(s.String() + ": scrape: " + err.Error())
+		}
+	}
 
 	L.Push(sucess)
 	return 1
@@ -193,18 +213,32 @@ func (s *Script) req(ctx context.Context, url, data string, hdr http.Header, aut
 	if data != "" {
 		method = "POST"
 	}
+	if !s.sys.Budget().SpendHTTP(s.String()) {
+		return nil, errors.New("the HTTP request budget has been exhausted")
+	}
 
-	numRateLimitChecks(s, s.seconds)
+	if err := s.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
 	ctx, cancel := context.WithTimeout(ctx, 20*time.Second)
 	defer cancel()
 
 	resp, err := http.RequestWebPage(ctx, &http.Request{
-		URL:    url,
-		Method: method,
-		Header: hdr,
-		Body:   data,
-		Auth:   auth,
+		URL:     url,
+		Method:  method,
+		Header:  hdr,
+		Body:    data,
+		Auth:    auth,
+		Session: s.session,
 	})
+	if resp != nil {
+		s.sys.Quotas().Observe(s.String(), resp.StatusCode, resp.Header)
+		if s.limiter.Observe(resp.StatusCode, resp.Header) {
+			s.sys.Config().Log.Printf("%s: throttled to %.2f requests per second after status %d",
+				s.String(), s.limiter.Rate().Rate, resp.StatusCode)
+		}
+	}
+	s.classifyHTTP(url, resp, err)
 	if err != nil {
 		cfg := s.sys.Config()
 
@@ -215,6 +249,57 @@ func (s *Script) req(ctx context.Context, url, data string, hdr http.Header, aut
 	return resp, err
 }
 
+// Wrapper so that scripts can obtain the HTML of a web page rendered by the headless browser.
+func (s *Script) browse(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString("No user data parameter or context expired"))
+		return 2
+	}
+
+	url := L.CheckString(2)
+	if url == "" {
+		L.Push(lua.LNil)
+		L.Push(lua.LString("No URL was provided"))
+		return 2
+	}
+
+	page, err := s.render(ctx, url)
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+
+	L.Push(lua.LString(page))
+	L.Push(lua.LNil)
+	return 2
+}
+
+func (s *Script) render(ctx context.Context, url string) (string, error) {
+	b := s.sys.Browser()
+	if b == nil {
+		return "", errors.New("the headless browser is not enabled")
+	}
+	if !s.sys.Budget().SpendHTTP(s.String()) {
+		return "", errors.New("the HTTP request budget has been exhausted")
+	}
+
+	if err := s.limiter.Wait(ctx); err != nil {
+		return "", err
+	}
+	page, err := b.Fetch(ctx, url)
+	if err != nil {
+		cfg := s.sys.Config()
+
+		if cfg.Verbose {
+			cfg.Log.Printf("%s: %s: %v", s.String(), url, err)
+		}
+	}
+	return page, err
+}
+
 // Wrapper so that scripts can crawl for subdomain names in scope.
 func (s *Script) crawl(L *lua.LState) int {
 	cfg := s.sys.Config()
@@ -233,9 +318,11 @@ func (s *Script) crawl(L *lua.LState) int {
 	defer cancel()
 
 	err = http.Crawl(ctx, u, cfg.Domains(), max, func(req *http.Request, resp *http.Response) {
+		s.sys.Budget().SpendHTTP(s.String())
 		if u, err := url.Parse(req.URL); err == nil {
 			s.newNameWithContext(ctx, http.CleanName(u.Hostname()))
 		}
+		s.newURLWithContext(ctx, req.URL)
 		s.internalSendNames(ctx, resp.Body)
 
 		if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
@@ -0,0 +1,36 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"github.com/owasp-amass/amass/v4/net/http"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Wrapper so that scripts can obtain the URLs of the external scripts referenced by a web page.
+func scriptSources(L *lua.LState) int {
+	tb := L.NewTable()
+
+	for _, src := range http.ScriptSources(L.CheckString(1), L.CheckString(2)) {
+		tb.Append(lua.LString(src))
+	}
+	L.Push(tb)
+	return 1
+}
+
+// Wrapper so that scripts can extract the endpoints from JavaScript or source maps. The URL of
+// the source map referenced by the script is also returned, or an empty string without one.
+func jsEndpoints(L *lua.LState) int {
+	base := L.CheckString(1)
+	content := L.CheckString(2)
+
+	tb := L.NewTable()
+	for _, u := range http.JSEndpoints(base, content) {
+		tb.Append(lua.LString(u))
+	}
+	L.Push(tb)
+	L.Push(lua.LString(http.SourceMapURL(base, content)))
+	return 2
+}
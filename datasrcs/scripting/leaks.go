@@ -0,0 +1,279 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	lua "github.com/yuin/gopher-lua"
+)
+
+const (
+	// CodeCredentialFinding is the finding type used for the credentials found in public code and commits.
+	CodeCredentialFinding = "code_credential_leak"
+	// CloudBucketFinding is the finding type used for the cloud storage buckets referenced by public code and commits.
+	CloudBucketFinding = "cloud_bucket_reference"
+)
+
+// The longest a script is blocked waiting for the rate limit of a service to be reset.
+const maxRetryAfter = time.Minute
+
+// The wait used when a service responds with status 429 without indicating when to retry.
+const defaultRetryAfter = 10 * time.Second
+
+var credentialPatterns = []struct {
+	kind string
+	re   *regexp.Regexp
+}{
+	{"aws_access_key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"github_token", regexp.MustCompile(`\bgh[opsur]_[A-Za-z0-9]{36}\b`)},
+	{"gitlab_token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20}\b`)},
+	{"slack_token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`)},
+	{"google_api_key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"private_key", regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP )?PRIVATE KEY(?: BLOCK)?-----`)},
+}
+
+const bucketName = `([a-z0-9][a-z0-9._-]{1,61}[a-z0-9])`
+
+// The path-style hosts must not be preceded by the label of a virtual-hosted bucket.
+const pathStyle = `(?i)(?:^|[^a-z0-9.-])`
+
+var bucketPatterns = []struct {
+	provider string
+	scheme   string
+	re       *regexp.Regexp
+}{
+	{"aws", "s3://", regexp.MustCompile(`(?i)\b` + bucketName + `\.s3(?:[.-][a-z0-9-]+)*\.amazonaws\.com`)},
+	{"aws", "s3://", regexp.MustCompile(pathStyle + `s3(?:[.-][a-z0-9-]+)*\.amazonaws\.com/` + bucketName)},
+	{"aws", "s3://", regexp.MustCompile(`(?i)\bs3://` + bucketName)},
+	{"gcp", "gs://", regexp.MustCompile(`(?i)\b` + bucketName + `\.storage\.googleapis\.com`)},
+	{"gcp", "gs://", regexp.MustCompile(pathStyle + `storage\.googleapis\.com/` + bucketName)},
+	{"gcp", "gs://", regexp.MustCompile(`(?i)\bgs://` + bucketName)},
+	{"azure", "azure://", regexp.MustCompile(`(?i)\b([a-z0-9]{3,24})\.blob\.core\.windows\.net`)},
+}
+
+var urlRE = regexp.MustCompile(`https?://[^\s"'<>()\[\]{}\\]+`)
+
+type codeCredential struct {
+	Kind  string
+	Value string
+}
+
+type cloudBucket struct {
+	Provider string
+	Bucket   string
+	URI      string
+}
+
+// Wrapper so that scripts can process the public code and commits mentioning the target. The names and URLs
+// in scope are sent to Amass, and the cloud storage buckets and credentials found are recorded as findings.
+func (s *Script) sendCodeLeaks(L *lua.LState) int {
+	var num int
+
+	ctx, err := extractContext(L.CheckUserData(1))
+	if err != nil || contextExpired(ctx) {
+		L.Push(lua.LNumber(num))
+		return 1
+	}
+
+	content := L.CheckString(2)
+	location := L.OptString(3, "")
+	if content != "" {
+		num = s.internalSendNames(ctx, content)
+
+		for _, u := range urlRE.FindAllString(content, -1) {
+			s.newURLWithContext(ctx, strings.TrimRight(u, ".,;:"))
+		}
+		if location != "" {
+			s.codeFindings(content, location)
+		}
+	}
+
+	L.Push(lua.LNumber(num))
+	return 1
+}
+
+func (s *Script) codeFindings(content, location string) {
+	for _, b := range extractBuckets(content) {
+		if _, err := s.sys.Findings().Add(&findings.Finding{
+			Type:        CloudBucketFinding,
+			Asset:       b.URI,
+			Severity:    findings.Info,
+			Description: "The cloud storage bucket is referenced by public code mentioning the target",
+			Source:      s.String(),
+			Details: map[string]string{
+				"provider": b.Provider,
+				"bucket":   b.Bucket,
+				"location": location,
+			},
+		}); err != nil {
+			s.sys.Config().Log.Printf("%s: send_code_leaks: %v", s.String(), err)
+		}
+	}
+
+	creds := extractCredentials(content)
+	if len(creds) == 0 {
+		return
+	}
+
+	var kinds, values []string
+	for _, c := range creds {
+		kinds = append(kinds, c.Kind)
+		values = append(values, redact(c.Value))
+	}
+	if _, err := s.sys.Findings().Add(&findings.Finding{
+		Type:        CodeCredentialFinding,
+		Asset:       location,
+		Severity:    findings.High,
+		Description: "Public code mentioning the target contains credentials: " + strings.Join(kinds, ", "),
+		Source:      s.String(),
+		Details: map[string]string{
+			"kinds":  strings.Join(kinds, ","),
+			"values": strings.Join(values, ","),
+		},
+	}); err != nil {
+		s.sys.Config().Log.Printf("%s: send_code_leaks: %v", s.String(), err)
+	}
+}
+
+func extractCredentials(content string) []*codeCredential {
+	seen := make(map[string]struct{})
+
+	var creds []*codeCredential
+	for _, p := range credentialPatterns {
+		for _, match := range p.re.FindAllString(content, -1) {
+			if _, found := seen[match]; found {
+				continue
+			}
+			seen[match] = struct{}{}
+			creds = append(creds, &codeCredential{Kind: p.kind, Value: match})
+		}
+	}
+	return creds
+}
+
+func extractBuckets(content string) []*cloudBucket {
+	seen := make(map[string]struct{})
+
+	var buckets []*cloudBucket
+	for _, p := range bucketPatterns {
+		for _, match := range p.re.FindAllStringSubmatch(content, -1) {
+			name := strings.ToLower(match[1])
+			uri := p.scheme + name
+
+			if _, found := seen[uri]; found {
+				continue
+			}
+			seen[uri] = struct{}{}
+			buckets = append(buckets, &cloudBucket{
+				Provider: p.provider,
+				Bucket:   name,
+				URI:      uri,
+			})
+		}
+	}
+
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].URI < buckets[j].URI })
+	return buckets
+}
+
+// redact keeps enough of the secret to identify it without storing the credential.
+func redact(secret string) string {
+	if strings.HasPrefix(secret, "-----BEGIN") {
+		return strings.Trim(secret, "-")
+	}
+	if len(secret) <= 8 {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:4] + strings.Repeat("*", len(secret)-8) + secret[len(secret)-4:]
+}
+
+// Wrapper so that scripts can wait out the rate limit indicated by the response of a service. It returns
+// true once the request can be sent again, and false when the response is not rate limited or the wait is too long.
+func (s *Script) retryAfter(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	resp := L.CheckTable(2)
+	if err != nil || contextExpired(ctx) || resp == nil {
+		L.Push(lua.LFalse)
+		return 1
+	}
+
+	code, _ := getNumberField(L, resp, "status_code")
+	hdr := make(map[string]string)
+	if tbl, ok := L.GetField(resp, "header").(*lua.LTable); ok {
+		tbl.ForEach(func(k, v lua.LValue) {
+			hdr[http.CanonicalHeaderKey(k.String())] = v.String()
+		})
+	}
+
+	delay, limited := rateLimitDelay(int(code), hdr, time.Now())
+	if !limited || delay > maxRetryAfter {
+		L.Push(lua.LFalse)
+		return 1
+	}
+
+	L.Push(lua.LBool(wait(ctx, s.Done(), delay)))
+	return 1
+}
+
+func wait(ctx context.Context, done <-chan struct{}, delay time.Duration) bool {
+	t := time.NewTimer(delay)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-done:
+		return false
+	case <-t.C:
+	}
+	return true
+}
+
+// rateLimitDelay returns how long to wait before retrying the request, using the Retry-After header
+// and the rate limit headers of the GitHub (X-Ratelimit-*) and GitLab (Ratelimit-*) APIs.
+func rateLimitDelay(code int, hdr map[string]string, now time.Time) (time.Duration, bool) {
+	if code != http.StatusTooManyRequests && code != http.StatusForbidden {
+		return 0, false
+	}
+
+	if v := hdr["Retry-After"]; v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			return positive(time.Duration(secs) * time.Second), true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return positive(t.Sub(now)), true
+		}
+	}
+
+	for _, prefix := range []string{"X-Ratelimit-", "Ratelimit-"} {
+		if hdr[prefix+"Remaining"] != "0" {
+			continue
+		}
+		if reset, err := strconv.ParseInt(hdr[prefix+"Reset"], 10, 64); err == nil {
+			return positive(time.Unix(reset, 0).Sub(now)), true
+		}
+	}
+
+	// A forbidden response without rate limit headers is an authorization failure
+	if code == http.StatusTooManyRequests {
+		return defaultRetryAfter, true
+	}
+	return 0, false
+}
+
+func positive(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
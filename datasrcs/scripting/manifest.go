@@ -0,0 +1,84 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/requests"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Manifest describes the capabilities of a loaded data source script.
+type Manifest struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	APIVersion int      `json:"api_version"`
+	Requires   []string `json:"requires,omitempty"`
+	Events     []*Event `json:"events"`
+	// The number of seconds between requests, or zero when the script sets no rate limit
+	RateLimit int `json:"rate_limit"`
+}
+
+// Event identifies a request type handled by a script callback.
+type Event struct {
+	Callback string `json:"callback"`
+	Request  string `json:"request"`
+	Priority int    `json:"priority"`
+}
+
+// The callbacks that are dispatched requests, along with the request type handled by each.
+var eventCallbacks = []struct {
+	name string
+	req  interface{}
+	cb   func(*callbacks) lua.LValue
+}{
+	{"vertical", &requests.DNSRequest{}, func(c *callbacks) lua.LValue { return c.Vertical }},
+	{"horizontal", &requests.WhoisRequest{}, func(c *callbacks) lua.LValue { return c.Horizontal }},
+	{"address", &requests.AddrRequest{}, func(c *callbacks) lua.LValue { return c.Address }},
+	{"asn", &requests.ASNRequest{}, func(c *callbacks) lua.LValue { return c.Asn }},
+	{"resolved", &requests.ResolvedRequest{}, func(c *callbacks) lua.LValue { return c.Resolved }},
+	{"subdomain", &requests.SubdomainRequest{}, func(c *callbacks) lua.LValue { return c.Subdomain }},
+	{"registrant", &requests.RegistrantRequest{}, func(c *callbacks) lua.LValue { return c.Registrant }},
+	{"organization", &requests.OrganizationRequest{}, func(c *callbacks) lua.LValue { return c.Organization }},
+}
+
+// Manifest returns the capabilities of the script. The rate limit is only known after the
+// script has been started, and priority provides the scheduling weight of each request type
+// that has not been overridden by the 'plugins' section.
+func (s *Script) Manifest(priority func(req interface{}) int) *Manifest {
+	s.cbsLock.Lock()
+	defer s.cbsLock.Unlock()
+
+	m := &Manifest{
+		Name:       s.String(),
+		Type:       s.SourceType,
+		APIVersion: APIVersion,
+		Requires:   s.requires,
+		RateLimit:  s.seconds,
+	}
+	if s.version > 0 {
+		m.APIVersion = s.version
+	}
+
+	for _, ec := range eventCallbacks {
+		if ec.cb(s.cbs).Type() == lua.LTNil {
+			continue
+		}
+
+		e := &Event{
+			Callback: ec.name,
+			Request:  strings.TrimPrefix(fmt.Sprintf("%T", ec.req), "*requests."),
+		}
+		if weight, found := s.Priority(ec.req); found {
+			e.Priority = weight
+		} else if priority != nil {
+			e.Priority = priority(ec.req)
+		}
+		m.Events = append(m.Events, e)
+	}
+	return m
+}
@@ -0,0 +1,53 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	script := `
+name="manifest"
+type="testing"
+api_version=1
+requires={"request", "json"}
+
+function start()
+	set_rate_limit(3)
+end
+
+function vertical(ctx, domain)
+end
+
+function resolved(ctx, name, domain, records)
+end
+`
+	srv, sys := setupMockScriptEnv(script)
+	if srv == nil || sys == nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	m := srv.(*Script).Manifest(func(req interface{}) int { return 5 })
+	if m.Name != "manifest" || m.Type != "testing" || m.APIVersion != 1 {
+		t.Errorf("Unexpected manifest identity: %+v", m)
+	}
+	if m.RateLimit != 3 {
+		t.Errorf("Expected a rate limit of 3 seconds, got %d", m.RateLimit)
+	}
+	if len(m.Requires) != 2 {
+		t.Errorf("Expected two required features, got %v", m.Requires)
+	}
+	if len(m.Events) != 2 {
+		t.Fatalf("Expected two events, got %d", len(m.Events))
+	}
+	if e := m.Events[0]; e.Callback != "vertical" || e.Request != "DNSRequest" || e.Priority != 5 {
+		t.Errorf("Unexpected event: %+v", e)
+	}
+	if e := m.Events[1]; e.Callback != "resolved" || e.Request != "ResolvedRequest" {
+		t.Errorf("Unexpected event: %+v", e)
+	}
+}
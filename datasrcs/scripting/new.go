@@ -7,10 +7,13 @@ package scripting
 import (
 	"context"
 	"net"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/evidence"
+	"github.com/owasp-amass/amass/v4/findings"
 	amassnet "github.com/owasp-amass/amass/v4/net"
 	amassdns "github.com/owasp-amass/amass/v4/net/dns"
 	"github.com/owasp-amass/amass/v4/net/http"
@@ -39,6 +42,9 @@ func (s *Script) newName(L *lua.LState) int {
 	if ctx, err := extractContext(L.CheckUserData(1)); err == nil && !contextExpired(ctx) {
 		if n := L.CheckString(2); n != "" {
 			if name := s.subre.FindString(n); name != "" {
+				if s.sys.Config().WhichDomain(name) == "" {
+					s.recordError(requests.ScopeRejectedError, name+" is out of scope")
+				}
 				s.newNameWithContext(ctx, name)
 			}
 		}
@@ -173,21 +179,59 @@ func (s *Script) newAddr(L *lua.LState) int {
 	}
 	if ctx, err := extractContext(L.CheckUserData(1)); err == nil && !contextExpired(ctx) {
 		if name := L.CheckString(3); err == nil && name != "" {
-			if domain := s.sys.Config().WhichDomain(name); domain != "" {
-				select {
-				case <-ctx.Done():
-				case <-s.Done():
-				case s.Output() <- &requests.AddrRequest{
-					Address: ip.String(),
-					Domain:  domain,
-				}:
-				}
-			}
+			s.newAddrWithContext(ctx, ip.String(), name)
 		}
 	}
 	return 0
 }
 
+func (s *Script) newAddrWithContext(ctx context.Context, addr, name string) {
+	if domain := s.sys.Config().WhichDomain(name); domain != "" {
+		select {
+		case <-ctx.Done():
+		case <-s.Done():
+		case s.Output() <- &requests.AddrRequest{
+			Address: addr,
+			Domain:  domain,
+		}:
+		}
+	}
+}
+
+// Wrapper so that scripts can send discovered URLs to Amass.
+func (s *Script) newURL(L *lua.LState) int {
+	if ctx, err := extractContext(L.CheckUserData(1)); err == nil && !contextExpired(ctx) {
+		s.newURLWithContext(ctx, L.CheckString(2))
+	}
+	return 0
+}
+
+func (s *Script) newURLWithContext(ctx context.Context, u string) {
+	req := &requests.URLRequest{
+		URL:    strings.TrimSpace(u),
+		Source: s.String(),
+	}
+	if !req.Valid() {
+		return
+	}
+
+	parsed, _ := url.Parse(req.URL)
+	host := strings.ToLower(parsed.Hostname())
+	if ip := net.ParseIP(host); ip != nil {
+		if !s.sys.Config().IsAddressInScope(ip.String()) {
+			return
+		}
+	} else if req.Domain = s.sys.Config().WhichDomain(host); req.Domain == "" {
+		return
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-s.Done():
+	case s.Output() <- req:
+	}
+}
+
 // Wrapper so that scripts can send discovered ASNs to Amass.
 func (s *Script) newASN(L *lua.LState) int {
 	if ctx, err := extractContext(L.CheckUserData(1)); err == nil && !contextExpired(ctx) {
@@ -242,6 +286,93 @@ func (s *Script) newASN(L *lua.LState) int {
 	return 0
 }
 
+// Wrapper so that scripts can send the prefixes announced by an autonomous system to Amass.
+func (s *Script) newRoutes(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	if err != nil || contextExpired(ctx) {
+		return 0
+	}
+
+	params := L.CheckTable(2)
+	if params == nil {
+		return 0
+	}
+
+	asn, _ := getNumberField(L, params, "asn")
+	desc, _ := getStringField(L, params, "desc")
+	if asn <= 0 || desc == "" {
+		return 0
+	}
+
+	var prefixes []string
+	if tbl, ok := L.GetField(params, "prefixes").(*lua.LTable); ok {
+		tbl.ForEach(func(_, v lua.LValue) {
+			if _, cidr, err := net.ParseCIDR(v.String()); err == nil {
+				if reserved, _ := amassnet.IsReservedAddress(cidr.IP.String()); !reserved {
+					prefixes = append(prefixes, cidr.String())
+				}
+			}
+		})
+	}
+	if len(prefixes) == 0 {
+		return 0
+	}
+	// Addresses within the announced prefixes no longer need to be looked up
+	s.sys.Cache().Update(&requests.ASNRequest{
+		Address:        strings.Split(prefixes[0], "/")[0],
+		ASN:            int(asn),
+		Prefix:         prefixes[0],
+		AllocationDate: time.Now(),
+		Description:    desc,
+		Netblocks:      prefixes,
+	})
+
+	select {
+	case <-ctx.Done():
+	case <-s.Done():
+	case s.Output() <- &requests.RoutingRequest{
+		ASN:         int(asn),
+		Description: desc,
+		Prefixes:    prefixes,
+		Source:      s.String(),
+	}:
+	}
+	return 0
+}
+
+// Wrapper so that scripts can send the contact that registered a domain to Amass.
+func (s *Script) newRegistrant(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	if err != nil || contextExpired(ctx) {
+		return 0
+	}
+
+	params := L.CheckTable(2)
+	if params == nil {
+		return 0
+	}
+
+	domain, _ := getStringField(L, params, "domain")
+	email, _ := getStringField(L, params, "email")
+	org, _ := getStringField(L, params, "organization")
+	req := &requests.RegistrantRequest{
+		Domain:       strings.ToLower(strings.TrimSpace(domain)),
+		Email:        strings.ToLower(strings.TrimSpace(email)),
+		Organization: strings.TrimSpace(org),
+		Source:       s.String(),
+	}
+	if !req.Valid() || s.sys.Config().WhichDomain(req.Domain) == "" {
+		return 0
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-s.Done():
+	case s.Output() <- req:
+	}
+	return 0
+}
+
 // Wrapper so that scripts can send discovered associated domains to Amass.
 func (s *Script) associated(L *lua.LState) int {
 	if ctx, err := extractContext(L.CheckUserData(1)); err == nil && !contextExpired(ctx) {
@@ -258,3 +389,83 @@ func (s *Script) associated(L *lua.LState) int {
 	}
 	return 0
 }
+
+// Wrapper so that scripts can report findings about discovered assets.
+func (s *Script) newFinding(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	if err != nil || contextExpired(ctx) {
+		return 0
+	}
+
+	params := L.CheckTable(2)
+	if params == nil {
+		return 0
+	}
+
+	ftype, _ := getStringField(L, params, "type")
+	asset, _ := getStringField(L, params, "asset")
+	desc, _ := getStringField(L, params, "description")
+	sevstr, _ := getStringField(L, params, "severity")
+	if ftype == "" || asset == "" {
+		return 0
+	}
+
+	sev, err := findings.ParseSeverity(sevstr)
+	if err != nil {
+		sev = findings.Info
+	}
+
+	var details map[string]string
+	if tbl, ok := L.GetField(params, "details").(*lua.LTable); ok {
+		details = make(map[string]string)
+		tbl.ForEach(func(k, v lua.LValue) {
+			details[k.String()] = v.String()
+		})
+	}
+
+	if _, err := s.sys.Findings().Add(&findings.Finding{
+		Type:        ftype,
+		Asset:       asset,
+		Severity:    sev,
+		Description: desc,
+		Source:      s.String(),
+		Details:     details,
+	}); err != nil {
+		s.sys.Config().Log.Printf("%s: new_finding: %v", s.String(), err)
+	}
+	return 0
+}
+
+// Wrapper so that scripts can keep the raw data backing their findings, such as an RDAP object.
+// The identifier returned is referenced by the 'evidence_id' detail of the finding, and is empty
+// when the evidence is discarded or exceeds the size limits.
+func (s *Script) storeEvidence(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	params := L.CheckTable(2)
+	if err != nil || params == nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString("proper parameters were not provided"))
+		return 2
+	}
+
+	kind, _ := getStringField(L, params, "kind")
+	asset, _ := getStringField(L, params, "asset")
+	ctype, _ := getStringField(L, params, "content_type")
+	content, _ := getStringField(L, params, "content")
+	if kind == "" || asset == "" || content == "" || contextExpired(ctx) {
+		L.Push(lua.LNil)
+		L.Push(lua.LString("the kind, asset and content fields must be provided"))
+		return 2
+	}
+
+	id, err := s.sys.Evidence().Put(kind, asset, s.String(), ctype, []byte(content))
+	if err != nil && err != evidence.ErrLimit {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+
+	L.Push(lua.LString(id))
+	L.Push(lua.LNil)
+	return 2
+}
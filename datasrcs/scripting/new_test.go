@@ -5,11 +5,15 @@
 package scripting
 
 import (
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/caffix/stringset"
+	"github.com/owasp-amass/amass/v4/evidence"
+	"github.com/owasp-amass/amass/v4/findings"
 	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
 	"github.com/owasp-amass/config/config"
 )
 
@@ -216,3 +220,150 @@ func TestAssociated(t *testing.T) {
 		}
 	}
 }
+
+func TestNewFinding(t *testing.T) {
+	store, err := findings.NewStore(filepath.Join(t.TempDir(), "findings.json"))
+	if err != nil {
+		t.Fatalf("Failed to create the findings store: %v", err)
+	}
+
+	sys := newMockSystem(config.NewConfig())
+	defer func() { _ = sys.Shutdown() }()
+	sys.(*systems.SimpleSystem).Store = store
+
+	script := NewScript(`
+		name="finding"
+		type="testing"
+
+		function vertical(ctx, domain)
+			new_finding(ctx, {
+				['type']="test_finding",
+				['asset']=domain,
+				['severity']="high",
+				['description']="testing",
+			})
+			new_name(ctx, domain)
+		end
+	`, sys)
+	if script == nil || sys.AddAndStart(script) != nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+
+	domain := "owasp.org"
+	sys.Config().AddDomain(domain)
+	script.Input() <- &requests.DNSRequest{Domain: domain}
+
+	select {
+	case <-script.Output():
+	case <-time.After(10 * time.Second):
+		t.Fatal("The script did not finish the callback")
+	}
+
+	all, err := store.All()
+	if err != nil || len(all) != 1 {
+		t.Fatalf("Expected one finding, got %d: %v", len(all), err)
+	}
+	if f := all[0]; f.Type != "test_finding" || f.Asset != domain || f.Severity != findings.High || f.Source != "finding" {
+		t.Errorf("Unexpected finding: %+v", f)
+	}
+}
+
+func TestStoreEvidence(t *testing.T) {
+	store, err := findings.NewStore(filepath.Join(t.TempDir(), "findings.json"))
+	if err != nil {
+		t.Fatalf("Failed to create the findings store: %v", err)
+	}
+	dir := filepath.Join(t.TempDir(), evidence.Dir)
+	blobs, err := evidence.NewStore(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("Failed to create the evidence store: %v", err)
+	}
+
+	sys := newMockSystem(config.NewConfig())
+	defer func() { _ = sys.Shutdown() }()
+	sys.(*systems.SimpleSystem).Store = store
+	sys.(*systems.SimpleSystem).Blobs = blobs
+
+	script := NewScript(`
+		name="evidence"
+		type="testing"
+
+		function vertical(ctx, domain)
+			local id, err = store_evidence(ctx, {
+				['kind']="rdap",
+				['asset']=domain,
+				['content_type']="application/rdap+json",
+				['content']='{"objectClassName":"domain"}',
+			})
+			if (err ~= nil and err ~= "") then
+				return
+			end
+
+			new_finding(ctx, {
+				['type']="test_finding",
+				['asset']=domain,
+				['severity']="info",
+				['details']={['evidence_id']=id},
+			})
+			new_name(ctx, domain)
+		end
+	`, sys)
+	if script == nil || sys.AddAndStart(script) != nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+
+	domain := "owasp.org"
+	sys.Config().AddDomain(domain)
+	script.Input() <- &requests.DNSRequest{Domain: domain}
+
+	select {
+	case <-script.Output():
+	case <-time.After(10 * time.Second):
+		t.Fatal("The script did not finish the callback")
+	}
+
+	all, err := store.All()
+	if err != nil || len(all) != 1 {
+		t.Fatalf("Expected one finding, got %d: %v", len(all), err)
+	}
+	recs, data, err := evidence.Read(dir, all[0].Details[evidence.DetailKey])
+	if err != nil || string(data) != `{"objectClassName":"domain"}` {
+		t.Fatalf("The finding did not reference the evidence: %v", err)
+	}
+	if r := recs[0]; r.Kind != evidence.RDAP || r.Asset != domain || r.Source != "evidence" || r.ContentType != "application/rdap+json" {
+		t.Errorf("Unexpected evidence record: %+v", r)
+	}
+}
+
+func TestNewURL(t *testing.T) {
+	sys := newMockSystem(config.NewConfig())
+	defer func() { _ = sys.Shutdown() }()
+
+	script := NewScript(`
+		name="url"
+		type="testing"
+
+		function vertical(ctx, domain)
+			new_url(ctx, "https://example.com/")
+			new_url(ctx, "mailto:admin@" .. domain)
+			new_url(ctx, "https://www." .. domain .. "/login?next=/")
+		end
+	`, sys)
+	if script == nil || sys.AddAndStart(script) != nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+
+	domain := "owasp.org"
+	sys.Config().AddDomain(domain)
+	script.Input() <- &requests.DNSRequest{Domain: domain}
+
+	select {
+	case req := <-script.Output():
+		u, ok := req.(*requests.URLRequest)
+		if !ok || u.URL != "https://www.owasp.org/login?next=/" || u.Domain != domain || u.Source != "url" {
+			t.Errorf("Unexpected output: %+v", req)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("The script did not send the in-scope URL")
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	lua "github.com/yuin/gopher-lua"
+	"golang.org/x/net/publicsuffix"
+)
+
+// CandidateDomainFinding is the finding type used for the root domains registered to the same
+// organization as a target domain, which are left out of the scope until an analyst reviews them.
+const CandidateDomainFinding = "candidate_domain"
+
+// Wrapper so that scripts can submit a root domain registered to the organization of a target domain.
+// Unlike the associated domains, the candidate is not added to the scope, and is only recorded as a
+// finding pending the review of an analyst.
+func (s *Script) candidateDomain(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	if err != nil || contextExpired(ctx) {
+		return 0
+	}
+
+	params := L.CheckTable(2)
+	if params == nil {
+		return 0
+	}
+
+	name, _ := getStringField(L, params, "domain")
+	org, _ := getStringField(L, params, "organization")
+	related, _ := getStringField(L, params, "related")
+	org = strings.TrimSpace(org)
+	domain, err := publicsuffix.EffectiveTLDPlusOne(strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), ".")))
+	if err != nil || org == "" {
+		return 0
+	}
+
+	cfg := s.sys.Config()
+	if cfg.WhichDomain(domain) != "" || cfg.Blacklisted(domain) {
+		return 0
+	}
+
+	details := map[string]string{
+		"organization": org,
+		"review":       "pending",
+	}
+	desc := fmt.Sprintf("The domain is registered to %s", org)
+	if related != "" {
+		details["related"] = related
+		desc += fmt.Sprintf(", the registrant of %s", related)
+	}
+
+	if _, err := s.sys.Findings().Add(&findings.Finding{
+		Type:        CandidateDomainFinding,
+		Asset:       domain,
+		Severity:    findings.Info,
+		Description: desc,
+		Source:      s.String(),
+		Details:     details,
+	}); err != nil {
+		cfg.Log.Printf("%s: candidate_domain: %v", s.String(), err)
+	}
+	return 0
+}
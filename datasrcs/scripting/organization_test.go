@@ -0,0 +1,70 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestCandidateDomain(t *testing.T) {
+	store, err := findings.NewStore(filepath.Join(t.TempDir(), "findings.json"))
+	if err != nil {
+		t.Fatalf("Failed to create the findings store: %v", err)
+	}
+
+	sys := newMockSystem(config.NewConfig())
+	defer func() { _ = sys.Shutdown() }()
+	sys.(*systems.SimpleSystem).Store = store
+
+	script := `
+		name="org"
+		type="testing"
+
+		function organization(ctx, org, domain)
+			for _, name in pairs({"www.owasp.org", "OWASP.NET.", "mail.owasp.net", "invalid"}) do
+				candidate_domain(ctx, {
+					['domain']=name,
+					['organization']=org,
+					['related']=domain,
+				})
+			end
+		end
+	`
+	s := NewScript(script, sys)
+	if s == nil || sys.AddAndStart(s) != nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+
+	sys.Config().AddDomain("owasp.org")
+	req := &requests.OrganizationRequest{Name: "OWASP Foundation", Domain: "owasp.org"}
+	if !s.HandlesReq(req) || s.HandlesReq(&requests.OrganizationRequest{Name: " ", Domain: "owasp.org"}) {
+		t.Fatal("The organization requests were not selected correctly")
+	}
+	s.Input() <- req
+
+	var all []*findings.Finding
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if all, err = store.All(); err == nil && len(all) > 0 {
+			break
+		}
+	}
+	if len(all) != 1 {
+		t.Fatalf("Expected one candidate domain, got %d: %v", len(all), err)
+	}
+	if f := all[0]; f.Type != CandidateDomainFinding || f.Asset != "owasp.net" ||
+		f.Details["organization"] != "OWASP Foundation" || f.Details["related"] != "owasp.org" || f.Details["review"] != "pending" {
+		t.Errorf("Unexpected finding: %+v", f)
+	}
+	if sys.Config().IsDomainInScope("owasp.net") {
+		t.Error("The candidate domain was added to the scope")
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// PassiveDNSFinding is the finding type used for the historical resolutions of the in-scope names
+// provided by the passive DNS data sources.
+const PassiveDNSFinding = "passive_dns_resolution"
+
+// PassiveDNSEnrichment is the value of the 'enrichment' global declared by the passive DNS data sources.
+const PassiveDNSEnrichment = "passive_dns"
+
+// DefaultPassiveDNSMaxAge is the age of the oldest resolutions used, unless the 'max_age' option of
+// the 'passive_dns' section provides another.
+const DefaultPassiveDNSMaxAge = 365 * 24 * time.Hour
+
+// PassiveDNSSettings are provided for a passive DNS data source by the 'passive_dns' section of the
+// configuration, which enables the enrichment of the names and addresses discovered.
+type PassiveDNSSettings struct {
+	// Names enables the lookups of the historical resolutions of the resolved names
+	Names bool
+	// Addresses enables the lookups of the names co-hosted on the discovered addresses
+	Addresses bool
+	// MaxAge keeps the resolutions last seen before this period from being used
+	MaxAge time.Duration
+}
+
+// PassiveDNSFromConfig returns the settings of the named passive DNS data source from the 'passive_dns'
+// section of the configuration. The names are matched without regard to case, and nil is returned when
+// the data source has no entry in the section, which leaves the enrichment disabled.
+func PassiveDNSFromConfig(cfg *config.Config, name string) (*PassiveDNSSettings, error) {
+	var raw interface{}
+	for k, v := range optionsSection(cfg, "passive_dns") {
+		if strings.EqualFold(k, name) {
+			raw = v
+			break
+		}
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	settings, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("passive_dns %s is not a map[string]interface{}", name)
+	}
+
+	pdns := &PassiveDNSSettings{
+		Names:     true,
+		Addresses: true,
+		MaxAge:    DefaultPassiveDNSMaxAge,
+	}
+	for key, ptr := range map[string]*bool{"names": &pdns.Names, "addresses": &pdns.Addresses} {
+		if v, found := settings[key]; found {
+			b, ok := v.(bool)
+			if !ok {
+				return nil, fmt.Errorf("passive_dns %s %s is not a bool", name, key)
+			}
+			*ptr = b
+		}
+	}
+	if v, found := settings["max_age"]; found {
+		days, ok := v.(int)
+		if !ok || days < 1 {
+			return nil, fmt.Errorf("passive_dns %s max_age must be a positive number of days", name)
+		}
+		pdns.MaxAge = time.Duration(days) * 24 * time.Hour
+	}
+	return pdns, nil
+}
+
+// scriptEnrichment returns the kind of enrichment declared by the script, which is empty for
+// the data sources without the 'enrichment' global.
+func (s *Script) scriptEnrichment() (string, error) {
+	lv := s.luaState.GetGlobal("enrichment")
+
+	if lv.Type() == lua.LTNil {
+		return "", nil
+	}
+	str, ok := lv.(lua.LString)
+	if !ok || string(str) != PassiveDNSEnrichment {
+		return "", fmt.Errorf("the script global 'enrichment' must be %q", PassiveDNSEnrichment)
+	}
+	return string(str), nil
+}
+
+// enriches returns true when the script receives the request, which the passive DNS data sources only
+// do for the names and addresses enabled by the 'passive_dns' section of the configuration.
+func (s *Script) enriches(req interface{}) bool {
+	if s.enrichment == "" {
+		return true
+	}
+	if s.pdns == nil {
+		return false
+	}
+
+	switch req.(type) {
+	case *requests.ResolvedRequest:
+		return s.pdns.Names
+	case *requests.AddrRequest:
+		return s.pdns.Addresses
+	}
+	return true
+}
+
+// Wrapper so that scripts can obtain the passive DNS settings of the data source. The table provides
+// the 'names' and 'addresses' enabled, and the 'since' Unix time of the oldest resolutions to use.
+func (s *Script) passiveDNSConfig(L *lua.LState) int {
+	if s.pdns == nil {
+		L.Push(lua.LNil)
+		return 1
+	}
+
+	tb := L.NewTable()
+	tb.RawSetString("names", lua.LBool(s.pdns.Names))
+	tb.RawSetString("addresses", lua.LBool(s.pdns.Addresses))
+	tb.RawSetString("since", lua.LNumber(time.Now().Add(-s.pdns.MaxAge).Unix()))
+	L.Push(tb)
+	return 1
+}
+
+// Wrapper so that scripts can submit a historical resolution provided by a passive DNS service,
+// with the Unix times it was first and last seen. The in-scope names are sent to Amass, along with
+// the addresses they resolved to, and the resolution is recorded as a finding for the timelines.
+func (s *Script) newPassiveDNS(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	if err != nil || contextExpired(ctx) {
+		return 0
+	}
+
+	params := L.CheckTable(2)
+	if params == nil {
+		return 0
+	}
+
+	rrname, _ := getStringField(L, params, "rrname")
+	rrtype, _ := getStringField(L, params, "rrtype")
+	rdata, _ := getStringField(L, params, "rdata")
+	rrname = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(rrname), "."))
+	rrtype = strings.ToUpper(strings.TrimSpace(rrtype))
+	rdata = strings.TrimSuffix(strings.TrimSpace(rdata), ".")
+	if rrname == "" || rrtype == "" || rdata == "" {
+		return 0
+	}
+
+	first, _ := getNumberField(L, params, "first_seen")
+	last, _ := getNumberField(L, params, "last_seen")
+	count, _ := getNumberField(L, params, "count")
+	if last < first {
+		last = first
+	}
+	if s.pdns != nil && last > 0 && time.Unix(int64(last), 0).Before(time.Now().Add(-s.pdns.MaxAge)) {
+		return 0
+	}
+
+	inScope := s.sys.Config().WhichDomain(rrname) != ""
+	if inScope {
+		s.newNameWithContext(ctx, rrname)
+	}
+	switch rrtype {
+	case "A", "AAAA":
+		if ip := net.ParseIP(rdata); ip != nil && inScope {
+			if reserved, _ := amassnet.IsReservedAddress(ip.String()); !reserved {
+				s.newAddrWithContext(ctx, ip.String(), rrname)
+			}
+		}
+	default:
+		s.internalSendNames(ctx, rdata)
+	}
+	if !inScope {
+		return 0
+	}
+
+	details := passiveDNSDetails(rrname, rrtype, rdata, int64(first), int64(last), int(count))
+	desc := fmt.Sprintf("The name resolved to the %s record %s", rrtype, rdata)
+	if f, found := details["first_seen"]; found {
+		desc += fmt.Sprintf(" between %s and %s", f, details["last_seen"])
+	}
+
+	if _, err := s.sys.Findings().Add(&findings.Finding{
+		Type:        PassiveDNSFinding,
+		Asset:       rrname + " " + rrtype + " " + rdata,
+		Severity:    findings.Info,
+		Description: desc,
+		Source:      s.String(),
+		Details:     details,
+	}); err != nil {
+		s.sys.Config().Log.Printf("%s: new_passive_dns: %v", s.String(), err)
+	}
+	return 0
+}
+
+func passiveDNSDetails(rrname, rrtype, rdata string, first, last int64, count int) map[string]string {
+	details := map[string]string{
+		"name":   rrname,
+		"rrtype": rrtype,
+		"rdata":  rdata,
+	}
+
+	if first > 0 {
+		details["first_seen"] = time.Unix(first, 0).UTC().Format(time.RFC3339)
+		details["last_seen"] = time.Unix(last, 0).UTC().Format(time.RFC3339)
+	}
+	if count > 0 {
+		details["count"] = strconv.Itoa(count)
+	}
+	return details
+}
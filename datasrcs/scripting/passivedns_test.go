@@ -0,0 +1,129 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestPassiveDNSFromConfig(t *testing.T) {
+	tests := []struct {
+		settings interface{}
+		valid    bool
+	}{
+		{map[string]interface{}{}, true},
+		{map[string]interface{}{"names": true, "addresses": false, "max_age": 30}, true},
+		{"enabled", false},
+		{map[string]interface{}{"names": "yes"}, false},
+		{map[string]interface{}{"max_age": 0}, false},
+	}
+
+	for _, test := range tests {
+		cfg := config.NewConfig()
+		cfg.Options["passive_dns"] = map[string]interface{}{"DNSDB": test.settings}
+
+		if _, err := PassiveDNSFromConfig(cfg, "dnsdb"); (err == nil) != test.valid {
+			t.Errorf("Expected the settings %v to be valid: %t, got the error %v", test.settings, test.valid, err)
+		}
+	}
+
+	if pdns, err := PassiveDNSFromConfig(config.NewConfig(), "DNSDB"); err != nil || pdns != nil {
+		t.Errorf("The enrichment was enabled without the passive_dns section: %+v", pdns)
+	}
+}
+
+func TestPassiveDNSEnrichment(t *testing.T) {
+	store, err := findings.NewStore(filepath.Join(t.TempDir(), "findings.json"))
+	if err != nil {
+		t.Fatalf("Failed to create the findings store: %v", err)
+	}
+
+	cfg := config.NewConfig()
+	cfg.Options["passive_dns"] = map[string]interface{}{
+		"pdns": map[string]interface{}{"addresses": false, "max_age": 30},
+	}
+	sys := newMockSystem(cfg)
+	defer func() { _ = sys.Shutdown() }()
+	sys.(*systems.SimpleSystem).Store = store
+
+	script := `
+		name="pdns"
+		type="testing"
+		enrichment="passive_dns"
+
+		function resolved(ctx, name, domain, records)
+			local pdns = passive_dns_config()
+			new_passive_dns(ctx, {
+				['rrname']="www." .. domain .. ".",
+				['rrtype']="a",
+				['rdata']="93.184.216.34",
+				['first_seen']=pdns.since + 60,
+				['last_seen']=pdns.since + 3600,
+				['count']=42,
+			})
+			new_passive_dns(ctx, {
+				['rrname']="old." .. domain,
+				['rrtype']="A",
+				['rdata']="93.184.216.35",
+				['first_seen']=pdns.since - 7200,
+				['last_seen']=pdns.since - 3600,
+			})
+			new_passive_dns(ctx, {
+				['rrname']="cdn.example.com",
+				['rrtype']="CNAME",
+				['rdata']="mail." .. domain .. ".",
+			})
+		end
+
+		function address(ctx, addr)
+		end
+	`
+	s := NewScript(script, sys)
+	if s == nil || sys.AddAndStart(s) != nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+
+	domain := "owasp.org"
+	sys.Config().AddDomain(domain)
+	resolved := &requests.ResolvedRequest{
+		Name:    domain,
+		Domain:  domain,
+		Records: []requests.DNSAnswer{{Name: domain, Type: 1, Data: "104.22.27.77"}},
+	}
+	if !s.HandlesReq(resolved) || s.HandlesReq(&requests.AddrRequest{Address: "104.22.27.77", Domain: domain}) {
+		t.Error("The requests were not selected by the passive_dns section")
+	}
+
+	cfg.Options["passive_dns"] = map[string]interface{}{}
+	if other := NewScript(script, sys); other == nil || other.HandlesReq(resolved) {
+		t.Error("The data source without an entry in the passive_dns section received the resolved name")
+	}
+
+	s.Input() <- resolved
+	// The in-scope name and address, followed by the name found in the record of the out-of-scope name
+	for i := 0; i < 3; i++ {
+		select {
+		case <-s.Output():
+		case <-time.After(10 * time.Second):
+			t.Fatal("The script did not send the names and addresses of the resolutions")
+		}
+	}
+
+	all, err := store.All()
+	if err != nil || len(all) != 1 {
+		t.Fatalf("Expected one finding, got %d: %v", len(all), err)
+	}
+	if f := all[0]; f.Type != PassiveDNSFinding || f.Asset != "www.owasp.org A 93.184.216.34" ||
+		f.Details["count"] != "42" || f.Details["first_seen"] == "" {
+		t.Errorf("Unexpected finding: %+v", f)
+	}
+}
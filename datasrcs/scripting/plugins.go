@@ -0,0 +1,165 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// MaxPluginInstances is the largest number of Lua states allowed to execute the callbacks of a script.
+const MaxPluginInstances = 32
+
+// PluginSettings are the overrides of a data source script provided by the 'plugins' section
+// of the configuration.
+type PluginSettings struct {
+	// Disabled keeps the script from being loaded
+	Disabled bool
+	// MaxInstances is the number of Lua states executing the callbacks of the script concurrently
+	MaxInstances int
+	// Priorities are the scheduling weights of the requests, keyed by the name of the callback
+	Priorities map[string]int
+	// Options are provided to the 'start' callback of the script
+	Options map[string]interface{}
+	// Timeout is the time allowed for each execution of the callbacks receiving requests
+	Timeout time.Duration
+}
+
+// PluginSettingsFromConfig returns the overrides of the named data source script from the 'plugins'
+// section of the configuration. The names are matched without regard to case, and the defaults are
+// returned when the script has no entry in the section.
+func PluginSettingsFromConfig(cfg *config.Config, name string) (*PluginSettings, error) {
+	ps := &PluginSettings{
+		MaxInstances: 1,
+		Timeout:      DefaultCallbackTimeout,
+	}
+
+	var raw interface{}
+	for k, v := range optionsSection(cfg, "plugins") {
+		if strings.EqualFold(k, name) {
+			raw = v
+			break
+		}
+	}
+	if raw == nil {
+		return ps, nil
+	}
+
+	settings, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("plugins %s is not a map[string]interface{}", name)
+	}
+
+	if v, found := settings["enabled"]; found {
+		enabled, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("plugins %s enabled is not a bool", name)
+		}
+		ps.Disabled = !enabled
+	}
+
+	if v, found := settings["max_instances"]; found {
+		n, ok := v.(int)
+		if !ok || n < 1 || n > MaxPluginInstances {
+			return nil, fmt.Errorf("plugins %s max_instances must be an integer from 1 to %d", name, MaxPluginInstances)
+		}
+		ps.MaxInstances = n
+	}
+
+	if v, found := settings["priority"]; found {
+		priorities, err := pluginPriorities(name, v)
+		if err != nil {
+			return nil, err
+		}
+		ps.Priorities = priorities
+	}
+
+	if v, found := settings["options"]; found {
+		options, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("plugins %s options is not a map[string]interface{}", name)
+		}
+		ps.Options = options
+	}
+
+	if v, found := settings["timeout"]; found {
+		secs, ok := v.(int)
+		if !ok || secs < 1 {
+			return nil, fmt.Errorf("plugins %s timeout must be a positive number of seconds", name)
+		}
+		ps.Timeout = time.Duration(secs) * time.Second
+	}
+	return ps, nil
+}
+
+// pluginPriorities accepts a single weight applied to all the callbacks, or a map of callback
+// names to their weights.
+func pluginPriorities(name string, raw interface{}) (map[string]int, error) {
+	priorities := make(map[string]int)
+
+	if weight, ok := raw.(int); ok {
+		if weight < 1 {
+			return nil, fmt.Errorf("plugins %s priority must be a positive integer", name)
+		}
+		for _, ec := range eventCallbacks {
+			priorities[ec.name] = weight
+		}
+		return priorities, nil
+	}
+
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("plugins %s priority is not an integer or a map[string]interface{}", name)
+	}
+	for cb, v := range m {
+		if !isEventCallback(cb) {
+			return nil, fmt.Errorf("plugins %s priority %s is not a callback receiving requests", name, cb)
+		}
+		weight, ok := v.(int)
+		if !ok || weight < 1 {
+			return nil, fmt.Errorf("plugins %s priority %s must be a positive integer", name, cb)
+		}
+		priorities[cb] = weight
+	}
+	return priorities, nil
+}
+
+func isEventCallback(name string) bool {
+	for _, ec := range eventCallbacks {
+		if ec.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Priority returns the scheduling weight of the request set by the 'plugins' section of the
+// configuration, and false when the weight of the request has not been overridden.
+func (s *Script) Priority(req interface{}) (int, bool) {
+	if s.plugin == nil || len(s.plugin.Priorities) == 0 {
+		return 0, false
+	}
+
+	for _, ec := range eventCallbacks {
+		if fmt.Sprintf("%T", ec.req) == fmt.Sprintf("%T", req) {
+			weight, found := s.plugin.Priorities[ec.name]
+			return weight, found
+		}
+	}
+	return 0, false
+}
+
+// startOptions returns the options of the 'plugins' section provided to the 'start' callback,
+// which is an empty table when the script has no options.
+func (s *Script) startOptions(L *lua.LState) lua.LValue {
+	if s.plugin == nil || s.plugin.Options == nil {
+		return L.NewTable()
+	}
+	return toLuaValue(L, s.plugin.Options)
+}
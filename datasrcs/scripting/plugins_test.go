@@ -0,0 +1,137 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestPluginSettingsFromConfig(t *testing.T) {
+	tests := []struct {
+		settings interface{}
+		valid    bool
+	}{
+		{map[string]interface{}{"enabled": false}, true},
+		{map[string]interface{}{"max_instances": 4, "priority": 2}, true},
+		{map[string]interface{}{"priority": map[string]interface{}{"vertical": 8, "subdomain": 1}}, true},
+		{map[string]interface{}{"options": map[string]interface{}{"depth": 2}}, true},
+		{map[string]interface{}{"timeout": 60}, true},
+		{"disabled", false},
+		{map[string]interface{}{"enabled": "no"}, false},
+		{map[string]interface{}{"max_instances": 0}, false},
+		{map[string]interface{}{"max_instances": MaxPluginInstances + 1}, false},
+		{map[string]interface{}{"priority": map[string]interface{}{"start": 2}}, false},
+		{map[string]interface{}{"priority": map[string]interface{}{"vertical": -1}}, false},
+		{map[string]interface{}{"options": []interface{}{"depth"}}, false},
+		{map[string]interface{}{"timeout": 0}, false},
+		{map[string]interface{}{"timeout": "1m"}, false},
+	}
+
+	for _, test := range tests {
+		cfg := config.NewConfig()
+		cfg.Options["plugins"] = map[string]interface{}{"Crtsh": test.settings}
+
+		if _, err := PluginSettingsFromConfig(cfg, "crtsh"); (err == nil) != test.valid {
+			t.Errorf("Expected the settings %v to be valid: %t, got the error %v", test.settings, test.valid, err)
+		}
+	}
+
+	ps, err := PluginSettingsFromConfig(config.NewConfig(), "Crtsh")
+	if err != nil || ps.Disabled || ps.MaxInstances != 1 || ps.Priorities != nil || ps.Timeout != DefaultCallbackTimeout {
+		t.Errorf("Unexpected defaults without the plugins section: %+v", ps)
+	}
+}
+
+type syncBuffer struct {
+	sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.String()
+}
+
+func TestPluginOverrides(t *testing.T) {
+	script := `
+name="overridden"
+type="testing"
+
+local label
+
+function start(options)
+	label = options.label
+end
+
+function vertical(ctx, domain)
+	log(ctx, label .. "." .. domain)
+end
+`
+	buf := new(syncBuffer)
+	cfg := config.NewConfig()
+	cfg.Log = log.New(buf, "", 0)
+	cfg.Options["plugins"] = map[string]interface{}{
+		"Overridden": map[string]interface{}{
+			"max_instances": 3,
+			"priority":      map[string]interface{}{"vertical": 2},
+			"options":       map[string]interface{}{"label": "www"},
+		},
+	}
+
+	sys := newMockSystem(cfg)
+	defer func() { _ = sys.Shutdown() }()
+
+	s := NewScript(script, sys)
+	if s == nil {
+		t.Fatal("Failed to load the script")
+	}
+	if err := sys.AddAndStart(s); err != nil {
+		t.Fatalf("Failed to start the script: %v", err)
+	}
+	if s.instances != 3 {
+		t.Errorf("Expected 3 instances of the script, got %d", s.instances)
+	}
+	if weight, found := s.Priority(&requests.DNSRequest{}); !found || weight != 2 {
+		t.Errorf("Expected the overridden weight of the vertical callback, got %d", weight)
+	}
+	if _, found := s.Priority(&requests.SubdomainRequest{}); found {
+		t.Error("The weight of a callback without an override was reported")
+	}
+
+	domains := []string{"owasp.org", "example.com", "utica.edu", "example.org"}
+	for _, d := range domains {
+		s.Input() <- &requests.DNSRequest{Domain: d}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) && strings.Count(buf.String(), "overridden: www.") < len(domains) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	for _, d := range domains {
+		if !strings.Contains(buf.String(), "overridden: www."+d) {
+			t.Errorf("The request for %s was not handled with the options of the plugins section", d)
+		}
+	}
+
+	cfg.Options["plugins"] = map[string]interface{}{"overridden": map[string]interface{}{"enabled": false}}
+	if NewScript(script, sys) != nil {
+		t.Error("The script disabled by the plugins section was loaded")
+	}
+}
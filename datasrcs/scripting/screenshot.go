@@ -0,0 +1,69 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+
+	"github.com/owasp-amass/amass/v4/screenshots"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Wrapper so that scripts can capture a screenshot and the title of a web page rendered by the headless browser.
+func (s *Script) screenshot(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString("No user data parameter or context expired"))
+		return 2
+	}
+
+	url := L.CheckString(2)
+	if url == "" {
+		L.Push(lua.LNil)
+		L.Push(lua.LString("No URL was provided"))
+		return 2
+	}
+
+	entry, err := s.capture(ctx, url)
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+
+	dir := screenshots.Dir(s.sys.Config())
+	tb := L.NewTable()
+	tb.RawSetString("url", lua.LString(entry.URL))
+	tb.RawSetString("title", lua.LString(entry.Title))
+	tb.RawSetString("path", lua.LString(filepath.Join(dir, entry.Path)))
+	L.Push(tb)
+	L.Push(lua.LNil)
+	return 2
+}
+
+func (s *Script) capture(ctx context.Context, url string) (*screenshots.Entry, error) {
+	b := s.sys.Browser()
+	if b == nil {
+		return nil, errors.New("the headless browser is not enabled")
+	}
+	if !s.sys.Budget().SpendHTTP(s.String()) {
+		return nil, errors.New("the HTTP request budget has been exhausted")
+	}
+
+	if err := s.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	c, err := b.Screenshot(ctx, url)
+	if err != nil {
+		if cfg := s.sys.Config(); cfg.Verbose {
+			cfg.Log.Printf("%s: %s: %v", s.String(), url, err)
+		}
+		return nil, err
+	}
+	return screenshots.Save(screenshots.Dir(s.sys.Config()), c.URL, c.Title, s.String(), c.Image)
+}
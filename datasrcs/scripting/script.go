@@ -9,29 +9,54 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/caffix/queue"
 	"github.com/caffix/service"
 	luaurl "github.com/cjoudrey/gluaurl"
+	"github.com/owasp-amass/amass/v4/brute"
 	"github.com/owasp-amass/amass/v4/net/dns"
+	"github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/net/whois"
+	"github.com/owasp-amass/amass/v4/ngram"
+	"github.com/owasp-amass/amass/v4/quota"
 	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/settings"
+	"github.com/owasp-amass/amass/v4/shared"
 	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/amass/v4/throttle"
 	"github.com/owasp-amass/config/config"
 	lua "github.com/yuin/gopher-lua"
 	luajson "layeh.com/gopher-json"
 )
 
+// APIVersion is the version of the scripting interface offered to data source scripts, which is
+// incremented whenever the functions offered to the scripts change.
+const APIVersion = 2
+
 // Script callback functions
 type callbacks struct {
-	Start      lua.LValue
-	Stop       lua.LValue
-	Check      lua.LValue
-	Vertical   lua.LValue
-	Horizontal lua.LValue
-	Address    lua.LValue
-	Asn        lua.LValue
-	Resolved   lua.LValue
-	Subdomain  lua.LValue
+	Start        lua.LValue
+	Stop         lua.LValue
+	Check        lua.LValue
+	Validate     lua.LValue
+	Vertical     lua.LValue
+	Horizontal   lua.LValue
+	Address      lua.LValue
+	Asn          lua.LValue
+	Resolved     lua.LValue
+	Subdomain    lua.LValue
+	Registrant   lua.LValue
+	Organization lua.LValue
+	Shared       lua.LValue
+}
+
+// instance is a Lua state executing the callbacks of the script.
+type instance struct {
+	L   *lua.LState
+	cbs *callbacks
 }
 
 // Script is the Service that handles access to the Script data source.
@@ -46,50 +71,145 @@ type Script struct {
 	cbs        *callbacks
 	cbsLock    sync.Mutex
 	subre      *regexp.Regexp
+	guesser    *ngram.Model
+	resume     *brute.Resume
+	bruteID    string
 	seconds    int
-	ctx        context.Context
-	cancel     context.CancelFunc
+	limiter    *throttle.Limiter
+	version    int
+	requires   []string
+	session    *http.Session
+	source     string
+	plugin     *PluginSettings
+	enrichment string
+	pdns       *PassiveDNSSettings
+	// The instances available to execute the callbacks, when the 'plugins' section requests several
+	pool       chan *instance
+	instances  int
+	resumeLock sync.Mutex
+	subsLock   sync.Mutex
+	topics     map[string]bool
+	handlers   *handlerStats
+	failures   errorStats
+	// The context of the session using the data source, canceling the callbacks along with it
+	bound     context.Context
+	boundLock sync.Mutex
+	// Entries of the subscribed topics waiting for the 'shared' callback
+	sharedQueue queue.Queue
+	unsubs      []func()
+	ctx         context.Context
+	cancel      context.CancelFunc
+	// The RDAP entities fetched by the script, so the registry contacts are fetched once
+	entities *whois.EntityResolver
 }
 
+// ErrCheckFailed is returned by the start of the scripts whose 'check' callback rejected the
+// configuration, such as when the API key of the data source has not been provided.
+var ErrCheckFailed = errors.New("check callback failed for the configuration")
+
+// ErrKeyRejected is returned by the start of the scripts whose API key was rejected by the data source
+// while being validated.
+var ErrKeyRejected = errors.New("the API key was rejected")
+
+// The time allowed for the 'validate' callback of a script.
+const validateTimeout = 30 * time.Second
+
 // NewScript returns the object initialized, but not yet started.
 func NewScript(script string, sys systems.System) *Script {
-	re, err := regexp.Compile(dns.AnySubdomainRegexString())
+	s, err := LoadScript(script, sys)
 	if err != nil {
+		sys.Config().Log.Printf("Script: %v", err)
 		return nil
 	}
+	return s
+}
 
-	s := &Script{
-		start:    make(chan struct{}, 1),
-		startRet: make(chan error, 1),
-		stop:     make(chan struct{}, 1),
-		sys:      sys,
-		subre:    re,
+// LoadScript returns the object initialized, but not yet started, or the reason the script could
+// not be loaded. A nil Script and error are returned when the type of the data source has been
+// disabled by the selected profile, or the data source by the 'plugins' section.
+func LoadScript(script string, sys systems.System) (*Script, error) {
+	re, err := regexp.Compile(dns.AnySubdomainRegexString())
+	if err != nil {
+		return nil, err
 	}
+
+	s := &Script{
+		start:       make(chan struct{}, 1),
+		startRet:    make(chan error, 1),
+		stop:        make(chan struct{}, 1),
+		sys:         sys,
+		source:      script,
+		subre:       re,
+		guesser:     ngram.NewModel(guesserOrder),
+		sharedQueue: queue.NewQueue(),
+		handlers:    newHandlerStats(),
+	}
+	s.entities = whois.NewEntityResolver(rdapEntityWorkers, s.fetchEntity)
 	s.ctx, s.cancel = context.WithCancel(context.Background())
 	L := s.newLuaState(sys.Config())
+	s.luaState = L
+	discard := func() {
+		s.cancel()
+		L.Close()
+	}
 
 	// Load the script
 	if err := L.DoString(script); err != nil {
-		sys.Config().Log.Printf("Script: Failed to load the %s script: %v", script, err)
-		return nil
+		discard()
+		return nil, fmt.Errorf("failed to load the script: %v", err)
 	}
 	// Pull the script name from the script
 	name, err := s.scriptName()
 	if err != nil {
-		sys.Config().Log.Printf("Script: Failed to obtain the %s script name: %v", script, err)
-		return nil
+		discard()
+		return nil, fmt.Errorf("failed to obtain the script name: %v", err)
 	}
 	// Pull the script type from the script
 	s.SourceType, err = s.scriptType()
 	if err != nil {
-		sys.Config().Log.Printf("Script: Failed to obtain the %s script type: %v", script, err)
-		return nil
+		discard()
+		return nil, fmt.Errorf("failed to obtain the %s script type: %v", name, err)
+	}
+	// Data sources of the types disabled by the selected profile are not registered
+	if p, err := settings.ProfileFromConfig(sys.Config()); err == nil && !p.Allows(s.SourceType) {
+		discard()
+		return nil, nil
+	}
+	// Data sources disabled by the 'plugins' section are not registered
+	s.plugin, err = PluginSettingsFromConfig(sys.Config(), name)
+	if err != nil {
+		discard()
+		return nil, fmt.Errorf("the %s script cannot be loaded: %v", name, err)
+	} else if s.plugin.Disabled {
+		discard()
+		return nil, nil
+	}
+	// Passive DNS data sources only enrich the names and addresses enabled by the 'passive_dns' section
+	if s.enrichment, err = s.scriptEnrichment(); err != nil {
+		discard()
+		return nil, fmt.Errorf("the %s script cannot be loaded: %v", name, err)
+	} else if s.enrichment == PassiveDNSEnrichment {
+		if s.pdns, err = PassiveDNSFromConfig(sys.Config(), name); err != nil {
+			discard()
+			return nil, fmt.Errorf("the %s script cannot be loaded: %v", name, err)
+		}
+	}
+	// Check that this version of the engine supports the script
+	if err := s.checkRequirements(); err != nil {
+		discard()
+		return nil, fmt.Errorf("the %s script cannot be loaded: %v", name, err)
+	}
+	// Obtain the headers and cookies configured for the requests of the script
+	s.session, err = httpSession(sys.Config(), name)
+	if err != nil {
+		discard()
+		return nil, fmt.Errorf("the %s script cannot be loaded: %v", name, err)
 	}
 
 	s.BaseService = *service.NewBaseService(s, name)
 	s.assignCallbacks()
 	go s.requests()
-	return s
+	return s, nil
 }
 
 // Setup the Lua state with desired constraints and access to necessary functionality.
@@ -101,7 +221,6 @@ func (s *Script) newLuaState(cfg *config.Config) *lua.LState {
 		RegistryMaxSize:     1024 * 100,
 		RegistryGrowStep:    32,
 	})
-	s.luaState = L
 
 	registerSocketType(L)
 	L.PreloadModule("url", luaurl.Loader)
@@ -109,8 +228,12 @@ func (s *Script) newLuaState(cfg *config.Config) *lua.LState {
 	L.SetGlobal("config", L.NewFunction(s.config))
 	L.SetGlobal("datasrc_config", L.NewFunction(s.dataSourceConfig))
 	L.SetGlobal("brute_wordlist", L.NewFunction(s.bruteWordlist))
+	L.SetGlobal("brute_progress", L.NewFunction(s.bruteProgress))
 	L.SetGlobal("alt_wordlist", L.NewFunction(s.altWordlist))
+	L.SetGlobal("train_guesser", L.NewFunction(s.trainGuesser))
+	L.SetGlobal("guess_labels", L.NewFunction(s.guessLabels))
 	L.SetGlobal("log", L.NewFunction(s.log))
+	L.SetGlobal("report_error", L.NewFunction(s.reportError))
 	L.SetGlobal("find", L.NewFunction(s.find))
 	L.SetGlobal("submatch", L.NewFunction(s.submatch))
 	L.SetGlobal("mtime", L.NewFunction(s.modDateTime))
@@ -118,19 +241,49 @@ func (s *Script) newLuaState(cfg *config.Config) *lua.LState {
 	L.SetGlobal("send_names", L.NewFunction(s.sendNames))
 	L.SetGlobal("send_dns_records", L.NewFunction(s.sendDNSRecords))
 	L.SetGlobal("new_addr", L.NewFunction(s.newAddr))
+	L.SetGlobal("new_url", L.NewFunction(s.newURL))
+	L.SetGlobal("new_archived_url", L.NewFunction(s.newArchivedURL))
+	L.SetGlobal("new_passive_dns", L.NewFunction(s.newPassiveDNS))
+	L.SetGlobal("passive_dns_config", L.NewFunction(s.passiveDNSConfig))
 	L.SetGlobal("new_asn", L.NewFunction(s.newASN))
+	L.SetGlobal("new_routes", L.NewFunction(s.newRoutes))
+	L.SetGlobal("new_registrant", L.NewFunction(s.newRegistrant))
 	L.SetGlobal("associated", L.NewFunction(s.associated))
+	L.SetGlobal("candidate_domain", L.NewFunction(s.candidateDomain))
+	L.SetGlobal("tracking_id_domain", L.NewFunction(s.trackingIDDomain))
+	L.SetGlobal("new_finding", L.NewFunction(s.newFinding))
+	L.SetGlobal("send_code_leaks", L.NewFunction(s.sendCodeLeaks))
+	L.SetGlobal("store_evidence", L.NewFunction(s.storeEvidence))
 	L.SetGlobal("in_scope", L.NewFunction(s.inScope))
 	L.SetGlobal("request", L.NewFunction(s.request))
 	L.SetGlobal("scrape", L.NewFunction(s.scrape))
+	L.SetGlobal("browse", L.NewFunction(s.browse))
+	L.SetGlobal("screenshot", L.NewFunction(s.screenshot))
 	L.SetGlobal("crawl", L.NewFunction(s.crawl))
 	L.SetGlobal("resolve", L.NewFunction(s.resolve))
 	L.SetGlobal("reverse_sweep", L.NewFunction(s.reverseSweep))
 	L.SetGlobal("zone_walk", L.NewFunction(s.zoneWalk))
 	L.SetGlobal("zone_transfer", L.NewFunction(s.wrapZoneTransfer))
+	L.SetGlobal("query_server", L.NewFunction(s.queryServer))
 	L.SetGlobal("output_dir", L.NewFunction(s.outputdir))
+	L.SetGlobal("dataset", L.NewFunction(s.dataset))
+	L.SetGlobal("favicon_hash", L.NewFunction(faviconHash))
+	L.SetGlobal("sha256", L.NewFunction(sha256Hex))
+	L.SetGlobal("body_hash", L.NewFunction(bodyHash))
+	L.SetGlobal("parked_page", L.NewFunction(parkedPage))
+	L.SetGlobal("script_sources", L.NewFunction(scriptSources))
+	L.SetGlobal("js_endpoints", L.NewFunction(jsEndpoints))
+	L.SetGlobal("tracking_ids", L.NewFunction(trackingIDs))
+	L.SetGlobal("publish", L.NewFunction(s.publish))
+	L.SetGlobal("get_shared", L.NewFunction(s.getShared))
+	L.SetGlobal("subscribe", L.NewFunction(s.subscribe))
+	L.SetGlobal("whois", L.NewFunction(s.whois))
+	L.SetGlobal("rdap_server", L.NewFunction(s.rdapServer))
+	L.SetGlobal("rdap_reverse_search", L.NewFunction(s.rdapReverseSearch))
+	L.SetGlobal("rdap_entities", L.NewFunction(s.rdapEntities))
 	L.SetGlobal("set_rate_limit", L.NewFunction(s.setRateLimit))
 	L.SetGlobal("check_rate_limit", L.NewFunction(s.checkRateLimit))
+	L.SetGlobal("retry_after", L.NewFunction(s.retryAfter))
 	L.SetGlobal("subdomain_regex", lua.LString(dns.AnySubdomainRegexString()))
 	return L
 }
@@ -140,17 +293,24 @@ func (s *Script) assignCallbacks() {
 	s.cbsLock.Lock()
 	defer s.cbsLock.Unlock()
 
-	L := s.luaState
-	s.cbs = &callbacks{
-		Start:      L.GetGlobal("start"),
-		Stop:       L.GetGlobal("stop"),
-		Check:      L.GetGlobal("check"),
-		Vertical:   L.GetGlobal("vertical"),
-		Horizontal: L.GetGlobal("horizontal"),
-		Address:    L.GetGlobal("address"),
-		Asn:        L.GetGlobal("asn"),
-		Resolved:   L.GetGlobal("resolved"),
-		Subdomain:  L.GetGlobal("subdomain"),
+	s.cbs = loadCallbacks(s.luaState)
+}
+
+func loadCallbacks(L *lua.LState) *callbacks {
+	return &callbacks{
+		Start:        L.GetGlobal("start"),
+		Stop:         L.GetGlobal("stop"),
+		Check:        L.GetGlobal("check"),
+		Validate:     L.GetGlobal("validate"),
+		Vertical:     L.GetGlobal("vertical"),
+		Horizontal:   L.GetGlobal("horizontal"),
+		Address:      L.GetGlobal("address"),
+		Asn:          L.GetGlobal("asn"),
+		Resolved:     L.GetGlobal("resolved"),
+		Subdomain:    L.GetGlobal("subdomain"),
+		Registrant:   L.GetGlobal("registrant"),
+		Organization: L.GetGlobal("organization"),
+		Shared:       L.GetGlobal("shared"),
 	}
 }
 
@@ -180,6 +340,63 @@ func (s *Script) scriptType() (string, error) {
 	return "", errors.New("the script global 'type' is not a string")
 }
 
+// Verifies that the API version and features required by the script are offered by the engine.
+func (s *Script) checkRequirements() error {
+	L := s.luaState
+
+	if lv := L.GetGlobal("api_version"); lv.Type() != lua.LTNil {
+		num, ok := lv.(lua.LNumber)
+		if !ok {
+			return errors.New("the script global 'api_version' is not a number")
+		}
+		if v := int(num); v > APIVersion {
+			return fmt.Errorf("the script requires API version %d, but the engine offers version %d", v, APIVersion)
+		}
+		s.version = int(num)
+	}
+
+	lv := L.GetGlobal("requires")
+	if lv.Type() == lua.LTNil {
+		return nil
+	}
+
+	tbl, ok := lv.(*lua.LTable)
+	if !ok {
+		return errors.New("the script global 'requires' is not a table")
+	}
+
+	var missing []string
+	tbl.ForEach(func(_, v lua.LValue) {
+		feature := v.String()
+
+		s.requires = append(s.requires, feature)
+		if !s.supportsFeature(feature) {
+			missing = append(missing, feature)
+		}
+	})
+	if len(missing) > 0 {
+		return fmt.Errorf("the engine is too old for the required features: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Checks for a function, table or preloaded module with the feature name in the Lua state.
+func (s *Script) supportsFeature(feature string) bool {
+	L := s.luaState
+
+	switch L.GetGlobal(feature).Type() {
+	case lua.LTFunction, lua.LTTable:
+		return true
+	}
+
+	if pkg, ok := L.GetGlobal("package").(*lua.LTable); ok {
+		if preload, ok := L.GetField(pkg, "preload").(*lua.LTable); ok {
+			return L.GetField(preload, feature).Type() == lua.LTFunction
+		}
+	}
+	return false
+}
+
 // Description implements the Service interface.
 func (s *Script) Description() string {
 	return s.SourceType
@@ -209,7 +426,7 @@ func (s *Script) HandlesReq(req interface{}) bool {
 			handles = true
 		}
 	case *requests.ResolvedRequest:
-		if s.cbs.Resolved.Type() != lua.LTNil && t != nil && t.Name != "" && len(t.Records) > 0 {
+		if s.cbs.Resolved.Type() != lua.LTNil && t != nil && t.Name != "" && len(t.Records) > 0 && s.enriches(t) {
 			handles = true
 		}
 	case *requests.SubdomainRequest:
@@ -217,7 +434,7 @@ func (s *Script) HandlesReq(req interface{}) bool {
 			handles = true
 		}
 	case *requests.AddrRequest:
-		if s.cbs.Address.Type() != lua.LTNil && t != nil && t.Address != "" {
+		if s.cbs.Address.Type() != lua.LTNil && t != nil && t.Address != "" && s.enriches(t) {
 			handles = true
 		}
 	case *requests.ASNRequest:
@@ -228,6 +445,14 @@ func (s *Script) HandlesReq(req interface{}) bool {
 		if s.cbs.Horizontal.Type() != lua.LTNil {
 			handles = true
 		}
+	case *requests.RegistrantRequest:
+		if s.cbs.Registrant.Type() != lua.LTNil && t != nil && t.Valid() {
+			handles = true
+		}
+	case *requests.OrganizationRequest:
+		if s.cbs.Organization.Type() != lua.LTNil && t != nil && t.Valid() {
+			handles = true
+		}
 	}
 	return handles
 }
@@ -244,30 +469,137 @@ func (s *Script) requests() {
 		case <-s.stop:
 			s.stopScript()
 		case in := <-s.Input():
-			s.dispatch(in)
+			s.handle(in)
+		case <-s.sharedQueue.Signal():
+			s.sharedQueue.Process(s.handle)
 		}
 	}
 }
 
 func (s *Script) startScript() {
-	if L := s.luaState; s.cbs.Start.Type() != lua.LTNil {
-		err := L.CallByParam(lua.P{
-			Fn:      s.cbs.Start,
-			NRet:    0,
-			Protect: true,
-		})
-		if err != nil {
-			s.sys.Config().Log.Printf("%s: start callback: %v", s.String(), err)
-			s.startRet <- err
-			return
-		}
+	if err := s.callStart(s.luaState, s.cbs); err != nil {
+		s.startRet <- err
+		return
 	}
 
 	if s.seconds > 0 {
 		s.SetRateLimit(1)
 	}
+	// The requests of the script are spaced by the limiter shared with the other sessions
+	opts, err := throttle.FromConfig(s.sys.Config())
+	if err != nil {
+		s.startRet <- err
+		return
+	}
+	s.limiter = throttle.For(s.String())
+	s.limiter.Configure(time.Duration(s.seconds)*time.Second, opts)
+
+	err = s.checkConfig()
+	if err == nil {
+		err = s.validateKey()
+	}
+	if err == nil {
+		s.startInstances()
+	}
+	s.startRet <- err
+}
+
+// validateKey executes the 'validate' callback of the script, which checks the API key with the data
+// source, and records the result with the quota tracker of the system. The callback returns true for
+// an accepted key, false and the reason for a rejected key, and nil when the key could not be checked.
+func (s *Script) validateKey() error {
+	t := s.sys.Quotas()
+	if s.cbs.Validate.Type() == lua.LTNil || !t.ValidateKeys() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, validateTimeout)
+	defer cancel()
+
+	L := s.luaState
+	err := L.CallByParam(lua.P{
+		Fn:      s.cbs.Validate,
+		NRet:    2,
+		Protect: true,
+	}, s.contextToUserData(ctx, L))
+	if err != nil {
+		s.sys.Config().Log.Printf("%s: validate callback: %v", s.String(), err)
+		return nil
+	}
+
+	ret, msg := L.Get(-2), L.Get(-1)
+	L.Pop(2)
+
+	var rejected error
+	accepted, ok := ret.(lua.LBool)
+	if ok && !bool(accepted) {
+		reason := "the data source did not accept the key"
+		if str, isStr := msg.(lua.LString); isStr && str != "" {
+			reason = string(str)
+		}
+		rejected = errors.New(reason)
+	} else if st := t.Status(s.String()); st != nil && st.State == quota.StateInvalid {
+		// The responses received while validating can also reject the key
+		rejected = errors.New(st.Detail)
+	}
+
+	if rejected == nil {
+		if ok {
+			t.Validated(s.String(), nil)
+		}
+		return nil
+	}
+
+	t.Validated(s.String(), rejected)
+	err = fmt.Errorf("%s: %w: %v", s.String(), ErrKeyRejected, rejected)
+	s.sys.Config().Log.Print(err.Error())
+	return err
+}
+
+func (s *Script) callStart(L *lua.LState, cbs *callbacks) error {
+	if cbs.Start.Type() == lua.LTNil {
+		return nil
+	}
 
-	s.startRet <- s.checkConfig()
+	err := L.CallByParam(lua.P{
+		Fn:      cbs.Start,
+		NRet:    0,
+		Protect: true,
+	}, s.startOptions(L))
+	if err != nil {
+		s.sys.Config().Log.Printf("%s: start callback: %v", s.String(), err)
+	}
+	return err
+}
+
+// startInstances loads the additional Lua states requested by the 'max_instances' of the 'plugins'
+// section, which execute the callbacks concurrently with the state of the script. The script keeps
+// executing the callbacks with the instances that started when others fail.
+func (s *Script) startInstances() {
+	if s.plugin == nil || s.plugin.MaxInstances <= 1 {
+		return
+	}
+
+	s.pool = make(chan *instance, s.plugin.MaxInstances)
+	s.pool <- &instance{L: s.luaState, cbs: s.cbs}
+	s.instances = 1
+
+	for i := 1; i < s.plugin.MaxInstances; i++ {
+		L := s.newLuaState(s.sys.Config())
+		if err := L.DoString(s.source); err != nil {
+			s.sys.Config().Log.Printf("%s: failed to load an instance of the script: %v", s.String(), err)
+			L.Close()
+			return
+		}
+
+		inst := &instance{L: L, cbs: loadCallbacks(L)}
+		if err := s.callStart(inst.L, inst.cbs); err != nil {
+			L.Close()
+			return
+		}
+		s.pool <- inst
+		s.instances++
+	}
 }
 
 func (s *Script) checkConfig() error {
@@ -297,87 +629,166 @@ func (s *Script) checkConfig() error {
 		return nil
 	}
 
-	estr := fmt.Sprintf("%s: check callback failed for the configuration", s.String())
-	s.sys.Config().Log.Print(estr)
-	return errors.New(estr)
+	err = fmt.Errorf("%s: %w", s.String(), ErrCheckFailed)
+	s.sys.Config().Log.Print(err.Error())
+	return err
 }
 
 func (s *Script) stopScript() {
 	s.cancel()
-
-	if L := s.luaState; s.cbs.Stop.Type() != lua.LTNil {
-		err := L.CallByParam(lua.P{
-			Fn:      s.cbs.Stop,
-			NRet:    0,
-			Protect: true,
-		})
-		if err != nil {
-			err = fmt.Errorf("%s: stop callback: %v", s.String(), err)
-			s.sys.Config().Log.Print(err.Error())
+	s.subsLock.Lock()
+	for _, unsub := range s.unsubs {
+		unsub()
+	}
+	s.subsLock.Unlock()
+
+	insts := []*instance{{L: s.luaState, cbs: s.cbs}}
+	// The callbacks being executed by the instances finish before their states are closed
+	if s.pool != nil {
+		insts = insts[:0]
+		for i := 0; i < s.instances; i++ {
+			insts = append(insts, <-s.pool)
+		}
+		s.pool = nil
+	}
+
+	for _, inst := range insts {
+		if inst.cbs.Stop.Type() != lua.LTNil {
+			err := inst.L.CallByParam(lua.P{
+				Fn:      inst.cbs.Stop,
+				NRet:    0,
+				Protect: true,
+			})
+			if err != nil {
+				err = fmt.Errorf("%s: stop callback: %v", s.String(), err)
+				s.sys.Config().Log.Print(err.Error())
+			}
 		}
+		inst.L.Close()
 	}
 
-	s.luaState.Close()
 	s.luaState = nil
+	// The positions reached within the wordlist are kept for the next session
+	if err := s.resume.Save(); err != nil {
+		s.sys.Config().Log.Printf("%s: failed to save the brute forcing positions: %v", s.String(), err)
+	}
 }
 
-func (s *Script) dispatch(in interface{}) {
+// handle dispatches the request to the Lua state of the script, or to the first instance becoming
+// available when the 'plugins' section requested several.
+func (s *Script) handle(in interface{}) {
+	if s.pool == nil {
+		s.dispatch(s.luaState, s.cbs, in)
+		return
+	}
+
+	select {
+	case <-s.Done():
+	case <-s.ctx.Done():
+	case inst := <-s.pool:
+		go func() {
+			defer func() { s.pool <- inst }()
+
+			s.dispatch(inst.L, inst.cbs, in)
+		}()
+	}
+}
+
+func (s *Script) dispatch(L *lua.LState, cbs *callbacks, in interface{}) {
 	s.cbsLock.Lock()
 
 	switch req := in.(type) {
 	case *requests.DNSRequest:
-		if s.cbs.Vertical.Type() != lua.LTNil && req != nil && req.Domain != "" {
-			callback := s.cbs.Vertical
+		if cbs.Vertical.Type() != lua.LTNil && req != nil && req.Domain != "" {
+			callback := cbs.Vertical
 			s.cbsLock.Unlock()
 			s.CheckRateLimit()
-			s.dnsRequest(s.ctx, callback, req)
+			s.watch(L, "vertical", func(ctx context.Context) {
+				s.dnsRequest(ctx, L, callback, req)
+			})
 		}
 	case *requests.ResolvedRequest:
-		if s.cbs.Resolved.Type() != lua.LTNil && req != nil && req.Name != "" && len(req.Records) > 0 {
-			callback := s.cbs.Resolved
+		if cbs.Resolved.Type() != lua.LTNil && req != nil && req.Name != "" && len(req.Records) > 0 {
+			callback := cbs.Resolved
 			s.cbsLock.Unlock()
 			s.CheckRateLimit()
-			s.resolvedRequest(s.ctx, callback, req)
+			s.watch(L, "resolved", func(ctx context.Context) {
+				s.resolvedRequest(ctx, L, callback, req)
+			})
 		}
 	case *requests.SubdomainRequest:
-		if s.cbs.Subdomain.Type() != lua.LTNil && req != nil && req.Name != "" {
-			callback := s.cbs.Subdomain
+		if cbs.Subdomain.Type() != lua.LTNil && req != nil && req.Name != "" {
+			callback := cbs.Subdomain
 			s.cbsLock.Unlock()
 			s.CheckRateLimit()
-			s.subdomainRequest(s.ctx, callback, req)
+			s.watch(L, "subdomain", func(ctx context.Context) {
+				s.subdomainRequest(ctx, L, callback, req)
+			})
 		}
 	case *requests.AddrRequest:
-		if s.cbs.Address.Type() != lua.LTNil && req != nil && req.Address != "" {
-			callback := s.cbs.Address
+		if cbs.Address.Type() != lua.LTNil && req != nil && req.Address != "" {
+			callback := cbs.Address
 			s.cbsLock.Unlock()
 			s.CheckRateLimit()
-			s.addrRequest(s.ctx, callback, req)
+			s.watch(L, "address", func(ctx context.Context) {
+				s.addrRequest(ctx, L, callback, req)
+			})
 		}
 	case *requests.ASNRequest:
-		if s.cbs.Asn.Type() != lua.LTNil && req != nil && (req.Address != "" || req.ASN != 0) {
-			callback := s.cbs.Asn
+		if cbs.Asn.Type() != lua.LTNil && req != nil && (req.Address != "" || req.ASN != 0) {
+			callback := cbs.Asn
 			s.cbsLock.Unlock()
 			// check that the cache entry has not already been made by a previous request
 			if s.sys.Cache().AddrSearch(req.Address) == nil {
 				s.CheckRateLimit()
-				s.asnRequest(s.ctx, callback, req)
+				s.watch(L, "asn", func(ctx context.Context) {
+					s.asnRequest(ctx, L, callback, req)
+				})
 			}
 		}
 	case *requests.WhoisRequest:
-		if s.cbs.Horizontal.Type() != lua.LTNil {
-			callback := s.cbs.Horizontal
+		if cbs.Horizontal.Type() != lua.LTNil {
+			callback := cbs.Horizontal
+			s.cbsLock.Unlock()
+			s.CheckRateLimit()
+			s.watch(L, "horizontal", func(ctx context.Context) {
+				s.whoisRequest(ctx, L, callback, req)
+			})
+		}
+	case *requests.RegistrantRequest:
+		if cbs.Registrant.Type() != lua.LTNil && req != nil && req.Valid() {
+			callback := cbs.Registrant
 			s.cbsLock.Unlock()
 			s.CheckRateLimit()
-			s.whoisRequest(s.ctx, callback, req)
+			s.watch(L, "registrant", func(ctx context.Context) {
+				s.registrantRequest(ctx, L, callback, req)
+			})
+		}
+	case *requests.OrganizationRequest:
+		defer req.Finished()
+
+		if cbs.Organization.Type() != lua.LTNil && req != nil && req.Valid() {
+			callback := cbs.Organization
+			s.cbsLock.Unlock()
+			s.CheckRateLimit()
+			s.watch(L, "organization", func(ctx context.Context) {
+				s.organizationRequest(ctx, L, callback, req)
+			})
+		}
+	case *shared.Entry:
+		if cbs.Shared.Type() != lua.LTNil && req != nil {
+			callback := cbs.Shared
+			s.cbsLock.Unlock()
+			s.watch(L, "shared", func(ctx context.Context) {
+				s.sharedEntry(ctx, L, callback, req)
+			})
 		}
 	default:
 		s.cbsLock.Unlock()
 	}
 }
 
-func (s *Script) dnsRequest(ctx context.Context, callback lua.LValue, req *requests.DNSRequest) {
-	L := s.luaState
-
+func (s *Script) dnsRequest(ctx context.Context, L *lua.LState, callback lua.LValue, req *requests.DNSRequest) {
 	if contextExpired(ctx) {
 		return
 	}
@@ -388,15 +799,13 @@ func (s *Script) dnsRequest(ctx context.Context, callback lua.LValue, req *reque
 		Fn:      callback,
 		NRet:    0,
 		Protect: true,
-	}, s.contextToUserData(ctx), lua.LString(req.Domain))
+	}, s.contextToUserData(ctx, L), lua.LString(req.Domain))
 	if err != nil {
 		s.sys.Config().Log.Printf("%s: vertical callback: %v", s.String(), err)
 	}
 }
 
-func (s *Script) resolvedRequest(ctx context.Context, callback lua.LValue, req *requests.ResolvedRequest) {
-	L := s.luaState
-
+func (s *Script) resolvedRequest(ctx context.Context, L *lua.LState, callback lua.LValue, req *requests.ResolvedRequest) {
 	if contextExpired(ctx) {
 		return
 	}
@@ -415,15 +824,13 @@ func (s *Script) resolvedRequest(ctx context.Context, callback lua.LValue, req *
 		Fn:      callback,
 		NRet:    0,
 		Protect: true,
-	}, s.contextToUserData(ctx), lua.LString(req.Name), lua.LString(req.Domain), records)
+	}, s.contextToUserData(ctx, L), lua.LString(req.Name), lua.LString(req.Domain), records)
 	if err != nil {
 		s.sys.Config().Log.Printf("%s: resolved callback: %v", s.String(), err)
 	}
 }
 
-func (s *Script) subdomainRequest(ctx context.Context, callback lua.LValue, req *requests.SubdomainRequest) {
-	L := s.luaState
-
+func (s *Script) subdomainRequest(ctx context.Context, L *lua.LState, callback lua.LValue, req *requests.SubdomainRequest) {
 	if contextExpired(ctx) {
 		return
 	}
@@ -432,15 +839,13 @@ func (s *Script) subdomainRequest(ctx context.Context, callback lua.LValue, req
 		Fn:      callback,
 		NRet:    0,
 		Protect: true,
-	}, s.contextToUserData(ctx), lua.LString(req.Name), lua.LString(req.Domain), lua.LNumber(req.Times))
+	}, s.contextToUserData(ctx, L), lua.LString(req.Name), lua.LString(req.Domain), lua.LNumber(req.Times))
 	if err != nil {
 		s.sys.Config().Log.Printf("%s: subdomain callback: %v", s.String(), err)
 	}
 }
 
-func (s *Script) addrRequest(ctx context.Context, callback lua.LValue, req *requests.AddrRequest) {
-	L := s.luaState
-
+func (s *Script) addrRequest(ctx context.Context, L *lua.LState, callback lua.LValue, req *requests.AddrRequest) {
 	if contextExpired(ctx) {
 		return
 	}
@@ -449,15 +854,13 @@ func (s *Script) addrRequest(ctx context.Context, callback lua.LValue, req *requ
 		Fn:      callback,
 		NRet:    0,
 		Protect: true,
-	}, s.contextToUserData(ctx), lua.LString(req.Address))
+	}, s.contextToUserData(ctx, L), lua.LString(req.Address))
 	if err != nil {
 		s.sys.Config().Log.Printf("%s: address callback: %v", s.String(), err)
 	}
 }
 
-func (s *Script) asnRequest(ctx context.Context, callback lua.LValue, req *requests.ASNRequest) {
-	L := s.luaState
-
+func (s *Script) asnRequest(ctx context.Context, L *lua.LState, callback lua.LValue, req *requests.ASNRequest) {
 	if contextExpired(ctx) {
 		return
 	}
@@ -466,15 +869,13 @@ func (s *Script) asnRequest(ctx context.Context, callback lua.LValue, req *reque
 		Fn:      callback,
 		NRet:    0,
 		Protect: true,
-	}, s.contextToUserData(ctx), lua.LString(req.Address), lua.LNumber(req.ASN))
+	}, s.contextToUserData(ctx, L), lua.LString(req.Address), lua.LNumber(req.ASN))
 	if err != nil {
 		s.sys.Config().Log.Printf("%s: asn callback: %v", s.String(), err)
 	}
 }
 
-func (s *Script) whoisRequest(ctx context.Context, callback lua.LValue, req *requests.WhoisRequest) {
-	L := s.luaState
-
+func (s *Script) whoisRequest(ctx context.Context, L *lua.LState, callback lua.LValue, req *requests.WhoisRequest) {
 	if contextExpired(ctx) {
 		return
 	}
@@ -483,8 +884,38 @@ func (s *Script) whoisRequest(ctx context.Context, callback lua.LValue, req *req
 		Fn:      callback,
 		NRet:    0,
 		Protect: true,
-	}, s.contextToUserData(ctx), lua.LString(req.Domain))
+	}, s.contextToUserData(ctx, L), lua.LString(req.Domain))
 	if err != nil {
 		s.sys.Config().Log.Printf("%s: horizontal callback: %v", s.String(), err)
 	}
 }
+
+func (s *Script) registrantRequest(ctx context.Context, L *lua.LState, callback lua.LValue, req *requests.RegistrantRequest) {
+	if contextExpired(ctx) {
+		return
+	}
+
+	err := L.CallByParam(lua.P{
+		Fn:      callback,
+		NRet:    0,
+		Protect: true,
+	}, s.contextToUserData(ctx, L), lua.LString(req.Domain), lua.LString(req.Email), lua.LString(req.Organization))
+	if err != nil {
+		s.sys.Config().Log.Printf("%s: registrant callback: %v", s.String(), err)
+	}
+}
+
+func (s *Script) organizationRequest(ctx context.Context, L *lua.LState, callback lua.LValue, req *requests.OrganizationRequest) {
+	if contextExpired(ctx) {
+		return
+	}
+
+	err := L.CallByParam(lua.P{
+		Fn:      callback,
+		NRet:    0,
+		Protect: true,
+	}, s.contextToUserData(ctx, L), lua.LString(req.Name), lua.LString(req.Domain))
+	if err != nil {
+		s.sys.Config().Log.Printf("%s: organization callback: %v", s.String(), err)
+	}
+}
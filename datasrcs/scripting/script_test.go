@@ -5,8 +5,12 @@
 package scripting
 
 import (
+	"errors"
+	"testing"
+
 	"github.com/caffix/netmap"
 	"github.com/caffix/service"
+	"github.com/owasp-amass/amass/v4/quota"
 	"github.com/owasp-amass/amass/v4/requests"
 	"github.com/owasp-amass/amass/v4/systems"
 	"github.com/owasp-amass/config/config"
@@ -39,3 +43,70 @@ func newMockSystem(cfg *config.Config) systems.System {
 	_ = ss.Trusted.AddResolvers(20, "8.8.8.8")
 	return ss
 }
+
+func TestScriptRequirements(t *testing.T) {
+	sys := newMockSystem(config.NewConfig())
+	defer func() { _ = sys.Shutdown() }()
+
+	tests := []struct {
+		script   string
+		expected bool
+	}{
+		{"name=\"supported\"\ntype=\"testing\"\napi_version=1\nrequires={\"request\", \"socket\", \"json\"}", true},
+		{"name=\"newer\"\ntype=\"testing\"\napi_version=99", false},
+		{"name=\"missing\"\ntype=\"testing\"\nrequires={\"request\", \"teleport\"}", false},
+		{"name=\"malformed\"\ntype=\"testing\"\nrequires=\"request\"", false},
+	}
+
+	for _, test := range tests {
+		if s := NewScript(test.script, sys); (s != nil) != test.expected {
+			t.Errorf("Expected the script loaded to be %t for: %s", test.expected, test.script)
+		}
+	}
+}
+
+func TestScriptProfile(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Options["profile"] = "normal"
+	sys := newMockSystem(cfg)
+	defer func() { _ = sys.Shutdown() }()
+
+	if s := NewScript("name=\"brute\"\ntype=\"brute\"", sys); s != nil {
+		t.Error("The data source disabled by the profile was registered")
+	}
+	if s := NewScript("name=\"alt\"\ntype=\"alt\"", sys); s == nil {
+		t.Error("The data source allowed by the profile was not registered")
+	}
+}
+
+func TestScriptValidate(t *testing.T) {
+	script := `
+name="validated"
+type="api"
+
+function validate(ctx)
+	return false, "the key has expired"
+end
+`
+	sys := newMockSystem(config.NewConfig())
+	defer func() { _ = sys.Shutdown() }()
+
+	tracker := quota.NewTracker(quota.DefaultReserve, true)
+	sys.(*systems.SimpleSystem).Keys = tracker
+
+	s := NewScript(script, sys)
+	if s == nil {
+		t.Fatal("Failed to load the script")
+	}
+	if err := s.Start(); !errors.Is(err, ErrKeyRejected) {
+		t.Errorf("Expected the API key to be rejected, got %v", err)
+	}
+	if st := tracker.Status("validated"); st == nil || st.State != quota.StateInvalid || st.Detail != "the key has expired" {
+		t.Errorf("The rejected API key was not recorded: %+v", st)
+	}
+
+	sys.(*systems.SimpleSystem).Keys = quota.NewTracker(quota.DefaultReserve, false)
+	if s := NewScript(script, sys); s == nil || s.Start() != nil {
+		t.Error("The API key was validated after disabling the validation")
+	}
+}
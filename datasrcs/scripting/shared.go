@@ -0,0 +1,148 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"context"
+
+	"github.com/owasp-amass/amass/v4/shared"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Wrapper so that scripts can share intermediate analysis with the other data sources. True is returned
+// when the value had not already been published for the key, so the scripts can claim work by publishing.
+func (s *Script) publish(L *lua.LState) int {
+	_, err := extractContext(L.CheckUserData(1))
+	topic := L.CheckString(2)
+	key := L.CheckString(3)
+	if err != nil || topic == "" || key == "" {
+		L.Push(lua.LFalse)
+		return 1
+	}
+
+	L.Push(lua.LBool(s.sys.Shared().Publish(&shared.Entry{
+		Topic:  topic,
+		Key:    key,
+		Value:  fromLuaValue(L.Get(4)),
+		Source: s.String(),
+	})))
+	return 1
+}
+
+// Wrapper so that scripts can obtain the analysis already shared by the data sources.
+func (s *Script) getShared(L *lua.LState) int {
+	_, err := extractContext(L.CheckUserData(1))
+	topic := L.CheckString(2)
+	key := L.CheckString(3)
+	if err != nil || topic == "" || key == "" {
+		L.Push(lua.LNil)
+		return 1
+	}
+
+	e, found := s.sys.Shared().Get(topic, key)
+	if !found {
+		L.Push(lua.LNil)
+		return 1
+	}
+
+	L.Push(toLuaValue(L, e.Value))
+	return 1
+}
+
+// Wrapper so that scripts can receive the analysis shared to a topic by the other data sources.
+// The entries are delivered to the 'shared' callback of the script.
+func (s *Script) subscribe(L *lua.LState) int {
+	topic := L.CheckString(1)
+	if topic == "" {
+		return 0
+	}
+
+	s.subsLock.Lock()
+	defer s.subsLock.Unlock()
+	// Each of the instances executing the callbacks of the script subscribes in its 'start' callback
+	if s.topics[topic] {
+		return 0
+	}
+	if s.topics == nil {
+		s.topics = make(map[string]bool)
+	}
+	s.topics[topic] = true
+
+	unsub := s.sys.Shared().Subscribe(topic, s.String(), func(e *shared.Entry) {
+		s.sharedQueue.Append(e)
+	})
+	s.unsubs = append(s.unsubs, unsub)
+	return 0
+}
+
+func (s *Script) sharedEntry(ctx context.Context, L *lua.LState, callback lua.LValue, e *shared.Entry) {
+	if contextExpired(ctx) {
+		return
+	}
+
+	err := L.CallByParam(lua.P{
+		Fn:      callback,
+		NRet:    0,
+		Protect: true,
+	}, s.contextToUserData(ctx, L), lua.LString(e.Topic), lua.LString(e.Key), toLuaValue(L, e.Value), lua.LString(e.Source))
+	if err != nil {
+		s.sys.Config().Log.Printf("%s: shared callback: %v", s.String(), err)
+	}
+}
+
+// fromLuaValue converts the Lua value into a value that can be shared with other Lua states.
+func fromLuaValue(lv lua.LValue) interface{} {
+	switch v := lv.(type) {
+	case lua.LString:
+		return string(v)
+	case lua.LNumber:
+		return float64(v)
+	case lua.LBool:
+		return bool(v)
+	case *lua.LTable:
+		if n := v.Len(); n > 0 {
+			list := make([]interface{}, 0, n)
+			for i := 1; i <= n; i++ {
+				list = append(list, fromLuaValue(v.RawGetInt(i)))
+			}
+			return list
+		}
+
+		m := make(map[string]interface{})
+		v.ForEach(func(k, val lua.LValue) {
+			if key, ok := k.(lua.LString); ok {
+				m[string(key)] = fromLuaValue(val)
+			}
+		})
+		return m
+	}
+	return nil
+}
+
+func toLuaValue(L *lua.LState, value interface{}) lua.LValue {
+	switch v := value.(type) {
+	case string:
+		return lua.LString(v)
+	case float64:
+		return lua.LNumber(v)
+	case int:
+		return lua.LNumber(v)
+	case bool:
+		return lua.LBool(v)
+	case []interface{}:
+		tb := L.NewTable()
+		for _, item := range v {
+			tb.Append(toLuaValue(L, item))
+		}
+		return tb
+	case map[string]interface{}:
+		tb := L.NewTable()
+		for key, item := range v {
+			tb.RawSetString(key, toLuaValue(L, item))
+		}
+		return tb
+	}
+	return lua.LNil
+}
@@ -0,0 +1,295 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/resources"
+	"github.com/owasp-amass/amass/v4/shared"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestSharedAnalysis(t *testing.T) {
+	sys := newMockSystem(config.NewConfig())
+	defer func() { _ = sys.Shutdown() }()
+	board := shared.NewBoard()
+	sys.(*systems.SimpleSystem).Board = board
+
+	publisher := NewScript(`
+		name="publisher"
+		type="testing"
+
+		function vertical(ctx, domain)
+			publish(ctx, "classification", domain, {['provider']="example", ['ports']={80, 443}})
+		end
+	`, sys)
+	subscriber := NewScript(`
+		name="subscriber"
+		type="testing"
+
+		function start()
+			subscribe("classification")
+		end
+
+		function shared(ctx, topic, key, value, source)
+			local cached = get_shared(ctx, topic, key)
+			if (source == "publisher" and cached ~= nil and cached.provider == value.provider and #value.ports == 2) then
+				new_name(ctx, "www." .. key)
+			end
+		end
+	`, sys)
+	if publisher == nil || subscriber == nil || publisher.Start() != nil || subscriber.Start() != nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+	defer func() { _ = publisher.Stop() }()
+	defer func() { _ = subscriber.Stop() }()
+
+	domain := "owasp.org"
+	sys.Config().AddDomain(domain)
+	publisher.Input() <- &requests.DNSRequest{Domain: domain}
+
+	select {
+	case req := <-subscriber.Output():
+		if dns, ok := req.(*requests.DNSRequest); !ok || dns.Name != "www."+domain {
+			t.Errorf("Unexpected output from the subscriber: %+v", req)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("The subscriber did not receive the shared analysis")
+	}
+
+	e, found := board.Get("classification", domain)
+	if !found || e.Source != "publisher" {
+		t.Fatalf("The shared analysis was not cached: %+v", e)
+	}
+	if v, ok := e.Value.(map[string]interface{}); !ok || v["provider"] != "example" {
+		t.Errorf("Unexpected shared value: %+v", e.Value)
+	}
+}
+
+func TestPublishClaim(t *testing.T) {
+	sys := newMockSystem(config.NewConfig())
+	defer func() { _ = sys.Shutdown() }()
+	sys.(*systems.SimpleSystem).Board = shared.NewBoard()
+
+	script := `
+		type="testing"
+
+		function vertical(ctx, domain)
+			if publish(ctx, "dns_recursion", domain, true) then
+				new_name(ctx, "probed." .. domain)
+			else
+				new_name(ctx, "skipped." .. domain)
+			end
+		end
+	`
+	first := NewScript("name=\"first\"\n"+script, sys)
+	second := NewScript("name=\"second\"\n"+script, sys)
+	if first == nil || second == nil || first.Start() != nil || second.Start() != nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+	defer func() { _ = first.Stop() }()
+	defer func() { _ = second.Stop() }()
+
+	domain := "owasp.org"
+	sys.Config().AddDomain(domain)
+	// Only the first script publishing the value claims the work
+	for _, test := range []struct {
+		src      *Script
+		expected string
+	}{
+		{first, "probed." + domain},
+		{second, "skipped." + domain},
+		{first, "skipped." + domain},
+	} {
+		test.src.Input() <- &requests.DNSRequest{Domain: domain}
+
+		select {
+		case req := <-test.src.Output():
+			if dns, ok := req.(*requests.DNSRequest); !ok || dns.Name != test.expected {
+				t.Errorf("Expected %s from %s, got %+v", test.expected, test.src.String(), req)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("The %s script did not return the result", test.src.String())
+		}
+	}
+}
+
+func TestTakeoverScript(t *testing.T) {
+	store, err := findings.NewStore(filepath.Join(t.TempDir(), "findings.json"))
+	if err != nil {
+		t.Fatalf("Failed to create the findings store: %v", err)
+	}
+
+	sys := newMockSystem(config.NewConfig())
+	defer func() { _ = sys.Shutdown() }()
+	board := shared.NewBoard()
+	sys.(*systems.SimpleSystem).Board = board
+	sys.(*systems.SimpleSystem).Store = store
+
+	f, err := resources.GetResourceFile("scripts/dns/takeover.ads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	script := NewScript(string(data), sys)
+	if script == nil || script.Start() != nil {
+		t.Fatal("Failed to initialize the takeover script")
+	}
+	defer func() { _ = script.Stop() }()
+
+	for name, target := range map[string]string{
+		"app.owasp.org":  "owasp-app.azurewebsites.net.",
+		"docs.owasp.org": "owasp-docs.example.net",
+	} {
+		board.Publish(&shared.Entry{Topic: shared.DanglingCNAME, Key: name, Value: target, Source: "Amass"})
+	}
+
+	var all []*findings.Finding
+	for i := 0; i < 100 && len(all) == 0; i++ {
+		time.Sleep(100 * time.Millisecond)
+		all, _ = store.All()
+	}
+	if len(all) != 1 {
+		t.Fatalf("Expected one takeover finding, got %d", len(all))
+	}
+	if f := all[0]; f.Type != "subdomain_takeover" || f.Asset != "app.owasp.org" || f.Severity != findings.High ||
+		f.Details["service"] != "Microsoft Azure" || f.Details["confidence"] != "90" {
+		t.Errorf("Unexpected finding: %+v", f)
+	}
+}
+
+func TestWellKnownScript(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/security.txt", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("Contact: mailto:Security@owasp.org\nContact: tel:+1-201-555-0123\nPolicy: https://www.owasp.org/policy\n"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	store, err := findings.NewStore(filepath.Join(t.TempDir(), "findings.json"))
+	if err != nil {
+		t.Fatalf("Failed to create the findings store: %v", err)
+	}
+
+	cfg := config.NewConfig()
+	cfg.Active = true
+	sys := newMockSystem(cfg)
+	defer func() { _ = sys.Shutdown() }()
+	board := shared.NewBoard()
+	sys.(*systems.SimpleSystem).Board = board
+	sys.(*systems.SimpleSystem).Store = store
+
+	f, err := resources.GetResourceFile("scripts/crawl/wellknown.ads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	script := NewScript(string(data), sys)
+	if script == nil || script.Start() != nil {
+		t.Fatal("Failed to initialize the well-known files script")
+	}
+	defer func() { _ = script.Stop() }()
+
+	board.Publish(&shared.Entry{
+		Topic:  "web_endpoint",
+		Key:    srv.URL + "/login",
+		Value:  map[string]interface{}{"status_code": 200, "final_url": srv.URL + "/"},
+		Source: "Amass",
+	})
+
+	var all []*findings.Finding
+	for i := 0; i < 100 && len(all) == 0; i++ {
+		time.Sleep(100 * time.Millisecond)
+		all, _ = store.All()
+	}
+	if len(all) != 1 {
+		t.Fatalf("Expected one security contact finding, got %d", len(all))
+	}
+	if f := all[0]; f.Type != "security_contact" || f.Asset != "security@owasp.org" ||
+		f.Details["domain"] != "owasp.org" || f.Details["url"] != srv.URL+"/.well-known/security.txt" {
+		t.Errorf("Unexpected finding: %+v", f)
+	}
+}
+
+func TestJavaScriptScript(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><script src="/static/app.js"></script><script src="https://cdn.example.com/lib.js"></script></html>`))
+	})
+	mux.HandleFunc("/static/app.js", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("fetch(\"/api/v1/users\");\n//# sourceMappingURL=app.js.map"))
+	})
+	mux.HandleFunc("/static/app.js.map", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"version":3,"sourcesContent":["const admin = '/internal/admin';"],"mappings":"AAAA"}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	cfg := config.NewConfig()
+	cfg.Active = true
+	sys := newMockSystem(cfg)
+	defer func() { _ = sys.Shutdown() }()
+	board := shared.NewBoard()
+	sys.(*systems.SimpleSystem).Board = board
+
+	f, err := resources.GetResourceFile("scripts/crawl/javascript.ads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	script := NewScript(string(data), sys)
+	if script == nil || script.Start() != nil {
+		t.Fatal("Failed to initialize the JavaScript endpoints script")
+	}
+	defer func() { _ = script.Stop() }()
+
+	board.Publish(&shared.Entry{
+		Topic:  "web_endpoint",
+		Key:    srv.URL + "/",
+		Value:  map[string]interface{}{"status_code": 200},
+		Source: "Amass",
+	})
+
+	expected := map[string]struct{}{
+		srv.URL + "/api/v1/users":   {},
+		srv.URL + "/internal/admin": {},
+	}
+	for len(expected) > 0 {
+		select {
+		case req := <-script.Output():
+			u, ok := req.(*requests.URLRequest)
+			if !ok {
+				t.Fatalf("Unexpected output: %+v", req)
+			}
+			if _, found := expected[u.URL]; !found {
+				t.Fatalf("Unexpected URL: %s", u.URL)
+			}
+			delete(expected, u.URL)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("The endpoints were not found: %v", expected)
+		}
+	}
+}
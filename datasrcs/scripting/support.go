@@ -18,8 +18,7 @@ type contextWrapper struct {
 }
 
 // Converts Go Context to Lua UserData.
-func (s *Script) contextToUserData(ctx context.Context) *lua.LUserData {
-	L := s.luaState
+func (s *Script) contextToUserData(ctx context.Context, L *lua.LState) *lua.LUserData {
 	ud := L.NewUserData()
 
 	ud.Value = &contextWrapper{Ctx: ctx}
@@ -150,3 +149,12 @@ func getNumberField(L *lua.LState, t lua.LValue, key string) (float64, bool) {
 	}
 	return 0, false
 }
+
+func getBoolField(L *lua.LState, t lua.LValue, key string) (bool, bool) {
+	if lv := L.GetField(t, key); lv != nil {
+		if b, ok := lv.(lua.LBool); ok {
+			return bool(b), true
+		}
+	}
+	return false, false
+}
@@ -0,0 +1,88 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/net/http"
+	lua "github.com/yuin/gopher-lua"
+	"golang.org/x/net/publicsuffix"
+)
+
+// TrackingIDDomainFinding is the finding type used for the root domains sharing a tracking ID with
+// a target host, which are left out of the scope until an analyst reviews them.
+const TrackingIDDomainFinding = "tracking_id_domain"
+
+// Wrapper so that scripts can extract the analytics and advertising tracking IDs from a web page.
+// Each ID is returned as a table providing the 'id' and the 'kind'.
+func trackingIDs(L *lua.LState) int {
+	tb := L.NewTable()
+
+	for _, t := range http.TrackingIDs(L.CheckString(1)) {
+		id := L.NewTable()
+
+		id.RawSetString("id", lua.LString(t.ID))
+		id.RawSetString("kind", lua.LString(t.Kind))
+		tb.Append(id)
+	}
+	L.Push(tb)
+	return 1
+}
+
+// Wrapper so that scripts can submit a root domain found by pivoting on the tracking ID of a target host.
+// Like the candidate domains, the domain is not added to the scope, and is only recorded as a finding
+// pending the review of an analyst.
+func (s *Script) trackingIDDomain(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	if err != nil || contextExpired(ctx) {
+		return 0
+	}
+
+	params := L.CheckTable(2)
+	if params == nil {
+		return 0
+	}
+
+	name, _ := getStringField(L, params, "domain")
+	id, _ := getStringField(L, params, "id")
+	related, _ := getStringField(L, params, "related")
+	id = strings.TrimSpace(id)
+	kind := http.TrackingIDKind(id)
+	domain, err := publicsuffix.EffectiveTLDPlusOne(strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), ".")))
+	if err != nil || kind == "" {
+		return 0
+	}
+
+	cfg := s.sys.Config()
+	if cfg.WhichDomain(domain) != "" || cfg.Blacklisted(domain) {
+		return 0
+	}
+
+	details := map[string]string{
+		"tracking_id":   id,
+		"tracking_kind": kind,
+		"review":        "pending",
+	}
+	desc := fmt.Sprintf("The domain shares the tracking ID %s", id)
+	if related = strings.ToLower(strings.TrimSpace(related)); related != "" {
+		details["related"] = related
+		desc += fmt.Sprintf(" with %s", related)
+	}
+
+	if _, err := s.sys.Findings().Add(&findings.Finding{
+		Type:        TrackingIDDomainFinding,
+		Asset:       domain,
+		Severity:    findings.Info,
+		Description: desc,
+		Source:      s.String(),
+		Details:     details,
+	}); err != nil {
+		cfg.Log.Printf("%s: tracking_id_domain: %v", s.String(), err)
+	}
+	return 0
+}
@@ -0,0 +1,72 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestTrackingIDDomain(t *testing.T) {
+	store, err := findings.NewStore(filepath.Join(t.TempDir(), "findings.json"))
+	if err != nil {
+		t.Fatalf("Failed to create the findings store: %v", err)
+	}
+
+	sys := newMockSystem(config.NewConfig())
+	defer func() { _ = sys.Shutdown() }()
+	sys.(*systems.SimpleSystem).Store = store
+
+	script := `
+		name="tracking"
+		type="testing"
+
+		function vertical(ctx, domain)
+			local ids = tracking_ids("<script>ga('create', 'UA-1234567-2', 'auto');</script>")
+			if (#ids ~= 1 or ids[1].kind ~= "google_analytics") then
+				return
+			end
+
+			for _, name in pairs({"www.owasp.org", "shop.owasp-store.com", "invalid"}) do
+				tracking_id_domain(ctx, {
+					['domain']=name,
+					['id']=ids[1].id,
+					['related']="www." .. domain,
+				})
+			end
+			tracking_id_domain(ctx, {['domain']="owasp.io", ['id']="not-an-id"})
+		end
+	`
+	s := NewScript(script, sys)
+	if s == nil || sys.AddAndStart(s) != nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+
+	sys.Config().AddDomain("owasp.org")
+	s.Input() <- &requests.DNSRequest{Name: "owasp.org", Domain: "owasp.org"}
+
+	var all []*findings.Finding
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if all, err = store.All(); err == nil && len(all) > 0 {
+			break
+		}
+	}
+	if len(all) != 1 {
+		t.Fatalf("Expected one tracking ID domain, got %d: %v", len(all), err)
+	}
+	if f := all[0]; f.Type != TrackingIDDomainFinding || f.Asset != "owasp-store.com" || f.Details["tracking_id"] != "UA-1234567-2" ||
+		f.Details["tracking_kind"] != "google_analytics" || f.Details["related"] != "www.owasp.org" || f.Details["review"] != "pending" {
+		t.Errorf("Unexpected finding: %+v", f)
+	}
+	if sys.Config().IsDomainInScope("owasp-store.com") {
+		t.Error("The domain sharing the tracking ID was added to the scope")
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/throttle"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// DefaultCallbackTimeout is the time allowed for each execution of the callbacks receiving requests,
+// unless the 'timeout' option of the 'plugins' section provides another.
+const DefaultCallbackTimeout = 10 * time.Minute
+
+// HandlerStats provides the executions of a script callback receiving requests.
+type HandlerStats struct {
+	Callback string `json:"callback"`
+	Calls    int    `json:"calls"`
+	// The number of executions taking more than half of the time allowed
+	Slow int `json:"slow"`
+	// The number of executions canceled, since they exceeded the time allowed
+	TimedOut int           `json:"timed_out"`
+	Total    time.Duration `json:"total_ns"`
+	Max      time.Duration `json:"max_ns"`
+}
+
+type handlerStats struct {
+	sync.Mutex
+	callbacks map[string]*HandlerStats
+}
+
+func newHandlerStats() *handlerStats {
+	return &handlerStats{callbacks: make(map[string]*HandlerStats)}
+}
+
+// record adds the execution of the callback and returns true for the first one that timed out.
+func (h *handlerStats) record(callback string, d, timeout time.Duration, timedOut bool) bool {
+	h.Lock()
+	defer h.Unlock()
+
+	hs, found := h.callbacks[callback]
+	if !found {
+		hs = &HandlerStats{Callback: callback}
+		h.callbacks[callback] = hs
+	}
+
+	hs.Calls++
+	hs.Total += d
+	if d > hs.Max {
+		hs.Max = d
+	}
+	if d > timeout/2 {
+		hs.Slow++
+	}
+	if timedOut {
+		hs.TimedOut++
+		return hs.TimedOut == 1
+	}
+	return false
+}
+
+// HandlerStats returns copies of the execution statistics of the script callbacks, sorted by name.
+func (s *Script) HandlerStats() []*HandlerStats {
+	s.handlers.Lock()
+	defer s.handlers.Unlock()
+
+	results := make([]*HandlerStats, 0, len(s.handlers.callbacks))
+	for _, hs := range s.handlers.callbacks {
+		c := *hs
+		results = append(results, &c)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Callback < results[j].Callback
+	})
+	return results
+}
+
+func (s *Script) callbackTimeout() time.Duration {
+	if s.plugin == nil || s.plugin.Timeout <= 0 {
+		return DefaultCallbackTimeout
+	}
+	return s.plugin.Timeout
+}
+
+// BindSession implements the datasrcs.SessionBound interface. The contexts provided to the callbacks
+// are canceled once the session context is done, along with the requests made through them.
+func (s *Script) BindSession(ctx context.Context) {
+	s.boundLock.Lock()
+	defer s.boundLock.Unlock()
+
+	s.bound = ctx
+}
+
+// callbackContext returns the context provided to a callback, which expires after the timeout, when
+// the script is stopped or when the session using the data source is terminated.
+func (s *Script) callbackContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(s.ctx, timeout)
+
+	s.boundLock.Lock()
+	bound := s.bound
+	s.boundLock.Unlock()
+	if bound == nil {
+		return ctx, cancel
+	}
+	// The requests of the callback are granted the share of the session using the data source
+	ctx = throttle.Inherit(ctx, bound)
+
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-bound.Done():
+			cancel()
+		case <-stop:
+		}
+	}()
+	return ctx, func() {
+		close(stop)
+		cancel()
+	}
+}
+
+// watch executes the callback with a context canceled once the time allowed has passed, so a hung
+// request cannot hold up the data source. The Lua state is interrupted at the deadline as well, which
+// stops the scripts looping without making requests.
+func (s *Script) watch(L *lua.LState, callback string, fn func(ctx context.Context)) {
+	timeout := s.callbackTimeout()
+	ctx, cancel := s.callbackContext(timeout)
+	defer cancel()
+
+	L.SetContext(ctx)
+	defer L.RemoveContext()
+
+	start := time.Now()
+	fn(ctx)
+	elapsed := time.Since(start)
+
+	timedOut := errors.Is(ctx.Err(), context.DeadlineExceeded)
+	// Only the first timeout of each callback is logged, and the total is reported at the end
+	if s.handlers.record(callback, elapsed, timeout, timedOut) {
+		s.sys.Config().Log.Printf("%s: the %s callback was canceled after exceeding the timeout of %s",
+			s.String(), callback, timeout)
+	}
+}
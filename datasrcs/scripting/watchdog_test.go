@@ -0,0 +1,118 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"context"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestCallbackTimeout(t *testing.T) {
+	script := `
+name="hung"
+type="testing"
+
+function vertical(ctx, domain)
+	if domain == "owasp.org" then
+		while true do end
+	end
+	log(ctx, "handled " .. domain)
+end
+`
+	buf := new(syncBuffer)
+	cfg := config.NewConfig()
+	cfg.Log = log.New(buf, "", 0)
+	cfg.Options["plugins"] = map[string]interface{}{
+		"hung": map[string]interface{}{"timeout": 1},
+	}
+
+	sys := newMockSystem(cfg)
+	defer func() { _ = sys.Shutdown() }()
+
+	s := NewScript(script, sys)
+	if s == nil {
+		t.Fatal("Failed to load the script")
+	}
+	if err := sys.AddAndStart(s); err != nil {
+		t.Fatalf("Failed to start the script: %v", err)
+	}
+
+	s.Input() <- &requests.DNSRequest{Domain: "owasp.org"}
+	s.Input() <- &requests.DNSRequest{Domain: "example.com"}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) && !strings.Contains(buf.String(), "hung: handled example.com") {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !strings.Contains(buf.String(), "hung: handled example.com") {
+		t.Fatal("The request following the hung callback was not handled")
+	}
+	if !strings.Contains(buf.String(), "exceeding the timeout") {
+		t.Error("The timeout of the callback was not logged")
+	}
+
+	stats := s.HandlerStats()
+	if len(stats) != 1 || stats[0].Callback != "vertical" {
+		t.Fatalf("Unexpected handler statistics: %+v", stats)
+	}
+	if hs := stats[0]; hs.Calls != 2 || hs.TimedOut != 1 || hs.Slow != 1 || hs.Max < time.Second {
+		t.Errorf("The executions of the callback were not recorded: %+v", hs)
+	}
+}
+
+func TestBindSession(t *testing.T) {
+	script := `
+name="bound"
+type="testing"
+
+function vertical(ctx, domain)
+	if domain == "owasp.org" then
+		while true do end
+	end
+	log(ctx, "handled " .. domain)
+end
+`
+	buf := new(syncBuffer)
+	cfg := config.NewConfig()
+	cfg.Log = log.New(buf, "", 0)
+
+	sys := newMockSystem(cfg)
+	defer func() { _ = sys.Shutdown() }()
+
+	s := NewScript(script, sys)
+	if s == nil {
+		t.Fatal("Failed to load the script")
+	}
+	if err := sys.AddAndStart(s); err != nil {
+		t.Fatalf("Failed to start the script: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.BindSession(ctx)
+	s.Input() <- &requests.DNSRequest{Domain: "owasp.org"}
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	// The callbacks of the next session are executed once the terminated session released the script
+	s.BindSession(context.Background())
+	s.Input() <- &requests.DNSRequest{Domain: "example.com"}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) && !strings.Contains(buf.String(), "bound: handled example.com") {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !strings.Contains(buf.String(), "bound: handled example.com") {
+		t.Fatal("The callback was not canceled along with the session")
+	}
+	if hs := s.HandlerStats(); len(hs) != 1 || hs[0].Calls != 2 || hs[0].TimedOut != 0 {
+		t.Errorf("The canceled callback was recorded as a timeout: %+v", hs)
+	}
+}
@@ -0,0 +1,253 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/datasets"
+	"github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/net/whois"
+	"github.com/owasp-amass/config/config"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// rdapEntityWorkers is the number of RDAP entities fetched at once by each script.
+const rdapEntityWorkers = 4
+
+// The RDAP bootstrap registry is shared by all the scripts.
+var bootstrap struct {
+	sync.Mutex
+	reg *whois.Bootstrap
+}
+
+// Wrapper so that scripts can obtain the registration record of a domain using WHOIS.
+func (s *Script) whois(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	name := L.CheckString(2)
+	if err != nil || name == "" {
+		L.Push(lua.LNil)
+		L.Push(lua.LString("proper parameters were not provided"))
+		return 2
+	}
+
+	rec, err := whois.Lookup(ctx, name)
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+
+	tb := L.NewTable()
+	tb.RawSetString("domain", lua.LString(rec.Domain))
+	tb.RawSetString("server", lua.LString(rec.Server))
+	tb.RawSetString("registrar", lua.LString(rec.Registrar))
+	tb.RawSetString("created", luaTime(rec.Created))
+	tb.RawSetString("updated", luaTime(rec.Updated))
+	tb.RawSetString("expires", luaTime(rec.Expires))
+	tb.RawSetString("status", luaStrings(L, rec.Status))
+	tb.RawSetString("name_servers", luaStrings(L, rec.NameServers))
+	for role, c := range map[string]*whois.Contact{
+		"registrant": rec.Registrant,
+		"admin":      rec.Admin,
+		"tech":       rec.Tech,
+	} {
+		if c == nil {
+			continue
+		}
+
+		contact := L.NewTable()
+		contact.RawSetString("name", lua.LString(c.Name))
+		contact.RawSetString("organization", lua.LString(c.Organization))
+		contact.RawSetString("email", lua.LString(c.Email))
+		contact.RawSetString("phone", lua.LString(c.Phone))
+		contact.RawSetString("country", lua.LString(c.Country))
+		tb.RawSetString(role, contact)
+	}
+
+	L.Push(tb)
+	L.Push(lua.LNil)
+	return 2
+}
+
+// Wrapper so that scripts can obtain the RDAP base URL for the TLD of a domain.
+// An empty string is returned when the TLD is not listed by the RDAP bootstrap registry.
+func (s *Script) rdapServer(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	name := L.CheckString(2)
+	if err != nil || name == "" {
+		L.Push(lua.LNil)
+		L.Push(lua.LString("proper parameters were not provided"))
+		return 2
+	}
+
+	reg, err := loadBootstrap(ctx, s.sys.Config())
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+
+	L.Push(lua.LString(reg.Server(name)))
+	L.Push(lua.LNil)
+	return 2
+}
+
+// Wrapper so that scripts can search an RDAP server for the domains registered to an organization,
+// using the reverse search defined by RFC 9536. Servers without the extension return an error.
+func (s *Script) rdapReverseSearch(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	server := L.CheckString(2)
+	org := L.CheckString(3)
+	if err != nil || server == "" || org == "" {
+		L.Push(lua.LNil)
+		L.Push(lua.LString("proper parameters were not provided"))
+		return 2
+	}
+
+	hdr := http.Header{"Accept": "application/rdap+json"}
+	resp, err := s.req(ctx, whois.ReverseSearchURL(server, org), "", hdr, nil)
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	} else if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		L.Push(lua.LNil)
+		L.Push(lua.LString("the reverse search returned with status: " + resp.Status))
+		return 2
+	}
+
+	names, err := whois.ParseReverseSearch(strings.NewReader(resp.Body))
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+
+	L.Push(luaStrings(L, names))
+	L.Push(lua.LNil)
+	return 2
+}
+
+// Wrapper so that scripts can obtain the contacts of a domain from an RDAP server, including the nested
+// entities. The entities referenced by a link are fetched in parallel under the rate limit of the script,
+// and each handle is only fetched once by the script.
+func (s *Script) rdapEntities(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	server := L.CheckString(2)
+	name := L.CheckString(3)
+	if err != nil || server == "" || name == "" {
+		L.Push(lua.LNil)
+		L.Push(lua.LString("proper parameters were not provided"))
+		return 2
+	}
+
+	body, err := s.rdapObject(ctx, whois.DomainURL(server, name))
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+
+	entities, err := s.entities.Entities(ctx, strings.NewReader(body))
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+
+	tb := L.NewTable()
+	for _, e := range entities {
+		ent := L.NewTable()
+		ent.RawSetString("handle", lua.LString(e.Handle))
+		ent.RawSetString("roles", luaStrings(L, e.Roles))
+		ent.RawSetString("name", lua.LString(e.Contact.Name))
+		ent.RawSetString("organization", lua.LString(e.Contact.Organization))
+		ent.RawSetString("email", lua.LString(e.Contact.Email))
+		ent.RawSetString("phone", lua.LString(e.Contact.Phone))
+		ent.RawSetString("country", lua.LString(e.Contact.Country))
+		tb.Append(ent)
+	}
+
+	L.Push(tb)
+	L.Push(lua.LNil)
+	return 2
+}
+
+func (s *Script) fetchEntity(ctx context.Context, link string) ([]byte, error) {
+	body, err := s.rdapObject(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(body), nil
+}
+
+// rdapObject returns the RDAP object found at the URL, waiting for the rate limiter of the script.
+func (s *Script) rdapObject(ctx context.Context, link string) (string, error) {
+	hdr := http.Header{"Accept": "application/rdap+json"}
+
+	resp, err := s.req(ctx, link, "", hdr, nil)
+	if err != nil {
+		return "", err
+	} else if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return "", fmt.Errorf("the RDAP lookup returned with status: %s", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func loadBootstrap(ctx context.Context, cfg *config.Config) (*whois.Bootstrap, error) {
+	bootstrap.Lock()
+	defer bootstrap.Unlock()
+
+	if bootstrap.reg != nil {
+		return bootstrap.reg, nil
+	}
+
+	mgr, err := datasets.FromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := mgr.Get(ctx, "rdap-dns")
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reg, err := whois.ParseBootstrap(file)
+	if err != nil {
+		return nil, err
+	}
+
+	bootstrap.reg = reg
+	return reg, nil
+}
+
+// luaTime returns the Unix time of t, or zero when the time was not provided.
+func luaTime(t time.Time) lua.LNumber {
+	if t.IsZero() {
+		return lua.LNumber(0)
+	}
+	return lua.LNumber(t.Unix())
+}
+
+func luaStrings(L *lua.LState, list []string) *lua.LTable {
+	tb := L.NewTable()
+
+	for _, s := range list {
+		tb.Append(lua.LString(s))
+	}
+	return tb
+}
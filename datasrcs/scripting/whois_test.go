@@ -0,0 +1,92 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestRDAPEntities(t *testing.T) {
+	var lock sync.Mutex
+	hits := make(map[string]int)
+
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		hits[r.URL.Path]++
+		lock.Unlock()
+
+		w.Header().Set("Content-Type", "application/rdap+json")
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/domain/"):
+			ref := `{"handle": "REG-1", "roles": ["%s"], "links": [{"rel": "self", "href": "` + ts.URL + `/entity/REG-1"}]}`
+			fmt.Fprintf(w, `{"ldhName": "%s", "entities": [%s, %s, %s]}`, strings.TrimPrefix(r.URL.Path, "/domain/"),
+				fmt.Sprintf(ref, "registrant"), fmt.Sprintf(ref, "administrative"), fmt.Sprintf(ref, "technical"))
+		case r.URL.Path == "/entity/REG-1":
+			fmt.Fprint(w, `{"handle": "REG-1", "vcardArray": ["vcard", [["org", {}, "text", "OWASP Foundation"]]]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	sys := newMockSystem(config.NewConfig())
+	defer func() { _ = sys.Shutdown() }()
+
+	script := fmt.Sprintf(`
+		name="rdap"
+		type="testing"
+
+		function vertical(ctx, domain)
+			local entities, err = rdap_entities(ctx, "%s", domain)
+			if (err ~= nil and err ~= "") then
+				return
+			end
+
+			for _, ent in pairs(entities) do
+				if (ent.organization == "OWASP Foundation" and #ent.roles == 3) then
+					new_name(ctx, "www." .. domain)
+				end
+			end
+		end
+	`, ts.URL)
+	s := NewScript(script, sys)
+	if s == nil || sys.AddAndStart(s) != nil {
+		t.Fatal("Failed to initialize the scripting environment")
+	}
+
+	// The registry contact referenced by both domains is only fetched once by the script
+	for _, domain := range []string{"owasp.org", "owasp.net"} {
+		sys.Config().AddDomain(domain)
+		s.Input() <- &requests.DNSRequest{Domain: domain}
+
+		select {
+		case req := <-s.Output():
+			if dns, ok := req.(*requests.DNSRequest); !ok || dns.Name != "www."+domain {
+				t.Errorf("Unexpected output from the script: %+v", req)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("The script did not return the entities of %s", domain)
+		}
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if n := hits["/entity/REG-1"]; n != 1 {
+		t.Errorf("The entity was fetched %d times", n)
+	}
+	if hits["/domain/owasp.org"] != 1 || hits["/domain/owasp.net"] != 1 {
+		t.Errorf("Unexpected RDAP lookups: %v", hits)
+	}
+}
@@ -5,6 +5,7 @@
 package datasrcs
 
 import (
+	"context"
 	"sort"
 
 	"github.com/caffix/service"
@@ -62,3 +63,19 @@ func SelectedDataSources(cfg *config.Config, avail []service.Service) []service.
 	})
 	return results
 }
+
+// SessionBound is implemented by the data sources whose callbacks receive a context tied to the
+// session using them.
+type SessionBound interface {
+	BindSession(ctx context.Context)
+}
+
+// BindSession ties the callbacks of the data sources to the lifecycle of the session, so the requests
+// being handled are canceled once the session is terminated.
+func BindSession(ctx context.Context, srcs []service.Service) {
+	for _, src := range srcs {
+		if sb, ok := src.(SessionBound); ok {
+			sb.BindSession(ctx)
+		}
+	}
+}
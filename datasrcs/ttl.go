@@ -0,0 +1,320 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package datasrcs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+// QueryLogFile is the name of the file, within the output directory, recording when the data sources were queried.
+const QueryLogFile = "source_queries.json"
+
+// The kinds of data source requests, which identify the transformation performed by the data sources.
+const (
+	DNSKind          = "dns"
+	ResolvedKind     = "resolved"
+	SubdomainKind    = "subdomain"
+	AddrKind         = "addr"
+	ASNKind          = "asn"
+	WhoisKind        = "whois"
+	RegistrantKind   = "registrant"
+	OrganizationKind = "organization"
+)
+
+var requestKinds = []string{DNSKind, ResolvedKind, SubdomainKind, AddrKind, ASNKind, WhoisKind, RegistrantKind, OrganizationKind}
+
+// RequestKey identifies the asset and the kind of the data source request, separated by a colon.
+// An empty string is returned for the requests that are not keyed.
+func RequestKey(req interface{}) string {
+	var key string
+
+	switch v := req.(type) {
+	case *requests.DNSRequest:
+		key = DNSKind + ":" + v.Name
+	case *requests.ResolvedRequest:
+		key = ResolvedKind + ":" + v.Name
+	case *requests.SubdomainRequest:
+		// The scripts act on specific counts of names seen within the subdomain
+		key = SubdomainKind + ":" + v.Name + ":" + strconv.Itoa(v.Times)
+	case *requests.AddrRequest:
+		key = AddrKind + ":" + v.Address
+	case *requests.ASNRequest:
+		key = ASNKind + ":" + v.Address + ":" + strconv.Itoa(v.ASN)
+	case *requests.WhoisRequest:
+		key = WhoisKind + ":" + v.Domain
+	case *requests.RegistrantRequest:
+		// Each contact is only searched once, regardless of the domain that provided it
+		key = RegistrantKind + ":" + v.Email + ":" + v.Organization
+	case *requests.OrganizationRequest:
+		// Each organization is only searched once, regardless of the domain that provided it
+		key = OrganizationKind + ":" + strings.TrimSpace(v.Name)
+	default:
+		return ""
+	}
+	return strings.ToLower(key)
+}
+
+// TTLs provides the period during which a data source is not queried again for the same asset.
+type TTLs struct {
+	sources map[string]time.Duration
+	kinds   map[string]map[string]time.Duration
+}
+
+// TTLsFromConfig returns the TTLs provided by the 'ttl' of each data source configuration and
+// overridden by the 'source_ttls' section of the configuration options.
+func TTLsFromConfig(cfg *config.Config) (*TTLs, error) {
+	t := &TTLs{
+		sources: make(map[string]time.Duration),
+		kinds:   make(map[string]map[string]time.Duration),
+	}
+
+	if cfg.DataSrcConfigs != nil {
+		for _, ds := range cfg.DataSrcConfigs.Datasources {
+			if ds != nil && ds.TTL > 0 {
+				t.sources[strings.ToLower(ds.Name)] = time.Duration(ds.TTL) * time.Minute
+			}
+		}
+	}
+
+	ttlsRaw, ok := cfg.Options["source_ttls"]
+	if !ok {
+		return t, nil
+	}
+
+	settings, ok := ttlsRaw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("source_ttls is not a map[string]interface{}")
+	}
+
+	for name, raw := range settings {
+		src := strings.ToLower(name)
+
+		switch v := raw.(type) {
+		case string:
+			d, err := parseTTL(name, v)
+			if err != nil {
+				return nil, err
+			}
+			t.sources[src] = d
+		case map[string]interface{}:
+			kinds := make(map[string]time.Duration)
+			for kind, value := range v {
+				kind = strings.ToLower(kind)
+				if !validKind(kind) {
+					return nil, fmt.Errorf("source_ttls %s has the unknown request kind %s, it must be one of %s",
+						name, kind, strings.Join(requestKinds, ", "))
+				}
+
+				str, ok := value.(string)
+				if !ok {
+					return nil, fmt.Errorf("source_ttls %s %s is not a string", name, kind)
+				}
+
+				d, err := parseTTL(name, str)
+				if err != nil {
+					return nil, err
+				}
+				kinds[kind] = d
+			}
+			t.kinds[src] = kinds
+		default:
+			return nil, fmt.Errorf("source_ttls %s must be a duration or a map of request kinds", name)
+		}
+	}
+	return t, nil
+}
+
+func parseTTL(name, str string) (time.Duration, error) {
+	d, err := time.ParseDuration(str)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("source_ttls %s is not a valid duration: %s", name, str)
+	}
+	return d, nil
+}
+
+func validKind(kind string) bool {
+	for _, k := range requestKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// TTL returns the period during which the data source is not queried again for
+// the same asset using the kind of request. A zero duration disables the TTL.
+func (t *TTLs) TTL(source, kind string) time.Duration {
+	if t == nil {
+		return 0
+	}
+
+	src := strings.ToLower(source)
+	if kinds, found := t.kinds[src]; found {
+		if d, found := kinds[kind]; found {
+			return d
+		}
+	}
+	return t.sources[src]
+}
+
+// Enabled returns true when a TTL has been set for any of the data sources.
+func (t *TTLs) Enabled() bool {
+	if t == nil {
+		return false
+	}
+
+	for _, d := range t.sources {
+		if d > 0 {
+			return true
+		}
+	}
+	for _, kinds := range t.kinds {
+		for _, d := range kinds {
+			if d > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// QueryLog records when each data source was queried for an asset, so the queries
+// are not repeated across enumerations until the TTL has expired.
+// All methods are safe to call on a nil QueryLog, which allows every query.
+type QueryLog struct {
+	sync.Mutex
+	path    string
+	ttls    *TTLs
+	queries map[string]map[string]time.Time
+	skipped map[string]int
+}
+
+// NewQueryLog returns a QueryLog enforcing the TTLs and loaded from the file at path, when it exists.
+func NewQueryLog(path string, ttls *TTLs) (*QueryLog, error) {
+	l := &QueryLog{
+		path:    path,
+		ttls:    ttls,
+		queries: make(map[string]map[string]time.Time),
+		skipped: make(map[string]int),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &l.queries); err != nil {
+		return nil, fmt.Errorf("failed to parse the data source query log %s: %v", path, err)
+	}
+	return l, nil
+}
+
+// Fresh returns true when the data source was queried for the asset of the request
+// within the TTL, and the request should not be sent to the data source again.
+func (l *QueryLog) Fresh(source string, req interface{}) bool {
+	if l == nil {
+		return false
+	}
+
+	key := RequestKey(req)
+	if key == "" {
+		return false
+	}
+
+	ttl := l.ttls.TTL(source, requestKind(key))
+	if ttl <= 0 {
+		return false
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	if t, found := l.queries[source][key]; found && time.Since(t) < ttl {
+		l.skipped[source]++
+		return true
+	}
+	return false
+}
+
+// Record notes that the data source was queried for the asset of the request.
+func (l *QueryLog) Record(source string, req interface{}) {
+	if l == nil {
+		return
+	}
+
+	key := RequestKey(req)
+	if key == "" || l.ttls.TTL(source, requestKind(key)) <= 0 {
+		return
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	if _, found := l.queries[source]; !found {
+		l.queries[source] = make(map[string]time.Time)
+	}
+	l.queries[source][key] = time.Now().UTC()
+}
+
+// Skipped returns the number of requests not sent to each data source, since the TTL had not expired.
+func (l *QueryLog) Skipped() map[string]int {
+	skipped := make(map[string]int)
+	if l == nil {
+		return skipped
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	for src, n := range l.skipped {
+		skipped[src] = n
+	}
+	return skipped
+}
+
+// Save writes the queries that have not expired to the file of the QueryLog.
+func (l *QueryLog) Save() error {
+	if l == nil {
+		return nil
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	for src, keys := range l.queries {
+		for key, t := range keys {
+			if ttl := l.ttls.TTL(src, requestKind(key)); ttl <= 0 || time.Since(t) >= ttl {
+				delete(keys, key)
+			}
+		}
+		if len(keys) == 0 {
+			delete(l.queries, src)
+		}
+	}
+
+	data, err := json.MarshalIndent(l.queries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(l.path, data, 0644)
+}
+
+func requestKind(key string) string {
+	if i := strings.Index(key, ":"); i >= 0 {
+		return key[:i]
+	}
+	return key
+}
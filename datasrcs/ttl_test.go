@@ -0,0 +1,128 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package datasrcs
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestTTLsFromConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.DataSrcConfigs = &config.DataSourceConfig{
+		Datasources: []*config.DataSource{
+			{Name: "RADb", TTL: 60},
+			{Name: "crtsh", TTL: 10},
+		},
+	}
+	cfg.Options["source_ttls"] = map[string]interface{}{
+		"radb":  map[string]interface{}{"asn": "720h", "ADDR": "0s"},
+		"crtsh": "1h",
+	}
+
+	ttls, err := TTLsFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("TTLsFromConfig returned an error: %v", err)
+	}
+	if !ttls.Enabled() {
+		t.Errorf("the TTLs were not enabled")
+	}
+
+	for _, tc := range []struct {
+		source, kind string
+		expected     time.Duration
+	}{
+		{"RADb", ASNKind, 720 * time.Hour},
+		{"RADb", AddrKind, 0},
+		{"RADb", WhoisKind, time.Hour},
+		{"crtsh", DNSKind, time.Hour},
+		{"BinaryEdge", DNSKind, 0},
+	} {
+		if d := ttls.TTL(tc.source, tc.kind); d != tc.expected {
+			t.Errorf("the %s TTL of %s was %s, expected %s", tc.kind, tc.source, d, tc.expected)
+		}
+	}
+
+	for _, settings := range []interface{}{
+		"1h",
+		map[string]interface{}{"crtsh": "soon"},
+		map[string]interface{}{"crtsh": 60},
+		map[string]interface{}{"crtsh": map[string]interface{}{"netblock": "1h"}},
+		map[string]interface{}{"crtsh": map[string]interface{}{"dns": "-1h"}},
+	} {
+		cfg.Options["source_ttls"] = settings
+		if _, err := TTLsFromConfig(cfg); err == nil {
+			t.Errorf("TTLsFromConfig accepted the settings %v", settings)
+		}
+	}
+
+	if ttls, err := TTLsFromConfig(config.NewConfig()); err != nil || ttls.Enabled() {
+		t.Errorf("TTLsFromConfig returned %v, %v without TTLs", ttls, err)
+	}
+}
+
+func TestQueryLog(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Options["source_ttls"] = map[string]interface{}{
+		"crtsh": "1h",
+		"RADb":  map[string]interface{}{"asn": "720h"},
+	}
+	ttls, err := TTLsFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("TTLsFromConfig returned an error: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), QueryLogFile)
+	l, err := NewQueryLog(path, ttls)
+	if err != nil {
+		t.Fatalf("NewQueryLog returned an error: %v", err)
+	}
+
+	dns := &requests.DNSRequest{Name: "owasp.org", Domain: "owasp.org"}
+	asn := &requests.ASNRequest{ASN: 26810}
+	addr := &requests.AddrRequest{Address: "192.0.2.1"}
+	if l.Fresh("crtsh", dns) {
+		t.Errorf("the query was fresh before it was recorded")
+	}
+
+	l.Record("crtsh", dns)
+	l.Record("RADb", asn)
+	l.Record("RADb", addr)
+	if !l.Fresh("crtsh", dns) || !l.Fresh("RADb", asn) {
+		t.Errorf("the recorded queries were not fresh")
+	}
+	if l.Fresh("RADb", addr) {
+		t.Errorf("the query without a TTL was fresh")
+	}
+	if err := l.Save(); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+
+	l, err = NewQueryLog(path, ttls)
+	if err != nil {
+		t.Fatalf("NewQueryLog returned an error: %v", err)
+	}
+	if !l.Fresh("crtsh", dns) || l.Fresh("crtsh", &requests.DNSRequest{Name: "www.owasp.org"}) {
+		t.Errorf("the saved queries were not loaded")
+	}
+	if n := l.Skipped()["crtsh"]; n != 1 {
+		t.Errorf("%d requests to crtsh were skipped, expected 1", n)
+	}
+
+	l.queries["crtsh"][RequestKey(dns)] = time.Now().Add(-2 * time.Hour)
+	if l.Fresh("crtsh", dns) {
+		t.Errorf("the expired query was fresh")
+	}
+
+	var nilLog *QueryLog
+	nilLog.Record("crtsh", dns)
+	if nilLog.Fresh("crtsh", dns) || nilLog.Save() != nil {
+		t.Errorf("the nil QueryLog did not allow the query")
+	}
+}
@@ -0,0 +1,515 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package dbcrypt keeps the local SQLite graph database encrypted at rest. The database is decrypted
+// into memory when a command opens it, and encrypted into the output directory at intervals and once
+// the command finishes, using AES-256-GCM with a key derived from the configured passphrase. The
+// decrypted database is never written to the disk.
+package dbcrypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/owasp-amass/config/config"
+	"golang.org/x/crypto/scrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// DatabaseFile is the local SQLite graph database in the output directory.
+const DatabaseFile = "amass.sqlite"
+
+// EncryptedFile is the encrypted copy of the local graph database kept in the output directory.
+const EncryptedFile = DatabaseFile + ".enc"
+
+// LockFile is locked by the process holding the decrypted graph database, and the system releases the
+// lock once the process exits.
+const LockFile = EncryptedFile + ".lock"
+
+// SaveInterval is how often the decrypted database is encrypted into the output directory while it is open.
+const SaveInterval = 5 * time.Minute
+
+// KeyEnv is the environment variable providing the passphrase, when it is not set in the configuration.
+const KeyEnv = "AMASS_DB_KEY"
+
+// ErrKey is returned when the database cannot be decrypted using the passphrase.
+var ErrKey = errors.New("the graph database could not be decrypted using the provided key")
+
+// ErrBothFiles is returned when the directory holds both the unencrypted and the encrypted graph database,
+// so one of them must be moved away before the database can be used.
+var ErrBothFiles = errors.New("the unencrypted and the encrypted graph databases are both present")
+
+const (
+	magic     = "AMASSDB2"
+	saltSize  = 16
+	chunkSize = 1 << 20
+	// The nonce of each chunk is the random prefix, the chunk counter and the final chunk flag
+	prefixSize = 7
+)
+
+// The SQLite files written alongside an unencrypted database, which hold the same data.
+var sidecars = []string{"-journal", "-wal", "-shm"}
+
+// Exists returns true when the directory holds the graph database, whether it is encrypted or not.
+func Exists(dir string) bool {
+	for _, name := range []string{DatabaseFile, EncryptedFile} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// FromConfig returns the passphrase set by the 'database_encryption' section of the configuration
+// options, or the AMASS_DB_KEY environment variable. An empty passphrase is returned when the
+// encryption has not been enabled.
+func FromConfig(cfg *config.Config) (string, error) {
+	raw, ok := cfg.Options["database_encryption"]
+	if !ok {
+		return "", nil
+	}
+
+	settings, ok := raw.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("database_encryption is not a map[string]interface{}")
+	}
+
+	if raw, ok := settings["enabled"]; ok {
+		enabled, ok := raw.(bool)
+		if !ok {
+			return "", fmt.Errorf("database_encryption enabled is not a bool")
+		}
+		if !enabled {
+			return "", nil
+		}
+	}
+
+	key := os.Getenv(KeyEnv)
+	if raw, ok := settings["key"]; ok {
+		k, ok := raw.(string)
+		if !ok {
+			return "", fmt.Errorf("database_encryption key is not a string")
+		}
+		key = k
+	}
+	if key == "" {
+		return "", fmt.Errorf("database_encryption requires the key option or the %s environment variable", KeyEnv)
+	}
+	return key, nil
+}
+
+// The graph databases decrypted by the process, keyed by their output directory.
+var (
+	vaultsLock sync.Mutex
+	vaults     = make(map[string]*vault)
+)
+
+// vault holds a graph database decrypted into memory, where the connections opened using its DSN share it.
+type vault struct {
+	sync.Mutex
+	dir        string
+	passphrase string
+	dsn        string
+	db         *sql.DB
+	// conn keeps the database in memory, since SQLite releases it with the last connection
+	conn *sql.Conn
+	lock *os.File
+	refs int
+	// imported is set when the database was read from the unencrypted file, which is removed once saved
+	imported bool
+	closed   bool
+	done     chan struct{}
+}
+
+// Unlock decrypts the graph database in the directory into memory, and returns the DSN used to open it.
+// The connections using the DSN share the decrypted database through the memdb VFS of SQLite, so it is
+// never written to the disk. An empty database is provided when the directory does not hold one yet, and
+// an unencrypted database, such as one written before the encryption was enabled, is read in place of the
+// encrypted copy and removed once the encrypted copy replaced it. Each call is paired with a call to Lock.
+func Unlock(dir, passphrase string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	vaultsLock.Lock()
+	defer vaultsLock.Unlock()
+
+	if v, found := vaults[dir]; found {
+		if v.passphrase != passphrase {
+			return "", ErrKey
+		}
+		v.refs++
+		return v.dsn, nil
+	}
+
+	// The lock keeps another process from decrypting its own copy and overwriting the changes
+	lock, err := os.OpenFile(filepath.Join(dir, LockFile), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return "", err
+	}
+	if err := lockFile(lock); err != nil {
+		lock.Close()
+		return "", fmt.Errorf("the graph database in %s is used by another process", dir)
+	}
+
+	v, err := newVault(dir, passphrase, lock)
+	if err != nil {
+		return "", err
+	}
+
+	vaults[dir] = v
+	go v.autosave()
+	return v.dsn, nil
+}
+
+// Lock encrypts the graph database decrypted by Unlock into the directory, replacing the encrypted copy,
+// and releases the decrypted database once every caller of Unlock is done with it.
+func Lock(dir string) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	vaultsLock.Lock()
+	defer vaultsLock.Unlock()
+
+	v, found := vaults[dir]
+	if !found {
+		return nil
+	}
+
+	err = v.save()
+	if v.refs--; v.refs > 0 {
+		return err
+	}
+
+	delete(vaults, dir)
+	v.close()
+	return err
+}
+
+func newVault(dir, passphrase string, lock *os.File) (*vault, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		lock.Close()
+		return nil, err
+	}
+	// The random name keeps the databases decrypted from different directories apart
+	dsn := fmt.Sprintf("file:/amass-%x?vfs=memdb", id)
+
+	// The connection waits for the writers as long as the connections opened by the commands do
+	db, err := gorm.Open(sqlite.Open(dsn+"&_pragma=busy_timeout(30000)"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		lock.Close()
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		lock.Close()
+		return nil, err
+	}
+
+	conn, err := sqlDB.Conn(context.Background())
+	if err != nil {
+		sqlDB.Close()
+		lock.Close()
+		return nil, err
+	}
+
+	v := &vault{
+		dir:        dir,
+		passphrase: passphrase,
+		dsn:        dsn,
+		db:         sqlDB,
+		conn:       conn,
+		lock:       lock,
+		refs:       1,
+		done:       make(chan struct{}),
+	}
+	if err := v.load(); err != nil {
+		v.close()
+		return nil, err
+	}
+	return v, nil
+}
+
+// load reads the database from the directory, importing the unencrypted file when it is the only one
+// present. The directory is refused when it holds both files, since either could hold the newer data.
+func (v *vault) load() error {
+	path := filepath.Join(v.dir, DatabaseFile)
+	_, perr := os.Stat(path)
+
+	in, err := os.Open(filepath.Join(v.dir, EncryptedFile))
+	if os.IsNotExist(err) {
+		if perr == nil {
+			v.imported = true
+			return v.importFile(path)
+		}
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if perr == nil {
+		return fmt.Errorf("%w: %s and %s are both in %s", ErrBothFiles, DatabaseFile, EncryptedFile, v.dir)
+	}
+
+	pr, pw := io.Pipe()
+	errc := make(chan error, 1)
+	go func() {
+		err := Decrypt(pw, in, v.passphrase)
+		pw.CloseWithError(err)
+		errc <- err
+	}()
+
+	err = restore(v.conn, pr)
+	// The decryption stops writing once the statements are no longer read
+	pr.CloseWithError(io.ErrClosedPipe)
+	if derr := <-errc; derr != nil && derr != io.ErrClosedPipe {
+		return derr
+	}
+	return err
+}
+
+// importFile copies the unencrypted database into memory, including the pages held by its write-ahead log.
+func (v *vault) importFile(path string) error {
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	conn, err := sqlDB.Conn(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return transfer(v.conn, conn)
+}
+
+// save encrypts the database into the directory, replacing the encrypted copy.
+func (v *vault) save() error {
+	v.Lock()
+	defer v.Unlock()
+
+	if v.closed {
+		return nil
+	}
+
+	enc := filepath.Join(v.dir, EncryptedFile)
+	tmp := enc + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	pr, pw := io.Pipe()
+	errc := make(chan error, 1)
+	go func() {
+		err := dump(v.conn, pw)
+		pw.CloseWithError(err)
+		errc <- err
+	}()
+
+	err = Encrypt(out, pr, v.passphrase)
+	// The dump stops writing once the statements are no longer encrypted
+	pr.CloseWithError(io.ErrClosedPipe)
+	<-errc
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, enc); err != nil {
+		return err
+	}
+
+	// The unencrypted database is only removed once the encrypted copy holds its content
+	if v.imported {
+		path := filepath.Join(v.dir, DatabaseFile)
+		for _, suffix := range append([]string{""}, sidecars...) {
+			if err := os.Remove(path + suffix); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		v.imported = false
+	}
+	return nil
+}
+
+// autosave encrypts the database at each interval, so a process that is killed only loses the changes
+// made since the last save.
+func (v *vault) autosave() {
+	t := time.NewTicker(SaveInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-v.done:
+			return
+		case <-t.C:
+			_ = v.save()
+		}
+	}
+}
+
+// close releases the decrypted database and the lock on the lock file.
+func (v *vault) close() {
+	v.Lock()
+	defer v.Unlock()
+
+	v.closed = true
+	close(v.done)
+	v.conn.Close()
+	v.db.Close()
+	// The lock file is kept, since another process can be waiting to lock it
+	v.lock.Close()
+}
+
+// Encrypt writes the content of r to w, encrypted using the passphrase.
+func Encrypt(w io.Writer, r io.Reader, passphrase string) error {
+	header := make([]byte, len(magic)+saltSize+prefixSize)
+	copy(header, magic)
+	if _, err := rand.Read(header[len(magic):]); err != nil {
+		return err
+	}
+
+	aead, err := newAEAD(passphrase, header[len(magic):len(magic)+saltSize])
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	prefix := header[len(magic)+saltSize:]
+	buf := make([]byte, chunkSize)
+	// The chunk read ahead identifies the final chunk, so a truncated file is detected
+	n, err := io.ReadFull(r, buf)
+	for counter := uint32(0); ; counter++ {
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		last := err != nil
+
+		var next []byte
+		var nn int
+		var nerr error
+		if !last {
+			next = make([]byte, chunkSize)
+			nn, nerr = io.ReadFull(r, next)
+			if nerr == io.EOF {
+				last = true
+			}
+		}
+
+		sealed := aead.Seal(nil, nonce(prefix, counter, last), buf[:n], nil)
+		var size [4]byte
+		binary.BigEndian.PutUint32(size[:], uint32(len(sealed)))
+		if _, err := w.Write(size[:]); err != nil {
+			return err
+		}
+		if _, err := w.Write(sealed); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+
+		buf, n, err = next, nn, nerr
+	}
+}
+
+// Decrypt writes the content encrypted by Encrypt in r to w. ErrKey is returned when the
+// passphrase is wrong or the content has been modified.
+func Decrypt(w io.Writer, r io.Reader, passphrase string) error {
+	header := make([]byte, len(magic)+saltSize+prefixSize)
+	if _, err := io.ReadFull(r, header); err != nil || !bytes.Equal(header[:len(magic)], []byte(magic)) {
+		return errors.New("the file is not an encrypted graph database")
+	}
+
+	aead, err := newAEAD(passphrase, header[len(magic):len(magic)+saltSize])
+	if err != nil {
+		return err
+	}
+
+	prefix := header[len(magic)+saltSize:]
+	for counter := uint32(0); ; counter++ {
+		var size [4]byte
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return errors.New("the encrypted graph database is truncated")
+		}
+
+		n := binary.BigEndian.Uint32(size[:])
+		if n < uint32(aead.Overhead()) || n > chunkSize+uint32(aead.Overhead()) {
+			return errors.New("the encrypted graph database is corrupted")
+		}
+		sealed := make([]byte, n)
+		if _, err := io.ReadFull(r, sealed); err != nil {
+			return errors.New("the encrypted graph database is truncated")
+		}
+
+		// The final chunk flag is only authenticated for the last chunk that was written
+		plain, err := aead.Open(nil, nonce(prefix, counter, false), sealed, nil)
+		last := false
+		if err != nil {
+			if plain, err = aead.Open(nil, nonce(prefix, counter, true), sealed, nil); err != nil {
+				return ErrKey
+			}
+			last = true
+		}
+		if _, err := w.Write(plain); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+	}
+}
+
+func newAEAD(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func nonce(prefix []byte, counter uint32, last bool) []byte {
+	n := make([]byte, prefixSize+5)
+	copy(n, prefix)
+	binary.BigEndian.PutUint32(n[prefixSize:], counter)
+	if last {
+		n[len(n)-1] = 1
+	}
+	return n
+}
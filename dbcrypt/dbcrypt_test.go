@@ -0,0 +1,286 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dbcrypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/owasp-amass/config/config"
+	"gorm.io/gorm"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	for _, size := range []int{0, 10, chunkSize, 2*chunkSize + 7} {
+		plain := make([]byte, size)
+		_, _ = rand.Read(plain)
+
+		var enc bytes.Buffer
+		if err := Encrypt(&enc, bytes.NewReader(plain), "secret"); err != nil {
+			t.Fatalf("Encrypt returned an error for %d bytes: %v", size, err)
+		}
+		if size > 0 && bytes.Contains(enc.Bytes(), plain[:size/2+1]) {
+			t.Errorf("The %d bytes were not encrypted", size)
+		}
+
+		var dec bytes.Buffer
+		if err := Decrypt(&dec, bytes.NewReader(enc.Bytes()), "secret"); err != nil || !bytes.Equal(dec.Bytes(), plain) {
+			t.Errorf("Decrypt did not return the %d bytes: %v", size, err)
+		}
+		if err := Decrypt(&bytes.Buffer{}, bytes.NewReader(enc.Bytes()), "wrong"); err != ErrKey {
+			t.Errorf("Decrypt returned %v using the wrong key", err)
+		}
+		// Dropping the final chunk is detected
+		if size > chunkSize {
+			truncated := enc.Bytes()[:len(magic)+saltSize+prefixSize+4+chunkSize+16]
+			if err := Decrypt(&bytes.Buffer{}, bytes.NewReader(truncated), "secret"); err == nil {
+				t.Error("Decrypt accepted the truncated content")
+			}
+		}
+	}
+}
+
+func openDB(t *testing.T, dsn string) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open the database: %v", err)
+	}
+	return db
+}
+
+func closeDB(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
+
+func TestLockUnlock(t *testing.T) {
+	dir := t.TempDir()
+
+	dsn, err := Unlock(dir, "secret")
+	if err != nil {
+		t.Fatalf("Unlock returned an error: %v", err)
+	}
+	db := openDB(t, dsn)
+	for _, stmt := range []string{
+		"CREATE TABLE assets (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, content BLOB, score REAL)",
+		"CREATE INDEX idx_assets_name ON assets (name)",
+		"INSERT INTO assets (name, content, score) VALUES ('www.owasp.org', x'00ff', 0.75)",
+		"INSERT INTO assets (name, content, score) VALUES ('it''s' || char(10) || 'owasp.org', NULL, NULL)",
+		"DELETE FROM assets WHERE id = 2",
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("Failed to execute %s: %v", stmt, err)
+		}
+	}
+	closeDB(db)
+
+	if err := Lock(dir); err != nil {
+		t.Fatalf("Lock returned an error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, DatabaseFile)); !os.IsNotExist(err) {
+		t.Error("The decrypted database was written to the output directory")
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, EncryptedFile)); err != nil || bytes.Contains(data, []byte("owasp.org")) {
+		t.Errorf("The database was not encrypted: %v", err)
+	}
+
+	if _, err := Unlock(dir, "wrong"); err != ErrKey {
+		t.Errorf("Unlock returned %v using the wrong key", err)
+	}
+
+	dsn, err = Unlock(dir, "secret")
+	if err != nil {
+		t.Fatalf("Unlock returned an error: %v", err)
+	}
+	// Each caller shares the decrypted database until the last one is done
+	if again, err := Unlock(dir, "secret"); err != nil || again != dsn {
+		t.Errorf("Unlock did not share the decrypted database: %s, %v", again, err)
+	}
+	if err := Lock(dir); err != nil {
+		t.Errorf("Lock returned an error: %v", err)
+	}
+
+	db = openDB(t, dsn)
+	defer closeDB(db)
+	if err := db.Exec("INSERT INTO assets (name) VALUES ('mail.owasp.org')").Error; err != nil {
+		t.Fatalf("Failed to insert the row: %v", err)
+	}
+
+	var rows []struct {
+		ID      int
+		Name    string
+		Content []byte
+		Score   float64
+	}
+	if err := db.Raw("SELECT id, name, content, score FROM assets ORDER BY id").Scan(&rows).Error; err != nil {
+		t.Fatalf("Failed to read the rows: %v", err)
+	}
+	if len(rows) != 2 || rows[0].Name != "www.owasp.org" || !bytes.Equal(rows[0].Content, []byte{0, 255}) || rows[0].Score != 0.75 {
+		t.Errorf("Unlock did not restore the rows: %+v", rows)
+	}
+	// The sequence of the AUTOINCREMENT table does not reuse the identifier of the deleted row
+	if len(rows) == 2 && rows[1].ID != 3 {
+		t.Errorf("The sequence was not restored: %+v", rows)
+	}
+
+	var index string
+	if err := db.Raw("SELECT name FROM sqlite_master WHERE type = 'index' AND name = 'idx_assets_name'").Scan(&index).Error; err != nil || index == "" {
+		t.Errorf("The index was not restored: %v", err)
+	}
+
+	if err := Lock(dir); err != nil {
+		t.Errorf("Lock returned an error: %v", err)
+	}
+	if err := Lock(t.TempDir()); err != nil {
+		t.Errorf("Lock returned an error without a database: %v", err)
+	}
+}
+
+func TestUnlockInUse(t *testing.T) {
+	dir := t.TempDir()
+	f, err := os.OpenFile(filepath.Join(dir, LockFile), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		t.Fatalf("Failed to create the lock file: %v", err)
+	}
+	if err := lockFile(f); err != nil {
+		t.Fatalf("Failed to lock the file: %v", err)
+	}
+
+	if _, err := Unlock(dir, "secret"); err == nil {
+		t.Error("Unlock decrypted the database used by another process")
+	}
+	// The lock is released along with the file
+	f.Close()
+	if _, err := Unlock(dir, "secret"); err != nil {
+		t.Errorf("Unlock returned an error once the lock was released: %v", err)
+	}
+	_ = Lock(dir)
+}
+
+func TestUnlockUnencrypted(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, DatabaseFile)
+
+	// The connection remains open, so the rows are only held by the write-ahead log
+	db := openDB(t, path+"?_pragma=journal_mode(WAL)")
+	for _, stmt := range []string{"CREATE TABLE assets (name TEXT)", "INSERT INTO assets VALUES ('www.owasp.org')"} {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("Failed to execute %s: %v", stmt, err)
+		}
+	}
+
+	if _, err := Unlock(dir, "secret"); err != nil {
+		t.Fatalf("Unlock returned an error: %v", err)
+	}
+	closeDB(db)
+	if err := Lock(dir); err != nil {
+		t.Fatalf("Lock returned an error: %v", err)
+	}
+	for _, suffix := range append([]string{""}, sidecars...) {
+		if _, err := os.Stat(path + suffix); !os.IsNotExist(err) {
+			t.Errorf("The file %s was not removed", filepath.Base(path+suffix))
+		}
+	}
+
+	dsn, err := Unlock(dir, "secret")
+	if err != nil {
+		t.Fatalf("Unlock returned an error: %v", err)
+	}
+	defer func() { _ = Lock(dir) }()
+
+	db = openDB(t, dsn)
+	defer closeDB(db)
+	var count int
+	if err := db.Raw("SELECT COUNT(*) FROM assets").Scan(&count).Error; err != nil || count != 1 {
+		t.Errorf("The rows held by the write-ahead log were lost: %d rows, %v", count, err)
+	}
+}
+
+func TestUnlockBothFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	dsn, err := Unlock(dir, "secret")
+	if err != nil {
+		t.Fatalf("Unlock returned an error: %v", err)
+	}
+	db := openDB(t, dsn)
+	if err := db.Exec("CREATE TABLE assets (name TEXT)").Error; err != nil {
+		t.Fatalf("Failed to create the table: %v", err)
+	}
+	closeDB(db)
+	if err := Lock(dir); err != nil {
+		t.Fatalf("Lock returned an error: %v", err)
+	}
+	enc, err := os.ReadFile(filepath.Join(dir, EncryptedFile))
+	if err != nil {
+		t.Fatalf("Failed to read the encrypted database: %v", err)
+	}
+
+	path := filepath.Join(dir, DatabaseFile)
+	db = openDB(t, path)
+	if err := db.Exec("CREATE TABLE other (name TEXT)").Error; err != nil {
+		t.Fatalf("Failed to create the table: %v", err)
+	}
+	closeDB(db)
+
+	if _, err := Unlock(dir, "secret"); !errors.Is(err, ErrBothFiles) {
+		t.Fatalf("Unlock returned %v with both databases present", err)
+	}
+	// Neither database is replaced by the other
+	if data, err := os.ReadFile(filepath.Join(dir, EncryptedFile)); err != nil || !bytes.Equal(data, enc) {
+		t.Errorf("The encrypted database was overwritten: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("The unencrypted database was removed: %v", err)
+	}
+
+	// The directory can be used once the unencrypted database is moved away
+	if err := os.Rename(path, filepath.Join(t.TempDir(), DatabaseFile)); err != nil {
+		t.Fatalf("Failed to move the unencrypted database: %v", err)
+	}
+	if _, err := Unlock(dir, "secret"); err != nil {
+		t.Errorf("Unlock returned an error once the unencrypted database was moved: %v", err)
+	}
+	_ = Lock(dir)
+}
+
+func TestFromConfig(t *testing.T) {
+	t.Setenv(KeyEnv, "")
+	cfg := config.NewConfig()
+	if key, err := FromConfig(cfg); key != "" || err != nil {
+		t.Errorf("FromConfig returned %q, %v without the section", key, err)
+	}
+
+	cfg.Options["database_encryption"] = map[string]interface{}{"enabled": true}
+	if _, err := FromConfig(cfg); err == nil {
+		t.Error("FromConfig accepted the encryption without a key")
+	}
+
+	t.Setenv(KeyEnv, "from-env")
+	if key, err := FromConfig(cfg); key != "from-env" || err != nil {
+		t.Errorf("FromConfig returned %q, %v using the environment", key, err)
+	}
+
+	cfg.Options["database_encryption"] = map[string]interface{}{"key": "from-config"}
+	if key, err := FromConfig(cfg); key != "from-config" || err != nil {
+		t.Errorf("FromConfig returned %q, %v using the configuration", key, err)
+	}
+
+	cfg.Options["database_encryption"] = map[string]interface{}{"enabled": false, "key": "from-config"}
+	if key, err := FromConfig(cfg); key != "" || err != nil {
+		t.Errorf("FromConfig returned %q, %v when disabled", key, err)
+	}
+
+	cfg.Options["database_encryption"] = map[string]interface{}{"key": 42}
+	if _, err := FromConfig(cfg); err == nil {
+		t.Error("FromConfig accepted a key that is not a string")
+	}
+}
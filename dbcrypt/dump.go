@@ -0,0 +1,218 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dbcrypt
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The database held in memory is encrypted as the statements recreating it, since the SQLite driver does
+// not provide the pages of the database. Each statement is preceded by its length.
+
+// The schema objects are recreated once the rows of the tables have been inserted, so the triggers do not fire.
+const objectsQuery = `SELECT type, name, sql FROM sqlite_master WHERE sql IS NOT NULL
+	ORDER BY CASE type WHEN 'table' THEN 0 WHEN 'index' THEN 1 ELSE 2 END, rowid`
+
+type object struct {
+	kind string
+	name string
+	sql  string
+}
+
+// transfer copies the schema and the rows of the src database into the dst database.
+func transfer(dst, src *sql.Conn) error {
+	pr, pw := io.Pipe()
+	errc := make(chan error, 1)
+	go func() {
+		err := dump(src, pw)
+		pw.CloseWithError(err)
+		errc <- err
+	}()
+
+	err := restore(dst, pr)
+	pr.CloseWithError(io.ErrClosedPipe)
+	if derr := <-errc; derr != nil && derr != io.ErrClosedPipe {
+		return derr
+	}
+	return err
+}
+
+// dump writes the statements recreating the schema and the rows of the database to w. The rows are read
+// within a transaction, so the statements describe the database at a single point in time.
+func dump(conn *sql.Conn, w io.Writer) error {
+	ctx := context.Background()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	objects, err := schemaObjects(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	var sequence bool
+	for _, o := range objects {
+		if o.kind != "table" {
+			continue
+		}
+		// The tables created by SQLite are not recreated, while the sequences of the AUTOINCREMENT
+		// tables are restored once the rows have been inserted
+		if strings.HasPrefix(o.name, "sqlite_") {
+			sequence = sequence || o.name == "sqlite_sequence"
+			continue
+		}
+		if err := writeStatement(bw, o.sql); err != nil {
+			return err
+		}
+		if err := dumpRows(ctx, tx, bw, o.name); err != nil {
+			return err
+		}
+	}
+	if sequence {
+		if err := writeStatement(bw, "DELETE FROM sqlite_sequence"); err != nil {
+			return err
+		}
+		if err := dumpRows(ctx, tx, bw, "sqlite_sequence"); err != nil {
+			return err
+		}
+	}
+	for _, o := range objects {
+		if o.kind != "table" && !strings.HasPrefix(o.name, "sqlite_") {
+			if err := writeStatement(bw, o.sql); err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+func schemaObjects(ctx context.Context, tx *sql.Tx) ([]*object, error) {
+	rows, err := tx.QueryContext(ctx, objectsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var objects []*object
+	for rows.Next() {
+		o := new(object)
+		if err := rows.Scan(&o.kind, &o.name, &o.sql); err != nil {
+			return nil, err
+		}
+		objects = append(objects, o)
+	}
+	return objects, rows.Err()
+}
+
+// dumpRows writes an INSERT statement for each row of the table, using the literals produced by SQLite.
+func dumpRows(ctx context.Context, tx *sql.Tx, w io.Writer, table string) error {
+	rows, err := tx.QueryContext(ctx, "SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return err
+	}
+
+	var values []string
+	for rows.Next() {
+		var col string
+		if err := rows.Scan(&col); err != nil {
+			rows.Close()
+			return err
+		}
+		values = append(values, "quote("+identifier(col)+")")
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	} else if len(values) == 0 {
+		return fmt.Errorf("the columns of the %s table were not found", table)
+	}
+
+	prefix := literal("INSERT INTO " + identifier(table) + " VALUES(")
+	rows, err = tx.QueryContext(ctx, "SELECT "+prefix+" || "+strings.Join(values, " || ',' || ")+" || ')' FROM "+identifier(table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var stmt string
+		if err := rows.Scan(&stmt); err != nil {
+			return err
+		}
+		if err := writeStatement(w, stmt); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// restore executes the statements read from r within a transaction, so a database is not partially restored.
+func restore(conn *sql.Conn, r io.Reader) error {
+	ctx := context.Background()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	br := bufio.NewReader(r)
+	for {
+		stmt, err := readStatement(br)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func writeStatement(w io.Writer, stmt string) error {
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(stmt)))
+	if _, err := w.Write(size[:]); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, stmt)
+	return err
+}
+
+func readStatement(r io.Reader) (string, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err == io.EOF {
+		return "", io.EOF
+	} else if err != nil {
+		return "", errors.New("the graph database statements are truncated")
+	}
+
+	stmt := make([]byte, binary.BigEndian.Uint32(size[:]))
+	if _, err := io.ReadFull(r, stmt); err != nil {
+		return "", errors.New("the graph database statements are truncated")
+	}
+	return string(stmt), nil
+}
+
+func identifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func literal(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
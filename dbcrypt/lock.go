@@ -0,0 +1,17 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows
+
+package dbcrypt
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes the exclusive lock of the file without waiting, which the system releases when the process exits.
+func lockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+}
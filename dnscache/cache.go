@@ -0,0 +1,251 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package dnscache keeps the DNS responses received by the enumerations until their records expire,
+// so repeated enumerations of the same targets do not resolve the unchanged names again.
+package dnscache
+
+import (
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// DefaultMaxTTL is the longest time a response is kept when no limit has been configured.
+const DefaultMaxTTL = 24 * time.Hour
+
+// Store holds the cached responses, and drops each of them once its time to live has elapsed.
+type Store interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration) error
+	Close() error
+}
+
+// Stats counts the lookups answered from the cache and the responses saved to it.
+type Stats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+	Stores uint64 `json:"stores"`
+}
+
+// The statistics of all the caches used by the process
+var totalHits, totalMisses, totalStores uint64
+
+// Totals returns the statistics of all the caches used by the process, such as across the
+// sessions executed by the API server.
+func Totals() Stats {
+	return Stats{
+		Hits:   atomic.LoadUint64(&totalHits),
+		Misses: atomic.LoadUint64(&totalMisses),
+		Stores: atomic.LoadUint64(&totalStores),
+	}
+}
+
+// Cache provides the responses saved by the earlier enumerations to a single enumeration.
+// All methods are safe to call on a nil Cache, which never answers a lookup.
+type Cache struct {
+	store *sharedStore
+	// namespace keeps the responses of the enumerations apart from the others using the store,
+	// such as those of the other tenants sharing a Redis server
+	namespace string
+	maxTTL    time.Duration
+	negative  bool
+	hits      uint64
+	misses    uint64
+	stores    uint64
+}
+
+// Lookup returns the cached response to the query, with the ID of the query and the time to live
+// of the records reduced by the time spent in the cache, or nil when the cache has no response.
+// The scope separates the responses, such as those of the trusted and untrusted resolvers.
+func (c *Cache) Lookup(query *dns.Msg, scope string) *dns.Msg {
+	if c == nil || len(query.Question) == 0 {
+		return nil
+	}
+
+	value, found := c.store.Get(c.key(scope, query.Question[0]))
+	if !found || len(value) <= 8 {
+		c.miss()
+		return nil
+	}
+
+	resp := new(dns.Msg)
+	if err := resp.Unpack(value[8:]); err != nil {
+		c.miss()
+		return nil
+	}
+
+	elapsed := uint32(time.Since(time.Unix(0, int64(binary.BigEndian.Uint64(value[:8])))) / time.Second)
+	for _, section := range [][]dns.RR{resp.Answer, resp.Ns, resp.Extra} {
+		for _, rr := range section {
+			if h := rr.Header(); h.Rrtype != dns.TypeOPT {
+				if h.Ttl > elapsed {
+					h.Ttl -= elapsed
+				} else {
+					h.Ttl = 0
+				}
+			}
+		}
+	}
+	resp.Id = query.Id
+	resp.Question = query.Question
+
+	atomic.AddUint64(&c.hits, 1)
+	atomic.AddUint64(&totalHits, 1)
+	return resp
+}
+
+func (c *Cache) miss() {
+	atomic.AddUint64(&c.misses, 1)
+	atomic.AddUint64(&totalMisses, 1)
+}
+
+// Save keeps the response until its records expire. The responses providing answers are kept for
+// the lowest time to live of the answers, and the responses without answers for the time to live
+// of the negative answers provided by the SOA record, when negative caching is enabled.
+func (c *Cache) Save(resp *dns.Msg, scope string) {
+	if c == nil || len(resp.Question) == 0 || resp.Truncated {
+		return
+	}
+
+	var ttl uint32
+	switch {
+	case resp.Rcode == dns.RcodeSuccess && len(resp.Answer) > 0:
+		ttl = minTTL(resp.Answer)
+	case c.negative && (resp.Rcode == dns.RcodeNameError || resp.Rcode == dns.RcodeSuccess):
+		for _, rr := range resp.Ns {
+			if soa, ok := rr.(*dns.SOA); ok {
+				// RFC 2308 limits the negative answers to the lower of the two values
+				ttl = soa.Hdr.Ttl
+				if soa.Minttl < ttl {
+					ttl = soa.Minttl
+				}
+				break
+			}
+		}
+	}
+	if ttl == 0 {
+		return
+	}
+
+	d := time.Duration(ttl) * time.Second
+	if d > c.maxTTL {
+		d = c.maxTTL
+	}
+
+	data, err := resp.Pack()
+	if err != nil {
+		return
+	}
+
+	value := make([]byte, 8, 8+len(data))
+	binary.BigEndian.PutUint64(value, uint64(time.Now().UnixNano()))
+	if err := c.store.Set(c.key(scope, resp.Question[0]), append(value, data...), d); err == nil {
+		atomic.AddUint64(&c.stores, 1)
+		atomic.AddUint64(&totalStores, 1)
+	}
+}
+
+// Stats returns the statistics of the lookups made through the cache.
+func (c *Cache) Stats() Stats {
+	if c == nil {
+		return Stats{}
+	}
+
+	return Stats{
+		Hits:   atomic.LoadUint64(&c.hits),
+		Misses: atomic.LoadUint64(&c.misses),
+		Stores: atomic.LoadUint64(&c.stores),
+	}
+}
+
+// Ping checks that the store can be reached, such as the Redis server, which is otherwise only
+// contacted by the lookups, whose failures are treated as misses.
+func (c *Cache) Ping() error {
+	if c == nil {
+		return nil
+	}
+	if p, ok := c.store.Store.(interface{ Ping() error }); ok {
+		return p.Ping()
+	}
+	return nil
+}
+
+// Close releases the store, which is closed once none of the enumerations use it.
+func (c *Cache) Close() error {
+	if c == nil {
+		return nil
+	}
+	return c.store.release()
+}
+
+func minTTL(rrs []dns.RR) uint32 {
+	ttl := rrs[0].Header().Ttl
+
+	for _, rr := range rrs[1:] {
+		if t := rr.Header().Ttl; t < ttl {
+			ttl = t
+		}
+	}
+	return ttl
+}
+
+func (c *Cache) key(scope string, q dns.Question) string {
+	if c.namespace != "" {
+		return c.namespace + ":" + cacheKey(scope, q)
+	}
+	return cacheKey(scope, q)
+}
+
+func cacheKey(scope string, q dns.Question) string {
+	return scope + ":" + strings.ToLower(strings.TrimSuffix(q.Name, ".")) + ":" + strconv.Itoa(int(q.Qtype))
+}
+
+// sharedStore is a Store used by the enumerations running in the process at the same time.
+type sharedStore struct {
+	Store
+	location string
+	refs     int
+}
+
+var (
+	storesLock sync.Mutex
+	stores     = make(map[string]*sharedStore)
+)
+
+// acquire returns the store at the location, opening it when no enumeration is using it.
+func acquire(location string, open func() (Store, error)) (*sharedStore, error) {
+	storesLock.Lock()
+	defer storesLock.Unlock()
+
+	if s, found := stores[location]; found {
+		s.refs++
+		return s, nil
+	}
+
+	store, err := open()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &sharedStore{Store: store, location: location, refs: 1}
+	stores[location] = s
+	return s, nil
+}
+
+func (s *sharedStore) release() error {
+	storesLock.Lock()
+	defer storesLock.Unlock()
+
+	if s.refs--; s.refs > 0 {
+		return nil
+	}
+	delete(stores, s.location)
+	return s.Store.Close()
+}
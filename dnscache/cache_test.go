@@ -0,0 +1,266 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dnscache
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/config/config"
+)
+
+func response(name string, qtype uint16, rcode int, rrs ...string) *dns.Msg {
+	q := new(dns.Msg)
+	q.SetQuestion(dns.Fqdn(name), qtype)
+
+	resp := new(dns.Msg)
+	resp.SetRcode(q, rcode)
+	for _, s := range rrs {
+		rr, _ := dns.NewRR(s)
+		if _, ok := rr.(*dns.SOA); ok {
+			resp.Ns = append(resp.Ns, rr)
+		} else {
+			resp.Answer = append(resp.Answer, rr)
+		}
+	}
+	return resp
+}
+
+func TestCache(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Dir = t.TempDir()
+	if c, err := FromConfig(cfg); err != nil || c != nil {
+		t.Fatalf("Expected no cache without the dns_cache section, got %v, %v", c, err)
+	}
+
+	cfg.Options["dns_cache"] = map[string]interface{}{"store": "disk", "max_ttl": "1h"}
+	c, err := FromConfig(cfg)
+	if err != nil || c == nil {
+		t.Fatalf("Failed to open the cache: %v", err)
+	}
+
+	c.Save(response("www.owasp.org", dns.TypeA, dns.RcodeSuccess,
+		"www.owasp.org. 300 IN A 104.22.27.77", "www.owasp.org. 600 IN A 104.22.26.77"), "trusted")
+	c.Save(response("nx.owasp.org", dns.TypeA, dns.RcodeNameError,
+		"owasp.org. 3600 IN SOA ns1.owasp.org. admin.owasp.org. 1 7200 900 1209600 60"), "trusted")
+	// Responses without a time to live are not kept
+	c.Save(response("zero.owasp.org", dns.TypeA, dns.RcodeSuccess, "zero.owasp.org. 0 IN A 192.0.2.1"), "trusted")
+
+	q := new(dns.Msg)
+	q.SetQuestion("WWW.owasp.org.", dns.TypeA)
+	resp := c.Lookup(q, "trusted")
+	if resp == nil || resp.Id != q.Id || len(resp.Answer) != 2 || resp.Answer[0].Header().Ttl > 300 {
+		t.Fatalf("Unexpected cached response: %v", resp)
+	}
+	if c.Lookup(q, "untrusted") != nil {
+		t.Error("Expected the scopes to be separated")
+	}
+
+	q.SetQuestion("nx.owasp.org.", dns.TypeA)
+	if resp := c.Lookup(q, "trusted"); resp == nil || resp.Rcode != dns.RcodeNameError {
+		t.Errorf("Expected the negative answer to be cached, got %v", resp)
+	}
+	q.SetQuestion("zero.owasp.org.", dns.TypeA)
+	if c.Lookup(q, "trusted") != nil {
+		t.Error("Expected the response without a time to live to not be cached")
+	}
+
+	if st := c.Stats(); st.Hits != 2 || st.Misses != 2 || st.Stores != 2 {
+		t.Errorf("Unexpected cache statistics: %+v", st)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Failed to save the cache: %v", err)
+	}
+
+	// The next session loads the responses saved to the output directory
+	c, err = FromConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open the cache again: %v", err)
+	}
+	defer c.Close()
+
+	q.SetQuestion("www.owasp.org.", dns.TypeA)
+	if c.Lookup(q, "trusted") == nil {
+		t.Error("Expected the response to be loaded from the disk store")
+	}
+	if tot := Totals(); tot.Hits < 3 {
+		t.Errorf("Unexpected process totals: %+v", tot)
+	}
+}
+
+func TestDiskStoreExpiration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DiskFile)
+
+	d, err := NewDiskStore(path)
+	if err != nil {
+		t.Fatalf("Failed to open the disk store: %v", err)
+	}
+	_ = d.Set("short", []byte("value"), 10*time.Millisecond)
+	_ = d.Set("long", []byte("value"), time.Hour)
+	time.Sleep(20 * time.Millisecond)
+
+	if _, found := d.Get("short"); found {
+		t.Error("Expected the expired entry to be dropped")
+	}
+	if err := d.Close(); err != nil {
+		t.Fatalf("Failed to save the disk store: %v", err)
+	}
+
+	d, err = NewDiskStore(path)
+	if err != nil {
+		t.Fatalf("Failed to load the disk store: %v", err)
+	}
+	if _, found := d.Get("long"); !found || len(d.entries) != 1 {
+		t.Errorf("Expected only the unexpired entry to be loaded, got %d entries", len(d.entries))
+	}
+}
+
+// fakeRedis serves the GET, SET, AUTH and SELECT commands of the Redis protocol.
+func fakeRedis(t *testing.T, password string) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	var lock sync.Mutex
+	data := make(map[string]string)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func(conn net.Conn) {
+				defer conn.Close()
+
+				authed := password == ""
+				r := bufio.NewReader(conn)
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+
+					var args []string
+					for i := 0; i < n; i++ {
+						line, _ = r.ReadString('\n')
+						size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+						buf := make([]byte, size+2)
+						_, _ = io.ReadFull(r, buf)
+						args = append(args, string(buf[:size]))
+					}
+
+					lock.Lock()
+					switch {
+					case args[0] == "AUTH":
+						authed = args[len(args)-1] == password
+						if authed {
+							_, _ = io.WriteString(conn, "+OK\r\n")
+						} else {
+							_, _ = io.WriteString(conn, "-WRONGPASS invalid password\r\n")
+						}
+					case !authed:
+						_, _ = io.WriteString(conn, "-NOAUTH Authentication required\r\n")
+					case args[0] == "SELECT":
+						_, _ = io.WriteString(conn, "+OK\r\n")
+					case args[0] == "SET":
+						data[args[1]] = args[2]
+						_, _ = io.WriteString(conn, "+OK\r\n")
+					case args[0] == "GET":
+						if v, found := data[args[1]]; found {
+							_, _ = io.WriteString(conn, "$"+strconv.Itoa(len(v))+"\r\n"+v+"\r\n")
+						} else {
+							_, _ = io.WriteString(conn, "$-1\r\n")
+						}
+					}
+					lock.Unlock()
+				}
+			}(conn)
+		}
+	}()
+	return l.Addr().String()
+}
+
+func TestRedisStore(t *testing.T) {
+	addr := fakeRedis(t, "secret")
+
+	if s, err := NewRedisStore("redis://:wrong@" + addr + "/1"); err != nil {
+		t.Fatalf("Failed to create the store: %v", err)
+	} else if err := s.Set("key", []byte("value"), time.Minute); err == nil {
+		t.Error("Expected the wrong password to be rejected")
+	}
+
+	s, err := NewRedisStore("redis://:secret@" + addr + "/1")
+	if err != nil {
+		t.Fatalf("Failed to create the store: %v", err)
+	}
+	defer s.Close()
+
+	value := []byte("binary\r\n\x00value")
+	if err := s.Set("key", value, time.Minute); err != nil {
+		t.Fatalf("Failed to set the key: %v", err)
+	}
+	if v, found := s.Get("key"); !found || string(v) != string(value) {
+		t.Errorf("Unexpected value: %q, %v", v, found)
+	}
+	if _, found := s.Get("missing"); found {
+		t.Error("Expected the missing key to not be found")
+	}
+	if _, err := NewRedisStore("http://" + addr); err == nil {
+		t.Error("Expected an error for the URL scheme")
+	}
+}
+
+func TestCacheNamespace(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Dir = t.TempDir()
+	if WithNamespace(cfg, "acme") != nil {
+		t.Error("Expected no settings without the dns_cache section")
+	}
+
+	cfg.Options["dns_cache"] = map[string]interface{}{"store": "disk", "namespace": "engine"}
+	open := func(tenant string) *Cache {
+		c := config.NewConfig()
+		c.Dir = cfg.Dir
+		c.Options["dns_cache"] = WithNamespace(cfg, tenant)
+
+		cache, err := FromConfig(c)
+		if err != nil || cache == nil {
+			t.Fatalf("Failed to open the cache of %q: %v", tenant, err)
+		}
+		t.Cleanup(func() { _ = cache.Close() })
+		return cache
+	}
+	acme, other, server := open("acme"), open("other"), open("")
+
+	acme.Save(response("www.owasp.org", dns.TypeA, dns.RcodeSuccess, "www.owasp.org. 300 IN A 104.22.27.77"), "trusted")
+
+	q := new(dns.Msg)
+	q.SetQuestion("www.owasp.org.", dns.TypeA)
+	if acme.Lookup(q, "trusted") == nil {
+		t.Error("Expected the response to be cached for the tenant")
+	}
+	// The enumerations sharing the store do not answer the lookups of the other tenants
+	if other.Lookup(q, "trusted") != nil || server.Lookup(q, "trusted") != nil {
+		t.Error("Expected the namespaces to be separated")
+	}
+	if _, found := acme.store.Get("engine:acme:trusted:www.owasp.org:1"); !found {
+		t.Error("Expected the key to start with the namespace of the tenant")
+	}
+	if settings := cfg.Options["dns_cache"].(map[string]interface{}); settings["namespace"] != "engine" {
+		t.Errorf("The settings of the server were modified: %v", settings)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dnscache
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// FromConfig returns the Cache selected by the 'dns_cache' section of the configuration options, which
+// provides the 'store' (disk or redis), the 'path' of the disk store, the 'redis' URL, the 'max_ttl'
+// of the responses, whether the 'negative' answers are cached and the 'namespace' keeping the responses
+// apart from those of the other enumerations using the store. A nil Cache is returned when the section
+// is missing or the cache has not been enabled.
+func FromConfig(cfg *config.Config) (*Cache, error) {
+	cacheRaw, ok := cfg.Options["dns_cache"]
+	if !ok {
+		return nil, nil
+	}
+
+	settings, ok := cacheRaw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("dns_cache is not a map[string]interface{}")
+	}
+
+	if raw, ok := settings["enabled"]; ok {
+		enabled, ok := raw.(bool)
+		if !ok {
+			return nil, fmt.Errorf("dns_cache enabled is not a bool")
+		}
+		if !enabled {
+			return nil, nil
+		}
+	}
+
+	strs := make(map[string]string)
+	for _, key := range []string{"store", "path", "redis", "max_ttl", "namespace"} {
+		if raw, ok := settings[key]; ok {
+			str, ok := raw.(string)
+			if !ok {
+				return nil, fmt.Errorf("dns_cache %s is not a string", key)
+			}
+			strs[key] = str
+		}
+	}
+
+	c := &Cache{namespace: strs["namespace"], maxTTL: DefaultMaxTTL, negative: true}
+	if v := strs["max_ttl"]; v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < time.Second {
+			return nil, fmt.Errorf("dns_cache max_ttl must be a duration of at least one second")
+		}
+		c.maxTTL = d
+	}
+	if raw, ok := settings["negative"]; ok {
+		if c.negative, ok = raw.(bool); !ok {
+			return nil, fmt.Errorf("dns_cache negative is not a bool")
+		}
+	}
+
+	var err error
+	switch store := strs["store"]; store {
+	case "", "disk":
+		path := strs["path"]
+		if path == "" {
+			path = filepath.Join(config.OutputDirectory(cfg.Dir), DiskFile)
+		}
+		c.store, err = acquire("disk:"+path, func() (Store, error) { return NewDiskStore(path) })
+	case "redis":
+		if strs["redis"] == "" {
+			return nil, fmt.Errorf("dns_cache redis must provide the URL of the Redis server")
+		}
+		c.store, err = acquire("redis:"+strs["redis"], func() (Store, error) { return NewRedisStore(strs["redis"]) })
+	default:
+		return nil, fmt.Errorf("dns_cache store must be disk or redis, not %s", store)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("dns_cache: %v", err)
+	}
+	return c, nil
+}
+
+// WithNamespace returns a copy of the dns_cache section of the configuration options with the namespace
+// extended by the name, such as the tenant of an enumeration session, so the enumerations sharing the
+// store do not answer the lookups of each other. Nil is returned when the section is missing.
+func WithNamespace(cfg *config.Config, name string) map[string]interface{} {
+	settings, ok := cfg.Options["dns_cache"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	c := make(map[string]interface{}, len(settings)+1)
+	for k, v := range settings {
+		c[k] = v
+	}
+	if name != "" {
+		if ns, _ := c["namespace"].(string); ns != "" {
+			name = ns + ":" + name
+		}
+		c["namespace"] = name
+	}
+	return c
+}
@@ -0,0 +1,128 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dnscache
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DiskFile is the name of the file holding the cache in the output directory.
+const DiskFile = "dnscache.json"
+
+type diskEntry struct {
+	Value   []byte    `json:"value"`
+	Expires time.Time `json:"expires"`
+}
+
+// DiskStore holds the cache in memory and saves it to a file when closed, so the enumerations
+// using the same output directory share the responses.
+type DiskStore struct {
+	sync.Mutex
+	path    string
+	entries map[string]*diskEntry
+	changed map[string]struct{}
+}
+
+// NewDiskStore returns a DiskStore loaded from the file at the path, when it exists.
+func NewDiskStore(path string) (*DiskStore, error) {
+	d := &DiskStore{
+		path:    path,
+		changed: make(map[string]struct{}),
+	}
+
+	entries, err := readEntries(path)
+	if err != nil {
+		return nil, err
+	}
+	d.entries = entries
+	return d, nil
+}
+
+func readEntries(path string) (map[string]*diskEntry, error) {
+	entries := make(map[string]*diskEntry)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return entries, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for k, e := range entries {
+		if e == nil || !now.Before(e.Expires) {
+			delete(entries, k)
+		}
+	}
+	return entries, nil
+}
+
+// Get implements the Store interface.
+func (d *DiskStore) Get(key string) ([]byte, bool) {
+	d.Lock()
+	defer d.Unlock()
+
+	e, found := d.entries[key]
+	if !found || !time.Now().Before(e.Expires) {
+		return nil, false
+	}
+	return e.Value, true
+}
+
+// Set implements the Store interface.
+func (d *DiskStore) Set(key string, value []byte, ttl time.Duration) error {
+	d.Lock()
+	defer d.Unlock()
+
+	d.entries[key] = &diskEntry{Value: value, Expires: time.Now().Add(ttl)}
+	d.changed[key] = struct{}{}
+	return nil
+}
+
+// Close implements the Store interface. The responses saved by other processes since the file
+// was loaded are kept, unless this store saved a response for the same query.
+func (d *DiskStore) Close() error {
+	d.Lock()
+	defer d.Unlock()
+
+	if len(d.changed) == 0 {
+		return nil
+	}
+
+	entries, err := readEntries(d.path)
+	if err != nil {
+		entries = make(map[string]*diskEntry)
+	}
+	now := time.Now()
+	for k := range d.changed {
+		if e := d.entries[k]; now.Before(e.Expires) {
+			entries[k] = e
+		}
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(d.path), 0755); err != nil {
+		return err
+	}
+
+	tmp := d.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	d.changed = make(map[string]struct{})
+	return os.Rename(tmp, d.path)
+}
@@ -0,0 +1,208 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dnscache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	amassnet "github.com/owasp-amass/amass/v4/net"
+)
+
+const (
+	redisKeyPrefix = "amass:dns:"
+	redisTimeout   = 5 * time.Second
+)
+
+// RedisStore holds the cache in a Redis server shared by the enumerations of several hosts,
+// using the expiration of the Redis keys for the time to live of the responses.
+type RedisStore struct {
+	sync.Mutex
+	addr string
+	user string
+	pass string
+	db   int
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// NewRedisStore returns a RedisStore for the server at the URL (e.g. redis://:password@localhost:6379/0).
+func NewRedisStore(rawurl string) (*RedisStore, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "redis" {
+		return nil, fmt.Errorf("the Redis URL scheme must be redis, not %s", u.Scheme)
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "6379"
+	}
+
+	s := &RedisStore{addr: net.JoinHostPort(u.Hostname(), port)}
+	if u.User != nil {
+		s.user = u.User.Username()
+		s.pass, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if s.db, err = strconv.Atoi(db); err != nil || s.db < 0 {
+			return nil, fmt.Errorf("%s is not a valid Redis database number", db)
+		}
+	}
+	return s, nil
+}
+
+// Get implements the Store interface.
+func (s *RedisStore) Get(key string) ([]byte, bool) {
+	reply, err := s.do("GET", redisKeyPrefix+key)
+	if err != nil || reply == nil {
+		return nil, false
+	}
+	return reply, true
+}
+
+// Set implements the Store interface.
+func (s *RedisStore) Set(key string, value []byte, ttl time.Duration) error {
+	ms := strconv.FormatInt(int64(ttl/time.Millisecond), 10)
+
+	_, err := s.do("SET", redisKeyPrefix+key, string(value), "PX", ms)
+	return err
+}
+
+// Ping sends the PING command, connecting to the server when required.
+func (s *RedisStore) Ping() error {
+	_, err := s.do("PING")
+	return err
+}
+
+// Close implements the Store interface.
+func (s *RedisStore) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.disconnect()
+	return nil
+}
+
+// do sends the command, connecting to the server when required, and returns the bulk string reply.
+func (s *RedisStore) do(args ...string) ([]byte, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return nil, err
+		}
+	}
+
+	reply, err := s.command(args...)
+	var rerr *redisError
+	if err != nil && !errors.As(err, &rerr) {
+		s.disconnect()
+	}
+	return reply, err
+}
+
+func (s *RedisStore) connect() error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	conn, err := amassnet.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	s.r = bufio.NewReader(conn)
+
+	if s.pass != "" {
+		args := []string{"AUTH", s.pass}
+		if s.user != "" {
+			args = []string{"AUTH", s.user, s.pass}
+		}
+		if _, err := s.command(args...); err != nil {
+			s.disconnect()
+			return err
+		}
+	}
+	if s.db > 0 {
+		if _, err := s.command("SELECT", strconv.Itoa(s.db)); err != nil {
+			s.disconnect()
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *RedisStore) disconnect() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+		s.r = nil
+	}
+}
+
+type redisError struct {
+	msg string
+}
+
+func (e *redisError) Error() string { return "the Redis server returned an error: " + e.msg }
+
+// command writes the command as a RESP array and reads the reply, returning nil for the null and
+// simple replies.
+func (s *RedisStore) command(args ...string) ([]byte, error) {
+	_ = s.conn.SetDeadline(time.Now().Add(redisTimeout))
+	defer func() { _ = s.conn.SetDeadline(time.Time{}) }()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := io.WriteString(s.conn, b.String()); err != nil {
+		return nil, err
+	}
+
+	line, err := s.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("the Redis server sent an empty reply")
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return nil, nil
+	case '-':
+		return nil, &redisError{msg: line[1:]}
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(s.r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+	return nil, fmt.Errorf("the Redis server sent an unexpected reply: %q", line)
+}
@@ -0,0 +1,110 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/cloud/accounts"
+	"github.com/owasp-amass/amass/v4/requests"
+	oam "github.com/owasp-amass/open-asset-model"
+)
+
+// importCloudAccounts submits the inventory of the configured cloud accounts into the enumeration,
+// so the authoritative records and addresses are merged with the assets found by external discovery.
+func (e *Enumeration) importCloudAccounts() {
+	for _, acct := range e.accounts {
+		select {
+		case <-e.done:
+			return
+		default:
+		}
+
+		inv, err := acct.Collect(e.ctx)
+		if err != nil {
+			e.Config.Log.Printf("Cloud accounts: the %s account (%s): %v", acct.Name(), acct.Provider(), err)
+		}
+		if inv != nil {
+			e.importInventory(acct, inv)
+		}
+	}
+}
+
+func (e *Enumeration) importInventory(acct accounts.Account, inv *accounts.Inventory) {
+	var outside []string
+	for _, zone := range inv.Zones {
+		if e.Config.WhichDomain(zone) == "" {
+			outside = append(outside, zone)
+		}
+	}
+
+	var numNames int
+	names, records := inv.RecordsByName()
+	for _, name := range names {
+		domain := e.Config.WhichDomain(name)
+		if domain == "" {
+			continue
+		}
+
+		var answers []requests.DNSAnswer
+		for _, rec := range records[name] {
+			answers = append(answers, requests.DNSAnswer{
+				Name: rec.Name,
+				Type: int(rec.Type),
+				Data: rec.Data,
+			})
+		}
+
+		numNames++
+		e.srcStats.name(accounts.Source)
+		e.nameSrc.newName(&requests.DNSRequest{
+			Name:    name,
+			Domain:  domain,
+			Records: answers,
+			Source:  accounts.Source,
+		})
+	}
+
+	for _, name := range inv.Hostnames {
+		if domain := e.Config.WhichDomain(name); domain != "" {
+			e.nameSrc.newName(&requests.DNSRequest{
+				Name:   name,
+				Domain: domain,
+				Source: accounts.Source,
+			})
+			continue
+		}
+		// The names assigned by the provider are stored, although they are outside of the scope
+		if _, err := e.graph.UpsertFQDN(e.ctx, name); err == nil {
+			e.publishEntity(oam.FQDN, name, accounts.Source)
+		}
+	}
+
+	for _, addr := range inv.Addresses {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			continue
+		}
+
+		if _, err := e.graph.UpsertAddress(e.ctx, ip.String()); err == nil {
+			e.publishEntity(oam.IPAddress, ip.String(), accounts.Source)
+		}
+		// The addresses of the account belong to the organization, even when outside of the configured scope
+		e.nameSrc.newAddr(&requests.AddrRequest{
+			Address: ip.String(),
+			InScope: true,
+		})
+	}
+
+	e.Config.Log.Printf("Cloud accounts: imported %d names, %d hostnames and %d addresses from the %s account",
+		numNames, len(inv.Hostnames), len(inv.Addresses), acct.Name())
+	if len(outside) > 0 {
+		sort.Strings(outside)
+		e.Config.Log.Printf("Cloud accounts: the %s account serves zones outside of the scope: %s",
+			acct.Name(), strings.Join(outside, ", "))
+	}
+}
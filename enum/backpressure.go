@@ -0,0 +1,129 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/owasp-amass/amass/v4/throttle"
+	"github.com/owasp-amass/config/config"
+)
+
+// DefaultQueueSize is the number of requests each data source can have waiting to be dispatched.
+const DefaultQueueSize = 10000
+
+// The ways of handling the requests for a data source whose queue is full.
+const (
+	// BlockOverflow holds up the new requests until the queue has room, slowing down the enumeration
+	BlockOverflow = "block"
+	// ShedOverflow drops the requests with the lowest priority
+	ShedOverflow = "shed"
+)
+
+// DispatchOptions returns the size of the data source queues and the handling of the requests
+// once a queue is full, set by the 'dispatch' section of the configuration options. A zero size
+// is returned when the queues have been left unbounded.
+func DispatchOptions(cfg *config.Config) (int, string, error) {
+	dispatchRaw, ok := cfg.Options["dispatch"]
+	if !ok {
+		return DefaultQueueSize, BlockOverflow, nil
+	}
+
+	settings, ok := dispatchRaw.(map[string]interface{})
+	if !ok {
+		return 0, "", fmt.Errorf("dispatch is not a map[string]interface{}")
+	}
+
+	size := DefaultQueueSize
+	if raw, ok := settings["queue_size"]; ok {
+		n, ok := raw.(int)
+		if !ok || n < 0 {
+			return 0, "", fmt.Errorf("dispatch queue_size must be zero or a positive integer")
+		}
+		size = n
+	}
+
+	overflow := BlockOverflow
+	if raw, ok := settings["overflow"]; ok {
+		str, ok := raw.(string)
+		if !ok || (str != BlockOverflow && str != ShedOverflow) {
+			return 0, "", fmt.Errorf("dispatch overflow must be %s or %s", BlockOverflow, ShedOverflow)
+		}
+		overflow = str
+	}
+	return size, overflow, nil
+}
+
+// DispatchPriority returns the priority of the session set by the 'priority' entry of the 'dispatch' section of
+// the configuration options. The sessions running in the process share the rate of each data source in
+// proportion to their priority, so short interactive scans can run alongside long monitoring sessions.
+func DispatchPriority(cfg *config.Config) (int, error) {
+	dispatchRaw, ok := cfg.Options["dispatch"]
+	if !ok {
+		return throttle.DefaultPriority, nil
+	}
+
+	settings, ok := dispatchRaw.(map[string]interface{})
+	if !ok {
+		return 0, fmt.Errorf("dispatch is not a map[string]interface{}")
+	}
+
+	raw, ok := settings["priority"]
+	if !ok {
+		return throttle.DefaultPriority, nil
+	}
+
+	n, ok := raw.(int)
+	if !ok || n < 1 {
+		return 0, fmt.Errorf("dispatch priority must be a positive integer")
+	}
+	return n, nil
+}
+
+// dispatchGate holds up the requests sent to the data sources while any of their queues is full.
+type dispatchGate struct {
+	sync.Mutex
+	full map[string]struct{}
+	open chan struct{}
+}
+
+func newDispatchGate() *dispatchGate {
+	g := &dispatchGate{
+		full: make(map[string]struct{}),
+		open: make(chan struct{}),
+	}
+
+	close(g.open)
+	return g
+}
+
+// update records whether the queue of the data source is full.
+func (g *dispatchGate) update(name string, full bool) {
+	g.Lock()
+	defer g.Unlock()
+
+	wasOpen := len(g.full) == 0
+	if full {
+		g.full[name] = struct{}{}
+	} else {
+		delete(g.full, name)
+	}
+
+	isOpen := len(g.full) == 0
+	if wasOpen && !isOpen {
+		g.open = make(chan struct{})
+	} else if !wasOpen && isOpen {
+		close(g.open)
+	}
+}
+
+// wait returns a channel that is closed once none of the data source queues is full.
+func (g *dispatchGate) wait() <-chan struct{} {
+	g.Lock()
+	defer g.Unlock()
+
+	return g.open
+}
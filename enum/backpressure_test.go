@@ -0,0 +1,87 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"testing"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func TestDispatchOptions(t *testing.T) {
+	cfg := config.NewConfig()
+	if size, overflow, err := DispatchOptions(cfg); err != nil || size != DefaultQueueSize || overflow != BlockOverflow {
+		t.Errorf("Expected the default options, got %d and %s: %v", size, overflow, err)
+	}
+
+	cfg.Options["dispatch"] = map[string]interface{}{"queue_size": 500, "overflow": "shed"}
+	if size, overflow, err := DispatchOptions(cfg); err != nil || size != 500 || overflow != ShedOverflow {
+		t.Errorf("Expected a queue size of 500 and shedding, got %d and %s: %v", size, overflow, err)
+	}
+
+	for _, settings := range []map[string]interface{}{
+		{"queue_size": -1},
+		{"queue_size": "large"},
+		{"overflow": "drop"},
+	} {
+		cfg.Options["dispatch"] = settings
+		if _, _, err := DispatchOptions(cfg); err == nil {
+			t.Errorf("Expected an error for the settings %v", settings)
+		}
+	}
+}
+
+func TestDispatchPriority(t *testing.T) {
+	cfg := config.NewConfig()
+	if p, err := DispatchPriority(cfg); err != nil || p != 1 {
+		t.Errorf("Expected the default priority, got %d: %v", p, err)
+	}
+
+	cfg.Options["dispatch"] = map[string]interface{}{"priority": 4}
+	if p, err := DispatchPriority(cfg); err != nil || p != 4 {
+		t.Errorf("Expected a priority of 4, got %d: %v", p, err)
+	}
+
+	for _, bad := range []interface{}{0, "high"} {
+		cfg.Options["dispatch"] = map[string]interface{}{"priority": bad}
+		if _, err := DispatchPriority(cfg); err == nil {
+			t.Errorf("Expected an error for the priority %v", bad)
+		}
+	}
+}
+
+func TestDispatchGate(t *testing.T) {
+	g := newDispatchGate()
+
+	isOpen := func() bool {
+		select {
+		case <-g.wait():
+			return true
+		default:
+			return false
+		}
+	}
+
+	if !isOpen() {
+		t.Error("The gate was closed before a queue was full")
+	}
+	g.update("crtsh", true)
+	g.update("RADb", true)
+	if isOpen() {
+		t.Error("The gate was open while the queues were full")
+	}
+
+	closed := g.wait()
+	g.update("crtsh", false)
+	if isOpen() {
+		t.Error("The gate was open while a queue was still full")
+	}
+	g.update("RADb", false)
+	select {
+	case <-closed:
+	default:
+		t.Error("The requests held up by the gate were not released")
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/cloud"
+	"github.com/owasp-amass/amass/v4/findings"
+)
+
+// CloudAssetFinding is the finding type used to tag the names served by a cloud provider.
+const CloudAssetFinding = "cloud_asset"
+
+// reportCloudAssets attributes the names discovered by the enumeration to the cloud providers serving them.
+func (e *Enumeration) reportCloudAssets() {
+	if e.cloud == nil {
+		return
+	}
+
+	assets := cloud.Assets(e.ctx, e.graph, e.cloud, e.Config.Domains(), e.Config.CollectionStartTime.UTC())
+	if len(assets) == 0 {
+		return
+	}
+
+	// Each name is tagged once, preferring the attributions that identify the service
+	best := make(map[string]*cloud.Asset)
+	var names []string
+	for _, a := range assets {
+		if cur, found := best[a.Name]; !found {
+			best[a.Name] = a
+			names = append(names, a.Name)
+		} else if cur.Service == "" && a.Service != "" {
+			best[a.Name] = a
+		}
+	}
+
+	store := e.Sys.Findings()
+	for _, name := range names {
+		a := best[name]
+		details := map[string]string{
+			"provider": a.Provider,
+			"evidence": a.Evidence,
+		}
+		if a.Region != "" {
+			details["region"] = a.Region
+		}
+		if a.Service != "" {
+			details["service"] = a.Service
+		}
+		if a.Target != "" {
+			details["target"] = a.Target
+		}
+		if a.Address != "" {
+			details["address"] = a.Address
+		}
+
+		if _, err := store.Add(&findings.Finding{
+			Type:        CloudAssetFinding,
+			Asset:       name,
+			Severity:    findings.Info,
+			Description: cloudDescription(a),
+			Source:      "Amass",
+			Details:     details,
+		}); err != nil {
+			e.Config.Log.Printf("Failed to save the cloud asset finding: %v", err)
+		}
+	}
+	e.Config.Log.Printf("Cloud attribution: %d discovered names are served by cloud providers", len(names))
+}
+
+func cloudDescription(a *cloud.Asset) string {
+	desc := "The name is served by " + strings.TrimSpace(a.Provider+" "+a.Service)
+	if a.Region != "" {
+		desc += " in " + a.Region
+	}
+
+	via := a.Target
+	if via == "" {
+		via = a.Address
+	}
+	return desc + ", since " + via + " matches the " + a.Evidence
+}
@@ -0,0 +1,40 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"time"
+)
+
+// observe records the source reporting the name. The resolution of an in-scope
+// name is also observed as the DNS source, and confirms the name.
+func (e *Enumeration) observe(name, source string, resolved bool) {
+	if e.observed == nil {
+		return
+	}
+
+	now := time.Now()
+	e.observed.Observe(name, source, now)
+	if resolved && e.Config.IsDomainInScope(name) {
+		e.observed.Observe(name, dnsEventSource, now)
+		e.observed.Confirm(name)
+	}
+}
+
+// saveConfidence saves the sources that reported the names, and logs the names below the threshold.
+func (e *Enumeration) saveConfidence() {
+	var low int
+	scores := e.observed.Scores(e.scorer, time.Now())
+	for _, score := range scores {
+		if e.scorer.Suppressed(score) {
+			low++
+		}
+	}
+
+	e.Config.Log.Printf("Confidence: %d names were scored, and %d are below the threshold", len(scores), low)
+	if err := e.observed.Save(); err != nil {
+		e.Config.Log.Printf("Failed to save the confidence observations: %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/shared"
+	"github.com/owasp-amass/resolve"
+)
+
+// DanglingCNAMEFinding is the finding type used for the names aliased to a target that does not exist.
+const DanglingCNAMEFinding = "dangling_cname"
+
+// danglingChecker reports the CNAME records of the enumeration that point to nonexistent names,
+// since anyone registering the target can serve content under the aliased name.
+type danglingChecker struct {
+	sync.Mutex
+	enum *Enumeration
+	seen map[string]struct{}
+	wg   sync.WaitGroup
+	num  int
+}
+
+func newDanglingChecker(e *Enumeration) *danglingChecker {
+	return &danglingChecker{
+		enum: e,
+		seen: make(map[string]struct{}),
+	}
+}
+
+// check queries the trusted resolvers for the target of the CNAME record once per enumeration.
+func (dc *danglingChecker) check(ctx context.Context, name, target string) {
+	if dc == nil {
+		return
+	}
+
+	dc.Lock()
+	if _, found := dc.seen[name]; found {
+		dc.Unlock()
+		return
+	}
+	dc.seen[name] = struct{}{}
+	dc.Unlock()
+
+	dc.wg.Add(1)
+	go func() {
+		defer dc.wg.Done()
+
+		if !dc.nxdomain(ctx, target) {
+			return
+		}
+		// The takeover checks of the data sources identify the services that allow the target to be claimed
+		dc.enum.Sys.Shared().Publish(&shared.Entry{
+			Topic:  shared.DanglingCNAME,
+			Key:    name,
+			Value:  target,
+			Source: "Amass",
+		})
+		if _, err := dc.enum.Sys.Findings().Add(&findings.Finding{
+			Type:        DanglingCNAMEFinding,
+			Asset:       name,
+			Severity:    findings.Medium,
+			Description: fmt.Sprintf("The CNAME record points to %s, which does not exist", target),
+			Source:      "Amass",
+			Details:     map[string]string{"target": target},
+		}); err != nil {
+			dc.enum.Config.Log.Printf("Failed to save the dangling CNAME finding: %v", err)
+			return
+		}
+
+		dc.Lock()
+		dc.num++
+		dc.Unlock()
+	}()
+}
+
+// nxdomain returns true when the trusted resolvers report that the name does not exist.
+func (dc *danglingChecker) nxdomain(ctx context.Context, name string) bool {
+	msg := resolve.QueryMsg(name, dns.TypeA)
+
+	for i := 0; i < maxDNSQueryAttempts; i++ {
+		select {
+		case <-ctx.Done():
+			return false
+		default:
+		}
+
+		dc.enum.Sys.Budget().SpendDNS(budgetSource)
+		resp, err := dc.enum.Sys.TrustedResolvers().QueryBlocking(ctx, msg)
+		if err != nil {
+			continue
+		}
+		switch resp.Rcode {
+		case dns.RcodeNameError:
+			return true
+		case dns.RcodeSuccess:
+			return false
+		}
+	}
+	return false
+}
+
+// wait blocks until the checks of the CNAME targets have finished, and logs the results.
+func (dc *danglingChecker) wait() {
+	if dc == nil {
+		return
+	}
+
+	dc.wg.Wait()
+	dc.Lock()
+	defer dc.Unlock()
+
+	if dc.num > 0 {
+		dc.enum.Config.Log.Printf("Dangling CNAME records: %d names point to targets that do not exist", dc.num)
+	}
+}
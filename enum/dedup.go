@@ -0,0 +1,203 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/datasrcs"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	// DefaultDedupWindow is the period during which the same asset cannot trigger the data sources again.
+	DefaultDedupWindow = 5 * time.Minute
+	// DefaultDedupEntries is the number of requests remembered by the deduplication.
+	DefaultDedupEntries = 1000000
+)
+
+// DedupWindow returns the window set by the 'dedup' section of the configuration options.
+// A zero duration is returned when the deduplication of data source requests has been disabled.
+func DedupWindow(cfg *config.Config) (time.Duration, error) {
+	dedupRaw, ok := cfg.Options["dedup"]
+	if !ok {
+		return DefaultDedupWindow, nil
+	}
+
+	settings, ok := dedupRaw.(map[string]interface{})
+	if !ok {
+		return 0, fmt.Errorf("dedup is not a map[string]interface{}")
+	}
+
+	raw, ok := settings["window"]
+	if !ok {
+		return DefaultDedupWindow, nil
+	}
+
+	str, ok := raw.(string)
+	if !ok {
+		return 0, fmt.Errorf("dedup window is not a string")
+	}
+
+	d, err := time.ParseDuration(str)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("dedup window is not a valid duration: %s", str)
+	}
+	return d, nil
+}
+
+// DedupPerSource returns true when the 'per_source' key of the 'dedup' section enables dropping the
+// requests already sent to a data source during the enumeration.
+func DedupPerSource(cfg *config.Config) (bool, error) {
+	dedupRaw, ok := cfg.Options["dedup"]
+	if !ok {
+		return false, nil
+	}
+
+	settings, ok := dedupRaw.(map[string]interface{})
+	if !ok {
+		return false, fmt.Errorf("dedup is not a map[string]interface{}")
+	}
+
+	raw, ok := settings["per_source"]
+	if !ok {
+		return false, nil
+	}
+
+	enabled, ok := raw.(bool)
+	if !ok {
+		return false, fmt.Errorf("dedup per_source is not a bool")
+	}
+	return enabled, nil
+}
+
+// DedupMaxEntries returns the number of requests remembered by the deduplication, set by the
+// 'max_entries' key of the 'dedup' section.
+func DedupMaxEntries(cfg *config.Config) (int, error) {
+	dedupRaw, ok := cfg.Options["dedup"]
+	if !ok {
+		return DefaultDedupEntries, nil
+	}
+
+	settings, ok := dedupRaw.(map[string]interface{})
+	if !ok {
+		return 0, fmt.Errorf("dedup is not a map[string]interface{}")
+	}
+
+	raw, ok := settings["max_entries"]
+	if !ok {
+		return DefaultDedupEntries, nil
+	}
+
+	max, ok := raw.(int)
+	if !ok || max <= 0 {
+		return 0, fmt.Errorf("dedup max_entries must be a positive integer")
+	}
+	return max, nil
+}
+
+// requestDeduper drops the data source requests for an asset that was already dispatched within the
+// window, such as a name emitted by several data sources, and the requests already sent to a data source
+// when the per source deduplication is enabled. The least recently seen requests are forgotten once
+// the deduper holds the maximum number of entries.
+type requestDeduper struct {
+	sync.Mutex
+	window    time.Duration
+	perSource bool
+	max       int
+	entries   map[string]*list.Element
+	order     *list.List
+	dropped   int
+}
+
+type dedupEntry struct {
+	key  string
+	seen time.Time
+}
+
+func newRequestDeduper(window time.Duration, perSource bool, max int) *requestDeduper {
+	if max <= 0 {
+		max = DefaultDedupEntries
+	}
+
+	return &requestDeduper{
+		window:    window,
+		perSource: perSource,
+		max:       max,
+		entries:   make(map[string]*list.Element),
+		order:     list.New(),
+	}
+}
+
+// allow returns true if the request should be dispatched to the data sources.
+func (d *requestDeduper) allow(req interface{}) bool {
+	if d == nil || d.window <= 0 {
+		return true
+	}
+
+	key := datasrcs.RequestKey(req)
+	if key == "" {
+		return true
+	}
+	if d.seen("\x00"+key, d.window) {
+		d.Lock()
+		d.dropped++
+		d.Unlock()
+		return false
+	}
+	return true
+}
+
+// first returns true if the request has not been sent to the data source during the enumeration.
+// All requests are sent when the per source deduplication is disabled.
+func (d *requestDeduper) first(source string, req interface{}) bool {
+	if d == nil || !d.perSource {
+		return true
+	}
+
+	key := datasrcs.RequestKey(req)
+	if key == "" {
+		return true
+	}
+	return !d.seen(source+"\x00"+key, 0)
+}
+
+// seen records the key and returns true when it was recorded within the ttl, or at any
+// time during the enumeration when the ttl is zero.
+func (d *requestDeduper) seen(key string, ttl time.Duration) bool {
+	d.Lock()
+	defer d.Unlock()
+
+	now := time.Now()
+	if elem, found := d.entries[key]; found {
+		d.order.MoveToFront(elem)
+
+		entry := elem.Value.(*dedupEntry)
+		if ttl <= 0 || now.Sub(entry.seen) <= ttl {
+			return true
+		}
+		entry.seen = now
+		return false
+	}
+
+	d.entries[key] = d.order.PushFront(&dedupEntry{key: key, seen: now})
+	if d.order.Len() > d.max {
+		oldest := d.order.Back()
+		d.order.Remove(oldest)
+		delete(d.entries, oldest.Value.(*dedupEntry).key)
+	}
+	return false
+}
+
+// Dropped returns the number of requests that were not dispatched again within the window.
+func (d *requestDeduper) Dropped() int {
+	d.Lock()
+	defer d.Unlock()
+
+	return d.dropped
+}
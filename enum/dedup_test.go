@@ -0,0 +1,138 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestRequestDeduper(t *testing.T) {
+	d := newRequestDeduper(50*time.Millisecond, false, 0)
+
+	if !d.allow(&requests.ResolvedRequest{Name: "www.owasp.org"}) {
+		t.Error("The first request for the name was dropped")
+	}
+	if d.allow(&requests.ResolvedRequest{Name: "WWW.owasp.org"}) {
+		t.Error("The duplicate request within the window was dispatched")
+	}
+	if !d.allow(&requests.SubdomainRequest{Name: "www.owasp.org", Times: 1}) {
+		t.Error("A request of another type for the name was dropped")
+	}
+	if !d.allow(&requests.SubdomainRequest{Name: "www.owasp.org", Times: 2}) {
+		t.Error("A subdomain request with a new count was dropped")
+	}
+
+	time.Sleep(75 * time.Millisecond)
+	if !d.allow(&requests.ResolvedRequest{Name: "www.owasp.org"}) {
+		t.Error("The request after the window expired was dropped")
+	}
+	if n := d.Dropped(); n != 1 {
+		t.Errorf("Expected 1 dropped request, got %d", n)
+	}
+
+	disabled := newRequestDeduper(0, false, 0)
+	if !disabled.allow(&requests.AddrRequest{Address: "192.0.2.1"}) || !disabled.allow(&requests.AddrRequest{Address: "192.0.2.1"}) {
+		t.Error("Requests were dropped with the deduplication disabled")
+	}
+}
+
+func TestDedupWindow(t *testing.T) {
+	cfg := config.NewConfig()
+	if w, err := DedupWindow(cfg); err != nil || w != DefaultDedupWindow {
+		t.Errorf("Expected the default window, got %s: %v", w, err)
+	}
+
+	cfg.Options["dedup"] = map[string]interface{}{"window": "0s"}
+	if w, err := DedupWindow(cfg); err != nil || w != 0 {
+		t.Errorf("Expected the window to be disabled, got %s: %v", w, err)
+	}
+
+	cfg.Options["dedup"] = map[string]interface{}{"window": "soon"}
+	if _, err := DedupWindow(cfg); err == nil {
+		t.Error("Expected an error for the invalid duration")
+	}
+}
+
+func TestSourceDedup(t *testing.T) {
+	d := newRequestDeduper(0, true, 0)
+
+	req := &requests.DNSRequest{Name: "www.owasp.org", Domain: "owasp.org"}
+	if !d.first("crtsh", req) {
+		t.Error("The first request to the data source was dropped")
+	}
+	if d.first("crtsh", &requests.DNSRequest{Name: "WWW.owasp.org", Domain: "owasp.org"}) {
+		t.Error("The request already sent to the data source was not dropped")
+	}
+	if !d.first("RADb", req) {
+		t.Error("The request to another data source was dropped")
+	}
+	if !d.first("crtsh", &requests.ResolvedRequest{Name: "www.owasp.org"}) {
+		t.Error("A request of another type for the name was dropped")
+	}
+
+	disabled := newRequestDeduper(DefaultDedupWindow, false, 0)
+	if !disabled.first("crtsh", req) || !disabled.first("crtsh", req) {
+		t.Error("Requests were dropped with the per source deduplication disabled")
+	}
+}
+
+func TestDedupEviction(t *testing.T) {
+	d := newRequestDeduper(time.Hour, true, 2)
+
+	a := &requests.ResolvedRequest{Name: "a.owasp.org"}
+	b := &requests.ResolvedRequest{Name: "b.owasp.org"}
+	c := &requests.ResolvedRequest{Name: "c.owasp.org"}
+	for _, req := range []interface{}{a, b} {
+		if !d.allow(req) {
+			t.Errorf("The first request was dropped: %v", req)
+		}
+	}
+	// The request seen again becomes the most recent, so the other request is forgotten first
+	if d.allow(a) {
+		t.Error("The duplicate request was dispatched")
+	}
+	if !d.allow(c) {
+		t.Error("The first request was dropped")
+	}
+	if !d.allow(b) {
+		t.Error("The least recently seen request was not forgotten")
+	}
+	if d.allow(c) {
+		t.Error("The most recent request was forgotten")
+	}
+	if len(d.entries) != 2 || d.order.Len() != 2 {
+		t.Errorf("The deduper holds %d entries beyond its maximum", len(d.entries))
+	}
+}
+
+func TestDedupPerSource(t *testing.T) {
+	cfg := config.NewConfig()
+	if enabled, err := DedupPerSource(cfg); err != nil || enabled {
+		t.Errorf("Expected the per source deduplication to be disabled, got %v: %v", enabled, err)
+	}
+	if max, err := DedupMaxEntries(cfg); err != nil || max != DefaultDedupEntries {
+		t.Errorf("Expected the default maximum entries, got %d: %v", max, err)
+	}
+
+	cfg.Options["dedup"] = map[string]interface{}{"per_source": true, "max_entries": 5000}
+	if enabled, err := DedupPerSource(cfg); err != nil || !enabled {
+		t.Errorf("Expected the per source deduplication to be enabled, got %v: %v", enabled, err)
+	}
+	if max, err := DedupMaxEntries(cfg); err != nil || max != 5000 {
+		t.Errorf("Expected 5000 maximum entries, got %d: %v", max, err)
+	}
+
+	cfg.Options["dedup"] = map[string]interface{}{"per_source": "no", "max_entries": 0}
+	if _, err := DedupPerSource(cfg); err == nil {
+		t.Error("Expected an error for the invalid value")
+	}
+	if _, err := DedupMaxEntries(cfg); err == nil {
+		t.Error("Expected an error for the invalid maximum entries")
+	}
+}
@@ -26,24 +26,26 @@ const (
 	maximumBackoffDelay time.Duration = 4 * time.Second
 )
 
-// FwdQueryTypes include the DNS record types that are queried for a discovered name.
+// FwdQueryTypes include the DNS record types that can be queried to resolve a discovered name, in the order
+// they are queried. The types selected by the 'dns_record_types' option are queried.
 var FwdQueryTypes = []uint16{
 	dns.TypeCNAME,
 	dns.TypeA,
 	dns.TypeAAAA,
 }
 
-var fwdQueryTypesLookup = map[uint16]int{dns.TypeCNAME: 0, dns.TypeA: 1, dns.TypeAAAA: 2}
-
 type req struct {
 	Ctx        context.Context
 	Data       pipeline.Data
 	Qtype      uint16
+	Zone       string
 	Attempts   int
 	Servfails  int
 	InScope    bool
 	Sent       bool
 	HasRecords bool
+	Confirmed  bool
+	Rejected   bool
 }
 
 // dnsTask is the task that handles all DNS name resolution requests within the pipeline.
@@ -60,6 +62,7 @@ type dnsTask struct {
 	resps     chan *dns.Msg
 	respQueue queue.Queue
 	release   chan struct{}
+	zones     *zoneLimiter
 }
 
 // newDNSTask returns a dNSTask specific to the provided Enumeration.
@@ -89,6 +92,9 @@ func newDNSTask(e *Enumeration, trusted bool) *dnsTask {
 	for i := 0; i < plen; i++ {
 		dt.release <- struct{}{}
 	}
+	if e.zoneMax > 0 {
+		dt.zones = newZoneLimiter(e.zoneMax, e.adaptive, e.cuts)
+	}
 
 	go dt.processResponses()
 	go dt.moveResponsesToQueue()
@@ -103,6 +109,13 @@ func (dt *dnsTask) stop() {
 	}
 	close(dt.done)
 	// TODO: empty the channel and queue to delete requests
+
+	if parks, cutbacks, reduced := dt.zones.stats(); parks > 0 || cutbacks > 0 {
+		dt.enum.Config.Log.Printf("Zone limits: %d names waited for their zone on the %s DNS task, and the limits were reduced %d times", parks, dt.trust, cutbacks)
+		if len(reduced) > 0 {
+			dt.enum.Config.Log.Printf("Zone limits: the %s DNS task ended with reduced limits for %s", dt.trust, strings.Join(reduced, ", "))
+		}
+	}
 }
 
 func (dt *dnsTask) moveResponsesToQueue() {
@@ -112,6 +125,7 @@ func (dt *dnsTask) moveResponsesToQueue() {
 			return
 		case r := <-dt.resps:
 			if r != nil {
+				dt.enum.dnsCache.Save(r, dt.trust)
 				dt.respQueue.Append(r)
 			}
 		}
@@ -169,26 +183,59 @@ func (dt *dnsTask) Process(ctx context.Context, data pipeline.Data, tp pipeline.
 	})
 
 	if v, ok := data.(*requests.DNSRequest); ok {
-		qtype := FwdQueryTypes[0]
-		msg := resolve.QueryMsg(v.Name, qtype)
-		k := key(msg.Id, msg.Question[0].Name)
-
-		if dt.addReqWithIncrement(k, &req{
-			Ctx:        ctx,
-			Data:       data.Clone(),
-			Qtype:      qtype,
-			Attempts:   1,
-			HasRecords: len(v.Records) > 0,
-		}) {
-			dt.pool.Query(ctx, msg, dt.resps)
-		} else {
-			dt.enum.Config.Log.Printf("Failed to enter %s into the request registry on the %s DNS task", msg.Question[0].Name, dt.trust)
+		v = v.Clone().(*requests.DNSRequest)
+		zone := dt.zones.zone(v.Name, v.Domain)
+		// The names over the limit of their zone are queried once a name of the zone has been resolved
+		if dt.zones.acquire(zone, func() { dt.query(ctx, v, zone) }) {
+			dt.query(ctx, v, zone)
 		}
 		return nil, nil
 	}
 	return data, nil
 }
 
+func (dt *dnsTask) query(ctx context.Context, v *requests.DNSRequest, zone string) {
+	select {
+	case <-ctx.Done():
+		dt.zones.release(zone)
+		return
+	case <-dt.done:
+		dt.zones.release(zone)
+		return
+	default:
+	}
+
+	qtype := dt.enum.rrtypes.forward[0]
+	msg := resolve.QueryMsg(v.Name, qtype)
+	k := key(msg.Id, msg.Question[0].Name)
+
+	if dt.addReqWithIncrement(k, &req{
+		Ctx:        ctx,
+		Data:       v,
+		Qtype:      qtype,
+		Zone:       zone,
+		Attempts:   1,
+		HasRecords: len(v.Records) > 0,
+	}) {
+		dt.send(ctx, msg)
+	} else {
+		dt.zones.release(zone)
+		dt.enum.Config.Log.Printf("Failed to enter %s into the request registry on the %s DNS task", msg.Question[0].Name, dt.trust)
+	}
+}
+
+// send queries the resolvers for the message, unless the DNS cache holds a response saved by an
+// earlier enumeration, which is processed in place of the response of the resolvers.
+func (dt *dnsTask) send(ctx context.Context, msg *dns.Msg) {
+	if resp := dt.enum.dnsCache.Lookup(msg, dt.trust); resp != nil {
+		dt.respQueue.Append(resp)
+		return
+	}
+
+	dt.enum.Sys.Budget().SpendDNS(budgetSource)
+	dt.pool.Query(ctx, msg, dt.resps)
+}
+
 func (dt *dnsTask) nextStage(ctx context.Context, data pipeline.Data) {
 	dt.Lock()
 	params := dt.params
@@ -255,7 +302,13 @@ func (dt *dnsTask) delReq(key string) *req {
 func (dt *dnsTask) delReqWithDecrement(key string) {
 	if req := dt.delReq(key); req != nil {
 		dt.release <- struct{}{}
+		dt.zones.release(req.Zone)
 
+		if dt.trusted {
+			if v, ok := req.Data.(*requests.DNSRequest); ok {
+				dt.enum.validator.settle(req.Ctx, v.Name, req.Confirmed, req.Rejected)
+			}
+		}
 		if !req.Sent && (req.InScope || req.HasRecords) {
 			dt.nextStage(req.Ctx, req.Data)
 		}
@@ -285,10 +338,13 @@ func (dt *dnsTask) processResp(resp *dns.Msg) {
 		dt.enum.Config.Log.Printf("Failed to find %s in the request registry on the %s DNS task", resp.Question[0].Name, dt.trust)
 		return
 	}
+	// The servers of a zone failing to answer are sent fewer queries at the same time
+	dt.zones.answered(entry.Zone, resp.Rcode == dns.RcodeServerFailure || resp.Rcode == dns.RcodeRefused)
 
 	switch resp.Rcode {
 	// check if the response indicates that the name doesn't exist
 	case dns.RcodeNameError:
+		entry.Rejected = dt.trusted
 		dt.delReqWithDecrement(k)
 		return
 	// the rest are errors that should not continue across many resolvers
@@ -333,7 +389,7 @@ func (dt *dnsTask) retry(msg *dns.Msg, id uint16, entry *req) {
 		dt.delReq(k)
 		dt.addReq(key(msg.Id, msg.Question[0].Name), entry)
 		time.Sleep(resolve.TruncatedExponentialBackoff(entry.Attempts-1, initialBackoffDelay, maximumBackoffDelay))
-		dt.pool.Query(entry.Ctx, msg, dt.resps)
+		dt.send(entry.Ctx, msg)
 	} else {
 		dt.enum.Config.Log.Printf("%s was dropped after failing to resolve %d times on the %s DNS task", msg.Question[0].Name, entry.Attempts-1, dt.trust)
 		dt.delReqWithDecrement(k)
@@ -343,16 +399,42 @@ func (dt *dnsTask) retry(msg *dns.Msg, id uint16, entry *req) {
 func (dt *dnsTask) nextType(ctx context.Context, name string, id, qtype uint16, entry *req) {
 	k := key(id, name)
 
-	if idx, found := fwdQueryTypesLookup[qtype]; found && idx+1 < len(FwdQueryTypes) {
+	if next, found := dt.enum.rrtypes.next(qtype); found {
 		entry.Attempts = 1
 		entry.Servfails = 0
-		entry.Qtype = FwdQueryTypes[idx+1]
+		entry.Qtype = next
 		msg := resolve.QueryMsg(name, entry.Qtype)
 		dt.delReq(k)
 		dt.addReq(key(msg.Id, msg.Question[0].Name), entry)
-		dt.pool.Query(ctx, msg, dt.resps)
+		dt.send(ctx, msg)
 	} else {
+		// the trusted resolvers found no records for the name
+		entry.Rejected = dt.trusted && !entry.Confirmed
+		dt.finish(ctx, k, entry, false)
+	}
+}
+
+// finish releases the request once the forward queries are complete. The other record types
+// selected for the names resolved by the trusted resolvers are queried first, unless the name is an alias.
+func (dt *dnsTask) finish(ctx context.Context, k string, entry *req, alias bool) {
+	v, ok := entry.Data.(*requests.DNSRequest)
+	if !ok || !dt.trusted || !entry.Confirmed || alias || len(dt.enum.rrtypes.name) == 0 {
 		dt.delReqWithDecrement(k)
+		return
+	}
+
+	go func() {
+		dt.queryNameTypes(ctx, v)
+		dt.delReqWithDecrement(k)
+	}()
+}
+
+// queryNameTypes appends the records of the other types selected for the resolved name, such as the TXT and CAA records.
+func (dt *dnsTask) queryNameTypes(ctx context.Context, req *requests.DNSRequest) {
+	for _, qtype := range dt.enum.rrtypes.name {
+		if resp, err := dt.enum.dnsQuery(ctx, req.Name, qtype, dt.pool, maxDNSQueryAttempts); err == nil && resp != nil {
+			req.Records = append(req.Records, extractRecords(resp, qtype)...)
+		}
 	}
 }
 
@@ -371,6 +453,7 @@ func (dt *dnsTask) processFwdRequest(ctx context.Context, resp *dns.Msg, name st
 
 	k := key(resp.Id, resp.Question[0].Name)
 	if !dt.trusted {
+		dt.enum.validator.expect(name, qtype)
 		dt.nextStage(ctx, req)
 		entry.Sent = true
 		dt.delReqWithDecrement(k)
@@ -384,24 +467,37 @@ func (dt *dnsTask) processFwdRequest(ctx context.Context, resp *dns.Msg, name st
 
 	req.Records = append(req.Records, convertAnswers(rr)...)
 	entry.HasRecords = len(req.Records) > 0
+	entry.Confirmed = true
 	// are there additional record types to query for?
-	if idx, found := fwdQueryTypesLookup[qtype]; found && qtype != dns.TypeCNAME && idx+1 < len(FwdQueryTypes) {
+	if _, found := dt.enum.rrtypes.next(qtype); found && qtype != dns.TypeCNAME {
 		dt.nextType(ctx, name, resp.Id, qtype, entry)
 		return
 	}
 	// delReq will send the request to the next stage if it has records
-	dt.delReqWithDecrement(k)
+	dt.finish(ctx, k, entry, qtype == dns.TypeCNAME)
 }
 
 func (dt *dnsTask) subdomainQueries(ctx context.Context, req *requests.DNSRequest, tp pipeline.TaskParams) {
-	ch := make(chan []requests.DNSAnswer, 4)
+	if req.Name == req.Domain {
+		dt.enum.mail.collect(ctx, req.Domain)
+	}
 
-	go dt.queryNS(ctx, req.Name, req.Domain, ch, tp)
-	go dt.queryMX(ctx, req.Name, ch, tp)
-	go dt.querySOA(ctx, req.Name, ch, tp)
-	go dt.querySPF(ctx, req.Name, ch, tp)
+	ch := make(chan []requests.DNSAnswer, 4)
+	// Only the record types selected by the configuration are queried
+	var queries int
+	for qtype, query := range map[uint16]func(){
+		dns.TypeNS:  func() { dt.queryNS(ctx, req.Name, req.Domain, ch, tp) },
+		dns.TypeMX:  func() { dt.queryMX(ctx, req.Name, ch, tp) },
+		dns.TypeSOA: func() { dt.querySOA(ctx, req.Name, ch, tp) },
+		dns.TypeSPF: func() { dt.querySPF(ctx, req.Name, ch, tp) },
+	} {
+		if dt.enum.rrtypes.zoneQueried(qtype) {
+			go query()
+			queries++
+		}
+	}
 
-	for i := 0; i < 4; i++ {
+	for i := 0; i < queries; i++ {
 		if rr := <-ch; rr != nil {
 			req.Records = append(req.Records, rr...)
 		}
@@ -419,6 +515,10 @@ func (dt *dnsTask) queryNS(ctx context.Context, name, domain string, ch chan []r
 			if rr := resolve.AnswersByType(ans, dns.TypeNS); len(rr) > 0 {
 				var records []requests.DNSAnswer
 
+				if name != domain {
+					dt.enum.cuts.add(name)
+				}
+
 				for _, record := range rr {
 					pipeline.SendData(ctx, "active", &requests.ZoneXFRRequest{
 						Name:   name,
@@ -462,6 +562,7 @@ func (dt *dnsTask) querySOA(ctx context.Context, name string, ch chan []requests
 					records = append(records, convertAnswers([]*resolve.ExtractedAnswer{a})...)
 				}
 				ch <- records
+				return
 			}
 		}
 	}
@@ -507,6 +608,7 @@ func (e *Enumeration) dnsQuery(ctx context.Context, name string, qtype uint16, r
 		default:
 		}
 
+		e.Sys.Budget().SpendDNS(budgetSource)
 		resp, err := r.QueryBlocking(ctx, msg)
 		if err != nil {
 			continue
@@ -528,6 +630,26 @@ func (e *Enumeration) wildcardDetected(ctx context.Context, req *requests.DNSReq
 	return e.Sys.TrustedResolvers().WildcardDetected(ctx, resp, req.Domain)
 }
 
+// extractRecords returns the answers of the type, including the CAA records that are not extracted by the resolve package.
+func extractRecords(resp *dns.Msg, qtype uint16) []requests.DNSAnswer {
+	if qtype != dns.TypeCAA {
+		return convertAnswers(resolve.AnswersByType(resolve.ExtractAnswers(resp), qtype))
+	}
+
+	var answers []requests.DNSAnswer
+	for _, rr := range resp.Answer {
+		if caa, ok := rr.(*dns.CAA); ok {
+			answers = append(answers, requests.DNSAnswer{
+				Name: strings.ToLower(resolve.RemoveLastDot(caa.Hdr.Name)),
+				Type: int(dns.TypeCAA),
+				TTL:  int(caa.Hdr.Ttl),
+				Data: fmt.Sprintf("%d %s %q", caa.Flag, strings.ToLower(caa.Tag), caa.Value),
+			})
+		}
+	}
+	return answers
+}
+
 func convertAnswers(ans []*resolve.ExtractedAnswer) []requests.DNSAnswer {
 	var answers []requests.DNSAnswer
 
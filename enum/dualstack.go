@@ -0,0 +1,71 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/analysis"
+	"github.com/owasp-amass/amass/v4/findings"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+)
+
+// IPv6ExposureFinding is the finding type used to tag the names with services only exposed over IPv6.
+const IPv6ExposureFinding = "ipv6_service_exposure"
+
+// reportDualStack pairs the IPv4 and IPv6 addresses of the discovered names, and reports
+// the names where the IPv6 addresses expose services that the IPv4 addresses do not.
+func (e *Enumeration) reportDualStack() {
+	all, err := e.Sys.Findings().All()
+	if err != nil {
+		return
+	}
+	services := analysis.ServicesByAddr(all)
+	if len(services) == 0 {
+		return
+	}
+
+	since := e.Config.CollectionStartTime.UTC()
+	var names []string
+	if assets, err := e.graph.DB.FindByType(oam.FQDN, since); err == nil {
+		for _, a := range assets {
+			if fqdn, ok := a.Asset.(domain.FQDN); ok && e.Config.IsDomainInScope(fqdn.Name) {
+				names = append(names, fqdn.Name)
+			}
+		}
+	}
+
+	var dual, exposed int
+	store := e.Sys.Findings()
+	for _, ds := range analysis.DualStacks(e.ctx, e.graph, since, time.Time{}, services, names...) {
+		if ds.Status() == analysis.StackDual {
+			dual++
+		}
+		if len(ds.IPv6Only) == 0 {
+			continue
+		}
+
+		exposed++
+		if _, err := store.Add(&findings.Finding{
+			Type:     IPv6ExposureFinding,
+			Asset:    ds.Name,
+			Severity: findings.Medium,
+			Description: fmt.Sprintf("The IPv6 addresses expose %s, which the IPv4 addresses do not",
+				strings.Join(ds.IPv6Only, ", ")),
+			Source: "Amass",
+			Details: map[string]string{
+				"ipv4":      strings.Join(ds.IPv4, ","),
+				"ipv6":      strings.Join(ds.IPv6, ","),
+				"ipv6_only": strings.Join(ds.IPv6Only, ","),
+			},
+		}); err != nil {
+			e.Config.Log.Printf("Failed to save the IPv6 service exposure finding: %v", err)
+		}
+	}
+	e.Config.Log.Printf("Dual-stack: %d names resolve to both address families, and %d expose services only over IPv6", dual, exposed)
+}
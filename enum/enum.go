@@ -6,6 +6,8 @@ package enum
 
 import (
 	"context"
+	"fmt"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -13,58 +15,375 @@ import (
 	"github.com/caffix/pipeline"
 	"github.com/caffix/queue"
 	"github.com/caffix/service"
+	"github.com/owasp-amass/amass/v4/cloud"
+	"github.com/owasp-amass/amass/v4/cloud/accounts"
+	"github.com/owasp-amass/amass/v4/confidence"
 	"github.com/owasp-amass/amass/v4/datasrcs"
+	"github.com/owasp-amass/amass/v4/dnscache"
+	"github.com/owasp-amass/amass/v4/events"
+	"github.com/owasp-amass/amass/v4/geoip"
+	"github.com/owasp-amass/amass/v4/net/local"
+	"github.com/owasp-amass/amass/v4/net/mailsec"
+	"github.com/owasp-amass/amass/v4/net/portscan"
+	"github.com/owasp-amass/amass/v4/net/validate"
 	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/scope"
 	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/amass/v4/threatintel"
+	"github.com/owasp-amass/amass/v4/throttle"
 	"github.com/owasp-amass/config/config"
 	oam "github.com/owasp-amass/open-asset-model"
 	"github.com/owasp-amass/open-asset-model/domain"
 )
 
+// The name used to attribute the resources consumed by the enumeration itself.
+const budgetSource = "enumeration"
+
 // Enumeration is the object type used to execute a DNS enumeration.
 type Enumeration struct {
-	Config   *config.Config
-	Sys      systems.System
-	ctx      context.Context
-	graph    *netmap.Graph
-	srcs     []service.Service
-	done     chan struct{}
-	nameSrc  *enumSource
-	subTask  *subdomainTask
-	dnsTask  *dnsTask
-	valTask  *dnsTask
-	store    *dataManager
-	requests queue.Queue
-	plock    sync.Mutex
-	pending  bool
+	// ID identifies the enumeration session in the published events
+	ID        string
+	Config    *config.Config
+	Sys       systems.System
+	ctx       context.Context
+	graph     *netmap.Graph
+	srcs      []service.Service
+	done      chan struct{}
+	nameSrc   *enumSource
+	subTask   *subdomainTask
+	dnsTask   *dnsTask
+	valTask   *dnsTask
+	validator *crossValidator
+	dedup     *requestDeduper
+	queueSize int
+	overflow  string
+	priority  int
+	gate      *dispatchGate
+	writes    *writeBehind
+	cuts      *zoneCuts
+	dnsCache  *dnscache.Cache
+	rrtypes   *recordTypes
+	zoneMax   int
+	adaptive  bool
+	queries   *datasrcs.QueryLog
+	honey     *honeyDetector
+	scorer    *confidence.Scorer
+	observed  *confidence.Observations
+	expand    bool
+	review    *scope.Queue
+	discover  bool
+	intel     []*threatintel.Feed
+	cloud     *cloud.Classifier
+	accounts  []accounts.Account
+	internal  *local.Options
+	confirm   *validate.Validator
+	ports     *portScanner
+	ipv6      *ipv6Expander
+	geo       *geoLocator
+	mail      *mailPosture
+	dangling  *danglingChecker
+	web       *webProber
+	srcStats  *sourceStats
+	idle      *idleDetector
+	completed []func(*Completion)
+	events    *events.Bus
+	pubs      []events.Publisher
+	published sync.Map
+	store     *dataManager
+	requests  queue.Queue
+	pauseLock sync.Mutex
+	paused    chan struct{}
+	resumed   chan struct{}
 }
 
 // NewEnumeration returns an initialized Enumeration that has not been started yet.
 func NewEnumeration(cfg *config.Config, sys systems.System, graph *netmap.Graph) *Enumeration {
 	return &Enumeration{
+		ID:       newSessionID(),
 		Config:   cfg,
 		Sys:      sys,
 		graph:    graph,
 		srcs:     datasrcs.SelectedDataSources(cfg, sys.DataSources()),
 		requests: queue.NewQueue(),
+		srcStats: newSourceStats(),
+		rrtypes:  newRecordTypes(FwdQueryTypes),
+		resumed:  make(chan struct{}, 1),
+	}
+}
+
+// ShardSources restricts the enumeration to the data sources of the shard, when the work of the domains
+// is split into count enumerations. The data sources are assigned to the shards in the order of their
+// names, so the enumerations of the shards query each data source once. It must be called before Start.
+func (e *Enumeration) ShardSources(shard, count int) {
+	if count <= 1 || shard < 0 || shard >= count {
+		return
+	}
+
+	var srcs []service.Service
+	for i, src := range e.srcs {
+		if i%count == shard {
+			srcs = append(srcs, src)
+		}
+	}
+	e.srcs = srcs
+}
+
+// Pause stops the enumeration from dispatching new work, while allowing the requests
+// already handed to data sources and pipeline stages to finish. Work that arrives
+// while paused remains queued until Resume is called.
+func (e *Enumeration) Pause() {
+	e.pauseLock.Lock()
+	defer e.pauseLock.Unlock()
+
+	if e.paused == nil {
+		e.paused = make(chan struct{})
+	}
+}
+
+// Resume continues dispatching the work queued while the enumeration was paused.
+func (e *Enumeration) Resume() {
+	e.pauseLock.Lock()
+	defer e.pauseLock.Unlock()
+
+	if e.paused != nil {
+		close(e.paused)
+		e.paused = nil
+
+		select {
+		case e.resumed <- struct{}{}:
+		default:
+		}
 	}
 }
 
+// IsPaused returns true if the enumeration has been paused.
+func (e *Enumeration) IsPaused() bool {
+	e.pauseLock.Lock()
+	defer e.pauseLock.Unlock()
+
+	return e.paused != nil
+}
+
+// Blocks while the enumeration is paused and returns false if the context expires first.
+func (e *Enumeration) waitWhilePaused(ctx context.Context) bool {
+	e.pauseLock.Lock()
+	paused := e.paused
+	e.pauseLock.Unlock()
+
+	if paused == nil {
+		return true
+	}
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-e.done:
+		return false
+	case <-paused:
+	}
+	return true
+}
+
 // Start begins the vertical domain correlation process.
 func (e *Enumeration) Start(ctx context.Context) error {
+	started := time.Now().UTC()
 	e.done = make(chan struct{})
 	defer close(e.done)
 
 	if err := e.Config.CheckSettings(); err != nil {
 		return err
 	}
+
+	window, err := IdleOptions(e.Config)
+	if err != nil {
+		return err
+	}
+	e.idle = newIdleDetector(window, e.IsPaused)
+
+	dedupWindow, err := DedupWindow(e.Config)
+	if err != nil {
+		return err
+	}
+	perSource, err := DedupPerSource(e.Config)
+	if err != nil {
+		return err
+	}
+	maxEntries, err := DedupMaxEntries(e.Config)
+	if err != nil {
+		return err
+	}
+	e.dedup = newRequestDeduper(dedupWindow, perSource, maxEntries)
+	defer e.reportDedup()
+
+	if e.queueSize, e.overflow, err = DispatchOptions(e.Config); err != nil {
+		return err
+	}
+	if e.queueSize > 0 && e.overflow == BlockOverflow {
+		e.gate = newDispatchGate()
+	}
+	if e.priority, err = DispatchPriority(e.Config); err != nil {
+		return err
+	}
+	defer e.reportShed()
+	defer e.reportQuotas()
+	defer e.reportHandlers()
+	defer e.reportErrors()
+
+	if e.zoneMax, e.adaptive, err = ZoneOptions(e.Config); err != nil {
+		return err
+	}
+	types, err := DNSRecordTypes(e.Config)
+	if err != nil {
+		return err
+	}
+	e.rrtypes = newRecordTypes(types)
+	e.cuts = newZoneCuts()
+
+	if e.dnsCache, err = dnscache.FromConfig(e.Config); err != nil {
+		return err
+	}
+	defer e.closeDNSCache()
+
+	batch, interval, err := WriteBehindOptions(e.Config)
+	if err != nil {
+		return err
+	}
+	if batch > 0 {
+		e.writes = newWriteBehind(e.graph, batch, interval, e.Config.Log.Printf)
+		defer e.writes.stop()
+	}
+
+	ttls, err := datasrcs.TTLsFromConfig(e.Config)
+	if err != nil {
+		return err
+	}
+	if ttls.Enabled() {
+		path := filepath.Join(config.OutputDirectory(e.Config.Dir), datasrcs.QueryLogFile)
+		if e.queries, err = datasrcs.NewQueryLog(path, ttls); err != nil {
+			return err
+		}
+		defer e.saveQueryLog()
+	}
+
+	detect, skip, err := HoneyOptions(e.Config)
+	if err != nil {
+		return err
+	}
+	if detect || skip {
+		e.honey = newHoneyDetector(detect)
+	}
+	if skip {
+		if all, err := e.Sys.Findings().All(); err == nil {
+			e.honey.skipNames(all)
+		}
+	}
+
+	if e.scorer, err = confidence.FromConfig(e.Config); err != nil {
+		return err
+	}
+	if e.scorer != nil {
+		if e.observed, err = confidence.Load(confidence.Path(e.Config)); err != nil {
+			return err
+		}
+		defer e.saveConfidence()
+	}
+
+	if e.expand, err = ExpandOnRegistrant(e.Config); err != nil {
+		return err
+	}
+	if auto, err := scope.AutoApprove(e.Config); err != nil {
+		return err
+	} else if e.expand && !auto {
+		if e.review, err = scope.Open(scope.Path(e.Config)); err != nil {
+			return err
+		}
+	}
+	if e.discover, err = DiscoverByOrganization(e.Config); err != nil {
+		return err
+	}
+	if _, err := TLDExpansion(e.Config); err != nil {
+		return err
+	}
+
+	if e.intel, err = threatintel.FromConfig(ctx, e.Config); err != nil {
+		return err
+	}
+	for _, f := range e.intel {
+		e.Config.Log.Printf("Threat intelligence: the %s feed provided %d indicators", f.Name, f.Len())
+	}
+
+	if e.cloud, err = cloud.FromConfig(ctx, e.Config); err != nil {
+		return err
+	}
+	if e.accounts, err = accounts.FromConfig(e.Config); err != nil {
+		return err
+	}
+	if e.confirm, err = validate.FromConfig(e.Config); err != nil {
+		return err
+	}
+	if e.internal, err = local.FromConfig(e.Config); err != nil {
+		return err
+	}
+
+	scanner, err := portscan.FromConfig(e.Config)
+	if err != nil {
+		return err
+	}
+	if scanner != nil {
+		e.ports = newPortScanner(e, scanner)
+	}
+	patterns, max, err := IPv6ExpansionOptions(e.Config)
+	if err != nil {
+		return err
+	}
+	if len(patterns) > 0 {
+		e.ipv6 = newIPv6Expander(e, patterns, max)
+	}
+	geodb, err := geoip.FromConfig(e.Config)
+	if err != nil {
+		return err
+	}
+	if geodb != nil {
+		e.geo = newGeoLocator(e, geodb)
+	}
+	mailopts, err := mailsec.FromConfig(e.Config)
+	if err != nil {
+		return err
+	}
+	if mailopts != nil {
+		e.mail = newMailPosture(e, mailopts)
+	}
+	e.dangling = newDanglingChecker(e)
+	// Probing the discovered URLs sends requests to the web servers of the target
+	if e.Config.Active {
+		e.web = newWebProber(e)
+	}
+
+	// Results shared by the data sources are only valid for this session
+	e.Sys.Shared().Reset()
+	// The size limit of the evidence applies to each session
+	e.Sys.Evidence().Reset()
+
+	if e.events, err = events.FromConfig(e.Config, e.pubs...); err != nil {
+		return err
+	}
+	defer e.closeEvents()
 	// This context, used throughout the enumeration, will provide the
 	// ability to pass the configuration and event bus to all the components
 	var cancel context.CancelFunc
 	e.ctx, cancel = context.WithCancel(ctx)
 	defer cancel()
+	// The requests of the data sources are weighted by the priority of the session
+	e.ctx = throttle.WithSession(e.ctx, e.ID, e.priority)
+	// The data source callbacks are canceled along with the enumeration
+	datasrcs.BindSession(e.ctx, e.srcs)
+	if b := e.Sys.Budget(); b != nil {
+		go e.enforceBudget(cancel)
+		defer e.reportBudget()
+	}
 	go e.manageDataSrcRequests()
 
+	e.validator = newCrossValidator(e, e.Config.Resolvers)
 	e.dnsTask = newDNSTask(e, false)
 	e.valTask = newDNSTask(e, true)
 	e.store = newDataManager(e)
@@ -84,9 +403,21 @@ func (e *Enumeration) Start(ctx context.Context) error {
 	// The pipeline input source will receive all the names
 	e.nameSrc = newEnumSource(p, e)
 	defer e.nameSrc.Stop()
+	e.srcStats.watch(e.nameSrc.queue, e.store.queue)
+	// The queues are drained when the enumeration has no outstanding work
+	e.idle.watch("requests", e.requests.Len)
+	e.idle.watch("input", e.nameSrc.queue.Len)
+	e.idle.watch("pipeline", p.DataItemCount)
+	e.idle.watch("store", e.store.queue.Len)
+	go e.idle.run()
+	defer e.idle.halt()
 
 	e.submitASNs()
 	e.submitDomainNames()
+	e.submitOrganizations()
+	if e.review != nil {
+		go e.watchReviews()
+	}
 	/*
 	 * Now that the pipeline input source has been setup, names provided
 	 * by the user and names acquired from the graph database can be brought
@@ -94,13 +425,106 @@ func (e *Enumeration) Start(ctx context.Context) error {
 	 */
 	go e.submitKnownNames()
 	go e.submitProvidedNames()
+	go e.importCloudAccounts()
+	go e.discoverLocal()
 
-	err := p.ExecuteBuffered(e.ctx, e.nameSrc, e.makeOutputSink(), 50)
+	err = p.ExecuteBuffered(e.ctx, e.nameSrc, e.makeOutputSink(), 50)
 	// Ensure all data has been stored
 	<-e.store.Stop()
+	e.writes.stop()
+	e.validator.wait()
+	e.ports.wait()
+	e.ipv6.wait()
+	e.geo.wait()
+	e.mail.wait()
+	e.dangling.wait()
+	e.web.wait()
+	e.reportValidation()
+	e.reportHoneyRecords()
+	e.reportThreatIntel()
+	e.reportCloudAssets()
+	e.reportDualStack()
+	e.reportBrandVariants()
+	e.reportNameEvidence()
+	e.reportEvidence()
+	e.complete(started)
 	return err
 }
 
+// ValidationStats returns the results of cross-validating the answers of the untrusted resolvers.
+func (e *Enumeration) ValidationStats() *ValidationStats {
+	if e.validator == nil {
+		return &ValidationStats{}
+	}
+	return e.validator.Stats()
+}
+
+// DNSCacheStats returns the lookups answered by the DNS cache shared across the enumerations.
+func (e *Enumeration) DNSCacheStats() dnscache.Stats {
+	return e.dnsCache.Stats()
+}
+
+func (e *Enumeration) closeDNSCache() {
+	if e.dnsCache == nil {
+		return
+	}
+
+	if st := e.dnsCache.Stats(); st.Hits+st.Misses > 0 {
+		e.Config.Log.Printf("DNS cache: %d queries were answered by the cache, %d were sent to the resolvers, and %d responses were saved", st.Hits, st.Misses, st.Stores)
+	}
+	if err := e.dnsCache.Close(); err != nil {
+		e.Config.Log.Printf("Failed to save the DNS cache: %v", err)
+	}
+}
+
+func (e *Enumeration) reportValidation() {
+	vs := e.ValidationStats()
+	if vs.Rejected == 0 {
+		return
+	}
+
+	e.Config.Log.Printf("Answer validation: the trusted resolvers confirmed %d names and rejected %d names", vs.Confirmed, vs.Rejected)
+	for i, s := range vs.Resolvers {
+		if i >= 5 || s.Mismatches == 0 {
+			break
+		}
+		e.Config.Log.Printf("Answer validation: resolver %s answered for %d of %d names rejected by the trusted resolvers", s.Address, s.Mismatches, s.Queries)
+	}
+}
+
+// Winds down the enumeration once a limit of the resource budget has been exhausted.
+func (e *Enumeration) enforceBudget(cancel context.CancelFunc) {
+	b := e.Sys.Budget()
+
+	var expired <-chan time.Time
+	if rt := b.Limits().Runtime; rt > 0 {
+		t := time.NewTimer(rt)
+		defer t.Stop()
+		expired = t.C
+	}
+
+	select {
+	case <-e.done:
+		return
+	case <-e.ctx.Done():
+		return
+	case <-expired:
+		b.Exhaust(fmt.Sprintf("the runtime limit of %s was reached", b.Limits().Runtime))
+	case <-b.Done():
+	}
+
+	e.Config.Log.Printf("The resource budget has been exhausted: %s, so the enumeration is winding down", b.Reason())
+	cancel()
+}
+
+func (e *Enumeration) reportBudget() {
+	b := e.Sys.Budget()
+
+	if reason := b.Reason(); reason != "" {
+		e.Config.Log.Printf("Resource budget: %s; the top consumers were %s", reason, b.Summary(5))
+	}
+}
+
 // Release the root domain names to the input source and each data source.
 func (e *Enumeration) submitDomainNames() {
 	for _, domain := range e.Config.Domains() {
@@ -123,9 +547,45 @@ func (e *Enumeration) submitASNs() {
 }
 
 func (e *Enumeration) sendRequests(element interface{}) {
+	if !e.dedup.allow(element) {
+		return
+	}
+	// Hold up the request while a data source has a full queue
+	if e.gate != nil {
+		select {
+		case <-e.gate.wait():
+		case <-e.done:
+			return
+		case <-e.ctx.Done():
+			return
+		}
+	}
 	e.requests.Append(element)
 }
 
+func (e *Enumeration) reportDedup() {
+	if n := e.dedup.Dropped(); n > 0 {
+		e.Config.Log.Printf("Request deduplication: %d data source requests were dropped within the %s window", n, e.dedup.window)
+	}
+
+	var dups int
+	for _, ss := range e.srcStats.snapshot() {
+		dups += ss.Duplicates
+	}
+	if dups > 0 {
+		e.Config.Log.Printf("Request deduplication: %d requests already sent to the data sources were dropped", dups)
+	}
+}
+
+func (e *Enumeration) saveQueryLog() {
+	for src, n := range e.queries.Skipped() {
+		e.Config.Log.Printf("Source TTLs: %d requests were not sent to %s, since the previous queries have not expired", n, src)
+	}
+	if err := e.queries.Save(); err != nil {
+		e.Config.Log.Printf("Failed to save the data source query log: %v", err)
+	}
+}
+
 func (e *Enumeration) manageDataSrcRequests() {
 	nameToSrc := make(map[string]service.Service)
 	for _, src := range e.srcs {
@@ -135,10 +595,20 @@ func (e *Enumeration) manageDataSrcRequests() {
 	pending := make(map[string]bool)
 	for _, src := range e.srcs {
 		pending[src.String()] = false
+		e.srcStats.queued(src.String(), 0)
 	}
 
 	finished := make(chan string, len(e.srcs)*2)
-	requestsMap := make(map[string][]interface{})
+	// Blocking holds up the new requests instead of shedding the queued requests
+	limit := e.queueSize
+	if e.overflow == BlockOverflow {
+		limit = 0
+	}
+
+	requestsMap := make(map[string]*fairQueue)
+	for _, src := range e.srcs {
+		requestsMap[src.String()] = newFairQueue(limit, sourcePriority(src))
+	}
 loop:
 	for {
 		select {
@@ -152,58 +622,97 @@ loop:
 				continue loop
 			}
 
+			paused := e.IsPaused()
 			for name := range nameToSrc {
 				if src := nameToSrc[name]; src != nil && src.HandlesReq(element) {
-					if len(requestsMap[name]) == 0 && !pending[name] {
+					if !e.dedup.first(name, element) {
+						e.srcStats.duplicate(name)
+						continue
+					}
+					if e.queries.Fresh(name, element) {
+						continue
+					}
+					e.idle.begin(name)
+					if !paused && requestsMap[name].Len() == 0 && !pending[name] {
 						go e.fireRequest(src, element, finished)
 						pending[name] = true
 					} else {
-						requestsMap[name] = append(requestsMap[name], element)
+						if shed := requestsMap[name].Append(element); shed != nil {
+							e.shedRequest(name, shed)
+						}
+						e.queued(name, requestsMap[name].Len())
 					}
 				}
 			}
 		case name := <-finished:
-			if len(requestsMap[name]) == 0 {
+			e.idle.end(name)
+
+			var next interface{}
+			ok := !e.IsPaused()
+			if ok {
+				next, ok = requestsMap[name].Next()
+				e.queued(name, requestsMap[name].Len())
+			}
+			if !ok {
 				pending[name] = false
-				e.setRequestsPending(pending)
 				continue loop
 			}
 
-			go e.fireRequest(nameToSrc[name], requestsMap[name][0], finished)
-			requestsMap[name] = requestsMap[name][1:]
+			go e.fireRequest(nameToSrc[name], next, finished)
+		case <-e.resumed:
+			for name, src := range nameToSrc {
+				if pending[name] {
+					continue
+				}
+				if next, ok := requestsMap[name].Next(); ok {
+					e.queued(name, requestsMap[name].Len())
+					go e.fireRequest(src, next, finished)
+					pending[name] = true
+				}
+			}
 		}
 	}
 	e.requests.Process(func(e interface{}) {})
 }
 
-func (e *Enumeration) requestsPending() bool {
-	e.plock.Lock()
-	defer e.plock.Unlock()
-
-	return e.pending
+// queued records the length of the data source queue, holding up the new requests while it is full.
+func (e *Enumeration) queued(name string, n int) {
+	e.srcStats.queued(name, n)
+	if e.gate != nil {
+		e.gate.update(name, n >= e.queueSize)
+	}
 }
 
-func (e *Enumeration) setRequestsPending(p map[string]bool) {
-	var pending bool
+func (e *Enumeration) shedRequest(name string, req interface{}) {
+	e.idle.end(name)
+	// Only the first request shed for each data source is logged, and the total is reported at the end
+	if e.srcStats.shed(name) == 1 {
+		e.Config.Log.Printf("Backpressure: the %s queue is full, and requests with the lowest priority, such as %s, are being shed",
+			name, datasrcs.RequestKey(req))
+	}
+}
 
-	for _, b := range p {
-		if b {
-			pending = true
-			break
+func (e *Enumeration) reportShed() {
+	for _, ss := range e.srcStats.snapshot() {
+		if ss.Shed > 0 {
+			e.Config.Log.Printf("Backpressure: %d requests were shed from the %s queue", ss.Shed, ss.Name)
 		}
 	}
-
-	e.plock.Lock()
-	e.pending = pending
-	e.plock.Unlock()
 }
 
 func (e *Enumeration) fireRequest(srv service.Service, req interface{}, finished chan string) {
+	if e.quotaExceeded(srv, req) {
+		finished <- srv.String()
+		return
+	}
+
 	select {
 	case <-e.done:
 	case <-e.ctx.Done():
 	case <-srv.Done():
 	case srv.Input() <- req:
+		e.srcStats.request(srv.String())
+		e.queries.Record(srv.String(), req)
 	}
 	finished <- srv.String()
 }
@@ -0,0 +1,86 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/caffix/service"
+)
+
+func TestPauseResume(t *testing.T) {
+	e := &Enumeration{
+		done:    make(chan struct{}),
+		resumed: make(chan struct{}, 1),
+	}
+
+	e.Pause()
+	if !e.IsPaused() {
+		t.Fatal("The enumeration was not paused")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if e.waitWhilePaused(ctx) {
+		t.Error("The wait returned true after the context expired")
+	}
+
+	ch := make(chan bool, 1)
+	go func() { ch <- e.waitWhilePaused(context.Background()) }()
+
+	e.Resume()
+	if e.IsPaused() {
+		t.Error("The enumeration was not resumed")
+	}
+	select {
+	case ok := <-ch:
+		if !ok {
+			t.Error("The wait returned false after the enumeration was resumed")
+		}
+	case <-time.After(time.Second):
+		t.Error("The wait did not return after the enumeration was resumed")
+	}
+	select {
+	case <-e.resumed:
+	default:
+		t.Error("The dispatcher was not signaled to resume")
+	}
+}
+
+func TestShardSources(t *testing.T) {
+	var all []service.Service
+	for _, name := range []string{"A", "B", "C", "D", "E"} {
+		all = append(all, service.NewBaseService(nil, name))
+	}
+
+	seen := make(map[string]int)
+	for shard := 0; shard < 2; shard++ {
+		e := &Enumeration{srcs: all}
+		e.ShardSources(shard, 2)
+		for _, src := range e.srcs {
+			seen[src.String()]++
+		}
+		if shard == 0 && len(e.srcs) != 3 {
+			t.Errorf("Unexpected data sources of the first shard: %v", e.srcs)
+		}
+	}
+	// Each data source is queried by a single shard
+	if len(seen) != len(all) {
+		t.Errorf("The shards did not cover the data sources: %v", seen)
+	}
+	for name, n := range seen {
+		if n != 1 {
+			t.Errorf("The data source %s was assigned to %d shards", name, n)
+		}
+	}
+
+	e := &Enumeration{srcs: all}
+	e.ShardSources(0, 1)
+	if len(e.srcs) != len(all) {
+		t.Error("The data sources were sharded without a split")
+	}
+}
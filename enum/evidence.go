@@ -0,0 +1,112 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/analysis"
+	blobs "github.com/owasp-amass/amass/v4/evidence"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/net/validate"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+)
+
+// NameValidationFinding is the finding type used to record the evidence confirming each resolved name.
+const NameValidationFinding = "name_validation"
+
+// reportNameEvidence probes the addresses of the resolved names with the method selected in the
+// configuration, and records the strongest evidence obtained for each name.
+func (e *Enumeration) reportNameEvidence() {
+	if e.confirm == nil {
+		return
+	}
+
+	since := e.Config.CollectionStartTime.UTC()
+	var names []string
+	if assets, err := e.graph.DB.FindByType(oam.FQDN, since); err == nil {
+		for _, a := range assets {
+			if fqdn, ok := a.Asset.(domain.FQDN); ok && e.Config.IsDomainInScope(fqdn.Name) {
+				names = append(names, fqdn.Name)
+			}
+		}
+	}
+
+	pairs, _ := analysis.NamesToAddrs(e.ctx, e.graph, since, time.Time{}, names...)
+	addrs := make(map[string][]string)
+	for _, p := range pairs {
+		addrs[p.FQDN.Name] = append(addrs[p.FQDN.Name], p.Addr.Address.String())
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	counts := make(map[string]int)
+	sem := make(chan struct{}, validate.DefaultConcurrency)
+	method := e.confirm.Method()
+loop:
+	for name, list := range addrs {
+		select {
+		case <-e.ctx.Done():
+			break loop
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(name string, list []string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			evidence, cert := e.confirm.Confirm(e.ctx, name, list)
+			details := map[string]string{"evidence": evidence, "method": method}
+			// The certificate covering the name backs the TLS evidence
+			if cert != nil {
+				if id := e.storeEvidence(blobs.Certificate, name, "Amass", "application/pkix-cert", cert); id != "" {
+					details[blobs.DetailKey] = id
+				}
+			}
+
+			if _, err := e.Sys.Findings().Add(&findings.Finding{
+				Type:        NameValidationFinding,
+				Asset:       name,
+				Severity:    findings.Info,
+				Description: fmt.Sprintf("The name was confirmed by %s evidence, using the %s method", evidence, method),
+				Source:      "Amass",
+				Details:     details,
+			}); err != nil {
+				e.Config.Log.Printf("Failed to save the name validation finding: %v", err)
+			}
+
+			mu.Lock()
+			counts[evidence]++
+			mu.Unlock()
+		}(name, list)
+	}
+	wg.Wait()
+
+	e.Config.Log.Printf("Name validation: %d names were confirmed by tls, %d by tcp and %d only by dns",
+		counts[validate.EvidenceTLS], counts[validate.EvidenceTCP], counts[validate.EvidenceDNS])
+}
+
+// storeEvidence stores the raw data backing a finding, and returns the identifier referenced by the
+// finding. An empty identifier is returned when the evidence is discarded or exceeds the size limits.
+func (e *Enumeration) storeEvidence(kind, asset, source, contentType string, data []byte) string {
+	id, err := e.Sys.Evidence().Put(kind, asset, source, contentType, data)
+	if err != nil && err != blobs.ErrLimit {
+		e.Config.Log.Printf("Failed to store the %s evidence for %s: %v", kind, asset, err)
+	}
+	return id
+}
+
+func (e *Enumeration) reportEvidence() {
+	stored, size, dropped := e.Sys.Evidence().Stats()
+	if stored == 0 && dropped == 0 {
+		return
+	}
+
+	e.Config.Log.Printf("Evidence: %d blobs were recorded using %d bytes, and %d were dropped by the size limits", stored, size, dropped)
+}
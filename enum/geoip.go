@@ -0,0 +1,102 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/geoip"
+	amassnet "github.com/owasp-amass/amass/v4/net"
+)
+
+// LocationFinding is the finding type used to record the geographical location of the in-scope addresses.
+const LocationFinding = "ip_location"
+
+// geoLocator records the location of the in-scope addresses discovered by the enumeration,
+// since the asset model does not provide a location asset.
+type geoLocator struct {
+	sync.Mutex
+	enum    *Enumeration
+	db      *geoip.DB
+	seen    map[string]struct{}
+	located int
+}
+
+func newGeoLocator(e *Enumeration, db *geoip.DB) *geoLocator {
+	return &geoLocator{
+		enum: e,
+		db:   db,
+		seen: make(map[string]struct{}),
+	}
+}
+
+// locate looks up the address once per enumeration.
+func (g *geoLocator) locate(addr string) {
+	if g == nil {
+		return
+	}
+	if reserved, _ := amassnet.IsReservedAddress(addr); reserved {
+		return
+	}
+
+	g.Lock()
+	defer g.Unlock()
+
+	if _, found := g.seen[addr]; found {
+		return
+	}
+	g.seen[addr] = struct{}{}
+
+	loc := g.db.Lookup(addr)
+	if loc == nil {
+		return
+	}
+
+	details := map[string]string{
+		"country_code": loc.CountryCode,
+		"database":     g.db.Name,
+	}
+	for k, v := range map[string]string{
+		"country": loc.Country,
+		"region":  loc.Region,
+		"city":    loc.City,
+	} {
+		if v != "" {
+			details[k] = v
+		}
+	}
+	if loc.Latitude != 0 || loc.Longitude != 0 {
+		details["latitude"] = strconv.FormatFloat(loc.Latitude, 'f', 4, 64)
+		details["longitude"] = strconv.FormatFloat(loc.Longitude, 'f', 4, 64)
+	}
+
+	if _, err := g.enum.Sys.Findings().Add(&findings.Finding{
+		Type:        LocationFinding,
+		Asset:       addr,
+		Severity:    findings.Info,
+		Description: "The address is located in " + loc.String(),
+		Source:      "GeoIP",
+		Details:     details,
+	}); err != nil {
+		g.enum.Config.Log.Printf("Failed to save the location finding: %v", err)
+		return
+	}
+	g.located++
+}
+
+// wait logs the number of addresses located, and releases the database.
+func (g *geoLocator) wait() {
+	if g == nil {
+		return
+	}
+
+	g.Lock()
+	defer g.Unlock()
+
+	g.enum.Config.Log.Printf("GeoIP: %d of the %d addresses were located", g.located, len(g.seen))
+	_ = g.db.Close()
+}
@@ -0,0 +1,201 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/config/config"
+)
+
+// HoneyRecordFinding is the finding type used to tag the names that are likely honey records.
+const HoneyRecordFinding = "honey_record"
+
+const (
+	// Canary labels are long and random, unlike the labels chosen by people
+	minCanaryLabelLen  int     = 12
+	minCanaryEntropy   float64 = 3.2
+	honeyFindingSource string  = "Honey Record Detection"
+)
+
+// HoneyOptions returns the settings in the 'honey_records' section of the configuration options.
+// Detection is disabled by default, and skip causes names tagged by previous enumerations to not be resolved.
+func HoneyOptions(cfg *config.Config) (enabled, skip bool, err error) {
+	honeyRaw, ok := cfg.Options["honey_records"]
+	if !ok {
+		return false, false, nil
+	}
+
+	settings, ok := honeyRaw.(map[string]interface{})
+	if !ok {
+		return false, false, fmt.Errorf("honey_records is not a map[string]interface{}")
+	}
+
+	if raw, ok := settings["enabled"]; ok {
+		if enabled, ok = raw.(bool); !ok {
+			return false, false, fmt.Errorf("honey_records enabled is not a bool")
+		}
+	}
+	if raw, ok := settings["skip"]; ok {
+		if skip, ok = raw.(bool); !ok {
+			return false, false, fmt.Errorf("honey_records skip is not a bool")
+		}
+	}
+	return enabled, skip, nil
+}
+
+// honeyDetector correlates the data sources that provided each name with the names that
+// resolved, since canary subdomains are unique to a single source and never resolve.
+type honeyDetector struct {
+	sync.Mutex
+	detect   bool
+	sources  map[string]map[string]struct{}
+	resolved map[string]struct{}
+	skip     map[string]struct{}
+}
+
+func newHoneyDetector(detect bool) *honeyDetector {
+	return &honeyDetector{
+		detect:   detect,
+		sources:  make(map[string]map[string]struct{}),
+		resolved: make(map[string]struct{}),
+		skip:     make(map[string]struct{}),
+	}
+}
+
+// observe records that the data source provided the name.
+func (h *honeyDetector) observe(name, source, stype string) {
+	// Names guessed by the engine were not returned by the data source
+	if h == nil || !h.detect || stype == "brute" || stype == "alt" {
+		return
+	}
+
+	name = strings.Trim(strings.ToLower(name), ".")
+	h.Lock()
+	defer h.Unlock()
+
+	srcs, found := h.sources[name]
+	if !found {
+		srcs = make(map[string]struct{})
+		h.sources[name] = srcs
+	}
+	srcs[source] = struct{}{}
+}
+
+// resolve records that the name was confirmed by the trusted resolvers.
+func (h *honeyDetector) resolve(name string) {
+	if h == nil || !h.detect {
+		return
+	}
+
+	h.Lock()
+	defer h.Unlock()
+
+	h.resolved[strings.ToLower(name)] = struct{}{}
+}
+
+// skipNames prevents the names from being resolved during the enumeration.
+func (h *honeyDetector) skipNames(all []*findings.Finding) {
+	h.Lock()
+	defer h.Unlock()
+
+	for _, f := range all {
+		if f.Type == HoneyRecordFinding {
+			h.skip[strings.ToLower(f.Asset)] = struct{}{}
+		}
+	}
+}
+
+// skipped returns true if the name was tagged as a honey record by a previous enumeration.
+func (h *honeyDetector) skipped(name string) bool {
+	if h == nil {
+		return false
+	}
+
+	h.Lock()
+	defer h.Unlock()
+
+	_, found := h.skip[strings.ToLower(name)]
+	return found
+}
+
+// candidates returns the names, and their data source, that are likely honey records.
+func (h *honeyDetector) candidates() map[string]string {
+	h.Lock()
+	defer h.Unlock()
+
+	results := make(map[string]string)
+	for name, srcs := range h.sources {
+		if len(srcs) != 1 {
+			continue
+		}
+		if _, found := h.resolved[name]; found {
+			continue
+		}
+		if label := strings.Split(name, ".")[0]; !looksRandom(label) {
+			continue
+		}
+
+		for src := range srcs {
+			results[name] = src
+		}
+	}
+	return results
+}
+
+func (e *Enumeration) reportHoneyRecords() {
+	if e.honey == nil {
+		return
+	}
+
+	cands := e.honey.candidates()
+	if len(cands) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(cands))
+	for name := range cands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	store := e.Sys.Findings()
+	for _, name := range names {
+		if _, err := store.Add(&findings.Finding{
+			Type:        HoneyRecordFinding,
+			Asset:       name,
+			Severity:    findings.Info,
+			Description: fmt.Sprintf("The name was only provided by %s and never resolved, so it is likely a canary record", cands[name]),
+			Source:      honeyFindingSource,
+		}); err != nil {
+			e.Config.Log.Printf("Failed to save the honey record finding: %v", err)
+		}
+	}
+	e.Config.Log.Printf("Honey record detection: %d names provided by a single data source never resolved and appear to be canary records", len(names))
+}
+
+// looksRandom returns true if the label has the length, digits and character entropy of a generated token.
+func looksRandom(label string) bool {
+	if len(label) < minCanaryLabelLen || !strings.ContainsAny(label, "0123456789") {
+		return false
+	}
+
+	counts := make(map[rune]int)
+	for _, c := range label {
+		counts[c]++
+	}
+
+	var entropy float64
+	for _, n := range counts {
+		p := float64(n) / float64(len(label))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy >= minCanaryEntropy
+}
@@ -0,0 +1,49 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/findings"
+)
+
+func TestHoneyDetector(t *testing.T) {
+	h := newHoneyDetector(true)
+
+	// A canary only provided by a single data source that never resolved
+	h.observe("k7f2q9x4m1z8w3.owasp.org", "Passive", "api")
+	// The same random name provided by two data sources
+	h.observe("p3n8v1c6j2r9t5.owasp.org", "Passive", "api")
+	h.observe("p3n8v1c6j2r9t5.owasp.org", "Archive", "archive")
+	// A random name that resolved
+	h.observe("b4h9s2l7d1g6y0.owasp.org", "Passive", "api")
+	h.resolve("b4h9s2l7d1g6y0.owasp.org")
+	// A name chosen by people that never resolved
+	h.observe("webserver2023.owasp.org", "Passive", "api")
+	// A random name guessed by the engine
+	h.observe("a8e3u6o1i9q2w7.owasp.org", "Brute Forcing", "brute")
+
+	cands := h.candidates()
+	if len(cands) != 1 {
+		t.Fatalf("Expected 1 honey record, got %v", cands)
+	}
+	if src, found := cands["k7f2q9x4m1z8w3.owasp.org"]; !found || src != "Passive" {
+		t.Errorf("The canary was not attributed to its data source: %v", cands)
+	}
+
+	h.skipNames([]*findings.Finding{
+		{Type: HoneyRecordFinding, Asset: "K7F2Q9X4M1Z8W3.owasp.org"},
+		{Type: "open_recursion", Asset: "ns1.owasp.org"},
+	})
+	if !h.skipped("k7f2q9x4m1z8w3.owasp.org") || h.skipped("ns1.owasp.org") {
+		t.Error("The names tagged as honey records were not the names skipped")
+	}
+
+	var disabled *honeyDetector
+	if disabled.skipped("k7f2q9x4m1z8w3.owasp.org") {
+		t.Error("A nil detector skipped a name")
+	}
+}
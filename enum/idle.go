@@ -0,0 +1,283 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/events"
+	"github.com/owasp-amass/config/config"
+)
+
+// DefaultQuiescence is how long the enumeration must remain without outstanding work before it is complete.
+const DefaultQuiescence = 10 * time.Second
+
+// The reasons for the completion of an enumeration.
+const (
+	// CompletionIdle is reported when the enumeration drained all the work it had discovered
+	CompletionIdle = "idle"
+	// CompletionCanceled is reported when the enumeration was canceled, or its time budget was reached
+	CompletionCanceled = "canceled"
+)
+
+// Completion describes how an enumeration session came to an end.
+type Completion struct {
+	Session  string    `json:"session"`
+	Reason   string    `json:"reason"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+}
+
+// IdleOptions returns the quiescence window set by the 'idle' section of the configuration options.
+func IdleOptions(cfg *config.Config) (time.Duration, error) {
+	idleRaw, ok := cfg.Options["idle"]
+	if !ok {
+		return DefaultQuiescence, nil
+	}
+
+	settings, ok := idleRaw.(map[string]interface{})
+	if !ok {
+		return 0, fmt.Errorf("idle is not a map[string]interface{}")
+	}
+
+	window := DefaultQuiescence
+	if raw, ok := settings["quiescence"]; ok {
+		str, ok := raw.(string)
+		if !ok {
+			return 0, fmt.Errorf("idle quiescence is not a string")
+		}
+
+		d, err := time.ParseDuration(str)
+		if err != nil || d <= 0 {
+			return 0, fmt.Errorf("idle quiescence is not a valid duration: %s", str)
+		}
+		window = d
+	}
+	return window, nil
+}
+
+// OnComplete registers the callback executed once the enumeration has come to an end and its
+// data has been stored. The callbacks must be registered before the enumeration is started.
+func (e *Enumeration) OnComplete(fn func(*Completion)) {
+	e.completed = append(e.completed, fn)
+}
+
+// Outstanding returns the number of events outstanding in each handler of the running enumeration.
+func (e *Enumeration) Outstanding() map[string]int {
+	return e.idle.snapshot()
+}
+
+// complete publishes the end of the enumeration and executes the registered callbacks.
+func (e *Enumeration) complete(started time.Time) {
+	c := &Completion{
+		Session:  e.ID,
+		Reason:   CompletionCanceled,
+		Started:  started,
+		Finished: time.Now().UTC(),
+	}
+	if e.idle.drained() {
+		c.Reason = CompletionIdle
+		e.Config.Log.Printf("The enumeration completed, since no work was outstanding for %s", e.idle.window)
+	}
+
+	if e.events != nil {
+		e.publish(&events.Event{
+			Kind:   events.SessionEvent,
+			Type:   events.SessionComplete,
+			Reason: c.Reason,
+			Source: budgetSource,
+			Time:   c.Finished,
+		})
+	}
+	for _, fn := range e.completed {
+		fn(c)
+	}
+}
+
+// idleDetector tracks the events outstanding in each handler of the enumeration, and signals the
+// completion once no events have been outstanding, and no activity observed, for the quiescence window.
+// The queues that are not instrumented are watched through probes returning their lengths.
+// All methods are safe to call on a nil idleDetector.
+type idleDetector struct {
+	sync.Mutex
+	window      time.Duration
+	outstanding map[string]int
+	probes      map[string]func() int
+	held        func() bool
+	last        time.Time
+	done        chan struct{}
+	once        sync.Once
+	stop        chan struct{}
+}
+
+// newIdleDetector returns an idleDetector for the quiescence window. The enumeration is never
+// considered idle while held returns true, such as while it is paused.
+func newIdleDetector(window time.Duration, held func() bool) *idleDetector {
+	return &idleDetector{
+		window:      window,
+		outstanding: make(map[string]int),
+		probes:      make(map[string]func() int),
+		held:        held,
+		last:        time.Now(),
+		done:        make(chan struct{}),
+		stop:        make(chan struct{}),
+	}
+}
+
+// begin records an event handed to the handler.
+func (d *idleDetector) begin(handler string) {
+	if d == nil {
+		return
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	d.outstanding[handler]++
+	d.last = time.Now()
+}
+
+// end records an event the handler is finished with.
+func (d *idleDetector) end(handler string) {
+	if d == nil {
+		return
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	if d.outstanding[handler] > 0 {
+		d.outstanding[handler]--
+	}
+	d.last = time.Now()
+}
+
+// touch records activity that is not tracked as an event, such as the output of a data source.
+func (d *idleDetector) touch() {
+	if d == nil {
+		return
+	}
+
+	d.Lock()
+	d.last = time.Now()
+	d.Unlock()
+}
+
+// watch adds the probe returning the number of events waiting in the queue of the handler.
+func (d *idleDetector) watch(handler string, probe func() int) {
+	if d == nil {
+		return
+	}
+
+	d.Lock()
+	d.probes[handler] = probe
+	d.Unlock()
+}
+
+// snapshot returns the handlers with outstanding events.
+func (d *idleDetector) snapshot() map[string]int {
+	results := make(map[string]int)
+	if d == nil {
+		return results
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	for handler, n := range d.outstanding {
+		if n > 0 {
+			results[handler] = n
+		}
+	}
+	for handler, probe := range d.probes {
+		if n := probe(); n > 0 {
+			results[handler] += n
+		}
+	}
+	return results
+}
+
+// Done returns the channel closed once the enumeration has been idle for the quiescence window.
+func (d *idleDetector) Done() <-chan struct{} {
+	if d == nil {
+		return nil
+	}
+	return d.done
+}
+
+// drained returns true when the completion was signaled.
+func (d *idleDetector) drained() bool {
+	if d == nil {
+		return false
+	}
+
+	select {
+	case <-d.done:
+		return true
+	default:
+	}
+	return false
+}
+
+// run checks the handlers until the enumeration has been idle for the quiescence window, or stop is called.
+func (d *idleDetector) run() {
+	t := time.NewTicker(d.resolution())
+	defer t.Stop()
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case now := <-t.C:
+			if d.check(now) {
+				d.once.Do(func() { close(d.done) })
+				return
+			}
+		}
+	}
+}
+
+// check returns true when the enumeration has been idle for the quiescence window.
+func (d *idleDetector) check(now time.Time) bool {
+	busy := len(d.snapshot()) > 0 || (d.held != nil && d.held())
+
+	d.Lock()
+	defer d.Unlock()
+
+	if busy {
+		d.last = now
+		return false
+	}
+	return now.Sub(d.last) >= d.window
+}
+
+// resolution returns how often the handlers are checked, so the completion is signaled shortly after the window.
+func (d *idleDetector) resolution() time.Duration {
+	r := d.window / 4
+	if r > time.Second {
+		r = time.Second
+	} else if r < 10*time.Millisecond {
+		r = 10 * time.Millisecond
+	}
+	return r
+}
+
+// halt stops checking the handlers.
+func (d *idleDetector) halt() {
+	if d == nil {
+		return
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	select {
+	case <-d.stop:
+	default:
+		close(d.stop)
+	}
+}
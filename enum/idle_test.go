@@ -0,0 +1,82 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func TestIdleOptions(t *testing.T) {
+	cfg := config.NewConfig()
+	if window, err := IdleOptions(cfg); err != nil || window != DefaultQuiescence {
+		t.Errorf("Expected the default quiescence window, got %s: %v", window, err)
+	}
+
+	cfg.Options["idle"] = map[string]interface{}{"quiescence": "30s"}
+	if window, err := IdleOptions(cfg); err != nil || window != 30*time.Second {
+		t.Errorf("Expected a quiescence window of 30s, got %s: %v", window, err)
+	}
+
+	for _, bad := range []interface{}{"0s", "soon", 30} {
+		cfg.Options["idle"] = map[string]interface{}{"quiescence": bad}
+		if _, err := IdleOptions(cfg); err == nil {
+			t.Errorf("The quiescence window %v was accepted", bad)
+		}
+	}
+}
+
+func TestIdleDetector(t *testing.T) {
+	var queued, paused int32
+	d := newIdleDetector(50*time.Millisecond, func() bool { return atomic.LoadInt32(&paused) == 1 })
+	d.watch("input", func() int { return int(atomic.LoadInt32(&queued)) })
+
+	d.begin("crtsh")
+	d.begin("crtsh")
+	d.end("crtsh")
+	atomic.StoreInt32(&queued, 3)
+	if got := d.snapshot(); !reflect.DeepEqual(got, map[string]int{"crtsh": 1, "input": 3}) {
+		t.Errorf("Unexpected outstanding events: %v", got)
+	}
+
+	go d.run()
+	defer d.halt()
+
+	select {
+	case <-d.Done():
+		t.Fatal("The completion was signaled while events were outstanding")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	d.end("crtsh")
+	atomic.StoreInt32(&paused, 1)
+	atomic.StoreInt32(&queued, 0)
+	select {
+	case <-d.Done():
+		t.Fatal("The completion was signaled while the enumeration was paused")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	atomic.StoreInt32(&paused, 0)
+	select {
+	case <-d.Done():
+	case <-time.After(time.Second):
+		t.Fatal("The completion was not signaled once the handlers drained")
+	}
+	if !d.drained() || len(d.snapshot()) != 0 {
+		t.Error("The detector did not report the drained handlers")
+	}
+
+	var nilDetector *idleDetector
+	nilDetector.begin("crtsh")
+	nilDetector.end("crtsh")
+	if nilDetector.drained() || len(nilDetector.snapshot()) != 0 {
+		t.Error("The nil detector reported activity")
+	}
+}
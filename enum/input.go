@@ -88,7 +88,7 @@ func (r *enumSource) newName(req *requests.DNSRequest) {
 	// Clean up the newly discovered name and domain
 	requests.SanitizeDNSRequest(req)
 
-	if r.enum.Config.Blacklisted(req.Name) {
+	if r.enum.Config.Blacklisted(req.Name) || r.enum.honey.skipped(req.Name) {
 		r.releaseOutput(1)
 		return
 	}
@@ -117,6 +117,11 @@ func (r *enumSource) accept(s string) bool {
 
 // Next implements the pipeline InputSource interface.
 func (r *enumSource) Next(ctx context.Context) bool {
+	// Hold back new names while the enumeration is paused
+	if !r.enum.waitWhilePaused(ctx) {
+		r.markDone()
+		return false
+	}
 	// Low if below 75%
 	if p := (float32(r.queue.Len()) / float32(r.max)) * 100; p < 75 {
 		r.fillQueue()
@@ -132,14 +137,10 @@ func (r *enumSource) Next(ctx context.Context) bool {
 		case <-ctx.Done():
 			r.markDone()
 			return false
+		case <-r.enum.idle.Done():
+			r.markDone()
+			return false
 		case <-t.C:
-			count := r.pipeline.DataItemCount()
-			if !r.enum.requestsPending() && count <= 0 {
-				if r.enum.store.queue.Len() == 0 {
-					r.markDone()
-					return false
-				}
-			}
 			r.fillQueue()
 			t.Reset(waitForDuration)
 		case <-r.queue.Signal():
@@ -190,6 +191,8 @@ func (r *enumSource) monitorDataSrcOutput(srv service.Service) {
 		case <-srv.Done():
 			return
 		case in := <-srv.Output():
+			r.enum.idle.touch()
+
 			select {
 			case <-r.done:
 				return
@@ -200,9 +203,28 @@ func (r *enumSource) monitorDataSrcOutput(srv service.Service) {
 
 			switch req := in.(type) {
 			case *requests.DNSRequest:
+				if req.Source == "" {
+					req.Source = srv.String()
+				}
+				r.enum.honey.observe(req.Name, srv.String(), srv.Description())
+				r.enum.observe(req.Name, req.Source, false)
+				r.enum.srcStats.name(srv.String())
 				r.newName(req)
 			case *requests.AddrRequest:
 				r.newAddr(req)
+			case *requests.RoutingRequest:
+				r.enum.newRoutes(req)
+				// The routing data is not added to the queue
+				r.releaseOutput(1)
+			case *requests.RegistrantRequest:
+				r.enum.newRegistrant(req)
+				r.releaseOutput(1)
+			case *requests.URLRequest:
+				r.enum.web.probe(req)
+				r.releaseOutput(1)
+			case *requests.WhoisRequest:
+				r.enum.newAssociations(req, srv.String())
+				r.releaseOutput(1)
 			}
 		}
 	}
@@ -0,0 +1,61 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/threatintel"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+// ThreatIntelFinding is the finding type used to tag the assets listed by a threat intelligence feed.
+const ThreatIntelFinding = "threat_intel_match"
+
+// reportThreatIntel checks the names and addresses discovered by the enumeration against the configured feeds.
+func (e *Enumeration) reportThreatIntel() {
+	if len(e.intel) == 0 {
+		return
+	}
+
+	since := e.Config.CollectionStartTime.UTC()
+	var names, addrs []string
+	if assets, err := e.graph.DB.FindByType(oam.FQDN, since); err == nil {
+		for _, a := range assets {
+			if fqdn, ok := a.Asset.(domain.FQDN); ok && e.Config.IsDomainInScope(fqdn.Name) {
+				names = append(names, fqdn.Name)
+			}
+		}
+	}
+	if assets, err := e.graph.DB.FindByType(oam.IPAddress, since); err == nil {
+		for _, a := range assets {
+			if ip, ok := a.Asset.(network.IPAddress); ok {
+				addrs = append(addrs, ip.Address.String())
+			}
+		}
+	}
+
+	matches := threatintel.Check(e.intel, names, addrs)
+	if len(matches) == 0 {
+		return
+	}
+
+	store := e.Sys.Findings()
+	for _, m := range matches {
+		if _, err := store.Add(&findings.Finding{
+			Type:        ThreatIntelFinding,
+			Asset:       m.Asset,
+			Severity:    m.Feed.Severity,
+			Description: fmt.Sprintf("The asset is listed by the %s threat intelligence feed", m.Feed.Name),
+			Source:      m.Feed.Name,
+		}); err != nil {
+			e.Config.Log.Printf("Failed to save the threat intelligence finding: %v", err)
+		}
+	}
+	e.Config.Log.Printf("Threat intelligence: %d discovered assets are listed by the configured feeds", len(matches))
+}
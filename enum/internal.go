@@ -0,0 +1,148 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/net/local"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+// LocalServiceFinding is the finding type used for the DNS-SD services advertised on the local network.
+const LocalServiceFinding = "local_service"
+
+// discoverLocal submits the hosts found on the local network segments into the enumeration. The
+// multicast DNS and NetBIOS responses provide the names, which are then resolved with LLMNR.
+func (e *Enumeration) discoverLocal() {
+	opts := e.internal
+	if opts == nil {
+		return
+	}
+
+	var mu sync.Mutex
+	var hosts []*local.Host
+	var wg sync.WaitGroup
+	run := func(proto string, query func() ([]*local.Host, error)) {
+		defer wg.Done()
+
+		found, err := query()
+		if err != nil {
+			e.Config.Log.Printf("Internal discovery: %s: %v", proto, err)
+			return
+		}
+
+		mu.Lock()
+		hosts = append(hosts, found...)
+		mu.Unlock()
+	}
+
+	if opts.MDNS {
+		wg.Add(1)
+		go run(local.SourceMDNS, func() ([]*local.Host, error) {
+			return local.BrowseMDNS(e.ctx, opts.Timeout)
+		})
+	}
+	if opts.NetBIOS {
+		subnets := opts.Subnets
+		if len(subnets) == 0 {
+			subnets = local.InterfaceSubnets()
+		}
+
+		wg.Add(1)
+		go run(local.SourceNetBIOS, func() ([]*local.Host, error) {
+			return local.QueryNetBIOS(e.ctx, subnets, opts.Timeout)
+		})
+	}
+	wg.Wait()
+
+	if opts.LLMNR {
+		names := append([]string{}, opts.Names...)
+		for _, h := range hosts {
+			names = append(names, h.Name)
+		}
+
+		wg.Add(1)
+		run(local.SourceLLMNR, func() ([]*local.Host, error) {
+			return local.QueryLLMNR(e.ctx, names, opts.Timeout)
+		})
+	}
+
+	e.importLocalHosts(local.Merge(hosts))
+}
+
+func (e *Enumeration) importLocalHosts(hosts []*local.Host) {
+	var numNames, numAddrs, numServices int
+
+	for _, h := range hosts {
+		select {
+		case <-e.done:
+			return
+		default:
+		}
+
+		name := e.internal.Qualify(h.Name)
+		if domain := e.Config.WhichDomain(name); domain != "" {
+			var answers []requests.DNSAnswer
+			for _, addr := range h.Addresses {
+				if ip := net.ParseIP(addr); ip != nil {
+					answers = append(answers, localAnswer(name, ip))
+				}
+			}
+
+			numNames++
+			e.srcStats.name(h.Source)
+			e.nameSrc.newName(&requests.DNSRequest{
+				Name:    name,
+				Domain:  domain,
+				Records: answers,
+				Source:  h.Source,
+			})
+		}
+
+		for _, addr := range h.Addresses {
+			if ip := net.ParseIP(addr); ip != nil {
+				numAddrs++
+				e.nameSrc.newAddr(&requests.AddrRequest{
+					Address: ip.String(),
+					InScope: e.Config.IsAddressInScope(ip.String()),
+				})
+			}
+		}
+
+		for _, svc := range h.Services {
+			numServices++
+			if _, err := e.Sys.Findings().Add(&findings.Finding{
+				Type:        LocalServiceFinding,
+				Asset:       svc.Instance,
+				Severity:    findings.Info,
+				Description: fmt.Sprintf("The %s service is advertised by %s on port %d", svc.Type, name, svc.Port),
+				Source:      h.Source,
+				Details: map[string]string{
+					"host": name,
+					"type": svc.Type,
+					"port": strconv.Itoa(svc.Port),
+				},
+			}); err != nil {
+				e.Config.Log.Printf("Failed to save the local service finding: %v", err)
+			}
+		}
+	}
+
+	e.Config.Log.Printf("Internal discovery: found %d hosts, submitted %d names, %d addresses and %d services",
+		len(hosts), numNames, numAddrs, numServices)
+}
+
+func localAnswer(name string, ip net.IP) requests.DNSAnswer {
+	if ip.To4() != nil {
+		return requests.DNSAnswer{Name: name, Type: int(dns.TypeA), Data: ip.String()}
+	}
+	return requests.DNSAnswer{Name: name, Type: int(dns.TypeAAAA), Data: ip.String()}
+}
@@ -0,0 +1,211 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+	"golang.org/x/net/publicsuffix"
+)
+
+const (
+	// DefaultIPv6Candidates is the number of neighbors generated for each IPv6 network when no limit has been configured.
+	DefaultIPv6Candidates = 128
+	// The number of PTR queries sent at the same time for the IPv6 neighbors
+	maxIPv6Queries = 25
+)
+
+// IPv6ExpansionOptions returns the allocation patterns used to expand the IPv6 addresses into their
+// neighbors, and the number of candidates for each network, as set by the 'ipv6_expansion' section
+// of the configuration options. No patterns are returned when the expansion has not been enabled.
+func IPv6ExpansionOptions(cfg *config.Config) ([]string, int, error) {
+	expRaw, ok := cfg.Options["ipv6_expansion"]
+	if !ok {
+		return nil, 0, nil
+	}
+
+	settings, ok := expRaw.(map[string]interface{})
+	if !ok {
+		return nil, 0, fmt.Errorf("ipv6_expansion is not a map[string]interface{}")
+	}
+
+	if raw, ok := settings["enabled"]; ok {
+		enabled, ok := raw.(bool)
+		if !ok {
+			return nil, 0, fmt.Errorf("ipv6_expansion enabled is not a bool")
+		}
+		if !enabled {
+			return nil, 0, nil
+		}
+	}
+
+	patterns := amassnet.IPv6Patterns
+	if raw, ok := settings["patterns"]; ok {
+		list, ok := raw.([]interface{})
+		if !ok {
+			return nil, 0, fmt.Errorf("ipv6_expansion patterns is not a list")
+		}
+
+		patterns = nil
+		for _, v := range list {
+			p, ok := v.(string)
+			if !ok || !validIPv6Pattern(p) {
+				return nil, 0, fmt.Errorf("ipv6_expansion patterns must be %s", strings.Join(amassnet.IPv6Patterns, ", "))
+			}
+			patterns = append(patterns, p)
+		}
+	}
+
+	max := DefaultIPv6Candidates
+	if raw, ok := settings["max_candidates"]; ok {
+		n, ok := raw.(int)
+		if !ok || n <= 0 {
+			return nil, 0, fmt.Errorf("ipv6_expansion max_candidates must be a positive integer")
+		}
+		max = n
+	}
+	return patterns, max, nil
+}
+
+func validIPv6Pattern(p string) bool {
+	for _, v := range amassnet.IPv6Patterns {
+		if p == v {
+			return true
+		}
+	}
+	return false
+}
+
+// ipv6Expander resolves the PTR records of the likely neighbors of the IPv6 addresses, since the
+// IPv6 networks are too large to be swept like the IPv4 netblocks. Each /64 network is expanded
+// once per enumeration, and all methods are safe to call on a nil ipv6Expander.
+type ipv6Expander struct {
+	sync.Mutex
+	enum       *Enumeration
+	patterns   []string
+	max        int
+	networks   map[string]struct{}
+	sem        chan struct{}
+	wg         sync.WaitGroup
+	candidates int
+	found      int
+}
+
+func newIPv6Expander(e *Enumeration, patterns []string, max int) *ipv6Expander {
+	return &ipv6Expander{
+		enum:     e,
+		patterns: patterns,
+		max:      max,
+		networks: make(map[string]struct{}),
+		sem:      make(chan struct{}, maxIPv6Queries),
+	}
+}
+
+// expand queries the PTR records of the neighbors of the address, when its network was not expanded yet.
+func (x *ipv6Expander) expand(ctx context.Context, addr string) {
+	if x == nil {
+		return
+	}
+
+	ip := net.ParseIP(addr)
+	if ip == nil || ip.To4() != nil || !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return
+	}
+	if reserved, _ := amassnet.IsReservedAddress(addr); reserved {
+		return
+	}
+
+	network := ip.Mask(net.CIDRMask(64, 128)).String()
+	x.Lock()
+	if _, found := x.networks[network]; found {
+		x.Unlock()
+		return
+	}
+	x.networks[network] = struct{}{}
+	x.Unlock()
+
+	for _, n := range amassnet.IPv6Neighbors(addr, x.patterns, x.max) {
+		x.wg.Add(1)
+		go x.resolve(ctx, n.String())
+	}
+}
+
+func (x *ipv6Expander) resolve(ctx context.Context, addr string) {
+	defer x.wg.Done()
+
+	select {
+	case <-ctx.Done():
+		return
+	case x.sem <- struct{}{}:
+	}
+	defer func() { <-x.sem }()
+
+	x.Lock()
+	x.candidates++
+	x.Unlock()
+
+	ptr, err := dns.ReverseAddr(addr)
+	if err != nil {
+		return
+	}
+	resp, err := x.enum.dnsQuery(ctx, ptr, dns.TypePTR, x.enum.Sys.TrustedResolvers(), 3)
+	if err != nil || resp == nil {
+		return
+	}
+
+	for _, rr := range resolve.AnswersByType(resolve.ExtractAnswers(resp), dns.TypePTR) {
+		target := strings.ToLower(resolve.RemoveLastDot(rr.Data))
+		// Only the neighbors named within the scope are fed back into the enumeration
+		if x.enum.Config.WhichDomain(target) == "" {
+			continue
+		}
+
+		name := strings.ToLower(resolve.RemoveLastDot(ptr))
+		domain, err := publicsuffix.EffectiveTLDPlusOne(name)
+		if err != nil {
+			continue
+		}
+
+		x.Lock()
+		x.found++
+		x.Unlock()
+
+		x.enum.nameSrc.newName(&requests.DNSRequest{
+			Name:   name,
+			Domain: domain,
+			Records: []requests.DNSAnswer{{
+				Name: name,
+				Type: int(dns.TypePTR),
+				Data: target,
+			}},
+			Source: "IPv6 Expansion",
+		})
+	}
+}
+
+// wait blocks until the PTR queries have finished, and logs the neighbors found within the scope.
+func (x *ipv6Expander) wait() {
+	if x == nil {
+		return
+	}
+	x.wg.Wait()
+
+	x.Lock()
+	defer x.Unlock()
+
+	if len(x.networks) > 0 {
+		x.enum.Config.Log.Printf("IPv6 expansion: %d networks were expanded into %d candidates, and %d PTR records named hosts within the scope",
+			len(x.networks), x.candidates, x.found)
+	}
+}
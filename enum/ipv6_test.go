@@ -0,0 +1,75 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"testing"
+
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestIPv6ExpansionOptions(t *testing.T) {
+	cfg := config.NewConfig()
+	if patterns, _, err := IPv6ExpansionOptions(cfg); err != nil || len(patterns) != 0 {
+		t.Errorf("Expected the expansion to be disabled, got %v: %v", patterns, err)
+	}
+
+	cfg.Options["ipv6_expansion"] = map[string]interface{}{"enabled": true}
+	if patterns, max, err := IPv6ExpansionOptions(cfg); err != nil ||
+		len(patterns) != len(amassnet.IPv6Patterns) || max != DefaultIPv6Candidates {
+		t.Errorf("Expected the default patterns and limit, got %v and %d: %v", patterns, max, err)
+	}
+
+	cfg.Options["ipv6_expansion"] = map[string]interface{}{
+		"patterns":       []interface{}{"ports"},
+		"max_candidates": 32,
+	}
+	if patterns, max, err := IPv6ExpansionOptions(cfg); err != nil || len(patterns) != 1 || max != 32 {
+		t.Errorf("Expected the ports pattern limited to 32 candidates, got %v and %d: %v", patterns, max, err)
+	}
+
+	cfg.Options["ipv6_expansion"] = map[string]interface{}{"enabled": false}
+	if patterns, _, err := IPv6ExpansionOptions(cfg); err != nil || len(patterns) != 0 {
+		t.Errorf("Expected the expansion to be disabled, got %v: %v", patterns, err)
+	}
+
+	for _, settings := range []map[string]interface{}{
+		{"enabled": "yes"},
+		{"patterns": "words"},
+		{"patterns": []interface{}{"random"}},
+		{"max_candidates": 0},
+	} {
+		cfg.Options["ipv6_expansion"] = settings
+		if _, _, err := IPv6ExpansionOptions(cfg); err == nil {
+			t.Errorf("Expected an error for the settings %v", settings)
+		}
+	}
+}
+
+func TestIPv6ExpanderNetworks(t *testing.T) {
+	var nilx *ipv6Expander
+	nilx.expand(context.Background(), "2001:db8::1")
+	nilx.wait()
+
+	// Canceling the context stops the queries before they are sent
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	x := newIPv6Expander(&Enumeration{}, amassnet.IPv6Patterns, 4)
+	x.sem = make(chan struct{})
+	for _, addr := range []string{"2600:9000:1::5", "2600:9000:1::ab", "2600:9000:2::1", "192.0.2.1", "::1", "fe80::1"} {
+		x.expand(ctx, addr)
+	}
+	x.wg.Wait()
+
+	if len(x.networks) != 2 {
+		t.Errorf("Expected two networks to be expanded, got %d", len(x.networks))
+	}
+	if x.candidates != 0 {
+		t.Errorf("Expected no queries after the cancelation, got %d", x.candidates)
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/net/mailsec"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+const (
+	// EmailSecurityFinding is the finding type used to record the email security posture of the root domains.
+	EmailSecurityFinding = "email_security"
+	// EmailRelatedFinding is the finding type used for the out-of-scope domains referenced by the email policies.
+	EmailRelatedFinding = "email_related_domain"
+)
+
+// The source of the in-scope names referenced by the email policies of the root domains.
+const emailPolicySource = "Email Policies"
+
+// mailPosture collects the SPF, DMARC, DKIM and MX records of the root domains of the enumeration.
+type mailPosture struct {
+	sync.Mutex
+	enum      *Enumeration
+	selectors []string
+	seen      map[string]struct{}
+	wg        sync.WaitGroup
+	weak      int
+}
+
+func newMailPosture(e *Enumeration, opts *mailsec.Options) *mailPosture {
+	return &mailPosture{
+		enum:      e,
+		selectors: opts.Selectors,
+		seen:      make(map[string]struct{}),
+	}
+}
+
+// collect records the posture of the root domain once per enumeration.
+func (mp *mailPosture) collect(ctx context.Context, domain string) {
+	if mp == nil {
+		return
+	}
+
+	mp.Lock()
+	if _, found := mp.seen[domain]; found {
+		mp.Unlock()
+		return
+	}
+	mp.seen[domain] = struct{}{}
+	mp.Unlock()
+
+	mp.wg.Add(1)
+	go func() {
+		defer mp.wg.Done()
+
+		var r mailsec.Resolver = &mailResolver{enum: mp.enum}
+		// Retrieving the MTA-STS policy sends a request to the web server of the target
+		if mp.enum.Config.Active {
+			r = &activeMailResolver{mailResolver{enum: mp.enum}}
+		}
+
+		p := mailsec.Collect(ctx, r, domain, mp.selectors)
+		mp.related(p)
+		weak := p.Weaknesses()
+
+		sev := findings.Info
+		desc := "The domain publishes SPF and DMARC policies rejecting spoofed mail"
+		if len(weak) > 0 {
+			sev = findings.Low
+			desc = "Mail from the domain can be spoofed: " + strings.Join(weak, ", ")
+		}
+
+		if _, err := mp.enum.Sys.Findings().Add(&findings.Finding{
+			Type:        EmailSecurityFinding,
+			Asset:       domain,
+			Severity:    sev,
+			Description: desc,
+			Source:      "Amass",
+			Details:     postureDetails(p),
+		}); err != nil {
+			mp.enum.Config.Log.Printf("Failed to save the email security finding: %v", err)
+			return
+		}
+		if len(weak) > 0 {
+			mp.Lock()
+			mp.weak++
+			mp.Unlock()
+		}
+	}()
+}
+
+// related submits the in-scope names referenced by the email policies to the enumeration, and records
+// the others as candidates, since the policies commonly reference the infrastructure of third parties.
+func (mp *mailPosture) related(p *mailsec.Posture) {
+	e := mp.enum
+
+	for _, rel := range p.Related() {
+		if e.Config.Blacklisted(rel.Name) {
+			continue
+		}
+
+		if root := e.Config.WhichDomain(rel.Name); root != "" {
+			e.nameSrc.newName(&requests.DNSRequest{
+				Name:   rel.Name,
+				Domain: root,
+				Source: emailPolicySource,
+			})
+			continue
+		}
+
+		if _, err := e.Sys.Findings().Add(&findings.Finding{
+			Type:        EmailRelatedFinding,
+			Asset:       rel.Name,
+			Severity:    findings.Info,
+			Description: relatedDescription(p.Domain, rel),
+			Source:      emailPolicySource,
+			Details: map[string]string{
+				"domain":   p.Domain,
+				"relation": rel.Relation,
+			},
+		}); err != nil {
+			e.Config.Log.Printf("Failed to save the email related domain finding: %v", err)
+		}
+	}
+}
+
+func relatedDescription(domain string, rel *mailsec.Related) string {
+	switch rel.Relation {
+	case mailsec.RelationSPFRedirect:
+		return "The SPF policy of " + domain + " is redirected to " + rel.Name
+	case mailsec.RelationMTASTSMX:
+		return "The MTA-STS policy of " + domain + " lists the mail exchange " + rel.Name
+	case mailsec.RelationTLSRPT:
+		return "The TLS-RPT reports of " + domain + " are sent to " + rel.Name
+	}
+	return "The SPF policy of " + domain + " includes " + rel.Name
+}
+
+func postureDetails(p *mailsec.Posture) map[string]string {
+	details := make(map[string]string)
+
+	if p.SPF != nil {
+		details["spf"] = p.SPF.Record
+		details["spf_all"] = p.SPF.All
+		details["spf_lookups"] = strconv.Itoa(p.SPF.Lookups)
+		if len(p.SPF.Includes) > 0 {
+			details["spf_includes"] = strings.Join(p.SPF.Includes, ",")
+		}
+		if len(p.SPF.Errors) > 0 {
+			details["spf_errors"] = strings.Join(p.SPF.Errors, ", ")
+		}
+	}
+	if p.DMARC != nil {
+		details["dmarc"] = p.DMARC.Record
+		details["dmarc_policy"] = p.DMARC.Policy
+		if len(p.DMARC.RUA) > 0 {
+			details["dmarc_rua"] = strings.Join(p.DMARC.RUA, ",")
+		}
+	}
+	if len(p.DKIM) > 0 {
+		details["dkim_selectors"] = strings.Join(p.DKIM, ",")
+	}
+	if len(p.MX) > 0 {
+		details["mx"] = strings.Join(p.MX, ",")
+	}
+	if p.MTASTS != nil {
+		details["mta_sts"] = p.MTASTS.Record
+		if p.MTASTS.Mode != "" {
+			details["mta_sts_mode"] = p.MTASTS.Mode
+		}
+	}
+	if p.TLSRPT != nil {
+		details["tls_rpt"] = p.TLSRPT.Record
+	}
+	return details
+}
+
+// wait blocks until the postures are collected, and logs the domains that can be spoofed.
+func (mp *mailPosture) wait() {
+	if mp == nil {
+		return
+	}
+
+	mp.wg.Wait()
+
+	mp.Lock()
+	defer mp.Unlock()
+
+	if len(mp.seen) > 0 {
+		mp.enum.Config.Log.Printf("Email security: %d of the %d root domains can be spoofed", mp.weak, len(mp.seen))
+	}
+}
+
+// mailResolver queries the trusted resolvers for the records of the posture.
+type mailResolver struct {
+	enum *Enumeration
+}
+
+func (r *mailResolver) TXT(ctx context.Context, name string) ([]string, error) {
+	resp, err := r.enum.dnsQuery(ctx, name, dns.TypeTXT, r.enum.Sys.TrustedResolvers(), maxDNSQueryAttempts)
+	if err != nil {
+		return nil, err
+	} else if resp == nil {
+		return nil, errors.New("query failed")
+	}
+
+	var txts []string
+	for _, rr := range resp.Answer {
+		// The strings of a record are concatenated without spaces, as required by the SPF and DKIM records
+		if t, ok := rr.(*dns.TXT); ok {
+			txts = append(txts, strings.Join(t.Txt, ""))
+		}
+	}
+	return txts, nil
+}
+
+func (r *mailResolver) MX(ctx context.Context, name string) ([]string, error) {
+	resp, err := r.enum.dnsQuery(ctx, name, dns.TypeMX, r.enum.Sys.TrustedResolvers(), maxDNSQueryAttempts)
+	if err != nil {
+		return nil, err
+	} else if resp == nil {
+		return nil, errors.New("query failed")
+	}
+
+	var targets []string
+	for _, rr := range resp.Answer {
+		if mx, ok := rr.(*dns.MX); ok {
+			targets = append(targets, mx.Mx)
+		}
+	}
+	return targets, nil
+}
+
+// activeMailResolver also retrieves the MTA-STS policies from the web servers of the target.
+type activeMailResolver struct {
+	mailResolver
+}
+
+func (r *activeMailResolver) FetchPolicy(ctx context.Context, url string) (string, error) {
+	if !r.enum.Sys.Budget().SpendHTTP(budgetSource) {
+		return "", errors.New("the HTTP budget has been exhausted")
+	}
+
+	resp, err := http.RequestWebPage(ctx, &http.Request{URL: url})
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("the policy request returned status code %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
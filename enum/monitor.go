@@ -20,6 +20,8 @@ func (e *Enumeration) checkForMissedWildcards(addr string) {
 	if err != nil {
 		return
 	}
+	// The names recently found to resolve to the address may still be pending
+	e.writes.sync(addr)
 
 	var t string
 	if ip.Is4() {
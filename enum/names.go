@@ -6,16 +6,22 @@ package enum
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
 	"github.com/caffix/pipeline"
 	"github.com/caffix/stringset"
+	"github.com/owasp-amass/amass/v4/findings"
 	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/shared"
 	"github.com/owasp-amass/asset-db/types"
 	oam "github.com/owasp-amass/open-asset-model"
 	"github.com/owasp-amass/open-asset-model/domain"
 )
 
+// WildcardFinding is the finding type used for the subdomains that answer queries for any name below them.
+const WildcardFinding = "dns_wildcard"
+
 // subdomainTask handles newly discovered proper subdomain names in the enumeration.
 type subdomainTask struct {
 	enum            *Enumeration
@@ -73,6 +79,9 @@ func (r *subdomainTask) Process(ctx context.Context, data pipeline.Data, tp pipe
 		}
 	}
 
+	if req.Name == req.Domain {
+		r.enum.mail.collect(ctx, req.Domain)
+	}
 	if r.checkForSubdomains(ctx, req, tp) {
 		r.enum.sendRequests(&requests.ResolvedRequest{
 			Name:    req.Name,
@@ -98,10 +107,22 @@ func (r *subdomainTask) checkForSubdomains(ctx context.Context, req *requests.DN
 
 	sub := strings.TrimSpace(strings.Join(nlabels[1:], "."))
 	times := r.timesForSubdomain(sub)
-	if times == 1 && r.subWithinWildcard(ctx, sub, req.Domain) {
-		r.withinWildcards.Insert(sub)
-		return false
-	} else if times > 1 && r.withinWildcards.Has(sub) {
+	if times == 1 {
+		wildcard := r.subWithinWildcard(ctx, sub, req.Domain)
+		// Share the result, so the data sources do not need to test the subdomain again
+		r.enum.Sys.Shared().Publish(&shared.Entry{
+			Topic:  shared.Wildcard,
+			Key:    sub,
+			Value:  wildcard,
+			Source: "Amass",
+		})
+		if wildcard {
+			r.withinWildcards.Insert(sub)
+			r.wildcardFinding(sub)
+			return false
+		}
+	}
+	if times > 1 && r.withinWildcards.Has(sub) {
 		return false
 	} else if times == 1 && r.enum.graph.IsCNAMENode(ctx, sub, r.enum.Config.CollectionStartTime.UTC()) {
 		r.cnames.Insert(sub)
@@ -125,7 +146,7 @@ func (r *subdomainTask) checkForSubdomains(ctx context.Context, req *requests.DN
 }
 
 func (r *subdomainTask) subWithinWildcard(ctx context.Context, name, domain string) bool {
-	for _, t := range FwdQueryTypes {
+	for _, t := range r.enum.rrtypes.forward {
 		select {
 		case <-ctx.Done():
 			return false
@@ -140,6 +161,20 @@ func (r *subdomainTask) subWithinWildcard(ctx context.Context, name, domain stri
 	return false
 }
 
+// wildcardFinding reports the subdomain that resolves any name below it, since the wildcard can
+// mask subdomain takeovers and allows attackers to host content under arbitrary names.
+func (r *subdomainTask) wildcardFinding(sub string) {
+	if _, err := r.enum.Sys.Findings().Add(&findings.Finding{
+		Type:        WildcardFinding,
+		Asset:       "*." + sub,
+		Severity:    findings.Low,
+		Description: fmt.Sprintf("The DNS wildcard resolves any name below %s", sub),
+		Source:      "Amass",
+	}); err != nil {
+		r.enum.Config.Log.Printf("Failed to save the DNS wildcard finding: %v", err)
+	}
+}
+
 func (r *subdomainTask) timesForSubdomain(sub string) int {
 	ch := make(chan int, 2)
 
@@ -177,6 +212,7 @@ func (r *subdomainTask) timesManager() {
 }
 
 func (r *subdomainTask) linkNodesToApexes() {
+	r.enum.writes.sync()
 	apexes := make(map[string]*types.Asset)
 
 	for k := range r.possibleApexes {
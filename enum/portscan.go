@@ -0,0 +1,134 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/owasp-amass/amass/v4/events"
+	"github.com/owasp-amass/amass/v4/findings"
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/owasp-amass/amass/v4/net/portscan"
+	"github.com/owasp-amass/amass/v4/shared"
+	oam "github.com/owasp-amass/open-asset-model"
+)
+
+const (
+	// OpenPortFinding is the finding type used to record the services exposed by the in-scope addresses.
+	OpenPortFinding = "open_port"
+	// ServiceTopic is the shared board topic of the open services, keyed by 'address:port/protocol'.
+	ServiceTopic = "service"
+)
+
+// The asset type used in the published events, since the asset model does not provide a service asset.
+const serviceAssetType = "Service"
+
+// portScanner reports the services exposed by the in-scope addresses discovered by the enumeration.
+type portScanner struct {
+	sync.Mutex
+	enum    *Enumeration
+	scanner *portscan.Scanner
+	seen    map[string]struct{}
+	wg      sync.WaitGroup
+	open    int
+}
+
+func newPortScanner(e *Enumeration, scanner *portscan.Scanner) *portScanner {
+	return &portScanner{
+		enum:    e,
+		scanner: scanner,
+		seen:    make(map[string]struct{}),
+	}
+}
+
+// scan checks the address for open services once per enumeration.
+func (ps *portScanner) scan(addr string) {
+	if ps == nil || !ps.enum.Config.IsAddressInScope(addr) {
+		return
+	}
+	if reserved, _ := amassnet.IsReservedAddress(addr); reserved {
+		return
+	}
+
+	ps.Lock()
+	if _, found := ps.seen[addr]; found {
+		ps.Unlock()
+		return
+	}
+	ps.seen[addr] = struct{}{}
+	ps.Unlock()
+
+	ps.wg.Add(1)
+	go func() {
+		defer ps.wg.Done()
+
+		for _, svc := range ps.scanner.Scan(ps.enum.ctx, addr) {
+			ps.newService(svc)
+		}
+	}()
+}
+
+func (ps *portScanner) newService(svc *portscan.Service) {
+	e := ps.enum
+	port := strconv.Itoa(svc.Port) + "/" + svc.Protocol
+	if svc.Name != "" {
+		port += " (" + svc.Name + ")"
+	}
+
+	if _, err := e.Sys.Findings().Add(&findings.Finding{
+		Type:        OpenPortFinding,
+		Asset:       svc.Key(),
+		Severity:    findings.Info,
+		Description: fmt.Sprintf("Port %s is open on %s", port, svc.Address),
+		Source:      svc.Source,
+	}); err != nil {
+		e.Config.Log.Printf("Failed to save the open port finding: %v", err)
+	}
+
+	e.Sys.Shared().Publish(&shared.Entry{
+		Topic: ServiceTopic,
+		Key:   svc.Key(),
+		Value: map[string]interface{}{
+			"address":  svc.Address,
+			"port":     svc.Port,
+			"protocol": svc.Protocol,
+			"name":     svc.Name,
+		},
+		Source: svc.Source,
+	})
+
+	if e.events != nil {
+		e.publishEntity(oam.IPAddress, svc.Address, svc.Source)
+		e.publishEntity(serviceAssetType, svc.Key(), svc.Source)
+		e.publish(&events.Event{
+			Kind:   events.EdgeEvent,
+			Type:   "port",
+			From:   svc.Address,
+			To:     svc.Key(),
+			Source: svc.Source,
+		})
+	}
+
+	ps.Lock()
+	ps.open++
+	ps.Unlock()
+}
+
+// wait blocks until the scans of the discovered addresses have finished, and logs the results.
+func (ps *portScanner) wait() {
+	if ps == nil {
+		return
+	}
+
+	ps.wg.Wait()
+	ps.Lock()
+	defer ps.Unlock()
+
+	if ps.open > 0 {
+		ps.enum.Config.Log.Printf("Port scan: %d open services were found on %d addresses", ps.open, len(ps.seen))
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/events"
+	oam "github.com/owasp-amass/open-asset-model"
+)
+
+// The sources attributed to the discoveries made by the enumeration itself.
+const (
+	dnsEventSource   = "DNS"
+	infraEventSource = "Infrastructure"
+)
+
+func newSessionID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// publishRecord publishes the assets on both ends of the relation and the relation itself,
+// the first time each is discovered during the enumeration.
+func (e *Enumeration) publishRecord(from, rel string, totype oam.AssetType, to, source string) {
+	if e.events == nil {
+		return
+	}
+	if source == "" {
+		source = dnsEventSource
+	}
+
+	e.publishEntity(oam.FQDN, from, source)
+	e.publishEntity(totype, to, source)
+	e.publish(&events.Event{
+		Kind:   events.EdgeEvent,
+		Type:   rel,
+		From:   from,
+		To:     to,
+		Source: source,
+	})
+}
+
+// publishInfrastructure publishes the assets and relations created for the address.
+func (e *Enumeration) publishInfrastructure(asn int, desc, addr, prefix string) {
+	if e.events == nil {
+		return
+	}
+
+	as := strconv.Itoa(asn)
+	e.publishEntity(oam.ASN, as, infraEventSource)
+	e.publishEntity(oam.RIROrg, desc, infraEventSource)
+	e.publishEntity(oam.Netblock, prefix, infraEventSource)
+	e.publishEntity(oam.IPAddress, addr, infraEventSource)
+	for _, edge := range [][]string{{as, "managed_by", desc}, {as, "announces", prefix}, {prefix, "contains", addr}} {
+		e.publish(&events.Event{
+			Kind:   events.EdgeEvent,
+			Type:   edge[1],
+			From:   edge[0],
+			To:     edge[2],
+			Source: infraEventSource,
+		})
+	}
+}
+
+// AddPublisher delivers the events of the enumeration to the publisher, along with the publishers of the
+// 'events' section of the configuration. The publishers must be added before the enumeration is started.
+func (e *Enumeration) AddPublisher(p events.Publisher) {
+	e.pubs = append(e.pubs, p)
+}
+
+func (e *Enumeration) publishEntity(atype oam.AssetType, asset, source string) {
+	e.publish(&events.Event{
+		Kind:   events.EntityEvent,
+		Type:   string(atype),
+		Asset:  asset,
+		Source: source,
+	})
+}
+
+func (e *Enumeration) publish(ev *events.Event) {
+	key := strings.ToLower(strings.Join([]string{ev.Kind, ev.Type, ev.Asset, ev.From, ev.To}, "|"))
+	if _, loaded := e.published.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+
+	ev.Session = e.ID
+	e.events.Send(ev)
+}
+
+func (e *Enumeration) closeEvents() {
+	if e.events == nil {
+		return
+	}
+
+	if err := e.events.Close(); err != nil {
+		e.Config.Log.Printf("Event publishing: %v", err)
+	}
+	if dropped, failed, err := e.events.Stats(); dropped > 0 || failed > 0 {
+		e.Config.Log.Printf("Event publishing: %d events were dropped and %d failed delivery: %v", dropped, failed, err)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"github.com/caffix/service"
+	"github.com/owasp-amass/amass/v4/datasrcs"
+	"github.com/owasp-amass/amass/v4/quota"
+)
+
+// quotaExceeded returns true when the request should not be sent to the data source, since its API
+// key was rejected or its quota ran out. Once the remaining quota falls below the reserve, only the
+// requests driving the enumeration are sent to the data source.
+func (e *Enumeration) quotaExceeded(srv service.Service, req interface{}) bool {
+	name := srv.String()
+	tracker := e.Sys.Quotas()
+
+	var reason string
+	if tracker.Exhausted(name) {
+		reason = "the API key was rejected or the quota ran out"
+	} else if tracker.Low(name) && sourcePriority(srv)(req) < highPriority {
+		reason = "the remaining quota is reserved for the requests driving the enumeration"
+	} else {
+		return false
+	}
+
+	// Only the first request dropped for each data source is logged, and the total is reported at the end
+	if e.srcStats.quotaDropped(name) == 1 {
+		e.Config.Log.Printf("API quota: requests to %s, such as %s, are being dropped, since %s",
+			name, datasrcs.RequestKey(req), reason)
+	}
+	return true
+}
+
+func (e *Enumeration) reportQuotas() {
+	dropped := make(map[string]int)
+	for _, ss := range e.srcStats.snapshot() {
+		dropped[ss.Name] = ss.QuotaDropped
+	}
+
+	for _, s := range e.Sys.Quotas().Snapshot() {
+		switch s.State {
+		case quota.StateInvalid:
+			e.Config.Log.Printf("API quota: the %s API key is invalid: %s", s.Source, s.Detail)
+		case quota.StateExhausted:
+			e.Config.Log.Printf("API quota: the %s quota was exhausted and %d requests were dropped: %s",
+				s.Source, dropped[s.Source], s.Detail)
+		default:
+			if s.Remaining < 0 {
+				continue
+			}
+			msg := "API quota: %d of the %s requests remain"
+			if dropped[s.Source] > 0 {
+				e.Config.Log.Printf(msg+", and %d requests were dropped to preserve them", s.Remaining, s.Source, dropped[s.Source])
+			} else {
+				e.Config.Log.Printf(msg, s.Remaining, s.Source)
+			}
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/report"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/scope"
+	"github.com/owasp-amass/config/config"
+	"golang.org/x/net/publicsuffix"
+)
+
+// ExpandOnRegistrant returns true when the 'scope' section of the configuration options allows the
+// enumeration to add the domains registered by the contacts of the target domains to the scope.
+func ExpandOnRegistrant(cfg *config.Config) (bool, error) {
+	return scopeBool(cfg, "expand_on_registrant")
+}
+
+// DiscoverByOrganization returns true when the 'scope' section of the configuration options enables
+// the search for the other root domains registered to the organizations of the target domains. The
+// domains found are reported as candidates for review, and are not added to the scope.
+func DiscoverByOrganization(cfg *config.Config) (bool, error) {
+	return scopeBool(cfg, "discover_by_organization")
+}
+
+// reviewInterval is how often the enumeration checks the scope review queue for the approved candidates.
+const reviewInterval = 10 * time.Second
+
+func scopeBool(cfg *config.Config, key string) (bool, error) {
+	scopeRaw, ok := cfg.Options["scope"]
+	if !ok {
+		return false, nil
+	}
+
+	settings, ok := scopeRaw.(map[string]interface{})
+	if !ok {
+		return false, fmt.Errorf("scope is not a map[string]interface{}")
+	}
+
+	raw, ok := settings[key]
+	if !ok {
+		return false, nil
+	}
+
+	b, ok := raw.(bool)
+	if !ok {
+		return false, fmt.Errorf("scope %s is not a bool", key)
+	}
+	return b, nil
+}
+
+// newRegistrant sends the contact of a registered domain to the reverse WHOIS data sources, and
+// the organization of the contact to the data sources searching for its other domains.
+func (e *Enumeration) newRegistrant(req *requests.RegistrantRequest) {
+	if !req.Valid() || !e.Config.IsDomainInScope(req.Domain) {
+		return
+	}
+	if e.discover && strings.TrimSpace(req.Organization) != "" {
+		e.sendRequests(&requests.OrganizationRequest{
+			Name:   strings.TrimSpace(req.Organization),
+			Domain: req.Domain,
+			Source: req.Source,
+		})
+	}
+	if e.expand {
+		e.sendRequests(req)
+	}
+}
+
+// submitOrganizations sends the organizations of the configuration that own the target domains to
+// the data sources searching for the other domains registered to them.
+func (e *Enumeration) submitOrganizations() {
+	if !e.discover {
+		return
+	}
+
+	orgs, err := report.OrganizationsFromConfig(e.Config)
+	if err != nil {
+		return
+	}
+	for _, org := range orgs {
+		for _, d := range org.Domains {
+			if e.Config.IsDomainInScope(d) {
+				e.sendRequests(&requests.OrganizationRequest{
+					Name:   org.Name,
+					Domain: d,
+				})
+				break
+			}
+		}
+	}
+}
+
+// newAssociations submits the domains registered by the same contact as a target domain for the review
+// of an analyst, and adds those approved to the scope. Without the review queue, the domains are approved.
+func (e *Enumeration) newAssociations(req *requests.WhoisRequest, source string) {
+	if !e.expand || !e.Config.IsDomainInScope(req.Domain) {
+		return
+	}
+
+	for _, name := range req.NewDomains {
+		domain, err := publicsuffix.EffectiveTLDPlusOne(strings.ToLower(strings.TrimSpace(strings.TrimSuffix(name, "."))))
+		if err != nil || e.Config.IsDomainInScope(domain) || e.Config.Blacklisted(domain) {
+			continue
+		}
+
+		if e.review != nil {
+			state, err := e.review.Submit(domain, req.Domain, source)
+			if err != nil {
+				e.Config.Log.Printf("Registrant pivot: failed to queue %s for review: %v", domain, err)
+				continue
+			}
+			if state == scope.Pending {
+				e.Config.Log.Printf("Registrant pivot: %s found %s, registered by the same contact as %s, awaiting approval", source, domain, req.Domain)
+			}
+			if state != scope.Approved {
+				continue
+			}
+		}
+		e.addRootDomain(domain, req.Domain, source)
+	}
+}
+
+// watchReviews adds the candidates approved by the analysts to the scope until the enumeration ends.
+func (e *Enumeration) watchReviews() {
+	t := time.NewTicker(reviewInterval)
+	defer t.Stop()
+
+	for {
+		e.addApproved()
+
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// addApproved adds the approved candidates related to the domains in scope.
+func (e *Enumeration) addApproved() {
+	list, err := e.review.Approved()
+	if err != nil {
+		e.Config.Log.Printf("Registrant pivot: failed to read the scope review queue: %v", err)
+		return
+	}
+
+	for _, c := range list {
+		if c.Related == "" || !e.Config.IsDomainInScope(c.Related) ||
+			e.Config.IsDomainInScope(c.Domain) || e.Config.Blacklisted(c.Domain) {
+			continue
+		}
+		e.addRootDomain(c.Domain, c.Related, c.Source)
+	}
+}
+
+// addRootDomain adds the domain to the scope, and submits it to the enumeration as a new root domain name.
+func (e *Enumeration) addRootDomain(domain, related, source string) {
+	e.Config.AddDomain(domain)
+	e.Config.Log.Printf("Registrant pivot: %s added %s to the scope, registered by the same contact as %s", source, domain, related)
+
+	root := &requests.DNSRequest{
+		Name:   domain,
+		Domain: domain,
+		Source: source,
+	}
+	e.nameSrc.newName(root)
+	e.sendRequests(root.Clone().(*requests.DNSRequest))
+}
@@ -0,0 +1,194 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/caffix/queue"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/scope"
+	"github.com/owasp-amass/config/config"
+	bf "github.com/tylertreat/BoomFilters"
+)
+
+func TestExpandOnRegistrant(t *testing.T) {
+	cfg := config.NewConfig()
+	if expand, err := ExpandOnRegistrant(cfg); err != nil || expand {
+		t.Errorf("Expected scope expansion to be disabled by default: %v", err)
+	}
+
+	cfg.Options["scope"] = map[string]interface{}{"expand_on_registrant": true}
+	if expand, err := ExpandOnRegistrant(cfg); err != nil || !expand {
+		t.Errorf("Expected scope expansion to be enabled: %v", err)
+	}
+
+	cfg.Options["scope"] = map[string]interface{}{"expand_on_registrant": "yes"}
+	if _, err := ExpandOnRegistrant(cfg); err == nil {
+		t.Error("Expected an error for the option that is not a bool")
+	}
+}
+
+func TestTLDExpansion(t *testing.T) {
+	cfg := config.NewConfig()
+	if tlds, err := TLDExpansion(cfg); err != nil || len(tlds) != 0 {
+		t.Errorf("Expected the TLD expansion to be disabled by default: %v, %v", tlds, err)
+	}
+
+	cfg.Options["scope"] = map[string]interface{}{"tld_expansion": []interface{}{"NET", ".co.uk"}}
+	if tlds, err := TLDExpansion(cfg); err != nil || len(tlds) != 2 || tlds[0] != "net" || tlds[1] != "co.uk" {
+		t.Errorf("Unexpected TLDs: %v, %v", tlds, err)
+	}
+
+	cfg.Options["scope"] = map[string]interface{}{"tld_expansion": "net"}
+	if _, err := TLDExpansion(cfg); err == nil {
+		t.Error("Expected an error for the option that is not a list")
+	}
+}
+
+func TestBrandVariants(t *testing.T) {
+	variants := brandVariants([]string{"owasp.org", "www.example.co.uk"}, []string{"org", "net", "com"})
+
+	expected := map[string]string{
+		"owasp.net":   "owasp.org",
+		"owasp.com":   "owasp.org",
+		"example.org": "example.co.uk",
+		"example.net": "example.co.uk",
+		"example.com": "example.co.uk",
+	}
+	if len(variants) != len(expected) {
+		t.Errorf("Unexpected variants: %v", variants)
+	}
+	for v, d := range expected {
+		if variants[v] != d {
+			t.Errorf("The variant %s was derived from %q, expected %s", v, variants[v], d)
+		}
+	}
+}
+
+func newRegistrantTestEnum(expand bool) *Enumeration {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+
+	e := &Enumeration{
+		Config:   cfg,
+		expand:   expand,
+		requests: queue.NewQueue(),
+	}
+	e.nameSrc = &enumSource{
+		enum:   e,
+		queue:  queue.NewQueue(),
+		filter: bf.NewDefaultStableBloomFilter(1000, 0.01),
+		done:   make(chan struct{}),
+	}
+	return e
+}
+
+func TestRegistrantPivot(t *testing.T) {
+	contact := &requests.RegistrantRequest{Domain: "owasp.org", Email: "admin@owasp.org"}
+	assoc := &requests.WhoisRequest{Domain: "owasp.org", NewDomains: []string{"www.owasp.net", "owasp.org", "example.co.uk"}}
+
+	e := newRegistrantTestEnum(false)
+	e.newRegistrant(contact)
+	e.newAssociations(assoc, "WhoisXMLAPI")
+	if e.requests.Len() != 0 || e.nameSrc.queue.Len() != 0 || len(e.Config.Domains()) != 1 {
+		t.Fatal("The scope was expanded while the option was disabled")
+	}
+
+	e = newRegistrantTestEnum(true)
+	e.newRegistrant(&requests.RegistrantRequest{Domain: "example.com", Email: "admin@example.com"})
+	if e.requests.Len() != 0 {
+		t.Error("A contact of a domain out of scope was sent to the data sources")
+	}
+	e.newRegistrant(contact)
+	if e.requests.Len() != 1 {
+		t.Error("The contact was not sent to the data sources")
+	}
+
+	e.newAssociations(assoc, "WhoisXMLAPI")
+	if domains := e.Config.Domains(); len(domains) != 3 {
+		t.Fatalf("Expected the scope to contain three domains: %v", domains)
+	}
+	if !e.Config.IsDomainInScope("owasp.net") || !e.Config.IsDomainInScope("example.co.uk") {
+		t.Error("The registered domains were not added to the scope")
+	}
+	if e.nameSrc.queue.Len() != 2 {
+		t.Errorf("Expected two new root domain names, got %d", e.nameSrc.queue.Len())
+	}
+
+	// Domains of associations with targets out of scope are not added
+	e.newAssociations(&requests.WhoisRequest{Domain: "example.com", NewDomains: []string{"example.net"}}, "WhoisXMLAPI")
+	if e.Config.IsDomainInScope("example.net") {
+		t.Error("A domain associated with a target out of scope was added")
+	}
+}
+
+func TestRegistrantReview(t *testing.T) {
+	e := newRegistrantTestEnum(true)
+	e.review, _ = scope.Open(filepath.Join(t.TempDir(), scope.ReviewFile))
+
+	e.newAssociations(&requests.WhoisRequest{Domain: "owasp.org", NewDomains: []string{"owasp.net", "example.net"}}, "WhoisXMLAPI")
+	if len(e.Config.Domains()) != 1 || e.nameSrc.queue.Len() != 0 {
+		t.Fatal("The domains were added to the scope before their review")
+	}
+	if list, _ := e.review.List(scope.Pending); len(list) != 2 || list[1].Related != "owasp.org" {
+		t.Fatalf("Expected two pending candidates, got %v", list)
+	}
+
+	_, _ = e.review.Review("owasp.net", scope.Approved, "alice")
+	_, _ = e.review.Review("example.net", scope.Denied, "alice")
+	e.addApproved()
+	if !e.Config.IsDomainInScope("owasp.net") || e.nameSrc.queue.Len() != 1 {
+		t.Error("The approved domain was not added to the scope")
+	}
+
+	e.newAssociations(&requests.WhoisRequest{Domain: "owasp.org", NewDomains: []string{"example.net"}}, "WhoisXMLAPI")
+	if e.Config.IsDomainInScope("example.net") {
+		t.Error("The denied domain was added to the scope")
+	}
+}
+
+func TestDiscoverByOrganization(t *testing.T) {
+	cfg := config.NewConfig()
+	if discover, err := DiscoverByOrganization(cfg); err != nil || discover {
+		t.Errorf("Expected the discovery to be disabled by default: %v", err)
+	}
+
+	cfg.Options["scope"] = map[string]interface{}{"discover_by_organization": true}
+	if discover, err := DiscoverByOrganization(cfg); err != nil || !discover {
+		t.Errorf("Expected the discovery to be enabled: %v", err)
+	}
+
+	cfg.Options["scope"] = map[string]interface{}{"discover_by_organization": 1}
+	if _, err := DiscoverByOrganization(cfg); err == nil {
+		t.Error("Expected an error for the option that is not a bool")
+	}
+
+	e := newRegistrantTestEnum(false)
+	e.discover = true
+	e.newRegistrant(&requests.RegistrantRequest{Domain: "owasp.org", Email: "admin@owasp.org"})
+	if e.requests.Len() != 0 {
+		t.Error("A contact without an organization was sent to the data sources")
+	}
+
+	e.newRegistrant(&requests.RegistrantRequest{Domain: "owasp.org", Email: "admin@owasp.org", Organization: " OWASP Foundation "})
+	if e.requests.Len() != 1 {
+		t.Fatalf("Expected only the organization to be sent to the data sources, got %d requests", e.requests.Len())
+	}
+	element, _ := e.requests.Next()
+	if org, ok := element.(*requests.OrganizationRequest); !ok || org.Name != "OWASP Foundation" || org.Domain != "owasp.org" {
+		t.Errorf("Unexpected request sent to the data sources: %v", element)
+	}
+
+	e.Config.Options["organizations"] = map[string]interface{}{
+		"OWASP":   []interface{}{"owasp.org"},
+		"Example": []interface{}{"example.com"},
+	}
+	e.submitOrganizations()
+	if e.requests.Len() != 1 {
+		t.Errorf("Expected the organization owning a target domain to be sent, got %d requests", e.requests.Len())
+	}
+}
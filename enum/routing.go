@@ -0,0 +1,105 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/events"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/asset-db/types"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+// BGPOriginChangeFinding is the finding type used when a prefix is announced by
+// a different autonomous system than the one stored in the graph database.
+const BGPOriginChangeFinding = "bgp_origin_change"
+
+// newRoutes stores the prefixes announced by the autonomous system, as observed in
+// BGP routing data, independently of the registration data for the addresses.
+func (e *Enumeration) newRoutes(req *requests.RoutingRequest) {
+	if !req.Valid() {
+		return
+	}
+
+	ctx := context.Background()
+	as, err := e.graph.UpsertAS(ctx, req.ASN, req.Description)
+	if err != nil {
+		e.Config.Log.Printf("%s: failed to store AS%d: %v", req.Source, req.ASN, err)
+		return
+	}
+
+	for _, prefix := range req.Prefixes {
+		netblock, err := e.graph.UpsertNetblock(ctx, prefix)
+		if err != nil {
+			e.Config.Log.Printf("%s: failed to store the netblock %s: %v", req.Source, prefix, err)
+			continue
+		}
+
+		e.checkOrigin(netblock, prefix, req)
+		if _, err := e.graph.DB.Create(as, "announces", netblock.Asset); err != nil {
+			e.Config.Log.Printf("%s: failed to store the announcement of %s: %v", req.Source, prefix, err)
+			continue
+		}
+		e.publishRoute(req.ASN, req.Description, prefix, req.Source)
+	}
+}
+
+// checkOrigin reports a finding when the netblock was previously announced by a different autonomous system.
+func (e *Enumeration) checkOrigin(netblock *types.Asset, prefix string, req *requests.RoutingRequest) {
+	e.writes.sync(prefix)
+	rels, err := e.graph.DB.IncomingRelations(netblock, time.Time{}, "announces")
+	if err != nil {
+		return
+	}
+
+	for _, rel := range rels {
+		a, err := e.graph.DB.FindById(rel.FromAsset.ID, time.Time{})
+		if err != nil {
+			continue
+		}
+		// Netblocks of addresses missing from the routing data are stored with AS number zero
+		as, ok := a.Asset.(network.AutonomousSystem)
+		if !ok || as.Number == 0 || as.Number == req.ASN {
+			continue
+		}
+
+		if _, err := e.Sys.Findings().Add(&findings.Finding{
+			Type:        BGPOriginChangeFinding,
+			Asset:       prefix,
+			Severity:    findings.Low,
+			Description: fmt.Sprintf("The prefix is announced by AS%d, but was previously announced by AS%d", req.ASN, as.Number),
+			Source:      req.Source,
+		}); err != nil {
+			e.Config.Log.Printf("Failed to save the BGP origin change finding: %v", err)
+		}
+	}
+}
+
+// publishRoute publishes the assets and relation created for the announced prefix.
+func (e *Enumeration) publishRoute(asn int, desc, prefix, source string) {
+	if e.events == nil {
+		return
+	}
+
+	as := strconv.Itoa(asn)
+	e.publishEntity(oam.ASN, as, source)
+	e.publishEntity(oam.RIROrg, desc, source)
+	e.publishEntity(oam.Netblock, prefix, source)
+	for _, edge := range [][]string{{as, "managed_by", desc}, {as, "announces", prefix}} {
+		e.publish(&events.Event{
+			Kind:   events.EdgeEvent,
+			Type:   edge[1],
+			From:   edge[0],
+			To:     edge[2],
+			Source: source,
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"net/netip"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+func TestNewRoutes(t *testing.T) {
+	store, err := findings.NewStore(filepath.Join(t.TempDir(), "findings.json"))
+	if err != nil {
+		t.Fatalf("Failed to create the findings store: %v", err)
+	}
+
+	g := netmap.NewGraph("memory", "", "")
+
+	e := &Enumeration{
+		Config: config.NewConfig(),
+		Sys:    &systems.SimpleSystem{Store: store},
+		graph:  g,
+	}
+
+	e.newRoutes(&requests.RoutingRequest{
+		ASN:         64500,
+		Description: "EXAMPLE-NET",
+		Prefixes:    []string{"192.0.2.0/24", "2001:db8::/32"},
+		Source:      "RIPEstat",
+	})
+
+	assets, err := g.DB.FindByContent(&network.AutonomousSystem{Number: 64500}, time.Time{})
+	if err != nil || len(assets) == 0 {
+		t.Fatalf("The autonomous system was not stored: %v", err)
+	}
+	rels, err := g.DB.OutgoingRelations(assets[0], time.Time{}, "announces")
+	if err != nil || len(rels) != 2 {
+		t.Fatalf("%d announces relations were stored, expected 2", len(rels))
+	}
+	if all, _ := store.All(); len(all) != 0 {
+		t.Errorf("%d findings were reported without an origin change", len(all))
+	}
+
+	e.newRoutes(&requests.RoutingRequest{
+		ASN:         64501,
+		Description: "OTHER-NET",
+		Prefixes:    []string{"192.0.2.0/24"},
+		Source:      "RIPEstat",
+	})
+
+	all, err := store.All()
+	if err != nil || len(all) != 1 {
+		t.Fatalf("%d findings were reported for the origin change, expected 1", len(all))
+	}
+	if f := all[0]; f.Type != BGPOriginChangeFinding || f.Asset != "192.0.2.0/24" {
+		t.Errorf("The finding was %+v", f)
+	}
+
+	nb, err := g.DB.FindByContent(&network.Netblock{Cidr: netip.MustParsePrefix("192.0.2.0/24"), Type: "IPv4"}, time.Time{})
+	if err != nil || len(nb) == 0 {
+		t.Fatalf("The netblock was not stored: %v", err)
+	}
+	if rels, err := g.DB.IncomingRelations(nb[0], time.Time{}, "announces"); err != nil || len(rels) != 2 {
+		t.Errorf("The netblock has %d announces relations, expected 2", len(rels))
+	}
+}
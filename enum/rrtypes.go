@@ -0,0 +1,141 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/config/config"
+)
+
+// DefaultRecordTypes are the DNS record types queried when the configuration does not provide the 'dns_record_types' option.
+var DefaultRecordTypes = []string{"CNAME", "A", "AAAA", "NS", "MX", "SOA"}
+
+// The DNS record types that can be selected, by where the enumeration queries them.
+var (
+	// zoneQueryTypes are queried for the root domains and the proper subdomains
+	zoneQueryTypes = []uint16{dns.TypeNS, dns.TypeMX, dns.TypeSOA}
+	// nameQueryTypes are queried for each name resolved by the trusted resolvers, unless it is an alias
+	nameQueryTypes = []uint16{dns.TypeTXT, dns.TypeSRV, dns.TypeCAA}
+)
+
+// recordTypes are the DNS record types selected for the enumeration.
+type recordTypes struct {
+	// forward resolve the discovered names, and are queried in the order of FwdQueryTypes
+	forward []uint16
+	zone    []uint16
+	name    []uint16
+}
+
+// DNSRecordTypes returns the DNS record types listed by the 'dns_record_types' option of the configuration.
+// At least one of the CNAME, A and AAAA types must be selected, since they resolve the discovered names.
+func DNSRecordTypes(cfg *config.Config) ([]uint16, error) {
+	names := DefaultRecordTypes
+	if raw, ok := cfg.Options["dns_record_types"]; ok {
+		list, ok := raw.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("dns_record_types is not a list")
+		}
+
+		names = nil
+		for _, v := range list {
+			name, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("dns_record_types must only provide the names of DNS record types")
+			}
+			names = append(names, name)
+		}
+	}
+
+	supported := supportedRecordTypes()
+	seen := make(map[uint16]struct{})
+	var types []uint16
+	for _, name := range names {
+		t, found := dns.StringToType[strings.ToUpper(strings.TrimSpace(name))]
+		if !found || !containsType(supported, t) {
+			return nil, fmt.Errorf("dns_record_types must be selected from %s", strings.Join(recordTypeNames(supported), ", "))
+		}
+		if _, dup := seen[t]; !dup {
+			seen[t] = struct{}{}
+			types = append(types, t)
+		}
+	}
+
+	if len(newRecordTypes(types).forward) == 0 {
+		return nil, fmt.Errorf("dns_record_types must select CNAME, A or AAAA to resolve the discovered names")
+	}
+	return types, nil
+}
+
+// newRecordTypes returns the selected DNS record types, arranged by where the enumeration queries them.
+func newRecordTypes(types []uint16) *recordTypes {
+	rt := new(recordTypes)
+
+	for _, t := range FwdQueryTypes {
+		if containsType(types, t) {
+			rt.forward = append(rt.forward, t)
+		}
+	}
+	for _, t := range zoneQueryTypes {
+		if containsType(types, t) {
+			rt.zone = append(rt.zone, t)
+		}
+	}
+	for _, t := range nameQueryTypes {
+		if containsType(types, t) {
+			rt.name = append(rt.name, t)
+		}
+	}
+	return rt
+}
+
+// next returns the forward type queried after qtype, and false when qtype was the last one.
+func (rt *recordTypes) next(qtype uint16) (uint16, bool) {
+	for i, t := range rt.forward {
+		if t == qtype && i+1 < len(rt.forward) {
+			return rt.forward[i+1], true
+		}
+	}
+	return 0, false
+}
+
+// zoneQueried returns true when the type is queried for the root domains and the proper subdomains.
+// The SPF records are queried along with the TXT records.
+func (rt *recordTypes) zoneQueried(qtype uint16) bool {
+	if qtype == dns.TypeSPF {
+		return containsType(rt.name, dns.TypeTXT)
+	}
+	return containsType(rt.zone, qtype)
+}
+
+func supportedRecordTypes() []uint16 {
+	var types []uint16
+
+	types = append(types, FwdQueryTypes...)
+	types = append(types, zoneQueryTypes...)
+	return append(types, nameQueryTypes...)
+}
+
+func recordTypeNames(types []uint16) []string {
+	names := make([]string, 0, len(types))
+
+	for _, t := range types {
+		names = append(names, dns.TypeToString[t])
+	}
+	sort.Strings(names)
+	return names
+}
+
+func containsType(types []uint16, qtype uint16) bool {
+	for _, t := range types {
+		if t == qtype {
+			return true
+		}
+	}
+	return false
+}
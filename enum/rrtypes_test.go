@@ -0,0 +1,73 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestDNSRecordTypes(t *testing.T) {
+	cfg := config.NewConfig()
+
+	types, err := DNSRecordTypes(cfg)
+	if err != nil {
+		t.Fatalf("The default record types returned an error: %v", err)
+	}
+	rt := newRecordTypes(types)
+	if !reflect.DeepEqual(rt.forward, FwdQueryTypes) || !reflect.DeepEqual(rt.zone, zoneQueryTypes) || len(rt.name) != 0 {
+		t.Errorf("Unexpected default record types: %+v", rt)
+	}
+	if rt.zoneQueried(dns.TypeSPF) {
+		t.Error("The SPF records were queried without the TXT records")
+	}
+
+	cfg.Options["dns_record_types"] = []interface{}{"aaaa", "TXT", "A", "caa", "A"}
+	if types, err = DNSRecordTypes(cfg); err != nil {
+		t.Fatalf("The record types returned an error: %v", err)
+	}
+	rt = newRecordTypes(types)
+	// The forward types are queried in their usual order, whatever the order of the configuration
+	if !reflect.DeepEqual(rt.forward, []uint16{dns.TypeA, dns.TypeAAAA}) {
+		t.Errorf("Unexpected forward types: %v", rt.forward)
+	}
+	if !reflect.DeepEqual(rt.name, []uint16{dns.TypeTXT, dns.TypeCAA}) || len(rt.zone) != 0 || !rt.zoneQueried(dns.TypeSPF) {
+		t.Errorf("Unexpected record types: %+v", rt)
+	}
+	if next, found := rt.next(dns.TypeA); !found || next != dns.TypeAAAA {
+		t.Errorf("Expected AAAA to follow A, got %d", next)
+	}
+	if _, found := rt.next(dns.TypeAAAA); found {
+		t.Error("A type was returned after the last forward type")
+	}
+
+	for _, bad := range []interface{}{
+		[]interface{}{"TXT", "MX"},
+		[]interface{}{"A", "HINFO"},
+		[]interface{}{"A", 1},
+		"A",
+	} {
+		cfg.Options["dns_record_types"] = bad
+		if _, err := DNSRecordTypes(cfg); err == nil {
+			t.Errorf("The record types %v were accepted", bad)
+		}
+	}
+}
+
+func TestExtractRecords(t *testing.T) {
+	caa, _ := dns.NewRR(`owasp.org. 300 IN CAA 0 issue "letsencrypt.org"`)
+	txt, _ := dns.NewRR(`owasp.org. 300 IN TXT "v=spf1 -all"`)
+	resp := &dns.Msg{Answer: []dns.RR{caa, txt}}
+
+	if records := extractRecords(resp, dns.TypeCAA); len(records) != 1 || records[0].Data != `0 issue "letsencrypt.org"` {
+		t.Errorf("Unexpected CAA records: %+v", records)
+	}
+	if records := extractRecords(resp, dns.TypeTXT); len(records) != 1 || records[0].Name != "owasp.org" {
+		t.Errorf("Unexpected TXT records: %+v", records)
+	}
+}
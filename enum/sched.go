@@ -0,0 +1,161 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"sort"
+
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+// Scheduling weights assigned to the request types dispatched to the data sources.
+const (
+	lowPriority    int = 1
+	normalPriority int = 4
+	highPriority   int = 8
+)
+
+// RequestPriority returns the scheduling weight for the provided data source request.
+func RequestPriority(req interface{}) int {
+	switch req.(type) {
+	case *requests.DNSRequest, *requests.ASNRequest, *requests.WhoisRequest:
+		return highPriority
+	case *requests.SubdomainRequest, *requests.AddrRequest, *requests.RegistrantRequest, *requests.OrganizationRequest:
+		return normalPriority
+	}
+	return lowPriority
+}
+
+// prioritized is implemented by the data sources whose scheduling weights can be overridden by the configuration.
+type prioritized interface {
+	Priority(req interface{}) (int, bool)
+}
+
+// sourcePriority returns the scheduling weights of the requests queued for the data source.
+func sourcePriority(src interface{}) func(interface{}) int {
+	p, ok := src.(prioritized)
+	if !ok {
+		return RequestPriority
+	}
+
+	return func(req interface{}) int {
+		if weight, found := p.Priority(req); found {
+			return weight
+		}
+		return RequestPriority(req)
+	}
+}
+
+type fairClass struct {
+	weight  int
+	current int
+	items   []interface{}
+}
+
+// fairQueue is a smooth weighted round-robin queue that keeps the large volume of
+// low priority requests from starving the requests that drive the enumeration.
+// When the limit is reached, the requests with the lowest priority are shed.
+type fairQueue struct {
+	classes  []*fairClass
+	length   int
+	limit    int
+	priority func(interface{}) int
+}
+
+// newFairQueue returns a fairQueue holding at most limit requests, or an unbounded queue for a zero limit.
+// The priority provides the scheduling weights of the requests, and defaults to RequestPriority.
+func newFairQueue(limit int, priority func(interface{}) int) *fairQueue {
+	if priority == nil {
+		priority = RequestPriority
+	}
+	return &fairQueue{limit: limit, priority: priority}
+}
+
+// Len returns the number of requests waiting in the queue.
+func (fq *fairQueue) Len() int {
+	return fq.length
+}
+
+// Append adds the request to the class matching its scheduling weight. When the queue is full,
+// the most recent request with the lowest priority is shed and returned, which can be the request
+// provided when no queued request has a lower priority.
+func (fq *fairQueue) Append(req interface{}) interface{} {
+	weight := fq.priority(req)
+
+	var shed interface{}
+	if fq.limit > 0 && fq.length >= fq.limit {
+		lowest := fq.lowest()
+		if lowest == nil || lowest.weight >= weight {
+			return req
+		}
+
+		last := len(lowest.items) - 1
+		shed = lowest.items[last]
+		lowest.items[last] = nil
+		lowest.items = lowest.items[:last]
+		fq.length--
+	}
+
+	var class *fairClass
+	for _, c := range fq.classes {
+		if c.weight == weight {
+			class = c
+			break
+		}
+	}
+	if class == nil {
+		class = &fairClass{weight: weight}
+		fq.classes = append(fq.classes, class)
+		sort.Slice(fq.classes, func(i, j int) bool {
+			return fq.classes[i].weight > fq.classes[j].weight
+		})
+	}
+
+	class.items = append(class.items, req)
+	fq.length++
+	return shed
+}
+
+// lowest returns the class with the lowest scheduling weight that holds requests.
+func (fq *fairQueue) lowest() *fairClass {
+	for i := len(fq.classes) - 1; i >= 0; i-- {
+		if len(fq.classes[i].items) > 0 {
+			return fq.classes[i]
+		}
+	}
+	return nil
+}
+
+// Next returns the request selected by the weighted round-robin.
+func (fq *fairQueue) Next() (interface{}, bool) {
+	var total int
+	var best *fairClass
+
+	for _, c := range fq.classes {
+		if len(c.items) == 0 {
+			continue
+		}
+
+		c.current += c.weight
+		total += c.weight
+		if best == nil || c.current > best.current {
+			best = c
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+
+	best.current -= total
+	req := best.items[0]
+	best.items[0] = nil
+	best.items = best.items[1:]
+	if len(best.items) == 0 {
+		best.current = 0
+	}
+
+	fq.length--
+	return req, true
+}
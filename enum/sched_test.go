@@ -0,0 +1,119 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+func TestFairQueueWeights(t *testing.T) {
+	fq := newFairQueue(0, nil)
+
+	for i := 0; i < 100; i++ {
+		fq.Append(&requests.ResolvedRequest{Name: "www.example.com"})
+	}
+	for i := 0; i < 10; i++ {
+		fq.Append(&requests.DNSRequest{Name: "example.com"})
+	}
+	if fq.Len() != 110 {
+		t.Errorf("Expected a queue length of 110, got %d", fq.Len())
+	}
+
+	var high int
+	for i := 0; i < 9; i++ {
+		req, ok := fq.Next()
+		if !ok {
+			t.Fatal("The queue returned no request while not empty")
+		}
+		if _, ok := req.(*requests.DNSRequest); ok {
+			high++
+		}
+	}
+	if high != 8 {
+		t.Errorf("Expected 8 of the first 9 requests to be high priority, got %d", high)
+	}
+
+	var count int
+	for {
+		if _, ok := fq.Next(); !ok {
+			break
+		}
+		count++
+	}
+	if count != 101 || fq.Len() != 0 {
+		t.Errorf("Expected the remaining 101 requests to be drained, got %d", count)
+	}
+}
+
+func TestFairQueueShedding(t *testing.T) {
+	fq := newFairQueue(3, nil)
+
+	fq.Append(&requests.ResolvedRequest{Name: "a.example.com"})
+	fq.Append(&requests.ResolvedRequest{Name: "b.example.com"})
+	fq.Append(&requests.AddrRequest{Address: "192.0.2.1"})
+
+	shed := fq.Append(&requests.DNSRequest{Name: "example.com"})
+	if r, ok := shed.(*requests.ResolvedRequest); !ok || r.Name != "b.example.com" {
+		t.Errorf("Expected the most recent low priority request to be shed, got %v", shed)
+	}
+	if shed := fq.Append(&requests.ResolvedRequest{Name: "c.example.com"}); shed == nil {
+		t.Error("The low priority request was queued while the queue was full")
+	}
+	if fq.Len() != 3 {
+		t.Errorf("Expected a queue length of 3, got %d", fq.Len())
+	}
+
+	fq.Append(&requests.DNSRequest{Name: "owasp.org"})
+	fq.Append(&requests.DNSRequest{Name: "owasp.net"})
+	shed = fq.Append(&requests.DNSRequest{Name: "example.net"})
+	if r, ok := shed.(*requests.DNSRequest); !ok || r.Name != "example.net" {
+		t.Errorf("Expected the new request to be shed when no queued request has a lower priority, got %v", shed)
+	}
+
+	var high int
+	for {
+		req, ok := fq.Next()
+		if !ok {
+			break
+		}
+		if _, ok := req.(*requests.DNSRequest); ok {
+			high++
+		}
+	}
+	if high != 3 {
+		t.Errorf("Expected the 3 high priority requests to be kept, got %d", high)
+	}
+}
+
+type overriddenSource struct{}
+
+func (s *overriddenSource) Priority(req interface{}) (int, bool) {
+	if _, ok := req.(*requests.ResolvedRequest); ok {
+		return 16, true
+	}
+	return 0, false
+}
+
+func TestSourcePriority(t *testing.T) {
+	priority := sourcePriority(&overriddenSource{})
+
+	if w := priority(&requests.ResolvedRequest{}); w != 16 {
+		t.Errorf("Expected the overridden weight of 16, got %d", w)
+	}
+	if w := priority(&requests.DNSRequest{}); w != highPriority {
+		t.Errorf("Expected the default weight of %d, got %d", highPriority, w)
+	}
+
+	fq := newFairQueue(0, priority)
+	fq.Append(&requests.DNSRequest{Name: "example.com"})
+	fq.Append(&requests.ResolvedRequest{Name: "www.example.com"})
+	if req, _ := fq.Next(); req == nil {
+		t.Fatal("The queue returned no request while not empty")
+	} else if _, ok := req.(*requests.ResolvedRequest); !ok {
+		t.Error("The request with the overridden weight was not selected first")
+	}
+}
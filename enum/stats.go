@@ -0,0 +1,257 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caffix/queue"
+	"github.com/owasp-amass/amass/v4/datasrcs/scripting"
+	"github.com/owasp-amass/amass/v4/quota"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+// Stats is a snapshot of the activity within a running enumeration.
+type Stats struct {
+	Paused bool `json:"paused"`
+	// The number of names waiting to enter the pipeline
+	InputQueue int `json:"input_queue"`
+	// The number of addresses waiting for infrastructure information
+	InfraQueue int `json:"infra_queue"`
+	// The number of events outstanding in each handler, which must drain for the enumeration to complete
+	Outstanding map[string]int `json:"outstanding,omitempty"`
+	Sources     []*SourceStats `json:"sources"`
+}
+
+// SourceStats provides the activity of a data source during the enumeration.
+type SourceStats struct {
+	Name     string `json:"name"`
+	Requests int    `json:"requests"`
+	Queued   int    `json:"queued"`
+	Names    int    `json:"names"`
+	// The number of requests dropped, since they were already sent to the data source
+	Duplicates int `json:"duplicates"`
+	// The number of requests dropped, since the queue of the data source was full
+	Shed int `json:"shed"`
+	// The number of requests dropped, since the API key was rejected or the quota ran out
+	QuotaDropped int `json:"quota_dropped"`
+	// The health of the API key and the quota remaining, when reported by the data source
+	Quota *quota.Status `json:"quota,omitempty"`
+	// The executions of the script callbacks, when the data source is a script
+	Handlers []*scripting.HandlerStats `json:"handlers,omitempty"`
+	// The failures of the data source by category, when the data source classifies them
+	Errors []*requests.ErrorCount `json:"errors,omitempty"`
+}
+
+// timedHandlers is implemented by the data sources recording the executions of their callbacks.
+type timedHandlers interface {
+	HandlerStats() []*scripting.HandlerStats
+}
+
+// classifiedErrors is implemented by the data sources counting their failures by category.
+type classifiedErrors interface {
+	ErrorCounts() []*requests.ErrorCount
+}
+
+type sourceStats struct {
+	sync.Mutex
+	srcs  map[string]*SourceStats
+	input queue.Queue
+	infra queue.Queue
+}
+
+func newSourceStats() *sourceStats {
+	return &sourceStats{srcs: make(map[string]*SourceStats)}
+}
+
+func (s *sourceStats) get(name string) *SourceStats {
+	ss, found := s.srcs[name]
+	if !found {
+		ss = &SourceStats{Name: name}
+		s.srcs[name] = ss
+	}
+	return ss
+}
+
+// watch provides the queues reported along with the data source activity.
+func (s *sourceStats) watch(input, infra queue.Queue) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.input = input
+	s.infra = infra
+}
+
+func (s *sourceStats) request(name string) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.get(name).Requests++
+}
+
+func (s *sourceStats) queued(name string, n int) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.get(name).Queued = n
+}
+
+func (s *sourceStats) duplicate(name string) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.get(name).Duplicates++
+}
+
+// shed counts a request dropped from the queue of the data source and returns the total.
+func (s *sourceStats) shed(name string) int {
+	s.Lock()
+	defer s.Unlock()
+
+	ss := s.get(name)
+	ss.Shed++
+	return ss.Shed
+}
+
+// quotaDropped counts a request dropped due to the API key or quota of the data source and returns the total.
+func (s *sourceStats) quotaDropped(name string) int {
+	s.Lock()
+	defer s.Unlock()
+
+	ss := s.get(name)
+	ss.QuotaDropped++
+	return ss.QuotaDropped
+}
+
+func (s *sourceStats) name(source string) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.get(source).Names++
+}
+
+// snapshot returns copies of the data source activity, ordered by the names provided.
+func (s *sourceStats) snapshot() []*SourceStats {
+	s.Lock()
+	defer s.Unlock()
+
+	results := make([]*SourceStats, 0, len(s.srcs))
+	for _, ss := range s.srcs {
+		c := *ss
+		results = append(results, &c)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Names != results[j].Names {
+			return results[i].Names > results[j].Names
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results
+}
+
+// Stats returns a snapshot of the activity within the enumeration.
+func (e *Enumeration) Stats() *Stats {
+	s := &Stats{
+		Paused:      e.IsPaused(),
+		Outstanding: e.Outstanding(),
+		Sources:     e.srcStats.snapshot(),
+	}
+	if e.Sys != nil {
+		handlers := e.handlerStats()
+		failures := e.errorCounts()
+		for _, ss := range s.Sources {
+			ss.Quota = e.Sys.Quotas().Status(ss.Name)
+			ss.Handlers = handlers[ss.Name]
+			ss.Errors = failures[ss.Name]
+		}
+	}
+
+	e.srcStats.Lock()
+	defer e.srcStats.Unlock()
+
+	if e.srcStats.input != nil {
+		s.InputQueue = e.srcStats.input.Len()
+	}
+	if e.srcStats.infra != nil {
+		s.InfraQueue = e.srcStats.infra.Len()
+	}
+	return s
+}
+
+func (e *Enumeration) handlerStats() map[string][]*scripting.HandlerStats {
+	results := make(map[string][]*scripting.HandlerStats)
+
+	for _, src := range e.Sys.DataSources() {
+		if th, ok := src.(timedHandlers); ok {
+			results[src.String()] = th.HandlerStats()
+		}
+	}
+	return results
+}
+
+func (e *Enumeration) reportHandlers() {
+	handlers := e.handlerStats()
+
+	names := make([]string, 0, len(handlers))
+	for name := range handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, hs := range handlers[name] {
+			if hs.TimedOut > 0 || hs.Slow > 0 {
+				e.Config.Log.Printf("Handlers: the %s %s callback was slow %d times and timed out %d times in %d executions, taking up to %s",
+					name, hs.Callback, hs.Slow, hs.TimedOut, hs.Calls, hs.Max.Round(time.Second))
+			}
+		}
+	}
+}
+
+func (e *Enumeration) errorCounts() map[string][]*requests.ErrorCount {
+	results := make(map[string][]*requests.ErrorCount)
+
+	for _, src := range e.Sys.DataSources() {
+		if ce, ok := src.(classifiedErrors); ok {
+			if counts := ce.ErrorCounts(); len(counts) > 0 {
+				results[src.String()] = counts
+			}
+		}
+	}
+	return results
+}
+
+// SourceErrors returns the breakdown of the failures of each data source by category, sorted by
+// the data source names, so a rejected API key can be told apart from an unreachable service.
+func (e *Enumeration) SourceErrors() []*requests.SourceErrors {
+	if e.Sys == nil {
+		return nil
+	}
+
+	var results []*requests.SourceErrors
+	for name, counts := range e.errorCounts() {
+		results = append(results, &requests.SourceErrors{Source: name, Errors: counts})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Source < results[j].Source
+	})
+	return results
+}
+
+func (e *Enumeration) reportErrors() {
+	for _, se := range e.SourceErrors() {
+		parts := make([]string, 0, len(se.Errors))
+		for _, ec := range se.Errors {
+			parts = append(parts, fmt.Sprintf("%d %s (last: %s)", ec.Count, ec.Category, ec.Last))
+		}
+		e.Config.Log.Printf("Errors: %s failed %d times: %s", se.Source, se.Total(), strings.Join(parts, ", "))
+	}
+}
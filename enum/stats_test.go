@@ -0,0 +1,57 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"testing"
+
+	"github.com/caffix/queue"
+)
+
+func TestSourceStats(t *testing.T) {
+	s := newSourceStats()
+
+	s.queued("Crtsh", 0)
+	s.request("DNS")
+	s.request("DNS")
+	s.queued("DNS", 3)
+	s.name("DNS")
+	s.name("HackerTarget")
+	s.name("HackerTarget")
+
+	snap := s.snapshot()
+	if len(snap) != 3 {
+		t.Fatalf("Expected 3 data sources, got %d", len(snap))
+	}
+	if snap[0].Name != "HackerTarget" || snap[1].Name != "DNS" || snap[2].Name != "Crtsh" {
+		t.Errorf("The data sources were not ordered by the names provided: %s, %s, %s", snap[0].Name, snap[1].Name, snap[2].Name)
+	}
+	if d := snap[1]; d.Requests != 2 || d.Queued != 3 || d.Names != 1 {
+		t.Errorf("Unexpected activity for the DNS data source: %+v", d)
+	}
+
+	snap[1].Names = 100
+	if s.snapshot()[1].Names != 1 {
+		t.Error("The snapshot shares the activity counters with the enumeration")
+	}
+}
+
+func TestEnumerationStats(t *testing.T) {
+	e := &Enumeration{srcStats: newSourceStats()}
+
+	if st := e.Stats(); st.InputQueue != 0 || st.InfraQueue != 0 || len(st.Sources) != 0 {
+		t.Errorf("Unexpected stats before the enumeration started: %+v", st)
+	}
+
+	input, infra := queue.NewQueue(), queue.NewQueue()
+	input.Append("www.owasp.org")
+	input.Append("mail.owasp.org")
+	infra.Append("192.0.2.1")
+	e.srcStats.watch(input, infra)
+
+	if st := e.Stats(); st.InputQueue != 2 || st.InfraQueue != 1 {
+		t.Errorf("Expected queue depths of 2 and 1, got %d and %d", st.InputQueue, st.InfraQueue)
+	}
+}
@@ -16,14 +16,24 @@ import (
 	"github.com/caffix/pipeline"
 	"github.com/caffix/queue"
 	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/findings"
 	amassnet "github.com/owasp-amass/amass/v4/net"
 	amassdns "github.com/owasp-amass/amass/v4/net/dns"
 	"github.com/owasp-amass/amass/v4/requests"
+	oam "github.com/owasp-amass/open-asset-model"
 	"github.com/owasp-amass/resolve"
 	bf "github.com/tylertreat/BoomFilters"
 	"golang.org/x/net/publicsuffix"
 )
 
+// The findings recording the DNS records that are not held by the graph database.
+const (
+	// TXTRecordFinding provides the TXT records of a name
+	TXTRecordFinding = "dns_txt"
+	// CAAPolicyFinding provides the certificate authorities authorized by the CAA records of a name
+	CAAPolicyFinding = "caa_policy"
+)
+
 // dataManager is the stage that stores all data processed by the pipeline.
 type dataManager struct {
 	enum        *Enumeration
@@ -93,6 +103,10 @@ func (dm *dataManager) dnsRequest(ctx context.Context, req *requests.DNSRequest,
 	if dm.enum.Config.Blacklisted(req.Name) {
 		return nil
 	}
+	if len(req.Records) > 0 {
+		dm.enum.honey.resolve(req.Name)
+		dm.enum.observe(req.Name, req.Source, true)
+	}
 	// Check for CNAME records first
 	for i, r := range req.Records {
 		req.Records[i].Name = strings.Trim(strings.ToLower(r.Name), ".")
@@ -137,6 +151,7 @@ func (dm *dataManager) dnsRequest(ctx context.Context, req *requests.DNSRequest,
 			err = e
 		}
 	}
+	dm.harvestRecords(req)
 	return err
 }
 
@@ -155,9 +170,11 @@ func (dm *dataManager) insertCNAME(ctx context.Context, req *requests.DNSRequest
 		Name:   target,
 		Domain: strings.ToLower(domain),
 	})
-	if err := dm.enum.graph.UpsertCNAME(ctx, req.Name, target); err != nil {
+	if err := dm.upsert("cname_record", req.Name, target, dm.enum.graph.UpsertCNAME); err != nil {
 		return fmt.Errorf("failed to insert CNAME: %v", err)
 	}
+	dm.enum.publishRecord(req.Name, "cname_record", oam.FQDN, target, req.Source)
+	dm.enum.dangling.check(dm.enum.ctx, req.Name, target)
 	return nil
 }
 
@@ -172,9 +189,10 @@ func (dm *dataManager) insertA(ctx context.Context, req *requests.DNSRequest, re
 		InScope: true,
 		Domain:  req.Domain,
 	})
-	if err := dm.enum.graph.UpsertA(ctx, req.Name, addr); err != nil {
+	if err := dm.upsert("a_record", req.Name, addr, dm.enum.graph.UpsertA); err != nil {
 		return fmt.Errorf("failed to insert A record: %v", err)
 	}
+	dm.enum.publishRecord(req.Name, "a_record", oam.IPAddress, addr, req.Source)
 	return nil
 }
 
@@ -189,9 +207,10 @@ func (dm *dataManager) insertAAAA(ctx context.Context, req *requests.DNSRequest,
 		InScope: true,
 		Domain:  req.Domain,
 	})
-	if err := dm.enum.graph.UpsertAAAA(ctx, req.Name, addr); err != nil {
+	if err := dm.upsert("aaaa_record", req.Name, addr, dm.enum.graph.UpsertAAAA); err != nil {
 		return fmt.Errorf("failed to insert AAAA record: %v", err)
 	}
+	dm.enum.publishRecord(req.Name, "aaaa_record", oam.IPAddress, addr, req.Source)
 	return nil
 }
 
@@ -210,9 +229,10 @@ func (dm *dataManager) insertPTR(ctx context.Context, req *requests.DNSRequest,
 		Name:   target,
 		Domain: domain,
 	})
-	if err := dm.enum.graph.UpsertPTR(ctx, req.Name, target); err != nil {
+	if err := dm.upsert("ptr_record", req.Name, target, dm.enum.graph.UpsertPTR); err != nil {
 		return fmt.Errorf("failed to insert PTR record: %v", err)
 	}
+	dm.enum.publishRecord(req.Name, "ptr_record", oam.FQDN, target, req.Source)
 	return nil
 }
 
@@ -228,9 +248,10 @@ func (dm *dataManager) insertSRV(ctx context.Context, req *requests.DNSRequest,
 			Domain: domain,
 		})
 	}
-	if err := dm.enum.graph.UpsertSRV(ctx, service, target); err != nil {
+	if err := dm.upsert("srv_record", service, target, dm.enum.graph.UpsertSRV); err != nil {
 		return fmt.Errorf("failed to insert SRV record: %v", err)
 	}
+	dm.enum.publishRecord(service, "srv_record", oam.FQDN, target, req.Source)
 	return nil
 }
 
@@ -250,9 +271,10 @@ func (dm *dataManager) insertNS(ctx context.Context, req *requests.DNSRequest, r
 			Domain: d,
 		})
 	}
-	if err := dm.enum.graph.UpsertNS(ctx, req.Name, target); err != nil {
+	if err := dm.upsert("ns_record", req.Name, target, dm.enum.graph.UpsertNS); err != nil {
 		return fmt.Errorf("failed to insert NS record: %v", err)
 	}
+	dm.enum.publishRecord(req.Name, "ns_record", oam.FQDN, target, req.Source)
 	return nil
 }
 
@@ -272,9 +294,10 @@ func (dm *dataManager) insertMX(ctx context.Context, req *requests.DNSRequest, r
 			Domain: d,
 		})
 	}
-	if err := dm.enum.graph.UpsertMX(ctx, req.Name, target); err != nil {
+	if err := dm.upsert("mx_record", req.Name, target, dm.enum.graph.UpsertMX); err != nil {
 		return fmt.Errorf("failed to insert MX record: %v", err)
 	}
+	dm.enum.publishRecord(req.Name, "mx_record", oam.FQDN, target, req.Source)
 	return nil
 }
 
@@ -299,6 +322,62 @@ func (dm *dataManager) insertSPF(ctx context.Context, req *requests.DNSRequest,
 	return nil
 }
 
+// harvestRecords records the TXT and CAA records of the in-scope name as findings, since the
+// graph database does not hold them, and they reveal the services and certificate authorities in use.
+func (dm *dataManager) harvestRecords(req *requests.DNSRequest) {
+	if !dm.enum.Config.IsDomainInScope(req.Name) {
+		return
+	}
+
+	var txt []string
+	caa := make(map[string][]string)
+	for _, r := range req.Records {
+		switch uint16(r.Type) {
+		case dns.TypeTXT:
+			txt = append(txt, r.Data)
+		case dns.TypeCAA:
+			// The data provides the flag, the tag and the quoted value
+			if parts := strings.SplitN(r.Data, " ", 3); len(parts) == 3 {
+				caa[parts[1]] = append(caa[parts[1]], strings.Trim(parts[2], `"`))
+			}
+		}
+	}
+
+	if len(txt) > 0 {
+		dm.addRecordFinding(&findings.Finding{
+			Type:        TXTRecordFinding,
+			Asset:       req.Name,
+			Description: fmt.Sprintf("The name publishes %d TXT records", len(txt)),
+			Details:     map[string]string{"records": strings.Join(txt, "\n")},
+		})
+	}
+	if len(caa) > 0 {
+		details := make(map[string]string, len(caa))
+		for tag, values := range caa {
+			details[tag] = strings.Join(values, ", ")
+		}
+
+		desc := "The CAA records of the name do not authorize any certificate authority"
+		if details["issue"] != "" {
+			desc = "The CAA records of the name authorize " + details["issue"] + " to issue certificates"
+		}
+		dm.addRecordFinding(&findings.Finding{
+			Type:        CAAPolicyFinding,
+			Asset:       req.Name,
+			Description: desc,
+			Details:     details,
+		})
+	}
+}
+
+func (dm *dataManager) addRecordFinding(f *findings.Finding) {
+	f.Severity = findings.Info
+	f.Source = dnsEventSource
+	if _, err := dm.enum.Sys.Findings().Add(f); err != nil {
+		dm.enum.Config.Log.Printf("Failed to save the %s finding: %v", f.Type, err)
+	}
+}
+
 func (dm *dataManager) findNamesAndAddresses(ctx context.Context, data, domain string, tp pipeline.TaskParams) {
 	ipre := regexp.MustCompile(amassnet.IPv4RE)
 	for _, ip := range ipre.FindAllString(data, -1) {
@@ -329,16 +408,19 @@ func (dm *dataManager) addrRequest(ctx context.Context, req *requests.AddrReques
 	if req == nil || !req.InScope {
 		return nil
 	}
+	dm.enum.ports.scan(req.Address)
+	dm.enum.ipv6.expand(ctx, req.Address)
+	dm.enum.geo.locate(req.Address)
 	if yes, prefix := amassnet.IsReservedAddress(req.Address); yes {
 		var err error
-		if e := dm.enum.graph.UpsertInfrastructure(ctx, 0, amassnet.ReservedCIDRDescription, req.Address, prefix); e != nil {
+		if e := dm.upsertInfrastructure(ctx, 0, amassnet.ReservedCIDRDescription, req.Address, prefix); e != nil {
 			err = e
 		}
 		return err
 	}
 	if r := dm.enum.Sys.Cache().AddrSearch(req.Address); r != nil {
 		var err error
-		if e := dm.enum.graph.UpsertInfrastructure(ctx, r.ASN, r.Description, req.Address, r.Prefix); e != nil {
+		if e := dm.upsertInfrastructure(ctx, r.ASN, r.Description, req.Address, r.Prefix); e != nil {
 			err = e
 		}
 		return err
@@ -373,7 +455,7 @@ func (dm *dataManager) nextInfraInfo() {
 	ctx := context.Background()
 	req := e.(*requests.AddrRequest)
 	if r := dm.enum.Sys.Cache().AddrSearch(req.Address); r != nil {
-		_ = dm.enum.graph.UpsertInfrastructure(ctx, r.ASN, r.Description, req.Address, r.Prefix)
+		_ = dm.upsertInfrastructure(ctx, r.ASN, r.Description, req.Address, r.Prefix)
 		return
 	}
 
@@ -388,7 +470,7 @@ loop:
 
 		time.Sleep(2 * time.Second)
 		if r := dm.enum.Sys.Cache().AddrSearch(req.Address); r != nil {
-			_ = dm.enum.graph.UpsertInfrastructure(ctx, r.ASN, r.Description, req.Address, r.Prefix)
+			_ = dm.upsertInfrastructure(ctx, r.ASN, r.Description, req.Address, r.Prefix)
 			return
 		}
 	}
@@ -396,7 +478,7 @@ loop:
 	asn := 0
 	desc := "Unknown"
 	prefix := fakePrefix(req.Address)
-	_ = dm.enum.graph.UpsertInfrastructure(ctx, asn, desc, req.Address, prefix)
+	_ = dm.upsertInfrastructure(ctx, asn, desc, req.Address, prefix)
 
 	first, cidr, _ := net.ParseCIDR(prefix)
 	dm.enum.Sys.Cache().Update(&requests.ASNRequest{
@@ -407,6 +489,24 @@ loop:
 	})
 }
 
+// upsert stores the DNS record in the graph database through the write-behind layer.
+func (dm *dataManager) upsert(rrtype, name, target string, fn func(context.Context, string, string) error) error {
+	return dm.enum.writes.add(rrtype, func(ctx context.Context) error {
+		return fn(ctx, name, target)
+	}, name, target)
+}
+
+func (dm *dataManager) upsertInfrastructure(ctx context.Context, asn int, desc, addr, prefix string) error {
+	if err := dm.enum.writes.add("infrastructure", func(ctx context.Context) error {
+		return dm.enum.graph.UpsertInfrastructure(ctx, asn, desc, addr, prefix)
+	}, addr, prefix); err != nil {
+		return err
+	}
+
+	dm.enum.publishInfrastructure(asn, desc, addr, prefix)
+	return nil
+}
+
 func fakePrefix(addr string) string {
 	bits := 24
 	total := 32
@@ -0,0 +1,148 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/report"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+	"golang.org/x/net/publicsuffix"
+)
+
+// BrandVariantFinding is the finding type used to tag the registered domains sharing the
+// label of a target domain under another TLD.
+const BrandVariantFinding = "brand_tld_variant"
+
+// TLDExpansion returns the TLDs checked for registrations of the target domain labels, as set by
+// the 'tld_expansion' option in the 'scope' section of the configuration options.
+func TLDExpansion(cfg *config.Config) ([]string, error) {
+	scopeRaw, ok := cfg.Options["scope"]
+	if !ok {
+		return nil, nil
+	}
+
+	settings, ok := scopeRaw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("scope is not a map[string]interface{}")
+	}
+
+	raw, ok := settings["tld_expansion"]
+	if !ok {
+		return nil, nil
+	}
+
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("scope tld_expansion is not a list")
+	}
+
+	var tlds []string
+	for _, v := range list {
+		tld, ok := v.(string)
+		tld = strings.ToLower(strings.Trim(strings.TrimSpace(tld), "."))
+		if !ok || tld == "" {
+			return nil, fmt.Errorf("scope tld_expansion must contain non-empty strings")
+		}
+		tlds = append(tlds, tld)
+	}
+	return tlds, nil
+}
+
+// brandVariants returns the domains sharing the second-level label of each target domain under the
+// TLDs, mapped to the target domain they were derived from.
+func brandVariants(domains, tlds []string) map[string]string {
+	variants := make(map[string]string)
+
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimSuffix(d, "."))
+		registered, err := publicsuffix.EffectiveTLDPlusOne(d)
+		if err != nil {
+			continue
+		}
+
+		suffix, _ := publicsuffix.PublicSuffix(registered)
+		label := strings.TrimSuffix(registered, "."+suffix)
+		for _, tld := range tlds {
+			if v := label + "." + tld; v != registered {
+				if _, found := variants[v]; !found {
+					variants[v] = registered
+				}
+			}
+		}
+	}
+	return variants
+}
+
+// reportBrandVariants checks the registration of the target domain labels across the configured
+// TLDs, and reports the registered variants along with the organization of the target domain for
+// review. The variants are not added to the scope, since they may be owned by other parties.
+func (e *Enumeration) reportBrandVariants() {
+	tlds, err := TLDExpansion(e.Config)
+	if err != nil || len(tlds) == 0 || e.Config.Passive {
+		return
+	}
+
+	owners := make(map[string]string)
+	if orgs, err := report.OrganizationsFromConfig(e.Config); err == nil {
+		for _, org := range orgs {
+			for _, d := range org.Domains {
+				owners[d] = org.Name
+			}
+		}
+	}
+
+	variants := brandVariants(e.Config.Domains(), tlds)
+	names := make([]string, 0, len(variants))
+	for v := range variants {
+		if !e.Config.IsDomainInScope(v) && !e.Config.Blacklisted(v) {
+			names = append(names, v)
+		}
+	}
+	sort.Strings(names)
+
+	var registered int
+	store := e.Sys.Findings()
+	for _, name := range names {
+		// The registered domains are delegated to name servers, while the others do not exist
+		resp, err := e.dnsQuery(e.ctx, name, dns.TypeNS, e.Sys.TrustedResolvers(), maxDNSQueryAttempts)
+		if err != nil || resp == nil {
+			continue
+		}
+
+		var servers []string
+		for _, rr := range resolve.AnswersByType(resolve.ExtractAnswers(resp), dns.TypeNS) {
+			servers = append(servers, strings.ToLower(strings.TrimSuffix(rr.Data, ".")))
+		}
+		sort.Strings(servers)
+
+		target := variants[name]
+		details := map[string]string{
+			"domain":       target,
+			"name_servers": strings.Join(servers, ","),
+		}
+		if org, found := owners[target]; found {
+			details["organization"] = org
+		}
+
+		registered++
+		if _, err := store.Add(&findings.Finding{
+			Type:        BrandVariantFinding,
+			Asset:       name,
+			Severity:    findings.Info,
+			Description: "The domain shares the label of " + target + " and is registered, but has not been added to the scope",
+			Source:      "Amass",
+			Details:     details,
+		}); err != nil {
+			e.Config.Log.Printf("Failed to save the brand TLD variant finding: %v", err)
+		}
+	}
+	e.Config.Log.Printf("TLD expansion: %d of the %d variants of the target domains are registered", registered, len(names))
+}
@@ -0,0 +1,189 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+	amassdns "github.com/owasp-amass/amass/v4/net/dns"
+	"github.com/owasp-amass/resolve"
+)
+
+const (
+	// Limits the rejected answers that are attributed to individual untrusted resolvers
+	maxAttributedMismatches int = 100
+	// The number of untrusted resolvers queried directly for each attributed mismatch
+	resolversPerMismatch  int = 25
+	maxAttributionQueries int = 10
+)
+
+// ResolverStats reports how often an untrusted resolver provided an answer the trusted resolvers rejected.
+type ResolverStats struct {
+	Address    string `json:"address"`
+	Queries    int    `json:"queries"`
+	Mismatches int    `json:"mismatches"`
+}
+
+// ValidationStats summarizes the cross-validation of untrusted answers by the trusted resolvers.
+type ValidationStats struct {
+	Confirmed int              `json:"confirmed"`
+	Rejected  int              `json:"rejected"`
+	Resolvers []*ResolverStats `json:"resolvers"`
+}
+
+// crossValidator compares the positive answers provided by the untrusted resolvers
+// with the answers of the trusted resolvers, and attributes the disagreements.
+type crossValidator struct {
+	sync.Mutex
+	enum       *Enumeration
+	servers    []string
+	next       int
+	pending    map[string]uint16
+	confirmed  int
+	rejected   int
+	attributed int
+	stats      map[string]*ResolverStats
+	sem        chan struct{}
+	wg         sync.WaitGroup
+}
+
+func newCrossValidator(e *Enumeration, servers []string) *crossValidator {
+	return &crossValidator{
+		enum:    e,
+		servers: servers,
+		pending: make(map[string]uint16),
+		stats:   make(map[string]*ResolverStats),
+		sem:     make(chan struct{}, maxAttributionQueries),
+	}
+}
+
+// expect records that the untrusted resolvers provided a positive answer of the qtype for the name.
+func (cv *crossValidator) expect(name string, qtype uint16) {
+	cv.Lock()
+	defer cv.Unlock()
+
+	name = strings.ToLower(name)
+	if _, found := cv.pending[name]; !found {
+		cv.pending[name] = qtype
+	}
+}
+
+// settle compares the conclusion of the trusted resolvers with the untrusted answer for the name.
+// Names that were neither confirmed nor rejected, such as wildcard matches, are not counted.
+func (cv *crossValidator) settle(ctx context.Context, name string, confirmed, rejected bool) {
+	cv.Lock()
+	defer cv.Unlock()
+
+	name = strings.ToLower(name)
+	qtype, found := cv.pending[name]
+	if !found {
+		return
+	}
+	delete(cv.pending, name)
+
+	if confirmed {
+		cv.confirmed++
+		return
+	} else if !rejected {
+		return
+	}
+
+	cv.rejected++
+	if cv.attributed >= maxAttributedMismatches || len(cv.servers) == 0 {
+		return
+	}
+	cv.attributed++
+
+	// Rotate through the untrusted resolvers so large pools are eventually covered
+	var servers []string
+	for i := 0; i < resolversPerMismatch && i < len(cv.servers); i++ {
+		servers = append(servers, cv.servers[cv.next])
+		cv.next = (cv.next + 1) % len(cv.servers)
+	}
+
+	cv.wg.Add(1)
+	go cv.attribute(ctx, name, qtype, servers)
+}
+
+// attribute queries each of the untrusted resolvers directly to learn which provided the rejected answer.
+func (cv *crossValidator) attribute(ctx context.Context, name string, qtype uint16, servers []string) {
+	defer cv.wg.Done()
+
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		select {
+		case <-ctx.Done():
+			return
+		case cv.sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(server string) {
+			defer wg.Done()
+			defer func() { <-cv.sem }()
+
+			if !cv.enum.Sys.Budget().SpendDNS(budgetSource) {
+				return
+			}
+
+			resp, err := amassdns.ExchangeMsg(ctx, resolve.QueryMsg(name, qtype), server)
+			if err != nil {
+				return
+			}
+
+			lied := resp.Rcode == dns.RcodeSuccess && len(resolve.AnswersByType(resolve.ExtractAnswers(resp), qtype)) > 0
+			cv.record(server, lied)
+		}(server)
+	}
+	wg.Wait()
+}
+
+func (cv *crossValidator) record(server string, mismatch bool) {
+	cv.Lock()
+	defer cv.Unlock()
+
+	s, found := cv.stats[server]
+	if !found {
+		s = &ResolverStats{Address: server}
+		cv.stats[server] = s
+	}
+
+	s.Queries++
+	if mismatch {
+		s.Mismatches++
+	}
+}
+
+// wait blocks until the outstanding attribution queries have completed.
+func (cv *crossValidator) wait() {
+	cv.wg.Wait()
+}
+
+// Stats returns the cross-validation results with the resolvers ordered by their mismatches.
+func (cv *crossValidator) Stats() *ValidationStats {
+	cv.Lock()
+	defer cv.Unlock()
+
+	vs := &ValidationStats{
+		Confirmed: cv.confirmed,
+		Rejected:  cv.rejected,
+	}
+	for _, s := range cv.stats {
+		c := *s
+		vs.Resolvers = append(vs.Resolvers, &c)
+	}
+
+	sort.Slice(vs.Resolvers, func(i, j int) bool {
+		if vs.Resolvers[i].Mismatches == vs.Resolvers[j].Mismatches {
+			return vs.Resolvers[i].Address < vs.Resolvers[j].Address
+		}
+		return vs.Resolvers[i].Mismatches > vs.Resolvers[j].Mismatches
+	})
+	return vs
+}
@@ -0,0 +1,64 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/systems"
+)
+
+// Starts a resolver that answers every A query with the provided address.
+func lyingResolver(t *testing.T, addr string) string {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen for DNS queries: %v", err)
+	}
+
+	srv := &dns.Server{
+		PacketConn: pc,
+		Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+			resp := new(dns.Msg)
+			resp.SetReply(req)
+			if rr, err := dns.NewRR(req.Question[0].Name + " 60 IN A " + addr); err == nil {
+				resp.Answer = append(resp.Answer, rr)
+			}
+			_ = w.WriteMsg(resp)
+		}),
+	}
+	go func() { _ = srv.ActivateAndServe() }()
+	t.Cleanup(func() { _ = srv.Shutdown() })
+	return pc.LocalAddr().String()
+}
+
+func TestCrossValidator(t *testing.T) {
+	server := lyingResolver(t, "192.0.2.1")
+	cv := newCrossValidator(&Enumeration{Sys: &systems.SimpleSystem{}}, []string{server})
+	ctx := context.Background()
+
+	cv.expect("www.owasp.org", dns.TypeA)
+	cv.settle(ctx, "WWW.owasp.org", true, false)
+	cv.expect("fake.owasp.org", dns.TypeA)
+	cv.settle(ctx, "fake.owasp.org", false, true)
+	cv.expect("wildcard.owasp.org", dns.TypeA)
+	cv.settle(ctx, "wildcard.owasp.org", false, false)
+	// names without an untrusted answer are not counted
+	cv.settle(ctx, "other.owasp.org", false, true)
+	cv.wait()
+
+	vs := cv.Stats()
+	if vs.Confirmed != 1 || vs.Rejected != 1 {
+		t.Errorf("Expected 1 confirmed and 1 rejected name, got %d and %d", vs.Confirmed, vs.Rejected)
+	}
+	if len(vs.Resolvers) != 1 {
+		t.Fatalf("Expected statistics for 1 resolver, got %d", len(vs.Resolvers))
+	}
+	if s := vs.Resolvers[0]; s.Address != server || s.Queries != 1 || s.Mismatches != 1 {
+		t.Errorf("Unexpected resolver statistics: %+v", s)
+	}
+}
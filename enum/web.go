@@ -0,0 +1,238 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/owasp-amass/amass/v4/events"
+	"github.com/owasp-amass/amass/v4/evidence"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/shared"
+	oam "github.com/owasp-amass/open-asset-model"
+)
+
+const (
+	// WebEndpointFinding is the finding type used to record the URLs served by live web endpoints.
+	WebEndpointFinding = "web_endpoint"
+	// WebEndpointTopic is the shared board topic of the live web endpoints, keyed by the discovered URL.
+	WebEndpointTopic = "web_endpoint"
+)
+
+const (
+	// The number of URLs probed concurrently
+	maxWebProbes = 10
+	// URLs found in web archives and by crawlers often share the host, so each host is only probed this many times
+	maxProbesPerHost = 20
+)
+
+// webProber resolves the URLs provided by the data sources into the live web endpoints serving them.
+type webProber struct {
+	sync.Mutex
+	enum  *Enumeration
+	seen  map[string]struct{}
+	hosts map[string]int
+	sem   chan struct{}
+	wg    sync.WaitGroup
+	live  int
+}
+
+func newWebProber(e *Enumeration) *webProber {
+	return &webProber{
+		enum:  e,
+		seen:  make(map[string]struct{}),
+		hosts: make(map[string]int),
+		sem:   make(chan struct{}, maxWebProbes),
+	}
+}
+
+// probe requests the URL once per enumeration and records the endpoint when it responds.
+func (wp *webProber) probe(req *requests.URLRequest) {
+	if wp == nil || !req.Valid() {
+		return
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return
+	}
+	u.Fragment = ""
+	host := strings.ToLower(u.Hostname())
+	key := u.String()
+
+	wp.Lock()
+	if _, found := wp.seen[key]; found || wp.hosts[host] >= maxProbesPerHost {
+		wp.Unlock()
+		return
+	}
+	wp.seen[key] = struct{}{}
+	wp.hosts[host]++
+	wp.Unlock()
+
+	wp.wg.Add(1)
+	go func() {
+		defer wp.wg.Done()
+
+		wp.sem <- struct{}{}
+		defer func() { <-wp.sem }()
+
+		e := wp.enum
+		if !e.Sys.Budget().SpendHTTP(budgetSource) {
+			return
+		}
+
+		resp, err := http.RequestWebPage(e.ctx, &http.Request{URL: key})
+		if err != nil {
+			return
+		}
+		wp.newEndpoint(key, req.Source, resp)
+	}()
+}
+
+// newEndpoint stores the hosts of the URL and its redirect target, and records the endpoint serving the URL.
+func (wp *webProber) newEndpoint(u, source string, resp *http.Response) {
+	e := wp.enum
+	final := resp.URL
+	if final == "" {
+		final = u
+	}
+	title := http.PageTitle(resp.Body)
+
+	hosts := []string{u}
+	if final != u {
+		hosts = append(hosts, final)
+	}
+	for _, h := range hosts {
+		wp.submitHost(h, source)
+	}
+
+	desc := fmt.Sprintf("The web endpoint responded with status %d", resp.StatusCode)
+	if title != "" {
+		desc += fmt.Sprintf(" and the title %q", title)
+	}
+	if final != u {
+		desc += " after redirecting to " + final
+	}
+	details := map[string]string{
+		"status_code": strconv.Itoa(resp.StatusCode),
+		"final_url":   final,
+	}
+	if title != "" {
+		details["title"] = title
+	}
+	if server, found := resp.Header["Server"]; found {
+		details["server"] = server
+	}
+	if id := e.storeEvidence(evidence.HTTPResponse, u, source, "message/http", resp.Dump()); id != "" {
+		details[evidence.DetailKey] = id
+	}
+
+	if _, err := e.Sys.Findings().Add(&findings.Finding{
+		Type:        WebEndpointFinding,
+		Asset:       u,
+		Severity:    findings.Info,
+		Description: desc,
+		Source:      source,
+		Details:     details,
+	}); err != nil {
+		e.Config.Log.Printf("Failed to save the web endpoint finding: %v", err)
+	}
+
+	e.Sys.Shared().Publish(&shared.Entry{
+		Topic: WebEndpointTopic,
+		Key:   u,
+		Value: map[string]interface{}{
+			"status_code": resp.StatusCode,
+			"title":       title,
+			"final_url":   final,
+		},
+		Source: source,
+	})
+
+	if e.events != nil {
+		for _, h := range hosts {
+			if parsed, err := url.Parse(h); err == nil {
+				atype := oam.FQDN
+				if net.ParseIP(parsed.Hostname()) != nil {
+					atype = oam.IPAddress
+				}
+				e.publishEntity(atype, parsed.Hostname(), source)
+				e.publishEntity(serviceAssetType, h, source)
+				e.publish(&events.Event{
+					Kind:   events.EdgeEvent,
+					Type:   "web_endpoint",
+					From:   parsed.Hostname(),
+					To:     h,
+					Source: source,
+				})
+			}
+		}
+		if final != u {
+			e.publish(&events.Event{
+				Kind:   events.EdgeEvent,
+				Type:   "redirects_to",
+				From:   u,
+				To:     final,
+				Source: source,
+			})
+		}
+	}
+
+	wp.Lock()
+	wp.live++
+	wp.Unlock()
+}
+
+// submitHost sends the in-scope name or address serving the URL into the enumeration,
+// so the FQDN and the addresses it resolves to are stored in the graph database.
+func (wp *webProber) submitHost(u, source string) {
+	e := wp.enum
+
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	if ip := net.ParseIP(host); ip != nil {
+		if e.Config.IsAddressInScope(ip.String()) {
+			e.nameSrc.newAddr(&requests.AddrRequest{
+				Address: ip.String(),
+				InScope: true,
+			})
+		}
+		return
+	}
+
+	if domain := e.Config.WhichDomain(host); domain != "" {
+		e.nameSrc.newName(&requests.DNSRequest{
+			Name:   host,
+			Domain: domain,
+			Source: source,
+		})
+	}
+}
+
+// wait blocks until the probes of the discovered URLs have finished, and logs the results.
+func (wp *webProber) wait() {
+	if wp == nil {
+		return
+	}
+
+	wp.wg.Wait()
+	wp.Lock()
+	defer wp.Unlock()
+
+	if len(wp.seen) > 0 {
+		wp.enum.Config.Log.Printf("Web endpoints: %d of the %d discovered URLs responded", wp.live, len(wp.seen))
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/caffix/queue"
+	"github.com/owasp-amass/amass/v4/evidence"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/shared"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	bf "github.com/tylertreat/BoomFilters"
+)
+
+func TestWebProber(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/login" {
+			http.Redirect(w, r, "/login", http.StatusMovedPermanently)
+			return
+		}
+		w.Header().Set("Server", "nginx")
+		fmt.Fprint(w, "<html><head><title>Sign In</title></head></html>")
+	}))
+	defer ts.Close()
+
+	store, err := findings.NewStore(filepath.Join(t.TempDir(), "findings.json"))
+	if err != nil {
+		t.Fatalf("Failed to create the findings store: %v", err)
+	}
+	board := shared.NewBoard()
+	dir := filepath.Join(t.TempDir(), evidence.Dir)
+	blobs, err := evidence.NewStore(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("Failed to create the evidence store: %v", err)
+	}
+
+	e := &Enumeration{
+		Config: config.NewConfig(),
+		Sys:    &systems.SimpleSystem{Store: store, Board: board, Blobs: blobs},
+		ctx:    context.Background(),
+	}
+	e.nameSrc = &enumSource{
+		enum:    e,
+		queue:   queue.NewQueue(),
+		filter:  bf.NewDefaultStableBloomFilter(1000, 0.01),
+		done:    make(chan struct{}),
+		release: make(chan struct{}, 10),
+	}
+	wp := newWebProber(e)
+
+	u := ts.URL + "/admin"
+	wp.probe(&requests.URLRequest{URL: u, Source: "Wayback"})
+	wp.probe(&requests.URLRequest{URL: u + "#top", Source: "Active Crawl"})
+	wp.probe(&requests.URLRequest{URL: "ftp://127.0.0.1/", Source: "Wayback"})
+	wp.wait()
+
+	all, err := store.All()
+	if err != nil || len(all) != 1 {
+		t.Fatalf("Expected one web endpoint finding, got %d: %v", len(all), err)
+	}
+	f := all[0]
+	if f.Type != WebEndpointFinding || f.Asset != u || f.Source != "Wayback" {
+		t.Errorf("Unexpected finding: %+v", f)
+	}
+	if f.Details["status_code"] != "200" || f.Details["title"] != "Sign In" ||
+		f.Details["final_url"] != ts.URL+"/login" || f.Details["server"] != "nginx" {
+		t.Errorf("Unexpected finding details: %v", f.Details)
+	}
+	// The response backing the finding is kept as evidence
+	if recs, data, err := evidence.Read(dir, f.Details[evidence.DetailKey]); err != nil || len(recs) != 1 ||
+		!strings.HasPrefix(string(data), "HTTP/1.1 200 OK\r\n") || !strings.HasSuffix(string(data), "<title>Sign In</title></head></html>") {
+		t.Errorf("Unexpected evidence for the finding: %q: %v", data, err)
+	}
+	// The address serving the endpoint is sent into the enumeration once
+	if e.nameSrc.queue.Len() != 1 {
+		t.Fatalf("%d requests were sent into the enumeration, expected 1", e.nameSrc.queue.Len())
+	}
+	if element, _ := e.nameSrc.queue.Next(); element.(*requests.AddrRequest).Address != "127.0.0.1" {
+		t.Errorf("Unexpected request: %+v", element)
+	}
+
+	entry, found := board.Get(WebEndpointTopic, u)
+	if !found {
+		t.Fatal("The web endpoint was not published")
+	}
+	if v, ok := entry.Value.(map[string]interface{}); !ok || v["status_code"] != 200 {
+		t.Errorf("Unexpected shared value: %+v", entry.Value)
+	}
+}
@@ -0,0 +1,319 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/config/config"
+)
+
+// The defaults for batching the writes to the graph database.
+const (
+	// DefaultWriteBatch is the number of pending writes that causes them to be flushed
+	DefaultWriteBatch = 500
+	// DefaultFlushInterval is the longest time a write remains pending
+	DefaultFlushInterval = 2 * time.Second
+)
+
+// WriteBehindOptions returns the batch size and flush interval set by the 'write_behind' section
+// of the configuration options. A zero batch size is returned when the writes to the graph database
+// are performed immediately.
+func WriteBehindOptions(cfg *config.Config) (int, time.Duration, error) {
+	wbRaw, ok := cfg.Options["write_behind"]
+	if !ok {
+		return DefaultWriteBatch, DefaultFlushInterval, nil
+	}
+
+	settings, ok := wbRaw.(map[string]interface{})
+	if !ok {
+		return 0, 0, fmt.Errorf("write_behind is not a map[string]interface{}")
+	}
+
+	batch := DefaultWriteBatch
+	if raw, ok := settings["batch_size"]; ok {
+		n, ok := raw.(int)
+		if !ok || n < 0 {
+			return 0, 0, fmt.Errorf("write_behind batch_size must be zero or a positive integer")
+		}
+		batch = n
+	}
+
+	interval := DefaultFlushInterval
+	if raw, ok := settings["flush_interval"]; ok {
+		str, ok := raw.(string)
+		if !ok {
+			return 0, 0, fmt.Errorf("write_behind flush_interval is not a string")
+		}
+
+		d, err := time.ParseDuration(str)
+		if err != nil || d <= 0 {
+			return 0, 0, fmt.Errorf("write_behind flush_interval is not a valid duration: %s", str)
+		}
+		interval = d
+	}
+	return batch, interval, nil
+}
+
+type graphWrite struct {
+	what   string
+	assets []string
+	fn     func(ctx context.Context) error
+}
+
+// writeBehind collects the writes to the graph database and performs them in batches, off
+// the pipeline, once the batch size is reached or the flush interval elapses. The same write
+// is only performed once per batch. The components reading the graph call sync first, so the
+// writes pending for the assets they read are flushed. All methods are safe to call on a nil
+// writeBehind, which performs the writes immediately.
+type writeBehind struct {
+	sync.Mutex
+	flushLock sync.Mutex
+	graph     *netmap.Graph
+	writer    *graphWriter
+	batch     int
+	interval  time.Duration
+	pending   []*graphWrite
+	keys      map[string]struct{}
+	touched   map[string]int
+	signal    chan struct{}
+	done      chan struct{}
+	stopped   chan struct{}
+	closed    bool
+	log       func(format string, v ...interface{})
+	writes    int
+	batches   int
+	coalesced int
+}
+
+func newWriteBehind(g *netmap.Graph, batch int, interval time.Duration, log func(format string, v ...interface{})) *writeBehind {
+	w := &writeBehind{
+		graph:    g,
+		writer:   acquireGraphWriter(g),
+		batch:    batch,
+		interval: interval,
+		keys:     make(map[string]struct{}),
+		touched:  make(map[string]int),
+		signal:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+		log:      log,
+	}
+
+	go w.flushLoop()
+	return w
+}
+
+// add schedules the write of the assets, described by what for the error messages. The
+// error is only returned when the write is performed immediately, since the failures of
+// the batched writes are logged.
+func (w *writeBehind) add(what string, fn func(ctx context.Context) error, assets ...string) error {
+	if w == nil {
+		return fn(context.Background())
+	}
+
+	w.Lock()
+	if w.closed {
+		w.Unlock()
+		return fn(context.Background())
+	}
+
+	key := what + "|" + strings.Join(assets, "|")
+	if _, found := w.keys[key]; found {
+		w.coalesced++
+		w.Unlock()
+		return nil
+	}
+
+	w.keys[key] = struct{}{}
+	for _, a := range assets {
+		w.touched[a]++
+	}
+	w.pending = append(w.pending, &graphWrite{what: what, assets: assets, fn: fn})
+	full := len(w.pending) >= w.batch
+	w.Unlock()
+
+	if full {
+		select {
+		case w.signal <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// sync flushes the pending writes before the graph is read. When assets are provided,
+// the writes are only flushed if any of them is pending for one of the assets.
+func (w *writeBehind) sync(assets ...string) {
+	if w == nil {
+		return
+	}
+	// Waits for a flush in progress, since it could hold the writes for the assets
+	w.flushLock.Lock()
+	defer w.flushLock.Unlock()
+
+	if len(assets) > 0 {
+		w.Lock()
+		var found bool
+		for _, a := range assets {
+			if w.touched[a] > 0 {
+				found = true
+				break
+			}
+		}
+		w.Unlock()
+
+		if !found {
+			return
+		}
+	}
+	w.flushPending()
+}
+
+func (w *writeBehind) flush() {
+	w.flushLock.Lock()
+	defer w.flushLock.Unlock()
+
+	w.flushPending()
+}
+
+// flushPending performs the pending writes in the order they were added. The flushLock must be held.
+func (w *writeBehind) flushPending() {
+	w.Lock()
+	pending := w.pending
+	w.pending = nil
+	w.keys = make(map[string]struct{})
+	w.touched = make(map[string]int)
+	w.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	w.writer.write(pending, w.log)
+
+	w.Lock()
+	w.writes += len(pending)
+	w.batches++
+	w.Unlock()
+}
+
+func (w *writeBehind) flushLoop() {
+	defer close(w.stopped)
+
+	t := time.NewTicker(w.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-t.C:
+		case <-w.signal:
+		}
+		w.flush()
+	}
+}
+
+// stop flushes the pending writes and performs the later writes immediately.
+func (w *writeBehind) stop() {
+	if w == nil {
+		return
+	}
+
+	w.Lock()
+	if w.closed {
+		w.Unlock()
+		return
+	}
+	w.closed = true
+	w.Unlock()
+
+	close(w.done)
+	<-w.stopped
+	w.flush()
+	releaseGraphWriter(w.graph)
+
+	w.Lock()
+	defer w.Unlock()
+	if w.log != nil && w.writes > 0 {
+		w.log("Graph writes: %d writes were stored in %d batches, and %d repeated writes were dropped", w.writes, w.batches, w.coalesced)
+	}
+}
+
+// graphWriter performs the batches flushed by all the enumerations sharing a graph database from
+// a single goroutine, so the local SQLite database does not receive concurrent writes waiting on
+// each other for the database lock.
+type graphWriter struct {
+	batches chan *writeBatch
+	refs    int
+}
+
+type writeBatch struct {
+	writes []*graphWrite
+	log    func(format string, v ...interface{})
+	done   chan struct{}
+}
+
+var graphWriters = struct {
+	sync.Mutex
+	writers map[*netmap.Graph]*graphWriter
+}{writers: make(map[*netmap.Graph]*graphWriter)}
+
+// acquireGraphWriter returns the writer of the graph, starting it for the first enumeration using the graph.
+func acquireGraphWriter(g *netmap.Graph) *graphWriter {
+	graphWriters.Lock()
+	defer graphWriters.Unlock()
+
+	gw, found := graphWriters.writers[g]
+	if !found {
+		gw = &graphWriter{batches: make(chan *writeBatch)}
+		graphWriters.writers[g] = gw
+		go gw.writeLoop()
+	}
+	gw.refs++
+	return gw
+}
+
+// releaseGraphWriter stops the writer of the graph once the last enumeration using it has finished.
+func releaseGraphWriter(g *netmap.Graph) {
+	graphWriters.Lock()
+	defer graphWriters.Unlock()
+
+	gw, found := graphWriters.writers[g]
+	if !found {
+		return
+	}
+	if gw.refs--; gw.refs == 0 {
+		delete(graphWriters.writers, g)
+		close(gw.batches)
+	}
+}
+
+// write performs the writes in the order provided and returns once they have been stored.
+func (gw *graphWriter) write(writes []*graphWrite, log func(format string, v ...interface{})) {
+	b := &writeBatch{writes: writes, log: log, done: make(chan struct{})}
+
+	gw.batches <- b
+	<-b.done
+}
+
+func (gw *graphWriter) writeLoop() {
+	ctx := context.Background()
+
+	for b := range gw.batches {
+		for _, w := range b.writes {
+			if err := w.fn(ctx); err != nil && b.log != nil {
+				b.log("Graph writes: failed to store the %s for %s: %v", w.what, strings.Join(w.assets, ", "), err)
+			}
+		}
+		close(b.done)
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/open-asset-model/domain"
+)
+
+func TestWriteBehindOptions(t *testing.T) {
+	cfg := config.NewConfig()
+	if batch, interval, err := WriteBehindOptions(cfg); err != nil || batch != DefaultWriteBatch || interval != DefaultFlushInterval {
+		t.Errorf("Expected the default options, got %d and %s: %v", batch, interval, err)
+	}
+
+	cfg.Options["write_behind"] = map[string]interface{}{"batch_size": 0, "flush_interval": "500ms"}
+	if batch, interval, err := WriteBehindOptions(cfg); err != nil || batch != 0 || interval != 500*time.Millisecond {
+		t.Errorf("Expected the immediate writes, got %d and %s: %v", batch, interval, err)
+	}
+
+	for _, settings := range []map[string]interface{}{
+		{"batch_size": -1},
+		{"batch_size": "large"},
+		{"flush_interval": "0s"},
+		{"flush_interval": 5},
+	} {
+		cfg.Options["write_behind"] = settings
+		if _, _, err := WriteBehindOptions(cfg); err == nil {
+			t.Errorf("Expected an error for the settings %v", settings)
+		}
+	}
+}
+
+func TestWriteBehind(t *testing.T) {
+	g := netmap.NewGraph("memory", "", "")
+	w := newWriteBehind(g, 100, time.Hour, nil)
+	defer w.stop()
+
+	stored := func(name string) bool {
+		assets, err := g.DB.FindByContent(domain.FQDN{Name: name}, time.Time{})
+		return err == nil && len(assets) > 0
+	}
+
+	var calls int
+	for i := 0; i < 3; i++ {
+		if err := w.add("a_record", func(ctx context.Context) error {
+			calls++
+			return g.UpsertA(ctx, "www.owasp.org", "192.0.2.1")
+		}, "www.owasp.org", "192.0.2.1"); err != nil {
+			t.Fatalf("add returned an error: %v", err)
+		}
+	}
+	_ = w.add("a_record", func(ctx context.Context) error {
+		return g.UpsertA(ctx, "mail.owasp.org", "192.0.2.2")
+	}, "mail.owasp.org", "192.0.2.2")
+
+	if stored("www.owasp.org") {
+		t.Fatal("The write was performed before the batch was flushed")
+	}
+	// Reading another asset does not flush the pending writes
+	w.sync("192.0.2.3")
+	if stored("www.owasp.org") {
+		t.Error("The writes were flushed for an asset without pending writes")
+	}
+
+	w.sync("192.0.2.1")
+	if !stored("www.owasp.org") || !stored("mail.owasp.org") {
+		t.Error("The pending writes were not flushed for the read")
+	}
+	if calls != 1 || w.coalesced != 2 {
+		t.Errorf("The repeated write was performed %d times with %d dropped", calls, w.coalesced)
+	}
+
+	// The writes are flushed once the batch size is reached
+	w.batch = 2
+	_ = w.add("a_record", func(ctx context.Context) error {
+		return g.UpsertA(ctx, "ftp.owasp.org", "192.0.2.4")
+	}, "ftp.owasp.org", "192.0.2.4")
+	_ = w.add("cname_record", func(ctx context.Context) error {
+		return g.UpsertCNAME(ctx, "docs.owasp.org", "ftp.owasp.org")
+	}, "docs.owasp.org", "ftp.owasp.org")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !stored("docs.owasp.org") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !stored("docs.owasp.org") {
+		t.Error("The writes were not flushed once the batch was full")
+	}
+
+	// Once stopped, the pending writes are flushed and the later writes are performed immediately
+	_ = w.add("a_record", func(ctx context.Context) error {
+		return g.UpsertA(ctx, "blog.owasp.org", "192.0.2.5")
+	}, "blog.owasp.org", "192.0.2.5")
+	w.stop()
+	if !stored("blog.owasp.org") {
+		t.Error("The pending write was not flushed when stopped")
+	}
+
+	failure := errors.New("failed")
+	if err := w.add("a_record", func(ctx context.Context) error { return failure }, "x.owasp.org"); err != failure {
+		t.Errorf("The write after stopping returned %v", err)
+	}
+
+	var nilWriter *writeBehind
+	if err := nilWriter.add("a_record", func(ctx context.Context) error { return failure }, "x.owasp.org"); err != failure {
+		t.Errorf("The write without batching returned %v", err)
+	}
+	nilWriter.sync()
+	nilWriter.stop()
+}
+
+func TestGraphWriter(t *testing.T) {
+	g := netmap.NewGraph("memory", "", "")
+	first := newWriteBehind(g, 100, time.Hour, nil)
+	second := newWriteBehind(g, 100, time.Hour, nil)
+
+	if first.writer != second.writer {
+		t.Fatal("The enumerations sharing the graph did not share the writer")
+	}
+
+	var order []string
+	for _, w := range []*writeBehind{first, second} {
+		w := w
+		name := "first"
+		if w == second {
+			name = "second"
+		}
+		_ = w.add("a_record", func(ctx context.Context) error {
+			order = append(order, name)
+			return nil
+		}, name+".owasp.org")
+	}
+	first.flush()
+	second.flush()
+	if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+		t.Errorf("The batches were not written in the order they were flushed: %v", order)
+	}
+
+	first.stop()
+	graphWriters.Lock()
+	_, found := graphWriters.writers[g]
+	graphWriters.Unlock()
+	if !found {
+		t.Error("The writer was stopped while an enumeration still used the graph")
+	}
+
+	second.stop()
+	graphWriters.Lock()
+	_, found = graphWriters.writers[g]
+	graphWriters.Unlock()
+	if found {
+		t.Error("The writer was not stopped once the enumerations finished")
+	}
+}
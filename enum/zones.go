@@ -0,0 +1,244 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// ZoneOptions returns the maximum number of names resolved at the same time within each DNS
+// zone, and whether the limit is reduced for the zones failing to answer, as set by the 'zones'
+// section of the configuration options. A zero maximum is returned when the zones are not limited.
+func ZoneOptions(cfg *config.Config) (int, bool, error) {
+	zonesRaw, ok := cfg.Options["zones"]
+	if !ok {
+		return 0, false, nil
+	}
+
+	settings, ok := zonesRaw.(map[string]interface{})
+	if !ok {
+		return 0, false, fmt.Errorf("zones is not a map[string]interface{}")
+	}
+
+	var max int
+	if raw, ok := settings["max_in_flight"]; ok {
+		n, ok := raw.(int)
+		if !ok || n < 0 {
+			return 0, false, fmt.Errorf("zones max_in_flight must be zero or a positive integer")
+		}
+		max = n
+	}
+
+	adaptive := true
+	if raw, ok := settings["adaptive"]; ok {
+		if adaptive, ok = raw.(bool); !ok {
+			return 0, false, fmt.Errorf("zones adaptive is not a bool")
+		}
+	}
+	return max, adaptive, nil
+}
+
+// zoneCuts holds the names found to have NS records, which are the apexes of the zones delegated
+// below the root domain names. It is shared by the DNS tasks of the enumeration, and all methods
+// are safe to call on a nil zoneCuts.
+type zoneCuts struct {
+	sync.Mutex
+	apexes map[string]struct{}
+}
+
+func newZoneCuts() *zoneCuts {
+	return &zoneCuts{apexes: make(map[string]struct{})}
+}
+
+func (c *zoneCuts) add(apex string) {
+	if c == nil {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.apexes[strings.ToLower(apex)] = struct{}{}
+}
+
+// zone returns the closest enclosing zone of the name, which is the root domain name
+// unless a zone has been delegated between them.
+func (c *zoneCuts) zone(name, domain string) string {
+	name = strings.ToLower(name)
+	domain = strings.ToLower(domain)
+	if c == nil {
+		return domain
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	for n := name; n != "" && n != domain && strings.HasSuffix(n, "."+domain); {
+		if _, found := c.apexes[n]; found {
+			return n
+		}
+
+		idx := strings.Index(n, ".")
+		if idx == -1 {
+			break
+		}
+		n = n[idx+1:]
+	}
+	return domain
+}
+
+type zoneState struct {
+	limit     int
+	inFlight  int
+	successes int
+	parked    []func()
+}
+
+// zoneLimiter bounds the names being resolved at the same time within each zone, so the small
+// authoritative servers are not overwhelmed by the queries sent through the resolvers. The names
+// over the limit are parked until a name of the zone has been resolved, instead of holding up the
+// names of the other zones. When adaptive, the limit of a zone is halved each time its servers fail
+// to answer, and raised by one once a limit's worth of names have been resolved. All methods are
+// safe to call on a nil zoneLimiter, which does not limit the zones.
+type zoneLimiter struct {
+	sync.Mutex
+	max      int
+	adaptive bool
+	cuts     *zoneCuts
+	zones    map[string]*zoneState
+	parks    int
+	cutbacks int
+}
+
+func newZoneLimiter(max int, adaptive bool, cuts *zoneCuts) *zoneLimiter {
+	return &zoneLimiter{
+		max:      max,
+		adaptive: adaptive,
+		cuts:     cuts,
+		zones:    make(map[string]*zoneState),
+	}
+}
+
+// zone returns the zone the name is resolved within.
+func (z *zoneLimiter) zone(name, domain string) string {
+	if z == nil || domain == "" {
+		return ""
+	}
+	return z.cuts.zone(name, domain)
+}
+
+func (z *zoneLimiter) state(zone string) *zoneState {
+	st, found := z.zones[zone]
+	if !found {
+		st = &zoneState{limit: z.max}
+		z.zones[zone] = st
+	}
+	return st
+}
+
+// acquire returns true when the name can be resolved within the zone right away. Otherwise,
+// fn is called once a name of the zone has been resolved, and the slot is handed over to it.
+func (z *zoneLimiter) acquire(zone string, fn func()) bool {
+	if z == nil || zone == "" {
+		return true
+	}
+
+	z.Lock()
+	defer z.Unlock()
+
+	st := z.state(zone)
+	if st.inFlight < st.limit {
+		st.inFlight++
+		return true
+	}
+
+	st.parked = append(st.parked, fn)
+	z.parks++
+	return false
+}
+
+// release frees the slot of a name resolved within the zone, or hands it over to a parked name.
+func (z *zoneLimiter) release(zone string) {
+	if z == nil || zone == "" {
+		return
+	}
+
+	z.Lock()
+	st := z.state(zone)
+	var next func()
+	if len(st.parked) > 0 && st.inFlight <= st.limit {
+		next = st.parked[0]
+		st.parked[0] = nil
+		st.parked = st.parked[1:]
+	} else if st.inFlight > 0 {
+		st.inFlight--
+	}
+	z.Unlock()
+
+	if next != nil {
+		go next()
+	}
+}
+
+// answered records whether the servers of the zone answered the query or failed to.
+func (z *zoneLimiter) answered(zone string, failed bool) {
+	if z == nil || zone == "" || !z.adaptive {
+		return
+	}
+
+	z.Lock()
+	defer z.Unlock()
+
+	st := z.state(zone)
+	if failed {
+		st.successes = 0
+		if st.limit > 1 {
+			st.limit /= 2
+			z.cutbacks++
+		}
+		return
+	}
+
+	if st.limit < z.max {
+		st.successes++
+		if st.successes >= st.limit {
+			st.limit++
+			st.successes = 0
+			// The raised limit makes room for a parked name
+			if len(st.parked) > 0 && st.inFlight < st.limit {
+				next := st.parked[0]
+				st.parked[0] = nil
+				st.parked = st.parked[1:]
+				st.inFlight++
+				go next()
+			}
+		}
+	}
+}
+
+// stats returns the number of names parked while their zone was at the limit, the number of times
+// a limit was reduced, and the zones left below the configured limit.
+func (z *zoneLimiter) stats() (int, int, []string) {
+	if z == nil {
+		return 0, 0, nil
+	}
+
+	z.Lock()
+	defer z.Unlock()
+
+	var reduced []string
+	for zone, st := range z.zones {
+		if st.limit < z.max {
+			reduced = append(reduced, fmt.Sprintf("%s (%d)", zone, st.limit))
+		}
+	}
+	sort.Strings(reduced)
+	return z.parks, z.cutbacks, reduced
+}
@@ -0,0 +1,120 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func TestZoneOptions(t *testing.T) {
+	cfg := config.NewConfig()
+	if max, _, err := ZoneOptions(cfg); err != nil || max != 0 {
+		t.Errorf("Expected the zones to not be limited, got %d: %v", max, err)
+	}
+
+	cfg.Options["zones"] = map[string]interface{}{"max_in_flight": 20}
+	if max, adaptive, err := ZoneOptions(cfg); err != nil || max != 20 || !adaptive {
+		t.Errorf("Expected an adaptive limit of 20, got %d and %t: %v", max, adaptive, err)
+	}
+
+	for _, settings := range []map[string]interface{}{
+		{"max_in_flight": -1},
+		{"max_in_flight": "many"},
+		{"adaptive": "yes"},
+	} {
+		cfg.Options["zones"] = settings
+		if _, _, err := ZoneOptions(cfg); err == nil {
+			t.Errorf("Expected an error for the settings %v", settings)
+		}
+	}
+}
+
+func TestZoneCuts(t *testing.T) {
+	c := newZoneCuts()
+	c.add("Corp.OWASP.org")
+
+	for name, expected := range map[string]string{
+		"www.owasp.org":           "owasp.org",
+		"owasp.org":               "owasp.org",
+		"corp.owasp.org":          "corp.owasp.org",
+		"vpn.eu.corp.owasp.org":   "corp.owasp.org",
+		"www.notcorp.owasp.org":   "owasp.org",
+		"www.corp.owasp.org.evil": "owasp.org",
+	} {
+		if zone := c.zone(name, "owasp.org"); zone != expected {
+			t.Errorf("The zone of %s was %s, expected %s", name, zone, expected)
+		}
+	}
+
+	var nilCuts *zoneCuts
+	nilCuts.add("corp.owasp.org")
+	if zone := nilCuts.zone("www.corp.owasp.org", "owasp.org"); zone != "owasp.org" {
+		t.Errorf("The nil zoneCuts returned the zone %s", zone)
+	}
+}
+
+func TestZoneLimiter(t *testing.T) {
+	z := newZoneLimiter(2, true, newZoneCuts())
+	zone := z.zone("www.owasp.org", "owasp.org")
+
+	ran := make(chan int, 10)
+	park := func(n int) func() { return func() { ran <- n } }
+	if !z.acquire(zone, park(1)) || !z.acquire(zone, park(2)) {
+		t.Fatal("The names within the limit were not resolved right away")
+	}
+	if z.acquire(zone, park(3)) {
+		t.Fatal("The name over the limit was not parked")
+	}
+	// The names of the other zones are not held up
+	if !z.acquire(z.zone("www.example.com", "example.com"), park(4)) {
+		t.Error("The name of another zone was parked")
+	}
+
+	z.release(zone)
+	select {
+	case n := <-ran:
+		if n != 3 {
+			t.Errorf("The parked name %d was resolved", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("The parked name was not resolved once a slot was released")
+	}
+
+	// A failure halves the limit, and the successes raise it again
+	z.answered(zone, true)
+	if z.zones[zone].limit != 1 {
+		t.Errorf("The limit was %d after the failure", z.zones[zone].limit)
+	}
+	z.release(zone)
+	if z.acquire(zone, park(5)) {
+		t.Error("The name was resolved over the reduced limit")
+	}
+	z.answered(zone, false)
+	if z.zones[zone].limit != 2 {
+		t.Errorf("The limit was %d after the success", z.zones[zone].limit)
+	}
+	select {
+	case n := <-ran:
+		if n != 5 {
+			t.Errorf("The parked name %d was resolved", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("The parked name was not resolved once the limit was raised")
+	}
+
+	if parks, cutbacks, reduced := z.stats(); parks != 2 || cutbacks != 1 || len(reduced) != 0 {
+		t.Errorf("The stats were %d parks, %d cutbacks and %v", parks, cutbacks, reduced)
+	}
+
+	var nilLimiter *zoneLimiter
+	if zone := nilLimiter.zone("www.owasp.org", "owasp.org"); zone != "" || !nilLimiter.acquire(zone, park(6)) {
+		t.Error("The nil zoneLimiter limited the zone")
+	}
+	nilLimiter.answered(zone, true)
+	nilLimiter.release(zone)
+}
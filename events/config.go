@@ -0,0 +1,82 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package events
+
+import (
+	"fmt"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// FromConfig returns a Bus delivering the events to the publishers in the 'events' section of the
+// configuration options, along with the extra publishers. A nil Bus is returned when no publishers
+// have been configured or provided.
+func FromConfig(cfg *config.Config, extra ...Publisher) (*Bus, error) {
+	pubs := append([]Publisher{}, extra...)
+
+	eventsRaw, ok := cfg.Options["events"]
+	if !ok {
+		if len(pubs) == 0 {
+			return nil, nil
+		}
+		return NewBus(pubs...), nil
+	}
+
+	settings, ok := eventsRaw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("events is not a map[string]interface{}")
+	}
+
+	if raw, ok := settings["kafka"]; ok {
+		values, err := stringSettings("kafka", raw, "url", "topic", "username", "password")
+		if err != nil {
+			return nil, err
+		}
+		if values["url"] == "" || values["topic"] == "" {
+			return nil, fmt.Errorf("events kafka url and topic must be provided")
+		}
+
+		pubs = append(pubs, NewKafkaPublisher(values["url"], values["topic"], values["username"], values["password"]))
+	}
+	if raw, ok := settings["nats"]; ok {
+		values, err := stringSettings("nats", raw, "url", "subject")
+		if err != nil {
+			return nil, err
+		}
+
+		p, err := NewNATSPublisher(values["url"], values["subject"])
+		if err != nil {
+			return nil, fmt.Errorf("events nats: %v", err)
+		}
+		pubs = append(pubs, p)
+	}
+
+	if len(pubs) == 0 {
+		return nil, nil
+	}
+	return NewBus(pubs...), nil
+}
+
+func stringSettings(name string, raw interface{}, keys ...string) (map[string]string, error) {
+	settings, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("events %s is not a map[string]interface{}", name)
+	}
+
+	values := make(map[string]string)
+	for _, key := range keys {
+		v, ok := settings[key]
+		if !ok {
+			continue
+		}
+
+		str, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("events %s %s is not a string", name, key)
+		}
+		values[key] = str
+	}
+	return values, nil
+}
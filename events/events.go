@@ -0,0 +1,175 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// The kinds of events published for the discoveries.
+const (
+	EntityEvent = "entity"
+	EdgeEvent   = "edge"
+	// SessionEvent reports a change in the state of the enumeration session
+	SessionEvent = "session"
+)
+
+// SessionComplete is the type of the session event published once the enumeration has come to an end.
+const SessionComplete = "complete"
+
+const (
+	busBufferSize int           = 10000
+	maxBatchSize  int           = 100
+	flushInterval time.Duration = time.Second
+)
+
+// Event describes an asset or relation discovered during an enumeration.
+type Event struct {
+	Session string    `json:"session"`
+	Kind    string    `json:"kind"`
+	Type    string    `json:"type"`
+	Asset   string    `json:"asset,omitempty"`
+	From    string    `json:"from,omitempty"`
+	To      string    `json:"to,omitempty"`
+	Reason  string    `json:"reason,omitempty"`
+	Source  string    `json:"source"`
+	Time    time.Time `json:"time"`
+}
+
+// Key returns the value used to partition the events, so those of the same asset stay in order.
+func (e *Event) Key() string {
+	switch e.Kind {
+	case EdgeEvent:
+		return e.From
+	case SessionEvent:
+		return e.Session
+	}
+	return e.Asset
+}
+
+// Publisher delivers batches of events to an external system.
+type Publisher interface {
+	Publish(ctx context.Context, events []*Event) error
+	Close() error
+}
+
+// Bus delivers events to the publishers in the background, so the enumeration is never blocked.
+// Events are dropped when the buffer is full. All methods are safe to call on a nil Bus.
+type Bus struct {
+	sync.Mutex
+	pubs    []Publisher
+	queue   chan *Event
+	done    chan struct{}
+	dropped int
+	failed  int
+	lastErr error
+}
+
+// NewBus returns a Bus delivering the events to the publishers.
+func NewBus(pubs ...Publisher) *Bus {
+	b := &Bus{
+		pubs:  pubs,
+		queue: make(chan *Event, busBufferSize),
+		done:  make(chan struct{}),
+	}
+
+	go b.deliver()
+	return b
+}
+
+// Send queues the event for delivery, filling in the time when it has not been set.
+func (b *Bus) Send(e *Event) {
+	if b == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	select {
+	case b.queue <- e:
+	default:
+		b.Lock()
+		b.dropped++
+		b.Unlock()
+	}
+}
+
+// Close delivers the queued events and closes the publishers.
+func (b *Bus) Close() error {
+	if b == nil {
+		return nil
+	}
+
+	close(b.queue)
+	<-b.done
+
+	var err error
+	for _, p := range b.pubs {
+		if e := p.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Stats returns the number of events dropped and that failed delivery, along with the last error.
+func (b *Bus) Stats() (dropped, failed int, err error) {
+	if b == nil {
+		return 0, 0, nil
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	return b.dropped, b.failed, b.lastErr
+}
+
+func (b *Bus) deliver() {
+	defer close(b.done)
+
+	t := time.NewTicker(flushInterval)
+	defer t.Stop()
+
+	var batch []*Event
+	for {
+		select {
+		case e, ok := <-b.queue:
+			if !ok {
+				b.flush(batch)
+				return
+			}
+
+			batch = append(batch, e)
+			if len(batch) >= maxBatchSize {
+				b.flush(batch)
+				batch = nil
+			}
+		case <-t.C:
+			b.flush(batch)
+			batch = nil
+		}
+	}
+}
+
+func (b *Bus) flush(batch []*Event) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, p := range b.pubs {
+		if err := p.Publish(ctx, batch); err != nil {
+			b.Lock()
+			b.failed += len(batch)
+			b.lastErr = err
+			b.Unlock()
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestKafkaPublisher(t *testing.T) {
+	var lock sync.Mutex
+	var records []*kafkaRecord
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/topics/amass-assets" || r.Header.Get("Content-Type") != "application/vnd.kafka.json.v2+json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		var body kafkaRecords
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		lock.Lock()
+		records = append(records, body.Records...)
+		lock.Unlock()
+		_, _ = w.Write([]byte(`{"offsets":[]}`))
+	}))
+	defer srv.Close()
+
+	bus := NewBus(NewKafkaPublisher(srv.URL, "amass-assets", "", ""))
+	bus.Send(&Event{Session: "s1", Kind: EntityEvent, Type: "FQDN", Asset: "www.owasp.org", Source: "Passive"})
+	bus.Send(&Event{Session: "s1", Kind: EdgeEvent, Type: "a_record", From: "www.owasp.org", To: "192.0.2.1", Source: "DNS"})
+	if err := bus.Close(); err != nil {
+		t.Fatalf("Failed to close the bus: %v", err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	if r := records[1]; r.Key != "www.owasp.org" || r.Value.To != "192.0.2.1" || r.Value.Time.IsZero() {
+		t.Errorf("Unexpected record: %+v %+v", r, r.Value)
+	}
+	if dropped, failed, _ := bus.Stats(); dropped != 0 || failed != 0 {
+		t.Errorf("Expected no dropped or failed events, got %d and %d", dropped, failed)
+	}
+}
+
+func TestNATSPublisher(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	msgs := make(chan string, 10)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		fmt.Fprint(conn, "INFO {\"server_id\":\"test\"}\r\n")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			switch fields := strings.Fields(line); fields[0] {
+			case "CONNECT":
+				if !strings.Contains(line, `"user":"amass"`) {
+					fmt.Fprint(conn, "-ERR 'Authorization Violation'\r\n")
+					return
+				}
+			case "PING":
+				fmt.Fprint(conn, "PONG\r\n")
+			case "PUB":
+				size, _ := strconv.Atoi(fields[2])
+				payload := make([]byte, size+2)
+				if _, err := io.ReadFull(r, payload); err != nil {
+					return
+				}
+				msgs <- fields[1] + " " + string(payload[:size])
+			}
+		}
+	}()
+
+	p, err := NewNATSPublisher("nats://amass:secret@"+l.Addr().String(), "amass.assets")
+	if err != nil {
+		t.Fatalf("Failed to create the publisher: %v", err)
+	}
+
+	bus := NewBus(p)
+	bus.Send(&Event{Session: "s1", Kind: EntityEvent, Type: "FQDN", Asset: "www.owasp.org", Source: "Passive"})
+	if err := bus.Close(); err != nil {
+		t.Fatalf("Failed to close the bus: %v", err)
+	}
+	if _, failed, err := bus.Stats(); failed != 0 {
+		t.Fatalf("The event failed delivery: %v", err)
+	}
+
+	msg := <-msgs
+	if !strings.HasPrefix(msg, "amass.assets ") || !strings.Contains(msg, `"asset":"www.owasp.org"`) {
+		t.Errorf("Unexpected message: %s", msg)
+	}
+
+	if _, err := NewNATSPublisher("http://localhost:4222", "amass.assets"); err == nil {
+		t.Error("Expected an error for the URL scheme")
+	}
+}
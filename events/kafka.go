@@ -0,0 +1,85 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// KafkaPublisher produces the events to a Kafka topic through the Confluent REST Proxy.
+type KafkaPublisher struct {
+	URL      string
+	Topic    string
+	Username string
+	Password string
+	http     *http.Client
+}
+
+// NewKafkaPublisher returns a KafkaPublisher for the REST Proxy at the URL (e.g. http://localhost:8082).
+func NewKafkaPublisher(proxy, topic, username, password string) *KafkaPublisher {
+	return &KafkaPublisher{
+		URL:      strings.TrimSuffix(proxy, "/"),
+		Topic:    topic,
+		Username: username,
+		Password: password,
+		http:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type kafkaRecord struct {
+	Key   string `json:"key"`
+	Value *Event `json:"value"`
+}
+
+type kafkaRecords struct {
+	Records []*kafkaRecord `json:"records"`
+}
+
+// Publish implements the Publisher interface.
+func (k *KafkaPublisher) Publish(ctx context.Context, events []*Event) error {
+	body := &kafkaRecords{}
+	for _, e := range events {
+		body.Records = append(body.Records, &kafkaRecord{Key: e.Key(), Value: e})
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	u := k.URL + "/topics/" + url.PathEscape(k.Topic)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+	if k.Username != "" {
+		req.SetBasicAuth(k.Username, k.Password)
+	}
+
+	resp, err := k.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("the Kafka REST Proxy returned status %s", resp.Status)
+	}
+	return nil
+}
+
+// Close implements the Publisher interface.
+func (k *KafkaPublisher) Close() error {
+	return nil
+}
@@ -0,0 +1,292 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package evidence stores the raw data backing the findings, such as the HTTP responses, certificates
+// and RDAP objects, so each assertion can be checked against what was actually observed. The blobs are
+// stored in the output directory, named by the SHA-256 digest of their content, and the findings
+// reference them using the 'evidence_id' detail.
+package evidence
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// Dir is the folder of the output directory holding the evidence.
+const Dir = "evidence"
+
+// IndexFile is the file of the evidence folder recording the metadata of each stored blob.
+const IndexFile = "index.jsonl"
+
+// DetailKey is the key of the finding details referencing the evidence.
+const DetailKey = "evidence_id"
+
+// The kinds of evidence stored by the enumeration.
+const (
+	HTTPResponse = "http_response"
+	Certificate  = "certificate"
+	RDAP         = "rdap"
+)
+
+// The default size limits of the evidence.
+const (
+	// DefaultSessionLimit is the number of bytes stored during each enumeration
+	DefaultSessionLimit = 256 << 20
+	// DefaultBlobLimit is the size of the largest blob stored
+	DefaultBlobLimit = 5 << 20
+)
+
+// ErrLimit is returned when storing the blob would exceed a size limit.
+var ErrLimit = errors.New("the evidence size limit was reached")
+
+// Record provides the metadata of a stored blob.
+type Record struct {
+	ID          string    `json:"id"`
+	Kind        string    `json:"kind"`
+	Asset       string    `json:"asset"`
+	Source      string    `json:"source,omitempty"`
+	ContentType string    `json:"content_type,omitempty"`
+	Size        int64     `json:"size"`
+	Time        time.Time `json:"time"`
+}
+
+// Store writes the evidence to a folder of the output directory. All methods are
+// safe to call on a nil Store, which discards the evidence.
+type Store struct {
+	sync.Mutex
+	dir          string
+	sessionLimit int64
+	blobLimit    int64
+	used         int64
+	stored       int
+	dropped      int
+}
+
+// NewStore returns a Store writing the evidence to the folder, which is created when missing.
+// A zero limit leaves the size of each session or blob unbounded.
+func NewStore(dir string, sessionLimit, blobLimit int64) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &Store{
+		dir:          dir,
+		sessionLimit: sessionLimit,
+		blobLimit:    blobLimit,
+	}, nil
+}
+
+// Reset starts a new session, so the session limit applies to the evidence stored from now on.
+func (s *Store) Reset() {
+	if s == nil {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	s.used = 0
+	s.stored = 0
+	s.dropped = 0
+}
+
+// Put stores the blob observed for the asset and returns its identifier. An empty identifier
+// and a nil error are returned by a nil Store, and ErrLimit when a size limit would be exceeded.
+func (s *Store) Put(kind, asset, source, contentType string, data []byte) (string, error) {
+	if s == nil {
+		return "", nil
+	}
+
+	size := int64(len(data))
+	sum := sha256.Sum256(data)
+	id := hex.EncodeToString(sum[:])
+	path := blobPath(s.dir, id)
+
+	s.Lock()
+	defer s.Unlock()
+
+	// The same content is only stored once, and does not count against the limits again
+	_, err := os.Stat(path)
+	exists := err == nil
+	if !exists {
+		if (s.blobLimit > 0 && size > s.blobLimit) || (s.sessionLimit > 0 && s.used+size > s.sessionLimit) {
+			s.dropped++
+			return "", ErrLimit
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return "", err
+		}
+		s.used += size
+	}
+
+	rec, err := json.Marshal(&Record{
+		ID:          id,
+		Kind:        kind,
+		Asset:       asset,
+		Source:      source,
+		ContentType: contentType,
+		Size:        size,
+		Time:        time.Now().UTC(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.OpenFile(filepath.Join(s.dir, IndexFile), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(rec, '\n')); err != nil {
+		return "", err
+	}
+	s.stored++
+	return id, nil
+}
+
+// Stats returns the number of blobs and bytes stored during the session, and the number of blobs dropped by the limits.
+func (s *Store) Stats() (int, int64, int) {
+	if s == nil {
+		return 0, 0, 0
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	return s.stored, s.used, s.dropped
+}
+
+func blobPath(dir, id string) string {
+	return filepath.Join(dir, id[:2], id)
+}
+
+// Records returns the metadata recorded in the evidence folder, in the order the blobs were stored.
+// When asset is not empty, only the records of the asset are returned.
+func Records(dir, asset string) ([]*Record, error) {
+	f, err := os.Open(filepath.Join(dir, IndexFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var recs []*Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		if asset == "" || strings.EqualFold(rec.Asset, asset) {
+			recs = append(recs, &rec)
+		}
+	}
+	return recs, scanner.Err()
+}
+
+// Read returns the records and the content of the blob identified by id, which can be shortened
+// to a unique prefix of at least eight characters.
+func Read(dir, id string) ([]*Record, []byte, error) {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if len(id) < 8 {
+		return nil, nil, fmt.Errorf("the evidence identifier %q is too short", id)
+	}
+
+	all, err := Records(dir, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var full string
+	var recs []*Record
+	for _, rec := range all {
+		if !strings.HasPrefix(rec.ID, id) {
+			continue
+		}
+		if full != "" && rec.ID != full {
+			return nil, nil, fmt.Errorf("the evidence identifier %s is ambiguous", id)
+		}
+		full = rec.ID
+		recs = append(recs, rec)
+	}
+	if full == "" {
+		return nil, nil, fmt.Errorf("the evidence %s was not found", id)
+	}
+
+	data, err := os.ReadFile(blobPath(dir, full))
+	if err != nil {
+		return nil, nil, err
+	}
+	return recs, data, nil
+}
+
+// FromConfig returns the Store writing the evidence to the output directory, once enabled by the
+// 'evidence' section of the configuration options. The 'session_limit' and 'blob_limit' keys provide
+// the limits in megabytes. A nil Store is returned when the evidence has not been enabled.
+func FromConfig(cfg *config.Config) (*Store, error) {
+	evRaw, ok := cfg.Options["evidence"]
+	if !ok {
+		return nil, nil
+	}
+
+	settings, ok := evRaw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("evidence is not a map[string]interface{}")
+	}
+
+	if raw, ok := settings["enabled"]; ok {
+		enabled, ok := raw.(bool)
+		if !ok {
+			return nil, fmt.Errorf("evidence enabled is not a bool")
+		}
+		if !enabled {
+			return nil, nil
+		}
+	}
+
+	sessionLimit, err := sizeLimit(settings, "session_limit", DefaultSessionLimit)
+	if err != nil {
+		return nil, err
+	}
+	blobLimit, err := sizeLimit(settings, "blob_limit", DefaultBlobLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := config.OutputDirectory(cfg.Dir)
+	if dir == "" {
+		return nil, errors.New("the output directory for the evidence could not be determined")
+	}
+	return NewStore(filepath.Join(dir, Dir), sessionLimit, blobLimit)
+}
+
+func sizeLimit(settings map[string]interface{}, key string, def int64) (int64, error) {
+	raw, ok := settings[key]
+	if !ok {
+		return def, nil
+	}
+
+	mb, ok := raw.(int)
+	if !ok || mb < 0 {
+		return 0, fmt.Errorf("evidence %s must be zero or a positive number of megabytes", key)
+	}
+	return int64(mb) << 20, nil
+}
@@ -0,0 +1,104 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package evidence
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func TestStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), Dir)
+	s, err := NewStore(dir, 64, 32)
+	if err != nil {
+		t.Fatalf("NewStore returned an error: %v", err)
+	}
+
+	resp := []byte("HTTP/1.1 200 OK\r\n\r\nhello")
+	id, err := s.Put(HTTPResponse, "https://www.owasp.org", "Amass", "message/http", resp)
+	if err != nil || len(id) != 64 {
+		t.Fatalf("Put returned %q: %v", id, err)
+	}
+	// The same content observed for another asset is recorded without being stored again
+	if again, err := s.Put(HTTPResponse, "https://owasp.org", "Amass", "message/http", resp); err != nil || again != id {
+		t.Errorf("Put returned %q for the same content: %v", again, err)
+	}
+	if _, err := s.Put(Certificate, "www.owasp.org", "Amass", "", bytes.Repeat([]byte("x"), 33)); err != ErrLimit {
+		t.Errorf("Put returned %v for a blob over the limit", err)
+	}
+	if _, err := s.Put(RDAP, "owasp.org", "RDAP", "", bytes.Repeat([]byte("y"), 32)); err != nil {
+		t.Errorf("Put returned an error: %v", err)
+	}
+	if _, err := s.Put(RDAP, "owasp.net", "RDAP", "", bytes.Repeat([]byte("z"), 32)); err != ErrLimit {
+		t.Errorf("Put returned %v for the session over the limit", err)
+	}
+	if stored, used, dropped := s.Stats(); stored != 3 || used != int64(len(resp))+32 || dropped != 2 {
+		t.Errorf("Stats returned %d, %d and %d", stored, used, dropped)
+	}
+
+	s.Reset()
+	if _, err := s.Put(RDAP, "owasp.net", "RDAP", "", bytes.Repeat([]byte("z"), 32)); err != nil {
+		t.Errorf("Put returned %v after the new session started", err)
+	}
+
+	recs, data, err := Read(dir, id[:12])
+	if err != nil || !bytes.Equal(data, resp) || len(recs) != 2 {
+		t.Fatalf("Read returned %d records and %q: %v", len(recs), data, err)
+	}
+	if recs[0].Asset != "https://www.owasp.org" || recs[1].Asset != "https://owasp.org" || recs[0].Size != int64(len(resp)) {
+		t.Errorf("Read returned the records %+v and %+v", recs[0], recs[1])
+	}
+	if _, _, err := Read(dir, "abc"); err == nil {
+		t.Error("Read accepted a short identifier")
+	}
+	if _, _, err := Read(dir, "00000000"); err == nil {
+		t.Error("Read returned missing evidence")
+	}
+
+	if recs, err := Records(dir, "OWASP.org"); err != nil || len(recs) != 1 {
+		t.Errorf("Records returned %d records for the asset: %v", len(recs), err)
+	}
+
+	var nilStore *Store
+	if id, err := nilStore.Put(RDAP, "owasp.org", "RDAP", "", resp); id != "" || err != nil {
+		t.Errorf("The nil Store returned %q: %v", id, err)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Dir = t.TempDir()
+	if s, err := FromConfig(cfg); s != nil || err != nil {
+		t.Errorf("FromConfig returned %v, %v without the evidence section", s, err)
+	}
+
+	cfg.Options["evidence"] = map[string]interface{}{"session_limit": 10}
+	s, err := FromConfig(cfg)
+	if err != nil || s == nil {
+		t.Fatalf("FromConfig did not enable the evidence: %v", err)
+	}
+	if s.sessionLimit != 10<<20 || s.blobLimit != DefaultBlobLimit || s.dir != filepath.Join(cfg.Dir, Dir) {
+		t.Errorf("FromConfig returned the limits %d and %d in %s", s.sessionLimit, s.blobLimit, s.dir)
+	}
+
+	cfg.Options["evidence"] = map[string]interface{}{"enabled": false}
+	if s, err := FromConfig(cfg); s != nil || err != nil {
+		t.Errorf("FromConfig returned %v, %v when disabled", s, err)
+	}
+
+	for _, settings := range []map[string]interface{}{
+		{"enabled": "yes"},
+		{"session_limit": -1},
+		{"blob_limit": "5MB"},
+	} {
+		cfg.Options["evidence"] = settings
+		if _, err := FromConfig(cfg); err == nil {
+			t.Errorf("FromConfig accepted the settings %v", settings)
+		}
+	}
+}
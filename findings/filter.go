@@ -0,0 +1,103 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package findings
+
+import (
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Filter selects the findings matching all of the provided criteria. The zero value matches every finding.
+type Filter struct {
+	// Domains limits the findings to assets within the root domain names
+	Domains []string
+	// Types limits the findings to the listed finding types
+	Types []string
+	// Severity is the minimum severity of the findings
+	Severity Severity
+	// Since limits the findings to those observed at or after the time
+	Since time.Time
+	// Details limits the findings to those providing the details, compared without regard to case
+	Details map[string]string
+}
+
+// Match returns true when the finding satisfies the criteria of the filter.
+func (fl *Filter) Match(f *Finding) bool {
+	if f.Severity < fl.Severity {
+		return false
+	}
+	if !fl.Since.IsZero() && f.Time.Before(fl.Since) {
+		return false
+	}
+	if len(fl.Types) > 0 && !containsFold(fl.Types, f.Type) {
+		return false
+	}
+	for k, v := range fl.Details {
+		if !strings.EqualFold(f.Details[k], strings.TrimSpace(v)) {
+			return false
+		}
+	}
+	if len(fl.Domains) == 0 {
+		return true
+	}
+
+	host := Host(f.Asset)
+	for _, d := range fl.Domains {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// Select returns the findings matching the filter, ordered by decreasing severity and then by asset.
+func Select(all []*Finding, fl *Filter) []*Finding {
+	var results []*Finding
+
+	for _, f := range all {
+		if fl == nil || fl.Match(f) {
+			results = append(results, f)
+		}
+	}
+	Sort(results)
+	return results
+}
+
+// Sort orders the findings by decreasing severity and then by asset.
+func Sort(all []*Finding) {
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].Severity == all[j].Severity {
+			return all[i].Asset < all[j].Asset
+		}
+		return all[i].Severity > all[j].Severity
+	})
+}
+
+// Host returns the lowercase name or address identified by the finding asset, which can be
+// provided as a URL, as a host and port, or as a wildcard name.
+func Host(asset string) string {
+	host := asset
+	if u, err := url.Parse(asset); err == nil && u.Host != "" {
+		host = u.Hostname()
+	} else if h, _, err := net.SplitHostPort(asset); err == nil {
+		host = h
+	}
+
+	host = strings.TrimPrefix(host, "*.")
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,212 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package findings
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Severity ranks the risk of a finding.
+type Severity int
+
+// The severities that can be assigned to a finding.
+const (
+	Info Severity = iota
+	Low
+	Medium
+	High
+	Critical
+)
+
+var severityNames = []string{"info", "low", "medium", "high", "critical"}
+
+func (s Severity) String() string {
+	if s < Info || s > Critical {
+		return "unknown"
+	}
+	return severityNames[s]
+}
+
+// ParseSeverity returns the Severity matching the provided name.
+func ParseSeverity(name string) (Severity, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	for i, n := range severityNames {
+		if n == name {
+			return Severity(i), nil
+		}
+	}
+	return Info, fmt.Errorf("%s is not a valid severity", name)
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (s *Severity) UnmarshalJSON(data []byte) error {
+	var name string
+
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+
+	sev, err := ParseSeverity(name)
+	if err != nil {
+		return err
+	}
+	*s = sev
+	return nil
+}
+
+// Finding is an observation about the security posture of a discovered asset.
+type Finding struct {
+	Type        string    `json:"type"`
+	Asset       string    `json:"asset"`
+	Severity    Severity  `json:"severity"`
+	Description string    `json:"description"`
+	Source      string    `json:"source"`
+	Time        time.Time `json:"time"`
+	// Details holds structured values, such as the registrar of a domain, used by the reports
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// Key returns the value that identifies repeated observations of the same finding.
+func (f *Finding) Key() string {
+	return strings.ToLower(f.Type + "|" + f.Asset)
+}
+
+// Store appends findings to a JSON lines file, ignoring repeated observations.
+// All methods are safe to call on a nil Store, which discards the findings.
+type Store struct {
+	sync.Mutex
+	path string
+	seen map[string]struct{}
+	subs []func(*Finding)
+}
+
+// NewStore returns a Store that persists findings to the provided file.
+func NewStore(path string) (*Store, error) {
+	s := &Store{
+		path: path,
+		seen: make(map[string]struct{}),
+	}
+
+	all, err := s.All()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range all {
+		s.seen[f.Key()] = struct{}{}
+	}
+	return s, nil
+}
+
+// Add persists the finding and returns true if it had not been observed before.
+func (s *Store) Add(f *Finding) (bool, error) {
+	if s == nil {
+		return false, nil
+	}
+	if f.Type == "" || f.Asset == "" {
+		return false, fmt.Errorf("the finding must provide a type and an asset")
+	}
+	if f.Time.IsZero() {
+		f.Time = time.Now()
+	}
+
+	added, err := s.persist(f)
+	if added {
+		s.Lock()
+		subs := s.subs
+		s.Unlock()
+
+		for _, fn := range subs {
+			fn(f)
+		}
+	}
+	return added, err
+}
+
+// Subscribe registers the function to be called with each finding that had not been observed before.
+func (s *Store) Subscribe(fn func(*Finding)) {
+	if s == nil {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	s.subs = append(s.subs, fn)
+}
+
+func (s *Store) persist(f *Finding) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	key := f.Key()
+	if _, found := s.seen[key]; found {
+		return false, nil
+	}
+
+	data, err := json.Marshal(f)
+	if err != nil {
+		return false, err
+	}
+
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return false, fmt.Errorf("failed to open the findings file: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return false, fmt.Errorf("failed to write the finding: %v", err)
+	}
+
+	s.seen[key] = struct{}{}
+	return true, nil
+}
+
+// All returns the findings persisted in the file.
+func (s *Store) All() ([]*Finding, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return ReadFile(s.path)
+}
+
+// ReadFile returns the findings persisted in the provided JSON lines file.
+func ReadFile(path string) ([]*Finding, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to open the findings file: %v", err)
+	}
+	defer file.Close()
+
+	var all []*Finding
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var f Finding
+		if err := json.Unmarshal([]byte(line), &f); err == nil {
+			all = append(all, &f)
+		}
+	}
+	return all, scanner.Err()
+}
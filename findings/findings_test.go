@@ -0,0 +1,116 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package findings
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStoreDeduplication(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "findings.json")
+
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("Failed to create the store: %v", err)
+	}
+
+	f := &Finding{Type: "dns_open_recursion", Asset: "ns1.owasp.org", Severity: Medium}
+	if added, err := s.Add(f); !added || err != nil {
+		t.Fatalf("Failed to add the finding: %v", err)
+	}
+	if added, _ := s.Add(&Finding{Type: "DNS_OPEN_RECURSION", Asset: "ns1.owasp.org"}); added {
+		t.Error("A repeated finding was added")
+	}
+	if _, err := s.Add(&Finding{Type: "missing_asset"}); err == nil {
+		t.Error("A finding without an asset was accepted")
+	}
+
+	// A new store for the same file must remember the earlier findings
+	s2, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen the store: %v", err)
+	}
+	if added, _ := s2.Add(f); added {
+		t.Error("A finding persisted by an earlier store was added again")
+	}
+
+	all, err := s2.All()
+	if err != nil || len(all) != 1 {
+		t.Fatalf("Expected one finding, got %d: %v", len(all), err)
+	}
+	if all[0].Severity != Medium || all[0].Time.IsZero() {
+		t.Errorf("Unexpected finding: %+v", all[0])
+	}
+}
+
+func TestStoreSubscribe(t *testing.T) {
+	s, err := NewStore(filepath.Join(t.TempDir(), "findings.json"))
+	if err != nil {
+		t.Fatalf("Failed to create the store: %v", err)
+	}
+
+	var received []string
+	s.Subscribe(func(f *Finding) { received = append(received, f.Asset) })
+
+	_, _ = s.Add(&Finding{Type: "takeover", Asset: "shop.owasp.org", Severity: Critical})
+	_, _ = s.Add(&Finding{Type: "takeover", Asset: "shop.owasp.org", Severity: Critical})
+	_, _ = s.Add(&Finding{Type: "takeover", Asset: "blog.owasp.org", Severity: Critical})
+	if len(received) != 2 || received[0] != "shop.owasp.org" || received[1] != "blog.owasp.org" {
+		t.Errorf("The subscriber received %v, expected only the new findings", received)
+	}
+
+	var nilStore *Store
+	nilStore.Subscribe(func(f *Finding) {})
+}
+
+func TestParseSeverity(t *testing.T) {
+	for i, name := range []string{"info", "Low", "MEDIUM", "high", " critical "} {
+		if sev, err := ParseSeverity(name); err != nil || sev != Severity(i) {
+			t.Errorf("Failed to parse %q: got %v, %v", name, sev, err)
+		}
+	}
+	if _, err := ParseSeverity("urgent"); err == nil {
+		t.Error("An invalid severity was accepted")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	now := time.Now()
+	all := []*Finding{
+		{Type: "dns_wildcard", Asset: "*.dev.owasp.org", Severity: Low, Time: now},
+		{Type: "dns_zone_transfer", Asset: "owasp.org", Severity: High, Time: now},
+		{Type: "certificate_expired", Asset: "https://www.owasp.org:443", Severity: High, Time: now.Add(-48 * time.Hour)},
+		{Type: "dangling_cname", Asset: "shop.example.com", Severity: Medium, Time: now},
+		{Type: "cloud_asset", Asset: "cdn.example.com", Severity: Info, Time: now,
+			Details: map[string]string{"provider": "AWS", "service": "CLOUDFRONT"}},
+	}
+
+	cases := []struct {
+		filter   *Filter
+		expected []string
+	}{
+		{&Filter{}, []string{"https://www.owasp.org:443", "owasp.org", "shop.example.com", "*.dev.owasp.org", "cdn.example.com"}},
+		{&Filter{Domains: []string{"owasp.org"}}, []string{"https://www.owasp.org:443", "owasp.org", "*.dev.owasp.org"}},
+		{&Filter{Severity: Medium}, []string{"https://www.owasp.org:443", "owasp.org", "shop.example.com"}},
+		{&Filter{Types: []string{"DNS_WILDCARD", "dangling_cname"}}, []string{"shop.example.com", "*.dev.owasp.org"}},
+		{&Filter{Since: now.Add(-time.Hour), Severity: High}, []string{"owasp.org"}},
+		{&Filter{Details: map[string]string{"service": "cloudfront"}}, []string{"cdn.example.com"}},
+		{&Filter{Details: map[string]string{"provider": "AWS", "region": "us-east-1"}}, []string{}},
+	}
+	for i, c := range cases {
+		got := Select(all, c.filter)
+		if len(got) != len(c.expected) {
+			t.Errorf("Case %d: expected %d findings, got %d", i, len(c.expected), len(got))
+			continue
+		}
+		for j, f := range got {
+			if f.Asset != c.expected[j] {
+				t.Errorf("Case %d: expected %s at position %d, got %s", i, c.expected[j], j, f.Asset)
+			}
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"time"
 
 	amassnet "github.com/owasp-amass/amass/v4/net"
 )
@@ -29,6 +30,16 @@ type ParseCIDRs []*net.IPNet
 // ParseASNs implements the flag.Value interface.
 type ParseASNs []int
 
+// ParseDuration implements the flag.Value interface.
+// A plain integer is interpreted as a number of minutes.
+type ParseDuration time.Duration
+
+// ParseTime implements the flag.Value interface.
+// Times are accepted in RFC 3339 format or as a date, and are interpreted as UTC when no zone is provided.
+type ParseTime time.Time
+
+var timeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
 func (p *ParseStrings) String() string {
 	if p == nil {
 		return ""
@@ -206,3 +217,56 @@ func (p *ParseASNs) Set(s string) error {
 	}
 	return nil
 }
+
+func (p *ParseDuration) String() string {
+	if p == nil || *p == 0 {
+		return ""
+	}
+	return time.Duration(*p).String()
+}
+
+// Set implements the flag.Value interface.
+func (p *ParseDuration) Set(s string) error {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return fmt.Errorf("duration parsing failed")
+	}
+
+	if m, err := strconv.Atoi(s); err == nil {
+		if m < 0 {
+			return fmt.Errorf("the duration %s is negative", s)
+		}
+		*p = ParseDuration(time.Duration(m) * time.Minute)
+		return nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	if d < 0 {
+		return fmt.Errorf("the duration %s is negative", s)
+	}
+	*p = ParseDuration(d)
+	return nil
+}
+
+func (p *ParseTime) String() string {
+	if p == nil || time.Time(*p).IsZero() {
+		return ""
+	}
+	return time.Time(*p).Format(time.RFC3339)
+}
+
+// Set implements the flag.Value interface.
+func (p *ParseTime) Set(s string) error {
+	s = strings.TrimSpace(s)
+
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			*p = ParseTime(t.UTC())
+			return nil
+		}
+	}
+	return fmt.Errorf("the time %s is not in RFC 3339 or YYYY-MM-DD format", s)
+}
@@ -364,3 +364,106 @@ func TestParseRange(t *testing.T) {
 		})
 	}
 }
+
+func TestParseDuration(t *testing.T) {
+	cases := []struct {
+		label    string
+		input    string
+		ok       bool
+		expected string
+	}{
+		{
+			label: "Empty_Input",
+			input: "",
+		}, {
+			label:    "Minutes",
+			input:    "30",
+			ok:       true,
+			expected: "30m0s",
+		}, {
+			label:    "Duration",
+			input:    "1h30m",
+			ok:       true,
+			expected: "1h30m0s",
+		}, {
+			label:    "Seconds",
+			input:    " 90s ",
+			ok:       true,
+			expected: "1m30s",
+		}, {
+			label: "Negative",
+			input: "-5m",
+		}, {
+			label: "Invalid",
+			input: "soon",
+		},
+	}
+
+	for _, c := range cases {
+		f := func(t *testing.T) {
+			var d ParseDuration
+
+			if err := d.Set(c.input); err != nil && c.ok {
+				t.Errorf("Got: %v; Expected: <nil>", err)
+			} else if err == nil && !c.ok {
+				t.Error("Got: <nil>; Expected: some error")
+			} else if err == nil && c.ok {
+				if got := d.String(); got != c.expected {
+					t.Errorf("Got: %q; Expected: %q", got, c.expected)
+				}
+			}
+		}
+
+		t.Run(c.label, f)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	cases := []struct {
+		label    string
+		input    string
+		ok       bool
+		expected string
+	}{
+		{
+			label: "Empty_Input",
+			input: "",
+		}, {
+			label:    "Date",
+			input:    "2023-04-01",
+			ok:       true,
+			expected: "2023-04-01T00:00:00Z",
+		}, {
+			label:    "RFC3339",
+			input:    "2023-04-01T10:30:00-04:00",
+			ok:       true,
+			expected: "2023-04-01T14:30:00Z",
+		}, {
+			label:    "Date_Time",
+			input:    " 2023-04-01 10:30:00 ",
+			ok:       true,
+			expected: "2023-04-01T10:30:00Z",
+		}, {
+			label: "Invalid",
+			input: "yesterday",
+		},
+	}
+
+	for _, c := range cases {
+		f := func(t *testing.T) {
+			var p ParseTime
+
+			if err := p.Set(c.input); err != nil && c.ok {
+				t.Errorf("Got: %v; Expected: <nil>", err)
+			} else if err == nil && !c.ok {
+				t.Error("Got: <nil>; Expected: some error")
+			} else if err == nil && c.ok {
+				if got := p.String(); got != c.expected {
+					t.Errorf("Got: %q; Expected: %q", got, c.expected)
+				}
+			}
+		}
+
+		t.Run(c.label, f)
+	}
+}
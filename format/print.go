@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -82,8 +83,125 @@ func PrintEnumerationSummary(total int, asns map[int]*ASNSummaryData, demo bool)
 	FprintEnumerationSummary(color.Error, total, asns, demo)
 }
 
+// The fields used to group the summary information.
+const (
+	// GroupByASN groups the netblocks by the autonomous systems announcing them
+	GroupByASN = "asn"
+	// GroupByProvider groups the netblocks by the cloud or hosting provider operating them
+	GroupByProvider = "provider"
+	// GroupByCountry groups the netblocks by the country where their autonomous systems are registered
+	GroupByCountry = "country"
+)
+
+// GroupSummaryData stores the ASs and netblocks rolled up into a provider or country.
+type GroupSummaryData struct {
+	ASNs      map[int]string
+	Netblocks map[string]int
+}
+
+// UpdateGroupedSummaryData updates the summary maps using the provided requests.Output data, and
+// the group selected for each of the addresses by the key function. Addresses without a group are skipped.
+func UpdateGroupedSummaryData(output *requests.Output, groups map[string]*GroupSummaryData, key func(*requests.Output, requests.AddressInfo) string) {
+	for _, addr := range output.Addresses {
+		if addr.CIDRStr == "" {
+			continue
+		}
+
+		group := key(output, addr)
+		if group == "" {
+			continue
+		}
+
+		data, found := groups[group]
+		if !found {
+			data = &GroupSummaryData{
+				ASNs:      make(map[int]string),
+				Netblocks: make(map[string]int),
+			}
+			groups[group] = data
+		}
+		data.ASNs[addr.ASN] = addr.Description
+		// Increment how many IPs were in this netblock
+		data.Netblocks[addr.CIDRStr]++
+	}
+}
+
 // FprintEnumerationSummary outputs the summary information utilized by the command-line tools.
 func FprintEnumerationSummary(out io.Writer, total int, asns map[int]*ASNSummaryData, demo bool) {
+	if !fprintSummaryHeader(out, total, len(asns) > 0) {
+		return
+	}
+	// Print the ASN and netblock information
+	for asn, data := range asns {
+		asnstr := strconv.Itoa(asn)
+		datastr := data.Name
+
+		if demo && asn > 0 {
+			asnstr = censorString(asnstr, 0, len(asnstr))
+			datastr = censorString(datastr, 0, len(datastr))
+		}
+		fmt.Fprintf(out, "%s%s %s %s\n", blue("ASN: "), yellow(asnstr), green("-"), green(datastr))
+		fprintNetblocks(out, data.Netblocks, demo)
+	}
+}
+
+// FprintGroupedSummary outputs the summary information rolled up into providers or countries,
+// as selected by the groupBy field. The groups are listed by the number of addresses discovered.
+func FprintGroupedSummary(out io.Writer, total int, groupBy string, groups map[string]*GroupSummaryData, demo bool) {
+	if !fprintSummaryHeader(out, total, len(groups) > 0) {
+		return
+	}
+
+	label := "Provider: "
+	if groupBy == GroupByCountry {
+		label = "Country: "
+	}
+
+	addrs := make(map[string]int, len(groups))
+	var names []string
+	for name, data := range groups {
+		names = append(names, name)
+		for _, ips := range data.Netblocks {
+			addrs[name] += ips
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if addrs[names[i]] != addrs[names[j]] {
+			return addrs[names[i]] > addrs[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		data := groups[name]
+
+		var asns []int
+		for asn := range data.ASNs {
+			asns = append(asns, asn)
+		}
+		sort.Ints(asns)
+
+		var list []string
+		for _, asn := range asns {
+			asnstr := strconv.Itoa(asn)
+			if demo && asn > 0 {
+				asnstr = censorString(asnstr, 0, len(asnstr))
+			}
+			list = append(list, asnstr)
+		}
+
+		namestr := name
+		if demo && groupBy == GroupByProvider {
+			namestr = censorString(namestr, 0, len(namestr))
+		}
+		fmt.Fprintf(out, "%s%s %s %s\n", blue(label), yellow(namestr), green("- ASN:"), green(strings.Join(list, ", ")))
+		fprintNetblocks(out, data.Netblocks, demo)
+	}
+}
+
+// fprintSummaryHeader outputs the header of the summary information, followed by the
+// separator of the table when the table is not empty, which is returned.
+func fprintSummaryHeader(out io.Writer, total int, table bool) bool {
 	pad := func(num int, chr string) {
 		for i := 0; i < num; i++ {
 			b.Fprint(out, chr)
@@ -102,35 +220,27 @@ func FprintEnumerationSummary(out io.Writer, total int, asns map[int]*ASNSummary
 	fmt.Fprintf(out, "\n%s%s", yellow(strconv.Itoa(total)), green(" names discovered"))
 	fmt.Fprintln(out)
 
-	if len(asns) == 0 {
-		return
+	if !table {
+		return false
 	}
 	// Another line gets printed
 	pad(8, "----------")
 	fmt.Fprintln(out)
-	// Print the ASN and netblock information
-	for asn, data := range asns {
-		asnstr := strconv.Itoa(asn)
-		datastr := data.Name
-
-		if demo && asn > 0 {
-			asnstr = censorString(asnstr, 0, len(asnstr))
-			datastr = censorString(datastr, 0, len(datastr))
-		}
-		fmt.Fprintf(out, "%s%s %s %s\n", blue("ASN: "), yellow(asnstr), green("-"), green(datastr))
-
-		for cidr, ips := range data.Netblocks {
-			countstr := strconv.Itoa(ips)
-			cidrstr := cidr
+	return true
+}
 
-			if demo {
-				cidrstr = censorNetBlock(cidrstr)
-			}
+func fprintNetblocks(out io.Writer, netblocks map[string]int, demo bool) {
+	for cidr, ips := range netblocks {
+		countstr := strconv.Itoa(ips)
+		cidrstr := cidr
 
-			countstr = fmt.Sprintf("\t%-4s", countstr)
-			cidrstr = fmt.Sprintf("\t%-18s", cidrstr)
-			fmt.Fprintf(out, "%s%s %s\n", yellow(cidrstr), yellow(countstr), blue("Subdomain Name(s)"))
+		if demo {
+			cidrstr = censorNetBlock(cidrstr)
 		}
+
+		countstr = fmt.Sprintf("\t%-4s", countstr)
+		cidrstr = fmt.Sprintf("\t%-18s", cidrstr)
+		fmt.Fprintf(out, "%s%s %s\n", yellow(cidrstr), yellow(countstr), blue("Subdomain Name(s)"))
 	}
 }
 
@@ -0,0 +1,55 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package format
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+func TestGroupedSummary(t *testing.T) {
+	color.NoColor = true
+
+	addr := func(ip, cidr string, asn int, desc string) requests.AddressInfo {
+		return requests.AddressInfo{Address: net.ParseIP(ip), CIDRStr: cidr, ASN: asn, Description: desc}
+	}
+	outputs := []*requests.Output{
+		{Name: "www.owasp.org", Addresses: []requests.AddressInfo{addr("52.1.1.1", "52.0.0.0/11", 16509, "AMAZON-02")}},
+		{Name: "api.owasp.org", Addresses: []requests.AddressInfo{
+			addr("52.1.1.2", "52.0.0.0/11", 16509, "AMAZON-02"),
+			addr("3.5.1.1", "3.5.0.0/16", 14618, "AMAZON-AES"),
+		}},
+		{Name: "mail.owasp.org", Addresses: []requests.AddressInfo{addr("192.0.2.1", "", 0, "")}},
+	}
+
+	providers := map[int]string{16509: "AWS", 14618: "AWS"}
+	groups := make(map[string]*GroupSummaryData)
+	for _, out := range outputs {
+		UpdateGroupedSummaryData(out, groups, func(_ *requests.Output, a requests.AddressInfo) string {
+			return providers[a.ASN]
+		})
+	}
+	if len(groups) != 1 || len(groups["AWS"].ASNs) != 2 || groups["AWS"].Netblocks["52.0.0.0/11"] != 2 {
+		t.Fatalf("Unexpected groups: %+v", groups["AWS"])
+	}
+
+	var buf bytes.Buffer
+	FprintGroupedSummary(&buf, len(outputs), GroupByProvider, groups, false)
+	if s := buf.String(); !strings.Contains(s, "3 names discovered") ||
+		!strings.Contains(s, "Provider: AWS - ASN: 14618, 16509") || !strings.Contains(s, "52.0.0.0/11") {
+		t.Errorf("Unexpected summary:\n%s", s)
+	}
+
+	buf.Reset()
+	FprintGroupedSummary(&buf, len(outputs), GroupByCountry, map[string]*GroupSummaryData{}, false)
+	if s := buf.String(); strings.Contains(s, "Country:") || !strings.Contains(s, "3 names discovered") {
+		t.Errorf("Unexpected summary without groups:\n%s", s)
+	}
+}
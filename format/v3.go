@@ -0,0 +1,101 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package format
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+// DefaultV3Tag is the tag of the names without sources known to the data source scripts.
+const DefaultV3Tag = "dns"
+
+// V3Output is a discovered name in the JSON schema written by Amass v3 with '-json'.
+type V3Output struct {
+	Name      string      `json:"name"`
+	Domain    string      `json:"domain"`
+	Addresses []V3Address `json:"addresses"`
+	Tag       string      `json:"tag"`
+	Sources   []string    `json:"sources"`
+}
+
+// V3Address is an address of the name in the JSON schema written by Amass v3.
+type V3Address struct {
+	IP          string `json:"ip"`
+	CIDR        string `json:"cidr"`
+	ASN         int    `json:"asn"`
+	Description string `json:"desc"`
+}
+
+var (
+	scriptNameRE = regexp.MustCompile(`(?m)^\s*name\s*=\s*"([^"]+)"`)
+	scriptTypeRE = regexp.MustCompile(`(?m)^\s*type\s*=\s*"([^"]+)"`)
+)
+
+// SourceTags returns the types of the data source scripts, keyed by the name of the data source.
+func SourceTags(scripts []string) map[string]string {
+	tags := make(map[string]string, len(scripts))
+
+	for _, script := range scripts {
+		name := scriptNameRE.FindStringSubmatch(script)
+		stype := scriptTypeRE.FindStringSubmatch(script)
+
+		if len(name) == 2 && len(stype) == 2 {
+			tags[name[1]] = stype[1]
+		}
+	}
+	return tags
+}
+
+// NewV3Output returns the legacy record of the output. The tag is the type of the first source
+// found in the tags, and DefaultV3Tag when none of the sources are known.
+func NewV3Output(out *requests.Output, sources []string, tags map[string]string) *V3Output {
+	v3 := &V3Output{
+		Name:      out.Name,
+		Domain:    out.Domain,
+		Addresses: []V3Address{},
+		Tag:       DefaultV3Tag,
+		Sources:   []string{},
+	}
+
+	for _, a := range out.Addresses {
+		cidr := a.CIDRStr
+		if cidr == "" && a.Netblock != nil {
+			cidr = a.Netblock.String()
+		}
+
+		v3.Addresses = append(v3.Addresses, V3Address{
+			IP:          a.Address.String(),
+			CIDR:        cidr,
+			ASN:         a.ASN,
+			Description: a.Description,
+		})
+	}
+
+	v3.Sources = append(v3.Sources, sources...)
+	for _, src := range sources {
+		if t, found := tags[src]; found {
+			v3.Tag = strings.ToLower(t)
+			break
+		}
+	}
+	return v3
+}
+
+// WriteV3JSON writes the records as JSON lines, one for each name, as Amass v3 did with '-json'.
+func WriteV3JSON(w io.Writer, records ...*V3Output) error {
+	enc := json.NewEncoder(w)
+
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package format
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/importer"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+func TestSourceTags(t *testing.T) {
+	tags := SourceTags([]string{
+		"name = \"Crtsh\"\ntype = \"cert\"\n\nfunction start()\nend",
+		"local url = \"https://example.com\"\nname = \"VirusTotal\"\ntype = \"api\"",
+		"name = \"Incomplete\"",
+	})
+
+	if len(tags) != 2 || tags["Crtsh"] != "cert" || tags["VirusTotal"] != "api" {
+		t.Errorf("SourceTags returned unexpected tags: %v", tags)
+	}
+}
+
+func TestWriteV3JSON(t *testing.T) {
+	tags := map[string]string{"Crtsh": "cert", "VirusTotal": "api"}
+	outputs := []*requests.Output{
+		{Name: "www.owasp.org", Domain: "owasp.org", Addresses: []requests.AddressInfo{
+			{Address: net.ParseIP("192.0.2.1"), CIDRStr: "192.0.2.0/24", ASN: 64500, Description: "EXAMPLE"},
+		}},
+		{Name: "dev.owasp.org", Domain: "owasp.org"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteV3JSON(&buf,
+		NewV3Output(outputs[0], []string{"DNS", "Crtsh", "VirusTotal"}, tags),
+		NewV3Output(outputs[1], nil, tags),
+	); err != nil {
+		t.Fatalf("WriteV3JSON returned an error: %v", err)
+	}
+
+	expected := `{"name":"www.owasp.org","domain":"owasp.org","addresses":[{"ip":"192.0.2.1","cidr":"192.0.2.0/24","asn":64500,"desc":"EXAMPLE"}],"tag":"cert","sources":["DNS","Crtsh","VirusTotal"]}
+{"name":"dev.owasp.org","domain":"owasp.org","addresses":[],"tag":"dns","sources":[]}
+`
+	if got := buf.String(); got != expected {
+		t.Errorf("WriteV3JSON wrote:\n%s\nexpected:\n%s", got, expected)
+	}
+
+	// The legacy records are read back by the import of the Amass v3 output
+	res, err := importer.Parse(bytes.NewReader(buf.Bytes()), "")
+	if err != nil {
+		t.Fatalf("Failed to import the legacy records: %v", err)
+	}
+	if res.Format != importer.AmassV3 || len(res.Names()) != 2 || len(res.Infrastructure) != 1 {
+		t.Errorf("The import of the legacy records provided unexpected results: %+v", res)
+	}
+}
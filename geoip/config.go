@@ -0,0 +1,45 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package geoip
+
+import (
+	"fmt"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// FromConfig opens the database selected by the 'path' of the 'geoip' section of the configuration
+// options. A nil DB is returned when the section is missing or the enrichment has not been enabled.
+func FromConfig(cfg *config.Config) (*DB, error) {
+	geoRaw, ok := cfg.Options["geoip"]
+	if !ok {
+		return nil, nil
+	}
+
+	settings, ok := geoRaw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("geoip is not a map[string]interface{}")
+	}
+
+	if raw, ok := settings["enabled"]; ok {
+		enabled, ok := raw.(bool)
+		if !ok {
+			return nil, fmt.Errorf("geoip enabled is not a bool")
+		}
+		if !enabled {
+			return nil, nil
+		}
+	}
+
+	raw, ok := settings["path"]
+	if !ok {
+		return nil, fmt.Errorf("geoip requires the path of the database")
+	}
+	path, ok := raw.(string)
+	if !ok || path == "" {
+		return nil, fmt.Errorf("geoip path is not a string")
+	}
+	return Open(path)
+}
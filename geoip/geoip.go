@@ -0,0 +1,230 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package geoip locates IP addresses using a local MaxMind GeoLite2 database, in the MMDB format,
+// or an IP2Location LITE database in the CSV format.
+package geoip
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Location is the geographical location of an IP address.
+type Location struct {
+	Country     string
+	CountryCode string
+	Region      string
+	City        string
+	Latitude    float64
+	Longitude   float64
+}
+
+// String returns the city, region and country of the location, omitting those that are unknown.
+func (l *Location) String() string {
+	var parts []string
+
+	for _, p := range []string{l.City, l.Region, l.Country} {
+		if p != "" && (len(parts) == 0 || parts[len(parts)-1] != p) {
+			parts = append(parts, p)
+		}
+	}
+	if len(parts) == 0 {
+		return l.CountryCode
+	}
+	return strings.Join(parts, ", ")
+}
+
+// DB locates the IP addresses using the records of a database file.
+type DB struct {
+	// Name is the base name of the database file
+	Name   string
+	mmdb   *maxminddb.Reader
+	ranges []*ipRange
+}
+
+type ipRange struct {
+	first []byte
+	last  []byte
+	loc   *Location
+}
+
+// Open loads the database file at the path. The format is selected by the extension
+// of the file, which is .mmdb for MaxMind databases and .csv for IP2Location databases.
+func Open(path string) (*DB, error) {
+	db := &DB{Name: filepath.Base(path)}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mmdb":
+		r, err := maxminddb.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the GeoIP database %s: %v", path, err)
+		}
+		db.mmdb = r
+	case ".csv":
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the GeoIP database %s: %v", path, err)
+		}
+		defer f.Close()
+
+		if db.ranges, err = readIP2Location(f); err != nil {
+			return nil, fmt.Errorf("failed to read the GeoIP database %s: %v", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("the GeoIP database %s must be a .mmdb or .csv file", path)
+	}
+	return db, nil
+}
+
+// Close releases the database file.
+func (db *DB) Close() error {
+	if db.mmdb != nil {
+		return db.mmdb.Close()
+	}
+	return nil
+}
+
+// Lookup returns the location of the address, or nil when the database does not locate it.
+func (db *DB) Lookup(addr string) *Location {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return nil
+	}
+
+	var loc *Location
+	if db.mmdb != nil {
+		loc = db.lookupMMDB(ip)
+	} else {
+		loc = db.lookupRanges(ip)
+	}
+	if loc == nil || loc.CountryCode == "" {
+		return nil
+	}
+	return loc
+}
+
+// mmdbRecord holds the fields shared by the GeoLite2 City and Country databases.
+type mmdbRecord struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	Location struct {
+		Latitude  float64 `maxminddb:"latitude"`
+		Longitude float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
+}
+
+func (db *DB) lookupMMDB(ip net.IP) *Location {
+	var rec mmdbRecord
+
+	if err := db.mmdb.Lookup(ip, &rec); err != nil {
+		return nil
+	}
+
+	loc := &Location{
+		Country:     rec.Country.Names["en"],
+		CountryCode: rec.Country.ISOCode,
+		City:        rec.City.Names["en"],
+		Latitude:    rec.Location.Latitude,
+		Longitude:   rec.Location.Longitude,
+	}
+	if len(rec.Subdivisions) > 0 {
+		loc.Region = rec.Subdivisions[0].Names["en"]
+	}
+	return loc
+}
+
+func (db *DB) lookupRanges(ip net.IP) *Location {
+	// The IPv4 databases number the addresses from zero, while the
+	// IPv6 databases provide them as IPv4-mapped addresses
+	keys := [][]byte{ip.To16()}
+	if ip4 := ip.To4(); ip4 != nil {
+		keys = append(keys, append(make([]byte, 12), ip4...))
+	}
+
+	for _, key := range keys {
+		i := sort.Search(len(db.ranges), func(i int) bool {
+			return bytes.Compare(db.ranges[i].last, key) >= 0
+		})
+		if i < len(db.ranges) && bytes.Compare(db.ranges[i].first, key) <= 0 && db.ranges[i].loc != nil {
+			return db.ranges[i].loc
+		}
+	}
+	return nil
+}
+
+// readIP2Location reads the rows of the IP2Location LITE databases, starting with the first and last
+// address of each range, as decimal numbers, followed by the country code, the country name and,
+// depending on the database, the region, city, latitude and longitude.
+func readIP2Location(r io.Reader) ([]*ipRange, error) {
+	var ranges []*ipRange
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if len(row) < 4 {
+			return nil, fmt.Errorf("the row %v does not provide a country", row)
+		}
+
+		first, ok1 := decimalIP(row[0])
+		last, ok2 := decimalIP(row[1])
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("the row %v does not start with an address range", row)
+		}
+
+		rng := &ipRange{first: first, last: last}
+		// The ranges that are not allocated are labeled with a hyphen
+		if cc := row[2]; cc != "" && cc != "-" {
+			rng.loc = &Location{CountryCode: cc, Country: row[3]}
+			if len(row) >= 6 {
+				rng.loc.Region = row[4]
+				rng.loc.City = row[5]
+			}
+			if len(row) >= 8 {
+				rng.loc.Latitude, _ = strconv.ParseFloat(row[6], 64)
+				rng.loc.Longitude, _ = strconv.ParseFloat(row[7], 64)
+			}
+		}
+		ranges = append(ranges, rng)
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return bytes.Compare(ranges[i].first, ranges[j].first) < 0
+	})
+	return ranges, nil
+}
+
+// decimalIP returns the 16 bytes of the address provided as a decimal number.
+func decimalIP(s string) ([]byte, bool) {
+	n, ok := new(big.Int).SetString(strings.TrimSpace(s), 10)
+	if !ok || n.Sign() < 0 || n.BitLen() > 128 {
+		return nil, false
+	}
+	return n.FillBytes(make([]byte, 16)), true
+}
@@ -0,0 +1,104 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package geoip
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/owasp-amass/config/config"
+)
+
+const ipv4CSV = `"0","16777215","-","-","-","-","0.000000","0.000000"
+"3221225984","3221226239","DE","Germany","Berlin","Berlin","52.524370","13.410530"
+"3221226240","3221226495","US","United States of America","California","San Jose","37.339390","-121.894960"
+`
+
+const ipv6CSV = `"0","281470681743359","-","-"
+"281473902969344","281473902969599","DE","Germany"
+"42540766411282592856903984951653826560","42540766490510755371168322545197776895","NL","Netherlands"
+`
+
+func writeDB(t *testing.T, name, data string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write the database: %v", err)
+	}
+	return path
+}
+
+func TestLookupIP2Location(t *testing.T) {
+	db, err := Open(writeDB(t, "IP2LOCATION-LITE-DB5.CSV", ipv4CSV))
+	if err != nil {
+		t.Fatalf("Failed to open the database: %v", err)
+	}
+	defer db.Close()
+
+	loc := db.Lookup("192.0.2.10")
+	if loc == nil || loc.CountryCode != "DE" || loc.City != "Berlin" || loc.Latitude != 52.52437 {
+		t.Fatalf("The address was not located correctly: %v", loc)
+	}
+	if s := loc.String(); s != "Berlin, Germany" {
+		t.Errorf("Expected the location Berlin, Germany, got %s", s)
+	}
+	if loc := db.Lookup("192.0.3.1"); loc == nil || loc.Region != "California" {
+		t.Errorf("The address was not located in the second range: %v", loc)
+	}
+	for _, addr := range []string{"0.0.0.1", "198.51.100.1", "bad"} {
+		if loc := db.Lookup(addr); loc != nil {
+			t.Errorf("Expected %s not to be located, got %v", addr, loc)
+		}
+	}
+}
+
+func TestLookupIP2LocationIPv6(t *testing.T) {
+	db, err := Open(writeDB(t, "IP2LOCATION-LITE-DB1.IPV6.CSV", ipv6CSV))
+	if err != nil {
+		t.Fatalf("Failed to open the database: %v", err)
+	}
+	defer db.Close()
+
+	if loc := db.Lookup("192.0.2.1"); loc == nil || loc.CountryCode != "DE" {
+		t.Errorf("The IPv4-mapped address was not located: %v", loc)
+	}
+	if loc := db.Lookup("2001:db8::1"); loc == nil || loc.String() != "Netherlands" {
+		t.Errorf("The IPv6 address was not located: %v", loc)
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	if _, err := Open(writeDB(t, "geo.bin", ipv4CSV)); err == nil {
+		t.Errorf("Expected an error for the unsupported format")
+	}
+	if _, err := Open(writeDB(t, "geo.csv", `"a","b","DE","Germany"`)); err == nil {
+		t.Errorf("Expected an error for the rows without an address range")
+	}
+	if _, err := Open(filepath.Join(t.TempDir(), "missing.mmdb")); err == nil {
+		t.Errorf("Expected an error for the missing database")
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	if db, err := FromConfig(cfg); db != nil || err != nil {
+		t.Errorf("Expected no database without the geoip section")
+	}
+
+	cfg.Options["geoip"] = map[string]interface{}{"enabled": false}
+	if db, err := FromConfig(cfg); db != nil || err != nil {
+		t.Errorf("Expected no database when the enrichment is disabled")
+	}
+
+	cfg.Options["geoip"] = map[string]interface{}{}
+	if _, err := FromConfig(cfg); err == nil {
+		t.Errorf("Expected an error without the path of the database")
+	}
+
+	cfg.Options["geoip"] = map[string]interface{}{"path": writeDB(t, "geo.csv", ipv4CSV)}
+	if db, err := FromConfig(cfg); db == nil || err != nil {
+		t.Errorf("Failed to open the database: %v", err)
+	}
+}
@@ -0,0 +1,269 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/datasrcs/scripting"
+	"github.com/owasp-amass/amass/v4/dnscache"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	// DefaultHTTPSTarget is the location requested to check the outbound HTTPS connectivity.
+	DefaultHTTPSTarget = "https://owasp.org"
+	// DefaultQueryName is the name resolved to check the DNS resolvers.
+	DefaultQueryName = "owasp.org"
+	// The number of resolvers queried at the same time
+	maxResolverQueries = 20
+	// The number of failing resolvers named by the detail of the check
+	maxListedResolvers = 5
+)
+
+// RedisCheck checks the Redis server used by the 'dns_cache' section of the configuration, which
+// is only contacted by the lookups of the enumerations, whose failures are treated as misses.
+func RedisCheck(cfg *config.Config) *Check {
+	return &Check{
+		Component: "redis",
+		Name:      "dns_cache",
+		Run: func(ctx context.Context) (string, error) {
+			if settings, ok := cfg.Options["dns_cache"].(map[string]interface{}); !ok || settings["store"] != "redis" {
+				return "", fmt.Errorf("the dns_cache section does not use a Redis store: %w", ErrSkipped)
+			}
+
+			c, err := dnscache.FromConfig(cfg)
+			if err != nil {
+				return "", err
+			} else if c == nil {
+				return "", fmt.Errorf("the dns_cache section has not been enabled: %w", ErrSkipped)
+			}
+			defer c.Close()
+
+			if err := c.Ping(); err != nil {
+				return "", err
+			}
+			return "the Redis server answered the PING command", nil
+		},
+	}
+}
+
+// ResolverChecks checks the trusted resolvers and the resolvers provided by the configuration.
+func ResolverChecks(cfg *config.Config) []*Check {
+	trusted := config.DefaultBaselineResolvers
+	if len(cfg.TrustedResolvers) > 0 {
+		trusted = cfg.TrustedResolvers
+	}
+
+	untrusted := ResolverCheck("resolvers", cfg.Resolvers, DefaultQueryName)
+	if len(cfg.Resolvers) == 0 {
+		untrusted.Run = func(ctx context.Context) (string, error) {
+			return "", fmt.Errorf("the public resolvers are obtained when the enumeration starts: %w", ErrSkipped)
+		}
+	}
+	return []*Check{ResolverCheck("trusted resolvers", trusted, DefaultQueryName), untrusted}
+}
+
+// ResolverCheck sends a query for the name to each of the resolvers. The check fails when none
+// of the resolvers answered, and warns about the resolvers that did not.
+func ResolverCheck(name string, resolvers []string, qname string) *Check {
+	return &Check{
+		Component: "dns",
+		Name:      name,
+		Run: func(ctx context.Context) (string, error) {
+			if len(resolvers) == 0 {
+				return "", fmt.Errorf("no resolvers were provided: %w", ErrSkipped)
+			}
+
+			failed := queryResolvers(ctx, resolvers, qname)
+			answered := len(resolvers) - len(failed)
+			detail := fmt.Sprintf("%d of %d resolvers answered the query for %s", answered, len(resolvers), qname)
+
+			switch {
+			case answered == 0:
+				return "", errors.New(detail)
+			case len(failed) > 0:
+				list := failed
+				if len(list) > maxListedResolvers {
+					list = list[:maxListedResolvers]
+				}
+				return "", Warnf("%s; %s did not answer", detail, strings.Join(list, ", "))
+			}
+			return detail, nil
+		},
+	}
+}
+
+// queryResolvers returns the resolvers that did not answer the query for the name.
+func queryResolvers(ctx context.Context, resolvers []string, qname string) []string {
+	sem := make(chan struct{}, maxResolverQueries)
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	answered := make(map[string]bool, len(resolvers))
+	for _, addr := range resolvers {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(addr string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			ok := queryResolver(ctx, addr, qname) == nil
+			lock.Lock()
+			answered[addr] = ok
+			lock.Unlock()
+		}(addr)
+	}
+	wg.Wait()
+
+	var failed []string
+	for _, addr := range resolvers {
+		if !answered[addr] {
+			failed = append(failed, addr)
+		}
+	}
+	return failed
+}
+
+func queryResolver(ctx context.Context, addr, qname string) error {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "53")
+	}
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(qname), dns.TypeA)
+
+	client := &dns.Client{Net: "udp"}
+	resp, _, err := client.ExchangeContext(ctx, msg, addr)
+	if err != nil {
+		return err
+	}
+	if resp.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf("the resolver returned %s", dns.RcodeToString[resp.Rcode])
+	}
+	return nil
+}
+
+// HTTPSCheck requests the location to check the outbound HTTPS connectivity. Any response from
+// the server passes the check, since the check is concerned with reaching it.
+func HTTPSCheck(target string, client *http.Client) *Check {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &Check{
+		Component: "network",
+		Name:      "https",
+		Run: func(ctx context.Context) (string, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodHead, target, nil)
+			if err != nil {
+				return "", err
+			}
+
+			resp, err := client.Do(req)
+			if err != nil {
+				return "", err
+			}
+			resp.Body.Close()
+			return fmt.Sprintf("%s returned status %d", target, resp.StatusCode), nil
+		},
+	}
+}
+
+// PluginChecks loads the data source scripts selected by the configuration, and starts each of them
+// to report its initialization. The scripts whose 'check' callback rejected the configuration, such
+// as without an API key, are reported as warnings, and the data sources disabled by the profile or
+// the 'plugins' section are skipped.
+// The scripts write their log messages to the logger of the configuration.
+func PluginChecks(cfg *config.Config) []*Check {
+	scripts, err := cfg.AcquireScripts()
+	if err != nil {
+		return []*Check{failedPlugin("scripts", fmt.Errorf("failed to acquire the data source scripts: %v", err))}
+	}
+
+	// The scripts log the failures also reported by the checks
+	if cfg.Log == nil {
+		cfg.Log = log.New(io.Discard, "", 0)
+	}
+	sys := &systems.SimpleSystem{Cfg: cfg}
+
+	var checks []*Check
+	for i, script := range scripts {
+		s, err := scripting.LoadScript(script, sys)
+		if err != nil {
+			checks = append(checks, failedPlugin(scriptName(script, i), err))
+			continue
+		}
+		if s == nil {
+			checks = append(checks, skippedPlugin(scriptName(script, i)))
+			continue
+		}
+		checks = append(checks, pluginCheck(s))
+	}
+	return checks
+}
+
+func pluginCheck(s *scripting.Script) *Check {
+	return &Check{
+		Component: "plugin",
+		Name:      s.String(),
+		Run: func(ctx context.Context) (string, error) {
+			err := s.Start()
+			defer func() { _ = s.Stop() }()
+
+			if errors.Is(err, scripting.ErrCheckFailed) {
+				return "", Warnf("the configuration was rejected, such as a missing API key")
+			} else if err != nil {
+				return "", err
+			}
+			return "the " + s.SourceType + " data source was initialized", nil
+		},
+	}
+}
+
+func failedPlugin(name string, err error) *Check {
+	return &Check{
+		Component: "plugin",
+		Name:      name,
+		Run: func(ctx context.Context) (string, error) {
+			return "", err
+		},
+	}
+}
+
+func skippedPlugin(name string) *Check {
+	return &Check{
+		Component: "plugin",
+		Name:      name,
+		Run: func(ctx context.Context) (string, error) {
+			return "", fmt.Errorf("the data source has been disabled by the profile or the plugins section: %w", ErrSkipped)
+		},
+	}
+}
+
+// scriptName returns the 'name' global assigned by the script without loading it, or the position
+// of the script when the name cannot be found.
+func scriptName(script string, i int) string {
+	for _, line := range strings.Split(script, "\n") {
+		if k, v, found := strings.Cut(line, "="); found && strings.TrimSpace(k) == "name" {
+			if name := strings.Trim(strings.TrimSpace(v), `"'`); name != "" {
+				return name
+			}
+		}
+	}
+	return "script " + strconv.Itoa(i+1)
+}
@@ -0,0 +1,143 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package health diagnoses the dependencies of the engine, such as the Redis server, the graph
+// database, the DNS resolvers, the outbound HTTPS connectivity and the data source plugins, so
+// the failures that would otherwise only degrade the enumerations are reported up front.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// The statuses of the checks.
+const (
+	StatusOK      = "ok"
+	StatusWarn    = "warn"
+	StatusFail    = "fail"
+	StatusSkipped = "skipped"
+)
+
+// DefaultTimeout is the time allowed for each check when no timeout has been provided.
+const DefaultTimeout = 10 * time.Second
+
+// ErrSkipped is returned by the checks of the components that have not been configured.
+var ErrSkipped = errors.New("not configured")
+
+// Warning is returned by the checks that passed with a problem worth reporting.
+type Warning struct {
+	Msg string
+}
+
+func (w *Warning) Error() string { return w.Msg }
+
+// Warnf returns a Warning with the formatted message.
+func Warnf(format string, a ...interface{}) error {
+	return &Warning{Msg: fmt.Sprintf(format, a...)}
+}
+
+// Check diagnoses a single component. Run returns the details of a successful check, a Warning
+// for a degraded component, an error wrapping ErrSkipped for a component that is not configured,
+// and any other error for a failure.
+type Check struct {
+	Component string
+	Name      string
+	Run       func(ctx context.Context) (string, error)
+}
+
+// Result is the outcome of a check.
+type Result struct {
+	Component string        `json:"component"`
+	Name      string        `json:"name"`
+	Status    string        `json:"status"`
+	Detail    string        `json:"detail,omitempty"`
+	Duration  time.Duration `json:"duration_ns"`
+}
+
+// Run executes the checks concurrently, allowing each of them the timeout, and returns the results
+// in the order of the checks.
+func Run(ctx context.Context, checks []*Check, timeout time.Duration) []*Result {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	results := make([]*Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+
+		go func(i int, c *Check) {
+			defer wg.Done()
+
+			cctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			results[i] = runCheck(cctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+	return results
+}
+
+func runCheck(ctx context.Context, c *Check) *Result {
+	res := &Result{Component: c.Component, Name: c.Name}
+
+	type outcome struct {
+		detail string
+		err    error
+	}
+	done := make(chan outcome, 1)
+
+	start := time.Now()
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- outcome{err: fmt.Errorf("the check panicked: %v", r)}
+			}
+		}()
+
+		detail, err := c.Run(ctx)
+		done <- outcome{detail: detail, err: err}
+	}()
+
+	var out outcome
+	// The checks ignoring the context are abandoned once the timeout elapses
+	select {
+	case out = <-done:
+	case <-ctx.Done():
+		out.err = fmt.Errorf("the check did not finish in time: %v", ctx.Err())
+	}
+	res.Duration = time.Since(start)
+
+	var warn *Warning
+	switch {
+	case out.err == nil:
+		res.Status = StatusOK
+		res.Detail = out.detail
+	case errors.Is(out.err, ErrSkipped):
+		res.Status = StatusSkipped
+		res.Detail = out.err.Error()
+	case errors.As(out.err, &warn):
+		res.Status = StatusWarn
+		res.Detail = warn.Msg
+	default:
+		res.Status = StatusFail
+		res.Detail = out.err.Error()
+	}
+	return res
+}
+
+// Healthy returns true when none of the checks failed.
+func Healthy(results []*Result) bool {
+	for _, r := range results {
+		if r.Status == StatusFail {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,150 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestRun(t *testing.T) {
+	checks := []*Check{
+		{Component: "a", Name: "ok", Run: func(ctx context.Context) (string, error) {
+			time.Sleep(20 * time.Millisecond)
+			return "fine", nil
+		}},
+		{Component: "b", Name: "warn", Run: func(ctx context.Context) (string, error) {
+			return "", Warnf("%d resolvers did not answer", 2)
+		}},
+		{Component: "c", Name: "skipped", Run: func(ctx context.Context) (string, error) {
+			return "", fmt.Errorf("no server: %w", ErrSkipped)
+		}},
+		{Component: "d", Name: "fail", Run: func(ctx context.Context) (string, error) {
+			return "", errors.New("connection refused")
+		}},
+		{Component: "e", Name: "hang", Run: func(ctx context.Context) (string, error) {
+			select {}
+		}},
+		{Component: "f", Name: "panic", Run: func(ctx context.Context) (string, error) {
+			panic("boom")
+		}},
+	}
+
+	results := Run(context.Background(), checks, 100*time.Millisecond)
+	expected := []string{StatusOK, StatusWarn, StatusSkipped, StatusFail, StatusFail, StatusFail}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %d", len(expected), len(results))
+	}
+	for i, res := range results {
+		if res.Name != checks[i].Name {
+			t.Errorf("The result %d belongs to the %s check, expected %s", i, res.Name, checks[i].Name)
+		}
+		if res.Status != expected[i] {
+			t.Errorf("The %s check has the status %s, expected %s", res.Name, res.Status, expected[i])
+		}
+	}
+	if results[0].Detail != "fine" || results[1].Detail != "2 resolvers did not answer" {
+		t.Errorf("Unexpected details: %q and %q", results[0].Detail, results[1].Detail)
+	}
+	if !strings.Contains(results[4].Detail, "did not finish in time") || !strings.Contains(results[5].Detail, "boom") {
+		t.Errorf("Unexpected failures: %q and %q", results[4].Detail, results[5].Detail)
+	}
+
+	if Healthy(results) || !Healthy(results[:3]) {
+		t.Error("Healthy did not consider only the failed checks")
+	}
+}
+
+func TestResolverCheck(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen for the DNS queries: %v", err)
+	}
+
+	srv := &dns.Server{
+		PacketConn: pc,
+		Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+			resp := new(dns.Msg)
+			resp.SetReply(req)
+			_ = w.WriteMsg(resp)
+		}),
+	}
+	go func() { _ = srv.ActivateAndServe() }()
+	defer func() { _ = srv.Shutdown() }()
+
+	// Find a port without a resolver listening
+	dead, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to obtain an unused port: %v", err)
+	}
+	deadAddr := dead.LocalAddr().String()
+	dead.Close()
+
+	addr := pc.LocalAddr().String()
+	tests := []struct {
+		resolvers []string
+		status    string
+	}{
+		{[]string{addr}, StatusOK},
+		{[]string{addr, deadAddr}, StatusWarn},
+		{[]string{deadAddr}, StatusFail},
+		{nil, StatusSkipped},
+	}
+
+	for _, test := range tests {
+		results := Run(context.Background(), []*Check{ResolverCheck("test", test.resolvers, "owasp.org")}, 2*time.Second)
+		if res := results[0]; res.Status != test.status {
+			t.Errorf("The resolvers %v have the status %s, expected %s: %s", test.resolvers, res.Status, test.status, res.Detail)
+		}
+	}
+}
+
+func TestHTTPSCheck(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	results := Run(context.Background(), []*Check{
+		HTTPSCheck(srv.URL, srv.Client()),
+		HTTPSCheck("https://127.0.0.1:1", srv.Client()),
+	}, 2*time.Second)
+	if results[0].Status != StatusOK || !strings.Contains(results[0].Detail, "403") {
+		t.Errorf("The reachable server was not reported: %s %s", results[0].Status, results[0].Detail)
+	}
+	if results[1].Status != StatusFail {
+		t.Errorf("The unreachable server has the status %s", results[1].Status)
+	}
+}
+
+func TestRedisCheckSkipped(t *testing.T) {
+	cfg := config.NewConfig()
+
+	results := Run(context.Background(), []*Check{RedisCheck(cfg)}, time.Second)
+	if results[0].Status != StatusSkipped {
+		t.Errorf("The Redis check has the status %s without a configured server", results[0].Status)
+	}
+}
+
+func TestScriptName(t *testing.T) {
+	script := "local json = require(\"json\")\n\nname = \"Crtsh\"\ntype = \"cert\"\n"
+
+	if got := scriptName(script, 0); got != "Crtsh" {
+		t.Errorf("Expected the Crtsh script name, got %s", got)
+	}
+	if got := scriptName("type = \"cert\"", 4); got != "script 5" {
+		t.Errorf("Expected the position of the unnamed script, got %s", got)
+	}
+}
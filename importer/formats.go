@@ -0,0 +1,306 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/net/portscan"
+)
+
+// The lines written by subfinder with '-oJ'.
+type subfinderLine struct {
+	Host  string `json:"host"`
+	IP    string `json:"ip"`
+	Input string `json:"input"`
+}
+
+// The lines written by dnsx with '-json', holding the answers of each record type queried.
+type dnsxLine struct {
+	Host  string   `json:"host"`
+	A     []string `json:"a"`
+	AAAA  []string `json:"aaaa"`
+	CNAME []string `json:"cname"`
+	NS    []string `json:"ns"`
+	MX    []string `json:"mx"`
+	PTR   []string `json:"ptr"`
+}
+
+// The lines written by massdns with '-o J', holding the response to each query.
+type massdnsLine struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Data   struct {
+		Answers []struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+			Data string `json:"data"`
+		} `json:"answers"`
+	} `json:"data"`
+}
+
+// The lines written by Amass v3 with '-json'.
+type amassV3Line struct {
+	Name      string `json:"name"`
+	Domain    string `json:"domain"`
+	Addresses []struct {
+		IP   string `json:"ip"`
+		CIDR string `json:"cidr"`
+		ASN  int    `json:"asn"`
+		Desc string `json:"desc"`
+	} `json:"addresses"`
+	Tag     string   `json:"tag"`
+	Sources []string `json:"sources"`
+}
+
+// The elements of the XML output written by nmap with '-oX'.
+type nmapRun struct {
+	Hosts []struct {
+		Status struct {
+			State string `xml:"state,attr"`
+		} `xml:"status"`
+		Addresses []struct {
+			Addr     string `xml:"addr,attr"`
+			AddrType string `xml:"addrtype,attr"`
+		} `xml:"address"`
+		Hostnames []struct {
+			Name string `xml:"name,attr"`
+			Type string `xml:"type,attr"`
+		} `xml:"hostnames>hostname"`
+		Ports []struct {
+			Protocol string `xml:"protocol,attr"`
+			PortID   int    `xml:"portid,attr"`
+			State    struct {
+				State string `xml:"state,attr"`
+			} `xml:"state"`
+			Service struct {
+				Name string `xml:"name,attr"`
+			} `xml:"service"`
+		} `xml:"ports>port"`
+	} `xml:"host"`
+}
+
+// eachLine calls fn with the lines of the output that are not empty.
+func eachLine(data []byte, fn func(int, []byte) error) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var num int
+	for scanner.Scan() {
+		num++
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			if err := fn(num, line); err != nil {
+				return err
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+func parseSubfinder(data []byte, res *Result) error {
+	return eachLine(data, func(num int, line []byte) error {
+		// The text output provides one name on each line
+		if line[0] != '{' {
+			res.addRecord(string(line), 0, "")
+			return nil
+		}
+
+		var l subfinderLine
+		if err := json.Unmarshal(line, &l); err != nil {
+			return fmt.Errorf("failed to parse line %d of the subfinder output: %v", num, err)
+		}
+		if l.IP != "" {
+			res.addAddress(l.Host, l.IP)
+		} else {
+			res.addRecord(l.Host, 0, "")
+		}
+		return nil
+	})
+}
+
+func parseDNSX(data []byte, res *Result) error {
+	return eachLine(data, func(num int, line []byte) error {
+		var l dnsxLine
+		if err := json.Unmarshal(line, &l); err != nil {
+			return fmt.Errorf("failed to parse line %d of the dnsx output: %v", num, err)
+		}
+
+		n := len(res.Records)
+		for _, addr := range append(l.A, l.AAAA...) {
+			res.addAddress(l.Host, addr)
+		}
+		for _, v := range l.CNAME {
+			res.addRecord(l.Host, dns.TypeCNAME, v)
+		}
+		for _, v := range l.NS {
+			res.addRecord(l.Host, dns.TypeNS, v)
+		}
+		for _, v := range l.MX {
+			res.addRecord(l.Host, dns.TypeMX, v)
+		}
+		// The PTR answers belong to the reverse name of the queried address
+		if ip := parseIP(l.Host); ip != "" {
+			if rev, err := dns.ReverseAddr(ip); err == nil {
+				for _, v := range l.PTR {
+					res.addRecord(rev, dns.TypePTR, v)
+				}
+			}
+		} else if len(res.Records) == n {
+			res.addRecord(l.Host, 0, "")
+		}
+		return nil
+	})
+}
+
+func parseMassDNS(data []byte, res *Result) error {
+	return eachLine(data, func(num int, line []byte) error {
+		if line[0] != '{' {
+			// The simple output provides the name, type and data of each answer
+			fields := strings.Fields(string(line))
+			if len(fields) < 3 {
+				return fmt.Errorf("failed to parse line %d of the massdns output", num)
+			}
+			addAnswer(res, fields[0], fields[1], strings.Join(fields[2:], " "))
+			return nil
+		}
+
+		var l massdnsLine
+		if err := json.Unmarshal(line, &l); err != nil {
+			return fmt.Errorf("failed to parse line %d of the massdns output: %v", num, err)
+		}
+		if l.Status != "" && l.Status != "NOERROR" {
+			return nil
+		}
+		for _, a := range l.Data.Answers {
+			addAnswer(res, a.Name, a.Type, a.Data)
+		}
+		return nil
+	})
+}
+
+func addAnswer(res *Result, name, rrtype, data string) {
+	t, found := dns.StringToType[strings.ToUpper(rrtype)]
+	if !found {
+		return
+	}
+
+	switch t {
+	case dns.TypeA, dns.TypeAAAA:
+		res.addAddress(name, data)
+	case dns.TypeMX:
+		// The preference precedes the name of the mail exchange
+		fields := strings.Fields(data)
+		if len(fields) > 0 {
+			res.addRecord(name, t, fields[len(fields)-1])
+		}
+	case dns.TypeCNAME, dns.TypeNS, dns.TypePTR:
+		res.addRecord(name, t, data)
+	}
+}
+
+func parseAmassV3(data []byte, res *Result) error {
+	return eachLine(data, func(num int, line []byte) error {
+		// The text output provides one name on each line, optionally followed by the addresses
+		if line[0] != '{' {
+			fields := strings.Fields(string(line))
+			if len(fields) == 1 {
+				res.addRecord(fields[0], 0, "")
+			}
+			for _, addr := range fields[1:] {
+				for _, a := range strings.Split(addr, ",") {
+					res.addAddress(fields[0], a)
+				}
+			}
+			return nil
+		}
+
+		var l amassV3Line
+		if err := json.Unmarshal(line, &l); err != nil {
+			return fmt.Errorf("failed to parse line %d of the Amass v3 output: %v", num, err)
+		}
+		if len(l.Addresses) == 0 {
+			res.addRecord(l.Name, 0, "")
+		}
+		for _, a := range l.Addresses {
+			res.addAddress(l.Name, a.IP)
+
+			ip := parseIP(a.IP)
+			if _, ipnet, err := net.ParseCIDR(a.CIDR); err == nil && ip != "" && a.ASN > 0 {
+				res.Infrastructure = append(res.Infrastructure, &Infrastructure{
+					Address:     ip,
+					CIDR:        ipnet.String(),
+					ASN:         a.ASN,
+					Description: a.Desc,
+				})
+			}
+		}
+		return nil
+	})
+}
+
+func parseNmap(data []byte, res *Result) error {
+	var run nmapRun
+	if err := xml.Unmarshal(data, &run); err != nil {
+		return fmt.Errorf("failed to parse the nmap output: %v", err)
+	}
+
+	for _, h := range run.Hosts {
+		if h.Status.State != "" && h.Status.State != "up" {
+			continue
+		}
+
+		var addrs []string
+		for _, a := range h.Addresses {
+			if a.AddrType == "ipv4" || a.AddrType == "ipv6" {
+				if ip := parseIP(a.Addr); ip != "" {
+					addrs = append(addrs, ip)
+				}
+			}
+		}
+
+		for _, hn := range h.Hostnames {
+			for _, addr := range addrs {
+				// The names found by the reverse lookups are not resolved by nmap
+				if hn.Type == "PTR" {
+					if rev, err := dns.ReverseAddr(addr); err == nil {
+						res.addRecord(rev, dns.TypePTR, hn.Name)
+					}
+					continue
+				}
+				res.addAddress(hn.Name, addr)
+			}
+		}
+
+		for _, p := range h.Ports {
+			if p.State.State != "open" {
+				continue
+			}
+			for _, addr := range addrs {
+				res.Services = append(res.Services, &portscan.Service{
+					Address:  addr,
+					Port:     p.PortID,
+					Protocol: p.Protocol,
+					Name:     p.Service.Name,
+					Source:   Source,
+				})
+			}
+		}
+	}
+	return nil
+}
+
+func parseIP(addr string) string {
+	if ip := net.ParseIP(strings.TrimSpace(addr)); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
@@ -0,0 +1,82 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package importer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/caffix/netmap"
+	"github.com/miekg/dns"
+)
+
+// Stats counts the assets stored by an import.
+type Stats struct {
+	Names     int `json:"names"`
+	Records   int `json:"records"`
+	Netblocks int `json:"netblocks"`
+	Skipped   int `json:"skipped"`
+}
+
+// Store maps the records and routing information of the result to the assets and relations of the
+// graph database. The records of the names rejected by the filter are skipped, and a nil filter
+// accepts all the names.
+func Store(ctx context.Context, g *netmap.Graph, res *Result, filter func(string) bool) (*Stats, error) {
+	stats := new(Stats)
+	names := make(map[string]struct{})
+
+	for _, rec := range res.Records {
+		name := rec.Name
+		// The reverse names are kept when the name of the host is accepted
+		if rec.Type == dns.TypePTR {
+			name = rec.Data
+		}
+		if filter != nil && !filter(name) {
+			stats.Skipped++
+			continue
+		}
+
+		if err := storeRecord(ctx, g, rec); err != nil {
+			return stats, fmt.Errorf("failed to store the %s record of %s: %v", dns.TypeToString[rec.Type], rec.Name, err)
+		}
+		if _, found := names[name]; !found {
+			names[name] = struct{}{}
+			stats.Names++
+		}
+		if rec.Type != 0 {
+			stats.Records++
+		}
+	}
+
+	for _, infra := range res.Infrastructure {
+		if err := g.UpsertInfrastructure(ctx, infra.ASN, infra.Description, infra.Address, infra.CIDR); err != nil {
+			return stats, fmt.Errorf("failed to store the netblock %s: %v", infra.CIDR, err)
+		}
+		stats.Netblocks++
+	}
+	return stats, nil
+}
+
+func storeRecord(ctx context.Context, g *netmap.Graph, rec *Record) error {
+	var err error
+
+	switch rec.Type {
+	case dns.TypeA:
+		err = g.UpsertA(ctx, rec.Name, rec.Data)
+	case dns.TypeAAAA:
+		err = g.UpsertAAAA(ctx, rec.Name, rec.Data)
+	case dns.TypeCNAME:
+		err = g.UpsertCNAME(ctx, rec.Name, rec.Data)
+	case dns.TypeNS:
+		err = g.UpsertNS(ctx, rec.Name, rec.Data)
+	case dns.TypeMX:
+		err = g.UpsertMX(ctx, rec.Name, rec.Data)
+	case dns.TypePTR:
+		err = g.UpsertPTR(ctx, rec.Name, rec.Data)
+	default:
+		_, err = g.UpsertFQDN(ctx, rec.Name)
+	}
+	return err
+}
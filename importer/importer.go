@@ -0,0 +1,232 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package importer reads the output of other reconnaissance tools, such as subfinder, massdns, dnsx,
+// nmap and earlier Amass releases, so the names, DNS records and services they discovered can seed
+// the graph database used by the following enumerations.
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/net/portscan"
+)
+
+// Source is the data source attributed to the imported assets.
+const Source = "import"
+
+// Format identifies the tool that produced the imported output.
+type Format string
+
+// The formats of the imported output.
+const (
+	// Subfinder is the JSON lines output written by subfinder with '-oJ'
+	Subfinder Format = "subfinder"
+	// MassDNS is the output written by massdns with '-o S' or '-o J'
+	MassDNS Format = "massdns"
+	// DNSX is the JSON lines output written by dnsx with '-json'
+	DNSX Format = "dnsx"
+	// Nmap is the XML output written by nmap with '-oX'
+	Nmap Format = "nmap"
+	// AmassV3 is the JSON lines output written by Amass v3 with '-json'
+	AmassV3 Format = "amass3"
+)
+
+// Formats lists the supported formats.
+var Formats = []Format{Subfinder, MassDNS, DNSX, Nmap, AmassV3}
+
+// ParseFormat returns the Format matching the provided name.
+func ParseFormat(name string) (Format, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	for _, f := range Formats {
+		if string(f) == name {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("%s is not a supported import format", name)
+}
+
+// Record is a DNS record observed by the tool. Names observed without records have the type zero.
+type Record struct {
+	Name string
+	Type uint16
+	Data string
+}
+
+// Infrastructure is the routing information of an address, provided by the Amass v3 output.
+type Infrastructure struct {
+	Address     string
+	CIDR        string
+	ASN         int
+	Description string
+}
+
+// Result holds the assets found in the imported output.
+type Result struct {
+	Format         Format
+	Records        []*Record
+	Infrastructure []*Infrastructure
+	Services       []*portscan.Service
+}
+
+// Names returns the distinct names of the records, in the order they were found.
+func (r *Result) Names() []string {
+	seen := make(map[string]struct{})
+
+	var names []string
+	for _, rec := range r.Records {
+		if _, found := seen[rec.Name]; !found {
+			seen[rec.Name] = struct{}{}
+			names = append(names, rec.Name)
+		}
+	}
+	return names
+}
+
+func (r *Result) addRecord(name string, rrtype uint16, data string) {
+	name = cleanName(name)
+	if name == "" {
+		return
+	}
+
+	switch rrtype {
+	case dns.TypeCNAME, dns.TypeNS, dns.TypeMX, dns.TypePTR:
+		data = cleanName(data)
+	default:
+		data = strings.TrimSpace(data)
+	}
+	if rrtype != 0 && data == "" {
+		return
+	}
+	r.Records = append(r.Records, &Record{Name: name, Type: rrtype, Data: data})
+}
+
+// addAddress adds the A or AAAA record matching the version of the address.
+func (r *Result) addAddress(name, addr string) {
+	if ip := parseIP(addr); ip == "" {
+		return
+	} else if strings.Contains(ip, ":") {
+		r.addRecord(name, dns.TypeAAAA, ip)
+	} else {
+		r.addRecord(name, dns.TypeA, ip)
+	}
+}
+
+// ReadFile returns the assets found in the output file, detecting the format when it is empty.
+func ReadFile(path string, format Format) (*Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Parse(f, format)
+}
+
+// Parse returns the assets found in the output, detecting the format when it is empty.
+func Parse(r io.Reader, format Format) (*Result, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	data = bytes.TrimSpace(data)
+	if format == "" {
+		if format, err = Detect(data); err != nil {
+			return nil, err
+		}
+	}
+
+	res := &Result{Format: format}
+	switch format {
+	case Subfinder:
+		err = parseSubfinder(data, res)
+	case MassDNS:
+		err = parseMassDNS(data, res)
+	case DNSX:
+		err = parseDNSX(data, res)
+	case Nmap:
+		err = parseNmap(data, res)
+	case AmassV3:
+		err = parseAmassV3(data, res)
+	default:
+		err = fmt.Errorf("%s is not a supported import format", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Detect identifies the format of the output from the first entry.
+func Detect(data []byte) (Format, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return "", fmt.Errorf("the output is empty")
+	}
+	if data[0] == '<' {
+		return Nmap, nil
+	}
+
+	line := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		line = bytes.TrimSpace(data[:i])
+	}
+	if line[0] != '{' {
+		fields := strings.Fields(string(line))
+		// The simple output of massdns provides the name, type and data of each record
+		if len(fields) >= 3 {
+			if _, found := dns.StringToType[strings.ToUpper(fields[1])]; found {
+				return MassDNS, nil
+			}
+		}
+		// The text output of Amass v3 provides the addresses following the names
+		if len(fields) == 2 {
+			return AmassV3, nil
+		}
+		// A list of names, such as the text output of subfinder
+		if len(fields) == 1 {
+			return Subfinder, nil
+		}
+		return "", fmt.Errorf("the format of the output was not recognized")
+	}
+
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(line, &keys); err != nil {
+		return "", fmt.Errorf("the format of the output was not recognized: %v", err)
+	}
+
+	has := func(key string) bool {
+		_, found := keys[key]
+		return found
+	}
+	switch {
+	case has("addresses") || has("tag"):
+		return AmassV3, nil
+	case has("name") && has("data"):
+		return MassDNS, nil
+	case has("host") && (has("a") || has("aaaa") || has("cname") || has("status_code") || has("resolver")):
+		return DNSX, nil
+	case has("host"):
+		return Subfinder, nil
+	}
+	return "", fmt.Errorf("the format of the output was not recognized")
+}
+
+func cleanName(name string) string {
+	name = strings.ToLower(strings.Trim(strings.TrimSpace(name), "."))
+	// Wildcard names reported by the certificates are not names of hosts
+	name = strings.TrimPrefix(name, "*.")
+	if name == "" || strings.ContainsAny(name, " /:@") {
+		return ""
+	}
+	return name
+}
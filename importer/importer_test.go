@@ -0,0 +1,185 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package importer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/miekg/dns"
+)
+
+const nmapOutput = `<?xml version="1.0"?>
+<nmaprun scanner="nmap">
+<host><status state="up"/>
+<address addr="192.0.2.10" addrtype="ipv4"/>
+<address addr="00:11:22:33:44:55" addrtype="mac"/>
+<hostnames>
+<hostname name="www.example.com" type="user"/>
+<hostname name="web01.example.com" type="PTR"/>
+</hostnames>
+<ports>
+<port protocol="tcp" portid="443"><state state="open"/><service name="https"/></port>
+<port protocol="tcp" portid="8080"><state state="closed"/></port>
+</ports>
+</host>
+<host><status state="down"/><address addr="192.0.2.11" addrtype="ipv4"/></host>
+</nmaprun>`
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		output string
+		want   Format
+	}{
+		{`{"host":"www.example.com","input":"example.com","source":"crtsh"}`, Subfinder},
+		{"www.example.com\nmail.example.com", Subfinder},
+		{`{"host":"www.example.com","a":["192.0.2.1"],"status_code":"NOERROR"}`, DNSX},
+		{"www.example.com. A 192.0.2.1", MassDNS},
+		{`{"name":"www.example.com.","type":"A","status":"NOERROR","data":{}}`, MassDNS},
+		{`{"name":"www.example.com","domain":"example.com","addresses":[],"tag":"cert","sources":["Crtsh"]}`, AmassV3},
+		{"www.example.com 192.0.2.1,192.0.2.2", AmassV3},
+		{nmapOutput, Nmap},
+	}
+
+	for _, test := range tests {
+		if got, err := Detect([]byte(test.output)); err != nil || got != test.want {
+			t.Errorf("Detect returned %q and %v for %q, expected %q", got, err, test.output, test.want)
+		}
+	}
+	if _, err := Detect([]byte("  ")); err == nil {
+		t.Error("Detect did not return an error for empty output")
+	}
+}
+
+func TestParseFormats(t *testing.T) {
+	tests := []struct {
+		format Format
+		output string
+		want   []Record
+	}{
+		{Subfinder, `{"host":"WWW.example.com","input":"example.com","source":"crtsh"}
+{"host":"*.dev.example.com","ip":"192.0.2.5","input":"example.com"}`, []Record{
+			{Name: "www.example.com"},
+			{Name: "dev.example.com", Type: dns.TypeA, Data: "192.0.2.5"},
+		}},
+		{DNSX, `{"host":"www.example.com","a":["192.0.2.1"],"aaaa":["2001:db8::1"],"cname":["cdn.example.net"]}
+{"host":"192.0.2.1","ptr":["web01.example.com"]}
+{"host":"empty.example.com","status_code":"NOERROR"}`, []Record{
+			{Name: "www.example.com", Type: dns.TypeA, Data: "192.0.2.1"},
+			{Name: "www.example.com", Type: dns.TypeAAAA, Data: "2001:db8::1"},
+			{Name: "www.example.com", Type: dns.TypeCNAME, Data: "cdn.example.net"},
+			{Name: "1.2.0.192.in-addr.arpa", Type: dns.TypePTR, Data: "web01.example.com"},
+			{Name: "empty.example.com"},
+		}},
+		{MassDNS, `www.example.com. CNAME cdn.example.net.
+cdn.example.net. A 192.0.2.1
+example.com. MX 10 mail.example.com.
+example.com. TXT "v=spf1 -all"`, []Record{
+			{Name: "www.example.com", Type: dns.TypeCNAME, Data: "cdn.example.net"},
+			{Name: "cdn.example.net", Type: dns.TypeA, Data: "192.0.2.1"},
+			{Name: "example.com", Type: dns.TypeMX, Data: "mail.example.com"},
+		}},
+		{MassDNS, `{"name":"www.example.com.","type":"A","status":"NOERROR","data":{"answers":[{"ttl":300,"type":"A","name":"www.example.com.","data":"192.0.2.1"}]}}
+{"name":"nx.example.com.","type":"A","status":"NXDOMAIN","data":{}}`, []Record{
+			{Name: "www.example.com", Type: dns.TypeA, Data: "192.0.2.1"},
+		}},
+		{AmassV3, `{"name":"www.example.com","domain":"example.com","addresses":[{"ip":"192.0.2.1","cidr":"192.0.2.0/24","asn":64500,"desc":"EXAMPLE"}],"tag":"cert","sources":["Crtsh"]}
+{"name":"dev.example.com","domain":"example.com","addresses":[],"tag":"api","sources":["VirusTotal"]}`, []Record{
+			{Name: "www.example.com", Type: dns.TypeA, Data: "192.0.2.1"},
+			{Name: "dev.example.com"},
+		}},
+		{Nmap, nmapOutput, []Record{
+			{Name: "www.example.com", Type: dns.TypeA, Data: "192.0.2.10"},
+			{Name: "10.2.0.192.in-addr.arpa", Type: dns.TypePTR, Data: "web01.example.com"},
+		}},
+	}
+
+	for _, test := range tests {
+		res, err := Parse(strings.NewReader(test.output), test.format)
+		if err != nil {
+			t.Errorf("Failed to parse the %s output: %v", test.format, err)
+			continue
+		}
+		if len(res.Records) != len(test.want) {
+			t.Errorf("The %s output provided %d records, expected %d", test.format, len(res.Records), len(test.want))
+			continue
+		}
+		for i, rec := range res.Records {
+			if *rec != test.want[i] {
+				t.Errorf("The %s output provided the record %+v, expected %+v", test.format, *rec, test.want[i])
+			}
+		}
+	}
+}
+
+func TestParseDetails(t *testing.T) {
+	res, err := Parse(strings.NewReader(nmapOutput), "")
+	if err != nil {
+		t.Fatalf("Failed to parse the nmap output: %v", err)
+	}
+	if len(res.Services) != 1 {
+		t.Fatalf("The nmap output provided %d services, expected 1", len(res.Services))
+	}
+	if svc := res.Services[0]; svc.Key() != "192.0.2.10:443/tcp" || svc.Name != "https" || svc.Source != Source {
+		t.Errorf("The nmap output provided an unexpected service: %+v", svc)
+	}
+
+	res, err = Parse(strings.NewReader(`{"name":"www.example.com","addresses":[{"ip":"192.0.2.1","cidr":"192.0.2.0/24","asn":64500,"desc":"EXAMPLE"}],"tag":"cert"}`), AmassV3)
+	if err != nil {
+		t.Fatalf("Failed to parse the Amass v3 output: %v", err)
+	}
+	if len(res.Infrastructure) != 1 || res.Infrastructure[0].ASN != 64500 || res.Infrastructure[0].CIDR != "192.0.2.0/24" {
+		t.Errorf("The Amass v3 output provided unexpected infrastructure: %+v", res.Infrastructure)
+	}
+
+	if _, err := Parse(strings.NewReader("{not json"), DNSX); err == nil {
+		t.Error("Parse did not return an error for malformed output")
+	}
+	if _, err := ParseFormat("httpx"); err == nil {
+		t.Error("ParseFormat did not return an error for an unsupported format")
+	}
+}
+
+func TestStore(t *testing.T) {
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	res, err := Parse(strings.NewReader(`www.example.com. A 192.0.2.1
+www.example.com. AAAA 2001:db8::1
+api.example.com. CNAME www.example.com.
+www.other.com. A 192.0.2.2`), MassDNS)
+	if err != nil {
+		t.Fatalf("Failed to parse the massdns output: %v", err)
+	}
+
+	stats, err := Store(context.Background(), g, res, func(name string) bool {
+		return strings.HasSuffix(name, "example.com")
+	})
+	if err != nil {
+		t.Fatalf("Store returned an error: %v", err)
+	}
+	if stats.Names != 2 || stats.Records != 3 || stats.Skipped != 1 {
+		t.Errorf("Store returned unexpected statistics: %+v", stats)
+	}
+
+	pairs, err := g.NamesToAddrs(context.Background(), time.Time{}, "www.example.com", "www.other.com")
+	if err != nil {
+		t.Fatalf("Failed to read the addresses of the names: %v", err)
+	}
+	if len(pairs) != 2 {
+		t.Errorf("The graph provided %d addresses for the imported names, expected 2", len(pairs))
+	}
+	for _, p := range pairs {
+		if p.FQDN.Name != "www.example.com" {
+			t.Errorf("The name %s rejected by the filter was stored", p.FQDN.Name)
+		}
+	}
+	if !g.IsCNAMENode(context.Background(), "api.example.com", time.Time{}) {
+		t.Error("The CNAME record was not stored")
+	}
+}
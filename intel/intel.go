@@ -81,6 +81,8 @@ func (c *Collection) HostedDomains(ctx context.Context) error {
 	var cancel context.CancelFunc
 	c.ctx, cancel = context.WithCancel(ctx)
 	defer cancel()
+	// The data source callbacks are canceled along with the collection
+	datasrcs.BindSession(c.ctx, c.srcs)
 
 	var stages []pipeline.Stage
 	stages = append(stages, pipeline.DynamicPool("", c.makeDNSTaskFunc(), maxDnsPipelineTasks))
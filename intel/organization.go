@@ -0,0 +1,167 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package intel
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caffix/service"
+	"github.com/owasp-amass/amass/v4/datasrcs"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+// orgSearchIdle is the time without routing data from the data sources after which the search for the
+// autonomous systems of an organization is abandoned, when the data sources have not all completed it.
+var orgSearchIdle = 30 * time.Second
+
+// OrganizationASNs searches the RDAP, ASN and BGP data sources for the autonomous systems attributed
+// to the organization, along with the netblocks they announce. The ASN cache is updated with the
+// results, which are also stored in the graph databases as AutonomousSystem and Netblock assets, so
+// later collections and enumerations can expand on them. The autonomous systems already in the cache
+// with a matching description are included, and the results are sorted by AS number.
+func (c *Collection) OrganizationASNs(ctx context.Context, org string) ([]*requests.ASNRequest, error) {
+	org = strings.TrimSpace(org)
+	if org == "" {
+		return nil, errors.New("no organization name was provided")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// The data source callbacks are canceled along with the search
+	datasrcs.BindSession(ctx, c.srcs)
+
+	var searches int
+	var wg sync.WaitGroup
+	routes := make(chan *requests.RoutingRequest, 100)
+	finished := make(chan struct{}, len(c.srcs))
+	for _, src := range c.srcs {
+		req := &requests.OrganizationRequest{Name: org, Done: make(chan struct{})}
+		if !src.HandlesReq(req) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-src.Done():
+		case src.Input() <- req:
+			searches++
+			wg.Add(1)
+			go c.collectRoutes(ctx, src, req.Done, routes, finished, &wg)
+		}
+	}
+
+	found := make(map[int]struct{})
+	t := time.NewTimer(orgSearchIdle)
+	defer t.Stop()
+loop:
+	for searches > 0 {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-t.C:
+			break loop
+		case <-finished:
+			searches--
+		case r := <-routes:
+			found[r.ASN] = struct{}{}
+			c.storeRoutes(ctx, r)
+
+			if !t.Stop() {
+				<-t.C
+			}
+			t.Reset(orgSearchIdle)
+		}
+	}
+	cancel()
+	wg.Wait()
+
+	for _, entry := range c.Sys.Cache().DescriptionSearch(org) {
+		found[entry.ASN] = struct{}{}
+	}
+
+	var results []*requests.ASNRequest
+	for asn := range found {
+		if entry := c.Sys.Cache().ASNSearch(asn); entry != nil {
+			results = append(results, entry)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ASN < results[j].ASN
+	})
+	return results, nil
+}
+
+// collectRoutes forwards the routing data sent by the data source until the source has handled the
+// search request, and then signals that the search of the source is complete.
+func (c *Collection) collectRoutes(ctx context.Context, src service.Service, done <-chan struct{},
+	routes chan<- *requests.RoutingRequest, finished chan<- struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	forward := func(out interface{}) bool {
+		if r, ok := out.(*requests.RoutingRequest); ok && r.Valid() {
+			select {
+			case <-ctx.Done():
+				return false
+			case routes <- r:
+			}
+		}
+		return true
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-src.Done():
+			return
+		case out := <-src.Output():
+			if !forward(out) {
+				return
+			}
+		case <-done:
+			// The routing data is sent before the callback returns, so what remains is already buffered
+		drain:
+			for {
+				select {
+				case out := <-src.Output():
+					if !forward(out) {
+						return
+					}
+				default:
+					break drain
+				}
+			}
+			finished <- struct{}{}
+			return
+		}
+	}
+}
+
+// storeRoutes saves the autonomous system and the netblocks it announces in the graph databases.
+func (c *Collection) storeRoutes(ctx context.Context, req *requests.RoutingRequest) {
+	for _, g := range c.Sys.GraphDatabases() {
+		as, err := g.UpsertAS(ctx, req.ASN, req.Description)
+		if err != nil {
+			c.Config.Log.Printf("%s: failed to store AS%d: %v", req.Source, req.ASN, err)
+			continue
+		}
+
+		for _, prefix := range req.Prefixes {
+			netblock, err := g.UpsertNetblock(ctx, prefix)
+			if err != nil {
+				c.Config.Log.Printf("%s: failed to store the netblock %s: %v", req.Source, prefix, err)
+				continue
+			}
+			if _, err := g.DB.Create(as, "announces", netblock.Asset); err != nil {
+				c.Config.Log.Printf("%s: failed to store the announcement of %s: %v", req.Source, prefix, err)
+			}
+		}
+	}
+}
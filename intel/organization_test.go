@@ -0,0 +1,152 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package intel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/caffix/service"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	oam "github.com/owasp-amass/open-asset-model"
+)
+
+type fakeOrgSource struct {
+	*service.BaseService
+	sys    systems.System
+	asn    int
+	prefix string
+	silent bool
+}
+
+func newFakeOrgSource(name string, sys systems.System, asn int, prefix string) *fakeOrgSource {
+	f := &fakeOrgSource{sys: sys, asn: asn, prefix: prefix}
+	f.BaseService = service.NewBaseService(f, name)
+	return f
+}
+
+func (f *fakeOrgSource) HandlesReq(req interface{}) bool {
+	_, ok := req.(*requests.OrganizationRequest)
+	return ok
+}
+
+func (f *fakeOrgSource) OnStart() error {
+	if !f.silent {
+		go f.requests()
+	}
+	return nil
+}
+
+func (f *fakeOrgSource) requests() {
+	for {
+		select {
+		case <-f.Done():
+			return
+		case in := <-f.Input():
+			req, ok := in.(*requests.OrganizationRequest)
+			if !ok {
+				continue
+			}
+			if f.asn != 0 {
+				f.sys.Cache().Update(&requests.ASNRequest{
+					ASN:         f.asn,
+					Prefix:      f.prefix,
+					Netblocks:   []string{f.prefix},
+					Description: req.Name,
+				})
+				f.Output() <- &requests.RoutingRequest{
+					ASN:         f.asn,
+					Description: req.Name,
+					Prefixes:    []string{f.prefix},
+					Source:      f.String(),
+				}
+			}
+			req.Finished()
+		}
+	}
+}
+
+func newOrgCollection(t *testing.T, srcs ...func(systems.System) service.Service) *Collection {
+	sys := &systems.SimpleSystem{
+		Cfg:      config.NewConfig(),
+		Graph:    netmap.NewGraph("memory", "", ""),
+		ASNCache: requests.NewASNCache(),
+	}
+
+	c := &Collection{Config: sys.Cfg, Sys: sys}
+	for _, fn := range srcs {
+		src := fn(sys)
+		if err := src.Start(); err != nil {
+			t.Fatalf("Failed to start the %s data source: %v", src.String(), err)
+		}
+		t.Cleanup(func() { _ = src.Stop() })
+		c.srcs = append(c.srcs, src)
+	}
+	return c
+}
+
+func TestOrganizationASNs(t *testing.T) {
+	prev := orgSearchIdle
+	orgSearchIdle = 10 * time.Second
+	defer func() { orgSearchIdle = prev }()
+
+	c := newOrgCollection(t,
+		func(sys systems.System) service.Service {
+			return newFakeOrgSource("RIR", sys, 64501, "198.51.100.0/24")
+		},
+		func(sys systems.System) service.Service {
+			return newFakeOrgSource("BGP", sys, 64500, "192.0.2.0/24")
+		},
+		func(sys systems.System) service.Service {
+			return newFakeOrgSource("Empty", sys, 0, "")
+		},
+	)
+
+	start := time.Now()
+	results, err := c.OrganizationASNs(context.Background(), "Example Org")
+	if err != nil {
+		t.Fatalf("OrganizationASNs returned an error: %v", err)
+	}
+	// The search completes once all the data sources have handled the request, without waiting to be idle
+	if elapsed := time.Since(start); elapsed >= orgSearchIdle/2 {
+		t.Errorf("The search took %s to complete after the data sources handled the request", elapsed)
+	}
+	if len(results) != 2 || results[0].ASN != 64500 || results[1].ASN != 64501 {
+		t.Fatalf("Expected AS64500 and AS64501 sorted by number, got %v", results)
+	}
+	if assets, err := c.Sys.GraphDatabases()[0].DB.FindByType(oam.ASN, time.Time{}); err != nil || len(assets) != 2 {
+		t.Errorf("Expected the two autonomous systems to be stored, got %d: %v", len(assets), err)
+	}
+}
+
+func TestOrganizationASNsCanceled(t *testing.T) {
+	c := newOrgCollection(t, func(sys systems.System) service.Service {
+		// The data source never reads the request
+		f := newFakeOrgSource("Stuck", sys, 64500, "192.0.2.0/24")
+		f.silent = true
+		return f
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := c.OrganizationASNs(ctx, "Example Org"); err != nil {
+			t.Errorf("OrganizationASNs returned an error: %v", err)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The search did not return after the context was canceled")
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package mock
+
+// Dataset is the data served by the mock Internet.
+type Dataset struct {
+	Domain string
+	// Zone provides the records of the domain in the RFC 1035 master file format
+	Zone string
+	// Names are returned by the mock HTTP data source, including names that do not resolve
+	Names     []string
+	Netblocks []*Netblock
+}
+
+// Netblock is an address block registered in the mock RDAP service.
+type Netblock struct {
+	CIDR string
+	ASN  int
+	Name string
+}
+
+// DefaultDataset returns a small organization using the documentation address blocks.
+func DefaultDataset() *Dataset {
+	return &Dataset{
+		Domain: "example.com",
+		Zone: `$TTL 300
+@      IN SOA   ns1 hostmaster 1 7200 3600 1209600 300
+@      IN NS    ns1
+@      IN A     192.0.2.1
+@      IN MX    10 mail
+ns1    IN A     192.0.2.53
+www    IN A     192.0.2.10
+www    IN AAAA  2001:db8::10
+mail   IN A     192.0.2.25
+api    IN CNAME www
+vpn    IN A     192.0.2.20
+`,
+		Names: []string{
+			"www.example.com",
+			"mail.example.com",
+			"api.example.com",
+			"vpn.example.com",
+			"stale.example.com",
+			"dev.example.com",
+		},
+		Netblocks: []*Netblock{
+			{CIDR: "192.0.2.0/24", ASN: 64496, Name: "EXAMPLE-NET"},
+			{CIDR: "2001:db8::/32", ASN: 64496, Name: "EXAMPLE-NET6"},
+		},
+	}
+}
+
+// Expected returns the names an enumeration of the dataset must discover. Every name in the zone
+// owning addresses or an alias can be reached from the data source or the records of the root domain,
+// while the names provided by the data source that do not resolve must not be discovered.
+func (m *Internet) Expected() []string {
+	return m.Resolvable()
+}
@@ -0,0 +1,90 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package mock
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// The name queried by the resolver pools to check for EDNS client subnet support.
+const clientSubnetCheckName = "o-o.myaddr.l.google.com."
+
+// The longest chain of aliases followed when answering a query.
+const maxAliasChain = 10
+
+func (m *Internet) serveDNS(w dns.ResponseWriter, req *dns.Msg) {
+	m.Lock()
+	m.queries++
+	m.Unlock()
+
+	resp := new(dns.Msg)
+	resp.SetReply(req)
+	resp.Authoritative = true
+	resp.RecursionAvailable = true
+
+	if len(req.Question) != 1 {
+		resp.Rcode = dns.RcodeFormatError
+		_ = w.WriteMsg(resp)
+		return
+	}
+
+	q := req.Question[0]
+	name := strings.ToLower(q.Name)
+	if name == clientSubnetCheckName && q.Qtype == dns.TypeTXT {
+		resp.Authoritative = false
+		resp.Answer = append(resp.Answer, &dns.TXT{
+			Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 60},
+			Txt: []string{"127.0.0.1"},
+		})
+		_ = w.WriteMsg(resp)
+		return
+	}
+
+	resp.Answer = m.answers(name, q.Qtype)
+	if _, found := m.records[name]; !found {
+		resp.Rcode = dns.RcodeNameError
+	}
+	if len(resp.Answer) == 0 {
+		if soa := m.soa(); soa != nil {
+			resp.Ns = []dns.RR{soa}
+		}
+	}
+	_ = w.WriteMsg(resp)
+}
+
+// answers returns the records of the type owned by the name, following the aliases.
+func (m *Internet) answers(name string, qtype uint16) []dns.RR {
+	var results []dns.RR
+
+	for i := 0; i < maxAliasChain; i++ {
+		var next string
+
+		for _, rr := range m.records[name] {
+			if rr.Header().Rrtype == qtype {
+				results = append(results, rr)
+			} else if c, ok := rr.(*dns.CNAME); ok && qtype != dns.TypeCNAME {
+				results = append(results, rr)
+				next = strings.ToLower(c.Target)
+			}
+		}
+
+		if next == "" {
+			break
+		}
+		name = next
+	}
+	return results
+}
+
+func (m *Internet) soa() dns.RR {
+	for _, rr := range m.records[dns.Fqdn(strings.ToLower(m.data.Domain))] {
+		if rr.Header().Rrtype == dns.TypeSOA {
+			return rr
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+type subdomainsResponse struct {
+	Domain     string   `json:"domain"`
+	Subdomains []string `json:"subdomains"`
+}
+
+// The subset of the RDAP IP network object (RFC 9083) used by the data sources.
+type rdapNetwork struct {
+	ObjectClassName string      `json:"objectClassName"`
+	Handle          string      `json:"handle"`
+	StartAddress    string      `json:"startAddress"`
+	EndAddress      string      `json:"endAddress"`
+	IPVersion       string      `json:"ipVersion"`
+	Name            string      `json:"name"`
+	CIDRs           []*rdapCIDR `json:"cidr0_cidrs"`
+	OriginASNs      []int       `json:"arin_originas0_originautnums"`
+}
+
+type rdapCIDR struct {
+	V4Prefix string `json:"v4prefix,omitempty"`
+	V6Prefix string `json:"v6prefix,omitempty"`
+	Length   int    `json:"length"`
+}
+
+type rdapAutnum struct {
+	ObjectClassName string `json:"objectClassName"`
+	Handle          string `json:"handle"`
+	StartAutnum     int    `json:"startAutnum"`
+	EndAutnum       int    `json:"endAutnum"`
+	Name            string `json:"name"`
+}
+
+// GET /subdomains?domain=
+func (m *Internet) serveSubdomains(w http.ResponseWriter, r *http.Request) {
+	domain := strings.ToLower(r.URL.Query().Get("domain"))
+	if domain == "" {
+		http.Error(w, "the domain parameter must be provided", http.StatusBadRequest)
+		return
+	}
+
+	resp := &subdomainsResponse{
+		Domain:     domain,
+		Subdomains: []string{},
+	}
+	for _, name := range m.data.Names {
+		if n := strings.ToLower(name); n == domain || strings.HasSuffix(n, "."+domain) {
+			resp.Subdomains = append(resp.Subdomains, n)
+		}
+	}
+	writeJSON(w, "application/json", resp)
+}
+
+// GET /rdap/ip/{addr}
+func (m *Internet) serveRDAPIP(w http.ResponseWriter, r *http.Request) {
+	addr, err := netip.ParseAddr(strings.TrimPrefix(r.URL.Path, "/rdap/ip/"))
+	if err != nil {
+		http.Error(w, "the IP address is not valid", http.StatusBadRequest)
+		return
+	}
+
+	for _, nb := range m.data.Netblocks {
+		prefix, err := netip.ParsePrefix(nb.CIDR)
+		if err != nil || !prefix.Contains(addr) {
+			continue
+		}
+
+		resp := &rdapNetwork{
+			ObjectClassName: "ip network",
+			Handle:          nb.Name,
+			StartAddress:    prefix.Masked().Addr().String(),
+			EndAddress:      lastAddr(prefix).String(),
+			Name:            nb.Name,
+			OriginASNs:      []int{nb.ASN},
+		}
+
+		cidr := &rdapCIDR{Length: prefix.Bits()}
+		if prefix.Addr().Is4() {
+			resp.IPVersion = "v4"
+			cidr.V4Prefix = prefix.Masked().Addr().String()
+		} else {
+			resp.IPVersion = "v6"
+			cidr.V6Prefix = prefix.Masked().Addr().String()
+		}
+		resp.CIDRs = []*rdapCIDR{cidr}
+
+		writeJSON(w, "application/rdap+json", resp)
+		return
+	}
+	http.Error(w, "the IP address is not registered", http.StatusNotFound)
+}
+
+// GET /rdap/autnum/{asn}
+func (m *Internet) serveRDAPAutnum(w http.ResponseWriter, r *http.Request) {
+	asn, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/rdap/autnum/"))
+	if err != nil {
+		http.Error(w, "the ASN is not valid", http.StatusBadRequest)
+		return
+	}
+
+	for _, nb := range m.data.Netblocks {
+		if nb.ASN != asn {
+			continue
+		}
+
+		writeJSON(w, "application/rdap+json", &rdapAutnum{
+			ObjectClassName: "autnum",
+			Handle:          fmt.Sprintf("AS%d", asn),
+			StartAutnum:     asn,
+			EndAutnum:       asn,
+			Name:            nb.Name,
+		})
+		return
+	}
+	http.Error(w, "the ASN is not registered", http.StatusNotFound)
+}
+
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	b := prefix.Masked().Addr().AsSlice()
+
+	for i := prefix.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 1 << (7 - uint(i%8))
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+func writeJSON(w http.ResponseWriter, ctype string, v interface{}) {
+	w.Header().Set("Content-Type", ctype)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,153 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package mock provides a small Internet of test doubles, a DNS server, an RDAP service and
+// an HTTP data source, so enumerations can be executed without reaching the real Internet.
+package mock
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// Internet runs the mock services on the loopback interface.
+type Internet struct {
+	// DNSAddr is the address of the mock DNS server, which acts as both resolver and authoritative server
+	DNSAddr string
+	// URL is the base URL of the mock HTTP data source and RDAP service
+	URL     string
+	data    *Dataset
+	records map[string][]dns.RR
+	dns     *dns.Server
+	http    *http.Server
+	sync.Mutex
+	queries int
+}
+
+// NewInternet starts the mock services for the dataset.
+func NewInternet(data *Dataset) (*Internet, error) {
+	records, err := parseZone(data.Domain, data.Zone)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Internet{
+		data:    data,
+		records: records,
+	}
+	if err := m.startDNS(); err != nil {
+		return nil, err
+	}
+	if err := m.startHTTP(); err != nil {
+		m.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// Close shuts down the mock services.
+func (m *Internet) Close() {
+	if m.dns != nil {
+		_ = m.dns.Shutdown()
+	}
+	if m.http != nil {
+		_ = m.http.Close()
+	}
+}
+
+// Dataset returns the data served by the mock services.
+func (m *Internet) Dataset() *Dataset {
+	return m.data
+}
+
+// Queries returns the number of DNS queries answered by the mock DNS server.
+func (m *Internet) Queries() int {
+	m.Lock()
+	defer m.Unlock()
+
+	return m.queries
+}
+
+func (m *Internet) startDNS() error {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("failed to start the mock DNS server: %v", err)
+	}
+
+	started := make(chan struct{})
+	m.DNSAddr = pc.LocalAddr().String()
+	m.dns = &dns.Server{
+		PacketConn:        pc,
+		Handler:           dns.HandlerFunc(m.serveDNS),
+		NotifyStartedFunc: func() { close(started) },
+	}
+	go func() { _ = m.dns.ActivateAndServe() }()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		return errors.New("the mock DNS server failed to start")
+	}
+	return nil
+}
+
+func (m *Internet) startHTTP() error {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("failed to start the mock HTTP server: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/subdomains", m.serveSubdomains)
+	mux.HandleFunc("/rdap/ip/", m.serveRDAPIP)
+	mux.HandleFunc("/rdap/autnum/", m.serveRDAPAutnum)
+
+	m.URL = "http://" + l.Addr().String()
+	m.http = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() { _ = m.http.Serve(l) }()
+	return nil
+}
+
+// parseZone returns the resource records of the zone file, keyed by the owner name.
+func parseZone(origin, zone string) (map[string][]dns.RR, error) {
+	records := make(map[string][]dns.RR)
+
+	zp := dns.NewZoneParser(strings.NewReader(zone), dns.Fqdn(origin), "")
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		name := strings.ToLower(rr.Header().Name)
+		records[name] = append(records[name], rr)
+	}
+	if err := zp.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse the mock zone: %v", err)
+	}
+	return records, nil
+}
+
+// Resolvable returns the names in the zone that have address records or aliases.
+func (m *Internet) Resolvable() []string {
+	var names []string
+
+	for name, rrs := range m.records {
+		for _, rr := range rrs {
+			if t := rr.Header().Rrtype; t == dns.TypeA || t == dns.TypeAAAA || t == dns.TypeCNAME {
+				names = append(names, strings.TrimSuffix(name, "."))
+				break
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
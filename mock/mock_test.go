@@ -0,0 +1,107 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package mock
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestInternet(t *testing.T) {
+	m, err := NewInternet(DefaultDataset())
+	if err != nil {
+		t.Fatalf("Failed to start the mock Internet: %v", err)
+	}
+	defer m.Close()
+
+	query := func(name string, qtype uint16) *dns.Msg {
+		msg := new(dns.Msg)
+		msg.SetQuestion(dns.Fqdn(name), qtype)
+
+		resp, err := dns.Exchange(msg, m.DNSAddr)
+		if err != nil {
+			t.Fatalf("Failed to query %s: %v", name, err)
+		}
+		return resp
+	}
+
+	resp := query("api.example.com", dns.TypeA)
+	if resp.Rcode != dns.RcodeSuccess || len(resp.Answer) != 2 {
+		t.Fatalf("Expected the alias and the address for api.example.com, got %v", resp.Answer)
+	}
+	if a, ok := resp.Answer[1].(*dns.A); !ok || a.A.String() != "192.0.2.10" {
+		t.Errorf("Unexpected address for api.example.com: %v", resp.Answer[1])
+	}
+	if resp := query("stale.example.com", dns.TypeA); resp.Rcode != dns.RcodeNameError || len(resp.Ns) != 1 {
+		t.Errorf("Expected NXDOMAIN along with the SOA record for stale.example.com, got %v", resp)
+	}
+	if resp := query("www.example.com", dns.TypeMX); resp.Rcode != dns.RcodeSuccess || len(resp.Answer) != 0 {
+		t.Errorf("Expected no answers for the MX query of www.example.com, got %v", resp.Answer)
+	}
+	if resp := query(clientSubnetCheckName, dns.TypeTXT); len(resp.Answer) != 1 || !resp.RecursionAvailable {
+		t.Errorf("The client subnet check was not answered: %v", resp)
+	}
+	if m.Queries() != 4 {
+		t.Errorf("Expected 4 queries, got %d", m.Queries())
+	}
+
+	var subs subdomainsResponse
+	if code := getJSON(t, m.URL+"/subdomains?domain=example.com", &subs); code != http.StatusOK || len(subs.Subdomains) != 6 {
+		t.Errorf("Unexpected subdomains response: %d %v", code, subs.Subdomains)
+	}
+
+	var nb rdapNetwork
+	if code := getJSON(t, m.URL+"/rdap/ip/2001:db8::10", &nb); code != http.StatusOK {
+		t.Fatalf("Expected status 200 for the RDAP IP query, got %d", code)
+	}
+	if nb.StartAddress != "2001:db8::" || !strings.HasPrefix(nb.EndAddress, "2001:db8:ffff:ffff") ||
+		len(nb.CIDRs) != 1 || nb.CIDRs[0].Length != 32 || nb.OriginASNs[0] != 64496 {
+		t.Errorf("Unexpected RDAP network: %+v", nb)
+	}
+	if code := getJSON(t, m.URL+"/rdap/ip/198.51.100.1", nil); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unregistered address, got %d", code)
+	}
+
+	var as rdapAutnum
+	if code := getJSON(t, m.URL+"/rdap/autnum/64496", &as); code != http.StatusOK || as.Handle != "AS64496" {
+		t.Errorf("Unexpected RDAP autnum response: %d %+v", code, as)
+	}
+
+	if s := m.Script(); !strings.Contains(s, m.URL) || !strings.Contains(s, SourceName) {
+		t.Error("The data source script does not query the mock Internet")
+	}
+}
+
+func TestExpected(t *testing.T) {
+	m, err := NewInternet(DefaultDataset())
+	if err != nil {
+		t.Fatalf("Failed to start the mock Internet: %v", err)
+	}
+	defer m.Close()
+
+	want := []string{"api.example.com", "example.com", "mail.example.com", "ns1.example.com", "vpn.example.com", "www.example.com"}
+	if got := m.Expected(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func getJSON(t *testing.T, url string, v interface{}) int {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("Failed to request %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	if v != nil && resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("Failed to decode the response of %s: %v", url, err)
+		}
+	}
+	return resp.StatusCode
+}
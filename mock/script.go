@@ -0,0 +1,81 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package mock
+
+import "strings"
+
+// SourceName is the name of the data source script that queries the mock Internet.
+const SourceName = "Mock Internet"
+
+// ScriptFile is the file name of the data source script that queries the mock Internet.
+const ScriptFile = "mock.ads"
+
+const scriptTemplate = `-- Queries the mock Internet started for testing Amass installations
+
+local json = require("json")
+
+name = "Mock Internet"
+type = "api"
+
+local base_url = "{{URL}}"
+
+function vertical(ctx, domain)
+    local resp, err = request(ctx, {['url']=base_url .. "/subdomains?domain=" .. domain})
+    if (err ~= nil and err ~= "") then
+        log(ctx, "vertical request to service failed: " .. err)
+        return
+    end
+
+    local d = json.decode(resp.body)
+    if (d == nil or d.subdomains == nil) then
+        return
+    end
+
+    for _, name in pairs(d.subdomains) do
+        new_name(ctx, name)
+    end
+end
+
+function asn(ctx, addr, asn)
+    if (addr == "") then
+        return
+    end
+
+    local resp, err = request(ctx, {['url']=base_url .. "/rdap/ip/" .. addr})
+    if (err ~= nil and err ~= "") then
+        log(ctx, "asn request to service failed: " .. err)
+        return
+    elseif (resp.status_code ~= 200) then
+        return
+    end
+
+    local d = json.decode(resp.body)
+    if (d == nil or d.cidr0_cidrs == nil or #(d.cidr0_cidrs) == 0 or
+        d.arin_originas0_originautnums == nil or #(d.arin_originas0_originautnums) == 0) then
+        return
+    end
+
+    local c = d.cidr0_cidrs[1]
+    local prefix = c.v4prefix
+    if (prefix == nil or prefix == "") then
+        prefix = c.v6prefix
+    end
+    prefix = prefix .. "/" .. tostring(c.length)
+
+    new_asn(ctx, {
+        ['addr']=addr,
+        ['asn']=d.arin_originas0_originautnums[1],
+        ['prefix']=prefix,
+        ['registry']="MOCK",
+        ['desc']=d.name,
+        ['netblocks']={prefix},
+    })
+end
+`
+
+// Script returns the data source script that queries the mock HTTP data source and RDAP service.
+func (m *Internet) Script() string {
+	return strings.ReplaceAll(scriptTemplate, "{{URL}}", m.URL)
+}
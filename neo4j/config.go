@@ -0,0 +1,79 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package neo4j
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// The file in the output directory recording when the graph was last synchronized.
+const syncMarkerFile = "neo4j.sync"
+
+// FromConfig returns a Client for the Neo4j instance in the 'neo4j' section of the configuration options.
+// A nil Client is returned when no instance has been configured.
+func FromConfig(cfg *config.Config) (*Client, error) {
+	neoRaw, ok := cfg.Options["neo4j"]
+	if !ok {
+		return nil, nil
+	}
+
+	settings, ok := neoRaw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("neo4j is not a map[string]interface{}")
+	}
+
+	values := make(map[string]string)
+	for _, key := range []string{"url", "database", "username", "password"} {
+		raw, ok := settings[key]
+		if !ok {
+			continue
+		}
+
+		str, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("neo4j %s is not a string", key)
+		}
+		values[key] = str
+	}
+	if values["url"] == "" {
+		return nil, fmt.Errorf("neo4j url must be provided")
+	}
+
+	c := NewClient(values["url"], values["database"], values["username"], values["password"])
+	if raw, ok := settings["batch_size"]; ok {
+		n, ok := raw.(int)
+		if !ok || n <= 0 {
+			return nil, fmt.Errorf("neo4j batch_size is not a positive integer")
+		}
+		c.BatchSize = n
+	}
+	return c, nil
+}
+
+// LastSync returns the time of the previous synchronization recorded in the directory,
+// or the zero time when the graph has not been synchronized.
+func LastSync(dir string) time.Time {
+	data, err := os.ReadFile(filepath.Join(dir, syncMarkerFile))
+	if err != nil {
+		return time.Time{}
+	}
+
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// SaveSync records the time of the synchronization in the directory.
+func SaveSync(dir string, t time.Time) error {
+	return os.WriteFile(filepath.Join(dir, syncMarkerFile), []byte(t.UTC().Format(time.RFC3339)+"\n"), 0644)
+}
@@ -0,0 +1,99 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package neo4j
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultBatchSize is the number of rows merged by each Cypher statement.
+const DefaultBatchSize = 500
+
+// Client sends Cypher statements to the transactional HTTP endpoint of a Neo4j instance.
+type Client struct {
+	URL       string
+	Database  string
+	Username  string
+	Password  string
+	BatchSize int
+	http      *http.Client
+}
+
+// NewClient returns a Client for the Neo4j instance at the URL (e.g. http://localhost:7474).
+func NewClient(url, database, username, password string) *Client {
+	if database == "" {
+		database = "neo4j"
+	}
+
+	return &Client{
+		URL:       strings.TrimSuffix(url, "/"),
+		Database:  database,
+		Username:  username,
+		Password:  password,
+		BatchSize: DefaultBatchSize,
+		http:      &http.Client{Timeout: time.Minute},
+	}
+}
+
+type statement struct {
+	Statement  string                 `json:"statement"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+}
+
+type txRequest struct {
+	Statements []*statement `json:"statements"`
+}
+
+type txResponse struct {
+	Errors []struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// run executes the statements within a single transaction.
+func (c *Client) run(ctx context.Context, stmts ...*statement) error {
+	body, err := json.Marshal(&txRequest{Statements: stmts})
+	if err != nil {
+		return err
+	}
+
+	u := fmt.Sprintf("%s/db/%s/tx/commit", c.URL, c.Database)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("the Neo4j instance returned status %s", resp.Status)
+	}
+
+	var tx txResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tx); err != nil {
+		return fmt.Errorf("failed to decode the Neo4j response: %v", err)
+	}
+	if len(tx.Errors) > 0 {
+		return errors.New(tx.Errors[0].Code + ": " + tx.Errors[0].Message)
+	}
+	return nil
+}
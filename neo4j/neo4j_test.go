@@ -0,0 +1,102 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package neo4j
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+)
+
+func TestSync(t *testing.T) {
+	var lock sync.Mutex
+	var stmts []*statement
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/db/amass/tx/commit" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if u, p, ok := r.BasicAuth(); !ok || u != "neo4j" || p != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var tx txRequest
+		if err := json.NewDecoder(r.Body).Decode(&tx); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		lock.Lock()
+		stmts = append(stmts, tx.Statements...)
+		lock.Unlock()
+		_, _ = w.Write([]byte(`{"results":[],"errors":[]}`))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	_ = g.UpsertCNAME(ctx, "www.owasp.org", "owasp.cdn.net")
+	_ = g.UpsertA(ctx, "owasp.cdn.net", "192.0.2.1")
+
+	c := NewClient(srv.URL, "amass", "neo4j", "secret")
+	c.BatchSize = 1
+	stats, err := c.Sync(ctx, g, time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to synchronize the graph: %v", err)
+	}
+	if stats.Nodes < 3 || stats.Edges != 2 {
+		t.Errorf("Unexpected synchronization stats: %+v", stats)
+	}
+	// Each row is sent in its own batch
+	if len(stmts) != stats.Nodes+stats.Edges {
+		t.Errorf("Expected %d statements, got %d", stats.Nodes+stats.Edges, len(stmts))
+	}
+
+	var cname bool
+	for _, s := range stmts {
+		if strings.Contains(s.Statement, ":CNAME_RECORD") {
+			cname = true
+		}
+	}
+	if !cname {
+		t.Error("The CNAME relation was not merged")
+	}
+
+	stmts = nil
+	if stats, err := c.Sync(ctx, g, time.Now().Add(time.Hour)); err != nil || stats.Nodes != 0 || len(stmts) != 0 {
+		t.Errorf("Expected nothing to synchronize after the last sync: %+v %v", stats, err)
+	}
+
+	c.Password = "wrong"
+	if _, err := c.Sync(ctx, g, time.Time{}); err == nil {
+		t.Error("Expected an error for the rejected credentials")
+	}
+}
+
+func TestSyncMarker(t *testing.T) {
+	dir := t.TempDir()
+
+	if !LastSync(dir).IsZero() {
+		t.Error("Expected the zero time before the first synchronization")
+	}
+
+	now := time.Now().Truncate(time.Second)
+	if err := SaveSync(dir, now); err != nil {
+		t.Fatalf("Failed to save the synchronization time: %v", err)
+	}
+	if last := LastSync(dir); !last.Equal(now) {
+		t.Errorf("Expected %s, got %s", now, last)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package neo4j
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/asset-db/types"
+	oam "github.com/owasp-amass/open-asset-model"
+)
+
+var assetTypes = []oam.AssetType{oam.FQDN, oam.IPAddress, oam.Netblock, oam.ASN, oam.RIROrg}
+
+// Relation types become Neo4j relationship types, which cannot be provided as parameters.
+var relTypeRE = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// SyncStats reports the entities and edges merged into the Neo4j instance.
+type SyncStats struct {
+	Nodes int
+	Edges int
+}
+
+// Sync merges the assets and relations of the graph last seen after since into the Neo4j
+// instance. Every node is given the Asset label, along with a label for the asset type.
+func (c *Client) Sync(ctx context.Context, g *netmap.Graph, since time.Time) (*SyncStats, error) {
+	nodes := make(map[string]*types.Asset)
+	for _, atype := range assetTypes {
+		if assets, err := g.DB.FindByType(atype, since.UTC()); err == nil {
+			for _, a := range assets {
+				nodes[a.ID] = a
+			}
+		}
+	}
+
+	var edges []*types.Relation
+	ids := make([]string, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	for _, id := range ids {
+		rels, err := g.DB.OutgoingRelations(nodes[id], time.Time{})
+		if err != nil {
+			continue
+		}
+
+		for _, rel := range rels {
+			if !since.IsZero() && !rel.LastSeen.After(since) {
+				continue
+			}
+			// Relations can be updated without the asset on the other end
+			if _, found := nodes[rel.ToAsset.ID]; !found {
+				to, err := g.DB.FindById(rel.ToAsset.ID, time.Time{})
+				if err != nil {
+					continue
+				}
+				nodes[to.ID] = to
+			}
+			rel.FromAsset = nodes[id]
+			edges = append(edges, rel)
+		}
+	}
+
+	stats := new(SyncStats)
+	if err := c.mergeNodes(ctx, nodes, stats); err != nil {
+		return stats, err
+	}
+	if err := c.mergeEdges(ctx, edges, stats); err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
+
+func (c *Client) mergeNodes(ctx context.Context, nodes map[string]*types.Asset, stats *SyncStats) error {
+	rows := make(map[oam.AssetType][]interface{})
+	for _, a := range nodes {
+		props := make(map[string]interface{})
+		if data, err := json.Marshal(a.Asset); err == nil {
+			_ = json.Unmarshal(data, &props)
+		}
+
+		atype := a.Asset.AssetType()
+		rows[atype] = append(rows[atype], map[string]interface{}{
+			"id":         a.ID,
+			"props":      props,
+			"created_at": a.CreatedAt.UTC().Format(time.RFC3339),
+			"last_seen":  a.LastSeen.UTC().Format(time.RFC3339),
+		})
+	}
+
+	for _, atype := range assetTypes {
+		query := fmt.Sprintf("UNWIND $rows AS row MERGE (a:Asset {id: row.id}) SET a:%s, a += row.props, "+
+			"a.created_at = row.created_at, a.last_seen = row.last_seen", atype)
+
+		n, err := c.mergeBatches(ctx, query, rows[atype])
+		stats.Nodes += n
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) mergeEdges(ctx context.Context, edges []*types.Relation, stats *SyncStats) error {
+	rows := make(map[string][]interface{})
+	for _, rel := range edges {
+		rtype := relTypeRE.ReplaceAllString(strings.ToUpper(rel.Type), "_")
+
+		rows[rtype] = append(rows[rtype], map[string]interface{}{
+			"id":        rel.ID,
+			"from":      rel.FromAsset.ID,
+			"to":        rel.ToAsset.ID,
+			"last_seen": rel.LastSeen.UTC().Format(time.RFC3339),
+		})
+	}
+
+	rtypes := make([]string, 0, len(rows))
+	for rtype := range rows {
+		rtypes = append(rtypes, rtype)
+	}
+	sort.Strings(rtypes)
+
+	for _, rtype := range rtypes {
+		query := fmt.Sprintf("UNWIND $rows AS row MATCH (f:Asset {id: row.from}), (t:Asset {id: row.to}) "+
+			"MERGE (f)-[r:%s {id: row.id}]->(t) SET r.last_seen = row.last_seen", rtype)
+
+		n, err := c.mergeBatches(ctx, query, rows[rtype])
+		stats.Edges += n
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeBatches executes the query for each batch of rows and returns the number of rows merged.
+func (c *Client) mergeBatches(ctx context.Context, query string, rows []interface{}) (int, error) {
+	size := c.BatchSize
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+
+	var merged int
+	for start := 0; start < len(rows); start += size {
+		end := start + size
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		if err := c.run(ctx, &statement{
+			Statement:  query,
+			Parameters: map[string]interface{}{"rows": rows[start:end]},
+		}); err != nil {
+			return merged, err
+		}
+		merged += end - start
+	}
+	return merged, nil
+}
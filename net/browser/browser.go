@@ -0,0 +1,306 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package browser renders web pages that provide their content using JavaScript,
+// sharing a small pool of headless Chrome tabs between the data sources.
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+)
+
+const (
+	// DefaultPoolSize is the number of tabs rendering pages concurrently.
+	DefaultPoolSize = 2
+	// DefaultMaxFetches is the number of pages rendered during an enumeration.
+	DefaultMaxFetches = 50
+	// DefaultTimeout is the time allowed for rendering a single page.
+	DefaultTimeout = 30 * time.Second
+)
+
+// ErrBudgetExhausted is returned once the browser has rendered the maximum number of pages.
+var ErrBudgetExhausted = errors.New("the browser fetch budget has been exhausted")
+
+// Options configures the headless browser.
+type Options struct {
+	PoolSize   int
+	MaxFetches int
+	Timeout    time.Duration
+	// ExecPath is the Chrome executable, which is searched for in the default locations when empty
+	ExecPath string
+}
+
+// Browser renders web pages using a pool of headless Chrome tabs. Chrome is only
+// launched once the first page is requested.
+type Browser struct {
+	sync.Mutex
+	opts       Options
+	started    bool
+	launchErr  error
+	browserCtx context.Context
+	cancel     context.CancelFunc
+	tabs       chan context.Context
+	slots      chan struct{}
+	fetches    int
+	closed     bool
+}
+
+// NewBrowser returns a Browser using the options, replacing zero values with the defaults.
+func NewBrowser(opts Options) *Browser {
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = DefaultPoolSize
+	}
+	if opts.MaxFetches <= 0 {
+		opts.MaxFetches = DefaultMaxFetches
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	return &Browser{
+		opts:  opts,
+		tabs:  make(chan context.Context, opts.PoolSize),
+		slots: make(chan struct{}, opts.PoolSize),
+	}
+}
+
+// Fetches returns the number of pages requested from the browser.
+func (b *Browser) Fetches() int {
+	if b == nil {
+		return 0
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	return b.fetches
+}
+
+// Remaining returns the number of pages the browser is still allowed to render.
+func (b *Browser) Remaining() int {
+	if b == nil {
+		return 0
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	return b.opts.MaxFetches - b.fetches
+}
+
+// Fetch returns the HTML of the page at the URL after the scripts of the page have executed.
+func (b *Browser) Fetch(ctx context.Context, u string) (string, error) {
+	if b == nil {
+		return "", errors.New("the headless browser is not enabled")
+	}
+	if err := b.spend(); err != nil {
+		return "", err
+	}
+
+	tab, err := b.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	html, err := b.render(ctx, tab, u)
+	if err != nil {
+		// The tab may have crashed, so it is replaced by a new tab
+		b.discard(tab)
+		return "", err
+	}
+
+	b.release(tab)
+	return html, nil
+}
+
+func (b *Browser) render(ctx context.Context, tab context.Context, u string) (string, error) {
+	var html string
+
+	if err := b.run(ctx, tab, u, chromedp.OuterHTML("html", &html, chromedp.ByQuery)); err != nil {
+		return "", err
+	}
+	return html, nil
+}
+
+// Capture is the screenshot of a rendered page.
+type Capture struct {
+	URL   string
+	Title string
+	// Image is the PNG encoded screenshot of the browser viewport
+	Image []byte
+}
+
+// Screenshot returns the title and a screenshot of the page at the URL after the scripts of the page have executed.
+func (b *Browser) Screenshot(ctx context.Context, u string) (*Capture, error) {
+	if b == nil {
+		return nil, errors.New("the headless browser is not enabled")
+	}
+	if err := b.spend(); err != nil {
+		return nil, err
+	}
+
+	tab, err := b.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Capture{URL: u}
+	if err := b.run(ctx, tab, u, chromedp.Title(&c.Title), chromedp.CaptureScreenshot(&c.Image)); err != nil {
+		b.discard(tab)
+		return nil, err
+	}
+
+	b.release(tab)
+	return c, nil
+}
+
+// run navigates the tab to the URL and performs the actions once the page is ready.
+func (b *Browser) run(ctx context.Context, tab context.Context, u string, actions ...chromedp.Action) error {
+	tctx, cancel := context.WithTimeout(tab, b.opts.Timeout)
+	defer cancel()
+
+	// The tab context is not derived from the context of the request
+	go func() {
+		select {
+		case <-ctx.Done():
+			cancel()
+		case <-tctx.Done():
+		}
+	}()
+
+	tasks := chromedp.Tasks{
+		chromedp.Navigate(u),
+		chromedp.WaitReady("body", chromedp.ByQuery),
+	}
+	if err := chromedp.Run(tctx, append(tasks, actions...)...); err != nil {
+		return fmt.Errorf("failed to render %s: %v", u, err)
+	}
+	return nil
+}
+
+func (b *Browser) spend() error {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.closed {
+		return errors.New("the headless browser has been closed")
+	}
+	if b.fetches >= b.opts.MaxFetches {
+		return ErrBudgetExhausted
+	}
+
+	b.fetches++
+	return nil
+}
+
+// acquire returns an idle tab, or opens a new tab while the pool has not been filled.
+func (b *Browser) acquire(ctx context.Context) (context.Context, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case b.slots <- struct{}{}:
+	}
+
+	select {
+	case tab := <-b.tabs:
+		return tab, nil
+	default:
+	}
+
+	tab, err := b.newTab()
+	if err != nil {
+		<-b.slots
+		return nil, err
+	}
+	return tab, nil
+}
+
+func (b *Browser) release(tab context.Context) {
+	b.tabs <- tab
+	<-b.slots
+}
+
+func (b *Browser) discard(tab context.Context) {
+	_ = chromedp.Cancel(tab)
+	<-b.slots
+}
+
+func (b *Browser) newTab() (context.Context, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.closed {
+		return nil, errors.New("the headless browser has been closed")
+	}
+	if !b.started {
+		// Chrome is not launched again after failing, such as when it is not installed
+		if b.launchErr == nil {
+			b.launchErr = b.launch()
+		}
+		if b.launchErr != nil {
+			return nil, b.launchErr
+		}
+	}
+
+	tab, _ := chromedp.NewContext(b.browserCtx)
+	// Create the target, so failures are reported before the tab is used
+	if err := chromedp.Run(tab); err != nil {
+		_ = chromedp.Cancel(tab)
+		return nil, fmt.Errorf("failed to open a browser tab: %v", err)
+	}
+	return tab, nil
+}
+
+func (b *Browser) launch() error {
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.UserAgent(amasshttp.UserAgent),
+		chromedp.Flag("ignore-certificate-errors", true),
+	)
+	if b.opts.ExecPath != "" {
+		opts = append(opts, chromedp.ExecPath(b.opts.ExecPath))
+	}
+
+	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
+	browserCtx, browserCancel := chromedp.NewContext(allocCtx)
+	// Launch the browser process
+	if err := chromedp.Run(browserCtx); err != nil {
+		browserCancel()
+		allocCancel()
+		return fmt.Errorf("failed to launch the headless browser: %v", err)
+	}
+
+	b.browserCtx = browserCtx
+	b.cancel = func() {
+		browserCancel()
+		allocCancel()
+	}
+	b.started = true
+	return nil
+}
+
+// Close terminates the browser process.
+func (b *Browser) Close() {
+	if b == nil {
+		return
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+
+	if b.started {
+		b.cancel()
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func TestFromConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	if b, err := FromConfig(cfg); err != nil || b != nil {
+		t.Errorf("Returned a browser without the browser section: %v", err)
+	}
+
+	cfg.Options["browser"] = map[string]interface{}{"enabled": false, "pool_size": 4}
+	if b, err := FromConfig(cfg); err != nil || b != nil {
+		t.Errorf("Returned a browser that was not enabled: %v", err)
+	}
+
+	cfg.Options["browser"] = map[string]interface{}{
+		"enabled":     true,
+		"pool_size":   4,
+		"max_fetches": 10,
+		"timeout":     "5s",
+		"chrome_path": "/usr/bin/chromium",
+	}
+	b, err := FromConfig(cfg)
+	if err != nil || b == nil {
+		t.Fatalf("Failed to return the browser: %v", err)
+	}
+	if b.opts.PoolSize != 4 || b.opts.MaxFetches != 10 || b.opts.Timeout != 5*time.Second || b.opts.ExecPath != "/usr/bin/chromium" {
+		t.Errorf("The options were not applied: %+v", b.opts)
+	}
+
+	for _, settings := range []map[string]interface{}{
+		{"enabled": true, "pool_size": 0},
+		{"enabled": true, "max_fetches": "ten"},
+		{"enabled": true, "timeout": "soon"},
+	} {
+		cfg.Options["browser"] = settings
+		if _, err := FromConfig(cfg); err == nil {
+			t.Errorf("Accepted the invalid settings %v", settings)
+		}
+	}
+}
+
+func TestFetchBudget(t *testing.T) {
+	b := NewBrowser(Options{MaxFetches: 2, ExecPath: "/nonexistent/chrome"})
+	defer b.Close()
+
+	for i := 0; i < 2; i++ {
+		if _, err := b.Fetch(context.Background(), "http://127.0.0.1/"); err == nil || errors.Is(err, ErrBudgetExhausted) {
+			t.Errorf("Expected the launch of the missing browser to fail, got %v", err)
+		}
+	}
+	if _, err := b.Fetch(context.Background(), "http://127.0.0.1/"); !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("Expected the fetch budget to be exhausted, got %v", err)
+	}
+	if b.Fetches() != 2 || b.Remaining() != 0 {
+		t.Errorf("Unexpected fetch counts: %d fetches and %d remaining", b.Fetches(), b.Remaining())
+	}
+
+	var nb *Browser
+	if _, err := nb.Fetch(context.Background(), "http://127.0.0.1/"); err == nil {
+		t.Error("A nil browser fetched the page")
+	}
+	nb.Close()
+}
+
+func TestFetch(t *testing.T) {
+	var path string
+	for _, name := range []string{"headless-shell", "chromium", "chromium-browser", "google-chrome"} {
+		if p, err := exec.LookPath(name); err == nil {
+			path = p
+			break
+		}
+	}
+	if path == "" {
+		t.Skip("Chrome is not installed")
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><script>document.body.innerHTML = "<p>" + ["www", "owasp", "org"].join(".") + "</p>";</script></body></html>`)
+	}))
+	defer srv.Close()
+
+	b := NewBrowser(Options{PoolSize: 1, MaxFetches: 2, ExecPath: path})
+	defer b.Close()
+
+	for i := 0; i < 2; i++ {
+		page, err := b.Fetch(context.Background(), srv.URL)
+		if err != nil {
+			t.Fatalf("Failed to render the page: %v", err)
+		}
+		if !strings.Contains(page, "<p>www.owasp.org</p>") {
+			t.Errorf("The scripts of the page were not executed: %s", page)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package browser
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// FromConfig returns a Browser using the settings in the 'browser' section of the configuration options.
+// A nil Browser is returned when the headless browser has not been enabled.
+func FromConfig(cfg *config.Config) (*Browser, error) {
+	browserRaw, ok := cfg.Options["browser"]
+	if !ok {
+		return nil, nil
+	}
+
+	settings, ok := browserRaw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("browser is not a map[string]interface{}")
+	}
+
+	if enabled, ok := settings["enabled"].(bool); !ok || !enabled {
+		return nil, nil
+	}
+
+	var opts Options
+	if raw, ok := settings["pool_size"]; ok {
+		n, ok := raw.(int)
+		if !ok || n <= 0 {
+			return nil, fmt.Errorf("browser pool_size is not a positive integer")
+		}
+		opts.PoolSize = n
+	}
+	if raw, ok := settings["max_fetches"]; ok {
+		n, ok := raw.(int)
+		if !ok || n <= 0 {
+			return nil, fmt.Errorf("browser max_fetches is not a positive integer")
+		}
+		opts.MaxFetches = n
+	}
+	if raw, ok := settings["timeout"]; ok {
+		str, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("browser timeout is not a string")
+		}
+
+		d, err := time.ParseDuration(str)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("browser timeout is not a valid duration: %s", str)
+		}
+		opts.Timeout = d
+	}
+	if raw, ok := settings["chrome_path"]; ok {
+		str, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("browser chrome_path is not a string")
+		}
+		opts.ExecPath = str
+	}
+	return NewBrowser(opts), nil
+}
@@ -0,0 +1,47 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package dns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	mdns "github.com/miekg/dns"
+	amassnet "github.com/owasp-amass/amass/v4/net"
+)
+
+// ExchangeTimeout is the time allowed for a server to respond to a message sent by ExchangeMsg.
+const ExchangeTimeout = 5 * time.Second
+
+// ExchangeMsg sends the DNS message directly to the server over UDP and returns the response.
+// Port 53 is used when the server address does not include a port.
+func ExchangeMsg(ctx context.Context, msg *mdns.Msg, server string) (*mdns.Msg, error) {
+	tctx, cancel := context.WithTimeout(ctx, ExchangeTimeout)
+	defer cancel()
+
+	addr := server
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		addr = net.JoinHostPort(server, "53")
+	}
+
+	conn, err := amassnet.DialContext(tctx, "udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain UDP connection to [%s]: %v", addr, err)
+	}
+	defer conn.Close()
+
+	client := &mdns.Client{
+		Net:     "udp",
+		UDPSize: mdns.DefaultMsgSize,
+		Timeout: ExchangeTimeout,
+	}
+	resp, _, err := client.ExchangeWithConn(msg, &mdns.Conn{Conn: conn, UDPSize: mdns.DefaultMsgSize})
+	if err != nil {
+		return nil, fmt.Errorf("the query to [%s] failed: %v", addr, err)
+	}
+	return resp, nil
+}
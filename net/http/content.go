@@ -0,0 +1,88 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+var (
+	htmlCommentRE = regexp.MustCompile(`(?s)<!--.*?-->`)
+	// The nonces, session identifiers and CSRF tokens embedded by dynamic pages
+	contentTokenRE = regexp.MustCompile(`[A-Za-z0-9+/_\-]{24,}={0,2}`)
+	// The timestamps and counters embedded by dynamic pages
+	contentNumberRE = regexp.MustCompile(`[0-9]{6,}`)
+	whitespaceRE    = regexp.MustCompile(`\s+`)
+)
+
+// parkingSignatures are the phrases and hosts found in the pages served by the domain parking
+// services and the registrars for the domains without content.
+var parkingSignatures = []string{
+	"this domain may be for sale",
+	"this domain is for sale",
+	"the domain is for sale",
+	"domain is for sale",
+	"buy this domain",
+	"this domain is parked",
+	"is parked free",
+	"parked-content.godaddy.com",
+	"parkingcrew.net",
+	"sedoparking.com",
+	"bodis.com",
+	"above.com/marketplace",
+	"afternic.com",
+	"hugedomains.com",
+	"dan.com/buy-domain",
+	"domain parking",
+}
+
+// NormalizeBody returns the content of the response body with the values that change between
+// responses, and between the names served by the same page, replaced by placeholders. The names
+// are replaced so the wildcard virtual hosts and the parked pages repeating the name are identical.
+func NormalizeBody(host, body string) string {
+	body = htmlCommentRE.ReplaceAllString(body, "")
+
+	host = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(host), "."))
+	if host != "" {
+		names := []string{host}
+		if d, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil && d != host {
+			names = append(names, d)
+		}
+
+		// The longer name is replaced first, since it contains the registered domain
+		for _, name := range names {
+			re := regexp.MustCompile(`(?i)` + regexp.QuoteMeta(name))
+			body = re.ReplaceAllString(body, "{host}")
+		}
+	}
+
+	body = contentTokenRE.ReplaceAllString(body, "{token}")
+	body = contentNumberRE.ReplaceAllString(body, "{number}")
+	return strings.TrimSpace(whitespaceRE.ReplaceAllString(body, " "))
+}
+
+// BodyHash returns the SHA-256 digest, as a hex string, of the normalized response body served for the host.
+func BodyHash(host, body string) string {
+	sum := sha256.Sum256([]byte(NormalizeBody(host, body)))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// ParkedPage returns true when the response body matches a page of a domain parking service.
+func ParkedPage(body string) bool {
+	body = strings.ToLower(body)
+
+	for _, sig := range parkingSignatures {
+		if strings.Contains(body, sig) {
+			return true
+		}
+	}
+	return false
+}
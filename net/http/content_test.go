@@ -0,0 +1,40 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import "testing"
+
+func TestBodyHash(t *testing.T) {
+	page := func(name, token, ts string) string {
+		return `<html><!-- served by node ` + ts + ` -->
+			<head><title>Welcome to ` + name + `</title></head>
+			<body><form><input type="hidden" name="csrf" value="` + token + `"></form>
+			<p>Generated at ` + ts + `</p></body></html>`
+	}
+
+	a := BodyHash("www.owasp.org", page("www.owasp.org", "Zm9vYmFyYmF6cXV4cXV1eDEyMzQ1Njc4OQ==", "1700000000"))
+	b := BodyHash("DEV.owasp.org", page("dev.OWASP.org", "c2Vjb25kIHRva2VuIGZvciB0aGUgcGFnZQ==", "1700000042"))
+	if a != b {
+		t.Error("The pages differing by the name, token and timestamp were not identical")
+	}
+	if c := BodyHash("www.owasp.org", page("www.owasp.org", "Zm9vYmFyYmF6cXV4cXV1eDEyMzQ1Njc4OQ==", "1700000000")+"<p>More</p>"); a == c {
+		t.Error("The pages with different content were identical")
+	}
+	if len(a) != 64 {
+		t.Errorf("The hash %s is not a SHA-256 digest", a)
+	}
+}
+
+func TestParkedPage(t *testing.T) {
+	if !ParkedPage(`<html><body><h1>owasp.net</h1><p>This domain may be FOR SALE!</p></body></html>`) {
+		t.Error("The parked page was not detected")
+	}
+	if !ParkedPage(`<script src="https://www.parkingcrew.net/js/park.js"></script>`) {
+		t.Error("The page of the parking service was not detected")
+	}
+	if ParkedPage(`<html><body><h1>OWASP Foundation</h1></body></html>`) {
+		t.Error("The page with content was detected as parked")
+	}
+}
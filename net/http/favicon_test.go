@@ -0,0 +1,36 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMurmur3(t *testing.T) {
+	for input, expected := range map[string]uint32{
+		"":      0,
+		"hello": 0x248bfa47,
+		"The quick brown fox jumps over the lazy dog": 0x2e4ff723,
+	} {
+		if h := murmur3([]byte(input), 0); h != expected {
+			t.Errorf("%q: expected %#x, got %#x", input, expected, h)
+		}
+	}
+}
+
+func TestFaviconHash(t *testing.T) {
+	short := []byte("icon")
+	if h := FaviconHash(short); h != int32(murmur3([]byte("aWNvbg==\n"), 0)) {
+		t.Errorf("unexpected hash for the short icon: %d", h)
+	}
+
+	// 60 bytes are encoded as 80 characters, so the line is broken after 76
+	long := []byte(strings.Repeat("a", 60))
+	enc := strings.Repeat("YWFh", 20)
+	if h := FaviconHash(long); h != int32(murmur3([]byte(enc[:76]+"\n"+enc[76:]+"\n"), 0)) {
+		t.Errorf("unexpected hash for the long icon: %d", h)
+	}
+}
@@ -5,6 +5,7 @@
 package http
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
@@ -17,6 +18,7 @@ import (
 	"net/url"
 	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -63,6 +65,8 @@ type Request struct {
 	Header Header
 	Body   string
 	Auth   *BasicAuth
+	// Session provides the headers and cookies configured for the data source making the request
+	Session *Session
 }
 
 // Response represents the HTTP response in the Amass preferred format.
@@ -76,6 +80,8 @@ type Response struct {
 	Body       string
 	Length     int64
 	TLS        *tls.ConnectionState
+	// URL is the location of the response after following the redirects
+	URL string
 }
 
 // BasicAuth contains the data used for HTTP basic authentication.
@@ -159,7 +165,13 @@ func RespToAmassResponse(resp *http.Response) *Response {
 		_ = resp.Body.Close()
 	}
 
+	var location string
+	if resp.Request != nil && resp.Request.URL != nil {
+		location = resp.Request.URL.String()
+	}
+
 	return &Response{
+		URL:        location,
 		Status:     resp.Status,
 		StatusCode: resp.StatusCode,
 		Proto:      resp.Proto,
@@ -172,6 +184,34 @@ func RespToAmassResponse(resp *http.Response) *Response {
 	}
 }
 
+// Dump returns the response in the HTTP/1.x wire format, with the headers sorted by name.
+func (r *Response) Dump() []byte {
+	proto := r.Proto
+	if proto == "" {
+		proto = "HTTP/1.1"
+	}
+	status := r.Status
+	if status == "" {
+		status = fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode))
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s\r\n", proto, status)
+
+	keys := make([]string, 0, len(r.Header))
+	for k := range r.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&buf, "%s: %s\r\n", k, r.Header[k])
+	}
+
+	buf.WriteString("\r\n")
+	buf.WriteString(r.Body)
+	return buf.Bytes()
+}
+
 // CopyCookies copies cookies from one domain to another. Some of our data
 // sources rely on shared auth tokens and this avoids sending extra requests
 // to have the site reissue cookies for the other domains.
@@ -223,7 +263,13 @@ func RequestWebPage(ctx context.Context, r *Request) (*Response, error) {
 		req.Header.Set(k, v)
 	}
 
-	resp, err := DefaultClient.Do(req)
+	client := DefaultClient
+	if r.Session != nil {
+		r.Session.apply(req)
+		client = r.Session.client
+	}
+
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -407,6 +453,15 @@ func NamesFromCert(cert *x509.Certificate) []string {
 	return subdomains.Slice()
 }
 
+// PageTitle returns the trimmed text of the title element in the HTML document.
+func PageTitle(body string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(body))
+	if err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(doc.Find("title").First().Text()), " ")
+}
+
 // CleanName will clean up the names scraped from the web.
 func CleanName(name string) string {
 	clean, err := strconv.Unquote("\"" + strings.TrimSpace(name) + "\"")
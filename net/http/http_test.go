@@ -293,3 +293,38 @@ func TestCleanName(t *testing.T) {
 		}
 	}
 }
+
+func TestRedirectedURL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/login" {
+			http.Redirect(w, r, "/login", http.StatusFound)
+			return
+		}
+		fmt.Fprint(w, "<html><head><title>\n  Sign In  \n</title></head><body><title>Other</title></body></html>")
+	}))
+	defer ts.Close()
+
+	resp, err := RequestWebPage(context.TODO(), &Request{URL: ts.URL + "/admin"})
+	if err != nil {
+		t.Fatalf("Failed to request the web page: %v", err)
+	}
+	if resp.URL != ts.URL+"/login" || resp.StatusCode != 200 {
+		t.Errorf("Unexpected location %s with status %d", resp.URL, resp.StatusCode)
+	}
+	if title := PageTitle(resp.Body); title != "Sign In" {
+		t.Errorf("Unexpected page title: %q", title)
+	}
+}
+
+func TestResponseDump(t *testing.T) {
+	resp := &Response{
+		StatusCode: 404,
+		Header:     Header{"Server": "nginx", "Content-Type": "text/html"},
+		Body:       "<html></html>",
+	}
+
+	expected := "HTTP/1.1 404 Not Found\r\nContent-Type: text/html\r\nServer: nginx\r\n\r\n<html></html>"
+	if got := string(resp.Dump()); got != expected {
+		t.Errorf("Got: %q, Want: %q", got, expected)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"encoding/json"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// The number of endpoints returned for each script, since bundles can hold thousands of literals.
+const maxScriptEndpoints = 500
+
+var (
+	jsLiteralRE   = regexp.MustCompile("\"([^\"\\\\\\n]{2,512})\"|'([^'\\\\\\n]{2,512})'|`([^`\\\\]{2,512})`")
+	jsPathRE      = regexp.MustCompile(`^/[A-Za-z0-9_\-.~%/:@]*[A-Za-z0-9_\-~%/]+(?:\?[A-Za-z0-9_\-.~%&=+,]*)?$`)
+	sourceMapRE   = regexp.MustCompile(`[#@]\s*sourceMappingURL=([^\s*]+)`)
+	jsSkippedExts = map[string]struct{}{
+		".css": {}, ".gif": {}, ".ico": {}, ".jpeg": {}, ".jpg": {}, ".png": {},
+		".svg": {}, ".ttf": {}, ".webp": {}, ".woff": {}, ".woff2": {},
+	}
+)
+
+// ScriptSources returns the absolute URLs of the external scripts referenced by the HTML document.
+func ScriptSources(base, body string) []string {
+	b, err := url.Parse(base)
+	if err != nil {
+		return nil
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(body))
+	if err != nil {
+		return nil
+	}
+
+	seen := make(map[string]struct{})
+	var srcs []string
+	doc.Find("script[src]").Each(func(_ int, s *goquery.Selection) {
+		src, _ := s.Attr("src")
+
+		if u := resolveReference(b, strings.TrimSpace(src)); u != "" {
+			if _, found := seen[u]; !found {
+				seen[u] = struct{}{}
+				srcs = append(srcs, u)
+			}
+		}
+	})
+	return srcs
+}
+
+// JSEndpoints returns the absolute URLs of the endpoints found in the string literals of the script,
+// resolving the paths against the URL of the script. When the content is a source map, the literals
+// of the original sources are searched instead.
+func JSEndpoints(base, content string) []string {
+	b, err := url.Parse(base)
+	if err != nil {
+		return nil
+	}
+
+	sources := []string{content}
+	if srcs, ok := sourceMapContents(content); ok {
+		sources = srcs
+	}
+
+	set := make(map[string]struct{})
+	for _, src := range sources {
+		for _, m := range jsLiteralRE.FindAllStringSubmatch(src, -1) {
+			lit := m[1] + m[2] + m[3]
+			// The expressions of template literals end the static part of the URL
+			if i := strings.Index(lit, "${"); i >= 0 {
+				lit = lit[:i]
+			}
+
+			if u := endpointURL(b, strings.TrimSpace(lit)); u != "" {
+				set[u] = struct{}{}
+			}
+		}
+	}
+
+	endpoints := make([]string, 0, len(set))
+	for u := range set {
+		endpoints = append(endpoints, u)
+	}
+	sort.Strings(endpoints)
+	if len(endpoints) > maxScriptEndpoints {
+		endpoints = endpoints[:maxScriptEndpoints]
+	}
+	return endpoints
+}
+
+// SourceMapURL returns the absolute URL of the source map referenced by the script, if any.
+func SourceMapURL(base, content string) string {
+	b, err := url.Parse(base)
+	if err != nil {
+		return ""
+	}
+
+	m := sourceMapRE.FindAllStringSubmatch(content, -1)
+	if len(m) == 0 {
+		return ""
+	}
+	// Source maps embedded as data URIs do not need to be retrieved
+	ref := m[len(m)-1][1]
+	if strings.HasPrefix(ref, "data:") {
+		return ""
+	}
+	return resolveReference(b, ref)
+}
+
+func sourceMapContents(content string) ([]string, bool) {
+	trimmed := strings.TrimSpace(content)
+	if !strings.HasPrefix(trimmed, "{") || !strings.Contains(trimmed, "\"mappings\"") {
+		return nil, false
+	}
+
+	var sm struct {
+		SourcesContent []string `json:"sourcesContent"`
+	}
+	if err := json.Unmarshal([]byte(trimmed), &sm); err != nil {
+		return nil, false
+	}
+	return sm.SourcesContent, true
+}
+
+func endpointURL(base *url.URL, lit string) string {
+	switch {
+	case strings.HasPrefix(lit, "http://") || strings.HasPrefix(lit, "https://"):
+	case strings.HasPrefix(lit, "//"):
+	case jsPathRE.MatchString(lit):
+	default:
+		return ""
+	}
+
+	u := resolveReference(base, lit)
+	if u == "" {
+		return ""
+	}
+	if parsed, err := url.Parse(u); err != nil || strings.ContainsAny(parsed.Host, " <>\"'") {
+		return ""
+	} else if _, found := jsSkippedExts[strings.ToLower(path.Ext(parsed.Path))]; found {
+		return ""
+	}
+	return u
+}
+
+func resolveReference(base *url.URL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+
+	u := base.ResolveReference(r)
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ""
+	}
+	u.Fragment = ""
+	return u.String()
+}
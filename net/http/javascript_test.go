@@ -0,0 +1,62 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScriptSources(t *testing.T) {
+	page := `<html><head>
+		<script src="/static/js/main.4f2c.js"></script>
+		<script src="https://cdn.example.com/lib.js"></script>
+		<script src="vendor.js#v1"></script>
+		<script src="/static/js/main.4f2c.js"></script>
+		<script>var inline = true;</script>
+	</head></html>`
+
+	expected := []string{
+		"https://www.owasp.org/static/js/main.4f2c.js",
+		"https://cdn.example.com/lib.js",
+		"https://www.owasp.org/app/vendor.js",
+	}
+	if srcs := ScriptSources("https://www.owasp.org/app/index.html", page); !reflect.DeepEqual(srcs, expected) {
+		t.Errorf("Expected the scripts %v, got %v", expected, srcs)
+	}
+}
+
+func TestJSEndpoints(t *testing.T) {
+	script := `const api="https://api.owasp.org/v2/",n='/api/users?page=1';
+		fetch(` + "`/api/projects/${id}/members`" + `);var logo="/img/logo.png",msg="Hello world",
+		cdn="//cdn.owasp.org/assets";
+		//# sourceMappingURL=main.js.map`
+
+	expected := []string{
+		"https://api.owasp.org/v2/",
+		"https://cdn.owasp.org/assets",
+		"https://www.owasp.org/api/projects/",
+		"https://www.owasp.org/api/users?page=1",
+	}
+	base := "https://www.owasp.org/static/main.js"
+	if endpoints := JSEndpoints(base, script); !reflect.DeepEqual(endpoints, expected) {
+		t.Errorf("Expected the endpoints %v, got %v", expected, endpoints)
+	}
+	if u := SourceMapURL(base, script); u != "https://www.owasp.org/static/main.js.map" {
+		t.Errorf("The source map was not found: %s", u)
+	}
+	if u := SourceMapURL(base, "//# sourceMappingURL=data:application/json;base64,e30="); u != "" {
+		t.Errorf("Expected the embedded source map to be ignored, got %s", u)
+	}
+}
+
+func TestJSEndpointsSourceMap(t *testing.T) {
+	sm := `{"version":3,"sources":["src/api.ts"],"sourcesContent":["export const base = \"https://internal.owasp.org/graphql\";"],"mappings":"AAAA"}`
+
+	expected := []string{"https://internal.owasp.org/graphql"}
+	if endpoints := JSEndpoints("https://www.owasp.org/main.js.map", sm); !reflect.DeepEqual(endpoints, expected) {
+		t.Errorf("Expected the endpoints %v, got %v", expected, endpoints)
+	}
+}
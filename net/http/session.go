@@ -0,0 +1,120 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// The number of redirects followed by the requests of a session, as with the default client.
+const maxSessionRedirects = 10
+
+// Session provides the headers and cookies added to the requests of a single data source sent to the
+// domains of the session and their subdomains. Cookies set by the responses are kept in a cookie jar
+// owned by the session, so an authenticated web session is never shared with the other data sources.
+type Session struct {
+	header  Header
+	domains []string
+	// cookies holds the configured cookies, which are matched against the requested URLs
+	cookies http.CookieJar
+	client  *http.Client
+}
+
+// NewSession returns a Session adding the headers and cookies to the requests sent to the domains.
+func NewSession(domains []string, hdr Header, cookies map[string]string) *Session {
+	jar, _ := cookiejar.New(nil)
+	configured, _ := cookiejar.New(nil)
+
+	s := &Session{
+		header:  make(Header),
+		cookies: configured,
+	}
+	s.client = &http.Client{
+		Timeout:       DefaultClient.Timeout,
+		Transport:     DefaultClient.Transport,
+		Jar:           jar,
+		CheckRedirect: s.checkRedirect,
+	}
+	for k, v := range hdr {
+		s.header[k] = v
+	}
+
+	names := make([]string, 0, len(cookies))
+	for name := range cookies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, d := range domains {
+		d = strings.Trim(strings.ToLower(strings.TrimSpace(d)), ".")
+		if d == "" {
+			continue
+		}
+		s.domains = append(s.domains, d)
+
+		var list []*http.Cookie
+		for _, name := range names {
+			list = append(list, &http.Cookie{Name: name, Value: cookies[name], Domain: d, Path: "/"})
+		}
+		configured.SetCookies(&url.URL{Scheme: "https", Host: d, Path: "/"}, list)
+	}
+	return s
+}
+
+// inScope returns true when the host is one of the domains of the session or their subdomains.
+func (s *Session) inScope(host string) bool {
+	host = strings.ToLower(host)
+
+	for _, d := range s.domains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply sets the session headers and the configured cookies not yet replaced by the server, when
+// the request is sent to the domains of the session.
+func (s *Session) apply(req *http.Request) {
+	if !s.inScope(req.URL.Hostname()) {
+		return
+	}
+
+	for k, v := range s.header {
+		req.Header.Set(k, v)
+	}
+
+	set := make(map[string]struct{})
+	for _, c := range s.client.Jar.Cookies(req.URL) {
+		set[c.Name] = struct{}{}
+	}
+	for _, c := range s.cookies.Cookies(req.URL) {
+		if _, found := set[c.Name]; !found {
+			req.AddCookie(c)
+		}
+	}
+}
+
+// checkRedirect removes the session headers and the configured cookies from the redirects leaving the
+// domains of the session, since the client copies them from the original request.
+func (s *Session) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxSessionRedirects {
+		return errors.New("stopped after 10 redirects")
+	}
+
+	if !s.inScope(req.URL.Hostname()) {
+		for k := range s.header {
+			req.Header.Del(k)
+		}
+		// The cookies set by the responses are added from the jar once the redirect is sent
+		req.Header.Del("Cookie")
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSession(t *testing.T) {
+	var token, session, other string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = r.Header.Get("X-Token")
+		session, other = "", ""
+		if c, err := r.Cookie("session"); err == nil {
+			session = c.Value
+		}
+		if c, err := r.Cookie("other"); err == nil {
+			other = c.Value
+		}
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "renewed", Path: "/"})
+		}
+	}))
+	defer srv.Close()
+
+	s := NewSession([]string{"127.0.0.1"}, Header{"X-Token": "secret"}, map[string]string{"session": "initial", "other": "value"})
+	req := func(path string, sess *Session) {
+		if _, err := RequestWebPage(context.Background(), &Request{URL: srv.URL + path, Session: sess}); err != nil {
+			t.Fatalf("Failed to request %s: %v", path, err)
+		}
+	}
+
+	req("/", s)
+	if token != "secret" || session != "initial" || other != "value" {
+		t.Errorf("The session was not applied: token %q, session %q, other %q", token, session, other)
+	}
+
+	req("/login", s)
+	req("/", s)
+	if session != "renewed" || other != "value" {
+		t.Errorf("The cookie set by the server did not replace the configured cookie: session %q, other %q", session, other)
+	}
+
+	req("/", nil)
+	if token != "" || session != "" {
+		t.Errorf("The session leaked into a request without it: token %q, session %q", token, session)
+	}
+
+	// The headers and cookies are only sent to the domains of the session
+	req("/", NewSession([]string{"example.com"}, Header{"X-Token": "secret"}, map[string]string{"session": "initial"}))
+	if token != "" || session != "" {
+		t.Errorf("The session leaked to another host: token %q, session %q", token, session)
+	}
+}
+
+func TestSessionScope(t *testing.T) {
+	s := NewSession([]string{"Example.com."}, nil, map[string]string{"session": "initial"})
+
+	for host, expected := range map[string]bool{
+		"example.com":      true,
+		"www.example.com":  true,
+		"WWW.EXAMPLE.COM":  true,
+		"badexample.com":   false,
+		"example.com.evil": false,
+		"www.other.org":    false,
+	} {
+		if s.inScope(host) != expected {
+			t.Errorf("inScope(%s) returned %t", host, !expected)
+		}
+	}
+
+	for u, expected := range map[string]bool{
+		"https://www.example.com/login": true,
+		"http://example.com/":           true,
+		"https://www.other.org/":        false,
+	} {
+		req, _ := http.NewRequest(http.MethodGet, u, nil)
+		s.apply(req)
+		if _, err := req.Cookie("session"); (err == nil) != expected {
+			t.Errorf("The cookie sent to %s did not match the domains of the session", u)
+		}
+	}
+}
+
+func TestSessionRedirect(t *testing.T) {
+	var token, session string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = r.Header.Get("X-Token")
+		if c, err := r.Cookie("session"); err == nil {
+			session = c.Value
+		}
+	}))
+	defer other.Close()
+
+	// The other server is reached through another name of the loopback address
+	target := strings.Replace(other.URL, "127.0.0.1", "localhost", 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target+"/landing", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	s := NewSession([]string{"127.0.0.1"}, Header{"X-Token": "secret"}, map[string]string{"session": "initial"})
+	if _, err := RequestWebPage(context.Background(), &Request{URL: srv.URL, Session: s}); err != nil {
+		t.Fatalf("Failed to request the page: %v", err)
+	}
+	if token != "" || session != "" {
+		t.Errorf("The session followed the redirect to another host: token %q, session %q", token, session)
+	}
+}
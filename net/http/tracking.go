@@ -0,0 +1,77 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// The kinds of the tracking IDs embedded in web pages by the analytics and advertising services.
+const (
+	GoogleAnalytics  = "google_analytics"
+	GoogleTagManager = "google_tag_manager"
+	GoogleAdsense    = "google_adsense"
+	FacebookPixel    = "facebook_pixel"
+)
+
+var trackingIDPatterns = []struct {
+	Kind string
+	RE   *regexp.Regexp
+}{
+	{GoogleAnalytics, regexp.MustCompile(`\b(UA-[0-9]{4,10}-[0-9]{1,4})\b`)},
+	{GoogleAnalytics, regexp.MustCompile(`\b(G-[A-Z0-9]{8,12})\b`)},
+	{GoogleTagManager, regexp.MustCompile(`\b(GTM-[A-Z0-9]{4,9})\b`)},
+	{GoogleAdsense, regexp.MustCompile(`\b(?:ca-)?(pub-[0-9]{10,20})\b`)},
+	{FacebookPixel, regexp.MustCompile(`fbq\(\s*['"]init['"]\s*,\s*['"]?([0-9]{10,20})['"]?`)},
+	{FacebookPixel, regexp.MustCompile(`facebook\.com/tr\?id=([0-9]{10,20})`)},
+}
+
+// TrackingID is an identifier of an analytics or advertising account found in a web page.
+// The pages sharing the identifier are likely operated by the same organization.
+type TrackingID struct {
+	Kind string `json:"kind"`
+	ID   string `json:"id"`
+}
+
+// TrackingIDs returns the tracking IDs found in the content of the web page, ordered by the IDs.
+func TrackingIDs(body string) []*TrackingID {
+	seen := make(map[string]struct{})
+
+	var ids []*TrackingID
+	for _, p := range trackingIDPatterns {
+		for _, m := range p.RE.FindAllStringSubmatch(body, -1) {
+			if _, found := seen[m[1]]; !found {
+				seen[m[1]] = struct{}{}
+				ids = append(ids, &TrackingID{Kind: p.Kind, ID: m[1]})
+			}
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].ID < ids[j].ID
+	})
+	return ids
+}
+
+// TrackingIDKind returns the kind of the tracking ID, or an empty string when the ID is not recognized.
+func TrackingIDKind(id string) string {
+	id = strings.TrimSpace(id)
+
+	for _, p := range trackingIDPatterns {
+		// The Facebook pixels are only recognized within the code loading them
+		if p.Kind == FacebookPixel {
+			continue
+		}
+		if m := p.RE.FindStringSubmatch(id); m != nil && m[1] == strings.TrimPrefix(id, "ca-") {
+			return p.Kind
+		}
+	}
+	if len(id) >= 10 && len(id) <= 20 && strings.Trim(id, "0123456789") == "" {
+		return FacebookPixel
+	}
+	return ""
+}
@@ -0,0 +1,48 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTrackingIDs(t *testing.T) {
+	page := `<html><head>
+		<script async src="https://www.googletagmanager.com/gtag/js?id=G-1A2B3C4D5E"></script>
+		<script>gtag('config', 'G-1A2B3C4D5E'); ga('create', 'UA-1234567-2', 'auto');</script>
+		<script>(function(w,d,s,l,i){})(window,document,'script','dataLayer','GTM-K9X2ZQ');</script>
+		<script data-ad-client="ca-pub-1234567890123456"></script>
+		<script>fbq('init', '123456789012345'); fbq('track', 'PageView');</script>
+		<p>UA-12-1 is not an ID, nor is G-SHORT</p>
+	</head></html>`
+
+	expected := []*TrackingID{
+		{Kind: FacebookPixel, ID: "123456789012345"},
+		{Kind: GoogleAnalytics, ID: "G-1A2B3C4D5E"},
+		{Kind: GoogleTagManager, ID: "GTM-K9X2ZQ"},
+		{Kind: GoogleAnalytics, ID: "UA-1234567-2"},
+		{Kind: GoogleAdsense, ID: "pub-1234567890123456"},
+	}
+	if ids := TrackingIDs(page); !reflect.DeepEqual(ids, expected) {
+		t.Errorf("Expected the tracking IDs %v, got %v", expected, ids)
+	}
+}
+
+func TestTrackingIDKind(t *testing.T) {
+	for id, expected := range map[string]string{
+		"UA-1234567-2":            GoogleAnalytics,
+		"G-1A2B3C4D5E":            GoogleAnalytics,
+		"GTM-K9X2ZQ":              GoogleTagManager,
+		"ca-pub-1234567890123456": GoogleAdsense,
+		"123456789012345":         FacebookPixel,
+		"UA-1234567-2 extra":      "",
+		"owasp.org":               "",
+	} {
+		if kind := TrackingIDKind(id); kind != expected {
+			t.Errorf("The ID %s returned the kind %q, expected %q", id, kind, expected)
+		}
+	}
+}
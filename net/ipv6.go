@@ -0,0 +1,98 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package net
+
+import (
+	"encoding/binary"
+	"net"
+	"strconv"
+)
+
+// The allocation patterns used to expand an IPv6 address into its likely neighbors.
+const (
+	// IPv6LowByte selects the low-numbered interface identifiers and those next to the address
+	IPv6LowByte = "low_byte"
+	// IPv6Words selects the interface identifiers spelling words in hexadecimal, such as ::cafe
+	IPv6Words = "words"
+	// IPv6Ports selects the interface identifiers matching the ports of common services, such as ::443 and ::1bb
+	IPv6Ports = "ports"
+)
+
+// IPv6Patterns are the allocation patterns supported by IPv6Neighbors.
+var IPv6Patterns = []string{IPv6LowByte, IPv6Words, IPv6Ports}
+
+// The number of low-numbered interface identifiers and of those on each side of the address
+const (
+	ipv6LowCount      = 64
+	ipv6AdjacentCount = 8
+)
+
+var ipv6Words = []uint64{
+	0xdead, 0xbeef, 0xdeadbeef, 0xcafe, 0xbabe, 0xcafebabe, 0xface, 0xfaceb00c, 0xc0de,
+	0xc0ffee, 0xf00d, 0xfeed, 0xbad, 0xace, 0xb00c, 0xd00d, 0xdead0000beef, 0xa, 0xb, 0xc,
+	0xd, 0xe, 0xf, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff, 0xabc, 0xabcd, 0xffff,
+}
+
+var ipv6Ports = []int{21, 22, 25, 53, 80, 110, 143, 389, 443, 465, 587, 993, 995, 1433, 3306, 3389, 5432, 8080, 8443}
+
+// IPv6Neighbors returns up to max addresses within the /64 network of the IPv6 address, selected
+// by the allocation patterns commonly used to number the hosts. The address is not included.
+func IPv6Neighbors(addr string, patterns []string, max int) []net.IP {
+	ip := net.ParseIP(addr)
+	if ip == nil || ip.To4() != nil || max <= 0 {
+		return nil
+	}
+	ip = ip.To16()
+
+	prefix := binary.BigEndian.Uint64(ip[:8])
+	iid := binary.BigEndian.Uint64(ip[8:])
+
+	var iids []uint64
+	for _, p := range patterns {
+		switch p {
+		case IPv6LowByte:
+			for i := uint64(1); i <= ipv6LowCount; i++ {
+				iids = append(iids, i)
+			}
+			// Addresses numbered in sequence are found next to each other
+			if iid>>16 == 0 {
+				for i := uint64(1); i <= ipv6AdjacentCount; i++ {
+					iids = append(iids, iid+i)
+					if iid > i {
+						iids = append(iids, iid-i)
+					}
+				}
+			}
+		case IPv6Words:
+			iids = append(iids, ipv6Words...)
+		case IPv6Ports:
+			for _, port := range ipv6Ports {
+				// The port written as hexadecimal digits (::443), and converted to hexadecimal (::1bb)
+				if v, err := strconv.ParseUint(strconv.Itoa(port), 16, 64); err == nil {
+					iids = append(iids, v)
+				}
+				iids = append(iids, uint64(port))
+			}
+		}
+	}
+
+	seen := map[uint64]struct{}{iid: {}}
+	var results []net.IP
+	for _, id := range iids {
+		if _, found := seen[id]; found || id == 0 {
+			continue
+		}
+		seen[id] = struct{}{}
+
+		n := make(net.IP, net.IPv6len)
+		binary.BigEndian.PutUint64(n[:8], prefix)
+		binary.BigEndian.PutUint64(n[8:], id)
+		results = append(results, n)
+		if len(results) >= max {
+			break
+		}
+	}
+	return results
+}
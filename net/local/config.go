@@ -0,0 +1,118 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package local
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// FromConfig returns the Options of the 'internal' section of the configuration options. Nil is returned
+// when the internal discovery mode has not been enabled. All the protocols are used when the section does
+// not list them, and the NetBIOS queries sweep the networks of the local interfaces without subnets.
+func FromConfig(cfg *config.Config) (*Options, error) {
+	raw, ok := cfg.Options["internal"]
+	if !ok {
+		return nil, nil
+	}
+
+	settings, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("internal is not a map[string]interface{}")
+	}
+
+	if enabled, ok := settings["enabled"].(bool); !ok || !enabled {
+		return nil, nil
+	}
+
+	opts := &Options{
+		MDNS:    true,
+		NetBIOS: true,
+		LLMNR:   true,
+		Timeout: DefaultTimeout,
+	}
+	if raw, ok := settings["protocols"]; ok {
+		list, err := stringList("protocols", raw)
+		if err != nil {
+			return nil, err
+		}
+
+		opts.MDNS, opts.NetBIOS, opts.LLMNR = false, false, false
+		for _, p := range list {
+			switch strings.ToLower(p) {
+			case "mdns":
+				opts.MDNS = true
+			case "netbios":
+				opts.NetBIOS = true
+			case "llmnr":
+				opts.LLMNR = true
+			default:
+				return nil, fmt.Errorf("internal protocols contains an unknown protocol: %s", p)
+			}
+		}
+	}
+	if raw, ok := settings["domain"]; ok {
+		domain, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("internal domain is not a string")
+		}
+		opts.Domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), ".")
+	}
+	if raw, ok := settings["subnets"]; ok {
+		list, err := stringList("subnets", raw)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range list {
+			_, ipnet, err := net.ParseCIDR(s)
+			if err != nil || ipnet.IP.To4() == nil {
+				return nil, fmt.Errorf("internal subnets contains an invalid IPv4 network: %s", s)
+			}
+			opts.Subnets = append(opts.Subnets, ipnet)
+		}
+	}
+	if raw, ok := settings["names"]; ok {
+		list, err := stringList("names", raw)
+		if err != nil {
+			return nil, err
+		}
+		opts.Names = list
+	}
+	if raw, ok := settings["timeout"]; ok {
+		str, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("internal timeout is not a string")
+		}
+
+		d, err := time.ParseDuration(str)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("internal timeout is not a valid duration: %s", str)
+		}
+		opts.Timeout = d
+	}
+	return opts, nil
+}
+
+func stringList(key string, raw interface{}) ([]string, error) {
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("internal %s is not a list", key)
+	}
+
+	var values []string
+	for _, v := range list {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("internal %s contains a value that is not a string: %v", key, v)
+		}
+		values = append(values, s)
+	}
+	return values, nil
+}
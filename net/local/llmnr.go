@@ -0,0 +1,82 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package local
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+var llmnrAddr = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 252), Port: 5355}
+
+// QueryLLMNR resolves the single-label names with Link-Local Multicast Name Resolution,
+// and returns the hosts that responded with their addresses.
+func QueryLLMNR(ctx context.Context, names []string, timeout time.Duration) ([]*Host, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	for _, name := range llmnrNames(names) {
+		for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+			msg := new(dns.Msg)
+			msg.SetQuestion(dns.Fqdn(name), qtype)
+			// LLMNR does not use the recursion desired flag
+			msg.RecursionDesired = false
+
+			data, err := msg.Pack()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := conn.WriteToUDP(data, llmnrAddr); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var hosts []*Host
+	collect(ctx, conn, timeout, func(data []byte, _ net.Addr) {
+		msg := new(dns.Msg)
+		if err := msg.Unpack(data); err == nil && msg.Response {
+			hosts = append(hosts, llmnrHosts(msg)...)
+		}
+	})
+	return Merge(hosts), nil
+}
+
+// llmnrNames returns the distinct names without the '.local' suffix, since LLMNR resolves single-label names.
+func llmnrNames(names []string) []string {
+	var labels []string
+
+	for _, name := range names {
+		name = strings.Trim(strings.ToLower(strings.TrimSpace(name)), ".")
+		name = strings.TrimSuffix(name, ".local")
+		if name != "" && !strings.Contains(name, ".") {
+			labels = appendUnique(labels, name)
+		}
+	}
+	return labels
+}
+
+func llmnrHosts(msg *dns.Msg) []*Host {
+	var hosts []*Host
+
+	for _, rr := range msg.Answer {
+		name := strings.Trim(strings.ToLower(rr.Header().Name), ".")
+
+		switch v := rr.(type) {
+		case *dns.A:
+			hosts = append(hosts, &Host{Name: name, Addresses: []string{v.A.String()}, Source: SourceLLMNR})
+		case *dns.AAAA:
+			hosts = append(hosts, &Host{Name: name, Addresses: []string{v.AAAA.String()}, Source: SourceLLMNR})
+		}
+	}
+	return hosts
+}
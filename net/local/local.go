@@ -0,0 +1,184 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package local discovers the hosts of the local network segments for internal engagements, using
+// multicast DNS with DNS-SD, NetBIOS name queries and LLMNR. The protocols do not cross routers,
+// so only the segments of the network interfaces are reached, and only IPv4 is used.
+package local
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The sources of the hosts, used as the data source names of the names submitted to the enumeration.
+const (
+	SourceMDNS    = "mDNS"
+	SourceNetBIOS = "NetBIOS"
+	SourceLLMNR   = "LLMNR"
+)
+
+// DefaultTimeout is the time spent waiting for the responses of each protocol.
+const DefaultTimeout = 3 * time.Second
+
+// Host is a name found on the local network, with the addresses it is reachable at.
+type Host struct {
+	Name      string
+	Addresses []string
+	// Services are the DNS-SD service instances advertised by the host
+	Services []*Service
+	Source   string
+}
+
+// Service is a DNS-SD service instance, such as 'printer._ipp._tcp.local'.
+type Service struct {
+	Instance string
+	Type     string
+	Port     int
+}
+
+// Options configures the protocols used by the discovery.
+type Options struct {
+	MDNS    bool
+	NetBIOS bool
+	LLMNR   bool
+	// Domain qualifies the single-label and '.local' names, such as 'corp.example.com'
+	Domain string
+	// Subnets are swept with the NetBIOS name queries
+	Subnets []*net.IPNet
+	// Names are resolved with LLMNR, in addition to the names found by the other protocols
+	Names   []string
+	Timeout time.Duration
+}
+
+// Qualify returns the FQDN of a name found on the local network. The '.local' suffix of multicast DNS
+// is replaced by the domain of the options, which also qualifies the single-label names. Without a
+// domain, the single-label names are returned with the '.local' suffix.
+func (o *Options) Qualify(name string) string {
+	name = strings.Trim(strings.ToLower(strings.TrimSpace(name)), ".")
+	if name == "" {
+		return ""
+	}
+
+	label := strings.TrimSuffix(name, ".local")
+	if label != name || !strings.Contains(name, ".") {
+		if o.Domain == "" {
+			return label + ".local"
+		}
+		return label + "." + strings.Trim(strings.ToLower(o.Domain), ".")
+	}
+	return name
+}
+
+// Merge combines the hosts sharing a name, keeping the source of the first one.
+func Merge(hosts []*Host) []*Host {
+	byName := make(map[string]*Host)
+
+	var merged []*Host
+	for _, h := range hosts {
+		name := strings.Trim(strings.ToLower(h.Name), ".")
+		if name == "" {
+			continue
+		}
+
+		m, found := byName[name]
+		if !found {
+			m = &Host{Name: name, Source: h.Source}
+			byName[name] = m
+			merged = append(merged, m)
+		}
+		m.Addresses = appendUnique(m.Addresses, h.Addresses...)
+		m.Services = append(m.Services, h.Services...)
+	}
+
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Name < merged[j].Name })
+	for _, m := range merged {
+		sort.Strings(m.Addresses)
+	}
+	return merged
+}
+
+// InterfaceSubnets returns the IPv4 networks of the local interfaces that are up, excluding the
+// loopback interfaces. Networks larger than a /22 are reduced to the /22 containing the address.
+func InterfaceSubnets() []*net.IPNet {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+
+	var subnets []*net.IPNet
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			ipnet, ok := a.(*net.IPNet)
+			if !ok || ipnet.IP.To4() == nil {
+				continue
+			}
+
+			if ones, _ := ipnet.Mask.Size(); ones < 22 {
+				ipnet.Mask = net.CIDRMask(22, 32)
+			}
+			subnets = append(subnets, &net.IPNet{IP: ipnet.IP.Mask(ipnet.Mask), Mask: ipnet.Mask})
+		}
+	}
+	return subnets
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+
+		for _, existing := range list {
+			if existing == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// collect reads the datagrams received on the connection until the timeout expires or the context is done.
+func collect(ctx context.Context, conn net.PacketConn, timeout time.Duration, handle func([]byte, net.Addr)) {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetReadDeadline(deadline)
+
+	buf := make([]byte, 9000)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		handle(buf[:n], addr)
+	}
+}
+
+func addrIP(addr net.Addr) string {
+	if u, ok := addr.(*net.UDPAddr); ok {
+		return u.IP.String()
+	}
+	return ""
+}
@@ -0,0 +1,208 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package local
+
+import (
+	"encoding/binary"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestQualify(t *testing.T) {
+	tests := []struct {
+		domain string
+		name   string
+		want   string
+	}{
+		{"", "Printer.local.", "printer.local"},
+		{"", "FILESRV", "filesrv.local"},
+		{"corp.example.com", "printer.local", "printer.corp.example.com"},
+		{"corp.example.com.", "FILESRV", "filesrv.corp.example.com"},
+		{"corp.example.com", "www.example.com", "www.example.com"},
+		{"corp.example.com", " ", ""},
+	}
+
+	for _, test := range tests {
+		opts := &Options{Domain: test.domain}
+
+		if got := opts.Qualify(test.name); got != test.want {
+			t.Errorf("Qualify(%q) with the domain %q returned %q, expected %q", test.name, test.domain, got, test.want)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	hosts := Merge([]*Host{
+		{Name: "filesrv.", Addresses: []string{"192.168.1.20"}, Source: SourceNetBIOS},
+		{Name: "Printer", Addresses: []string{"192.168.1.10"}, Source: SourceMDNS},
+		{Name: "filesrv", Addresses: []string{"192.168.1.20", "192.168.1.2"}, Source: SourceLLMNR},
+		{Name: "", Addresses: []string{"192.168.1.30"}},
+	})
+
+	if len(hosts) != 2 {
+		t.Fatalf("Merge returned %d hosts, expected 2", len(hosts))
+	}
+	if h := hosts[0]; h.Name != "filesrv" || h.Source != SourceNetBIOS ||
+		!reflect.DeepEqual(h.Addresses, []string{"192.168.1.2", "192.168.1.20"}) {
+		t.Errorf("Merge returned an unexpected first host: %+v", h)
+	}
+	if h := hosts[1]; h.Name != "printer" || len(h.Addresses) != 1 {
+		t.Errorf("Merge returned an unexpected second host: %+v", h)
+	}
+}
+
+func TestNodeStatusQuery(t *testing.T) {
+	query := nodeStatusQuery(0x1234)
+
+	if len(query) != 50 {
+		t.Fatalf("The node status query has %d bytes, expected 50", len(query))
+	}
+	if id := binary.BigEndian.Uint16(query); id != 0x1234 {
+		t.Errorf("The node status query has the ID %#x", id)
+	}
+	if string(query[13:17]) != "CKAA" {
+		t.Errorf("The node status query does not encode the wildcard name: %q", query[13:45])
+	}
+	if qtype := binary.BigEndian.Uint16(query[46:]); qtype != typeNBSTAT {
+		t.Errorf("The node status query has the type %#x", qtype)
+	}
+}
+
+func TestParseNodeStatus(t *testing.T) {
+	resp := []byte{0x12, 0x34, 0x84, 0x00, 0, 0, 0, 1, 0, 0, 0, 0}
+	resp = append(resp, encodeNetBIOSName("*", 0)...)
+	resp = append(resp, 0, typeNBSTAT, 0, 1, 0, 0, 0, 0, 0, 0)
+
+	entry := func(name string, suffix byte, flags uint16) []byte {
+		e := []byte(name + "               ")[:15]
+		e = append(e, suffix)
+		return binary.BigEndian.AppendUint16(e, flags)
+	}
+	resp = append(resp, 3)
+	resp = append(resp, entry("WORKGROUP", 0x00, flagGroup)...)
+	resp = append(resp, entry("FILESRV", 0x20, 0)...)
+	resp = append(resp, entry("FILESRV", 0x00, 0)...)
+
+	if name, err := parseNodeStatus(resp); err != nil || name != "filesrv" {
+		t.Errorf("parseNodeStatus returned %q and %v, expected filesrv", name, err)
+	}
+	if _, err := parseNodeStatus(resp[:12]); err == nil {
+		t.Error("parseNodeStatus did not return an error for a truncated response")
+	}
+	if _, err := parseNodeStatus(nodeStatusQuery(1)); err == nil {
+		t.Error("parseNodeStatus did not return an error for a query")
+	}
+}
+
+func TestSubnetAddrs(t *testing.T) {
+	_, subnet, _ := net.ParseCIDR("192.168.1.0/30")
+
+	addrs := subnetAddrs(subnet, 10)
+	if len(addrs) != 2 || addrs[0].String() != "192.168.1.1" || addrs[1].String() != "192.168.1.2" {
+		t.Errorf("subnetAddrs returned %v for %s", addrs, subnet)
+	}
+
+	_, subnet, _ = net.ParseCIDR("10.0.0.0/16")
+	if addrs := subnetAddrs(subnet, 100); len(addrs) != 100 {
+		t.Errorf("subnetAddrs returned %d addresses, expected the maximum of 100", len(addrs))
+	}
+}
+
+func TestBrowser(t *testing.T) {
+	b := newBrowser()
+
+	msg := new(dns.Msg)
+	msg.Response = true
+	msg.Answer = []dns.RR{
+		&dns.PTR{Hdr: dns.RR_Header{Name: servicesName, Rrtype: dns.TypePTR}, Ptr: "_ipp._tcp.local."},
+	}
+	if types := b.process(msg); !reflect.DeepEqual(types, []string{"_ipp._tcp.local."}) {
+		t.Errorf("process returned the service types %v", types)
+	}
+	if types := b.process(msg); len(types) != 0 {
+		t.Errorf("process returned the service types %v again", types)
+	}
+
+	msg.Answer = []dns.RR{
+		&dns.PTR{Hdr: dns.RR_Header{Name: "_ipp._tcp.local.", Rrtype: dns.TypePTR}, Ptr: "Office._ipp._tcp.local."},
+	}
+	msg.Extra = []dns.RR{
+		&dns.SRV{Hdr: dns.RR_Header{Name: "Office._ipp._tcp.local.", Rrtype: dns.TypeSRV}, Port: 631, Target: "Printer.local."},
+		&dns.A{Hdr: dns.RR_Header{Name: "Printer.local.", Rrtype: dns.TypeA}, A: net.ParseIP("192.168.1.10")},
+	}
+	b.process(msg)
+
+	hosts := b.hosts()
+	if len(hosts) != 1 {
+		t.Fatalf("hosts returned %d hosts, expected 1", len(hosts))
+	}
+	if h := hosts[0]; h.Name != "printer.local" || !reflect.DeepEqual(h.Addresses, []string{"192.168.1.10"}) {
+		t.Errorf("hosts returned an unexpected host: %+v", h)
+	}
+	if svcs := hosts[0].Services; len(svcs) != 1 || svcs[0].Type != "_ipp._tcp.local" ||
+		svcs[0].Instance != "office._ipp._tcp.local" || svcs[0].Port != 631 {
+		t.Errorf("hosts returned unexpected services: %+v", svcs)
+	}
+}
+
+func TestLLMNR(t *testing.T) {
+	names := llmnrNames([]string{"FILESRV", "filesrv.local.", "printer.local", "www.example.com", ""})
+	if !reflect.DeepEqual(names, []string{"filesrv", "printer"}) {
+		t.Errorf("llmnrNames returned %v", names)
+	}
+
+	msg := new(dns.Msg)
+	msg.Answer = []dns.RR{
+		&dns.A{Hdr: dns.RR_Header{Name: "FILESRV.", Rrtype: dns.TypeA}, A: net.ParseIP("192.168.1.20")},
+		&dns.AAAA{Hdr: dns.RR_Header{Name: "filesrv.", Rrtype: dns.TypeAAAA}, AAAA: net.ParseIP("fe80::1")},
+	}
+	hosts := Merge(llmnrHosts(msg))
+	if len(hosts) != 1 || hosts[0].Name != "filesrv" || len(hosts[0].Addresses) != 2 || hosts[0].Source != SourceLLMNR {
+		t.Errorf("llmnrHosts returned unexpected hosts: %+v", hosts)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	if opts, err := FromConfig(cfg); err != nil || opts != nil {
+		t.Errorf("FromConfig returned %v and %v without the internal section", opts, err)
+	}
+
+	cfg.Options["internal"] = map[string]interface{}{
+		"enabled":   true,
+		"protocols": []interface{}{"mdns", "LLMNR"},
+		"domain":    "Corp.Example.com.",
+		"subnets":   []interface{}{"192.168.1.0/24"},
+		"names":     []interface{}{"filesrv"},
+		"timeout":   "5s",
+	}
+	opts, err := FromConfig(cfg)
+	if err != nil {
+		t.Fatalf("FromConfig returned an error: %v", err)
+	}
+	if !opts.MDNS || opts.NetBIOS || !opts.LLMNR {
+		t.Errorf("FromConfig returned the protocols %+v", opts)
+	}
+	if opts.Domain != "corp.example.com" || len(opts.Subnets) != 1 ||
+		len(opts.Names) != 1 || opts.Timeout != 5*time.Second {
+		t.Errorf("FromConfig returned unexpected options: %+v", opts)
+	}
+
+	for _, bad := range []map[string]interface{}{
+		{"enabled": true, "protocols": []interface{}{"wins"}},
+		{"enabled": true, "subnets": []interface{}{"fe80::/64"}},
+		{"enabled": true, "timeout": "soon"},
+	} {
+		cfg.Options["internal"] = bad
+		if _, err := FromConfig(cfg); err == nil {
+			t.Errorf("FromConfig did not return an error for %v", bad)
+		}
+	}
+}
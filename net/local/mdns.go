@@ -0,0 +1,150 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package local
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// The DNS-SD name enumerating the service types advertised on the link.
+const servicesName = "_services._dns-sd._udp.local."
+
+var mdnsAddr = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// BrowseMDNS enumerates the DNS-SD services advertised with multicast DNS, and returns the hosts
+// providing them. The questions request unicast responses, so the responders reply to the socket.
+func BrowseMDNS(ctx context.Context, timeout time.Duration) ([]*Host, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := sendMDNSQuery(conn, servicesName); err != nil {
+		return nil, err
+	}
+
+	b := newBrowser()
+	collect(ctx, conn, timeout, func(data []byte, _ net.Addr) {
+		msg := new(dns.Msg)
+		if err := msg.Unpack(data); err != nil || !msg.Response {
+			return
+		}
+		// The instances of each service type are requested once the type is advertised
+		for _, t := range b.process(msg) {
+			_ = sendMDNSQuery(conn, t)
+		}
+	})
+	return b.hosts(), nil
+}
+
+func sendMDNSQuery(conn *net.UDPConn, name string) error {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), dns.TypePTR)
+	msg.Id = 0
+	msg.RecursionDesired = false
+	// The top bit of the class requests a unicast response
+	msg.Question[0].Qclass |= 1 << 15
+
+	data, err := msg.Pack()
+	if err != nil {
+		return err
+	}
+	_, err = conn.WriteToUDP(data, mdnsAddr)
+	return err
+}
+
+// browser accumulates the records of the multicast DNS responses.
+type browser struct {
+	types     map[string]struct{}
+	instances map[string]string
+	srv       map[string]*dns.SRV
+	addrs     map[string][]string
+}
+
+func newBrowser() *browser {
+	return &browser{
+		types:     make(map[string]struct{}),
+		instances: make(map[string]string),
+		srv:       make(map[string]*dns.SRV),
+		addrs:     make(map[string][]string),
+	}
+}
+
+// process records the answers and additional records of the response, and returns the service types seen for the first time.
+func (b *browser) process(msg *dns.Msg) []string {
+	var newTypes []string
+
+	for _, rr := range append(msg.Answer, msg.Extra...) {
+		name := strings.ToLower(rr.Header().Name)
+
+		switch v := rr.(type) {
+		case *dns.PTR:
+			target := strings.ToLower(v.Ptr)
+			if name == servicesName {
+				if _, found := b.types[target]; !found {
+					b.types[target] = struct{}{}
+					newTypes = append(newTypes, target)
+				}
+			} else {
+				b.instances[target] = name
+			}
+		case *dns.SRV:
+			b.srv[name] = v
+		case *dns.A:
+			b.addrs[name] = appendUnique(b.addrs[name], v.A.String())
+		case *dns.AAAA:
+			b.addrs[name] = appendUnique(b.addrs[name], v.AAAA.String())
+		}
+	}
+	return newTypes
+}
+
+// hosts returns the targets of the service instances, and the other names with addresses.
+func (b *browser) hosts() []*Host {
+	byName := make(map[string]*Host)
+
+	for instance, srv := range b.srv {
+		target := strings.ToLower(srv.Target)
+
+		h, found := byName[target]
+		if !found {
+			h = &Host{Name: strings.TrimSuffix(target, "."), Source: SourceMDNS}
+			byName[target] = h
+		}
+
+		stype := b.instances[instance]
+		if stype == "" {
+			// The instance name is followed by the service type
+			if i := strings.Index(instance, "._"); i >= 0 {
+				stype = instance[i+1:]
+			}
+		}
+		h.Services = append(h.Services, &Service{
+			Instance: strings.TrimSuffix(instance, "."),
+			Type:     strings.TrimSuffix(stype, "."),
+			Port:     int(srv.Port),
+		})
+	}
+	for name, addrs := range b.addrs {
+		h, found := byName[name]
+		if !found {
+			h = &Host{Name: strings.TrimSuffix(name, "."), Source: SourceMDNS}
+			byName[name] = h
+		}
+		h.Addresses = appendUnique(h.Addresses, addrs...)
+	}
+
+	var hosts []*Host
+	for _, h := range byName {
+		hosts = append(hosts, h)
+	}
+	return Merge(hosts)
+}
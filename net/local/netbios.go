@@ -0,0 +1,160 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package local
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	netbiosPort = 137
+	// The resource record type of the node status responses
+	typeNBSTAT = 0x21
+	// The name suffix of the workstation service
+	suffixWorkstation = 0x00
+	// The flag of the group names, such as the workgroup or domain
+	flagGroup = 0x8000
+	// The number of addresses of each subnet queried
+	maxSweepAddrs = 1024
+)
+
+// QueryNetBIOS sends the NetBIOS node status query to the addresses of the subnets, and returns the
+// names registered by the responding hosts.
+func QueryNetBIOS(ctx context.Context, subnets []*net.IPNet, timeout time.Duration) ([]*Host, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	query := nodeStatusQuery(0x4e42)
+	go func() {
+		for _, subnet := range subnets {
+			for _, ip := range subnetAddrs(subnet, maxSweepAddrs) {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+				_, _ = conn.WriteToUDP(query, &net.UDPAddr{IP: ip, Port: netbiosPort})
+			}
+		}
+	}()
+
+	var hosts []*Host
+	collect(ctx, conn, timeout, func(data []byte, addr net.Addr) {
+		if name, err := parseNodeStatus(data); err == nil && name != "" {
+			hosts = append(hosts, &Host{
+				Name:      name,
+				Addresses: []string{addrIP(addr)},
+				Source:    SourceNetBIOS,
+			})
+		}
+	})
+	return Merge(hosts), nil
+}
+
+// nodeStatusQuery builds the NBSTAT query for the wildcard name, which every host answers.
+func nodeStatusQuery(id uint16) []byte {
+	msg := make([]byte, 12, 50)
+
+	binary.BigEndian.PutUint16(msg[0:], id)
+	// One question, without the recursion or broadcast flags
+	binary.BigEndian.PutUint16(msg[4:], 1)
+
+	msg = append(msg, encodeNetBIOSName("*", 0)...)
+	msg = append(msg, 0, typeNBSTAT, 0, 1)
+	return msg
+}
+
+// encodeNetBIOSName applies the first-level encoding to the padded name and its suffix.
+func encodeNetBIOSName(name string, suffix byte) []byte {
+	// The wildcard name is padded with nulls, and the other names with spaces
+	raw := make([]byte, 16)
+	if name != "*" {
+		for i := range raw {
+			raw[i] = ' '
+		}
+	}
+	copy(raw, strings.ToUpper(name))
+	raw[15] = suffix
+
+	encoded := []byte{32}
+	for _, b := range raw {
+		encoded = append(encoded, 'A'+(b>>4), 'A'+(b&0x0f))
+	}
+	return append(encoded, 0)
+}
+
+// parseNodeStatus returns the unique workstation name listed by the node status response.
+func parseNodeStatus(data []byte) (string, error) {
+	if len(data) < 12 || data[2]&0x80 == 0 || binary.BigEndian.Uint16(data[6:]) == 0 {
+		return "", errors.New("not a node status response")
+	}
+
+	off := 12
+	// Skip the question name, which can be compressed
+	for off < len(data) {
+		l := int(data[off])
+		if l == 0 {
+			off++
+			break
+		}
+		if l&0xc0 == 0xc0 {
+			off += 2
+			break
+		}
+		off += l + 1
+	}
+	// The type, class, TTL and data length precede the number of names
+	if off+11 > len(data) || binary.BigEndian.Uint16(data[off:]) != typeNBSTAT {
+		return "", errors.New("the response does not provide the node status")
+	}
+	off += 10
+
+	num := int(data[off])
+	off++
+	for i := 0; i < num && off+18 <= len(data); i++ {
+		name := strings.TrimRight(string(data[off:off+15]), " \x00")
+		suffix := data[off+15]
+		flags := binary.BigEndian.Uint16(data[off+16:])
+		off += 18
+
+		if suffix == suffixWorkstation && flags&flagGroup == 0 && name != "" {
+			return strings.ToLower(name), nil
+		}
+	}
+	return "", errors.New("the response does not provide a workstation name")
+}
+
+// subnetAddrs returns the host addresses of the IPv4 subnet, up to the maximum.
+func subnetAddrs(subnet *net.IPNet, max int) []net.IP {
+	base := subnet.IP.Mask(subnet.Mask).To4()
+	if base == nil {
+		return nil
+	}
+
+	ones, bits := subnet.Mask.Size()
+	size := 1 << uint(bits-ones)
+	start := binary.BigEndian.Uint32(base)
+
+	var addrs []net.IP
+	for i := 0; i < size && len(addrs) < max; i++ {
+		// The network and broadcast addresses are skipped in subnets providing host addresses
+		if size > 2 && (i == 0 || i == size-1) {
+			continue
+		}
+
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, start+uint32(i))
+		addrs = append(addrs, ip)
+	}
+	return addrs
+}
@@ -0,0 +1,61 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package mailsec
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// Options are the settings of the posture collection.
+type Options struct {
+	// Selectors are the DKIM selectors queried for each domain
+	Selectors []string
+}
+
+// FromConfig returns the Options in the 'email_security' section of the configuration options. The
+// posture is collected using the default selectors when the section is missing, and nil Options are
+// returned when the collection has been disabled.
+func FromConfig(cfg *config.Config) (*Options, error) {
+	opts := &Options{Selectors: DefaultSelectors}
+
+	secRaw, ok := cfg.Options["email_security"]
+	if !ok {
+		return opts, nil
+	}
+
+	settings, ok := secRaw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("email_security is not a map[string]interface{}")
+	}
+
+	if raw, ok := settings["enabled"]; ok {
+		enabled, ok := raw.(bool)
+		if !ok {
+			return nil, fmt.Errorf("email_security enabled is not a bool")
+		}
+		if !enabled {
+			return nil, nil
+		}
+	}
+	if raw, ok := settings["dkim_selectors"]; ok {
+		list, ok := raw.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("email_security dkim_selectors is not a list")
+		}
+
+		opts.Selectors = nil
+		for _, v := range list {
+			sel, ok := v.(string)
+			if !ok || strings.TrimSpace(sel) == "" {
+				return nil, fmt.Errorf("email_security dkim_selectors contains an invalid selector: %v", v)
+			}
+			opts.Selectors = append(opts.Selectors, strings.ToLower(strings.TrimSpace(sel)))
+		}
+	}
+	return opts, nil
+}
@@ -0,0 +1,451 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package mailsec collects the records describing the email security posture of a domain:
+// the SPF policy along with the policies it includes, the DMARC policy, the DKIM keys
+// published under well known selectors, the MTA-STS and TLS-RPT policies, and the mail exchanges.
+package mailsec
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SPFLookupLimit is the number of DNS lookups an SPF evaluation may cause, as defined by RFC 7208.
+const SPFLookupLimit = 10
+
+// maxSPFDepth stops walking chains of includes that never end.
+const maxSPFDepth = 10
+
+// DefaultSelectors are the DKIM selectors commonly used by the mail providers and platforms.
+var DefaultSelectors = []string{
+	"default", "dkim", "mail", "selector1", "selector2", "google", "k1", "k2", "k3",
+	"s1", "s2", "smtp", "mx", "email", "key1", "key2", "mandrill", "mailjet", "zoho",
+	"protonmail", "protonmail2", "protonmail3", "everlytickey1", "mxvault", "pm", "sig1",
+	"cm", "amazonses", "sendgrid", "dk",
+}
+
+// Resolver provides the DNS records used to collect the posture.
+type Resolver interface {
+	// TXT returns the text records of the name, with the strings of each record concatenated
+	TXT(ctx context.Context, name string) ([]string, error)
+	// MX returns the targets of the MX records of the name
+	MX(ctx context.Context, name string) ([]string, error)
+}
+
+// PolicyFetcher is implemented by the Resolvers able to retrieve the MTA-STS policies, which
+// are served by the web server of the mta-sts label of the domain.
+type PolicyFetcher interface {
+	// FetchPolicy returns the body of the page at the URL
+	FetchPolicy(ctx context.Context, url string) (string, error)
+}
+
+// SPF is the sender policy of a domain, along with the policies included by it.
+type SPF struct {
+	Record string
+	// All is the qualified 'all' mechanism ending the policy, such as -all, or empty when missing
+	All string
+	// Includes are the domains whose policies are included, directly or through other includes
+	Includes []string
+	// Redirect is the domain providing the policy in place of this one
+	Redirect string
+	// Lookups is the number of DNS lookups caused by evaluating the policy
+	Lookups int
+	// Errors describes the includes that could not be evaluated
+	Errors []string
+}
+
+// DMARC is the policy published at the _dmarc label of a domain.
+type DMARC struct {
+	Record          string
+	Policy          string
+	SubdomainPolicy string
+	Percent         int
+	// RUA and RUF are the URIs receiving the aggregate and forensic reports
+	RUA []string
+	RUF []string
+}
+
+// MTASTS is the MTA-STS policy of a domain, requiring the mail exchanges to support TLS.
+type MTASTS struct {
+	Record string
+	// Mode and MX are provided by the policy file, when it could be retrieved
+	Mode string
+	MX   []string
+}
+
+// TLSRPT is the TLS-RPT policy of a domain, requesting the reports of TLS failures.
+type TLSRPT struct {
+	Record string
+	RUA    []string
+}
+
+// Posture is the email security posture of a domain.
+type Posture struct {
+	Domain string
+	SPF    *SPF
+	DMARC  *DMARC
+	// DKIM are the selectors publishing a key
+	DKIM   []string
+	MX     []string
+	MTASTS *MTASTS
+	TLSRPT *TLSRPT
+}
+
+// The relations between a domain and the domains referenced by its email policies.
+const (
+	RelationSPFInclude  = "spf_include"
+	RelationSPFRedirect = "spf_redirect"
+	RelationMTASTSMX    = "mta_sts_mx"
+	RelationTLSRPT      = "tls_rpt_rua"
+)
+
+// Related is a domain referenced by the email policies of a domain.
+type Related struct {
+	Name     string
+	Relation string
+}
+
+// Collect returns the posture of the domain, querying the DKIM keys of the selectors.
+func Collect(ctx context.Context, r Resolver, domain string, selectors []string) *Posture {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	p := &Posture{Domain: domain}
+
+	p.SPF = WalkSPF(ctx, r, domain)
+	if txts, err := r.TXT(ctx, "_dmarc."+domain); err == nil {
+		for _, txt := range txts {
+			if d := ParseDMARC(txt); d != nil {
+				p.DMARC = d
+				break
+			}
+		}
+	}
+	for _, sel := range selectors {
+		select {
+		case <-ctx.Done():
+			return p
+		default:
+		}
+
+		if txts, err := r.TXT(ctx, sel+"._domainkey."+domain); err == nil && hasDKIMKey(txts) {
+			p.DKIM = append(p.DKIM, sel)
+		}
+	}
+	if mx, err := r.MX(ctx, domain); err == nil {
+		for _, target := range mx {
+			if target = strings.ToLower(strings.TrimSuffix(target, ".")); target != "" {
+				p.MX = append(p.MX, target)
+			}
+		}
+		sort.Strings(p.MX)
+	}
+	p.MTASTS = collectMTASTS(ctx, r, domain)
+	if txts, err := r.TXT(ctx, "_smtp._tls."+domain); err == nil {
+		for _, txt := range txts {
+			if t := ParseTLSRPT(txt); t != nil {
+				p.TLSRPT = t
+				break
+			}
+		}
+	}
+	return p
+}
+
+func collectMTASTS(ctx context.Context, r Resolver, domain string) *MTASTS {
+	txts, err := r.TXT(ctx, "_mta-sts."+domain)
+	if err != nil {
+		return nil
+	}
+
+	var m *MTASTS
+	for _, txt := range txts {
+		if t := strings.TrimSpace(txt); strings.HasPrefix(strings.ToLower(t), "v=stsv1") {
+			m = &MTASTS{Record: t}
+			break
+		}
+	}
+	if m == nil {
+		return nil
+	}
+
+	if f, ok := r.(PolicyFetcher); ok {
+		if body, err := f.FetchPolicy(ctx, "https://mta-sts."+domain+"/.well-known/mta-sts.txt"); err == nil {
+			m.Mode, m.MX = ParseMTASTSPolicy(body)
+		}
+	}
+	return m
+}
+
+// ParseMTASTSPolicy returns the mode and the mail exchange patterns of the MTA-STS policy file.
+func ParseMTASTSPolicy(body string) (string, []string) {
+	var mode string
+	var mx []string
+
+	for _, line := range strings.Split(body, "\n") {
+		k, v, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+
+		v = strings.ToLower(strings.TrimSpace(v))
+		switch strings.ToLower(strings.TrimSpace(k)) {
+		case "mode":
+			mode = v
+		case "mx":
+			if v != "" {
+				mx = append(mx, v)
+			}
+		}
+	}
+	return mode, mx
+}
+
+// ParseTLSRPT returns the TLS-RPT policy provided by the text record, or nil when it is not a TLS-RPT record.
+func ParseTLSRPT(txt string) *TLSRPT {
+	txt = strings.TrimSpace(txt)
+	if !strings.HasPrefix(strings.ToLower(txt), "v=tlsrptv1") {
+		return nil
+	}
+
+	t := &TLSRPT{Record: txt}
+	for _, tag := range strings.Split(txt, ";") {
+		if k, v, found := strings.Cut(tag, "="); found && strings.EqualFold(strings.TrimSpace(k), "rua") {
+			t.RUA = splitURIs(v)
+		}
+	}
+	return t
+}
+
+// Related returns the domains referenced by the SPF includes and redirects, the mail exchanges
+// of the MTA-STS policy and the endpoints receiving the TLS-RPT reports, excluding the domain.
+func (p *Posture) Related() []*Related {
+	var related []*Related
+	seen := map[string]struct{}{p.Domain: {}}
+
+	add := func(name, rel string) {
+		name = strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(name), "*."), "."))
+		if _, found := seen[name]; found || !strings.Contains(name, ".") {
+			return
+		}
+		seen[name] = struct{}{}
+		related = append(related, &Related{Name: name, Relation: rel})
+	}
+
+	if p.SPF != nil {
+		for _, inc := range p.SPF.Includes {
+			rel := RelationSPFInclude
+			if inc == p.SPF.Redirect {
+				rel = RelationSPFRedirect
+			}
+			add(inc, rel)
+		}
+	}
+	if p.MTASTS != nil {
+		for _, mx := range p.MTASTS.MX {
+			add(mx, RelationMTASTSMX)
+		}
+	}
+	if p.TLSRPT != nil {
+		for _, uri := range p.TLSRPT.RUA {
+			add(uriHost(uri), RelationTLSRPT)
+		}
+	}
+	return related
+}
+
+// uriHost returns the domain of the mailto address or the host of the HTTPS endpoint.
+func uriHost(uri string) string {
+	if strings.HasPrefix(strings.ToLower(uri), "mailto:") {
+		addr := uri[len("mailto:"):]
+		if i := strings.LastIndex(addr, "@"); i >= 0 {
+			// A size limit may follow the address
+			return strings.Split(addr[i+1:], "!")[0]
+		}
+		return ""
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// Weaknesses returns the descriptions of the gaps in the posture allowing the domain to be spoofed.
+func (p *Posture) Weaknesses() []string {
+	var weak []string
+
+	switch {
+	case p.SPF == nil:
+		weak = append(weak, "no SPF record is published")
+	case p.SPF.All == "+all":
+		weak = append(weak, "the SPF policy allows any sender")
+	case p.SPF.All == "?all" || p.SPF.All == "":
+		weak = append(weak, "the SPF policy does not reject unlisted senders")
+	}
+	if p.SPF != nil && p.SPF.Lookups > SPFLookupLimit {
+		weak = append(weak, "the SPF policy exceeds the limit of "+strconv.Itoa(SPFLookupLimit)+" DNS lookups")
+	}
+
+	if p.DMARC == nil {
+		weak = append(weak, "no DMARC record is published")
+	} else if p.DMARC.Policy == "none" || p.DMARC.Policy == "" {
+		weak = append(weak, "the DMARC policy does not quarantine or reject failing mail")
+	} else if p.DMARC.Percent < 100 {
+		weak = append(weak, "the DMARC policy only applies to "+strconv.Itoa(p.DMARC.Percent)+"% of failing mail")
+	}
+	return weak
+}
+
+// WalkSPF returns the SPF policy of the domain, following the includes and redirects, or nil when
+// the domain does not publish a policy.
+func WalkSPF(ctx context.Context, r Resolver, domain string) *SPF {
+	record := lookupSPF(ctx, r, domain)
+	if record == "" {
+		return nil
+	}
+
+	spf := &SPF{Record: record}
+	seen := map[string]struct{}{domain: {}}
+	walkSPF(ctx, r, spf, record, seen, 0, true)
+	sort.Strings(spf.Includes)
+	return spf
+}
+
+func walkSPF(ctx context.Context, r Resolver, spf *SPF, record string, seen map[string]struct{}, depth int, top bool) {
+	for _, term := range strings.Fields(record)[1:] {
+		term = strings.ToLower(term)
+		qualifier, mech := splitQualifier(term)
+
+		name, value, _ := strings.Cut(mech, ":")
+		if strings.HasPrefix(mech, "redirect=") {
+			name, value = "redirect", strings.TrimPrefix(mech, "redirect=")
+		}
+
+		switch name {
+		case "all":
+			if top {
+				spf.All = qualifier + "all"
+			}
+		case "a", "mx", "ptr", "exists":
+			spf.Lookups++
+		case "include", "redirect":
+			spf.Lookups++
+			if value == "" || strings.Contains(value, "%") {
+				continue
+			}
+			if name == "redirect" && top {
+				spf.Redirect = value
+			}
+			if _, found := seen[value]; found {
+				continue
+			}
+			seen[value] = struct{}{}
+			spf.Includes = append(spf.Includes, value)
+
+			if depth+1 >= maxSPFDepth {
+				spf.Errors = append(spf.Errors, value+" is nested too deeply")
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			next := lookupSPF(ctx, r, value)
+			if next == "" {
+				spf.Errors = append(spf.Errors, value+" does not publish an SPF policy")
+				continue
+			}
+			// The redirected policy takes the place of this one, including its 'all' mechanism
+			walkSPF(ctx, r, spf, next, seen, depth+1, top && name == "redirect")
+		}
+	}
+}
+
+func lookupSPF(ctx context.Context, r Resolver, domain string) string {
+	txts, err := r.TXT(ctx, domain)
+	if err != nil {
+		return ""
+	}
+
+	for _, txt := range txts {
+		if t := strings.TrimSpace(txt); strings.EqualFold(t, "v=spf1") || strings.HasPrefix(strings.ToLower(t), "v=spf1 ") {
+			return t
+		}
+	}
+	return ""
+}
+
+// splitQualifier returns the qualifier of the term, which is '+' when omitted, and the mechanism.
+func splitQualifier(term string) (string, string) {
+	if term != "" && strings.ContainsAny(term[:1], "+-~?") {
+		return term[:1], term[1:]
+	}
+	return "+", term
+}
+
+// ParseDMARC returns the DMARC policy provided by the text record, or nil when it is not a DMARC record.
+func ParseDMARC(txt string) *DMARC {
+	txt = strings.TrimSpace(txt)
+	if !strings.HasPrefix(strings.ToLower(txt), "v=dmarc1") {
+		return nil
+	}
+
+	d := &DMARC{Record: txt, Percent: 100}
+	for _, tag := range strings.Split(txt, ";") {
+		k, v, found := strings.Cut(tag, "=")
+		if !found {
+			continue
+		}
+
+		v = strings.TrimSpace(v)
+		switch strings.ToLower(strings.TrimSpace(k)) {
+		case "p":
+			d.Policy = strings.ToLower(v)
+		case "sp":
+			d.SubdomainPolicy = strings.ToLower(v)
+		case "pct":
+			if n, err := strconv.Atoi(v); err == nil && n >= 0 && n <= 100 {
+				d.Percent = n
+			}
+		case "rua":
+			d.RUA = splitURIs(v)
+		case "ruf":
+			d.RUF = splitURIs(v)
+		}
+	}
+	if d.SubdomainPolicy == "" {
+		d.SubdomainPolicy = d.Policy
+	}
+	return d
+}
+
+func splitURIs(v string) []string {
+	var uris []string
+
+	for _, u := range strings.Split(v, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			uris = append(uris, u)
+		}
+	}
+	return uris
+}
+
+func hasDKIMKey(txts []string) bool {
+	for _, txt := range txts {
+		for _, tag := range strings.Split(txt, ";") {
+			k, v, found := strings.Cut(tag, "=")
+			// A revoked key has an empty public key
+			if found && strings.TrimSpace(k) == "p" && strings.TrimSpace(v) != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
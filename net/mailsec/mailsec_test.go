@@ -0,0 +1,198 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package mailsec
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/owasp-amass/config/config"
+)
+
+type fakeResolver struct {
+	txt map[string][]string
+	mx  map[string][]string
+}
+
+func (f *fakeResolver) TXT(ctx context.Context, name string) ([]string, error) {
+	if txts, found := f.txt[name]; found {
+		return txts, nil
+	}
+	return nil, errors.New("name does not exist")
+}
+
+func (f *fakeResolver) MX(ctx context.Context, name string) ([]string, error) {
+	if mx, found := f.mx[name]; found {
+		return mx, nil
+	}
+	return nil, errors.New("name does not exist")
+}
+
+func TestWalkSPF(t *testing.T) {
+	r := &fakeResolver{txt: map[string][]string{
+		"owasp.org":             {"google-site-verification=abc", "v=spf1 mx include:_spf.google.com include:loop.owasp.org ~all"},
+		"_spf.google.com":       {"v=spf1 include:_netblocks.google.com ~all"},
+		"_netblocks.google.com": {"v=spf1 ip4:192.0.2.0/24 -all"},
+		"loop.owasp.org":        {"v=spf1 include:owasp.org include:missing.example.com"},
+		"redirect.org":          {"v=spf1 redirect=_spf.owasp.org"},
+		"_spf.owasp.org":        {"v=spf1 a -all"},
+	}}
+
+	spf := WalkSPF(context.Background(), r, "owasp.org")
+	if spf == nil {
+		t.Fatal("Failed to find the SPF policy")
+	}
+	if spf.All != "~all" {
+		t.Errorf("Expected the policy to end with ~all, got %s", spf.All)
+	}
+	expected := []string{"_netblocks.google.com", "_spf.google.com", "loop.owasp.org", "missing.example.com"}
+	if !reflect.DeepEqual(spf.Includes, expected) {
+		t.Errorf("Expected the includes %v, got %v", expected, spf.Includes)
+	}
+	// mx, three includes reached from owasp.org, and the include of owasp.org within the loop
+	if spf.Lookups != 6 {
+		t.Errorf("Expected 6 DNS lookups, got %d", spf.Lookups)
+	}
+	if len(spf.Errors) != 1 {
+		t.Errorf("Expected the missing include to be reported, got %v", spf.Errors)
+	}
+
+	spf = WalkSPF(context.Background(), r, "redirect.org")
+	if spf == nil || spf.Redirect != "_spf.owasp.org" || spf.All != "-all" {
+		t.Errorf("The redirected policy was not followed: %v", spf)
+	}
+	if WalkSPF(context.Background(), r, "none.org") != nil {
+		t.Error("Expected no policy for the domain without an SPF record")
+	}
+}
+
+func TestParseDMARC(t *testing.T) {
+	if ParseDMARC("v=spf1 -all") != nil {
+		t.Error("Expected the SPF record not to be parsed as DMARC")
+	}
+
+	d := ParseDMARC("v=DMARC1; p=Quarantine; pct=50; rua=mailto:dmarc@owasp.org, mailto:reports@example.com")
+	if d == nil || d.Policy != "quarantine" || d.SubdomainPolicy != "quarantine" || d.Percent != 50 {
+		t.Fatalf("The DMARC record was not parsed correctly: %v", d)
+	}
+	if len(d.RUA) != 2 || d.RUA[1] != "mailto:reports@example.com" {
+		t.Errorf("The report URIs were not parsed correctly: %v", d.RUA)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	r := &fakeResolver{
+		txt: map[string][]string{
+			"owasp.org":                      {"v=spf1 -all"},
+			"_dmarc.owasp.org":               {"v=DMARC1; p=reject"},
+			"google._domainkey.owasp.org":    {"v=DKIM1; k=rsa; p=MIGfMA0GCSqGSIb3DQEBAQUAA4GNADCBiQKBgQC"},
+			"selector1._domainkey.owasp.org": {"v=DKIM1; p="},
+			"spoof.org":                      {"v=spf1 +all"},
+		},
+		mx: map[string][]string{"owasp.org": {"ASPMX.L.GOOGLE.COM.", "alt1.aspmx.l.google.com."}},
+	}
+
+	p := Collect(context.Background(), r, "owasp.org", DefaultSelectors)
+	if p.DMARC == nil || p.DMARC.Policy != "reject" {
+		t.Errorf("The DMARC policy was not collected: %v", p.DMARC)
+	}
+	if !reflect.DeepEqual(p.DKIM, []string{"google"}) {
+		t.Errorf("Expected only the selector with a key, got %v", p.DKIM)
+	}
+	if !reflect.DeepEqual(p.MX, []string{"alt1.aspmx.l.google.com", "aspmx.l.google.com"}) {
+		t.Errorf("The mail exchanges were not collected: %v", p.MX)
+	}
+	if weak := p.Weaknesses(); len(weak) != 0 {
+		t.Errorf("Expected no weaknesses, got %v", weak)
+	}
+
+	p = Collect(context.Background(), r, "spoof.org", nil)
+	if weak := p.Weaknesses(); len(weak) != 2 {
+		t.Errorf("Expected the permissive SPF policy and the missing DMARC record, got %v", weak)
+	}
+}
+
+type fetchingResolver struct {
+	fakeResolver
+	pages map[string]string
+}
+
+func (f *fetchingResolver) FetchPolicy(ctx context.Context, url string) (string, error) {
+	if body, found := f.pages[url]; found {
+		return body, nil
+	}
+	return "", errors.New("not found")
+}
+
+func TestRelated(t *testing.T) {
+	r := &fetchingResolver{
+		fakeResolver: fakeResolver{txt: map[string][]string{
+			"owasp.org":            {"v=spf1 include:_spf.google.com redirect=spf.owasp.net"},
+			"_spf.google.com":      {"v=spf1 -all"},
+			"spf.owasp.net":        {"v=spf1 -all"},
+			"_mta-sts.owasp.org":   {"v=STSv1; id=20230101"},
+			"_smtp._tls.owasp.org": {"v=TLSRPTv1; rua=mailto:tls@reports.example.com,https://tlsrpt.example.net/v1"},
+		}},
+		pages: map[string]string{
+			"https://mta-sts.owasp.org/.well-known/mta-sts.txt": "version: STSv1\r\nmode: enforce\r\nmx: *.mail.owasp.org\r\nmx: mx.example.org\r\nmax_age: 86400\r\n",
+		},
+	}
+
+	p := Collect(context.Background(), r, "owasp.org", nil)
+	if p.MTASTS == nil || p.MTASTS.Mode != "enforce" {
+		t.Fatalf("The MTA-STS policy was not collected: %v", p.MTASTS)
+	}
+	if p.TLSRPT == nil || len(p.TLSRPT.RUA) != 2 {
+		t.Fatalf("The TLS-RPT policy was not collected: %v", p.TLSRPT)
+	}
+
+	expected := map[string]string{
+		"_spf.google.com":     RelationSPFInclude,
+		"spf.owasp.net":       RelationSPFRedirect,
+		"mail.owasp.org":      RelationMTASTSMX,
+		"mx.example.org":      RelationMTASTSMX,
+		"reports.example.com": RelationTLSRPT,
+		"tlsrpt.example.net":  RelationTLSRPT,
+	}
+	related := p.Related()
+	if len(related) != len(expected) {
+		t.Errorf("Expected %d related domains, got %d", len(expected), len(related))
+	}
+	for _, rel := range related {
+		if expected[rel.Name] != rel.Relation {
+			t.Errorf("Unexpected relation %s for %s", rel.Relation, rel.Name)
+		}
+	}
+
+	// Without the fetcher, only the MTA-STS record is collected
+	p = Collect(context.Background(), &r.fakeResolver, "owasp.org", nil)
+	if p.MTASTS == nil || p.MTASTS.Mode != "" || len(p.MTASTS.MX) != 0 {
+		t.Errorf("Expected only the MTA-STS record without the policy file: %v", p.MTASTS)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	if opts, err := FromConfig(cfg); err != nil || len(opts.Selectors) != len(DefaultSelectors) {
+		t.Errorf("Expected the default selectors without the email_security section")
+	}
+
+	cfg.Options["email_security"] = map[string]interface{}{"dkim_selectors": []interface{}{"Custom"}}
+	if opts, err := FromConfig(cfg); err != nil || !reflect.DeepEqual(opts.Selectors, []string{"custom"}) {
+		t.Errorf("The selectors were not parsed correctly: %v", err)
+	}
+
+	cfg.Options["email_security"] = map[string]interface{}{"enabled": false}
+	if opts, err := FromConfig(cfg); err != nil || opts != nil {
+		t.Errorf("Expected no options when the collection is disabled")
+	}
+
+	cfg.Options["email_security"] = map[string]interface{}{"dkim_selectors": "google"}
+	if _, err := FromConfig(cfg); err == nil {
+		t.Errorf("Expected an error for the selectors that are not a list")
+	}
+}
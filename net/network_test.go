@@ -253,3 +253,42 @@ func TestIPDec(t *testing.T) {
 		}
 	}
 }
+
+func TestIPv6Neighbors(t *testing.T) {
+	contains := func(ips []net.IP, addr string) bool {
+		for _, ip := range ips {
+			if ip.String() == addr {
+				return true
+			}
+		}
+		return false
+	}
+
+	low := IPv6Neighbors("2001:db8:1:2::a0", []string{IPv6LowByte}, 1000)
+	for _, addr := range []string{"2001:db8:1:2::1", "2001:db8:1:2::40", "2001:db8:1:2::a8", "2001:db8:1:2::98"} {
+		if !contains(low, addr) {
+			t.Errorf("The low byte neighbors did not include %s", addr)
+		}
+	}
+	if contains(low, "2001:db8:1:2::a0") {
+		t.Error("The neighbors included the expanded address")
+	}
+
+	words := IPv6Neighbors("2001:db8:1:2:5054:ff:fe12:3456", []string{IPv6Words, IPv6Ports}, 1000)
+	for _, addr := range []string{"2001:db8:1:2::cafe", "2001:db8:1:2::dead:beef", "2001:db8:1:2::443", "2001:db8:1:2::1bb"} {
+		if !contains(words, addr) {
+			t.Errorf("The word and port neighbors did not include %s", addr)
+		}
+	}
+	// The addresses assigned from the MAC address do not have sequential neighbors
+	if contains(IPv6Neighbors("2001:db8:1:2:5054:ff:fe12:3456", IPv6Patterns, 1000), "2001:db8:1:2:5054:ff:fe12:3457") {
+		t.Error("Expected no adjacent neighbors for the large interface identifier")
+	}
+
+	if n := len(IPv6Neighbors("2001:db8::1", IPv6Patterns, 10)); n != 10 {
+		t.Errorf("Expected the neighbors to be limited to 10, got %d", n)
+	}
+	if n := len(IPv6Neighbors("192.0.2.1", IPv6Patterns, 10)); n != 0 {
+		t.Errorf("Expected no neighbors for an IPv4 address, got %d", n)
+	}
+}
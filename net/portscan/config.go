@@ -0,0 +1,79 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package portscan
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// FromConfig returns a Scanner using the settings in the 'port_scan' section of the configuration options.
+// A nil Scanner is returned when port scanning has not been enabled. The ports are only probed during
+// active enumerations, while the services imported from an external scanner are always reported.
+func FromConfig(cfg *config.Config) (*Scanner, error) {
+	scanRaw, ok := cfg.Options["port_scan"]
+	if !ok {
+		return nil, nil
+	}
+
+	settings, ok := scanRaw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("port_scan is not a map[string]interface{}")
+	}
+
+	if enabled, ok := settings["enabled"].(bool); !ok || !enabled {
+		return nil, nil
+	}
+
+	opts := Options{Probe: cfg.Active}
+	if raw, ok := settings["ports"]; ok {
+		list, ok := raw.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("port_scan ports is not a list")
+		}
+
+		for _, v := range list {
+			port, ok := v.(int)
+			if !ok || port <= 0 || port > 65535 {
+				return nil, fmt.Errorf("port_scan ports contains an invalid port: %v", v)
+			}
+			opts.Ports = append(opts.Ports, port)
+		}
+	}
+	if raw, ok := settings["timeout"]; ok {
+		str, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("port_scan timeout is not a string")
+		}
+
+		d, err := time.ParseDuration(str)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("port_scan timeout is not a valid duration: %s", str)
+		}
+		opts.Timeout = d
+	}
+	if raw, ok := settings["concurrency"]; ok {
+		n, ok := raw.(int)
+		if !ok || n <= 0 {
+			return nil, fmt.Errorf("port_scan concurrency is not a positive integer")
+		}
+		opts.Concurrency = n
+	}
+	if raw, ok := settings["import"]; ok {
+		path, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("port_scan import is not a string")
+		}
+
+		svcs, err := ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("port_scan import: %v", err)
+		}
+		opts.Imported = svcs
+	}
+	return NewScanner(opts), nil
+}
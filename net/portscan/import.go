@@ -0,0 +1,151 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package portscan
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+)
+
+// The entries written by masscan with '-oJ', where each entry holds the ports of one address.
+type masscanEntry struct {
+	IP    string `json:"ip"`
+	Ports []struct {
+		Port    int    `json:"port"`
+		Proto   string `json:"proto"`
+		Status  string `json:"status"`
+		Service struct {
+			Name string `json:"name"`
+		} `json:"service"`
+	} `json:"ports"`
+}
+
+// The lines written by naabu with '-json', where each line holds one port of an address.
+type naabuLine struct {
+	Host     string `json:"host"`
+	IP       string `json:"ip"`
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+}
+
+// ReadFile returns the open services in the JSON output of an external scanner.
+func ReadFile(path string) ([]*Service, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Parse(f)
+}
+
+// Parse returns the open services in the JSON output of masscan ('-oJ') or naabu ('-json').
+// The format is detected from the content, so the output of either scanner can be provided.
+func Parse(r io.Reader) ([]*Service, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+	if data[0] == '[' {
+		return parseMasscan(data)
+	}
+	return parseJSONLines(data)
+}
+
+func parseMasscan(data []byte) ([]*Service, error) {
+	// Older masscan releases leave a trailing comma after the last entry
+	data = bytes.Replace(data, []byte(",\n]"), []byte("\n]"), 1)
+
+	var entries []masscanEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse the masscan output: %v", err)
+	}
+
+	var results []*Service
+	for _, e := range entries {
+		for _, p := range e.Ports {
+			if p.Status != "" && p.Status != "open" {
+				continue
+			}
+			if svc := newService(e.IP, p.Port, p.Proto, p.Service.Name, "masscan"); svc != nil {
+				results = append(results, svc)
+			}
+		}
+	}
+	return results, nil
+}
+
+func parseJSONLines(data []byte) ([]*Service, error) {
+	var results []*Service
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		// masscan also provides one entry per line with '-oD'
+		if bytes.Contains(line, []byte(`"ports"`)) {
+			var e masscanEntry
+			if err := json.Unmarshal(bytes.TrimSuffix(line, []byte(",")), &e); err != nil {
+				return nil, fmt.Errorf("line %d: %v", n, err)
+			}
+			for _, p := range e.Ports {
+				if svc := newService(e.IP, p.Port, p.Proto, p.Service.Name, "masscan"); svc != nil && (p.Status == "" || p.Status == "open") {
+					results = append(results, svc)
+				}
+			}
+			continue
+		}
+
+		var l naabuLine
+		if err := json.Unmarshal(line, &l); err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+
+		addr := l.IP
+		if addr == "" {
+			addr = l.Host
+		}
+		if svc := newService(addr, l.Port, l.Protocol, "", "naabu"); svc != nil {
+			results = append(results, svc)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func newService(addr string, port int, proto, name, source string) *Service {
+	ip := net.ParseIP(strings.TrimSpace(addr))
+	if ip == nil || port <= 0 || port > 65535 {
+		return nil
+	}
+
+	proto = strings.ToLower(proto)
+	if proto == "" {
+		proto = "tcp"
+	}
+	return &Service{
+		Address:  ip.String(),
+		Port:     port,
+		Protocol: proto,
+		Name:     name,
+		Source:   source,
+	}
+}
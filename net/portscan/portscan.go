@@ -0,0 +1,156 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package portscan identifies the services exposed by IP addresses, either by probing the ports
+// with TCP connections or by reading the JSON output of an external scanner.
+package portscan
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The defaults used when the options do not provide a value.
+const (
+	DefaultTimeout     = 2 * time.Second
+	DefaultConcurrency = 100
+)
+
+// DefaultPorts are probed when the options do not provide a port list.
+var DefaultPorts = []int{21, 22, 23, 25, 53, 80, 110, 143, 443, 445, 465, 587, 993, 995,
+	1433, 1521, 2375, 3306, 3389, 5432, 5900, 6379, 8080, 8443, 9200, 27017}
+
+// Service is a port found open on an IP address.
+type Service struct {
+	Address  string `json:"address"`
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+	// Name is the service identified by an external scanner, such as 'http'
+	Name   string `json:"name,omitempty"`
+	Source string `json:"source"`
+}
+
+// Key returns the 'address:port/protocol' form used to identify the service.
+func (s *Service) Key() string {
+	return net.JoinHostPort(s.Address, strconv.Itoa(s.Port)) + "/" + s.Protocol
+}
+
+// Options configures the Scanner.
+type Options struct {
+	Ports       []int
+	Timeout     time.Duration
+	Concurrency int
+	// Imported services are used in place of probing the addresses found in the results
+	Imported []*Service
+	// Probe is false when only the imported services are reported
+	Probe bool
+}
+
+// Scanner probes the ports of IP addresses with TCP connect scans.
+type Scanner struct {
+	opts     Options
+	sem      chan struct{}
+	imported map[string][]*Service
+	dial     func(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// NewScanner returns a Scanner using the provided options, or the defaults for the zero values.
+func NewScanner(opts Options) *Scanner {
+	if len(opts.Ports) == 0 {
+		opts.Ports = DefaultPorts
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+
+	s := &Scanner{
+		opts:     opts,
+		sem:      make(chan struct{}, opts.Concurrency),
+		imported: make(map[string][]*Service),
+	}
+	for _, svc := range opts.Imported {
+		addr := normalizeAddr(svc.Address)
+		s.imported[addr] = append(s.imported[addr], svc)
+	}
+
+	d := &net.Dialer{Timeout: opts.Timeout}
+	s.dial = d.DialContext
+	return s
+}
+
+// Ports returns the ports probed by the Scanner.
+func (s *Scanner) Ports() []int {
+	return s.opts.Ports
+}
+
+// Scan returns the services open on the address, sorted by port. The services imported
+// from an external scanner are returned for the address when available.
+func (s *Scanner) Scan(ctx context.Context, addr string) []*Service {
+	if svcs, found := s.imported[normalizeAddr(addr)]; found {
+		return svcs
+	}
+	if !s.opts.Probe || net.ParseIP(addr) == nil {
+		return nil
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var results []*Service
+loop:
+	for _, port := range s.opts.Ports {
+		select {
+		case <-ctx.Done():
+			break loop
+		case s.sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(port int) {
+			defer wg.Done()
+			defer func() { <-s.sem }()
+
+			if s.probe(ctx, addr, port) {
+				mu.Lock()
+				results = append(results, &Service{
+					Address:  addr,
+					Port:     port,
+					Protocol: "tcp",
+					Source:   "Port Scan",
+				})
+				mu.Unlock()
+			}
+		}(port)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Port < results[j].Port })
+	return results
+}
+
+func (s *Scanner) probe(ctx context.Context, addr string, port int) bool {
+	ctx, cancel := context.WithTimeout(ctx, s.opts.Timeout)
+	defer cancel()
+
+	conn, err := s.dial(ctx, "tcp", net.JoinHostPort(addr, strconv.Itoa(port)))
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+func normalizeAddr(addr string) string {
+	if ip := net.ParseIP(strings.TrimSpace(addr)); ip != nil {
+		return ip.String()
+	}
+	return strings.TrimSpace(addr)
+}
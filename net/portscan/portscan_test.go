@@ -0,0 +1,132 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package portscan
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func TestScan(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	open := ln.Addr().(*net.TCPAddr).Port
+	// Obtain a port that is not listening
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	s := NewScanner(Options{Ports: []int{closedPort, open}, Timeout: time.Second, Probe: true})
+	svcs := s.Scan(context.Background(), "127.0.0.1")
+	if len(svcs) != 1 || svcs[0].Port != open || svcs[0].Protocol != "tcp" {
+		t.Fatalf("Unexpected services: %v", svcs)
+	}
+
+	s = NewScanner(Options{Ports: []int{open}, Timeout: time.Second})
+	if svcs := s.Scan(context.Background(), "127.0.0.1"); len(svcs) != 0 {
+		t.Errorf("The scanner probed the ports without the probe option: %v", svcs)
+	}
+}
+
+func TestScanImported(t *testing.T) {
+	s := NewScanner(Options{
+		Imported: []*Service{{Address: "192.0.2.1", Port: 22, Protocol: "tcp", Source: "naabu"}},
+		Probe:    true,
+	})
+
+	svcs := s.Scan(context.Background(), "192.0.2.1")
+	if len(svcs) != 1 || svcs[0].Key() != "192.0.2.1:22/tcp" {
+		t.Errorf("The imported services were not returned: %v", svcs)
+	}
+}
+
+func TestParseMasscan(t *testing.T) {
+	data := `[
+{   "ip": "192.0.2.1",   "timestamp": "1690000000", "ports": [ {"port": 443, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 54} ] },
+{   "ip": "192.0.2.1",   "timestamp": "1690000000", "ports": [ {"port": 22, "proto": "tcp", "status": "closed", "reason": "rst", "ttl": 54} ] },
+{   "ip": "2001:db8::1",   "timestamp": "1690000000", "ports": [ {"port": 80, "proto": "tcp", "service": {"name": "http", "banner": ""} } ] },
+]`
+
+	svcs, err := Parse(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Failed to parse the output: %v", err)
+	}
+	if len(svcs) != 2 {
+		t.Fatalf("Expected 2 services, got %d", len(svcs))
+	}
+	if svcs[0].Key() != "192.0.2.1:443/tcp" || svcs[0].Source != "masscan" {
+		t.Errorf("Unexpected first service: %+v", svcs[0])
+	}
+	if svcs[1].Key() != "[2001:db8::1]:80/tcp" || svcs[1].Name != "http" {
+		t.Errorf("Unexpected second service: %+v", svcs[1])
+	}
+}
+
+func TestParseNaabu(t *testing.T) {
+	data := `{"host":"www.owasp.org","ip":"192.0.2.1","port":443,"protocol":"tcp","tls":false,"timestamp":"2023-07-01T00:00:00Z"}
+{"ip":"192.0.2.2","port":8080,"timestamp":"2023-07-01T00:00:00Z"}
+{"host":"192.0.2.3","port":0}
+`
+
+	svcs, err := Parse(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Failed to parse the output: %v", err)
+	}
+	if len(svcs) != 2 || svcs[0].Key() != "192.0.2.1:443/tcp" || svcs[1].Key() != "192.0.2.2:8080/tcp" {
+		t.Errorf("Unexpected services: %v", svcs)
+	}
+
+	if _, err := Parse(strings.NewReader("not json")); err == nil {
+		t.Error("Parse did not fail on invalid output")
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	if s, err := FromConfig(cfg); err != nil || s != nil {
+		t.Errorf("Expected no scanner without the section: %v", err)
+	}
+
+	cfg.Active = true
+	cfg.Options["port_scan"] = map[string]interface{}{
+		"enabled":     true,
+		"ports":       []interface{}{22, 443},
+		"timeout":     "500ms",
+		"concurrency": 10,
+	}
+	s, err := FromConfig(cfg)
+	if err != nil || s == nil {
+		t.Fatalf("Failed to create the scanner: %v", err)
+	}
+	if len(s.Ports()) != 2 || s.opts.Timeout != 500*time.Millisecond || !s.opts.Probe {
+		t.Errorf("Unexpected options: %+v", s.opts)
+	}
+
+	cfg.Options["port_scan"] = map[string]interface{}{"enabled": true, "ports": []interface{}{70000}}
+	if _, err := FromConfig(cfg); err == nil {
+		t.Error("FromConfig accepted an invalid port")
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// FromConfig returns a Validator using the settings in the 'name_validation' section of the configuration
+// options. A nil Validator is returned when the names are only confirmed by DNS, which is the default, or
+// when the enumeration is passive, since the probes are sent to the addresses of the target.
+func FromConfig(cfg *config.Config) (*Validator, error) {
+	validRaw, ok := cfg.Options["name_validation"]
+	if !ok {
+		return nil, nil
+	}
+
+	settings, ok := validRaw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("name_validation is not a map[string]interface{}")
+	}
+
+	opts := Options{Method: EvidenceDNS}
+	if raw, ok := settings["method"]; ok {
+		method, ok := raw.(string)
+		if !ok || Rank(method) < 0 {
+			return nil, fmt.Errorf("name_validation method must be dns, tcp or tls")
+		}
+		opts.Method = method
+	}
+	if raw, ok := settings["ports"]; ok {
+		list, ok := raw.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("name_validation ports is not a list")
+		}
+
+		for _, v := range list {
+			port, ok := v.(int)
+			if !ok || port <= 0 || port > 65535 {
+				return nil, fmt.Errorf("name_validation ports contains an invalid port: %v", v)
+			}
+			opts.Ports = append(opts.Ports, port)
+		}
+	}
+	if raw, ok := settings["timeout"]; ok {
+		str, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("name_validation timeout is not a string")
+		}
+
+		d, err := time.ParseDuration(str)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("name_validation timeout is not a valid duration: %s", str)
+		}
+		opts.Timeout = d
+	}
+
+	if opts.Method == EvidenceDNS || !cfg.Active {
+		return nil, nil
+	}
+	return NewValidator(opts), nil
+}
@@ -0,0 +1,170 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package validate confirms the names resolved by an enumeration with stronger evidence than
+// the DNS answers, by connecting to the addresses or completing TLS handshakes for the names.
+package validate
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"strconv"
+	"time"
+)
+
+// The evidence levels, ordered from the weakest, which are also the methods selecting the probes sent.
+const (
+	EvidenceDNS = "dns"
+	EvidenceTCP = "tcp"
+	EvidenceTLS = "tls"
+)
+
+// The defaults used when the options do not provide a value.
+const (
+	DefaultTimeout     = 3 * time.Second
+	DefaultConcurrency = 50
+)
+
+// DefaultPorts are the ports connected to by each method when the options do not provide a port list.
+var DefaultPorts = map[string][]int{
+	EvidenceTCP: {80, 443},
+	EvidenceTLS: {443},
+}
+
+// Rank returns the strength of the evidence level, or -1 for an unknown level.
+func Rank(evidence string) int {
+	switch evidence {
+	case EvidenceDNS:
+		return 0
+	case EvidenceTCP:
+		return 1
+	case EvidenceTLS:
+		return 2
+	}
+	return -1
+}
+
+// AtLeast returns true when the evidence is as strong as the level.
+func AtLeast(evidence, level string) bool {
+	r := Rank(evidence)
+	return r >= 0 && r >= Rank(level)
+}
+
+// Options configures the Validator.
+type Options struct {
+	// Method is the evidence level sought for each name: tcp or tls
+	Method      string
+	Ports       []int
+	Timeout     time.Duration
+	Concurrency int
+}
+
+// Validator probes the addresses of the names to confirm that they are served.
+type Validator struct {
+	opts Options
+	sem  chan struct{}
+	dial func(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// NewValidator returns a Validator using the provided options, or the defaults for the zero values.
+func NewValidator(opts Options) *Validator {
+	if Rank(opts.Method) < 0 {
+		opts.Method = EvidenceDNS
+	}
+	if len(opts.Ports) == 0 {
+		opts.Ports = DefaultPorts[opts.Method]
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+
+	d := &net.Dialer{Timeout: opts.Timeout}
+	return &Validator{
+		opts: opts,
+		sem:  make(chan struct{}, opts.Concurrency),
+		dial: d.DialContext,
+	}
+}
+
+// Method returns the evidence level sought by the Validator.
+func (v *Validator) Method() string {
+	return v.opts.Method
+}
+
+// Validate returns the strongest evidence obtained for the name resolving to the addresses, up to
+// the level of the method. The DNS evidence is returned when none of the probes succeeded.
+func (v *Validator) Validate(ctx context.Context, name string, addrs []string) string {
+	evidence, _ := v.Confirm(ctx, name, addrs)
+	return evidence
+}
+
+// Confirm returns the same evidence as Validate, along with the DER encoding of the
+// certificate covering the name when the TLS evidence was obtained.
+func (v *Validator) Confirm(ctx context.Context, name string, addrs []string) (string, []byte) {
+	best := EvidenceDNS
+	if v.opts.Method == EvidenceDNS {
+		return best, nil
+	}
+
+	for _, addr := range addrs {
+		if net.ParseIP(addr) == nil {
+			continue
+		}
+
+		for _, port := range v.opts.Ports {
+			select {
+			case <-ctx.Done():
+				return best, nil
+			case v.sem <- struct{}{}:
+			}
+
+			evidence, cert := v.probe(ctx, name, net.JoinHostPort(addr, strconv.Itoa(port)))
+			<-v.sem
+
+			if Rank(evidence) > Rank(best) {
+				best = evidence
+			}
+			if best == v.opts.Method {
+				return best, cert
+			}
+		}
+	}
+	return best, nil
+}
+
+// probe connects to the address, and completes a TLS handshake for the name when requested by the method.
+func (v *Validator) probe(ctx context.Context, name, address string) (string, []byte) {
+	ctx, cancel := context.WithTimeout(ctx, v.opts.Timeout)
+	defer cancel()
+
+	conn, err := v.dial(ctx, "tcp", address)
+	if err != nil {
+		return EvidenceDNS, nil
+	}
+	defer conn.Close()
+
+	if v.opts.Method != EvidenceTLS {
+		return EvidenceTCP, nil
+	}
+
+	// The certificate chain is not verified, since only the coverage of the name is relevant
+	tconn := tls.Client(conn, &tls.Config{
+		ServerName:         name,
+		InsecureSkipVerify: true,
+		MinVersion:         tls.VersionTLS10,
+	})
+	if err := tconn.HandshakeContext(ctx); err != nil {
+		return EvidenceTCP, nil
+	}
+
+	certs := tconn.ConnectionState().PeerCertificates
+	if len(certs) == 0 || certs[0].VerifyHostname(name) != nil {
+		return EvidenceTCP, nil
+	}
+	return EvidenceTLS, certs[0].Raw
+}
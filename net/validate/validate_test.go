@@ -0,0 +1,128 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func hostPort(t *testing.T, addr string) (string, int) {
+	host, p, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatalf("Failed to split the address %s: %v", addr, err)
+	}
+	port, _ := strconv.Atoi(p)
+	return host, port
+}
+
+func TestValidateTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	host, port := hostPort(t, ln.Addr().String())
+	v := NewValidator(Options{Method: EvidenceTCP, Ports: []int{port}})
+	if e := v.Validate(context.Background(), "www.example.com", []string{host}); e != EvidenceTCP {
+		t.Errorf("Expected the tcp evidence, got %s", e)
+	}
+
+	ln.Close()
+	if e := v.Validate(context.Background(), "www.example.com", []string{host}); e != EvidenceDNS {
+		t.Errorf("Expected the dns evidence for the closed port, got %s", e)
+	}
+}
+
+func TestValidateTLS(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	host, port := hostPort(t, ts.Listener.Addr().String())
+	v := NewValidator(Options{Method: EvidenceTLS, Ports: []int{port}})
+	// The certificate of the test server is issued for example.com
+	if e := v.Validate(context.Background(), "example.com", []string{host}); e != EvidenceTLS {
+		t.Errorf("Expected the tls evidence, got %s", e)
+	}
+	if e := v.Validate(context.Background(), "www.owasp.org", []string{host}); e != EvidenceTCP {
+		t.Errorf("Expected the tcp evidence for the name not covered by the certificate, got %s", e)
+	}
+
+	if e, der := v.Confirm(context.Background(), "example.com", []string{host}); e != EvidenceTLS || !bytes.Equal(der, ts.Certificate().Raw) {
+		t.Errorf("Expected the certificate of the server along with the tls evidence, got %s and %d bytes", e, len(der))
+	}
+	if _, der := v.Confirm(context.Background(), "www.owasp.org", []string{host}); der != nil {
+		t.Error("Expected no certificate for the name not covered by the certificate")
+	}
+}
+
+func TestAtLeast(t *testing.T) {
+	tests := []struct {
+		evidence, level string
+		expected        bool
+	}{
+		{EvidenceTLS, EvidenceTCP, true},
+		{EvidenceTCP, EvidenceTCP, true},
+		{EvidenceDNS, EvidenceTCP, false},
+		{"", EvidenceDNS, false},
+	}
+
+	for _, test := range tests {
+		if got := AtLeast(test.evidence, test.level); got != test.expected {
+			t.Errorf("AtLeast(%q, %q) returned %v", test.evidence, test.level, got)
+		}
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Active = true
+	if v, err := FromConfig(cfg); err != nil || v != nil {
+		t.Errorf("Expected no validator without the section: %v %v", v, err)
+	}
+
+	cfg.Options["name_validation"] = map[string]interface{}{"method": "tls", "timeout": "5s"}
+	v, err := FromConfig(cfg)
+	if err != nil || v == nil {
+		t.Fatalf("FromConfig failed: %v", err)
+	}
+	if v.Method() != EvidenceTLS || len(v.opts.Ports) != 1 || v.opts.Ports[0] != 443 {
+		t.Errorf("Unexpected options: %+v", v.opts)
+	}
+
+	cfg.Active = false
+	if v, err := FromConfig(cfg); err != nil || v != nil {
+		t.Errorf("Expected no validator during passive enumerations: %v %v", v, err)
+	}
+
+	cfg.Active = true
+	for _, settings := range []map[string]interface{}{
+		{"method": "ping"},
+		{"method": "tcp", "ports": []interface{}{70000}},
+		{"method": "tcp", "timeout": "soon"},
+	} {
+		cfg.Options["name_validation"] = settings
+		if _, err := FromConfig(cfg); err == nil {
+			t.Errorf("FromConfig accepted the invalid settings: %v", settings)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package whois
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// registry provides the query format and response parser of a WHOIS server.
+type registry struct {
+	query func(domain string) string
+	parse func(raw string) *Record
+}
+
+func plainQuery(domain string) string { return domain }
+
+// The registries with queries or responses that differ from the common key / value format.
+var registries = map[string]*registry{
+	// DENIC only provides the name servers and status when asked for the technical view
+	"whois.denic.de": {query: func(d string) string { return "-T dn,ace " + d }, parse: parseKeyValues},
+	// JPRS responds in Japanese unless the English output is requested
+	"whois.jprs.jp": {query: func(d string) string { return d + "/e" }, parse: parseBracketed},
+	"whois.nic.uk":  {query: plainQuery, parse: parseSections},
+	"whois.dns.be":  {query: plainQuery, parse: parseSections},
+	"whois.nic.it":  {query: plainQuery, parse: parseSections},
+}
+
+func registryFor(server string) *registry {
+	if reg, found := registries[strings.ToLower(server)]; found {
+		return reg
+	}
+	return &registry{query: plainQuery, parse: parseKeyValues}
+}
+
+// The record fields, and the contact fields prefixed by the contact role.
+const (
+	fieldRegistrar  = "registrar"
+	fieldReferral   = "referral"
+	fieldCreated    = "created"
+	fieldUpdated    = "updated"
+	fieldExpires    = "expires"
+	fieldStatus     = "status"
+	fieldNameServer = "nameserver"
+	fieldName       = "name"
+	fieldOrg        = "org"
+	fieldEmail      = "email"
+	fieldPhone      = "phone"
+	fieldCountry    = "country"
+)
+
+// The keys used by the registries for each of the record fields.
+var fieldKeys = map[string]string{
+	"registrar":                              fieldRegistrar,
+	"sponsoring registrar":                   fieldRegistrar,
+	"registrar name":                         fieldRegistrar,
+	"registrar organization":                 fieldRegistrar,
+	"registrar whois server":                 fieldReferral,
+	"whois server":                           fieldReferral,
+	"creation date":                          fieldCreated,
+	"created":                                fieldCreated,
+	"created on":                             fieldCreated,
+	"registered on":                          fieldCreated,
+	"registered":                             fieldCreated,
+	"registered date":                        fieldCreated,
+	"registration time":                      fieldCreated,
+	"domain registration date":               fieldCreated,
+	"updated date":                           fieldUpdated,
+	"last updated":                           fieldUpdated,
+	"last updated on":                        fieldUpdated,
+	"last modified":                          fieldUpdated,
+	"last update":                            fieldUpdated,
+	"last-update":                            fieldUpdated,
+	"changed":                                fieldUpdated,
+	"modified":                               fieldUpdated,
+	"registry expiry date":                   fieldExpires,
+	"registrar registration expiration date": fieldExpires,
+	"expiration date":                        fieldExpires,
+	"expiry date":                            fieldExpires,
+	"expires":                                fieldExpires,
+	"expires on":                             fieldExpires,
+	"expire date":                            fieldExpires,
+	"paid-till":                              fieldExpires,
+	"renewal date":                           fieldExpires,
+	"domain status":                          fieldStatus,
+	"status":                                 fieldStatus,
+	"state":                                  fieldStatus,
+	"name server":                            fieldNameServer,
+	"name servers":                           fieldNameServer,
+	"nameserver":                             fieldNameServer,
+	"nameservers":                            fieldNameServer,
+	"nserver":                                fieldNameServer,
+	"dns":                                    fieldNameServer,
+	"registrant":                             "registrant " + fieldName,
+	"registrant name":                        "registrant " + fieldName,
+	"registrant organization":                "registrant " + fieldOrg,
+	"registrant organisation":                "registrant " + fieldOrg,
+	"registrant email":                       "registrant " + fieldEmail,
+	"registrant phone":                       "registrant " + fieldPhone,
+	"registrant country":                     "registrant " + fieldCountry,
+	"owner":                                  "registrant " + fieldName,
+	"owner-c":                                "registrant " + fieldName,
+	"org":                                    "registrant " + fieldOrg,
+	"organization":                           "registrant " + fieldOrg,
+	"organisation":                           "registrant " + fieldOrg,
+	"admin name":                             "admin " + fieldName,
+	"admin organization":                     "admin " + fieldOrg,
+	"admin email":                            "admin " + fieldEmail,
+	"admin phone":                            "admin " + fieldPhone,
+	"admin country":                          "admin " + fieldCountry,
+	"administrative contact":                 "admin " + fieldName,
+	"tech name":                              "tech " + fieldName,
+	"tech organization":                      "tech " + fieldOrg,
+	"tech email":                             "tech " + fieldEmail,
+	"tech phone":                             "tech " + fieldPhone,
+	"tech country":                           "tech " + fieldCountry,
+	"technical contact":                      "tech " + fieldName,
+}
+
+// Values that registries provide in place of the data withheld for privacy.
+var redacted = regexp.MustCompile(`(?i)redacted|not disclosed|withheld|data protected|gdpr masked|privacy`)
+
+// recordBuilder collects the field values of a response into a Record.
+type recordBuilder struct {
+	rec      *Record
+	contacts map[string]*Contact
+}
+
+func newRecordBuilder() *recordBuilder {
+	return &recordBuilder{
+		rec:      &Record{},
+		contacts: make(map[string]*Contact),
+	}
+}
+
+func (b *recordBuilder) set(key, value string) {
+	field, found := fieldKeys[normalizeKey(key)]
+	value = strings.TrimSpace(value)
+	if !found || value == "" {
+		return
+	}
+
+	switch field {
+	case fieldRegistrar:
+		if b.rec.Registrar == "" {
+			b.rec.Registrar = value
+		}
+	case fieldReferral:
+		if b.rec.Referral == "" {
+			b.rec.Referral = strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(value), "whois://"), "rwhois://")
+		}
+	case fieldCreated:
+		setTime(&b.rec.Created, value)
+	case fieldUpdated:
+		setTime(&b.rec.Updated, value)
+	case fieldExpires:
+		setTime(&b.rec.Expires, value)
+	case fieldStatus:
+		// Status values are often followed by an ICANN URL explaining the value
+		if status := strings.Fields(value); len(status) > 0 && !contains(b.rec.Status, status[0]) {
+			b.rec.Status = append(b.rec.Status, status[0])
+		}
+	case fieldNameServer:
+		// Some registries provide the addresses of the name server after the name
+		if fields := strings.Fields(strings.ReplaceAll(value, ",", " ")); len(fields) > 0 {
+			ns := strings.ToLower(strings.TrimSuffix(fields[0], "."))
+			if strings.Contains(ns, ".") && !isAddress(ns) && !contains(b.rec.NameServers, ns) {
+				b.rec.NameServers = append(b.rec.NameServers, ns)
+			}
+		}
+	default:
+		b.setContact(field, value)
+	}
+}
+
+func (b *recordBuilder) setContact(field, value string) {
+	if redacted.MatchString(value) {
+		return
+	}
+
+	role, attr, _ := strings.Cut(field, " ")
+	c, found := b.contacts[role]
+	if !found {
+		c = &Contact{}
+		b.contacts[role] = c
+	}
+
+	switch attr {
+	case fieldName:
+		if c.Name == "" {
+			c.Name = value
+		}
+	case fieldOrg:
+		if c.Organization == "" {
+			c.Organization = value
+		}
+	case fieldEmail:
+		if c.Email == "" {
+			c.Email = strings.ToLower(value)
+		}
+	case fieldPhone:
+		if c.Phone == "" {
+			c.Phone = value
+		}
+	case fieldCountry:
+		if c.Country == "" {
+			c.Country = value
+		}
+	}
+}
+
+func (b *recordBuilder) record() *Record {
+	for role, c := range b.contacts {
+		if c.empty() {
+			continue
+		}
+
+		switch role {
+		case "registrant":
+			b.rec.Registrant = c
+		case "admin":
+			b.rec.Admin = c
+		case "tech":
+			b.rec.Tech = c
+		}
+	}
+	return b.rec
+}
+
+// parseKeyValues parses the 'Key: Value' lines used by the gTLD registries and most ccTLDs.
+func parseKeyValues(raw string) *Record {
+	b := newRecordBuilder()
+
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "%") || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ">>>") {
+			continue
+		}
+
+		if key, value, found := strings.Cut(line, ":"); found {
+			b.set(key, value)
+		}
+	}
+	return b.record()
+}
+
+// parseBracketed parses the '[Key] Value' lines used by JPRS.
+func parseBracketed(raw string) *Record {
+	b := newRecordBuilder()
+
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		// The lines of the registrant section are prefixed by a letter, such as 'g. [Organization]'
+		if i := strings.Index(line, "["); i > 0 && i <= 3 {
+			line = line[i:]
+		}
+		if !strings.HasPrefix(line, "[") {
+			continue
+		}
+
+		if key, value, found := strings.Cut(line[1:], "]"); found {
+			b.set(key, value)
+		}
+	}
+	return b.record()
+}
+
+// parseSections parses the responses that provide a section header followed by the indented
+// values of the section, such as the 'Name servers:' section used by Nominet.
+func parseSections(raw string) *Record {
+	b := newRecordBuilder()
+
+	var section string
+	for _, line := range strings.Split(raw, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "%") || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		key, value, found := strings.Cut(trimmed, ":")
+		switch {
+		case found && strings.TrimSpace(value) == "":
+			// A header without a value starts a new section
+			section = key
+		case found && !strings.Contains(value, "//"):
+			// The keys within a contact section are qualified by the section, such as 'Registrant Email'
+			if _, known := fieldKeys[normalizeKey(key)]; !known && section != "" {
+				key = section + " " + key
+			}
+			b.set(key, value)
+		case section != "":
+			// Values without a key are provided one per line below the section header
+			b.set(section, trimmed)
+		}
+	}
+	return b.record()
+}
+
+func normalizeKey(key string) string {
+	key = strings.ToLower(strings.TrimSpace(key))
+	key = strings.ReplaceAll(key, "_", " ")
+	return strings.Join(strings.Fields(key), " ")
+}
+
+// The layouts of the dates provided by the registries.
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02",
+	"2006.01.02",
+	"2006/01/02",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04:05 (MST)",
+	"02-Jan-2006",
+	"02-Jan-2006 15:04:05 MST",
+	"02.01.2006",
+	"02/01/2006",
+	"20060102",
+	"January 2 2006",
+	"Mon Jan 2 15:04:05 MST 2006",
+}
+
+func setTime(t *time.Time, value string) {
+	if !t.IsZero() {
+		return
+	}
+
+	value = strings.TrimSpace(strings.TrimSuffix(value, "."))
+	for _, layout := range timeLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			*t = parsed.UTC()
+			return
+		}
+	}
+	// Some registries append the time zone or other text after the date
+	if fields := strings.Fields(value); len(fields) > 1 {
+		setTime(t, fields[0])
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func isAddress(s string) bool {
+	return strings.Count(s, ".") == 3 && strings.Trim(s, "0123456789.") == "" || strings.Contains(s, ":")
+}
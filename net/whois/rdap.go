@@ -0,0 +1,397 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package whois
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Bootstrap is the IANA RDAP bootstrap registry for domain names (RFC 9224), used to
+// decide if a TLD provides an RDAP service or must be queried using WHOIS.
+type Bootstrap struct {
+	servers map[string]string
+}
+
+type bootstrapFile struct {
+	Services [][][]string `json:"services"`
+}
+
+// ParseBootstrap reads the RDAP bootstrap registry published by IANA as dns.json.
+func ParseBootstrap(r io.Reader) (*Bootstrap, error) {
+	var file bootstrapFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to decode the RDAP bootstrap registry: %v", err)
+	}
+
+	b := &Bootstrap{servers: make(map[string]string)}
+	for _, service := range file.Services {
+		if len(service) != 2 || len(service[1]) == 0 {
+			continue
+		}
+
+		// Prefer the HTTPS base URL when the service provides several
+		url := service[1][0]
+		for _, u := range service[1] {
+			if strings.HasPrefix(u, "https://") {
+				url = u
+				break
+			}
+		}
+		for _, tld := range service[0] {
+			b.servers[strings.ToLower(tld)] = url
+		}
+	}
+	return b, nil
+}
+
+// Server returns the RDAP base URL for the TLD of the domain, or an empty string
+// when the registry of the TLD provides no RDAP service.
+func (b *Bootstrap) Server(domain string) string {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+
+	// The registry can list multi-label entries, so the longest match is used
+	for d := domain; d != ""; {
+		if url, found := b.servers[d]; found {
+			return url
+		}
+
+		i := strings.Index(d, ".")
+		if i == -1 {
+			break
+		}
+		d = d[i+1:]
+	}
+	return ""
+}
+
+type reverseSearchResults struct {
+	Results []struct {
+		LDHName     string `json:"ldhName"`
+		UnicodeName string `json:"unicodeName"`
+	} `json:"domainSearchResults"`
+}
+
+// ReverseSearchURL returns the RDAP reverse search (RFC 9536) for the domains registered to the
+// organization, using the base URL of an RDAP server.
+func ReverseSearchURL(server, org string) string {
+	if !strings.HasSuffix(server, "/") {
+		server += "/"
+	}
+	return server + "domains/reverse_search/entity?fn=" + url.QueryEscape(org) + "&role=registrant"
+}
+
+// DomainURL returns the RDAP lookup of the domain, using the base URL of an RDAP server.
+func DomainURL(server, domain string) string {
+	if !strings.HasSuffix(server, "/") {
+		server += "/"
+	}
+	return server + "domain/" + url.PathEscape(strings.ToLower(strings.TrimSuffix(domain, ".")))
+}
+
+// ParseReverseSearch returns the names of the domains provided by an RDAP reverse search response.
+func ParseReverseSearch(r io.Reader) ([]string, error) {
+	var results reverseSearchResults
+	if err := json.NewDecoder(r).Decode(&results); err != nil {
+		return nil, fmt.Errorf("failed to decode the RDAP reverse search response: %v", err)
+	}
+
+	var names []string
+	for _, d := range results.Results {
+		name := d.LDHName
+		if name == "" {
+			name = d.UnicodeName
+		}
+		if name = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), ".")); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// Entity is a contact of an RDAP object (RFC 9083), such as the registrant of a domain, along with
+// the roles it holds for the object.
+type Entity struct {
+	Handle  string
+	Roles   []string
+	Contact Contact
+}
+
+// HasRole returns true when the entity holds the role for the object.
+func (e *Entity) HasRole(role string) bool {
+	for _, r := range e.Roles {
+		if strings.EqualFold(r, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// EntityFetcher returns the RDAP entity object found at the URL.
+type EntityFetcher func(ctx context.Context, link string) ([]byte, error)
+
+// EntityResolver collects the entities of RDAP objects, including the nested entities. The entities
+// only referenced by a link are fetched in parallel by a bounded number of workers, and each handle
+// is fetched once, since the same registry contacts are referenced by many objects of a session.
+type EntityResolver struct {
+	sync.Mutex
+	fetch   EntityFetcher
+	workers int
+	handles map[string]*entityCall
+}
+
+// entityCall is the fetch of an entity handle, which is shared by the walks referencing it.
+type entityCall struct {
+	done   chan struct{}
+	entity *rdapEntity
+	err    error
+}
+
+type rdapObject struct {
+	Entities []*rdapEntity `json:"entities"`
+}
+
+type rdapEntity struct {
+	Handle   string          `json:"handle"`
+	Roles    []string        `json:"roles"`
+	VCard    json.RawMessage `json:"vcardArray"`
+	Links    []*rdapLink     `json:"links"`
+	Entities []*rdapEntity   `json:"entities"`
+}
+
+type rdapLink struct {
+	Rel  string `json:"rel"`
+	Href string `json:"href"`
+}
+
+// NewEntityResolver returns an EntityResolver fetching the referenced entities using up to workers requests at once.
+func NewEntityResolver(workers int, fetch EntityFetcher) *EntityResolver {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	return &EntityResolver{
+		fetch:   fetch,
+		workers: workers,
+		handles: make(map[string]*entityCall),
+	}
+}
+
+// Entities returns the entities of the RDAP object, walking the nested entities one level at a time.
+// Each handle is provided once with all the roles it holds, and the entities that could not be fetched
+// are skipped.
+func (r *EntityResolver) Entities(ctx context.Context, body io.Reader) ([]*Entity, error) {
+	var obj rdapObject
+	if err := json.NewDecoder(body).Decode(&obj); err != nil {
+		return nil, fmt.Errorf("failed to decode the RDAP object: %v", err)
+	}
+
+	var results []*Entity
+	seen := make(map[string]*Entity)
+	for level := obj.Entities; len(level) > 0; {
+		var next []*rdapEntity
+
+		for i, ent := range r.resolveLevel(ctx, level) {
+			if ent == nil {
+				continue
+			}
+			// The roles are held for the object referencing the entity
+			roles := level[i].Roles
+			if len(roles) == 0 {
+				roles = ent.Roles
+			}
+
+			key := handleKey(ent.Handle)
+			if prev, found := seen[key]; found && key != "" {
+				for _, role := range roles {
+					if !prev.HasRole(role) {
+						prev.Roles = append(prev.Roles, role)
+					}
+				}
+				continue
+			}
+
+			e := &Entity{
+				Handle:  ent.Handle,
+				Roles:   append([]string(nil), roles...),
+				Contact: parseVCard(ent.VCard),
+			}
+			if key != "" {
+				seen[key] = e
+			}
+			results = append(results, e)
+			next = append(next, ent.Entities...)
+		}
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		level = next
+	}
+	return results, nil
+}
+
+// resolveLevel returns the complete entity for each entity of the level, fetching those without
+// contact details in parallel. Nil is returned for the entities that could not be fetched.
+func (r *EntityResolver) resolveLevel(ctx context.Context, level []*rdapEntity) []*rdapEntity {
+	results := make([]*rdapEntity, len(level))
+	sem := make(chan struct{}, r.workers)
+
+	var wg sync.WaitGroup
+	for i, ent := range level {
+		link := ent.selfLink()
+		if len(ent.VCard) > 0 || link == "" || handleKey(ent.Handle) == "" {
+			results[i] = ent
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, handle, link string) {
+			defer wg.Done()
+
+			if e, err := r.resolve(ctx, sem, handle, link); err == nil {
+				results[i] = e
+			}
+		}(i, ent.Handle, link)
+	}
+	wg.Wait()
+	return results
+}
+
+// resolve returns the entity fetched for the handle, waiting for the fetch already in progress.
+func (r *EntityResolver) resolve(ctx context.Context, sem chan struct{}, handle, link string) (*rdapEntity, error) {
+	key := handleKey(handle)
+
+	r.Lock()
+	if call, found := r.handles[key]; found {
+		r.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-call.done:
+		}
+		return call.entity, call.err
+	}
+
+	call := &entityCall{done: make(chan struct{})}
+	r.handles[key] = call
+	r.Unlock()
+
+	select {
+	case <-ctx.Done():
+		call.err = ctx.Err()
+	case sem <- struct{}{}:
+		call.entity, call.err = r.fetchEntity(ctx, link)
+		<-sem
+	}
+	// The failed fetches are attempted again by later walks
+	if call.err != nil {
+		r.Lock()
+		delete(r.handles, key)
+		r.Unlock()
+	}
+	close(call.done)
+	return call.entity, call.err
+}
+
+func (r *EntityResolver) fetchEntity(ctx context.Context, link string) (*rdapEntity, error) {
+	data, err := r.fetch(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+
+	var ent rdapEntity
+	if err := json.Unmarshal(data, &ent); err != nil {
+		return nil, fmt.Errorf("failed to decode the RDAP entity: %v", err)
+	}
+	return &ent, nil
+}
+
+// selfLink returns the URL of the entity object, or an empty string when it is not provided.
+func (e *rdapEntity) selfLink() string {
+	for _, l := range e.Links {
+		if strings.EqualFold(l.Rel, "self") && l.Href != "" {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+func handleKey(handle string) string {
+	return strings.ToUpper(strings.TrimSpace(handle))
+}
+
+// parseVCard returns the contact details of the jCard (RFC 7095) provided by the vcardArray of an entity.
+func parseVCard(data json.RawMessage) Contact {
+	var c Contact
+
+	var card []json.RawMessage
+	if len(data) == 0 || json.Unmarshal(data, &card) != nil || len(card) != 2 {
+		return c
+	}
+
+	var props [][]json.RawMessage
+	if json.Unmarshal(card[1], &props) != nil {
+		return c
+	}
+	for _, p := range props {
+		if len(p) < 4 {
+			continue
+		}
+
+		var name string
+		if json.Unmarshal(p[0], &name) != nil {
+			continue
+		}
+		switch strings.ToLower(name) {
+		case "fn":
+			c.Name = vcardText(p[3])
+		case "org":
+			c.Organization = vcardText(p[3])
+		case "email":
+			c.Email = vcardText(p[3])
+		case "tel":
+			c.Phone = strings.TrimPrefix(vcardText(p[3]), "tel:")
+		case "adr":
+			var params struct {
+				CC string `json:"cc"`
+			}
+			_ = json.Unmarshal(p[1], &params)
+
+			c.Country = params.CC
+			// Without the country code parameter, the country name is the last component of the address
+			var parts []json.RawMessage
+			if c.Country == "" && json.Unmarshal(p[3], &parts) == nil && len(parts) > 0 {
+				c.Country = vcardText(parts[len(parts)-1])
+			}
+		}
+	}
+	return c
+}
+
+// vcardText returns the value of a jCard property, joining the components of structured values.
+func vcardText(data json.RawMessage) string {
+	var s string
+	if json.Unmarshal(data, &s) == nil {
+		return strings.TrimSpace(s)
+	}
+
+	var parts []string
+	if json.Unmarshal(data, &parts) == nil {
+		var kept []string
+		for _, p := range parts {
+			if p = strings.TrimSpace(p); p != "" {
+				kept = append(kept, p)
+			}
+		}
+		return strings.Join(kept, " ")
+	}
+	return ""
+}
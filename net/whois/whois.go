@@ -0,0 +1,237 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package whois obtains domain registration records using the WHOIS protocol (port 43), for the
+// many ccTLDs and legacy registries that still do not provide an RDAP service.
+package whois
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"golang.org/x/net/publicsuffix"
+)
+
+// IANAServer is the WHOIS server that refers queries to the server of each TLD.
+const IANAServer = "whois.iana.org"
+
+const (
+	queryTimeout = 20 * time.Second
+	// The largest response read from a WHOIS server
+	maxResponseSize = 1 << 20
+)
+
+// Contact is a party responsible for the registered domain.
+type Contact struct {
+	Name         string `json:"name,omitempty"`
+	Organization string `json:"organization,omitempty"`
+	Email        string `json:"email,omitempty"`
+	Phone        string `json:"phone,omitempty"`
+	Country      string `json:"country,omitempty"`
+}
+
+func (c *Contact) empty() bool {
+	return c.Name == "" && c.Organization == "" && c.Email == "" && c.Phone == "" && c.Country == ""
+}
+
+// Record is the registration record of a domain, providing the same information as an RDAP domain object.
+type Record struct {
+	Domain      string    `json:"domain"`
+	Server      string    `json:"server"`
+	Registrar   string    `json:"registrar,omitempty"`
+	Created     time.Time `json:"created,omitempty"`
+	Updated     time.Time `json:"updated,omitempty"`
+	Expires     time.Time `json:"expires,omitempty"`
+	Status      []string  `json:"status,omitempty"`
+	NameServers []string  `json:"name_servers,omitempty"`
+	Registrant  *Contact  `json:"registrant,omitempty"`
+	Admin       *Contact  `json:"admin,omitempty"`
+	Tech        *Contact  `json:"tech,omitempty"`
+	// Referral is the registrar WHOIS server provided by thin registries
+	Referral string `json:"-"`
+	Raw      string `json:"-"`
+}
+
+// ErrNotFound is returned when the WHOIS server has no record for the domain.
+var ErrNotFound = errors.New("the domain is not registered")
+
+// Caches the WHOIS server of each TLD referred by IANA.
+var servers sync.Map
+
+// Lookup returns the registration record of the registered domain of the name. The record of a thin
+// registry is completed using the WHOIS server of the registrar, when the registry refers to one.
+func Lookup(ctx context.Context, name string) (*Record, error) {
+	domain, err := publicsuffix.EffectiveTLDPlusOne(strings.ToLower(strings.TrimSuffix(name, ".")))
+	if err != nil {
+		return nil, err
+	}
+
+	server, err := Server(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	rec, err := lookup(ctx, server, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	if ref := rec.Referral; ref != "" && !strings.EqualFold(ref, server) {
+		if detail, err := lookup(ctx, ref, domain); err == nil {
+			rec.merge(detail)
+		}
+	}
+	return rec, nil
+}
+
+func lookup(ctx context.Context, server, domain string) (*Record, error) {
+	reg := registryFor(server)
+
+	raw, err := Query(ctx, server, reg.query(domain))
+	if err != nil {
+		return nil, err
+	}
+
+	rec := reg.parse(raw)
+	// Only responses without any registration data are checked for the messages
+	// of unregistered domains, since contact fields can also be 'not found'
+	if rec.empty() {
+		if notFound(raw) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("the response from %s provided no registration data", server)
+	}
+	rec.Domain = domain
+	rec.Server = server
+	rec.Raw = raw
+	return rec, nil
+}
+
+// Server returns the WHOIS server of the TLD of the domain, as referred by IANA.
+func Server(ctx context.Context, domain string) (string, error) {
+	tld := domain
+	if i := strings.LastIndex(domain, "."); i != -1 {
+		tld = domain[i+1:]
+	}
+
+	if s, found := servers.Load(tld); found {
+		return s.(string), nil
+	}
+
+	raw, err := Query(ctx, IANAServer, tld)
+	if err != nil {
+		return "", err
+	}
+
+	var server string
+	for _, line := range strings.Split(raw, "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+
+		if k := strings.ToLower(strings.TrimSpace(key)); k == "refer" || k == "whois" {
+			server = strings.ToLower(strings.TrimSpace(value))
+			break
+		}
+	}
+	if server == "" {
+		return "", fmt.Errorf("no WHOIS server is known for the %s TLD", tld)
+	}
+
+	servers.Store(tld, server)
+	return server, nil
+}
+
+// Query sends the query to the WHOIS server and returns the response.
+func Query(ctx context.Context, server, query string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	addr := server
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		addr = net.JoinHostPort(server, "43")
+	}
+
+	conn, err := amassnet.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to %s: %v", server, err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if _, err := io.WriteString(conn, query+"\r\n"); err != nil {
+		return "", fmt.Errorf("failed to send the query to %s: %v", server, err)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(conn, maxResponseSize))
+	if err != nil && len(data) == 0 {
+		return "", fmt.Errorf("failed to read the response from %s: %v", server, err)
+	}
+	return string(data), nil
+}
+
+func (r *Record) empty() bool {
+	return r.Registrar == "" && r.Created.IsZero() && r.Expires.IsZero() && len(r.NameServers) == 0
+}
+
+// merge completes the record using the values provided by the registrar.
+func (r *Record) merge(detail *Record) {
+	if detail.Registrar != "" {
+		r.Registrar = detail.Registrar
+	}
+	if r.Created.IsZero() {
+		r.Created = detail.Created
+	}
+	if r.Updated.IsZero() {
+		r.Updated = detail.Updated
+	}
+	if r.Expires.IsZero() {
+		r.Expires = detail.Expires
+	}
+	if len(r.NameServers) == 0 {
+		r.NameServers = detail.NameServers
+	}
+	if len(r.Status) == 0 {
+		r.Status = detail.Status
+	}
+	if detail.Registrant != nil {
+		r.Registrant = detail.Registrant
+	}
+	if detail.Admin != nil {
+		r.Admin = detail.Admin
+	}
+	if detail.Tech != nil {
+		r.Tech = detail.Tech
+	}
+}
+
+func notFound(raw string) bool {
+	lower := strings.ToLower(raw)
+
+	for _, s := range []string{
+		"no match for",
+		"not found",
+		"no entries found",
+		"no data found",
+		"status: free",
+		"status:\tavailable",
+		"is available for registration",
+		"no matching record",
+	} {
+		if strings.Contains(lower, s) {
+			return true
+		}
+	}
+	return false
+}
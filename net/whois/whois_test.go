@@ -0,0 +1,422 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package whois
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const genericResponse = `Domain Name: EXAMPLE.COM
+Registry Domain ID: 2336799_DOMAIN_COM-VRSN
+Registrar WHOIS Server: whois.registrar.example
+Updated Date: 2023-08-14T07:01:38Z
+Creation Date: 1995-08-14T04:00:00Z
+Registry Expiry Date: 2024-08-13T04:00:00Z
+Registrar: RESERVED-Internet Assigned Numbers Authority
+Domain Status: clientDeleteProhibited https://icann.org/epp#clientDeleteProhibited
+Domain Status: clientTransferProhibited https://icann.org/epp#clientTransferProhibited
+Name Server: A.IANA-SERVERS.NET
+Name Server: B.IANA-SERVERS.NET
+Registrant Organization: Internet Assigned Numbers Authority
+Registrant Email: REDACTED FOR PRIVACY
+Registrant Country: US
+>>> Last update of whois database: 2023-09-01T00:00:00Z <<<
+`
+
+const nominetResponse = `
+    Domain name:
+        example.co.uk
+
+    Registrant:
+        Example Ltd
+
+    Registrar:
+        Example Registrar Ltd [Tag = EXAMPLE]
+        URL: https://www.registrar.example
+
+    Relevant dates:
+        Registered on: 26-Aug-1996
+        Expiry date:  26-Aug-2025
+        Last updated:  29-Jul-2024
+
+    Registration status:
+        Registered until expiry date.
+
+    Name servers:
+        ns1.example.net           192.0.2.1
+        ns2.example.net
+
+    WHOIS lookup made at 12:00:00 01-Sep-2024
+`
+
+const jprsResponse = `[ JPRS database provides information on network administration. ]
+
+Domain Information:
+a. [Domain Name]                EXAMPLE.JP
+g. [Organization]               Example Co., Ltd.
+l. [Organization Type]          Corporation
+p. [Name Server]                ns1.example.jp
+p. [Name Server]                ns2.example.jp
+[State]                         Connected (2024/10/31)
+[Registered Date]               2001/05/17
+[Connected Date]                2001/05/17
+[Last Update]                   2023/11/01 01:05:04 (JST)
+`
+
+const denicResponse = `% Restricted rights.
+Domain: example.de
+Nserver: ns1.example.de. 192.0.2.53
+Nserver: ns2.example.net
+Status: connect
+Changed: 2018-03-12T21:44:25+01:00
+`
+
+func TestParseKeyValues(t *testing.T) {
+	rec := parseKeyValues(genericResponse)
+
+	if rec.Registrar != "RESERVED-Internet Assigned Numbers Authority" {
+		t.Errorf("unexpected registrar: %s", rec.Registrar)
+	}
+	if rec.Referral != "whois.registrar.example" {
+		t.Errorf("unexpected referral: %s", rec.Referral)
+	}
+	if want := time.Date(1995, 8, 14, 4, 0, 0, 0, time.UTC); !rec.Created.Equal(want) {
+		t.Errorf("unexpected creation date: %v", rec.Created)
+	}
+	if want := time.Date(2024, 8, 13, 4, 0, 0, 0, time.UTC); !rec.Expires.Equal(want) {
+		t.Errorf("unexpected expiry date: %v", rec.Expires)
+	}
+	if got := strings.Join(rec.Status, ","); got != "clientDeleteProhibited,clientTransferProhibited" {
+		t.Errorf("unexpected status: %s", got)
+	}
+	if got := strings.Join(rec.NameServers, ","); got != "a.iana-servers.net,b.iana-servers.net" {
+		t.Errorf("unexpected name servers: %s", got)
+	}
+	if c := rec.Registrant; c == nil || c.Organization != "Internet Assigned Numbers Authority" || c.Country != "US" {
+		t.Errorf("unexpected registrant: %+v", c)
+	} else if c.Email != "" {
+		t.Errorf("the redacted email was kept: %s", c.Email)
+	}
+	if rec.Admin != nil || rec.Tech != nil {
+		t.Errorf("contacts were created without any values")
+	}
+}
+
+func TestParseSections(t *testing.T) {
+	rec := parseSections(nominetResponse)
+
+	if rec.Registrar != "Example Registrar Ltd [Tag = EXAMPLE]" {
+		t.Errorf("unexpected registrar: %s", rec.Registrar)
+	}
+	if want := time.Date(1996, 8, 26, 0, 0, 0, 0, time.UTC); !rec.Created.Equal(want) {
+		t.Errorf("unexpected creation date: %v", rec.Created)
+	}
+	if want := time.Date(2025, 8, 26, 0, 0, 0, 0, time.UTC); !rec.Expires.Equal(want) {
+		t.Errorf("unexpected expiry date: %v", rec.Expires)
+	}
+	if want := time.Date(2024, 7, 29, 0, 0, 0, 0, time.UTC); !rec.Updated.Equal(want) {
+		t.Errorf("unexpected update date: %v", rec.Updated)
+	}
+	if got := strings.Join(rec.NameServers, ","); got != "ns1.example.net,ns2.example.net" {
+		t.Errorf("unexpected name servers: %s", got)
+	}
+	if c := rec.Registrant; c == nil || c.Name != "Example Ltd" {
+		t.Errorf("unexpected registrant: %+v", c)
+	}
+}
+
+func TestParseBracketed(t *testing.T) {
+	rec := parseBracketed(jprsResponse)
+
+	if got := strings.Join(rec.NameServers, ","); got != "ns1.example.jp,ns2.example.jp" {
+		t.Errorf("unexpected name servers: %s", got)
+	}
+	if want := time.Date(2001, 5, 17, 0, 0, 0, 0, time.UTC); !rec.Created.Equal(want) {
+		t.Errorf("unexpected creation date: %v", rec.Created)
+	}
+	if got := strings.Join(rec.Status, ","); got != "Connected" {
+		t.Errorf("unexpected status: %s", got)
+	}
+	if c := rec.Registrant; c == nil || c.Organization != "Example Co., Ltd." {
+		t.Errorf("unexpected registrant: %+v", c)
+	}
+}
+
+func TestRegistryFor(t *testing.T) {
+	if q := registryFor("whois.denic.de").query("example.de"); q != "-T dn,ace example.de" {
+		t.Errorf("unexpected DENIC query: %s", q)
+	}
+	if q := registryFor("WHOIS.JPRS.JP").query("example.jp"); q != "example.jp/e" {
+		t.Errorf("unexpected JPRS query: %s", q)
+	}
+	if q := registryFor("whois.verisign-grs.com").query("example.com"); q != "example.com" {
+		t.Errorf("unexpected default query: %s", q)
+	}
+
+	rec := registryFor("whois.denic.de").parse(denicResponse)
+	if got := strings.Join(rec.NameServers, ","); got != "ns1.example.de,ns2.example.net" {
+		t.Errorf("unexpected name servers: %s", got)
+	}
+	if rec.Updated.IsZero() || strings.Join(rec.Status, ",") != "connect" {
+		t.Errorf("unexpected DENIC record: %+v", rec)
+	}
+}
+
+// fakeServer answers each WHOIS query using the responses keyed by the query.
+func fakeServer(t *testing.T, responses map[string]string) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go func(c net.Conn) {
+				defer c.Close()
+
+				query, err := bufio.NewReader(c).ReadString('\n')
+				if err != nil {
+					return
+				}
+				if resp, found := responses[strings.TrimSpace(query)]; found {
+					_, _ = c.Write([]byte(resp))
+				} else {
+					_, _ = c.Write([]byte("No match for \"" + strings.TrimSpace(query) + "\".\r\n"))
+				}
+			}(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestLookup(t *testing.T) {
+	server := fakeServer(t, map[string]string{"example.com": genericResponse})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rec, err := lookup(ctx, server, "example.com")
+	if err != nil {
+		t.Fatalf("the lookup failed: %v", err)
+	}
+	if rec.Domain != "example.com" || rec.Server != server || len(rec.NameServers) != 2 || rec.Raw == "" {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+
+	if _, err := lookup(ctx, server, "unregistered.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an unregistered domain, got %v", err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	thin := parseKeyValues(genericResponse)
+	thin.Registrant = nil
+
+	thin.merge(&Record{
+		Registrar:  "Example Registrar, Inc.",
+		Registrant: &Contact{Name: "Domain Administrator"},
+	})
+	if thin.Registrar != "Example Registrar, Inc." {
+		t.Errorf("the registrar was not completed: %s", thin.Registrar)
+	}
+	if thin.Registrant == nil || thin.Registrant.Name != "Domain Administrator" {
+		t.Errorf("the registrant was not completed: %+v", thin.Registrant)
+	}
+	if len(thin.NameServers) != 2 {
+		t.Errorf("the name servers of the registry were replaced: %v", thin.NameServers)
+	}
+}
+
+func TestBootstrap(t *testing.T) {
+	reg, err := ParseBootstrap(strings.NewReader(`{
+		"version": "1.0",
+		"services": [
+			[["com", "net"], ["http://rdap.example.com/", "https://rdap.example.com/"]],
+			[["br"], ["https://rdap.registro.br/"]]
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("failed to parse the bootstrap registry: %v", err)
+	}
+
+	for domain, want := range map[string]string{
+		"www.example.com": "https://rdap.example.com/",
+		"EXAMPLE.NET.":    "https://rdap.example.com/",
+		"example.com.br":  "https://rdap.registro.br/",
+		"example.de":      "",
+	} {
+		if got := reg.Server(domain); got != want {
+			t.Errorf("%s: expected %q, got %q", domain, want, got)
+		}
+	}
+}
+
+func TestReverseSearch(t *testing.T) {
+	if got := ReverseSearchURL("https://rdap.example.com", "Example Corp"); got !=
+		"https://rdap.example.com/domains/reverse_search/entity?fn=Example+Corp&role=registrant" {
+		t.Errorf("unexpected reverse search URL: %s", got)
+	}
+
+	names, err := ParseReverseSearch(strings.NewReader(`{
+		"rdapConformance": ["rdap_level_0", "reverse_search"],
+		"domainSearchResults": [
+			{"objectClassName": "domain", "ldhName": "EXAMPLE.COM."},
+			{"objectClassName": "domain", "unicodeName": "example.net"},
+			{"objectClassName": "domain"}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("failed to parse the reverse search response: %v", err)
+	}
+	if len(names) != 2 || names[0] != "example.com" || names[1] != "example.net" {
+		t.Errorf("unexpected domains in the reverse search response: %v", names)
+	}
+
+	if _, err := ParseReverseSearch(strings.NewReader("<html>")); err == nil {
+		t.Error("the invalid response was parsed")
+	}
+}
+
+func TestEntityResolver(t *testing.T) {
+	const base = "https://rdap.example.com/entity/"
+	objects := map[string]string{
+		"REG-1": `{"handle": "REG-1", "vcardArray": ["vcard", [
+			["version", {}, "text", "4.0"],
+			["fn", {}, "text", "Jane Doe"],
+			["org", {}, "text", "Example Corp"],
+			["email", {}, "text", "hostmaster@example.com"],
+			["tel", {"type": "voice"}, "uri", "tel:+1.5555550100"],
+			["adr", {"cc": "US"}, "text", ["", "", "1 Main St", "Springfield", "", "", ""]]
+		]], "entities": [
+			{"handle": "TECH-1", "roles": ["technical"], "links": [{"rel": "self", "href": "` + base + `TECH-1"}]}
+		]}`,
+		"TECH-1": `{"handle": "TECH-1", "vcardArray": ["vcard", [
+			["fn", {}, "text", "Network Operations"],
+			["adr", {}, "text", ["", "", "", "", "", "", "Canada"]]
+		]]}`,
+		"ABUSE-1": `{"handle": "ABUSE-1", "vcardArray": ["vcard", [["email", {}, "text", "abuse@registrar.example"]]]}`,
+	}
+
+	var lock sync.Mutex
+	var inflight, maxInflight int
+	fetched := make(map[string]int)
+	broken := true
+	fetch := func(ctx context.Context, link string) ([]byte, error) {
+		handle := strings.TrimPrefix(link, base)
+
+		lock.Lock()
+		fetched[handle]++
+		if inflight++; inflight > maxInflight {
+			maxInflight = inflight
+		}
+		fail := handle == "BROKEN-1" && broken
+		lock.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		lock.Lock()
+		inflight--
+		lock.Unlock()
+		if fail {
+			return nil, errors.New("the registry is unavailable")
+		}
+		if handle == "BROKEN-1" {
+			return []byte(`{"handle": "BROKEN-1", "vcardArray": ["vcard", [["fn", {}, "text", "Restored"]]]}`), nil
+		}
+		return []byte(objects[handle]), nil
+	}
+
+	ref := func(handle, role string) string {
+		return `{"handle": "` + handle + `", "roles": ["` + role + `"], "links": [{"rel": "self", "href": "` + base + handle + `"}]}`
+	}
+	domain := `{"objectClassName": "domain", "ldhName": "example.com", "entities": [` +
+		ref("REG-1", "registrant") + `,` + ref("reg-1", "administrative") + `,` +
+		ref("TECH-1", "technical") + `,` + ref("TECH-1", "technical") + `,` + ref("TECH-1", "technical") + `,` +
+		ref("BROKEN-1", "billing") + `,
+		{"handle": "146", "roles": ["registrar"], "vcardArray": ["vcard", [["fn", {}, "text", "Example Registrar"]]],
+			"entities": [` + ref("ABUSE-1", "abuse") + `]}
+	]}`
+
+	r := NewEntityResolver(2, fetch)
+	entities, err := r.Entities(context.Background(), strings.NewReader(domain))
+	if err != nil {
+		t.Fatalf("Entities returned an error: %v", err)
+	}
+
+	got := make(map[string]*Entity)
+	for _, e := range entities {
+		if _, found := got[e.Handle]; found {
+			t.Errorf("The entity %s was provided more than once", e.Handle)
+		}
+		got[e.Handle] = e
+	}
+	if len(got) != 4 || got["BROKEN-1"] != nil {
+		t.Errorf("Expected the four entities that could be fetched, got %d", len(got))
+	}
+	if e := got["REG-1"]; e == nil || !e.HasRole("registrant") || !e.HasRole("ADMINISTRATIVE") || e.Contact != (Contact{
+		Name:         "Jane Doe",
+		Organization: "Example Corp",
+		Email:        "hostmaster@example.com",
+		Phone:        "+1.5555550100",
+		Country:      "US",
+	}) {
+		t.Errorf("Unexpected registrant: %+v", e)
+	}
+	if e := got["TECH-1"]; e == nil || !e.HasRole("technical") || e.Contact.Name != "Network Operations" || e.Contact.Country != "Canada" {
+		t.Errorf("Unexpected technical contact: %+v", e)
+	}
+	if e := got["146"]; e == nil || !e.HasRole("registrar") || e.Contact.Name != "Example Registrar" {
+		t.Errorf("Unexpected registrar: %+v", e)
+	}
+	if e := got["ABUSE-1"]; e == nil || !e.HasRole("abuse") || e.Contact.Email != "abuse@registrar.example" {
+		t.Errorf("Unexpected abuse contact: %+v", e)
+	}
+
+	// The entities already fetched during the session are not fetched again
+	lock.Lock()
+	broken = false
+	lock.Unlock()
+	if _, err := r.Entities(context.Background(), strings.NewReader(domain)); err != nil {
+		t.Fatalf("Entities returned an error: %v", err)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	for handle, n := range fetched {
+		expected := 1
+		// The failed fetch is attempted again by the next walk
+		if handle == "BROKEN-1" {
+			expected = 2
+		}
+		if n != expected {
+			t.Errorf("The entity %s was fetched %d times", handle, n)
+		}
+	}
+	if len(fetched) != 4 {
+		t.Errorf("Expected four entities to be fetched, got %v", fetched)
+	}
+	if maxInflight > 2 {
+		t.Errorf("The entities were fetched using %d requests at once, beyond the limit of 2", maxInflight)
+	}
+
+	if _, err := r.Entities(context.Background(), strings.NewReader("<html>")); err == nil {
+		t.Error("The invalid RDAP object was parsed")
+	}
+	if got := DomainURL("https://rdap.example.com", "EXAMPLE.COM."); got != "https://rdap.example.com/domain/example.com" {
+		t.Errorf("Unexpected domain URL: %s", got)
+	}
+}
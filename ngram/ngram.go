@@ -0,0 +1,176 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package ngram
+
+import (
+	"container/heap"
+	"math"
+	"strings"
+	"sync"
+)
+
+const (
+	startChar rune = '^'
+	endChar   rune = '$'
+	// The longest label produced by the model
+	maxLabelLen int = 32
+	// Limits the work performed while searching for the most probable labels
+	maxExpansions int = 50000
+)
+
+// Model is a character n-gram model of the DNS labels it has been trained on.
+type Model struct {
+	sync.Mutex
+	order   int
+	counts  map[string]map[rune]int
+	totals  map[string]int
+	trained map[string]struct{}
+}
+
+// NewModel returns a Model that predicts each character from the order-1 characters preceding it.
+func NewModel(order int) *Model {
+	if order < 2 {
+		order = 2
+	}
+
+	return &Model{
+		order:   order,
+		counts:  make(map[string]map[rune]int),
+		totals:  make(map[string]int),
+		trained: make(map[string]struct{}),
+	}
+}
+
+// Train adds the label to the model. Labels are only counted the first time they are provided.
+func (m *Model) Train(label string) {
+	label = strings.ToLower(strings.TrimSpace(label))
+	if label == "" || len(label) > maxLabelLen || strings.ContainsAny(label, string([]rune{startChar, endChar})) {
+		return
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if _, found := m.trained[label]; found {
+		return
+	}
+	m.trained[label] = struct{}{}
+
+	seq := []rune(strings.Repeat(string(startChar), m.order-1) + label + string(endChar))
+	for i := m.order - 1; i < len(seq); i++ {
+		ctx := string(seq[i-m.order+1 : i])
+
+		next, found := m.counts[ctx]
+		if !found {
+			next = make(map[rune]int)
+			m.counts[ctx] = next
+		}
+		next[seq[i]]++
+		m.totals[ctx]++
+	}
+}
+
+// Len returns the number of distinct labels the model was trained on.
+func (m *Model) Len() int {
+	m.Lock()
+	defer m.Unlock()
+
+	return len(m.trained)
+}
+
+// Probability returns the likelihood of the model generating the label.
+func (m *Model) Probability(label string) float64 {
+	m.Lock()
+	defer m.Unlock()
+
+	prefix := strings.Repeat(string(startChar), m.order-1)
+	logp := 0.0
+	for _, c := range strings.ToLower(label) + string(endChar) {
+		p := m.prob(prefix, c)
+		if p == 0 {
+			return 0
+		}
+
+		logp += math.Log(p)
+		prefix = string(append([]rune(prefix)[1:], c))
+	}
+	return math.Exp(logp)
+}
+
+func (m *Model) prob(ctx string, c rune) float64 {
+	total := m.totals[ctx]
+	if total == 0 {
+		return 0
+	}
+	return float64(m.counts[ctx][c]) / float64(total)
+}
+
+// Guess returns up to max of the most probable labels that the model was not trained on.
+func (m *Model) Guess(max int) []string {
+	m.Lock()
+	defer m.Unlock()
+
+	var results []string
+	if max <= 0 || len(m.trained) == 0 {
+		return results
+	}
+
+	pq := &candidates{{ctx: strings.Repeat(string(startChar), m.order-1)}}
+	for expansions := 0; pq.Len() > 0 && len(results) < max && expansions < maxExpansions; expansions++ {
+		c := heap.Pop(pq).(*candidate)
+
+		for next, count := range m.counts[c.ctx] {
+			cost := c.cost - math.Log(float64(count)/float64(m.totals[c.ctx]))
+
+			if next == endChar {
+				if _, found := m.trained[c.label]; !found && c.label != "" {
+					heap.Push(pq, &candidate{label: c.label, cost: cost, done: true})
+				}
+				continue
+			}
+			if len(c.label) >= maxLabelLen {
+				continue
+			}
+
+			heap.Push(pq, &candidate{
+				label: c.label + string(next),
+				ctx:   string(append([]rune(c.ctx)[1:], next)),
+				cost:  cost,
+			})
+		}
+
+		// Complete labels are popped in order of their probability
+		for pq.Len() > 0 && (*pq)[0].done && len(results) < max {
+			results = append(results, heap.Pop(pq).(*candidate).label)
+		}
+	}
+	return results
+}
+
+type candidate struct {
+	label string
+	ctx   string
+	cost  float64
+	done  bool
+}
+
+// candidates is a priority queue ordering the partial labels by their negative log probability.
+type candidates []*candidate
+
+func (c candidates) Len() int           { return len(c) }
+func (c candidates) Less(i, j int) bool { return c[i].cost < c[j].cost }
+func (c candidates) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+
+func (c *candidates) Push(x interface{}) {
+	*c = append(*c, x.(*candidate))
+}
+
+func (c *candidates) Pop() interface{} {
+	old := *c
+	n := len(old)
+	item := old[n-1]
+	*c = old[:n-1]
+	return item
+}
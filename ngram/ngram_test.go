@@ -0,0 +1,43 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package ngram
+
+import "testing"
+
+func TestModelGuess(t *testing.T) {
+	m := NewModel(3)
+	for _, label := range []string{"web01", "web02", "web03", "web04", "db01", "db02"} {
+		m.Train(label)
+	}
+	m.Train("WEB01")
+	if m.Len() != 6 {
+		t.Errorf("Expected the model to be trained on 6 labels, got %d", m.Len())
+	}
+
+	guesses := m.Guess(10)
+	if len(guesses) == 0 {
+		t.Fatal("The model did not guess any labels")
+	}
+
+	found := make(map[string]struct{})
+	for _, g := range guesses {
+		if g == "web01" || g == "db01" {
+			t.Errorf("The model guessed the trained label %s", g)
+		}
+		found[g] = struct{}{}
+	}
+	for _, expected := range []string{"db03", "db04"} {
+		if _, ok := found[expected]; !ok {
+			t.Errorf("Expected %s among the guesses %v", expected, guesses)
+		}
+	}
+
+	if p := m.Probability("web01"); p <= 0 || p > 1 {
+		t.Errorf("Unexpected probability for a trained label: %f", p)
+	}
+	if p := m.Probability("mail"); p != 0 {
+		t.Errorf("Expected a zero probability for an unseen label, got %f", p)
+	}
+}
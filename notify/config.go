@@ -0,0 +1,254 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/report"
+	"github.com/owasp-amass/config/config"
+)
+
+// FromConfig returns a Dispatcher delivering the messages through the channels in the 'notifications'
+// section of the configuration options. A nil Dispatcher is returned when no channels have been configured.
+func FromConfig(cfg *config.Config) (*Dispatcher, error) {
+	notifyRaw, ok := cfg.Options["notifications"]
+	if !ok {
+		return nil, nil
+	}
+
+	settings, ok := notifyRaw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("notifications is not a map[string]interface{}")
+	}
+
+	var notifiers []Notifier
+	if raw, ok := settings["webhook"]; ok {
+		c, err := channelSettings("webhook", raw)
+		if err != nil {
+			return nil, err
+		}
+
+		w := NewWebhook(c.URL)
+		w.Template = c.Template
+		if ct, ok := c.Settings["content_type"]; ok {
+			if w.ContentType, ok = ct.(string); !ok {
+				return nil, fmt.Errorf("notifications webhook content_type is not a string")
+			}
+		}
+		notifiers = append(notifiers, w)
+	}
+	if raw, ok := settings["slack"]; ok {
+		c, err := channelSettings("slack", raw)
+		if err != nil {
+			return nil, err
+		}
+
+		s := NewSlack(c.URL)
+		s.Template = c.Template
+		notifiers = append(notifiers, s)
+	}
+
+	if raw, ok := settings["email"]; ok {
+		e, err := emailFromConfig(cfg, raw)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, e)
+	}
+
+	if len(notifiers) == 0 {
+		return nil, nil
+	}
+	return NewDispatcher(notifiers...), nil
+}
+
+type channel struct {
+	URL      string
+	Template *Template
+	Settings map[string]interface{}
+}
+
+// channelSettings returns the URL and the optional template shared by the channel sections.
+func channelSettings(name string, raw interface{}) (*channel, error) {
+	settings, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("notifications %s is not a map[string]interface{}", name)
+	}
+
+	u, ok := settings["url"].(string)
+	if !ok || u == "" {
+		return nil, fmt.Errorf("notifications %s url must be provided", name)
+	}
+	c := &channel{URL: u, Settings: settings}
+
+	tmpl, err := channelTemplate(name, settings)
+	if err != nil {
+		return nil, err
+	}
+	c.Template = tmpl
+	return c, nil
+}
+
+// channelTemplate returns the template provided by the 'template' or 'template_file' key of the channel section.
+func channelTemplate(name string, settings map[string]interface{}) (*Template, error) {
+	text, hasText := settings["template"]
+	path, hasPath := settings["template_file"]
+
+	switch {
+	case hasText && hasPath:
+		return nil, fmt.Errorf("notifications %s cannot have both a template and a template_file", name)
+	case hasText:
+		t, ok := text.(string)
+		if !ok {
+			return nil, fmt.Errorf("notifications %s template is not a string", name)
+		}
+		return NewTemplate(name, t)
+	case hasPath:
+		p, ok := path.(string)
+		if !ok {
+			return nil, fmt.Errorf("notifications %s template_file is not a string", name)
+		}
+		return ReadTemplate(name, p)
+	}
+	return nil, nil
+}
+
+func emailFromConfig(cfg *config.Config, raw interface{}) (*Email, error) {
+	settings, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("notifications email is not a map[string]interface{}")
+	}
+
+	host, ok := settings["host"].(string)
+	if !ok || host == "" {
+		return nil, fmt.Errorf("notifications email host must be provided")
+	}
+	from, ok := settings["from"].(string)
+	if !ok || from == "" {
+		return nil, fmt.Errorf("notifications email from must be provided")
+	}
+
+	var to []string
+	switch v := settings["to"].(type) {
+	case string:
+		to = append(to, v)
+	case []interface{}:
+		for _, addr := range v {
+			str, ok := addr.(string)
+			if !ok || str == "" {
+				return nil, fmt.Errorf("notifications email to contains an invalid address: %v", addr)
+			}
+			to = append(to, str)
+		}
+	}
+	if len(to) == 0 {
+		return nil, fmt.Errorf("notifications email to must be provided")
+	}
+
+	e := NewEmail(host, 0, from, to)
+	if raw, ok := settings["tls"]; ok {
+		str, ok := raw.(string)
+		if !ok || (str != StartTLS && str != ImplicitTLS && str != NoTLS) {
+			return nil, fmt.Errorf("notifications email tls must be %s, %s or %s", StartTLS, ImplicitTLS, NoTLS)
+		}
+		e.TLS = str
+	}
+	if raw, ok := settings["port"]; ok {
+		port, ok := raw.(int)
+		if !ok || port <= 0 || port > 65535 {
+			return nil, fmt.Errorf("notifications email port is not a valid port number: %v", raw)
+		}
+		e.Port = port
+	} else {
+		e.Port = defaultSMTPPorts[e.TLS]
+	}
+	if raw, ok := settings["insecure_skip_verify"]; ok {
+		if e.InsecureSkipVerify, ok = raw.(bool); !ok {
+			return nil, fmt.Errorf("notifications email insecure_skip_verify is not a bool")
+		}
+	}
+
+	for key, field := range map[string]*string{"username": &e.Username, "password": &e.Password} {
+		if raw, ok := settings[key]; ok {
+			str, ok := raw.(string)
+			if !ok {
+				return nil, fmt.Errorf("notifications email %s is not a string", key)
+			}
+			*field = str
+		}
+	}
+
+	if raw, ok := settings["digest"]; ok {
+		str, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("notifications email digest is not a string")
+		}
+
+		d, err := digestPeriod(str)
+		if err != nil {
+			return nil, err
+		}
+		e.Digest = d
+	}
+
+	if raw, ok := settings["subject"]; ok {
+		str, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("notifications email subject is not a string")
+		}
+
+		t, err := NewTemplate("email subject", str)
+		if err != nil {
+			return nil, err
+		}
+		e.Subject = t
+	}
+
+	tmpl, err := channelTemplate("email", settings)
+	if err != nil {
+		return nil, err
+	}
+	e.Template = tmpl
+
+	orgs, err := report.OrganizationsFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(orgs) > 0 {
+		e.Organizations = make(map[string]string)
+		for _, org := range orgs {
+			for _, d := range org.Domains {
+				e.Organizations[d] = org.Name
+			}
+		}
+	}
+	return e, nil
+}
+
+var defaultSMTPPorts = map[string]int{
+	StartTLS:    587,
+	ImplicitTLS: 465,
+	NoTLS:       25,
+}
+
+func digestPeriod(str string) (time.Duration, error) {
+	switch strings.ToLower(str) {
+	case "hourly":
+		return time.Hour, nil
+	case "daily":
+		return 24 * time.Hour, nil
+	case "weekly":
+		return 7 * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(str)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("notifications email digest must be hourly, daily, weekly or a duration: %s", str)
+	}
+	return d, nil
+}
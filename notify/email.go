@@ -0,0 +1,300 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The methods of securing the connections to the SMTP server.
+const (
+	StartTLS    = "starttls"
+	ImplicitTLS = "tls"
+	NoTLS       = "none"
+)
+
+// Email sends the messages through an SMTP server. When the Digest period is set, the messages
+// are collected and sent together once the period has elapsed since the previous digest.
+type Email struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+	// TLS is StartTLS, ImplicitTLS or NoTLS
+	TLS                string
+	InsecureSkipVerify bool
+	Subject            *Template
+	Template           *Template
+	Digest             time.Duration
+	// Organizations maps the root domain names to the organizations grouping the names of the digests
+	Organizations map[string]string
+	sync.Mutex
+	pending []*Message
+	last    time.Time
+}
+
+// NewEmail returns an Email sending the messages from the address to the recipients
+// through the SMTP server at host, using STARTTLS.
+func NewEmail(host string, port int, from string, to []string) *Email {
+	return &Email{
+		Host: host,
+		Port: port,
+		From: from,
+		To:   to,
+		TLS:  StartTLS,
+		last: time.Now(),
+	}
+}
+
+// String implements the Notifier interface.
+func (e *Email) String() string { return "email" }
+
+// Notify implements the Notifier interface.
+func (e *Email) Notify(ctx context.Context, m *Message) error {
+	if e.Digest <= 0 {
+		subject, body, err := e.render(m, m.Title, m.Text())
+		if err != nil {
+			return err
+		}
+		return e.send(ctx, subject, body)
+	}
+
+	e.Lock()
+	e.pending = append(e.pending, m)
+	e.Unlock()
+	return e.flush(ctx, false)
+}
+
+// Flush sends the digest of the collected messages when the digest period has elapsed.
+func (e *Email) Flush(ctx context.Context) error {
+	return e.flush(ctx, false)
+}
+
+// Close sends the digest of the collected messages, regardless of the digest period.
+func (e *Email) Close(ctx context.Context) error {
+	return e.flush(ctx, true)
+}
+
+func (e *Email) flush(ctx context.Context, force bool) error {
+	e.Lock()
+	defer e.Unlock()
+
+	now := time.Now()
+	if len(e.pending) == 0 || (!force && now.Sub(e.last) < e.Digest) {
+		return nil
+	}
+
+	d := e.digest(e.last, now)
+	subject, body, err := e.render(d, d.Title, d.Text())
+	if err != nil {
+		return err
+	}
+	if err := e.send(ctx, subject, body); err != nil {
+		return err
+	}
+
+	e.pending = nil
+	e.last = now
+	return nil
+}
+
+// DigestGroup holds the names discovered for an organization, or a root domain name
+// that does not belong to any of the organizations.
+type DigestGroup struct {
+	Name  string   `json:"name"`
+	Names []string `json:"names"`
+}
+
+// DigestMessage combines the messages collected during the digest period.
+type DigestMessage struct {
+	Title    string         `json:"title"`
+	Since    time.Time      `json:"since"`
+	Until    time.Time      `json:"until"`
+	Groups   []*DigestGroup `json:"groups"`
+	Messages []*Message     `json:"messages"`
+}
+
+// Text returns the digest as plain text, listing the names of each group below its name.
+func (d *DigestMessage) Text() string {
+	var b strings.Builder
+
+	b.WriteString(d.Title)
+	for _, g := range d.Groups {
+		b.WriteString(fmt.Sprintf("\n\n%s (%d)", g.Name, len(g.Names)))
+		for _, name := range g.Names {
+			b.WriteString("\n" + name)
+		}
+	}
+	return b.String()
+}
+
+func (e *Email) digest(since, until time.Time) *DigestMessage {
+	groups := make(map[string]map[string]struct{})
+
+	var total int
+	for _, m := range e.pending {
+		for _, name := range m.Names {
+			group := e.group(name, m.Domains)
+			if _, found := groups[group]; !found {
+				groups[group] = make(map[string]struct{})
+			}
+			if _, found := groups[group][name]; !found {
+				groups[group][name] = struct{}{}
+				total++
+			}
+		}
+	}
+
+	d := &DigestMessage{
+		Title:    fmt.Sprintf("%d new names were discovered since %s", total, since.UTC().Format(time.RFC1123)),
+		Since:    since,
+		Until:    until,
+		Messages: e.pending,
+	}
+	for name, set := range groups {
+		g := &DigestGroup{Name: name}
+		for n := range set {
+			g.Names = append(g.Names, n)
+		}
+		sort.Strings(g.Names)
+		d.Groups = append(d.Groups, g)
+	}
+	sort.Slice(d.Groups, func(i, j int) bool { return d.Groups[i].Name < d.Groups[j].Name })
+	return d
+}
+
+// group returns the organization of the name, or the root domain name containing it.
+func (e *Email) group(name string, domains []string) string {
+	var root, org string
+	for d, o := range e.Organizations {
+		if (name == d || strings.HasSuffix(name, "."+d)) && len(d) > len(root) {
+			root, org = d, o
+		}
+	}
+	if org != "" {
+		return org
+	}
+
+	for _, d := range domains {
+		if (name == d || strings.HasSuffix(name, "."+d)) && len(d) > len(root) {
+			root = d
+		}
+	}
+	if root == "" {
+		return "Other"
+	}
+	return root
+}
+
+func (e *Email) render(v interface{}, subject, body string) (string, string, error) {
+	if e.Subject != nil {
+		data, err := e.Subject.Execute(v)
+		if err != nil {
+			return "", "", err
+		}
+		subject = string(data)
+	}
+	if e.Template != nil {
+		data, err := e.Template.Execute(v)
+		if err != nil {
+			return "", "", err
+		}
+		body = string(data)
+	}
+	return strings.TrimSpace(strings.ReplaceAll(subject, "\n", " ")), body, nil
+}
+
+func (e *Email) send(ctx context.Context, subject, body string) error {
+	addr := net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+	tlsConfig := &tls.Config{
+		ServerName:         e.Host,
+		InsecureSkipVerify: e.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	} else {
+		_ = conn.SetDeadline(time.Now().Add(time.Minute))
+	}
+	if e.TLS == ImplicitTLS {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	c, err := smtp.NewClient(conn, e.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if e.TLS == StartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("the SMTP server %s does not support STARTTLS", addr)
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if e.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.Username, e.Password, e.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(e.From); err != nil {
+		return err
+	}
+	for _, to := range e.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(e.compose(subject, body)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// compose returns the message with bare line feeds, which the SMTP client converts to CRLF.
+func (e *Email) compose(subject, body string) []byte {
+	var b bytes.Buffer
+
+	b.WriteString("From: " + e.From + "\n")
+	b.WriteString("To: " + strings.Join(e.To, ", ") + "\n")
+	b.WriteString("Subject: " + subject + "\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\n")
+	b.WriteString("MIME-Version: 1.0\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\n")
+	b.WriteString("\n")
+	b.WriteString(strings.ReplaceAll(body, "\r\n", "\n"))
+	b.WriteString("\n")
+	return b.Bytes()
+}
@@ -0,0 +1,266 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package notify
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+type smtpServer struct {
+	sync.Mutex
+	ln     net.Listener
+	auth   []string
+	rcpts  []string
+	emails []string
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	s := &smtpServer{ln: ln}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpServer) port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+
+	_ = tp.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		cmd := strings.ToUpper(strings.Fields(line + " x")[0])
+		switch cmd {
+		case "EHLO":
+			_ = tp.PrintfLine("250-localhost")
+			_ = tp.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			s.Lock()
+			s.auth = append(s.auth, line)
+			s.Unlock()
+			_ = tp.PrintfLine("235 authenticated")
+		case "RCPT":
+			s.Lock()
+			s.rcpts = append(s.rcpts, line)
+			s.Unlock()
+			_ = tp.PrintfLine("250 ok")
+		case "DATA":
+			_ = tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.Lock()
+			s.emails = append(s.emails, string(data))
+			s.Unlock()
+			_ = tp.PrintfLine("250 queued")
+		case "QUIT":
+			_ = tp.PrintfLine("221 bye")
+			return
+		default:
+			_ = tp.PrintfLine("250 ok")
+		}
+	}
+}
+
+func (s *smtpServer) received() []string {
+	s.Lock()
+	defer s.Unlock()
+
+	return append([]string(nil), s.emails...)
+}
+
+func TestEmail(t *testing.T) {
+	srv := newSMTPServer(t)
+	defer srv.ln.Close()
+
+	cfg := config.NewConfig()
+	cfg.Options["notifications"] = map[string]interface{}{
+		"email": map[string]interface{}{
+			"host":     "127.0.0.1",
+			"port":     srv.port(),
+			"tls":      "none",
+			"username": "amass",
+			"password": "secret",
+			"from":     "amass@example.com",
+			"to":       []interface{}{"secops@example.com", "noc@example.com"},
+			"subject":  "[amass] {{.title}}",
+		},
+	}
+	d, err := FromConfig(cfg)
+	if err != nil {
+		t.Fatalf("FromConfig returned an error: %v", err)
+	}
+
+	m := &Message{
+		Kind:    NewNamesMessage,
+		Title:   "2 new names",
+		Domains: []string{"owasp.org"},
+		Names:   []string{"a.owasp.org", ".b.owasp.org"},
+	}
+	if err := d.Notify(context.Background(), m); err != nil {
+		t.Fatalf("Notify returned an error: %v", err)
+	}
+
+	emails := srv.received()
+	if len(emails) != 1 {
+		t.Fatalf("the server received %d emails, expected 1", len(emails))
+	}
+	for _, expected := range []string{"Subject: [amass] 2 new names\n", "To: secops@example.com, noc@example.com\n", "\n2 new names\na.owasp.org\n.b.owasp.org\n"} {
+		if !strings.Contains(emails[0], expected) {
+			t.Errorf("the email did not contain %q:\n%s", expected, emails[0])
+		}
+	}
+	if len(srv.auth) != 1 || len(srv.rcpts) != 2 {
+		t.Errorf("the client sent %d AUTH and %d RCPT commands", len(srv.auth), len(srv.rcpts))
+	}
+
+	e := d.notifiers[0].(*Email)
+	e.TLS = StartTLS
+	if err := e.Notify(context.Background(), m); err == nil {
+		t.Errorf("Notify did not fail without STARTTLS support")
+	}
+}
+
+func TestEmailDigest(t *testing.T) {
+	srv := newSMTPServer(t)
+	defer srv.ln.Close()
+
+	cfg := config.NewConfig()
+	cfg.Options["organizations"] = map[string]interface{}{
+		"OWASP": []interface{}{"owasp.org", "owasp.net"},
+	}
+	cfg.Options["notifications"] = map[string]interface{}{
+		"email": map[string]interface{}{
+			"host":   "127.0.0.1",
+			"port":   srv.port(),
+			"tls":    "none",
+			"from":   "amass@example.com",
+			"to":     "secops@example.com",
+			"digest": "daily",
+		},
+	}
+	d, err := FromConfig(cfg)
+	if err != nil {
+		t.Fatalf("FromConfig returned an error: %v", err)
+	}
+
+	ctx := context.Background()
+	for _, m := range []*Message{
+		{Kind: NewNamesMessage, Domains: []string{"owasp.org", "example.com"}, Names: []string{"b.owasp.org", "www.example.com"}},
+		{Kind: NewNamesMessage, Domains: []string{"owasp.net"}, Names: []string{"a.owasp.net", "b.owasp.org"}},
+	} {
+		if err := d.Notify(ctx, m); err != nil {
+			t.Fatalf("Notify returned an error: %v", err)
+		}
+	}
+	if err := d.Flush(ctx); err != nil || len(srv.received()) != 0 {
+		t.Fatalf("the digest was sent before the period elapsed: %v", err)
+	}
+
+	e := d.notifiers[0].(*Email)
+	e.last = time.Now().Add(-25 * time.Hour)
+	if err := d.Flush(ctx); err != nil {
+		t.Fatalf("Flush returned an error: %v", err)
+	}
+
+	emails := srv.received()
+	if len(emails) != 1 {
+		t.Fatalf("the server received %d emails, expected 1", len(emails))
+	}
+	if expected := "\n\nOWASP (2)\na.owasp.net\nb.owasp.org\n\nexample.com (1)\nwww.example.com\n"; !strings.Contains(emails[0], expected) {
+		t.Errorf("the digest did not contain %q:\n%s", expected, emails[0])
+	}
+	if !strings.Contains(emails[0], "Subject: 3 new names were discovered since ") {
+		t.Errorf("the digest had an unexpected subject:\n%s", emails[0])
+	}
+
+	if err := d.Close(ctx); err != nil || len(srv.received()) != 1 {
+		t.Errorf("Close sent an empty digest: %v", err)
+	}
+	_ = d.Notify(ctx, &Message{Names: []string{"c.owasp.org"}, Domains: []string{"owasp.org"}})
+	if err := d.Close(ctx); err != nil || len(srv.received()) != 2 {
+		t.Errorf("Close did not send the pending digest: %v", err)
+	}
+}
+
+func TestEmailFromConfig(t *testing.T) {
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"host": "smtp.example.com",
+			"from": "amass@example.com",
+			"to":   "secops@example.com",
+		}
+	}
+
+	cfg := config.NewConfig()
+	cfg.Options["notifications"] = map[string]interface{}{"email": valid()}
+	d, err := FromConfig(cfg)
+	if err != nil {
+		t.Fatalf("FromConfig returned an error: %v", err)
+	}
+	if e := d.notifiers[0].(*Email); e.Port != 587 || e.TLS != StartTLS || e.Digest != 0 {
+		t.Errorf("the email defaults were %s:%d, %s, %s", e.Host, e.Port, e.TLS, e.Digest)
+	}
+
+	for key, value := range map[string]interface{}{
+		"host":    "",
+		"from":    nil,
+		"to":      []interface{}{},
+		"tls":     "ssl",
+		"port":    "587",
+		"digest":  "monthly",
+		"subject": "{{.title",
+	} {
+		settings := valid()
+		if value == nil {
+			delete(settings, key)
+		} else {
+			settings[key] = value
+		}
+
+		cfg.Options["notifications"] = map[string]interface{}{"email": settings}
+		if _, err := FromConfig(cfg); err == nil {
+			t.Errorf("FromConfig accepted the email %s %v", key, value)
+		}
+	}
+
+	settings := valid()
+	settings["tls"] = "tls"
+	settings["digest"] = "12h"
+	cfg.Options["notifications"] = map[string]interface{}{"email": settings}
+	if d, err := FromConfig(cfg); err != nil {
+		t.Errorf("FromConfig returned an error: %v", err)
+	} else if e := d.notifiers[0].(*Email); e.Port != 465 || e.Digest != 12*time.Hour {
+		t.Errorf("the email used port %d and the digest %s", e.Port, e.Digest)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package notify delivers messages describing changes to the attack surface through the configured channels.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The kinds of messages delivered by the channels.
+const (
+	NewNamesMessage = "new_names"
+)
+
+// Message describes a change observed by the engine, such as names discovered by a scheduled enumeration.
+type Message struct {
+	Kind    string    `json:"kind"`
+	Title   string    `json:"title"`
+	Domains []string  `json:"domains,omitempty"`
+	Names   []string  `json:"names,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// Text returns the message as plain text, listing the names below the title.
+func (m *Message) Text() string {
+	var b strings.Builder
+
+	b.WriteString(m.Title)
+	for _, name := range m.Names {
+		b.WriteString("\n" + name)
+	}
+	return b.String()
+}
+
+// Notifier delivers messages through a single channel.
+type Notifier interface {
+	Notify(ctx context.Context, m *Message) error
+	String() string
+}
+
+// Dispatcher delivers each message through all the configured channels.
+// All methods are safe to call on a nil Dispatcher, which discards the messages.
+type Dispatcher struct {
+	notifiers []Notifier
+}
+
+// NewDispatcher returns a Dispatcher delivering the messages through the notifiers.
+func NewDispatcher(notifiers ...Notifier) *Dispatcher {
+	return &Dispatcher{notifiers: notifiers}
+}
+
+// Notify delivers the message through every channel, filling in the time when it has not been set.
+// The channels are all attempted, and the first failure is returned.
+func (d *Dispatcher) Notify(ctx context.Context, m *Message) error {
+	if d == nil {
+		return nil
+	}
+	if m.Time.IsZero() {
+		m.Time = time.Now()
+	}
+
+	var err error
+	for _, n := range d.notifiers {
+		if e := n.Notify(ctx, m); e != nil && err == nil {
+			err = fmt.Errorf("%s: %v", n.String(), e)
+		}
+	}
+	return err
+}
+
+// Flusher is implemented by the channels that collect the messages before delivering them.
+type Flusher interface {
+	// Flush delivers the collected messages that are due
+	Flush(ctx context.Context) error
+	// Close delivers all the collected messages
+	Close(ctx context.Context) error
+}
+
+// Flush delivers the messages collected by the channels that are due, such as the digests
+// whose period has elapsed. The first failure is returned.
+func (d *Dispatcher) Flush(ctx context.Context) error {
+	return d.flush(ctx, Flusher.Flush)
+}
+
+// Close delivers all the messages collected by the channels, and should be called
+// before the program terminates. The first failure is returned.
+func (d *Dispatcher) Close(ctx context.Context) error {
+	return d.flush(ctx, Flusher.Close)
+}
+
+func (d *Dispatcher) flush(ctx context.Context, fn func(Flusher, context.Context) error) error {
+	if d == nil {
+		return nil
+	}
+
+	var err error
+	for _, n := range d.notifiers {
+		if f, ok := n.(Flusher); ok {
+			if e := fn(f, ctx); e != nil && err == nil {
+				err = fmt.Errorf("%s: %v", n.String(), e)
+			}
+		}
+	}
+	return err
+}
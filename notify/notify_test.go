@@ -0,0 +1,166 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func TestDispatcher(t *testing.T) {
+	var webhook Message
+	var slack slackMessage
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		switch r.URL.Path {
+		case "/webhook":
+			err = json.NewDecoder(r.Body).Decode(&webhook)
+		case "/slack":
+			err = json.NewDecoder(r.Body).Decode(&slack)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher(NewWebhook(srv.URL+"/webhook"), NewSlack(srv.URL+"/slack"))
+	m := &Message{
+		Kind:    NewNamesMessage,
+		Title:   "2 new names",
+		Domains: []string{"owasp.org"},
+		Names:   []string{"a.owasp.org", "b.owasp.org"},
+	}
+	if err := d.Notify(context.Background(), m); err != nil {
+		t.Fatalf("Notify returned an error: %v", err)
+	}
+	if m.Time.IsZero() {
+		t.Errorf("Notify did not fill in the time of the message")
+	}
+	if webhook.Kind != NewNamesMessage || len(webhook.Names) != 2 {
+		t.Errorf("the webhook received %+v", webhook)
+	}
+	if expected := "2 new names\na.owasp.org\nb.owasp.org"; slack.Text != expected {
+		t.Errorf("slack received %q, expected %q", slack.Text, expected)
+	}
+
+	d = NewDispatcher(NewWebhook(srv.URL + "/missing"))
+	if err := d.Notify(context.Background(), m); err == nil {
+		t.Errorf("Notify did not return an error for the failed delivery")
+	}
+
+	var nilDispatcher *Dispatcher
+	if err := nilDispatcher.Notify(context.Background(), m); err != nil {
+		t.Errorf("Notify returned an error for the nil Dispatcher: %v", err)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	if d, err := FromConfig(cfg); d != nil || err != nil {
+		t.Errorf("FromConfig returned %v, %v without the notifications section", d, err)
+	}
+
+	cfg.Options["notifications"] = map[string]interface{}{
+		"webhook": map[string]interface{}{"url": "https://example.com/hook"},
+		"slack":   map[string]interface{}{"url": "https://hooks.slack.com/services/T/B/X"},
+	}
+	d, err := FromConfig(cfg)
+	if err != nil {
+		t.Fatalf("FromConfig returned an error: %v", err)
+	}
+	if d == nil || len(d.notifiers) != 2 {
+		t.Errorf("FromConfig did not return both channels")
+	}
+
+	cfg.Options["notifications"] = map[string]interface{}{
+		"webhook": map[string]interface{}{},
+	}
+	if _, err := FromConfig(cfg); err == nil {
+		t.Errorf("FromConfig accepted a webhook without a URL")
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	var body, contentType string
+	var slack slackMessage
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/webhook":
+			data, _ := io.ReadAll(r.Body)
+			body = string(data)
+			contentType = r.Header.Get("Content-Type")
+		case "/slack":
+			_ = json.NewDecoder(r.Body).Decode(&slack)
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "slack.tmpl")
+	if err := os.WriteFile(path, []byte(`*{{.title}}*{{range .names}} <{{.}}>{{end}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.NewConfig()
+	cfg.Options["notifications"] = map[string]interface{}{
+		"webhook": map[string]interface{}{
+			"url":          srv.URL + "/webhook",
+			"template":     `{"summary": {{json .title}}, "hosts": "{{join .names ","}}"}`,
+			"content_type": "application/vnd.alert+json",
+		},
+		"slack": map[string]interface{}{
+			"url":           srv.URL + "/slack",
+			"template_file": path,
+		},
+	}
+	d, err := FromConfig(cfg)
+	if err != nil {
+		t.Fatalf("FromConfig returned an error: %v", err)
+	}
+
+	m := &Message{
+		Kind:  NewNamesMessage,
+		Title: `2 "new" names`,
+		Names: []string{"a.owasp.org", "b.owasp.org"},
+	}
+	if err := d.Notify(context.Background(), m); err != nil {
+		t.Fatalf("Notify returned an error: %v", err)
+	}
+	if expected := `{"summary": "2 \"new\" names", "hosts": "a.owasp.org,b.owasp.org"}`; body != expected {
+		t.Errorf("the webhook received %q, expected %q", body, expected)
+	}
+	if contentType != "application/vnd.alert+json" {
+		t.Errorf("the webhook received the content type %q", contentType)
+	}
+	if expected := `*2 "new" names* <a.owasp.org> <b.owasp.org>`; slack.Text != expected {
+		t.Errorf("slack received %q, expected %q", slack.Text, expected)
+	}
+
+	for _, settings := range []map[string]interface{}{
+		{"url": srv.URL, "template": "{{.title"},
+		{"url": srv.URL, "template": "{{.title}}", "template_file": path},
+		{"url": srv.URL, "template_file": filepath.Join(dir, "missing.tmpl")},
+	} {
+		cfg.Options["notifications"] = map[string]interface{}{"webhook": settings}
+		if _, err := FromConfig(cfg); err == nil {
+			t.Errorf("FromConfig accepted the webhook settings %v", settings)
+		}
+	}
+}
@@ -0,0 +1,343 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package notify
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/config/config"
+)
+
+// The endpoints receiving the alerts of the paging services.
+const (
+	PagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+	OpsgenieURL  = "https://api.opsgenie.com/v2/alerts"
+)
+
+// Pager raises an alert with an on-call paging service for a finding.
+type Pager interface {
+	Page(ctx context.Context, f *findings.Finding) error
+	String() string
+}
+
+// DedupKey returns the key identifying the alerts raised for the same finding, so the
+// paging services do not page again when the finding is observed by later enumerations.
+func DedupKey(f *findings.Finding) string {
+	sum := sha256.Sum256([]byte(f.Key()))
+	return "amass-" + hex.EncodeToString(sum[:16])
+}
+
+func alertSummary(f *findings.Finding) string {
+	summary := fmt.Sprintf("[%s] %s: %s", f.Severity, f.Type, f.Asset)
+	if f.Description != "" {
+		summary += " - " + f.Description
+	}
+	// Both services limit the length of the summary
+	if len(summary) > 1024 {
+		summary = summary[:1024]
+	}
+	return summary
+}
+
+// PagerDuty triggers the alerts through the PagerDuty Events API v2.
+type PagerDuty struct {
+	URL        string
+	RoutingKey string
+	http       *http.Client
+}
+
+// NewPagerDuty returns a PagerDuty channel triggering the alerts for the integration routing key.
+func NewPagerDuty(routingKey string) *PagerDuty {
+	return &PagerDuty{
+		URL:        PagerDutyURL,
+		RoutingKey: routingKey,
+		http:       &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// String implements the Pager interface.
+func (p *PagerDuty) String() string { return "pagerduty" }
+
+var pagerDutySeverities = map[findings.Severity]string{
+	findings.Critical: "critical",
+	findings.High:     "error",
+	findings.Medium:   "warning",
+	findings.Low:      "info",
+	findings.Info:     "info",
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string           `json:"routing_key"`
+	EventAction string           `json:"event_action"`
+	DedupKey    string           `json:"dedup_key"`
+	Payload     pagerDutyPayload `json:"payload"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Timestamp     string            `json:"timestamp"`
+	Class         string            `json:"class"`
+	CustomDetails *findings.Finding `json:"custom_details"`
+}
+
+// Page implements the Pager interface.
+func (p *PagerDuty) Page(ctx context.Context, f *findings.Finding) error {
+	return postJSON(ctx, p.http, p.URL, &pagerDutyEvent{
+		RoutingKey:  p.RoutingKey,
+		EventAction: "trigger",
+		DedupKey:    DedupKey(f),
+		Payload: pagerDutyPayload{
+			Summary:       alertSummary(f),
+			Source:        f.Asset,
+			Severity:      pagerDutySeverities[f.Severity],
+			Timestamp:     f.Time.UTC().Format(time.RFC3339),
+			Class:         f.Type,
+			CustomDetails: f,
+		},
+	})
+}
+
+// Opsgenie creates the alerts through the Opsgenie Alert API.
+type Opsgenie struct {
+	URL    string
+	APIKey string
+	http   *http.Client
+}
+
+// NewOpsgenie returns an Opsgenie channel creating the alerts using the API key.
+func NewOpsgenie(apikey string) *Opsgenie {
+	return &Opsgenie{
+		URL:    OpsgenieURL,
+		APIKey: apikey,
+		http:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// String implements the Pager interface.
+func (o *Opsgenie) String() string { return "opsgenie" }
+
+var opsgeniePriorities = map[findings.Severity]string{
+	findings.Critical: "P1",
+	findings.High:     "P2",
+	findings.Medium:   "P3",
+	findings.Low:      "P4",
+	findings.Info:     "P5",
+}
+
+type opsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description,omitempty"`
+	Source      string            `json:"source"`
+	Entity      string            `json:"entity"`
+	Priority    string            `json:"priority"`
+	Tags        []string          `json:"tags"`
+	Details     map[string]string `json:"details,omitempty"`
+}
+
+// Page implements the Pager interface.
+func (o *Opsgenie) Page(ctx context.Context, f *findings.Finding) error {
+	msg := fmt.Sprintf("%s: %s", f.Type, f.Asset)
+	// Opsgenie limits the length of the message
+	if len(msg) > 130 {
+		msg = msg[:130]
+	}
+
+	data, err := json.Marshal(&opsgenieAlert{
+		Message:     msg,
+		Alias:       DedupKey(f),
+		Description: alertSummary(f),
+		Source:      "amass",
+		Entity:      f.Asset,
+		Priority:    opsgeniePriorities[f.Severity],
+		Tags:        []string{"amass", f.Type, f.Severity.String()},
+		Details:     f.Details,
+	})
+	if err != nil {
+		return err
+	}
+
+	return post(ctx, o.http, o.URL, data, http.Header{
+		"Content-Type":  {"application/json"},
+		"Authorization": {"GenieKey " + o.APIKey},
+	})
+}
+
+type pagerRoute struct {
+	pager  Pager
+	filter *findings.Filter
+}
+
+// Paging raises the alerts for the findings matching the filter of each paging service.
+// All methods are safe to call on a nil Paging, which discards the findings.
+type Paging struct {
+	routes []*pagerRoute
+	wg     sync.WaitGroup
+	// Log receives the failures of the alerts raised by Subscribe
+	Log func(format string, v ...interface{})
+}
+
+// NewPaging returns an empty Paging, receiving the paging services through Add.
+func NewPaging() *Paging {
+	return &Paging{}
+}
+
+// Add routes the findings matching the filter to the paging service.
+func (p *Paging) Add(pager Pager, filter *findings.Filter) {
+	p.routes = append(p.routes, &pagerRoute{pager: pager, filter: filter})
+}
+
+// Page raises an alert with each paging service whose filter matches the finding.
+// The services are all attempted, and the first failure is returned.
+func (p *Paging) Page(ctx context.Context, f *findings.Finding) error {
+	if p == nil {
+		return nil
+	}
+
+	var err error
+	for _, r := range p.routes {
+		if !r.filter.Match(f) {
+			continue
+		}
+		if e := r.pager.Page(ctx, f); e != nil && err == nil {
+			err = fmt.Errorf("%s: %v", r.pager.String(), e)
+		}
+	}
+	return err
+}
+
+// Subscribe raises the alerts for the new findings added to the store, without
+// holding up the callers adding the findings. Wait blocks until they are raised.
+func (p *Paging) Subscribe(store *findings.Store) {
+	if p == nil {
+		return
+	}
+
+	store.Subscribe(func(f *findings.Finding) {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+
+			if err := p.Page(ctx, f); err != nil && p.Log != nil {
+				p.Log("Failed to page for the %s finding on %s: %v", f.Type, f.Asset, err)
+			}
+		}()
+	})
+}
+
+// Wait blocks until the alerts raised for the findings added to the store have been delivered.
+func (p *Paging) Wait() {
+	if p != nil {
+		p.wg.Wait()
+	}
+}
+
+// PagingFromConfig returns the Paging raising the alerts through the 'pagerduty' and 'opsgenie' channels
+// in the 'notifications' section of the configuration options. A nil Paging is returned when neither
+// channel has been configured.
+func PagingFromConfig(cfg *config.Config) (*Paging, error) {
+	notifyRaw, ok := cfg.Options["notifications"]
+	if !ok {
+		return nil, nil
+	}
+
+	settings, ok := notifyRaw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("notifications is not a map[string]interface{}")
+	}
+
+	p := NewPaging()
+	if raw, ok := settings["pagerduty"]; ok {
+		s, filter, err := pagerSettings("pagerduty", raw)
+		if err != nil {
+			return nil, err
+		}
+
+		key, ok := s["routing_key"].(string)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("notifications pagerduty routing_key must be provided")
+		}
+
+		pd := NewPagerDuty(key)
+		if u, ok := s["url"].(string); ok && u != "" {
+			pd.URL = u
+		}
+		p.Add(pd, filter)
+	}
+	if raw, ok := settings["opsgenie"]; ok {
+		s, filter, err := pagerSettings("opsgenie", raw)
+		if err != nil {
+			return nil, err
+		}
+
+		key, ok := s["api_key"].(string)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("notifications opsgenie api_key must be provided")
+		}
+
+		og := NewOpsgenie(key)
+		if u, ok := s["url"].(string); ok && u != "" {
+			og.URL = u
+		}
+		p.Add(og, filter)
+	}
+
+	if len(p.routes) == 0 {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// pagerSettings returns the settings of the paging channel, along with the filter built from the
+// minimum 'severity' (critical by default) and the finding 'types' that raise the alerts.
+func pagerSettings(name string, raw interface{}) (map[string]interface{}, *findings.Filter, error) {
+	settings, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("notifications %s is not a map[string]interface{}", name)
+	}
+
+	filter := &findings.Filter{Severity: findings.Critical}
+	if raw, ok := settings["severity"]; ok {
+		str, ok := raw.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("notifications %s severity is not a string", name)
+		}
+
+		sev, err := findings.ParseSeverity(str)
+		if err != nil {
+			return nil, nil, fmt.Errorf("notifications %s severity: %v", name, err)
+		}
+		filter.Severity = sev
+	}
+	if raw, ok := settings["types"]; ok {
+		list, ok := raw.([]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("notifications %s types is not a list", name)
+		}
+
+		for _, v := range list {
+			t, ok := v.(string)
+			if !ok || strings.TrimSpace(t) == "" {
+				return nil, nil, fmt.Errorf("notifications %s types contains an invalid finding type: %v", name, v)
+			}
+			filter.Types = append(filter.Types, strings.TrimSpace(t))
+		}
+	}
+	return settings, filter, nil
+}
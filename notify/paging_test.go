@@ -0,0 +1,135 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestPaging(t *testing.T) {
+	var lock sync.Mutex
+	var events []pagerDutyEvent
+	var alerts []opsgenieAlert
+	var auth string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		switch r.URL.Path {
+		case "/pagerduty":
+			var ev pagerDutyEvent
+			_ = json.NewDecoder(r.Body).Decode(&ev)
+			events = append(events, ev)
+		case "/opsgenie":
+			var a opsgenieAlert
+			_ = json.NewDecoder(r.Body).Decode(&a)
+			alerts = append(alerts, a)
+			auth = r.Header.Get("Authorization")
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	cfg := config.NewConfig()
+	cfg.Options["notifications"] = map[string]interface{}{
+		"pagerduty": map[string]interface{}{
+			"routing_key": "R0UT1NG",
+			"url":         srv.URL + "/pagerduty",
+			"types":       []interface{}{"subdomain_takeover"},
+		},
+		"opsgenie": map[string]interface{}{
+			"api_key":  "G3N13",
+			"url":      srv.URL + "/opsgenie",
+			"severity": "high",
+		},
+	}
+	p, err := PagingFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("PagingFromConfig returned an error: %v", err)
+	}
+
+	store, err := findings.NewStore(filepath.Join(t.TempDir(), "findings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Subscribe(store)
+
+	for _, f := range []*findings.Finding{
+		{Type: "subdomain_takeover", Asset: "shop.owasp.org", Severity: findings.Critical, Description: "The CNAME target can be claimed"},
+		{Type: "subdomain_takeover", Asset: "shop.owasp.org", Severity: findings.Critical},
+		{Type: "expired_certificate", Asset: "www.owasp.org", Severity: findings.High},
+		{Type: "open_port", Asset: "192.0.2.1", Severity: findings.Medium},
+	} {
+		if _, err := store.Add(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p.Wait()
+
+	if len(events) != 1 {
+		t.Fatalf("PagerDuty received %d events, expected 1", len(events))
+	}
+	ev := events[0]
+	if ev.RoutingKey != "R0UT1NG" || ev.EventAction != "trigger" || ev.Payload.Severity != "critical" {
+		t.Errorf("PagerDuty received the event %+v", ev)
+	}
+	if expected := DedupKey(&findings.Finding{Type: "SUBDOMAIN_TAKEOVER", Asset: "shop.owasp.org"}); ev.DedupKey != expected {
+		t.Errorf("the dedup key was %s, expected %s", ev.DedupKey, expected)
+	}
+
+	if len(alerts) != 2 {
+		t.Fatalf("Opsgenie received %d alerts, expected 2", len(alerts))
+	}
+	if auth != "GenieKey G3N13" {
+		t.Errorf("Opsgenie received the authorization %q", auth)
+	}
+	priorities := map[string]string{}
+	for _, a := range alerts {
+		priorities[a.Entity] = a.Priority
+	}
+	if priorities["shop.owasp.org"] != "P1" || priorities["www.owasp.org"] != "P2" {
+		t.Errorf("Opsgenie received the priorities %v", priorities)
+	}
+
+	var nilPaging *Paging
+	nilPaging.Subscribe(store)
+	nilPaging.Wait()
+}
+
+func TestPagingFromConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	if p, err := PagingFromConfig(cfg); p != nil || err != nil {
+		t.Errorf("PagingFromConfig returned %v, %v without the notifications section", p, err)
+	}
+
+	cfg.Options["notifications"] = map[string]interface{}{
+		"webhook": map[string]interface{}{"url": "https://example.com/hook"},
+	}
+	if p, err := PagingFromConfig(cfg); p != nil || err != nil {
+		t.Errorf("PagingFromConfig returned %v, %v without the paging channels", p, err)
+	}
+
+	for _, settings := range []map[string]interface{}{
+		{"pagerduty": map[string]interface{}{}},
+		{"opsgenie": map[string]interface{}{"api_key": ""}},
+		{"pagerduty": map[string]interface{}{"routing_key": "R", "severity": "urgent"}},
+		{"opsgenie": map[string]interface{}{"api_key": "K", "types": "subdomain_takeover"}},
+		{"opsgenie": "K"},
+	} {
+		cfg.Options["notifications"] = settings
+		if _, err := PagingFromConfig(cfg); err == nil {
+			t.Errorf("PagingFromConfig accepted the settings %v", settings)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// The functions available to the templates, in addition to the builtin functions.
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"join": func(list []interface{}, sep string) string {
+		parts := make([]string, len(list))
+		for i, v := range list {
+			parts[i] = fmt.Sprint(v)
+		}
+		return strings.Join(parts, sep)
+	},
+}
+
+// Template renders the messages in the format expected by the receiver of a channel.
+// The template is executed over the JSON object of the message, so the fields are
+// referenced by their JSON names, such as {{.title}} and {{range .names}}.
+type Template struct {
+	tmpl *template.Template
+}
+
+// NewTemplate returns the Template parsed from the text.
+func NewTemplate(name, text string) (*Template, error) {
+	t, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the %s template: %v", name, err)
+	}
+	return &Template{tmpl: t}, nil
+}
+
+// ReadTemplate returns the Template parsed from the file at path.
+func ReadTemplate(name, path string) (*Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the %s template: %v", name, err)
+	}
+	return NewTemplate(name, string(data))
+}
+
+// Execute renders the message, or another value encoded as JSON, using the template.
+func (t *Template) Execute(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	if err := t.tmpl.Execute(&b, obj); err != nil {
+		return nil, fmt.Errorf("failed to execute the %s template: %v", t.tmpl.Name(), err)
+	}
+	return b.Bytes(), nil
+}
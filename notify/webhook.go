@@ -0,0 +1,115 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Webhook posts the messages as JSON to a URL.
+// When the Template is set, the rendered template is posted instead, using the ContentType.
+type Webhook struct {
+	URL         string
+	Template    *Template
+	ContentType string
+	http        *http.Client
+}
+
+// NewWebhook returns a Webhook posting the messages to the URL.
+func NewWebhook(u string) *Webhook {
+	return &Webhook{
+		URL:  u,
+		http: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// String implements the Notifier interface.
+func (w *Webhook) String() string { return "webhook" }
+
+// Notify implements the Notifier interface.
+func (w *Webhook) Notify(ctx context.Context, m *Message) error {
+	if w.Template == nil {
+		return postJSON(ctx, w.http, w.URL, m)
+	}
+
+	data, err := w.Template.Execute(m)
+	if err != nil {
+		return err
+	}
+
+	ct := w.ContentType
+	if ct == "" {
+		ct = "application/json"
+	}
+	return post(ctx, w.http, w.URL, data, http.Header{"Content-Type": {ct}})
+}
+
+// Slack posts the messages to a Slack incoming webhook.
+// When the Template is set, the rendered template provides the text of the Slack messages.
+type Slack struct {
+	URL      string
+	Template *Template
+	http     *http.Client
+}
+
+// NewSlack returns a Slack channel posting the messages to the incoming webhook URL.
+func NewSlack(u string) *Slack {
+	return &Slack{
+		URL:  u,
+		http: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// String implements the Notifier interface.
+func (s *Slack) String() string { return "slack" }
+
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// Notify implements the Notifier interface.
+func (s *Slack) Notify(ctx context.Context, m *Message) error {
+	text := m.Text()
+	if s.Template != nil {
+		data, err := s.Template.Execute(m)
+		if err != nil {
+			return err
+		}
+		text = string(data)
+	}
+	return postJSON(ctx, s.http, s.URL, &slackMessage{Text: text})
+}
+
+func postJSON(ctx context.Context, client *http.Client, u string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return post(ctx, client, u, data, http.Header{"Content-Type": {"application/json"}})
+}
+
+func post(ctx context.Context, client *http.Client, u string, data []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header = header
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("the server returned status %s", resp.Status)
+	}
+	return nil
+}
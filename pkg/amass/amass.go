@@ -0,0 +1,252 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package amass is the supported API for embedding the engine in other Go programs. It starts the
+// enumerations within the process, delivers the discovered assets over a channel, and queries the
+// results stored in the graph database, so programs do not need to execute the amass command or
+// depend on the packages of the engine, whose APIs can change between releases.
+//
+//	cfg, err := amass.LoadConfig("", "")
+//	cfg.AddDomain("example.com")
+//	e, err := amass.Enumerate(ctx, cfg)
+//	for d := range e.Discoveries() {
+//		fmt.Println(d.Type, d.Name)
+//	}
+//	result, err := e.Wait()
+package amass
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/enum"
+	"github.com/owasp-amass/amass/v4/events"
+	"github.com/owasp-amass/amass/v4/scheduler"
+	"github.com/owasp-amass/amass/v4/settings"
+	"github.com/owasp-amass/config/config"
+)
+
+// DiscoveryBuffer is the capacity of the channel delivering the discoveries of an enumeration.
+const DiscoveryBuffer = 1000
+
+// The kinds of discoveries delivered by an enumeration.
+const (
+	// AssetDiscovery is an asset, such as a name, an address, a netblock or an autonomous system
+	AssetDiscovery = "asset"
+	// RelationDiscovery is a relation between two assets, such as the address of a name
+	RelationDiscovery = "relation"
+)
+
+// Discovery is an asset or a relation discovered by an enumeration.
+type Discovery struct {
+	Kind string `json:"kind"`
+	// Type is the type of the asset, such as FQDN or IPAddress, or of the relation, such as a_record
+	Type string `json:"type"`
+	// Name is the asset, and From and To are the assets of the relation
+	Name   string    `json:"name,omitempty"`
+	From   string    `json:"from,omitempty"`
+	To     string    `json:"to,omitempty"`
+	Source string    `json:"source"`
+	Time   time.Time `json:"time"`
+}
+
+// Result describes an enumeration once it has finished.
+type Result struct {
+	Session string `json:"session"`
+	// Reason is "idle" when the enumeration drained its work, and "canceled" when its context expired
+	Reason   string    `json:"reason"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	// Names are the names within the domains stored in the graph database once the enumeration finished
+	Names []string `json:"names"`
+	// NewNames are the names that were not stored in the graph database before the enumeration
+	NewNames []string `json:"new_names"`
+	// Errors are the number of failures of each data source by category, such as auth or rate_limited
+	Errors map[string]map[string]int `json:"errors,omitempty"`
+}
+
+// Enumeration is an enumeration running within the process.
+type Enumeration struct {
+	// dropped counts the discoveries not delivered since the buffer was full, and is the first
+	// field so the atomic operations are aligned on 32-bit platforms
+	dropped uint64
+	out     chan *Discovery
+	once    sync.Once
+	done    chan struct{}
+	result  *Result
+	err     error
+}
+
+// LoadConfig returns the configuration resolved from the defaults, the configuration file and the
+// environment variables, as used by the amass command. Empty arguments select the configuration file
+// and the output directory used by the amass command.
+func LoadConfig(dir, file string) (*config.Config, error) {
+	cfg := config.NewConfig()
+
+	if err := settings.Load("enum", cfg, dir, file); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Enumerate starts an enumeration of the domains in the configuration, using the output directory
+// and the graph database it selects. The discoveries must be received from the channel returned by
+// Discoveries, or they are dropped once the buffer is full, as counted by Dropped. The enumeration
+// ends once it has no work outstanding or the context expires.
+func Enumerate(ctx context.Context, cfg *config.Config) (*Enumeration, error) {
+	if len(cfg.Domains()) == 0 {
+		return nil, errors.New("no root domain names were provided")
+	}
+	if err := cfg.CheckSettings(); err != nil {
+		return nil, err
+	}
+	if cfg.Log == nil {
+		cfg.Log = log.New(io.Discard, "", 0)
+	}
+
+	e := &Enumeration{
+		out:  make(chan *Discovery, DiscoveryBuffer),
+		done: make(chan struct{}),
+	}
+	go e.run(ctx, cfg)
+	return e, nil
+}
+
+func (e *Enumeration) run(ctx context.Context, cfg *config.Config) {
+	defer close(e.done)
+	// The channel is closed along with the event bus, unless the enumeration failed to start
+	defer e.close()
+
+	res, err := scheduler.Execute(ctx, cfg, func(en *enum.Enumeration) {
+		en.AddPublisher(&discoveryPublisher{enum: e})
+	})
+	if err != nil {
+		e.err = err
+		return
+	}
+	e.result = newResult(res)
+}
+
+// Discoveries returns the channel delivering the assets and relations discovered by the enumeration,
+// which is closed once the enumeration has finished.
+func (e *Enumeration) Discoveries() <-chan *Discovery {
+	return e.out
+}
+
+// Dropped returns the number of discoveries dropped since the buffer of the channel was full. The
+// discoveries are not delivered again, while the assets remain stored in the graph database.
+func (e *Enumeration) Dropped() uint64 {
+	return atomic.LoadUint64(&e.dropped)
+}
+
+// Done returns a channel that is closed once the enumeration has finished.
+func (e *Enumeration) Done() <-chan struct{} {
+	return e.done
+}
+
+// Wait blocks until the enumeration has finished, and returns its result.
+func (e *Enumeration) Wait() (*Result, error) {
+	<-e.done
+	return e.result, e.err
+}
+
+func (e *Enumeration) close() {
+	e.once.Do(func() { close(e.out) })
+}
+
+func newResult(res *scheduler.Result) *Result {
+	r := &Result{
+		Names:    res.After,
+		NewNames: difference(res.After, res.Before),
+		Errors:   make(map[string]map[string]int),
+	}
+	if c := res.Completion; c != nil {
+		r.Session = c.Session
+		r.Reason = c.Reason
+		r.Started = c.Started
+		r.Finished = c.Finished
+	}
+	for _, se := range res.Errors {
+		counts := make(map[string]int)
+		for _, ec := range se.Errors {
+			counts[string(ec.Category)] += ec.Count
+		}
+		r.Errors[se.Source] = counts
+	}
+	return r
+}
+
+// discoveryPublisher delivers the events of the enumeration to the channel of discoveries.
+type discoveryPublisher struct {
+	enum *Enumeration
+}
+
+// Publish implements the events.Publisher interface. The discoveries are dropped once the buffer is
+// full, so a program that is slow to receive them does not stall the delivery of the events.
+func (p *discoveryPublisher) Publish(ctx context.Context, batch []*events.Event) error {
+	for _, ev := range batch {
+		d := newDiscovery(ev)
+		if d == nil {
+			continue
+		}
+
+		select {
+		case p.enum.out <- d:
+		default:
+			atomic.AddUint64(&p.enum.dropped, 1)
+		}
+	}
+	return nil
+}
+
+// Close implements the events.Publisher interface.
+func (p *discoveryPublisher) Close() error {
+	p.enum.close()
+	return nil
+}
+
+// newDiscovery returns the Discovery described by the event, or nil for the events of the session.
+func newDiscovery(ev *events.Event) *Discovery {
+	d := &Discovery{
+		Type:   ev.Type,
+		Source: ev.Source,
+		Time:   ev.Time,
+	}
+
+	switch ev.Kind {
+	case events.EntityEvent:
+		d.Kind = AssetDiscovery
+		d.Name = ev.Asset
+	case events.EdgeEvent:
+		d.Kind = RelationDiscovery
+		d.From = ev.From
+		d.To = ev.To
+	default:
+		return nil
+	}
+	return d
+}
+
+// difference returns the sorted strings in a that are not in b.
+func difference(a, b []string) []string {
+	set := make(map[string]struct{}, len(b))
+	for _, s := range b {
+		set[s] = struct{}{}
+	}
+
+	results := []string{}
+	for _, s := range a {
+		if _, found := set[s]; !found {
+			results = append(results, s)
+		}
+	}
+	sort.Strings(results)
+	return results
+}
@@ -0,0 +1,166 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package amass
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/amass/v4/dbcrypt"
+	"github.com/owasp-amass/amass/v4/events"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestDiscoveryPublisher(t *testing.T) {
+	e := &Enumeration{out: make(chan *Discovery, 10)}
+	p := &discoveryPublisher{enum: e}
+
+	now := time.Now()
+	batch := []*events.Event{
+		{Kind: events.EntityEvent, Type: "FQDN", Asset: "www.owasp.org", Source: "DNS", Time: now},
+		{Kind: events.EdgeEvent, Type: "a_record", From: "www.owasp.org", To: "192.0.2.1", Source: "DNS", Time: now},
+		{Kind: events.SessionEvent, Type: "completed", Reason: "idle", Time: now},
+	}
+	if err := p.Publish(context.Background(), batch); err != nil {
+		t.Fatalf("Failed to publish the events: %v", err)
+	}
+	_ = p.Close()
+	_ = p.Close()
+
+	var got []*Discovery
+	for d := range e.Discoveries() {
+		got = append(got, d)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Expected two discoveries, got %d", len(got))
+	}
+	if d := got[0]; d.Kind != AssetDiscovery || d.Type != "FQDN" || d.Name != "www.owasp.org" || d.Source != "DNS" {
+		t.Errorf("Unexpected asset discovery: %+v", d)
+	}
+	if d := got[1]; d.Kind != RelationDiscovery || d.From != "www.owasp.org" || d.To != "192.0.2.1" {
+		t.Errorf("Unexpected relation discovery: %+v", d)
+	}
+
+	if e.Dropped() != 0 {
+		t.Errorf("Expected no dropped discoveries, got %d", e.Dropped())
+	}
+
+	// A receiver that stopped reading does not block the publisher, which drops the discoveries
+	e = &Enumeration{out: make(chan *Discovery, 1)}
+	done := make(chan error, 1)
+	go func() { done <- (&discoveryPublisher{enum: e}).Publish(context.Background(), batch) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Failed to publish the events: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("The publisher blocked on the full buffer")
+	}
+	if e.Dropped() != 1 || len(e.out) != 1 {
+		t.Errorf("Expected one delivered and one dropped discovery, got %d and %d", len(e.out), e.Dropped())
+	}
+}
+
+func TestEnumerateWithoutDomains(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Dir = t.TempDir()
+
+	if _, err := Enumerate(context.Background(), cfg); err == nil {
+		t.Error("The enumeration was started without domains")
+	}
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	g := netmap.NewGraph("local", filepath.Join(dir, dbcrypt.DatabaseFile), "")
+	if g == nil {
+		t.Fatal("Failed to create the graph database")
+	}
+	for _, name := range []string{"www.owasp.org", "mail.owasp.org", "www.example.com"} {
+		if _, err := g.UpsertFQDN(ctx, name); err != nil {
+			t.Fatalf("Failed to insert %s: %v", name, err)
+		}
+	}
+	if err := g.UpsertA(ctx, "www.owasp.org", "192.0.2.1"); err != nil {
+		t.Fatalf("Failed to insert the address: %v", err)
+	}
+
+	cfg := config.NewConfig()
+	cfg.Dir = dir
+	cfg.AddDomain("owasp.org")
+
+	s, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open the store: %v", err)
+	}
+	defer s.Close()
+
+	if names := s.Names(); len(names) != 3 || names[0] != "mail.owasp.org" || names[2] != "www.owasp.org" {
+		t.Errorf("Unexpected names within the domains of the configuration: %v", names)
+	}
+	if names := s.Names("example.com"); len(names) != 2 || names[1] != "www.example.com" {
+		t.Errorf("Unexpected names within example.com: %v", names)
+	}
+
+	addrs, err := s.Addresses(ctx, "www.owasp.org")
+	if err != nil || len(addrs) != 1 || addrs[0].Address != "192.0.2.1" {
+		t.Errorf("Unexpected addresses: %+v, %v", addrs, err)
+	}
+	if list, err := s.Findings(); err != nil || len(list) != 0 {
+		t.Errorf("Unexpected findings: %v, %v", list, err)
+	}
+
+	cfg = config.NewConfig()
+	cfg.Dir = t.TempDir()
+	if _, err := Open(cfg); err == nil {
+		t.Error("The store was opened without a graph database")
+	}
+}
+
+func TestStoreEncrypted(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	g := netmap.NewGraph("local", filepath.Join(dir, dbcrypt.DatabaseFile), "")
+	if g == nil {
+		t.Fatal("Failed to create the graph database")
+	}
+	if _, err := g.UpsertFQDN(ctx, "www.owasp.org"); err != nil {
+		t.Fatalf("Failed to insert the name: %v", err)
+	}
+
+	cfg := config.NewConfig()
+	cfg.Dir = dir
+	cfg.AddDomain("owasp.org")
+	cfg.Options["database_encryption"] = map[string]interface{}{"key": "secret"}
+
+	// The unencrypted database is read once and replaced by the encrypted copy
+	for i := 0; i < 2; i++ {
+		s, err := Open(cfg)
+		if err != nil {
+			t.Fatalf("Failed to open the store: %v", err)
+		}
+		if names := s.Names(); len(names) != 2 || names[1] != "www.owasp.org" {
+			t.Errorf("Unexpected names in the decrypted database: %v", names)
+		}
+		if err := s.Close(); err != nil {
+			t.Fatalf("Failed to close the store: %v", err)
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, dbcrypt.DatabaseFile)); !os.IsNotExist(err) {
+			t.Error("The unencrypted database was left in the output directory")
+		}
+		if _, err := os.Stat(filepath.Join(dir, dbcrypt.EncryptedFile)); err != nil {
+			t.Errorf("The encrypted database was not written: %v", err)
+		}
+	}
+}
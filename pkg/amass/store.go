@@ -0,0 +1,147 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package amass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/amass/v4/analysis"
+	"github.com/owasp-amass/amass/v4/dbcrypt"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/scheduler"
+	"github.com/owasp-amass/amass/v4/schema"
+	"github.com/owasp-amass/config/config"
+)
+
+// Finding is an observation recorded by the enumerations, such as a dangling name or a weak mail policy.
+type Finding = findings.Finding
+
+// Address is an address of a name stored in the graph database.
+type Address struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+// Store queries the results of the enumerations saved to the graph database and the output directory.
+type Store struct {
+	cfg   *config.Config
+	graph *netmap.Graph
+}
+
+// Open returns the Store of the primary graph database selected by the configuration. The local graph
+// database encrypted by the configuration stays decrypted in memory until the Store is closed.
+func Open(cfg *config.Config) (*Store, error) {
+	system, dsn, options, err := primaryGraphDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{cfg: cfg}
+	if schema.Versioned(system) {
+		if _, _, err := schema.Upgrade(system, dsn); err != nil {
+			_ = s.lock()
+			return nil, err
+		}
+	}
+
+	s.graph = netmap.NewGraph(system, dsn, options)
+	if s.graph == nil {
+		_ = s.lock()
+		return nil, fmt.Errorf("failed to open the %s graph database", strings.ToLower(system))
+	}
+	return s, nil
+}
+
+// Close encrypts the local graph database again, when the encryption has been enabled by the configuration.
+func (s *Store) Close() error {
+	return s.lock()
+}
+
+// Names returns the sorted names stored within the domains, or within the domains of the configuration
+// when none are provided.
+func (s *Store) Names(domains ...string) []string {
+	if len(domains) == 0 {
+		domains = s.cfg.Domains()
+	}
+	return scheduler.Names(s.graph, domains)
+}
+
+// Addresses returns the addresses of the names stored in the graph database, following their aliases.
+func (s *Store) Addresses(ctx context.Context, names ...string) ([]*Address, error) {
+	pairs, err := analysis.NamesToAddrs(ctx, s.graph, time.Time{}, time.Time{}, names...)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := make([]*Address, 0, len(pairs))
+	for _, p := range pairs {
+		addrs = append(addrs, &Address{
+			Name:    p.FQDN.Name,
+			Address: p.Addr.Address.String(),
+		})
+	}
+	return addrs, nil
+}
+
+// Findings returns the findings recorded by the enumerations in the output directory.
+func (s *Store) Findings() ([]*Finding, error) {
+	return findings.ReadFile(filepath.Join(config.OutputDirectory(s.cfg.Dir), "findings.json"))
+}
+
+// Graph returns the graph database, for the queries not provided by the Store.
+func (s *Store) Graph() *netmap.Graph {
+	return s.graph
+}
+
+func (s *Store) lock() error {
+	key, err := dbcrypt.FromConfig(s.cfg)
+	if err != nil || key == "" {
+		return err
+	}
+	return dbcrypt.Lock(config.OutputDirectory(s.cfg.Dir))
+}
+
+// primaryGraphDatabase returns the system, the connection string and the options of the primary
+// graph database selected by the configuration, as the amass command does.
+func primaryGraphDatabase(cfg *config.Config) (string, string, string, error) {
+	for _, db := range append(cfg.GraphDBs, cfg.LocalDatabaseSettings(cfg.GraphDBs)) {
+		if !db.Primary {
+			continue
+		}
+
+		if db.System == "local" {
+			dir := config.OutputDirectory(cfg.Dir)
+			if !dbcrypt.Exists(dir) {
+				return "", "", "", fmt.Errorf("failed to find the graph database in %s", dir)
+			}
+
+			opts, err := schema.SQLiteOptionsFromConfig(cfg)
+			if err != nil {
+				return "", "", "", err
+			}
+
+			path := filepath.Join(dir, dbcrypt.DatabaseFile)
+			// The encrypted database is decrypted into memory until the store is closed
+			if key, err := dbcrypt.FromConfig(cfg); err != nil {
+				return "", "", "", err
+			} else if key != "" {
+				if path, err = dbcrypt.Unlock(dir, key); err != nil {
+					return "", "", "", err
+				}
+			}
+			return db.System, opts.DSN(path), db.Options, nil
+		}
+
+		connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s", db.Host, db.Port, db.Username, db.Password, db.DBName)
+		return db.System, connStr, db.Options, nil
+	}
+	return "", "", "", errors.New("no primary graph database was found in the configuration")
+}
@@ -0,0 +1,46 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package prune
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// FromConfig returns the Policy in the 'retention' section of the configuration options, or an
+// empty Policy when the section is not provided.
+func FromConfig(cfg *config.Config) (*Policy, error) {
+	policy := new(Policy)
+
+	raw, ok := cfg.Options["retention"]
+	if !ok {
+		return policy, nil
+	}
+
+	settings, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("retention is not a map[string]interface{}")
+	}
+
+	for key, ptr := range map[string]*time.Duration{"max_age": &policy.MaxAge, "superseded": &policy.Superseded} {
+		if v, ok := settings[key]; ok {
+			days, ok := v.(int)
+			if !ok || days < 1 {
+				return nil, fmt.Errorf("retention %s must be a positive number of days", key)
+			}
+			*ptr = time.Duration(days) * 24 * time.Hour
+		}
+	}
+	if v, ok := settings["vacuum"]; ok {
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("retention vacuum is not a bool")
+		}
+		policy.Vacuum = b
+	}
+	return policy, nil
+}
@@ -0,0 +1,257 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package prune applies retention policies to the graph database, removing the assets and relations
+// that were not observed again, so the databases used for long-running monitoring do not grow without bound.
+package prune
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// DNSRelations are the relation types of the DNS records, which are superseded by newer records
+// of the same type for the same name.
+var DNSRelations = []string{"a_record", "aaaa_record", "cname_record", "ns_record", "mx_record", "ptr_record", "srv_record"}
+
+// Policy describes the assets and relations removed from the graph database.
+type Policy struct {
+	// MaxAge removes the assets and relations not seen again within this period
+	MaxAge time.Duration
+	// Superseded removes the DNS records of a name that were last seen this long before a
+	// newer record of the same type for the name
+	Superseded time.Duration
+	// Vacuum reclaims the space released by the removals
+	Vacuum bool
+	// DryRun reports what would be removed without changing the database
+	DryRun bool
+}
+
+// Report describes what was removed from the graph database, or would be during a dry run.
+type Report struct {
+	DryRun bool `json:"dry_run"`
+	// Assets are the numbers of stale assets, keyed by the asset type
+	Assets map[string]int `json:"assets"`
+	// Relations are the numbers of stale relations, including those of the stale assets, keyed by the relation type
+	Relations map[string]int `json:"relations"`
+	// Superseded are the numbers of superseded DNS records, keyed by the relation type
+	Superseded map[string]int `json:"superseded"`
+	Vacuumed   bool           `json:"vacuumed"`
+}
+
+// Pruner executes the retention policies against the tables of a graph database.
+type Pruner struct {
+	db     *gorm.DB
+	system string
+	now    func() time.Time
+}
+
+// Open connects to the graph database using the same system names and DSNs accepted by netmap.
+func Open(system, dsn string) (*Pruner, error) {
+	var dialector gorm.Dialector
+
+	switch system {
+	case "local":
+		dialector = sqlite.Open(dsn)
+	case "postgres":
+		dialector = postgres.Open(dsn)
+	default:
+		return nil, fmt.Errorf("the %s graph database system cannot be pruned", system)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the %s graph database: %v", system, err)
+	}
+	return &Pruner{db: db, system: system, now: time.Now}, nil
+}
+
+// Close releases the connections to the graph database.
+func (p *Pruner) Close() error {
+	sqlDB, err := p.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// Prune applies the policy to the graph database. The removals are made in a single transaction,
+// and the database is vacuumed once it has been committed.
+func (p *Pruner) Prune(ctx context.Context, policy *Policy) (*Report, error) {
+	if policy == nil || (policy.MaxAge <= 0 && policy.Superseded <= 0 && !policy.Vacuum) {
+		return nil, errors.New("the retention policy does not remove anything")
+	}
+
+	report := &Report{
+		DryRun:     policy.DryRun,
+		Assets:     make(map[string]int),
+		Relations:  make(map[string]int),
+		Superseded: make(map[string]int),
+	}
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if policy.MaxAge > 0 {
+			if err := p.stale(tx, policy, report); err != nil {
+				return err
+			}
+		}
+		if policy.Superseded > 0 {
+			if err := p.superseded(tx, policy, report); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if policy.Vacuum && !policy.DryRun {
+		stmt := "VACUUM"
+		if p.system == "postgres" {
+			stmt = "VACUUM (ANALYZE) relations, assets"
+		}
+		if err := p.db.WithContext(ctx).Exec(stmt).Error; err != nil {
+			return nil, fmt.Errorf("failed to vacuum the graph database: %v", err)
+		}
+		report.Vacuumed = true
+	}
+	return report, nil
+}
+
+type typeCount struct {
+	Type  string
+	Count int
+}
+
+func counts(tx *gorm.DB, stmt string, args ...interface{}) (map[string]int, error) {
+	var rows []typeCount
+	if err := tx.Raw(stmt, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]int)
+	for _, r := range rows {
+		results[r.Type] = r.Count
+	}
+	return results, nil
+}
+
+// stale removes the assets and relations last seen before the maximum age, along with the
+// relations of the removed assets.
+func (p *Pruner) stale(tx *gorm.DB, policy *Policy, report *Report) error {
+	cutoff := p.timeParam(p.now().Add(-policy.MaxAge))
+	assets := "SELECT id FROM assets WHERE " + p.timeExpr("last_seen") + " < " + p.timeExpr("?")
+	relations := p.staleRelations()
+
+	var err error
+	report.Assets, err = counts(tx, "SELECT type, COUNT(*) AS count FROM assets WHERE "+
+		p.timeExpr("last_seen")+" < "+p.timeExpr("?")+" GROUP BY type", cutoff)
+	if err != nil {
+		return fmt.Errorf("failed to count the stale assets: %v", err)
+	}
+	report.Relations, err = counts(tx, "SELECT type, COUNT(*) AS count FROM relations WHERE "+relations+" GROUP BY type", cutoff, cutoff, cutoff)
+	if err != nil {
+		return fmt.Errorf("failed to count the stale relations: %v", err)
+	}
+	if policy.DryRun {
+		return nil
+	}
+
+	// The relations are removed first, since the foreign keys are not enforced by every connection
+	if err := tx.Exec("DELETE FROM relations WHERE "+relations, cutoff, cutoff, cutoff).Error; err != nil {
+		return fmt.Errorf("failed to remove the stale relations: %v", err)
+	}
+	if err := tx.Exec("DELETE FROM assets WHERE id IN ("+assets+")", cutoff).Error; err != nil {
+		return fmt.Errorf("failed to remove the stale assets: %v", err)
+	}
+	return nil
+}
+
+// staleRelations returns the condition matching the relations last seen before the cutoff, or
+// connecting an asset last seen before it. The cutoff is provided three times.
+func (p *Pruner) staleRelations() string {
+	assets := "SELECT id FROM assets WHERE " + p.timeExpr("last_seen") + " < " + p.timeExpr("?")
+
+	return fmt.Sprintf("%s < %s OR from_asset_id IN (%s) OR to_asset_id IN (%s)",
+		p.timeExpr("last_seen"), p.timeExpr("?"), assets, assets)
+}
+
+// superseded removes the DNS records of each name last seen long before a newer record of the same type.
+func (p *Pruner) superseded(tx *gorm.DB, policy *Policy, report *Report) error {
+	types := "'" + strings.Join(DNSRelations, "', '") + "'"
+
+	var newer string
+	var arg interface{}
+	switch p.system {
+	case "postgres":
+		newer = "n.last_seen - r.last_seen > ? * interval '1 second'"
+		arg = policy.Superseded.Seconds()
+	default:
+		newer = "julianday(n.last_seen) - julianday(r.last_seen) > ?"
+		arg = policy.Superseded.Hours() / 24
+	}
+	where := fmt.Sprintf("r.type IN (%s) AND EXISTS (SELECT 1 FROM relations n WHERE "+
+		"n.from_asset_id = r.from_asset_id AND n.type = r.type AND %s)", types, newer)
+	args := []interface{}{arg}
+	// The stale relations are only counted once during a dry run
+	if policy.MaxAge > 0 {
+		cutoff := p.timeParam(p.now().Add(-policy.MaxAge))
+		where += " AND NOT (" + p.staleRelations() + ")"
+		args = append(args, cutoff, cutoff, cutoff)
+	}
+
+	var err error
+	report.Superseded, err = counts(tx, "SELECT r.type AS type, COUNT(*) AS count FROM relations r WHERE "+where+" GROUP BY r.type", args...)
+	if err != nil {
+		return fmt.Errorf("failed to count the superseded DNS records: %v", err)
+	}
+	if policy.DryRun {
+		return nil
+	}
+
+	if err := tx.Exec("DELETE FROM relations WHERE id IN (SELECT r.id FROM relations r WHERE "+where+")", args...).Error; err != nil {
+		return fmt.Errorf("failed to remove the superseded DNS records: %v", err)
+	}
+	return nil
+}
+
+// timeExpr returns the expression comparing the timestamps, since SQLite stores them as text
+// that can carry different time zone offsets.
+func (p *Pruner) timeExpr(col string) string {
+	if p.system == "postgres" {
+		return col
+	}
+	return "julianday(" + col + ")"
+}
+
+func (p *Pruner) timeParam(t time.Time) interface{} {
+	if p.system == "postgres" {
+		return t
+	}
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+// Total returns the number of assets and relations removed.
+func (r *Report) Total() (int, int) {
+	var assets, relations int
+
+	for _, n := range r.Assets {
+		assets += n
+	}
+	for _, n := range r.Relations {
+		relations += n
+	}
+	for _, n := range r.Superseded {
+		relations += n
+	}
+	return assets, relations
+}
@@ -0,0 +1,111 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package prune
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestPrune(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "amass.sqlite")
+
+	g := netmap.NewGraph("local", path, "")
+	if g == nil {
+		t.Fatal("Failed to create the graph database")
+	}
+	defer g.Remove()
+
+	for name, addr := range map[string]string{
+		"www.owasp.org":  "104.22.27.77",
+		"old.owasp.org":  "192.0.2.10",
+		"mail.owasp.org": "104.22.27.78",
+	} {
+		if err := g.UpsertA(ctx, name, addr); err != nil {
+			t.Fatalf("Failed to insert %s: %v", name, err)
+		}
+	}
+	// The name moved to another address, which was observed recently
+	if err := g.UpsertA(ctx, "www.owasp.org", "104.22.27.79"); err != nil {
+		t.Fatalf("Failed to insert the new address: %v", err)
+	}
+
+	p, err := Open("local", path)
+	if err != nil {
+		t.Fatalf("Failed to open the pruner: %v", err)
+	}
+	defer p.Close()
+
+	for stmt, age := range map[string]time.Duration{
+		"UPDATE assets SET last_seen = ? WHERE content LIKE '%old.owasp.org%' OR content LIKE '%192.0.2.10%'":                 60 * 24 * time.Hour,
+		"UPDATE relations SET last_seen = ? WHERE to_asset_id IN (SELECT id FROM assets WHERE content LIKE '%104.22.27.77%')": 20 * 24 * time.Hour,
+	} {
+		if err := p.db.Exec(stmt, time.Now().Add(-age).UTC().Format("2006-01-02 15:04:05")).Error; err != nil {
+			t.Fatalf("Failed to age the assets: %v", err)
+		}
+	}
+
+	policy := &Policy{MaxAge: 30 * 24 * time.Hour, Superseded: 7 * 24 * time.Hour, DryRun: true}
+	report, err := p.Prune(ctx, policy)
+	if err != nil {
+		t.Fatalf("The dry run failed: %v", err)
+	}
+	if report.Assets["FQDN"] != 1 || report.Assets["IPAddress"] != 1 {
+		t.Errorf("Unexpected stale assets: %v", report.Assets)
+	}
+	// The record of the old name, and the record of www superseded by the new address
+	if report.Relations["a_record"] != 1 || report.Superseded["a_record"] != 1 {
+		t.Errorf("Unexpected stale relations %v and superseded records %v", report.Relations, report.Superseded)
+	}
+
+	var before int64
+	p.db.Raw("SELECT COUNT(*) FROM assets").Scan(&before)
+	policy.DryRun = false
+	policy.Vacuum = true
+	if _, err := p.Prune(ctx, policy); err != nil {
+		t.Fatalf("The pruning failed: %v", err)
+	}
+
+	var after, relations int64
+	p.db.Raw("SELECT COUNT(*) FROM assets").Scan(&after)
+	p.db.Raw("SELECT COUNT(*) FROM relations").Scan(&relations)
+	if before-after != 2 {
+		t.Errorf("Expected two assets to be removed, %d were", before-after)
+	}
+	// The records of www and mail observed recently remain
+	if relations != 2 {
+		t.Errorf("Expected two relations to remain, got %d", relations)
+	}
+
+	if _, err := p.Prune(ctx, &Policy{}); err == nil {
+		t.Error("The empty policy was accepted")
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	if policy, err := FromConfig(cfg); err != nil || policy.MaxAge != 0 || policy.Vacuum {
+		t.Errorf("Unexpected policy without the retention section: %+v, %v", policy, err)
+	}
+
+	cfg.Options["retention"] = map[string]interface{}{"max_age": 90, "superseded": 7, "vacuum": true}
+	if policy, err := FromConfig(cfg); err != nil || policy.MaxAge != 90*24*time.Hour ||
+		policy.Superseded != 7*24*time.Hour || !policy.Vacuum {
+		t.Errorf("Unexpected policy: %+v, %v", policy, err)
+	}
+
+	for _, settings := range []interface{}{"90d", map[string]interface{}{"max_age": 0}, map[string]interface{}{"vacuum": "yes"}} {
+		cfg.Options["retention"] = settings
+		if _, err := FromConfig(cfg); err == nil {
+			t.Errorf("The settings %v were accepted", settings)
+		}
+	}
+}
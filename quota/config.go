@@ -0,0 +1,43 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package quota
+
+import (
+	"fmt"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// FromConfig returns a Tracker using the settings in the 'quotas' section of the configuration options.
+// The API keys are validated and the quotas are tracked when the section is not provided.
+func FromConfig(cfg *config.Config) (*Tracker, error) {
+	reserve, validate := int64(DefaultReserve), true
+
+	raw, ok := cfg.Options["quotas"]
+	if !ok {
+		return NewTracker(reserve, validate), nil
+	}
+
+	settings, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("quotas is not a map[string]interface{}")
+	}
+
+	if v, ok := settings["validate"]; ok {
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("quotas validate is not a bool")
+		}
+		validate = b
+	}
+	if v, ok := settings["reserve"]; ok {
+		n, ok := v.(int)
+		if !ok || n < 0 {
+			return nil, fmt.Errorf("quotas reserve is not a positive integer")
+		}
+		reserve = int64(n)
+	}
+	return NewTracker(reserve, validate), nil
+}
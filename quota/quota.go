@@ -0,0 +1,270 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package quota tracks the health of the API keys used by the data sources and the quotas remaining
+// for them, so the sources whose keys were rejected or whose quotas ran out stop receiving requests
+// for the rest of the enumeration.
+package quota
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The states of the API keys used by the data sources.
+const (
+	StateOK        = "ok"
+	StateLow       = "low"
+	StateExhausted = "exhausted"
+	StateInvalid   = "invalid"
+)
+
+// DefaultReserve is the remaining quota below which a data source only receives the requests
+// driving the enumeration.
+const DefaultReserve = 10
+
+// exhaustedAfter is the wait before the quota resets, beyond which an empty quota is considered
+// exhausted instead of a short rate limit handled by the data source.
+const exhaustedAfter = 5 * time.Minute
+
+// The headers providing the remaining quota, the size of the quota and when it resets.
+var (
+	remainingHeaders = []string{"X-Ratelimit-Remaining", "X-Rate-Limit-Remaining", "Ratelimit-Remaining", "X-Quota-Remaining"}
+	limitHeaders     = []string{"X-Ratelimit-Limit", "X-Rate-Limit-Limit", "Ratelimit-Limit", "X-Quota-Limit"}
+	resetHeaders     = []string{"X-Ratelimit-Reset", "X-Rate-Limit-Reset", "Ratelimit-Reset", "X-Quota-Reset"}
+)
+
+// Status is the health of the API key used by a data source and the quota remaining for it.
+type Status struct {
+	Source string `json:"source"`
+	State  string `json:"state"`
+	// Remaining is the number of requests left in the quota, or -1 when unknown
+	Remaining int64      `json:"remaining"`
+	Limit     int64      `json:"limit,omitempty"`
+	Reset     *time.Time `json:"reset,omitempty"`
+	// Validated is true once the API key was accepted by the validation of the data source
+	Validated bool   `json:"validated"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+// Tracker records the status of the API keys used by the data sources during an enumeration.
+// All methods are safe to call on a nil Tracker, which validates the keys and tracks nothing.
+type Tracker struct {
+	sync.Mutex
+	reserve  int64
+	validate bool
+	sources  map[string]*Status
+	now      func() time.Time
+}
+
+// NewTracker returns a Tracker restricting the data sources to the requests driving the enumeration
+// once their remaining quota falls below the reserve. The validate argument determines whether the
+// data sources check their API keys when the enumeration starts.
+func NewTracker(reserve int64, validate bool) *Tracker {
+	return &Tracker{
+		reserve:  reserve,
+		validate: validate,
+		sources:  make(map[string]*Status),
+		now:      time.Now,
+	}
+}
+
+// ValidateKeys returns true when the data sources should check their API keys when the enumeration starts.
+func (t *Tracker) ValidateKeys() bool {
+	return t == nil || t.validate
+}
+
+func (t *Tracker) get(source string) *Status {
+	s, found := t.sources[source]
+	if !found {
+		s = &Status{Source: source, State: StateOK, Remaining: -1}
+		t.sources[source] = s
+	}
+	return s
+}
+
+// Validated records the result of checking the API key of the data source. A non-nil error marks
+// the key as invalid for the rest of the enumeration.
+func (t *Tracker) Validated(source string, err error) {
+	if t == nil {
+		return
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	s := t.get(source)
+	if err != nil {
+		s.State = StateInvalid
+		s.Detail = err.Error()
+		return
+	}
+	s.Validated = true
+}
+
+// Observe records the quota provided by the headers of a response received by the data source.
+// An unauthorized response marks the key as invalid, and an empty quota that does not reset soon
+// marks it as exhausted.
+func (t *Tracker) Observe(source string, code int, hdr map[string]string) {
+	if t == nil {
+		return
+	}
+
+	now := t.now()
+	remaining, hasRemaining := headerInt(hdr, remainingHeaders)
+	limit, _ := headerInt(hdr, limitHeaders)
+	reset := resetTime(hdr, now)
+
+	t.Lock()
+	defer t.Unlock()
+
+	s := t.get(source)
+	if s.State == StateInvalid {
+		return
+	}
+	if hasRemaining {
+		s.Remaining = remaining
+		s.Limit = limit
+		s.Reset = reset
+	}
+
+	switch {
+	case code == http.StatusUnauthorized:
+		s.State = StateInvalid
+		s.Detail = fmt.Sprintf("the API key was rejected with status %d", code)
+	case code == http.StatusPaymentRequired:
+		s.State = StateExhausted
+		s.Detail = fmt.Sprintf("the service responded with status %d", code)
+	case hasRemaining && remaining == 0 && (reset == nil || reset.Sub(now) > exhaustedAfter):
+		s.State = StateExhausted
+		s.Detail = "no requests remain in the quota"
+		if reset != nil {
+			s.Detail += " until " + reset.Format(time.RFC3339)
+		}
+	case hasRemaining && remaining <= t.reserve:
+		s.State = StateLow
+		s.Detail = ""
+	case hasRemaining:
+		s.State = StateOK
+		s.Detail = ""
+	}
+}
+
+// Exhausted returns true when the API key of the data source was rejected, or its quota has run
+// out and not yet reset.
+func (t *Tracker) Exhausted(source string) bool {
+	if t == nil {
+		return false
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	s, found := t.sources[source]
+	if !found {
+		return false
+	}
+
+	switch s.State {
+	case StateInvalid:
+		return true
+	case StateExhausted:
+		if s.Reset != nil && !t.now().Before(*s.Reset) {
+			s.State = StateOK
+			s.Remaining = -1
+			s.Reset = nil
+			s.Detail = ""
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// Low returns true when the remaining quota of the data source has fallen below the reserve.
+func (t *Tracker) Low(source string) bool {
+	if t == nil {
+		return false
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	s, found := t.sources[source]
+	return found && s.State == StateLow
+}
+
+// Status returns a copy of the status of the data source, or nil when nothing has been recorded.
+func (t *Tracker) Status(source string) *Status {
+	if t == nil {
+		return nil
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	s, found := t.sources[source]
+	if !found {
+		return nil
+	}
+
+	c := *s
+	return &c
+}
+
+// Snapshot returns a copy of the status of each data source, sorted by name.
+func (t *Tracker) Snapshot() []*Status {
+	if t == nil {
+		return nil
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	results := make([]*Status, 0, len(t.sources))
+	for _, s := range t.sources {
+		c := *s
+		results = append(results, &c)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Source < results[j].Source
+	})
+	return results
+}
+
+func headerInt(hdr map[string]string, names []string) (int64, bool) {
+	for _, name := range names {
+		if v := strings.TrimSpace(hdr[name]); v != "" {
+			// Some services provide a list of values for the policies applied to the request
+			v, _, _ = strings.Cut(v, ",")
+			if n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil && n >= 0 {
+				return n, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// resetTime accepts the epoch time of the reset, or the number of seconds until it happens.
+func resetTime(hdr map[string]string, now time.Time) *time.Time {
+	n, found := headerInt(hdr, resetHeaders)
+	if !found {
+		return nil
+	}
+
+	var t time.Time
+	// The values smaller than a year of seconds are relative to the current time
+	if n < 365*24*60*60 {
+		t = now.Add(time.Duration(n) * time.Second)
+	} else {
+		t = time.Unix(n, 0)
+	}
+	return &t
+}
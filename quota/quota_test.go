@@ -0,0 +1,125 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package quota
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func TestObserve(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		code  int
+		hdr   map[string]string
+		state string
+	}{
+		{200, nil, StateOK},
+		{200, map[string]string{"X-Ratelimit-Remaining": "500", "X-Ratelimit-Limit": "1000"}, StateOK},
+		{200, map[string]string{"X-Rate-Limit-Remaining": "5"}, StateLow},
+		{200, map[string]string{"Ratelimit-Remaining": "0, 10;w=60", "Ratelimit-Reset": "30"}, StateLow},
+		{200, map[string]string{"X-Ratelimit-Remaining": "0"}, StateExhausted},
+		{429, map[string]string{"X-Ratelimit-Remaining": "0", "X-Ratelimit-Reset": "3600"}, StateExhausted},
+		{402, nil, StateExhausted},
+		{401, nil, StateInvalid},
+	}
+
+	for _, test := range tests {
+		tr := NewTracker(DefaultReserve, true)
+		tr.now = func() time.Time { return now }
+
+		tr.Observe("test", test.code, test.hdr)
+		if s := tr.Status("test"); s == nil || s.State != test.state {
+			t.Errorf("The status %d with the headers %v resulted in %+v, expected the %s state", test.code, test.hdr, s, test.state)
+		}
+	}
+}
+
+func TestExhaustedReset(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	tr := NewTracker(DefaultReserve, true)
+	tr.now = func() time.Time { return now }
+
+	reset := now.Add(time.Hour).Unix()
+	tr.Observe("test", 429, map[string]string{
+		"X-Ratelimit-Remaining": "0",
+		"X-Ratelimit-Reset":     strconv.FormatInt(reset, 10),
+	})
+	if !tr.Exhausted("test") {
+		t.Fatal("The empty quota was not considered exhausted")
+	}
+
+	now = now.Add(2 * time.Hour)
+	if tr.Exhausted("test") {
+		t.Error("The quota was still considered exhausted after the reset")
+	}
+	if s := tr.Status("test"); s.State != StateOK || s.Remaining != -1 {
+		t.Errorf("Unexpected status after the reset: %+v", s)
+	}
+}
+
+func TestInvalidKey(t *testing.T) {
+	tr := NewTracker(DefaultReserve, true)
+
+	tr.Validated("valid", nil)
+	tr.Validated("invalid", errors.New("the service responded with status 403"))
+	// The responses received later cannot restore a rejected key
+	tr.Observe("invalid", 200, map[string]string{"X-Ratelimit-Remaining": "100"})
+
+	if tr.Exhausted("valid") || !tr.Status("valid").Validated {
+		t.Error("The validated key was not recorded")
+	}
+	if !tr.Exhausted("invalid") || tr.Status("invalid").State != StateInvalid {
+		t.Error("The rejected key was not recorded")
+	}
+	if snap := tr.Snapshot(); len(snap) != 2 || snap[0].Source != "invalid" {
+		t.Errorf("Unexpected snapshot: %+v", snap)
+	}
+}
+
+func TestNilTracker(t *testing.T) {
+	var tr *Tracker
+
+	tr.Validated("test", errors.New("rejected"))
+	tr.Observe("test", 401, nil)
+	if tr.Exhausted("test") || tr.Low("test") || tr.Status("test") != nil || tr.Snapshot() != nil {
+		t.Error("The nil tracker recorded the status of the data source")
+	}
+	if !tr.ValidateKeys() {
+		t.Error("The nil tracker does not validate the API keys")
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	tests := []struct {
+		settings interface{}
+		valid    bool
+	}{
+		{map[string]interface{}{"validate": false, "reserve": 50}, true},
+		{map[string]interface{}{"reserve": 0}, true},
+		{"enabled", false},
+		{map[string]interface{}{"validate": "no"}, false},
+		{map[string]interface{}{"reserve": -1}, false},
+	}
+
+	for _, test := range tests {
+		cfg := config.NewConfig()
+		cfg.Options["quotas"] = test.settings
+
+		if _, err := FromConfig(cfg); (err == nil) != test.valid {
+			t.Errorf("Expected the settings %v to be valid: %t, got the error %v", test.settings, test.valid, err)
+		}
+	}
+
+	tr, err := FromConfig(config.NewConfig())
+	if err != nil || tr.reserve != DefaultReserve || !tr.ValidateKeys() {
+		t.Errorf("Unexpected defaults without the quotas section: %v", err)
+	}
+}
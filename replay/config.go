@@ -0,0 +1,54 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package replay
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// DefaultFile is the name of the cassette in the output directory, unless the 'file' option of the
+// 'replay' section provides another path.
+const DefaultFile = "replay.jsonl"
+
+// Settings are provided by the 'replay' section of the configuration.
+type Settings struct {
+	Mode Mode
+	// Path is the location of the cassette file
+	Path string
+}
+
+// FromConfig returns the Settings in the 'replay' section of the configuration options, or nil when
+// the section is not provided. Relative cassette paths are within the output directory.
+func FromConfig(cfg *config.Config) (*Settings, error) {
+	raw, ok := cfg.Options["replay"]
+	if !ok {
+		return nil, nil
+	}
+
+	settings, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("replay is not a map[string]interface{}")
+	}
+
+	mode, _ := settings["mode"].(string)
+	s := &Settings{Mode: Mode(mode), Path: DefaultFile}
+	if s.Mode != Record && s.Mode != Replay {
+		return nil, fmt.Errorf("replay mode must be %q or %q", Record, Replay)
+	}
+	if v, ok := settings["file"]; ok {
+		path, ok := v.(string)
+		if !ok || path == "" {
+			return nil, fmt.Errorf("replay file is not a path")
+		}
+		s.Path = path
+	}
+	if !filepath.IsAbs(s.Path) {
+		s.Path = filepath.Join(config.OutputDirectory(cfg.Dir), s.Path)
+	}
+	return s, nil
+}
@@ -0,0 +1,220 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package replay
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// The time waited for a response from an upstream resolver while recording.
+const upstreamTimeout = 2 * time.Second
+
+// The number of upstream resolvers tried for each query while recording.
+const upstreamAttempts = 3
+
+func dnsKey(name, qtype string) string {
+	return strings.ToLower(dns.Fqdn(name)) + " " + strings.ToUpper(qtype)
+}
+
+// StartDNS starts the DNS server on the loopback interface that the resolvers of the session are
+// pointed at, and returns its address. While recording, the queries are forwarded to the upstream
+// resolvers in turn, and the names missing from the cassette are answered as nonexistent when replaying.
+func (c *Cassette) StartDNS(upstreams []string) (string, error) {
+	if c.mode == Record && len(upstreams) == 0 {
+		return "", errors.New("no upstream resolvers were provided for the recording")
+	}
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("failed to start the replay DNS server: %v", err)
+	}
+
+	fwd := &forwarder{
+		upstreams: upstreams,
+		udp:       &dns.Client{Net: "udp", Timeout: upstreamTimeout},
+		tcp:       &dns.Client{Net: "tcp", Timeout: upstreamTimeout},
+	}
+	started := make(chan struct{})
+	c.server = &dns.Server{
+		PacketConn:        pc,
+		NotifyStartedFunc: func() { close(started) },
+		Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+			c.serveDNS(w, req, fwd)
+		}),
+	}
+	go func() { _ = c.server.ActivateAndServe() }()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		return "", errors.New("the replay DNS server failed to start")
+	}
+	return pc.LocalAddr().String(), nil
+}
+
+func (c *Cassette) serveDNS(w dns.ResponseWriter, req *dns.Msg, fwd *forwarder) {
+	if len(req.Question) != 1 {
+		resp := new(dns.Msg)
+		resp.SetRcode(req, dns.RcodeFormatError)
+		_ = w.WriteMsg(resp)
+		return
+	}
+
+	var resp *dns.Msg
+	if c.mode == Replay {
+		resp = c.replayDNS(req)
+	} else {
+		resp = c.recordDNS(req, fwd)
+	}
+
+	size := dns.MinMsgSize
+	if opt := req.IsEdns0(); opt != nil && int(opt.UDPSize()) > size {
+		size = int(opt.UDPSize())
+	}
+	resp.Truncate(size)
+	_ = w.WriteMsg(resp)
+}
+
+func (c *Cassette) replayDNS(req *dns.Msg) *dns.Msg {
+	q := req.Question[0]
+	key := dnsKey(q.Name, dns.TypeToString[q.Qtype])
+
+	resp := new(dns.Msg)
+	resp.SetReply(req)
+	resp.RecursionAvailable = true
+
+	c.Lock()
+	defer c.Unlock()
+
+	list := c.dns[key]
+	if len(list) == 0 {
+		c.misses++
+		resp.Rcode = dns.RcodeNameError
+		return resp
+	}
+	c.replayed++
+
+	reply := list[c.nextIndex(key, len(list))]
+	resp.Rcode = reply.rcode
+	resp.Answer = copyRRs(reply.answer)
+	resp.Ns = copyRRs(reply.ns)
+	resp.Extra = copyRRs(reply.extra)
+	return resp
+}
+
+func (c *Cassette) recordDNS(req *dns.Msg, fwd *forwarder) *dns.Msg {
+	in, err := fwd.exchange(req)
+	if err != nil {
+		// The failures are not recorded, since the resolvers retry the queries
+		resp := new(dns.Msg)
+		resp.SetRcode(req, dns.RcodeServerFailure)
+		return resp
+	}
+
+	q := req.Question[0]
+	it := &DNSInteraction{
+		Name:   strings.ToLower(q.Name),
+		Type:   dns.TypeToString[q.Qtype],
+		Rcode:  dns.RcodeToString[in.Rcode],
+		Answer: rrStrings(in.Answer),
+		Ns:     rrStrings(in.Ns),
+		Extra:  rrStrings(in.Extra),
+	}
+	_ = c.write(&entry{DNS: it})
+
+	in.Id = req.Id
+	return in
+}
+
+// forwarder sends the queries to the upstream resolvers in turn.
+type forwarder struct {
+	sync.Mutex
+	upstreams []string
+	next      int
+	udp       *dns.Client
+	tcp       *dns.Client
+}
+
+func (f *forwarder) exchange(req *dns.Msg) (*dns.Msg, error) {
+	var err error
+
+	for i := 0; i < upstreamAttempts; i++ {
+		f.Lock()
+		addr := f.upstreams[f.next%len(f.upstreams)]
+		f.next++
+		f.Unlock()
+
+		var in *dns.Msg
+		in, _, err = f.udp.Exchange(req, addr)
+		if err == nil && in.Truncated {
+			in, _, err = f.tcp.Exchange(req, addr)
+		}
+		if err == nil {
+			return in, nil
+		}
+	}
+	return nil, err
+}
+
+// rrStrings returns the records in the zone file presentation format, leaving out the EDNS options.
+func rrStrings(rrs []dns.RR) []string {
+	var results []string
+
+	for _, rr := range rrs {
+		if rr.Header().Rrtype == dns.TypeOPT {
+			continue
+		}
+		results = append(results, rr.String())
+	}
+	return results
+}
+
+func parseDNSInteraction(it *DNSInteraction) (*dnsReply, error) {
+	if _, found := dns.StringToType[strings.ToUpper(it.Type)]; !found || it.Name == "" {
+		return nil, fmt.Errorf("the DNS query %s %s is not valid", it.Name, it.Type)
+	}
+
+	rcode, found := dns.StringToRcode[strings.ToUpper(it.Rcode)]
+	if !found {
+		return nil, fmt.Errorf("the DNS response code %s is not valid", it.Rcode)
+	}
+
+	reply := &dnsReply{rcode: rcode}
+	for _, section := range []struct {
+		records []string
+		rrs     *[]dns.RR
+	}{
+		{records: it.Answer, rrs: &reply.answer},
+		{records: it.Ns, rrs: &reply.ns},
+		{records: it.Extra, rrs: &reply.extra},
+	} {
+		for _, s := range section.records {
+			rr, err := dns.NewRR(s)
+			if err != nil {
+				return nil, err
+			}
+			if rr != nil {
+				*section.rrs = append(*section.rrs, rr)
+			}
+		}
+	}
+	return reply, nil
+}
+
+func copyRRs(rrs []dns.RR) []dns.RR {
+	var results []dns.RR
+
+	for _, rr := range rrs {
+		results = append(results, dns.Copy(rr))
+	}
+	return results
+}
@@ -0,0 +1,162 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package replay
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Transport is the http.RoundTripper that records the requests to the cassette, or answers them from it.
+type Transport struct {
+	// Base sends the requests while recording
+	Base     http.RoundTripper
+	cassette *Cassette
+}
+
+// Transport returns a Transport for the cassette, which sends the requests using the base while recording.
+func (c *Cassette) Transport(base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{Base: base, cassette: c}
+}
+
+// Intercept replaces the transport of the client, so the sessions created from it afterwards are
+// intercepted as well. The original transport is restored when the cassette is closed.
+func (c *Cassette) Intercept(client *http.Client) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.client = client
+	c.base = client.Transport
+	client.Transport = c.Transport(client.Transport)
+}
+
+func httpKey(method, url, digest string) string {
+	return method + " " + url + " " + digest
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	c := t.cassette
+	c.Lock()
+	url := c.redact(req.URL.String())
+	var digest string
+	if len(body) > 0 {
+		sum := sha256.Sum256([]byte(c.redact(string(body))))
+		digest = hex.EncodeToString(sum[:])
+	}
+	c.Unlock()
+
+	if c.mode == Replay {
+		return t.replay(req, httpKey(req.Method, url, digest))
+	}
+	return t.record(req, url, digest)
+}
+
+func (t *Transport) replay(req *http.Request, key string) (*http.Response, error) {
+	c := t.cassette
+	c.Lock()
+	defer c.Unlock()
+
+	list := c.http[key]
+	if len(list) == 0 {
+		c.misses++
+		return nil, fmt.Errorf("replay: the cassette has no response for %s", key)
+	}
+	c.replayed++
+
+	it := list[c.nextIndex(key, len(list))]
+	if it.Error != "" {
+		return nil, errors.New(it.Error)
+	}
+
+	body := []byte(it.Body)
+	if len(it.BodyBase64) > 0 {
+		body = it.BodyBase64
+	}
+	header := make(http.Header, len(it.Header))
+	for k, v := range it.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", it.Status, http.StatusText(it.Status)),
+		StatusCode:    it.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func (t *Transport) record(req *http.Request, url, digest string) (*http.Response, error) {
+	c := t.cassette
+	it := &HTTPInteraction{
+		Method:     req.Method,
+		URL:        url,
+		BodyDigest: digest,
+	}
+
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		c.Lock()
+		it.Error = c.redact(err.Error())
+		c.Unlock()
+
+		_ = c.write(&entry{HTTP: it})
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	it.Status = resp.StatusCode
+	it.Header = make(map[string][]string, len(resp.Header))
+	c.Lock()
+	for k, v := range resp.Header {
+		for _, val := range v {
+			it.Header[k] = append(it.Header[k], c.redact(val))
+		}
+	}
+	c.Unlock()
+	if utf8.Valid(body) {
+		it.Body = string(body)
+	} else {
+		it.BodyBase64 = body
+	}
+
+	// The responses received after the cassette is closed are still returned
+	_ = c.write(&entry{HTTP: it})
+	return resp, nil
+}
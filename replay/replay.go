@@ -0,0 +1,244 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package replay records the outbound HTTP requests and DNS queries of a session to a cassette file,
+// and later answers them from the cassette, so data sources can be developed and tested against
+// reproducible responses without reaching the live services.
+package replay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+)
+
+// Mode selects whether the interactions are recorded to the cassette or replayed from it.
+type Mode string
+
+// The modes supported by the cassettes.
+const (
+	Record Mode = "record"
+	Replay Mode = "replay"
+)
+
+// Redacted replaces the secrets found in the recorded interactions.
+const Redacted = "REDACTED"
+
+// HTTPInteraction is an HTTP request recorded in the cassette along with the response received.
+type HTTPInteraction struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	// BodyDigest is the SHA-256 digest of the request body, which is not recorded
+	BodyDigest string              `json:"body_sha256,omitempty"`
+	Status     int                 `json:"status,omitempty"`
+	Header     map[string][]string `json:"header,omitempty"`
+	// Body holds the response bodies that are valid UTF-8, and BodyBase64 holds the others
+	Body       string `json:"body,omitempty"`
+	BodyBase64 []byte `json:"body_base64,omitempty"`
+	// Error is the failure of the request, which is returned again during the replay
+	Error string `json:"error,omitempty"`
+}
+
+// DNSInteraction is a DNS query recorded in the cassette along with the records of the response,
+// which are kept in the zone file presentation format.
+type DNSInteraction struct {
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	Rcode  string   `json:"rcode"`
+	Answer []string `json:"answer,omitempty"`
+	Ns     []string `json:"ns,omitempty"`
+	Extra  []string `json:"extra,omitempty"`
+}
+
+// entry is a line of the cassette file, which holds a single interaction.
+type entry struct {
+	HTTP *HTTPInteraction `json:"http,omitempty"`
+	DNS  *DNSInteraction  `json:"dns,omitempty"`
+}
+
+// dnsReply is a DNS interaction parsed from the cassette.
+type dnsReply struct {
+	rcode  int
+	answer []dns.RR
+	ns     []dns.RR
+	extra  []dns.RR
+}
+
+// Cassette records the interactions of a session to a file of JSON lines, or replays them.
+// The identical requests are replayed in the order they were recorded, and the last response
+// is repeated once they are exhausted.
+type Cassette struct {
+	sync.Mutex
+	mode     Mode
+	path     string
+	file     *os.File
+	enc      *json.Encoder
+	http     map[string][]*HTTPInteraction
+	dns      map[string][]*dnsReply
+	next     map[string]int
+	secrets  []string
+	recorded int
+	replayed int
+	misses   int
+	server   *dns.Server
+	client   *http.Client
+	base     http.RoundTripper
+}
+
+// Open returns a Cassette for the file. The file is created, or truncated, when recording, and
+// the interactions are loaded from it when replaying.
+func Open(path string, mode Mode) (*Cassette, error) {
+	c := &Cassette{
+		mode: mode,
+		path: path,
+		http: make(map[string][]*HTTPInteraction),
+		dns:  make(map[string][]*dnsReply),
+		next: make(map[string]int),
+	}
+
+	switch mode {
+	case Record:
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create the directory of the cassette %s: %v", path, err)
+		}
+
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the cassette %s: %v", path, err)
+		}
+		c.file = f
+		c.enc = json.NewEncoder(f)
+	case Replay:
+		if err := c.load(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("the replay mode must be %q or %q", Record, Replay)
+	}
+	return c, nil
+}
+
+func (c *Cassette) load() error {
+	f, err := os.Open(c.path)
+	if err != nil {
+		return fmt.Errorf("failed to open the cassette %s: %v", c.path, err)
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	for line := 1; ; line++ {
+		var e entry
+
+		if err := dec.Decode(&e); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("failed to read interaction %d of the cassette %s: %v", line, c.path, err)
+		}
+
+		switch {
+		case e.HTTP != nil:
+			key := httpKey(e.HTTP.Method, e.HTTP.URL, e.HTTP.BodyDigest)
+			c.http[key] = append(c.http[key], e.HTTP)
+		case e.DNS != nil:
+			reply, err := parseDNSInteraction(e.DNS)
+			if err != nil {
+				return fmt.Errorf("failed to parse interaction %d of the cassette %s: %v", line, c.path, err)
+			}
+
+			key := dnsKey(e.DNS.Name, e.DNS.Type)
+			c.dns[key] = append(c.dns[key], reply)
+		}
+	}
+	return nil
+}
+
+// Mode returns whether the cassette is recording or replaying the interactions.
+func (c *Cassette) Mode() Mode {
+	return c.mode
+}
+
+// Redact keeps the secrets, such as the API keys of the data sources, out of the recorded URLs and
+// headers. The same secrets must be provided when replaying, so the requests match the cassette.
+func (c *Cassette) Redact(secrets ...string) {
+	c.Lock()
+	defer c.Unlock()
+
+	for _, s := range secrets {
+		if s = strings.TrimSpace(s); s != "" {
+			c.secrets = append(c.secrets, s)
+		}
+	}
+}
+
+func (c *Cassette) redact(s string) string {
+	for _, secret := range c.secrets {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+	return s
+}
+
+// Stats returns the numbers of interactions recorded, replayed and missing from the cassette.
+func (c *Cassette) Stats() (recorded, replayed, misses int) {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.recorded, c.replayed, c.misses
+}
+
+// Close stops the DNS server, restores the transport of the intercepted HTTP client, and
+// finishes writing the cassette.
+func (c *Cassette) Close() error {
+	if c.server != nil {
+		_ = c.server.Shutdown()
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if c.client != nil {
+		if t, ok := c.client.Transport.(*Transport); ok && t.cassette == c {
+			c.client.Transport = c.base
+		}
+		c.client = nil
+	}
+	if c.file != nil {
+		err := c.file.Close()
+		c.file = nil
+		return err
+	}
+	return nil
+}
+
+// write appends the entry to the cassette file.
+func (c *Cassette) write(e *entry) error {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.enc == nil || c.file == nil {
+		return errors.New("the cassette is closed")
+	}
+	if err := c.enc.Encode(e); err != nil {
+		return fmt.Errorf("failed to write to the cassette %s: %v", c.path, err)
+	}
+	c.recorded++
+	return nil
+}
+
+// nextIndex returns the index of the interaction replayed for the key, which stops advancing
+// at the last of the n interactions recorded.
+func (c *Cassette) nextIndex(key string, n int) int {
+	i := c.next[key]
+	if i < n-1 {
+		c.next[key] = i + 1
+	}
+	return i
+}
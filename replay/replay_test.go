@@ -0,0 +1,226 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package replay
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestRecordReplayHTTP(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"hits":`+strconv.Itoa(hits)+`}`)
+	}))
+
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	url := srv.URL + "/v1/domains?apikey=secret123"
+
+	rec, err := Open(path, Record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Redact("secret123")
+	client := &http.Client{}
+	rec.Intercept(client)
+
+	for _, expected := range []string{`{"hits":1}`, `{"hits":2}`} {
+		if body := get(t, client, url); body != expected {
+			t.Errorf("The recorded response was %s, expected %s", body, expected)
+		}
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := client.Transport.(*Transport); ok {
+		t.Error("The transport of the client was not restored")
+	}
+	srv.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret123") || !strings.Contains(string(data), Redacted) {
+		t.Error("The API key was not redacted from the cassette")
+	}
+
+	rep, err := Open(path, Replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rep.Close()
+	rep.Redact("secret123")
+	rep.Intercept(client)
+
+	// The last response is repeated once the recorded responses are exhausted
+	for _, expected := range []string{`{"hits":1}`, `{"hits":2}`, `{"hits":2}`} {
+		if body := get(t, client, url); body != expected {
+			t.Errorf("The replayed response was %s, expected %s", body, expected)
+		}
+	}
+	if _, err := client.Get(srv.URL + "/v1/other"); err == nil {
+		t.Error("The request missing from the cassette was answered")
+	}
+	if recorded, replayed, misses := rep.Stats(); recorded != 0 || replayed != 3 || misses != 1 {
+		t.Errorf("The replay returned %d recorded, %d replayed and %d misses", recorded, replayed, misses)
+	}
+}
+
+func get(t *testing.T, client *http.Client, url string) string {
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("The response had the status %d and headers %v", resp.StatusCode, resp.Header)
+	}
+	return string(body)
+}
+
+func TestRecordReplayDNS(t *testing.T) {
+	upstream := startUpstream(t)
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+
+	rec, err := Open(path, Record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := rec.StartDNS([]string{upstream})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp := query(t, addr, "www.owasp.org", dns.TypeA); len(resp.Answer) != 1 || resp.Rcode != dns.RcodeSuccess {
+		t.Errorf("The recorded response was %v", resp)
+	}
+	if resp := query(t, addr, "none.owasp.org", dns.TypeA); resp.Rcode != dns.RcodeNameError {
+		t.Errorf("The recorded response was %v", resp)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rep, err := Open(path, Replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rep.Close()
+	addr, err = rep.StartDNS(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := query(t, addr, "WWW.owasp.org", dns.TypeA)
+	if len(resp.Answer) != 1 || resp.Rcode != dns.RcodeSuccess {
+		t.Fatalf("The replayed response was %v", resp)
+	}
+	if a, ok := resp.Answer[0].(*dns.A); !ok || !a.A.Equal(net.ParseIP("192.168.1.1")) {
+		t.Errorf("The replayed answer was %v", resp.Answer[0])
+	}
+	if resp := query(t, addr, "none.owasp.org", dns.TypeA); resp.Rcode != dns.RcodeNameError {
+		t.Errorf("The replayed response was %v", resp)
+	}
+	if resp := query(t, addr, "www.owasp.org", dns.TypeAAAA); resp.Rcode != dns.RcodeNameError {
+		t.Errorf("The query missing from the cassette was answered with %v", resp)
+	}
+	if _, replayed, misses := rep.Stats(); replayed != 2 || misses != 1 {
+		t.Errorf("The replay returned %d replayed and %d misses", replayed, misses)
+	}
+}
+
+func startUpstream(t *testing.T) string {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan struct{})
+	srv := &dns.Server{
+		PacketConn:        pc,
+		NotifyStartedFunc: func() { close(started) },
+		Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+			resp := new(dns.Msg)
+			resp.SetReply(req)
+
+			if q := req.Question[0]; strings.EqualFold(q.Name, "www.owasp.org.") && q.Qtype == dns.TypeA {
+				rr, _ := dns.NewRR("www.owasp.org. 300 IN A 192.168.1.1")
+				resp.Answer = append(resp.Answer, rr)
+			} else {
+				resp.Rcode = dns.RcodeNameError
+			}
+			_ = w.WriteMsg(resp)
+		}),
+	}
+	go func() { _ = srv.ActivateAndServe() }()
+	t.Cleanup(func() { _ = srv.Shutdown() })
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The upstream DNS server failed to start")
+	}
+	return pc.LocalAddr().String()
+}
+
+func query(t *testing.T, addr, name string, qtype uint16) *dns.Msg {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), qtype)
+
+	resp, _, err := new(dns.Client).Exchange(msg, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestFromConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Dir = t.TempDir()
+
+	if s, err := FromConfig(cfg); err != nil || s != nil {
+		t.Errorf("The missing section returned %v and %v", s, err)
+	}
+
+	cfg.Options["replay"] = map[string]interface{}{"mode": "replay"}
+	if s, err := FromConfig(cfg); err != nil || s.Mode != Replay ||
+		s.Path != filepath.Join(config.OutputDirectory(cfg.Dir), DefaultFile) {
+		t.Errorf("The replay mode returned %+v and %v", s, err)
+	}
+
+	cfg.Options["replay"] = map[string]interface{}{"mode": "record", "file": "/tmp/cassette.jsonl"}
+	if s, err := FromConfig(cfg); err != nil || s.Mode != Record || s.Path != "/tmp/cassette.jsonl" {
+		t.Errorf("The record mode returned %+v and %v", s, err)
+	}
+
+	for _, bad := range []interface{}{
+		"record",
+		map[string]interface{}{"mode": "playback"},
+		map[string]interface{}{"mode": "record", "file": 5},
+	} {
+		cfg.Options["replay"] = bad
+		if _, err := FromConfig(cfg); err == nil {
+			t.Errorf("The section %v did not return an error", bad)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// Organization is a set of root domain names reported together on the scoreboard.
+type Organization struct {
+	Name    string
+	Domains []string
+}
+
+// OrganizationsFromConfig returns the organizations in the 'organizations' section of the configuration
+// options, which maps each organization name to the list of its root domain names.
+func OrganizationsFromConfig(cfg *config.Config) ([]*Organization, error) {
+	orgsRaw, ok := cfg.Options["organizations"]
+	if !ok {
+		return nil, nil
+	}
+
+	orgs, ok := orgsRaw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("organizations is not a map[string]interface{}")
+	}
+
+	var results []*Organization
+	for name, raw := range orgs {
+		list, ok := raw.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("organizations %s is not a list", name)
+		}
+
+		org := &Organization{Name: name}
+		for _, v := range list {
+			d, ok := v.(string)
+			if !ok || strings.TrimSpace(d) == "" {
+				return nil, fmt.Errorf("organizations %s contains an invalid domain name: %v", name, v)
+			}
+			org.Domains = append(org.Domains, strings.ToLower(strings.TrimSpace(d)))
+		}
+		if len(org.Domains) == 0 {
+			return nil, fmt.Errorf("organizations %s does not provide any domain names", name)
+		}
+		results = append(results, org)
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results, nil
+}
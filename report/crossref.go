@@ -0,0 +1,144 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package report
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/viz"
+	oam "github.com/owasp-amass/open-asset-model"
+)
+
+// The kinds of infrastructure compared between the organizations.
+const (
+	SharedAddress     = "address"
+	SharedNameserver  = "nameserver"
+	SharedCertificate = "certificate"
+)
+
+// CertificateDetail is the finding detail providing the SHA-256 fingerprint of the certificate served by a web endpoint.
+const CertificateDetail = "cert_sha256"
+
+// SharedAsset is infrastructure used by the names of more than one organization.
+type SharedAsset struct {
+	Kind  string
+	Asset string
+	Users []*OrgNames
+}
+
+// OrgNames lists the names of an organization leading to a shared asset.
+type OrgNames struct {
+	Organization string
+	Names        []string
+}
+
+// CrossReference is the data provided to the template rendering the infrastructure shared between organizations.
+type CrossReference struct {
+	Title         string
+	Generated     time.Time
+	Organizations []*Organization
+	// Counts provides the number of shared assets of each kind
+	Counts []*Count
+	Shared []*SharedAsset
+}
+
+// NewCrossReference returns the addresses, nameservers and certificates used by the names of more than one
+// organization, using the graph built for the domains of each organization and the findings of the web endpoints.
+func NewCrossReference(title string, orgs []*Organization, graphs map[string]*viz.Graph, all []*findings.Finding) *CrossReference {
+	cr := &CrossReference{
+		Title:         title,
+		Generated:     time.Now().UTC(),
+		Organizations: orgs,
+	}
+
+	// Maps each kind and asset to the names of each organization leading to it
+	users := make(map[string]map[string]map[string]bool)
+	add := func(kind, asset, org, name string) {
+		key := kind + "|" + asset
+		if _, found := users[key]; !found {
+			users[key] = make(map[string]map[string]bool)
+		}
+		if _, found := users[key][org]; !found {
+			users[key][org] = make(map[string]bool)
+		}
+		users[key][org][name] = true
+	}
+
+	for _, org := range orgs {
+		if g, found := graphs[org.Name]; found && g != nil {
+			crossRefGraph(org, g, add)
+		}
+		for _, f := range all {
+			if fp := f.Details[CertificateDetail]; fp != "" && inScope(f.Asset, org.Domains, nil) {
+				add(SharedCertificate, strings.ToLower(fp), org.Name, findings.Host(f.Asset))
+			}
+		}
+	}
+
+	counts := make(map[string]int)
+	for key, byOrg := range users {
+		if len(byOrg) < 2 {
+			continue
+		}
+
+		kind, asset, _ := strings.Cut(key, "|")
+		shared := &SharedAsset{Kind: kind, Asset: asset}
+		for org, names := range byOrg {
+			u := &OrgNames{Organization: org}
+			for name := range names {
+				u.Names = append(u.Names, name)
+			}
+			sort.Strings(u.Names)
+			shared.Users = append(shared.Users, u)
+		}
+		sort.Slice(shared.Users, func(i, j int) bool {
+			return shared.Users[i].Organization < shared.Users[j].Organization
+		})
+
+		cr.Shared = append(cr.Shared, shared)
+		counts[kind]++
+	}
+	// The assets shared by the most organizations are listed first
+	sort.Slice(cr.Shared, func(i, j int) bool {
+		a, b := cr.Shared[i], cr.Shared[j]
+		if len(a.Users) != len(b.Users) {
+			return len(a.Users) > len(b.Users)
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Asset < b.Asset
+	})
+
+	for _, kind := range []string{SharedAddress, SharedNameserver, SharedCertificate} {
+		cr.Counts = append(cr.Counts, &Count{Name: kind, Count: counts[kind]})
+	}
+	return cr
+}
+
+// crossRefGraph reports the addresses and nameservers of the names within the organization domains.
+func crossRefGraph(org *Organization, g *viz.Graph, add func(kind, asset, org, name string)) {
+	nodes := make(map[string]*viz.Node, len(g.Nodes))
+	for _, n := range g.Nodes {
+		nodes[n.ID] = n
+	}
+
+	for _, e := range g.Edges {
+		from, to := nodes[e.From], nodes[e.To]
+		if oam.AssetType(from.Type) != oam.FQDN || !inScope(from.Label, org.Domains, nil) {
+			continue
+		}
+
+		switch e.Label {
+		case "a_record", "aaaa_record":
+			add(SharedAddress, to.Label, org.Name, from.Label)
+		case "ns_record":
+			add(SharedNameserver, strings.ToLower(to.Label), org.Name, from.Label)
+		}
+	}
+}
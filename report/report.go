@@ -0,0 +1,382 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package report summarizes the assets and findings of the enumerations into a self-contained
+// HTML document rendered with Go templates, so the presentation can be changed by each team.
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/analysis"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/viz"
+	oam "github.com/owasp-amass/open-asset-model"
+)
+
+const (
+	// TakeoverFinding is the type of the findings reporting names that can be claimed by others.
+	TakeoverFinding = "subdomain_takeover"
+	// DefaultMaxSnippets is the number of new names shown with the relations leading to their infrastructure.
+	DefaultMaxSnippets = 10
+	// DefaultMaxRegistrars is the number of registrars listed by the report.
+	DefaultMaxRegistrars = 10
+)
+
+// Report is the data provided to the template rendering the document.
+type Report struct {
+	Title     string
+	Generated time.Time
+	Domains   []string
+	// Since is the start of the latest enumeration, and the zero time when the history is unknown
+	Since        time.Time
+	Summary      Summary
+	NewAssets    []*viz.Node
+	ASNs         []*ASNRow
+	Registrars   []*Count
+	Certificates []*findings.Finding
+	Findings     []*findings.Finding
+	// ContentGroups are the hosts serving identical content, reported once in the findings
+	ContentGroups []*analysis.ContentGroup
+	Snippets      []*Snippet
+}
+
+// Summary provides the number of assets of each type and of the findings of each severity.
+type Summary struct {
+	Names     int
+	Addresses int
+	Netblocks int
+	ASNs      int
+	Orgs      int
+	// Live is the number of names resolving to addresses, directly or through aliases
+	Live       int
+	New        int
+	Findings   int
+	Takeovers  int
+	Severities []*Count
+}
+
+// ASNRow describes an autonomous system hosting the discovered assets.
+type ASNRow struct {
+	ASN       string
+	Org       string
+	Netblocks int
+	Addresses int
+	Names     int
+}
+
+// Count is a value along with the number of times it was observed.
+type Count struct {
+	Name  string
+	Count int
+}
+
+// Snippet is the portion of the graph relating a new name to its infrastructure, in the text export format.
+type Snippet struct {
+	Name string
+	Text string
+}
+
+// New returns the report of the graph built for the domains and the findings observed within them.
+// Assets first seen at or after since are reported as new.
+func New(title string, domains []string, g *viz.Graph, all []*findings.Finding, since time.Time) *Report {
+	rep := &Report{
+		Title:     title,
+		Generated: time.Now().UTC(),
+		Domains:   domains,
+		Since:     since,
+	}
+
+	labels := make(map[string]bool, len(g.Nodes))
+	for _, n := range g.Nodes {
+		labels[strings.ToLower(n.Label)] = true
+
+		switch oam.AssetType(n.Type) {
+		case oam.FQDN:
+			rep.Summary.Names++
+		case oam.IPAddress:
+			rep.Summary.Addresses++
+		case oam.Netblock:
+			rep.Summary.Netblocks++
+		case oam.ASN:
+			rep.Summary.ASNs++
+		case oam.RIROrg:
+			rep.Summary.Orgs++
+		}
+		if !since.IsZero() && !n.FirstSeen.Before(since) && (oam.AssetType(n.Type) == oam.FQDN || oam.AssetType(n.Type) == oam.IPAddress) {
+			rep.NewAssets = append(rep.NewAssets, n)
+		}
+	}
+	rep.Summary.New = len(rep.NewAssets)
+	rep.Summary.Live = liveNames(g)
+
+	for _, f := range all {
+		if inScope(f.Asset, domains, labels) {
+			rep.Findings = append(rep.Findings, f)
+		}
+	}
+	findings.Sort(rep.Findings)
+	rep.ContentGroups = analysis.ContentGroups(rep.Findings)
+
+	rep.collapseContent()
+	rep.summarizeFindings()
+	rep.ASNs = asnTable(g)
+	rep.Snippets = snippets(g, rep.NewAssets, DefaultMaxSnippets)
+	return rep
+}
+
+// summarizeFindings counts the severities and extracts the registrars and certificate expiry warnings.
+// The registration details are removed from the findings, since they do not describe a risk.
+func (rep *Report) summarizeFindings() {
+	sevs := make(map[findings.Severity]int)
+	registrars := make(map[string]int)
+
+	var observations []*findings.Finding
+	for _, f := range rep.Findings {
+		switch f.Type {
+		case "domain_registration":
+			if r := f.Details["registrar"]; r != "" {
+				registrars[r]++
+			}
+			continue
+		case "certificate_expired", "certificate_expiring":
+			rep.Certificates = append(rep.Certificates, f)
+		case TakeoverFinding:
+			rep.Summary.Takeovers++
+		}
+		observations = append(observations, f)
+		sevs[f.Severity]++
+	}
+	rep.Findings = observations
+	rep.Summary.Findings = len(observations)
+
+	for s := findings.Critical; s >= findings.Info; s-- {
+		if n := sevs[s]; n > 0 {
+			rep.Summary.Severities = append(rep.Summary.Severities, &Count{Name: s.String(), Count: n})
+		}
+	}
+
+	for name, n := range registrars {
+		rep.Registrars = append(rep.Registrars, &Count{Name: name, Count: n})
+	}
+	sort.Slice(rep.Registrars, func(i, j int) bool {
+		if rep.Registrars[i].Count == rep.Registrars[j].Count {
+			return rep.Registrars[i].Name < rep.Registrars[j].Name
+		}
+		return rep.Registrars[i].Count > rep.Registrars[j].Count
+	})
+	if len(rep.Registrars) > DefaultMaxRegistrars {
+		rep.Registrars = rep.Registrars[:DefaultMaxRegistrars]
+	}
+}
+
+// collapseContent replaces the findings of the hosts serving identical content with a single
+// finding for each group, so thousands of parked or wildcard names are reported on one line.
+func (rep *Report) collapseContent() {
+	groups := make(map[string]*analysis.ContentGroup, len(rep.ContentGroups))
+	for _, g := range rep.ContentGroups {
+		groups[g.Hash] = g
+	}
+	if len(groups) == 0 {
+		return
+	}
+
+	seen := make(map[string]bool)
+	var kept []*findings.Finding
+	for _, f := range rep.Findings {
+		hash := f.Details[analysis.BodyHashDetail]
+		g, found := groups[hash]
+		if !found {
+			kept = append(kept, f)
+			continue
+		}
+		if seen[f.Type+"|"+hash] {
+			continue
+		}
+		seen[f.Type+"|"+hash] = true
+
+		c := *f
+		c.Description = fmt.Sprintf("%s (identical content served by %d hosts, %s)",
+			strings.TrimSpace(f.Description), len(g.Hosts), strings.ReplaceAll(g.Label, "_", " "))
+		kept = append(kept, &c)
+	}
+	rep.Findings = kept
+}
+
+// inScope returns true when the finding asset is a name within the domains or an asset of the graph.
+func inScope(asset string, domains []string, labels map[string]bool) bool {
+	host := findings.Host(asset)
+	if labels[host] {
+		return true
+	}
+	for _, d := range domains {
+		d = strings.ToLower(d)
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// liveNames returns the number of names that resolve to an address, following the aliases.
+func liveNames(g *viz.Graph) int {
+	nodes := make(map[string]*viz.Node, len(g.Nodes))
+	for _, n := range g.Nodes {
+		nodes[n.ID] = n
+	}
+
+	live := make(map[string]bool)
+	aliases := make(map[string][]string)
+	for _, e := range g.Edges {
+		from, to := nodes[e.From], nodes[e.To]
+		if oam.AssetType(from.Type) != oam.FQDN {
+			continue
+		}
+
+		switch oam.AssetType(to.Type) {
+		case oam.IPAddress:
+			live[from.ID] = true
+		case oam.FQDN:
+			if e.Label == "cname_record" {
+				aliases[to.ID] = append(aliases[to.ID], from.ID)
+			}
+		}
+	}
+
+	// Names aliasing a live name are also live
+	var queue []string
+	for id := range live {
+		queue = append(queue, id)
+	}
+	for ; len(queue) > 0; queue = queue[1:] {
+		for _, alias := range aliases[queue[0]] {
+			if !live[alias] {
+				live[alias] = true
+				queue = append(queue, alias)
+			}
+		}
+	}
+	return len(live)
+}
+
+// asnTable returns the autonomous systems ordered by the number of names hosted within them.
+func asnTable(g *viz.Graph) []*ASNRow {
+	nodes := make(map[string]*viz.Node, len(g.Nodes))
+	for _, n := range g.Nodes {
+		nodes[n.ID] = n
+	}
+
+	out := make(map[string][]*viz.Edge)
+	in := make(map[string][]*viz.Edge)
+	for _, e := range g.Edges {
+		out[e.From] = append(out[e.From], e)
+		in[e.To] = append(in[e.To], e)
+	}
+
+	var rows []*ASNRow
+	for _, n := range g.Nodes {
+		if oam.AssetType(n.Type) != oam.ASN {
+			continue
+		}
+
+		row := &ASNRow{ASN: n.Label}
+		names := make(map[string]struct{})
+		for _, e := range out[n.ID] {
+			to := nodes[e.To]
+
+			switch oam.AssetType(to.Type) {
+			case oam.RIROrg:
+				row.Org = to.Label
+			case oam.Netblock:
+				row.Netblocks++
+				for _, c := range out[to.ID] {
+					row.Addresses++
+					for _, r := range in[c.To] {
+						if from := nodes[r.From]; oam.AssetType(from.Type) == oam.FQDN {
+							names[from.ID] = struct{}{}
+						}
+					}
+				}
+			}
+		}
+		row.Names = len(names)
+		rows = append(rows, row)
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Names == rows[j].Names {
+			return rows[i].ASN < rows[j].ASN
+		}
+		return rows[i].Names > rows[j].Names
+	})
+	return rows
+}
+
+// snippets returns the relations leading from the new names toward the infrastructure hosting them.
+func snippets(g *viz.Graph, assets []*viz.Node, max int) []*Snippet {
+	nodes := make(map[string]*viz.Node, len(g.Nodes))
+	for _, n := range g.Nodes {
+		nodes[n.ID] = n
+	}
+
+	// The edges are followed from the names to the addresses, and then up to the netblocks,
+	// the autonomous systems announcing them and the organizations managing them
+	next := make(map[string][]*viz.Edge)
+	for _, e := range g.Edges {
+		from, to := nodes[e.From], nodes[e.To]
+
+		switch oam.AssetType(from.Type) {
+		case oam.FQDN:
+			next[e.From] = append(next[e.From], e)
+		case oam.Netblock:
+			if oam.AssetType(to.Type) == oam.IPAddress {
+				next[e.To] = append(next[e.To], e)
+			}
+		case oam.ASN:
+			if oam.AssetType(to.Type) == oam.Netblock {
+				next[e.To] = append(next[e.To], e)
+			} else {
+				next[e.From] = append(next[e.From], e)
+			}
+		}
+	}
+
+	var results []*Snippet
+	for _, a := range assets {
+		if len(results) >= max {
+			break
+		}
+		if oam.AssetType(a.Type) != oam.FQDN || len(next[a.ID]) == 0 {
+			continue
+		}
+
+		sub := &viz.Graph{Nodes: []*viz.Node{a}}
+		seen := map[string]bool{a.ID: true}
+		for queue := []string{a.ID}; len(queue) > 0; queue = queue[1:] {
+			for _, e := range next[queue[0]] {
+				sub.Edges = append(sub.Edges, e)
+
+				id := e.To
+				if id == queue[0] {
+					id = e.From
+				}
+				if !seen[id] {
+					seen[id] = true
+					sub.Nodes = append(sub.Nodes, nodes[id])
+					queue = append(queue, id)
+				}
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := viz.WriteText(&buf, sub); err == nil {
+			results = append(results, &Snippet{Name: a.Label, Text: buf.String()})
+		}
+	}
+	return results
+}
@@ -0,0 +1,255 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package report
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/viz"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestReport(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	_ = g.UpsertA(ctx, "www.owasp.org", "192.0.2.1")
+	_ = g.UpsertA(ctx, "mail.owasp.org", "192.0.2.2")
+	_ = g.UpsertInfrastructure(ctx, 64496, "EXAMPLE-NET", "192.0.2.1", "192.0.2.0/24")
+	_ = g.UpsertInfrastructure(ctx, 64496, "EXAMPLE-NET", "192.0.2.2", "192.0.2.0/24")
+
+	graph, err := viz.Build(ctx, g, []string{"owasp.org"}, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to build the graph: %v", err)
+	}
+
+	all := []*findings.Finding{
+		{Type: "domain_registration", Asset: "owasp.org", Details: map[string]string{"registrar": "Example Registrar"}},
+		{Type: "certificate_expiring", Asset: "https://www.owasp.org:443", Severity: findings.Medium,
+			Details: map[string]string{"common_name": "www.owasp.org", "expires": "2023-01-01"}},
+		{Type: "dns_version_exposed", Asset: "ns1.example.com", Severity: findings.Low},
+	}
+
+	rep := New("Amass Report", []string{"owasp.org"}, graph, all, time.Now().Add(-time.Hour))
+	if rep.Summary.Names != 3 || rep.Summary.Addresses != 2 || rep.Summary.ASNs != 1 || rep.Summary.New != 5 {
+		t.Errorf("Unexpected summary: %+v", rep.Summary)
+	}
+	if len(rep.ASNs) != 1 || rep.ASNs[0].Org != "EXAMPLE-NET" || rep.ASNs[0].Addresses != 2 || rep.ASNs[0].Names != 2 {
+		t.Errorf("Unexpected ASN table: %+v", rep.ASNs)
+	}
+	if len(rep.Registrars) != 1 || rep.Registrars[0].Name != "Example Registrar" {
+		t.Errorf("Unexpected registrars: %+v", rep.Registrars)
+	}
+	// The out of scope finding and the registration details are not reported as findings
+	if len(rep.Findings) != 1 || len(rep.Certificates) != 1 || rep.Summary.Findings != 1 {
+		t.Errorf("Unexpected findings: %+v", rep.Findings)
+	}
+	if len(rep.Snippets) != 2 || !strings.Contains(rep.Snippets[0].Text, "AS64496 (ASN)") {
+		t.Errorf("Unexpected graph snippets: %+v", rep.Snippets)
+	}
+
+	tmpl, err := ParseTemplate("")
+	if err != nil {
+		t.Fatalf("Failed to parse the default template: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, rep, tmpl); err != nil {
+		t.Fatalf("Failed to render the report: %v", err)
+	}
+	for _, s := range []string{"<title>Amass Report</title>", "Example Registrar", "EXAMPLE-NET", "https://www.owasp.org:443"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("The report does not contain %s", s)
+		}
+	}
+}
+
+func TestReportIdenticalContent(t *testing.T) {
+	graph := &viz.Graph{}
+	parked := map[string]string{"body_sha256": "aa11", "parked": "true"}
+
+	var all []*findings.Finding
+	for _, name := range []string{"owasp.net", "owasp.info", "owasp.biz"} {
+		all = append(all, &findings.Finding{Type: "http_fingerprint", Asset: "https://" + name + ":443",
+			Description: "Web server fingerprint", Details: parked})
+	}
+	all = append(all, &findings.Finding{Type: "http_fingerprint", Asset: "https://www.owasp.org:443",
+		Description: "Web server fingerprint", Details: map[string]string{"body_sha256": "bb22"}})
+
+	rep := New("Amass Report", []string{"owasp.org", "owasp.net", "owasp.info", "owasp.biz"}, graph, all, time.Time{})
+	if len(rep.ContentGroups) != 1 || len(rep.ContentGroups[0].Hosts) != 3 || rep.ContentGroups[0].Label != "parked" {
+		t.Errorf("Unexpected content groups: %+v", rep.ContentGroups)
+	}
+	// The parked hosts are collapsed into a single finding
+	if len(rep.Findings) != 2 || rep.Summary.Findings != 2 {
+		t.Fatalf("Unexpected findings: %+v", rep.Findings)
+	}
+	var collapsed bool
+	for _, f := range rep.Findings {
+		if strings.Contains(f.Description, "identical content served by 3 hosts, parked") {
+			collapsed = true
+		}
+	}
+	if !collapsed {
+		t.Errorf("The collapsed finding does not describe the group: %+v", rep.Findings)
+	}
+	if all[0].Description != "Web server fingerprint" {
+		t.Error("The findings provided to the report were modified")
+	}
+}
+
+func TestRuns(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+
+	if since := LastRunStart(nil, []string{"owasp.org"}); !since.IsZero() {
+		t.Errorf("Expected the zero time without a history, got %v", since)
+	}
+	_ = RecordRun(dir, &Run{Started: start.Add(-time.Hour), Finished: start, Domains: []string{"owasp.org"}})
+	_ = RecordRun(dir, &Run{Started: start, Finished: start.Add(time.Minute), Domains: []string{"example.com"}})
+
+	runs, err := LoadRuns(dir)
+	if err != nil || len(runs) != 2 {
+		t.Fatalf("Failed to load the runs: %v", err)
+	}
+	if since := LastRunStart(runs, []string{"owasp.org"}); !since.Equal(start.Add(-time.Hour)) {
+		t.Errorf("Unexpected last run start: %v", since)
+	}
+}
+
+func TestScoreboard(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	_ = g.UpsertCNAME(ctx, "www.owasp.org", "owasp.cdn.net")
+	_ = g.UpsertA(ctx, "owasp.cdn.net", "192.0.2.1")
+	_ = g.UpsertCNAME(ctx, "old.owasp.org", "owasp.s3.amazonaws.com")
+	_ = g.UpsertA(ctx, "www.example.com", "192.0.2.2")
+
+	cfg := config.NewConfig()
+	cfg.Options["organizations"] = map[string]interface{}{
+		"OWASP":   []interface{}{"owasp.org"},
+		"Example": []interface{}{"example.com", "example.net"},
+	}
+	orgs, err := OrganizationsFromConfig(cfg)
+	if err != nil || len(orgs) != 2 || orgs[0].Name != "Example" || len(orgs[0].Domains) != 2 {
+		t.Fatalf("Unexpected organizations: %v", err)
+	}
+
+	all := []*findings.Finding{
+		{Type: TakeoverFinding, Asset: "old.owasp.org", Severity: findings.High},
+		{Type: "certificate_expired", Asset: "https://www.example.com:443", Severity: findings.High},
+	}
+
+	var scores []*Score
+	for _, org := range orgs {
+		graph, err := viz.Build(ctx, g, org.Domains, time.Time{}, time.Time{})
+		if err != nil {
+			t.Fatalf("Failed to build the graph for %s: %v", org.Name, err)
+		}
+		scores = append(scores, NewScore(org, graph, all, time.Now().Add(-DefaultPeriod)))
+	}
+
+	ex, ow := scores[0], scores[1]
+	if ex.ExpiringCerts != 1 || ex.Takeovers != 0 || ex.LiveHosts != 1 {
+		t.Errorf("Unexpected score for Example: %+v", ex)
+	}
+	// The name aliasing the CDN name is live, while the name aliasing the bucket is not
+	if ow.Takeovers != 1 || ow.HighRisk != 1 || ow.LiveHosts != 2 {
+		t.Errorf("Unexpected score for OWASP: %+v", ow)
+	}
+
+	sb := NewScoreboard("Scoreboard", scores, time.Now().Add(-DefaultPeriod))
+	if sb.Total.HighRisk != 2 || sb.Total.Subdomains != ex.Subdomains+ow.Subdomains {
+		t.Errorf("Unexpected totals: %+v", sb.Total)
+	}
+
+	tmpl, err := ParseScoreboardTemplate("")
+	if err != nil {
+		t.Fatalf("Failed to parse the default scoreboard template: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, sb, tmpl); err != nil || !strings.Contains(buf.String(), "example.com, example.net") {
+		t.Errorf("Failed to render the scoreboard: %v", err)
+	}
+}
+
+func TestCrossReference(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	_ = g.UpsertA(ctx, "www.owasp.org", "192.0.2.1")
+	_ = g.UpsertA(ctx, "shop.example.com", "192.0.2.1")
+	_ = g.UpsertA(ctx, "mail.example.com", "192.0.2.2")
+	_ = g.UpsertNS(ctx, "owasp.org", "ns1.dnshost.net")
+	_ = g.UpsertNS(ctx, "example.com", "ns1.dnshost.net")
+	_ = g.UpsertNS(ctx, "example.com", "ns2.example.com")
+
+	orgs := []*Organization{
+		{Name: "Example", Domains: []string{"example.com"}},
+		{Name: "OWASP", Domains: []string{"owasp.org"}},
+	}
+	graphs := make(map[string]*viz.Graph)
+	for _, org := range orgs {
+		graph, err := viz.Build(ctx, g, org.Domains, time.Time{}, time.Time{})
+		if err != nil {
+			t.Fatalf("Failed to build the graph for %s: %v", org.Name, err)
+		}
+		graphs[org.Name] = graph
+	}
+
+	all := []*findings.Finding{
+		{Type: "http_fingerprint", Asset: "https://www.owasp.org:443", Details: map[string]string{CertificateDetail: "AB12"}},
+		{Type: "http_fingerprint", Asset: "https://shop.example.com:443", Details: map[string]string{CertificateDetail: "ab12"}},
+		{Type: "http_fingerprint", Asset: "https://mail.example.com:443", Details: map[string]string{CertificateDetail: "cd34"}},
+	}
+
+	cr := NewCrossReference("Cross Reference", orgs, graphs, all)
+	shared := make(map[string]*SharedAsset)
+	for _, s := range cr.Shared {
+		shared[s.Kind+" "+s.Asset] = s
+	}
+	if len(shared) != 3 {
+		t.Errorf("Unexpected shared assets: %v", shared)
+	}
+	for _, key := range []string{"address 192.0.2.1", "nameserver ns1.dnshost.net", "certificate ab12"} {
+		s, found := shared[key]
+		if !found || len(s.Users) != 2 || s.Users[0].Organization != "Example" || s.Users[1].Organization != "OWASP" {
+			t.Errorf("The %s was not shared by both organizations: %+v", key, s)
+		}
+	}
+	if s := shared["address 192.0.2.1"]; s != nil && (len(s.Users[0].Names) != 1 || s.Users[0].Names[0] != "shop.example.com") {
+		t.Errorf("Unexpected names leading to the shared address: %+v", s.Users[0])
+	}
+
+	tmpl, err := ParseCrossReferenceTemplate("")
+	if err != nil {
+		t.Fatalf("Failed to parse the default cross reference template: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, cr, tmpl); err != nil || !strings.Contains(buf.String(), "ns1.dnshost.net") {
+		t.Errorf("Failed to render the cross reference: %v", err)
+	}
+}
+
+func TestOrganizationsFromConfig(t *testing.T) {
+	cfg := config.NewConfig()
+
+	if orgs, err := OrganizationsFromConfig(cfg); err != nil || orgs != nil {
+		t.Errorf("Expected no organizations without the section: %v", err)
+	}
+	cfg.Options["organizations"] = map[string]interface{}{"OWASP": "owasp.org"}
+	if _, err := OrganizationsFromConfig(cfg); err == nil {
+		t.Error("Accepted organizations without a list of domain names")
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package report
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RunsName is the file within the output directory listing the completed enumerations, one JSON entry per line.
+const RunsName = "runs.jsonl"
+
+// Run describes an enumeration that completed using the output directory.
+type Run struct {
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	Domains  []string  `json:"domains"`
+}
+
+// RecordRun appends the enumeration to the run history kept in the output directory.
+func RecordRun(dir string, run *Run) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, RunsName), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// LoadRuns returns the enumerations recorded in the output directory, ordered by start time.
+func LoadRuns(dir string) ([]*Run, error) {
+	f, err := os.Open(filepath.Join(dir, RunsName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var runs []*Run
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var run Run
+		if err := json.Unmarshal([]byte(line), &run); err == nil {
+			runs = append(runs, &run)
+		}
+	}
+
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Started.Before(runs[j].Started) })
+	return runs, scanner.Err()
+}
+
+// LastRunStart returns the start time of the latest enumeration that included any of the domains.
+// The zero time is returned when the domains have not been enumerated before.
+func LastRunStart(runs []*Run, domains []string) time.Time {
+	for i := len(runs) - 1; i >= 0; i-- {
+		for _, d := range runs[i].Domains {
+			if containsDomain(domains, d) {
+				return runs[i].Started
+			}
+		}
+	}
+	return time.Time{}
+}
+
+func containsDomain(domains []string, name string) bool {
+	for _, d := range domains {
+		if strings.EqualFold(d, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package report
+
+import (
+	"time"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/viz"
+)
+
+// DefaultPeriod is the reporting period of the scoreboard when the start time is not provided.
+const DefaultPeriod = 30 * 24 * time.Hour
+
+// Score provides the metrics of an organization across all of its root domain names.
+type Score struct {
+	Organization  string
+	Domains       []string
+	Subdomains    int
+	LiveHosts     int
+	ASNs          int
+	ExpiringCerts int
+	Takeovers     int
+	NewAssets     int
+	Findings      int
+	// HighRisk is the number of findings with high or critical severity
+	HighRisk int
+}
+
+// Scoreboard is the data provided to the template rendering the organization scores.
+type Scoreboard struct {
+	Title     string
+	Generated time.Time
+	Since     time.Time
+	Scores    []*Score
+	// Total sums the scores, so assets shared by organizations are counted once for each of them
+	Total Score
+}
+
+// NewScore returns the metrics of the organization from the graph built for its domains and the findings.
+// Assets first seen at or after since are counted as new.
+func NewScore(org *Organization, g *viz.Graph, all []*findings.Finding, since time.Time) *Score {
+	rep := New(org.Name, org.Domains, g, all, since)
+
+	score := &Score{
+		Organization:  org.Name,
+		Domains:       org.Domains,
+		Subdomains:    rep.Summary.Names,
+		LiveHosts:     rep.Summary.Live,
+		ASNs:          rep.Summary.ASNs,
+		ExpiringCerts: len(rep.Certificates),
+		Takeovers:     rep.Summary.Takeovers,
+		NewAssets:     rep.Summary.New,
+		Findings:      rep.Summary.Findings,
+	}
+	for _, f := range rep.Findings {
+		if f.Severity >= findings.High {
+			score.HighRisk++
+		}
+	}
+	return score
+}
+
+// NewScoreboard returns the scoreboard of the organization scores for the period starting at since.
+func NewScoreboard(title string, scores []*Score, since time.Time) *Scoreboard {
+	sb := &Scoreboard{
+		Title:     title,
+		Generated: time.Now().UTC(),
+		Since:     since,
+		Scores:    scores,
+	}
+
+	sb.Total.Organization = "Total"
+	for _, s := range scores {
+		sb.Total.Domains = append(sb.Total.Domains, s.Domains...)
+		sb.Total.Subdomains += s.Subdomains
+		sb.Total.LiveHosts += s.LiveHosts
+		sb.Total.ASNs += s.ASNs
+		sb.Total.ExpiringCerts += s.ExpiringCerts
+		sb.Total.Takeovers += s.Takeovers
+		sb.Total.NewAssets += s.NewAssets
+		sb.Total.Findings += s.Findings
+		sb.Total.HighRisk += s.HighRisk
+	}
+	return sb
+}
@@ -0,0 +1,83 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"time"
+)
+
+// DefaultTemplate is the template used when a custom template is not provided.
+//
+//go:embed report.html
+var DefaultTemplate string
+
+// DefaultScoreboardTemplate is the template of the scoreboard used when a custom template is not provided.
+//
+//go:embed scoreboard.html
+var DefaultScoreboardTemplate string
+
+// DefaultCrossReferenceTemplate is the template of the shared infrastructure used when a custom template is not provided.
+//
+//go:embed crossref.html
+var DefaultCrossReferenceTemplate string
+
+// The functions available to the templates, in addition to the builtin functions.
+var templateFuncs = template.FuncMap{
+	"date": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format("2006-01-02 15:04 MST")
+	},
+	"day": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format("2006-01-02")
+	},
+}
+
+// ParseTemplate returns the template read from the file at path, or the default template when path is empty.
+func ParseTemplate(path string) (*template.Template, error) {
+	return parseTemplate(path, DefaultTemplate)
+}
+
+// ParseScoreboardTemplate returns the template read from the file at path, or the default
+// scoreboard template when path is empty.
+func ParseScoreboardTemplate(path string) (*template.Template, error) {
+	return parseTemplate(path, DefaultScoreboardTemplate)
+}
+
+// ParseCrossReferenceTemplate returns the template read from the file at path, or the default
+// template of the shared infrastructure when path is empty.
+func ParseCrossReferenceTemplate(path string) (*template.Template, error) {
+	return parseTemplate(path, DefaultCrossReferenceTemplate)
+}
+
+func parseTemplate(path, text string) (*template.Template, error) {
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the report template: %v", err)
+		}
+		text = string(data)
+	}
+
+	t, err := template.New("report").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the report template: %v", err)
+	}
+	return t, nil
+}
+
+// Write renders the report, scoreboard or cross reference to w using the template.
+func Write(w io.Writer, data interface{}, t *template.Template) error {
+	return t.Execute(w, data)
+}
@@ -0,0 +1,139 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package requests
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ErrorCategory classifies the failures of the data sources, so the failures needing the attention
+// of the user, such as a rejected API key, can be told apart from the transient ones.
+type ErrorCategory string
+
+// The categories of the data source failures.
+const (
+	// NetworkError is a service that could not be reached or failed to respond
+	NetworkError ErrorCategory = "network"
+	// AuthError is a service rejecting the credentials of the data source
+	AuthError ErrorCategory = "auth"
+	// RateLimitedError is a service refusing requests beyond the rate limit or quota
+	RateLimitedError ErrorCategory = "rate_limited"
+	// ParseError is a response that could not be decoded
+	ParseError ErrorCategory = "parse"
+	// ScopeRejectedError is a name provided by the data source that is out of scope
+	ScopeRejectedError ErrorCategory = "scope_rejected"
+)
+
+// ErrorCategories are the categories of the data source failures in the order they are reported.
+var ErrorCategories = []ErrorCategory{AuthError, RateLimitedError, NetworkError, ParseError, ScopeRejectedError}
+
+// ParseErrorCategory returns the category with the name, which is matched without regard to case.
+func ParseErrorCategory(name string) (ErrorCategory, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	for _, c := range ErrorCategories {
+		if string(c) == name {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("%q is not an error category", name)
+}
+
+// HTTPErrorCategory returns the category of the failure indicated by the HTTP status code, or
+// an empty category when the status code does not indicate a failure of the data source.
+func HTTPErrorCategory(code int) ErrorCategory {
+	switch {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return AuthError
+	case code == http.StatusTooManyRequests || code == http.StatusPaymentRequired:
+		return RateLimitedError
+	case code >= 500:
+		return NetworkError
+	}
+	return ""
+}
+
+// ErrorCount is the number of failures of a data source within a category, along with the
+// message of the most recent one.
+type ErrorCount struct {
+	Category ErrorCategory `json:"category"`
+	Count    int           `json:"count"`
+	Last     string        `json:"last,omitempty"`
+}
+
+// SourceErrors is the breakdown of the failures of a data source by category.
+type SourceErrors struct {
+	Source string        `json:"source"`
+	Errors []*ErrorCount `json:"errors"`
+}
+
+// Total returns the number of failures of the data source.
+func (s *SourceErrors) Total() int {
+	var total int
+
+	for _, e := range s.Errors {
+		total += e.Count
+	}
+	return total
+}
+
+// SortErrorCounts orders the counts by the position of their categories in ErrorCategories.
+func SortErrorCounts(counts []*ErrorCount) {
+	pos := make(map[ErrorCategory]int, len(ErrorCategories))
+	for i, c := range ErrorCategories {
+		pos[c] = i
+	}
+
+	sort.SliceStable(counts, func(i, j int) bool {
+		return pos[counts[i].Category] < pos[counts[j].Category]
+	})
+}
+
+// MergeSourceErrors combines the breakdowns, such as those of the enumerations executed by
+// separate workers, and returns them sorted by the data source names. The message of the
+// breakdown provided last is kept for each category.
+func MergeSourceErrors(lists ...[]*SourceErrors) []*SourceErrors {
+	merged := make(map[string]map[ErrorCategory]*ErrorCount)
+
+	for _, list := range lists {
+		for _, se := range list {
+			counts, found := merged[se.Source]
+			if !found {
+				counts = make(map[ErrorCategory]*ErrorCount)
+				merged[se.Source] = counts
+			}
+
+			for _, e := range se.Errors {
+				ec, found := counts[e.Category]
+				if !found {
+					ec = &ErrorCount{Category: e.Category}
+					counts[e.Category] = ec
+				}
+				ec.Count += e.Count
+				if e.Last != "" {
+					ec.Last = e.Last
+				}
+			}
+		}
+	}
+
+	results := make([]*SourceErrors, 0, len(merged))
+	for src, counts := range merged {
+		se := &SourceErrors{Source: src}
+		for _, ec := range counts {
+			se.Errors = append(se.Errors, ec)
+		}
+		SortErrorCounts(se.Errors)
+		results = append(results, se)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Source < results[j].Source
+	})
+	return results
+}
@@ -0,0 +1,52 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package requests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorCategories(t *testing.T) {
+	c, err := ParseErrorCategory(" Rate_Limited ")
+	require.NoError(t, err)
+	require.Equal(t, RateLimitedError, c)
+
+	_, err = ParseErrorCategory("timeout")
+	require.Error(t, err)
+
+	for code, expected := range map[int]ErrorCategory{
+		200: "",
+		404: "",
+		401: AuthError,
+		403: AuthError,
+		402: RateLimitedError,
+		429: RateLimitedError,
+		503: NetworkError,
+	} {
+		require.Equal(t, expected, HTTPErrorCategory(code), "status code %d", code)
+	}
+}
+
+func TestMergeSourceErrors(t *testing.T) {
+	merged := MergeSourceErrors([]*SourceErrors{
+		{Source: "SecurityTrails", Errors: []*ErrorCount{{Category: NetworkError, Count: 2, Last: "timeout"}}},
+	}, []*SourceErrors{
+		{Source: "SecurityTrails", Errors: []*ErrorCount{
+			{Category: NetworkError, Count: 1, Last: "connection refused"},
+			{Category: AuthError, Count: 3, Last: "status code 401"},
+		}},
+		{Source: "Crtsh", Errors: []*ErrorCount{{Category: ParseError, Count: 1}}},
+	})
+
+	require.Len(t, merged, 2)
+	require.Equal(t, "Crtsh", merged[0].Source)
+	st := merged[1]
+	require.Equal(t, 6, st.Total())
+	require.Len(t, st.Errors, 2)
+	require.Equal(t, &ErrorCount{Category: AuthError, Count: 3, Last: "status code 401"}, st.Errors[0])
+	require.Equal(t, &ErrorCount{Category: NetworkError, Count: 3, Last: "connection refused"}, st.Errors[1])
+}
@@ -6,6 +6,7 @@ package requests
 
 import (
 	"net"
+	"net/url"
 	"strings"
 	"time"
 
@@ -40,6 +41,8 @@ type DNSRequest struct {
 	Name    string
 	Domain  string
 	Records []DNSAnswer
+	// The data source that provided the name, if any
+	Source string
 }
 
 // Clone implements pipeline Data.
@@ -48,6 +51,7 @@ func (d *DNSRequest) Clone() pipeline.Data {
 		Name:    d.Name,
 		Domain:  d.Domain,
 		Records: append([]DNSAnswer(nil), d.Records...),
+		Source:  d.Source,
 	}
 }
 
@@ -235,6 +239,137 @@ func (a *ASNRequest) Valid() bool {
 	return true
 }
 
+// RoutingRequest provides the prefixes announced by an autonomous system, as observed in BGP routing data.
+type RoutingRequest struct {
+	ASN         int
+	Description string
+	Prefixes    []string
+	Source      string
+}
+
+// Clone implements pipeline Data.
+func (r *RoutingRequest) Clone() pipeline.Data {
+	return &RoutingRequest{
+		ASN:         r.ASN,
+		Description: r.Description,
+		Prefixes:    append([]string(nil), r.Prefixes...),
+		Source:      r.Source,
+	}
+}
+
+// MarkAsProcessed implements pipeline Data.
+func (r *RoutingRequest) MarkAsProcessed() {}
+
+// Valid performs input validation of the receiver.
+func (r *RoutingRequest) Valid() bool {
+	if r.ASN <= 0 || len(r.Prefixes) == 0 {
+		return false
+	}
+	for _, prefix := range r.Prefixes {
+		if _, _, err := net.ParseCIDR(prefix); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// RegistrantRequest provides the contact that registered a domain, so reverse WHOIS
+// data sources can find the other domains registered by the same contact.
+type RegistrantRequest struct {
+	Domain       string
+	Email        string
+	Organization string
+	Source       string
+}
+
+// Clone implements pipeline Data.
+func (r *RegistrantRequest) Clone() pipeline.Data {
+	return &RegistrantRequest{
+		Domain:       r.Domain,
+		Email:        r.Email,
+		Organization: r.Organization,
+		Source:       r.Source,
+	}
+}
+
+// MarkAsProcessed implements pipeline Data.
+func (r *RegistrantRequest) MarkAsProcessed() {}
+
+// Valid performs input validation of the receiver.
+func (r *RegistrantRequest) Valid() bool {
+	if r.Domain == "" || (r.Email == "" && r.Organization == "") {
+		return false
+	}
+	if r.Email != "" && !strings.Contains(r.Email, "@") {
+		return false
+	}
+	return true
+}
+
+// OrganizationRequest provides the organization that registered a target domain, so the registrar,
+// RDAP and reverse WHOIS data sources can find the other domains registered to the same organization,
+// and the ASN data sources can find the autonomous systems attributed to it. The Domain is empty when
+// the organization was not discovered through a target domain. When Done is not nil, the data source
+// closes it once the request has been handled, so the caller knows the search is complete.
+type OrganizationRequest struct {
+	Name   string
+	Domain string
+	Source string
+	Done   chan struct{}
+}
+
+// Clone implements pipeline Data.
+func (r *OrganizationRequest) Clone() pipeline.Data {
+	return &OrganizationRequest{
+		Name:   r.Name,
+		Domain: r.Domain,
+		Source: r.Source,
+	}
+}
+
+// MarkAsProcessed implements pipeline Data.
+func (r *OrganizationRequest) MarkAsProcessed() {}
+
+// Finished closes the Done channel of the request, when it was provided.
+func (r *OrganizationRequest) Finished() {
+	if r != nil && r.Done != nil {
+		close(r.Done)
+	}
+}
+
+// Valid performs input validation of the receiver.
+func (r *OrganizationRequest) Valid() bool {
+	return strings.TrimSpace(r.Name) != ""
+}
+
+// URLRequest provides a URL discovered by a data source, such as a link found by a crawler or in a web archive.
+type URLRequest struct {
+	URL    string
+	Domain string
+	Source string
+}
+
+// Clone implements pipeline Data.
+func (u *URLRequest) Clone() pipeline.Data {
+	return &URLRequest{
+		URL:    u.URL,
+		Domain: u.Domain,
+		Source: u.Source,
+	}
+}
+
+// MarkAsProcessed implements pipeline Data.
+func (u *URLRequest) MarkAsProcessed() {}
+
+// Valid performs input validation of the receiver.
+func (u *URLRequest) Valid() bool {
+	parsed, err := url.Parse(u.URL)
+	if err != nil || parsed.Hostname() == "" {
+		return false
+	}
+	return parsed.Scheme == "http" || parsed.Scheme == "https"
+}
+
 // WhoisRequest handles data needed throughout Service processing of reverse whois.
 type WhoisRequest struct {
 	Domain     string
@@ -0,0 +1,51 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// FromConfig returns the recurrence and whether an enumeration is launched immediately, using the
+// settings in the 'schedule' section of the configuration options. A nil Recurrence is returned
+// when the enumerations have not been scheduled.
+func FromConfig(cfg *config.Config) (Recurrence, bool, error) {
+	scheduleRaw, ok := cfg.Options["schedule"]
+	if !ok {
+		return nil, true, nil
+	}
+
+	settings, ok := scheduleRaw.(map[string]interface{})
+	if !ok {
+		return nil, true, fmt.Errorf("schedule is not a map[string]interface{}")
+	}
+
+	onStart := true
+	if raw, ok := settings["run_on_start"]; ok {
+		b, ok := raw.(bool)
+		if !ok {
+			return nil, true, fmt.Errorf("schedule run_on_start is not a bool")
+		}
+		onStart = b
+	}
+
+	raw, ok := settings["recurrence"]
+	if !ok {
+		return nil, onStart, nil
+	}
+
+	expr, ok := raw.(string)
+	if !ok {
+		return nil, onStart, fmt.Errorf("schedule recurrence is not a string")
+	}
+
+	r, err := ParseRecurrence(expr)
+	if err != nil {
+		return nil, onStart, fmt.Errorf("schedule recurrence: %v", err)
+	}
+	return r, onStart, nil
+}
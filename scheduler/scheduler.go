@@ -0,0 +1,251 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package scheduler launches recurring enumerations and reports the names discovered since the previous runs.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/amass/v4/datasrcs"
+	"github.com/owasp-amass/amass/v4/enum"
+	"github.com/owasp-amass/amass/v4/notify"
+	"github.com/owasp-amass/amass/v4/report"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+)
+
+// Enumerator executes a single enumeration and returns the names within the scope
+// stored in the graph database before the enumeration started and once it finished.
+type Enumerator func(ctx context.Context, cfg *config.Config) (before, after []string, err error)
+
+// Delta describes the names discovered by a scheduled enumeration that were not previously known.
+type Delta struct {
+	Run      int       `json:"run"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	Domains  []string  `json:"domains"`
+	NewNames []string  `json:"new_names"`
+	Total    int       `json:"total"`
+	// Baseline is true when the graph database held no names before the enumeration,
+	// so the names discovered are not reported as changes to the attack surface
+	Baseline bool `json:"baseline"`
+}
+
+// Scheduler launches the enumerations of the configuration at the times provided by the recurrence.
+type Scheduler struct {
+	Config     *config.Config
+	Recurrence Recurrence
+	Enumerate  Enumerator
+	Notifier   *notify.Dispatcher
+	// RunOnStart launches an enumeration immediately instead of waiting for the first activation time
+	RunOnStart bool
+}
+
+// NewScheduler returns a Scheduler executing the enumerations within the process.
+func NewScheduler(cfg *config.Config, r Recurrence, n *notify.Dispatcher) *Scheduler {
+	return &Scheduler{
+		Config:     cfg,
+		Recurrence: r,
+		Enumerate:  Enumerate,
+		Notifier:   n,
+		RunOnStart: true,
+	}
+}
+
+// Run launches the enumerations until the context is cancelled, providing the delta or the error
+// of each run to the callback. Activation times missed while an enumeration is running are skipped.
+func (s *Scheduler) Run(ctx context.Context, callback func(*Delta, error)) error {
+	if s.Recurrence == nil || s.Enumerate == nil {
+		return errors.New("the scheduler requires a recurrence and an enumerator")
+	}
+
+	next := time.Now()
+	if !s.RunOnStart {
+		next = s.Recurrence.Next(next)
+	}
+
+	for run := 1; ; run++ {
+		if next.IsZero() {
+			return errors.New("the recurrence provides no further activation times")
+		}
+
+		t := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil
+		case <-t.C:
+		}
+
+		d, err := s.execute(ctx, run)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if callback != nil {
+			callback(d, err)
+		}
+		next = s.Recurrence.Next(time.Now())
+	}
+}
+
+func (s *Scheduler) execute(ctx context.Context, run int) (*Delta, error) {
+	d := &Delta{
+		Run:     run,
+		Started: time.Now(),
+		Domains: s.Config.Domains(),
+	}
+
+	before, after, err := s.Enumerate(ctx, s.Config)
+	d.Finished = time.Now()
+	if err != nil {
+		return d, err
+	}
+
+	d.NewNames = difference(after, before)
+	d.Total = len(after)
+	d.Baseline = len(before) == 0
+	if !d.Baseline && len(d.NewNames) > 0 {
+		err = s.Notifier.Notify(ctx, &notify.Message{
+			Kind:    notify.NewNamesMessage,
+			Title:   fmt.Sprintf("The scheduled enumeration of %s discovered %d new names", joinDomains(d.Domains), len(d.NewNames)),
+			Domains: d.Domains,
+			Names:   d.NewNames,
+			Time:    d.Finished,
+		})
+		if err != nil {
+			return d, fmt.Errorf("failed to deliver the notification: %v", err)
+		}
+	}
+	// Digests become due between the runs that discovered new names
+	if err := s.Notifier.Flush(ctx); err != nil {
+		return d, fmt.Errorf("failed to deliver the digest: %v", err)
+	}
+	return d, nil
+}
+
+// Result describes an enumeration executed within the process.
+type Result struct {
+	// Before and After are the names within the domains stored in the graph database
+	Before []string
+	After  []string
+	// Errors are the failures of the data sources by category
+	Errors []*requests.SourceErrors
+	// Completion describes how the enumeration came to an end
+	Completion *enum.Completion
+}
+
+// Enumerate executes an enumeration within the process using the settings of the configuration.
+func Enumerate(ctx context.Context, cfg *config.Config) ([]string, []string, error) {
+	r, err := Execute(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return r.Before, r.After, nil
+}
+
+// Execute executes an enumeration within the process using the settings of the configuration,
+// and returns the names before and after the enumeration along with the data source failures.
+// The setup functions are executed with the enumeration before it is started.
+func Execute(ctx context.Context, cfg *config.Config, setup ...func(*enum.Enumeration)) (*Result, error) {
+	sys, err := systems.NewLocalSystem(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	if err := sys.SetDataSources(datasrcs.GetAllSources(sys)); err != nil {
+		return nil, err
+	}
+
+	g := sys.GraphDatabases()[0]
+	before := Names(g, cfg.Domains())
+
+	e := enum.NewEnumeration(cfg, sys, g)
+	if e == nil {
+		return nil, errors.New("failed to setup the enumeration")
+	}
+
+	var completion *enum.Completion
+	e.OnComplete(func(c *enum.Completion) { completion = c })
+	for _, fn := range setup {
+		fn(e)
+	}
+	if err := e.Start(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := report.RecordRun(config.OutputDirectory(cfg.Dir), &report.Run{
+		Started:  cfg.CollectionStartTime.UTC(),
+		Finished: time.Now().UTC(),
+		Domains:  cfg.Domains(),
+	}); err != nil {
+		cfg.Log.Printf("Failed to record the enumeration history: %v", err)
+	}
+	return &Result{
+		Before:     before,
+		After:      Names(g, cfg.Domains()),
+		Errors:     e.SourceErrors(),
+		Completion: completion,
+	}, nil
+}
+
+// Names returns the sorted names within the domains that are stored in the graph database.
+func Names(g *netmap.Graph, domains []string) []string {
+	set := make(map[string]struct{})
+
+	for _, d := range domains {
+		assets, err := g.DB.FindByScope([]oam.Asset{domain.FQDN{Name: d}}, time.Time{})
+		if err != nil {
+			continue
+		}
+
+		for _, a := range assets {
+			if fqdn, ok := a.Asset.(domain.FQDN); ok {
+				set[fqdn.Name] = struct{}{}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// difference returns the strings in a that are not in b.
+func difference(a, b []string) []string {
+	set := make(map[string]struct{}, len(b))
+	for _, s := range b {
+		set[s] = struct{}{}
+	}
+
+	var results []string
+	for _, s := range a {
+		if _, found := set[s]; !found {
+			results = append(results, s)
+		}
+	}
+	return results
+}
+
+func joinDomains(domains []string) string {
+	switch len(domains) {
+	case 0:
+		return "the scope"
+	case 1:
+		return domains[0]
+	}
+	return fmt.Sprintf("%s and %d other domains", domains[0], len(domains)-1)
+}
@@ -0,0 +1,177 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/notify"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	start := time.Date(2023, time.March, 15, 10, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"@every 6h", start.Add(6 * time.Hour)},
+		{"@hourly", time.Date(2023, time.March, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2023, time.March, 16, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2023, time.March, 19, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2023, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2023, time.March, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2023, time.March, 15, 13, 0, 0, 0, time.UTC)},
+		{"30 2 * * 1,5", time.Date(2023, time.March, 17, 2, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2023, time.March, 19, 0, 0, 0, 0, time.UTC)},
+		// Either day field matches when both have been restricted
+		{"0 0 20 * 5", time.Date(2023, time.March, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, c := range cases {
+		rec, err := ParseRecurrence(c.expr)
+		if err != nil {
+			t.Errorf("%s: ParseRecurrence returned an error: %v", c.expr, err)
+			continue
+		}
+		if next := rec.Next(start); !next.Equal(c.expected) {
+			t.Errorf("%s: Next returned %v, expected %v", c.expr, next, c.expected)
+		}
+	}
+
+	rec, _ := ParseRecurrence("0 0 30 2 *")
+	if next := rec.Next(start); !next.IsZero() {
+		t.Errorf("Next returned %v for a date that never occurs", next)
+	}
+
+	for _, expr := range []string{"", "@every 10s", "@every soon", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := ParseRecurrence(expr); err == nil {
+			t.Errorf("ParseRecurrence accepted the invalid expression %q", expr)
+		}
+	}
+}
+
+type recorder struct {
+	messages []*notify.Message
+}
+
+func (r *recorder) Notify(ctx context.Context, m *notify.Message) error {
+	r.messages = append(r.messages, m)
+	return nil
+}
+
+func (r *recorder) String() string { return "recorder" }
+
+func TestSchedulerDeltas(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+
+	rec := &recorder{}
+	s := NewScheduler(cfg, interval(time.Minute), notify.NewDispatcher(rec))
+
+	runs := []struct {
+		before, after []string
+		err           error
+	}{
+		{nil, []string{"a.owasp.org", "owasp.org"}, nil},
+		{[]string{"a.owasp.org", "owasp.org"}, []string{"a.owasp.org", "owasp.org"}, nil},
+		{[]string{"a.owasp.org", "owasp.org"}, nil, errors.New("failed")},
+		{[]string{"a.owasp.org", "owasp.org"}, []string{"a.owasp.org", "b.owasp.org", "owasp.org"}, nil},
+	}
+	var i int
+	s.Enumerate = func(ctx context.Context, cfg *config.Config) ([]string, []string, error) {
+		run := runs[i]
+		i++
+		return run.before, run.after, run.err
+	}
+
+	var deltas []*Delta
+	for run := 1; run <= len(runs); run++ {
+		d, err := s.execute(context.Background(), run)
+		if (err != nil) != (runs[run-1].err != nil) {
+			t.Errorf("run %d returned the error %v", run, err)
+		}
+		deltas = append(deltas, d)
+	}
+
+	if !deltas[0].Baseline || deltas[0].Total != 2 {
+		t.Errorf("the first run did not establish the baseline: %+v", deltas[0])
+	}
+	if len(deltas[1].NewNames) != 0 {
+		t.Errorf("the second run discovered %v", deltas[1].NewNames)
+	}
+	if !reflect.DeepEqual(deltas[3].NewNames, []string{"b.owasp.org"}) || deltas[3].Baseline {
+		t.Errorf("the fourth run discovered %v", deltas[3].NewNames)
+	}
+	// Only the fourth run delivered a notification
+	if len(rec.messages) != 1 {
+		t.Fatalf("%d notifications were delivered, expected 1", len(rec.messages))
+	}
+	if m := rec.messages[0]; m.Kind != notify.NewNamesMessage || !reflect.DeepEqual(m.Names, []string{"b.owasp.org"}) {
+		t.Errorf("the notification was %+v", m)
+	}
+}
+
+func TestSchedulerRun(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var runs int
+	s := NewScheduler(cfg, interval(time.Minute), nil)
+	s.Enumerate = func(ctx context.Context, cfg *config.Config) ([]string, []string, error) {
+		return nil, []string{"owasp.org"}, nil
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Run(ctx, func(d *Delta, err error) {
+			runs++
+			cancel()
+		})
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run returned an error: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Run did not return after the context was cancelled")
+	}
+	if runs != 1 {
+		t.Errorf("Run executed %d enumerations, expected 1", runs)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	if rec, onStart, err := FromConfig(cfg); rec != nil || !onStart || err != nil {
+		t.Errorf("FromConfig returned %v, %v, %v without the schedule section", rec, onStart, err)
+	}
+
+	cfg.Options["schedule"] = map[string]interface{}{
+		"recurrence":   "@daily",
+		"run_on_start": false,
+	}
+	rec, onStart, err := FromConfig(cfg)
+	if err != nil || rec == nil || onStart {
+		t.Errorf("FromConfig returned %v, %v, %v", rec, onStart, err)
+	}
+
+	cfg.Options["schedule"] = map[string]interface{}{"recurrence": "every day"}
+	if _, _, err := FromConfig(cfg); err == nil {
+		t.Errorf("FromConfig accepted an invalid recurrence")
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package schema detects the version of the asset-db schema used by a graph database, and upgrades
+// the databases created by earlier builds before they are opened by netmap.
+package schema
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/glebarez/sqlite"
+	pgmigrations "github.com/owasp-amass/asset-db/migrations/postgres"
+	sqlitemigrations "github.com/owasp-amass/asset-db/migrations/sqlite3"
+	migrate "github.com/rubenv/sql-migrate"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The table used by sql-migrate to record the migrations applied to the database.
+const migrationsTable = "gorp_migrations"
+
+// ErrNewerSchema is returned when the database was migrated by a build newer than this one.
+var ErrNewerSchema = errors.New("the graph database schema is newer than this build supports")
+
+// Status describes the schema version of a graph database.
+type Status struct {
+	System string
+	// Applied are the IDs of the migrations recorded in the database
+	Applied []string
+	// Pending are the IDs of the migrations known by this build and not yet applied
+	Pending []string
+	// Unknown are the IDs of the applied migrations that are not known by this build
+	Unknown []string
+	// Unversioned is true when the database has the asset-db tables without the migration records
+	Unversioned bool
+}
+
+// Version returns the ID of the last migration applied to the database.
+func (s *Status) Version() string {
+	if len(s.Applied) == 0 {
+		return ""
+	}
+	return s.Applied[len(s.Applied)-1]
+}
+
+// Current returns true when the database does not require an upgrade.
+func (s *Status) Current() bool {
+	return !s.Unversioned && len(s.Pending) == 0 && len(s.Unknown) == 0
+}
+
+// probe reports whether the changes made by a migration are present in an unversioned database.
+type probe func(m gorm.Migrator) bool
+
+func hasTables(tables ...string) probe {
+	return func(m gorm.Migrator) bool {
+		for _, t := range tables {
+			if !m.HasTable(t) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func hasColumn(table, column string) probe {
+	return func(m gorm.Migrator) bool { return m.HasColumn(table, column) }
+}
+
+func hasIndex(table, index string) probe {
+	return func(m gorm.Migrator) bool { return m.HasIndex(table, index) }
+}
+
+func hasColumnType(table, column, dbtype string) probe {
+	return func(m gorm.Migrator) bool {
+		types, err := m.ColumnTypes(table)
+		if err != nil {
+			return false
+		}
+		for _, t := range types {
+			if t.Name() == column {
+				return strings.EqualFold(t.DatabaseTypeName(), dbtype)
+			}
+		}
+		return false
+	}
+}
+
+// database provides the asset-db migrations and probes for a graph database system.
+type database struct {
+	dialect    string
+	migrations embed.FS
+	open       func(dsn string) gorm.Dialector
+	probes     map[string]probe
+}
+
+var databases = map[string]*database{
+	"local": {
+		dialect:    "sqlite3",
+		migrations: sqlitemigrations.Migrations(),
+		open:       sqlite.Open,
+		probes: map[string]probe{
+			"001_schema_init.sql":         hasTables("assets", "relations"),
+			"002_add_last_seen.sql":       hasColumn("assets", "last_seen"),
+			"003_relations_last_seen.sql": hasColumn("relations", "last_seen"),
+			"004_assets_indexes.sql":      hasIndex("assets", "idx_assets_type"),
+			"005_relations_indexes.sql":   hasIndex("relations", "idx_rel_created_at"),
+		},
+	},
+	"postgres": {
+		dialect:    "postgres",
+		migrations: pgmigrations.Migrations(),
+		open:       postgres.Open,
+		probes: map[string]probe{
+			"001_schema_init.sql":            hasTables("assets", "relations"),
+			"002_add_last_seen.sql":          hasColumn("assets", "last_seen"),
+			"003_relations_last_seen.sql":    hasColumn("relations", "last_seen"),
+			"004_timestamp_without_zone.sql": hasColumnType("assets", "created_at", "TIMESTAMP"),
+			"005_assets_indexes.sql":         hasIndex("assets", "idx_assets_type_hash"),
+			"006_relations_indexes.sql":      hasIndex("relations", "idx_rel_created_at"),
+		},
+	},
+}
+
+// Versioned returns true when the schema of the graph database system is managed by migrations.
+func Versioned(system string) bool {
+	_, found := databases[system]
+	return found
+}
+
+// conn is an open graph database and the migrations known by this build.
+type conn struct {
+	db     *gorm.DB
+	dbinfo *database
+	source migrate.MigrationSource
+}
+
+func open(system, dsn string) (*conn, error) {
+	dbinfo, found := databases[system]
+	if !found {
+		return nil, fmt.Errorf("the %s graph database system does not have a versioned schema", system)
+	}
+
+	db, err := gorm.Open(dbinfo.open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the %s graph database: %v", system, err)
+	}
+
+	return &conn{
+		db:     db,
+		dbinfo: dbinfo,
+		source: migrate.EmbedFileSystemMigrationSource{FileSystem: dbinfo.migrations, Root: "/"},
+	}, nil
+}
+
+func (c *conn) close() {
+	if sqlDB, err := c.db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
+
+// Check returns the schema version of the graph database without modifying it.
+func Check(system, dsn string) (*Status, error) {
+	c, err := open(system, dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer c.close()
+
+	return c.status(system)
+}
+
+func (c *conn) status(system string) (*Status, error) {
+	known, err := c.source.FindMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the %s migrations: %v", system, err)
+	}
+
+	status := &Status{System: system}
+	applied := make(map[string]bool)
+	if c.db.Migrator().HasTable(migrationsTable) {
+		var ids []string
+		if err := c.db.Table(migrationsTable).Order("id").Pluck("id", &ids).Error; err != nil {
+			return nil, fmt.Errorf("failed to read the %s migration records: %v", system, err)
+		}
+		for _, id := range ids {
+			applied[id] = true
+			status.Applied = append(status.Applied, id)
+		}
+	} else if c.db.Migrator().HasTable("assets") {
+		status.Unversioned = true
+	}
+
+	ids := make(map[string]bool, len(known))
+	for _, m := range known {
+		ids[m.Id] = true
+		if !applied[m.Id] {
+			status.Pending = append(status.Pending, m.Id)
+		}
+	}
+	for _, id := range status.Applied {
+		if !ids[id] {
+			status.Unknown = append(status.Unknown, id)
+		}
+	}
+	return status, nil
+}
+
+// Upgrade applies the pending migrations to the graph database and returns the resulting schema version
+// with the number of migrations applied. Unversioned databases created by earlier builds first have the
+// migrations already reflected in their tables recorded, so only the missing changes are executed.
+func Upgrade(system, dsn string) (*Status, int, error) {
+	c, err := open(system, dsn)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer c.close()
+
+	status, err := c.status(system)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(status.Unknown) > 0 {
+		return status, 0, fmt.Errorf("%w: %s", ErrNewerSchema, strings.Join(status.Unknown, ", "))
+	}
+	if status.Current() {
+		return status, 0, nil
+	}
+
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return status, 0, err
+	}
+	if n := c.baseline(status.Pending); status.Unversioned && n > 0 {
+		if _, err := migrate.SkipMax(sqlDB, c.dbinfo.dialect, c.source, migrate.Up, n); err != nil {
+			return status, 0, fmt.Errorf("failed to record the %s schema version: %v", system, err)
+		}
+	}
+
+	n, err := migrate.Exec(sqlDB, c.dbinfo.dialect, c.source, migrate.Up)
+	if err != nil {
+		return status, n, fmt.Errorf("failed to upgrade the %s graph database: %v", system, err)
+	}
+
+	status, err = c.status(system)
+	return status, n, err
+}
+
+// baseline returns the number of leading migrations with changes present in an unversioned database.
+func (c *conn) baseline(pending []string) int {
+	var n int
+	for _, id := range pending {
+		p, found := c.dbinfo.probes[id]
+		if !found || !p(c.db.Migrator()) {
+			break
+		}
+		n++
+	}
+	return n
+}
@@ -0,0 +1,173 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package schema
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/owasp-amass/config/config"
+	"gorm.io/gorm"
+)
+
+func exec(t *testing.T, path string, stmts ...string) {
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open the database: %v", err)
+	}
+	defer func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}()
+
+	for _, stmt := range stmts {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("Failed to execute %s: %v", stmt, err)
+		}
+	}
+}
+
+func TestUpgradeNewDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "amass.sqlite")
+
+	status, n, err := Upgrade("local", path)
+	if err != nil {
+		t.Fatalf("Failed to upgrade the database: %v", err)
+	}
+	if n != 5 || !status.Current() || status.Version() != "005_relations_indexes.sql" {
+		t.Errorf("Unexpected status after applying %d migrations: %+v", n, status)
+	}
+
+	if _, n, err = Upgrade("local", path); err != nil || n != 0 {
+		t.Errorf("The second upgrade applied %d migrations: %v", n, err)
+	}
+}
+
+func TestUpgradePartialDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "amass.sqlite")
+	exec(t, path,
+		"CREATE TABLE assets(id INTEGER PRIMARY KEY, created_at DATETIME, type TEXT, content TEXT)",
+		"CREATE TABLE relations(id INTEGER PRIMARY KEY, created_at DATETIME, type TEXT, from_asset_id INTEGER, to_asset_id INTEGER)",
+		"ALTER TABLE assets ADD COLUMN last_seen DATETIME",
+		"CREATE TABLE gorp_migrations(id VARCHAR(255) NOT NULL PRIMARY KEY, applied_at DATETIME)",
+		"INSERT INTO gorp_migrations VALUES ('001_schema_init.sql', CURRENT_TIMESTAMP), ('002_add_last_seen.sql', CURRENT_TIMESTAMP)",
+	)
+
+	status, err := Check("local", path)
+	if err != nil {
+		t.Fatalf("Failed to check the database: %v", err)
+	}
+	if status.Current() || status.Version() != "002_add_last_seen.sql" || len(status.Pending) != 3 {
+		t.Errorf("Unexpected status before the upgrade: %+v", status)
+	}
+
+	status, n, err := Upgrade("local", path)
+	if err != nil {
+		t.Fatalf("Failed to upgrade the database: %v", err)
+	}
+	if n != 3 || !status.Current() {
+		t.Errorf("Unexpected status after applying %d migrations: %+v", n, status)
+	}
+}
+
+func TestUpgradeUnversionedDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "amass.sqlite")
+	exec(t, path,
+		"CREATE TABLE assets(id INTEGER PRIMARY KEY, created_at DATETIME, type TEXT, content TEXT)",
+		"CREATE TABLE relations(id INTEGER PRIMARY KEY, created_at DATETIME, type TEXT, from_asset_id INTEGER, to_asset_id INTEGER)",
+		"ALTER TABLE assets ADD COLUMN last_seen DATETIME",
+		"INSERT INTO assets(type, content) VALUES ('FQDN', '{\"name\":\"owasp.org\"}')",
+	)
+
+	status, err := Check("local", path)
+	if err != nil {
+		t.Fatalf("Failed to check the database: %v", err)
+	}
+	if !status.Unversioned || status.Current() {
+		t.Errorf("The database was not detected as unversioned: %+v", status)
+	}
+
+	// The first two migrations are recorded without being executed, since the changes are present
+	status, n, err := Upgrade("local", path)
+	if err != nil {
+		t.Fatalf("Failed to upgrade the database: %v", err)
+	}
+	if n != 3 || !status.Current() || len(status.Applied) != 5 {
+		t.Errorf("Unexpected status after applying %d migrations: %+v", n, status)
+	}
+}
+
+func TestUpgradeNewerDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "amass.sqlite")
+	if _, _, err := Upgrade("local", path); err != nil {
+		t.Fatalf("Failed to upgrade the database: %v", err)
+	}
+	exec(t, path, "INSERT INTO gorp_migrations VALUES ('999_future.sql', CURRENT_TIMESTAMP)")
+
+	if _, _, err := Upgrade("local", path); !errors.Is(err, ErrNewerSchema) {
+		t.Errorf("Expected the newer schema error, got: %v", err)
+	}
+}
+
+func TestVersioned(t *testing.T) {
+	if !Versioned("local") || !Versioned("postgres") || Versioned("memory") {
+		t.Error("Versioned returned the wrong systems")
+	}
+	if _, err := Check("memory", ""); err == nil {
+		t.Error("Check did not fail for the memory system")
+	}
+}
+
+func TestSQLiteOptions(t *testing.T) {
+	cfg := config.NewConfig()
+	opts, err := SQLiteOptionsFromConfig(cfg)
+	if err != nil || opts.JournalMode != DefaultJournalMode || opts.BusyTimeout != DefaultBusyTimeout || opts.Synchronous != DefaultSynchronous {
+		t.Errorf("Expected the default options, got %+v: %v", opts, err)
+	}
+
+	cfg.Options["sqlite"] = map[string]interface{}{"journal_mode": "delete", "busy_timeout": "2s", "synchronous": "full"}
+	opts, err = SQLiteOptionsFromConfig(cfg)
+	if err != nil || opts.JournalMode != "DELETE" || opts.BusyTimeout != 2*time.Second || opts.Synchronous != "FULL" {
+		t.Errorf("Expected the configured options, got %+v: %v", opts, err)
+	}
+
+	for _, settings := range []map[string]interface{}{
+		{"journal_mode": "memory"},
+		{"busy_timeout": "soon"},
+		{"busy_timeout": 5},
+		{"synchronous": "sometimes"},
+	} {
+		cfg.Options["sqlite"] = settings
+		if _, err := SQLiteOptionsFromConfig(cfg); err == nil {
+			t.Errorf("Expected an error for the settings %v", settings)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "amass.sqlite")
+	db, err := gorm.Open(sqlite.Open(DefaultSQLiteOptions().DSN(path)), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open the database: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	var mode string
+	if err := db.Raw("PRAGMA journal_mode").Scan(&mode).Error; err != nil || mode != "wal" {
+		t.Errorf("Expected the WAL journal mode, got %s: %v", mode, err)
+	}
+	var timeout int
+	if err := db.Raw("PRAGMA busy_timeout").Scan(&timeout).Error; err != nil || timeout != 30000 {
+		t.Errorf("Expected the busy timeout of 30000ms, got %d: %v", timeout, err)
+	}
+
+	if dsn := DefaultSQLiteOptions().DSN("file:/amass?vfs=memdb"); !strings.HasPrefix(dsn, "file:/amass?vfs=memdb&_pragma=") {
+		t.Errorf("The pragmas were not appended to the parameters of the URI: %s", dsn)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package schema
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// The defaults for the connections to the local SQLite graph database.
+const (
+	// DefaultJournalMode lets the readers proceed while the enumeration writes to the database
+	DefaultJournalMode = "WAL"
+	// DefaultBusyTimeout is how long a connection waits for the lock held by another one
+	DefaultBusyTimeout = 30 * time.Second
+	// DefaultSynchronous only syncs the write-ahead log at the checkpoints
+	DefaultSynchronous = "NORMAL"
+)
+
+var (
+	journalModes = []string{"WAL", "DELETE", "TRUNCATE", "PERSIST"}
+	syncLevels   = []string{"OFF", "NORMAL", "FULL", "EXTRA"}
+)
+
+// SQLiteOptions are the pragmas applied to each connection to the local SQLite graph database.
+type SQLiteOptions struct {
+	JournalMode string
+	BusyTimeout time.Duration
+	Synchronous string
+}
+
+// DefaultSQLiteOptions returns the pragmas used when the configuration does not provide the 'sqlite' section.
+func DefaultSQLiteOptions() *SQLiteOptions {
+	return &SQLiteOptions{
+		JournalMode: DefaultJournalMode,
+		BusyTimeout: DefaultBusyTimeout,
+		Synchronous: DefaultSynchronous,
+	}
+}
+
+// SQLiteOptionsFromConfig returns the pragmas set by the 'sqlite' section of the configuration options.
+func SQLiteOptionsFromConfig(cfg *config.Config) (*SQLiteOptions, error) {
+	opts := DefaultSQLiteOptions()
+
+	raw, ok := cfg.Options["sqlite"]
+	if !ok {
+		return opts, nil
+	}
+
+	settings, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("sqlite is not a map[string]interface{}")
+	}
+
+	if raw, ok := settings["journal_mode"]; ok {
+		mode, ok := raw.(string)
+		if !ok || !contains(journalModes, strings.ToUpper(mode)) {
+			return nil, fmt.Errorf("sqlite journal_mode must be one of %s", strings.ToLower(strings.Join(journalModes, ", ")))
+		}
+		opts.JournalMode = strings.ToUpper(mode)
+	}
+
+	if raw, ok := settings["busy_timeout"]; ok {
+		str, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("sqlite busy_timeout is not a string")
+		}
+
+		d, err := time.ParseDuration(str)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("sqlite busy_timeout is not a valid duration: %s", str)
+		}
+		opts.BusyTimeout = d
+	}
+
+	if raw, ok := settings["synchronous"]; ok {
+		level, ok := raw.(string)
+		if !ok || !contains(syncLevels, strings.ToUpper(level)) {
+			return nil, fmt.Errorf("sqlite synchronous must be one of %s", strings.ToLower(strings.Join(syncLevels, ", ")))
+		}
+		opts.Synchronous = strings.ToUpper(level)
+	}
+	return opts, nil
+}
+
+// DSN returns the data source name opening the SQLite database at the path with the pragmas applied.
+// The busy timeout is applied first, so changing the journal mode waits for the other connections. The
+// path can be a URI carrying its own parameters, such as the database decrypted into memory.
+func (o *SQLiteOptions) DSN(path string) string {
+	q := url.Values{}
+	q.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", o.BusyTimeout.Milliseconds()))
+	q.Add("_pragma", fmt.Sprintf("journal_mode(%s)", o.JournalMode))
+	q.Add("_pragma", fmt.Sprintf("synchronous(%s)", o.Synchronous))
+
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + q.Encode()
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
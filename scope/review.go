@@ -0,0 +1,223 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package scope keeps the queue of the root domains discovered automatically by the enumerations,
+// such as those registered by the same contact as a target domain. The candidates wait in the queue
+// until an analyst approves or denies them, and only the approved domains are added to the scope.
+// The queue is saved to a file in the output directory, so it is shared by the enumerations, the
+// command-line tool and the API server.
+package scope
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// ReviewFile is the name of the file holding the queue in the output directory.
+const ReviewFile = "scope_review.json"
+
+// The review states of the candidates.
+const (
+	Pending  = "pending"
+	Approved = "approved"
+	Denied   = "denied"
+)
+
+// Candidate is a root domain waiting for, or having received, the review of an analyst.
+type Candidate struct {
+	Domain     string    `json:"domain"`
+	Related    string    `json:"related,omitempty"`
+	Source     string    `json:"source,omitempty"`
+	State      string    `json:"state"`
+	Discovered time.Time `json:"discovered"`
+	Reviewed   time.Time `json:"reviewed"`
+	Reviewer   string    `json:"reviewer,omitempty"`
+}
+
+// Queue reads and writes the candidates saved to the file. The file is read again by each
+// operation, so the reviews made by other processes are observed.
+type Queue struct {
+	sync.Mutex
+	path string
+}
+
+// Path returns the path of the queue in the output directory of the configuration.
+func Path(cfg *config.Config) string {
+	return filepath.Join(config.OutputDirectory(cfg.Dir), ReviewFile)
+}
+
+// Open returns the Queue saved to the file at the path, and checks that the file can be parsed.
+func Open(path string) (*Queue, error) {
+	q := &Queue{path: path}
+
+	if _, err := q.read(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// AutoApprove returns true when the 'scope' section of the configuration options adds the
+// discovered root domains to the scope without the review of an analyst.
+func AutoApprove(cfg *config.Config) (bool, error) {
+	scopeRaw, ok := cfg.Options["scope"]
+	if !ok {
+		return false, nil
+	}
+
+	settings, ok := scopeRaw.(map[string]interface{})
+	if !ok {
+		return false, fmt.Errorf("scope is not a map[string]interface{}")
+	}
+
+	raw, ok := settings["auto_approve"]
+	if !ok {
+		return false, nil
+	}
+
+	b, ok := raw.(bool)
+	if !ok {
+		return false, fmt.Errorf("scope auto_approve is not a bool")
+	}
+	return b, nil
+}
+
+// Submit adds the domain to the queue as a pending candidate, and returns the state of the
+// candidate, which the analyst may already have reviewed.
+func (q *Queue) Submit(domain, related, source string) (string, error) {
+	domain = key(domain)
+	if domain == "" {
+		return "", errors.New("the domain must be provided")
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	candidates, err := q.read()
+	if err != nil {
+		return "", err
+	}
+	if c, found := candidates[domain]; found {
+		return c.State, nil
+	}
+
+	candidates[domain] = &Candidate{
+		Domain:     domain,
+		Related:    key(related),
+		Source:     source,
+		State:      Pending,
+		Discovered: time.Now().UTC(),
+	}
+	return Pending, q.write(candidates)
+}
+
+// Review sets the state of the candidate, and adds the domain to the queue when it was not submitted.
+func (q *Queue) Review(domain, state, reviewer string) (*Candidate, error) {
+	domain = key(domain)
+	if domain == "" {
+		return nil, errors.New("the domain must be provided")
+	}
+	if state != Pending && state != Approved && state != Denied {
+		return nil, fmt.Errorf("%s is not a review state", state)
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	candidates, err := q.read()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	c, found := candidates[domain]
+	if !found {
+		c = &Candidate{Domain: domain, Discovered: now}
+		candidates[domain] = c
+	}
+	c.State = state
+	c.Reviewed = now
+	c.Reviewer = reviewer
+	return c, q.write(candidates)
+}
+
+// List returns the candidates in the state, or all the candidates when the state is empty, sorted by domain.
+func (q *Queue) List(state string) ([]*Candidate, error) {
+	q.Lock()
+	defer q.Unlock()
+
+	candidates, err := q.read()
+	if err != nil {
+		return nil, err
+	}
+
+	var list []*Candidate
+	for _, c := range candidates {
+		if state == "" || c.State == state {
+			list = append(list, c)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Domain < list[j].Domain })
+	return list, nil
+}
+
+// Approved returns the approved candidates.
+func (q *Queue) Approved() ([]*Candidate, error) {
+	return q.List(Approved)
+}
+
+func (q *Queue) read() (map[string]*Candidate, error) {
+	candidates := make(map[string]*Candidate)
+
+	data, err := os.ReadFile(q.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return candidates, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var list []*Candidate
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse the scope review queue: %v", err)
+	}
+	for _, c := range list {
+		candidates[c.Domain] = c
+	}
+	return candidates, nil
+}
+
+func (q *Queue) write(candidates map[string]*Candidate) error {
+	list := make([]*Candidate, 0, len(candidates))
+	for _, c := range candidates {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Domain < list[j].Domain })
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(q.path), 0755); err != nil {
+		return err
+	}
+
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, q.path)
+}
+
+func key(domain string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+}
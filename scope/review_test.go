@@ -0,0 +1,77 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scope
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func TestQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ReviewFile)
+
+	q, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open the queue: %v", err)
+	}
+	if state, err := q.Submit("Owasp.NET.", "owasp.org", "WhoisXMLAPI"); err != nil || state != Pending {
+		t.Fatalf("Expected the candidate to be pending, got %s: %v", state, err)
+	}
+	_, _ = q.Submit("example.co.uk", "owasp.org", "WhoisXMLAPI")
+
+	// The reviews are observed by the other queues opened on the file
+	other, _ := Open(path)
+	if c, err := other.Review("owasp.net", Approved, "alice"); err != nil || c.Related != "owasp.org" || c.Reviewer != "alice" {
+		t.Fatalf("Unexpected reviewed candidate %+v: %v", c, err)
+	}
+	if _, err := other.Review("example.net", Denied, "alice"); err != nil {
+		t.Fatalf("Failed to deny a domain that was not submitted: %v", err)
+	}
+	if _, err := other.Review("owasp.net", "maybe", "alice"); err == nil {
+		t.Error("The review accepted an unknown state")
+	}
+
+	if state, _ := q.Submit("owasp.net", "owasp.org", "WhoisXMLAPI"); state != Approved {
+		t.Errorf("The submitted domain lost its review, got %s", state)
+	}
+	if state, _ := q.Submit("example.net", "owasp.org", "WhoisXMLAPI"); state != Denied {
+		t.Errorf("The denied domain was queued again, got %s", state)
+	}
+
+	if list, err := q.Approved(); err != nil || len(list) != 1 || list[0].Domain != "owasp.net" {
+		t.Errorf("Unexpected approved candidates %v: %v", list, err)
+	}
+	if list, _ := q.List(Pending); len(list) != 1 || list[0].Domain != "example.co.uk" {
+		t.Errorf("Unexpected pending candidates %v", list)
+	}
+	if list, _ := q.List(""); len(list) != 3 {
+		t.Errorf("Expected three candidates, got %d", len(list))
+	}
+
+	_ = os.WriteFile(path, []byte("{"), 0644)
+	if _, err := Open(path); err == nil {
+		t.Error("The queue was opened from a corrupt file")
+	}
+}
+
+func TestAutoApprove(t *testing.T) {
+	cfg := config.NewConfig()
+	if auto, err := AutoApprove(cfg); err != nil || auto {
+		t.Errorf("Expected the review to be required by default: %v", err)
+	}
+
+	cfg.Options["scope"] = map[string]interface{}{"auto_approve": true}
+	if auto, err := AutoApprove(cfg); err != nil || !auto {
+		t.Errorf("Expected the domains to be approved automatically: %v", err)
+	}
+
+	cfg.Options["scope"] = map[string]interface{}{"auto_approve": "yes"}
+	if _, err := AutoApprove(cfg); err == nil {
+		t.Error("A string was accepted for the auto_approve option")
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package screenshots
+
+import (
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Pages with the same title are grouped, so default installations and error pages stand out.
+var galleryTemplate = template.Must(template.New("gallery").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>OWASP Amass Screenshots</title>
+<style>
+body { font-family: sans-serif; margin: 1em; background: #f4f4f4; }
+h2 { border-bottom: 1px solid #ccc; font-size: 1.1em; }
+.grid { display: flex; flex-wrap: wrap; gap: 1em; }
+.shot { background: #fff; padding: 0.5em; width: 320px; box-shadow: 0 1px 3px #aaa; }
+.shot img { width: 100%; border: 1px solid #ddd; }
+.shot a { word-break: break-all; font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>Screenshots ({{.Total}})</h1>
+{{range .Groups}}<h2>{{if .Title}}{{.Title}}{{else}}(no title){{end}} &mdash; {{len .Entries}}</h2>
+<div class="grid">
+{{range .Entries}}<div class="shot"><a href="{{.Path}}"><img src="{{.Path}}" alt="{{.URL}}" loading="lazy"></a><br><a href="{{.URL}}">{{.URL}}</a></div>
+{{end}}</div>
+{{end}}</body>
+</html>
+`))
+
+type galleryGroup struct {
+	Title   string
+	Entries []*Entry
+}
+
+// WriteGallery writes an HTML page to w showing the screenshots grouped by page title,
+// with the image paths relative to the screenshots directory.
+func WriteGallery(w io.Writer, entries []*Entry) error {
+	groups := make(map[string]*galleryGroup)
+	for _, e := range entries {
+		key := strings.ToLower(e.Title)
+		g, found := groups[key]
+		if !found {
+			g = &galleryGroup{Title: e.Title}
+			groups[key] = g
+		}
+		g.Entries = append(g.Entries, e)
+	}
+
+	var list []*galleryGroup
+	for _, g := range groups {
+		sort.Slice(g.Entries, func(i, j int) bool { return g.Entries[i].URL < g.Entries[j].URL })
+		list = append(list, g)
+	}
+	// The largest groups are shown first
+	sort.Slice(list, func(i, j int) bool {
+		if len(list[i].Entries) == len(list[j].Entries) {
+			return list[i].Title < list[j].Title
+		}
+		return len(list[i].Entries) > len(list[j].Entries)
+	})
+
+	return galleryTemplate.Execute(w, struct {
+		Total  int
+		Groups []*galleryGroup
+	}{
+		Total:  len(entries),
+		Groups: list,
+	})
+}
+
+// WriteGalleryFile renders the gallery of the screenshots in the directory, and returns
+// the path of the gallery with the number of screenshots included.
+func WriteGalleryFile(dir string) (string, int, error) {
+	entries, err := Load(dir)
+	if err != nil {
+		return "", 0, err
+	}
+
+	path := filepath.Join(dir, GalleryName)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	if err := WriteGallery(f, entries); err != nil {
+		return "", 0, err
+	}
+	return path, len(entries), nil
+}
@@ -0,0 +1,147 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package screenshots stores the screenshots of the web pages captured during the enumerations
+// and renders them into an HTML gallery for visual triage.
+package screenshots
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	// DirName is the directory within the output directory that holds the screenshots.
+	DirName = "screenshots"
+	// IndexName is the file listing the screenshots within the directory, one JSON entry per line.
+	IndexName = "index.jsonl"
+	// GalleryName is the HTML gallery written to the directory.
+	GalleryName = "gallery.html"
+)
+
+// Entry describes a screenshot saved to the directory.
+type Entry struct {
+	URL   string `json:"url"`
+	Title string `json:"title"`
+	// Path is the image file, relative to the screenshots directory
+	Path   string    `json:"path"`
+	Source string    `json:"source,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// The index is appended by the data sources concurrently.
+var indexLock sync.Mutex
+
+var unsafeChars = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+// Dir returns the screenshots directory within the output directory of the configuration.
+func Dir(cfg *config.Config) string {
+	return filepath.Join(config.OutputDirectory(cfg.Dir), DirName)
+}
+
+// Save writes the PNG image of the page at the URL to the directory and adds it to the index.
+// A later screenshot of the same URL replaces the image and the index entry.
+func Save(dir, u, title, source string, image []byte) (*Entry, error) {
+	if len(image) == 0 {
+		return nil, errors.New("the screenshot does not contain an image")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	name := fileName(u)
+	if err := os.WriteFile(filepath.Join(dir, name), image, 0644); err != nil {
+		return nil, err
+	}
+
+	e := &Entry{
+		URL:    u,
+		Title:  strings.TrimSpace(title),
+		Path:   name,
+		Source: source,
+		Time:   time.Now().UTC(),
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+
+	indexLock.Lock()
+	defer indexLock.Unlock()
+
+	f, err := os.OpenFile(filepath.Join(dir, IndexName), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// fileName returns a readable and unique image file name for the URL.
+func fileName(u string) string {
+	base := u
+	if parsed, err := url.Parse(u); err == nil && parsed.Host != "" {
+		base = parsed.Scheme + "-" + parsed.Host + parsed.Path
+	}
+
+	base = strings.Trim(unsafeChars.ReplaceAllString(base, "_"), "_.")
+	if len(base) > 100 {
+		base = base[:100]
+	}
+
+	sum := sha1.Sum([]byte(u))
+	return base + "-" + hex.EncodeToString(sum[:4]) + ".png"
+}
+
+// Load returns the entries of the screenshots in the directory, keeping the latest entry for each URL.
+func Load(dir string) ([]*Entry, error) {
+	indexLock.Lock()
+	defer indexLock.Unlock()
+
+	f, err := os.Open(filepath.Join(dir, IndexName))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []*Entry
+	byURL := make(map[string]int)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var e Entry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return nil, fmt.Errorf("%s line %d: %v", IndexName, n, err)
+		}
+		if i, found := byURL[e.URL]; found {
+			entries[i] = &e
+			continue
+		}
+
+		byURL[e.URL] = len(entries)
+		entries = append(entries, &e)
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,69 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package screenshots
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveAndGallery(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), DirName)
+	png := []byte("\x89PNG\r\n\x1a\n")
+
+	if _, err := Save(dir, "https://www.owasp.org:443/", "OWASP", "Screenshot", png); err != nil {
+		t.Fatalf("Failed to save the screenshot: %v", err)
+	}
+	if _, err := Save(dir, "http://dev.owasp.org/", "IIS Windows Server", "Screenshot", png); err != nil {
+		t.Fatalf("Failed to save the screenshot: %v", err)
+	}
+	// The later screenshot of the same URL replaces the earlier entry
+	e, err := Save(dir, "https://www.owasp.org:443/", "OWASP Foundation", "Screenshot", png)
+	if err != nil {
+		t.Fatalf("Failed to save the screenshot: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, e.Path)); err != nil || !strings.HasPrefix(e.Path, "https-www.owasp.org_443") {
+		t.Errorf("Unexpected image path %s: %v", e.Path, err)
+	}
+	if _, err := Save(dir, "https://empty.owasp.org/", "", "Screenshot", nil); err == nil {
+		t.Error("Save accepted an empty image")
+	}
+
+	entries, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Failed to load the index: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Title != "OWASP Foundation" {
+		t.Fatalf("Unexpected entries: %+v", entries)
+	}
+
+	path, n, err := WriteGalleryFile(dir)
+	if err != nil || n != 2 {
+		t.Fatalf("Failed to write the gallery: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read the gallery: %v", err)
+	}
+	for _, s := range []string{"Screenshots (2)", "IIS Windows Server", `src="` + e.Path + `"`} {
+		if !strings.Contains(string(data), s) {
+			t.Errorf("The gallery does not contain %s", s)
+		}
+	}
+}
+
+func TestFileName(t *testing.T) {
+	a := fileName("https://www.owasp.org/login?next=/admin")
+	b := fileName("https://www.owasp.org/login?next=/other")
+
+	if a == b {
+		t.Error("Different URLs were given the same file name")
+	}
+	if strings.ContainsAny(a, "/?:=") || !strings.HasSuffix(a, ".png") {
+		t.Errorf("Unsafe file name: %s", a)
+	}
+}
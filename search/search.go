@@ -0,0 +1,303 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package search matches the names of assets stored in the graph database using the database indexes,
+// so the assets never need to be loaded into memory.
+package search
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	sqlite3 "github.com/glebarez/go-sqlite"
+	"github.com/glebarez/sqlite"
+	oam "github.com/owasp-amass/open-asset-model"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The SQLite query planner does not match the '->>' operator against the expression index created
+// by the asset-db migrations, so the searcher maintains an index on the extracted names.
+const sqliteNameIndex = "idx_assets_type_name"
+
+// DefaultLimit is the maximum number of matches returned when the query does not provide a limit.
+const DefaultLimit = 1000
+
+// Types are the asset types that can be searched, since their content provides a name.
+var Types = []oam.AssetType{oam.FQDN, oam.RIROrg}
+
+// Query describes the names to be matched.
+type Query struct {
+	// Pattern is a glob supporting the '*' and '?' wildcards, or a regular expression
+	Pattern string
+	Regex   bool
+	// Types restricts the asset types searched, and all the searchable types are used when empty
+	Types []oam.AssetType
+	Limit int
+}
+
+// Match is an asset with a name matching the query.
+type Match struct {
+	ID       string        `json:"id"`
+	Type     oam.AssetType `json:"type"`
+	Name     string        `json:"name"`
+	LastSeen time.Time     `json:"last_seen"`
+}
+
+// Searcher executes queries against the assets table of a graph database.
+type Searcher struct {
+	db     *gorm.DB
+	system string
+}
+
+var regexps sync.Map
+
+func init() {
+	// SQLite only provides the REGEXP operator when a function with this name is registered
+	sqlite3.MustRegisterDeterministicScalarFunction("regexp", 2, sqliteRegexp)
+}
+
+// Open connects to the graph database using the same system names and DSNs accepted by netmap.
+func Open(system, dsn string) (*Searcher, error) {
+	var dialector gorm.Dialector
+
+	switch system {
+	case "local":
+		dialector = sqlite.Open(dsn)
+	case "postgres":
+		dialector = postgres.Open(dsn)
+	default:
+		return nil, fmt.Errorf("the %s graph database system cannot be searched", system)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the %s graph database: %v", system, err)
+	}
+	if system == "local" {
+		stmt := "CREATE INDEX IF NOT EXISTS " + sqliteNameIndex + " ON assets (type, json_extract(content, '$.name') COLLATE NOCASE)"
+		if err := db.Exec(stmt).Error; err != nil {
+			return nil, fmt.Errorf("failed to create the %s index: %v", sqliteNameIndex, err)
+		}
+	}
+	return &Searcher{db: db, system: system}, nil
+}
+
+// Close releases the connections to the graph database.
+func (s *Searcher) Close() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// Search returns the assets with a name matching the query, sorted by name.
+func (s *Searcher) Search(ctx context.Context, q *Query) ([]*Match, error) {
+	if q == nil || q.Pattern == "" {
+		return nil, errors.New("the search pattern must be provided")
+	}
+	if q.Regex {
+		if _, err := compile(q.Pattern); err != nil {
+			return nil, fmt.Errorf("the regular expression is not valid: %v", err)
+		}
+	}
+
+	types := q.Types
+	if len(types) == 0 {
+		types = Types
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	var results []*Match
+	for _, t := range types {
+		if !searchable(t) {
+			return nil, fmt.Errorf("assets of type %s cannot be searched", t)
+		}
+
+		matches, err := s.searchType(ctx, t, q, limit)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, matches...)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := strings.ToLower(results[i].Name), strings.ToLower(results[j].Name)
+		if a == b {
+			return results[i].Type < results[j].Type
+		}
+		return a < b
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+type row struct {
+	ID       int64
+	Name     string
+	LastSeen time.Time
+}
+
+func (s *Searcher) searchType(ctx context.Context, t oam.AssetType, q *Query, limit int) ([]*Match, error) {
+	// The asset type is written into the statement, since the partial index of the
+	// PostgreSQL migrations is only selected by the query planner for a literal condition
+	name := "content->>'name'"
+	where := []string{fmt.Sprintf("type = '%s'", t)}
+	var args []interface{}
+
+	switch s.system {
+	case "postgres":
+		// The trigram index serves both case-insensitive operators
+		if q.Regex {
+			where = append(where, name+" ~* ?")
+			args = append(args, q.Pattern)
+		} else {
+			where = append(where, name+` ILIKE ? ESCAPE '\'`)
+			args = append(args, globToLike(q.Pattern))
+		}
+	default:
+		name = "json_extract(content, '$.name') COLLATE NOCASE"
+		if q.Regex {
+			where = append(where, name+" REGEXP ?")
+			args = append(args, "(?i)"+q.Pattern)
+		} else {
+			// The expression index can only be used for the range implied by the literal prefix
+			if low, high, ok := prefixRange(q.Pattern); ok {
+				where = append(where, name+" >= ?", name+" < ?")
+				args = append(args, low, high)
+			}
+			where = append(where, name+` LIKE ? ESCAPE '\'`)
+			args = append(args, globToLike(q.Pattern))
+		}
+	}
+
+	stmt := fmt.Sprintf("SELECT id, %s AS name, last_seen FROM assets WHERE %s ORDER BY %s LIMIT %d",
+		name, strings.Join(where, " AND "), name, limit)
+
+	var rows []row
+	if err := s.db.WithContext(ctx).Raw(stmt, args...).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to search the %s assets: %v", t, err)
+	}
+
+	var results []*Match
+	for _, r := range rows {
+		results = append(results, &Match{
+			ID:       fmt.Sprint(r.ID),
+			Type:     t,
+			Name:     r.Name,
+			LastSeen: r.LastSeen,
+		})
+	}
+	return results, nil
+}
+
+func searchable(t oam.AssetType) bool {
+	for _, st := range Types {
+		if t == st {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseType returns the searchable asset type identified by the name, such as 'fqdn' or 'org'.
+func ParseType(name string) (oam.AssetType, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "fqdn", "name", "domain":
+		return oam.FQDN, nil
+	case "org", "organization", "rirorg":
+		return oam.RIROrg, nil
+	}
+	return "", fmt.Errorf("%s is not a searchable asset type", name)
+}
+
+// globToLike converts the glob wildcards into the LIKE wildcards and escapes the remaining characters.
+func globToLike(pattern string) string {
+	var b strings.Builder
+
+	for _, c := range pattern {
+		switch c {
+		case '*':
+			b.WriteRune('%')
+		case '?':
+			b.WriteRune('_')
+		case '%', '_', '\\':
+			b.WriteRune('\\')
+			b.WriteRune(c)
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// prefixRange returns the bounds of the names starting with the literal prefix of the glob.
+func prefixRange(pattern string) (string, string, bool) {
+	prefix := pattern
+	if i := strings.IndexAny(pattern, "*?"); i != -1 {
+		prefix = pattern[:i]
+	}
+
+	prefix = strings.ToLower(prefix)
+	if prefix == "" || prefix[len(prefix)-1] == 0xff {
+		return "", "", false
+	}
+
+	high := []byte(prefix)
+	high[len(high)-1]++
+	return prefix, string(high), true
+}
+
+func compile(pattern string) (*regexp.Regexp, error) {
+	if re, found := regexps.Load(pattern); found {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexps.Store(pattern, re)
+	return re, nil
+}
+
+// sqliteRegexp implements 'X REGEXP Y', which SQLite evaluates as regexp(Y, X).
+func sqliteRegexp(_ *sqlite3.FunctionContext, args []driver.Value) (driver.Value, error) {
+	pattern, ok := args[0].(string)
+	if !ok {
+		return false, errors.New("the regular expression must be a string")
+	}
+
+	var subject string
+	switch v := args[1].(type) {
+	case string:
+		subject = v
+	case []byte:
+		subject = string(v)
+	case nil:
+		return false, nil
+	default:
+		subject = fmt.Sprint(v)
+	}
+
+	re, err := compile(pattern)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(subject), nil
+}
@@ -0,0 +1,115 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package search
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/caffix/netmap"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "amass.sqlite")
+
+	g := netmap.NewGraph("local", path, "")
+	if g == nil {
+		t.Fatal("Failed to create the graph database")
+	}
+	defer g.Remove()
+
+	for _, name := range []string{"vpn.owasp.org", "VPN2.owasp.org", "vpnx.other.org", "www.owasp.org", "mail.owasp.org"} {
+		if _, err := g.UpsertFQDN(ctx, name); err != nil {
+			t.Fatalf("Failed to insert %s: %v", name, err)
+		}
+	}
+	if _, err := g.DB.Create(nil, "", network.RIROrganization{Name: "OWASP Foundation", RIRId: "OWASP-1", RIR: "ARIN"}); err != nil {
+		t.Fatalf("Failed to insert the organization: %v", err)
+	}
+
+	s, err := Open("local", path)
+	if err != nil {
+		t.Fatalf("Failed to open the searcher: %v", err)
+	}
+	defer s.Close()
+
+	tests := []struct {
+		query    *Query
+		expected []string
+	}{
+		{&Query{Pattern: "vpn*.owasp.org"}, []string{"vpn.owasp.org", "VPN2.owasp.org"}},
+		{&Query{Pattern: "*.owasp.org", Limit: 2}, []string{"mail.owasp.org", "vpn.owasp.org"}},
+		{&Query{Pattern: "vpn?.*"}, []string{"VPN2.owasp.org", "vpnx.other.org"}},
+		{&Query{Pattern: `^(www|mail)\.owasp\.org$`, Regex: true}, []string{"mail.owasp.org", "www.owasp.org"}},
+		{&Query{Pattern: "*owasp*", Types: []oam.AssetType{oam.RIROrg}}, []string{"OWASP Foundation"}},
+		{&Query{Pattern: "vpn_.owasp.org"}, nil},
+	}
+
+	for _, test := range tests {
+		matches, err := s.Search(ctx, test.query)
+		if err != nil {
+			t.Errorf("Search for %s failed: %v", test.query.Pattern, err)
+			continue
+		}
+
+		var names []string
+		for _, m := range matches {
+			names = append(names, m.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("Search for %s returned %v, expected %v", test.query.Pattern, names, test.expected)
+		}
+	}
+
+	if _, err := s.Search(ctx, &Query{Pattern: "(", Regex: true}); err == nil {
+		t.Error("Search accepted an invalid regular expression")
+	}
+	if _, err := s.Search(ctx, &Query{Pattern: "*", Types: []oam.AssetType{oam.ASN}}); err == nil {
+		t.Error("Search accepted an asset type without names")
+	}
+}
+
+func TestPrefixUsesIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "amass.sqlite")
+
+	g := netmap.NewGraph("local", path, "")
+	if g == nil {
+		t.Fatal("Failed to create the graph database")
+	}
+	defer g.Remove()
+
+	s, err := Open("local", path)
+	if err != nil {
+		t.Fatalf("Failed to open the searcher: %v", err)
+	}
+	defer s.Close()
+
+	low, high, _ := prefixRange("vpn*.owasp.org")
+	var plan []struct{ Detail string }
+	if err := s.db.Raw("EXPLAIN QUERY PLAN SELECT id FROM assets WHERE type = 'FQDN' AND "+
+		"json_extract(content, '$.name') COLLATE NOCASE >= ? AND json_extract(content, '$.name') COLLATE NOCASE < ?", low, high).Scan(&plan).Error; err != nil {
+		t.Fatalf("Failed to explain the query: %v", err)
+	}
+	if len(plan) == 0 || !strings.Contains(plan[0].Detail, sqliteNameIndex) || !strings.Contains(plan[0].Detail, ">") {
+		t.Errorf("The prefix search did not use the name index: %v", plan)
+	}
+}
+
+func TestGlobToLike(t *testing.T) {
+	if got := globToLike(`a*b?c%d_e\f`); got != `a%b_c\%d\_e\\f` {
+		t.Errorf("Unexpected LIKE pattern: %s", got)
+	}
+	if low, high, ok := prefixRange("VPN*.example.com"); !ok || low != "vpn" || high != "vpo" {
+		t.Errorf("Unexpected prefix range: %s %s", low, high)
+	}
+	if _, _, ok := prefixRange("*.example.com"); ok {
+		t.Error("A pattern starting with a wildcard returned a prefix range")
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package selftest validates an installation by executing a small enumeration against the mock Internet.
+package selftest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/datasrcs"
+	"github.com/owasp-amass/amass/v4/enum"
+	"github.com/owasp-amass/amass/v4/mock"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+)
+
+// Result describes the names discovered by the enumeration against the mock Internet.
+type Result struct {
+	Expected   []string      `json:"expected"`
+	Discovered []string      `json:"discovered"`
+	Missing    []string      `json:"missing"`
+	Unexpected []string      `json:"unexpected"`
+	Queries    int           `json:"dns_queries"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// Passed returns true when the enumeration discovered exactly the expected names.
+func (r *Result) Passed() bool {
+	return len(r.Missing) == 0 && len(r.Unexpected) == 0
+}
+
+// Run starts the mock Internet and enumerates its domain, storing the graph database and the
+// data source script in the directory. The logger receives the messages of the enumeration.
+func Run(ctx context.Context, dir string, logger *log.Logger) (*Result, error) {
+	start := time.Now()
+
+	m, err := mock.NewInternet(mock.DefaultDataset())
+	if err != nil {
+		return nil, err
+	}
+	defer m.Close()
+
+	scripts := filepath.Join(dir, "scripts")
+	if err := os.MkdirAll(scripts, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create the scripts directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(scripts, mock.ScriptFile), []byte(m.Script()), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write the mock data source script: %v", err)
+	}
+
+	cfg := config.NewConfig()
+	cfg.Dir = dir
+	if logger != nil {
+		cfg.Log = logger
+	}
+	cfg.AddDomain(m.Dataset().Domain)
+	cfg.Resolvers = []string{m.DNSAddr}
+	cfg.TrustedResolvers = []string{m.DNSAddr}
+	cfg.ScriptsDirectory = scripts
+	// Only the mock data source is used, so the real Internet is never queried
+	cfg.SourceFilter.Include = true
+	cfg.SourceFilter.Sources = []string{mock.SourceName}
+
+	sys, err := systems.NewLocalSystem(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	srcs := datasrcs.SelectedDataSources(cfg, datasrcs.GetAllSources(sys))
+	if len(srcs) == 0 {
+		return nil, errors.New("the mock data source script failed to load")
+	}
+	if err := sys.SetDataSources(srcs); err != nil {
+		return nil, err
+	}
+
+	e := enum.NewEnumeration(cfg, sys, sys.GraphDatabases()[0])
+	if e == nil {
+		return nil, errors.New("failed to setup the enumeration")
+	}
+	if err := e.Start(ctx); err != nil {
+		return nil, err
+	}
+
+	r := &Result{
+		Expected:   m.Expected(),
+		Discovered: discovered(sys, m.Dataset().Domain),
+		Queries:    m.Queries(),
+	}
+	r.Missing = difference(r.Expected, r.Discovered)
+	r.Unexpected = difference(r.Discovered, r.Expected)
+	r.Duration = time.Since(start)
+	return r, nil
+}
+
+// discovered returns the names within the domain that were stored in the graph database.
+func discovered(sys systems.System, d string) []string {
+	g := sys.GraphDatabases()[0]
+
+	assets, err := g.DB.FindByScope([]oam.Asset{domain.FQDN{Name: d}}, time.Time{})
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, a := range assets {
+		if fqdn, ok := a.Asset.(domain.FQDN); ok {
+			names = append(names, fqdn.Name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// difference returns the strings in a that are not in b.
+func difference(a, b []string) []string {
+	set := make(map[string]struct{}, len(b))
+	for _, s := range b {
+		set[s] = struct{}{}
+	}
+
+	var results []string
+	for _, s := range a {
+		if _, found := set[s]; !found {
+			results = append(results, s)
+		}
+	}
+	return results
+}
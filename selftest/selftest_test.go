@@ -0,0 +1,27 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package selftest
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	r, err := Run(ctx, t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("The self-test failed to execute: %v", err)
+	}
+	if !r.Passed() {
+		t.Errorf("Missing names: %v, unexpected names: %v", r.Missing, r.Unexpected)
+	}
+	if r.Queries == 0 {
+		t.Error("The mock DNS server did not receive any queries")
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package settings
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/owasp-amass/config/config"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// EnvPrefix begins the names of the environment variables that override the configuration.
+	EnvPrefix = "AMASS_"
+	// OptionsEnvPrefix begins the names of the environment variables that override the options
+	// sections, with double underscores separating the section and key names.
+	OptionsEnvPrefix = EnvPrefix + "OPTIONS_"
+)
+
+type envSetter func(cfg *config.Config, value string) error
+
+// The environment variables that override the settings shared by the commands and the engine.
+var envSetters = map[string]envSetter{
+	"AMASS_DIR": func(cfg *config.Config, value string) error {
+		cfg.Dir = value
+		return nil
+	},
+	"AMASS_SCRIPTS_DIR": func(cfg *config.Config, value string) error {
+		cfg.ScriptsDirectory = value
+		return nil
+	},
+	"AMASS_DOMAINS": func(cfg *config.Config, value string) error {
+		cfg.AddDomains(splitList(value)...)
+		return nil
+	},
+	"AMASS_RESOLVERS": func(cfg *config.Config, value string) error {
+		cfg.SetResolvers(splitList(value)...)
+		return nil
+	},
+	"AMASS_TRUSTED_RESOLVERS": func(cfg *config.Config, value string) error {
+		cfg.TrustedResolvers = []string{}
+		cfg.AddTrustedResolvers(splitList(value)...)
+		return nil
+	},
+	"AMASS_ACTIVE":        boolSetter(func(cfg *config.Config, b bool) { cfg.Active = b }),
+	"AMASS_PASSIVE":       boolSetter(func(cfg *config.Config, b bool) { cfg.Passive = b }),
+	"AMASS_VERBOSE":       boolSetter(func(cfg *config.Config, b bool) { cfg.Verbose = b }),
+	"AMASS_BRUTE_FORCING": boolSetter(func(cfg *config.Config, b bool) { cfg.BruteForcing = b }),
+	"AMASS_ALTERATIONS":   boolSetter(func(cfg *config.Config, b bool) { cfg.Alterations = b }),
+	"AMASS_RECURSIVE":     boolSetter(func(cfg *config.Config, b bool) { cfg.Recursive = b }),
+	"AMASS_MAX_DNS_QUERIES": intSetter(func(cfg *config.Config, n int) {
+		cfg.MaxDNSQueries = n
+	}),
+	"AMASS_RESOLVERS_QPS": intSetter(func(cfg *config.Config, n int) {
+		cfg.ResolversQPS = n
+	}),
+	"AMASS_TRUSTED_QPS": intSetter(func(cfg *config.Config, n int) {
+		cfg.TrustedQPS = n
+	}),
+}
+
+func boolSetter(set func(*config.Config, bool)) envSetter {
+	return func(cfg *config.Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s is not a boolean", value)
+		}
+		set(cfg, b)
+		return nil
+	}
+}
+
+func intSetter(set func(*config.Config, int)) envSetter {
+	return func(cfg *config.Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("%s is not a positive integer", value)
+		}
+		set(cfg, n)
+		return nil
+	}
+}
+
+// EnvNames returns the names of the environment variables that override the configuration settings.
+func EnvNames() []string {
+	var names []string
+
+	for name := range envSetters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyEnv overrides the configuration with the environment variables in the KEY=value form of os.Environ.
+// Variables such as AMASS_OPTIONS_BUDGET__MAX_HTTP_REQUESTS=100 set the max_http_requests key of the budget
+// section, and the values are parsed as YAML, so booleans, numbers and [a, b] lists keep their types.
+func ApplyEnv(cfg *config.Config, environ []string) error {
+	for _, kv := range environ {
+		name, value, found := strings.Cut(kv, "=")
+		if !found || !strings.HasPrefix(name, EnvPrefix) {
+			continue
+		}
+
+		if set, ok := envSetters[name]; ok {
+			if err := set(cfg, strings.TrimSpace(value)); err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+		} else if strings.HasPrefix(name, OptionsEnvPrefix) {
+			if err := setOption(cfg, strings.TrimPrefix(name, OptionsEnvPrefix), value); err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func setOption(cfg *config.Config, path, value string) error {
+	keys := strings.Split(strings.ToLower(path), "__")
+	for _, k := range keys {
+		if k == "" {
+			return fmt.Errorf("the option path %s contains an empty name", path)
+		}
+	}
+
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(value), &v); err != nil || v == nil {
+		v = value
+	}
+
+	if cfg.Options == nil {
+		cfg.Options = make(map[string]interface{})
+	}
+	m := cfg.Options
+	for _, k := range keys[:len(keys)-1] {
+		next, ok := m[k].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			m[k] = next
+		}
+		m = next
+	}
+	m[keys[len(keys)-1]] = v
+	return nil
+}
+
+func splitList(value string) []string {
+	var list []string
+
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,152 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package settings
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// ProfileKey is the option selecting the profile applied to the configuration.
+const ProfileKey = "profile"
+
+// Profile is a named preset of the enumeration modes, along with the groups of data sources it disables.
+type Profile struct {
+	Name        string
+	Description string
+	// DisabledTypes are the types of the data sources that are not registered while the profile is selected
+	DisabledTypes []string
+	apply         func(cfg *config.Config)
+}
+
+// The profiles in the order of the traffic they send to the target.
+var profiles = []*Profile{
+	{
+		Name:          "passive",
+		Description:   "Only queries the data sources, without resolving names or sending traffic to the target",
+		DisabledTypes: []string{"dns", "brute", "alt"},
+		apply: func(cfg *config.Config) {
+			cfg.Passive = true
+			cfg.Active = false
+			cfg.BruteForcing = false
+			cfg.Alterations = false
+		},
+	},
+	{
+		Name:          "normal",
+		Description:   "Resolves the names discovered by the data sources and their alterations, without brute forcing",
+		DisabledTypes: []string{"brute"},
+		apply: func(cfg *config.Config) {
+			cfg.Passive = false
+			cfg.Active = false
+			cfg.BruteForcing = false
+			cfg.Alterations = true
+		},
+	},
+	{
+		Name:        "aggressive",
+		Description: "Also attempts zone transfers, certificate grabs and recursive brute forcing",
+		apply: func(cfg *config.Config) {
+			cfg.Passive = false
+			cfg.Active = true
+			cfg.BruteForcing = true
+			cfg.Alterations = true
+			cfg.Recursive = true
+		},
+	},
+}
+
+// Profiles returns the names of the available profiles.
+func Profiles() []string {
+	var names []string
+	for _, p := range profiles {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// GetProfile returns the profile with the provided name.
+func GetProfile(name string) (*Profile, error) {
+	for _, p := range profiles {
+		if strings.EqualFold(p.Name, name) {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("the profile must be %s", strings.Join(Profiles(), ", "))
+}
+
+// Allows returns true when the profile does not disable the data sources of the type.
+func (p *Profile) Allows(srcType string) bool {
+	if p == nil {
+		return true
+	}
+
+	for _, t := range p.DisabledTypes {
+		if strings.EqualFold(t, srcType) {
+			return false
+		}
+	}
+	return true
+}
+
+// Check returns an error when the settings enable a group of data sources disabled by the profile,
+// such as a command-line flag requesting brute forcing with the normal profile.
+func (p *Profile) Check(cfg *config.Config) error {
+	switch {
+	case p == nil:
+		return nil
+	case cfg.Active && !p.Allows("dns"):
+		return fmt.Errorf("the active mode is disabled by the %s profile", p.Name)
+	case cfg.BruteForcing && !p.Allows("brute"):
+		return fmt.Errorf("brute forcing is disabled by the %s profile", p.Name)
+	case cfg.Alterations && !p.Allows("alt"):
+		return fmt.Errorf("name alterations are disabled by the %s profile", p.Name)
+	}
+	return nil
+}
+
+// ApplyProfile applies the profile with the provided name, or the profile selected by the profile
+// option when the name is empty, and records the selection in the options. A nil Profile is
+// returned when a profile was not selected.
+func ApplyProfile(cfg *config.Config, name string) (*Profile, error) {
+	if name == "" {
+		p, err := ProfileFromConfig(cfg)
+		if p != nil {
+			p.apply(cfg)
+		}
+		return p, err
+	}
+
+	p, err := GetProfile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Options == nil {
+		cfg.Options = make(map[string]interface{})
+	}
+	cfg.Options[ProfileKey] = p.Name
+	p.apply(cfg)
+	return p, nil
+}
+
+// ProfileFromConfig returns the profile selected by the profile option, or nil when a profile was not selected.
+func ProfileFromConfig(cfg *config.Config) (*Profile, error) {
+	raw, found := cfg.Options[ProfileKey]
+	if !found || raw == nil {
+		return nil, nil
+	}
+
+	name, ok := raw.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a string", ProfileKey)
+	}
+	if name == "" {
+		return nil, nil
+	}
+	return GetProfile(name)
+}
@@ -0,0 +1,147 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package settings
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sessionOptions are the option sections accepted by the configuration of the sessions requested through
+// the API, along with the keys of each section naming files on the server, which are refused. The other
+// sections, such as the databases, datasets, data source credentials and replay files, belong to the server.
+var sessionOptions = map[string][]string{
+	"profile":          nil,
+	"resolvers":        nil,
+	"bruteforce":       {"wordlists"},
+	"alterations":      {"wordlists"},
+	"budget":           nil,
+	"pivot":            nil,
+	"dedup":            nil,
+	"dispatch":         nil,
+	"dns_record_types": nil,
+	"zones":            nil,
+	"idle":             nil,
+	"source_ttls":      nil,
+	"scope":            nil,
+	"honey_records":    nil,
+	"port_scan":        {"import"},
+	"email_security":   nil,
+	"ipv6_expansion":   nil,
+	"confidence":       nil,
+	"name_validation":  nil,
+	"plugins":          nil,
+	"passive_dns":      nil,
+	"quotas":           nil,
+	"throttle":         nil,
+}
+
+// CheckSession returns an error naming the settings of the session configuration that are not accepted
+// from the clients of the API. Only the scope and the option sections controlling the data sources,
+// limits and enumeration modes are accepted, and the settings reading files on the server are refused.
+func CheckSession(data string) error {
+	_, refused, err := filterSession(data, false)
+	if err != nil || len(refused) == 0 {
+		return err
+	}
+	return fmt.Errorf("the session configuration cannot set %s", strings.Join(refused, ", "))
+}
+
+// StripSession removes the settings refused by CheckSession from the configuration, and returns the
+// configuration accepted by the engines along with the names of the removed settings.
+func StripSession(data string) (string, []string, error) {
+	raw, refused, err := filterSession(data, true)
+	if err != nil || len(refused) == 0 {
+		return data, nil, err
+	}
+
+	out, err := yaml.Marshal(raw)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(out), refused, nil
+}
+
+// filterSession returns the parsed configuration and the settings not accepted by the sessions, which
+// are removed from the configuration when strip is true.
+func filterSession(data string, strip bool) (map[string]interface{}, []string, error) {
+	if strings.TrimSpace(data) == "" {
+		return nil, nil, nil
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal([]byte(data), &raw); err != nil {
+		return nil, nil, fmt.Errorf("the session configuration is not valid YAML: %v", err)
+	}
+
+	var refused []string
+	for key, value := range raw {
+		switch key {
+		case "scope":
+		case "options":
+			options, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, nil, fmt.Errorf("the session configuration options is not a map[string]interface{}")
+			}
+			refused = append(refused, filterOptions(options, strip)...)
+		default:
+			refused = append(refused, key)
+			if strip {
+				delete(raw, key)
+			}
+		}
+	}
+
+	sort.Strings(refused)
+	return raw, refused, nil
+}
+
+func filterOptions(options map[string]interface{}, strip bool) []string {
+	var refused []string
+
+	for name, value := range options {
+		paths, accepted := sessionOptions[name]
+		if !accepted {
+			refused = append(refused, "options."+name)
+			if strip {
+				delete(options, name)
+			}
+			continue
+		}
+		// The resolvers that are not IP addresses are read from files
+		if name == "resolvers" {
+			list, _ := value.([]interface{})
+			var kept []interface{}
+			for _, r := range list {
+				if s, ok := r.(string); !ok || net.ParseIP(s) == nil {
+					refused = append(refused, fmt.Sprintf("options.resolvers entry %v", r))
+					continue
+				}
+				kept = append(kept, r)
+			}
+			if strip && len(kept) > 0 {
+				options[name] = kept
+			} else if strip {
+				delete(options, name)
+			}
+			continue
+		}
+
+		section, _ := value.(map[string]interface{})
+		for _, key := range paths {
+			if _, found := section[key]; found {
+				refused = append(refused, "options."+name+"."+key)
+				if strip {
+					delete(section, key)
+				}
+			}
+		}
+	}
+	return refused
+}
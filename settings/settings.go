@@ -0,0 +1,295 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package settings layers the configuration of each command, so the built-in defaults are overridden
+// by the configuration file, then by the environment variables, and finally by the command-line flags.
+package settings
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/workspace"
+	"github.com/owasp-amass/config/config"
+)
+
+// Layer identifies the source of a configuration value.
+type Layer string
+
+// The layers in the order of precedence, where later layers override the earlier layers.
+const (
+	DefaultLayer Layer = "default"
+	FileLayer    Layer = "file"
+	EnvLayer     Layer = "env"
+	ProfileLayer Layer = "profile"
+	FlagLayer    Layer = "flag"
+)
+
+const (
+	// ConfigFileEnv is the environment variable providing the configuration file path.
+	ConfigFileEnv = "AMASS_CONFIG"
+	// CommandsKey is the options section of the configuration file holding the per-command overrides.
+	CommandsKey    = "commands"
+	configFileName = "config.yaml"
+)
+
+// Value is a resolved configuration setting along with the layer that provided it.
+type Value struct {
+	Key   string
+	Value string
+	Layer Layer
+}
+
+// Loader applies the configuration layers for a command and tracks the layer providing each value.
+type Loader struct {
+	Command string
+	cfg     *config.Config
+	values  map[string]string
+	origins map[string]Layer
+}
+
+// NewLoader returns a Loader for the command, with the current settings of cfg as the defaults.
+func NewLoader(command string, cfg *config.Config) *Loader {
+	l := &Loader{
+		Command: command,
+		cfg:     cfg,
+		values:  make(map[string]string),
+		origins: make(map[string]Layer),
+	}
+	l.Record(DefaultLayer)
+	return l
+}
+
+// Load applies the configuration file layer, including the overrides for the command, followed by the environment layer.
+// The flag layer is applied by the command and then recorded. The commands call Load before applying their flags, so the
+// values are taken from the defaults, the configuration file, the overrides for the command, the environment variables
+// and the command-line flags, in increasing order of precedence.
+func Load(command string, cfg *config.Config, dir, file string) error {
+	return NewLoader(command, cfg).Load(dir, file)
+}
+
+// Load applies the configuration file found using the dir and file arguments, followed by the environment variables
+// and the profile selected by them. Without a dir argument, the directory of the active project is used.
+func (l *Loader) Load(dir, file string) error {
+	if dir = workspace.OutputDirectory(dir); dir != "" {
+		l.cfg.Dir = dir
+	}
+	l.cfg.Filepath = config.OutputDirectory(dir)
+	if path := ConfigPath(dir, file); path != "" {
+		if err := l.cfg.LoadSettings(path); err != nil {
+			return err
+		}
+		if err := applyCommand(l.cfg, l.Command); err != nil {
+			return err
+		}
+	}
+	l.Record(FileLayer)
+
+	if err := ApplyEnv(l.cfg, os.Environ()); err != nil {
+		return err
+	}
+	l.Record(EnvLayer)
+	return l.ApplyProfile("")
+}
+
+// ApplyProfile applies the profile with the provided name, or the profile selected by the configuration
+// when the name is empty, and attributes the changed values to the profile layer.
+func (l *Loader) ApplyProfile(name string) error {
+	if _, err := ApplyProfile(l.cfg, name); err != nil {
+		return err
+	}
+	l.Record(ProfileLayer)
+	return nil
+}
+
+// ConfigPath returns the configuration file selected by the file argument, the AMASS_CONFIG environment
+// variable, the output directory or the system configuration directory, in that order. The output
+// directory of the active project is used without a dir argument. An empty string is returned when
+// none of the locations provide a configuration file.
+func ConfigPath(dir, file string) string {
+	if file != "" {
+		return file
+	}
+	if f, set := os.LookupEnv(ConfigFileEnv); set && f != "" {
+		return f
+	}
+
+	if path := filepath.Join(config.OutputDirectory(workspace.OutputDirectory(dir)), configFileName); fileExists(path) {
+		return path
+	}
+	if runtime.GOOS != "windows" {
+		if path := filepath.Join("/etc", "amass", configFileName); fileExists(path) {
+			return path
+		}
+	}
+	return ""
+}
+
+func fileExists(path string) bool {
+	finfo, err := os.Stat(path)
+	return err == nil && !finfo.IsDir()
+}
+
+// applyCommand merges the options provided for the command in the commands section over the other options.
+func applyCommand(cfg *config.Config, command string) error {
+	raw, found := cfg.Options[CommandsKey]
+	if !found || command == "" {
+		return nil
+	}
+
+	commands, ok := raw.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s is not a map[string]interface{}", CommandsKey)
+	}
+	raw, found = commands[command]
+	if !found || raw == nil {
+		return nil
+	}
+
+	overrides, ok := raw.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s %s is not a map[string]interface{}", CommandsKey, command)
+	}
+	merge(cfg.Options, overrides)
+	return nil
+}
+
+// merge copies the values of src into dst, combining the nested maps instead of replacing them.
+func merge(dst, src map[string]interface{}) {
+	for k, v := range src {
+		sm, ok := v.(map[string]interface{})
+		if !ok {
+			dst[k] = v
+			continue
+		}
+
+		dm, ok := dst[k].(map[string]interface{})
+		if !ok {
+			dm = make(map[string]interface{})
+			dst[k] = dm
+		}
+		merge(dm, sm)
+	}
+}
+
+// Record attributes the values changed since the previous layer to the provided layer.
+func (l *Loader) Record(layer Layer) {
+	current := Flatten(l.cfg)
+
+	for k, v := range current {
+		if prev, found := l.values[k]; !found || prev != v {
+			l.origins[k] = layer
+		}
+	}
+	for k := range l.values {
+		if _, found := current[k]; !found {
+			delete(l.origins, k)
+		}
+	}
+	l.values = current
+}
+
+// Values returns the resolved configuration settings, sorted by key.
+func (l *Loader) Values() []*Value {
+	var values []*Value
+
+	for k, v := range l.values {
+		values = append(values, &Value{Key: k, Value: v, Layer: l.origins[k]})
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].Key < values[j].Key })
+	return values
+}
+
+// Flatten returns the settings of the configuration used by the commands and the engine as key and value strings.
+// The values of options with names suggesting credentials are redacted.
+func Flatten(cfg *config.Config) map[string]string {
+	values := map[string]string{
+		"dir":               cfg.Dir,
+		"config_file":       cfg.Filepath,
+		"scripts_directory": cfg.ScriptsDirectory,
+		"active":            fmt.Sprint(cfg.Active),
+		"passive":           fmt.Sprint(cfg.Passive),
+		"verbose":           fmt.Sprint(cfg.Verbose),
+		"brute_forcing":     fmt.Sprint(cfg.BruteForcing),
+		"recursive":         fmt.Sprint(cfg.Recursive),
+		"alterations":       fmt.Sprint(cfg.Alterations),
+		"max_dns_queries":   fmt.Sprint(cfg.MaxDNSQueries),
+		"resolvers":         strings.Join(cfg.Resolvers, ","),
+		"resolvers_qps":     fmt.Sprint(cfg.ResolversQPS),
+		"trusted_resolvers": strings.Join(cfg.TrustedResolvers, ","),
+		"trusted_qps":       fmt.Sprint(cfg.TrustedQPS),
+	}
+
+	if s := cfg.Scope; s != nil {
+		values["scope.domains"] = strings.Join(s.Domains, ",")
+		values["scope.ports"] = joinInts(s.Ports)
+		values["scope.asns"] = joinInts(s.ASNs)
+		cidrs := make([]string, len(s.CIDRs))
+		for i, cidr := range s.CIDRs {
+			cidrs[i] = cidr.String()
+		}
+		values["scope.cidrs"] = strings.Join(cidrs, ",")
+		values["scope.ips"] = strings.Join(s.IP, ",")
+		values["scope.blacklist"] = strings.Join(s.Blacklist, ",")
+	}
+
+	for k, v := range cfg.Options {
+		// The per-command overrides have already been merged into the options
+		if k == CommandsKey {
+			continue
+		}
+		flattenOption(values, "options."+k, v)
+	}
+	return values
+}
+
+func flattenOption(values map[string]string, key string, v interface{}) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, nested := range val {
+			flattenOption(values, key+"."+k, nested)
+		}
+	case []interface{}:
+		parts := make([]string, len(val))
+		for i, item := range val {
+			parts[i] = fmt.Sprint(item)
+		}
+		values[key] = redact(key, strings.Join(parts, ","))
+	default:
+		values[key] = redact(key, fmt.Sprint(val))
+	}
+}
+
+var sensitive = []string{"password", "secret", "token", "apikey", "api_key", "auth", "cookie", "credential", "access_key", "private_key"}
+
+// The webhook URLs and session values carry credentials that their names do not reveal.
+var sensitiveSections = []string{"options.notifications.", "options.http_sessions."}
+
+func redact(key, value string) string {
+	lower := strings.ToLower(key)
+
+	for _, s := range sensitiveSections {
+		if strings.HasPrefix(lower, s) && value != "" {
+			return "[redacted]"
+		}
+	}
+	for _, s := range sensitive {
+		if strings.Contains(lower, s) && value != "" {
+			return "[redacted]"
+		}
+	}
+	return value
+}
+
+func joinInts(list []int) string {
+	parts := make([]string, len(list))
+	for i, n := range list {
+		parts[i] = fmt.Sprint(n)
+	}
+	return strings.Join(parts, ",")
+}
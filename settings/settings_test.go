@@ -0,0 +1,228 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/owasp-amass/config/config"
+)
+
+const testConfig = `
+scope:
+  domains:
+    - owasp.org
+options:
+  budget:
+    max_http_requests: 50
+    max_dns_queries: 500
+  commands:
+    api:
+      budget:
+        max_http_requests: 10
+`
+
+func TestLayers(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(testConfig), 0644); err != nil {
+		t.Fatalf("Failed to write the configuration file: %v", err)
+	}
+	t.Setenv(ConfigFileEnv, "")
+	t.Setenv("AMASS_VERBOSE", "true")
+	t.Setenv("AMASS_OPTIONS_BUDGET__MAX_DNS_QUERIES", "1000")
+
+	cfg := config.NewConfig()
+	l := NewLoader("api", cfg)
+	if err := l.Load(dir, ""); err != nil {
+		t.Fatalf("Failed to load the configuration: %v", err)
+	}
+	cfg.Active = true
+	l.Record(FlagLayer)
+
+	budget := cfg.Options["budget"].(map[string]interface{})
+	if budget["max_http_requests"] != 10 || budget["max_dns_queries"] != 1000 {
+		t.Errorf("Unexpected budget options: %v", budget)
+	}
+
+	expected := map[string]Layer{
+		"recursive":                        DefaultLayer,
+		"scope.domains":                    FileLayer,
+		"options.budget.max_http_requests": FileLayer,
+		"options.budget.max_dns_queries":   EnvLayer,
+		"verbose":                          EnvLayer,
+		"active":                           FlagLayer,
+	}
+	for _, v := range l.Values() {
+		if layer, found := expected[v.Key]; found && v.Layer != layer {
+			t.Errorf("%s was provided by the %s layer, expected %s", v.Key, v.Layer, layer)
+		}
+		if v.Key == "options.commands.api.budget.max_http_requests" {
+			t.Errorf("The per-command overrides were included in the values")
+		}
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	cfg := config.NewConfig()
+
+	err := ApplyEnv(cfg, []string{
+		"AMASS_DOMAINS=owasp.org, example.com",
+		"AMASS_MAX_DNS_QUERIES=2000",
+		"AMASS_OPTIONS_PORT_SCAN__PORTS=[22, 8443]",
+		"AMASS_OPTIONS_API__LISTEN=:9000",
+		"HOME=/root",
+	})
+	if err != nil {
+		t.Fatalf("Failed to apply the environment: %v", err)
+	}
+	if len(cfg.Domains()) != 2 || cfg.MaxDNSQueries != 2000 {
+		t.Errorf("Unexpected settings: %v %d", cfg.Domains(), cfg.MaxDNSQueries)
+	}
+	if ports, ok := cfg.Options["port_scan"].(map[string]interface{})["ports"].([]interface{}); !ok || len(ports) != 2 || ports[0] != 22 {
+		t.Errorf("Unexpected port_scan options: %v", cfg.Options["port_scan"])
+	}
+	if cfg.Options["api"].(map[string]interface{})["listen"] != ":9000" {
+		t.Errorf("Unexpected api options: %v", cfg.Options["api"])
+	}
+
+	if err := ApplyEnv(cfg, []string{"AMASS_ACTIVE=maybe"}); err == nil {
+		t.Error("ApplyEnv accepted an invalid boolean")
+	}
+}
+
+func TestRedact(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Options["notifications"] = map[string]interface{}{"slack": map[string]interface{}{"url": "https://hooks.slack.com/services/X"}}
+	cfg.Options["threat_intel"] = map[string]interface{}{"api_key": "1234", "feeds": []interface{}{"a", "b"}}
+	cfg.Options["cloud_accounts"] = map[string]interface{}{"prod": map[string]interface{}{"provider": "aws", "access_key": "AKIDEXAMPLE"}}
+
+	values := Flatten(cfg)
+	if v := values["options.notifications.slack.url"]; v != "[redacted]" {
+		t.Errorf("The webhook URL was not redacted: %s", v)
+	}
+	if v := values["options.threat_intel.api_key"]; v != "[redacted]" {
+		t.Errorf("The API key was not redacted: %s", v)
+	}
+	if v := values["options.cloud_accounts.prod.access_key"]; v != "[redacted]" {
+		t.Errorf("The access key was not redacted: %s", v)
+	}
+	if v := values["options.cloud_accounts.prod.provider"]; v != "aws" {
+		t.Errorf("Unexpected provider value: %s", v)
+	}
+	if v := values["options.threat_intel.feeds"]; v != "a,b" {
+		t.Errorf("Unexpected list value: %s", v)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Active = true
+	if p, err := ApplyProfile(cfg, ""); err != nil || p != nil {
+		t.Errorf("Expected no profile without the option: %v %v", p, err)
+	}
+
+	p, err := ApplyProfile(cfg, "Passive")
+	if err != nil {
+		t.Fatalf("Failed to apply the passive profile: %v", err)
+	}
+	if !cfg.Passive || cfg.Active || cfg.BruteForcing || cfg.Options[ProfileKey] != "passive" {
+		t.Errorf("Unexpected settings for the passive profile: %+v", cfg.Options)
+	}
+	if p.Allows("dns") || !p.Allows("api") {
+		t.Error("Unexpected data source types allowed by the passive profile")
+	}
+	cfg.Active = true
+	if err := p.Check(cfg); err == nil {
+		t.Error("The active mode was accepted by the passive profile")
+	}
+
+	// The profile option selects the profile applied by the loader
+	cfg = config.NewConfig()
+	cfg.Options[ProfileKey] = "aggressive"
+	l := NewLoader("enum", cfg)
+	if err := l.ApplyProfile(""); err != nil {
+		t.Fatalf("Failed to apply the aggressive profile: %v", err)
+	}
+	if !cfg.Active || !cfg.BruteForcing || !cfg.Alterations {
+		t.Error("The aggressive profile did not enable the active mode, brute forcing and alterations")
+	}
+	for _, v := range l.Values() {
+		if v.Key == "brute_forcing" && v.Layer != ProfileLayer {
+			t.Errorf("brute_forcing was attributed to the %s layer", v.Layer)
+		}
+	}
+
+	if _, err := ApplyProfile(cfg, "stealthy"); err == nil {
+		t.Error("An unknown profile was accepted")
+	}
+	cfg.Options[ProfileKey] = 3
+	if _, err := ProfileFromConfig(cfg); err == nil {
+		t.Error("A profile option that is not a string was accepted")
+	}
+}
+
+func TestCheckSession(t *testing.T) {
+	accepted := `
+scope:
+  domains:
+    - owasp.org
+options:
+  resolvers:
+    - 8.8.8.8
+  bruteforce:
+    enabled: true
+  budget:
+    runtime: 1h
+  plugins:
+    Crtsh:
+      enabled: false
+`
+	if err := CheckSession(accepted); err != nil {
+		t.Errorf("The session configuration was refused: %v", err)
+	}
+	if err := CheckSession(""); err != nil {
+		t.Errorf("The empty session configuration was refused: %v", err)
+	}
+
+	for _, refused := range []string{
+		"options:\n  replay:\n    mode: record\n    file: /etc/cron.d/amass\n",
+		"options:\n  datasources: /etc/amass/datasources.yaml\n",
+		"options:\n  resolvers:\n    - /etc/passwd\n",
+		"options:\n  bruteforce:\n    wordlists:\n      - /etc/shadow\n",
+		"options:\n  port_scan:\n    import: /tmp/masscan.json\n",
+		"options:\n  geoip:\n    path: /tmp/GeoLite2-City.mmdb\n",
+		"options:\n  commands:\n    enum:\n      replay:\n        mode: replay\n",
+		"filepath: /etc/passwd\n",
+		"options: [",
+	} {
+		if err := CheckSession(refused); err == nil {
+			t.Errorf("Expected the session configuration to be refused: %q", refused)
+		}
+	}
+
+	err := CheckSession("options:\n  datasets:\n    psl:\n      refresh: 1h\n  alterations:\n    wordlists: [a.txt]\n")
+	if err == nil || err.Error() != "the session configuration cannot set options.alterations.wordlists, options.datasets" {
+		t.Errorf("Unexpected error naming the refused settings: %v", err)
+	}
+}
+
+func TestStripSession(t *testing.T) {
+	stripped, dropped, err := StripSession(testConfig + "  datasources: ./datasources.yaml\n  resolvers:\n    - 8.8.8.8\n    - ./resolvers.txt\n")
+	if err != nil {
+		t.Fatalf("Failed to strip the session configuration: %v", err)
+	}
+	if len(dropped) != 3 || dropped[0] != "options.commands" || dropped[1] != "options.datasources" {
+		t.Errorf("Unexpected settings removed from the session configuration: %v", dropped)
+	}
+	if err := CheckSession(stripped); err != nil {
+		t.Errorf("The stripped configuration was refused: %v", err)
+	}
+	if !strings.Contains(stripped, "owasp.org") || !strings.Contains(stripped, "8.8.8.8") || strings.Contains(stripped, "resolvers.txt") {
+		t.Errorf("Unexpected stripped configuration: %s", stripped)
+	}
+}
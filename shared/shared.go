@@ -0,0 +1,200 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package shared allows the data sources to publish intermediate analysis, such as the wildcard
+// status of a domain or the CDN serving an address, so other data sources can use the results
+// without deriving them again. Results are cached for the enumeration session.
+package shared
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Wildcard is the topic of the DNS wildcard status published by the enumeration.
+// The entries are keyed by the subdomain name and have a bool value.
+const Wildcard = "wildcard"
+
+// DanglingCNAME is the topic of the CNAME records pointing to names that do not exist, published by the
+// enumeration. The entries are keyed by the aliased name and have the target name as a string value.
+const DanglingCNAME = "dangling_cname"
+
+// Entry is a result published on the board.
+type Entry struct {
+	Topic  string
+	Key    string
+	Value  interface{}
+	Source string
+	Time   time.Time
+}
+
+// Handler receives the entries published to a subscribed topic.
+type Handler func(e *Entry)
+
+type subscription struct {
+	id      int
+	source  string
+	handler Handler
+}
+
+// Board caches the results published during an enumeration and delivers them to the subscribers.
+// All the methods are safe to call on a nil Board.
+type Board struct {
+	sync.Mutex
+	entries map[string]map[string]*Entry
+	subs    map[string][]*subscription
+	nextID  int
+}
+
+// NewBoard returns an empty Board.
+func NewBoard() *Board {
+	return &Board{
+		entries: make(map[string]map[string]*Entry),
+		subs:    make(map[string][]*subscription),
+	}
+}
+
+// Publish caches the entry and delivers it to the subscribers of the topic, other than the source
+// of the entry. False is returned when the same value was already published for the key.
+func (b *Board) Publish(e *Entry) bool {
+	if b == nil || e == nil || e.Topic == "" || e.Key == "" {
+		return false
+	}
+
+	topic := normalize(e.Topic)
+	entry := &Entry{
+		Topic:  topic,
+		Key:    normalize(e.Key),
+		Value:  e.Value,
+		Source: e.Source,
+		Time:   e.Time,
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	b.Lock()
+	keys, found := b.entries[topic]
+	if !found {
+		keys = make(map[string]*Entry)
+		b.entries[topic] = keys
+	}
+	if prev, found := keys[entry.Key]; found && equal(prev.Value, entry.Value) {
+		b.Unlock()
+		return false
+	}
+	keys[entry.Key] = entry
+
+	var handlers []Handler
+	for _, s := range b.subs[topic] {
+		if s.source == "" || s.source != entry.Source {
+			handlers = append(handlers, s.handler)
+		}
+	}
+	b.Unlock()
+
+	for _, h := range handlers {
+		h(entry)
+	}
+	return true
+}
+
+// Get returns the entry published for the key of the topic.
+func (b *Board) Get(topic, key string) (*Entry, bool) {
+	if b == nil {
+		return nil, false
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	e, found := b.entries[normalize(topic)][normalize(key)]
+	return e, found
+}
+
+// Entries returns the entries published to the topic, sorted by key.
+func (b *Board) Entries(topic string) []*Entry {
+	if b == nil {
+		return nil
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	var results []*Entry
+	for _, e := range b.entries[normalize(topic)] {
+		results = append(results, e)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Key < results[j].Key })
+	return results
+}
+
+// Subscribe registers the handler for the entries published to the topic. Entries published by the
+// source are not delivered back to it, and the entries already cached are delivered immediately.
+// The returned function removes the subscription.
+func (b *Board) Subscribe(topic, source string, h Handler) func() {
+	if b == nil || h == nil {
+		return func() {}
+	}
+
+	topic = normalize(topic)
+	b.Lock()
+	b.nextID++
+	sub := &subscription{id: b.nextID, source: source, handler: h}
+	b.subs[topic] = append(b.subs[topic], sub)
+
+	var cached []*Entry
+	for _, e := range b.entries[topic] {
+		if source == "" || e.Source != source {
+			cached = append(cached, e)
+		}
+	}
+	b.Unlock()
+
+	for _, e := range cached {
+		h(e)
+	}
+	return func() { b.unsubscribe(topic, sub.id) }
+}
+
+func (b *Board) unsubscribe(topic string, id int) {
+	b.Lock()
+	defer b.Unlock()
+
+	subs := b.subs[topic]
+	for i, s := range subs {
+		if s.id == id {
+			b.subs[topic] = append(subs[:i:i], subs[i+1:]...)
+			break
+		}
+	}
+}
+
+// Reset discards the cached entries, so results are not shared across enumeration sessions.
+// The subscriptions remain registered.
+func (b *Board) Reset() {
+	if b == nil {
+		return
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	b.entries = make(map[string]map[string]*Entry)
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+func equal(a, b interface{}) bool {
+	switch a.(type) {
+	case string, bool, float64, int, int64, nil:
+		return a == b
+	}
+	// Composite values are always considered to be updates
+	return false
+}
@@ -0,0 +1,73 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package shared
+
+import "testing"
+
+func TestPublishSubscribe(t *testing.T) {
+	b := NewBoard()
+
+	var received []*Entry
+	unsub := b.Subscribe(Wildcard, "source1", func(e *Entry) { received = append(received, e) })
+
+	if !b.Publish(&Entry{Topic: Wildcard, Key: "WWW.Example.com", Value: true, Source: "source2"}) {
+		t.Errorf("the new entry was not published")
+	}
+	if b.Publish(&Entry{Topic: Wildcard, Key: "www.example.com", Value: true, Source: "source3"}) {
+		t.Errorf("the repeated value was published again")
+	}
+	// Entries are not delivered back to the source
+	b.Publish(&Entry{Topic: Wildcard, Key: "api.example.com", Value: false, Source: "source1"})
+	b.Publish(&Entry{Topic: "other", Key: "www.example.com", Value: "cdn", Source: "source2"})
+
+	if len(received) != 1 || received[0].Key != "www.example.com" || received[0].Value != true {
+		t.Fatalf("unexpected entries were delivered: %+v", received)
+	}
+	if e, found := b.Get("WILDCARD", "api.example.com"); !found || e.Value != false || e.Time.IsZero() {
+		t.Errorf("the entry was not cached: %+v", e)
+	}
+	if entries := b.Entries(Wildcard); len(entries) != 2 || entries[0].Key != "api.example.com" {
+		t.Errorf("unexpected entries for the topic: %+v", entries)
+	}
+
+	unsub()
+	b.Publish(&Entry{Topic: Wildcard, Key: "www.example.com", Value: false, Source: "source2"})
+	if len(received) != 1 {
+		t.Errorf("an entry was delivered after the subscription was removed")
+	}
+}
+
+func TestSubscribeCached(t *testing.T) {
+	b := NewBoard()
+	b.Publish(&Entry{Topic: Wildcard, Key: "www.example.com", Value: true, Source: "source1"})
+
+	var received int
+	b.Subscribe(Wildcard, "source2", func(e *Entry) { received++ })
+	if received != 1 {
+		t.Errorf("the cached entries were not delivered to the new subscriber")
+	}
+
+	b.Reset()
+	if _, found := b.Get(Wildcard, "www.example.com"); found {
+		t.Errorf("the entries were kept after the reset")
+	}
+	b.Publish(&Entry{Topic: Wildcard, Key: "www.example.com", Value: true, Source: "source1"})
+	if received != 2 {
+		t.Errorf("the subscription was not kept after the reset")
+	}
+}
+
+func TestNilBoard(t *testing.T) {
+	var b *Board
+
+	if b.Publish(&Entry{Topic: Wildcard, Key: "www.example.com", Value: true}) {
+		t.Errorf("a nil board published the entry")
+	}
+	if _, found := b.Get(Wildcard, "www.example.com"); found {
+		t.Errorf("a nil board returned an entry")
+	}
+	b.Subscribe(Wildcard, "", func(e *Entry) {})()
+	b.Reset()
+}
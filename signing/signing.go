@@ -0,0 +1,258 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package signing produces and verifies detached Ed25519 signatures of the exported reports and
+// archives, so the recipients can prove the files were not modified after they were produced.
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// Ext is appended to the path of a file to name the file holding its signature.
+const Ext = ".sig"
+
+// Algorithm identifies the signatures produced by this package.
+const Algorithm = "ed25519"
+
+// Signature is the detached signature of a file.
+type Signature struct {
+	Algorithm string    `json:"algorithm"`
+	File      string    `json:"file"`
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256"`
+	KeyID     string    `json:"key_id"`
+	Signed    time.Time `json:"signed"`
+	Signature string    `json:"signature"`
+}
+
+// The signed message binds the digest and size of the file to the time of the signature.
+func (s *Signature) message() []byte {
+	return []byte("amass-signature-v1\n" + s.SHA256 + "\n" +
+		strconv.FormatInt(s.Size, 10) + "\n" + s.Signed.UTC().Format(time.RFC3339) + "\n")
+}
+
+// KeyID returns the identifier of the public key, which is recorded in the signatures.
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// GenerateKey writes a new private key to privPath, readable only by the owner, and its public key to pubPath.
+func GenerateKey(privPath, pubPath string) (ed25519.PublicKey, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeNew(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0600); err != nil {
+		return nil, err
+	}
+	if err := writeNew(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0644); err != nil {
+		return nil, err
+	}
+	return pub, nil
+}
+
+// writeNew refuses to overwrite an existing key.
+func writeNew(path string, data []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(data)
+	return err
+}
+
+// LoadPrivateKey returns the Ed25519 private key in the PEM file at path.
+func LoadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEM(path, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the private key in %s: %v", path, err)
+	}
+
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s does not hold an Ed25519 private key", path)
+	}
+	return priv, nil
+}
+
+// LoadPublicKey returns the Ed25519 public key in the PEM file at path.
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEM(path, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the public key in %s: %v", path, err)
+	}
+
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s does not hold an Ed25519 public key", path)
+	}
+	return pub, nil
+}
+
+func readPEM(path, blockType string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != blockType {
+		return nil, fmt.Errorf("%s does not hold a PEM encoded %s", path, blockType)
+	}
+	return block, nil
+}
+
+func digest(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// SignFile signs the file at path and writes the signature next to it, using the Ext extension.
+func SignFile(path string, priv ed25519.PrivateKey) (*Signature, error) {
+	sum, size, err := digest(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Signature{
+		Algorithm: Algorithm,
+		File:      filepath.Base(path),
+		Size:      size,
+		SHA256:    sum,
+		KeyID:     KeyID(priv.Public().(ed25519.PublicKey)),
+		Signed:    time.Now().UTC().Truncate(time.Second),
+	}
+	s.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(priv, s.message()))
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path+Ext, append(data, '\n'), 0644); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ErrTampered is returned when the file does not match its signature.
+var ErrTampered = errors.New("the file does not match the signature")
+
+// VerifyFile checks the file at path against the signature in sigPath, or in the file
+// next to it when sigPath is empty, using the public key. ErrTampered is returned when
+// the file was modified after it was signed.
+func VerifyFile(path, sigPath string, pub ed25519.PublicKey) (*Signature, error) {
+	if sigPath == "" {
+		sigPath = path + Ext
+	}
+
+	data, err := os.ReadFile(sigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Signature
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse the signature %s: %v", sigPath, err)
+	}
+	if s.Algorithm != Algorithm {
+		return nil, fmt.Errorf("the signature algorithm %s is not supported", s.Algorithm)
+	}
+	if id := KeyID(pub); s.KeyID != id {
+		return nil, fmt.Errorf("the file was signed with the key %s, not the key %s", s.KeyID, id)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(s.Signature)
+	if err != nil || !ed25519.Verify(pub, s.message(), sig) {
+		return nil, fmt.Errorf("the signature %s is not valid for the key %s", sigPath, s.KeyID)
+	}
+
+	sum, size, err := digest(path)
+	if err != nil {
+		return nil, err
+	}
+	if sum != s.SHA256 || size != s.Size {
+		return &s, ErrTampered
+	}
+	return &s, nil
+}
+
+// FromConfig returns the private key in the file provided by the 'private_key' key of the 'signing'
+// section of the configuration options. A nil key is returned when signing has not been configured.
+func FromConfig(cfg *config.Config) (ed25519.PrivateKey, error) {
+	signRaw, ok := cfg.Options["signing"]
+	if !ok {
+		return nil, nil
+	}
+
+	settings, ok := signRaw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("signing is not a map[string]interface{}")
+	}
+
+	raw, ok := settings["private_key"]
+	if !ok {
+		return nil, nil
+	}
+
+	path, ok := raw.(string)
+	if !ok || path == "" {
+		return nil, fmt.Errorf("signing private_key is not a path")
+	}
+
+	priv, err := LoadPrivateKey(path)
+	if err != nil {
+		return nil, fmt.Errorf("signing private_key: %v", err)
+	}
+	return priv, nil
+}
@@ -0,0 +1,105 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package signing
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	privPath := filepath.Join(dir, "amass.key")
+	pubPath := filepath.Join(dir, "amass.pub")
+
+	pub, err := GenerateKey(privPath, pubPath)
+	if err != nil {
+		t.Fatalf("GenerateKey returned an error: %v", err)
+	}
+	if _, err := GenerateKey(privPath, pubPath); err == nil {
+		t.Errorf("GenerateKey overwrote the existing key")
+	}
+	if info, err := os.Stat(privPath); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("the private key was not restricted to the owner: %v", err)
+	}
+
+	priv, err := LoadPrivateKey(privPath)
+	if err != nil {
+		t.Fatalf("LoadPrivateKey returned an error: %v", err)
+	}
+	loaded, err := LoadPublicKey(pubPath)
+	if err != nil || !loaded.Equal(pub) {
+		t.Fatalf("LoadPublicKey did not return the generated key: %v", err)
+	}
+	if _, err := LoadPublicKey(privPath); err == nil {
+		t.Errorf("LoadPublicKey accepted the private key file")
+	}
+
+	path := filepath.Join(dir, "report.html")
+	if err := os.WriteFile(path, []byte("<html>owasp.org</html>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := SignFile(path, priv)
+	if err != nil {
+		t.Fatalf("SignFile returned an error: %v", err)
+	}
+	if s.File != "report.html" || s.KeyID != KeyID(pub) || s.Size != 22 {
+		t.Errorf("SignFile returned %+v", s)
+	}
+	if _, err := VerifyFile(path, "", pub); err != nil {
+		t.Errorf("VerifyFile rejected the signed file: %v", err)
+	}
+
+	// A signature moved along with the file is still verified
+	moved := filepath.Join(dir, "delivered.html")
+	if err := os.Rename(path, moved); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyFile(moved, path+Ext, pub); err != nil {
+		t.Errorf("VerifyFile rejected the moved file: %v", err)
+	}
+
+	if err := os.WriteFile(moved, []byte("<html>0wasp.org</html>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyFile(moved, path+Ext, pub); err != ErrTampered {
+		t.Errorf("VerifyFile returned %v for the modified file", err)
+	}
+
+	other, err := GenerateKey(filepath.Join(dir, "other.key"), filepath.Join(dir, "other.pub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyFile(moved, path+Ext, other); err == nil || err == ErrTampered {
+		t.Errorf("VerifyFile returned %v for another key", err)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	if priv, err := FromConfig(cfg); priv != nil || err != nil {
+		t.Errorf("FromConfig returned %v, %v without the signing section", priv, err)
+	}
+
+	dir := t.TempDir()
+	privPath := filepath.Join(dir, "amass.key")
+	if _, err := GenerateKey(privPath, filepath.Join(dir, "amass.pub")); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Options["signing"] = map[string]interface{}{"private_key": privPath}
+	if priv, err := FromConfig(cfg); priv == nil || err != nil {
+		t.Errorf("FromConfig did not load the private key: %v", err)
+	}
+
+	cfg.Options["signing"] = map[string]interface{}{"private_key": filepath.Join(dir, "missing.key")}
+	if _, err := FromConfig(cfg); err == nil {
+		t.Errorf("FromConfig accepted a missing private key")
+	}
+}
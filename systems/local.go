@@ -17,9 +17,20 @@ import (
 
 	"github.com/caffix/netmap"
 	"github.com/caffix/service"
+	"github.com/owasp-amass/amass/v4/budget"
+	"github.com/owasp-amass/amass/v4/dbcrypt"
+	"github.com/owasp-amass/amass/v4/evidence"
+	"github.com/owasp-amass/amass/v4/findings"
 	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/owasp-amass/amass/v4/net/browser"
+	"github.com/owasp-amass/amass/v4/notify"
+	"github.com/owasp-amass/amass/v4/quota"
+	"github.com/owasp-amass/amass/v4/replay"
 	"github.com/owasp-amass/amass/v4/requests"
 	"github.com/owasp-amass/amass/v4/resources"
+	"github.com/owasp-amass/amass/v4/schema"
+	"github.com/owasp-amass/amass/v4/shared"
+	"github.com/owasp-amass/amass/v4/throttle"
 	"github.com/owasp-amass/config/config"
 	"github.com/owasp-amass/resolve"
 )
@@ -31,6 +42,15 @@ type LocalSystem struct {
 	trusted           *resolve.Resolvers
 	graphs            []*netmap.Graph
 	cache             *requests.ASNCache
+	budget            *budget.Budget
+	quotas            *quota.Tracker
+	findings          *findings.Store
+	evidence          *evidence.Store
+	paging            *notify.Paging
+	replay            *replay.Cassette
+	browser           *browser.Browser
+	board             *shared.Board
+	dbKey             string
 	done              chan struct{}
 	doneAlreadyClosed bool
 	addSource         chan service.Service
@@ -43,13 +63,45 @@ func NewLocalSystem(cfg *config.Config) (*LocalSystem, error) {
 		return nil, err
 	}
 
+	limits, err := budget.FromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	quotas, err := quota.FromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// The data sources configure their limiters once started
+	if _, err := throttle.FromConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	headless, err := browser.FromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	paging, err := notify.PagingFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// The resolvers are pointed at the replay DNS server before the pools are built
+	cassette, err := startReplay(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	trusted, num := trustedResolvers(cfg)
 	if trusted == nil || num == 0 {
+		stopReplay(cfg, cassette)
 		return nil, errors.New("the system was unable to build the pool of trusted resolvers")
 	}
 
 	pool, num := untrustedResolvers(cfg)
 	if pool == nil || num == 0 {
+		stopReplay(cfg, cassette)
 		return nil, errors.New("the system was unable to build the pool of untrusted resolvers")
 	}
 	if cfg.MaxDNSQueries == 0 {
@@ -67,6 +119,12 @@ func NewLocalSystem(cfg *config.Config) (*LocalSystem, error) {
 		pool:       pool,
 		trusted:    trusted,
 		cache:      requests.NewASNCache(),
+		budget:     limits,
+		quotas:     quotas,
+		browser:    headless,
+		paging:     paging,
+		replay:     cassette,
+		board:      shared.NewBoard(),
 		done:       make(chan struct{}, 2),
 		addSource:  make(chan service.Service),
 		allSources: make(chan chan []service.Service, 10),
@@ -82,6 +140,13 @@ func NewLocalSystem(cfg *config.Config) (*LocalSystem, error) {
 		_ = sys.Shutdown()
 		return nil, err
 	}
+	// Page the responders for the new findings at the configured severities
+	if paging != nil {
+		if cfg.Log != nil {
+			paging.Log = cfg.Log.Printf
+		}
+		paging.Subscribe(sys.findings)
+	}
 	// Setup the correct graph database handler
 	if err := sys.setupGraphDBs(cfg); err != nil {
 		_ = sys.Shutdown()
@@ -112,6 +177,36 @@ func (l *LocalSystem) Cache() *requests.ASNCache {
 	return l.cache
 }
 
+// Budget implements the System interface.
+func (l *LocalSystem) Budget() *budget.Budget {
+	return l.budget
+}
+
+// Quotas implements the System interface.
+func (l *LocalSystem) Quotas() *quota.Tracker {
+	return l.quotas
+}
+
+// Findings implements the System interface.
+func (l *LocalSystem) Findings() *findings.Store {
+	return l.findings
+}
+
+// Evidence implements the System interface.
+func (l *LocalSystem) Evidence() *evidence.Store {
+	return l.evidence
+}
+
+// Browser implements the System interface.
+func (l *LocalSystem) Browser() *browser.Browser {
+	return l.browser
+}
+
+// Shared implements the System interface.
+func (l *LocalSystem) Shared() *shared.Board {
+	return l.board
+}
+
 // AddSource implements the System interface.
 func (l *LocalSystem) AddSource(src service.Service) error {
 	l.addSource <- src
@@ -190,9 +285,23 @@ func (l *LocalSystem) Shutdown() error {
 		//g.Close()
 	}
 
+	l.paging.Wait()
+	l.browser.Close()
 	l.pool.Stop()
 	l.trusted.Stop()
+	stopReplay(l.Cfg, l.replay)
 	l.cache = nil
+
+	if l.dbKey != "" {
+		// The callers ignore the error, so the failure to encrypt the database is logged as well
+		if err := dbcrypt.Lock(config.OutputDirectory(l.Cfg.Dir)); err != nil {
+			err = fmt.Errorf("System: failed to encrypt the graph database: %v", err)
+			if l.Cfg.Log != nil {
+				l.Cfg.Log.Print(err.Error())
+			}
+			return err
+		}
+	}
 	return nil
 }
 
@@ -208,7 +317,13 @@ func (l *LocalSystem) setupOutputDirectory() error {
 		return nil
 	}
 
-	return nil
+	l.findings, err = findings.NewStore(filepath.Join(path, "findings.json"))
+	if err != nil {
+		return err
+	}
+
+	l.evidence, err = evidence.FromConfig(l.Cfg)
+	return err
 }
 
 // Select the graph that will store the System findings.
@@ -218,14 +333,35 @@ func (l *LocalSystem) setupGraphDBs(cfg *config.Config) error {
 
 	for _, db := range cfg.GraphDBs {
 		if db.Primary {
-			var g *netmap.Graph
+			// The graph is only provided by the repositories of asset-db for PostgreSQL and SQLite
+			if db.System != "local" && db.System != "postgres" {
+				return fmt.Errorf("System: the %s graph database is not supported, use postgres or the local database", db.System)
+			}
 
-			if db.System == "local" {
-				g = netmap.NewGraph(db.System, filepath.Join(config.OutputDirectory(cfg.Dir), "amass.sqlite"), db.Options)
+			dir := config.OutputDirectory(cfg.Dir)
+			dsn := filepath.Join(dir, dbcrypt.DatabaseFile)
+			if db.System != "local" {
+				dsn = fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s", db.Host, db.Port, db.Username, db.Password, db.DBName)
 			} else {
-				connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s", db.Host, db.Port, db.Username, db.Password, db.DBName)
-				g = netmap.NewGraph(db.System, connStr, db.Options)
+				opts, err := schema.SQLiteOptionsFromConfig(cfg)
+				if err != nil {
+					return fmt.Errorf("System: %v", err)
+				}
+				if path, err := l.unlockGraphDB(dir); err != nil {
+					return fmt.Errorf("System: %v", err)
+				} else if path != "" {
+					dsn = path
+				}
+				dsn = opts.DSN(dsn)
 			}
+			// Databases created by earlier builds are upgraded before netmap applies the migrations
+			if schema.Versioned(db.System) {
+				if _, _, err := schema.Upgrade(db.System, dsn); err != nil {
+					return fmt.Errorf("System: %v", err)
+				}
+			}
+
+			g := netmap.NewGraph(db.System, dsn, db.Options)
 
 			if g == nil {
 				return fmt.Errorf("System: failed to create the graph for database: %s", db.System)
@@ -242,6 +378,23 @@ func (l *LocalSystem) setupGraphDBs(cfg *config.Config) error {
 	return nil
 }
 
+// unlockGraphDB decrypts the local graph database into memory, when the encryption has been enabled by
+// the configuration, and returns the path used to open it. The database is encrypted again once the
+// system is shutdown.
+func (l *LocalSystem) unlockGraphDB(dir string) (string, error) {
+	key, err := dbcrypt.FromConfig(l.Cfg)
+	if err != nil || key == "" {
+		return "", err
+	}
+
+	path, err := dbcrypt.Unlock(dir, key)
+	if err != nil {
+		return "", err
+	}
+	l.dbKey = key
+	return path, nil
+}
+
 // GetMemoryUsage returns the number bytes allocated to heap objects on this system.
 func (l *LocalSystem) GetMemoryUsage() uint64 {
 	var m runtime.MemStats
@@ -302,7 +455,12 @@ func trustedResolvers(cfg *config.Config) (*resolve.Resolvers, int) {
 	}
 
 	_ = pool.AddResolvers(cfg.TrustedQPS, trusted...)
-	pool.SetDetectionResolver(cfg.TrustedQPS, "8.8.8.8")
+	// Wildcard detection is performed by the trusted resolvers provided by the user
+	detector := "8.8.8.8"
+	if len(cfg.TrustedResolvers) > 0 {
+		detector = cfg.TrustedResolvers[0]
+	}
+	pool.SetDetectionResolver(cfg.TrustedQPS, detector)
 
 	pool.SetLogger(cfg.Log)
 	pool.SetTimeout(2 * time.Second)
@@ -6,7 +6,10 @@ package systems
 
 import (
 	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/owasp-amass/config/config"
 )
 
 func TestCheckAddresses(t *testing.T) {
@@ -56,3 +59,20 @@ func TestCheckAddresses(t *testing.T) {
 		})
 	}
 }
+
+func TestSetupGraphDBsUnsupported(t *testing.T) {
+	for _, system := range []string{"mysql", "mariadb"} {
+		cfg := config.NewConfig()
+		cfg.Dir = t.TempDir()
+		cfg.GraphDBs = []*config.Database{{System: system, Primary: true, Host: "localhost", Port: "3306", DBName: "assetdb"}}
+
+		l := &LocalSystem{Cfg: cfg}
+		err := l.setupGraphDBs(cfg)
+		if err == nil || !strings.Contains(err.Error(), system+" graph database is not supported") {
+			t.Errorf("Expected the %s graph database to be refused, got %v", system, err)
+		}
+		if len(l.graphs) != 0 {
+			t.Errorf("A graph was created for the %s database", system)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/replay"
+	"github.com/owasp-amass/config/config"
+)
+
+// startReplay opens the cassette in the 'replay' section of the configuration, intercepts the HTTP
+// client shared by the data sources, and replaces the resolvers with the replay DNS server. While
+// recording, the queries are forwarded to the resolvers that were configured.
+func startReplay(cfg *config.Config) (*replay.Cassette, error) {
+	settings, err := replay.FromConfig(cfg)
+	if err != nil || settings == nil {
+		return nil, err
+	}
+
+	c, err := replay.Open(settings.Path, settings.Mode)
+	if err != nil {
+		return nil, err
+	}
+	c.Redact(credentialSecrets(cfg)...)
+
+	upstreams := checkAddresses(append(append([]string{}, cfg.TrustedResolvers...), cfg.Resolvers...))
+	if len(upstreams) == 0 {
+		upstreams = checkAddresses(config.DefaultBaselineResolvers)
+	}
+
+	addr, err := c.StartDNS(upstreams)
+	if err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+	cfg.Resolvers = []string{addr}
+	cfg.TrustedResolvers = []string{addr}
+	c.Intercept(amasshttp.DefaultClient)
+
+	if cfg.Log != nil {
+		cfg.Log.Printf("Replay: the session is in %s mode using the cassette %s", settings.Mode, settings.Path)
+	}
+	return c, nil
+}
+
+// stopReplay closes the cassette and restores the HTTP client shared by the data sources.
+func stopReplay(cfg *config.Config, c *replay.Cassette) {
+	if c == nil {
+		return
+	}
+
+	if err := c.Close(); err != nil && cfg.Log != nil {
+		cfg.Log.Printf("Replay: %v", err)
+	}
+	if cfg.Log != nil {
+		recorded, replayed, misses := c.Stats()
+		cfg.Log.Printf("Replay: %d interactions recorded, %d replayed and %d missing from the cassette", recorded, replayed, misses)
+	}
+}
+
+// credentialSecrets returns the API keys, secrets and passwords of the data sources, which are
+// redacted from the cassette.
+func credentialSecrets(cfg *config.Config) []string {
+	var secrets []string
+
+	if cfg.DataSrcConfigs == nil {
+		return secrets
+	}
+	for _, src := range cfg.DataSrcConfigs.Datasources {
+		for _, creds := range src.Creds {
+			if creds == nil {
+				continue
+			}
+			secrets = append(secrets, creds.Apikey, creds.Secret, creds.Password)
+		}
+	}
+	return secrets
+}
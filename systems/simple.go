@@ -9,7 +9,13 @@ import (
 
 	"github.com/caffix/netmap"
 	"github.com/caffix/service"
+	"github.com/owasp-amass/amass/v4/budget"
+	"github.com/owasp-amass/amass/v4/evidence"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/net/browser"
+	"github.com/owasp-amass/amass/v4/quota"
 	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/shared"
 	"github.com/owasp-amass/config/config"
 	"github.com/owasp-amass/resolve"
 )
@@ -20,6 +26,12 @@ type SimpleSystem struct {
 	Trusted  *resolve.Resolvers
 	Graph    *netmap.Graph
 	ASNCache *requests.ASNCache
+	Limits   *budget.Budget
+	Keys     *quota.Tracker
+	Store    *findings.Store
+	Blobs    *evidence.Store
+	Headless *browser.Browser
+	Board    *shared.Board
 	Service  service.Service
 }
 
@@ -35,6 +47,24 @@ func (ss *SimpleSystem) TrustedResolvers() *resolve.Resolvers { return ss.Truste
 // Cache implements the System interface.
 func (ss *SimpleSystem) Cache() *requests.ASNCache { return ss.ASNCache }
 
+// Budget implements the System interface.
+func (ss *SimpleSystem) Budget() *budget.Budget { return ss.Limits }
+
+// Quotas implements the System interface.
+func (ss *SimpleSystem) Quotas() *quota.Tracker { return ss.Keys }
+
+// Findings implements the System interface.
+func (ss *SimpleSystem) Findings() *findings.Store { return ss.Store }
+
+// Evidence implements the System interface.
+func (ss *SimpleSystem) Evidence() *evidence.Store { return ss.Blobs }
+
+// Browser implements the System interface.
+func (ss *SimpleSystem) Browser() *browser.Browser { return ss.Headless }
+
+// Shared implements the System interface.
+func (ss *SimpleSystem) Shared() *shared.Board { return ss.Board }
+
 // AddSource implements the System interface.
 func (ss *SimpleSystem) AddSource(src service.Service) error { ss.Service = src; return nil }
 
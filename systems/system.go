@@ -10,7 +10,13 @@ import (
 
 	"github.com/caffix/netmap"
 	"github.com/caffix/service"
+	"github.com/owasp-amass/amass/v4/budget"
+	"github.com/owasp-amass/amass/v4/evidence"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/amass/v4/net/browser"
+	"github.com/owasp-amass/amass/v4/quota"
 	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/shared"
 	"github.com/owasp-amass/config/config"
 	"github.com/owasp-amass/resolve"
 )
@@ -29,6 +35,24 @@ type System interface {
 	// Returns the cache populated by the system
 	Cache() *requests.ASNCache
 
+	// Returns the resource budget enforced by the system, which is nil when unlimited
+	Budget() *budget.Budget
+
+	// Returns the tracker of the API keys and quotas used by the data sources, which is nil when not tracked
+	Quotas() *quota.Tracker
+
+	// Returns the store for the findings produced by the system, which is nil when discarded
+	Findings() *findings.Store
+
+	// Returns the store for the raw data backing the findings, which is nil when discarded
+	Evidence() *evidence.Store
+
+	// Returns the headless browser shared by the data sources, which is nil when not enabled
+	Browser() *browser.Browser
+
+	// Returns the board used by the data sources to share intermediate analysis
+	Shared() *shared.Board
+
 	// AddSource appends the provided data source to the slice of sources managed by the System
 	AddSource(srv service.Service) error
 
@@ -0,0 +1,115 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package threatintel
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/owasp-amass/amass/v4/datasets"
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/config/config"
+)
+
+// DefaultSeverity is assigned to the matches of feeds without a configured severity.
+const DefaultSeverity = findings.Medium
+
+// FromConfig loads the feeds in the 'threat_intel' section of the configuration options.
+// Each feed is read from a local file, or from a dataset that is downloaded and cached.
+func FromConfig(ctx context.Context, cfg *config.Config) ([]*Feed, error) {
+	intelRaw, ok := cfg.Options["threat_intel"]
+	if !ok {
+		return nil, nil
+	}
+
+	feeds, ok := intelRaw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("threat_intel is not a map[string]interface{}")
+	}
+
+	var names []string
+	for name := range feeds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var mgr *datasets.Manager
+	var results []*Feed
+	for _, name := range names {
+		settings, ok := feeds[name].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("threat_intel %s is not a map[string]interface{}", name)
+		}
+
+		format, err := optionalString(settings, name, "format")
+		if err != nil {
+			return nil, err
+		}
+		path, err := optionalString(settings, name, "path")
+		if err != nil {
+			return nil, err
+		}
+		dataset, err := optionalString(settings, name, "dataset")
+		if err != nil {
+			return nil, err
+		}
+		sevname, err := optionalString(settings, name, "severity")
+		if err != nil {
+			return nil, err
+		}
+
+		sev := DefaultSeverity
+		if sevname != "" {
+			if sev, err = findings.ParseSeverity(sevname); err != nil {
+				return nil, fmt.Errorf("threat_intel %s severity: %v", name, err)
+			}
+		}
+
+		if path == "" && dataset == "" {
+			return nil, fmt.Errorf("threat_intel %s must provide a path or dataset", name)
+		} else if path == "" {
+			if mgr == nil {
+				if mgr, err = datasets.FromConfig(cfg); err != nil {
+					return nil, err
+				}
+			}
+			if path, err = mgr.Get(ctx, dataset); err != nil {
+				return nil, fmt.Errorf("threat_intel %s: %v", name, err)
+			}
+		}
+
+		f, err := loadFeed(name, format, sev, path)
+		if err != nil {
+			return nil, fmt.Errorf("threat_intel %s: %v", name, err)
+		}
+		results = append(results, f)
+	}
+	return results, nil
+}
+
+func loadFeed(name, format string, sev findings.Severity, path string) (*Feed, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseFeed(name, format, sev, file)
+}
+
+func optionalString(settings map[string]interface{}, feed, key string) (string, error) {
+	raw, ok := settings[key]
+	if !ok {
+		return "", nil
+	}
+
+	str, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("threat_intel %s %s is not a string", feed, key)
+	}
+	return str, nil
+}
@@ -0,0 +1,221 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package threatintel checks the discovered names and addresses against threat intelligence feeds,
+// so assets that are already compromised or abused can be spotted in the external footprint.
+package threatintel
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/netip"
+	"regexp"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/findings"
+)
+
+// The formats of the feeds that can be loaded.
+const (
+	// MISPFormat is a MISP warninglist in JSON
+	MISPFormat = "misp"
+	// ListFormat is a text file providing a name, address or CIDR at the start of each line
+	ListFormat = "list"
+)
+
+// Feed holds the indicators provided by a single threat intelligence feed.
+type Feed struct {
+	Name     string
+	Severity findings.Severity
+	// Names match the indicator and all the subdomains of the indicator
+	names      map[string]struct{}
+	substrings []string
+	regexps    []*regexp.Regexp
+	addrs      map[netip.Addr]struct{}
+	prefixes   []netip.Prefix
+}
+
+func newFeed(name string, sev findings.Severity) *Feed {
+	return &Feed{
+		Name:     name,
+		Severity: sev,
+		names:    make(map[string]struct{}),
+		addrs:    make(map[netip.Addr]struct{}),
+	}
+}
+
+// Len returns the number of indicators provided by the feed.
+func (f *Feed) Len() int {
+	return len(f.names) + len(f.substrings) + len(f.regexps) + len(f.addrs) + len(f.prefixes)
+}
+
+type warninglist struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Type        string   `json:"type"`
+	List        []string `json:"list"`
+}
+
+// ParseFeed reads the indicators of the feed in the provided format.
+func ParseFeed(name, format string, sev findings.Severity, r io.Reader) (*Feed, error) {
+	f := newFeed(name, sev)
+
+	switch strings.ToLower(format) {
+	case MISPFormat:
+		var wl warninglist
+		if err := json.NewDecoder(r).Decode(&wl); err != nil {
+			return nil, fmt.Errorf("failed to decode the MISP warninglist: %v", err)
+		}
+
+		for _, entry := range wl.List {
+			if err := f.addWarninglistEntry(wl.Type, entry); err != nil {
+				return nil, err
+			}
+		}
+	case ListFormat, "":
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+				continue
+			}
+			// Feeds exported as CSV provide the indicator in the first column
+			if fields := strings.FieldsFunc(line, func(c rune) bool {
+				return c == ',' || c == ';' || c == ' ' || c == '\t'
+			}); len(fields) > 0 {
+				f.addIndicator(strings.Trim(fields[0], `"'`))
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("the feed format %s is not supported", format)
+	}
+	return f, nil
+}
+
+func (f *Feed) addWarninglistEntry(wltype, entry string) error {
+	switch wltype {
+	case "substring":
+		if s := strings.ToLower(strings.TrimSpace(entry)); s != "" {
+			f.substrings = append(f.substrings, s)
+		}
+	case "regex":
+		re, err := regexp.Compile("(?i)" + entry)
+		if err != nil {
+			return fmt.Errorf("the warninglist regular expression %s is invalid: %v", entry, err)
+		}
+		f.regexps = append(f.regexps, re)
+	default:
+		// The string, hostname and cidr warninglists provide exact indicators
+		f.addIndicator(entry)
+	}
+	return nil
+}
+
+func (f *Feed) addIndicator(ind string) {
+	ind = strings.ToLower(strings.TrimSpace(ind))
+	if ind == "" {
+		return
+	}
+
+	if strings.Contains(ind, "/") {
+		if prefix, err := netip.ParsePrefix(ind); err == nil {
+			f.prefixes = append(f.prefixes, prefix.Masked())
+			return
+		}
+	}
+	if addr, err := netip.ParseAddr(ind); err == nil {
+		f.addrs[addr.Unmap()] = struct{}{}
+		return
+	}
+	// Indicators are often provided as URLs or with a leading wildcard label
+	if i := strings.Index(ind, "://"); i != -1 {
+		ind = ind[i+3:]
+	}
+	if i := strings.IndexAny(ind, "/:?"); i != -1 {
+		ind = ind[:i]
+	}
+	ind = strings.TrimPrefix(strings.TrimPrefix(ind, "*"), ".")
+	if ind = strings.TrimSuffix(ind, "."); ind != "" {
+		f.names[ind] = struct{}{}
+	}
+}
+
+// MatchName returns true when the name or one of its parent domains is listed by the feed.
+func (f *Feed) MatchName(name string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+
+	for n := name; n != ""; {
+		if _, found := f.names[n]; found {
+			return true
+		}
+
+		i := strings.Index(n, ".")
+		if i == -1 {
+			break
+		}
+		n = n[i+1:]
+	}
+	for _, s := range f.substrings {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	for _, re := range f.regexps {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchAddr returns true when the address is listed by the feed or is within a listed netblock.
+func (f *Feed) MatchAddr(addr string) bool {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return false
+	}
+
+	ip = ip.Unmap()
+	if _, found := f.addrs[ip]; found {
+		return true
+	}
+	for _, prefix := range f.prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match describes an asset listed by a feed.
+type Match struct {
+	Feed  *Feed
+	Asset string
+}
+
+// Check returns a Match for each feed listing one of the names or addresses.
+func Check(feeds []*Feed, names, addrs []string) []*Match {
+	var matches []*Match
+
+	for _, f := range feeds {
+		for _, name := range names {
+			if f.MatchName(name) {
+				matches = append(matches, &Match{Feed: f, Asset: name})
+			}
+		}
+		for _, addr := range addrs {
+			if f.MatchAddr(addr) {
+				matches = append(matches, &Match{Feed: f, Asset: addr})
+			}
+		}
+	}
+	return matches
+}
@@ -0,0 +1,136 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package threatintel
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/findings"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestParseWarninglist(t *testing.T) {
+	feeds := map[string]string{
+		"hostname":  `{"name": "Dynamic DNS", "type": "hostname", "list": ["duckdns.org", "*.no-ip.biz"]}`,
+		"cidr":      `{"name": "Sinkholes", "type": "cidr", "list": ["198.51.100.0/24", "2001:db8::1"]}`,
+		"substring": `{"name": "Phishing", "type": "substring", "list": ["-login-verify"]}`,
+		"regex":     `{"name": "Generated", "type": "regex", "list": ["^[a-z]{20}\\."]}`,
+	}
+
+	f := make(map[string]*Feed)
+	for name, data := range feeds {
+		feed, err := ParseFeed(name, MISPFormat, findings.Low, strings.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: ParseFeed returned an error: %v", name, err)
+		}
+		f[name] = feed
+	}
+
+	cases := []struct {
+		feed  string
+		asset string
+		addr  bool
+		match bool
+	}{
+		{"hostname", "owasp.duckdns.org", false, true},
+		{"hostname", "duckdns.org", false, true},
+		{"hostname", "notduckdns.org", false, false},
+		{"hostname", "www.owasp.no-ip.biz", false, true},
+		{"cidr", "198.51.100.77", true, true},
+		{"cidr", "198.51.101.1", true, false},
+		{"cidr", "2001:db8::1", true, true},
+		{"substring", "owasp-login-verify.example.com", false, true},
+		{"substring", "login.owasp.org", false, false},
+		{"regex", "abcdefghijklmnopqrst.owasp.org", false, true},
+		{"regex", "www.owasp.org", false, false},
+	}
+
+	for _, c := range cases {
+		var match bool
+		if c.addr {
+			match = f[c.feed].MatchAddr(c.asset)
+		} else {
+			match = f[c.feed].MatchName(c.asset)
+		}
+		if match != c.match {
+			t.Errorf("%s: matching %s returned %t, expected %t", c.feed, c.asset, match, c.match)
+		}
+	}
+
+	if _, err := ParseFeed("bad", MISPFormat, findings.Low, strings.NewReader(`{"type": "regex", "list": ["("]}`)); err == nil {
+		t.Errorf("ParseFeed accepted an invalid regular expression")
+	}
+}
+
+func TestParseList(t *testing.T) {
+	data := `# indicator,first_seen
+evil.example.com,2023-01-01
+"https://phish.example.net/login",2023-02-01
+192.0.2.15
+203.0.113.0/28 ; sinkhole
+
+`
+	f, err := ParseFeed("blocklist", ListFormat, findings.High, strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseFeed returned an error: %v", err)
+	}
+	if f.Len() != 4 {
+		t.Errorf("the feed provided %d indicators, expected 4", f.Len())
+	}
+
+	matches := Check([]*Feed{f},
+		[]string{"evil.example.com", "www.phish.example.net", "owasp.org"},
+		[]string{"192.0.2.15", "203.0.113.9", "203.0.113.16"},
+	)
+
+	var got []string
+	for _, m := range matches {
+		got = append(got, m.Asset)
+	}
+	if expected := "evil.example.com,www.phish.example.net,192.0.2.15,203.0.113.9"; strings.Join(got, ",") != expected {
+		t.Errorf("Check returned %v, expected %s", got, expected)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.txt")
+	if err := os.WriteFile(path, []byte("evil.example.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.NewConfig()
+	if feeds, err := FromConfig(context.Background(), cfg); feeds != nil || err != nil {
+		t.Errorf("FromConfig returned %v, %v without the threat_intel section", feeds, err)
+	}
+
+	cfg.Options["threat_intel"] = map[string]interface{}{
+		"internal": map[string]interface{}{"path": path, "severity": "high"},
+	}
+	feeds, err := FromConfig(context.Background(), cfg)
+	if err != nil || len(feeds) != 1 {
+		t.Fatalf("FromConfig returned %v, %v", feeds, err)
+	}
+	if f := feeds[0]; f.Name != "internal" || f.Severity != findings.High || !f.MatchName("evil.example.com") {
+		t.Errorf("the feed was not loaded as configured: %+v", f)
+	}
+
+	cfg.Options["threat_intel"] = map[string]interface{}{
+		"internal": map[string]interface{}{"path": path, "format": "stix"},
+	}
+	if _, err := FromConfig(context.Background(), cfg); err == nil {
+		t.Errorf("FromConfig accepted an unsupported format")
+	}
+
+	cfg.Options["threat_intel"] = map[string]interface{}{
+		"internal": map[string]interface{}{"severity": "low"},
+	}
+	if _, err := FromConfig(context.Background(), cfg); err == nil {
+		t.Errorf("FromConfig accepted a feed without a path or dataset")
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package throttle
+
+import (
+	"fmt"
+
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	// DefaultMinRate is the lowest number of requests per second a data source is throttled to.
+	DefaultMinRate = 0.1
+	// DefaultInitialRate is the number of requests per second applied to the data sources without a
+	// rate limit once they respond with status 429 or a server error.
+	DefaultInitialRate = 10.0
+)
+
+// Options determine how the limiters adapt the rates of the data sources.
+type Options struct {
+	Adaptive    bool
+	MinRate     float64
+	InitialRate float64
+}
+
+// DefaultOptions returns the Options used when the configuration does not provide the 'throttle' section.
+func DefaultOptions() *Options {
+	return &Options{
+		Adaptive:    true,
+		MinRate:     DefaultMinRate,
+		InitialRate: DefaultInitialRate,
+	}
+}
+
+// FromConfig returns the Options set by the 'throttle' section of the configuration options.
+func FromConfig(cfg *config.Config) (*Options, error) {
+	opts := DefaultOptions()
+
+	raw, ok := cfg.Options["throttle"]
+	if !ok {
+		return opts, nil
+	}
+
+	settings, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("throttle is not a map[string]interface{}")
+	}
+
+	if v, ok := settings["adaptive"]; ok {
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("throttle adaptive is not a bool")
+		}
+		opts.Adaptive = b
+	}
+	for key, dst := range map[string]*float64{
+		"min_rate":     &opts.MinRate,
+		"initial_rate": &opts.InitialRate,
+	} {
+		v, ok := settings[key]
+		if !ok {
+			continue
+		}
+
+		rate, err := positive(v)
+		if err != nil {
+			return nil, fmt.Errorf("throttle %s %v", key, err)
+		}
+		*dst = rate
+	}
+	if opts.MinRate > opts.InitialRate {
+		return nil, fmt.Errorf("throttle min_rate must not exceed the initial_rate")
+	}
+	return opts, nil
+}
+
+func positive(raw interface{}) (float64, error) {
+	var v float64
+
+	switch n := raw.(type) {
+	case int:
+		v = float64(n)
+	case float64:
+		v = n
+	default:
+		return 0, fmt.Errorf("is not a number")
+	}
+	if v <= 0 {
+		return 0, fmt.Errorf("must be a positive number of requests per second")
+	}
+	return v, nil
+}
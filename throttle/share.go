@@ -0,0 +1,54 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package throttle
+
+import "context"
+
+// DefaultPriority is the weight of the sessions that were not assigned a priority.
+const DefaultPriority = 1
+
+// Share identifies the session making a request, and its weight when the sessions running in the
+// process compete for the requests allowed by the rate of a data source.
+type Share struct {
+	Session  string
+	Priority int
+}
+
+type shareKey struct{}
+
+// WithSession returns a context identifying the requests made on behalf of the session. A session
+// with twice the priority of another is granted twice as many requests to a busy data source.
+func WithSession(ctx context.Context, session string, priority int) context.Context {
+	if priority < 1 {
+		priority = DefaultPriority
+	}
+	return context.WithValue(ctx, shareKey{}, &Share{Session: session, Priority: priority})
+}
+
+// SessionFrom returns the share of the session carried by the context, and false when the requests
+// are not made on behalf of a session.
+func SessionFrom(ctx context.Context) (*Share, bool) {
+	s, ok := ctx.Value(shareKey{}).(*Share)
+	return s, ok
+}
+
+// Inherit returns the context with the session carried by the parent, so the requests made using a
+// context derived elsewhere are still attributed to the session.
+func Inherit(ctx, parent context.Context) context.Context {
+	if parent == nil {
+		return ctx
+	}
+	if s, ok := SessionFrom(parent); ok {
+		return context.WithValue(ctx, shareKey{}, s)
+	}
+	return ctx
+}
+
+func shareFrom(ctx context.Context) *Share {
+	if s, ok := SessionFrom(ctx); ok {
+		return s
+	}
+	return &Share{Priority: DefaultPriority}
+}
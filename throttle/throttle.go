@@ -0,0 +1,343 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package throttle spaces the requests sent by the data sources, adapting the rate of each source to
+// its responses. The rate is halved when the source responds with status 429 or a server error, and
+// raised again while the responses are healthy, without exceeding the rate limit set by the source.
+// The limiters are shared by the enumerations running in the process, since they use the same services,
+// and the requests allowed by the rate are granted round-robin across the sessions, weighted by their priority.
+package throttle
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// backoffFactor multiplies the rate of a source responding with status 429 or a server error
+	backoffFactor = 0.5
+	// increaseFactor multiplies the rate of a source once recoverAfter healthy responses were received
+	increaseFactor = 1.25
+	recoverAfter   = 10
+	// maxRetryAfter is the longest wait requested by the Retry-After header that is honored
+	maxRetryAfter = 5 * time.Minute
+)
+
+// Rate is the request rate currently applied to a data source.
+type Rate struct {
+	Source string `json:"source"`
+	// Rate is the number of requests per second, or zero when the source is not limited
+	Rate float64 `json:"rate"`
+	// Ceiling is the rate limit set by the source, or zero when the source sets no rate limit
+	Ceiling   float64 `json:"ceiling"`
+	Throttled bool    `json:"throttled"`
+	Requests  uint64  `json:"requests"`
+	Backoffs  uint64  `json:"backoffs"`
+}
+
+// The limiters used by the data sources of the process
+var (
+	limitersLock sync.Mutex
+	limiters     = make(map[string]*Limiter)
+)
+
+// For returns the limiter shared by the data sources of the process using the name.
+func For(source string) *Limiter {
+	limitersLock.Lock()
+	defer limitersLock.Unlock()
+
+	l, found := limiters[source]
+	if !found {
+		l = &Limiter{source: source, opts: *DefaultOptions(), now: time.Now}
+		limiters[source] = l
+	}
+	return l
+}
+
+// Rates returns the rates currently applied to the data sources of the process, sorted by source.
+func Rates() []Rate {
+	limitersLock.Lock()
+	list := make([]*Limiter, 0, len(limiters))
+	for _, l := range limiters {
+		list = append(list, l)
+	}
+	limitersLock.Unlock()
+
+	rates := make([]Rate, 0, len(list))
+	for _, l := range list {
+		rates = append(rates, l.Rate())
+	}
+	sort.Slice(rates, func(i, j int) bool { return rates[i].Source < rates[j].Source })
+	return rates
+}
+
+// Limiter spaces the requests sent by a data source. All methods are safe to call on a nil Limiter,
+// which never delays the requests.
+type Limiter struct {
+	sync.Mutex
+	source   string
+	opts     Options
+	ceiling  float64
+	rate     float64
+	next     time.Time
+	healthy  int
+	requests uint64
+	backoffs uint64
+	now      func() time.Time
+	// The requests waiting for their turn, ordered by the virtual finish times of weighted fair queuing
+	waiting []*waiter
+	vtime   float64
+	finish  map[string]float64
+	changed chan struct{}
+}
+
+// waiter is a request waiting for the limiter to allow it.
+type waiter struct {
+	session string
+	tag     float64
+}
+
+// Configure sets the interval between the requests required by the data source, which is zero when the
+// source sets no rate limit. The rate returns to the new limit when the interval differs from the previous one.
+func (l *Limiter) Configure(interval time.Duration, opts *Options) {
+	if l == nil {
+		return
+	}
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	var ceiling float64
+	if interval > 0 {
+		ceiling = float64(time.Second) / float64(interval)
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	l.opts = *opts
+	if ceiling != l.ceiling || !l.opts.Adaptive {
+		l.ceiling = ceiling
+		l.rate = ceiling
+		l.healthy = 0
+	}
+	l.notify()
+}
+
+// Wait blocks until the next request of the data source can be sent, or the context expires. The
+// requests waiting at the same time are allowed in the order of weighted fair queuing, so each session
+// identified by the contexts receives a share of the rate proportional to its priority.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.Lock()
+	l.requests++
+	w := l.enqueue(shareFrom(ctx))
+	for {
+		now := l.now()
+		head := l.waiting[0] == w
+		if head && !now.Before(l.next) {
+			l.grant(w, now)
+			l.Unlock()
+			return nil
+		}
+
+		var timer *time.Timer
+		var expired <-chan time.Time
+		if head {
+			timer = time.NewTimer(l.next.Sub(now))
+			expired = timer.C
+		}
+		changed := l.changed
+		l.Unlock()
+
+		var err error
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-expired:
+		case <-changed:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+
+		l.Lock()
+		if err != nil {
+			l.remove(w)
+			l.notify()
+			l.Unlock()
+			return err
+		}
+	}
+}
+
+// enqueue adds a waiter for the session, tagged with the virtual time its turn ends. The tags of a
+// session advance by the inverse of its priority, so the sessions with a higher priority get more turns.
+// It must be called while holding the lock.
+func (l *Limiter) enqueue(share *Share) *waiter {
+	if l.finish == nil {
+		l.finish = make(map[string]float64)
+	}
+	if l.changed == nil {
+		l.changed = make(chan struct{})
+	}
+
+	start := l.vtime
+	if f := l.finish[share.Session]; f > start {
+		start = f
+	}
+	w := &waiter{session: share.Session, tag: start + 1/float64(share.Priority)}
+	l.finish[share.Session] = w.tag
+
+	// The waiters with the same tag keep the order of their arrival
+	idx := sort.Search(len(l.waiting), func(i int) bool { return l.waiting[i].tag > w.tag })
+	l.waiting = append(l.waiting, nil)
+	copy(l.waiting[idx+1:], l.waiting[idx:])
+	l.waiting[idx] = w
+	return w
+}
+
+// grant allows the request of the waiter, and spaces the next request by the current rate.
+// It must be called while holding the lock.
+func (l *Limiter) grant(w *waiter, now time.Time) {
+	l.remove(w)
+	l.vtime = w.tag
+	// The sessions without waiting requests start over from the virtual time
+	if len(l.waiting) == 0 {
+		l.finish = nil
+	}
+
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	if l.rate > 0 {
+		l.next = at.Add(time.Duration(float64(time.Second) / l.rate))
+	}
+	l.notify()
+}
+
+// remove drops the waiter from the queue, and must be called while holding the lock.
+func (l *Limiter) remove(w *waiter) {
+	for i, cur := range l.waiting {
+		if cur == w {
+			l.waiting = append(l.waiting[:i], l.waiting[i+1:]...)
+			return
+		}
+	}
+}
+
+// notify wakes the waiters, so they check whether their turn has come, and must be called while holding the lock.
+func (l *Limiter) notify() {
+	if l.changed != nil {
+		close(l.changed)
+	}
+	l.changed = make(chan struct{})
+}
+
+// Observe adapts the rate of the data source to the status code of a response it provided, and returns
+// true when the rate was lowered. The requests are also delayed as requested by the Retry-After header.
+func (l *Limiter) Observe(code int, hdr map[string]string) bool {
+	if l == nil || code <= 0 {
+		return false
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	if code != http.StatusTooManyRequests && code < http.StatusInternalServerError {
+		l.healthy++
+		if l.opts.Adaptive && l.healthy >= recoverAfter {
+			l.healthy = 0
+			l.increase()
+		}
+		return false
+	}
+
+	if wait := retryAfter(hdr, l.now()); wait > 0 {
+		if until := l.now().Add(wait); until.After(l.next) {
+			l.next = until
+			l.notify()
+		}
+	}
+	if !l.opts.Adaptive {
+		return false
+	}
+
+	l.healthy = 0
+	l.backoffs++
+	if l.rate == 0 {
+		l.rate = l.opts.InitialRate
+		if l.ceiling > 0 && l.rate > l.ceiling {
+			l.rate = l.ceiling
+		}
+	} else {
+		l.rate *= backoffFactor
+	}
+	if l.rate < l.opts.MinRate {
+		l.rate = l.opts.MinRate
+	}
+	return true
+}
+
+// increase raises the rate toward the limit of the data source. The sources without a rate limit
+// are no longer limited once their rate recovers to the initial rate.
+func (l *Limiter) increase() {
+	if l.rate == 0 {
+		return
+	}
+
+	l.rate *= increaseFactor
+	if l.ceiling > 0 && l.rate >= l.ceiling {
+		l.rate = l.ceiling
+	} else if l.ceiling == 0 && l.rate >= l.opts.InitialRate {
+		l.rate = 0
+	}
+}
+
+// Rate returns the rate currently applied to the data source.
+func (l *Limiter) Rate() Rate {
+	if l == nil {
+		return Rate{}
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	return Rate{
+		Source:    l.source,
+		Rate:      l.rate,
+		Ceiling:   l.ceiling,
+		Throttled: l.rate != l.ceiling,
+		Requests:  l.requests,
+		Backoffs:  l.backoffs,
+	}
+}
+
+// retryAfter accepts the number of seconds to wait or the HTTP date provided by the Retry-After header.
+func retryAfter(hdr map[string]string, now time.Time) time.Duration {
+	v := strings.TrimSpace(hdr["Retry-After"])
+	if v == "" {
+		return 0
+	}
+
+	var wait time.Duration
+	if n, err := strconv.Atoi(v); err == nil {
+		wait = time.Duration(n) * time.Second
+	} else if t, err := http.ParseTime(v); err == nil {
+		wait = t.Sub(now)
+	}
+	if wait > maxRetryAfter {
+		wait = maxRetryAfter
+	}
+	return wait
+}
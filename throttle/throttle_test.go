@@ -0,0 +1,192 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package throttle
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := &Limiter{source: "SecurityTrails", opts: *DefaultOptions(), now: func() time.Time { return now }}
+	l.Configure(time.Second, nil)
+
+	if r := l.Rate(); r.Rate != 1 || r.Ceiling != 1 || r.Throttled {
+		t.Fatalf("Unexpected initial rate: %+v", r)
+	}
+	if !l.Observe(429, nil) || !l.Observe(503, nil) {
+		t.Error("The rate was not lowered for the unhealthy responses")
+	}
+	if r := l.Rate(); r.Rate != 0.25 || !r.Throttled || r.Backoffs != 2 {
+		t.Errorf("Expected the rate to be halved twice: %+v", r)
+	}
+
+	for i := 0; i < 10*recoverAfter; i++ {
+		if l.Observe(200, nil) {
+			t.Fatal("The rate was lowered for a healthy response")
+		}
+	}
+	if r := l.Rate(); r.Rate != 1 || r.Throttled {
+		t.Errorf("Expected the rate to recover to the ceiling: %+v", r)
+	}
+
+	// The requests are delayed as long as requested by the Retry-After header
+	l.Observe(429, map[string]string{"Retry-After": "30"})
+	if !l.next.Equal(now.Add(30 * time.Second)) {
+		t.Errorf("The Retry-After header was not honored, the next request is at %s", l.next)
+	}
+
+	// Configuring the same interval keeps the current rate, while a new interval resets it
+	l.Configure(time.Second, nil)
+	if r := l.Rate(); r.Rate != 0.5 {
+		t.Errorf("The current rate was reset: %+v", r)
+	}
+	l.Configure(500*time.Millisecond, nil)
+	if r := l.Rate(); r.Rate != 2 || r.Ceiling != 2 {
+		t.Errorf("The rate did not return to the new limit: %+v", r)
+	}
+
+	fixed := DefaultOptions()
+	fixed.Adaptive = false
+	l.Configure(time.Second, fixed)
+	if l.Observe(429, nil) || l.Rate().Rate != 1 {
+		t.Error("The rate was adapted while disabled")
+	}
+
+	var nilLimiter *Limiter
+	if err := nilLimiter.Wait(context.Background()); err != nil || nilLimiter.Observe(429, nil) {
+		t.Error("The nil limiter delayed the requests")
+	}
+}
+
+func TestUnlimitedSource(t *testing.T) {
+	l := &Limiter{source: "crtsh", opts: *DefaultOptions(), now: time.Now}
+	l.Configure(0, nil)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		_ = l.Wait(context.Background())
+	}
+	if time.Since(start) > 100*time.Millisecond {
+		t.Error("The requests of the unlimited source were delayed")
+	}
+
+	l.Observe(429, nil)
+	if r := l.Rate(); r.Rate != DefaultInitialRate || !r.Throttled {
+		t.Fatalf("Expected the initial rate once throttled: %+v", r)
+	}
+	l.Observe(500, nil)
+	for i := 0; i < 10*recoverAfter; i++ {
+		l.Observe(200, nil)
+	}
+	if r := l.Rate(); r.Rate != 0 || r.Throttled {
+		t.Errorf("Expected the source to be unlimited once recovered: %+v", r)
+	}
+}
+
+func TestWait(t *testing.T) {
+	l := For("TestWait")
+	l.Configure(50*time.Millisecond, nil)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_ = l.Wait(context.Background())
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("The requests were not spaced, three took %s", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l.Observe(429, map[string]string{"Retry-After": "60"})
+	if err := l.Wait(ctx); err == nil {
+		t.Error("The wait did not end with the context")
+	}
+
+	var found bool
+	for _, r := range Rates() {
+		if r.Source == "TestWait" {
+			found = r.Requests == 4 && r.Backoffs == 1
+		}
+	}
+	if !found {
+		t.Errorf("The limiter was not reported by the rates of the process: %+v", Rates())
+	}
+}
+
+func TestSessionPriority(t *testing.T) {
+	l := &Limiter{source: "TestSessionPriority", opts: *DefaultOptions(), now: time.Now}
+	l.Configure(2*time.Millisecond, nil)
+	// The requests are held until all of them are waiting
+	l.next = time.Now().Add(100 * time.Millisecond)
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	for _, s := range []struct {
+		name     string
+		priority int
+	}{{"monitoring", 1}, {"interactive", 3}} {
+		ctx := WithSession(context.Background(), s.name, s.priority)
+
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(name string) {
+				defer wg.Done()
+
+				if err := l.Wait(ctx); err == nil {
+					mu.Lock()
+					order = append(order, name)
+					mu.Unlock()
+				}
+			}(s.name)
+		}
+	}
+	wg.Wait()
+
+	var interactive int
+	for _, name := range order[:20] {
+		if name == "interactive" {
+			interactive++
+		}
+	}
+	// The session with three times the priority is granted three of every four requests
+	if interactive < 14 || interactive > 16 {
+		t.Errorf("The interactive session was granted %d of the first 20 requests: %v", interactive, order)
+	}
+
+	if s, ok := SessionFrom(Inherit(context.Background(), WithSession(context.Background(), "a", 0))); !ok || s.Priority != DefaultPriority {
+		t.Errorf("The session was not inherited with the default priority: %+v", s)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	if opts, err := FromConfig(cfg); err != nil || !opts.Adaptive || opts.MinRate != DefaultMinRate {
+		t.Errorf("Expected the default options: %+v, %v", opts, err)
+	}
+
+	cfg.Options["throttle"] = map[string]interface{}{"adaptive": false, "min_rate": 0.5, "initial_rate": 2}
+	if opts, err := FromConfig(cfg); err != nil || opts.Adaptive || opts.MinRate != 0.5 || opts.InitialRate != 2 {
+		t.Errorf("Unexpected options: %+v, %v", opts, err)
+	}
+
+	for _, bad := range []map[string]interface{}{
+		{"adaptive": "yes"},
+		{"min_rate": 0},
+		{"initial_rate": "fast"},
+		{"min_rate": 5, "initial_rate": 1},
+	} {
+		cfg.Options["throttle"] = bad
+		if _, err := FromConfig(cfg); err == nil {
+			t.Errorf("The options %v were accepted", bad)
+		}
+	}
+}
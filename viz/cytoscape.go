@@ -0,0 +1,66 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package viz
+
+import (
+	"encoding/json"
+	"io"
+)
+
+type cytoscape struct {
+	Elements cytoscapeElements `json:"elements"`
+}
+
+type cytoscapeElements struct {
+	Nodes []cytoscapeElement `json:"nodes"`
+	Edges []cytoscapeElement `json:"edges"`
+}
+
+type cytoscapeElement struct {
+	Data map[string]string `json:"data"`
+}
+
+// WriteCytoscape writes the graph to w as Cytoscape.js elements JSON, which can
+// also be loaded into D3 and the Cytoscape desktop application.
+func WriteCytoscape(w io.Writer, g *Graph) error {
+	doc := &cytoscape{
+		Elements: cytoscapeElements{
+			Nodes: []cytoscapeElement{},
+			Edges: []cytoscapeElement{},
+		},
+	}
+
+	for _, n := range g.Nodes {
+		data := map[string]string{
+			"id":         n.ID,
+			"label":      n.Label,
+			"type":       n.Type,
+			"first_seen": timeString(n.FirstSeen),
+			"last_seen":  timeString(n.LastSeen),
+		}
+		if c := confidenceString(n); c != "" {
+			data["confidence"] = c
+		}
+		if t := tagsString(n); t != "" {
+			data["tags"] = t
+		}
+		doc.Elements.Nodes = append(doc.Elements.Nodes, cytoscapeElement{Data: data})
+	}
+	for _, e := range g.Edges {
+		doc.Elements.Edges = append(doc.Elements.Edges, cytoscapeElement{
+			Data: map[string]string{
+				"id":        e.ID,
+				"source":    e.From,
+				"target":    e.To,
+				"label":     e.Label,
+				"last_seen": timeString(e.LastSeen),
+			},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(doc)
+}
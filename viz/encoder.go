@@ -0,0 +1,105 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package viz
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Encoder writes the graph in one of the export formats.
+type Encoder interface {
+	// Name is the lowercase name used to select the format, such as 'graphml'
+	Name() string
+	// ContentType is the media type of the encoded graph, used by the API responses
+	ContentType() string
+	Encode(w io.Writer, g *Graph) error
+}
+
+type encoderFunc struct {
+	name        string
+	contentType string
+	encode      func(io.Writer, *Graph) error
+}
+
+// NewEncoder returns an Encoder that writes the graph using the provided function.
+func NewEncoder(name, contentType string, encode func(io.Writer, *Graph) error) Encoder {
+	return &encoderFunc{
+		name:        strings.ToLower(name),
+		contentType: contentType,
+		encode:      encode,
+	}
+}
+
+func (e *encoderFunc) Name() string                       { return e.name }
+func (e *encoderFunc) ContentType() string                { return e.contentType }
+func (e *encoderFunc) Encode(w io.Writer, g *Graph) error { return e.encode(w, g) }
+
+var (
+	encoderLock sync.RWMutex
+	encoders    = make(map[string]Encoder)
+)
+
+func init() {
+	for _, e := range []Encoder{
+		NewEncoder("text", "text/plain; charset=utf-8", WriteText),
+		NewEncoder("json", "application/json", WriteJSON),
+		NewEncoder("csv", "text/csv", WriteCSV),
+		NewEncoder("stix", "application/stix+json;version=2.1", WriteSTIX),
+		NewEncoder("graphml", "application/graphml+xml", WriteGraphML),
+		NewEncoder("gexf", "application/gexf+xml", WriteGEXF),
+		NewEncoder("cytoscape", "application/json", WriteCytoscape),
+	} {
+		_ = RegisterEncoder(e)
+	}
+}
+
+// RegisterEncoder makes the export format available to the commands and the API.
+func RegisterEncoder(e Encoder) error {
+	encoderLock.Lock()
+	defer encoderLock.Unlock()
+
+	name := strings.ToLower(e.Name())
+	if name == "" {
+		return fmt.Errorf("the encoder must provide a name")
+	}
+	if _, found := encoders[name]; found {
+		return fmt.Errorf("an encoder named %s has already been registered", name)
+	}
+
+	encoders[name] = e
+	return nil
+}
+
+// GetEncoder returns the registered Encoder for the export format name.
+func GetEncoder(name string) (Encoder, error) {
+	encoderLock.RLock()
+	defer encoderLock.RUnlock()
+
+	if e, found := encoders[strings.ToLower(strings.TrimSpace(name))]; found {
+		return e, nil
+	}
+	return nil, fmt.Errorf("%s is not a supported export format, use one of: %s", name, strings.Join(encoderNames(), ", "))
+}
+
+// Encoders returns the names of the registered export formats.
+func Encoders() []string {
+	encoderLock.RLock()
+	defer encoderLock.RUnlock()
+
+	return encoderNames()
+}
+
+func encoderNames() []string {
+	var names []string
+	for name := range encoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,122 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package viz
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+type gexf struct {
+	XMLName xml.Name  `xml:"gexf"`
+	XMLNS   string    `xml:"xmlns,attr"`
+	Version string    `xml:"version,attr"`
+	Meta    gexfMeta  `xml:"meta"`
+	Graph   gexfGraph `xml:"graph"`
+}
+
+type gexfMeta struct {
+	LastModified string `xml:"lastmodifieddate,attr"`
+	Creator      string `xml:"creator"`
+}
+
+type gexfGraph struct {
+	DefaultEdgeType string           `xml:"defaultedgetype,attr"`
+	Mode            string           `xml:"mode,attr"`
+	Attributes      []gexfAttributes `xml:"attributes"`
+	Nodes           []gexfNode       `xml:"nodes>node"`
+	Edges           []gexfEdge       `xml:"edges>edge"`
+}
+
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfNode struct {
+	ID        string         `xml:"id,attr"`
+	Label     string         `xml:"label,attr"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfEdge struct {
+	ID        string         `xml:"id,attr"`
+	Source    string         `xml:"source,attr"`
+	Target    string         `xml:"target,attr"`
+	Label     string         `xml:"label,attr"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfAttValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// WriteGEXF writes the graph to w in the GEXF format used by Gephi.
+func WriteGEXF(w io.Writer, g *Graph) error {
+	doc := &gexf{
+		XMLNS:   "http://gexf.net/1.3",
+		Version: "1.3",
+		Meta: gexfMeta{
+			LastModified: time.Now().UTC().Format("2006-01-02"),
+			Creator:      "OWASP Amass",
+		},
+		Graph: gexfGraph{
+			DefaultEdgeType: "directed",
+			Mode:            "static",
+			Attributes: []gexfAttributes{
+				{
+					Class: "node",
+					Attributes: []gexfAttribute{
+						{ID: "type", Title: "type", Type: "string"},
+						{ID: "first_seen", Title: "first_seen", Type: "string"},
+						{ID: "last_seen", Title: "last_seen", Type: "string"},
+						{ID: "confidence", Title: "confidence", Type: "string"},
+						{ID: "tags", Title: "tags", Type: "string"},
+					},
+				},
+				{
+					Class: "edge",
+					Attributes: []gexfAttribute{
+						{ID: "last_seen", Title: "last_seen", Type: "string"},
+					},
+				},
+			},
+		},
+	}
+
+	for _, n := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, gexfNode{
+			ID:    n.ID,
+			Label: n.Label,
+			AttValues: []gexfAttValue{
+				{For: "type", Value: n.Type},
+				{For: "first_seen", Value: timeString(n.FirstSeen)},
+				{For: "last_seen", Value: timeString(n.LastSeen)},
+				{For: "confidence", Value: confidenceString(n)},
+				{For: "tags", Value: tagsString(n)},
+			},
+		})
+	}
+	for _, e := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, gexfEdge{
+			ID:     e.ID,
+			Source: e.From,
+			Target: e.To,
+			Label:  e.Label,
+			AttValues: []gexfAttValue{
+				{For: "last_seen", Value: timeString(e.LastSeen)},
+			},
+		})
+	}
+	return writeXML(w, doc)
+}
@@ -0,0 +1,114 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package viz
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+type graphml struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphmlKey `xml:"key"`
+	Graph   graphmlGraph `xml:"graph"`
+}
+
+type graphmlKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphmlGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphmlNode `xml:"node"`
+	Edges       []graphmlEdge `xml:"edge"`
+}
+
+type graphmlNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphmlData `xml:"data"`
+}
+
+type graphmlEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphmlData `xml:"data"`
+}
+
+type graphmlData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML writes the graph to w in the GraphML format.
+func WriteGraphML(w io.Writer, g *Graph) error {
+	doc := &graphml{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphmlKey{
+			{ID: "label", For: "node", Name: "label", Type: "string"},
+			{ID: "type", For: "node", Name: "type", Type: "string"},
+			{ID: "first_seen", For: "node", Name: "first_seen", Type: "string"},
+			{ID: "last_seen", For: "node", Name: "last_seen", Type: "string"},
+			{ID: "confidence", For: "node", Name: "confidence", Type: "string"},
+			{ID: "tags", For: "node", Name: "tags", Type: "string"},
+			{ID: "relation", For: "edge", Name: "relation", Type: "string"},
+			{ID: "edge_last_seen", For: "edge", Name: "last_seen", Type: "string"},
+		},
+		Graph: graphmlGraph{ID: "amass", EdgeDefault: "directed"},
+	}
+
+	for _, n := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphmlNode{
+			ID: n.ID,
+			Data: []graphmlData{
+				{Key: "label", Value: n.Label},
+				{Key: "type", Value: n.Type},
+				{Key: "first_seen", Value: timeString(n.FirstSeen)},
+				{Key: "last_seen", Value: timeString(n.LastSeen)},
+				{Key: "confidence", Value: confidenceString(n)},
+				{Key: "tags", Value: tagsString(n)},
+			},
+		})
+	}
+	for _, e := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphmlEdge{
+			ID:     e.ID,
+			Source: e.From,
+			Target: e.To,
+			Data: []graphmlData{
+				{Key: "relation", Value: e.Label},
+				{Key: "edge_last_seen", Value: timeString(e.LastSeen)},
+			},
+		})
+	}
+	return writeXML(w, doc)
+}
+
+func writeXML(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func timeString(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
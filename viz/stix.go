@@ -0,0 +1,138 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package viz
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	oam "github.com/owasp-amass/open-asset-model"
+)
+
+// The namespace defined by STIX 2.1 for the deterministic identifiers of the cyber-observable objects.
+var stixNamespace = [16]byte{0x00, 0xab, 0xed, 0xb4, 0xaa, 0x42, 0x46, 0x6c, 0x9c, 0x01, 0xfe, 0xd2, 0x33, 0x15, 0xa9, 0xb7}
+
+// The relations that match the relationship types suggested by STIX for the observables.
+var stixRelationships = map[string]string{
+	"a_record":     "resolves-to",
+	"aaaa_record":  "resolves-to",
+	"cname_record": "resolves-to",
+	"contains":     "contains",
+	"announces":    "announces",
+	"managed_by":   "managed-by",
+}
+
+// WriteSTIX writes the graph to w as a STIX 2.1 bundle. The assets are represented by cyber-observable
+// objects, the organizations by identities, and the relations by relationship objects.
+func WriteSTIX(w io.Writer, g *Graph) error {
+	now := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+	objects := []map[string]interface{}{}
+
+	ids := make(map[string]string, len(g.Nodes))
+	for _, n := range g.Nodes {
+		obj := stixObject(n, now)
+		if obj == nil {
+			continue
+		}
+
+		ids[n.ID] = obj["id"].(string)
+		objects = append(objects, obj)
+	}
+	for _, e := range g.Edges {
+		from, to := ids[e.From], ids[e.To]
+		if from == "" || to == "" {
+			continue
+		}
+
+		rel, found := stixRelationships[e.Label]
+		if !found {
+			rel = strings.ReplaceAll(strings.ToLower(e.Label), "_", "-")
+		}
+		objects = append(objects, map[string]interface{}{
+			"type":              "relationship",
+			"spec_version":      "2.1",
+			"id":                "relationship--" + uuid5(stixNamespace, from+"|"+rel+"|"+to),
+			"created":           now,
+			"modified":          now,
+			"relationship_type": rel,
+			"source_ref":        from,
+			"target_ref":        to,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]interface{}{
+		"type":    "bundle",
+		"id":      "bundle--" + uuid5(stixNamespace, now),
+		"objects": objects,
+	})
+}
+
+func stixObject(n *Node, now string) map[string]interface{} {
+	var stype string
+	contrib := make(map[string]interface{})
+
+	switch oam.AssetType(n.Type) {
+	case oam.FQDN:
+		stype = "domain-name"
+		contrib["value"] = n.Label
+	case oam.IPAddress, oam.Netblock:
+		stype = "ipv4-addr"
+		if strings.Contains(n.Label, ":") {
+			stype = "ipv6-addr"
+		}
+		contrib["value"] = n.Label
+	case oam.ASN:
+		num, err := strconv.Atoi(strings.TrimPrefix(n.Label, "AS"))
+		if err != nil {
+			return nil
+		}
+		stype = "autonomous-system"
+		contrib["number"] = num
+	case oam.RIROrg:
+		return map[string]interface{}{
+			"type":           "identity",
+			"spec_version":   "2.1",
+			"id":             "identity--" + uuid5(stixNamespace, n.Label),
+			"created":        now,
+			"modified":       now,
+			"name":           n.Label,
+			"identity_class": "organization",
+		}
+	default:
+		return nil
+	}
+
+	// The identifier is derived from the contributing properties, as required for the observables
+	data, _ := json.Marshal(contrib)
+	obj := map[string]interface{}{
+		"type":         stype,
+		"spec_version": "2.1",
+		"id":           stype + "--" + uuid5(stixNamespace, string(data)),
+	}
+	for k, v := range contrib {
+		obj[k] = v
+	}
+	return obj
+}
+
+// uuid5 returns the name-based UUID of the name within the namespace, as defined by RFC 4122.
+func uuid5(namespace [16]byte, name string) string {
+	h := sha1.New()
+	h.Write(namespace[:])
+	h.Write([]byte(name))
+
+	var u [16]byte
+	copy(u[:], h.Sum(nil))
+	u[6] = (u[6] & 0x0f) | 0x50
+	u[8] = (u[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
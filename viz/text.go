@@ -0,0 +1,97 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package viz
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// WriteText writes each relation of the graph to w on a separate line, followed by the assets without relations.
+func WriteText(w io.Writer, g *Graph) error {
+	bw := bufio.NewWriter(w)
+	nodes := make(map[string]*Node, len(g.Nodes))
+	for _, n := range g.Nodes {
+		nodes[n.ID] = n
+	}
+
+	related := make(map[string]bool)
+	for _, e := range g.Edges {
+		from, to := nodes[e.From], nodes[e.To]
+		if from == nil || to == nil {
+			continue
+		}
+
+		related[from.ID] = true
+		related[to.ID] = true
+		fmt.Fprintf(bw, "%s (%s) --> %s --> %s (%s)\n", from.Label, from.Type, e.Label, to.Label, to.Type)
+	}
+	for _, n := range g.Nodes {
+		if !related[n.ID] {
+			fmt.Fprintf(bw, "%s (%s)\n", n.Label, n.Type)
+		}
+	}
+	return bw.Flush()
+}
+
+type jsonNode struct {
+	ID         string            `json:"id"`
+	Label      string            `json:"label"`
+	Type       string            `json:"type"`
+	FirstSeen  time.Time         `json:"first_seen"`
+	LastSeen   time.Time         `json:"last_seen"`
+	Confidence *float64          `json:"confidence,omitempty"`
+	Tags       map[string]string `json:"tags,omitempty"`
+}
+
+type jsonEdge struct {
+	ID       string    `json:"id"`
+	From     string    `json:"from"`
+	To       string    `json:"to"`
+	Label    string    `json:"label"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// WriteJSON writes the nodes and edges of the graph to w as a JSON document.
+func WriteJSON(w io.Writer, g *Graph) error {
+	doc := struct {
+		Nodes []jsonNode `json:"nodes"`
+		Edges []jsonEdge `json:"edges"`
+	}{
+		Nodes: []jsonNode{},
+		Edges: []jsonEdge{},
+	}
+
+	for _, n := range g.Nodes {
+		doc.Nodes = append(doc.Nodes, jsonNode(*n))
+	}
+	for _, e := range g.Edges {
+		doc.Edges = append(doc.Edges, jsonEdge(*e))
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// WriteCSV writes a row to w for each node and edge of the graph, distinguished by the kind column.
+func WriteCSV(w io.Writer, g *Graph) error {
+	cw := csv.NewWriter(w)
+
+	_ = cw.Write([]string{"kind", "id", "type", "label", "from", "to", "first_seen", "last_seen", "confidence"})
+	for _, n := range g.Nodes {
+		_ = cw.Write([]string{"node", n.ID, n.Type, n.Label, "", "", timeString(n.FirstSeen), timeString(n.LastSeen), confidenceString(n)})
+	}
+	for _, e := range g.Edges {
+		_ = cw.Write([]string{"edge", e.ID, "", e.Label, e.From, e.To, "", timeString(e.LastSeen), ""})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,355 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package viz
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/amass/v4/analysis"
+	"github.com/owasp-amass/amass/v4/annotations"
+	"github.com/owasp-amass/asset-db/types"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+// Node represents an asset in the graph exported for visualization.
+type Node struct {
+	ID        string
+	Label     string
+	Type      string
+	FirstSeen time.Time
+	LastSeen  time.Time
+	// Confidence is the score of the sources reporting the name, and nil for the other assets
+	Confidence *float64
+	// Tags are the key=value pairs attached to the asset by the analysts
+	Tags map[string]string
+}
+
+// Edge represents a relation between two of the assets in the graph exported for visualization.
+type Edge struct {
+	ID       string
+	From     string
+	To       string
+	Label    string
+	LastSeen time.Time
+}
+
+// Graph is the portion of the graph database selected for visualization.
+type Graph struct {
+	Nodes []*Node
+	Edges []*Edge
+}
+
+// Build returns the assets discovered for the domain names, along with the addresses, netblocks,
+// autonomous systems and organizations they lead to. Only the relations observed within the start
+// and end times are followed, and a zero time leaves that side of the interval open, so the graph
+// shows the domains as they were during the time range.
+func Build(ctx context.Context, g *netmap.Graph, domains []string, start, end time.Time) (*Graph, error) {
+	var fqdns []oam.Asset
+	for _, d := range domains {
+		fqdns = append(fqdns, domain.FQDN{Name: d})
+	}
+	if len(fqdns) == 0 {
+		return nil, errors.New("no domain names were provided")
+	}
+
+	assets, err := g.DB.FindByScope(fqdns, start.UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	b := &builder{
+		g:     g,
+		start: start,
+		end:   end,
+		nodes: make(map[string]*Node),
+		edges: make(map[string]*Edge),
+	}
+	for _, a := range assets {
+		if _, ok := a.Asset.(domain.FQDN); ok && (end.IsZero() || !a.CreatedAt.After(end)) {
+			b.queue = append(b.queue, a)
+		}
+	}
+
+	for len(b.queue) > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		a := b.queue[0]
+		b.queue = b.queue[1:]
+		b.expand(a)
+	}
+	return b.graph(), nil
+}
+
+type builder struct {
+	g     *netmap.Graph
+	start time.Time
+	end   time.Time
+	queue []*types.Asset
+	nodes map[string]*Node
+	edges map[string]*Edge
+}
+
+// expand follows the relations that lead away from the names toward the infrastructure hosting them.
+func (b *builder) expand(a *types.Asset) {
+	if _, found := b.nodes[a.ID]; found {
+		return
+	}
+
+	n := newNode(a)
+	if n == nil {
+		return
+	}
+	b.nodes[a.ID] = n
+
+	var rels []*types.Relation
+	var incoming bool
+	switch a.Asset.AssetType() {
+	case oam.FQDN:
+		rels, _ = b.g.DB.OutgoingRelations(a, time.Time{})
+	case oam.IPAddress:
+		rels, _ = b.g.DB.IncomingRelations(a, time.Time{}, "contains")
+		incoming = true
+	case oam.Netblock:
+		rels, _ = b.g.DB.IncomingRelations(a, time.Time{}, "announces")
+		incoming = true
+	case oam.ASN:
+		rels, _ = b.g.DB.OutgoingRelations(a, time.Time{}, "managed_by")
+	}
+
+	for _, rel := range rels {
+		id := rel.ToAsset.ID
+		if incoming {
+			id = rel.FromAsset.ID
+		}
+
+		other, err := b.g.DB.FindById(id, time.Time{})
+		if err != nil {
+			continue
+		}
+
+		from, to := a, other
+		if incoming {
+			from, to = other, a
+		}
+		if !analysis.ValidInInterval(rel, from, to, b.start, b.end) {
+			continue
+		}
+
+		b.edges[rel.ID] = &Edge{
+			ID:       rel.ID,
+			From:     from.ID,
+			To:       to.ID,
+			Label:    rel.Type,
+			LastSeen: rel.LastSeen,
+		}
+		b.queue = append(b.queue, other)
+	}
+}
+
+// graph returns the nodes and edges in a stable order, dropping edges to assets that could not be labeled.
+func (b *builder) graph() *Graph {
+	result := new(Graph)
+
+	for _, n := range b.nodes {
+		result.Nodes = append(result.Nodes, n)
+	}
+	for _, e := range b.edges {
+		if _, found := b.nodes[e.From]; !found {
+			continue
+		}
+		if _, found := b.nodes[e.To]; !found {
+			continue
+		}
+		result.Edges = append(result.Edges, e)
+	}
+
+	sort.Slice(result.Nodes, func(i, j int) bool {
+		if result.Nodes[i].Type == result.Nodes[j].Type {
+			return result.Nodes[i].Label < result.Nodes[j].Label
+		}
+		return result.Nodes[i].Type < result.Nodes[j].Type
+	})
+	sort.Slice(result.Edges, func(i, j int) bool {
+		return result.Edges[i].ID < result.Edges[j].ID
+	})
+	return result
+}
+
+// ApplyConfidence sets the confidence of the names found in the scores, and removes the names
+// suppressed by the function along with their relations. Names without a score are kept.
+func (g *Graph) ApplyConfidence(scores map[string]float64, suppressed func(float64) bool) {
+	removed := make(map[string]struct{})
+
+	var nodes []*Node
+	for _, n := range g.Nodes {
+		if oam.AssetType(n.Type) == oam.FQDN {
+			if score, found := scores[strings.ToLower(n.Label)]; found {
+				if suppressed != nil && suppressed(score) {
+					removed[n.ID] = struct{}{}
+					continue
+				}
+				n.Confidence = &score
+			}
+		}
+		nodes = append(nodes, n)
+	}
+	g.Nodes = nodes
+
+	var edges []*Edge
+	for _, e := range g.Edges {
+		_, from := removed[e.From]
+		_, to := removed[e.To]
+		if !from && !to {
+			edges = append(edges, e)
+		}
+	}
+	g.Edges = edges
+}
+
+// ApplyTags sets the tags of the assets found in the map, which is keyed by the lowercase labels.
+// When required tags are provided, only the assets carrying all of them are kept, along with the
+// relations between them.
+func (g *Graph) ApplyTags(tags map[string]map[string]string, required map[string]string) {
+	kept := make(map[string]struct{})
+
+	var nodes []*Node
+	for _, n := range g.Nodes {
+		if t, found := tags[strings.ToLower(n.Label)]; found && len(t) > 0 {
+			n.Tags = t
+		}
+		if len(required) > 0 && !annotations.Matches(n.Tags, required) {
+			continue
+		}
+		kept[n.ID] = struct{}{}
+		nodes = append(nodes, n)
+	}
+	g.Nodes = nodes
+
+	var edges []*Edge
+	for _, e := range g.Edges {
+		_, from := kept[e.From]
+		_, to := kept[e.To]
+		if from && to {
+			edges = append(edges, e)
+		}
+	}
+	g.Edges = edges
+}
+
+// tagsString returns the tags of the node as key=value pairs separated by commas, sorted by key.
+func tagsString(n *Node) string {
+	var pairs []string
+	for k, v := range n.Tags {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// LocationType is the type of the nodes added for the locations of the addresses.
+const LocationType = "Location"
+
+// AddLocations adds a node for each location in the map, keyed by the addresses located there,
+// along with the located_in relations of the address nodes. The asset model does not provide a
+// location asset, so the locations are only part of the exported graph.
+func (g *Graph) AddLocations(locs map[string]string) {
+	places := make(map[string]*Node)
+
+	for _, n := range g.Nodes {
+		if oam.AssetType(n.Type) != oam.IPAddress {
+			continue
+		}
+
+		label, found := locs[n.Label]
+		if !found || label == "" {
+			continue
+		}
+
+		loc, found := places[label]
+		if !found {
+			loc = &Node{
+				ID:        "location:" + label,
+				Label:     label,
+				Type:      LocationType,
+				FirstSeen: n.FirstSeen,
+				LastSeen:  n.LastSeen,
+			}
+			places[label] = loc
+		}
+		if n.FirstSeen.Before(loc.FirstSeen) {
+			loc.FirstSeen = n.FirstSeen
+		}
+		if n.LastSeen.After(loc.LastSeen) {
+			loc.LastSeen = n.LastSeen
+		}
+
+		g.Edges = append(g.Edges, &Edge{
+			ID:       n.ID + ":located_in",
+			From:     n.ID,
+			To:       loc.ID,
+			Label:    "located_in",
+			LastSeen: n.LastSeen,
+		})
+	}
+
+	var labels []string
+	for label := range places {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		g.Nodes = append(g.Nodes, places[label])
+	}
+}
+
+// confidenceString returns the confidence of the node with two decimals, or an empty string.
+func confidenceString(n *Node) string {
+	if n.Confidence == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*n.Confidence, 'f', 2, 64)
+}
+
+func newNode(a *types.Asset) *Node {
+	var label string
+
+	switch v := a.Asset.(type) {
+	case domain.FQDN:
+		label = v.Name
+	case network.IPAddress:
+		label = v.Address.String()
+	case network.Netblock:
+		label = v.Cidr.String()
+	case network.AutonomousSystem:
+		label = "AS" + strconv.Itoa(v.Number)
+	case network.RIROrganization:
+		label = v.Name
+		if v.RIRId != "" {
+			label = v.RIRId + " " + v.Name
+		}
+	default:
+		return nil
+	}
+
+	return &Node{
+		ID:        a.ID,
+		Label:     label,
+		Type:      string(a.Asset.AssetType()),
+		FirstSeen: a.CreatedAt,
+		LastSeen:  a.LastSeen,
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package viz
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+)
+
+func TestWriters(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	_ = g.UpsertCNAME(ctx, "www.owasp.org", "owasp.cdn.net")
+	_ = g.UpsertA(ctx, "owasp.cdn.net", "192.0.2.1")
+
+	graph, err := Build(ctx, g, []string{"owasp.org"}, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to build the graph: %v", err)
+	}
+	if len(graph.Nodes) != 4 || len(graph.Edges) != 2 {
+		t.Fatalf("Expected 4 nodes and 2 edges, got %d and %d", len(graph.Nodes), len(graph.Edges))
+	}
+
+	var buf bytes.Buffer
+	if err := WriteGraphML(&buf, graph); err != nil {
+		t.Fatalf("Failed to write the GraphML: %v", err)
+	}
+	var gml graphml
+	if err := xml.Unmarshal(buf.Bytes(), &gml); err != nil || len(gml.Graph.Nodes) != 4 || len(gml.Graph.Edges) != 2 {
+		t.Errorf("The GraphML output was not valid: %v", err)
+	}
+
+	buf.Reset()
+	if err := WriteGEXF(&buf, graph); err != nil {
+		t.Fatalf("Failed to write the GEXF: %v", err)
+	}
+	var gx gexf
+	if err := xml.Unmarshal(buf.Bytes(), &gx); err != nil || len(gx.Graph.Nodes) != 4 || len(gx.Graph.Edges) != 2 {
+		t.Errorf("The GEXF output was not valid: %v", err)
+	}
+
+	buf.Reset()
+	if err := WriteCytoscape(&buf, graph); err != nil {
+		t.Fatalf("Failed to write the Cytoscape JSON: %v", err)
+	}
+	var cy cytoscape
+	if err := json.Unmarshal(buf.Bytes(), &cy); err != nil || len(cy.Elements.Nodes) != 4 || len(cy.Elements.Edges) != 2 {
+		t.Errorf("The Cytoscape output was not valid: %v", err)
+	}
+	if !strings.Contains(buf.String(), "cname_record") {
+		t.Error("The Cytoscape output is missing the relation label")
+	}
+}
+
+func TestBuildInterval(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	_ = g.UpsertCNAME(ctx, "www.owasp.org", "owasp.cdn.net")
+	_ = g.UpsertA(ctx, "owasp.cdn.net", "192.0.2.1")
+
+	now := time.Now()
+	graph, err := Build(ctx, g, []string{"owasp.org"}, now.Add(-time.Hour), now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to build the graph: %v", err)
+	}
+	if len(graph.Nodes) != 4 || len(graph.Edges) != 2 {
+		t.Errorf("Expected 4 nodes and 2 edges within the interval, got %d and %d", len(graph.Nodes), len(graph.Edges))
+	}
+
+	// The assets were discovered after the end of the interval
+	graph, err = Build(ctx, g, []string{"owasp.org"}, time.Time{}, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to build the graph: %v", err)
+	}
+	if len(graph.Nodes) != 0 || len(graph.Edges) != 0 {
+		t.Errorf("Expected an empty graph before the assets were discovered, got %d nodes and %d edges", len(graph.Nodes), len(graph.Edges))
+	}
+}
+
+func TestApplyConfidence(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	_ = g.UpsertCNAME(ctx, "www.owasp.org", "owasp.cdn.net")
+	_ = g.UpsertA(ctx, "owasp.cdn.net", "192.0.2.1")
+
+	graph, err := Build(ctx, g, []string{"owasp.org"}, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to build the graph: %v", err)
+	}
+
+	graph.ApplyConfidence(map[string]float64{"owasp.cdn.net": 0.8, "www.owasp.org": 0.1}, func(s float64) bool {
+		return s < 0.5
+	})
+	if len(graph.Nodes) != 3 || len(graph.Edges) != 1 {
+		t.Fatalf("Expected 3 nodes and 1 edge after the suppression, got %d and %d", len(graph.Nodes), len(graph.Edges))
+	}
+	for _, n := range graph.Nodes {
+		if n.Label == "owasp.cdn.net" && confidenceString(n) != "0.80" {
+			t.Errorf("Expected the confidence 0.80, got %q", confidenceString(n))
+		}
+	}
+}
+
+func TestApplyTags(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	_ = g.UpsertA(ctx, "www.owasp.org", "192.0.2.1")
+	_ = g.UpsertA(ctx, "mail.owasp.org", "192.0.2.2")
+
+	graph, err := Build(ctx, g, []string{"owasp.org"}, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to build the graph: %v", err)
+	}
+
+	tags := map[string]map[string]string{
+		"www.owasp.org": {"status": "confirmed", "owner": "web"},
+		"192.0.2.1":     {"status": "Confirmed"},
+		"192.0.2.2":     {"status": "ignored"},
+	}
+	graph.ApplyTags(tags, map[string]string{"status": "confirmed"})
+	if len(graph.Nodes) != 2 || len(graph.Edges) != 1 {
+		t.Fatalf("Expected 2 nodes and 1 edge carrying the tag, got %d and %d", len(graph.Nodes), len(graph.Edges))
+	}
+	for _, n := range graph.Nodes {
+		if n.Label == "www.owasp.org" && tagsString(n) != "owner=web,status=confirmed" {
+			t.Errorf("Unexpected tags %q", tagsString(n))
+		}
+	}
+}
+
+func TestAddLocations(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	_ = g.UpsertA(ctx, "www.owasp.org", "192.0.2.1")
+	_ = g.UpsertA(ctx, "mail.owasp.org", "192.0.2.2")
+
+	graph, err := Build(ctx, g, []string{"owasp.org"}, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to build the graph: %v", err)
+	}
+	nodes, edges := len(graph.Nodes), len(graph.Edges)
+
+	graph.AddLocations(map[string]string{"192.0.2.1": "Berlin, Germany", "192.0.2.2": "Berlin, Germany"})
+	if len(graph.Nodes) != nodes+1 || len(graph.Edges) != edges+2 {
+		t.Fatalf("Expected one location node and two relations, got %d nodes and %d edges", len(graph.Nodes)-nodes, len(graph.Edges)-edges)
+	}
+	if n := graph.Nodes[len(graph.Nodes)-1]; n.Type != LocationType || n.Label != "Berlin, Germany" {
+		t.Errorf("The location node was not added correctly: %v", n)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteGraphML(&buf, graph); err != nil || !strings.Contains(buf.String(), "located_in") {
+		t.Errorf("The GraphML output is missing the location relations: %v", err)
+	}
+}
+
+func TestEncoders(t *testing.T) {
+	ctx := context.Background()
+	g := netmap.NewGraph("memory", "", "")
+	defer g.Remove()
+
+	_ = g.UpsertA(ctx, "www.owasp.org", "192.0.2.1")
+	_ = g.UpsertInfrastructure(ctx, 64496, "EXAMPLE-NET", "192.0.2.1", "192.0.2.0/24")
+
+	graph, err := Build(ctx, g, []string{"owasp.org"}, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to build the graph: %v", err)
+	}
+
+	for _, name := range []string{"text", "json", "csv", "stix", "graphml", "gexf", "cytoscape"} {
+		e, err := GetEncoder(name)
+		if err != nil {
+			t.Fatalf("The %s encoder was not registered: %v", name, err)
+		}
+
+		var buf bytes.Buffer
+		if err := e.Encode(&buf, graph); err != nil || buf.Len() == 0 {
+			t.Errorf("The %s encoder failed: %v", name, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	_ = WriteText(&buf, graph)
+	if !strings.Contains(buf.String(), "www.owasp.org (FQDN) --> a_record --> 192.0.2.1 (IPAddress)") {
+		t.Errorf("Unexpected text output: %s", buf.String())
+	}
+
+	buf.Reset()
+	_ = WriteSTIX(&buf, graph)
+	var bundle struct {
+		Type    string                   `json:"type"`
+		Objects []map[string]interface{} `json:"objects"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &bundle); err != nil || bundle.Type != "bundle" {
+		t.Fatalf("The STIX output was not a valid bundle: %v", err)
+	}
+	var rels int
+	for _, obj := range bundle.Objects {
+		if obj["type"] == "relationship" {
+			rels++
+		}
+	}
+	if rels != len(graph.Edges) {
+		t.Errorf("Expected %d relationships in the bundle, got %d", len(graph.Edges), rels)
+	}
+
+	if _, err := GetEncoder("unknown"); err == nil {
+		t.Error("GetEncoder returned an unknown format")
+	}
+	if err := RegisterEncoder(NewEncoder("JSON", "application/json", WriteJSON)); err == nil {
+		t.Error("RegisterEncoder accepted a duplicate name")
+	}
+}
+
+func TestUUID5(t *testing.T) {
+	// The DNS namespace defined by RFC 4122
+	ns := [16]byte{0x6b, 0xa7, 0xb8, 0x10, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+
+	if id := uuid5(ns, "python.org"); id != "886313e1-3b8a-5372-9b90-0c9aee199e5d" {
+		t.Errorf("Unexpected UUID: %s", id)
+	}
+}
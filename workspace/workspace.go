@@ -0,0 +1,252 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package workspace isolates the output directory of each project, so the configuration, graph database,
+// datasets, caches and logs of an engagement are kept apart from the other engagements. The projects are
+// stored below the default output directory, and the commands use the directory of the active project
+// whenever a directory is not provided.
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+const (
+	// Env selects the project used by a single command, in place of the active project.
+	Env = "AMASS_PROJECT"
+	// DirName is the directory below the default output directory holding the projects.
+	DirName        = "projects"
+	archiveDirName = "archive"
+	activeFileName = "active"
+	configFileName = "config.yaml"
+)
+
+var nameRE = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+// ErrNotFound is returned for the projects that have not been created.
+var ErrNotFound = errors.New("the project does not exist")
+
+// Project is a workspace holding the output directory of an engagement.
+type Project struct {
+	Name     string    `json:"name"`
+	Dir      string    `json:"dir"`
+	Active   bool      `json:"active"`
+	Archived bool      `json:"archived"`
+	Modified time.Time `json:"modified"`
+}
+
+// Root returns the directory holding the projects.
+func Root() string {
+	dir := config.OutputDirectory()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, DirName)
+}
+
+// CheckName returns an error when the name cannot be used as the directory of a project.
+func CheckName(name string) error {
+	if !nameRE.MatchString(name) || name == archiveDirName {
+		return fmt.Errorf("%q is not a valid project name: use up to 64 lowercase letters, digits, dots, dashes and underscores", name)
+	}
+	return nil
+}
+
+// Dir returns the output directory of the project, whether or not it has been created.
+func Dir(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if err := CheckName(name); err != nil {
+		return "", err
+	}
+
+	root := Root()
+	if root == "" {
+		return "", errors.New("failed to obtain the output directory")
+	}
+	return filepath.Join(root, name), nil
+}
+
+// Create makes the output directory of the project, and returns it. The configuration file of the
+// default output directory is copied into new projects, and existing projects are left unchanged.
+func Create(name string) (string, error) {
+	dir, err := Dir(name)
+	if err != nil {
+		return "", err
+	}
+	if Exists(name) {
+		return dir, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	if data, err := os.ReadFile(filepath.Join(config.OutputDirectory(), configFileName)); err == nil {
+		if err := os.WriteFile(filepath.Join(dir, configFileName), data, 0644); err != nil {
+			return dir, err
+		}
+	}
+	return dir, nil
+}
+
+// Exists returns true when the output directory of the project has been created.
+func Exists(name string) bool {
+	dir, err := Dir(name)
+	if err != nil {
+		return false
+	}
+
+	finfo, err := os.Stat(dir)
+	return err == nil && finfo.IsDir()
+}
+
+// Active returns the name of the project selected by the AMASS_PROJECT environment variable,
+// or by the last switch. An empty string is returned when no project has been selected.
+func Active() string {
+	if name, set := os.LookupEnv(Env); set && strings.TrimSpace(name) != "" {
+		return strings.ToLower(strings.TrimSpace(name))
+	}
+
+	root := Root()
+	if root == "" {
+		return ""
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, activeFileName))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// Switch makes the project active for the following commands. The default output directory
+// is used again once the empty name is provided.
+func Switch(name string) error {
+	root := Root()
+	if root == "" {
+		return errors.New("failed to obtain the output directory")
+	}
+
+	path := filepath.Join(root, activeFileName)
+	if name == "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	name = strings.ToLower(strings.TrimSpace(name))
+	if err := CheckName(name); err != nil {
+		return err
+	}
+	if !Exists(name) {
+		return fmt.Errorf("%s: %w", name, ErrNotFound)
+	}
+	return os.WriteFile(path, []byte(name+"\n"), 0644)
+}
+
+// OutputDirectory returns the provided directory, or the directory of the active project when
+// the directory is empty. The empty string is returned when neither one is available, so the
+// default output directory is used.
+func OutputDirectory(dir string) string {
+	if dir != "" {
+		return dir
+	}
+	if name := Active(); name != "" {
+		if d, err := Dir(name); err == nil {
+			return d
+		}
+	}
+	return ""
+}
+
+// List returns the projects, followed by the archived projects, sorted by name.
+func List() ([]*Project, error) {
+	root := Root()
+	if root == "" {
+		return nil, errors.New("failed to obtain the output directory")
+	}
+
+	projects, err := readProjects(root, false)
+	if err != nil {
+		return nil, err
+	}
+
+	archived, err := readProjects(filepath.Join(root, archiveDirName), true)
+	if err != nil {
+		return nil, err
+	}
+	return append(projects, archived...), nil
+}
+
+func readProjects(dir string, archived bool) ([]*Project, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	active := Active()
+	var projects []*Project
+	for _, entry := range entries {
+		if !entry.IsDir() || (!archived && entry.Name() == archiveDirName) {
+			continue
+		}
+
+		p := &Project{
+			Name:     entry.Name(),
+			Dir:      filepath.Join(dir, entry.Name()),
+			Archived: archived,
+		}
+		if !archived && p.Name == active {
+			p.Active = true
+		}
+		if finfo, err := entry.Info(); err == nil {
+			p.Modified = finfo.ModTime()
+		}
+		projects = append(projects, p)
+	}
+
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Name < projects[j].Name })
+	return projects, nil
+}
+
+// Archive moves the output directory of the project into the archive, so it no longer appears
+// as an available project, and returns the new location. The active project is deselected.
+func Archive(name string) (string, error) {
+	dir, err := Dir(name)
+	if err != nil {
+		return "", err
+	}
+	if !Exists(name) {
+		return "", fmt.Errorf("%s: %w", name, ErrNotFound)
+	}
+
+	archive := filepath.Join(Root(), archiveDirName)
+	if err := os.MkdirAll(archive, 0755); err != nil {
+		return "", err
+	}
+	// The archived projects keep the date, so a project name can be reused and archived again
+	dest := filepath.Join(archive, filepath.Base(dir)+"-"+time.Now().UTC().Format("20060102T150405"))
+	if err := os.Rename(dir, dest); err != nil {
+		return "", err
+	}
+
+	if data, err := os.ReadFile(filepath.Join(Root(), activeFileName)); err == nil &&
+		strings.TrimSpace(string(data)) == filepath.Base(dir) {
+		if err := Switch(""); err != nil {
+			return dest, err
+		}
+	}
+	return dest, nil
+}
@@ -0,0 +1,138 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package workspace
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// setupRoot directs the default output directory into a temporary directory.
+func setupRoot(t *testing.T) string {
+	dir := t.TempDir()
+
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("APPDATA", dir)
+	t.Setenv(Env, "")
+	if err := os.MkdirAll(config.OutputDirectory(), 0755); err != nil {
+		t.Fatalf("Failed to create the output directory: %v", err)
+	}
+	return config.OutputDirectory()
+}
+
+func TestCheckName(t *testing.T) {
+	for _, name := range []string{"acme", "acme-2023", "red_team.q1"} {
+		if err := CheckName(name); err != nil {
+			t.Errorf("CheckName(%q) returned an error: %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "Acme", "../acme", "a/b", ".hidden", archiveDirName} {
+		if err := CheckName(name); err == nil {
+			t.Errorf("CheckName(%q) did not return an error", name)
+		}
+	}
+}
+
+func TestCreateAndSwitch(t *testing.T) {
+	base := setupRoot(t)
+	if err := os.WriteFile(filepath.Join(base, configFileName), []byte("options: {}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write the configuration file: %v", err)
+	}
+
+	if got := OutputDirectory(""); got != "" {
+		t.Errorf("OutputDirectory returned %q without an active project", got)
+	}
+
+	dir, err := Create("Acme")
+	if err != nil {
+		t.Fatalf("Create returned an error: %v", err)
+	}
+	if dir != filepath.Join(base, DirName, "acme") {
+		t.Errorf("Create returned the directory %s", dir)
+	}
+	if _, err := os.Stat(filepath.Join(dir, configFileName)); err != nil {
+		t.Errorf("The configuration file was not copied into the project: %v", err)
+	}
+
+	if err := Switch("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Switch to a missing project returned %v", err)
+	}
+	if err := Switch("acme"); err != nil {
+		t.Fatalf("Switch returned an error: %v", err)
+	}
+	if got := OutputDirectory(""); got != dir {
+		t.Errorf("OutputDirectory returned %q, expected the active project %q", got, dir)
+	}
+	if got := OutputDirectory("/tmp/out"); got != "/tmp/out" {
+		t.Errorf("OutputDirectory did not return the provided directory: %q", got)
+	}
+
+	if _, err := Create("beta"); err != nil {
+		t.Fatalf("Create returned an error: %v", err)
+	}
+	t.Setenv(Env, "beta")
+	if got := Active(); got != "beta" {
+		t.Errorf("Active returned %q, expected the project of the environment variable", got)
+	}
+	t.Setenv(Env, "")
+
+	if err := Switch(""); err != nil {
+		t.Fatalf("Switch returned an error: %v", err)
+	}
+	if got := Active(); got != "" {
+		t.Errorf("Active returned %q after the project was deselected", got)
+	}
+}
+
+func TestListAndArchive(t *testing.T) {
+	setupRoot(t)
+
+	for _, name := range []string{"beta", "acme"} {
+		if _, err := Create(name); err != nil {
+			t.Fatalf("Create returned an error: %v", err)
+		}
+	}
+	if err := Switch("acme"); err != nil {
+		t.Fatalf("Switch returned an error: %v", err)
+	}
+
+	projects, err := List()
+	if err != nil {
+		t.Fatalf("List returned an error: %v", err)
+	}
+	if len(projects) != 2 || projects[0].Name != "acme" || !projects[0].Active || projects[1].Active {
+		t.Errorf("List returned unexpected projects: %+v", projects)
+	}
+
+	dest, err := Archive("acme")
+	if err != nil {
+		t.Fatalf("Archive returned an error: %v", err)
+	}
+	if Exists("acme") {
+		t.Error("The archived project still exists")
+	}
+	if _, err := os.Stat(dest); err != nil {
+		t.Errorf("The archived project was not moved to %s: %v", dest, err)
+	}
+	if got := Active(); got != "" {
+		t.Errorf("Active returned %q after the active project was archived", got)
+	}
+	if _, err := Archive("acme"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Archive of a missing project returned %v", err)
+	}
+
+	projects, err = List()
+	if err != nil {
+		t.Fatalf("List returned an error: %v", err)
+	}
+	if len(projects) != 2 || projects[0].Name != "beta" || !projects[1].Archived {
+		t.Errorf("List returned unexpected projects after archiving: %+v", projects)
+	}
+}